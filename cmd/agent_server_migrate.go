@@ -21,7 +21,7 @@ var migrateStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Display status of each migration",
 	Run: func(cmd *cobra.Command, args []string) {
-		migrator, err := migrations.NewMigrator()
+		migrator, err := newMigrator(cmd)
 		if err != nil {
 			fmt.Println("Unable to initialize migrator", err)
 			os.Exit(1)
@@ -38,7 +38,7 @@ var migrateCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new empty migration file",
 	Run: func(cmd *cobra.Command, args []string) {
-		migrator, err := migrations.NewMigrator()
+		migrator, err := newMigrator(cmd)
 		if err != nil {
 			fmt.Println("Unable to initialize migrator", err)
 			os.Exit(1)
@@ -65,7 +65,7 @@ var migrateUpCmd = &cobra.Command{
 	Short: "Run up migrations",
 	Long:  "Run all 'up' migrations by default.\nIf version is provided, it will run 'up' migrations to reach the version.\nIf step is provided, it will run `N` 'up' migrations.",
 	Run: func(cmd *cobra.Command, args []string) {
-		migrator, err := migrations.NewMigrator()
+		migrator, err := newMigrator(cmd)
 		if err != nil {
 			fmt.Println("Unable to initialize migrator", err)
 			os.Exit(1)
@@ -92,7 +92,7 @@ var migrateDownCmd = &cobra.Command{
 	Short: "Run down migrations",
 	Long:  "Run all 'down' migrations by default.\nIf version is provided, it will run 'down' migrations to reach the version.\nIf step is provided, it will run `N` 'down' migrations.",
 	Run: func(cmd *cobra.Command, args []string) {
-		migrator, err := migrations.NewMigrator()
+		migrator, err := newMigrator(cmd)
 		if err != nil {
 			fmt.Println("Unable to fetch migrator", err)
 			os.Exit(1)
@@ -114,8 +114,19 @@ var migrateDownCmd = &cobra.Command{
 	},
 }
 
+// newMigrator returns the migrator of the primary database, or of the region given with --region
+func newMigrator(cmd *cobra.Command) (*migrations.Migrator, error) {
+	region, err := cmd.Flags().GetString("region")
+	if err != nil || region == "" {
+		return migrations.NewMigrator()
+	}
+	return migrations.NewRegionMigrator(region)
+}
+
 // Register the "migrate" command
 func init() {
+	migrateCmd.PersistentFlags().String("region", "", "Region in DB_REGIONS whose database is migrated instead of the primary one")
+
 	migrateCreateCmd.Flags().StringP("name", "n", "", "Name for the migration")
 	migrateCmd.AddCommand(migrateCreateCmd)
 
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/internal/encryption"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/internal/services/provider"
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+)
+
+var encryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage the keys of the encryption at rest",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(cmd.Help())
+	},
+}
+
+var encryptionRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotate the data keys of all projects",
+	Long:  "Replace the data key of every project with a new one and wrap all data keys with the current master key.\nWith --reencrypt, the stored data is re-encrypted with the new data keys, including data written before encryption was enabled.",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conf, conn, keyring := encryptionKeyring()
+
+		rotated, err := keyring.RotateDataKeys(ctx)
+		if err != nil {
+			fmt.Println("Unable to rotate data keys", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Rotated %d data keys\n", rotated)
+
+		rewrapped, err := keyring.RewrapDataKeys(ctx)
+		if err != nil {
+			fmt.Println("Unable to rewrap data keys", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Rewrapped %d data keys\n", rewrapped)
+
+		reencrypt, err := cmd.Flags().GetBool("reencrypt")
+		if err != nil {
+			fmt.Println("Unable to read flag `reencrypt`", err)
+			os.Exit(1)
+		}
+		if !reencrypt {
+			os.Exit(0)
+		}
+
+		batchSize, err := cmd.Flags().GetInt("batch-size")
+		if err != nil {
+			fmt.Println("Unable to read flag `batch-size`", err)
+			os.Exit(1)
+		}
+
+		reencryptConversations(ctx, conversation.NewConversationRepo(conn, keyring), batchSize)
+
+		// The conversations of projects pinned to a region are stored in the database of the region
+		for region := range conf.GetRegionDatabases() {
+			regionConn, err := db.NewRegionConn(conf, region)
+			if err != nil {
+				fmt.Println("Unable to connect to the database of region", region, err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Region %s:\n", region)
+			reencryptConversations(ctx, conversation.NewRegionalConversationRepo(regionConn, keyring, region), batchSize)
+		}
+
+		apiKeys, err := provider.NewProviderRepo(conn, keyring).ReencryptAPIKeys(ctx)
+		fmt.Printf("Re-encrypted %d provider keys\n", apiKeys)
+		if err != nil {
+			fmt.Println("Unable to re-encrypt provider keys", err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	},
+}
+
+var encryptionRewrapCmd = &cobra.Command{
+	Use:   "rewrap",
+	Short: "Wrap all data keys with the current master key",
+	Long:  "Wrap the data keys that were wrapped by a previous master key with the current one.\nOnce it is done, the previous master key can be removed from ENCRYPTION_MASTER_KEYS.",
+	Run: func(cmd *cobra.Command, args []string) {
+		_, _, keyring := encryptionKeyring()
+
+		rewrapped, err := keyring.RewrapDataKeys(context.Background())
+		if err != nil {
+			fmt.Println("Unable to rewrap data keys", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Rewrapped %d data keys\n", rewrapped)
+
+		os.Exit(0)
+	},
+}
+
+func reencryptConversations(ctx context.Context, conversations *conversation.ConversationRepo, batchSize int) {
+	messages, err := conversations.ReencryptMessages(ctx, batchSize)
+	fmt.Printf("Re-encrypted %d messages\n", messages)
+	if err != nil {
+		fmt.Println("Unable to re-encrypt messages", err)
+		os.Exit(1)
+	}
+
+	summaries, err := conversations.ReencryptSummaries(ctx, batchSize)
+	fmt.Printf("Re-encrypted %d summaries\n", summaries)
+	if err != nil {
+		fmt.Println("Unable to re-encrypt summaries", err)
+		os.Exit(1)
+	}
+}
+
+func encryptionKeyring() (*config.Config, *sqlx.DB, *encryption.Keyring) {
+	conf := config.ReadConfig()
+	conn := db.NewConn(conf)
+
+	keyring, err := encryption.NewKeyringFromConfig(conf, conn)
+	if err != nil {
+		fmt.Println("Invalid encryption configuration", err)
+		os.Exit(1)
+	}
+	if !keyring.Enabled() {
+		fmt.Println("No master key is configured, set ENCRYPTION_MASTER_KEYS or ENCRYPTION_VAULT_ADDR")
+		os.Exit(1)
+	}
+
+	return conf, conn, keyring
+}
+
+// Register the "encryption" command
+func init() {
+	encryptionRotateCmd.Flags().Bool("reencrypt", false, "Re-encrypt the stored data with the new data keys")
+	encryptionRotateCmd.Flags().Int("batch-size", 500, "Number of rows to read at once while re-encrypting")
+	encryptionCmd.AddCommand(encryptionRotateCmd)
+
+	encryptionCmd.AddCommand(encryptionRewrapCmd)
+
+	rootCmd.AddCommand(encryptionCmd)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/loadgen"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+var loadgenCmd = &cobra.Command{
+	Use:   "loadgen",
+	Short: "Load test the converse endpoint",
+	Long:  "Drive concurrent synthetic conversations against the converse endpoint of an agent server and report the time to first token, the chunk throughput and the latency of the writes to the conversation history.\nRun `loadgen mock` and point an OpenAI provider of the project at it to test without a real provider.",
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		endpoint, _ := flags.GetString("endpoint")
+		projectIDStr, _ := flags.GetString("project-id")
+		agent, _ := flags.GetString("agent")
+		token, _ := flags.GetString("token")
+		conversations, _ := flags.GetInt("conversations")
+		concurrency, _ := flags.GetInt("concurrency")
+		turns, _ := flags.GetInt("turns")
+		message, _ := flags.GetString("message")
+		namespace, _ := flags.GetString("namespace")
+		historyTimeout, _ := flags.GetDuration("history-timeout")
+		out, _ := flags.GetString("out")
+
+		projectID, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			fmt.Println("Invalid flag `project-id`", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		report, err := loadgen.Run(ctx, loadgen.Options{
+			Endpoint:       endpoint,
+			ProjectID:      projectID,
+			Agent:          agent,
+			Token:          token,
+			Conversations:  conversations,
+			Concurrency:    concurrency,
+			Turns:          turns,
+			Message:        message,
+			Namespace:      namespace,
+			HistoryTimeout: historyTimeout,
+		}, func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\r%d/%d turns", done, total)
+		})
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			fmt.Println("Unable to run load test", err)
+			os.Exit(1)
+		}
+
+		report.Print(os.Stdout)
+
+		if out != "" {
+			buf, err := json.ConfigDefault.MarshalIndent(report, "", "  ")
+			if err == nil {
+				err = os.WriteFile(out, buf, 0o644)
+			}
+			if err != nil {
+				fmt.Println("Unable to write report", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+var loadgenMockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Serve a mock OpenAI provider for load tests",
+	Long:  "Serve the OpenAI Responses API with synthetic streamed responses.\nSet the base URL of an OpenAI provider of the project to http://<addr>/v1 to send the requests of the agents to the mock.",
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		addr, _ := flags.GetString("addr")
+		ttft, _ := flags.GetDuration("ttft")
+		chunkDelay, _ := flags.GetDuration("chunk-delay")
+		chunks, _ := flags.GetInt("chunks")
+
+		fmt.Printf("Mock provider listening on %s\n", addr)
+		if err := http.ListenAndServe(addr, &loadgen.MockProvider{TTFT: ttft, ChunkDelay: chunkDelay, Chunks: chunks}); err != nil {
+			fmt.Println("Unable to serve mock provider", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Register the "loadgen" command
+func init() {
+	loadgenCmd.Flags().String("endpoint", "http://localhost:6060", "Base URL of the agent server")
+	loadgenCmd.Flags().String("project-id", "", "Project of the agent")
+	loadgenCmd.Flags().String("agent", "", "Agent to converse with, as accepted by the agent_id parameter of the converse endpoint")
+	loadgenCmd.Flags().String("token", "", "Access token, when authentication is enabled")
+	loadgenCmd.Flags().Int("conversations", 100, "Number of conversations")
+	loadgenCmd.Flags().Int("concurrency", 10, "Number of conversations running at once")
+	loadgenCmd.Flags().Int("turns", 3, "Number of user messages of every conversation")
+	loadgenCmd.Flags().String("message", "Hello! Tell me something about load testing.", "Text of the user messages")
+	loadgenCmd.Flags().String("namespace", "loadgen", "Namespace of the conversations")
+	loadgenCmd.Flags().Duration("history-timeout", 5*time.Second, "How long to wait for the messages to be readable from the history, 0 to skip measuring the writes")
+	loadgenCmd.Flags().String("out", "", "Write the report as JSON to this file")
+	_ = loadgenCmd.MarkFlagRequired("project-id")
+	_ = loadgenCmd.MarkFlagRequired("agent")
+
+	loadgenMockCmd.Flags().String("addr", ":9090", "Address to listen on")
+	loadgenMockCmd.Flags().Duration("ttft", 300*time.Millisecond, "Delay before the first chunk of a response")
+	loadgenMockCmd.Flags().Duration("chunk-delay", 20*time.Millisecond, "Delay between two chunks of a response")
+	loadgenMockCmd.Flags().Int("chunks", 50, "Number of chunks of a response")
+	loadgenCmd.AddCommand(loadgenMockCmd)
+
+	rootCmd.AddCommand(loadgenCmd)
+}
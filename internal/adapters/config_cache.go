@@ -0,0 +1,293 @@
+package adapters
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/pubsub"
+	"github.com/redis/go-redis/v9"
+)
+
+// ConfigCacheOptions configures a ConfigCache
+type ConfigCacheOptions struct {
+	// Name prefixes the redis keys and identifies the cache in logs and stats
+	Name string
+
+	// Capacity is the maximum number of entries kept in process. Defaults to 1024.
+	Capacity int
+
+	// TTL bounds how long an entry is kept in redis. Defaults to 5 minutes.
+	TTL time.Duration
+
+	// InvalidateOn lists the pubsub change types that invalidate the whole cache
+	InvalidateOn []pubsub.ConfigChangeType
+}
+
+// ConfigCacheStats reports how lookups were served
+type ConfigCacheStats struct {
+	Name     string  `json:"name"`
+	L1Hits   int64   `json:"l1_hits"`
+	L2Hits   int64   `json:"l2_hits"`
+	Misses   int64   `json:"misses"`
+	HitRate  float64 `json:"hit_rate"`
+	L1Size   int     `json:"l1_size"`
+	Capacity int     `json:"capacity"`
+}
+
+// ConfigCache is a two-level read-through cache for configuration loaded from the database.
+// The first level is an in-process LRU, the second level is redis, shared across replicas.
+// Both levels are invalidated when a config change is received over pubsub: every replica clears
+// its LRU, and redis entries are dropped by bumping the generation that is part of their keys.
+// Loads still in flight when an invalidation runs don't store their result in the LRU.
+type ConfigCache struct {
+	opts  ConfigCacheOptions
+	redis *redis.Client
+
+	mu             sync.Mutex
+	order          *list.List
+	items          map[string]*list.Element
+	generation     uint64
+	keyGenerations map[string]uint64
+
+	l1Hits atomic.Int64
+	l2Hits atomic.Int64
+	misses atomic.Int64
+}
+
+type configCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// configCacheGeneration identifies the invalidations a lookup has seen
+type configCacheGeneration struct {
+	all uint64
+	key uint64
+}
+
+// NewConfigCache creates a config cache. redisClient may be nil, in which case only the in-process level is used.
+func NewConfigCache(redisClient *redis.Client, opts ConfigCacheOptions) *ConfigCache {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1024
+	}
+
+	if opts.TTL <= 0 {
+		opts.TTL = 5 * time.Minute
+	}
+
+	return &ConfigCache{
+		opts:           opts,
+		redis:          redisClient,
+		order:          list.New(),
+		items:          make(map[string]*list.Element),
+		keyGenerations: make(map[string]uint64),
+	}
+}
+
+// SubscribeToPubSub invalidates the cache on the configured change types.
+func (c *ConfigCache) SubscribeToPubSub(ps *pubsub.PubSub) {
+	ps.Subscribe(func(event pubsub.ConfigChangeEvent) {
+		for _, changeType := range c.opts.InvalidateOn {
+			if event.ChangeType == changeType {
+				c.Invalidate(context.Background())
+				return
+			}
+		}
+	})
+}
+
+// Invalidate drops all cached entries
+func (c *ConfigCache) Invalidate(ctx context.Context) {
+	c.mu.Lock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.generation++
+	c.mu.Unlock()
+
+	if c.redis != nil {
+		if err := c.redis.Incr(ctx, c.generationKey()).Err(); err != nil {
+			slog.WarnContext(ctx, "Failed to invalidate config cache", slog.String("cache", c.opts.Name), slog.Any("error", err))
+		}
+	}
+
+	slog.Debug("Invalidated config cache", slog.String("cache", c.opts.Name))
+}
+
+// InvalidateKey drops the cached entry of key
+func (c *ConfigCache) InvalidateKey(ctx context.Context, key string) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+	c.keyGenerations[key]++
+	c.mu.Unlock()
+
+	if c.redis != nil {
+		if err := c.redis.Incr(ctx, c.keyGenerationKey(key)).Err(); err != nil {
+			slog.WarnContext(ctx, "Failed to invalidate config cache", slog.String("cache", c.opts.Name), slog.String("key", key), slog.Any("error", err))
+		}
+	}
+
+	slog.Debug("Invalidated config cache entry", slog.String("cache", c.opts.Name), slog.String("key", key))
+}
+
+// Stats returns the hit counters of the cache
+func (c *ConfigCache) Stats() ConfigCacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	stats := ConfigCacheStats{
+		Name:     c.opts.Name,
+		L1Hits:   c.l1Hits.Load(),
+		L2Hits:   c.l2Hits.Load(),
+		Misses:   c.misses.Load(),
+		L1Size:   size,
+		Capacity: c.opts.Capacity,
+	}
+
+	if total := stats.L1Hits + stats.L2Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.L1Hits+stats.L2Hits) / float64(total)
+	}
+
+	return stats
+}
+
+// GetOrLoad returns the cached value of key, calling load and caching its result on a miss.
+// Values are cached as JSON, so every caller receives its own copy.
+func GetOrLoad[T any](ctx context.Context, c *ConfigCache, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var out T
+
+	// Taken before reading either level, so that a value read or loaded before an invalidation isn't stored
+	generation := c.currentGeneration(key)
+
+	if buf, ok := c.getL1(key); ok {
+		if err := sonic.Unmarshal(buf, &out); err == nil {
+			c.l1Hits.Add(1)
+			return out, nil
+		}
+	}
+
+	redisKey, ok := c.redisKey(ctx, key)
+	if ok {
+		buf, err := c.redis.Get(ctx, redisKey).Bytes()
+		if err == nil {
+			if err := sonic.Unmarshal(buf, &out); err == nil {
+				c.l2Hits.Add(1)
+				c.setL1(key, buf, generation)
+				return out, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			slog.WarnContext(ctx, "Failed to read config cache", slog.String("cache", c.opts.Name), slog.Any("error", err))
+		}
+	}
+
+	c.misses.Add(1)
+	out, err := load(ctx)
+	if err != nil {
+		return out, err
+	}
+
+	buf, err := sonic.Marshal(out)
+	if err != nil {
+		return out, nil
+	}
+
+	if !c.setL1(key, buf, generation) {
+		return out, nil
+	}
+
+	if ok {
+		if err := c.redis.Set(ctx, redisKey, buf, c.opts.TTL).Err(); err != nil {
+			slog.WarnContext(ctx, "Failed to write config cache", slog.String("cache", c.opts.Name), slog.Any("error", err))
+		}
+	}
+
+	return out, nil
+}
+
+func (c *ConfigCache) getL1(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return el.Value.(*configCacheEntry).value, true
+}
+
+func (c *ConfigCache) currentGeneration(key string) configCacheGeneration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return configCacheGeneration{all: c.generation, key: c.keyGenerations[key]}
+}
+
+// setL1 stores the value of key unless the cache or the key were invalidated since generation was taken
+func (c *ConfigCache) setL1(key string, value []byte, generation configCacheGeneration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation.all != c.generation || generation.key != c.keyGenerations[key] {
+		return false
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*configCacheEntry).value = value
+		c.order.MoveToFront(el)
+		return true
+	}
+
+	c.items[key] = c.order.PushFront(&configCacheEntry{key: key, value: value})
+
+	for c.order.Len() > c.opts.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*configCacheEntry).key)
+	}
+
+	return true
+}
+
+func (c *ConfigCache) generationKey() string {
+	return fmt.Sprintf("uno:config_cache:%s:generation", c.opts.Name)
+}
+
+func (c *ConfigCache) keyGenerationKey(key string) string {
+	return fmt.Sprintf("uno:config_cache:%s:generation:%s", c.opts.Name, key)
+}
+
+// redisKey returns the key of the entry in the current generations of the cache and of the entry.
+// It returns false when the second level is disabled or unavailable.
+func (c *ConfigCache) redisKey(ctx context.Context, key string) (string, bool) {
+	if c.redis == nil {
+		return "", false
+	}
+
+	values, err := c.redis.MGet(ctx, c.generationKey(), c.keyGenerationKey(key)).Result()
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read config cache generation", slog.String("cache", c.opts.Name), slog.Any("error", err))
+		return "", false
+	}
+
+	generations := make([]string, len(values))
+	for i, value := range values {
+		generations[i] = "0"
+		if s, ok := value.(string); ok {
+			generations[i] = s
+		}
+	}
+
+	return fmt.Sprintf("uno:config_cache:%s:%s:%s:%s", c.opts.Name, generations[0], generations[1], key), true
+}
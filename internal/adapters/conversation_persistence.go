@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"context"
+	"errors"
 
 	"github.com/curaious/uno/internal/services/conversation"
 	"github.com/curaious/uno/pkg/llm/responses"
@@ -16,12 +17,17 @@ var (
 
 type InternalConversationPersistence struct {
 	svc       *conversation.ConversationService
+	spool     *conversation.HistorySpool
 	projectID uuid.UUID
 }
 
-func NewInternalConversationPersistence(svc *conversation.ConversationService, projectID uuid.UUID) *InternalConversationPersistence {
+// NewInternalConversationPersistence creates the persistence of a project.
+// When a spool is given, writes that fail are queued in it for retry instead of failing the run, and so are the
+// writes of the namespaces with writes in it, to keep their history in order.
+func NewInternalConversationPersistence(svc *conversation.ConversationService, projectID uuid.UUID, spool *conversation.HistorySpool) *InternalConversationPersistence {
 	return &InternalConversationPersistence{
 		svc:       svc,
+		spool:     spool,
 		projectID: projectID,
 	}
 }
@@ -78,7 +84,7 @@ func (p *InternalConversationPersistence) SaveMessages(ctx context.Context, name
 		attribute.Int("messages_count", len(messages)),
 	)
 
-	err := p.svc.AddMessages(ctx, &conversation.AddMessageRequest{
+	req := &conversation.AddMessageRequest{
 		ProjectID:         p.projectID,
 		Namespace:         namespace,
 		MessageID:         msgId,
@@ -86,10 +92,24 @@ func (p *InternalConversationPersistence) SaveMessages(ctx context.Context, name
 		Messages:          messages,
 		Meta:              meta,
 		ConversationID:    conversationId,
-	})
+	}
+
+	if p.spool != nil && p.spool.HasPending(p.projectID, namespace) {
+		span.SetAttributes(attribute.Bool("spooled", true))
+		return p.spool.EnqueueMessages(req, conversation.ErrNamespacePending)
+	}
+
+	err := p.svc.AddMessages(ctx, req)
 	if err != nil {
 		span.RecordError(err)
-		return err
+		if p.spool == nil {
+			return err
+		}
+
+		if spoolErr := p.spool.EnqueueMessages(req, err); spoolErr != nil {
+			return errors.Join(err, spoolErr)
+		}
+		span.SetAttributes(attribute.Bool("spooled", true))
 	}
 
 	return nil
@@ -100,10 +120,22 @@ func (p *InternalConversationPersistence) SaveSummary(ctx context.Context, names
 	ctx, span := tracer.Start(ctx, "InternalConversationPersistence.SaveSummary")
 	defer span.End()
 
+	if p.spool != nil && p.spool.HasPending(p.projectID, namespace) {
+		span.SetAttributes(attribute.Bool("spooled", true))
+		return p.spool.EnqueueSummary(p.projectID, namespace, summary, conversation.ErrNamespacePending)
+	}
+
 	err := p.svc.CreateSummary(ctx, p.projectID, namespace, summary)
 	if err != nil {
 		span.RecordError(err)
-		return err
+		if p.spool == nil {
+			return err
+		}
+
+		if spoolErr := p.spool.EnqueueSummary(p.projectID, namespace, summary, err); spoolErr != nil {
+			return errors.Join(err, spoolErr)
+		}
+		span.SetAttributes(attribute.Bool("spooled", true))
 	}
 
 	return nil
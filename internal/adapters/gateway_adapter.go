@@ -15,18 +15,64 @@ import (
 // This is used within the agent-server where we have direct access to services.
 // It handles virtual key resolution and provider configuration from the database.
 type InternalLLMGateway struct {
-	gateway *gateway.LLMGateway
-	key     string // Virtual key or direct API key
+	gateway    *gateway.LLMGateway
+	key        string // Virtual key or direct API key
+	dataRegion func(ctx context.Context) (string, error)
+}
+
+type InternalLLMGatewayOption func(*InternalLLMGateway)
+
+// WithDataRegion restricts the requests to the providers approved for the data region returned by resolve.
+// No restriction applies when it returns an empty region.
+func WithDataRegion(resolve func(ctx context.Context) (string, error)) InternalLLMGatewayOption {
+	return func(p *InternalLLMGateway) {
+		p.dataRegion = resolve
+	}
 }
 
 // NewInternalLLMGateway creates a provider using the internal gateway.
 // The key can be a virtual key (sk-uno-xxx) which will be resolved to actual API keys,
 // or a direct API key for the provider.
-func NewInternalLLMGateway(gw *gateway.LLMGateway, key string) *InternalLLMGateway {
-	return &InternalLLMGateway{
+func NewInternalLLMGateway(gw *gateway.LLMGateway, key string, opts ...InternalLLMGatewayOption) *InternalLLMGateway {
+	p := &InternalLLMGateway{
 		gateway: gw,
 		key:     key,
 	}
+
+	for _, o := range opts {
+		o(p)
+	}
+
+	return p
+}
+
+func (p *InternalLLMGateway) handleRequest(ctx context.Context, providerName llm.ProviderName, req *llm.Request) (*llm.Response, error) {
+	ctx, err := p.withDataRegion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.gateway.HandleRequest(ctx, providerName, p.key, req)
+}
+
+func (p *InternalLLMGateway) handleStreamingRequest(ctx context.Context, providerName llm.ProviderName, req *llm.Request) (*llm.StreamingResponse, error) {
+	ctx, err := p.withDataRegion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.gateway.HandleStreamingRequest(ctx, providerName, p.key, req)
+}
+
+func (p *InternalLLMGateway) withDataRegion(ctx context.Context) (context.Context, error) {
+	if p.dataRegion == nil {
+		return ctx, nil
+	}
+
+	region, err := p.dataRegion(ctx)
+	if err != nil || region == "" {
+		return ctx, err
+	}
+
+	return gateway.ContextWithDataRegion(ctx, region), nil
 }
 
 func (p *InternalLLMGateway) NewResponses(ctx context.Context, providerName llm.ProviderName, req *responses.Request) (*responses.Response, error) {
@@ -34,7 +80,7 @@ func (p *InternalLLMGateway) NewResponses(ctx context.Context, providerName llm.
 		OfResponsesInput: req,
 	}
 
-	resp, err := p.gateway.HandleRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +93,7 @@ func (p *InternalLLMGateway) NewStreamingResponses(ctx context.Context, provider
 		OfResponsesInput: req,
 	}
 
-	streamResp, err := p.gateway.HandleStreamingRequest(ctx, providerName, p.key, llmReq)
+	streamResp, err := p.handleStreamingRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +106,7 @@ func (p *InternalLLMGateway) NewEmbedding(ctx context.Context, providerName llm.
 		OfEmbeddingsInput: req,
 	}
 
-	resp, err := p.gateway.HandleRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +119,7 @@ func (p *InternalLLMGateway) NewChatCompletion(ctx context.Context, providerName
 		OfChatCompletionInput: req,
 	}
 
-	resp, err := p.gateway.HandleRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +132,7 @@ func (p *InternalLLMGateway) NewStreamingChatCompletion(ctx context.Context, pro
 		OfChatCompletionInput: req,
 	}
 
-	resp, err := p.gateway.HandleStreamingRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleStreamingRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +145,7 @@ func (p *InternalLLMGateway) NewSpeech(ctx context.Context, providerName llm.Pro
 		OfSpeech: req,
 	}
 
-	resp, err := p.gateway.HandleRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +158,7 @@ func (p *InternalLLMGateway) NewStreamingSpeech(ctx context.Context, providerNam
 		OfSpeech: req,
 	}
 
-	resp, err := p.gateway.HandleStreamingRequest(ctx, providerName, p.key, llmReq)
+	resp, err := p.handleStreamingRequest(ctx, providerName, llmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -20,23 +20,36 @@ import (
 // ServiceConfigStore implements gateway.ConfigStore using provider and virtual key services.
 // This is used server-side where we have access to the database services.
 // It maintains an in-memory cache that is automatically updated via PostgreSQL LISTEN/NOTIFY.
+// The configs are loaded through a ConfigCache when one is given, so that replicas share them.
 type ServiceConfigStore struct {
 	providerConfigs map[llm.ProviderName]*gateway.ProviderConfig
 	virtualKeys     map[string]*gateway.VirtualKeyConfig
 
 	providerService   *provider.ProviderService
 	virtualKeyService *virtual_key.VirtualKeyService
+	cache             *ConfigCache
 
 	mu sync.RWMutex
+
+	// reloadMu serializes the reloads, so that a reload started before a change can't overwrite a later one
+	reloadMu sync.Mutex
 }
 
-// NewServiceConfigStore creates a config store backed by database services.
-func NewServiceConfigStore(providerSvc *provider.ProviderService, virtualKeySvc *virtual_key.VirtualKeyService) *ServiceConfigStore {
+// Keys of the gateway configs in the config cache
+const (
+	providerConfigsCacheKey = "provider_configs"
+	apiKeysCacheKey         = "api_keys"
+	virtualKeysCacheKey     = "virtual_keys"
+)
+
+// NewServiceConfigStore creates a config store backed by database services. cache may be nil.
+func NewServiceConfigStore(providerSvc *provider.ProviderService, virtualKeySvc *virtual_key.VirtualKeyService, cache *ConfigCache) *ServiceConfigStore {
 	store := &ServiceConfigStore{
 		providerConfigs:   make(map[llm.ProviderName]*gateway.ProviderConfig),
 		virtualKeys:       make(map[string]*gateway.VirtualKeyConfig),
 		providerService:   providerSvc,
 		virtualKeyService: virtualKeySvc,
+		cache:             cache,
 	}
 
 	// Initial load of all configurations
@@ -57,20 +70,41 @@ func (s *ServiceConfigStore) SubscribeToPubSub(ps *pubsub.PubSub) {
 
 		switch event.ChangeType {
 		case pubsub.ChangeTypeProviderConfig:
+			s.invalidate(providerConfigsCacheKey)
 			s.reloadProviderConfigs()
 		case pubsub.ChangeTypeAPIKey:
+			s.invalidate(apiKeysCacheKey)
 			s.reloadAPIKeys()
 		case pubsub.ChangeTypeVirtualKey, pubsub.ChangeTypeVirtualKeyProvider, pubsub.ChangeTypeVirtualKeyModel:
+			s.invalidate(virtualKeysCacheKey)
 			s.reloadVirtualKeys()
 		}
 	})
 }
 
+// invalidate drops the cached config of key, so that the next reload reads the database
+func (s *ServiceConfigStore) invalidate(key string) {
+	if s.cache != nil {
+		s.cache.InvalidateKey(context.Background(), key)
+	}
+}
+
+// loadConfig loads a config through the cache when the store has one
+func loadConfig[T any](ctx context.Context, s *ServiceConfigStore, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	if s.cache == nil {
+		return load(ctx)
+	}
+	return GetOrLoad(ctx, s.cache, key, load)
+}
+
 // reloadProviderConfigs reloads all provider configurations from the database
 func (s *ServiceConfigStore) reloadProviderConfigs() {
 	ctx := context.Background()
 
-	providerConfigs, err := s.providerService.ListProviderConfigs(ctx)
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	providerConfigs, err := loadConfig(ctx, s, providerConfigsCacheKey, s.providerService.ListProviderConfigs)
 	if err != nil {
 		slog.Error("Failed to reload provider configs", slog.Any("error", err))
 		return
@@ -99,6 +133,45 @@ func (s *ServiceConfigStore) reloadProviderConfigs() {
 		} else {
 			existing.CustomHeaders = nil
 		}
+
+		existing.Regions = nil
+		for _, region := range providerConfig.Regions {
+			existing.Regions = append(existing.Regions, gateway.RegionConfig{
+				Name:    region.Name,
+				BaseURL: region.BaseURL,
+			})
+		}
+
+		if providerConfig.PinnedRegion != nil {
+			existing.PinnedRegion = *providerConfig.PinnedRegion
+		} else {
+			existing.PinnedRegion = ""
+		}
+
+		existing.DataRegions = providerConfig.DataRegions
+		existing.ToolShimModels = providerConfig.ToolShimModels
+
+		existing.Capabilities = nil
+		if providerConfig.Capabilities != nil {
+			existing.Capabilities = &gateway.ProviderCapabilities{
+				Tools:     providerConfig.Capabilities.Tools,
+				Reasoning: providerConfig.Capabilities.Reasoning,
+				Images:    providerConfig.Capabilities.Images,
+			}
+		}
+
+		if providerConfig.APIVersion != nil {
+			existing.APIVersion = *providerConfig.APIVersion
+		} else {
+			existing.APIVersion = ""
+		}
+		existing.Deployments = providerConfig.Deployments
+
+		if providerConfig.GCPProject != nil {
+			existing.GCPProject = *providerConfig.GCPProject
+		} else {
+			existing.GCPProject = ""
+		}
 	}
 
 	slog.Debug("Reloaded provider configs", slog.Int("count", len(providerConfigs)))
@@ -108,7 +181,12 @@ func (s *ServiceConfigStore) reloadProviderConfigs() {
 func (s *ServiceConfigStore) reloadAPIKeys() {
 	ctx := context.Background()
 
-	providerKeys, err := s.providerService.List(ctx, nil, false)
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	providerKeys, err := loadConfig(ctx, s, apiKeysCacheKey, func(ctx context.Context) ([]*provider.APIKey, error) {
+		return s.providerService.List(ctx, nil, false)
+	})
 	if err != nil {
 		slog.Error("Failed to reload API keys", slog.Any("error", err))
 		return
@@ -155,7 +233,10 @@ func (s *ServiceConfigStore) reloadAPIKeys() {
 func (s *ServiceConfigStore) reloadVirtualKeys() {
 	ctx := context.Background()
 
-	virtualKeys, err := s.virtualKeyService.List(ctx)
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	virtualKeys, err := loadConfig(ctx, s, virtualKeysCacheKey, s.virtualKeyService.List)
 	if err != nil {
 		slog.Error("Failed to reload virtual keys", slog.Any("error", err))
 		return
@@ -168,12 +249,17 @@ func (s *ServiceConfigStore) reloadVirtualKeys() {
 	s.virtualKeys = make(map[string]*gateway.VirtualKeyConfig)
 	for _, virtualKey := range virtualKeys {
 		vk := &gateway.VirtualKeyConfig{
+			ID:               virtualKey.ID.String(),
 			SecretKey:        virtualKey.SecretKey,
 			AllowedProviders: virtualKey.Providers,
 			AllowedModels:    virtualKey.ModelNames,
 			RateLimits:       []gateway.RateLimit{},
 		}
 
+		if virtualKey.SigningSecret != nil {
+			vk.SigningSecret = *virtualKey.SigningSecret
+		}
+
 		if virtualKey.RateLimits != nil {
 			for _, rateLimit := range virtualKey.RateLimits {
 				vk.RateLimits = append(vk.RateLimits, gateway.RateLimit{
@@ -225,7 +311,7 @@ func (s *ServiceConfigStore) GetVirtualKey(secretKey string) (*gateway.VirtualKe
 
 	vk, ok := s.virtualKeys[secretKey]
 	if !ok {
-		return nil, fmt.Errorf("secret key not exist")
+		return nil, gateway.ErrVirtualKeyNotFound
 	}
 
 	return vk, nil
@@ -8,6 +8,7 @@ import (
 	"github.com/curaious/uno/pkg/agent-framework/summariser"
 	"github.com/curaious/uno/pkg/gateway"
 	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/textsplitter"
 	adapters2 "github.com/curaious/uno/pkg/sdk/adapters"
 	"github.com/google/uuid"
 )
@@ -22,19 +23,26 @@ func BuildConversationManager(svc *services.Services, projectID uuid.UUID, llmGa
 		switch config.Summarizer.Type {
 		case "llm":
 			summarizerInstruction := BuildPrompt(svc.Prompt, projectID, config.Summarizer.LLMSummarizerPrompt, nil)
-			summarizerLLM := BuildLLMClient(llmGateway, key, llm.ProviderName(config.Summarizer.LLMSummarizerModel.ProviderType), config.Summarizer.LLMSummarizerModel.ModelID)
+			summarizerLLM := BuildLLMClient(llmGateway, key, llm.ProviderName(config.Summarizer.LLMSummarizerModel.ProviderType), config.Summarizer.LLMSummarizerModel.ModelID, DataRegionOf(svc.Regions, projectID))
 			summarizerModelParams, err := BuildModelParams(config.Summarizer.LLMSummarizerModel)
 			if err != nil {
 				return nil, err
 			}
 
-			summarizer := summariser.NewLLMHistorySummarizer(&summariser.LLMHistorySummarizerOptions{
+			summarizerOptions := &summariser.LLMHistorySummarizerOptions{
 				LLM:             summarizerLLM,
 				Instruction:     summarizerInstruction,
 				TokenThreshold:  *config.Summarizer.LLMTokenThreshold,
 				KeepRecentCount: *config.Summarizer.LLMKeepRecentCount,
 				Parameters:      summarizerModelParams,
-			})
+				ShadowMode:      config.Summarizer.LLMShadowMode,
+				Tokenizer:       textsplitter.TokenizerFor(llm.ProviderName(config.Summarizer.LLMSummarizerModel.ProviderType)),
+			}
+			if config.Summarizer.LLMMaxInputTokens != nil {
+				summarizerOptions.MaxInputTokens = *config.Summarizer.LLMMaxInputTokens
+			}
+
+			summarizer := summariser.NewLLMHistorySummarizer(summarizerOptions)
 			options = append(options, history.WithSummarizer(summarizer))
 		case "sliding_window":
 			summarizer := summariser.NewSlidingWindowHistorySummarizer(&summariser.SlidingWindowHistorySummarizerOptions{
@@ -44,8 +52,12 @@ func BuildConversationManager(svc *services.Services, projectID uuid.UUID, llmGa
 		}
 	}
 
+	if config.DeduplicateToolOutputs {
+		options = append(options, history.WithToolOutputDeduplication())
+	}
+
 	return history.NewConversationManager(
-		adapters.NewInternalConversationPersistence(svc.Conversation, projectID),
+		adapters.NewInternalConversationPersistence(svc.Conversation, projectID, svc.HistorySpool),
 		options...,
 	), nil
 }
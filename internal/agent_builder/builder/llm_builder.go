@@ -1,15 +1,26 @@
 package builder
 
 import (
+	"context"
+
 	"github.com/curaious/uno/internal/adapters"
+	"github.com/curaious/uno/internal/db"
 	"github.com/curaious/uno/pkg/gateway"
 	"github.com/curaious/uno/pkg/llm"
+	"github.com/google/uuid"
 )
 
-func BuildLLMClient(llmGateway *gateway.LLMGateway, virtualKey string, providerName llm.ProviderName, modelID string) llm.Provider {
+func BuildLLMClient(llmGateway *gateway.LLMGateway, virtualKey string, providerName llm.ProviderName, modelID string, opts ...adapters.InternalLLMGatewayOption) llm.Provider {
 	return gateway.NewLLMClient(
-		adapters.NewInternalLLMGateway(llmGateway, virtualKey),
+		adapters.NewInternalLLMGateway(llmGateway, virtualKey, opts...),
 		providerName,
 		modelID,
 	)
 }
+
+// DataRegionOf restricts the LLM calls to the providers approved for the region the project is pinned to
+func DataRegionOf(regions *db.RegionRouter, projectID uuid.UUID) adapters.InternalLLMGatewayOption {
+	return adapters.WithDataRegion(func(ctx context.Context) (string, error) {
+		return regions.Region(ctx, projectID)
+	})
+}
@@ -2,13 +2,14 @@ package builder
 
 import (
 	"context"
+	"time"
 
 	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/curaious/uno/pkg/agent-framework/mcpclient"
 )
 
-func BuildMCPClient(config *agent_config.MCPServerConfig) (*mcpclient.MCPClient, error) {
-	options := []mcpclient.McpServerOption{}
+func BuildMCPClient(ctx context.Context, config *agent_config.MCPServerConfig) (*mcpclient.MCPClient, error) {
+	options := []mcpclient.McpServerOption{mcpclient.WithName(config.Name)}
 	if config.Headers != nil && len(config.Headers) > 0 {
 		options = append(options, mcpclient.WithHeaders(config.Headers))
 	}
@@ -21,7 +22,27 @@ func BuildMCPClient(config *agent_config.MCPServerConfig) (*mcpclient.MCPClient,
 		options = append(options, mcpclient.WithApprovalRequiredTools(config.ToolsRequiringHumanApproval...))
 	}
 
-	mcpServer, err := mcpclient.NewSSEClient(context.Background(), config.Endpoint, options...)
+	if config.ToolPrefix != "" {
+		options = append(options, mcpclient.WithToolPrefix(config.ToolPrefix))
+	}
+
+	if len(config.ToolRenames) > 0 {
+		options = append(options, mcpclient.WithToolRenames(config.ToolRenames))
+	}
+
+	if config.TimeoutSeconds > 0 {
+		options = append(options, mcpclient.WithTimeout(time.Duration(config.TimeoutSeconds)*time.Second))
+	}
+
+	if config.MaxRetries > 0 {
+		options = append(options, mcpclient.WithMaxRetries(config.MaxRetries))
+	}
+
+	if config.MaxResultBytes > 0 {
+		options = append(options, mcpclient.WithMaxResultBytes(config.MaxResultBytes))
+	}
+
+	mcpServer, err := mcpclient.NewSSEClient(ctx, config.Endpoint, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,46 @@
+package builder
+
+import (
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/policies"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/google/uuid"
+)
+
+// BuildResponsePolicy builds the response policy of an agent, nil when the policy is not enabled. The judge is only
+// built with a model, the length needs none.
+func BuildResponsePolicy(svc *services.Services, projectID uuid.UUID, llmGateway *gateway.LLMGateway, config *agent_config.ResponsePolicyConfig, key string) (*agents.ResponsePolicyOptions, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+
+	opts := &agents.ResponsePolicyOptions{
+		Policy: core.ResponsePolicy{
+			Language:  config.Language,
+			Tone:      config.Tone,
+			MaxLength: config.MaxLength,
+		},
+	}
+	if config.MaxRevisions != nil {
+		opts.MaxRevisions = *config.MaxRevisions
+	}
+
+	if config.Model != nil {
+		judgeLLM := BuildLLMClient(llmGateway, key, llm.ProviderName(config.Model.ProviderType), config.Model.ModelID, DataRegionOf(svc.Regions, projectID))
+		judgeModelParams, err := BuildModelParams(config.Model)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Judge = policies.NewLLMResponseJudge(&policies.LLMResponseJudgeOptions{
+			LLM:        judgeLLM,
+			Parameters: judgeModelParams,
+		})
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/tools"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// maxSubAgentDepth caps the chain of agents delegating to each other
+const maxSubAgentDepth = 5
+
+// SubAgentTool calls another agent of the project with the Agent-as-Tool wrapper. The agent is loaded and built
+// when the tool is called, so that its config is current and agents delegating to each other don't recurse at
+// build time. Sub-agents run without history, each call is a new conversation.
+type SubAgentTool struct {
+	*core.BaseTool
+	builder   *AgentBuilder
+	projectID uuid.UUID
+	config    agent_config.SubAgentConfig
+	key       string
+	parents   []string
+}
+
+func (b *AgentBuilder) NewSubAgentTool(projectID uuid.UUID, config agent_config.SubAgentConfig, key string, parents []string) *SubAgentTool {
+	return &SubAgentTool{
+		BaseTool: &core.BaseTool{
+			ToolUnion: *SubAgentToolDefinition(config),
+		},
+		builder:   b,
+		projectID: projectID,
+		config:    config,
+		key:       key,
+		parents:   slices.Clone(parents),
+	}
+}
+
+// SubAgentToolDefinition returns the function tool the model calls to delegate to the sub-agent
+func SubAgentToolDefinition(config agent_config.SubAgentConfig) *responses.ToolUnion {
+	return &responses.ToolUnion{
+		OfFunction: &responses.FunctionTool{
+			Name:        agent_config.SubAgentToolName(config),
+			Description: utils.Ptr(config.Description),
+			Parameters: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"input": map[string]any{
+						"type":        "string",
+						"description": "The request for the agent, with all the context it needs",
+					},
+				},
+				"required": []string{"input"},
+			},
+		},
+	}
+}
+
+func (t *SubAgentTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
+	if slices.Contains(t.parents, t.config.AgentName) {
+		return nil, fmt.Errorf("sub-agent %s delegates back to itself through %v", t.config.AgentName, t.parents)
+	}
+	if len(t.parents) >= maxSubAgentDepth {
+		return nil, fmt.Errorf("sub-agent %s exceeds the maximum delegation depth (%d)", t.config.AgentName, maxSubAgentDepth)
+	}
+
+	agentConfig, err := t.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := t.builder.buildAgent(ctx, agentConfig, t.key, false, t.parents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sub-agent %s: %w", t.config.AgentName, err)
+	}
+
+	return tools.NewAgentTool(&t.ToolUnion, agent).Execute(ctx, params)
+}
+
+// load returns the config of the sub-agent, version 0 unless a version is set, with the defaults of the project
+func (t *SubAgentTool) load(ctx context.Context) (*agent_config.AgentConfig, error) {
+	var agentConfig *agent_config.AgentConfig
+	var err error
+	if t.config.Version != nil {
+		agentConfig, err = t.builder.svc.AgentConfig.GetByNameAndVersion(ctx, t.projectID, t.config.AgentName, *t.config.Version)
+	} else {
+		agentConfig, err = t.builder.svc.AgentConfig.GetLatestByName(ctx, t.projectID, t.config.AgentName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sub-agent %s: %w", t.config.AgentName, err)
+	}
+
+	// The sub-agents fall back to the defaults of the project, like the agents run directly
+	project, err := t.builder.svc.Project.GetByID(ctx, t.projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sub-agent %s: %w", t.config.AgentName, err)
+	}
+
+	return project.ApplyAgentDefaults(agentConfig)
+}
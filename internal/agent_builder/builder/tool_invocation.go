@@ -0,0 +1,19 @@
+package builder
+
+import (
+	"context"
+
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+)
+
+// InvokeTool calls a tool of the agent of the config outside of a run, with the guardrails, approval policy and
+// quotas of the agent applied
+func (b *AgentBuilder) InvokeTool(ctx context.Context, agentConfig *agent_config.AgentConfig, key string, in *agents.ToolInvocation) (*agents.ToolInvocationResult, error) {
+	agent, err := b.buildAgent(ctx, agentConfig, key, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent.InvokeTool(ctx, in)
+}
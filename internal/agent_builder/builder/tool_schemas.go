@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// toolSchemasMCPTimeout bounds listing the tools of a single MCP server
+const toolSchemasMCPTimeout = 30 * time.Second
+
+// Sources of the tools of an agent
+const (
+	ToolSourceNative   = "native"
+	ToolSourceSubAgent = "sub_agent"
+	ToolSourceMCP      = "mcp"
+)
+
+// ToolSchema is a tool an agent exposes to its model, with the JSON schema of its arguments
+type ToolSchema struct {
+	Name             string         `json:"name"`
+	Type             string         `json:"type"`             // "function", or the type of a tool executed by the provider, e.g. "web_search"
+	Source           string         `json:"source"`           // "native", "sub_agent" or "mcp"
+	Server           string         `json:"server,omitempty"` // Name of the MCP server of the tool
+	Description      string         `json:"description,omitempty"`
+	Parameters       map[string]any `json:"parameters,omitempty"` // JSON schema of the arguments of a function tool
+	RequiresApproval bool           `json:"requires_approval"`
+}
+
+// ToolSchemaError is an MCP server whose tools couldn't be listed
+type ToolSchemaError struct {
+	Server string `json:"server"`
+	Error  string `json:"error"`
+}
+
+// ToolSchemas are the tools an agent exposes. The tools of the MCP servers that can't be reached are missing, and
+// their servers are listed in Errors.
+type ToolSchemas struct {
+	Tools  []*ToolSchema     `json:"tools"`
+	Errors []ToolSchemaError `json:"errors,omitempty"`
+}
+
+// ListToolSchemas returns the tools the agent of the config exposes to its model: the native tools, the sub-agents
+// and the tools of the MCP servers, under the names the model calls them.
+func (b *AgentBuilder) ListToolSchemas(ctx context.Context, agentConfig *agent_config.AgentConfig) *ToolSchemas {
+	out := &ToolSchemas{Tools: []*ToolSchema{}}
+
+	for _, tool := range BuildToolsList(agentConfig.Config.Tools, b.sandboxManager) {
+		out.Tools = append(out.Tools, newToolSchema(tool.Tool(ctx), tool.NeedApproval(), ToolSourceNative, ""))
+	}
+
+	for _, subAgent := range agentConfig.Config.SubAgents {
+		out.Tools = append(out.Tools, newToolSchema(SubAgentToolDefinition(subAgent), false, ToolSourceSubAgent, ""))
+	}
+
+	for _, server := range agentConfig.Config.MCPServers {
+		tools, err := listMCPTools(ctx, &server)
+		if err != nil {
+			out.Errors = append(out.Errors, ToolSchemaError{Server: server.Name, Error: err.Error()})
+			continue
+		}
+
+		for _, tool := range tools {
+			out.Tools = append(out.Tools, newToolSchema(tool.Tool(ctx), tool.NeedApproval(), ToolSourceMCP, server.Name))
+		}
+	}
+
+	return out
+}
+
+// listMCPTools lists the tools of the server as the agent exposes them, with its filter, prefix and renames applied
+func listMCPTools(ctx context.Context, server *agent_config.MCPServerConfig) ([]core.Tool, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolSchemasMCPTimeout)
+	defer cancel()
+
+	srv, err := BuildMCPClient(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers can only be resolved from the environment here, there is no run
+	cli, err := srv.GetClient(ctx, map[string]any{"Env": utils.EnvironmentVariables()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools of MCP server '%s': %w", server.Name, err)
+	}
+	defer cli.Client.Close()
+
+	return cli.GetTools(), nil
+}
+
+func newToolSchema(union *responses.ToolUnion, requiresApproval bool, source string, server string) *ToolSchema {
+	schema := &ToolSchema{Source: source, Server: server, RequiresApproval: requiresApproval}
+
+	switch {
+	case union.OfFunction != nil:
+		schema.Type = "function"
+		schema.Name = union.OfFunction.Name
+		schema.Parameters = union.OfFunction.Parameters
+		if union.OfFunction.Description != nil {
+			schema.Description = *union.OfFunction.Description
+		}
+	case union.OfImageGeneration != nil:
+		schema.Type, schema.Name = "image_generation", "image_generation"
+	case union.OfWebSearch != nil:
+		schema.Type, schema.Name = "web_search", "web_search"
+	case union.OfCodeExecution != nil:
+		schema.Type, schema.Name = "code_execution", "code_execution"
+	case union.OfFileSearch != nil:
+		schema.Type, schema.Name = "file_search", "file_search"
+	}
+
+	return schema
+}
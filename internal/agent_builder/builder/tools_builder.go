@@ -38,3 +38,17 @@ func BuildToolsList(config *agent_config.ToolConfig, svc sandbox.Manager) []core
 
 	return toolList
 }
+
+// BuildToolQuotas returns the tool quotas of the config
+func BuildToolQuotas(config []agent_config.ToolQuotaConfig) []core.ToolQuota {
+	var quotas []core.ToolQuota
+	for _, quota := range config {
+		quotas = append(quotas, core.ToolQuota{
+			Tool:   quota.Tool,
+			Scope:  core.ToolQuotaScope(quota.Scope),
+			Limit:  quota.Limit,
+			Period: core.ToolQuotaPeriod(quota.Period),
+		})
+	}
+	return quotas
+}
@@ -0,0 +1,32 @@
+package builder
+
+import (
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/translation"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/google/uuid"
+)
+
+// BuildTranslation builds the translation options of an agent, nil when translation is not enabled
+func BuildTranslation(svc *services.Services, projectID uuid.UUID, llmGateway *gateway.LLMGateway, config *agent_config.TranslationConfig, key string) (*agents.TranslationOptions, error) {
+	if config == nil || !config.Enabled || config.Model == nil {
+		return nil, nil
+	}
+
+	translatorLLM := BuildLLMClient(llmGateway, key, llm.ProviderName(config.Model.ProviderType), config.Model.ModelID, DataRegionOf(svc.Regions, projectID))
+	translatorModelParams, err := BuildModelParams(config.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agents.TranslationOptions{
+		Translator: translation.NewLLMTranslator(&translation.LLMTranslatorOptions{
+			LLM:        translatorLLM,
+			Parameters: translatorModelParams,
+		}),
+		Language: config.Language,
+	}, nil
+}
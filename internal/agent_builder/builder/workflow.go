@@ -30,6 +30,17 @@ func NewAgentBuilder(svc *services.Services, llmGateway *gateway.LLMGateway, bro
 }
 
 func (b *AgentBuilder) BuildAndExecuteAgent(ctx context.Context, agentConfig *agent_config.AgentConfig, in *agents.AgentInput, key string) (*agents.AgentOutput, error) {
+	agent, err := b.buildAgent(ctx, agentConfig, key, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent.Execute(ctx, in)
+}
+
+// buildAgent builds the agent of the config. Sub-agents are built without history, parents are the agents that
+// delegated to it.
+func (b *AgentBuilder) buildAgent(ctx context.Context, agentConfig *agent_config.AgentConfig, key string, withHistory bool, parents []string) (*agents.Agent, error) {
 	projectID := agentConfig.ProjectID
 
 	// Build prompt
@@ -55,10 +66,15 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx context.Context, agentConfig *ag
 		key,
 		llm.ProviderName(agentConfig.Config.Model.ProviderType),
 		agentConfig.Config.Model.ModelID,
+		DataRegionOf(b.svc.Regions, projectID),
 	)
 
 	// History
-	cm, err := BuildConversationManager(b.svc, projectID, b.llmGateway, agentConfig.Config.History, key)
+	historyConfig := agentConfig.Config.History
+	if !withHistory {
+		historyConfig = nil
+	}
+	cm, err := BuildConversationManager(b.svc, projectID, b.llmGateway, historyConfig, key)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +82,7 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx context.Context, agentConfig *ag
 	// MCP Servers
 	var mcpProxies []agents.MCPToolset
 	for _, mcpServerConfig := range agentConfig.Config.MCPServers {
-		mcpClient, err := BuildMCPClient(&mcpServerConfig)
+		mcpClient, err := BuildMCPClient(ctx, &mcpServerConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -75,18 +91,47 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx context.Context, agentConfig *ag
 
 	// Tools
 	toolList := BuildToolsList(agentConfig.Config.Tools, b.sandboxManager)
+	for _, subAgent := range agentConfig.Config.SubAgents {
+		toolList = append(toolList, b.NewSubAgentTool(projectID, subAgent, key, append(parents, agentConfig.Name)))
+	}
+
+	// Translation
+	translation, err := BuildTranslation(b.svc, projectID, b.llmGateway, agentConfig.Config.Translation, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response policy
+	responsePolicy, err := BuildResponsePolicy(b.svc, projectID, b.llmGateway, agentConfig.Config.ResponsePolicy, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tool quotas
+	var toolQuotaCounter core.ToolQuotaCounter
+	if len(agentConfig.Config.ToolQuotas) > 0 {
+		toolQuotaCounter = b.svc.Usage.ToolQuotaCounter(projectID)
+	}
 
 	// Agent
 	return agents.NewAgent(&agents.AgentOptions{
-		Name:        agentConfig.GetName(),
-		Instruction: instruction,
-		Parameters:  modelParams,
-		LLM:         llmClient,
-		Output:      outputFormat,
-		History:     cm,
-		McpServers:  mcpProxies,
-		Tools:       toolList,
-		Runtime:     nil,
-		MaxLoops:    agentConfig.Config.MaxIteration,
-	}).Execute(ctx, in)
+		Name:                  agentConfig.GetName(),
+		Instruction:           instruction,
+		Parameters:            modelParams,
+		LLM:                   llmClient,
+		Output:                outputFormat,
+		History:               cm,
+		McpServers:            mcpProxies,
+		Tools:                 toolList,
+		Runtime:               nil,
+		MaxLoops:              agentConfig.Config.MaxIteration,
+		DisableArgumentRepair: agentConfig.Config.DisableArgumentRepair,
+		Provenance:            agentConfig.Config.Provenance,
+		Translation:           translation,
+		ResponsePolicy:        responsePolicy,
+		ToolQuotas:            BuildToolQuotas(agentConfig.Config.ToolQuotas),
+		ToolQuotaCounter:      toolQuotaCounter,
+		ProviderPinning:       core.ProviderPinning(agentConfig.Config.ProviderPinning),
+		ToolDeadLetters:       b.svc.ToolDeadLetter.Store(projectID, agentConfig.AgentID, agentConfig.Version),
+	}), nil
 }
@@ -0,0 +1,165 @@
+package restate_agent_builder
+
+import (
+	"log/slog"
+
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	restate "github.com/restatedev/sdk-go"
+)
+
+// Keys of the state of the conversation sessions
+const (
+	sessionStateHead            = "head"
+	sessionStateRuns            = "runs"
+	sessionStateLoops           = "loops"
+	sessionStatePendingApproval = "pending_approval"
+)
+
+// SessionInput is the input of a message to a conversation session
+type SessionInput struct {
+	WorkflowInput
+
+	// StreamID is the broker channel the chunks of the run are published on
+	StreamID string
+}
+
+// SessionHead is the last run of a conversation session, and the message it continued
+type SessionHead struct {
+	BasedOn   string `json:"based_on"`
+	MessageID string `json:"message_id"`
+}
+
+// SessionPendingApproval is the run of the session paused for the approval of tool calls
+type SessionPendingApproval struct {
+	RunID     string                          `json:"run_id"`
+	ToolCalls []responses.FunctionCallMessage `json:"tool_calls"`
+	Loops     int                             `json:"loops"`
+}
+
+// SessionState is the state of a conversation session
+type SessionState struct {
+	Head            *SessionHead            `json:"head,omitempty"`
+	Runs            int                     `json:"runs"`
+	Loops           int                     `json:"loops"`
+	PendingApproval *SessionPendingApproval `json:"pending_approval,omitempty"`
+}
+
+// ConversationSession is a Restate virtual object keyed by conversation. Restate runs the exclusive handlers of a
+// key one at a time, so the messages sent concurrently to a conversation are executed one after the other, and the
+// steps of their runs, tool calls included, are journaled once per invocation.
+type ConversationSession struct {
+	builder *AgentBuilder
+}
+
+func NewConversationSession(builder *AgentBuilder) *ConversationSession {
+	return &ConversationSession{
+		builder: builder,
+	}
+}
+
+// Converse executes a message of the conversation. A message that raced with the previous one, continuing the same
+// message, continues the run of the previous one instead, so that the conversation doesn't fork.
+func (s *ConversationSession) Converse(ctx restate.ObjectContext, in *SessionInput) (*agents.AgentOutput, error) {
+	traceCtx, span := tracer.Start(ctx, "Restate.ConversationSession.Converse")
+	defer span.End()
+
+	ctx = restate.WrapContext(ctx, traceCtx)
+
+	cb := func(chunk *responses.ResponseChunk) {
+		if err := s.builder.broker.Publish(ctx, in.StreamID, chunk); err != nil {
+			slog.WarnContext(ctx, "unable to publish chunk to broker", slog.Any("error", err))
+		}
+	}
+	defer s.builder.broker.Close(ctx, in.StreamID)
+
+	head, err := restate.Get[*SessionHead](ctx, sessionStateHead)
+	if err != nil {
+		return nil, err
+	}
+
+	basedOn := in.Input.PreviousMessageID
+	resuming := len(in.Input.Messages) > 0 && in.Input.Messages[0].OfFunctionCallApprovalResponse != nil
+	if head != nil && !resuming && basedOn == head.BasedOn && basedOn != head.MessageID {
+		in.Input.PreviousMessageID = head.MessageID
+	}
+
+	agent, err := s.builder.buildAgent(ctx, &in.WorkflowInput)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := agent.ExecuteWithExecutor(ctx, in.Input, cb)
+	if err != nil {
+		return out, err
+	}
+
+	if out.RunID != "" {
+		restate.Set(ctx, sessionStateHead, &SessionHead{BasedOn: basedOn, MessageID: out.RunID})
+	}
+
+	pendingApproval, err := restate.Get[*SessionPendingApproval](ctx, sessionStatePendingApproval)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := restate.Get[int](ctx, sessionStateRuns)
+	if err != nil {
+		return nil, err
+	}
+	loops, err := restate.Get[int](ctx, sessionStateLoops)
+	if err != nil {
+		return nil, err
+	}
+
+	// A resumed run was counted when it paused, its loops count from its start
+	if pendingApproval != nil && pendingApproval.RunID == out.RunID {
+		loops -= pendingApproval.Loops
+	} else {
+		runs++
+	}
+	restate.Set(ctx, sessionStateRuns, runs)
+	restate.Set(ctx, sessionStateLoops, loops+out.Loops)
+
+	if out.Status == core.RunStatusPaused {
+		restate.Set(ctx, sessionStatePendingApproval, &SessionPendingApproval{
+			RunID:     out.RunID,
+			ToolCalls: out.PendingApprovals,
+			Loops:     out.Loops,
+		})
+	} else {
+		restate.Clear(ctx, sessionStatePendingApproval)
+	}
+
+	return out, nil
+}
+
+// GetState returns the state of the session, it doesn't wait for the message being executed
+func (s *ConversationSession) GetState(ctx restate.ObjectSharedContext) (*SessionState, error) {
+	head, err := restate.Get[*SessionHead](ctx, sessionStateHead)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := restate.Get[int](ctx, sessionStateRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	loops, err := restate.Get[int](ctx, sessionStateLoops)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingApproval, err := restate.Get[*SessionPendingApproval](ctx, sessionStatePendingApproval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionState{
+		Head:            head,
+		Runs:            runs,
+		Loops:           loops,
+		PendingApproval: pendingApproval,
+	}, nil
+}
@@ -59,6 +59,17 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx restate.WorkflowContext, in *Wor
 	}
 	defer b.broker.Close(ctx, workflowId)
 
+	agent, err := b.buildAgent(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent.ExecuteWithExecutor(ctx, in.Input, cb)
+}
+
+// buildAgent builds the agent of the config with its IO operations as durable steps of the invocation, it is
+// shared by the workflows and the conversation sessions
+func (b *AgentBuilder) buildAgent(ctx restate.Context, in *WorkflowInput) (*agents.Agent, error) {
 	// Project
 	projectID := in.AgentConfig.ProjectID
 
@@ -87,6 +98,7 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx restate.WorkflowContext, in *Wor
 			in.Key,
 			llm.ProviderName(in.AgentConfig.Config.Model.ProviderType),
 			in.AgentConfig.Config.Model.ModelID,
+			builder.DataRegionOf(b.svc.Regions, projectID),
 		),
 	)
 
@@ -103,13 +115,16 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx restate.WorkflowContext, in *Wor
 			conversationSummarizerProxy := restate_runtime.NewRestateConversationSummarizer(ctx, cm.Summarizer)
 			options = append(options, history.WithSummarizer(conversationSummarizerProxy))
 		}
+		if in.AgentConfig.Config.History.DeduplicateToolOutputs {
+			options = append(options, history.WithToolOutputDeduplication())
+		}
 		conversationManager = history.NewConversationManager(conversationPersistenceProxy, options...)
 	}
 
 	// MCP Servers
 	var mcpProxies []agents.MCPToolset
 	for _, mcpServerConfig := range in.AgentConfig.Config.MCPServers {
-		mcpClient, err := builder.BuildMCPClient(&mcpServerConfig)
+		mcpClient, err := builder.BuildMCPClient(ctx, &mcpServerConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -124,16 +139,62 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx restate.WorkflowContext, in *Wor
 		restateToolList = append(restateToolList, restate_runtime.NewRestateTool(ctx, tool))
 	}
 
+	// Sub-agents run as a single durable step each
+	localBuilder := builder.NewAgentBuilder(b.svc, b.llmGateway, b.broker, b.sandboxManager)
+	for _, subAgent := range in.AgentConfig.Config.SubAgents {
+		subAgentTool := localBuilder.NewSubAgentTool(projectID, subAgent, in.Key, []string{in.AgentConfig.Name})
+		restateToolList = append(restateToolList, restate_runtime.NewRestateTool(ctx, subAgentTool))
+	}
+
+	// Translation
+	translation, err := builder.BuildTranslation(b.svc, projectID, b.llmGateway, in.AgentConfig.Config.Translation, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	if translation != nil {
+		translation.Translator = restate_runtime.NewRestateTranslator(ctx, translation.Translator)
+	}
+
+	// Response policy
+	responsePolicy, err := builder.BuildResponsePolicy(b.svc, projectID, b.llmGateway, in.AgentConfig.Config.ResponsePolicy, in.Key)
+	if err != nil {
+		return nil, err
+	}
+	if responsePolicy != nil && responsePolicy.Judge != nil {
+		responsePolicy.Judge = restate_runtime.NewRestateResponseJudge(ctx, responsePolicy.Judge)
+	}
+
+	// Approvals
+	var approvalGate core.ApprovalGate
+	if in.AgentConfig.Config.DurableApprovals {
+		approvalGate = restate_runtime.NewRestateApprovalGate(ctx)
+	}
+
+	// Tool quotas
+	var toolQuotaCounter core.ToolQuotaCounter
+	if len(in.AgentConfig.Config.ToolQuotas) > 0 {
+		toolQuotaCounter = restate_runtime.NewRestateToolQuotaCounter(ctx, b.svc.Usage.ToolQuotaCounter(projectID))
+	}
+
 	// Agent
 	return agents.NewAgent(&agents.AgentOptions{
-		Name:        in.AgentConfig.GetName(),
-		Instruction: instruction,
-		Parameters:  modelParams,
-		Output:      outputFormat,
-		History:     conversationManager,
-		McpServers:  mcpProxies,
-		Tools:       restateToolList,
-		Runtime:     nil,
-		MaxLoops:    in.AgentConfig.Config.MaxIteration,
-	}).WithLLM(llmClient).ExecuteWithExecutor(ctx, in.Input, cb)
+		Name:                  in.AgentConfig.GetName(),
+		Instruction:           instruction,
+		Parameters:            modelParams,
+		Output:                outputFormat,
+		History:               conversationManager,
+		McpServers:            mcpProxies,
+		Tools:                 restateToolList,
+		Runtime:               nil,
+		MaxLoops:              in.AgentConfig.Config.MaxIteration,
+		DisableArgumentRepair: in.AgentConfig.Config.DisableArgumentRepair,
+		Provenance:            in.AgentConfig.Config.Provenance,
+		Translation:           translation,
+		ResponsePolicy:        responsePolicy,
+		ApprovalGate:          approvalGate,
+		ToolQuotas:            builder.BuildToolQuotas(in.AgentConfig.Config.ToolQuotas),
+		ToolQuotaCounter:      toolQuotaCounter,
+		ProviderPinning:       core.ProviderPinning(in.AgentConfig.Config.ProviderPinning),
+		ToolDeadLetters:       restate_runtime.NewRestateToolDeadLetterStore(ctx, b.svc.ToolDeadLetter.Store(projectID, in.AgentConfig.AgentID, in.AgentConfig.Version)),
+	}).WithLLM(llmClient), nil
 }
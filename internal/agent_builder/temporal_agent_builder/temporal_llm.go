@@ -9,12 +9,13 @@ import (
 	"github.com/curaious/uno/pkg/agent-framework/agents"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/workflow"
 )
 
-func (b *AgentBuilder) LLMCall(ctx context.Context, config *agent_config.ModelConfig, in *responses.Request, key string) (*responses.Response, error) {
-	llmClient := builder.BuildLLMClient(b.llmGateway, key, llm.ProviderName(config.ProviderType), config.ModelID)
+func (b *AgentBuilder) LLMCall(ctx context.Context, projectID uuid.UUID, config *agent_config.ModelConfig, in *responses.Request, key string) (*responses.Response, error) {
+	llmClient := builder.BuildLLMClient(b.llmGateway, key, llm.ProviderName(config.ProviderType), config.ModelID, builder.DataRegionOf(b.svc.Regions, projectID))
 
 	stream, err := llmClient.NewStreamingResponses(ctx, in)
 	if err != nil {
@@ -22,7 +23,7 @@ func (b *AgentBuilder) LLMCall(ctx context.Context, config *agent_config.ModelCo
 	}
 
 	acc := agents.Accumulator{}
-	resp, err := acc.ReadStream(stream, func(chunk *responses.ResponseChunk) {
+	resp, err := acc.ReadStream(ctx, stream, func(chunk *responses.ResponseChunk) {
 		if err := b.broker.Publish(ctx, activity.GetInfo(ctx).WorkflowExecution.ID, chunk); err != nil {
 			slog.ErrorContext(ctx, "Failed to publish chunk to stream broker", "error", err)
 		}
@@ -37,21 +38,31 @@ func (b *AgentBuilder) LLMCall(ctx context.Context, config *agent_config.ModelCo
 
 type TemporalLLMProxy struct {
 	workflowCtx workflow.Context
+	projectID   uuid.UUID
 	config      *agent_config.ModelConfig
 	key         string
 }
 
-func NewTemporalLLMProxy(workflowCtx workflow.Context, config *agent_config.ModelConfig, key string) agents.LLM {
+func NewTemporalLLMProxy(workflowCtx workflow.Context, projectID uuid.UUID, config *agent_config.ModelConfig, key string) agents.LLM {
 	return &TemporalLLMProxy{
 		workflowCtx: workflowCtx,
+		projectID:   projectID,
 		config:      config,
 		key:         key,
 	}
 }
 
+func (l *TemporalLLMProxy) ProviderName() llm.ProviderName {
+	return llm.ProviderName(l.config.ProviderType)
+}
+
+func (l *TemporalLLMProxy) ModelName() string {
+	return l.config.ModelID
+}
+
 func (l *TemporalLLMProxy) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
 	var response *responses.Response
-	err := workflow.ExecuteActivity(l.workflowCtx, "LLMCall", l.config, in, l.key).Get(l.workflowCtx, &response)
+	err := workflow.ExecuteActivity(l.workflowCtx, "LLMCall", l.projectID, l.config, in, l.key).Get(l.workflowCtx, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -12,7 +12,7 @@ import (
 )
 
 func (b *AgentBuilder) MCPListTools(ctx context.Context, config *agent_config.MCPServerConfig, runContext map[string]any) ([]core.BaseTool, error) {
-	client, err := builder.BuildMCPClient(config)
+	client, err := builder.BuildMCPClient(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -34,8 +34,8 @@ func (b *AgentBuilder) MCPListTools(ctx context.Context, config *agent_config.MC
 	return tools, nil
 }
 
-func (b *AgentBuilder) MCPCallTool(ctx context.Context, config *agent_config.MCPServerConfig, params *core.ToolCall, runContext map[string]any) (*responses.FunctionCallOutputMessage, error) {
-	client, err := builder.BuildMCPClient(config)
+func (b *AgentBuilder) MCPCallTool(ctx context.Context, config *agent_config.MCPServerConfig, params *core.ToolCall, runContext map[string]any) (*core.ToolResult, error) {
+	client, err := builder.BuildMCPClient(ctx, config)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +47,7 @@ func (b *AgentBuilder) MCPCallTool(ctx context.Context, config *agent_config.MCP
 
 	for _, tool := range mcpTools {
 		if t := tool.Tool(ctx); t != nil && t.OfFunction != nil && params.Name == t.OfFunction.Name {
-			return tool.Execute(ctx, params)
+			return core.ExecuteTool(ctx, tool, params)
 		}
 	}
 
@@ -102,11 +102,11 @@ func NewTemporalMCPToolProxy(workflowCtx workflow.Context, config *agent_config.
 }
 
 func (t *TemporalMCPToolProxy) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	var output *responses.FunctionCallOutputMessage
-	err := workflow.ExecuteActivity(t.workflowCtx, "MCPCallTool", t.config, params, t.runContext).Get(t.workflowCtx, &output)
+	var result core.ToolResult
+	err := workflow.ExecuteActivity(t.workflowCtx, "MCPCallTool", t.config, params, t.runContext).Get(t.workflowCtx, &result)
 	if err != nil {
 		return nil, err
 	}
 
-	return output, nil
+	return result.Unwrap()
 }
@@ -0,0 +1,50 @@
+package temporal_agent_builder
+
+import (
+	"context"
+	"errors"
+
+	"github.com/curaious/uno/internal/agent_builder/builder"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+func (b *AgentBuilder) JudgeResponse(ctx context.Context, projectID uuid.UUID, config *agent_config.ResponsePolicyConfig, text string, policy core.ResponsePolicy, key string) (*core.ResponseJudgement, error) {
+	responsePolicy, err := builder.BuildResponsePolicy(b.svc, projectID, b.llmGateway, config, key)
+	if err != nil {
+		return nil, err
+	}
+	if responsePolicy == nil || responsePolicy.Judge == nil {
+		return nil, errors.New("response policy has no model")
+	}
+
+	return responsePolicy.Judge.Judge(ctx, text, policy)
+}
+
+type TemporalResponseJudgeProxy struct {
+	workflowCtx workflow.Context
+	projectID   uuid.UUID
+	config      *agent_config.ResponsePolicyConfig
+	key         string
+}
+
+func NewTemporalResponseJudgeProxy(workflowCtx workflow.Context, projectID uuid.UUID, config *agent_config.ResponsePolicyConfig, key string) core.ResponseJudge {
+	return &TemporalResponseJudgeProxy{
+		workflowCtx: workflowCtx,
+		projectID:   projectID,
+		config:      config,
+		key:         key,
+	}
+}
+
+func (j *TemporalResponseJudgeProxy) Judge(ctx context.Context, text string, policy core.ResponsePolicy) (*core.ResponseJudgement, error) {
+	var judgement *core.ResponseJudgement
+	err := workflow.ExecuteActivity(j.workflowCtx, "JudgeResponse", j.projectID, j.config, text, policy, j.key).Get(j.workflowCtx, &judgement)
+	if err != nil {
+		return nil, err
+	}
+
+	return judgement, nil
+}
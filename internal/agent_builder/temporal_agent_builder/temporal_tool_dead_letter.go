@@ -0,0 +1,34 @@
+package temporal_agent_builder
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+func (b *AgentBuilder) RecordToolDeadLetter(ctx context.Context, projectID uuid.UUID, agentID uuid.UUID, version int, letter *core.ToolDeadLetter) error {
+	_, err := b.svc.ToolDeadLetter.Record(ctx, projectID, agentID, version, letter)
+	return err
+}
+
+type TemporalToolDeadLetterStoreProxy struct {
+	workflowCtx workflow.Context
+	projectID   uuid.UUID
+	agentID     uuid.UUID
+	version     int
+}
+
+func NewTemporalToolDeadLetterStoreProxy(workflowCtx workflow.Context, projectID uuid.UUID, agentID uuid.UUID, version int) core.ToolDeadLetterStore {
+	return &TemporalToolDeadLetterStoreProxy{
+		workflowCtx: workflowCtx,
+		projectID:   projectID,
+		agentID:     agentID,
+		version:     version,
+	}
+}
+
+func (s *TemporalToolDeadLetterStoreProxy) Record(ctx context.Context, letter *core.ToolDeadLetter) error {
+	return workflow.ExecuteActivity(s.workflowCtx, "RecordToolDeadLetter", s.projectID, s.agentID, s.version, letter).Get(s.workflowCtx, nil)
+}
@@ -0,0 +1,56 @@
+package temporal_agent_builder
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ToolQuotaConsumption is the result of the ConsumeToolQuota activity
+type ToolQuotaConsumption struct {
+	Used int  `json:"used"`
+	OK   bool `json:"ok"`
+}
+
+func (b *AgentBuilder) ConsumeToolQuota(ctx context.Context, projectID uuid.UUID, quota core.ToolQuota, scopeKey string, n int) (*ToolQuotaConsumption, error) {
+	used, ok, err := b.svc.Usage.ConsumeToolQuota(ctx, projectID, quota, scopeKey, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ToolQuotaConsumption{Used: used, OK: ok}, nil
+}
+
+func (b *AgentBuilder) ToolQuotaUsed(ctx context.Context, projectID uuid.UUID, quota core.ToolQuota, scopeKey string) (int, error) {
+	return b.svc.Usage.ToolQuotaUsed(ctx, projectID, quota, scopeKey)
+}
+
+type TemporalToolQuotaCounterProxy struct {
+	workflowCtx workflow.Context
+	projectID   uuid.UUID
+}
+
+func NewTemporalToolQuotaCounterProxy(workflowCtx workflow.Context, projectID uuid.UUID) core.ToolQuotaCounter {
+	return &TemporalToolQuotaCounterProxy{
+		workflowCtx: workflowCtx,
+		projectID:   projectID,
+	}
+}
+
+func (c *TemporalToolQuotaCounterProxy) Consume(ctx context.Context, quota core.ToolQuota, scopeKey string, n int) (int, bool, error) {
+	var out ToolQuotaConsumption
+	err := workflow.ExecuteActivity(c.workflowCtx, "ConsumeToolQuota", c.projectID, quota, scopeKey, n).Get(c.workflowCtx, &out)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return out.Used, out.OK, nil
+}
+
+func (c *TemporalToolQuotaCounterProxy) Used(ctx context.Context, quota core.ToolQuota, scopeKey string) (int, error) {
+	var used int
+	err := workflow.ExecuteActivity(c.workflowCtx, "ToolQuotaUsed", c.projectID, quota, scopeKey).Get(c.workflowCtx, &used)
+	return used, err
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"os"
 
+	"github.com/curaious/uno/internal/agent_builder/builder"
 	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/curaious/uno/pkg/agent-framework/core"
 	"github.com/curaious/uno/pkg/agent-framework/tools"
 	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
 	"go.temporal.io/sdk/workflow"
 )
 
@@ -44,6 +46,40 @@ func (t *TemporalToolProxy) NeedApproval() bool {
 	return t.wrappedTool.NeedApproval()
 }
 
+// SubAgentTool runs a sub-agent of the agent, with its whole run as a single activity
+func (b *AgentBuilder) SubAgentTool(ctx context.Context, projectID uuid.UUID, subAgent agent_config.SubAgentConfig, key string, parent string, toolCall *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
+	subAgentTool := builder.NewAgentBuilder(b.svc, b.llmGateway, b.broker, b.sandboxManager).NewSubAgentTool(projectID, subAgent, key, []string{parent})
+	return subAgentTool.Execute(ctx, toolCall)
+}
+
+type TemporalSubAgentToolProxy struct {
+	*core.BaseTool
+	workflowCtx workflow.Context
+	projectID   uuid.UUID
+	subAgent    agent_config.SubAgentConfig
+	key         string
+	parent      string
+}
+
+func NewTemporalSubAgentToolProxy(workflowCtx workflow.Context, projectID uuid.UUID, subAgent agent_config.SubAgentConfig, key string, parent string) *TemporalSubAgentToolProxy {
+	return &TemporalSubAgentToolProxy{
+		BaseTool: &core.BaseTool{
+			ToolUnion: *builder.SubAgentToolDefinition(subAgent),
+		},
+		workflowCtx: workflowCtx,
+		projectID:   projectID,
+		subAgent:    subAgent,
+		key:         key,
+		parent:      parent,
+	}
+}
+
+func (p *TemporalSubAgentToolProxy) Execute(ctx context.Context, toolCall *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
+	var out responses.FunctionCallOutputMessage
+	err := workflow.ExecuteActivity(p.workflowCtx, "SubAgentTool", p.projectID, p.subAgent, p.key, p.parent, toolCall).Get(p.workflowCtx, &out)
+	return &out, err
+}
+
 func BuildTemporalToolsList(workflowCtx workflow.Context, config *agent_config.ToolConfig) []core.Tool {
 	var toolList []core.Tool
 	if config == nil {
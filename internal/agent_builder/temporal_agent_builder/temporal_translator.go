@@ -0,0 +1,50 @@
+package temporal_agent_builder
+
+import (
+	"context"
+	"errors"
+
+	"github.com/curaious/uno/internal/agent_builder/builder"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/workflow"
+)
+
+func (b *AgentBuilder) Translate(ctx context.Context, projectID uuid.UUID, config *agent_config.TranslationConfig, text string, from string, to string, key string) (*core.Translation, error) {
+	translation, err := builder.BuildTranslation(b.svc, projectID, b.llmGateway, config, key)
+	if err != nil {
+		return nil, err
+	}
+	if translation == nil {
+		return nil, errors.New("translation is not enabled")
+	}
+
+	return translation.Translator.Translate(ctx, text, from, to)
+}
+
+type TemporalTranslatorProxy struct {
+	workflowCtx workflow.Context
+	projectID   uuid.UUID
+	config      *agent_config.TranslationConfig
+	key         string
+}
+
+func NewTemporalTranslatorProxy(workflowCtx workflow.Context, projectID uuid.UUID, config *agent_config.TranslationConfig, key string) core.Translator {
+	return &TemporalTranslatorProxy{
+		workflowCtx: workflowCtx,
+		projectID:   projectID,
+		config:      config,
+		key:         key,
+	}
+}
+
+func (t *TemporalTranslatorProxy) Translate(ctx context.Context, text string, from string, to string) (*core.Translation, error) {
+	var translation *core.Translation
+	err := workflow.ExecuteActivity(t.workflowCtx, "Translate", t.projectID, t.config, text, from, to, t.key).Get(t.workflowCtx, &translation)
+	if err != nil {
+		return nil, err
+	}
+
+	return translation, nil
+}
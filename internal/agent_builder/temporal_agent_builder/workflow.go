@@ -65,7 +65,7 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx workflow.Context, agentConfig *a
 	}
 
 	// LLM Client
-	llmClient := NewTemporalLLMProxy(ctx, agentConfig.Config.Model, key)
+	llmClient := NewTemporalLLMProxy(ctx, projectID, agentConfig.Config.Model, key)
 
 	// History
 	var conversationManager *history.CommonConversationManager
@@ -76,6 +76,9 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx workflow.Context, agentConfig *a
 			conversationSummarizerProxy := NewTemporalConversationSummarizerProxy(ctx, projectID, agentConfig.Config.History, key)
 			options = append(options, history.WithSummarizer(conversationSummarizerProxy))
 		}
+		if agentConfig.Config.History.DeduplicateToolOutputs {
+			options = append(options, history.WithToolOutputDeduplication())
+		}
 		conversationManager = history.NewConversationManager(conversationPersistenceProxy, options...)
 	}
 
@@ -88,17 +91,61 @@ func (b *AgentBuilder) BuildAndExecuteAgent(ctx workflow.Context, agentConfig *a
 
 	// Tools
 	toolList := BuildTemporalToolsList(ctx, agentConfig.Config.Tools)
+	for _, subAgent := range agentConfig.Config.SubAgents {
+		toolList = append(toolList, NewTemporalSubAgentToolProxy(ctx, projectID, subAgent, key, agentConfig.Name))
+	}
+
+	// Translation
+	var translation *agents.TranslationOptions
+	if agentConfig.Config.Translation != nil && agentConfig.Config.Translation.Enabled {
+		translation = &agents.TranslationOptions{
+			Translator: NewTemporalTranslatorProxy(ctx, projectID, agentConfig.Config.Translation, key),
+			Language:   agentConfig.Config.Translation.Language,
+		}
+	}
+
+	// Response policy
+	var responsePolicy *agents.ResponsePolicyOptions
+	if policy := agentConfig.Config.ResponsePolicy; policy != nil && policy.Enabled {
+		responsePolicy = &agents.ResponsePolicyOptions{
+			Policy: core.ResponsePolicy{
+				Language:  policy.Language,
+				Tone:      policy.Tone,
+				MaxLength: policy.MaxLength,
+			},
+		}
+		if policy.MaxRevisions != nil {
+			responsePolicy.MaxRevisions = *policy.MaxRevisions
+		}
+		if policy.Model != nil {
+			responsePolicy.Judge = NewTemporalResponseJudgeProxy(ctx, projectID, policy, key)
+		}
+	}
+
+	// Tool quotas
+	var toolQuotaCounter core.ToolQuotaCounter
+	if len(agentConfig.Config.ToolQuotas) > 0 {
+		toolQuotaCounter = NewTemporalToolQuotaCounterProxy(ctx, projectID)
+	}
 
 	// Agent
 	return agents.NewAgent(&agents.AgentOptions{
-		Name:        agentConfig.GetName(),
-		Instruction: instruction,
-		Parameters:  modelParams,
-		Output:      outputFormat,
-		History:     conversationManager,
-		McpServers:  mcpProxies,
-		Tools:       toolList,
-		Runtime:     nil,
-		MaxLoops:    agentConfig.Config.MaxIteration,
+		Name:                  agentConfig.GetName(),
+		Instruction:           instruction,
+		Parameters:            modelParams,
+		Output:                outputFormat,
+		History:               conversationManager,
+		McpServers:            mcpProxies,
+		Tools:                 toolList,
+		Runtime:               nil,
+		MaxLoops:              agentConfig.Config.MaxIteration,
+		DisableArgumentRepair: agentConfig.Config.DisableArgumentRepair,
+		Provenance:            agentConfig.Config.Provenance,
+		Translation:           translation,
+		ResponsePolicy:        responsePolicy,
+		ToolQuotas:            builder.BuildToolQuotas(agentConfig.Config.ToolQuotas),
+		ToolQuotaCounter:      toolQuotaCounter,
+		ProviderPinning:       core.ProviderPinning(agentConfig.Config.ProviderPinning),
+		ToolDeadLetters:       NewTemporalToolDeadLetterStoreProxy(ctx, projectID, agentConfig.AgentID, agentConfig.Version),
 	}).WithLLM(llmClient).ExecuteWithExecutor(context.Background(), in, cb)
 }
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,11 +18,16 @@ import (
 	"github.com/curaious/uno/internal/migrations"
 	"github.com/curaious/uno/internal/pubsub"
 	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/outbox"
 	"github.com/curaious/uno/pkg/agent-framework/core"
 	"github.com/curaious/uno/pkg/agent-framework/streaming"
 	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/gateway/middlewares/key_usage_middleware"
 	"github.com/curaious/uno/pkg/gateway/middlewares/logger"
+	"github.com/curaious/uno/pkg/gateway/middlewares/response_store_middleware"
 	"github.com/curaious/uno/pkg/gateway/middlewares/virtual_key_middleware"
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter"
+	"github.com/curaious/uno/pkg/gateway/providers/replicate"
 	"github.com/curaious/uno/pkg/sandbox"
 	"github.com/curaious/uno/pkg/sandbox/docker_sandbox"
 	"github.com/curaious/uno/pkg/sandbox/k8s_sandbox"
@@ -47,7 +53,12 @@ type Server struct {
 	pubsub        *pubsub.PubSub
 	redisClient   *redis.Client
 	broker        core.StreamBroker
+	runEvents     core.RunEventStore
 	sandboxManger sandbox.Manager
+
+	agentConfigCache   *adapters.ConfigCache
+	gatewayConfigCache *adapters.ConfigCache
+	outboxDispatchers  []*outbox.Dispatcher
 }
 
 // New creates a new server by wrapping *planner.App with *http.Server
@@ -64,6 +75,16 @@ func New() *Server {
 		panic("unable to run migrations")
 	}
 
+	for region := range conf.GetRegionDatabases() {
+		rm, err := migrations.NewRegionMigrator(region)
+		if err != nil {
+			panic("unable to create migrator for region " + region)
+		}
+		if err := rm.Up(0); err != nil {
+			panic("unable to run migrations for region " + region)
+		}
+	}
+
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", conf.REDIS_HOST, conf.REDIS_PORT),
 		DB:       10,
@@ -78,14 +99,24 @@ func New() *Server {
 	svc := services.NewServices(conf)
 	slog.Info("services initialized")
 
-	// Create shared config store for the LLM gateway
-	configStore := adapters.NewServiceConfigStore(svc.Provider, svc.VirtualKey)
+	// Create shared config store for the LLM gateway, caching the provider and virtual key configs across replicas
+	gatewayConfigCache := adapters.NewConfigCache(redisClient, adapters.ConfigCacheOptions{
+		Name: "gateway_configs",
+	})
+	configStore := adapters.NewServiceConfigStore(svc.Provider, svc.VirtualKey, gatewayConfigCache)
 
 	// Create pubsub for live configuration updates
 	ps := pubsub.NewPubSub(conf)
 
-	// Subscribe config store to pubsub before starting
+	// Cache agent config resolution across requests and replicas
+	agentConfigCache := adapters.NewConfigCache(redisClient, adapters.ConfigCacheOptions{
+		Name:         "agent_configs",
+		InvalidateOn: []pubsub.ConfigChangeType{pubsub.ChangeTypeAgentConfig, pubsub.ChangeTypeAgentConfigAlias},
+	})
+
+	// Subscribe config store and caches to pubsub before starting
 	configStore.SubscribeToPubSub(ps)
+	agentConfigCache.SubscribeToPubSub(ps)
 
 	// Start pubsub listener
 	if err := ps.Start(); err != nil {
@@ -96,21 +127,86 @@ func New() *Server {
 	llmGateway := gateway.NewLLMGateway(configStore)
 	llmGateway.UseMiddleware(
 		logger.NewLoggerMiddleware(),
+		key_usage_middleware.NewKeyUsageMiddleware(svc.KeyUsage),
+		// Stores the responses with the virtual keys they are owned by, before they are replaced with the provider keys
+		response_store_middleware.NewResponseStoreMiddleware(configStore, svc.GatewayResponse),
 		virtual_key_middleware.NewVirtualKeyMiddleware(
 			configStore,
 			virtual_key_middleware.NewRedisRateLimiterStorage(redisClient, ""),
 		),
 	)
+	llmGateway.UseImageStore(svc.Attachment)
+	if conf.FAULT_INJECTION != "" {
+		faults, err := gateway.ParseFaultConfig(conf.FAULT_INJECTION)
+		if err != nil {
+			log.Fatalln("Invalid fault injection configuration", err.Error())
+		}
+		llmGateway.UseFaultInjection(faults)
+		slog.Warn("Fault injection is enabled on the requests to the providers", slog.String("faults", conf.FAULT_INJECTION))
+	}
+	if conf.REPLICATE_WEBHOOK_URL != "" {
+		webhooks, err := replicate.NewWebhooks(conf.REPLICATE_WEBHOOK_URL, conf.REPLICATE_WEBHOOK_SECRET)
+		if err != nil {
+			log.Fatalln("Invalid Replicate webhook configuration", err.Error())
+		}
+		llmGateway.UseReplicateWebhooks(webhooks)
+	}
+
+	// The models of the requests to OpenRouter are checked against its catalog, loaded without delaying the startup
+	llmGateway.UseOpenRouterCatalog(openrouter.NewCatalog())
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := llmGateway.LoadOpenRouterCatalog(ctx); err != nil {
+			slog.Warn("Failed to load the catalog of OpenRouter, its models are not checked", slog.Any("error", err))
+			return
+		}
+		slog.Info("Loaded the catalog of OpenRouter", slog.Int("models", len(llmGateway.OpenRouterCatalog().Models())))
+	}()
 	slog.Info("LLM gateway initialized with pubsub")
 
 	// Broker
-	broker, err := streaming.NewRedisStreamBroker(streaming.RedisStreamBrokerOptions{
-		Client: redisClient,
-	})
-	if err != nil {
-		log.Fatalf("Failed to create redis stream broker: %v", err)
+	var broker core.StreamBroker
+	switch conf.STREAM_BROKER_TRANSPORT {
+	case "streams":
+		broker = streaming.NewRedisStreamsBroker(redisClient, streaming.RedisStreamsBrokerOptions{})
+	case "pubsub":
+		broker, err = streaming.NewRedisStreamBroker(streaming.RedisStreamBrokerOptions{
+			Client: redisClient,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create redis stream broker: %v", err)
+		}
+	default:
+		log.Fatalf("Invalid STREAM_BROKER_TRANSPORT '%s': use pubsub or streams", conf.STREAM_BROKER_TRANSPORT)
 	}
-	slog.Info("Redis stream broker initialized")
+	slog.Info("Redis stream broker initialized", slog.String("transport", conf.STREAM_BROKER_TRANSPORT))
+
+	// Record the chunks of runs, so that more than one consumer can follow a run
+	runEvents := streaming.NewRedisRunEventStore(redisClient, streaming.RedisRunEventStoreOptions{})
+
+	// Deliver outbox events to the configured sinks
+	outboxSinks := []outbox.Sink{}
+	if conf.OUTBOX_REDIS_STREAM != "none" {
+		outboxSinks = append(outboxSinks, outbox.NewRedisSink(redisClient, conf.OUTBOX_REDIS_STREAM, 0))
+	}
+	for _, url := range strings.Split(conf.OUTBOX_WEBHOOK_URLS, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			outboxSinks = append(outboxSinks, outbox.NewWebhookSink(url, conf.OUTBOX_WEBHOOK_SECRET))
+		}
+	}
+	if conf.OUTBOX_KAFKA_REST_URL != "" {
+		outboxSinks = append(outboxSinks, outbox.NewKafkaSink(conf.OUTBOX_KAFKA_REST_URL, conf.OUTBOX_KAFKA_TOPIC))
+	}
+	outboxDispatchers := []*outbox.Dispatcher{svc.Outbox.NewDispatcher(outboxSinks, outbox.DispatcherOptions{})}
+	// The events of projects pinned to a region are written to the outbox of the region
+	for _, conn := range svc.Regions.Conns() {
+		outboxDispatchers = append(outboxDispatchers, outbox.NewDispatcher(outbox.NewOutboxRepo(conn), outboxSinks, outbox.DispatcherOptions{}))
+	}
+	for _, dispatcher := range outboxDispatchers {
+		dispatcher.Start()
+	}
+	slog.Info("Outbox dispatcher started", slog.Int("sinks", len(outboxSinks)), slog.Int("dispatchers", len(outboxDispatchers)))
 
 	// Sandbox manager
 	var sandboxManager sandbox.Manager
@@ -143,7 +239,12 @@ func New() *Server {
 		pubsub:        ps,
 		redisClient:   redisClient,
 		broker:        broker,
+		runEvents:     runEvents,
 		sandboxManger: sandboxManager,
+
+		agentConfigCache:   agentConfigCache,
+		gatewayConfigCache: gatewayConfigCache,
+		outboxDispatchers:  outboxDispatchers,
 	}
 
 	s.srv.Handler = s.initNewRoutes()
@@ -202,9 +303,15 @@ func (s *Server) StartTemporalWorker() {
 	w.RegisterActivityWithOptions(agentBuilder.SaveMessages, activity.RegisterOptions{Name: "SaveMessages"})
 	w.RegisterActivityWithOptions(agentBuilder.SaveSummary, activity.RegisterOptions{Name: "SaveSummary"})
 	w.RegisterActivityWithOptions(agentBuilder.Summarize, activity.RegisterOptions{Name: "Summarize"})
+	w.RegisterActivityWithOptions(agentBuilder.Translate, activity.RegisterOptions{Name: "Translate"})
+	w.RegisterActivityWithOptions(agentBuilder.JudgeResponse, activity.RegisterOptions{Name: "JudgeResponse"})
 	w.RegisterActivityWithOptions(agentBuilder.MCPListTools, activity.RegisterOptions{Name: "MCPListTools"})
 	w.RegisterActivityWithOptions(agentBuilder.MCPCallTool, activity.RegisterOptions{Name: "MCPCallTool"})
 	w.RegisterActivityWithOptions(agentBuilder.SandboxTool, activity.RegisterOptions{Name: "SandboxTool"})
+	w.RegisterActivityWithOptions(agentBuilder.SubAgentTool, activity.RegisterOptions{Name: "SubAgentTool"})
+	w.RegisterActivityWithOptions(agentBuilder.ConsumeToolQuota, activity.RegisterOptions{Name: "ConsumeToolQuota"})
+	w.RegisterActivityWithOptions(agentBuilder.ToolQuotaUsed, activity.RegisterOptions{Name: "ToolQuotaUsed"})
+	w.RegisterActivityWithOptions(agentBuilder.RecordToolDeadLetter, activity.RegisterOptions{Name: "RecordToolDeadLetter"})
 
 	w.RegisterWorkflowWithOptions(agentBuilder.BuildAndExecuteAgent, workflow.RegisterOptions{
 		Name: "AgentBuilder",
@@ -222,6 +329,7 @@ func (s *Server) StartRestateWorker() {
 
 	if err := server.NewRestate().
 		Bind(restate.Reflect(agentBuilder)).
+		Bind(restate.Reflect(restate_agent_builder.NewConversationSession(agentBuilder))).
 		Start(context.Background(), ":9080"); err != nil {
 		log.Fatal(err)
 	}
@@ -236,6 +344,10 @@ func (s *Server) shutdown(ctx context.Context) {
 		s.pubsub.Stop()
 	}
 
+	for _, dispatcher := range s.outboxDispatchers {
+		dispatcher.Stop()
+	}
+
 	if err := s.srv.Shutdown(); err != nil {
 		slog.Error("Failed to shutdown the server", slog.Any("error", err))
 	}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/valyala/fasthttp"
+)
+
+// uncompressedKey marks the responses that are too small to be worth compressing
+const uncompressedKey = "uncompressed"
+
+// withCompression compresses the responses with brotli or gzip, as accepted by the client. Bodies smaller than
+// minBytes are sent as they are. Streamed bodies, e.g. SSE, are always compressed: the compressor is flushed after
+// every write of the stream, so events reach the client as soon as the handler flushes them.
+//
+// fasthttp never compresses bodies smaller than 200 bytes, nor bodies of content types that don't compress well,
+// such as images and audio.
+func withCompression(next fasthttp.RequestHandler, minBytes int) fasthttp.RequestHandler {
+	compress := fasthttp.CompressHandlerBrotliLevel(func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+
+		// fasthttp leaves alone the responses that have a content encoding
+		if !ctx.Response.IsBodyStream() && len(ctx.Response.Body()) < minBytes && len(ctx.Response.Header.ContentEncoding()) == 0 {
+			ctx.Response.Header.SetContentEncoding("identity")
+			ctx.SetUserValue(uncompressedKey, true)
+		}
+	}, fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressDefaultCompression)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		compress(ctx)
+
+		if ctx.UserValue(uncompressedKey) != nil {
+			ctx.Response.Header.Del(fasthttp.HeaderContentEncoding)
+		}
+	}
+}
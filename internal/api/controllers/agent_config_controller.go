@@ -1,20 +1,102 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"strconv"
 	"strings"
 
+	"github.com/curaious/uno/internal/api/response"
 	"github.com/curaious/uno/internal/perrors"
 	"github.com/curaious/uno/internal/services"
 	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
 	"github.com/fasthttp/router"
 	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
 )
 
-func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
+// writeAgentConfigError returns validation errors of the config as a bad request, listing the invalid fields,
+// and concurrent modifications as a conflict, with the latest revision of the config
+func writeAgentConfigError(ctx *fasthttp.RequestCtx, stdCtx context.Context, msg string, err error) {
+	var validationErr *agent_config.ValidationError
+	if errors.As(err, &validationErr) {
+		writeError(ctx, stdCtx, "Invalid agent config", perrors.NewErrInvalidRequest("Invalid agent config", err, map[string]interface{}{"fields": validationErr.Errors}))
+		return
+	}
+
+	if errors.Is(err, agent_config.ErrInvalidETag) {
+		writeError(ctx, stdCtx, "Invalid If-Match etag", perrors.NewErrInvalidRequest("Invalid If-Match etag", err))
+		return
+	}
+
+	var conflictErr *agent_config.ConflictError
+	if errors.As(err, &conflictErr) {
+		latest := withETag(ctx, conflictErr.Latest)
+		response.NewResponse[any](stdCtx, "Agent config was modified concurrently", latest).
+			WithError(perrors.New(perrors.ErrCodeConflict, "Agent config was modified concurrently", err)).
+			Write(ctx)
+		return
+	}
+
+	writeError(ctx, stdCtx, msg, perrors.NewErrInternalServerError(msg, err))
+}
+
+// ToolInvocationRequest is a manual call of a tool of an agent
+type ToolInvocationRequest struct {
+	Version        int             `json:"version"`
+	Arguments      json.RawMessage `json:"arguments"` // JSON object, or a string holding it as the models send them
+	Approved       bool            `json:"approved"`  // Approves the call of a tool requiring approval
+	Namespace      string          `json:"namespace"`
+	ConversationID string          `json:"conversation_id"`
+	Context        map[string]any  `json:"context"`
+}
+
+// arguments returns the arguments as the JSON string a model would send
+func (r *ToolInvocationRequest) arguments() (string, error) {
+	raw := bytes.TrimSpace(r.Arguments)
+	if len(raw) == 0 || string(raw) == "null" {
+		return "{}", nil
+	}
+
+	if raw[0] == '"' {
+		var arguments string
+		if err := json.Unmarshal(raw, &arguments); err != nil {
+			return "", err
+		}
+		return arguments, nil
+	}
+
+	if raw[0] != '{' {
+		return "", errors.New("the arguments must be a JSON object")
+	}
+	return string(raw), nil
+}
+
+// withETag sets the etag of the config on the response and in the config
+func withETag(ctx *fasthttp.RequestCtx, config *agent_config.AgentConfig) *agent_config.AgentConfig {
+	config.ETag = config.GetETag()
+	ctx.Response.Header.Set("ETag", config.ETag)
+	return config
+}
+
+// ifMatch sets the revision precondition of the update from the If-Match header
+func ifMatch(ctx *fasthttp.RequestCtx, req *agent_config.UpdateAgentConfigRequest) {
+	if header := ctx.Request.Header.Peek("If-Match"); len(header) > 0 {
+		req.IfMatch = string(header)
+	}
+}
+
+func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	// Canonical JSON schema of the config payload
+	r.GET("/api/agent-server/agent-configs/schema", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		writeOK(ctx, stdCtx, "Agent config schema retrieved", json.RawMessage(agent_config.AgentConfigSchema))
+	})
+
 	// Create agent config
 	r.POST("/api/agent-server/agent-configs", func(ctx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(ctx)
@@ -37,11 +119,11 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 
 		created, err := svc.AgentConfig.Create(stdCtx, projectID, &body)
 		if err != nil {
-			writeError(ctx, stdCtx, "Failed to create agent config", perrors.NewErrInternalServerError("Failed to create agent config", err))
+			writeAgentConfigError(ctx, stdCtx, "Failed to create agent config", err)
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config created successfully", created)
+		writeOK(ctx, stdCtx, "Agent config created successfully", withETag(ctx, created))
 	})
 
 	// List agent configs
@@ -59,7 +141,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent configs retrieved successfully", configs)
+		writeOKConditional(ctx, stdCtx, "Agent configs retrieved successfully", configs)
 	})
 
 	// Get agent config by ID
@@ -89,7 +171,12 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config retrieved successfully", config)
+		if config.MCPDrift, err = svc.AgentConfig.GetMCPDrift(stdCtx, config.AgentID); err != nil {
+			writeError(ctx, stdCtx, "Failed to get MCP drift", perrors.NewErrInternalServerError("Failed to get MCP drift", err))
+			return
+		}
+
+		writeOKConditional(ctx, stdCtx, "Agent config retrieved successfully", withETag(ctx, config))
 	})
 
 	// Get agent config by name (latest version)
@@ -122,7 +209,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 				return
 			}
 
-			writeOK(ctx, stdCtx, "Agent config retrieved successfully", config)
+			writeOKConditional(ctx, stdCtx, "Agent config retrieved successfully", withETag(ctx, config))
 			return
 		}
 
@@ -133,7 +220,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config retrieved successfully", config)
+		writeOKConditional(ctx, stdCtx, "Agent config retrieved successfully", withETag(ctx, config))
 	})
 
 	// List all versions of an agent config by agent_id
@@ -164,7 +251,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config versions retrieved successfully", configs)
+		writeOKConditional(ctx, stdCtx, "Agent config versions retrieved successfully", configs)
 	})
 
 	// List all versions of an agent config by name (for backward compatibility)
@@ -188,7 +275,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config versions retrieved successfully", configs)
+		writeOKConditional(ctx, stdCtx, "Agent config versions retrieved successfully", configs)
 	})
 
 	// Update version 0 (mutable) of agent config by ID
@@ -224,14 +311,15 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
 			return
 		}
+		ifMatch(ctx, &body)
 
 		updated, err := svc.AgentConfig.UpdateVersion0(stdCtx, config.AgentID, &body)
 		if err != nil {
-			writeError(ctx, stdCtx, "Failed to update agent config", perrors.NewErrInternalServerError("Failed to update agent config", err))
+			writeAgentConfigError(ctx, stdCtx, "Failed to update agent config", err)
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config updated successfully", updated)
+		writeOK(ctx, stdCtx, "Agent config updated successfully", withETag(ctx, updated))
 	})
 
 	// Update version 0 (mutable) of agent config by name (for backward compatibility)
@@ -254,14 +342,15 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
 			return
 		}
+		ifMatch(ctx, &body)
 
 		updated, err := svc.AgentConfig.UpdateVersion0ByName(stdCtx, projectID, name, &body)
 		if err != nil {
-			writeError(ctx, stdCtx, "Failed to update agent config", perrors.NewErrInternalServerError("Failed to update agent config", err))
+			writeAgentConfigError(ctx, stdCtx, "Failed to update agent config", err)
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Agent config updated successfully", updated)
+		writeOK(ctx, stdCtx, "Agent config updated successfully", withETag(ctx, updated))
 	})
 
 	// Create new immutable version from version 0 by ID
@@ -394,6 +483,311 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 		writeOK(ctx, stdCtx, "Agent config version deleted successfully", nil)
 	})
 
+	// Get the drift of the tools of the MCP servers of an agent from the last check
+	r.GET("/api/agent-server/agent-configs/{id}/mcp-drift", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if _, err := requireUUIDQuery(ctx, "project_id"); err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		drifts, err := svc.AgentConfig.GetMCPDrift(stdCtx, agentID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get MCP drift", perrors.NewErrInternalServerError("Failed to get MCP drift", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "MCP drift retrieved successfully", drifts)
+	})
+
+	// Check the tools of the MCP servers of the latest version of an agent now
+	r.POST("/api/agent-server/agent-configs/{id}/mcp-drift/check", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		drifts, err := svc.AgentConfig.CheckMCPDriftByAgentID(stdCtx, projectID, agentID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to check MCP drift", perrors.NewErrInternalServerError("Failed to check MCP drift", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "MCP drift checked successfully", drifts)
+	})
+
+	// Accept the tools last observed on an MCP server of an agent as the expected ones
+	r.POST("/api/agent-server/agent-configs/{id}/mcp-drift/accept", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if _, err := requireUUIDQuery(ctx, "project_id"); err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		server, err := requireStringQuery(ctx, "server")
+		if err != nil {
+			writeError(ctx, stdCtx, "MCP server name is required", perrors.NewErrInvalidRequest("MCP server name is required", err))
+			return
+		}
+
+		if err := svc.AgentConfig.AcceptMCPDrift(stdCtx, agentID, server); err != nil {
+			writeError(ctx, stdCtx, "Failed to accept MCP drift", perrors.NewErrInvalidRequest("Failed to accept MCP drift", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "MCP drift accepted", nil)
+	})
+
+	// List the tools a version of an agent exposes with the JSON schemas of their arguments, for the forms of
+	// manual tool calls and approvals. Defaults to version 0.
+	r.GET("/api/agent-server/agent-configs/{id}/tools", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		version := 0
+		if versionStr := string(ctx.QueryArgs().Peek("version")); versionStr != "" {
+			if version, err = strconv.Atoi(versionStr); err != nil {
+				writeError(ctx, stdCtx, "Invalid version format", perrors.NewErrInvalidRequest("Invalid version format", err))
+				return
+			}
+		}
+
+		config, err := svc.AgentConfig.GetByAgentIDAndVersion(stdCtx, projectID, agentID, version)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get agent config", perrors.NewErrInternalServerError("Failed to get agent config", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Agent tools retrieved successfully", runner.ToolSchemas(stdCtx, config))
+	})
+
+	// Call a tool of a version of an agent with the arguments supplied, outside of a run, e.g. to debug a tool. The
+	// guardrails, approval policy and quotas of the agent apply. Defaults to version 0.
+	r.POST("/api/agent-server/agent-configs/{id}/tools/{name}:invoke", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		var body ToolInvocationRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		arguments, err := body.arguments()
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid tool arguments", perrors.NewErrInvalidRequest("Invalid tool arguments", err))
+			return
+		}
+
+		project, err := svc.Project.GetByID(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get project", perrors.NewErrInternalServerError("Failed to get project", err))
+			return
+		}
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		config, err := svc.AgentConfig.GetByAgentIDAndVersion(stdCtx, projectID, agentID, body.Version)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get agent config", perrors.NewErrInternalServerError("Failed to get agent config", err))
+			return
+		}
+
+		namespace := body.Namespace
+		if namespace == "" {
+			namespace = "tool-invocation"
+		}
+
+		result, err := runner.InvokeTool(stdCtx, config, *project.DefaultKey, &agents.ToolInvocation{
+			Name:           ctx.UserValue("name").(string),
+			Arguments:      arguments,
+			Approved:       body.Approved,
+			Namespace:      namespace,
+			ConversationID: body.ConversationID,
+			RunContext:     runContextFromRequest(ctx, body.Context),
+		})
+		if errors.Is(err, agents.ErrToolNotFound) {
+			writeError(ctx, stdCtx, "Tool not found", perrors.New(perrors.ErrCodeNotFound, "Tool not found", err))
+			return
+		}
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to invoke tool", perrors.NewErrInternalServerError("Failed to invoke tool", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Tool invoked", result)
+	})
+
+	// List the overrides of the config of an agent per namespace
+	r.GET("/api/agent-server/agent-configs/{id}/namespace-overrides", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		overrides, err := svc.AgentConfig.ListNamespaceOverrides(stdCtx, projectID, agentID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list namespace overrides", perrors.NewErrInternalServerError("Failed to list namespace overrides", err))
+			return
+		}
+
+		writeOKConditional(ctx, stdCtx, "Namespace overrides retrieved successfully", overrides)
+	})
+
+	// Get the overrides of the config of an agent for a namespace
+	r.GET("/api/agent-server/agent-configs/{id}/namespace-overrides/{namespace}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		namespace, err := pathParam(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		override, err := svc.AgentConfig.GetNamespaceOverride(stdCtx, projectID, agentID, namespace)
+		if err != nil {
+			if errors.Is(err, agent_config.ErrNamespaceOverrideNotFound) {
+				writeError(ctx, stdCtx, "Namespace override not found", perrors.New(perrors.ErrCodeNotFound, "Namespace override not found", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to get namespace override", perrors.NewErrInternalServerError("Failed to get namespace override", err))
+			return
+		}
+
+		writeOKConditional(ctx, stdCtx, "Namespace override retrieved successfully", override)
+	})
+
+	// Create or replace the overrides of the config of an agent for a namespace
+	r.PUT("/api/agent-server/agent-configs/{id}/namespace-overrides/{namespace}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		namespace, err := pathParam(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		var body agent_config.ConfigOverrides
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		override, err := svc.AgentConfig.SetNamespaceOverride(stdCtx, projectID, agentID, namespace, &body)
+		if err != nil {
+			writeAgentConfigError(ctx, stdCtx, "Failed to save namespace override", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Namespace override saved successfully", override)
+	})
+
+	// Delete the overrides of the config of an agent for a namespace
+	r.DELETE("/api/agent-server/agent-configs/{id}/namespace-overrides/{namespace}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		namespace, err := pathParam(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		if err := svc.AgentConfig.DeleteNamespaceOverride(stdCtx, projectID, agentID, namespace); err != nil {
+			if errors.Is(err, agent_config.ErrNamespaceOverrideNotFound) {
+				writeError(ctx, stdCtx, "Namespace override not found", perrors.New(perrors.ErrCodeNotFound, "Namespace override not found", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to delete namespace override", perrors.NewErrInternalServerError("Failed to delete namespace override", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Namespace override deleted successfully", nil)
+	})
+
 	// Create alias by agent config ID
 	r.POST("/api/agent-server/agent-configs/{id}/aliases", func(ctx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(ctx)
@@ -494,7 +888,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Aliases retrieved successfully", aliases)
+		writeOKConditional(ctx, stdCtx, "Aliases retrieved successfully", aliases)
 	})
 
 	// List aliases by name (for backward compatibility)
@@ -518,7 +912,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Aliases retrieved successfully", aliases)
+		writeOKConditional(ctx, stdCtx, "Aliases retrieved successfully", aliases)
 	})
 
 	// Get alias by ID
@@ -542,7 +936,7 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Alias retrieved successfully", alias)
+		writeOKConditional(ctx, stdCtx, "Alias retrieved successfully", alias)
 	})
 
 	// Update alias
@@ -566,6 +960,20 @@ func RegisterAgentConfigRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
+		// Pointing the alias at a new version runs the eval suite of the agent, unless forced
+		if !req.Force {
+			existing, err := svc.AgentConfig.GetAlias(stdCtx, projectID, id)
+			if err != nil {
+				writeError(ctx, stdCtx, "Failed to get alias", perrors.NewErrInternalServerError("Failed to get alias", err))
+				return
+			}
+
+			if err := runner.gatePromotion(stdCtx, projectID, existing, &req); err != nil {
+				writePromotionError(ctx, stdCtx, err)
+				return
+			}
+		}
+
 		alias, err := svc.AgentConfig.UpdateAlias(stdCtx, projectID, id, &req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Failed to update alias", perrors.NewErrInternalServerError("Failed to update alias", err))
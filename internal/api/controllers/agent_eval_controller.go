@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/curaious/uno/internal/api/response"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/services/eval_suite"
+	"github.com/curaious/uno/pkg/agent-framework/eval"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterAgentEvalRoutes registers routes to manage the eval suite of an agent, which gates the promotion of its
+// aliases, and to run it
+func RegisterAgentEvalRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	r.GET("/api/agent-server/agent-configs/{id}/eval-suite", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		suite, err := svc.EvalSuite.Get(stdCtx, projectID, agentID)
+		if err != nil {
+			if errors.Is(err, eval_suite.ErrSuiteNotFound) {
+				writeError(ctx, stdCtx, "Eval suite not found", perrors.New(perrors.ErrCodeNotFound, "Eval suite not found", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to get eval suite", perrors.NewErrInternalServerError("Failed to get eval suite", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval suite retrieved successfully", suite)
+	})
+
+	// Create or replace the eval suite of an agent
+	r.PUT("/api/agent-server/agent-configs/{id}/eval-suite", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		var body eval_suite.SaveSuiteRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if _, err := svc.AgentConfig.GetByAgentIDAndVersion(stdCtx, projectID, agentID, 0); err != nil {
+			writeError(ctx, stdCtx, "Agent config not found", perrors.New(perrors.ErrCodeNotFound, "Agent config not found", err))
+			return
+		}
+
+		suite, err := svc.EvalSuite.Save(stdCtx, projectID, agentID, &body)
+		if err != nil {
+			var validationErr *eval_suite.ValidationError
+			if errors.As(err, &validationErr) {
+				writeError(ctx, stdCtx, "Invalid eval suite", perrors.NewErrInvalidRequest("Invalid eval suite", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to save eval suite", perrors.NewErrInternalServerError("Failed to save eval suite", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval suite saved successfully", suite)
+	})
+
+	r.DELETE("/api/agent-server/agent-configs/{id}/eval-suite", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		if err := svc.EvalSuite.Delete(stdCtx, projectID, agentID); err != nil {
+			if errors.Is(err, eval_suite.ErrSuiteNotFound) {
+				writeError(ctx, stdCtx, "Eval suite not found", perrors.New(perrors.ErrCodeNotFound, "Eval suite not found", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to delete eval suite", perrors.NewErrInternalServerError("Failed to delete eval suite", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval suite deleted successfully", nil)
+	})
+
+	// Run the eval suite on a version, compared with the baseline version when one is given
+	r.POST("/api/agent-server/agent-configs/{id}/eval-suite/run", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		var body eval_suite.RunSuiteRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		suite, err := svc.EvalSuite.Get(stdCtx, projectID, agentID)
+		if err != nil {
+			if errors.Is(err, eval_suite.ErrSuiteNotFound) {
+				writeError(ctx, stdCtx, "Eval suite not found", perrors.New(perrors.ErrCodeNotFound, "Eval suite not found", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to get eval suite", perrors.NewErrInternalServerError("Failed to get eval suite", err))
+			return
+		}
+
+		run, err := runner.runEvalSuite(stdCtx, suite, nil, body.Version, body.BaselineVersion)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to run eval suite", perrors.NewErrInvalidRequest("Failed to run eval suite", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval suite run successfully", run)
+	})
+
+	r.GET("/api/agent-server/agent-configs/{id}/eval-runs", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		runs, err := svc.EvalSuite.ListRuns(stdCtx, projectID, agentID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list eval runs", perrors.NewErrInternalServerError("Failed to list eval runs", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval runs retrieved successfully", runs)
+	})
+
+	r.GET("/api/agent-server/agent-configs/eval-runs/{run_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		runIDStr, err := pathParam(ctx, "run_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid eval run ID", perrors.NewErrInvalidRequest("Invalid eval run ID", err))
+			return
+		}
+
+		runID, err := uuid.Parse(runIDStr)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid eval run ID", perrors.NewErrInvalidRequest("Invalid eval run ID", err))
+			return
+		}
+
+		run, err := svc.EvalSuite.GetRun(stdCtx, projectID, runID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Eval run not found", perrors.New(perrors.ErrCodeNotFound, "Eval run not found", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Eval run retrieved successfully", run)
+	})
+}
+
+// writePromotionError returns a version failing the eval suite as a conflict, with the eval run
+func writePromotionError(ctx *fasthttp.RequestCtx, stdCtx context.Context, err error) {
+	var regressionErr *eval_suite.RegressionError
+	if errors.As(err, &regressionErr) {
+		response.NewResponse[any](stdCtx, "The version fails the eval suite, set force to promote it anyway", regressionErr.Run).
+			WithError(perrors.New(perrors.ErrCodeConflict, "The version fails the eval suite", err)).
+			Write(ctx)
+		return
+	}
+
+	writeError(ctx, stdCtx, "Failed to run eval suite", perrors.NewErrInternalServerError("Failed to run eval suite", err))
+}
+
+// gatePromotion runs the eval suite of the agent on the versions the update points the alias at, compared with the
+// version the alias points at. It returns a *eval_suite.RegressionError when a version fails the suite. Agents
+// without an eval suite are not gated.
+func (a *AgentRunner) gatePromotion(ctx context.Context, projectID uuid.UUID, alias *agent_config.AgentConfigAlias, req *agent_config.UpdateAliasRequest) error {
+	var promoted []int
+	for _, version := range []*int{req.Version1, req.Version2} {
+		if version == nil || *version == alias.Version1 || (alias.Version2 != nil && *version == *alias.Version2) {
+			continue
+		}
+		promoted = append(promoted, *version)
+	}
+	if len(promoted) == 0 {
+		return nil
+	}
+
+	suite, err := a.svc.EvalSuite.Get(ctx, projectID, alias.AgentID)
+	if err != nil {
+		if errors.Is(err, eval_suite.ErrSuiteNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	for _, version := range promoted {
+		run, err := a.runEvalSuite(ctx, suite, &alias.ID, version, &alias.Version1)
+		if err != nil {
+			return err
+		}
+		if run.Status == eval_suite.StatusFailed {
+			return &eval_suite.RegressionError{Run: run}
+		}
+	}
+
+	return nil
+}
+
+// runEvalSuite runs the cases of the suite on the version and on the baseline version, on the local runtime, and
+// records the scores
+func (a *AgentRunner) runEvalSuite(ctx context.Context, suite *eval_suite.Suite, aliasID *uuid.UUID, version int, baselineVersion *int) (*eval_suite.EvalRun, error) {
+	project, err := a.svc.Project.GetByID(ctx, suite.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get project: %w", err)
+	}
+	if project.DefaultKey == nil {
+		return nil, errors.New("project default key is required to run the eval suite")
+	}
+
+	candidate, err := a.testConfig(ctx, suite.ProjectID, suite.AgentID, version, "")
+	if err != nil {
+		return nil, fmt.Errorf("version %d: %w", version, err)
+	}
+
+	var baseline *agent_config.AgentConfig
+	if baselineVersion != nil && *baselineVersion != version {
+		baseline, err = a.testConfig(ctx, suite.ProjectID, suite.AgentID, *baselineVersion, "")
+		if err != nil {
+			return nil, fmt.Errorf("baseline version %d: %w", *baselineVersion, err)
+		}
+	} else {
+		baselineVersion = nil
+	}
+
+	results := make([]eval_suite.CaseResult, len(suite.Cases))
+	for idx, c := range suite.Cases {
+		result := eval_suite.CaseResult{Index: idx, Name: c.Name}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcome := eval.Outcome(*a.runTestOutcome(ctx, candidate, c.Input, c.Context, *project.DefaultKey))
+			result.Candidate = &outcome
+		}()
+
+		if baseline != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				outcome := eval.Outcome(*a.runTestOutcome(ctx, baseline, c.Input, c.Context, *project.DefaultKey))
+				result.Baseline = &outcome
+			}()
+		}
+		wg.Wait()
+
+		results[idx] = result
+	}
+
+	return a.svc.EvalSuite.Record(ctx, suite, aliasID, version, baselineVersion, results)
+}
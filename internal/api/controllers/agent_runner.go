@@ -0,0 +1,422 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/curaious/uno/internal/adapters"
+	"github.com/curaious/uno/internal/agent_builder/builder"
+	"github.com/curaious/uno/internal/agent_builder/restate_agent_builder"
+	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/services/environment"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/streaming"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/curaious/uno/pkg/sandbox"
+	"github.com/curaious/uno/pkg/sdk/runtime/restate_runtime"
+	"github.com/google/uuid"
+	restate "github.com/restatedev/sdk-go"
+	"github.com/restatedev/sdk-go/ingress"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
+	"go.temporal.io/sdk/client"
+)
+
+// AgentRunner starts agent runs on the runtime configured for the agent and streams back their chunks
+type AgentRunner struct {
+	svc            *services.Services
+	llmGateway     *gateway.LLMGateway
+	broker         core.StreamBroker
+	sandboxManager sandbox.Manager
+	temporalClient client.Client
+	restateClient  *ingress.Client
+	restateURL     string
+	configCache    *adapters.ConfigCache
+	events         core.RunEventStore
+}
+
+func NewAgentRunner(svc *services.Services, llmGateway *gateway.LLMGateway, conf *config.Config, broker core.StreamBroker, sandboxManager sandbox.Manager, configCache *adapters.ConfigCache, events core.RunEventStore) *AgentRunner {
+	runner := &AgentRunner{
+		svc:            svc,
+		llmGateway:     llmGateway,
+		broker:         broker,
+		sandboxManager: sandboxManager,
+		configCache:    configCache,
+		events:         events,
+	}
+
+	if conf.TEMPORAL_SERVER_HOST_PORT != "" {
+		runner.temporalClient = getTemporalClient(conf)
+	}
+
+	if conf.RESTATE_SERVER_ENDPOINT != "" {
+		runner.restateURL = conf.RESTATE_SERVER_ENDPOINT
+		runner.restateClient = ingress.NewClient(conf.RESTATE_SERVER_ENDPOINT, restate.WithHttpClient(&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}))
+	}
+
+	return runner
+}
+
+// requestEnvironment returns the environment the agent of the request is resolved in, set with the
+// x-uno-environment header
+func requestEnvironment(reqCtx *fasthttp.RequestCtx) string {
+	return strings.TrimSpace(string(reqCtx.Request.Header.Peek("x-uno-environment")))
+}
+
+// ResolveAgentConfig resolves an agent reference of the form "agent_id", "agent_id:version" or "agent_id:alias".
+// Configs and aliases are read through the config cache when one is set.
+// With an environment, a plain "agent_id" resolves to the version deployed to that environment, and a prompt
+// referenced by the config is replaced with the prompt version deployed there, if any.
+func (a *AgentRunner) ResolveAgentConfig(ctx context.Context, projectID uuid.UUID, agentRef string, env string) (*agent_config.AgentConfig, error) {
+	version := 0
+	frag := strings.Split(agentRef, ":")
+
+	agentID, err := uuid.Parse(frag[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(frag) == 1 && env != "" {
+		version, err = a.svc.Environment.ResolveVersion(ctx, projectID, env, environment.KindAgent, agentID)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s in environment '%s': %w", agentID, env, err)
+		}
+	}
+
+	if len(frag) > 1 {
+		v, err := strconv.Atoi(frag[1])
+		if err != nil {
+			alias, aliasErr := a.getAlias(ctx, projectID, agentID, frag[1])
+			if aliasErr != nil {
+				return nil, aliasErr
+			}
+			v = alias.PickVersion()
+		}
+		version = v
+	}
+
+	config, err := a.getAgentConfig(ctx, projectID, agentID, version)
+	if err != nil || env == "" {
+		return config, err
+	}
+
+	if err := a.applyEnvironmentPrompts(ctx, projectID, env, config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (a *AgentRunner) getAgentConfig(ctx context.Context, projectID, agentID uuid.UUID, version int) (*agent_config.AgentConfig, error) {
+	if a.configCache == nil {
+		return a.svc.AgentConfig.GetByAgentIDAndVersion(ctx, projectID, agentID, version)
+	}
+
+	key := fmt.Sprintf("%s:%s:%d", projectID, agentID, version)
+	return adapters.GetOrLoad(ctx, a.configCache, key, func(ctx context.Context) (*agent_config.AgentConfig, error) {
+		return a.svc.AgentConfig.GetByAgentIDAndVersion(ctx, projectID, agentID, version)
+	})
+}
+
+// ToolSchemas returns the tools the agent of the config exposes to its model, with the JSON schemas of their arguments
+func (a *AgentRunner) ToolSchemas(ctx context.Context, agentConfig *agent_config.AgentConfig) *builder.ToolSchemas {
+	return builder.NewAgentBuilder(a.svc, a.llmGateway, a.broker, a.sandboxManager).ListToolSchemas(ctx, agentConfig)
+}
+
+// InvokeTool calls a tool of the agent of the config outside of a run, e.g. to debug it
+func (a *AgentRunner) InvokeTool(ctx context.Context, agentConfig *agent_config.AgentConfig, key string, in *agents.ToolInvocation) (*agents.ToolInvocationResult, error) {
+	return builder.NewAgentBuilder(a.svc, a.llmGateway, a.broker, a.sandboxManager).InvokeTool(ctx, agentConfig, key, in)
+}
+
+// applyEnvironmentPrompts pins the prompts referenced by the config to the versions deployed to the environment.
+// Prompts that were never promoted to the environment keep the version set in the config.
+func (a *AgentRunner) applyEnvironmentPrompts(ctx context.Context, projectID uuid.UUID, env string, config *agent_config.AgentConfig) error {
+	prompts := []*agent_config.PromptConfig{config.Config.Prompt}
+	if history := config.Config.History; history != nil && history.Summarizer != nil {
+		prompts = append(prompts, history.Summarizer.LLMSummarizerPrompt)
+	}
+
+	for _, prompt := range prompts {
+		if prompt == nil || prompt.PromptID == nil || *prompt.PromptID == uuid.Nil {
+			continue
+		}
+
+		version, err := a.svc.Environment.ResolveVersion(ctx, projectID, env, environment.KindPrompt, *prompt.PromptID)
+		if errors.Is(err, environment.ErrNotDeployed) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		prompt.Version = &version
+	}
+
+	return nil
+}
+
+// getAlias returns the alias itself rather than a resolved version, so that traffic is still split per request
+func (a *AgentRunner) getAlias(ctx context.Context, projectID, agentID uuid.UUID, name string) (*agent_config.AgentConfigAlias, error) {
+	if a.configCache == nil {
+		return a.svc.AgentConfig.GetAliasByAgentID(ctx, projectID, agentID, name)
+	}
+
+	key := fmt.Sprintf("%s:%s:alias:%s", projectID, agentID, name)
+	return adapters.GetOrLoad(ctx, a.configCache, key, func(ctx context.Context) (*agent_config.AgentConfigAlias, error) {
+		return a.svc.AgentConfig.GetAliasByAgentID(ctx, projectID, agentID, name)
+	})
+}
+
+// Start executes the agent in the background and returns the stream of its chunks.
+// The stream is subscribed before the run starts, so no chunks are missed.
+// Runs are refused while an MCP server that blocks on breaking drift has breaking changes.
+func (a *AgentRunner) Start(ctx context.Context, span trace.Span, agentConfig *agent_config.AgentConfig, in *agents.AgentInput, key string) (<-chan *responses.ResponseChunk, error) {
+	if err := a.svc.AgentConfig.CheckRunnable(ctx, agentConfig); err != nil {
+		return nil, err
+	}
+
+	switch *agentConfig.Config.Runtime {
+	case "Restate":
+		if a.restateClient == nil {
+			return nil, errors.New("restate runtime is not enabled")
+		}
+
+		runID := uuid.New().String()
+		var sessionKey string
+		if agentConfig.Config.ConversationSessions {
+			var err error
+			if sessionKey, err = a.sessionKey(ctx, agentConfig, in, runID); err != nil {
+				return nil, err
+			}
+		}
+
+		stream, err := a.broker.Subscribe(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+
+		if sessionKey != "" {
+			go func() {
+				// The idempotency key keeps a retried request from executing the message twice
+				_, err := ingress.Object[*restate_agent_builder.SessionInput, *agents.AgentOutput](
+					a.restateClient,
+					"ConversationSession",
+					sessionKey,
+					"Converse",
+				).Request(ctx, &restate_agent_builder.SessionInput{
+					WorkflowInput: restate_agent_builder.WorkflowInput{
+						AgentConfig: agentConfig,
+						Input:       in,
+						Key:         key,
+					},
+					StreamID: runID,
+				}, restate.WithIdempotencyKey(runID))
+				if err != nil {
+					RecordSpanError(span, err)
+				}
+			}()
+
+			return stream, nil
+		}
+
+		go func() {
+			_, err := ingress.Workflow[*restate_agent_builder.WorkflowInput, *agents.AgentOutput](
+				a.restateClient,
+				"AgentBuilder",
+				runID,
+				"BuildAndExecuteAgent",
+			).Request(ctx, &restate_agent_builder.WorkflowInput{
+				AgentConfig: agentConfig,
+				Input:       in,
+				Key:         key,
+			})
+			if err != nil {
+				RecordSpanError(span, err)
+			}
+		}()
+
+		return stream, nil
+
+	case "Temporal":
+		if a.temporalClient == nil {
+			return nil, errors.New("temporal runtime is not enabled")
+		}
+
+		runID := uuid.New().String()
+		stream, err := a.broker.Subscribe(ctx, runID)
+		if err != nil {
+			return nil, err
+		}
+
+		go func() {
+			run, err := a.temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+				ID:        runID,
+				TaskQueue: "AgentBuilder",
+			}, "AgentBuilder", agentConfig, in, &key)
+			if err != nil {
+				RecordSpanError(span, err)
+				return
+			}
+
+			var output agents.AgentOutput
+			if err := run.Get(ctx, &output); err != nil {
+				RecordSpanError(span, err)
+			}
+		}()
+
+		return stream, nil
+
+	default:
+		b := streaming.NewMemoryStreamBroker()
+		stream, err := b.Subscribe(ctx, "default")
+		if err != nil {
+			return nil, err
+		}
+
+		in.Callback = func(chunk *responses.ResponseChunk) {
+			b.Publish(ctx, "default", chunk)
+		}
+
+		go func() {
+			defer b.Close(ctx, "default")
+			_, err := builder.NewAgentBuilder(a.svc, a.llmGateway, b, a.sandboxManager).BuildAndExecuteAgent(ctx, agentConfig, in, key)
+			if err != nil {
+				RecordSpanError(span, err)
+			}
+		}()
+
+		return stream, nil
+	}
+}
+
+// sessionKey returns the key of the Restate conversation session of a message, the conversation of the message it
+// continues. The first message of a conversation has a session of its own.
+func (a *AgentRunner) sessionKey(ctx context.Context, agentConfig *agent_config.AgentConfig, in *agents.AgentInput, runID string) (string, error) {
+	if in.PreviousMessageID == "" {
+		return runID, nil
+	}
+
+	message, err := a.svc.Conversation.GetMessage(ctx, agentConfig.ProjectID, in.Namespace, in.PreviousMessageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous message: %w", err)
+	}
+
+	return fmt.Sprintf("%s:%s:%s", agentConfig.ProjectID, in.Namespace, message.ConversationID), nil
+}
+
+// ResolveApproval gives the decision on the tool calls of a run that the Restate runtime keeps waiting for approval,
+// the run resumes where it stopped
+func (a *AgentRunner) ResolveApproval(ctx context.Context, approvalID string, approval *responses.FunctionCallApprovalResponseMessage) error {
+	if a.restateClient == nil {
+		return errors.New("restate runtime is not enabled")
+	}
+
+	return restate_runtime.ResolveApproval(ctx, a.restateURL, approvalID, approval)
+}
+
+// Broadcasts reports whether runs can be streamed to more than one subscriber
+func (a *AgentRunner) Broadcasts() bool {
+	return a.events != nil
+}
+
+// StartBroadcast is Start for runs that more than one subscriber may attach to. The chunks of the run are
+// recorded in the run event store under a new stream ID, which is returned along with the events of the stream.
+// The run keeps being recorded when the caller stops reading, so that other subscribers still receive it.
+func (a *AgentRunner) StartBroadcast(ctx context.Context, span trace.Span, agentConfig *agent_config.AgentConfig, in *agents.AgentInput, key string) (string, <-chan *core.RunEvent, error) {
+	streamID := uuid.NewString()
+	name := runStreamName(agentConfig.ProjectID, streamID)
+	if err := a.events.Open(ctx, name); err != nil {
+		return "", nil, err
+	}
+
+	stream, err := a.Start(ctx, span, agentConfig, in, key)
+	if err != nil {
+		_ = a.events.Close(ctx, name)
+		return "", nil, err
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		defer a.events.Close(ctx, name)
+
+		runID := ""
+		for chunk := range stream {
+			if id := core.RunIDOf(chunk); id != "" {
+				runID = id
+			}
+			if _, err := a.events.Append(ctx, name, runID, chunk); err != nil {
+				RecordSpanError(span, err)
+				return
+			}
+
+			// A run kept waiting for approval by a durable runtime goes on once approved
+			if chunk.OfRunCompleted != nil || chunk.OfRunFailed != nil || (chunk.OfRunPaused != nil && chunk.OfRunPaused.RunState.ApprovalID == "") {
+				return
+			}
+		}
+	}()
+
+	events, err := a.events.Read(ctx, name, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	return streamID, events, nil
+}
+
+// Subscribe attaches to the stream of a run started with StartBroadcast, from the given offset
+func (a *AgentRunner) Subscribe(ctx context.Context, projectID uuid.UUID, streamID string, after string) (<-chan *core.RunEvent, error) {
+	if a.events == nil {
+		return nil, errors.New("run streams are not enabled")
+	}
+	return a.events.Read(ctx, runStreamName(projectID, streamID), after)
+}
+
+// PublishThreadEvent records a chunk that isn't part of a run in the stream of the thread, e.g. the takeover
+// events. It does nothing without a run event store.
+func (a *AgentRunner) PublishThreadEvent(ctx context.Context, projectID uuid.UUID, threadID string, chunk *responses.ResponseChunk) error {
+	if a.events == nil {
+		return nil
+	}
+
+	name := threadStreamName(projectID, threadID)
+	if err := a.events.Open(ctx, name); err != nil {
+		return err
+	}
+
+	_, err := a.events.Append(ctx, name, "", chunk)
+	return err
+}
+
+// SubscribeThread attaches to the stream of the events of a thread, from the given offset. The stream is opened
+// if nothing was published yet, so that clients can attach before the first event.
+func (a *AgentRunner) SubscribeThread(ctx context.Context, projectID uuid.UUID, threadID string, after string) (<-chan *core.RunEvent, error) {
+	if a.events == nil {
+		return nil, errors.New("run streams are not enabled")
+	}
+
+	name := threadStreamName(projectID, threadID)
+	if err := a.events.Open(ctx, name); err != nil {
+		return nil, err
+	}
+
+	return a.events.Read(ctx, name, after)
+}
+
+// threadStreamName is the name of the stream of the events of a thread, it can't clash with a run stream ID
+func threadStreamName(projectID uuid.UUID, threadID string) string {
+	return runStreamName(projectID, "thread:"+threadID)
+}
+
+// runStreamName scopes the streams to their project, so that a stream can't be read through another project
+func runStreamName(projectID uuid.UUID, streamID string) string {
+	return projectID.String() + ":" + streamID
+}
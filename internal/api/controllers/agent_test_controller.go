@@ -0,0 +1,432 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/agent_builder/builder"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/services/environment"
+	"github.com/curaious/uno/internal/services/test_run"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/streaming"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// testSampleTimeout bounds the run of a single sample on a single version
+const testSampleTimeout = 5 * time.Minute
+
+// RegisterAgentTestRoutes registers routes to run sample inputs against an agent version and to read the reports
+func RegisterAgentTestRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	// Run sample messages against a version and compare with the live version. Results are streamed as SSE,
+	// one "test.result" event per sample, followed by a "test.completed" event with the stored report.
+	r.POST("/api/agent-server/agent-configs/{id}/test", func(reqCtx *fasthttp.RequestCtx) {
+		ctx := requestContext(reqCtx)
+		projectID, err := requireUUIDQuery(reqCtx, "project_id")
+		if err != nil {
+			writeError(reqCtx, ctx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(reqCtx)
+		if err != nil {
+			writeError(reqCtx, ctx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		var body test_run.CreateTestRunRequest
+		if err := parseBody(reqCtx, &body); err != nil {
+			writeError(reqCtx, ctx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if len(body.Messages) == 0 {
+			err := errors.New("at least one sample message is required")
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		project, err := svc.Project.GetByID(ctx, projectID)
+		if err != nil {
+			writeError(reqCtx, ctx, "unable to get project", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		candidate, err := runner.testConfig(ctx, projectID, agentID, body.Version, body.Environment)
+		if err != nil {
+			writeError(reqCtx, ctx, "Failed to get agent config", perrors.NewErrInvalidRequest("Failed to get agent config", err))
+			return
+		}
+
+		live, err := runner.liveTestConfig(ctx, projectID, agentID, body.Version, body.Environment)
+		if err != nil {
+			writeError(reqCtx, ctx, "Failed to get live agent config", perrors.NewErrInvalidRequest("Failed to get live agent config", err))
+			return
+		}
+
+		var liveVersion *int
+		if live != nil {
+			liveVersion = &live.Version
+		}
+
+		run, err := svc.TestRun.Start(ctx, projectID, agentID, candidate.Version, liveVersion, len(body.Messages))
+		if err != nil {
+			writeError(reqCtx, ctx, "Failed to start test run", perrors.NewErrInternalServerError("Failed to start test run", err))
+			return
+		}
+
+		reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
+		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+		reqCtx.SetStatusCode(fasthttp.StatusOK)
+		reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			write := func(event string, data any) {
+				buf, _ := json.Marshal(data)
+				_, _ = fmt.Fprintf(w, "event: %s\n", event)
+				_, _ = fmt.Fprintf(w, "data: %s\n\n", buf)
+				_ = w.Flush()
+			}
+
+			write("test.started", run)
+
+			results := make([]test_run.SampleResult, 0, len(body.Messages))
+			for idx, msg := range body.Messages {
+				result := runner.runTestSample(ctx, candidate, live, msg, body.RunContext, *project.DefaultKey)
+				result.Index = idx
+				results = append(results, result)
+				write("test.result", result)
+			}
+
+			completed, err := svc.TestRun.Complete(ctx, run, results, nil)
+			if err != nil {
+				write("error", map[string]string{"error": err.Error()})
+				return
+			}
+			write("test.completed", completed)
+		})
+	})
+
+	// Show how the request sent to the provider for a message differs between two versions, by rendering it as a
+	// dry run on both. With "execute", the message is also run on both versions to compare their outputs.
+	r.POST("/api/agent-server/agent-configs/{id}/diff", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		var body test_run.DiffRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if body.Message.OfEasyInput == nil && body.Message.OfInputMessage == nil {
+			err := errors.New("an input message is required")
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		project, err := svc.Project.GetByID(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "unable to get project", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		base, err := runner.testConfig(stdCtx, projectID, agentID, body.BaseVersion, body.Environment)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get base agent config", perrors.NewErrInvalidRequest("Failed to get base agent config", err))
+			return
+		}
+
+		candidate, err := runner.testConfig(stdCtx, projectID, agentID, body.Version, body.Environment)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get agent config", perrors.NewErrInvalidRequest("Failed to get agent config", err))
+			return
+		}
+
+		diff := runner.diffVersions(stdCtx, base, candidate, body, *project.DefaultKey)
+		if err := diff.Diff(); err != nil {
+			writeError(ctx, stdCtx, "Failed to diff the requests", perrors.NewErrInternalServerError("Failed to diff the requests", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Requests compared successfully", diff)
+	})
+
+	r.GET("/api/agent-server/agent-configs/{id}/test-runs", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentID, err := agentIDParam(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		runs, err := svc.TestRun.ListByAgent(stdCtx, projectID, agentID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list test runs", perrors.NewErrInternalServerError("Failed to list test runs", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Test runs retrieved successfully", runs)
+	})
+
+	r.GET("/api/agent-server/agent-configs/test-runs/{run_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		runIDStr, err := pathParam(ctx, "run_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid test run ID", perrors.NewErrInvalidRequest("Invalid test run ID", err))
+			return
+		}
+
+		runID, err := uuid.Parse(runIDStr)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid test run ID", perrors.NewErrInvalidRequest("Invalid test run ID", err))
+			return
+		}
+
+		run, err := svc.TestRun.Get(stdCtx, projectID, runID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Test run not found", perrors.New(perrors.ErrCodeNotFound, "Test run not found", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Test run retrieved successfully", run)
+	})
+}
+
+func agentIDParam(ctx *fasthttp.RequestCtx) (uuid.UUID, error) {
+	idRaw, err := pathParam(ctx, "id")
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return uuid.Parse(idRaw)
+}
+
+// testConfig loads a version of the agent for a test run. History is disabled, so that the samples
+// are not written to any namespace, and prompts are pinned to the environment when one is set.
+func (a *AgentRunner) testConfig(ctx context.Context, projectID, agentID uuid.UUID, version int, env string) (*agent_config.AgentConfig, error) {
+	config, err := a.svc.AgentConfig.GetByAgentIDAndVersion(ctx, projectID, agentID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Config.History = nil
+
+	if env != "" {
+		if err := a.applyEnvironmentPrompts(ctx, projectID, env, config); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// liveTestConfig loads the version the test run is compared with: the version deployed to the environment
+// when one is set, or else the latest published version. It returns nil when there is no other version.
+func (a *AgentRunner) liveTestConfig(ctx context.Context, projectID, agentID uuid.UUID, candidateVersion int, env string) (*agent_config.AgentConfig, error) {
+	liveVersion := 0
+	if env != "" {
+		version, err := a.svc.Environment.ResolveVersion(ctx, projectID, env, environment.KindAgent, agentID)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s in environment '%s': %w", agentID, env, err)
+		}
+		liveVersion = version
+	} else {
+		versions, err := a.svc.AgentConfig.ListVersions(ctx, projectID, agentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			liveVersion = max(liveVersion, v.Version)
+		}
+	}
+
+	if liveVersion == 0 || liveVersion == candidateVersion {
+		return nil, nil
+	}
+
+	return a.testConfig(ctx, projectID, agentID, liveVersion, env)
+}
+
+// runTestSample runs the sample on the candidate and the live version concurrently
+func (a *AgentRunner) runTestSample(ctx context.Context, candidate, live *agent_config.AgentConfig, msg responses.InputMessageUnion, runContext map[string]any, key string) test_run.SampleResult {
+	result := test_run.SampleResult{Input: msg}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result.Candidate = a.runTestOutcome(ctx, candidate, msg, runContext, key)
+	}()
+
+	if live != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Live = a.runTestOutcome(ctx, live, msg, runContext, key)
+		}()
+	}
+	wg.Wait()
+
+	test_run.Compare(&result)
+	return result
+}
+
+// diffVersions renders the request of the message on both versions, and runs it on both when asked to
+func (a *AgentRunner) diffVersions(ctx context.Context, base, candidate *agent_config.AgentConfig, body test_run.DiffRequest, key string) *test_run.RequestDiff {
+	diff := &test_run.RequestDiff{
+		Input:     body.Message,
+		Base:      a.renderRequest(ctx, base, body.Message, body.RunContext, key),
+		Candidate: a.renderRequest(ctx, candidate, body.Message, body.RunContext, key),
+	}
+
+	if body.Execute {
+		diff.Outputs = &test_run.OutputComparison{}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			diff.Outputs.Base = a.runTestOutcome(ctx, base, body.Message, body.RunContext, key)
+		}()
+		go func() {
+			defer wg.Done()
+			diff.Outputs.Candidate = a.runTestOutcome(ctx, candidate, body.Message, body.RunContext, key)
+		}()
+		wg.Wait()
+
+		test_run.CompareOutputs(diff.Outputs)
+	}
+
+	return diff
+}
+
+// renderRequest returns the request the version sends to the provider for the message, without sending it
+func (a *AgentRunner) renderRequest(ctx context.Context, config *agent_config.AgentConfig, msg responses.InputMessageUnion, runContext map[string]any, key string) *test_run.RenderedRequest {
+	ctx, cancel := context.WithTimeout(ctx, testSampleTimeout)
+	defer cancel()
+
+	rendered := &test_run.RenderedRequest{Version: config.Version}
+
+	in := &agents.AgentInput{
+		Namespace:  "test-run",
+		Messages:   []responses.InputMessageUnion{msg},
+		RunContext: map[string]any{"Env": utils.EnvironmentVariables(), "Context": runContext, "Header": map[string]string{}},
+		DryRun:     true,
+	}
+
+	out, err := builder.NewAgentBuilder(a.svc, a.llmGateway, streaming.NewMemoryStreamBroker(), a.sandboxManager).BuildAndExecuteAgent(ctx, config, in, key)
+	switch {
+	case err != nil:
+		rendered.Error = err.Error()
+	case out.DryRun == nil:
+		rendered.Error = "the agent did not call the LLM"
+	default:
+		rendered.DryRun = out.DryRun
+	}
+
+	return rendered
+}
+
+// runTestOutcome runs a sample on the local runtime, regardless of the runtime configured for the agent
+func (a *AgentRunner) runTestOutcome(ctx context.Context, config *agent_config.AgentConfig, msg responses.InputMessageUnion, runContext map[string]any, key string) *test_run.Outcome {
+	ctx, cancel := context.WithTimeout(ctx, testSampleTimeout)
+	defer cancel()
+
+	outcome := &test_run.Outcome{Version: config.Version}
+
+	var mu sync.Mutex
+	in := &agents.AgentInput{
+		Namespace:  "test-run",
+		Messages:   []responses.InputMessageUnion{msg},
+		RunContext: map[string]any{"Env": utils.EnvironmentVariables(), "Context": runContext, "Header": map[string]string{}},
+		Callback: func(chunk *responses.ResponseChunk) {
+			if chunk.OfResponseCompleted == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			usage := chunk.OfResponseCompleted.Response.Usage
+			if outcome.Usage == nil {
+				outcome.Usage = &responses.Usage{}
+			}
+			outcome.Usage.InputTokens += usage.InputTokens
+			outcome.Usage.OutputTokens += usage.OutputTokens
+			outcome.Usage.TotalTokens += usage.TotalTokens
+		},
+	}
+
+	start := time.Now()
+	out, err := builder.NewAgentBuilder(a.svc, a.llmGateway, streaming.NewMemoryStreamBroker(), a.sandboxManager).BuildAndExecuteAgent(ctx, config, in, key)
+	outcome.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		outcome.Status = "error"
+		outcome.Error = err.Error()
+		return outcome
+	}
+
+	outcome.Status = string(out.Status)
+	outcome.Output = outputText(out.Output)
+	return outcome
+}
+
+// outputText joins the text of the assistant messages of a run
+func outputText(messages []responses.InputMessageUnion) string {
+	var texts []string
+	for _, m := range messages {
+		if m.OfOutputMessage == nil {
+			continue
+		}
+		for _, c := range m.OfOutputMessage.Content {
+			if c.OfOutputText != nil {
+				texts = append(texts, c.OfOutputText.Text)
+			}
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/analytics"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterAnalyticsRoutes registers run analytics routes
+func RegisterAnalyticsRoutes(r *router.Router, svc *services.Services) {
+	// Aggregates per agent/namespace and hour or day over a required time range
+	r.GET("/api/agent-server/analytics", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		startTime, endTime, err := requireTimeRange(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid time range", perrors.NewErrInvalidRequest("Invalid time range", err))
+			return
+		}
+
+		report, err := svc.Analytics.GetAnalytics(stdCtx, &analytics.AnalyticsQuery{
+			ProjectID: projectID,
+			Namespace: string(ctx.QueryArgs().Peek("namespace")),
+			AgentName: string(ctx.QueryArgs().Peek("agent_name")),
+			StartTime: startTime,
+			EndTime:   endTime,
+			Interval:  string(ctx.QueryArgs().Peek("interval")),
+		})
+		if errors.Is(err, analytics.ErrInvalidQuery) {
+			writeError(ctx, stdCtx, "Invalid analytics query", perrors.NewErrInvalidRequest("Invalid analytics query", err))
+			return
+		}
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get analytics", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "OK", report)
+	})
+}
+
+// requireTimeRange parses the start_time and end_time query parameters, both RFC 3339 timestamps
+func requireTimeRange(ctx *fasthttp.RequestCtx) (time.Time, time.Time, error) {
+	var times [2]time.Time
+	for i, name := range []string{"start_time", "end_time"} {
+		value := string(ctx.QueryArgs().Peek(name))
+		if value == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("%s is required", name)
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		times[i] = t
+	}
+
+	if !times[0].Before(times[1]) {
+		return time.Time{}, time.Time{}, errors.New("start_time must be before end_time")
+	}
+
+	return times[0], times[1], nil
+}
+
+// RegisterToolUsageRoutes registers the route reading the counters of the tool quotas
+func RegisterToolUsageRoutes(r *router.Router, svc *services.Services) {
+	// Counters of a conversation, of a user, or of a namespace for the runs without a user_id in their context
+	r.GET("/api/agent-server/tool-usage", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var scopeKey string
+		switch args := ctx.QueryArgs(); {
+		case args.Has("conversation_id"):
+			scopeKey = "conversation:" + string(args.Peek("conversation_id"))
+		case args.Has("user_id"):
+			scopeKey = "user:" + string(args.Peek("user_id"))
+		case args.Has("namespace"):
+			scopeKey = "namespace:" + string(args.Peek("namespace"))
+		default:
+			err = errors.New("one of conversation_id, user_id or namespace is required")
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		usages, err := svc.Usage.ListToolUsage(stdCtx, projectID, scopeKey)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get tool usage", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "OK", usages)
+	})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterApprovalRoutes registers the route giving the approval of the tool calls of the runs that the Restate
+// runtime keeps waiting, for the agents with durable approvals. The run resumes in the workflow where it stopped,
+// its chunks are streamed on the stream of the converse request that started it.
+func RegisterApprovalRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	r.POST("/api/agent-server/messages/{message_id}/approval", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		messageID, err := pathParam(ctx, "message_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Message ID is required", perrors.NewErrInvalidRequest("Message ID is required", err))
+			return
+		}
+
+		var body responses.FunctionCallApprovalResponseMessage
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		message, err := svc.Conversation.GetMessage(stdCtx, projectID, namespace, messageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(ctx, stdCtx, "Message not found", perrors.New(perrors.ErrCodeNotFound, "Message not found", err))
+			return
+		}
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get message", perrors.NewErrInternalServerError("Failed to get message", err))
+			return
+		}
+
+		runState := core.LoadRunStateFromMeta(message.Meta)
+		if runState == nil || !runState.IsPaused() || runState.ApprovalID == "" {
+			err := errors.New("the run of the message isn't waiting for a durable approval")
+			writeError(ctx, stdCtx, err.Error(), perrors.New(perrors.ErrCodeConflict, err.Error(), err))
+			return
+		}
+
+		if err := checkApproval(runState.PendingToolCalls, &body); err != nil {
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		if err := runner.ResolveApproval(stdCtx, runState.ApprovalID, &body); err != nil {
+			writeError(ctx, stdCtx, "Failed to resolve approval", perrors.NewErrInternalServerError("Failed to resolve approval", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Approval given", &body)
+	})
+}
+
+// checkApproval checks that the approval decides on every pending tool call of the run, and on nothing else
+func checkApproval(pending []responses.FunctionCallMessage, approval *responses.FunctionCallApprovalResponseMessage) error {
+	decided := map[string]bool{}
+	for _, callID := range slices.Concat(approval.ApprovedCallIds, approval.RejectedCallIds) {
+		if decided[callID] {
+			return fmt.Errorf("tool call %s is decided more than once", callID)
+		}
+		decided[callID] = true
+	}
+
+	for _, toolCall := range pending {
+		if !decided[toolCall.CallID] {
+			return fmt.Errorf("tool call %s is neither approved nor rejected", toolCall.CallID)
+		}
+		delete(decided, toolCall.CallID)
+	}
+
+	for callID := range decided {
+		return fmt.Errorf("tool call %s isn't pending approval", callID)
+	}
+
+	return nil
+}
@@ -0,0 +1,584 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RegisterAssistantsRoutes exposes an OpenAI Assistants API compatible surface.
+// A thread maps to a conversation, a message to a persisted message and a run to an agent run, with
+// assistant_id referring to an agent ("agent_id", "agent_id:version" or "agent_id:alias").
+// Like the rest of the API, the project_id and namespace query parameters are required.
+func RegisterAssistantsRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	// Runs are only persisted once they complete or pause, so track the runs started by this instance
+	// to report them as in progress in the meantime
+	activeRuns := &sync.Map{}
+
+	// Create thread
+	r.POST("/v1/threads", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, ok := assistantsScope(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		var body AssistantsThreadRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		conv, err := svc.Conversation.CreateConversation(stdCtx, projectID, namespace, "New Conversation")
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to create thread", perrors.NewErrInternalServerError("Failed to create thread", err))
+			return
+		}
+
+		for _, m := range body.Messages {
+			if _, err := appendAssistantsMessage(stdCtx, svc, projectID, namespace, conv.ConversationID, m); err != nil {
+				writeError(ctx, stdCtx, "Failed to add message", perrors.NewErrInvalidRequest("Failed to add message", err))
+				return
+			}
+		}
+
+		metadata := body.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+
+		writeJSON(ctx, AssistantsThread{
+			ID:        conv.ConversationID,
+			Object:    "thread",
+			CreatedAt: conv.CreatedAt.Unix(),
+			Metadata:  metadata,
+		})
+	})
+
+	// Retrieve thread
+	r.GET("/v1/threads/{thread_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, ok := assistantsScope(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		conv, ok := assistantsThread(ctx, stdCtx, svc, projectID, namespace)
+		if !ok {
+			return
+		}
+
+		writeJSON(ctx, AssistantsThread{
+			ID:        conv.ConversationID,
+			Object:    "thread",
+			CreatedAt: conv.CreatedAt.Unix(),
+			Metadata:  map[string]any{},
+		})
+	})
+
+	// Create message
+	r.POST("/v1/threads/{thread_id}/messages", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, ok := assistantsScope(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		conv, ok := assistantsThread(ctx, stdCtx, svc, projectID, namespace)
+		if !ok {
+			return
+		}
+
+		var body AssistantsMessageRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		msg, err := appendAssistantsMessage(stdCtx, svc, projectID, namespace, conv.ConversationID, body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to add message", perrors.NewErrInvalidRequest("Failed to add message", err))
+			return
+		}
+
+		writeJSON(ctx, msg)
+	})
+
+	// List messages
+	r.GET("/v1/threads/{thread_id}/messages", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, ok := assistantsScope(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		conv, ok := assistantsThread(ctx, stdCtx, svc, projectID, namespace)
+		if !ok {
+			return
+		}
+
+		list := AssistantsMessageList{Object: "list", Data: []AssistantsMessage{}}
+
+		thread, err := svc.Conversation.GetLatestThread(stdCtx, projectID, namespace, conv.ConversationID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get thread", perrors.NewErrInternalServerError("Failed to get thread", err))
+			return
+		}
+
+		if thread != nil {
+			rows, err := svc.Conversation.ListMessages(stdCtx, projectID, namespace, thread.ThreadID)
+			if err != nil {
+				writeError(ctx, stdCtx, "Failed to list messages", perrors.NewErrInternalServerError("Failed to list messages", err))
+				return
+			}
+			for _, row := range rows {
+				list.Data = append(list.Data, toAssistantsMessages(conv.ConversationID, row)...)
+			}
+		}
+
+		// Newest first unless order=asc, as in the Assistants API
+		if string(ctx.QueryArgs().Peek("order")) != "asc" {
+			slices.Reverse(list.Data)
+		}
+
+		if limit := ctx.QueryArgs().GetUintOrZero("limit"); limit > 0 && limit < len(list.Data) {
+			list.Data = list.Data[:limit]
+			list.HasMore = true
+		}
+
+		if len(list.Data) > 0 {
+			list.FirstID = utils.Ptr(list.Data[0].ID)
+			list.LastID = utils.Ptr(list.Data[len(list.Data)-1].ID)
+		}
+
+		writeJSON(ctx, list)
+	})
+
+	// Create run
+	r.POST("/v1/threads/{thread_id}/runs", func(reqCtx *fasthttp.RequestCtx) {
+		ctx, span := tracer.Start(requestContext(reqCtx), "Controller.Assistants.CreateRun")
+		ctx = responses.ContextWithEnqueuedAt(ctx, time.Now())
+
+		projectID, namespace, ok := assistantsScope(reqCtx, ctx)
+		if !ok {
+			span.End()
+			return
+		}
+
+		conv, ok := assistantsThread(reqCtx, ctx, svc, projectID, namespace)
+		if !ok {
+			span.End()
+			return
+		}
+
+		var body AssistantsRunRequest
+		if err := parseBody(reqCtx, &body); err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			span.End()
+			return
+		}
+
+		if body.AssistantID == "" {
+			err := errors.New("assistant_id is required")
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			span.End()
+			return
+		}
+
+		span.SetAttributes(
+			attribute.String("project_id", projectID.String()),
+			attribute.String("namespace", namespace),
+			attribute.String("thread_id", conv.ConversationID),
+		)
+
+		project, err := svc.Project.GetByID(ctx, projectID)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to get project", perrors.NewErrInternalServerError(err.Error(), err))
+			span.End()
+			return
+		}
+
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
+			span.End()
+			return
+		}
+
+		agentConfig, err := runner.ResolveAgentConfig(ctx, projectID, body.AssistantID, requestEnvironment(reqCtx))
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to get agent config", perrors.NewErrInvalidRequest(err.Error(), err))
+			span.End()
+			return
+		}
+		span.SetAttributes(attribute.String("agent_name", agentConfig.Name))
+
+		agentConfig, err = project.ApplyAgentDefaults(agentConfig)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to apply project defaults", perrors.NewErrInternalServerError(err.Error(), err))
+			span.End()
+			return
+		}
+
+		// The namespace may override parts of the config, the effective config is recorded in the run state
+		var runMeta map[string]any
+		agentConfig, override, err := svc.AgentConfig.ApplyNamespaceOverride(ctx, agentConfig, namespace)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to apply namespace overrides", perrors.NewErrInternalServerError(err.Error(), err))
+			span.End()
+			return
+		}
+		if override != nil {
+			span.SetAttributes(attribute.String("namespace_override", override.Namespace))
+			runMeta = map[string]any{
+				"namespace_override": override.Namespace,
+				"effective_config":   agentConfig.Config,
+			}
+		}
+
+		// Additional messages are persisted like regular thread messages, the run then picks up from the last one
+		for _, m := range body.AdditionalMessages {
+			if _, err := appendAssistantsMessage(ctx, svc, projectID, namespace, conv.ConversationID, m); err != nil {
+				RecordSpanError(span, err)
+				writeError(reqCtx, ctx, "Failed to add message", perrors.NewErrInvalidRequest("Failed to add message", err))
+				span.End()
+				return
+			}
+		}
+
+		thread, err := svc.Conversation.GetLatestThread(ctx, projectID, namespace, conv.ConversationID)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "Failed to get thread", perrors.NewErrInternalServerError("Failed to get thread", err))
+			span.End()
+			return
+		}
+		if thread == nil || thread.LastMessageID == "" {
+			err := errors.New("thread has no messages to run on")
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			span.End()
+			return
+		}
+
+		in := &agents.AgentInput{
+			Namespace:         namespace,
+			PreviousMessageID: thread.LastMessageID,
+			Messages:          []responses.InputMessageUnion{},
+			RunContext:        runContextFromRequest(reqCtx, body.Metadata),
+			RunMeta:           runMeta,
+		}
+
+		stream, err := runner.Start(ctx, span, agentConfig, in, *project.DefaultKey)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
+			span.End()
+			return
+		}
+
+		run := &assistantsRunStream{
+			threadID:    conv.ConversationID,
+			assistantID: body.AssistantID,
+			createdAt:   time.Now().Unix(),
+			activeRuns:  activeRuns,
+		}
+
+		if body.Stream {
+			reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
+			reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+			reqCtx.SetStatusCode(fasthttp.StatusOK)
+			reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				defer span.End()
+				run.consume(ctx, stream, func(event string, data any) {
+					buf, _ := json.Marshal(data)
+					_, _ = fmt.Fprintf(w, "event: %s\n", event)
+					_, _ = fmt.Fprintf(w, "data: %s\n\n", buf)
+					_ = w.Flush()
+				})
+				_, _ = fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
+			})
+			return
+		}
+
+		// Without streaming, respond as soon as the run is created and let it continue in the background
+		created := make(chan AssistantsRun, 1)
+		go func() {
+			defer span.End()
+			run.consume(ctx, stream, func(event string, data any) {
+				if event == "thread.run.created" {
+					created <- data.(AssistantsRun)
+				}
+			})
+			close(created)
+		}()
+
+		out, ok := <-created
+		if !ok {
+			err := errors.New("run ended before it was created")
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+		writeJSON(reqCtx, out)
+	})
+
+	// Retrieve run
+	r.GET("/v1/threads/{thread_id}/runs/{run_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, ok := assistantsScope(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		threadID, _ := pathParam(ctx, "thread_id")
+		runID, err := pathParam(ctx, "run_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Run ID is required", perrors.NewErrInvalidRequest("Run ID is required", err))
+			return
+		}
+
+		msg, err := svc.Conversation.GetMessage(stdCtx, projectID, namespace, runID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if active, ok := activeRuns.Load(runID); ok {
+				writeJSON(ctx, active)
+				return
+			}
+			writeError(ctx, stdCtx, "Run not found", perrors.NewErrInvalidRequest("Run not found", err))
+			return
+		}
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get run", perrors.NewErrInternalServerError("Failed to get run", err))
+			return
+		}
+
+		state := core.LoadRunStateFromMeta(msg.Meta)
+		if state == nil || msg.ConversationID != threadID {
+			writeError(ctx, stdCtx, "Run not found", perrors.NewErrInvalidRequest("Run not found", errors.New("message is not a run of this thread")))
+			return
+		}
+
+		status := core.RunStatusInProgress
+		if runState, ok := msg.Meta["run_state"].(map[string]any); ok {
+			if s, ok := runState["status"].(string); ok {
+				status = core.RunStatus(s)
+			}
+		}
+
+		// The assistant used for the run is not persisted, only its name
+		writeJSON(ctx, newAssistantsRun(runID, threadID, "", msg.CreatedAt.Unix(), status, &state.Usage, state.PendingToolCalls))
+	})
+}
+
+// assistantsRunStream maps the chunks of an agent run onto Assistants run and message events
+type assistantsRunStream struct {
+	threadID    string
+	assistantID string
+	createdAt   int64
+	activeRuns  *sync.Map
+
+	runID   string
+	message *AssistantsMessage
+	text    string
+}
+
+func (s *assistantsRunStream) consume(ctx context.Context, stream <-chan *responses.ResponseChunk, emit func(event string, data any)) {
+	defer func() {
+		if s.runID != "" {
+			s.activeRuns.Delete(s.runID)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case chunk, ok := <-stream:
+			if !ok {
+				if s.runID != "" {
+					emit("thread.run.failed", newAssistantsRun(s.runID, s.threadID, s.assistantID, s.createdAt, core.RunStatusError, nil, nil))
+				}
+				return
+			}
+
+			switch {
+			case chunk.OfRunCreated != nil:
+				s.runID = chunk.OfRunCreated.RunState.Id
+				run := newAssistantsRun(s.runID, s.threadID, s.assistantID, s.createdAt, core.RunStatusInProgress, nil, nil)
+				s.activeRuns.Store(s.runID, run)
+				emit("thread.run.created", run)
+				emit("thread.run.in_progress", run)
+
+			case chunk.OfOutputItemAdded != nil && chunk.OfOutputItemAdded.Item.Type == "message":
+				s.text = ""
+				s.message = &AssistantsMessage{
+					ID:          chunk.OfOutputItemAdded.Item.Id,
+					Object:      "thread.message",
+					CreatedAt:   time.Now().Unix(),
+					ThreadID:    s.threadID,
+					Status:      "in_progress",
+					Role:        "assistant",
+					Content:     []AssistantsMessageContent{},
+					AssistantID: utils.Ptr(s.assistantID),
+					RunID:       utils.Ptr(s.runID),
+					Attachments: []any{},
+					Metadata:    map[string]any{},
+				}
+				emit("thread.message.created", s.message)
+
+			case chunk.OfOutputTextDelta != nil && s.message != nil:
+				s.text += chunk.OfOutputTextDelta.Delta
+				delta := AssistantsMessageDelta{ID: s.message.ID, Object: "thread.message.delta"}
+				delta.Delta.Content = []AssistantsMessageContent{newAssistantsTextContent(chunk.OfOutputTextDelta.Delta, utils.Ptr(0))}
+				emit("thread.message.delta", delta)
+
+			case chunk.OfOutputItemDone != nil && chunk.OfOutputItemDone.Item.Type == "message" && s.message != nil:
+				s.message.Status = "completed"
+				s.message.Content = []AssistantsMessageContent{newAssistantsTextContent(s.text, nil)}
+				emit("thread.message.completed", s.message)
+				s.message = nil
+
+			case chunk.OfRunPaused != nil:
+				data := chunk.OfRunPaused.RunState
+				emit("thread.run.requires_action", newAssistantsRun(s.runID, s.threadID, s.assistantID, s.createdAt, core.RunStatusPaused, &data.Usage, data.PendingToolCalls))
+				return
+
+			case chunk.OfRunCompleted != nil:
+				data := chunk.OfRunCompleted.RunState
+				emit("thread.run.completed", newAssistantsRun(s.runID, s.threadID, s.assistantID, s.createdAt, core.RunStatusCompleted, &data.Usage, nil))
+				return
+
+			case chunk.OfRunFailed != nil:
+				data := chunk.OfRunFailed.RunState
+				run := newAssistantsRun(s.runID, s.threadID, s.assistantID, s.createdAt, core.RunStatusError, &data.Usage, nil)
+				if data.Error != nil {
+					run.LastError.Message = data.Error.Message
+				}
+				emit("thread.run.failed", run)
+				return
+			}
+		}
+	}
+}
+
+// assistantsScope reads the required project_id and namespace query parameters
+func assistantsScope(ctx *fasthttp.RequestCtx, stdCtx context.Context) (uuid.UUID, string, bool) {
+	projectID, err := requireUUIDQuery(ctx, "project_id")
+	if err != nil {
+		writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+		return uuid.Nil, "", false
+	}
+
+	namespace, err := requireStringQuery(ctx, "namespace")
+	if err != nil {
+		writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+		return uuid.Nil, "", false
+	}
+
+	return projectID, namespace, true
+}
+
+// assistantsThread loads the conversation backing the thread_id path parameter
+func assistantsThread(ctx *fasthttp.RequestCtx, stdCtx context.Context, svc *services.Services, projectID uuid.UUID, namespace string) (conversation.Conversation, bool) {
+	threadID, err := pathParam(ctx, "thread_id")
+	if err != nil {
+		writeError(ctx, stdCtx, "Thread ID is required", perrors.NewErrInvalidRequest("Thread ID is required", err))
+		return conversation.Conversation{}, false
+	}
+
+	conv, err := svc.Conversation.GetConversation(stdCtx, projectID, namespace, threadID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(ctx, stdCtx, "Thread not found", perrors.NewErrInvalidRequest("Thread not found", err))
+		return conversation.Conversation{}, false
+	}
+	if err != nil {
+		writeError(ctx, stdCtx, "Failed to get thread", perrors.NewErrInternalServerError("Failed to get thread", err))
+		return conversation.Conversation{}, false
+	}
+
+	return conv, true
+}
+
+// appendAssistantsMessage persists a message at the end of the conversation's thread
+func appendAssistantsMessage(ctx context.Context, svc *services.Services, projectID uuid.UUID, namespace string, conversationID string, m AssistantsMessageRequest) (AssistantsMessage, error) {
+	input, err := m.toInputMessage()
+	if err != nil {
+		return AssistantsMessage{}, err
+	}
+
+	previousMessageID := ""
+	thread, err := svc.Conversation.GetLatestThread(ctx, projectID, namespace, conversationID)
+	if err != nil {
+		return AssistantsMessage{}, err
+	}
+	if thread != nil {
+		previousMessageID = thread.LastMessageID
+	}
+
+	messageID := uuid.NewString()
+	err = svc.Conversation.AddMessages(ctx, &conversation.AddMessageRequest{
+		ProjectID:         projectID,
+		Namespace:         namespace,
+		MessageID:         messageID,
+		PreviousMessageID: previousMessageID,
+		ConversationID:    conversationID,
+		Messages:          []responses.InputMessageUnion{input},
+		Meta:              map[string]any{},
+	})
+	if err != nil {
+		return AssistantsMessage{}, err
+	}
+
+	metadata := m.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	msg := toAssistantsMessages(conversationID, conversation.ConversationMessage{
+		MessageID: messageID,
+		Messages:  []responses.InputMessageUnion{input},
+		CreatedAt: time.Now(),
+	})[0]
+	msg.Metadata = metadata
+
+	return msg, nil
+}
+
+// writeJSON writes data as a plain JSON body, for endpoints that mirror third party APIs
+func writeJSON(ctx *fasthttp.RequestCtx, data any) {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		writeError(ctx, requestContext(ctx), "Error marshalling response", perrors.NewErrInternalServerError("Error marshalling response", err))
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetBody(buf)
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Objects of the OpenAI Assistants API, mapped onto conversations (threads) and agent runs
+
+type AssistantsThread struct {
+	ID        string         `json:"id"`
+	Object    string         `json:"object"` // "thread"
+	CreatedAt int64          `json:"created_at"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+type AssistantsMessage struct {
+	ID          string                     `json:"id"`
+	Object      string                     `json:"object"` // "thread.message"
+	CreatedAt   int64                      `json:"created_at"`
+	ThreadID    string                     `json:"thread_id"`
+	Status      string                     `json:"status"`
+	Role        string                     `json:"role"`
+	Content     []AssistantsMessageContent `json:"content"`
+	AssistantID *string                    `json:"assistant_id"`
+	RunID       *string                    `json:"run_id"`
+	Attachments []any                      `json:"attachments"`
+	Metadata    map[string]any             `json:"metadata"`
+}
+
+type AssistantsMessageContent struct {
+	Index *int                  `json:"index,omitempty"` // Only on message deltas
+	Type  string                `json:"type"`            // "text"
+	Text  AssistantsMessageText `json:"text"`
+}
+
+type AssistantsMessageText struct {
+	Value       string `json:"value"`
+	Annotations []any  `json:"annotations"`
+}
+
+type AssistantsMessageDelta struct {
+	ID     string `json:"id"`
+	Object string `json:"object"` // "thread.message.delta"
+	Delta  struct {
+		Content []AssistantsMessageContent `json:"content"`
+	} `json:"delta"`
+}
+
+type AssistantsMessageList struct {
+	Object  string              `json:"object"` // "list"
+	Data    []AssistantsMessage `json:"data"`
+	FirstID *string             `json:"first_id"`
+	LastID  *string             `json:"last_id"`
+	HasMore bool                `json:"has_more"`
+}
+
+type AssistantsRun struct {
+	ID             string                    `json:"id"`
+	Object         string                    `json:"object"` // "thread.run"
+	CreatedAt      int64                     `json:"created_at"`
+	ThreadID       string                    `json:"thread_id"`
+	AssistantID    string                    `json:"assistant_id"`
+	Status         string                    `json:"status"` // "queued", "in_progress", "requires_action", "completed", "failed"
+	RequiredAction *AssistantsRequiredAction `json:"required_action"`
+	LastError      *AssistantsRunError       `json:"last_error"`
+	Usage          *AssistantsRunUsage       `json:"usage"`
+	Metadata       map[string]any            `json:"metadata"`
+}
+
+type AssistantsRequiredAction struct {
+	Type              string `json:"type"` // "submit_tool_outputs"
+	SubmitToolOutputs struct {
+		ToolCalls []AssistantsToolCall `json:"tool_calls"`
+	} `json:"submit_tool_outputs"`
+}
+
+type AssistantsToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"` // "function"
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type AssistantsRunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type AssistantsRunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// AssistantsMessageRequest is the body of the create message endpoint, and the item of additional_messages.
+// Content is either a string or a list of {"type": "text", "text": "..."} parts.
+type AssistantsMessageRequest struct {
+	Role     string         `json:"role"`
+	Content  any            `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+type AssistantsThreadRequest struct {
+	Messages []AssistantsMessageRequest `json:"messages"`
+	Metadata map[string]any             `json:"metadata"`
+}
+
+type AssistantsRunRequest struct {
+	AssistantID        string                     `json:"assistant_id"`
+	Stream             bool                       `json:"stream"`
+	AdditionalMessages []AssistantsMessageRequest `json:"additional_messages"`
+	Metadata           map[string]any             `json:"metadata"`
+}
+
+// toInputMessage converts an Assistants message into the native input message
+func (m AssistantsMessageRequest) toInputMessage() (responses.InputMessageUnion, error) {
+	role := constants.RoleUser
+	if m.Role == string(constants.RoleAssistant) {
+		role = constants.RoleAssistant
+	}
+
+	var text string
+	switch content := m.Content.(type) {
+	case string:
+		text = content
+	case []any:
+		for _, part := range content {
+			p, ok := part.(map[string]any)
+			if !ok || p["type"] != "text" {
+				return responses.InputMessageUnion{}, fmt.Errorf("only text content parts are supported")
+			}
+			if t, ok := p["text"].(string); ok {
+				text += t
+			}
+		}
+	default:
+		return responses.InputMessageUnion{}, fmt.Errorf("content must be a string or a list of content parts")
+	}
+
+	return responses.InputMessageUnion{
+		OfEasyInput: &responses.EasyMessage{
+			Role:    role,
+			Content: responses.EasyInputContentUnion{OfString: utils.Ptr(text)},
+		},
+	}, nil
+}
+
+// toAssistantsMessages flattens a persisted message row into Assistants messages.
+// Only user and assistant text messages are returned; tool calls and reasoning are omitted.
+func toAssistantsMessages(threadID string, msg conversation.ConversationMessage) []AssistantsMessage {
+	var runID *string
+	if core.LoadRunStateFromMeta(msg.Meta) != nil {
+		runID = utils.Ptr(msg.MessageID)
+	}
+
+	out := []AssistantsMessage{}
+	for idx, m := range msg.Messages {
+		var role string
+		var texts []string
+
+		switch {
+		case m.OfEasyInput != nil:
+			role = string(m.OfEasyInput.Role)
+			if m.OfEasyInput.Content.OfString != nil {
+				texts = append(texts, *m.OfEasyInput.Content.OfString)
+			}
+			texts = append(texts, inputContentTexts(m.OfEasyInput.Content.OfInputMessageList)...)
+		case m.OfInputMessage != nil:
+			role = string(m.OfInputMessage.Role)
+			texts = inputContentTexts(m.OfInputMessage.Content)
+		case m.OfOutputMessage != nil:
+			role = string(constants.RoleAssistant)
+			for _, c := range m.OfOutputMessage.Content {
+				if c.OfOutputText != nil {
+					texts = append(texts, c.OfOutputText.Text)
+				}
+			}
+		default:
+			continue
+		}
+
+		if role == "" {
+			role = string(constants.RoleUser)
+		}
+
+		id := m.ID()
+		if id == "" {
+			id = fmt.Sprintf("%s_%d", msg.MessageID, idx)
+		}
+
+		content := make([]AssistantsMessageContent, 0, len(texts))
+		for _, t := range texts {
+			content = append(content, newAssistantsTextContent(t, nil))
+		}
+
+		out = append(out, AssistantsMessage{
+			ID:          id,
+			Object:      "thread.message",
+			CreatedAt:   msg.CreatedAt.Unix(),
+			ThreadID:    threadID,
+			Status:      "completed",
+			Role:        role,
+			Content:     content,
+			RunID:       runID,
+			Attachments: []any{},
+			Metadata:    map[string]any{},
+		})
+	}
+
+	return out
+}
+
+func inputContentTexts(content responses.InputContent) []string {
+	texts := []string{}
+	for _, c := range content {
+		if c.OfInputText != nil {
+			texts = append(texts, c.OfInputText.Text)
+		}
+		if c.OfOutputText != nil {
+			texts = append(texts, c.OfOutputText.Text)
+		}
+	}
+	return texts
+}
+
+func newAssistantsTextContent(text string, index *int) AssistantsMessageContent {
+	return AssistantsMessageContent{
+		Index: index,
+		Type:  "text",
+		Text:  AssistantsMessageText{Value: text, Annotations: []any{}},
+	}
+}
+
+// newAssistantsRun builds a run object from the status reported in the run chunks or persisted run state
+func newAssistantsRun(runID, threadID, assistantID string, createdAt int64, status core.RunStatus, usage *responses.Usage, pending []responses.FunctionCallMessage) AssistantsRun {
+	run := AssistantsRun{
+		ID:          runID,
+		Object:      "thread.run",
+		CreatedAt:   createdAt,
+		ThreadID:    threadID,
+		AssistantID: assistantID,
+		Metadata:    map[string]any{},
+	}
+
+	switch status {
+	case core.RunStatusCompleted:
+		run.Status = "completed"
+	case core.RunStatusPaused:
+		run.Status = "requires_action"
+		action := &AssistantsRequiredAction{Type: "submit_tool_outputs"}
+		action.SubmitToolOutputs.ToolCalls = []AssistantsToolCall{}
+		for _, call := range pending {
+			toolCall := AssistantsToolCall{ID: call.CallID, Type: "function"}
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = call.Arguments
+			action.SubmitToolOutputs.ToolCalls = append(action.SubmitToolOutputs.ToolCalls, toolCall)
+		}
+		run.RequiredAction = action
+	case core.RunStatusError:
+		run.Status = "failed"
+		run.LastError = &AssistantsRunError{Code: "server_error", Message: "the run failed"}
+	default:
+		run.Status = "in_progress"
+	}
+
+	if usage != nil {
+		run.Usage = &AssistantsRunUsage{
+			PromptTokens:     usage.InputTokens,
+			CompletionTokens: usage.OutputTokens,
+			TotalTokens:      usage.TotalTokens,
+		}
+	}
+
+	return run
+}
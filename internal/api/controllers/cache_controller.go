@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"github.com/curaious/uno/internal/adapters"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterCacheRoutes registers routes reporting the hit rate of the config caches
+func RegisterCacheRoutes(r *router.Router, caches ...*adapters.ConfigCache) {
+	r.GET("/api/agent-server/cache/stats", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		stats := []adapters.ConfigCacheStats{}
+		for _, cache := range caches {
+			if cache != nil {
+				stats = append(stats, cache.Stats())
+			}
+		}
+
+		writeOK(ctx, stdCtx, "Cache stats retrieved", stats)
+	})
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/integrations"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/chat_widget"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/gateway/middlewares/virtual_key_middleware"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// widgetHistoryReadsPerMinute bounds the history reads of a visitor of a chat widget
+const widgetHistoryReadsPerMinute = 60
+
+// WidgetSessionRequest is the request of a visitor for a session, with the token of its previous session to keep
+// its conversation
+type WidgetSessionRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+// WidgetConverseRequest is a message of a visitor of a chat widget
+type WidgetConverseRequest struct {
+	Message string `json:"message"`
+	// NewConversation starts a new conversation instead of continuing the latest one of the visitor
+	NewConversation bool `json:"new_conversation,omitempty"`
+}
+
+// WidgetHistoryMessage is a message of the conversation of a visitor, without the run details of the agent
+type WidgetHistoryMessage struct {
+	MessageID string                        `json:"message_id"`
+	Messages  []responses.InputMessageUnion `json:"messages"`
+	CreatedAt time.Time                     `json:"created_at"`
+}
+
+// RegisterChatWidgetRoutes registers the routes to manage chat widgets, and the public endpoints the embedded
+// widgets call: sessions, converse and history. The public endpoints are authenticated with the session tokens
+// of the widgets, only answer the pages of their allowed origins and are rate limited per visitor and client IP.
+func RegisterChatWidgetRoutes(r *router.Router, svc *services.Services, runner *AgentRunner, limiter virtual_key_middleware.RateLimiterStorage) {
+	backend := &integrationBackend{svc: svc, runner: runner}
+
+	r.GET("/api/agent-server/chat-widgets", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		widgets, err := svc.ChatWidget.List(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list chat widgets", perrors.NewErrInternalServerError("Failed to list chat widgets", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widgets retrieved successfully", widgets)
+	})
+
+	r.POST("/api/agent-server/chat-widgets", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body chat_widget.CreateChatWidgetRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		widget, err := svc.ChatWidget.Create(stdCtx, projectID, &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to create chat widget", perrors.NewErrInvalidRequest("Failed to create chat widget", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widget created successfully", widget)
+	})
+
+	r.GET("/api/agent-server/chat-widgets/{widget_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		widgetID, err := pathParamUUID(ctx, "widget_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid widget ID", perrors.NewErrInvalidRequest("Invalid widget ID", err))
+			return
+		}
+
+		widget, err := svc.ChatWidget.Get(stdCtx, projectID, widgetID)
+		if err != nil {
+			writeChatWidgetError(ctx, stdCtx, "Failed to get chat widget", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widget retrieved successfully", widget)
+	})
+
+	r.PUT("/api/agent-server/chat-widgets/{widget_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		widgetID, err := pathParamUUID(ctx, "widget_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid widget ID", perrors.NewErrInvalidRequest("Invalid widget ID", err))
+			return
+		}
+
+		var body chat_widget.UpdateChatWidgetRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		widget, err := svc.ChatWidget.Update(stdCtx, projectID, widgetID, &body)
+		if err != nil {
+			writeChatWidgetError(ctx, stdCtx, "Failed to update chat widget", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widget updated successfully", widget)
+	})
+
+	r.DELETE("/api/agent-server/chat-widgets/{widget_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		widgetID, err := pathParamUUID(ctx, "widget_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid widget ID", perrors.NewErrInvalidRequest("Invalid widget ID", err))
+			return
+		}
+
+		if err := svc.ChatWidget.Delete(stdCtx, projectID, widgetID); err != nil {
+			writeChatWidgetError(ctx, stdCtx, "Failed to delete chat widget", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widget deleted successfully", nil)
+	})
+
+	// The pages embedding a widget get a session for their visitor, which authenticates the other public endpoints
+	r.POST("/api/agent-server/widget/{widget_id}/sessions", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		widget, ok := publicChatWidget(ctx, stdCtx, svc)
+		if !ok {
+			return
+		}
+
+		if !allowWidgetRequest(ctx, stdCtx, limiter, "chat_widget:"+widget.ID.String()+":ip:"+widgetClientIP(ctx), gateway.RateLimit{Unit: "1h", Limit: int64(widget.SessionsPerHour)}) {
+			return
+		}
+
+		var body WidgetSessionRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := parseBody(ctx, &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		session, err := svc.ChatWidget.IssueSession(widget, string(ctx.Request.Header.Peek("Origin")), body.Token)
+		if err != nil {
+			writeChatWidgetError(ctx, stdCtx, "Failed to issue chat widget session", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Chat widget session issued successfully", session)
+	})
+
+	// A visitor converses with the agent of the widget, continuing its latest conversation. The response streams
+	// like the converse endpoint, with the chunks a chat needs: the text of the reply, the run and the takeovers.
+	r.POST("/api/agent-server/widget/{widget_id}/converse", func(reqCtx *fasthttp.RequestCtx) {
+		ctx, span := tracer.Start(reqCtx, "Controller.WidgetConverse")
+		widget, ok := publicChatWidget(reqCtx, ctx, svc)
+		if !ok {
+			span.End()
+			return
+		}
+		visitor, ok := widgetVisitor(reqCtx, ctx, svc, widget)
+		if !ok {
+			span.End()
+			return
+		}
+		span.SetAttributes(
+			attribute.String("project_id", widget.ProjectID.String()),
+			attribute.String("widget_id", widget.ID.String()),
+			attribute.String("visitor_id", visitor.ID),
+		)
+
+		if !allowWidgetRequest(reqCtx, ctx, limiter, "chat_widget:"+widget.ID.String()+":visitor:"+visitor.ID, gateway.RateLimit{Unit: "1min", Limit: int64(widget.MessagesPerMinute)}) {
+			span.End()
+			return
+		}
+
+		var body WidgetConverseRequest
+		if err := parseBody(reqCtx, &body); err != nil {
+			RecordSpanError(span, err)
+			span.End()
+			writeError(reqCtx, ctx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+		if err := svc.ChatWidget.ValidateMessage(widget, body.Message); err != nil {
+			RecordSpanError(span, err)
+			span.End()
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		namespace := chat_widget.Namespace(widget, visitor.ID)
+		previousMessageID := ""
+		if !body.NewConversation {
+			thread, err := latestWidgetThread(ctx, svc, widget.ProjectID, namespace)
+			if err != nil {
+				RecordSpanError(span, err)
+				span.End()
+				writeError(reqCtx, ctx, "Failed to get the conversation", perrors.NewErrInternalServerError("Failed to get the conversation", err))
+				return
+			}
+			if thread != nil {
+				previousMessageID = thread.LastMessageID
+			}
+		}
+
+		stream, err := backend.Converse(ctx, &integrations.ConverseInput{
+			ProjectID:         widget.ProjectID,
+			Agent:             widget.AgentID,
+			Namespace:         namespace,
+			PreviousMessageID: previousMessageID,
+			Message: responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+				Role:    constants.RoleUser,
+				Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: body.Message}}},
+			}},
+			Context: map[string]any{
+				"widget_id":      widget.ID.String(),
+				"widget_visitor": visitor.ID,
+				"widget_origin":  visitor.Origin,
+			},
+		})
+		if err != nil {
+			RecordSpanError(span, err)
+			span.End()
+			writeError(reqCtx, ctx, "Failed to run the agent", perrors.NewErrInternalServerError("Failed to run the agent", err))
+			return
+		}
+
+		// The widget renders the reply as it streams, a single JSON response isn't offered
+		format := streamFormatFromRequest(reqCtx, nil)
+		if format == streamFormatJSON {
+			format = streamFormatSSE
+		}
+		respondRunEvents(ctx, reqCtx, runEventsOf(stream), span, responses.NewChunkPipeline(widgetChunkFilter), format)
+	})
+
+	// The history of the latest conversation of the visitor, for the widget to show when a page loads
+	r.GET("/api/agent-server/widget/{widget_id}/history", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		widget, ok := publicChatWidget(ctx, stdCtx, svc)
+		if !ok {
+			return
+		}
+		visitor, ok := widgetVisitor(ctx, stdCtx, svc, widget)
+		if !ok {
+			return
+		}
+
+		if !allowWidgetRequest(ctx, stdCtx, limiter, "chat_widget:"+widget.ID.String()+":history:"+visitor.ID, gateway.RateLimit{Unit: "1min", Limit: widgetHistoryReadsPerMinute}) {
+			return
+		}
+
+		namespace := chat_widget.Namespace(widget, visitor.ID)
+		history := []WidgetHistoryMessage{}
+		thread, err := latestWidgetThread(stdCtx, svc, widget.ProjectID, namespace)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get the conversation", perrors.NewErrInternalServerError("Failed to get the conversation", err))
+			return
+		}
+		if thread != nil {
+			messages, err := svc.Conversation.ListMessages(stdCtx, widget.ProjectID, namespace, thread.ThreadID)
+			if err != nil {
+				writeError(ctx, stdCtx, "Failed to list messages", perrors.NewErrInternalServerError("Failed to list messages", err))
+				return
+			}
+			for _, message := range messages {
+				history = append(history, WidgetHistoryMessage{
+					MessageID: message.MessageID,
+					Messages:  message.Messages,
+					CreatedAt: message.CreatedAt,
+				})
+			}
+		}
+
+		writeOKConditional(ctx, stdCtx, "Chat widget history retrieved successfully", history)
+	})
+}
+
+// publicChatWidget gets the enabled widget of a public request
+func publicChatWidget(ctx *fasthttp.RequestCtx, stdCtx context.Context, svc *services.Services) (*chat_widget.ChatWidget, bool) {
+	widgetID, err := pathParamUUID(ctx, "widget_id")
+	if err != nil {
+		writeError(ctx, stdCtx, "Invalid widget ID", perrors.NewErrInvalidRequest("Invalid widget ID", err))
+		return nil, false
+	}
+
+	widget, err := svc.ChatWidget.GetEnabled(stdCtx, widgetID)
+	if err != nil {
+		writeChatWidgetError(ctx, stdCtx, "Failed to get chat widget", err)
+		return nil, false
+	}
+
+	return widget, true
+}
+
+// widgetVisitor authenticates the visitor of a public request with its session token
+func widgetVisitor(ctx *fasthttp.RequestCtx, stdCtx context.Context, svc *services.Services, widget *chat_widget.ChatWidget) (*chat_widget.Visitor, bool) {
+	token := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
+	visitor, err := svc.ChatWidget.VerifySession(widget, token, string(ctx.Request.Header.Peek("Origin")))
+	if err != nil {
+		writeChatWidgetError(ctx, stdCtx, "Invalid chat widget session", err)
+		return nil, false
+	}
+
+	return visitor, true
+}
+
+// allowWidgetRequest consumes a request of a rate limit, answering 429 when it is exhausted
+func allowWidgetRequest(ctx *fasthttp.RequestCtx, stdCtx context.Context, limiter virtual_key_middleware.RateLimiterStorage, key string, limit gateway.RateLimit) bool {
+	allowed, err := limiter.Allow(stdCtx, key, limit)
+	if err != nil {
+		writeError(ctx, stdCtx, "Failed to check the rate limit", perrors.NewErrInternalServerError("Failed to check the rate limit", err))
+		return false
+	}
+	if !allowed {
+		writeError(ctx, stdCtx, "Rate limit exceeded", perrors.New(perrors.ErrCodeTooManyRequests, "Rate limit exceeded", errors.New("rate limit exceeded")))
+		return false
+	}
+
+	return true
+}
+
+// latestWidgetThread is the latest thread of the latest conversation of a visitor, nil before its first message
+func latestWidgetThread(ctx context.Context, svc *services.Services, projectID uuid.UUID, namespace string) (*conversation.Thread, error) {
+	conversations, err := svc.Conversation.ListConversations(ctx, projectID, namespace)
+	if err != nil || len(conversations) == 0 {
+		return nil, err
+	}
+
+	return svc.Conversation.GetLatestThread(ctx, projectID, namespace, conversations[0].ConversationID)
+}
+
+// widgetClientIP is the IP of the client of a request. Behind a proxy it is the last address of X-Forwarded-For,
+// the one the proxy appended, as the others are sent by the client.
+func widgetClientIP(ctx *fasthttp.RequestCtx) string {
+	if forwarded := string(ctx.Request.Header.Peek("X-Forwarded-For")); forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addresses[len(addresses)-1])
+	}
+	return ctx.RemoteIP().String()
+}
+
+// widgetChunkFilter passes on the chunks a chat needs to the visitors of a widget. The tool calls, reasoning and
+// usage of the agent aren't shown to them.
+func widgetChunkFilter() responses.ChunkTransformer {
+	return widgetChunks{}
+}
+
+type widgetChunks struct{}
+
+func (widgetChunks) Transform(_ context.Context, chunk *responses.ResponseChunk, emit func(*responses.ResponseChunk)) {
+	switch {
+	case chunk.OfOutputTextDelta != nil, chunk.OfOutputTextDone != nil, chunk.OfTranslation != nil,
+		chunk.OfTakeoverActive != nil, chunk.OfTakeoverStarted != nil, chunk.OfTakeoverMessage != nil, chunk.OfTakeoverEnded != nil:
+		emit(chunk)
+	case chunk.OfRunCreated != nil:
+		run := *chunk.OfRunCreated
+		run.RunState = publicRunState(run.RunState)
+		emit(&responses.ResponseChunk{OfRunCreated: &run})
+	case chunk.OfRunPaused != nil:
+		run := *chunk.OfRunPaused
+		run.RunState = publicRunState(run.RunState)
+		emit(&responses.ResponseChunk{OfRunPaused: &run})
+	case chunk.OfRunCompleted != nil:
+		run := *chunk.OfRunCompleted
+		run.RunState = publicRunState(run.RunState)
+		emit(&responses.ResponseChunk{OfRunCompleted: &run})
+	case chunk.OfRunFailed != nil:
+		// The visitors are not shown the error of the run
+		run := *chunk.OfRunFailed
+		run.RunState = publicRunState(run.RunState)
+		emit(&responses.ResponseChunk{OfRunFailed: &run})
+	}
+}
+
+func (widgetChunks) Flush(context.Context, func(*responses.ResponseChunk)) {}
+
+// publicRunState is the state of a run without its pending tool calls, usage and trace
+func publicRunState(state responses.ChunkRunData) responses.ChunkRunData {
+	return responses.ChunkRunData{Id: state.Id, Object: state.Object, Status: state.Status}
+}
+
+func writeChatWidgetError(ctx *fasthttp.RequestCtx, stdCtx context.Context, message string, err error) {
+	switch {
+	case errors.Is(err, chat_widget.ErrWidgetNotFound), errors.Is(err, chat_widget.ErrWidgetDisabled):
+		// A disabled widget is hidden from its visitors
+		writeError(ctx, stdCtx, chat_widget.ErrWidgetNotFound.Error(), perrors.New(perrors.ErrCodeNotFound, chat_widget.ErrWidgetNotFound.Error(), err))
+	case errors.Is(err, chat_widget.ErrOriginNotAllowed):
+		writeError(ctx, stdCtx, err.Error(), perrors.New(perrors.ErrCodeForbidden, err.Error(), err))
+	case errors.Is(err, chat_widget.ErrInvalidSession):
+		writeError(ctx, stdCtx, chat_widget.ErrInvalidSession.Error(), perrors.New(perrors.ErrCodeUnauthorized, chat_widget.ErrInvalidSession.Error(), err))
+	default:
+		writeError(ctx, stdCtx, message, perrors.NewErrInvalidRequest(message, err))
+	}
+}
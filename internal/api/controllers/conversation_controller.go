@@ -1,6 +1,10 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
+	"log/slog"
+
 	"github.com/curaious/uno/internal/services"
 	"github.com/curaious/uno/internal/services/conversation"
 	"github.com/fasthttp/router"
@@ -30,7 +34,41 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", conversations)
+		writeOKConditional(ctx, stdCtx, "OK", conversations)
+	})
+
+	// Import conversations from a ChatGPT or Claude export
+	r.POST("/api/agent-server/conversations/import", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+		format, err := requireStringQuery(ctx, "format")
+		if err != nil {
+			writeError(ctx, stdCtx, "Format is required", perrors.NewErrInvalidRequest("Format is required", err))
+			return
+		}
+
+		conversations, err := conversation.ParseConversationExport(conversation.ImportFormat(format), ctx.PostBody())
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid export", perrors.NewErrInvalidRequest("Invalid export", err))
+			return
+		}
+
+		results, err := svc.Conversation.ImportConversations(stdCtx, projectID, namespace, conversations)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to import conversations", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Conversations imported successfully", results)
 	})
 
 	// List threads in a conversation
@@ -58,7 +96,7 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", threads)
+		writeOKConditional(ctx, stdCtx, "OK", threads)
 	})
 
 	// List messages in a thread
@@ -88,7 +126,7 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", messages)
+		writeOKConditional(ctx, stdCtx, "OK", messages)
 	})
 
 	// Get specific message by ID
@@ -118,9 +156,15 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", message)
+		writeOKConditional(ctx, stdCtx, "OK", message)
 	})
 
+	// Pin a message, its run is always kept in the history of the agent
+	r.PUT("/api/agent-server/messages/{message_id}/pin", setMessagePinned(svc, true))
+
+	// Unpin a message
+	r.DELETE("/api/agent-server/messages/{message_id}/pin", setMessagePinned(svc, false))
+
 	// Add messages to a conversation/thread
 	r.POST("/api/agent-server/messages", func(ctx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(ctx)
@@ -142,7 +186,37 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Messages added successfully", nil)
+		writeOK(ctx, stdCtx, "Messages added successfully", map[string]string{"message_id": body.MessageID})
+	})
+
+	// Get the message an external ID is mapped to
+	r.GET("/api/agent-server/messages/external/{external_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		externalID, err := pathParam(ctx, "external_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "External ID is required", perrors.NewErrInvalidRequest("External ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		message, err := svc.Conversation.GetMessageByExternalID(stdCtx, projectID, namespace, externalID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get message", err)
+			return
+		}
+
+		writeOKConditional(ctx, stdCtx, "OK", message)
 	})
 
 	// Get all messages till a specific run (previous_message_id)
@@ -172,7 +246,7 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", messages)
+		writeOKConditional(ctx, stdCtx, "OK", messages)
 	})
 
 	// Get specific thread by ID
@@ -202,7 +276,7 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", thread)
+		writeOKConditional(ctx, stdCtx, "OK", thread)
 	})
 
 	// Get specific conversation by ID
@@ -232,7 +306,93 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "OK", conv)
+		writeOKConditional(ctx, stdCtx, "OK", conv)
+	})
+
+	// Append a batch of messages, e.g. imported from another channel, to a thread of a conversation
+	r.POST("/api/agent-server/conversations/{conversation_id}/messages:batch", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		conversationID, err := pathParam(ctx, "conversation_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Conversation ID is required", perrors.NewErrInvalidRequest("Conversation ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body conversation.AppendMessagesRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		body.ProjectID = projectID
+		body.Namespace = namespace
+		body.ConversationID = conversationID
+		result, err := svc.Conversation.AppendMessages(stdCtx, &body)
+		switch {
+		case err == nil:
+			writeOK(ctx, stdCtx, "Messages appended successfully", result)
+		case errors.Is(err, conversation.ErrInvalidBatch):
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(ctx, stdCtx, "Conversation not found", perrors.New(perrors.ErrCodeNotFound, "Conversation not found", err))
+		case errors.Is(err, conversation.ErrThreadNotFound):
+			writeError(ctx, stdCtx, "Thread not found", perrors.New(perrors.ErrCodeNotFound, "Thread not found", err))
+		case errors.Is(err, conversation.ErrThreadMoved), errors.Is(err, conversation.ErrExternalIDExists):
+			writeError(ctx, stdCtx, err.Error(), perrors.New(perrors.ErrCodeConflict, err.Error(), err))
+		default:
+			writeError(ctx, stdCtx, "Failed to append messages", err)
+		}
+	})
+
+	// Get the structured trace of a run
+	r.GET("/api/agent-server/runs/{message_id}/trace", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		messageID, err := pathParam(ctx, "message_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Message ID is required", perrors.NewErrInvalidRequest("Message ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		runTrace, err := svc.Conversation.GetRunTrace(stdCtx, projectID, namespace, messageID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get run trace", err)
+			return
+		}
+
+		// The request log of the gateway is the trace of the run, when the traces are collected
+		if svc.Traces != nil && runTrace.TraceID != "" {
+			if trace, err := svc.Traces.GetTrace(stdCtx, runTrace.TraceID); err == nil {
+				conversation.AttachGatewayRequests(runTrace, trace.Spans)
+			} else {
+				slog.DebugContext(stdCtx, "Failed to get the trace of the run", slog.String("trace_id", runTrace.TraceID), slog.Any("error", err))
+			}
+		}
+
+		writeOK(ctx, stdCtx, "OK", runTrace)
 	})
 
 	// Save summary
@@ -264,3 +424,37 @@ func RegisterConversationRoutes(r *router.Router, svc *services.Services) {
 		writeOK(ctx, stdCtx, "Summary saved successfully", nil)
 	})
 }
+
+// setMessagePinned returns the handler pinning or unpinning a message
+func setMessagePinned(svc *services.Services, pinned bool) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		messageID, err := pathParam(ctx, "message_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Message ID is required", perrors.NewErrInvalidRequest("Message ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		err = svc.Conversation.SetMessagePinned(stdCtx, projectID, namespace, messageID, pinned)
+		switch {
+		case err == nil:
+			writeOK(ctx, stdCtx, "OK", map[string]any{"message_id": messageID, "pinned": pinned})
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(ctx, stdCtx, "Message not found", perrors.New(perrors.ErrCodeNotFound, "Message not found", err))
+		default:
+			writeError(ctx, stdCtx, "Failed to pin message", err)
+		}
+	}
+}
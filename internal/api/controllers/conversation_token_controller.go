@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/conversation_token"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterConversationTokenRoutes registers the route the backends of browser clients issue conversation tokens
+// with, so that the clients converse without holding the credentials of the project
+func RegisterConversationTokenRoutes(r *router.Router, svc *services.Services) {
+	r.POST("/api/agent-server/conversation-tokens", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body conversation_token.IssueTokenRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		token, err := svc.ConversationToken.Issue(stdCtx, projectID, &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to issue conversation token", perrors.NewErrInvalidRequest("Failed to issue conversation token", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Conversation token issued successfully", token)
+	})
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// streamFormat is the format of the chunks of a run in a response
+type streamFormat string
+
+const (
+	streamFormatSSE    streamFormat = "sse"    // text/event-stream, the default
+	streamFormatNDJSON streamFormat = "ndjson" // application/x-ndjson, one JSON chunk per line
+	streamFormatJSON   streamFormat = "json"   // application/json, a single response once the run ends
+)
+
+// ConverseResponse is the response of the converse endpoint when it doesn't stream
+type ConverseResponse struct {
+	RunID            string                          `json:"run_id,omitempty"`
+	Status           string                          `json:"status"` // "completed", "paused" or "taken_over"
+	Output           []responses.OutputMessageUnion  `json:"output"`
+	PendingToolCalls []responses.FunctionCallMessage `json:"pending_tool_calls,omitempty"`
+	Usage            *responses.Usage                `json:"usage,omitempty"`
+	TraceID          string                          `json:"traceid,omitempty"`
+	DryRun           *responses.DryRun               `json:"dry_run,omitempty"`
+
+	// ApprovalID is set when the run paused for approval is kept waiting by a durable runtime, the approval is given
+	// on the approval endpoint of the message instead of conversing
+	ApprovalID string `json:"approval_id,omitempty"`
+
+	// Takeover is set when an operator has taken over the thread, the message was held for them
+	Takeover *responses.ChunkTakeover[constants.ChunkTypeTakeoverActive] `json:"takeover,omitempty"`
+}
+
+// streamFormatFromRequest negotiates the format of the response. A request with "stream": false gets a single JSON
+// response, otherwise the first supported media type of the Accept header wins: text/event-stream,
+// application/x-ndjson (or application/ndjson, application/jsonl) or application/json. SSE is the default.
+func streamFormatFromRequest(reqCtx *fasthttp.RequestCtx, stream *bool) streamFormat {
+	if stream != nil && !*stream {
+		return streamFormatJSON
+	}
+
+	for _, accepted := range strings.Split(string(reqCtx.Request.Header.Peek(fasthttp.HeaderAccept)), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "text/event-stream":
+			return streamFormatSSE
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return streamFormatNDJSON
+		case "application/json":
+			return streamFormatJSON
+		}
+	}
+
+	return streamFormatSSE
+}
+
+// respondRunEvents answers with the chunks of a run in the negotiated format. Streamed formats return at once and
+// stream from the body writer, the JSON response is written once the run ends.
+func respondRunEvents(ctx context.Context, reqCtx *fasthttp.RequestCtx, events <-chan *core.RunEvent, span trace.Span, pipeline *responses.ChunkPipeline, format streamFormat) {
+	span.SetAttributes(attribute.String("response_format", string(format)))
+
+	switch format {
+	case streamFormatJSON:
+		bufferRunEvents(ctx, reqCtx, events, span, pipeline)
+	case streamFormatNDJSON:
+		reqCtx.Response.Header.Set("Content-Type", "application/x-ndjson")
+		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+		reqCtx.SetStatusCode(fasthttp.StatusOK)
+		streamRunEventsAs(ctx, reqCtx, events, span, pipeline, format)
+	default:
+		reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
+		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+		reqCtx.SetStatusCode(fasthttp.StatusOK)
+		streamRunEventsAs(ctx, reqCtx, events, span, pipeline, format)
+	}
+}
+
+// bufferRunEvents reads the chunks of a run until it completes, pauses or is held for an operator, and writes them
+// as a single ConverseResponse. The chunks go through the pipeline like streamed ones.
+func bufferRunEvents(ctx context.Context, reqCtx *fasthttp.RequestCtx, events <-chan *core.RunEvent, span trace.Span, pipeline *responses.ChunkPipeline) {
+	defer span.End()
+
+	var chunks []*responses.ResponseChunk
+	out := pipeline.NewStream(ctx, func(m *responses.ResponseChunk) {
+		chunks = append(chunks, m)
+	})
+
+	ended := false
+	for !ended {
+		select {
+		case <-ctx.Done():
+			ended = true
+		case event, ok := <-events:
+			if !ok {
+				ended = true
+				break
+			}
+
+			m := event.Chunk
+			out.Push(m)
+
+			if m.OfProvenance != nil {
+				setProvenanceTrailers(reqCtx, &m.OfProvenance.Provenance)
+			}
+
+			ended = m.OfRunCompleted != nil || m.OfRunPaused != nil || m.OfRunFailed != nil || m.OfTakeoverActive != nil
+		}
+	}
+	out.Flush()
+
+	result, err := converseResponseOf(chunks)
+	if err != nil {
+		RecordSpanError(span, err)
+		writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
+		return
+	}
+
+	writeOK(reqCtx, ctx, "OK", result)
+}
+
+// converseResponseOf accumulates the chunks of a run that completed, paused or was held for an operator into a
+// ConverseResponse, the failed runs are errors
+func converseResponseOf(chunks []*responses.ResponseChunk) (*ConverseResponse, error) {
+	result := &ConverseResponse{}
+	var failure *responses.ChunkRunError
+	buffered := make(chan *responses.ResponseChunk, len(chunks))
+	for _, chunk := range chunks {
+		buffered <- chunk
+	}
+	close(buffered)
+
+	acc := agents.Accumulator{}
+	resp, _ := acc.ReadStream(context.Background(), buffered, func(chunk *responses.ResponseChunk) {
+		switch {
+		case chunk.OfRunCreated != nil:
+			result.RunID = chunk.OfRunCreated.RunState.Id
+		case chunk.OfRunCompleted != nil:
+			setConverseRunState(result, &chunk.OfRunCompleted.RunState)
+		case chunk.OfRunPaused != nil:
+			setConverseRunState(result, &chunk.OfRunPaused.RunState)
+		case chunk.OfRunFailed != nil:
+			failure = chunk.OfRunFailed.RunState.Error
+		case chunk.OfTakeoverActive != nil:
+			result.Status = "taken_over"
+			result.Takeover = chunk.OfTakeoverActive
+		}
+	})
+
+	if failure != nil {
+		return nil, fmt.Errorf("the run failed (%s): %s", failure.Code, failure.Message)
+	}
+
+	// Dry runs end after the rendered request, without completing
+	if result.Status == "" && resp.DryRun != nil {
+		result.Status = "completed"
+	}
+	if result.Status == "" {
+		return nil, errors.New("the run ended before completing")
+	}
+
+	result.Output = resp.Output
+	result.DryRun = resp.DryRun
+	return result, nil
+}
+
+func setConverseRunState(result *ConverseResponse, state *responses.ChunkRunData) {
+	result.RunID = state.Id
+	result.Status = state.Status
+	result.PendingToolCalls = state.PendingToolCalls
+	result.Usage = &state.Usage
+	result.TraceID = state.TraceID
+	result.ApprovalID = state.ApprovalID
+}
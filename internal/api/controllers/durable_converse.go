@@ -6,29 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	json "github.com/bytedance/sonic"
-	"github.com/curaious/uno/internal/agent_builder/builder"
-	"github.com/curaious/uno/internal/agent_builder/restate_agent_builder"
 	"github.com/curaious/uno/internal/config"
 	"github.com/curaious/uno/internal/perrors"
 	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/agent-framework/agents"
 	"github.com/curaious/uno/pkg/agent-framework/core"
-	"github.com/curaious/uno/pkg/agent-framework/streaming"
-	"github.com/curaious/uno/pkg/gateway"
 	"github.com/curaious/uno/pkg/llm/responses"
-	"github.com/curaious/uno/pkg/sandbox"
 	"github.com/fasthttp/router"
 	"github.com/google/uuid"
-	restate "github.com/restatedev/sdk-go"
-	"github.com/restatedev/sdk-go/ingress"
 	"github.com/valyala/fasthttp"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -48,6 +41,8 @@ type ConverseRequest struct {
 	PreviousMessageID string                      `json:"previous_message_id" doc:"Previous run ID for threading"`
 	Context           map[string]any              `json:"context" doc:"Context to pass to prompt template"`
 	SessionID         string                      `json:"session_id" required:"true" doc:"Session ID"`
+	DryRun            bool                        `json:"dry_run" doc:"Return the request the provider would receive instead of running the agent"`
+	Stream            *bool                       `json:"stream,omitempty" doc:"Set to false to receive a single JSON response once the run ends instead of a stream"`
 }
 
 func getTemporalClient(conf *config.Config) client.Client {
@@ -79,19 +74,10 @@ func RecordSpanError(span trace.Span, err error) {
 	span.SetStatus(codes.Error, err.Error())
 }
 
-func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, llmGateway *gateway.LLMGateway, conf *config.Config, broker core.StreamBroker, sandboxManager sandbox.Manager) {
-	var temporalClient client.Client
-	if conf.TEMPORAL_SERVER_HOST_PORT != "" {
-		temporalClient = getTemporalClient(conf)
-	}
-
-	var restateClient *ingress.Client
-	if conf.RESTATE_SERVER_ENDPOINT != "" {
-		restateClient = ingress.NewClient(conf.RESTATE_SERVER_ENDPOINT, restate.WithHttpClient(&http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}))
-	}
-
+func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, runner *AgentRunner) {
 	r.POST("/api/agent-server/converse", func(reqCtx *fasthttp.RequestCtx) {
 		ctx, span := tracer.Start(reqCtx, "Controller.Converse")
+		ctx = responses.ContextWithEnqueuedAt(ctx, time.Now())
 		traceID := span.SpanContext().TraceID().String()
 		reqCtx.Response.Header.Set("X-Trace-Id", traceID)
 
@@ -109,35 +95,18 @@ func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, llmG
 			return
 		}
 
-		agentIDStr := string(reqCtx.QueryArgs().Peek("agent_id"))
-		if agentIDStr == "" {
-			RecordSpanError(span, errors.New("agent_id is required"))
-			writeError(reqCtx, ctx, "Agent ID is required", perrors.NewErrInvalidRequest("agent_id parameter is required", errors.New("agent_id parameter is required")))
-			return
-		}
-
-		version := 0
-		frag := strings.Split(agentIDStr, ":")
-
-		agentID, err := uuid.Parse(frag[0])
+		pipeline, err := streamPipelineFromQuery(reqCtx)
 		if err != nil {
 			RecordSpanError(span, err)
-			writeError(reqCtx, ctx, "unable to parse agent id", perrors.NewErrInternalServerError(err.Error(), err))
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
 			return
 		}
 
-		if len(frag) > 1 {
-			agentIDStr = frag[0]
-			v, err := strconv.Atoi(frag[1])
-			if err != nil {
-				v, err = svc.AgentConfig.GetAgentVersionByAlias(ctx, projectID, agentID, frag[1])
-			}
-			if err != nil {
-				RecordSpanError(span, err)
-				writeError(reqCtx, ctx, "invalid version or alias", perrors.NewErrInternalServerError(err.Error(), err))
-				return
-			}
-			version = v
+		agentIDStr := string(reqCtx.QueryArgs().Peek("agent_id"))
+		if agentIDStr == "" {
+			RecordSpanError(span, errors.New("agent_id is required"))
+			writeError(reqCtx, ctx, "Agent ID is required", perrors.NewErrInvalidRequest("agent_id parameter is required", errors.New("agent_id parameter is required")))
+			return
 		}
 
 		// Parse request body first to get message_id for trace ID
@@ -150,11 +119,14 @@ func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, llmG
 
 		span.SetAttributes(
 			attribute.String("project_id", projectIDStr),
-			attribute.String("agent_id", agentIDStr),
+			attribute.String("agent_id", strings.Split(agentIDStr, ":")[0]),
 			attribute.String("namespace", reqPayload.Namespace),
 			attribute.String("session_id", reqPayload.SessionID),
 		)
 
+		// SSE by default, the clients that can't consume SSE ask for NDJSON or a single JSON response
+		format := streamFormatFromRequest(reqCtx, reqPayload.Stream)
+
 		project, err := svc.Project.GetByID(ctx, projectID)
 		if err != nil {
 			RecordSpanError(span, err)
@@ -169,7 +141,7 @@ func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, llmG
 			return
 		}
 
-		agentConfig, err := svc.AgentConfig.GetByAgentIDAndVersion(ctx, projectID, agentID, version)
+		agentConfig, err := runner.ResolveAgentConfig(ctx, projectID, agentIDStr, requestEnvironment(reqCtx))
 		if err != nil {
 			RecordSpanError(span, err)
 			writeError(reqCtx, ctx, "unable to get agent config", perrors.NewErrInternalServerError(err.Error(), err))
@@ -177,163 +149,255 @@ func RegisterDurableConverseRoute(r *router.Router, svc *services.Services, llmG
 		}
 		span.SetAttributes(attribute.String("agent_name", agentConfig.Name))
 
-		reqHeaders := map[string]string{}
-		reqCtx.Request.Header.VisitAll(func(key, value []byte) {
-			reqHeaders[strings.ReplaceAll(string(key), "-", "_")] = string(value)
-		})
+		agentConfig, err = project.ApplyAgentDefaults(agentConfig)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to apply project defaults", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
 
-		contextData := map[string]any{
-			"Env":     utils.EnvironmentVariables(),
-			"Context": reqPayload.Context,
-			"Header":  reqHeaders,
+		// The namespace may override parts of the config, the effective config is recorded in the run state
+		var runMeta map[string]any
+		agentConfig, override, err := svc.AgentConfig.ApplyNamespaceOverride(ctx, agentConfig, reqPayload.Namespace)
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, "unable to apply namespace overrides", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+		if override != nil {
+			span.SetAttributes(attribute.String("namespace_override", override.Namespace))
+			runMeta = map[string]any{
+				"namespace_override": override.Namespace,
+				"effective_config":   agentConfig.Config,
+			}
+		}
+
+		// While an operator has taken over the thread, the message is held for them and the agent doesn't run
+		if reqPayload.PreviousMessageID != "" {
+			thread, takeover, err := svc.Conversation.GetTakeoverOfMessage(ctx, projectID, reqPayload.Namespace, reqPayload.PreviousMessageID)
+			if err == nil && takeover.Active {
+				holdForOperator(ctx, reqCtx, span, svc, runner, projectID, &reqPayload, thread, takeover, format)
+				return
+			}
 		}
 
 		in := &agents.AgentInput{
 			Namespace:         reqPayload.Namespace,
 			PreviousMessageID: reqPayload.PreviousMessageID,
 			Messages:          []responses.InputMessageUnion{reqPayload.Message},
-			RunContext:        contextData,
+			RunContext:        runContextFromRequest(reqCtx, reqPayload.Context),
+			RunMeta:           runMeta,
+			DryRun:            reqPayload.DryRun,
 		}
 
-		var runID string
-
-		reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
-		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
-		reqCtx.SetStatusCode(fasthttp.StatusOK)
+		if agentConfig.Config.Provenance {
+			setProvenanceHeaders(reqCtx, agentConfig)
+		}
 
-		switch *agentConfig.Config.Runtime {
-		case "Restate":
-			if restateClient == nil {
-				err = errors.New("restate runtime is not enabled")
-				RecordSpanError(span, err)
-				writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
-				return
+		// With a run event store, other subscribers can attach to the run with the stream ID
+		var events <-chan *core.RunEvent
+		if runner.Broadcasts() {
+			var streamID string
+			streamID, events, err = runner.StartBroadcast(ctx, span, agentConfig, in, *project.DefaultKey)
+			if err == nil {
+				reqCtx.Response.Header.Set("X-Uno-Stream-Id", streamID)
 			}
-			runID = uuid.New().String()
-			// Subscribe first to ensure we don't miss any chunks
-			stream, subErr := broker.Subscribe(ctx, runID)
-			if subErr != nil {
-				RecordSpanError(span, subErr)
-				writeError(reqCtx, ctx, "failed to subscribe to stream", perrors.NewErrInternalServerError(subErr.Error(), subErr))
-				return
-			}
-
-			// Start workflow in goroutine so the handler can return and streaming can begin
-			go func() {
-				_, err := ingress.Workflow[*restate_agent_builder.WorkflowInput, *agents.AgentOutput](
-					restateClient,
-					"AgentBuilder",
-					runID,
-					"BuildAndExecuteAgent",
-				).Request(ctx, &restate_agent_builder.WorkflowInput{
-					AgentConfig: agentConfig,
-					Input:       in,
-					Key:         *project.DefaultKey,
-				})
-				if err != nil {
-					RecordSpanError(span, err)
-				}
-			}()
+		} else {
+			var stream <-chan *responses.ResponseChunk
+			stream, err = runner.Start(ctx, span, agentConfig, in, *project.DefaultKey)
+			events = runEventsOf(stream)
+		}
+		if err == nil && project.ClientSafeStream {
+			events = clientSafeRunEventsOf(events)
+		}
+		if err != nil {
+			RecordSpanError(span, err)
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
 
-			// Stream chunks - this allows the handler to return so streaming can start
-			streamChunksFromChannel(ctx, reqCtx, stream, span)
+		// Stream chunks - this allows the handler to return so streaming can start
+		respondRunEvents(ctx, reqCtx, events, span, pipeline, format)
+	})
+}
 
-		case "Temporal":
-			if temporalClient == nil {
-				err = errors.New("temporal runtime is not enabled")
-				RecordSpanError(span, err)
-				writeError(reqCtx, ctx, err.Error(), perrors.NewErrInternalServerError(err.Error(), err))
-				return
-			}
+// runEventsOf wraps the chunks of a run that isn't recorded, they have no offset
+func runEventsOf(stream <-chan *responses.ResponseChunk) <-chan *core.RunEvent {
+	if stream == nil {
+		return nil
+	}
 
-			runID = uuid.New().String()
-			// Subscribe first to ensure we don't miss any chunks
-			stream, subErr := broker.Subscribe(ctx, runID)
-			if subErr != nil {
-				RecordSpanError(span, subErr)
-				writeError(reqCtx, ctx, "failed to subscribe to stream", perrors.NewErrInternalServerError(subErr.Error(), subErr))
-				return
-			}
+	events := make(chan *core.RunEvent)
+	go func() {
+		defer close(events)
 
-			// Start workflow in goroutine so the handler can return and streaming can begin
-			go func() {
-				run, err := temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-					ID:        runID,
-					TaskQueue: "AgentBuilder",
-				}, "AgentBuilder", agentConfig, in, project.DefaultKey)
-				if err != nil {
-					RecordSpanError(span, err)
-					return
-				}
+		sequencer := &core.RunEventSequencer{}
+		for chunk := range stream {
+			events <- sequencer.Next(chunk)
+		}
+	}()
 
-				var output agents.AgentOutput
-				err = run.Get(ctx, &output)
-				if err != nil {
-					RecordSpanError(span, err)
-				}
-			}()
-
-			// Stream chunks - this allows the handler to return so streaming can start
-			streamChunksFromChannel(ctx, reqCtx, stream, span)
-
-		default:
-			b := streaming.NewMemoryStreamBroker()
-			// Subscribe first to ensure we don't miss any chunks
-			stream, subErr := b.Subscribe(ctx, "default")
-			if subErr != nil {
-				RecordSpanError(span, subErr)
-				writeError(reqCtx, ctx, "failed to subscribe to stream", perrors.NewErrInternalServerError(subErr.Error(), subErr))
-				return
-			}
+	return events
+}
 
-			in.Callback = func(chunk *responses.ResponseChunk) {
-				b.Publish(ctx, "default", chunk)
-			}
+// clientSafeRunEventsOf strips the encrypted content, extra params and internal IDs from the chunks of the events,
+// the chunks recorded for the history and the other subscribers keep them
+func clientSafeRunEventsOf(events <-chan *core.RunEvent) <-chan *core.RunEvent {
+	if events == nil {
+		return nil
+	}
 
-			// Start execution in goroutine so the handler can return and streaming can begin
-			go func() {
-				defer b.Close(ctx, "default")
-				_, err := builder.NewAgentBuilder(svc, llmGateway, b, sandboxManager).BuildAndExecuteAgent(ctx, agentConfig, in, *project.DefaultKey)
-				if err != nil {
-					RecordSpanError(span, err)
-				}
-			}()
+	safe := make(chan *core.RunEvent)
+	go func() {
+		defer close(safe)
 
-			// Stream chunks - this allows the handler to return so streaming can start
-			streamChunksFromChannel(ctx, reqCtx, stream, span)
+		for event := range events {
+			redacted := *event
+			redacted.Chunk = responses.ClientSafeChunk(event.Chunk)
+			safe <- &redacted
 		}
+	}()
+
+	return safe
+}
 
+// runContextFromRequest builds the data available to prompt templates: environment variables,
+// the request context and the request headers (with "-" replaced by "_")
+func runContextFromRequest(reqCtx *fasthttp.RequestCtx, requestContext map[string]any) map[string]any {
+	reqHeaders := map[string]string{}
+	reqCtx.Request.Header.VisitAll(func(key, value []byte) {
+		reqHeaders[strings.ReplaceAll(string(key), "-", "_")] = string(value)
 	})
+
+	return map[string]any{
+		"Env":     utils.EnvironmentVariables(),
+		"Context": requestContext,
+		"Header":  reqHeaders,
+	}
 }
 
-// streamChunksFromChannel sets up SSE streaming from a pre-subscribed channel.
+// streamRunEvents sets up SSE streaming from a pre-subscribed channel.
 // The channel must be subscribed BEFORE the workflow starts to avoid missing chunks.
 // This function sets up SetBodyStreamWriter and returns immediately, allowing
 // the HTTP handler to return so fasthttp can begin streaming the response.
-func streamChunksFromChannel(ctx context.Context, reqCtx *fasthttp.RequestCtx, stream <-chan *responses.ResponseChunk, span trace.Span) {
+// Chunks are passed through the given pipeline (which may be nil) before being written.
+// The offsets of recorded chunks are written as event IDs, except with a pipeline, which may merge chunks.
+func streamRunEvents(ctx context.Context, reqCtx *fasthttp.RequestCtx, events <-chan *core.RunEvent, span trace.Span, pipeline *responses.ChunkPipeline) {
+	streamRunEventsAs(ctx, reqCtx, events, span, pipeline, streamFormatSSE)
+}
+
+// streamRunEventsAs is streamRunEvents writing the chunks in the given format, SSE or NDJSON. With the envelope
+// query parameter, the chunks are wrapped in a core.RunEventEnvelope carrying their run, sequence number and
+// offset, the same on every stream of runs. Otherwise NDJSON lines carry no offsets.
+func streamRunEventsAs(ctx context.Context, reqCtx *fasthttp.RequestCtx, events <-chan *core.RunEvent, span trace.Span, pipeline *responses.ChunkPipeline, format streamFormat) {
+	envelope := reqCtx.QueryArgs().GetBool("envelope")
+
 	reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
 		defer w.Flush()
 		defer span.End()
 
+		var last *core.RunEvent
+		out := pipeline.NewStream(ctx, func(m *responses.ResponseChunk) {
+			// A pipeline may merge chunks, so the chunks it writes can't be resumed from
+			offset := ""
+			if last != nil && pipeline.IsEmpty() {
+				offset = last.Offset
+			}
+
+			var buf []byte
+			if envelope && last != nil {
+				wrapped := core.NewRunEventEnvelope(last, m)
+				wrapped.ID = offset
+				buf, _ = json.Marshal(wrapped)
+			} else {
+				buf, _ = json.Marshal(m)
+			}
+
+			if format == streamFormatNDJSON {
+				_, _ = w.Write(buf)
+				_ = w.WriteByte('\n')
+				_ = w.Flush()
+				return
+			}
+
+			if offset != "" {
+				_, _ = fmt.Fprintf(w, "id: %s\n", offset)
+			}
+			_, _ = fmt.Fprintf(w, "event: %s\n", m.ChunkType())
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", string(buf))
+			_ = w.Flush()
+		})
+		defer out.Flush()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case m, ok := <-stream:
+			case <-out.Expiry():
+				out.Expire()
+			case event, ok := <-events:
 				if !ok {
 					return
 				}
 
-				buf, _ := json.Marshal(m)
+				m := event.Chunk
+				last = event
+				out.Push(m)
 
-				_, _ = fmt.Fprintf(w, "event: %s\n", m.ChunkType())
-				_, _ = fmt.Fprintf(w, "data: %s\n\n", string(buf))
-				_ = w.Flush()
+				if m.OfProvenance != nil {
+					setProvenanceTrailers(reqCtx, &m.OfProvenance.Provenance)
+				}
 
-				if m.OfRunCompleted != nil || m.OfRunPaused != nil {
+				if m.OfRunCompleted != nil || m.OfRunPaused != nil || m.OfRunFailed != nil {
 					return
 				}
 			}
 		}
 	})
 }
+
+// setProvenanceHeaders labels the stream as AI generated with the model configured for the agent. The run ID and
+// generation time are only known once the output is complete, so they are declared as trailers.
+func setProvenanceHeaders(reqCtx *fasthttp.RequestCtx, agentConfig *agent_config.AgentConfig) {
+	reqCtx.Response.Header.Set("X-Uno-AI-Generated", "true")
+	reqCtx.Response.Header.Set("X-Uno-Agent", agentConfig.Name)
+	if model := agentConfig.Config.Model; model != nil {
+		reqCtx.Response.Header.Set("X-Uno-Model", model.ModelID)
+		reqCtx.Response.Header.Set("X-Uno-Provider", model.ProviderType)
+	}
+	_ = reqCtx.Response.Header.SetTrailer("X-Uno-Run-Id, X-Uno-Generated-At")
+}
+
+// setProvenanceTrailers sets the trailers declared by setProvenanceHeaders, they are written after the last chunk
+func setProvenanceTrailers(reqCtx *fasthttp.RequestCtx, provenance *responses.Provenance) {
+	reqCtx.Response.Header.Set("X-Uno-Run-Id", provenance.RunID)
+	reqCtx.Response.Header.Set("X-Uno-Generated-At", provenance.GeneratedAt.Format(time.RFC3339))
+}
+
+// streamPipelineFromQuery builds the SSE chunk pipeline from the optional coalesce_ms and coalesce_bytes
+// query parameters. When either is set, consecutive text deltas are merged before being written.
+func streamPipelineFromQuery(reqCtx *fasthttp.RequestCtx) (*responses.ChunkPipeline, error) {
+	var opts responses.CoalesceOptions
+
+	if v := string(reqCtx.QueryArgs().Peek("coalesce_ms")); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return nil, errors.New("coalesce_ms must be a non-negative integer")
+		}
+		opts.Window = time.Duration(ms) * time.Millisecond
+	}
+
+	if v := string(reqCtx.QueryArgs().Peek("coalesce_bytes")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, errors.New("coalesce_bytes must be a non-negative integer")
+		}
+		opts.MaxBytes = n
+	}
+
+	if opts.Window == 0 && opts.MaxBytes == 0 {
+		return nil, nil
+	}
+
+	return responses.NewChunkPipeline(responses.CoalesceTextDeltas(opts)), nil
+}
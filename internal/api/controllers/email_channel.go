@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/integrations/email"
+	"github.com/curaious/uno/internal/services"
+	"github.com/google/uuid"
+)
+
+// StartEmailChannel starts polling the mailbox of the email channel. The channel has no endpoint, its emails are
+// polled with IMAP and answered with SMTP.
+func StartEmailChannel(svc *services.Services, runner *AgentRunner, conf *config.Config) {
+	projectID, err := uuid.Parse(conf.EMAIL_PROJECT_ID)
+	if err != nil || conf.EMAIL_AGENT == "" || conf.EMAIL_ADDRESS == "" {
+		slog.Error("The email channel needs EMAIL_PROJECT_ID, EMAIL_AGENT and EMAIL_ADDRESS, it is disabled", slog.Any("error", err))
+		return
+	}
+
+	sender := &email.SMTPSender{
+		Addr:     conf.EMAIL_SMTP_ADDR,
+		Username: conf.EMAIL_SMTP_USERNAME,
+		Password: conf.EMAIL_SMTP_PASSWORD,
+	}
+	adapter := email.NewAdapter(sender, &integrationBackend{svc: svc, runner: runner}, svc.Email, email.Options{
+		ProjectID:    projectID,
+		Agent:        conf.EMAIL_AGENT,
+		Address:      conf.EMAIL_ADDRESS,
+		IMAPAddr:     conf.EMAIL_IMAP_ADDR,
+		IMAPUsername: conf.EMAIL_IMAP_USERNAME,
+		IMAPPassword: conf.EMAIL_IMAP_PASSWORD,
+		Mailbox:      conf.EMAIL_IMAP_MAILBOX,
+		PollInterval: time.Duration(conf.EMAIL_POLL_INTERVAL_SECONDS) * time.Second,
+	})
+
+	go adapter.Poll(context.Background())
+}
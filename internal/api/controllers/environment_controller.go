@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"github.com/curaious/uno/internal/api/authenticator"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/environment"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// anonymousUser reviews and requests promotions when authentication is disabled. Environments that require
+// more than one approval can't be promoted to in that case, as each user can only approve once.
+const anonymousUser = "anonymous"
+
+// currentUserID returns the ID of the authenticated user
+func currentUserID(ctx *fasthttp.RequestCtx) string {
+	claims, ok := ctx.UserValue("userClaims").(*authenticator.UserClaims)
+	if !ok || claims == nil || claims.UserID == "" {
+		return anonymousUser
+	}
+	return claims.UserID
+}
+
+// RegisterEnvironmentRoutes registers routes to manage environments and promote agent and prompt versions between them
+func RegisterEnvironmentRoutes(r *router.Router, svc *services.Services) {
+	r.GET("/api/agent-server/environments", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		envs, err := svc.Environment.List(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list environments", perrors.NewErrInternalServerError("Failed to list environments", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Environments retrieved successfully", envs)
+	})
+
+	r.POST("/api/agent-server/environments", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body environment.CreateEnvironmentRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		env, err := svc.Environment.Create(stdCtx, projectID, &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to create environment", perrors.NewErrInvalidRequest("Failed to create environment", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Environment created successfully", env)
+	})
+
+	r.PUT("/api/agent-server/environments/{name}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		name, err := pathParam(ctx, "name")
+		if err != nil {
+			writeError(ctx, stdCtx, "Environment name is required", perrors.NewErrInvalidRequest("Environment name is required", err))
+			return
+		}
+
+		var body environment.UpdateEnvironmentRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		env, err := svc.Environment.Update(stdCtx, projectID, name, &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to update environment", perrors.NewErrInvalidRequest("Failed to update environment", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Environment updated successfully", env)
+	})
+
+	r.DELETE("/api/agent-server/environments/{name}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		name, err := pathParam(ctx, "name")
+		if err != nil {
+			writeError(ctx, stdCtx, "Environment name is required", perrors.NewErrInvalidRequest("Environment name is required", err))
+			return
+		}
+
+		if err := svc.Environment.Delete(stdCtx, projectID, name); err != nil {
+			writeError(ctx, stdCtx, "Failed to delete environment", perrors.NewErrInvalidRequest("Failed to delete environment", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Environment deleted successfully", nil)
+	})
+
+	// What is deployed to each environment
+	r.GET("/api/agent-server/environments/deployments", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		deployments, err := svc.Environment.ListDeployments(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list deployments", perrors.NewErrInternalServerError("Failed to list deployments", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Deployments retrieved successfully", deployments)
+	})
+
+	r.GET("/api/agent-server/promotions", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		promotions, err := svc.Environment.ListPromotions(stdCtx, projectID, string(ctx.QueryArgs().Peek("status")))
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list promotions", perrors.NewErrInternalServerError("Failed to list promotions", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Promotions retrieved successfully", promotions)
+	})
+
+	r.POST("/api/agent-server/promotions", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body environment.CreatePromotionRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		promotion, err := svc.Environment.CreatePromotion(stdCtx, projectID, &body, currentUserID(ctx))
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to create promotion", perrors.NewErrInvalidRequest("Failed to create promotion", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Promotion created successfully", promotion)
+	})
+
+	r.GET("/api/agent-server/promotions/{id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, id, err := promotionParams(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid request", perrors.NewErrInvalidRequest("Invalid request", err))
+			return
+		}
+
+		promotion, err := svc.Environment.GetPromotion(stdCtx, projectID, id)
+		if err != nil {
+			writeError(ctx, stdCtx, "Promotion not found", perrors.New(perrors.ErrCodeNotFound, "Promotion not found", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Promotion retrieved successfully", promotion)
+	})
+
+	r.POST("/api/agent-server/promotions/{id}/approve", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, id, err := promotionParams(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid request", perrors.NewErrInvalidRequest("Invalid request", err))
+			return
+		}
+
+		var body environment.ReviewPromotionRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := parseBody(ctx, &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		promotion, err := svc.Environment.ApprovePromotion(stdCtx, projectID, id, currentUserID(ctx), &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to approve promotion", perrors.New(perrors.ErrCodeConflict, "Failed to approve promotion", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Promotion approved", promotion)
+	})
+
+	r.POST("/api/agent-server/promotions/{id}/reject", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, id, err := promotionParams(ctx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid request", perrors.NewErrInvalidRequest("Invalid request", err))
+			return
+		}
+
+		var body environment.ReviewPromotionRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := parseBody(ctx, &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		promotion, err := svc.Environment.RejectPromotion(stdCtx, projectID, id, currentUserID(ctx), &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to reject promotion", perrors.New(perrors.ErrCodeConflict, "Failed to reject promotion", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Promotion rejected", promotion)
+	})
+}
+
+func promotionParams(ctx *fasthttp.RequestCtx) (uuid.UUID, uuid.UUID, error) {
+	projectID, err := requireUUIDQuery(ctx, "project_id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	idStr, err := pathParam(ctx, "id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return projectID, id, nil
+}
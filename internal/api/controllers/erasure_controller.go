@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/erasure"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterErasureRoutes registers the routes to erase the data of a data subject and verify erasure reports
+func RegisterErasureRoutes(r *router.Router, svc *services.Services) {
+	r.POST("/api/agent-server/erasures", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body erasure.EraseSubjectRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if body.Subject == "" {
+			writeError(ctx, stdCtx, "Subject is required", perrors.NewErrInvalidRequest("Subject is required", errors.New("subject is required")))
+			return
+		}
+
+		if body.Mode != "" && body.Mode != erasure.ModeDelete && body.Mode != erasure.ModeAnonymize {
+			writeError(ctx, stdCtx, "Mode must be delete or anonymize", perrors.NewErrInvalidRequest("Mode must be delete or anonymize", errors.New("invalid mode")))
+			return
+		}
+
+		report, err := svc.Erasure.EraseSubject(stdCtx, projectID, &body)
+		if err != nil {
+			if errors.Is(err, db.ErrRegionUnavailable) {
+				writeError(ctx, stdCtx, "Region is not available", perrors.NewErrInternalServerError("Region is not available", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to erase subject", perrors.NewErrInternalServerError("Failed to erase subject", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Subject erased", report)
+	})
+
+	r.POST("/api/agent-server/erasures/verify", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		var report erasure.ErasureReport
+		if err := parseBody(ctx, &report); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if err := svc.Erasure.VerifyReport(&report); err != nil {
+			if errors.Is(err, erasure.ErrInvalidSignature) {
+				writeError(ctx, stdCtx, "Invalid signature", perrors.NewErrInvalidRequest("Invalid signature", err))
+				return
+			}
+			writeError(ctx, stdCtx, "Failed to verify erasure report", perrors.NewErrInternalServerError("Failed to verify erasure report", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Erasure report is valid", report)
+	})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/curaious/uno/internal/services"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/gateway/middlewares/response_store_middleware"
 	"github.com/curaious/uno/pkg/gateway/providers/anthropic/anthropic_responses"
 	"github.com/curaious/uno/pkg/gateway/providers/gemini/gemini_embeddings"
 	"github.com/curaious/uno/pkg/gateway/providers/gemini/gemini_responses"
@@ -29,6 +31,36 @@ import (
 )
 
 func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *gateway.LLMGateway) {
+	// Completion of the Replicate predictions, the clients waiting for them poll them otherwise
+	r.Handle(http.MethodPost, "/replicate/webhook", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		webhooks := llmGateway.ReplicateWebhooks()
+		if webhooks == nil {
+			writeError(ctx, stdCtx, "Replicate webhooks are not enabled", perrors.New(perrors.ErrCodeNotFound, "Replicate webhooks are not enabled", nil))
+			return
+		}
+
+		body := ctx.PostBody()
+		err := webhooks.Verify(
+			string(ctx.Request.Header.Peek("webhook-id")),
+			string(ctx.Request.Header.Peek("webhook-timestamp")),
+			string(ctx.Request.Header.Peek("webhook-signature")),
+			body,
+		)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid signature", perrors.New(perrors.ErrCodeUnauthorized, "Invalid signature", err))
+			return
+		}
+
+		if err := webhooks.Deliver(body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})
+
 	r.Handle(http.MethodPost, "/responses", func(reqCtx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(reqCtx)
 
@@ -55,7 +87,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 			OfResponsesInput: nativeRequest,
 		}
 
-		frags := strings.Split(nativeRequest.Model, ":")
+		frags := strings.SplitN(nativeRequest.Model, ":", 2)
 		providerName := llm.ProviderName(frags[0])
 		model := frags[1]
 		nativeRequest.Model = model
@@ -63,7 +95,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		// Handle non-streaming request
 		if !nativeRequest.IsStreamingRequest() {
 			// Call gateway to handle the gateway request
-			out, err := llmGateway.HandleRequest(ctx, providerName, vk, req)
+			out, err := llmGateway.HandleRequest(regionContext(ctx, reqCtx), providerName, vk, req)
 			if err != nil {
 				writeError(reqCtx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 				span.RecordError(err)
@@ -94,7 +126,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Handling streaming request
-		out, err := llmGateway.HandleStreamingRequest(ctx, providerName, vk, req)
+		out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, reqCtx), providerName, vk, req)
 		if err != nil {
 			writeError(reqCtx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 			span.RecordError(err)
@@ -131,6 +163,64 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 			}
 		})
 	})
+	r.Handle(http.MethodGet, "/responses/{id}", func(reqCtx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(reqCtx)
+
+		id, err := pathParam(reqCtx, "id")
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Invalid response id", perrors.NewErrInvalidRequest("Invalid response id", err))
+			return
+		}
+
+		owner, err := response_store_middleware.ResponseOwner(llmGateway.ConfigStore, extractKey(reqCtx))
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Error getting response", storedResponseError(err))
+			return
+		}
+
+		stored, err := svc.GatewayResponse.GetResponse(stdCtx, owner, id)
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Error getting response", storedResponseError(err))
+			return
+		}
+
+		buf, err := sonic.Marshal(stored.ToResponse())
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Error marshalling response", perrors.NewErrInternalServerError("Error marshalling response", err))
+			return
+		}
+
+		reqCtx.SetContentType("application/json")
+		_, _ = reqCtx.Write(buf)
+	})
+	r.Handle(http.MethodDelete, "/responses/{id}", func(reqCtx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(reqCtx)
+
+		id, err := pathParam(reqCtx, "id")
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Invalid response id", perrors.NewErrInvalidRequest("Invalid response id", err))
+			return
+		}
+
+		owner, err := response_store_middleware.ResponseOwner(llmGateway.ConfigStore, extractKey(reqCtx))
+		if err != nil {
+			writeError(reqCtx, stdCtx, "Error deleting response", storedResponseError(err))
+			return
+		}
+
+		if err := svc.GatewayResponse.DeleteResponse(stdCtx, owner, id); err != nil {
+			writeError(reqCtx, stdCtx, "Error deleting response", storedResponseError(err))
+			return
+		}
+
+		buf, _ := sonic.Marshal(map[string]any{"id": id, "object": "response", "deleted": true})
+		reqCtx.SetContentType("application/json")
+		_, _ = reqCtx.Write(buf)
+	})
+	r.Handle(http.MethodGet, "/regions", func(reqCtx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(reqCtx)
+		writeOK(reqCtx, stdCtx, "Region stats retrieved", llmGateway.Regions.Stats())
+	})
 	r.Handle(http.MethodPost, "/embeddings", func(reqCtx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(reqCtx)
 
@@ -158,12 +248,12 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 			OfEmbeddingsInput: nativeRequest,
 		}
 
-		frags := strings.Split(nativeRequest.Model, ":")
+		frags := strings.SplitN(nativeRequest.Model, ":", 2)
 		providerName := llm.ProviderName(frags[0])
 		model := frags[1]
 		nativeRequest.Model = model
 
-		out, err := llmGateway.HandleRequest(ctx, providerName, vk, req)
+		out, err := llmGateway.HandleRequest(regionContext(ctx, reqCtx), providerName, vk, req)
 		if err != nil {
 			writeError(reqCtx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 			span.RecordError(err)
@@ -215,7 +305,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		// Handle non-streaming request
 		if !nativeRequest.IsStreamingRequest() {
 			// Call gateway to handle the gateway request
-			out, err := llmGateway.HandleRequest(ctx, llm.ProviderNameAnthropic, vk, req)
+			out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), llm.ProviderNameAnthropic, vk, req)
 			if err != nil {
 				writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 				return
@@ -239,7 +329,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Handling streaming request
-		out, err := llmGateway.HandleStreamingRequest(ctx, llm.ProviderNameAnthropic, vk, req)
+		out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), llm.ProviderNameAnthropic, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 			return
@@ -342,7 +432,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		// Handle non-streaming request
 		if !nativeRequest.IsStreamingRequest() {
 			// Call gateway to handle the gateway request
-			out, err := llmGateway.HandleRequest(ctx, provider, vk, req)
+			out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), provider, vk, req)
 			if err != nil {
 				writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 				return
@@ -366,7 +456,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Handling streaming request
-		out, err := llmGateway.HandleStreamingRequest(ctx, llm.ProviderNameOpenAI, vk, req)
+		out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), llm.ProviderNameOpenAI, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 			return
@@ -422,7 +512,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Call gateway to handle the gateway request
-		out, err := llmGateway.HandleRequest(ctx, llm.ProviderNameOpenAI, vk, req)
+		out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), llm.ProviderNameOpenAI, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 			return
@@ -466,7 +556,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 
 		if !nativeRequest.IsStreamingRequest() {
 			// Call gateway to handle the gateway request
-			out, err := llmGateway.HandleRequest(stdCtx, llm.ProviderNameOpenAI, vk, req)
+			out, err := llmGateway.HandleRequest(regionContext(stdCtx, ctx), llm.ProviderNameOpenAI, vk, req)
 			if err != nil {
 				writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 				return
@@ -490,7 +580,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Handling streaming request
-		out, err := llmGateway.HandleStreamingRequest(ctx, llm.ProviderNameOpenAI, vk, req)
+		out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), llm.ProviderNameOpenAI, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 			return
@@ -554,7 +644,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 
 		if !nativeRequest.IsStreamingRequest() {
 			// Call gateway to handle the gateway request
-			out, err := llmGateway.HandleRequest(stdCtx, provider, vk, req)
+			out, err := llmGateway.HandleRequest(regionContext(stdCtx, ctx), provider, vk, req)
 			if err != nil {
 				writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 				return
@@ -575,7 +665,7 @@ func RegisterGatewayRoutes(r *router.Group, svc *services.Services, llmGateway *
 		}
 
 		// Handling streaming request
-		out, err := llmGateway.HandleStreamingRequest(ctx, provider, vk, req)
+		out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), provider, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 			return
@@ -637,7 +727,7 @@ func GeminiResponse(ctx *fasthttp.RequestCtx, stdCtx context.Context, model, act
 	// Handle non-streaming request
 	if !nativeRequest.IsStreamingRequest() {
 		// Call gateway to handle the gateway request
-		out, err := llmGateway.HandleRequest(ctx, llm.ProviderNameGemini, vk, req)
+		out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), llm.ProviderNameGemini, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 			return
@@ -661,7 +751,7 @@ func GeminiResponse(ctx *fasthttp.RequestCtx, stdCtx context.Context, model, act
 	}
 
 	// Handling streaming request
-	out, err := llmGateway.HandleStreamingRequest(ctx, llm.ProviderNameGemini, vk, req)
+	out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), llm.ProviderNameGemini, vk, req)
 	if err != nil {
 		writeError(ctx, stdCtx, "Error handling LLM Gateway streaming request", perrors.NewErrInternalServerError("Error handling LLM Gateway streaming request", err))
 		return
@@ -729,7 +819,7 @@ func GeminiEmbedding(ctx *fasthttp.RequestCtx, stdCtx context.Context, model, ac
 	}
 
 	// Call gateway to handle the gateway request
-	out, err := llmGateway.HandleRequest(ctx, llm.ProviderNameGemini, vk, req)
+	out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), llm.ProviderNameGemini, vk, req)
 	if err != nil {
 		writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 		return
@@ -773,7 +863,7 @@ func GeminiSpeech(ctx *fasthttp.RequestCtx, stdCtx context.Context, model, actio
 
 	if !isStream {
 		// Call gateway to handle the gateway request
-		out, err := llmGateway.HandleRequest(ctx, llm.ProviderNameGemini, vk, req)
+		out, err := llmGateway.HandleRequest(regionContext(ctx, ctx), llm.ProviderNameGemini, vk, req)
 		if err != nil {
 			writeError(ctx, stdCtx, "Error handling request", perrors.NewErrInternalServerError("Error handling request", err))
 			return
@@ -794,7 +884,7 @@ func GeminiSpeech(ctx *fasthttp.RequestCtx, stdCtx context.Context, model, actio
 		}
 	}
 
-	out, err := llmGateway.HandleStreamingRequest(ctx, llm.ProviderNameGemini, vk, req)
+	out, err := llmGateway.HandleStreamingRequest(regionContext(ctx, ctx), llm.ProviderNameGemini, vk, req)
 	if err != nil {
 		writeError(ctx, stdCtx, "Error handling streaming request", perrors.NewErrInternalServerError("Error handling streaming request", err))
 		return
@@ -868,3 +958,20 @@ func extractKey(ctx *fasthttp.RequestCtx) string {
 
 	return vk
 }
+
+// regionContext pins the gateway request to the region given in the x-uno-region header, if any
+func regionContext(ctx context.Context, reqCtx *fasthttp.RequestCtx) context.Context {
+	region := string(reqCtx.Request.Header.Peek("x-uno-region"))
+	if region == "" {
+		return ctx
+	}
+	return gateway.ContextWithRegion(ctx, region)
+}
+
+// storedResponseError answers the responses of other keys, and of unknown keys, as not found
+func storedResponseError(err error) error {
+	if errors.Is(err, response_store_middleware.ErrResponseNotFound) || errors.Is(err, gateway.ErrVirtualKeyNotFound) {
+		return perrors.New(perrors.ErrCodeNotFound, "Response not found", err)
+	}
+	return perrors.NewErrInternalServerError("Error reading stored response", err)
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+type HistorySpoolResponse struct {
+	Stats  *conversation.HistorySpoolStats `json:"stats"`
+	Writes []*conversation.SpooledWrite    `json:"writes"`
+}
+
+// RegisterHistorySpoolRoutes registers admin routes to inspect and manage the history writes queued for retry
+func RegisterHistorySpoolRoutes(r *router.Router, svc *services.Services) {
+	errSpoolDisabled := errors.New("history spool is not enabled")
+
+	r.GET("/api/agent-server/history-spool", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if svc.HistorySpool == nil {
+			writeError(ctx, stdCtx, "History spool is not enabled", perrors.New(perrors.ErrCodeNotImplemented, "History spool is not enabled", errSpoolDisabled))
+			return
+		}
+
+		stats, err := svc.HistorySpool.Stats()
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to read history spool", perrors.NewErrInternalServerError("Failed to read history spool", err))
+			return
+		}
+
+		writes, err := svc.HistorySpool.List()
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to read history spool", perrors.NewErrInternalServerError("Failed to read history spool", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "History spool retrieved", HistorySpoolResponse{Stats: stats, Writes: writes})
+	})
+
+	// Replay a queued write now, including writes that were given up on
+	r.POST("/api/agent-server/history-spool/{id}/retry", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if svc.HistorySpool == nil {
+			writeError(ctx, stdCtx, "History spool is not enabled", perrors.New(perrors.ErrCodeNotImplemented, "History spool is not enabled", errSpoolDisabled))
+			return
+		}
+
+		id, err := pathParam(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		if err := svc.HistorySpool.Retry(stdCtx, id); err != nil {
+			writeError(ctx, stdCtx, "Failed to replay history write", perrors.NewErrInternalServerError("Failed to replay history write", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "History write replayed", nil)
+	})
+
+	r.DELETE("/api/agent-server/history-spool/{id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if svc.HistorySpool == nil {
+			writeError(ctx, stdCtx, "History spool is not enabled", perrors.New(perrors.ErrCodeNotImplemented, "History spool is not enabled", errSpoolDisabled))
+			return
+		}
+
+		id, err := pathParam(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		if err := svc.HistorySpool.Delete(id); err != nil {
+			writeError(ctx, stdCtx, "Failed to delete history write", perrors.NewErrInternalServerError("Failed to delete history write", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "History write deleted", nil)
+	})
+}
@@ -34,6 +34,12 @@ func writeOK(ctx *fasthttp.RequestCtx, stdCtx context.Context, message string, d
 	response.NewResponse(stdCtx, message, data).Write(ctx)
 }
 
+// writeOKConditional is writeOK for reads that clients poll: the response carries an ETag, and the request gets
+// 304 Not Modified when its If-None-Match matches it
+func writeOKConditional(ctx *fasthttp.RequestCtx, stdCtx context.Context, message string, data any) {
+	response.NewResponse(stdCtx, message, data).WriteConditional(ctx)
+}
+
 func pathParam(ctx *fasthttp.RequestCtx, key string) (string, error) {
 	val := ctx.UserValue(key)
 	if val == nil {
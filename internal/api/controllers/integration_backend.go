@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/curaious/uno/internal/integrations"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// integrationBackend runs the agents of the messaging integrations like the converse endpoint does
+type integrationBackend struct {
+	svc    *services.Services
+	runner *AgentRunner
+}
+
+func (b *integrationBackend) Converse(ctx context.Context, in *integrations.ConverseInput) (<-chan *responses.ResponseChunk, error) {
+	ctx, span := tracer.Start(ctx, "Controller.IntegrationConverse")
+	ctx = responses.ContextWithEnqueuedAt(ctx, time.Now())
+	span.SetAttributes(
+		attribute.String("project_id", in.ProjectID.String()),
+		attribute.String("namespace", in.Namespace),
+	)
+
+	project, err := b.svc.Project.GetByID(ctx, in.ProjectID)
+	if err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+	if project.DefaultKey == nil {
+		err := errors.New("project default key is required")
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	agentConfig, err := b.runner.ResolveAgentConfig(ctx, in.ProjectID, in.Agent, "")
+	if err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("agent_name", agentConfig.Name))
+
+	agentConfig, err = project.ApplyAgentDefaults(agentConfig)
+	if err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	var runMeta map[string]any
+	agentConfig, override, err := b.svc.AgentConfig.ApplyNamespaceOverride(ctx, agentConfig, in.Namespace)
+	if err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+	if override != nil {
+		runMeta = map[string]any{
+			"namespace_override": override.Namespace,
+			"effective_config":   agentConfig.Config,
+		}
+	}
+
+	// While an operator has taken over the thread, the message is held for them and the agent doesn't run
+	if in.PreviousMessageID != "" {
+		thread, takeover, err := b.svc.Conversation.GetTakeoverOfMessage(ctx, in.ProjectID, in.Namespace, in.PreviousMessageID)
+		if err == nil && takeover.Active {
+			defer span.End()
+			return b.holdForOperator(ctx, in, thread, takeover)
+		}
+	}
+
+	stream, err := b.runner.Start(ctx, span, agentConfig, &agents.AgentInput{
+		Namespace:         in.Namespace,
+		PreviousMessageID: in.PreviousMessageID,
+		Messages:          []responses.InputMessageUnion{in.Message},
+		RunContext:        in.Context,
+		RunMeta:           runMeta,
+	}, *project.DefaultKey)
+	if err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		return nil, err
+	}
+
+	out := make(chan *responses.ResponseChunk)
+	go func() {
+		defer span.End()
+		defer close(out)
+		for chunk := range stream {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// holdForOperator adds the message to a thread taken over by an operator, the stream only holds the takeover
+func (b *integrationBackend) holdForOperator(ctx context.Context, in *integrations.ConverseInput, thread *conversation.Thread, takeover *conversation.Takeover) (<-chan *responses.ResponseChunk, error) {
+	req := &conversation.AddMessageRequest{
+		ProjectID:         in.ProjectID,
+		Namespace:         in.Namespace,
+		PreviousMessageID: in.PreviousMessageID,
+		ConversationID:    thread.ConversationID,
+		Messages:          []responses.InputMessageUnion{in.Message},
+	}
+	if err := b.svc.Conversation.AddMessages(ctx, req); err != nil {
+		return nil, err
+	}
+
+	chunk := &responses.ResponseChunk{OfTakeoverActive: &responses.ChunkTakeover[constants.ChunkTypeTakeoverActive]{
+		ThreadID:  thread.ThreadID,
+		Operator:  takeover.Operator,
+		MessageID: req.MessageID,
+		Message:   &in.Message,
+		At:        time.Now().UTC(),
+	}}
+	publishTakeoverEvent(ctx, b.runner, in.ProjectID, thread.ThreadID, chunk)
+
+	out := make(chan *responses.ResponseChunk, 1)
+	out <- chunk
+	close(out)
+	return out, nil
+}
+
+func (b *integrationBackend) LastMessageID(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) (string, error) {
+	thread, err := b.svc.Conversation.GetThread(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return "", err
+	}
+	return thread.LastMessageID, nil
+}
+
+func (b *integrationBackend) ThreadOfMessage(ctx context.Context, projectID uuid.UUID, namespace string, messageID string) (string, error) {
+	message, err := b.svc.Conversation.GetMessage(ctx, projectID, namespace, messageID)
+	if err != nil {
+		return "", err
+	}
+	return message.ThreadID, nil
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/outbox"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+type OutboxResponse struct {
+	Stats  *outbox.OutboxStats `json:"stats"`
+	Events []outbox.Event      `json:"events"`
+}
+
+// RegisterOutboxRoutes registers admin routes to inspect the outbox and redeliver events
+func RegisterOutboxRoutes(r *router.Router, svc *services.Services) {
+	r.GET("/api/agent-server/outbox", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		stats, err := svc.Outbox.Stats(stdCtx)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to read outbox", perrors.NewErrInternalServerError("Failed to read outbox", err))
+			return
+		}
+
+		limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+		events, err := svc.Outbox.ListUndelivered(stdCtx, limit)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to read outbox", perrors.NewErrInternalServerError("Failed to read outbox", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Outbox retrieved", OutboxResponse{Stats: stats, Events: events})
+	})
+
+	// Deliver a pending or dead event now
+	r.POST("/api/agent-server/outbox/{id}/requeue", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		idStr, err := pathParam(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		if err := svc.Outbox.Requeue(stdCtx, id); err != nil {
+			writeError(ctx, stdCtx, "Failed to requeue outbox event", perrors.NewErrInternalServerError("Failed to requeue outbox event", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Outbox event requeued", nil)
+	})
+}
@@ -3,7 +3,9 @@ package controllers
 import (
 	"errors"
 
+	"github.com/curaious/uno/internal/db"
 	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
 	project2 "github.com/curaious/uno/internal/services/project"
 	"github.com/fasthttp/router"
 	"github.com/google/uuid"
@@ -29,9 +31,14 @@ func RegisterProjectRoutes(r *router.Router, svc *services.Services) {
 
 		created, err := svc.Project.Create(stdCtx, &body)
 		if err != nil {
+			var validationErr *agent_config.ValidationError
 			switch {
 			case errors.Is(err, project2.ErrProjectAlreadyExists):
 				writeError(ctx, stdCtx, "Project with this name already exists", perrors.New(perrors.ErrCodeConflict, "Project with this name already exists", err))
+			case errors.Is(err, db.ErrRegionUnavailable):
+				writeError(ctx, stdCtx, "Region is not available", perrors.NewErrInvalidRequest("Region is not available", err))
+			case errors.As(err, &validationErr):
+				writeError(ctx, stdCtx, "Invalid agent defaults", perrors.NewErrInvalidRequest("Invalid agent defaults", err, map[string]interface{}{"fields": validationErr.Errors}))
 			default:
 				writeError(ctx, stdCtx, "Failed to create project", perrors.NewErrInternalServerError("Failed to create project", err))
 			}
@@ -99,11 +106,16 @@ func RegisterProjectRoutes(r *router.Router, svc *services.Services) {
 
 		updated, err := svc.Project.Update(stdCtx, id, &body)
 		if err != nil {
+			var validationErr *agent_config.ValidationError
 			switch {
 			case errors.Is(err, project2.ErrProjectNotFound):
 				writeError(ctx, stdCtx, "Project not found", perrors.New(perrors.ErrCodeNotFound, "Project not found", err))
 			case errors.Is(err, project2.ErrProjectAlreadyExists):
 				writeError(ctx, stdCtx, "Project with this name already exists", perrors.New(perrors.ErrCodeConflict, "Project with this name already exists", err))
+			case errors.Is(err, db.ErrRegionUnavailable):
+				writeError(ctx, stdCtx, "Region is not available", perrors.NewErrInvalidRequest("Region is not available", err))
+			case errors.As(err, &validationErr):
+				writeError(ctx, stdCtx, "Invalid agent defaults", perrors.NewErrInvalidRequest("Invalid agent defaults", err, map[string]interface{}{"fields": validationErr.Errors}))
 			default:
 				writeError(ctx, stdCtx, "Failed to update project", perrors.NewErrInternalServerError("Failed to update project", err))
 			}
@@ -67,7 +67,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompts retrieved successfully", prompts)
+		writeOKConditional(ctx, stdCtx, "Prompts retrieved successfully", prompts)
 	})
 
 	// Get prompt by name
@@ -91,7 +91,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompt retrieved successfully", p)
+		writeOKConditional(ctx, stdCtx, "Prompt retrieved successfully", p)
 	})
 
 	// Delete prompt
@@ -187,7 +187,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompt versions retrieved successfully", versions)
+		writeOKConditional(ctx, stdCtx, "Prompt versions retrieved successfully", versions)
 	})
 
 	// List prompt versions
@@ -211,7 +211,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompt versions retrieved successfully", versions)
+		writeOKConditional(ctx, stdCtx, "Prompt versions retrieved successfully", versions)
 	})
 
 	// Get prompt version
@@ -247,7 +247,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompt version retrieved successfully", promptVersion)
+		writeOKConditional(ctx, stdCtx, "Prompt version retrieved successfully", promptVersion)
 	})
 
 	// Get prompt version by label
@@ -277,7 +277,7 @@ func RegisterPromptRoutes(r *router.Router, svc *services.Services) {
 			return
 		}
 
-		writeOK(ctx, stdCtx, "Prompt version retrieved successfully", promptVersion)
+		writeOKConditional(ctx, stdCtx, "Prompt version retrieved successfully", promptVersion)
 	})
 
 	// Update prompt version label
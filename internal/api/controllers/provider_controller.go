@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"errors"
+	"time"
 
 	"github.com/curaious/uno/internal/services"
 	provider2 "github.com/curaious/uno/internal/services/provider"
+	"github.com/curaious/uno/pkg/gateway"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/fasthttp/router"
 	"github.com/google/uuid"
@@ -13,11 +15,19 @@ import (
 	"github.com/curaious/uno/internal/perrors"
 )
 
-func RegisterProviderRoutes(r *router.Router, svc *services.Services) {
+func RegisterProviderRoutes(r *router.Router, svc *services.Services, llmGateway *gateway.LLMGateway) {
 	// Get provider models
 	r.GET("/api/agent-server/providers/models", func(ctx *fasthttp.RequestCtx) {
 		stdCtx := requestContext(ctx)
 		modelsResponse := provider2.GetProviderModelsResponse()
+
+		// The models of OpenRouter are the ones of its catalog once it is loaded
+		if catalog := llmGateway.OpenRouterCatalog(); catalog != nil && catalog.Loaded() {
+			data := modelsResponse.Providers[string(llm.ProviderNameOpenRouter)]
+			data.Models = catalog.Models()
+			modelsResponse.Providers[string(llm.ProviderNameOpenRouter)] = data
+		}
+
 		writeOK(ctx, stdCtx, "Provider models retrieved successfully", modelsResponse)
 	})
 
@@ -189,4 +199,70 @@ func RegisterProviderRoutes(r *router.Router, svc *services.Services) {
 
 		writeOK(ctx, stdCtx, "Provider config saved successfully", config)
 	})
+
+	// Test the API keys of a provider, each key is sent a minimal request and the result is recorded on the
+	// provider config
+	r.POST("/api/agent-server/provider-configs/{provider_type}/test", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		providerTypeRaw, err := pathParam(ctx, "provider_type")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid provider type", perrors.NewErrInvalidRequest("Invalid provider type", err))
+			return
+		}
+
+		pt := llm.ProviderName(providerTypeRaw)
+		if !pt.IsValid() {
+			writeError(ctx, stdCtx, "Invalid provider type", perrors.NewErrInvalidRequest("Invalid provider type", errors.New("provider_type must be one of: OpenAI, Anthropic, Gemini, xAI")))
+			return
+		}
+
+		// The body is optional, the model defaults to the probe model of the provider
+		var body provider2.TestProviderRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := parseBody(ctx, &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		model := body.Model
+		if model == "" {
+			model = provider2.ProbeModels[pt]
+		}
+		if model == "" {
+			writeError(ctx, stdCtx, "Model is required", perrors.NewErrInvalidRequest("Model is required", errors.New("model is required to test a self-hosted provider")))
+			return
+		}
+
+		probes, err := llmGateway.ProbeKeys(stdCtx, pt, model)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to test provider", perrors.NewErrInvalidRequest("Failed to test provider", err))
+			return
+		}
+
+		result := &provider2.ProviderTestResult{
+			Model:    model,
+			TestedAt: time.Now().UTC(),
+			Keys:     make([]provider2.KeyTestResult, 0, len(probes)),
+		}
+		for _, probe := range probes {
+			key := provider2.KeyTestResult{
+				Name:      probe.Name,
+				Enabled:   probe.Enabled,
+				OK:        probe.Err == nil,
+				LatencyMs: probe.Latency.Milliseconds(),
+			}
+			if probe.Err != nil {
+				key.Error = probe.Err.Error()
+			}
+			result.Keys = append(result.Keys, key)
+		}
+
+		if err := svc.Provider.RecordProviderTest(stdCtx, pt, result); err != nil {
+			writeError(ctx, stdCtx, "Failed to record provider test", perrors.NewErrInternalServerError("Failed to record provider test", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Provider tested successfully", result)
+	})
 }
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RegisterRunStreamRoutes lets more than one consumer follow a run started on the converse endpoint, e.g. the end
+// user and a supervisor dashboard. Each consumer reads from its own offset.
+func RegisterRunStreamRoutes(r *router.Router, runner *AgentRunner) {
+	// Attach to the stream of a run. The chunks after the offset given with `after`, or with the Last-Event-ID
+	// header when reconnecting, are backfilled before the live ones.
+	r.GET("/api/agent-server/streams/{stream_id}", func(reqCtx *fasthttp.RequestCtx) {
+		ctx, span := tracer.Start(requestContext(reqCtx), "Controller.SubscribeRunStream")
+
+		projectID, err := requireUUIDQuery(reqCtx, "project_id")
+		if err != nil {
+			span.End()
+			writeError(reqCtx, ctx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		streamID, err := pathParam(reqCtx, "stream_id")
+		if err != nil {
+			span.End()
+			writeError(reqCtx, ctx, "Stream ID is required", perrors.NewErrInvalidRequest("Stream ID is required", err))
+			return
+		}
+
+		after := strings.TrimSpace(string(reqCtx.QueryArgs().Peek("after")))
+		if after == "" {
+			after = strings.TrimSpace(string(reqCtx.Request.Header.Peek("Last-Event-ID")))
+		}
+
+		span.SetAttributes(
+			attribute.String("project_id", projectID.String()),
+			attribute.String("stream_id", streamID),
+		)
+
+		events, err := runner.Subscribe(ctx, projectID, streamID, after)
+		if errors.Is(err, core.ErrRunStreamNotFound) {
+			span.End()
+			writeError(reqCtx, ctx, "Stream not found", perrors.New(perrors.ErrCodeNotFound, "Stream not found", err))
+			return
+		}
+		if errors.Is(err, core.ErrInvalidRunStreamOffset) {
+			span.End()
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+		if err != nil {
+			RecordSpanError(span, err)
+			span.End()
+			writeError(reqCtx, ctx, "Failed to subscribe to stream", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+
+		reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
+		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+		reqCtx.SetStatusCode(fasthttp.StatusOK)
+
+		streamRunEvents(ctx, reqCtx, events, span, nil)
+	})
+}
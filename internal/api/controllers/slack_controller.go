@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/integrations/slack"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// RegisterSlackRoutes registers the Events API and interactivity endpoints of the Slack integration. Slack expects
+// an answer within 3 seconds, so the requests are acknowledged at once and the agent answers in Slack.
+func RegisterSlackRoutes(r *router.Router, svc *services.Services, runner *AgentRunner, conf *config.Config) {
+	projectID, err := uuid.Parse(conf.SLACK_PROJECT_ID)
+	if err != nil || conf.SLACK_AGENT == "" {
+		slog.Error("The slack integration needs SLACK_PROJECT_ID and SLACK_AGENT, it is disabled", slog.Any("error", err))
+		return
+	}
+
+	adapter := slack.NewAdapter(slack.NewClient(conf.SLACK_BOT_TOKEN), &integrationBackend{svc: svc, runner: runner}, svc.Slack, slack.Options{
+		ProjectID:      projectID,
+		Agent:          conf.SLACK_AGENT,
+		UpdateInterval: time.Duration(conf.SLACK_UPDATE_INTERVAL_MS) * time.Millisecond,
+		Approvers:      conf.GetSlackApprovers(),
+	})
+
+	verify := func(ctx *fasthttp.RequestCtx) bool {
+		err := slack.VerifySignature(
+			conf.SLACK_SIGNING_SECRET,
+			string(ctx.Request.Header.Peek("X-Slack-Request-Timestamp")),
+			string(ctx.Request.Header.Peek("X-Slack-Signature")),
+			ctx.PostBody(),
+			time.Now(),
+		)
+		if err != nil {
+			writeError(ctx, requestContext(ctx), "Invalid signature", perrors.New(perrors.ErrCodeUnauthorized, "Invalid signature", err))
+			return false
+		}
+		return true
+	}
+
+	r.POST("/api/agent-server/integrations/slack/events", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if !verify(ctx) {
+			return
+		}
+
+		var env slack.EventEnvelope
+		if err := parseBody(ctx, &env); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if env.Type == "url_verification" {
+			ctx.SetContentType("text/plain")
+			ctx.SetBodyString(env.Challenge)
+			return
+		}
+
+		if env.Type == "event_callback" {
+			go adapter.HandleEvent(context.Background(), &env)
+		}
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})
+
+	r.POST("/api/agent-server/integrations/slack/interactions", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		if !verify(ctx) {
+			return
+		}
+
+		// Interactions are posted as a form with the JSON payload in its "payload" field
+		form, err := url.ParseQuery(string(ctx.PostBody()))
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		var payload slack.InteractionPayload
+		if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+			writeError(ctx, stdCtx, "Invalid payload", perrors.NewErrInvalidRequest("Invalid payload", err))
+			return
+		}
+
+		go adapter.HandleInteraction(context.Background(), &payload)
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})
+}
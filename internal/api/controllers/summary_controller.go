@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/curaious/uno/internal/agent_builder/builder"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/pkg/agent-framework/summariser"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// RegenerateSummaryRequest holds the prompt and model to summarize the covered messages with again
+type RegenerateSummaryRequest struct {
+	Prompt *agent_config.PromptConfig `json:"prompt"`
+	Model  *agent_config.ModelConfig  `json:"model"`
+}
+
+// RegisterSummaryRoutes registers routes to inspect and regenerate the summaries of threads
+func RegisterSummaryRoutes(r *router.Router, svc *services.Services, llmGateway *gateway.LLMGateway) {
+	// List the summaries of a thread
+	r.GET("/api/agent-server/threads/{thread_id}/summaries", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		threadID, err := pathParam(ctx, "thread_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Thread ID is required", perrors.NewErrInvalidRequest("Thread ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		summaries, err := svc.Conversation.ListSummaries(stdCtx, projectID, namespace, threadID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list summaries", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "OK", summaries)
+	})
+
+	// Get a summary along with the messages it covers
+	r.GET("/api/agent-server/summaries/{summary_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		summaryID, err := pathParam(ctx, "summary_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Summary ID is required", perrors.NewErrInvalidRequest("Summary ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		coverage, err := svc.Conversation.GetSummaryCoverage(stdCtx, projectID, namespace, summaryID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get summary", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "OK", coverage)
+	})
+
+	// Summarize the messages covered by a summary again, with a different prompt or model. The runs after
+	// the summary continue from the regenerated one.
+	r.POST("/api/agent-server/summaries/{summary_id}/regenerate", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		summaryID, err := pathParam(ctx, "summary_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Summary ID is required", perrors.NewErrInvalidRequest("Summary ID is required", err))
+			return
+		}
+
+		namespace, err := requireStringQuery(ctx, "namespace")
+		if err != nil {
+			writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+			return
+		}
+
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body RegenerateSummaryRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if body.Prompt == nil || (body.Prompt.RawPrompt == nil && (body.Prompt.PromptID == nil || body.Prompt.Version == nil)) {
+			writeError(ctx, stdCtx, "Prompt is required", perrors.NewErrInvalidRequest("Prompt is required", errors.New("prompt must have raw_prompt, or prompt_id and version")))
+			return
+		}
+
+		if body.Model == nil || body.Model.ProviderType == "" || body.Model.ModelID == "" {
+			writeError(ctx, stdCtx, "Model is required", perrors.NewErrInvalidRequest("Model is required", errors.New("model must have provider_type and model_id")))
+			return
+		}
+
+		project, err := svc.Project.GetByID(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get project", err)
+			return
+		}
+
+		// TODO: avoid default key, and come up with a better mechanism
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			writeError(ctx, stdCtx, "Project default key is required", perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		coverage, err := svc.Conversation.GetSummaryCoverage(stdCtx, projectID, namespace, summaryID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get summary", err)
+			return
+		}
+
+		messages := []responses.InputMessageUnion{}
+		if coverage.PreviousSummary != nil {
+			messages = append(messages, coverage.PreviousSummary.SummaryMessage)
+		}
+		for _, msg := range coverage.Messages {
+			messages = append(messages, msg.Messages...)
+		}
+
+		parameters, err := builder.BuildModelParams(body.Model)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid model parameters", perrors.NewErrInvalidRequest("Invalid model parameters", err))
+			return
+		}
+
+		summarizer := summariser.NewLLMHistorySummarizer(&summariser.LLMHistorySummarizerOptions{
+			LLM:         builder.BuildLLMClient(llmGateway, *project.DefaultKey, llm.ProviderName(body.Model.ProviderType), body.Model.ModelID, builder.DataRegionOf(svc.Regions, project.ID)),
+			Instruction: builder.BuildPrompt(svc.Prompt, projectID, body.Prompt, nil),
+			Parameters:  parameters,
+		})
+
+		summaryMessage, usage, err := summarizer.SummarizeMessages(stdCtx, messages)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to regenerate summary", perrors.NewErrInternalServerError("Failed to regenerate summary", err))
+			return
+		}
+
+		summary := coverage.Summary
+		summary.SummaryMessage = *summaryMessage
+		summary.Meta = maps.Clone(summary.Meta)
+		if summary.Meta == nil {
+			summary.Meta = map[string]any{}
+		}
+		summary.Meta["regenerated_at"] = time.Now()
+		summary.Meta["model"] = map[string]any{
+			"provider_type": body.Model.ProviderType,
+			"model_id":      body.Model.ModelID,
+		}
+		if usage != nil {
+			summary.Meta["usage"] = usage
+		}
+
+		if err := svc.Conversation.UpdateSummaryMessage(stdCtx, projectID, summary.ID, summary.SummaryMessage, summary.Meta); err != nil {
+			writeError(ctx, stdCtx, "Failed to save summary", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Summary regenerated successfully", summary)
+	})
+}
@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RegisterTakeoverRoutes registers the routes for a human operator to take over a thread from the agent, write in
+// it as the assistant and hand it back. The takeover events are published to the event stream of the thread, so
+// that the client UI can follow the transitions.
+func RegisterTakeoverRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	// Get the takeover state of a thread, along with the past takeovers
+	r.GET("/api/agent-server/threads/{thread_id}/takeover", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, threadID, ok := threadParams(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		takeover, err := svc.Conversation.GetTakeover(stdCtx, projectID, namespace, threadID)
+		if err != nil {
+			writeTakeoverError(ctx, stdCtx, "Failed to get takeover", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "OK", takeover)
+	})
+
+	// Take over a thread, the agent stops answering the messages of the user
+	r.POST("/api/agent-server/threads/{thread_id}/takeover", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, threadID, ok := threadParams(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		var body conversation.StartTakeoverRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+		if strings.TrimSpace(body.Operator) == "" {
+			writeError(ctx, stdCtx, "Operator is required", perrors.NewErrInvalidRequest("Operator is required", errors.New("operator is required")))
+			return
+		}
+
+		takeover, event, err := svc.Conversation.StartTakeover(stdCtx, projectID, namespace, threadID, body.Operator, body.Reason)
+		if err != nil {
+			writeTakeoverError(ctx, stdCtx, "Failed to take over thread", err)
+			return
+		}
+
+		publishTakeoverEvent(stdCtx, runner, projectID, threadID, takeoverChunk(threadID, event, nil))
+		writeOK(ctx, stdCtx, "Thread taken over", takeover)
+	})
+
+	// Write a message in the thread as the assistant
+	r.POST("/api/agent-server/threads/{thread_id}/takeover/messages", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, threadID, ok := threadParams(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		var body conversation.TakeoverMessageRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+		if strings.TrimSpace(body.Operator) == "" {
+			writeError(ctx, stdCtx, "Operator is required", perrors.NewErrInvalidRequest("Operator is required", errors.New("operator is required")))
+			return
+		}
+		if strings.TrimSpace(body.Text) == "" {
+			writeError(ctx, stdCtx, "Text is required", perrors.NewErrInvalidRequest("Text is required", errors.New("text is required")))
+			return
+		}
+
+		event, message, err := svc.Conversation.InjectMessage(stdCtx, projectID, namespace, threadID, body.Operator, body.Text)
+		if err != nil {
+			writeTakeoverError(ctx, stdCtx, "Failed to add message", err)
+			return
+		}
+
+		publishTakeoverEvent(stdCtx, runner, projectID, threadID, takeoverChunk(threadID, event, message))
+		writeOK(ctx, stdCtx, "Message added", event)
+	})
+
+	// Hand the thread back to the agent
+	r.POST("/api/agent-server/threads/{thread_id}/takeover/end", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, namespace, threadID, ok := threadParams(ctx, stdCtx)
+		if !ok {
+			return
+		}
+
+		var body conversation.EndTakeoverRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+		if strings.TrimSpace(body.Operator) == "" {
+			writeError(ctx, stdCtx, "Operator is required", perrors.NewErrInvalidRequest("Operator is required", errors.New("operator is required")))
+			return
+		}
+
+		takeover, event, err := svc.Conversation.EndTakeover(stdCtx, projectID, namespace, threadID, body.Operator)
+		if err != nil {
+			writeTakeoverError(ctx, stdCtx, "Failed to end takeover", err)
+			return
+		}
+
+		publishTakeoverEvent(stdCtx, runner, projectID, threadID, takeoverChunk(threadID, event, nil))
+		writeOK(ctx, stdCtx, "Thread handed back to the agent", takeover)
+	})
+
+	// Follow the events of a thread that aren't part of a run: the takeover transitions, the messages of the
+	// operator, and the messages of the user held while the thread is taken over
+	r.GET("/api/agent-server/threads/{thread_id}/events", func(reqCtx *fasthttp.RequestCtx) {
+		ctx, span := tracer.Start(requestContext(reqCtx), "Controller.SubscribeThreadEvents")
+
+		projectID, err := requireUUIDQuery(reqCtx, "project_id")
+		if err != nil {
+			span.End()
+			writeError(reqCtx, ctx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		threadID, err := pathParam(reqCtx, "thread_id")
+		if err != nil {
+			span.End()
+			writeError(reqCtx, ctx, "Thread ID is required", perrors.NewErrInvalidRequest("Thread ID is required", err))
+			return
+		}
+
+		after := strings.TrimSpace(string(reqCtx.QueryArgs().Peek("after")))
+		if after == "" {
+			after = strings.TrimSpace(string(reqCtx.Request.Header.Peek("Last-Event-ID")))
+		}
+
+		span.SetAttributes(
+			attribute.String("project_id", projectID.String()),
+			attribute.String("thread_id", threadID),
+		)
+
+		events, err := runner.SubscribeThread(ctx, projectID, threadID, after)
+		if errors.Is(err, core.ErrInvalidRunStreamOffset) {
+			span.End()
+			writeError(reqCtx, ctx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+		if err != nil {
+			RecordSpanError(span, err)
+			span.End()
+			writeError(reqCtx, ctx, "Failed to subscribe to thread events", perrors.NewErrInternalServerError(err.Error(), err))
+			return
+		}
+
+		reqCtx.Response.Header.Set("Content-Type", "text/event-stream")
+		reqCtx.Response.Header.Set("Cache-Control", "no-cache")
+		reqCtx.SetStatusCode(fasthttp.StatusOK)
+
+		streamRunEvents(ctx, reqCtx, events, span, nil)
+	})
+}
+
+// threadParams reads the project, namespace and thread of the takeover routes, and writes the error if one is
+// missing
+func threadParams(ctx *fasthttp.RequestCtx, stdCtx context.Context) (uuid.UUID, string, string, bool) {
+	threadID, err := pathParam(ctx, "thread_id")
+	if err != nil {
+		writeError(ctx, stdCtx, "Thread ID is required", perrors.NewErrInvalidRequest("Thread ID is required", err))
+		return uuid.Nil, "", "", false
+	}
+
+	namespace, err := requireStringQuery(ctx, "namespace")
+	if err != nil {
+		writeError(ctx, stdCtx, "Namespace is required", perrors.NewErrInvalidRequest("Namespace is required", err))
+		return uuid.Nil, "", "", false
+	}
+
+	projectID, err := requireUUIDQuery(ctx, "project_id")
+	if err != nil {
+		writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+		return uuid.Nil, "", "", false
+	}
+
+	return projectID, namespace, threadID, true
+}
+
+func writeTakeoverError(ctx *fasthttp.RequestCtx, stdCtx context.Context, message string, err error) {
+	switch {
+	case errors.Is(err, conversation.ErrThreadNotFound):
+		writeError(ctx, stdCtx, "Thread not found", perrors.New(perrors.ErrCodeNotFound, "Thread not found", err))
+	case errors.Is(err, conversation.ErrTakeoverActive), errors.Is(err, conversation.ErrTakeoverNotActive):
+		writeError(ctx, stdCtx, err.Error(), perrors.New(perrors.ErrCodeConflict, err.Error(), err))
+	default:
+		writeError(ctx, stdCtx, message, perrors.NewErrInternalServerError(message, err))
+	}
+}
+
+// publishTakeoverEvent publishes a takeover chunk to the event stream of the thread. The takeover is recorded in
+// the thread already, a failure to publish is only logged.
+func publishTakeoverEvent(ctx context.Context, runner *AgentRunner, projectID uuid.UUID, threadID string, chunk *responses.ResponseChunk) {
+	if err := runner.PublishThreadEvent(ctx, projectID, threadID, chunk); err != nil {
+		slog.WarnContext(ctx, "Failed to publish takeover event", slog.String("thread_id", threadID), slog.Any("error", err))
+	}
+}
+
+// holdForOperator adds the message of the user to a thread taken over by an operator, and answers with a
+// takeover.active chunk, in the format of the response, instead of running the agent
+func holdForOperator(ctx context.Context, reqCtx *fasthttp.RequestCtx, span trace.Span, svc *services.Services, runner *AgentRunner, projectID uuid.UUID, reqPayload *ConverseRequest, thread *conversation.Thread, takeover *conversation.Takeover, format streamFormat) {
+	in := &conversation.AddMessageRequest{
+		ProjectID:         projectID,
+		Namespace:         reqPayload.Namespace,
+		PreviousMessageID: reqPayload.PreviousMessageID,
+		ConversationID:    thread.ConversationID,
+		Messages:          []responses.InputMessageUnion{reqPayload.Message},
+	}
+	if err := svc.Conversation.AddMessages(ctx, in); err != nil {
+		RecordSpanError(span, err)
+		span.End()
+		writeError(reqCtx, ctx, "Failed to add message", perrors.NewErrInternalServerError(err.Error(), err))
+		return
+	}
+
+	chunk := &responses.ResponseChunk{OfTakeoverActive: &responses.ChunkTakeover[constants.ChunkTypeTakeoverActive]{
+		ThreadID:  thread.ThreadID,
+		Operator:  takeover.Operator,
+		MessageID: in.MessageID,
+		Message:   &reqPayload.Message,
+		At:        time.Now().UTC(),
+	}}
+	publishTakeoverEvent(ctx, runner, projectID, thread.ThreadID, chunk)
+
+	span.SetAttributes(attribute.Bool("taken_over", true))
+
+	events := make(chan *core.RunEvent, 1)
+	events <- &core.RunEvent{Chunk: chunk}
+	close(events)
+
+	respondRunEvents(ctx, reqCtx, events, span, nil, format)
+}
+
+// takeoverChunk builds the chunk of a takeover event
+func takeoverChunk(threadID string, event *conversation.TakeoverEvent, message *responses.InputMessageUnion) *responses.ResponseChunk {
+	switch event.Action {
+	case conversation.TakeoverActionStarted:
+		return &responses.ResponseChunk{OfTakeoverStarted: &responses.ChunkTakeover[constants.ChunkTypeTakeoverStarted]{
+			ThreadID: threadID,
+			Operator: event.Operator,
+			Reason:   event.Reason,
+			At:       event.At,
+		}}
+	case conversation.TakeoverActionMessage:
+		return &responses.ResponseChunk{OfTakeoverMessage: &responses.ChunkTakeover[constants.ChunkTypeTakeoverMessage]{
+			ThreadID:  threadID,
+			Operator:  event.Operator,
+			MessageID: event.MessageID,
+			Message:   message,
+			At:        event.At,
+		}}
+	default:
+		return &responses.ResponseChunk{OfTakeoverEnded: &responses.ChunkTakeover[constants.ChunkTypeTakeoverEnded]{
+			ThreadID: threadID,
+			Operator: event.Operator,
+			At:       event.At,
+		}}
+	}
+}
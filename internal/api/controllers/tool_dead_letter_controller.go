@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/tool_dead_letter"
+	"github.com/curaious/uno/pkg/agent-framework/agents"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// ToolDeadLetterReplayRequest re-executes a failed tool call with its arguments
+type ToolDeadLetterReplayRequest struct {
+	Version *int           `json:"version"` // Version of the agent to replay the call on, the version of the failed call by default
+	Context map[string]any `json:"context"` // Run context of the replay, the failed calls don't keep theirs
+}
+
+// RegisterToolDeadLetterRoutes registers admin routes to inspect the failed tool calls of the agents and replay them
+func RegisterToolDeadLetterRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	r.GET("/api/agent-server/tool-dead-letters", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		filter := tool_dead_letter.ListFilter{
+			ToolName: string(ctx.QueryArgs().Peek("tool")),
+			Status:   string(ctx.QueryArgs().Peek("status")),
+		}
+		if raw := string(ctx.QueryArgs().Peek("agent_id")); raw != "" {
+			agentID, err := uuid.Parse(raw)
+			if err != nil {
+				writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+				return
+			}
+			filter.AgentID = &agentID
+		}
+		filter.Limit, _ = strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+
+		letters, err := svc.ToolDeadLetter.List(stdCtx, projectID, filter)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list tool dead letters", perrors.NewErrInternalServerError("Failed to list tool dead letters", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Tool dead letters retrieved successfully", letters)
+	})
+
+	r.GET("/api/agent-server/tool-dead-letters/{id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		id, err := pathParamUUID(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		letter, err := svc.ToolDeadLetter.Get(stdCtx, projectID, id)
+		if err != nil {
+			writeError(ctx, stdCtx, "Tool dead letter not found", perrors.New(perrors.ErrCodeNotFound, "Tool dead letter not found", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Tool dead letter retrieved successfully", letter)
+	})
+
+	// Re-execute a failed call with its arguments, in its namespace and conversation. The call is approved, the
+	// quotas of the tool apply.
+	r.POST("/api/agent-server/tool-dead-letters/{id}/replay", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		id, err := pathParamUUID(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID", perrors.NewErrInvalidRequest("Invalid ID", err))
+			return
+		}
+
+		var body ToolDeadLetterReplayRequest
+		if len(ctx.PostBody()) > 0 {
+			if err := parseBody(ctx, &body); err != nil {
+				writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		letter, err := svc.ToolDeadLetter.Get(stdCtx, projectID, id)
+		if err != nil {
+			writeError(ctx, stdCtx, "Tool dead letter not found", perrors.New(perrors.ErrCodeNotFound, "Tool dead letter not found", err))
+			return
+		}
+
+		project, err := svc.Project.GetByID(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get project", perrors.NewErrInternalServerError("Failed to get project", err))
+			return
+		}
+		if project.DefaultKey == nil {
+			err := errors.New("project default key is required")
+			writeError(ctx, stdCtx, err.Error(), perrors.NewErrInvalidRequest(err.Error(), err))
+			return
+		}
+
+		version := letter.AgentVersion
+		if body.Version != nil {
+			version = *body.Version
+		}
+
+		config, err := svc.AgentConfig.GetByAgentIDAndVersion(stdCtx, projectID, letter.AgentID, version)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get agent config", perrors.NewErrInternalServerError("Failed to get agent config", err))
+			return
+		}
+
+		result, err := runner.InvokeTool(stdCtx, config, *project.DefaultKey, &agents.ToolInvocation{
+			Name:           letter.ToolName,
+			Arguments:      letter.Arguments,
+			Approved:       true,
+			Namespace:      letter.Namespace,
+			ConversationID: letter.ConversationID,
+			RunContext:     runContextFromRequest(ctx, body.Context),
+		})
+		if errors.Is(err, agents.ErrToolNotFound) {
+			writeError(ctx, stdCtx, "Tool not found", perrors.New(perrors.ErrCodeNotFound, "Tool not found", err))
+			return
+		}
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to replay tool call", perrors.NewErrInternalServerError("Failed to replay tool call", err))
+			return
+		}
+
+		letter, err = svc.ToolDeadLetter.RecordReplay(stdCtx, letter, tool_dead_letter.Replay{
+			Version:    version,
+			Status:     result.Status,
+			Output:     result.Output,
+			Error:      result.Error,
+			DurationMs: result.DurationMs,
+		}, result.Status == agents.ToolInvocationCompleted)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to record tool call replay", perrors.NewErrInternalServerError("Failed to record tool call replay", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Tool call replayed", letter)
+	})
+}
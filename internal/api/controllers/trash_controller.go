@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/curaious/uno/internal/services/prompt"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+type TrashResponse struct {
+	AgentConfigs  []*agent_config.DeletedAgentConfig `json:"agent_configs"`
+	Prompts       []*prompt.DeletedPrompt            `json:"prompts"`
+	RetentionDays int                                `json:"retention_days"`
+}
+
+// RegisterTrashRoutes registers routes to list and restore deleted agent configs and prompts
+func RegisterTrashRoutes(r *router.Router, svc *services.Services) {
+	r.GET("/api/agent-server/trash", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentConfigs, err := svc.AgentConfig.ListDeleted(stdCtx, projectID, svc.TrashRetention)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list deleted agent configs", perrors.NewErrInternalServerError("Failed to list deleted agent configs", err))
+			return
+		}
+
+		prompts, err := svc.Prompt.ListDeletedPrompts(stdCtx, projectID, svc.TrashRetention)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list deleted prompts", perrors.NewErrInternalServerError("Failed to list deleted prompts", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Trash retrieved", TrashResponse{
+			AgentConfigs:  agentConfigs,
+			Prompts:       prompts,
+			RetentionDays: int(svc.TrashRetention.Hours() / 24),
+		})
+	})
+
+	// Restore all versions of a deleted agent config
+	r.POST("/api/agent-server/trash/agent-configs/{agent_id}/restore", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		agentIDStr, err := pathParam(ctx, "agent_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		agentID, err := uuid.Parse(agentIDStr)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid agent ID", perrors.NewErrInvalidRequest("Invalid agent ID", err))
+			return
+		}
+
+		restored, err := svc.AgentConfig.Restore(stdCtx, projectID, agentID, svc.TrashRetention)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to restore agent config", perrors.NewErrInvalidRequest("Failed to restore agent config", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Agent config restored", restored)
+	})
+
+	// Restore a deleted prompt with all its versions
+	r.POST("/api/agent-server/trash/prompts/{id}/restore", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		idStr, err := pathParam(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid prompt ID", perrors.NewErrInvalidRequest("Invalid prompt ID", err))
+			return
+		}
+
+		promptID, err := uuid.Parse(idStr)
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid prompt ID", perrors.NewErrInvalidRequest("Invalid prompt ID", err))
+			return
+		}
+
+		restored, err := svc.Prompt.RestorePrompt(stdCtx, projectID, promptID, svc.TrashRetention)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to restore prompt", perrors.NewErrInvalidRequest("Failed to restore prompt", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Prompt restored", restored)
+	})
+}
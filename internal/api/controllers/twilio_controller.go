@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/integrations/twilio"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// emptyTwiML answers a webhook of Twilio without replying, the agent replies with the REST API as it streams
+const emptyTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response></Response>`
+
+// RegisterTwilioRoutes registers the messaging webhook of the Twilio integration, set as the webhook of the SMS and
+// WhatsApp messages of the numbers of the account
+func RegisterTwilioRoutes(r *router.Router, svc *services.Services, runner *AgentRunner, conf *config.Config) {
+	projectID, err := uuid.Parse(conf.TWILIO_PROJECT_ID)
+	if err != nil || conf.TWILIO_AGENT == "" {
+		slog.Error("The twilio integration needs TWILIO_PROJECT_ID and TWILIO_AGENT, it is disabled", slog.Any("error", err))
+		return
+	}
+
+	adapter := twilio.NewAdapter(twilio.NewClient(conf.TWILIO_ACCOUNT_SID, conf.TWILIO_AUTH_TOKEN), &integrationBackend{svc: svc, runner: runner}, svc.Twilio, twilio.Options{
+		ProjectID:        projectID,
+		Agent:            conf.TWILIO_AGENT,
+		MaxMessageLength: conf.TWILIO_MAX_MESSAGE_LENGTH,
+	})
+
+	r.POST("/api/agent-server/integrations/twilio/messages", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+
+		form, err := url.ParseQuery(string(ctx.PostBody()))
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		// Twilio signs the URL it calls, which only the configuration knows behind a proxy
+		webhookURL := conf.TWILIO_WEBHOOK_URL
+		if webhookURL == "" {
+			scheme := "http"
+			if ctx.IsTLS() || string(ctx.Request.Header.Peek("X-Forwarded-Proto")) == "https" {
+				scheme = "https"
+			}
+			webhookURL = scheme + "://" + string(ctx.Host()) + string(ctx.RequestURI())
+		}
+		if err := twilio.VerifySignature(conf.TWILIO_AUTH_TOKEN, webhookURL, form, string(ctx.Request.Header.Peek("X-Twilio-Signature"))); err != nil {
+			writeError(ctx, stdCtx, "Invalid signature", perrors.New(perrors.ErrCodeUnauthorized, "Invalid signature", err))
+			return
+		}
+
+		go adapter.HandleMessage(context.Background(), &twilio.InboundMessage{
+			MessageSID:  form.Get("MessageSid"),
+			AccountSID:  form.Get("AccountSid"),
+			From:        form.Get("From"),
+			To:          form.Get("To"),
+			Body:        form.Get("Body"),
+			ProfileName: form.Get("ProfileName"),
+		})
+
+		ctx.SetContentType("text/xml")
+		ctx.SetBodyString(emptyTwiML)
+	})
+}
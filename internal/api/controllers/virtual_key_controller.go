@@ -3,8 +3,10 @@ package controllers
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/key_usage"
 	"github.com/curaious/uno/internal/services/virtual_key"
 	"github.com/fasthttp/router"
 	"github.com/google/uuid"
@@ -106,4 +108,57 @@ func RegisterVirtualKeyRoutes(r *router.Router, svc *services.Services) {
 
 		writeOK(ctx, stdCtx, "Virtual key deleted successfully", nil)
 	})
+
+	// Usage of a virtual key by hour or by day
+	r.GET("/api/agent-server/virtual-keys/{id}/usage", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		id, err := pathParamUUID(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID format", perrors.NewErrInvalidRequest("Invalid ID format", err))
+			return
+		}
+
+		startTime, endTime := parseTimeRange(ctx)
+		if startTime.After(endTime) {
+			err = errors.New("start_time must be before end_time")
+			writeError(ctx, stdCtx, "Invalid time range", perrors.NewErrInvalidRequest("Invalid time range", err))
+			return
+		}
+
+		report, err := svc.KeyUsage.GetUsage(stdCtx, &key_usage.UsageQuery{
+			VirtualKeyID: id,
+			StartTime:    startTime,
+			EndTime:      endTime,
+			Interval:     string(ctx.QueryArgs().Peek("interval")),
+		})
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to get usage of virtual key", perrors.NewErrInvalidRequest("Failed to get usage of virtual key", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Usage retrieved successfully", report)
+	})
+
+	// Alerts raised on the anomalies of the usage of a virtual key, latest first
+	r.GET("/api/agent-server/virtual-keys/{id}/alerts", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		id, err := pathParamUUID(ctx, "id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid ID format", perrors.NewErrInvalidRequest("Invalid ID format", err))
+			return
+		}
+
+		limit, _ := strconv.Atoi(string(ctx.QueryArgs().Peek("limit")))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+
+		alerts, err := svc.KeyUsage.ListAlerts(stdCtx, id, limit)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list alerts of virtual key", perrors.NewErrInternalServerError("Failed to list alerts of virtual key", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Alerts retrieved successfully", alerts)
+	})
 }
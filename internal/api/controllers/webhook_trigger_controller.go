@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/integrations"
+	"github.com/curaious/uno/internal/perrors"
+	"github.com/curaious/uno/internal/services"
+	"github.com/curaious/uno/internal/services/webhook_trigger"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// webhookTriggerRunTimeout bounds the run of an invocation of a webhook trigger
+const webhookTriggerRunTimeout = 10 * time.Minute
+
+// RegisterWebhookTriggerRoutes registers the routes to manage webhook triggers, and the endpoint the external
+// systems post their payloads to
+func RegisterWebhookTriggerRoutes(r *router.Router, svc *services.Services, runner *AgentRunner) {
+	backend := &integrationBackend{svc: svc, runner: runner}
+
+	r.GET("/api/agent-server/webhook-triggers", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggers, err := svc.WebhookTrigger.List(stdCtx, projectID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list webhook triggers", perrors.NewErrInternalServerError("Failed to list webhook triggers", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook triggers retrieved successfully", triggers)
+	})
+
+	r.POST("/api/agent-server/webhook-triggers", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		var body webhook_trigger.CreateWebhookTriggerRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		trigger, err := svc.WebhookTrigger.Create(stdCtx, projectID, &body)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to create webhook trigger", perrors.NewErrInvalidRequest("Failed to create webhook trigger", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger created successfully", trigger)
+	})
+
+	r.GET("/api/agent-server/webhook-triggers/{trigger_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		trigger, err := svc.WebhookTrigger.Get(stdCtx, projectID, triggerID)
+		if err != nil {
+			writeWebhookTriggerError(ctx, stdCtx, "Failed to get webhook trigger", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger retrieved successfully", trigger)
+	})
+
+	r.PUT("/api/agent-server/webhook-triggers/{trigger_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		var body webhook_trigger.UpdateWebhookTriggerRequest
+		if err := parseBody(ctx, &body); err != nil {
+			writeError(ctx, stdCtx, "Invalid request body", perrors.NewErrInvalidRequest("Invalid request body", err))
+			return
+		}
+
+		trigger, err := svc.WebhookTrigger.Update(stdCtx, projectID, triggerID, &body)
+		if err != nil {
+			writeWebhookTriggerError(ctx, stdCtx, "Failed to update webhook trigger", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger updated successfully", trigger)
+	})
+
+	r.DELETE("/api/agent-server/webhook-triggers/{trigger_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		if err := svc.WebhookTrigger.Delete(stdCtx, projectID, triggerID); err != nil {
+			writeWebhookTriggerError(ctx, stdCtx, "Failed to delete webhook trigger", err)
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger deleted successfully", nil)
+	})
+
+	r.GET("/api/agent-server/webhook-triggers/{trigger_id}/invocations", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		invocations, err := svc.WebhookTrigger.ListInvocations(stdCtx, projectID, triggerID)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to list webhook trigger invocations", perrors.NewErrInternalServerError("Failed to list webhook trigger invocations", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger invocations retrieved successfully", invocations)
+	})
+
+	r.GET("/api/agent-server/webhook-triggers/{trigger_id}/invocations/{invocation_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		projectID, err := requireUUIDQuery(ctx, "project_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Project ID is required", perrors.NewErrInvalidRequest("Project ID is required", err))
+			return
+		}
+
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		invocationID, err := pathParamUUID(ctx, "invocation_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid invocation ID", perrors.NewErrInvalidRequest("Invalid invocation ID", err))
+			return
+		}
+
+		invocation, err := svc.WebhookTrigger.GetInvocation(stdCtx, projectID, triggerID, invocationID)
+		if err != nil {
+			writeWebhookTriggerError(ctx, stdCtx, "Failed to get webhook trigger invocation", err)
+			return
+		}
+
+		writeOKConditional(ctx, stdCtx, "Webhook trigger invocation retrieved successfully", invocation)
+	})
+
+	// The external systems post their payloads here, authenticated with the secret of the trigger. The run starts
+	// at once and the request is answered with the invocation, the result goes to the callback URL.
+	r.POST("/api/agent-server/webhooks/{trigger_id}", func(ctx *fasthttp.RequestCtx) {
+		stdCtx := requestContext(ctx)
+		triggerID, err := pathParamUUID(ctx, "trigger_id")
+		if err != nil {
+			writeError(ctx, stdCtx, "Invalid trigger ID", perrors.NewErrInvalidRequest("Invalid trigger ID", err))
+			return
+		}
+
+		trigger, err := svc.WebhookTrigger.GetByID(stdCtx, triggerID)
+		if err != nil {
+			writeWebhookTriggerError(ctx, stdCtx, "Failed to get webhook trigger", err)
+			return
+		}
+
+		token := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
+		timestamp := string(ctx.Request.Header.Peek("X-Uno-Timestamp"))
+		if err := svc.WebhookTrigger.Verify(trigger, ctx.PostBody(), timestamp, string(ctx.Request.Header.Peek("X-Uno-Signature")), token); err != nil {
+			writeError(ctx, stdCtx, "Invalid signature", perrors.New(perrors.ErrCodeUnauthorized, "Invalid signature", err))
+			return
+		}
+		if !trigger.Enabled {
+			writeError(ctx, stdCtx, "Webhook trigger is disabled", perrors.New(perrors.ErrCodeConflict, "Webhook trigger is disabled", webhook_trigger.ErrTriggerDisabled))
+			return
+		}
+
+		rendered, err := svc.WebhookTrigger.Render(trigger, webhookTemplateData(ctx))
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to map the payload", perrors.NewErrInvalidRequest("Failed to map the payload", err))
+			return
+		}
+
+		invocation, err := svc.WebhookTrigger.StartInvocation(stdCtx, trigger)
+		if err != nil {
+			writeError(ctx, stdCtx, "Failed to start webhook trigger invocation", perrors.NewErrInternalServerError("Failed to start webhook trigger invocation", err))
+			return
+		}
+
+		writeOK(ctx, stdCtx, "Webhook trigger invocation started", invocation)
+		ctx.SetStatusCode(fasthttp.StatusAccepted)
+
+		go runWebhookTrigger(svc, backend, trigger, invocation, rendered)
+	})
+}
+
+// webhookTemplateData is what the templates of a trigger read from a request: the payload, parsed when it is JSON
+// or a form, the headers with lowercase names and the query parameters
+func webhookTemplateData(ctx *fasthttp.RequestCtx) map[string]any {
+	var payload any
+	if err := json.Unmarshal(ctx.PostBody(), &payload); err != nil {
+		payload = string(ctx.PostBody())
+		if strings.HasPrefix(string(ctx.Request.Header.ContentType()), "application/x-www-form-urlencoded") {
+			form := map[string]any{}
+			ctx.PostArgs().VisitAll(func(key, value []byte) {
+				form[string(key)] = string(value)
+			})
+			payload = form
+		}
+	}
+
+	headers := map[string]any{}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		name := strings.ToLower(string(key))
+		// The credentials of the request aren't passed on to the agent
+		if name == "authorization" || name == "x-uno-signature" || name == "cookie" {
+			return
+		}
+		headers[name] = string(value)
+	})
+
+	query := map[string]any{}
+	ctx.QueryArgs().VisitAll(func(key, value []byte) {
+		query[string(key)] = string(value)
+	})
+
+	return map[string]any{
+		"payload": payload,
+		"headers": headers,
+		"query":   query,
+	}
+}
+
+// runWebhookTrigger runs the agent of an invocation, records its result and delivers it to the callback URL
+func runWebhookTrigger(svc *services.Services, backend *integrationBackend, trigger *webhook_trigger.WebhookTrigger, invocation *webhook_trigger.Invocation, rendered *webhook_trigger.Rendered) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTriggerRunTimeout)
+	defer cancel()
+
+	stream, err := backend.Converse(ctx, &integrations.ConverseInput{
+		ProjectID: trigger.ProjectID,
+		Agent:     trigger.AgentID,
+		Namespace: rendered.Namespace,
+		Message: responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+			Role:    constants.RoleUser,
+			Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: rendered.Message}}},
+		}},
+		Context: rendered.Context,
+	})
+
+	var result *ConverseResponse
+	if err == nil {
+		var chunks []*responses.ResponseChunk
+		for chunk := range stream {
+			chunks = append(chunks, chunk)
+			if chunk.OfRunCompleted != nil || chunk.OfRunPaused != nil || chunk.OfRunFailed != nil || chunk.OfTakeoverActive != nil {
+				break
+			}
+		}
+		result, err = converseResponseOf(chunks)
+	}
+
+	if err == nil {
+		invocation.Status = result.Status
+		invocation.RunID = result.RunID
+		invocation.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		invocation.Status = webhook_trigger.StatusFailed
+		invocation.Error = err.Error()
+	}
+
+	if err := svc.WebhookTrigger.CompleteInvocation(ctx, invocation); err != nil {
+		slog.ErrorContext(ctx, "Failed to complete webhook trigger invocation", slog.String("invocation_id", invocation.ID.String()), slog.Any("error", err))
+	}
+	if err := svc.WebhookTrigger.DeliverCallback(ctx, trigger, invocation); err != nil {
+		slog.ErrorContext(ctx, "Failed to deliver webhook trigger callback", slog.String("invocation_id", invocation.ID.String()), slog.Any("error", err))
+	}
+}
+
+func writeWebhookTriggerError(ctx *fasthttp.RequestCtx, stdCtx context.Context, message string, err error) {
+	if errors.Is(err, webhook_trigger.ErrTriggerNotFound) || errors.Is(err, webhook_trigger.ErrInvocationNotFound) {
+		writeError(ctx, stdCtx, err.Error(), perrors.New(perrors.ErrCodeNotFound, err.Error(), err))
+		return
+	}
+
+	writeError(ctx, stdCtx, message, perrors.NewErrInvalidRequest(message, err))
+}
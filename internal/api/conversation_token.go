@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/services/conversation_token"
+	"github.com/valyala/fasthttp"
+)
+
+// conversationScopeKey holds the scope of the conversation token of a request
+const conversationScopeKey = "conversationScope"
+
+// authorizeConversationToken authenticates a request carrying a conversation token, which only reaches the
+// conversation reads and the converse endpoint within the scope of the token. It answers the requests it rejects.
+func (s *Server) authorizeConversationToken(ctx *fasthttp.RequestCtx, token string) bool {
+	scope, err := s.services.ConversationToken.Verify(token)
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+		return false
+	}
+
+	if err := s.checkConversationScope(ctx, scope); err != nil {
+		if errors.Is(err, conversation_token.ErrOutOfScope) {
+			ctx.SetStatusCode(fasthttp.StatusForbidden)
+			return false
+		}
+		slog.Error("Failed to check the scope of a conversation token", slog.Any("error", err))
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		return false
+	}
+
+	ctx.SetUserValue(conversationScopeKey, scope)
+	return true
+}
+
+// checkConversationScope checks that a request stays within the scope of a conversation token. Reads need the read
+// permission and the project and namespace of the scope, conversing needs the write permission and the agent of the
+// scope. A token scoped to a conversation only reads its threads and messages, and only continues it.
+func (s *Server) checkConversationScope(ctx *fasthttp.RequestCtx, scope *conversation_token.Scope) error {
+	stdCtx := context.Background()
+	args := ctx.QueryArgs()
+	path := string(ctx.Path())
+
+	if string(args.Peek("project_id")) != scope.ProjectID.String() {
+		return conversation_token.ErrOutOfScope
+	}
+
+	inScope := func(ok bool, err error) error {
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return conversation_token.ErrOutOfScope
+		}
+		return nil
+	}
+
+	switch string(ctx.Method()) {
+	case fasthttp.MethodGet:
+		if !scope.Allows(conversation_token.PermissionRead) || string(args.Peek("namespace")) != scope.Namespace {
+			return conversation_token.ErrOutOfScope
+		}
+
+		switch {
+		case path == "/api/agent-server/conversations":
+			// Listing would show the other conversations of the namespace
+			return inScope(scope.ConversationID == "", nil)
+		case path == "/api/agent-server/threads":
+			return inScope(scope.ConversationID == "" || string(args.Peek("conversation_id")) == scope.ConversationID, nil)
+		case path == "/api/agent-server/messages":
+			return inScope(s.services.ConversationToken.ThreadInScope(stdCtx, scope, string(args.Peek("thread_id"))))
+		}
+
+		if id, ok := pathID(path, "/api/agent-server/conversations/"); ok {
+			return inScope(scope.ConversationID == "" || id == scope.ConversationID, nil)
+		}
+		if id, ok := pathID(path, "/api/agent-server/threads/"); ok {
+			return inScope(s.services.ConversationToken.ThreadInScope(stdCtx, scope, id))
+		}
+		if id, ok := pathID(path, "/api/agent-server/messages/"); ok && id != "summary" {
+			return inScope(s.services.ConversationToken.MessageInScope(stdCtx, scope, id))
+		}
+
+	case fasthttp.MethodPost:
+		if path != "/api/agent-server/converse" || !scope.Allows(conversation_token.PermissionWrite) {
+			return conversation_token.ErrOutOfScope
+		}
+		if string(args.Peek("agent_id")) != scope.AgentID {
+			return conversation_token.ErrOutOfScope
+		}
+
+		var body struct {
+			Namespace         string `json:"namespace"`
+			PreviousMessageID string `json:"previous_message_id"`
+		}
+		if err := json.Unmarshal(ctx.PostBody(), &body); err != nil || body.Namespace != scope.Namespace {
+			return conversation_token.ErrOutOfScope
+		}
+
+		// A token scoped to a conversation can't start another one
+		if scope.ConversationID != "" && body.PreviousMessageID == "" {
+			return conversation_token.ErrOutOfScope
+		}
+		if body.PreviousMessageID != "" {
+			return inScope(s.services.ConversationToken.MessageInScope(stdCtx, scope, body.PreviousMessageID))
+		}
+		return nil
+	}
+
+	return conversation_token.ErrOutOfScope
+}
+
+// pathID returns the ID of a path made of a prefix and an ID
+func pathID(path string, prefix string) (string, bool) {
+	id, ok := strings.CutPrefix(path, prefix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp"
+)
+
+// redisNonceStore remembers the nonces of the signed requests in Redis, shared by the instances of the server
+type redisNonceStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func (s *redisNonceStore) UseNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+nonce, 1, ttl).Result()
+}
+
+// verifyRequestSignature verifies the signature of the gateway requests made with a virtual key with a signing
+// secret. The requests of the other keys are left to the gateway. It answers the requests it rejects.
+func (s *Server) verifyRequestSignature(ctx *fasthttp.RequestCtx) bool {
+	key := gatewayKey(ctx)
+	if !strings.HasPrefix(key, "sk-uno") {
+		return true
+	}
+
+	// The unknown keys are rejected by the gateway, a key that can't be looked up can't be let through unsigned
+	vk, err := s.llmGateway.ConfigStore.GetVirtualKey(key)
+	if errors.Is(err, gateway.ErrVirtualKeyNotFound) {
+		return true
+	}
+	if err != nil {
+		slog.Error("Failed to get the virtual key of a gateway request", slog.Any("error", err))
+		writeSignatureError(ctx, fasthttp.StatusInternalServerError, "failed to verify the signature of the request")
+		return false
+	}
+	if vk.SigningSecret == "" {
+		return true
+	}
+
+	// The nonces are kept under the ID of the key, its secret must not end up in the names of the Redis keys
+	nonces := &redisNonceStore{client: s.redisClient, prefix: "request_nonce:" + vk.ID + ":"}
+	err = gateway.VerifyRequestSignature(
+		ctx, vk.SigningSecret, string(ctx.Method()), string(ctx.RequestURI()),
+		func(name string) string { return string(ctx.Request.Header.Peek(name)) },
+		ctx.Request.Body(), nonces, time.Now(),
+	)
+	if err == nil {
+		return true
+	}
+
+	switch {
+	case errors.Is(err, gateway.ErrMissingSignature),
+		errors.Is(err, gateway.ErrInvalidSignature),
+		errors.Is(err, gateway.ErrExpiredSignature),
+		errors.Is(err, gateway.ErrReplayedRequest):
+		slog.Warn("Rejected gateway request with invalid signature", slog.String("request_uri", string(ctx.RequestURI())), slog.Any("error", err))
+		writeSignatureError(ctx, fasthttp.StatusUnauthorized, err.Error())
+	default:
+		slog.Error("Failed to verify the signature of a gateway request", slog.Any("error", err))
+		writeSignatureError(ctx, fasthttp.StatusInternalServerError, "failed to verify the signature of the request")
+	}
+
+	return false
+}
+
+// writeSignatureError answers a rejected gateway request with an error in the format of the gateway
+func writeSignatureError(ctx *fasthttp.RequestCtx, status int, message string) {
+	body, _ := sonic.Marshal(map[string]any{"error": map[string]any{"message": message}})
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	_, _ = ctx.Write(body)
+}
+
+// gatewayKey returns the key of a gateway request, read from the headers like the gateway controllers do
+func gatewayKey(ctx *fasthttp.RequestCtx) string {
+	if key := string(ctx.Request.Header.Peek("x-virtual-key")); key != "" {
+		return key
+	}
+	if key := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer "); key != "" {
+		return key
+	}
+	return string(ctx.Request.Header.Peek("x-goog-api-key"))
+}
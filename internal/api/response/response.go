@@ -2,9 +2,12 @@ package response
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	json "github.com/bytedance/sonic"
 	"github.com/valyala/fasthttp"
@@ -78,3 +81,42 @@ func (r *Response[T]) Write(ctx *fasthttp.RequestCtx) {
 
 	ctx.SetBody(body)
 }
+
+// WriteConditional writes the response like Write, identified by an entity tag: the ETag header set by the handler,
+// e.g. the revision of an entity, or else a hash of the body. When the If-None-Match header of the request matches
+// the tag, the response is 304 Not Modified, without a body.
+func (r *Response[T]) WriteConditional(ctx *fasthttp.RequestCtx) {
+	r.Write(ctx)
+	if r.Error || ctx.Response.StatusCode() != http.StatusOK {
+		return
+	}
+
+	etag := string(ctx.Response.Header.Peek(fasthttp.HeaderETag))
+	if etag == "" {
+		sum := sha256.Sum256(ctx.Response.Body())
+		etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		ctx.Response.Header.Set(fasthttp.HeaderETag, etag)
+	}
+	ctx.Response.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+
+	if etagMatches(string(ctx.Request.Header.Peek(fasthttp.HeaderIfNoneMatch)), etag) {
+		ctx.Response.ResetBody()
+		ctx.SetStatusCode(http.StatusNotModified)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches the entity tag, with the weak comparison of RFC 9110
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/curaious/uno/internal/api/authenticator"
 	"github.com/curaious/uno/internal/api/controllers"
 	"github.com/curaious/uno/internal/config"
+	"github.com/curaious/uno/internal/services/conversation_token"
+	"github.com/curaious/uno/pkg/gateway/middlewares/virtual_key_middleware"
 	"github.com/fasthttp/router"
 	"github.com/valyala/fasthttp"
 	"go.opentelemetry.io/otel/propagation"
@@ -37,18 +39,56 @@ func (s *Server) initNewRoutes() fasthttp.RequestHandler {
 	controllers.RegisterTracesRoutes(r.Group("/api/agent-server"), s.services)
 
 	// Gateway routes
-	controllers.RegisterProviderRoutes(r, s.services)
+	controllers.RegisterProviderRoutes(r, s.services, s.llmGateway)
 	controllers.RegisterVirtualKeyRoutes(r, s.services)
 	controllers.RegisterGatewayRoutes(r.Group("/api/gateway"), s.services, s.llmGateway)
 
 	// Agent framework routes
 	controllers.RegisterProjectRoutes(r, s.services)
 	controllers.RegisterPromptRoutes(r, s.services)
-	controllers.RegisterAgentConfigRoutes(r, s.services)
+	runner := controllers.NewAgentRunner(s.services, s.llmGateway, s.conf, s.broker, s.sandboxManger, s.agentConfigCache, s.runEvents)
+	controllers.RegisterAgentConfigRoutes(r, s.services, runner)
 	controllers.RegisterConversationRoutes(r, s.services)
-	controllers.RegisterDurableConverseRoute(r, s.services, s.llmGateway, s.conf, s.broker, s.sandboxManger)
+	controllers.RegisterConversationTokenRoutes(r, s.services)
+	controllers.RegisterSummaryRoutes(r, s.services, s.llmGateway)
+	controllers.RegisterAnalyticsRoutes(r, s.services)
+	controllers.RegisterToolUsageRoutes(r, s.services)
+	controllers.RegisterHistorySpoolRoutes(r, s.services)
+	controllers.RegisterOutboxRoutes(r, s.services)
+	controllers.RegisterToolDeadLetterRoutes(r, s.services, runner)
+	controllers.RegisterTrashRoutes(r, s.services)
+	controllers.RegisterErasureRoutes(r, s.services)
+	controllers.RegisterEnvironmentRoutes(r, s.services)
+	controllers.RegisterCacheRoutes(r, s.agentConfigCache, s.gatewayConfigCache)
+	controllers.RegisterDurableConverseRoute(r, s.services, runner)
+	controllers.RegisterRunStreamRoutes(r, runner)
+	controllers.RegisterTakeoverRoutes(r, s.services, runner)
+	controllers.RegisterApprovalRoutes(r, s.services, runner)
+	controllers.RegisterAgentTestRoutes(r, s.services, runner)
+	controllers.RegisterAgentEvalRoutes(r, s.services, runner)
+	controllers.RegisterWebhookTriggerRoutes(r, s.services, runner)
+	controllers.RegisterChatWidgetRoutes(r, s.services, runner, virtual_key_middleware.NewRedisRateLimiterStorage(s.redisClient, "chat_widget_rate_limit:"))
+
+	// OpenAI Assistants API compatibility
+	controllers.RegisterAssistantsRoutes(r, s.services, runner)
+
+	// Integrations
+	if s.conf.SlackEnabled() {
+		controllers.RegisterSlackRoutes(r, s.services, runner, s.conf)
+	}
+	if s.conf.TwilioEnabled() {
+		controllers.RegisterTwilioRoutes(r, s.services, runner, s.conf)
+	}
+	if s.conf.EmailEnabled() {
+		controllers.StartEmailChannel(s.services, runner, s.conf)
+	}
+
+	handler := r.Handler
+	if s.conf.RESPONSE_COMPRESSION {
+		handler = withCompression(handler, s.conf.RESPONSE_COMPRESSION_MIN_BYTES)
+	}
 
-	return s.withMiddlewares(r.Handler, auth)
+	return s.withMiddlewares(handler, auth)
 }
 
 func (s *Server) withMiddlewares(next fasthttp.RequestHandler, auth *authenticator.Authenticator) fasthttp.RequestHandler {
@@ -71,8 +111,19 @@ func (s *Server) withMiddlewares(next fasthttp.RequestHandler, auth *authenticat
 		traceCtx := tracePropagator.Extract(ctx, propagation.HeaderCarrier(h))
 		ctx.SetUserValue("traceCtx", traceCtx)
 
-		// Auth check
-		if auth.AuthEnabled() && !isPublicRoute(ctx) {
+		// Gateway requests made with a virtual key with a signing secret must be signed with it
+		if strings.HasPrefix(string(ctx.Path()), "/api/gateway/") && !s.verifyRequestSignature(ctx) {
+			return
+		}
+
+		// Conversation tokens of browser clients only reach the conversations in their scope, whether or not
+		// authentication is enabled
+		if bearer := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer "); conversation_token.IsToken(bearer) && !isPublicRoute(ctx) {
+			if !s.authorizeConversationToken(ctx, bearer) {
+				return
+			}
+		} else if auth.AuthEnabled() && !isPublicRoute(ctx) {
+			// Auth check
 			accessToken := strings.TrimPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
 			if accessToken == "" {
 				accessToken = string(ctx.Request.Header.Cookie("access_token"))
@@ -120,6 +171,21 @@ func isPublicRoute(ctx *fasthttp.RequestCtx) bool {
 	switch {
 	case path == "/api/health":
 		return true
+	case strings.HasPrefix(path, "/api/agent-server/widget/"):
+		// Requests of chat widgets are authenticated with the session tokens of their visitors
+		return true
+	case path == "/api/gateway/replicate/webhook":
+		// Requests of Replicate are verified with the webhook signing secret of the account
+		return true
+	case strings.HasPrefix(path, "/api/agent-server/webhooks/"):
+		// Requests of webhook triggers are verified with the secret of the trigger
+		return true
+	case strings.HasPrefix(path, "/api/agent-server/integrations/slack/"):
+		// Requests of Slack are verified with the signing secret of the Slack app
+		return true
+	case strings.HasPrefix(path, "/api/agent-server/integrations/twilio/"):
+		// Requests of Twilio are verified with the auth token of the account
+		return true
 	default:
 		for _, route := range publicAuthRoutes {
 			if path == route {
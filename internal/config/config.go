@@ -5,6 +5,8 @@ import (
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,11 +17,22 @@ type Config struct {
 	DB_NAME     string
 	DISABLE_TLS string
 
+	// Read replicas, as comma separated host[:port]
+	DB_REPLICA_HOSTS      string
+	DB_REPLICA_MAX_LAG_MS int
+
+	// Databases of the regions projects can be pinned to, as comma separated <region>=<host>[:<port>][/<database>]
+	DB_REGIONS string
+
 	REDIS_HOST     string
 	REDIS_PORT     string
 	REDIS_USERNAME string
 	REDIS_PASSWORD string
 
+	// Transport of the chunks of the runs executed by Restate and Temporal workers, "pubsub" or "streams". Redis
+	// Streams keep the chunks published while the agent server isn't reading.
+	STREAM_BROKER_TRANSPORT string
+
 	// ClickHouse configuration for traces
 	CLICKHOUSE_HOST     string
 	CLICKHOUSE_PORT     int
@@ -48,6 +61,84 @@ type Config struct {
 
 	// Data Path
 	DATA_PATH string
+
+	// Days deleted agent configs and prompts are kept in the trash before they are purged
+	TRASH_RETENTION_DAYS int
+
+	// Minutes between checks of the tools of the MCP servers of agents for drift, 0 disables the checks
+	MCP_DRIFT_CHECK_INTERVAL_MINUTES int
+
+	// Outbox sinks
+	OUTBOX_REDIS_STREAM   string
+	OUTBOX_WEBHOOK_URLS   string
+	OUTBOX_WEBHOOK_SECRET string
+	OUTBOX_KAFKA_REST_URL string
+	OUTBOX_KAFKA_TOPIC    string
+
+	// Encryption at rest, as comma separated <id>:<base64 256 bit key>. The first key wraps new data keys.
+	ENCRYPTION_MASTER_KEYS string
+	// Transit key of HashiCorp Vault or OpenBao to wrap data keys with, it takes precedence over ENCRYPTION_MASTER_KEYS
+	ENCRYPTION_VAULT_ADDR        string
+	ENCRYPTION_VAULT_TOKEN       string
+	ENCRYPTION_VAULT_TRANSIT_KEY string
+
+	// Secret the erasure reports are signed with, defaults to JWT_SECRET
+	ERASURE_REPORT_SECRET string
+
+	// Secret the conversation tokens of browser clients are signed with, defaults to JWT_SECRET
+	CONVERSATION_TOKEN_SECRET string
+
+	// Faults injected in the requests to the providers for resilience testing, as comma separated <fault>=<rate>,
+	// e.g. "rate_limit=0.05,disconnect=0.02". Never set it in production.
+	FAULT_INJECTION string
+
+	// Compression of the responses with brotli or gzip, as accepted by the client. Bodies smaller than
+	// RESPONSE_COMPRESSION_MIN_BYTES are sent uncompressed, streams are compressed and flushed event by event.
+	RESPONSE_COMPRESSION           bool
+	RESPONSE_COMPRESSION_MIN_BYTES int
+
+	// Webhooks notifying the gateway of the completion of Replicate predictions, which are polled otherwise.
+	// REPLICATE_WEBHOOK_URL is the public URL of /api/gateway/replicate/webhook, REPLICATE_WEBHOOK_SECRET the
+	// signing secret of the Replicate account.
+	REPLICATE_WEBHOOK_URL    string
+	REPLICATE_WEBHOOK_SECRET string
+
+	// Slack integration, enabled when the signing secret and the bot token of the Slack app are set. Messages of
+	// the Slack channels are answered by SLACK_AGENT of SLACK_PROJECT_ID, updating the reply every
+	// SLACK_UPDATE_INTERVAL_MS as it streams. Only the comma separated SLACK_APPROVERS, when set, can approve tool calls.
+	SLACK_SIGNING_SECRET     string
+	SLACK_BOT_TOKEN          string
+	SLACK_PROJECT_ID         string
+	SLACK_AGENT              string
+	SLACK_UPDATE_INTERVAL_MS int
+	SLACK_APPROVERS          string
+
+	// Twilio integration, enabled when the account SID and auth token are set. SMS and WhatsApp messages to the
+	// numbers of the account are answered by TWILIO_AGENT of TWILIO_PROJECT_ID, in replies of at most
+	// TWILIO_MAX_MESSAGE_LENGTH characters. TWILIO_WEBHOOK_URL is the public URL of the webhook the requests of
+	// Twilio are signed for, when the agent server is behind a proxy.
+	TWILIO_ACCOUNT_SID        string
+	TWILIO_AUTH_TOKEN         string
+	TWILIO_PROJECT_ID         string
+	TWILIO_AGENT              string
+	TWILIO_WEBHOOK_URL        string
+	TWILIO_MAX_MESSAGE_LENGTH int
+
+	// Email channel, enabled when the IMAP and SMTP servers are set. The emails of the EMAIL_IMAP_MAILBOX mailbox
+	// are polled every EMAIL_POLL_INTERVAL_SECONDS and answered by EMAIL_AGENT of EMAIL_PROJECT_ID from
+	// EMAIL_ADDRESS. IMAP is spoken with TLS except on port 143, SMTP with TLS on port 465 and with STARTTLS on
+	// the other ports when the server offers it.
+	EMAIL_ADDRESS               string
+	EMAIL_PROJECT_ID            string
+	EMAIL_AGENT                 string
+	EMAIL_IMAP_ADDR             string
+	EMAIL_IMAP_USERNAME         string
+	EMAIL_IMAP_PASSWORD         string
+	EMAIL_IMAP_MAILBOX          string
+	EMAIL_SMTP_ADDR             string
+	EMAIL_SMTP_USERNAME         string
+	EMAIL_SMTP_PASSWORD         string
+	EMAIL_POLL_INTERVAL_SECONDS int
 }
 
 func ReadConfig() *Config {
@@ -59,6 +150,55 @@ func ReadConfig() *Config {
 		}
 	}
 
+	replicaMaxLag := 5000
+	if lagStr := os.Getenv("DB_REPLICA_MAX_LAG_MS"); lagStr != "" {
+		if lag, err := strconv.Atoi(lagStr); err == nil {
+			replicaMaxLag = lag
+		}
+	}
+
+	trashRetentionDays := 30
+	if daysStr := os.Getenv("TRASH_RETENTION_DAYS"); daysStr != "" {
+		if days, err := strconv.Atoi(daysStr); err == nil && days > 0 {
+			trashRetentionDays = days
+		}
+	}
+
+	mcpDriftCheckInterval := 15
+	if minutesStr := os.Getenv("MCP_DRIFT_CHECK_INTERVAL_MINUTES"); minutesStr != "" {
+		if minutes, err := strconv.Atoi(minutesStr); err == nil && minutes >= 0 {
+			mcpDriftCheckInterval = minutes
+		}
+	}
+
+	compressionMinBytes := 1024
+	if bytesStr := os.Getenv("RESPONSE_COMPRESSION_MIN_BYTES"); bytesStr != "" {
+		if n, err := strconv.Atoi(bytesStr); err == nil && n >= 0 {
+			compressionMinBytes = n
+		}
+	}
+
+	slackUpdateInterval := 1000
+	if msStr := os.Getenv("SLACK_UPDATE_INTERVAL_MS"); msStr != "" {
+		if n, err := strconv.Atoi(msStr); err == nil && n > 0 {
+			slackUpdateInterval = n
+		}
+	}
+
+	twilioMaxMessageLength := 1600
+	if lengthStr := os.Getenv("TWILIO_MAX_MESSAGE_LENGTH"); lengthStr != "" {
+		if n, err := strconv.Atoi(lengthStr); err == nil && n > 0 {
+			twilioMaxMessageLength = n
+		}
+	}
+
+	emailPollInterval := 60
+	if secondsStr := os.Getenv("EMAIL_POLL_INTERVAL_SECONDS"); secondsStr != "" {
+		if n, err := strconv.Atoi(secondsStr); err == nil && n > 0 {
+			emailPollInterval = n
+		}
+	}
+
 	return &Config{
 		DB_USERNAME: os.Getenv("DB_USERNAME"),
 		DB_PASSWORD: os.Getenv("DB_PASSWORD"),
@@ -67,11 +207,18 @@ func ReadConfig() *Config {
 		DB_NAME:     os.Getenv("DB_NAME"),
 		DISABLE_TLS: os.Getenv("DISABLE_TLS"),
 
+		DB_REPLICA_HOSTS:      os.Getenv("DB_REPLICA_HOSTS"),
+		DB_REPLICA_MAX_LAG_MS: replicaMaxLag,
+
+		DB_REGIONS: os.Getenv("DB_REGIONS"),
+
 		REDIS_HOST:     os.Getenv("REDIS_HOST"),
 		REDIS_PORT:     os.Getenv("REDIS_PORT"),
 		REDIS_USERNAME: os.Getenv("REDIS_USERNAME"),
 		REDIS_PASSWORD: os.Getenv("REDIS_PASSWORD"),
 
+		STREAM_BROKER_TRANSPORT: getEnvOrDefault("STREAM_BROKER_TRANSPORT", "pubsub"),
+
 		CLICKHOUSE_HOST:     getEnvOrDefault("CLICKHOUSE_HOST", "localhost"),
 		CLICKHOUSE_PORT:     clickhousePort,
 		CLICKHOUSE_DATABASE: getEnvOrDefault("CLICKHOUSE_DATABASE", "otel"),
@@ -94,6 +241,59 @@ func ReadConfig() *Config {
 		TEMPORAL_SERVER_HOST_PORT: os.Getenv("TEMPORAL_SERVER_HOST_PORT"),
 
 		DATA_PATH: getDataPath(),
+
+		TRASH_RETENTION_DAYS: trashRetentionDays,
+
+		MCP_DRIFT_CHECK_INTERVAL_MINUTES: mcpDriftCheckInterval,
+
+		OUTBOX_REDIS_STREAM:   getEnvOrDefault("OUTBOX_REDIS_STREAM", "uno:events"),
+		OUTBOX_WEBHOOK_URLS:   os.Getenv("OUTBOX_WEBHOOK_URLS"),
+		OUTBOX_WEBHOOK_SECRET: os.Getenv("OUTBOX_WEBHOOK_SECRET"),
+		OUTBOX_KAFKA_REST_URL: os.Getenv("OUTBOX_KAFKA_REST_URL"),
+		OUTBOX_KAFKA_TOPIC:    getEnvOrDefault("OUTBOX_KAFKA_TOPIC", "uno-events"),
+
+		ENCRYPTION_MASTER_KEYS:       os.Getenv("ENCRYPTION_MASTER_KEYS"),
+		ENCRYPTION_VAULT_ADDR:        os.Getenv("ENCRYPTION_VAULT_ADDR"),
+		ENCRYPTION_VAULT_TOKEN:       os.Getenv("ENCRYPTION_VAULT_TOKEN"),
+		ENCRYPTION_VAULT_TRANSIT_KEY: os.Getenv("ENCRYPTION_VAULT_TRANSIT_KEY"),
+
+		ERASURE_REPORT_SECRET: os.Getenv("ERASURE_REPORT_SECRET"),
+
+		CONVERSATION_TOKEN_SECRET: os.Getenv("CONVERSATION_TOKEN_SECRET"),
+
+		FAULT_INJECTION: os.Getenv("FAULT_INJECTION"),
+
+		RESPONSE_COMPRESSION:           os.Getenv("RESPONSE_COMPRESSION") != "false",
+		RESPONSE_COMPRESSION_MIN_BYTES: compressionMinBytes,
+
+		REPLICATE_WEBHOOK_URL:    os.Getenv("REPLICATE_WEBHOOK_URL"),
+		REPLICATE_WEBHOOK_SECRET: os.Getenv("REPLICATE_WEBHOOK_SECRET"),
+
+		SLACK_SIGNING_SECRET:     os.Getenv("SLACK_SIGNING_SECRET"),
+		SLACK_BOT_TOKEN:          os.Getenv("SLACK_BOT_TOKEN"),
+		SLACK_PROJECT_ID:         os.Getenv("SLACK_PROJECT_ID"),
+		SLACK_AGENT:              os.Getenv("SLACK_AGENT"),
+		SLACK_UPDATE_INTERVAL_MS: slackUpdateInterval,
+		SLACK_APPROVERS:          os.Getenv("SLACK_APPROVERS"),
+
+		TWILIO_ACCOUNT_SID:        os.Getenv("TWILIO_ACCOUNT_SID"),
+		TWILIO_AUTH_TOKEN:         os.Getenv("TWILIO_AUTH_TOKEN"),
+		TWILIO_PROJECT_ID:         os.Getenv("TWILIO_PROJECT_ID"),
+		TWILIO_AGENT:              os.Getenv("TWILIO_AGENT"),
+		TWILIO_WEBHOOK_URL:        os.Getenv("TWILIO_WEBHOOK_URL"),
+		TWILIO_MAX_MESSAGE_LENGTH: twilioMaxMessageLength,
+
+		EMAIL_ADDRESS:               os.Getenv("EMAIL_ADDRESS"),
+		EMAIL_PROJECT_ID:            os.Getenv("EMAIL_PROJECT_ID"),
+		EMAIL_AGENT:                 os.Getenv("EMAIL_AGENT"),
+		EMAIL_IMAP_ADDR:             os.Getenv("EMAIL_IMAP_ADDR"),
+		EMAIL_IMAP_USERNAME:         os.Getenv("EMAIL_IMAP_USERNAME"),
+		EMAIL_IMAP_PASSWORD:         os.Getenv("EMAIL_IMAP_PASSWORD"),
+		EMAIL_IMAP_MAILBOX:          getEnvOrDefault("EMAIL_IMAP_MAILBOX", "INBOX"),
+		EMAIL_SMTP_ADDR:             os.Getenv("EMAIL_SMTP_ADDR"),
+		EMAIL_SMTP_USERNAME:         os.Getenv("EMAIL_SMTP_USERNAME"),
+		EMAIL_SMTP_PASSWORD:         os.Getenv("EMAIL_SMTP_PASSWORD"),
+		EMAIL_POLL_INTERVAL_SECONDS: emailPollInterval,
 	}
 }
 
@@ -119,6 +319,97 @@ func getDataPath() string {
 	return filepath.Join(".", "data")
 }
 
+// GetReplicaHosts returns the configured read replica hosts
+func (c *Config) GetReplicaHosts() []string {
+	hosts := []string{}
+	for _, host := range strings.Split(c.DB_REPLICA_HOSTS, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetReplicaMaxLag returns the replication lag above which reads go to the primary instead of a replica
+func (c *Config) GetReplicaMaxLag() time.Duration {
+	return time.Duration(c.DB_REPLICA_MAX_LAG_MS) * time.Millisecond
+}
+
+// GetRegionDatabases returns the database of each region, as <host>[:<port>][/<database>]
+func (c *Config) GetRegionDatabases() map[string]string {
+	regions := map[string]string{}
+	for _, entry := range strings.Split(c.DB_REGIONS, ",") {
+		region, database, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if ok && region != "" && database != "" {
+			regions[strings.TrimSpace(region)] = strings.TrimSpace(database)
+		}
+	}
+	return regions
+}
+
+// GetEncryptionMasterKeys returns the configured master keys, as <id>:<base64 key>
+func (c *Config) GetEncryptionMasterKeys() []string {
+	keys := []string{}
+	for _, key := range strings.Split(c.ENCRYPTION_MASTER_KEYS, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// GetErasureReportSecret returns the secret the erasure reports are signed with
+func (c *Config) GetErasureReportSecret() string {
+	if c.ERASURE_REPORT_SECRET != "" {
+		return c.ERASURE_REPORT_SECRET
+	}
+	return c.JWT_SECRET
+}
+
+// GetConversationTokenSecret returns the secret the conversation tokens are signed with
+func (c *Config) GetConversationTokenSecret() string {
+	if c.CONVERSATION_TOKEN_SECRET != "" {
+		return c.CONVERSATION_TOKEN_SECRET
+	}
+	return c.JWT_SECRET
+}
+
+// GetTrashRetention returns how long deleted agent configs and prompts can be restored
+func (c *Config) GetTrashRetention() time.Duration {
+	return time.Duration(c.TRASH_RETENTION_DAYS) * 24 * time.Hour
+}
+
+// GetMCPDriftCheckInterval returns how often the tools of MCP servers are checked for drift, or 0 if they aren't
+func (c *Config) GetMCPDriftCheckInterval() time.Duration {
+	return time.Duration(c.MCP_DRIFT_CHECK_INTERVAL_MINUTES) * time.Minute
+}
+
+// SlackEnabled reports whether the Slack integration is configured
+func (c *Config) SlackEnabled() bool {
+	return c.SLACK_SIGNING_SECRET != "" && c.SLACK_BOT_TOKEN != ""
+}
+
+// GetSlackApprovers returns the Slack users allowed to approve tool calls, empty when everyone is
+func (c *Config) GetSlackApprovers() []string {
+	approvers := []string{}
+	for _, user := range strings.Split(c.SLACK_APPROVERS, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			approvers = append(approvers, user)
+		}
+	}
+	return approvers
+}
+
+// TwilioEnabled reports whether the Twilio integration is configured
+func (c *Config) TwilioEnabled() bool {
+	return c.TWILIO_ACCOUNT_SID != "" && c.TWILIO_AUTH_TOKEN != ""
+}
+
+// EmailEnabled reports whether the email channel is configured
+func (c *Config) EmailEnabled() bool {
+	return c.EMAIL_IMAP_ADDR != "" && c.EMAIL_SMTP_ADDR != ""
+}
+
 // GetAgentDataPath returns the path where agent data is stored.
 func (c *Config) GetAgentDataPath() string {
 	return path.Join(c.DATA_PATH, "agent-data")
@@ -128,3 +419,8 @@ func (c *Config) GetAgentDataPath() string {
 func (c *Config) GetSessionDataPath() string {
 	return path.Join(c.DATA_PATH, "session-data")
 }
+
+// GetHistorySpoolPath returns the path where history writes that failed are queued for retry
+func (c *Config) GetHistorySpoolPath() string {
+	return path.Join(c.DATA_PATH, "history-spool")
+}
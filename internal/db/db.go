@@ -1,7 +1,6 @@
 package db
 
 import (
-	"fmt"
 	"log"
 	"log/slog"
 
@@ -10,10 +9,7 @@ import (
 )
 
 func NewConn(conf *config.Config) *sqlx.DB {
-	str := fmt.Sprintf("postgresql://%v:%v@%v:%v/%v", conf.DB_USERNAME, conf.DB_PASSWORD, conf.DB_HOST, conf.DB_PORT, conf.DB_NAME)
-	if conf.DISABLE_TLS == "true" {
-		str = str + "?sslmode=disable"
-	}
+	str := dsn(conf, conf.DB_HOST, conf.DB_PORT)
 	slog.Info("Connecting to database")
 
 	// Connect to database
@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// projectRegionTTL bounds how long the region of a project is cached
+const projectRegionTTL = 30 * time.Second
+
+// ErrRegionUnavailable is returned for a project pinned to a region that has no database configured. Its data
+// is never written to another region instead.
+var ErrRegionUnavailable = errors.New("no database is configured for the region of the project")
+
+type projectRegion struct {
+	region   string
+	loadedAt time.Time
+}
+
+// RegionRouter routes the data of projects pinned to a region to the database of that region. Projects that
+// aren't pinned are served by the primary database.
+type RegionRouter struct {
+	primary *sqlx.DB
+	regions map[string]*sqlx.DB
+
+	mu       sync.Mutex
+	projects map[uuid.UUID]projectRegion
+}
+
+// NewRegionRouter connects to the databases listed in DB_REGIONS
+func NewRegionRouter(conf *config.Config, primary *sqlx.DB) *RegionRouter {
+	router := &RegionRouter{
+		primary:  primary,
+		regions:  map[string]*sqlx.DB{},
+		projects: map[uuid.UUID]projectRegion{},
+	}
+
+	for region, database := range conf.GetRegionDatabases() {
+		conn, err := openRegion(conf, database)
+		if err != nil {
+			log.Fatalln("Unable to open database of region", region, err.Error())
+		}
+
+		router.regions[region] = conn
+		slog.Info("Region database configured", slog.String("region", region))
+	}
+
+	return router
+}
+
+// NewRegionConn connects to the database of a single region listed in DB_REGIONS
+func NewRegionConn(conf *config.Config, region string) (*sqlx.DB, error) {
+	database, ok := conf.GetRegionDatabases()[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRegionUnavailable, region)
+	}
+	return openRegion(conf, database)
+}
+
+// openRegion opens a database given as <host>[:<port>][/<database>], the port and database default to those
+// of the primary database
+func openRegion(conf *config.Config, database string) (*sqlx.DB, error) {
+	host, name, _ := strings.Cut(database, "/")
+	if name == "" {
+		name = conf.DB_NAME
+	}
+	port := conf.DB_PORT
+	if h, p, ok := strings.Cut(host, ":"); ok {
+		host, port = h, p
+	}
+
+	return sqlx.Open("postgres", databaseDSN(conf, host, port, name))
+}
+
+// Conns returns the database of every configured region
+func (r *RegionRouter) Conns() map[string]*sqlx.DB {
+	if r == nil {
+		return nil
+	}
+	return r.regions
+}
+
+// HasRegion reports whether a database is configured for the region
+func (r *RegionRouter) HasRegion(region string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.regions[region]
+	return ok
+}
+
+// Region returns the region the project is pinned to, or "" when it isn't pinned
+func (r *RegionRouter) Region(ctx context.Context, projectID uuid.UUID) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+
+	r.mu.Lock()
+	cached, ok := r.projects[projectID]
+	r.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < projectRegionTTL {
+		return cached.region, nil
+	}
+
+	var region *string
+	err := r.primary.GetContext(ctx, &region, `SELECT region FROM projects WHERE id = $1`, projectID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get region of project %s: %w", projectID, err)
+	}
+
+	cached = projectRegion{loadedAt: time.Now()}
+	if region != nil {
+		cached.region = *region
+	}
+
+	r.mu.Lock()
+	r.projects[projectID] = cached
+	r.mu.Unlock()
+
+	return cached.region, nil
+}
+
+// Forget drops the cached region of a project, after it was changed
+func (r *RegionRouter) Forget(projectID uuid.UUID) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.projects, projectID)
+	r.mu.Unlock()
+}
+
+// Conn returns the database that holds the data of the project, along with its region
+func (r *RegionRouter) Conn(ctx context.Context, projectID uuid.UUID) (*sqlx.DB, string, error) {
+	region, err := r.Region(ctx, projectID)
+	if err != nil || region == "" {
+		return r.primaryConn(), "", err
+	}
+
+	conn, ok := r.regions[region]
+	if !ok {
+		return nil, region, fmt.Errorf("%w: %s", ErrRegionUnavailable, region)
+	}
+
+	return conn, region, nil
+}
+
+func (r *RegionRouter) primaryConn() *sqlx.DB {
+	if r == nil {
+		return nil
+	}
+	return r.primary
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/jmoiron/sqlx"
+)
+
+// replicaLagQuery returns the replication lag of a replica in seconds. A replica that has replayed everything
+// it received is not lagging, even if the primary has been idle since the last replayed transaction.
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END
+`
+
+type replica struct {
+	host    string
+	db      *sqlx.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // milliseconds
+}
+
+// ReplicaPool routes reads to read replicas of the primary database. Replicas are checked periodically, and a
+// replica that is unreachable or lags behind the primary by more than the allowed lag is skipped until it catches up.
+// When no replica can serve a read, the primary is used.
+type ReplicaPool struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReplicaPool connects to the replicas listed in DB_REPLICA_HOSTS. It returns nil when no replica is configured,
+// in which case reads go to the primary.
+func NewReplicaPool(conf *config.Config, primary *sqlx.DB) *ReplicaPool {
+	hosts := conf.GetReplicaHosts()
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	pool := &ReplicaPool{
+		primary: primary,
+		maxLag:  conf.GetReplicaMaxLag(),
+		stop:    make(chan struct{}),
+	}
+
+	for _, host := range hosts {
+		hostname, port := host, conf.DB_PORT
+		if h, p, ok := strings.Cut(host, ":"); ok {
+			hostname, port = h, p
+		}
+
+		// Connections are opened lazily, so an unreachable replica doesn't block startup
+		conn, err := sqlx.Open("postgres", dsn(conf, hostname, port))
+		if err != nil {
+			slog.Error("Failed to open read replica", slog.String("host", host), slog.Any("error", err))
+			continue
+		}
+
+		pool.replicas = append(pool.replicas, &replica{host: host, db: conn})
+	}
+
+	if len(pool.replicas) == 0 {
+		return nil
+	}
+
+	pool.check()
+	go pool.run()
+
+	slog.Info("Routing reads to read replicas", slog.Int("replicas", len(pool.replicas)), slog.Duration("max_lag", pool.maxLag))
+
+	return pool
+}
+
+// Reader returns a healthy replica that is within the allowed lag, or the primary if there is none.
+// It returns nil when called on a nil pool.
+func (p *ReplicaPool) Reader() *sqlx.DB {
+	if p == nil || len(p.replicas) == 0 {
+		return nil
+	}
+
+	start := p.next.Add(1)
+	for i := range p.replicas {
+		r := p.replicas[(int(start)+i)%len(p.replicas)]
+		if r.healthy.Load() && time.Duration(r.lag.Load())*time.Millisecond <= p.maxLag {
+			return r.db
+		}
+	}
+
+	return p.primary
+}
+
+// Close stops the health checks and closes the replica connections
+func (p *ReplicaPool) Close() {
+	if p == nil {
+		return
+	}
+
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		for _, r := range p.replicas {
+			r.db.Close()
+		}
+	})
+}
+
+func (p *ReplicaPool) run() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.check()
+		}
+	}
+}
+
+// check refreshes the health and lag of every replica
+func (p *ReplicaPool) check() {
+	for _, r := range p.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		var lagSeconds float64
+		err := r.db.GetContext(ctx, &lagSeconds, replicaLagQuery)
+		cancel()
+
+		wasHealthy := r.healthy.Load()
+		if err != nil {
+			r.healthy.Store(false)
+			if wasHealthy {
+				slog.Warn("Read replica is unavailable, reading from primary", slog.String("host", r.host), slog.Any("error", err))
+			}
+			continue
+		}
+
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		r.lag.Store(lag.Milliseconds())
+		r.healthy.Store(true)
+
+		if lag > p.maxLag {
+			slog.Warn("Read replica is lagging, reading from primary", slog.String("host", r.host), slog.Duration("lag", lag))
+		}
+	}
+}
+
+func dsn(conf *config.Config, host string, port string) string {
+	return databaseDSN(conf, host, port, conf.DB_NAME)
+}
+
+func databaseDSN(conf *config.Config, host string, port string, name string) string {
+	str := fmt.Sprintf("postgresql://%v:%v@%v:%v/%v", conf.DB_USERNAME, conf.DB_PASSWORD, host, port, name)
+	if conf.DISABLE_TLS == "true" {
+		str = str + "?sslmode=disable"
+	}
+	return str
+}
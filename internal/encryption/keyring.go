@@ -0,0 +1,389 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/internal/config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// tokenPrefix marks encrypted values: enc:v1:<data key id>:<base64 of nonce and ciphertext>
+const tokenPrefix = "enc:v1:"
+
+// activeKeyTTL bounds how long a server keeps encrypting with a data key after another one rotated it
+const activeKeyTTL = 5 * time.Minute
+
+// InstanceScope is the scope of the data key of data that belongs to no project, like provider keys
+var InstanceScope = uuid.Nil
+
+// ErrNotConfigured is returned when encrypted data is read without a master key configured
+var ErrNotConfigured = errors.New("data is encrypted but no master key is configured")
+
+type activeKey struct {
+	id       uuid.UUID
+	loadedAt time.Time
+}
+
+// Keyring encrypts data with envelope encryption. Every project has its own data key, which is stored wrapped
+// by the master key. Rotating the data key of a project only affects new writes, the data keys it replaced
+// are kept to decrypt what was written with them until it is re-encrypted.
+type Keyring struct {
+	db      *sqlx.DB
+	current MasterKey
+	masters map[string]MasterKey
+
+	mu     sync.Mutex
+	keys   map[uuid.UUID]cipher.AEAD
+	active map[uuid.UUID]activeKey
+}
+
+// NewKeyring creates a keyring. The first master key wraps new data keys, the others are only used to unwrap the
+// data keys they wrapped, until those are rewrapped.
+func NewKeyring(conn *sqlx.DB, masters ...MasterKey) *Keyring {
+	if len(masters) == 0 {
+		return nil
+	}
+
+	k := &Keyring{
+		db:      conn,
+		current: masters[0],
+		masters: map[string]MasterKey{},
+		keys:    map[uuid.UUID]cipher.AEAD{},
+		active:  map[uuid.UUID]activeKey{},
+	}
+	for _, master := range masters {
+		k.masters[master.ID()] = master
+	}
+
+	return k
+}
+
+// NewKeyringFromConfig creates a keyring with the master keys of the configuration. It returns nil when no master
+// key is configured, in which case data is stored unencrypted.
+func NewKeyringFromConfig(conf *config.Config, conn *sqlx.DB) (*Keyring, error) {
+	var masters []MasterKey
+	if conf.ENCRYPTION_VAULT_ADDR != "" {
+		if conf.ENCRYPTION_VAULT_TRANSIT_KEY == "" {
+			return nil, errors.New("ENCRYPTION_VAULT_TRANSIT_KEY is required with ENCRYPTION_VAULT_ADDR")
+		}
+		masters = append(masters, NewVaultTransitMasterKey(conf.ENCRYPTION_VAULT_ADDR, conf.ENCRYPTION_VAULT_TOKEN, conf.ENCRYPTION_VAULT_TRANSIT_KEY))
+	}
+
+	for _, entry := range conf.GetEncryptionMasterKeys() {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("master key must be <id>:<base64 key>, got %q", id)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s is not valid base64: %w", id, err)
+		}
+
+		master, err := NewLocalMasterKey(id, key)
+		if err != nil {
+			return nil, err
+		}
+		masters = append(masters, master)
+	}
+
+	return NewKeyring(conn, masters...), nil
+}
+
+// Enabled reports whether data is encrypted
+func (k *Keyring) Enabled() bool {
+	return k != nil
+}
+
+// IsEncrypted reports whether a value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, tokenPrefix)
+}
+
+// Encrypt encrypts the plaintext with the active data key of the project
+func (k *Keyring) Encrypt(ctx context.Context, projectID uuid.UUID, plaintext []byte) (string, error) {
+	keyID, err := k.activeKeyID(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := k.dataKey(ctx, keyID)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := seal(aead, plaintext, []byte(keyID.String()))
+	if err != nil {
+		return "", err
+	}
+
+	return tokenPrefix + keyID.String() + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt. Values that are not encrypted are returned as they are, so data
+// written before encryption was enabled stays readable.
+func (k *Keyring) Decrypt(ctx context.Context, value string) ([]byte, error) {
+	if !IsEncrypted(value) {
+		return []byte(value), nil
+	}
+	if k == nil {
+		return nil, ErrNotConfigured
+	}
+
+	keyID, sealed, err := parseToken(value)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := k.dataKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(aead, sealed, []byte(keyID.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with data key %s: %w", keyID, err)
+	}
+
+	return plaintext, nil
+}
+
+// IsCurrent reports whether a value is encrypted with the active data key of the project, values that are not
+// have to be re-encrypted after a rotation
+func (k *Keyring) IsCurrent(ctx context.Context, projectID uuid.UUID, value string) (bool, error) {
+	if !IsEncrypted(value) {
+		return false, nil
+	}
+
+	keyID, _, err := parseToken(value)
+	if err != nil {
+		return false, err
+	}
+
+	activeID, err := k.activeKeyID(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+
+	return keyID == activeID, nil
+}
+
+func parseToken(value string) (uuid.UUID, []byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, tokenPrefix), ":")
+	if !ok {
+		return uuid.Nil, nil, errors.New("malformed encrypted value")
+	}
+
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("malformed data key ID: %w", err)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return uuid.Nil, nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	return keyID, sealed, nil
+}
+
+// activeKeyID returns the ID of the data key new data of the project is encrypted with, creating it if needed
+func (k *Keyring) activeKeyID(ctx context.Context, projectID uuid.UUID) (uuid.UUID, error) {
+	k.mu.Lock()
+	cached, ok := k.active[projectID]
+	k.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < activeKeyTTL {
+		return cached.id, nil
+	}
+
+	var keyID uuid.UUID
+	err := k.db.GetContext(ctx, &keyID, `
+		SELECT id FROM data_keys WHERE project_id = $1 AND rotated_at IS NULL
+	`, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		keyID, err = k.createDataKey(ctx, k.db, projectID)
+		if err == nil && keyID == uuid.Nil {
+			// Another server created it first
+			err = k.db.GetContext(ctx, &keyID, `
+				SELECT id FROM data_keys WHERE project_id = $1 AND rotated_at IS NULL
+			`, projectID)
+		}
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get data key of project %s: %w", projectID, err)
+	}
+
+	k.mu.Lock()
+	k.active[projectID] = activeKey{id: keyID, loadedAt: time.Now()}
+	k.mu.Unlock()
+
+	return keyID, nil
+}
+
+// createDataKey creates a data key for the project. It returns uuid.Nil when the project already has an active one.
+func (k *Keyring) createDataKey(ctx context.Context, conn sqlx.ExtContext, projectID uuid.UUID) (uuid.UUID, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return uuid.Nil, err
+	}
+
+	wrapped, err := k.current.Wrap(ctx, key)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	keyID := uuid.New()
+	result, err := conn.ExecContext(ctx, `
+		INSERT INTO data_keys (id, project_id, master_key_id, wrapped_key, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id) WHERE rotated_at IS NULL DO NOTHING
+	`, keyID, projectID, k.current.ID(), wrapped, time.Now())
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return uuid.Nil, nil
+	}
+
+	return keyID, nil
+}
+
+// dataKey returns the unwrapped data key with the ID
+func (k *Keyring) dataKey(ctx context.Context, keyID uuid.UUID) (cipher.AEAD, error) {
+	k.mu.Lock()
+	aead, ok := k.keys[keyID]
+	k.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	var row struct {
+		MasterKeyID string `db:"master_key_id"`
+		WrappedKey  []byte `db:"wrapped_key"`
+	}
+	err := k.db.GetContext(ctx, &row, `SELECT master_key_id, wrapped_key FROM data_keys WHERE id = $1`, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key %s: %w", keyID, err)
+	}
+
+	master, ok := k.masters[row.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("data key %s is wrapped by master key %s, which is not configured", keyID, row.MasterKeyID)
+	}
+
+	key, err := master.Unwrap(ctx, row.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key %s: %w", keyID, err)
+	}
+
+	aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	k.keys[keyID] = aead
+	k.mu.Unlock()
+
+	return aead, nil
+}
+
+// RotateDataKeys replaces the active data key of every scope that has one. New data is encrypted with the new keys
+// right away by this keyring, and within activeKeyTTL by the keyrings of other servers.
+func (k *Keyring) RotateDataKeys(ctx context.Context) (int, error) {
+	var projectIDs []uuid.UUID
+	err := k.db.SelectContext(ctx, &projectIDs, `SELECT DISTINCT project_id FROM data_keys WHERE rotated_at IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list data keys: %w", err)
+	}
+
+	for _, projectID := range projectIDs {
+		if err := k.rotateDataKey(ctx, projectID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(projectIDs), nil
+}
+
+func (k *Keyring) rotateDataKey(ctx context.Context, projectID uuid.UUID) error {
+	tx, err := k.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE data_keys SET rotated_at = NOW() WHERE project_id = $1 AND rotated_at IS NULL
+	`, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to retire data key of project %s: %w", projectID, err)
+	}
+
+	keyID, err := k.createDataKey(ctx, tx, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to create data key of project %s: %w", projectID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.active[projectID] = activeKey{id: keyID, loadedAt: time.Now()}
+	k.mu.Unlock()
+
+	return nil
+}
+
+// RewrapDataKeys wraps the data keys that were wrapped by another master key with the current one. Once it is
+// done, the other master keys can be removed from the configuration.
+func (k *Keyring) RewrapDataKeys(ctx context.Context) (int, error) {
+	var rows []struct {
+		ID          uuid.UUID `db:"id"`
+		MasterKeyID string    `db:"master_key_id"`
+		WrappedKey  []byte    `db:"wrapped_key"`
+	}
+	err := k.db.SelectContext(ctx, &rows, `
+		SELECT id, master_key_id, wrapped_key FROM data_keys WHERE master_key_id != $1
+	`, k.current.ID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to list data keys: %w", err)
+	}
+
+	for _, row := range rows {
+		master, ok := k.masters[row.MasterKeyID]
+		if !ok {
+			return 0, fmt.Errorf("data key %s is wrapped by master key %s, which is not configured", row.ID, row.MasterKeyID)
+		}
+
+		key, err := master.Unwrap(ctx, row.WrappedKey)
+		if err != nil {
+			return 0, fmt.Errorf("failed to unwrap data key %s: %w", row.ID, err)
+		}
+
+		wrapped, err := k.current.Wrap(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to wrap data key %s: %w", row.ID, err)
+		}
+
+		_, err = k.db.ExecContext(ctx, `
+			UPDATE data_keys SET master_key_id = $2, wrapped_key = $3 WHERE id = $1
+		`, row.ID, k.current.ID(), wrapped)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update data key %s: %w", row.ID, err)
+		}
+	}
+
+	return len(rows), nil
+}
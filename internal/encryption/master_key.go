@@ -0,0 +1,166 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+)
+
+// MasterKey wraps the data keys that encrypt the data. Only wrapped data keys are stored, so the data can't be
+// read without the master key.
+type MasterKey interface {
+	// ID identifies the key, it is stored with the data keys it wraps
+	ID() string
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// LocalMasterKey is a master key held in the configuration of the server
+type LocalMasterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalMasterKey creates a master key from a 256 bit key
+func NewLocalMasterKey(id string, key []byte) (*LocalMasterKey, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key %s must be 32 bytes, got %d", id, len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalMasterKey{id: "local:" + id, aead: aead}, nil
+}
+
+func (k *LocalMasterKey) ID() string {
+	return k.id
+}
+
+func (k *LocalMasterKey) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	return seal(k.aead, key, []byte(k.id))
+}
+
+func (k *LocalMasterKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return open(k.aead, wrapped, []byte(k.id))
+}
+
+// VaultTransitMasterKey is a master key held by the transit secrets engine of HashiCorp Vault or OpenBao. The key
+// never leaves the KMS, data keys are sent to it to be wrapped and unwrapped.
+type VaultTransitMasterKey struct {
+	addr   string
+	token  string
+	key    string
+	client *http.Client
+}
+
+// NewVaultTransitMasterKey creates a master key for the transit key with the given name
+func NewVaultTransitMasterKey(addr string, token string, key string) *VaultTransitMasterKey {
+	return &VaultTransitMasterKey{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		key:    key,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (k *VaultTransitMasterKey) ID() string {
+	return "vault:" + k.key
+}
+
+func (k *VaultTransitMasterKey) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	var out struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := k.call(ctx, "encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)}, &out); err != nil {
+		return nil, err
+	}
+
+	// The ciphertext names the version of the transit key, so data keys wrapped before the key was rotated in
+	// the KMS can still be unwrapped
+	return []byte(out.Data.Ciphertext), nil
+}
+
+func (k *VaultTransitMasterKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var out struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := k.call(ctx, "decrypt", map[string]string{"ciphertext": string(wrapped)}, &out); err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(out.Data.Plaintext)
+}
+
+func (k *VaultTransitMasterKey) call(ctx context.Context, op string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/transit/%s/%s", k.addr, op, k.key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", k.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("transit %s failed: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("transit %s failed with status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext, the random nonce is prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
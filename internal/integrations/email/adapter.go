@@ -0,0 +1,374 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/integrations"
+	email2 "github.com/curaious/uno/internal/services/email"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// runTimeout bounds a run of the agent for an email
+	runTimeout = 10 * time.Minute
+	// maxEmailsPerPoll bounds the emails fetched by a poll, the others wait for the next one
+	maxEmailsPerPoll = 50
+)
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// MessageStore records the emails received and sent, and the threads of their conversations
+type MessageStore interface {
+	GetMessage(ctx context.Context, messageID string) (*email2.EmailMessage, error)
+	CreateMessage(ctx context.Context, message *email2.EmailMessage) (bool, error)
+	UpdateThread(ctx context.Context, messageID string, threadID string) error
+}
+
+// Options configure the email adapter
+type Options struct {
+	ProjectID uuid.UUID
+	// Agent answers the emails, as accepted by the agent_id parameter of the converse endpoint
+	Agent string
+	// Address is the address of the mailbox, the replies are sent from it
+	Address string
+
+	// IMAPAddr is the host:port of the IMAP server of the mailbox, polled every PollInterval
+	IMAPAddr     string
+	IMAPUsername string
+	IMAPPassword string
+	Mailbox      string
+	PollInterval time.Duration
+}
+
+// Adapter answers the emails of a mailbox with an agent. The mailbox is a namespace and every email thread a
+// conversation: an email starts a conversation, unless it replies to an email of a conversation, which it finds with
+// its In-Reply-To and References headers. The reply of the agent is sent when the run ends, tool calls needing
+// approval are approved or rejected by replying YES or NO.
+type Adapter struct {
+	sender   Sender
+	backend  integrations.Backend
+	messages MessageStore
+	opts     Options
+}
+
+func NewAdapter(sender Sender, backend integrations.Backend, messages MessageStore, opts Options) *Adapter {
+	if opts.Mailbox == "" {
+		opts.Mailbox = "INBOX"
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+
+	return &Adapter{
+		sender:   sender,
+		backend:  backend,
+		messages: messages,
+		opts:     opts,
+	}
+}
+
+// Poll answers the unseen emails of the mailbox every poll interval, until the context is done
+func (a *Adapter) Poll(ctx context.Context) {
+	ticker := time.NewTicker(a.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		emails, err := a.fetchUnseen(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to poll the mailbox of the email channel", slog.Any("error", err))
+		}
+		for _, raw := range emails {
+			if err := a.HandleEmail(ctx, raw); err != nil {
+				slog.ErrorContext(ctx, "Failed to answer email", slog.Any("error", err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchUnseen returns the unseen emails of the mailbox, flagging them as seen. The connection isn't held while the
+// agent answers, the emails are deduplicated by their Message-ID when several replicas poll the mailbox.
+func (a *Adapter) fetchUnseen(ctx context.Context) ([][]byte, error) {
+	c, err := dialIMAP(ctx, a.opts.IMAPAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	if err := c.Login(a.opts.IMAPUsername, a.opts.IMAPPassword); err != nil {
+		return nil, err
+	}
+	if err := c.Select(a.opts.Mailbox); err != nil {
+		return nil, err
+	}
+
+	uids, err := c.SearchUnseen()
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) > maxEmailsPerPoll {
+		uids = uids[:maxEmailsPerPoll]
+	}
+
+	var emails [][]byte
+	for _, uid := range uids {
+		raw, err := c.Fetch(uid)
+		if err != nil {
+			return emails, err
+		}
+		if err := c.MarkSeen(uid); err != nil {
+			return emails, err
+		}
+		emails = append(emails, raw)
+	}
+
+	return emails, nil
+}
+
+// HandleEmail answers a raw email received by the mailbox
+func (a *Adapter) HandleEmail(ctx context.Context, raw []byte) error {
+	email, err := ParseEmail(raw)
+	if err != nil {
+		return err
+	}
+	// Automatic emails and the emails of the mailbox itself aren't answered, so that two responders don't loop
+	if email.MessageID == "" || email.AutoSubmitted || strings.EqualFold(email.From, a.opts.Address) || email.Text == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	parent, err := a.parentOf(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	namespace := namespaceOf(a.opts.Address)
+	threadID := ""
+	if parent != nil {
+		namespace = parent.Namespace
+		threadID = parent.ThreadID
+	}
+
+	// The email is recorded before it is answered, an email recorded already was answered
+	created, err := a.messages.CreateMessage(ctx, &email2.EmailMessage{
+		MessageID: email.MessageID,
+		ProjectID: a.opts.ProjectID,
+		Namespace: namespace,
+		ThreadID:  threadID,
+	})
+	if err != nil || !created {
+		return err
+	}
+
+	previousMessageID := ""
+	if threadID != "" {
+		previousMessageID, err = a.backend.LastMessageID(ctx, a.opts.ProjectID, namespace, threadID)
+		if err != nil {
+			return err
+		}
+	}
+
+	text := email.Text
+	if parent == nil && email.Subject != "" {
+		text = "Subject: " + email.Subject + "\n\n" + text
+	}
+	message := responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+		Role:    constants.RoleUser,
+		Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: text}}},
+	}}
+
+	// A reply to the approval of a paused run resumes it when it starts with YES or NO
+	if parent != nil && parent.RunID != "" && parent.RunID == previousMessageID && len(parent.PendingCallIDs) > 0 {
+		firstLine, _, _ := strings.Cut(email.Text, "\n")
+		if approved, ok := isApproval(firstLine); ok {
+			decision := responses.FunctionCallApprovalResponseMessage{
+				ID:              uuid.NewString(),
+				ApprovedCallIds: []string{},
+				RejectedCallIds: []string{},
+			}
+			if approved {
+				decision.ApprovedCallIds = parent.PendingCallIDs
+			} else {
+				decision.RejectedCallIds = parent.PendingCallIDs
+			}
+			message = responses.InputMessageUnion{OfFunctionCallApprovalResponse: &decision}
+		}
+	}
+
+	return a.run(ctx, email, namespace, threadID, previousMessageID, message)
+}
+
+// run answers an email with the reply of the agent, once the run ended
+func (a *Adapter) run(ctx context.Context, email *Email, namespace string, threadID string, previousMessageID string, message responses.InputMessageUnion) error {
+	stream, err := a.backend.Converse(ctx, &integrations.ConverseInput{
+		ProjectID:         a.opts.ProjectID,
+		Agent:             a.opts.Agent,
+		Namespace:         namespace,
+		PreviousMessageID: previousMessageID,
+		Message:           message,
+		Context: map[string]any{
+			"email_from":    email.From,
+			"email_subject": email.Subject,
+		},
+	})
+	if err != nil {
+		a.reply(ctx, email, namespace, threadID, "", nil, "Sorry, we could not answer your email. Please try again later.")
+		return err
+	}
+
+	var text strings.Builder
+	lastItemID := ""
+	runID := ""
+	var pendingCalls []responses.FunctionCallMessage
+	held := false
+	ended := false
+	for chunk := range stream {
+		switch {
+		case chunk.OfOutputTextDelta != nil:
+			// The output messages of the run are the paragraphs of the reply
+			if chunk.OfOutputTextDelta.ItemId != lastItemID && text.Len() > 0 {
+				text.WriteString("\n\n")
+			}
+			lastItemID = chunk.OfOutputTextDelta.ItemId
+			text.WriteString(chunk.OfOutputTextDelta.Delta)
+
+		case chunk.OfRunCompleted != nil:
+			runID = chunk.OfRunCompleted.RunState.Id
+			ended = true
+
+		case chunk.OfRunPaused != nil:
+			runID = chunk.OfRunPaused.RunState.Id
+			pendingCalls = chunk.OfRunPaused.RunState.PendingToolCalls
+			ended = true
+
+		case chunk.OfTakeoverActive != nil:
+			// The email was passed on to the operator of the conversation, who answers it
+			runID = chunk.OfTakeoverActive.MessageID
+			held = true
+			ended = true
+		}
+
+		if ended {
+			break
+		}
+	}
+
+	if threadID == "" && runID != "" {
+		threadID, err = a.backend.ThreadOfMessage(ctx, a.opts.ProjectID, namespace, runID)
+		if err == nil {
+			err = a.messages.UpdateThread(ctx, email.MessageID, threadID)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to thread email", slog.String("message_id", email.MessageID), slog.Any("error", err))
+		}
+	}
+
+	if held {
+		return nil
+	}
+
+	body := strings.TrimSpace(text.String())
+	if !ended {
+		body = strings.TrimSpace(body + "\n\nSorry, the answer to your email was interrupted. Please try again.")
+	}
+
+	var callIDs []string
+	if len(pendingCalls) > 0 {
+		names := []string{}
+		for _, call := range pendingCalls {
+			callIDs = append(callIDs, call.CallID)
+			names = append(names, call.Name)
+		}
+		body = strings.TrimSpace(body + fmt.Sprintf("\n\nApproval needed to run %s. Reply YES to approve or NO to reject.", strings.Join(names, ", ")))
+	}
+
+	if body == "" {
+		return nil
+	}
+	a.reply(ctx, email, namespace, threadID, runID, callIDs, body)
+	return nil
+}
+
+// reply sends a reply to an email and records it in the thread of its conversation
+func (a *Adapter) reply(ctx context.Context, email *Email, namespace string, threadID string, runID string, callIDs []string, body string) {
+	messageID := "<" + uuid.NewString() + "@" + domainOf(a.opts.Address) + ">"
+	msg := replyMessage(a.opts.Address, email, messageID, body, time.Now())
+	if err := a.sender.Send(ctx, a.opts.Address, []string{email.From}, msg); err != nil {
+		slog.ErrorContext(ctx, "Failed to send email", slog.String("in_reply_to", email.MessageID), slog.Any("error", err))
+		return
+	}
+
+	_, err := a.messages.CreateMessage(ctx, &email2.EmailMessage{
+		MessageID:      messageID,
+		ProjectID:      a.opts.ProjectID,
+		Namespace:      namespace,
+		ThreadID:       threadID,
+		RunID:          runID,
+		PendingCallIDs: pq.StringArray(callIDs),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record sent email", slog.String("message_id", messageID), slog.Any("error", err))
+	}
+}
+
+// parentOf finds the email of a conversation an email replies to, nil for an email starting a conversation
+func (a *Adapter) parentOf(ctx context.Context, email *Email) (*email2.EmailMessage, error) {
+	candidates := slices.Clone(email.References)
+	slices.Reverse(candidates)
+	if email.InReplyTo != "" {
+		candidates = append([]string{email.InReplyTo}, candidates...)
+	}
+
+	for _, messageID := range candidates {
+		message, err := a.messages.GetMessage(ctx, messageID)
+		if err != nil {
+			return nil, err
+		}
+		if message != nil && message.ThreadID != "" {
+			return message, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isApproval reports whether a reply answers an approval, and whether it approves
+func isApproval(text string) (approved bool, ok bool) {
+	switch strings.ToLower(strings.Trim(text, " .!\r\n")) {
+	case "yes", "approve", "approved":
+		return true, true
+	case "no", "reject", "rejected":
+		return false, true
+	}
+
+	return false, false
+}
+
+// domainOf is the domain of an address, the Message-IDs of the replies are generated in it
+func domainOf(address string) string {
+	if _, domain, ok := strings.Cut(address, "@"); ok {
+		return domain
+	}
+	return "localhost"
+}
+
+// namespaceOf is the namespace of the conversations of a mailbox, like email-support-example-com
+func namespaceOf(address string) string {
+	return "email-" + strings.Trim(nonAlphanumericRegex.ReplaceAllString(strings.ToLower(address), "-"), "-")
+}
@@ -0,0 +1,192 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// imapTimeout bounds a command of the IMAP server
+const imapTimeout = time.Minute
+
+// imapResponse is an untagged response of the IMAP server, with the literals it holds
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+// imapClient is the subset of IMAP the email channel polls a mailbox with: it logs in, searches the unseen
+// messages, fetches them and flags them as seen
+type imapClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// dialIMAP connects to an IMAP server, with TLS unless the address is the plain text port 143
+func dialIMAP(ctx context.Context, addr string) (*imapClient, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if port == "143" {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &imapClient{conn: conn, r: bufio.NewReader(conn)}
+	_ = conn.SetDeadline(time.Now().Add(imapTimeout))
+	greeting, _, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", greeting)
+	}
+
+	return c, nil
+}
+
+func (c *imapClient) Close() error {
+	_, _ = c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+func (c *imapClient) Login(username string, password string) error {
+	_, err := c.command("LOGIN " + quoteIMAP(username) + " " + quoteIMAP(password))
+	return err
+}
+
+func (c *imapClient) Select(mailbox string) error {
+	_, err := c.command("SELECT " + quoteIMAP(mailbox))
+	return err
+}
+
+// SearchUnseen returns the UIDs of the messages of the mailbox that weren't seen
+func (c *imapClient) SearchUnseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, res := range responses {
+		fields := strings.Fields(res.line)
+		if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[2:] {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("imap: invalid uid %q", field)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+
+	return uids, nil
+}
+
+// Fetch returns the raw message of a UID, without flagging it as seen
+func (c *imapClient) Fetch(uid uint32) ([]byte, error) {
+	responses, err := c.command(fmt.Sprintf("UID FETCH %d (BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, res := range responses {
+		if strings.Contains(strings.ToUpper(res.line), "FETCH") && len(res.literals) > 0 {
+			return res.literals[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("imap: message %d not found", uid)
+}
+
+// MarkSeen flags the message of a UID as seen
+func (c *imapClient) MarkSeen(uid uint32) error {
+	_, err := c.command(fmt.Sprintf("UID STORE %d +FLAGS.SILENT (\\Seen)", uid))
+	return err
+}
+
+// command sends a command and returns its untagged responses, or the error the server answered with
+func (c *imapClient) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := "A" + strconv.Itoa(c.tag)
+
+	_ = c.conn.SetDeadline(time.Now().Add(imapTimeout))
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+
+	var responses []imapResponse
+	for {
+		line, literals, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+				verb, _, _ := strings.Cut(cmd, " ")
+				return nil, fmt.Errorf("imap %s: %s", verb, status)
+			}
+			return responses, nil
+		}
+		responses = append(responses, imapResponse{line: line, literals: literals})
+	}
+}
+
+// readLine reads a response line, with the literals it holds. A literal {n} ends a line and is followed by its n
+// bytes and the rest of the line.
+func (c *imapClient) readLine() (string, [][]byte, error) {
+	var line strings.Builder
+	var literals [][]byte
+	for {
+		part, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		part = strings.TrimRight(part, "\r\n")
+		line.WriteString(part)
+
+		if !strings.HasSuffix(part, "}") {
+			return line.String(), literals, nil
+		}
+		open := strings.LastIndex(part, "{")
+		if open < 0 {
+			return line.String(), literals, nil
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(part[open+1:len(part)-1], "+"))
+		if err != nil || size < 0 {
+			return "", nil, errors.New("imap: invalid literal")
+		}
+
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return "", nil, err
+		}
+		literals = append(literals, literal)
+	}
+}
+
+// quoteIMAP quotes a string argument of a command
+func quoteIMAP(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,225 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// quoteHeaderRegex finds the line introducing the quoted email of a reply, like "On Mon, Jan 1, Jo wrote:"
+	quoteHeaderRegex = regexp.MustCompile(`(?m)^(On .+ wrote:|-----Original Message-----|_{10,})\s*$`)
+	brRegex          = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</h[1-6]>`)
+	tagRegex         = regexp.MustCompile(`<[^>]*>`)
+	blankLinesRegex  = regexp.MustCompile(`\n{3,}`)
+	messageIDRegex   = regexp.MustCompile(`<[^<>\s]+>`)
+)
+
+var wordDecoder = mime.WordDecoder{}
+
+// Email is a received email, as the agent reads it
+type Email struct {
+	MessageID  string
+	From       string
+	FromName   string
+	Subject    string
+	InReplyTo  string
+	References []string
+	// Text is the text of the email, without the quoted email it replies to
+	Text string
+	// AutoSubmitted is set for the auto-replies and the bulk emails, which aren't answered
+	AutoSubmitted bool
+}
+
+// ParseEmail reads the headers and the text of a raw email
+func ParseEmail(raw []byte) (*Email, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender: %w", err)
+	}
+
+	subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	email := &Email{
+		MessageID:  strings.TrimSpace(msg.Header.Get("Message-ID")),
+		From:       from.Address,
+		FromName:   from.Name,
+		Subject:    strings.TrimSpace(subject),
+		InReplyTo:  messageIDRegex.FindString(msg.Header.Get("In-Reply-To")),
+		References: messageIDRegex.FindAllString(msg.Header.Get("References"), -1),
+	}
+
+	autoSubmitted := strings.ToLower(msg.Header.Get("Auto-Submitted"))
+	precedence := strings.ToLower(msg.Header.Get("Precedence"))
+	email.AutoSubmitted = (autoSubmitted != "" && autoSubmitted != "no") ||
+		precedence == "bulk" || precedence == "list" || precedence == "junk" ||
+		msg.Header.Get("List-Id") != ""
+
+	plain, htmlText, err := readBody(msg.Header, msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	text := plain
+	if strings.TrimSpace(text) == "" && htmlText != "" {
+		text = htmlToText(htmlText)
+	}
+	email.Text = stripQuoted(text)
+
+	return email, nil
+}
+
+// header is the headers of an email or of a part of its body
+type header interface {
+	Get(key string) string
+}
+
+// readBody returns the plain text and the HTML of a body, looking into the multipart bodies
+func readBody(h header, body io.Reader) (string, string, error) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		plain, htmlText := "", ""
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", err
+			}
+			// Attachments aren't read
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+
+			p, h, err := readBody(part.Header, part)
+			if err != nil {
+				return "", "", err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+		return plain, htmlText, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	var decoded io.Reader = body
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		// The parts of a multipart body are decoded by the multipart reader, which hides their header
+		decoded = quotedprintable.NewReader(body)
+	case "base64":
+		decoded = base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	}
+
+	buf, err := io.ReadAll(decoded)
+	if err != nil {
+		return "", "", err
+	}
+
+	text := strings.ReplaceAll(string(buf), "\r\n", "\n")
+	if mediaType == "text/html" {
+		return "", text, nil
+	}
+	return text, "", nil
+}
+
+// newlineStripper drops the line breaks of a base64 body
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	out := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[out] = b
+			out++
+		}
+	}
+	return out, err
+}
+
+func htmlToText(text string) string {
+	text = brRegex.ReplaceAllString(text, "\n")
+	text = tagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return blankLinesRegex.ReplaceAllString(text, "\n\n")
+}
+
+// stripQuoted drops the email a reply quotes, after its introduction line or as its quoted lines
+func stripQuoted(text string) string {
+	if loc := quoteHeaderRegex.FindStringIndex(text); loc != nil {
+		text = text[:loc[0]]
+	}
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// replyMessage builds the reply of the agent to an email
+func replyMessage(from string, to *Email, messageID string, text string, now time.Time) []byte {
+	subject := to.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	references := append(append([]string{}, to.References...), to.MessageID)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", (&mail.Address{Name: to.FromName, Address: to.From}).String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&b, "In-Reply-To: %s\r\n", to.MessageID)
+	fmt.Fprintf(&b, "References: %s\r\n", strings.Join(references, " "))
+	// The replies of the agent are automatic, other automatic responders don't answer them
+	b.WriteString("Auto-Submitted: auto-replied\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	_, _ = w.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	_ = w.Close()
+
+	return b.Bytes()
+}
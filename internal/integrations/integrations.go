@@ -0,0 +1,31 @@
+// Package integrations holds what the messaging integrations, which answer the messages of other apps with an
+// agent, have in common.
+package integrations
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// ConverseInput is a message of an integration for the agent
+type ConverseInput struct {
+	ProjectID         uuid.UUID
+	Agent             string
+	Namespace         string
+	PreviousMessageID string
+	Message           responses.InputMessageUnion
+	Context           map[string]any
+}
+
+// Backend runs the agent of an integration and reads the conversation history
+type Backend interface {
+	// Converse runs the agent on a message, continuing from the previous message when it isn't empty, and
+	// returns the chunks of the run
+	Converse(ctx context.Context, in *ConverseInput) (<-chan *responses.ResponseChunk, error)
+	// LastMessageID returns the last message of a thread of the history
+	LastMessageID(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) (string, error)
+	// ThreadOfMessage returns the thread of a message of the history
+	ThreadOfMessage(ctx context.Context, projectID uuid.UUID, namespace string, messageID string) (string, error)
+}
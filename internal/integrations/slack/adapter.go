@@ -0,0 +1,429 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/integrations"
+	slack2 "github.com/curaious/uno/internal/services/slack"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+const (
+	// runTimeout bounds a run of the agent for a message, Slack has no way to cancel it
+	runTimeout = 10 * time.Minute
+	// seenRetention is how long delivered messages are remembered, a message comes as both message and app_mention
+	seenRetention = 10 * time.Minute
+)
+
+// ThreadStore maps Slack threads to the threads of their conversations
+type ThreadStore interface {
+	GetThread(ctx context.Context, teamID, channelID, threadTS string) (*slack2.SlackThread, error)
+	CreateThread(ctx context.Context, thread *slack2.SlackThread) error
+}
+
+// Options configure the Slack adapter
+type Options struct {
+	ProjectID uuid.UUID
+	// Agent answers the messages, as accepted by the agent_id parameter of the converse endpoint
+	Agent string
+	// UpdateInterval is the least time between two updates of a reply as it streams
+	UpdateInterval time.Duration
+	// Approvers are the Slack users allowed to approve tool calls, everyone when empty
+	Approvers []string
+}
+
+// Adapter answers the messages of Slack with an agent. Every channel is a namespace and every Slack thread a
+// conversation, the messages of a direct message channel are a single conversation. The reply of the agent is
+// posted when the run starts and updated as it streams, tool calls needing approval get approve and reject buttons.
+type Adapter struct {
+	client  *Client
+	backend integrations.Backend
+	threads ThreadStore
+	opts    Options
+
+	approvers map[string]bool
+
+	mu    sync.Mutex
+	locks map[string]*threadLock
+	seen  map[string]time.Time
+}
+
+// threadLock serializes the runs of a conversation, refs counts the messages waiting for it
+type threadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// conversationKey locates the conversation of a message in Slack
+type conversationKey struct {
+	teamID    string
+	channelID string
+	// threadTS is the Slack thread of the conversation, empty for a direct message channel
+	threadTS string
+	// replyTS is the Slack thread the replies are posted in, empty to post them in the channel
+	replyTS string
+}
+
+func NewAdapter(client *Client, backend integrations.Backend, threads ThreadStore, opts Options) *Adapter {
+	if opts.UpdateInterval <= 0 {
+		opts.UpdateInterval = time.Second
+	}
+
+	approvers := map[string]bool{}
+	for _, user := range opts.Approvers {
+		approvers[user] = true
+	}
+
+	return &Adapter{
+		client:    client,
+		backend:   backend,
+		threads:   threads,
+		opts:      opts,
+		approvers: approvers,
+		locks:     map[string]*threadLock{},
+		seen:      map[string]time.Time{},
+	}
+}
+
+// HandleEvent answers a message or a mention of the bot. In channels, the bot answers when it is mentioned and
+// follows the threads it answered in; in direct messages, it answers every message.
+func (a *Adapter) HandleEvent(ctx context.Context, env *EventEnvelope) {
+	ev := env.Event
+	if ev.Type != "message" && ev.Type != "app_mention" {
+		return
+	}
+	// Edits, joins and the messages of bots, including the replies of the agent, aren't answered
+	if ev.Subtype != "" || ev.BotID != "" || ev.User == "" {
+		return
+	}
+
+	botUserID := ""
+	for _, auth := range env.Authorizations {
+		if auth.IsBot {
+			botUserID = auth.UserID
+			break
+		}
+	}
+
+	key := conversationKey{teamID: env.TeamID, channelID: ev.Channel, threadTS: ev.ThreadTS, replyTS: ev.ThreadTS}
+	isDM := ev.ChannelType == "im"
+	if isDM {
+		key.threadTS = ""
+	} else if key.threadTS == "" {
+		key.threadTS = ev.TS
+		key.replyTS = ev.TS
+	}
+
+	// A mention in a channel comes as both events, the app_mention is answered
+	mentioned := botUserID != "" && strings.Contains(ev.Text, "<@"+botUserID+">")
+	if ev.Type == "message" && !isDM && (ev.ThreadTS == "" || mentioned) {
+		return
+	}
+	if !a.firstDelivery(ev.Channel + ":" + ev.TS) {
+		return
+	}
+
+	text := messageText(ev.Text, botUserID)
+	if text == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	unlock := a.lock(key)
+	defer unlock()
+
+	thread, err := a.threads.GetThread(ctx, key.teamID, key.channelID, key.threadTS)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get slack thread", slog.Any("error", err))
+		return
+	}
+	// Outside of direct messages, only the threads the bot was mentioned in are followed
+	if ev.Type == "message" && !isDM && thread == nil {
+		return
+	}
+
+	previousMessageID := ""
+	if thread != nil {
+		previousMessageID, err = a.backend.LastMessageID(ctx, thread.ProjectID, thread.Namespace, thread.ThreadID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get the last message of the slack thread", slog.Any("error", err))
+			return
+		}
+	}
+
+	message := responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+		Role:    constants.RoleUser,
+		Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: text}}},
+	}}
+	a.run(ctx, key, thread, previousMessageID, message, map[string]any{
+		"slack_user":    ev.User,
+		"slack_channel": ev.Channel,
+	})
+}
+
+// HandleInteraction resumes a paused run with the decision of an approve or reject button
+func (a *Adapter) HandleInteraction(ctx context.Context, payload *InteractionPayload) {
+	if payload.Type != "block_actions" {
+		return
+	}
+
+	for _, action := range payload.Actions {
+		if action.ActionID != actionApprove && action.ActionID != actionReject {
+			continue
+		}
+
+		var value approvalValue
+		if err := json.Unmarshal([]byte(action.Value), &value); err != nil {
+			slog.WarnContext(ctx, "Invalid slack approval", slog.Any("error", err))
+			continue
+		}
+
+		a.decide(ctx, payload, value, action.ActionID == actionApprove)
+	}
+}
+
+func (a *Adapter) decide(ctx context.Context, payload *InteractionPayload, value approvalValue, approved bool) {
+	key := conversationKey{teamID: payload.Team.ID, channelID: payload.Channel.ID, threadTS: payload.Message.ThreadTS, replyTS: payload.Message.ThreadTS}
+	// Direct message channels are a single conversation
+	if strings.HasPrefix(key.channelID, "D") {
+		key.threadTS = ""
+	}
+
+	if len(a.approvers) > 0 && !a.approvers[payload.User.ID] {
+		a.ephemeral(ctx, key, payload.User.ID, "You are not allowed to approve the tool calls of the agent.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	unlock := a.lock(key)
+	defer unlock()
+
+	thread, err := a.threads.GetThread(ctx, key.teamID, key.channelID, key.threadTS)
+	if err != nil || thread == nil {
+		slog.ErrorContext(ctx, "Failed to get slack thread of approval", slog.Any("error", err))
+		return
+	}
+
+	// The run is resumed once, the buttons of an answered approval are stale
+	last, err := a.backend.LastMessageID(ctx, thread.ProjectID, thread.Namespace, thread.ThreadID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get the last message of the slack thread", slog.Any("error", err))
+		return
+	}
+	if last != value.RunID {
+		a.ephemeral(ctx, key, payload.User.ID, "This approval was answered already.")
+		return
+	}
+
+	decision := responses.FunctionCallApprovalResponseMessage{ID: uuid.NewString()}
+	outcome := fmt.Sprintf(":white_check_mark: Approved by <@%s>", payload.User.ID)
+	if approved {
+		decision.ApprovedCallIds = value.CallIDs
+		decision.RejectedCallIds = []string{}
+	} else {
+		decision.ApprovedCallIds = []string{}
+		decision.RejectedCallIds = value.CallIDs
+		outcome = fmt.Sprintf(":no_entry_sign: Rejected by <@%s>", payload.User.ID)
+	}
+
+	// The buttons are replaced by the decision
+	text := strings.TrimSpace(payload.Message.Text + "\n\n" + outcome)
+	if err := a.client.UpdateMessage(ctx, &Message{Channel: key.channelID, TS: payload.Message.TS, Text: text}); err != nil {
+		slog.WarnContext(ctx, "Failed to update slack approval", slog.Any("error", err))
+	}
+
+	message := responses.InputMessageUnion{OfFunctionCallApprovalResponse: &decision}
+	a.run(ctx, key, thread, value.RunID, message, map[string]any{
+		"slack_user":    payload.User.ID,
+		"slack_channel": key.channelID,
+	})
+}
+
+// run posts the reply of the agent to a message and updates it as the run streams
+func (a *Adapter) run(ctx context.Context, key conversationKey, thread *slack2.SlackThread, previousMessageID string, message responses.InputMessageUnion, runContext map[string]any) {
+	namespace := namespaceOf(key)
+	if thread != nil {
+		namespace = thread.Namespace
+	}
+
+	reply := &Message{Channel: key.channelID, ThreadTS: key.replyTS, Text: ":hourglass_flowing_sand: Thinking…"}
+	ts, err := a.client.PostMessage(ctx, reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to post slack reply", slog.Any("error", err))
+		return
+	}
+	reply.TS = ts
+	reply.ThreadTS = ""
+
+	stream, err := a.backend.Converse(ctx, &integrations.ConverseInput{
+		ProjectID:         a.opts.ProjectID,
+		Agent:             a.opts.Agent,
+		Namespace:         namespace,
+		PreviousMessageID: previousMessageID,
+		Message:           message,
+		Context:           runContext,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to run agent for slack", slog.Any("error", err))
+		a.update(ctx, reply, ":warning: The agent could not answer: "+err.Error(), nil)
+		return
+	}
+
+	var text strings.Builder
+	lastItemID := ""
+	lastUpdate := time.Now()
+	messageID := ""
+	ended := false
+	for chunk := range stream {
+		switch {
+		case chunk.OfOutputTextDelta != nil:
+			// The text of every output message of the run goes in the reply
+			if chunk.OfOutputTextDelta.ItemId != lastItemID && text.Len() > 0 {
+				text.WriteString("\n\n")
+			}
+			lastItemID = chunk.OfOutputTextDelta.ItemId
+			text.WriteString(chunk.OfOutputTextDelta.Delta)
+
+			if time.Since(lastUpdate) >= a.opts.UpdateInterval {
+				a.update(ctx, reply, toMrkdwn(text.String()), nil)
+				lastUpdate = time.Now()
+			}
+
+		case chunk.OfRunCompleted != nil:
+			messageID = chunk.OfRunCompleted.RunState.Id
+			a.update(ctx, reply, orDefault(toMrkdwn(text.String()), "_The agent has nothing to add._"), nil)
+			ended = true
+
+		case chunk.OfRunPaused != nil:
+			state := chunk.OfRunPaused.RunState
+			messageID = state.Id
+			blocks, err := approvalBlocks(toMrkdwn(text.String()), state.Id, state.PendingToolCalls)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to build slack approval", slog.Any("error", err))
+			}
+			a.update(ctx, reply, orDefault(toMrkdwn(text.String()), "Approval needed"), blocks)
+			ended = true
+
+		case chunk.OfTakeoverActive != nil:
+			// The message was passed on to the operator of the conversation instead of the agent
+			messageID = chunk.OfTakeoverActive.MessageID
+			a.update(ctx, reply, ":bust_in_silhouette: An operator is handling this conversation, your message was passed on.", nil)
+			ended = true
+		}
+
+		if ended {
+			break
+		}
+	}
+
+	if !ended {
+		a.update(ctx, reply, orDefault(toMrkdwn(text.String()), "")+"\n\n:warning: The agent stopped before answering.", nil)
+		return
+	}
+
+	if thread == nil && messageID != "" {
+		a.mapThread(ctx, key, namespace, messageID)
+	}
+}
+
+// mapThread maps the Slack thread to the thread of the conversation its first run started
+func (a *Adapter) mapThread(ctx context.Context, key conversationKey, namespace string, messageID string) {
+	threadID, err := a.backend.ThreadOfMessage(ctx, a.opts.ProjectID, namespace, messageID)
+	if err == nil {
+		err = a.threads.CreateThread(ctx, &slack2.SlackThread{
+			TeamID:    key.teamID,
+			ChannelID: key.channelID,
+			ThreadTS:  key.threadTS,
+			ProjectID: a.opts.ProjectID,
+			Namespace: namespace,
+			ThreadID:  threadID,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to map slack thread", slog.String("message_id", messageID), slog.Any("error", err))
+	}
+}
+
+func (a *Adapter) update(ctx context.Context, reply *Message, text string, blocks []Block) {
+	reply.Text = text
+	reply.Blocks = blocks
+	if err := a.client.UpdateMessage(ctx, reply); err != nil {
+		// A missed update is caught up by the next one
+		slog.WarnContext(ctx, "Failed to update slack reply", slog.Any("error", err))
+	}
+}
+
+func (a *Adapter) ephemeral(ctx context.Context, key conversationKey, user string, text string) {
+	if err := a.client.PostEphemeral(ctx, key.channelID, user, key.replyTS, text); err != nil {
+		slog.WarnContext(ctx, "Failed to post slack ephemeral message", slog.Any("error", err))
+	}
+}
+
+// lock serializes the runs of a conversation, so that every message continues from the previous run
+func (a *Adapter) lock(key conversationKey) func() {
+	id := key.teamID + ":" + key.channelID + ":" + key.threadTS
+
+	a.mu.Lock()
+	l, ok := a.locks[id]
+	if !ok {
+		l = &threadLock{}
+		a.locks[id] = l
+	}
+	l.refs++
+	a.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		a.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(a.locks, id)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// firstDelivery reports whether a message is delivered for the first time
+func (a *Adapter) firstDelivery(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for seenID, at := range a.seen {
+		if now.Sub(at) > seenRetention {
+			delete(a.seen, seenID)
+		}
+	}
+
+	if _, ok := a.seen[id]; ok {
+		return false
+	}
+	a.seen[id] = now
+	return true
+}
+
+// namespaceOf is the namespace of the conversations of a Slack channel
+func namespaceOf(key conversationKey) string {
+	return "slack-" + strings.ToLower(key.teamID) + "-" + strings.ToLower(key.channelID)
+}
+
+func orDefault(text string, fallback string) string {
+	if strings.TrimSpace(text) == "" {
+		return fallback
+	}
+	return text
+}
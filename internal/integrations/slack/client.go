@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	json "github.com/bytedance/sonic"
+)
+
+const defaultAPIURL = "https://slack.com/api/"
+
+// Client calls the Web API of Slack with the token of the bot
+type Client struct {
+	Token  string
+	APIURL string
+	HTTP   *http.Client
+}
+
+// NewClient creates a client of the Web API for a bot token
+func NewClient(token string) *Client {
+	return &Client{
+		Token:  token,
+		APIURL: defaultAPIURL,
+		HTTP:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Message is the content of a message posted or updated by the bot
+type Message struct {
+	Channel  string  `json:"channel"`
+	TS       string  `json:"ts,omitempty"`
+	ThreadTS string  `json:"thread_ts,omitempty"`
+	Text     string  `json:"text"`
+	Blocks   []Block `json:"blocks,omitempty"`
+}
+
+// Block is a block of Block Kit
+type Block = map[string]any
+
+// PostMessage posts a message and returns its timestamp
+func (c *Client) PostMessage(ctx context.Context, msg *Message) (string, error) {
+	var out struct {
+		TS string `json:"ts"`
+	}
+	if err := c.call(ctx, "chat.postMessage", msg, &out); err != nil {
+		return "", err
+	}
+
+	return out.TS, nil
+}
+
+// UpdateMessage replaces the content of a message of the bot, removing its blocks when it has none
+func (c *Client) UpdateMessage(ctx context.Context, msg *Message) error {
+	blocks := msg.Blocks
+	if blocks == nil {
+		blocks = []Block{}
+	}
+
+	return c.call(ctx, "chat.update", map[string]any{
+		"channel": msg.Channel,
+		"ts":      msg.TS,
+		"text":    msg.Text,
+		"blocks":  blocks,
+	}, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.APIURL+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s: status %d", method, res.StatusCode)
+	}
+
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return err
+	}
+	if !status.OK {
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out != nil {
+		return json.Unmarshal(raw, out)
+	}
+
+	return nil
+}
+
+// PostEphemeral posts a message only the user can see
+func (c *Client) PostEphemeral(ctx context.Context, channel string, user string, threadTS string, text string) error {
+	return c.call(ctx, "chat.postEphemeral", map[string]any{
+		"channel":   channel,
+		"user":      user,
+		"thread_ts": threadTS,
+		"text":      text,
+	}, nil)
+}
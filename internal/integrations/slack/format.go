@@ -0,0 +1,107 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const (
+	actionApprove = "uno_approve"
+	actionReject  = "uno_reject"
+
+	// maxTextLength keeps the text of a message under the limit of Slack
+	maxTextLength = 39000
+	// maxArgumentsLength is how much of the arguments of a tool call is shown for its approval
+	maxArgumentsLength = 500
+)
+
+var (
+	headingRegex  = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	boldRegex     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	italicRegex   = regexp.MustCompile(`(^|[^*\w])\*([^*\n]+)\*`)
+	strikeRegex   = regexp.MustCompile(`~~(.+?)~~`)
+	linkRegex     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	listItemRegex = regexp.MustCompile(`(?m)^(\s*)[-*]\s+`)
+)
+
+// approvalValue is the value of the approval buttons of a paused run
+type approvalValue struct {
+	RunID   string   `json:"run_id"`
+	CallIDs []string `json:"call_ids"`
+}
+
+// messageText returns the text of a Slack message without the mentions of the bot
+func messageText(text string, botUserID string) string {
+	if botUserID != "" {
+		text = strings.ReplaceAll(text, "<@"+botUserID+">", "")
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// toMrkdwn converts the Markdown of the model into the mrkdwn of Slack, leaving code blocks as they are
+func toMrkdwn(markdown string) string {
+	parts := strings.Split(markdown, "```")
+	for i := 0; i < len(parts); i += 2 {
+		text := parts[i]
+		text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+		text = headingRegex.ReplaceAllString(text, "\x00$1\x00")
+		text = boldRegex.ReplaceAllString(text, "\x00$1$2\x00")
+		text = italicRegex.ReplaceAllString(text, "${1}_${2}_")
+		text = strings.ReplaceAll(text, "\x00", "*")
+		text = strikeRegex.ReplaceAllString(text, "~$1~")
+		text = linkRegex.ReplaceAllString(text, "<$2|$1>")
+		text = listItemRegex.ReplaceAllString(text, "$1• ")
+		parts[i] = text
+	}
+
+	return truncate(strings.Join(parts, "```"), maxTextLength)
+}
+
+// approvalBlocks asks for the approval of the pending tool calls of a paused run
+func approvalBlocks(text string, runID string, calls []responses.FunctionCallMessage) ([]Block, error) {
+	value := approvalValue{RunID: runID}
+	var b strings.Builder
+	b.WriteString(":raised_hand: *Approval needed* to run:")
+	for _, call := range calls {
+		value.CallIDs = append(value.CallIDs, call.CallID)
+		fmt.Fprintf(&b, "\n*%s*\n```%s```", call.Name, truncate(call.Arguments, maxArgumentsLength))
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []Block{}
+	if text != "" {
+		blocks = append(blocks, Block{"type": "section", "text": Block{"type": "mrkdwn", "text": truncate(text, 3000)}})
+	}
+	blocks = append(blocks,
+		Block{"type": "section", "text": Block{"type": "mrkdwn", "text": truncate(b.String(), 3000)}},
+		Block{"type": "actions", "elements": []Block{
+			{"type": "button", "action_id": actionApprove, "style": "primary", "value": string(buf), "text": Block{"type": "plain_text", "text": "Approve"}},
+			{"type": "button", "action_id": actionReject, "style": "danger", "value": string(buf), "text": Block{"type": "plain_text", "text": "Reject"}},
+		}},
+	)
+
+	return blocks, nil
+}
+
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+
+	// Cut on a rune boundary
+	cut := max - len("…")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
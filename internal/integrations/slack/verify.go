@@ -0,0 +1,38 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// maxRequestAge is how old the timestamp of a request from Slack can be, older requests may be replayed
+const maxRequestAge = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("invalid slack signature")
+
+// VerifySignature checks the X-Slack-Signature of a request against the signing secret of the Slack app, see
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func VerifySignature(secret string, timestamp string, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
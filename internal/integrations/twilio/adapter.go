@@ -0,0 +1,366 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/internal/integrations"
+	twilio2 "github.com/curaious/uno/internal/services/twilio"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+const (
+	// runTimeout bounds a run of the agent for a message
+	runTimeout = 10 * time.Minute
+	// seenRetention is how long delivered messages are remembered, Twilio retries the webhooks it has no answer for
+	seenRetention = 10 * time.Minute
+	// whatsappPrefix prefixes the WhatsApp numbers
+	whatsappPrefix = "whatsapp:"
+)
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// InboundMessage is an SMS or WhatsApp message posted to the webhook of a number
+type InboundMessage struct {
+	MessageSID string
+	AccountSID string
+	From       string
+	To         string
+	Body       string
+	// ProfileName is the WhatsApp profile name of the sender
+	ProfileName string
+}
+
+// ConversationStore maps the senders of the numbers to the threads of their conversations
+type ConversationStore interface {
+	GetConversation(ctx context.Context, address, sender string) (*twilio2.TwilioConversation, error)
+	CreateConversation(ctx context.Context, conversation *twilio2.TwilioConversation) error
+}
+
+// Options configure the Twilio adapter
+type Options struct {
+	ProjectID uuid.UUID
+	// Agent answers the messages, as accepted by the agent_id parameter of the converse endpoint
+	Agent string
+	// MaxMessageLength is the most characters of a message the agent sends, longer replies are sent in parts
+	MaxMessageLength int
+}
+
+// Adapter answers the SMS and WhatsApp messages of a Twilio account with an agent. Every number of the account is
+// a namespace and the messages of a sender to a number are a single conversation. The reply of the agent is sent as
+// it streams, a message for every output message of the run, cut on paragraphs into messages of at most
+// MaxMessageLength characters. Tool calls needing approval are approved or rejected with a YES or NO reply.
+type Adapter struct {
+	client        *Client
+	backend       integrations.Backend
+	conversations ConversationStore
+	opts          Options
+
+	mu      sync.Mutex
+	locks   map[string]*conversationLock
+	seen    map[string]time.Time
+	pending map[string]pendingApproval
+}
+
+// conversationLock serializes the runs of a conversation, refs counts the messages waiting for it
+type conversationLock struct {
+	sync.Mutex
+	refs int
+}
+
+// pendingApproval is a run paused for the approval of tool calls, answered by the next message of the sender
+type pendingApproval struct {
+	runID   string
+	callIDs []string
+}
+
+func NewAdapter(client *Client, backend integrations.Backend, conversations ConversationStore, opts Options) *Adapter {
+	if opts.MaxMessageLength <= 0 {
+		opts.MaxMessageLength = 1600
+	}
+
+	return &Adapter{
+		client:        client,
+		backend:       backend,
+		conversations: conversations,
+		opts:          opts,
+		locks:         map[string]*conversationLock{},
+		seen:          map[string]time.Time{},
+		pending:       map[string]pendingApproval{},
+	}
+}
+
+// HandleMessage answers a message sent to a number of the account
+func (a *Adapter) HandleMessage(ctx context.Context, msg *InboundMessage) {
+	if msg.AccountSID != a.client.AccountSID || msg.From == "" || msg.To == "" {
+		return
+	}
+	if !a.firstDelivery(msg.MessageSID) {
+		return
+	}
+
+	text := strings.TrimSpace(msg.Body)
+	if text == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	key := msg.To + ":" + msg.From
+	unlock := a.lock(key)
+	defer unlock()
+
+	conversation, err := a.conversations.GetConversation(ctx, msg.To, msg.From)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get twilio conversation", slog.Any("error", err))
+		return
+	}
+
+	previousMessageID := ""
+	if conversation != nil {
+		previousMessageID, err = a.backend.LastMessageID(ctx, conversation.ProjectID, conversation.Namespace, conversation.ThreadID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get the last message of the twilio conversation", slog.Any("error", err))
+			return
+		}
+	}
+
+	message := responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+		Role:    constants.RoleUser,
+		Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: text}}},
+	}}
+
+	// A paused run is resumed by a YES or NO reply, any other message continues the conversation
+	if pending, ok := a.takePending(key); ok && pending.runID == previousMessageID {
+		if approved, ok := isApproval(text); ok {
+			decision := responses.FunctionCallApprovalResponseMessage{
+				ID:              uuid.NewString(),
+				ApprovedCallIds: []string{},
+				RejectedCallIds: []string{},
+			}
+			if approved {
+				decision.ApprovedCallIds = pending.callIDs
+			} else {
+				decision.RejectedCallIds = pending.callIDs
+			}
+			message = responses.InputMessageUnion{OfFunctionCallApprovalResponse: &decision}
+		}
+	}
+
+	runContext := map[string]any{
+		"twilio_from":    msg.From,
+		"twilio_channel": channelOf(msg.To),
+	}
+	if msg.ProfileName != "" {
+		runContext["twilio_profile_name"] = msg.ProfileName
+	}
+
+	a.run(ctx, key, msg, conversation, previousMessageID, message, runContext)
+}
+
+// run sends the reply of the agent to a message as the run streams
+func (a *Adapter) run(ctx context.Context, key string, msg *InboundMessage, conversation *twilio2.TwilioConversation, previousMessageID string, message responses.InputMessageUnion, runContext map[string]any) {
+	namespace := namespaceOf(msg.To)
+	if conversation != nil {
+		namespace = conversation.Namespace
+	}
+
+	stream, err := a.backend.Converse(ctx, &integrations.ConverseInput{
+		ProjectID:         a.opts.ProjectID,
+		Agent:             a.opts.Agent,
+		Namespace:         namespace,
+		PreviousMessageID: previousMessageID,
+		Message:           message,
+		Context:           runContext,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to run agent for twilio", slog.Any("error", err))
+		a.send(ctx, msg, "Sorry, we could not answer your message. Please try again later.")
+		return
+	}
+
+	whatsapp := strings.HasPrefix(msg.To, whatsappPrefix)
+	// buf holds the text of the current output message that wasn't sent yet
+	var buf strings.Builder
+	flush := func() {
+		if text := formatText(buf.String(), whatsapp); text != "" {
+			a.send(ctx, msg, text)
+		}
+		buf.Reset()
+	}
+
+	lastItemID := ""
+	messageID := ""
+	ended := false
+	for chunk := range stream {
+		switch {
+		case chunk.OfOutputTextDelta != nil:
+			// Every output message of the run is sent on its own
+			if chunk.OfOutputTextDelta.ItemId != lastItemID {
+				flush()
+			}
+			lastItemID = chunk.OfOutputTextDelta.ItemId
+			buf.WriteString(chunk.OfOutputTextDelta.Delta)
+
+			// The parts of the message that fill a message are sent while it streams
+			rest := buf.String()
+			for {
+				part, next, ok := nextPart(rest, a.opts.MaxMessageLength)
+				if !ok {
+					break
+				}
+				a.send(ctx, msg, formatText(part, whatsapp))
+				rest = next
+			}
+			if rest != buf.String() {
+				buf.Reset()
+				buf.WriteString(rest)
+			}
+
+		case chunk.OfRunCompleted != nil:
+			messageID = chunk.OfRunCompleted.RunState.Id
+			flush()
+			ended = true
+
+		case chunk.OfRunPaused != nil:
+			state := chunk.OfRunPaused.RunState
+			messageID = state.Id
+			flush()
+
+			pending := pendingApproval{runID: state.Id}
+			names := []string{}
+			for _, call := range state.PendingToolCalls {
+				pending.callIDs = append(pending.callIDs, call.CallID)
+				names = append(names, call.Name)
+			}
+			a.setPending(key, pending)
+			a.send(ctx, msg, fmt.Sprintf("Approval needed to run %s. Reply YES to approve or NO to reject.", strings.Join(names, ", ")))
+			ended = true
+
+		case chunk.OfTakeoverActive != nil:
+			// The message was passed on to the operator of the conversation, who answers it
+			messageID = chunk.OfTakeoverActive.MessageID
+			ended = true
+		}
+
+		if ended {
+			break
+		}
+	}
+
+	if !ended {
+		flush()
+		a.send(ctx, msg, "Sorry, the answer to your message was interrupted. Please try again.")
+		return
+	}
+
+	if conversation == nil && messageID != "" {
+		a.mapConversation(ctx, msg, namespace, messageID)
+	}
+}
+
+// mapConversation maps the sender to the thread of the conversation its first run started
+func (a *Adapter) mapConversation(ctx context.Context, msg *InboundMessage, namespace string, messageID string) {
+	threadID, err := a.backend.ThreadOfMessage(ctx, a.opts.ProjectID, namespace, messageID)
+	if err == nil {
+		err = a.conversations.CreateConversation(ctx, &twilio2.TwilioConversation{
+			Address:   msg.To,
+			Sender:    msg.From,
+			ProjectID: a.opts.ProjectID,
+			Namespace: namespace,
+			ThreadID:  threadID,
+		})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to map twilio conversation", slog.String("message_id", messageID), slog.Any("error", err))
+	}
+}
+
+// send replies to the sender of a message from the number it was sent to
+func (a *Adapter) send(ctx context.Context, msg *InboundMessage, text string) {
+	if _, err := a.client.SendMessage(ctx, msg.To, msg.From, text); err != nil {
+		slog.ErrorContext(ctx, "Failed to send twilio message", slog.Any("error", err))
+	}
+}
+
+func (a *Adapter) setPending(key string, pending pendingApproval) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending[key] = pending
+}
+
+// takePending returns and forgets the approval a conversation waits for
+func (a *Adapter) takePending(key string) (pendingApproval, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pending, ok := a.pending[key]
+	delete(a.pending, key)
+	return pending, ok
+}
+
+// lock serializes the runs of a conversation, so that every message continues from the previous run
+func (a *Adapter) lock(key string) func() {
+	a.mu.Lock()
+	l, ok := a.locks[key]
+	if !ok {
+		l = &conversationLock{}
+		a.locks[key] = l
+	}
+	l.refs++
+	a.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		a.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(a.locks, key)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// firstDelivery reports whether a message is delivered for the first time
+func (a *Adapter) firstDelivery(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for seenID, at := range a.seen {
+		if now.Sub(at) > seenRetention {
+			delete(a.seen, seenID)
+		}
+	}
+
+	if _, ok := a.seen[id]; ok {
+		return false
+	}
+	a.seen[id] = now
+	return true
+}
+
+// channelOf is the channel of a number, "sms" or "whatsapp"
+func channelOf(address string) string {
+	if strings.HasPrefix(address, whatsappPrefix) {
+		return "whatsapp"
+	}
+	return "sms"
+}
+
+// namespaceOf is the namespace of the conversations of a number, like twilio-whatsapp-14155238886
+func namespaceOf(address string) string {
+	number := nonAlphanumericRegex.ReplaceAllString(strings.ToLower(strings.TrimPrefix(address, whatsappPrefix)), "")
+	return "twilio-" + channelOf(address) + "-" + number
+}
@@ -0,0 +1,74 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+)
+
+const defaultAPIURL = "https://api.twilio.com/2010-04-01/"
+
+// Client sends messages with the REST API of Twilio
+type Client struct {
+	AccountSID string
+	AuthToken  string
+	APIURL     string
+	HTTP       *http.Client
+}
+
+// NewClient creates a client of the REST API for an account
+func NewClient(accountSID string, authToken string) *Client {
+	return &Client{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		APIURL:     defaultAPIURL,
+		HTTP:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendMessage sends an SMS or WhatsApp message, whatsapp numbers are prefixed with "whatsapp:", and returns its SID
+func (c *Client) SendMessage(ctx context.Context, from string, to string, body string) (string, error) {
+	form := url.Values{}
+	form.Set("From", from)
+	form.Set("To", to)
+	form.Set("Body", body)
+
+	endpoint := c.APIURL + "Accounts/" + url.PathEscape(c.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var out struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil && res.StatusCode < 300 {
+		return "", err
+	}
+	if res.StatusCode >= 300 {
+		return "", fmt.Errorf("twilio send message: status %d: %d %s", res.StatusCode, out.Code, out.Message)
+	}
+
+	return out.SID, nil
+}
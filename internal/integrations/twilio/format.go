@@ -0,0 +1,84 @@
+package twilio
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	headingRegex  = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	boldRegex     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	strikeRegex   = regexp.MustCompile(`~~(.+?)~~`)
+	linkRegex     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	codeLangRegex = regexp.MustCompile(`^[\w+-]*\n`)
+)
+
+// formatText converts the Markdown of the model into the formatting of WhatsApp, or into plain text for SMS. The
+// text never gets longer, so that the parts cut by nextPart stay within the limit.
+func formatText(markdown string, whatsapp bool) string {
+	parts := strings.Split(markdown, "```")
+	for i := 0; i < len(parts); i += 2 {
+		text := parts[i]
+		if whatsapp {
+			text = headingRegex.ReplaceAllString(text, "*$1*")
+			text = boldRegex.ReplaceAllString(text, "*$1$2*")
+			text = strikeRegex.ReplaceAllString(text, "~$1~")
+		} else {
+			text = headingRegex.ReplaceAllString(text, "$1")
+			text = boldRegex.ReplaceAllString(text, "$1$2")
+			text = strikeRegex.ReplaceAllString(text, "$1")
+		}
+		text = linkRegex.ReplaceAllString(text, "$1 ($2)")
+		parts[i] = text
+	}
+
+	// WhatsApp shows code blocks, SMS has no formatting
+	if whatsapp {
+		return strings.TrimSpace(strings.Join(parts, "```"))
+	}
+	for i := 1; i < len(parts); i += 2 {
+		parts[i] = codeLangRegex.ReplaceAllString(parts[i], "")
+	}
+	return strings.TrimSpace(strings.Join(parts, ""))
+}
+
+// nextPart cuts the first message of a text longer than max characters, on the last paragraph, line, sentence or
+// word that fits. It returns false when the text fits in a message.
+func nextPart(text string, max int) (string, string, bool) {
+	if utf8.RuneCountInString(text) <= max {
+		return "", text, false
+	}
+
+	// The byte offset of the max-th character
+	limit := 0
+	for i := 0; i < max; i++ {
+		_, size := utf8.DecodeRuneInString(text[limit:])
+		limit += size
+	}
+
+	// Paragraphs, lines and sentences are cut on when they don't leave too short a message
+	for _, sep := range []string{"\n\n", "\n", ". ", "! ", "? "} {
+		if i := strings.LastIndex(text[:limit], sep); i >= limit/2 {
+			cut := i + len(sep)
+			return strings.TrimSpace(text[:cut]), strings.TrimLeft(text[cut:], " \n"), true
+		}
+	}
+	if i := strings.LastIndex(text[:limit], " "); i > 0 {
+		return text[:i], strings.TrimLeft(text[i:], " \n"), true
+	}
+
+	return text[:limit], text[limit:], true
+}
+
+// isApproval reports whether a reply answers an approval, and whether it approves
+func isApproval(text string) (approved bool, ok bool) {
+	switch strings.ToLower(strings.Trim(text, " .!\n")) {
+	case "yes", "y", "approve":
+		return true, true
+	case "no", "n", "reject":
+		return false, true
+	}
+
+	return false, false
+}
@@ -0,0 +1,45 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidSignature = errors.New("invalid twilio signature")
+
+// VerifySignature checks the X-Twilio-Signature of a webhook request against the auth token of the account. The
+// signature covers the URL Twilio called and the parameters of the form it posted, see
+// https://www.twilio.com/docs/usage/webhooks/webhooks-security
+func VerifySignature(authToken string, webhookURL string, params url.Values, signature string) error {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var data strings.Builder
+	data.WriteString(webhookURL)
+	for _, key := range keys {
+		values := append([]string(nil), params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if signature == "" || !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package loadgen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/google/uuid"
+)
+
+// Options configure a load test of the converse endpoint
+type Options struct {
+	// Endpoint is the base URL of the agent server, e.g. http://localhost:6060
+	Endpoint  string
+	ProjectID uuid.UUID
+	// Agent is the agent_id of the converse requests, with an optional alias or version: "<agent>:<alias>"
+	Agent string
+	// Token is the access token sent when the agent server has authentication enabled
+	Token string
+
+	// Conversations is the number of synthetic conversations, Concurrency of them run at once
+	Conversations int
+	Concurrency   int
+	// Turns is the number of user messages of every conversation
+	Turns int
+	// Message is the text of the user messages
+	Message   string
+	Namespace string
+
+	// HistoryTimeout is how long to wait for the messages of a turn to be readable from the conversation history.
+	// The write latency isn't measured when it is zero.
+	HistoryTimeout time.Duration
+
+	Client *http.Client
+}
+
+// turnResult holds the measurements of one message of a conversation
+type turnResult struct {
+	ttft         time.Duration // Until the first text delta
+	duration     time.Duration // Until run.completed
+	chunks       int           // Text deltas
+	historyWrite time.Duration // From run.completed until the message is readable
+	hasTTFT      bool
+	hasHistory   bool
+	err          error
+}
+
+// Run drives the synthetic conversations against the converse endpoint and reports the measurements. Progress, when
+// not nil, is called after every turn.
+func Run(ctx context.Context, opts Options, progress func(done, total int)) (*Report, error) {
+	if opts.Endpoint == "" || opts.ProjectID == uuid.Nil || opts.Agent == "" {
+		return nil, errors.New("endpoint, project and agent are required")
+	}
+	if opts.Conversations <= 0 || opts.Turns <= 0 {
+		return nil, errors.New("conversations and turns must be positive")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+
+	total := opts.Conversations * opts.Turns
+	results := make(chan turnResult, opts.Concurrency)
+	conversations := make(chan int)
+
+	var wg sync.WaitGroup
+	for range opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range conversations {
+				converse(ctx, opts, results)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(conversations)
+		for i := range opts.Conversations {
+			select {
+			case conversations <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	start := time.Now()
+	collected := make([]turnResult, 0, total)
+	for result := range results {
+		collected = append(collected, result)
+		if progress != nil {
+			progress(len(collected), total)
+		}
+	}
+
+	return newReport(opts, collected, time.Since(start)), nil
+}
+
+// converse runs the turns of one conversation, each message continuing from the run of the previous one. The
+// conversation stops at the first failed turn, the remaining turns are reported as failed.
+func converse(ctx context.Context, opts Options, results chan<- turnResult) {
+	previousMessageID := ""
+	for turn := range opts.Turns {
+		result, runID := runTurn(ctx, opts, previousMessageID)
+		results <- result
+
+		if result.err != nil {
+			for range opts.Turns - turn - 1 {
+				results <- turnResult{err: errors.New("skipped after a failed turn")}
+			}
+			return
+		}
+		previousMessageID = runID
+	}
+}
+
+func runTurn(ctx context.Context, opts Options, previousMessageID string) (turnResult, string) {
+	var result turnResult
+
+	body, err := json.Marshal(map[string]any{
+		"message":             map[string]any{"role": "user", "content": opts.Message},
+		"namespace":           opts.Namespace,
+		"previous_message_id": previousMessageID,
+	})
+	if err != nil {
+		result.err = err
+		return result, ""
+	}
+
+	query := url.Values{}
+	query.Set("project_id", opts.ProjectID.String())
+	query.Set("agent_id", opts.Agent)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.Endpoint+"/api/agent-server/converse?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		result.err = err
+		return result, ""
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	setAuth(req, opts.Token)
+
+	start := time.Now()
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		result.err = err
+		return result, ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		result.err = fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return result, ""
+	}
+
+	runID := ""
+	event := ""
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			switch event {
+			case "response.output_text.delta":
+				if !result.hasTTFT {
+					result.ttft = time.Since(start)
+					result.hasTTFT = true
+				}
+				result.chunks++
+			case "run.paused":
+				result.err = errors.New("the run paused, loadgen agents must not have tools requiring approval")
+				return result, ""
+			case "run.completed":
+				result.duration = time.Since(start)
+
+				var chunk struct {
+					RunState struct {
+						ID string `json:"id"`
+					} `json:"run_state"`
+				}
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+					result.err = fmt.Errorf("invalid run.completed event: %w", err)
+					return result, ""
+				}
+				runID = chunk.RunState.ID
+			}
+		}
+		if runID != "" {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		result.err = err
+		return result, ""
+	}
+	if runID == "" {
+		result.err = errors.New("the stream ended before run.completed")
+		return result, ""
+	}
+
+	if opts.HistoryTimeout > 0 {
+		written, err := waitForMessage(ctx, opts, runID)
+		if err != nil {
+			result.err = err
+			return result, ""
+		}
+		result.historyWrite = written
+		result.hasHistory = true
+	}
+
+	return result, runID
+}
+
+// waitForMessage polls the conversation history until the message of the run is readable, returning how long it took
+func waitForMessage(ctx context.Context, opts Options, messageID string) (time.Duration, error) {
+	query := url.Values{}
+	query.Set("project_id", opts.ProjectID.String())
+	query.Set("namespace", opts.Namespace)
+	target := opts.Endpoint + "/api/agent-server/messages/" + url.PathEscape(messageID) + "?" + query.Encode()
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, opts.HistoryTimeout)
+	defer cancel()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return 0, err
+		}
+		setAuth(req, opts.Token)
+
+		resp, err := opts.Client.Do(req)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return time.Since(start), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("message %s not in the history after %s", messageID, opts.HistoryTimeout)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func setAuth(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
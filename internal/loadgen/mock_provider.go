@@ -0,0 +1,139 @@
+package loadgen
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/google/uuid"
+)
+
+// MockProvider serves the OpenAI Responses API with synthetic streamed responses, so that the agent server can be
+// load tested without the latency and costs of a real provider. Point the base URL of an OpenAI provider of the
+// project at the address of the mock.
+type MockProvider struct {
+	// TTFT is how long the mock waits before the first delta
+	TTFT time.Duration
+	// ChunkDelay is the delay between two deltas
+	ChunkDelay time.Duration
+	// Chunks is the number of deltas of every response
+	Chunks int
+}
+
+// ServeHTTP answers every POST to /responses, streamed or not, with a text message of Chunks words
+func (m *MockProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/responses") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if err := json.ConfigDefault.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":{"type":"invalid_request_error","message":%q}}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	responseID := "resp_" + uuid.NewString()
+	itemID := "msg_" + uuid.NewString()
+	deltas := make([]string, m.Chunks)
+	for i := range deltas {
+		deltas[i] = fmt.Sprintf("word%d ", i)
+	}
+	text := strings.Join(deltas, "")
+
+	message := map[string]any{
+		"type":    "message",
+		"id":      itemID,
+		"status":  "completed",
+		"role":    "assistant",
+		"content": []any{map[string]any{"type": "output_text", "text": text, "annotations": []any{}}},
+	}
+	response := func(status string, output []any) map[string]any {
+		return map[string]any{
+			"id":         responseID,
+			"object":     "response",
+			"created_at": time.Now().Unix(),
+			"status":     status,
+			"model":      req.Model,
+			"output":     output,
+			"usage": map[string]any{
+				"input_tokens":          len(deltas),
+				"input_tokens_details":  map[string]any{"cached_tokens": 0},
+				"output_tokens":         len(deltas),
+				"output_tokens_details": map[string]any{"reasoning_tokens": 0},
+				"total_tokens":          2 * len(deltas),
+			},
+		}
+	}
+
+	if !req.Stream {
+		if !sleep(r, m.TTFT) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.ConfigDefault.NewEncoder(w).Encode(response("completed", []any{message}))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	sequence := 0
+	send := func(event map[string]any) {
+		event["sequence_number"] = sequence
+		sequence++
+		buf, _ := json.Marshal(event)
+		_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event["type"], buf)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	part := func(text string) map[string]any {
+		return map[string]any{"type": "output_text", "text": text, "annotations": []any{}}
+	}
+
+	send(map[string]any{"type": "response.created", "response": response("in_progress", []any{})})
+	send(map[string]any{"type": "response.in_progress", "response": response("in_progress", []any{})})
+	send(map[string]any{"type": "response.output_item.added", "output_index": 0, "item": map[string]any{
+		"type": "message", "id": itemID, "status": "in_progress", "role": "assistant", "content": []any{},
+	}})
+	send(map[string]any{"type": "response.content_part.added", "item_id": itemID, "output_index": 0, "content_index": 0, "part": part("")})
+
+	for i, delta := range deltas {
+		delay := m.ChunkDelay
+		if i == 0 {
+			delay = m.TTFT
+		}
+		if !sleep(r, delay) {
+			return
+		}
+		send(map[string]any{"type": "response.output_text.delta", "item_id": itemID, "output_index": 0, "content_index": 0, "delta": delta})
+	}
+
+	send(map[string]any{"type": "response.output_text.done", "item_id": itemID, "output_index": 0, "content_index": 0, "text": text})
+	send(map[string]any{"type": "response.content_part.done", "item_id": itemID, "output_index": 0, "content_index": 0, "part": part(text)})
+	send(map[string]any{"type": "response.output_item.done", "output_index": 0, "item": message})
+	send(map[string]any{"type": "response.completed", "response": response("completed", []any{message})})
+}
+
+// sleep waits for the delay, false when the request is cancelled first
+func sleep(r *http.Request, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
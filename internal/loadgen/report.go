@@ -0,0 +1,151 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Report holds the measurements of a load test
+type Report struct {
+	Conversations int     `json:"conversations"`
+	Concurrency   int     `json:"concurrency"`
+	Turns         int     `json:"turns"`
+	Failed        int     `json:"failed"`
+	DurationMs    float64 `json:"duration_ms"`
+	// TurnsPerSecond is the number of successful turns per second of the test
+	TurnsPerSecond float64 `json:"turns_per_second"`
+
+	TTFT         Distribution `json:"ttft_ms"`          // Until the first text delta
+	TurnLatency  Distribution `json:"turn_latency_ms"`  // Until run.completed
+	HistoryWrite Distribution `json:"history_write_ms"` // From run.completed until the message is readable
+	// ChunksPerSecond is the throughput of text deltas of every turn, after its first delta
+	ChunksPerSecond Distribution `json:"chunks_per_second"`
+	Chunks          int          `json:"chunks"`
+
+	// Errors counts the failed turns by error
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// Distribution summarizes measurements
+type Distribution struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+func newReport(opts Options, results []turnResult, elapsed time.Duration) *Report {
+	report := &Report{
+		Conversations: opts.Conversations,
+		Concurrency:   opts.Concurrency,
+		Turns:         len(results),
+		DurationMs:    milliseconds(elapsed),
+	}
+
+	var ttft, latency, history, throughput []float64
+	succeeded := 0
+	for _, result := range results {
+		if result.err != nil {
+			report.Failed++
+			if report.Errors == nil {
+				report.Errors = map[string]int{}
+			}
+			report.Errors[result.err.Error()]++
+			continue
+		}
+
+		succeeded++
+		report.Chunks += result.chunks
+		latency = append(latency, milliseconds(result.duration))
+		if result.hasTTFT {
+			ttft = append(ttft, milliseconds(result.ttft))
+			if streaming := result.duration - result.ttft; result.chunks > 1 && streaming > 0 {
+				throughput = append(throughput, float64(result.chunks-1)/streaming.Seconds())
+			}
+		}
+		if result.hasHistory {
+			history = append(history, milliseconds(result.historyWrite))
+		}
+	}
+
+	if elapsed > 0 {
+		report.TurnsPerSecond = float64(succeeded) / elapsed.Seconds()
+	}
+	report.TTFT = distribution(ttft)
+	report.TurnLatency = distribution(latency)
+	report.HistoryWrite = distribution(history)
+	report.ChunksPerSecond = distribution(throughput)
+
+	return report
+}
+
+// Print writes the report as a table
+func (r *Report) Print(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Conversations: %d (concurrency %d)\n", r.Conversations, r.Concurrency)
+	_, _ = fmt.Fprintf(w, "Turns:         %d, %d failed\n", r.Turns, r.Failed)
+	_, _ = fmt.Fprintf(w, "Duration:      %.1fs, %.2f turns/s\n", r.DurationMs/1000, r.TurnsPerSecond)
+	_, _ = fmt.Fprintf(w, "Chunks:        %d\n\n", r.Chunks)
+
+	_, _ = fmt.Fprintf(w, "%-20s %8s %10s %10s %10s %10s %10s %10s\n", "", "count", "min", "mean", "p50", "p95", "p99", "max")
+	for _, row := range []struct {
+		name string
+		d    Distribution
+	}{
+		{"ttft (ms)", r.TTFT},
+		{"turn latency (ms)", r.TurnLatency},
+		{"history write (ms)", r.HistoryWrite},
+		{"chunks/s", r.ChunksPerSecond},
+	} {
+		d := row.d
+		_, _ = fmt.Fprintf(w, "%-20s %8d %10.1f %10.1f %10.1f %10.1f %10.1f %10.1f\n", row.name, d.Count, d.Min, d.Mean, d.P50, d.P95, d.P99, d.Max)
+	}
+
+	if len(r.Errors) > 0 {
+		_, _ = fmt.Fprintln(w, "\nErrors:")
+		for err, count := range r.Errors {
+			_, _ = fmt.Fprintf(w, "  %6d  %s\n", count, err)
+		}
+	}
+}
+
+func distribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+
+	sort.Float64s(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return Distribution{
+		Count: len(values),
+		Min:   values[0],
+		Mean:  sum / float64(len(values)),
+		P50:   percentile(values, 0.50),
+		P95:   percentile(values, 0.95),
+		P99:   percentile(values, 0.99),
+		Max:   values[len(values)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile of an ascending sorted slice
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
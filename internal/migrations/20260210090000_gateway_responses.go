@@ -0,0 +1,37 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260210090000",
+		up:      mig_20260210090000_gateway_responses_up,
+		down:    mig_20260210090000_gateway_responses_down,
+	})
+}
+
+func mig_20260210090000_gateway_responses_up(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS gateway_responses (
+			id TEXT PRIMARY KEY,
+			provider VARCHAR(50) NOT NULL,
+			model VARCHAR(255) NOT NULL,
+			instructions TEXT,
+			input JSONB NOT NULL DEFAULT '[]'::jsonb,
+			output JSONB NOT NULL DEFAULT '[]'::jsonb,
+			usage JSONB,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_gateway_responses_created_at ON gateway_responses(created_at);`)
+	return err
+}
+
+func mig_20260210090000_gateway_responses_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS gateway_responses;`)
+	return err
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260212093000",
+		up:      mig_20260212093000_agent_config_notify_up,
+		down:    mig_20260212093000_agent_config_notify_down,
+	})
+}
+
+func mig_20260212093000_agent_config_notify_up(tx *sqlx.Tx) error {
+	// Trigger for agent_configs table, used to invalidate the agent config cache
+	_, err := tx.Exec(`
+		CREATE TRIGGER agent_configs_notify
+		AFTER INSERT OR UPDATE OR DELETE ON agent_configs
+		FOR EACH ROW EXECUTE FUNCTION notify_config_change();
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Trigger for agent_config_aliases table
+	_, err = tx.Exec(`
+		CREATE TRIGGER agent_config_aliases_notify
+		AFTER INSERT OR UPDATE OR DELETE ON agent_config_aliases
+		FOR EACH ROW EXECUTE FUNCTION notify_config_change();
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func mig_20260212093000_agent_config_notify_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TRIGGER IF EXISTS agent_configs_notify ON agent_configs;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP TRIGGER IF EXISTS agent_config_aliases_notify ON agent_config_aliases;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
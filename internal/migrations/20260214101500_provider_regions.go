@@ -0,0 +1,38 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260214101500",
+		up:      mig_20260214101500_provider_regions_up,
+		down:    mig_20260214101500_provider_regions_down,
+	})
+}
+
+func mig_20260214101500_provider_regions_up(tx *sqlx.Tx) error {
+	// Regional endpoints of a provider, and the region all requests are pinned to
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs
+		ADD COLUMN IF NOT EXISTS regions JSONB NOT NULL DEFAULT '[]'::jsonb,
+		ADD COLUMN IF NOT EXISTS pinned_region VARCHAR(255);
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func mig_20260214101500_provider_regions_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs
+		DROP COLUMN IF EXISTS regions,
+		DROP COLUMN IF EXISTS pinned_region;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
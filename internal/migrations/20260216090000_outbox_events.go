@@ -0,0 +1,43 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260216090000",
+		up:      mig_20260216090000_outbox_events_up,
+		down:    mig_20260216090000_outbox_events_down,
+	})
+}
+
+func mig_20260216090000_outbox_events_up(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS outbox_events (
+			id BIGSERIAL PRIMARY KEY,
+			topic VARCHAR(255) NOT NULL,
+			dedup_key TEXT NOT NULL UNIQUE,
+			payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			locked_until TIMESTAMP WITH TIME ZONE,
+			delivered_at TIMESTAMP WITH TIME ZONE,
+			dead_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at)
+		WHERE delivered_at IS NULL AND dead_at IS NULL;
+	`)
+	return err
+}
+
+func mig_20260216090000_outbox_events_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS outbox_events;`)
+	return err
+}
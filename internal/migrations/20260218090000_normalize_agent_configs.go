@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"log/slog"
+
+	"github.com/curaious/uno/internal/services/agent_config"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260218090000",
+		up:      mig_20260218090000_normalize_agent_configs_up,
+		down:    mig_20260218090000_normalize_agent_configs_down,
+	})
+}
+
+// Rewrites the stored agent configs in their canonical form. Configs that don't pass validation are kept as they
+// are and logged, so that they can be fixed on their next update.
+func mig_20260218090000_normalize_agent_configs_up(tx *sqlx.Tx) error {
+	rows := []struct {
+		ID      uuid.UUID                    `db:"id"`
+		Name    string                       `db:"name"`
+		Version int                          `db:"version"`
+		Config  agent_config.AgentConfigData `db:"config"`
+	}{}
+
+	if err := tx.Select(&rows, `SELECT id, name, version, config FROM agent_configs`); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		agent_config.NormalizeConfig(&row.Config)
+		if err := agent_config.ValidateConfig(&row.Config); err != nil {
+			slog.Warn("Agent config doesn't match the config schema", slog.String("name", row.Name), slog.Int("version", row.Version), slog.Any("error", err))
+		}
+
+		if _, err := tx.Exec(`UPDATE agent_configs SET config = $1 WHERE id = $2`, row.Config, row.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mig_20260218090000_normalize_agent_configs_down(tx *sqlx.Tx) error {
+	// Normalization is not reversible, and the normalized configs are valid for the previous version as well
+	return nil
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260220090000",
+		up:      mig_20260220090000_soft_delete_up,
+		down:    mig_20260220090000_soft_delete_down,
+	})
+}
+
+func mig_20260220090000_soft_delete_up(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE agent_configs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_agent_configs_deleted_at ON agent_configs(deleted_at) WHERE deleted_at IS NOT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE prompts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_prompts_deleted_at ON prompts(deleted_at) WHERE deleted_at IS NOT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	// Prompts in the trash must not block creating a new prompt with the same name
+	_, err = tx.Exec(`DROP INDEX IF EXISTS idx_prompts_project_name;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_prompts_project_name
+		ON prompts(project_id, name) WHERE project_id IS NOT NULL AND deleted_at IS NULL;
+	`)
+	return err
+}
+
+func mig_20260220090000_soft_delete_down(tx *sqlx.Tx) error {
+	// Rows in the trash are dropped, as they would become visible again otherwise
+	_, err := tx.Exec(`DELETE FROM agent_configs WHERE deleted_at IS NOT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM prompts WHERE deleted_at IS NOT NULL;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP INDEX IF EXISTS idx_prompts_project_name;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_prompts_project_name
+		ON prompts(project_id, name) WHERE project_id IS NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE prompts DROP COLUMN IF EXISTS deleted_at;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`ALTER TABLE agent_configs DROP COLUMN IF EXISTS deleted_at;`)
+	return err
+}
@@ -0,0 +1,107 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260222090000",
+		up:      mig_20260222090000_environments_up,
+		down:    mig_20260222090000_environments_down,
+	})
+}
+
+func mig_20260222090000_environments_up(tx *sqlx.Tx) error {
+	// Environments of a project, ordered by position (e.g. dev -> staging -> prod)
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS environments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			name VARCHAR(64) NOT NULL,
+			position INT NOT NULL DEFAULT 0,
+			required_approvals INT NOT NULL DEFAULT 0 CHECK (required_approvals >= 0),
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE(project_id, name)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// The agent or prompt version currently deployed to an environment
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS environment_deployments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			environment_id UUID NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+			kind VARCHAR(16) NOT NULL CHECK (kind IN ('agent', 'prompt')),
+			ref_id UUID NOT NULL,
+			version INT NOT NULL,
+			promotion_id UUID,
+			deployed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE(environment_id, kind, ref_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS promotions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			kind VARCHAR(16) NOT NULL CHECK (kind IN ('agent', 'prompt')),
+			ref_id UUID NOT NULL,
+			version INT NOT NULL,
+			from_environment VARCHAR(64),
+			to_environment VARCHAR(64) NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			required_approvals INT NOT NULL DEFAULT 0,
+			requested_by VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			applied_at TIMESTAMP WITH TIME ZONE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_promotions_project_status ON promotions(project_id, status);`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS promotion_reviews (
+			promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+			user_id VARCHAR(255) NOT NULL,
+			decision VARCHAR(16) NOT NULL,
+			comment TEXT,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (promotion_id, user_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Existing projects get the default environments
+	_, err = tx.Exec(`
+		INSERT INTO environments (project_id, name, position, required_approvals)
+		SELECT p.id, e.name, e.position, e.required_approvals
+		FROM projects p
+		CROSS JOIN (VALUES ('dev', 0, 0), ('staging', 1, 0), ('prod', 2, 1)) AS e(name, position, required_approvals)
+		ON CONFLICT (project_id, name) DO NOTHING;
+	`)
+	return err
+}
+
+func mig_20260222090000_environments_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		DROP TABLE IF EXISTS promotion_reviews;
+		DROP TABLE IF EXISTS promotions;
+		DROP TABLE IF EXISTS environment_deployments;
+		DROP TABLE IF EXISTS environments;
+	`)
+	return err
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260224090000",
+		up:      mig_20260224090000_agent_test_runs_up,
+		down:    mig_20260224090000_agent_test_runs_down,
+	})
+}
+
+func mig_20260224090000_agent_test_runs_up(tx *sqlx.Tx) error {
+	// Reports of bulk test runs of an agent version against sample inputs, compared with the live version
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS agent_test_runs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			agent_id UUID NOT NULL,
+			version INT NOT NULL,
+			live_version INT,
+			status VARCHAR(16) NOT NULL DEFAULT 'running',
+			samples INT NOT NULL DEFAULT 0,
+			report JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			completed_at TIMESTAMP WITH TIME ZONE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_agent_test_runs_agent ON agent_test_runs(project_id, agent_id, created_at DESC);`)
+	return err
+}
+
+func mig_20260224090000_agent_test_runs_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS agent_test_runs;`)
+	return err
+}
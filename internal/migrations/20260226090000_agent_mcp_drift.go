@@ -0,0 +1,36 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260226090000",
+		up:      mig_20260226090000_agent_mcp_drift_up,
+		down:    mig_20260226090000_agent_mcp_drift_down,
+	})
+}
+
+func mig_20260226090000_agent_mcp_drift_up(tx *sqlx.Tx) error {
+	// Accepted and observed tools of the MCP servers of each agent, and the drift between them
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS agent_mcp_drift (
+			agent_id UUID NOT NULL,
+			server_name VARCHAR(255) NOT NULL,
+			endpoint VARCHAR(500) NOT NULL,
+			expected JSONB NOT NULL DEFAULT '{}',
+			observed JSONB NOT NULL DEFAULT '{}',
+			drifts JSONB NOT NULL DEFAULT '[]',
+			breaking BOOLEAN NOT NULL DEFAULT FALSE,
+			error TEXT,
+			checked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			accepted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (agent_id, server_name)
+		);
+	`)
+	return err
+}
+
+func mig_20260226090000_agent_mcp_drift_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS agent_mcp_drift;`)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260228090000",
+		up:      mig_20260228090000_message_external_ids_up,
+		down:    mig_20260228090000_message_external_ids_down,
+	})
+}
+
+func mig_20260228090000_message_external_ids_up(tx *sqlx.Tx) error {
+	// IDs of the integrators' own chat stores, a write with a known external ID has already been applied
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS message_external_ids (
+			project_id UUID NOT NULL,
+			namespace VARCHAR(255) NOT NULL,
+			external_id VARCHAR(255) NOT NULL,
+			message_id VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (project_id, namespace, external_id),
+			FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_message_external_ids_message_id ON message_external_ids(message_id);
+	`)
+	return err
+}
+
+func mig_20260228090000_message_external_ids_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS message_external_ids;`)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260302090000",
+		up:      mig_20260302090000_data_keys_up,
+		down:    mig_20260302090000_data_keys_down,
+	})
+}
+
+func mig_20260302090000_data_keys_up(tx *sqlx.Tx) error {
+	// Data keys of the encryption at rest, wrapped by a master key. The nil project ID is the scope of the data
+	// that belongs to no project. Keys that were rotated are kept to decrypt the data written with them.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS data_keys (
+			id UUID PRIMARY KEY,
+			project_id UUID NOT NULL,
+			master_key_id VARCHAR(255) NOT NULL,
+			wrapped_key BYTEA NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			rotated_at TIMESTAMP WITH TIME ZONE
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_data_keys_active ON data_keys(project_id) WHERE rotated_at IS NULL;
+	`)
+	return err
+}
+
+func mig_20260302090000_data_keys_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS data_keys;`)
+	return err
+}
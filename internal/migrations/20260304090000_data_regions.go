@@ -0,0 +1,29 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260304090000",
+		up:      mig_20260304090000_data_regions_up,
+		down:    mig_20260304090000_data_regions_down,
+	})
+}
+
+func mig_20260304090000_data_regions_up(tx *sqlx.Tx) error {
+	// The region a project is pinned to, and the regions a provider may serve. A provider without regions only
+	// serves projects that aren't pinned.
+	_, err := tx.Exec(`
+		ALTER TABLE projects ADD COLUMN IF NOT EXISTS region VARCHAR(64);
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS data_regions TEXT[] NOT NULL DEFAULT '{}';
+	`)
+	return err
+}
+
+func mig_20260304090000_data_regions_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS data_regions;
+		ALTER TABLE projects DROP COLUMN IF EXISTS region;
+	`)
+	return err
+}
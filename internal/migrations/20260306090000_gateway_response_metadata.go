@@ -0,0 +1,22 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260306090000",
+		up:      mig_20260306090000_gateway_response_metadata_up,
+		down:    mig_20260306090000_gateway_response_metadata_down,
+	})
+}
+
+func mig_20260306090000_gateway_response_metadata_up(tx *sqlx.Tx) error {
+	// The metadata of the request, which identifies the data subject of a response when it is erased
+	_, err := tx.Exec(`ALTER TABLE gateway_responses ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;`)
+	return err
+}
+
+func mig_20260306090000_gateway_response_metadata_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE gateway_responses DROP COLUMN IF EXISTS metadata;`)
+	return err
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260308090000",
+		up:      mig_20260308090000_agent_namespace_overrides_up,
+		down:    mig_20260308090000_agent_namespace_overrides_down,
+	})
+}
+
+func mig_20260308090000_agent_namespace_overrides_up(tx *sqlx.Tx) error {
+	// Parts of the config of an agent overridden for the conversations of a namespace
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS agent_namespace_overrides (
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			agent_id UUID NOT NULL,
+			namespace VARCHAR(255) NOT NULL,
+			overrides JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (project_id, agent_id, namespace)
+		);
+	`)
+	return err
+}
+
+func mig_20260308090000_agent_namespace_overrides_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS agent_namespace_overrides;`)
+	return err
+}
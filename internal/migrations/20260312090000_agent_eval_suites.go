@@ -0,0 +1,61 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260312090000",
+		up:      mig_20260312090000_agent_eval_suites_up,
+		down:    mig_20260312090000_agent_eval_suites_down,
+	})
+}
+
+func mig_20260312090000_agent_eval_suites_up(tx *sqlx.Tx) error {
+	// Eval suite an agent version must pass before an alias is pointed at it
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS agent_eval_suites (
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			agent_id UUID NOT NULL,
+			cases JSONB NOT NULL DEFAULT '[]',
+			evaluators JSONB NOT NULL DEFAULT '[]',
+			max_regression DOUBLE PRECISION NOT NULL DEFAULT 0.05,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (project_id, agent_id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Reports of the runs of the eval suites, compared with the baseline version
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS agent_eval_runs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			agent_id UUID NOT NULL,
+			alias_id UUID,
+			version INT NOT NULL,
+			baseline_version INT,
+			status VARCHAR(16) NOT NULL,
+			report JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_agent_eval_runs_agent ON agent_eval_runs(project_id, agent_id, created_at DESC);`)
+	return err
+}
+
+func mig_20260312090000_agent_eval_suites_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS agent_eval_runs;`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DROP TABLE IF EXISTS agent_eval_suites;`)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260316090000",
+		up:      mig_20260316090000_slack_threads_up,
+		down:    mig_20260316090000_slack_threads_down,
+	})
+}
+
+func mig_20260316090000_slack_threads_up(tx *sqlx.Tx) error {
+	// Threads of the conversations the Slack integration holds for Slack threads and direct messages. The thread
+	// is empty for a direct message channel, whose messages are a single conversation.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS slack_threads (
+			team_id VARCHAR(32) NOT NULL,
+			channel_id VARCHAR(32) NOT NULL,
+			thread_ts VARCHAR(32) NOT NULL DEFAULT '',
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			namespace VARCHAR(255) NOT NULL,
+			thread_id VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (team_id, channel_id, thread_ts)
+		);
+	`)
+	return err
+}
+
+func mig_20260316090000_slack_threads_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS slack_threads;`)
+	return err
+}
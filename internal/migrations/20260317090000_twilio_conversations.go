@@ -0,0 +1,33 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260317090000",
+		up:      mig_20260317090000_twilio_conversations_up,
+		down:    mig_20260317090000_twilio_conversations_down,
+	})
+}
+
+func mig_20260317090000_twilio_conversations_up(tx *sqlx.Tx) error {
+	// Threads of the conversations the Twilio integration holds for the SMS and WhatsApp senders of its numbers.
+	// The addresses are phone numbers, prefixed with "whatsapp:" for WhatsApp.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS twilio_conversations (
+			address VARCHAR(64) NOT NULL,
+			sender VARCHAR(64) NOT NULL,
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			namespace VARCHAR(255) NOT NULL,
+			thread_id VARCHAR(255) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (address, sender)
+		);
+	`)
+	return err
+}
+
+func mig_20260317090000_twilio_conversations_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS twilio_conversations;`)
+	return err
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260318090000",
+		up:      mig_20260318090000_email_messages_up,
+		down:    mig_20260318090000_email_messages_down,
+	})
+}
+
+func mig_20260318090000_email_messages_up(tx *sqlx.Tx) error {
+	// Emails the email channel received and sent, by their Message-ID, with the thread of their conversation.
+	// Replies are threaded by looking up their In-Reply-To and References, the replies of the agent keep the run
+	// they answer with and the tool calls it waits the approval of.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS email_messages (
+			message_id VARCHAR(998) PRIMARY KEY,
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			namespace VARCHAR(255) NOT NULL,
+			thread_id VARCHAR(255) NOT NULL DEFAULT '',
+			run_id VARCHAR(255) NOT NULL DEFAULT '',
+			pending_call_ids TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+		);
+	`)
+	return err
+}
+
+func mig_20260318090000_email_messages_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS email_messages;`)
+	return err
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260320090000",
+		up:      mig_20260320090000_webhook_triggers_up,
+		down:    mig_20260320090000_webhook_triggers_down,
+	})
+}
+
+func mig_20260320090000_webhook_triggers_up(tx *sqlx.Tx) error {
+	// Webhook triggers run an agent on the payloads external systems post to them, the templates map a payload to
+	// the message, namespace and context of the run. The result is posted to the callback URL.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_triggers (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			agent_id VARCHAR(255) NOT NULL,
+			namespace_template VARCHAR(255) NOT NULL DEFAULT '',
+			message_template TEXT NOT NULL DEFAULT '',
+			context_template JSONB NOT NULL DEFAULT '{}',
+			callback_url TEXT NOT NULL DEFAULT '',
+			secret VARCHAR(255) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, name)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// An invocation is a payload posted to a trigger and the run it started, with the delivery of its callback
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_trigger_invocations (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			trigger_id UUID NOT NULL REFERENCES webhook_triggers(id) ON DELETE CASCADE,
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			status VARCHAR(32) NOT NULL DEFAULT 'running',
+			run_id VARCHAR(255) NOT NULL DEFAULT '',
+			result JSONB,
+			error TEXT NOT NULL DEFAULT '',
+			callback_status INTEGER NOT NULL DEFAULT 0,
+			callback_attempts INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			completed_at TIMESTAMP WITH TIME ZONE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_webhook_trigger_invocations_trigger ON webhook_trigger_invocations(trigger_id, created_at DESC);
+	`)
+	return err
+}
+
+func mig_20260320090000_webhook_triggers_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		DROP TABLE IF EXISTS webhook_trigger_invocations;
+		DROP TABLE IF EXISTS webhook_triggers;
+	`)
+	return err
+}
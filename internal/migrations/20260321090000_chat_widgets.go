@@ -0,0 +1,40 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260321090000",
+		up:      mig_20260321090000_chat_widgets_up,
+		down:    mig_20260321090000_chat_widgets_down,
+	})
+}
+
+func mig_20260321090000_chat_widgets_up(tx *sqlx.Tx) error {
+	// Chat widgets let the visitors of websites converse with an agent. The pages of the allowed origins get
+	// sessions signed with the secret of the widget, every visitor converses in a namespace of its own.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS chat_widgets (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			agent_id VARCHAR(255) NOT NULL,
+			allowed_origins TEXT[] NOT NULL DEFAULT '{}',
+			secret VARCHAR(255) NOT NULL,
+			session_ttl_minutes INTEGER NOT NULL DEFAULT 1440,
+			messages_per_minute INTEGER NOT NULL DEFAULT 10,
+			sessions_per_hour INTEGER NOT NULL DEFAULT 20,
+			max_message_length INTEGER NOT NULL DEFAULT 4000,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			UNIQUE (project_id, name)
+		);
+	`)
+	return err
+}
+
+func mig_20260321090000_chat_widgets_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS chat_widgets;`)
+	return err
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260322090000",
+		up:      mig_20260322090000_tool_usage_counters_up,
+		down:    mig_20260322090000_tool_usage_counters_down,
+	})
+}
+
+func mig_20260322090000_tool_usage_counters_up(tx *sqlx.Tx) error {
+	// Tool usage counters count the calls of tools against the quotas of agents, per conversation or per user and
+	// per period. Quotas without a period are counted in the period starting at the epoch.
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS tool_usage_counters (
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			scope_key VARCHAR(512) NOT NULL,
+			tool VARCHAR(255) NOT NULL,
+			window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			PRIMARY KEY (project_id, scope_key, tool, window_start)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_tool_usage_counters_updated_at ON tool_usage_counters(updated_at);
+	`)
+	return err
+}
+
+func mig_20260322090000_tool_usage_counters_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS tool_usage_counters;`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260323090000",
+		up:      mig_20260323090000_tool_shim_models_up,
+		down:    mig_20260323090000_tool_shim_models_down,
+	})
+}
+
+func mig_20260323090000_tool_shim_models_up(tx *sqlx.Tx) error {
+	// The models of a provider without native tool calling, their tools are described in the prompt
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS tool_shim_models TEXT[] NOT NULL DEFAULT '{}';
+	`)
+	return err
+}
+
+func mig_20260323090000_tool_shim_models_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS tool_shim_models;
+	`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260324090000",
+		up:      mig_20260324090000_client_safe_stream_up,
+		down:    mig_20260324090000_client_safe_stream_down,
+	})
+}
+
+func mig_20260324090000_client_safe_stream_up(tx *sqlx.Tx) error {
+	// Whether the encrypted content, extra params and internal IDs are stripped from the chunks streamed to clients
+	_, err := tx.Exec(`
+		ALTER TABLE projects ADD COLUMN IF NOT EXISTS client_safe_stream BOOLEAN NOT NULL DEFAULT TRUE;
+	`)
+	return err
+}
+
+func mig_20260324090000_client_safe_stream_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE projects DROP COLUMN IF EXISTS client_safe_stream;
+	`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260326090000",
+		up:      mig_20260326090000_project_agent_defaults_up,
+		down:    mig_20260326090000_project_agent_defaults_down,
+	})
+}
+
+func mig_20260326090000_project_agent_defaults_up(tx *sqlx.Tx) error {
+	// Defaults merged under the configs of the agents of the project: model, temperature, summarizer and guardrails
+	_, err := tx.Exec(`
+		ALTER TABLE projects ADD COLUMN IF NOT EXISTS agent_defaults JSONB;
+	`)
+	return err
+}
+
+func mig_20260326090000_project_agent_defaults_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE projects DROP COLUMN IF EXISTS agent_defaults;
+	`)
+	return err
+}
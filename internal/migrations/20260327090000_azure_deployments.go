@@ -0,0 +1,28 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260327090000",
+		up:      mig_20260327090000_azure_deployments_up,
+		down:    mig_20260327090000_azure_deployments_down,
+	})
+}
+
+func mig_20260327090000_azure_deployments_up(tx *sqlx.Tx) error {
+	// The api-version of the requests to Azure OpenAI, and the deployments serving the models
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS api_version TEXT;
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS deployments JSONB NOT NULL DEFAULT '{}';
+	`)
+	return err
+}
+
+func mig_20260327090000_azure_deployments_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS deployments;
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS api_version;
+	`)
+	return err
+}
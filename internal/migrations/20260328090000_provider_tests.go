@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260328090000",
+		up:      mig_20260328090000_provider_tests_up,
+		down:    mig_20260328090000_provider_tests_down,
+	})
+}
+
+func mig_20260328090000_provider_tests_up(tx *sqlx.Tx) error {
+	// The result of the latest test of the API keys of the provider
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS last_test JSONB;
+	`)
+	return err
+}
+
+func mig_20260328090000_provider_tests_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS last_test;
+	`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260329090000",
+		up:      mig_20260329090000_provider_gcp_project_up,
+		down:    mig_20260329090000_provider_gcp_project_down,
+	})
+}
+
+func mig_20260329090000_provider_gcp_project_up(tx *sqlx.Tx) error {
+	// The Google Cloud project of the requests to Vertex AI
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS gcp_project TEXT;
+	`)
+	return err
+}
+
+func mig_20260329090000_provider_gcp_project_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS gcp_project;
+	`)
+	return err
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260330090000",
+		up:      mig_20260330090000_virtual_key_usage_up,
+		down:    mig_20260330090000_virtual_key_usage_down,
+	})
+}
+
+func mig_20260330090000_virtual_key_usage_up(tx *sqlx.Tx) error {
+	// Hourly usage of the virtual keys by model, the alerts raised on its anomalies and their settings
+	_, err := tx.Exec(`
+		ALTER TABLE virtual_keys ADD COLUMN IF NOT EXISTS usage_alerts JSONB;
+
+		CREATE TABLE IF NOT EXISTS virtual_key_usage (
+			virtual_key_id UUID NOT NULL REFERENCES virtual_keys(id) ON DELETE CASCADE,
+			hour TIMESTAMPTZ NOT NULL,
+			provider_type VARCHAR(50) NOT NULL,
+			model_name VARCHAR(255) NOT NULL,
+			requests BIGINT NOT NULL DEFAULT 0,
+			errors BIGINT NOT NULL DEFAULT 0,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (virtual_key_id, hour, provider_type, model_name)
+		);
+
+		CREATE TABLE IF NOT EXISTS virtual_key_alerts (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			virtual_key_id UUID NOT NULL REFERENCES virtual_keys(id) ON DELETE CASCADE,
+			kind VARCHAR(50) NOT NULL,
+			detail TEXT NOT NULL DEFAULT '',
+			window_start TIMESTAMPTZ NOT NULL,
+			message TEXT NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (virtual_key_id, kind, detail, window_start)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_virtual_key_alerts_key_created ON virtual_key_alerts(virtual_key_id, created_at DESC);
+	`)
+	return err
+}
+
+func mig_20260330090000_virtual_key_usage_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		DROP TABLE IF EXISTS virtual_key_alerts;
+		DROP TABLE IF EXISTS virtual_key_usage;
+		ALTER TABLE virtual_keys DROP COLUMN IF EXISTS usage_alerts;
+	`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260331090000",
+		up:      mig_20260331090000_virtual_key_signing_up,
+		down:    mig_20260331090000_virtual_key_signing_down,
+	})
+}
+
+func mig_20260331090000_virtual_key_signing_up(tx *sqlx.Tx) error {
+	// The requests made with a virtual key with a signing secret must be signed with it
+	_, err := tx.Exec(`
+		ALTER TABLE virtual_keys ADD COLUMN IF NOT EXISTS signing_secret TEXT;
+	`)
+	return err
+}
+
+func mig_20260331090000_virtual_key_signing_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE virtual_keys DROP COLUMN IF EXISTS signing_secret;
+	`)
+	return err
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260401090000",
+		up:      mig_20260401090000_provider_capabilities_up,
+		down:    mig_20260401090000_provider_capabilities_down,
+	})
+}
+
+func mig_20260401090000_provider_capabilities_up(tx *sqlx.Tx) error {
+	// The features supported by an OpenAI-compatible server, NULL when it supports all of them
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs ADD COLUMN IF NOT EXISTS capabilities JSONB;
+	`)
+	return err
+}
+
+func mig_20260401090000_provider_capabilities_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		ALTER TABLE provider_configs DROP COLUMN IF EXISTS capabilities;
+	`)
+	return err
+}
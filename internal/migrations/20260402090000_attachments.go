@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260402090000",
+		up:      mig_20260402090000_attachments_up,
+		down:    mig_20260402090000_attachments_down,
+	})
+}
+
+func mig_20260402090000_attachments_up(tx *sqlx.Tx) error {
+	// The files sent by the users, keyed by the SHA-256 of their data so that a file sent again is stored once
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS attachments (
+			sha256 TEXT PRIMARY KEY,
+			mime_type TEXT NOT NULL,
+			size_bytes INTEGER NOT NULL,
+			data BYTEA NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`)
+	return err
+}
+
+func mig_20260402090000_attachments_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+		DROP TABLE IF EXISTS attachments;
+	`)
+	return err
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260403090000",
+		up:      mig_20260403090000_tool_dead_letters_up,
+		down:    mig_20260403090000_tool_dead_letters_down,
+	})
+}
+
+func mig_20260403090000_tool_dead_letters_up(tx *sqlx.Tx) error {
+	// The failed tool calls of the agents, with their arguments, error and attempts, kept to be diagnosed and replayed
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS tool_dead_letters (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+			agent_id UUID NOT NULL,
+			agent_version INT NOT NULL,
+			agent_name TEXT NOT NULL DEFAULT '',
+			run_id TEXT NOT NULL DEFAULT '',
+			conversation_id TEXT NOT NULL DEFAULT '',
+			namespace TEXT NOT NULL DEFAULT '',
+			tool_name TEXT NOT NULL,
+			call_id TEXT NOT NULL DEFAULT '',
+			arguments TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			stack TEXT NOT NULL DEFAULT '',
+			attempts INT NOT NULL DEFAULT 1,
+			status VARCHAR(16) NOT NULL DEFAULT 'failed',
+			replay_count INT NOT NULL DEFAULT 0,
+			last_replay JSONB,
+			created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			last_replay_at TIMESTAMP WITH TIME ZONE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`CREATE INDEX IF NOT EXISTS idx_tool_dead_letters_project ON tool_dead_letters(project_id, created_at DESC);`)
+	return err
+}
+
+func mig_20260403090000_tool_dead_letters_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`DROP TABLE IF EXISTS tool_dead_letters;`)
+	return err
+}
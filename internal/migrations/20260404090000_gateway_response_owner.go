@@ -0,0 +1,23 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260404090000",
+		up:      mig_20260404090000_gateway_response_owner_up,
+		down:    mig_20260404090000_gateway_response_owner_down,
+	})
+}
+
+func mig_20260404090000_gateway_response_owner_up(tx *sqlx.Tx) error {
+	// The virtual key, or the hash of the provider key, the response was created with. The responses stored before
+	// have no owner, and can no longer be read, deleted or continued from.
+	_, err := tx.Exec(`ALTER TABLE gateway_responses ADD COLUMN IF NOT EXISTS owner TEXT;`)
+	return err
+}
+
+func mig_20260404090000_gateway_response_owner_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE gateway_responses DROP COLUMN IF EXISTS owner;`)
+	return err
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "github.com/jmoiron/sqlx"
+
+func init() {
+	m.addMigration(&migration{
+		version: "20260405090000",
+		up:      mig_20260405090000_virtual_key_project_up,
+		down:    mig_20260405090000_virtual_key_project_down,
+	})
+}
+
+func mig_20260405090000_virtual_key_project_up(tx *sqlx.Tx) error {
+	// The project a virtual key is used by, whose erasures reach the responses stored with the key
+	_, err := tx.Exec(`ALTER TABLE virtual_keys ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL;`)
+	return err
+}
+
+func mig_20260405090000_virtual_key_project_down(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE virtual_keys DROP COLUMN IF EXISTS project_id;`)
+	return err
+}
@@ -41,15 +41,41 @@ func NewMigrator() (*Migrator, error) {
 	conf := config.ReadConfig()
 
 	// Get the database instance
-	m.db = db.NewConn(conf)
+	return newMigrator(db.NewConn(conf))
+}
+
+// NewRegionMigrator returns a migrator for the database of a region listed in DB_REGIONS. Regional databases
+// hold the full schema, as the conversations of pinned projects are stored there.
+func NewRegionMigrator(region string) (*Migrator, error) {
+	conf := config.ReadConfig()
+
+	conn, err := db.NewRegionConn(conf, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMigrator(conn)
+}
+
+func newMigrator(conn *sqlx.DB) (*Migrator, error) {
+	// Every database tracks its own completed migrations
+	migrator := &Migrator{
+		db:         conn,
+		versions:   append([]string{}, m.versions...),
+		migrations: map[string]*migration{},
+	}
+	for version, mg := range m.migrations {
+		copied := *mg
+		migrator.migrations[version] = &copied
+	}
 
-	_, err := m.db.Exec(`CREATE SCHEMA IF NOT EXISTS metadata`)
+	_, err := migrator.db.Exec(`CREATE SCHEMA IF NOT EXISTS metadata`)
 	if err != nil {
 		slog.Error("Unable to create metadata schema", slog.Any("error", err))
 		return nil, err
 	}
 
-	_, err = m.db.Exec(`CREATE TABLE IF NOT EXISTS metadata.schema_migrations (
+	_, err = migrator.db.Exec(`CREATE TABLE IF NOT EXISTS metadata.schema_migrations (
 		version varchar(255)
 	);`)
 	if err != nil {
@@ -57,7 +83,7 @@ func NewMigrator() (*Migrator, error) {
 		return nil, err
 	}
 
-	rows, err := m.db.Query("SELECT version FROM metadata.schema_migrations;")
+	rows, err := migrator.db.Query("SELECT version FROM metadata.schema_migrations;")
 	if err != nil {
 		slog.Error("Unable to fetch completed migrations", slog.Any("error", err))
 		return nil, err
@@ -72,12 +98,12 @@ func NewMigrator() (*Migrator, error) {
 			return nil, err
 		}
 
-		if m.migrations[version] != nil {
-			m.migrations[version].done = true
+		if migrator.migrations[version] != nil {
+			migrator.migrations[version].done = true
 		}
 	}
 
-	return m, nil
+	return migrator, nil
 }
 
 // addMigration ..
@@ -21,6 +21,8 @@ const (
 	ChangeTypeVirtualKey         ConfigChangeType = "virtual_keys"
 	ChangeTypeVirtualKeyProvider ConfigChangeType = "virtual_key_providers"
 	ChangeTypeVirtualKeyModel    ConfigChangeType = "virtual_key_models"
+	ChangeTypeAgentConfig        ConfigChangeType = "agent_configs"
+	ChangeTypeAgentConfigAlias   ConfigChangeType = "agent_config_aliases"
 )
 
 // ConfigChangeEvent represents a configuration change notification
@@ -95,6 +97,10 @@ func (ps *PubSub) Start() error {
 				ChangeType: ChangeTypeVirtualKey,
 				Operation:  "RELOAD",
 			})
+			ps.notifyHandlers(ConfigChangeEvent{
+				ChangeType: ChangeTypeAgentConfig,
+				Operation:  "RELOAD",
+			})
 		}
 	}
 
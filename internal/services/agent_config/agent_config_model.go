@@ -3,7 +3,9 @@ package agent_config
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +44,12 @@ type MCPServerConfig struct {
 	Headers                     map[string]string `json:"headers,omitempty"`
 	ToolFilters                 []string          `json:"tool_filters,omitempty"`                   // Tools to include (empty means all)
 	ToolsRequiringHumanApproval []string          `json:"tools_requiring_human_approval,omitempty"` // Tools that need human approval
+	BlockOnBreakingDrift        bool              `json:"block_on_breaking_drift,omitempty"`        // Refuse runs while the tools of the server have breaking changes
+	ToolPrefix                  string            `json:"tool_prefix,omitempty"`                    // Prepended to the name of every tool of the server
+	ToolRenames                 map[string]string `json:"tool_renames,omitempty"`                   // Names to expose tools under, takes precedence over the prefix
+	TimeoutSeconds              int               `json:"timeout_seconds,omitempty"`                // Bound of a single tool call attempt (default 60)
+	MaxRetries                  int               `json:"max_retries,omitempty"`                    // Retries of tool calls that failed to reach the server or timed out
+	MaxResultBytes              int               `json:"max_result_bytes,omitempty"`               // Tool results are truncated beyond this size (0 means no limit)
 }
 
 // SummarizerConfig represents conversation summarization configuration
@@ -51,13 +59,18 @@ type SummarizerConfig struct {
 	LLMKeepRecentCount     *int          `json:"llm_keep_recent_count,omitempty"`     // For "llm" type
 	LLMSummarizerPrompt    *PromptConfig `json:"llm_summarizer_prompt,omitempty"`     // For "llm" type
 	LLMSummarizerModel     *ModelConfig  `json:"llm_summarizer_model,omitempty"`      // For "llm" type
+	LLMShadowMode          bool          `json:"llm_shadow_mode,omitempty"`           // For "llm" type: summaries are only compared, not applied
+	LLMMaxInputTokens      *int          `json:"llm_max_input_tokens,omitempty"`      // For "llm" type: longer histories are summarized in parts
 	SlidingWindowKeepCount *int          `json:"sliding_window_keep_count,omitempty"` // For "sliding_window" type
 }
 
 // HistoryConfig represents conversation history configuration
 type HistoryConfig struct {
 	Enabled    bool              `json:"enabled"`
-	Summarizer *SummarizerConfig `json:"summarizer,omitempty"` // Required when enabled is true
+	Summarizer *SummarizerConfig `json:"summarizer,omitempty"` // The default summarizer of the project when nil
+
+	// DeduplicateToolOutputs sends the outputs of repeated tool calls as a reference to the first call
+	DeduplicateToolOutputs bool `json:"deduplicate_tool_outputs,omitempty"`
 }
 
 // ToolConfig represents tools enabled and their parameters
@@ -104,6 +117,39 @@ type TempSkillUploadResponse struct {
 	SkillFolder string `json:"skill_folder"` // Folder name of the skill (from zip name)
 }
 
+// TranslationConfig represents the translation of conversations to the working language of the agent
+type TranslationConfig struct {
+	Enabled  bool         `json:"enabled"`
+	Language string       `json:"language,omitempty"` // Working language of the agent, as an ISO 639-1 code (default "en")
+	Model    *ModelConfig `json:"model,omitempty"`    // Model detecting the language and translating, required when enabled is true
+}
+
+// ResponsePolicyConfig represents the constraints on the answers of the agent, the answers violating them are revised
+type ResponsePolicyConfig struct {
+	Enabled      bool         `json:"enabled"`
+	Language     string       `json:"language,omitempty"`      // Language of the answers, as an ISO 639-1 code
+	Tone         string       `json:"tone,omitempty"`          // Tone of the answers, e.g. "formal"
+	MaxLength    int          `json:"max_length,omitempty"`    // Maximum number of characters of the answers
+	MaxRevisions *int         `json:"max_revisions,omitempty"` // Revisions of an answer before keeping it (default 2)
+	Model        *ModelConfig `json:"model,omitempty"`         // Model judging the language and the tone, required with either
+}
+
+// SubAgentConfig represents another agent of the project that the agent can call as a tool
+type SubAgentConfig struct {
+	AgentName   string `json:"agent_name"`
+	Description string `json:"description"`         // Tells the model when to call the agent
+	ToolName    string `json:"tool_name,omitempty"` // Name of the tool, defaults to the agent name
+	Version     *int   `json:"version,omitempty"`   // Version of the agent, defaults to version 0
+}
+
+// ToolQuotaConfig limits the calls of a tool per conversation or per user
+type ToolQuotaConfig struct {
+	Tool   string `json:"tool"`             // Function name of the tool, or image_generation, web_search or code_execution
+	Scope  string `json:"scope"`            // "conversation" or "user"
+	Limit  int    `json:"limit"`            // Calls allowed in the period
+	Period string `json:"period,omitempty"` // "hour", "day" or "month", the counters never start over when empty
+}
+
 // AgentConfigData represents the complete JSON configuration stored in the config column
 type AgentConfigData struct {
 	MaxIteration *int              `json:"max_iteration,omitempty"`
@@ -115,6 +161,36 @@ type AgentConfigData struct {
 	History      *HistoryConfig    `json:"history,omitempty"`
 	Tools        *ToolConfig       `json:"tools,omitempty"`
 	Skills       []SkillConfig     `json:"skills,omitempty"` // Skills attached to this agent
+
+	// DisableArgumentRepair asks the model to retry malformed tool call arguments instead of repairing them
+	DisableArgumentRepair bool `json:"disable_argument_repair,omitempty"`
+
+	// Provenance labels every output message with the model, provider and run that generated it
+	Provenance bool `json:"provenance,omitempty"`
+
+	// Translation translates the messages of the user to the working language of the agent, and the answers back
+	Translation *TranslationConfig `json:"translation,omitempty"`
+
+	// ResponsePolicy enforces the language, the tone and the length of the answers, revising the ones violating it
+	ResponsePolicy *ResponsePolicyConfig `json:"response_policy,omitempty"`
+
+	// DurableApprovals keeps the runs of the Restate runtime waiting for the approval of tool calls, instead of
+	// ending them when they pause. The approval is given on the approval endpoint of the paused message.
+	DurableApprovals bool `json:"durable_approvals,omitempty"`
+
+	// ConversationSessions runs the messages of a conversation in a Restate virtual object keyed by the conversation,
+	// so that concurrent messages to it are executed one after the other. Only used by the Restate runtime.
+	ConversationSessions bool `json:"conversation_sessions,omitempty"`
+
+	// SubAgents are the other agents of the project the agent can delegate to, each is exposed as a tool
+	SubAgents []SubAgentConfig `json:"sub_agents,omitempty"`
+
+	// ToolQuotas limit the calls of tools, e.g. 3 image generations per conversation
+	ToolQuotas []ToolQuotaConfig `json:"tool_quotas,omitempty"`
+
+	// ProviderPinning keeps the conversations with the provider ("provider"), or the provider and the model
+	// ("model"), of their runs. By default they follow the model of the agent, their history is sanitized for it.
+	ProviderPinning string `json:"provider_pinning,omitempty"`
 }
 
 // Scan implements the sql.Scanner interface for database/sql
@@ -153,12 +229,37 @@ type AgentConfig struct {
 	Config    AgentConfigData `json:"config" db:"config"`
 	CreatedAt time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+
+	// ETag identifies the revision of the config, set on API responses. See GetETag.
+	ETag string `json:"etag,omitempty" db:"-"`
+
+	// MCPDrift lists the MCP servers of the agent whose tools changed since they were last accepted, set on API responses
+	MCPDrift []*MCPServerDrift `json:"mcp_drift,omitempty" db:"-"`
 }
 
 func (c *AgentConfig) GetName() string {
 	return strings.ToLower(fmt.Sprintf("%s-%v", c.Name, c.Version))
 }
 
+// GetETag returns the entity tag of the config revision. Every update of version 0 changes updated_at,
+// so the timestamp identifies the revision.
+func (c *AgentConfig) GetETag() string {
+	return fmt.Sprintf(`"%d"`, c.UpdatedAt.UnixMicro())
+}
+
+// ErrInvalidETag is returned when an entity tag wasn't returned by GetETag
+var ErrInvalidETag = errors.New("invalid etag")
+
+// ParseETag returns the updated_at timestamp of the revision identified by an entity tag returned by GetETag
+func ParseETag(etag string) (time.Time, error) {
+	raw := strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+	micros, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q", ErrInvalidETag, etag)
+	}
+	return time.UnixMicro(micros), nil
+}
+
 // CreateAgentConfigRequest represents the request to create a new agent config
 type CreateAgentConfigRequest struct {
 	Name   string          `json:"name" validate:"required,min=1,max=255"`
@@ -168,6 +269,19 @@ type CreateAgentConfigRequest struct {
 // UpdateAgentConfigRequest represents the request to update version 0 (mutable) or create a new version
 type UpdateAgentConfigRequest struct {
 	Config AgentConfigData `json:"config" validate:"required"`
+
+	// IfMatch is the etag of the revision the update was based on. When set, the update fails with a
+	// *ConflictError if version 0 was changed since. The If-Match header takes precedence.
+	IfMatch string `json:"if_match,omitempty"`
+}
+
+// ConflictError is returned when version 0 was updated concurrently, carrying the latest revision
+type ConflictError struct {
+	Latest *AgentConfig
+}
+
+func (e *ConflictError) Error() string {
+	return "agent config was modified concurrently, reload it and apply the changes again"
 }
 
 // CreateVersionRequest represents the request to create a new immutable version from version 0
@@ -186,6 +300,17 @@ type AgentConfigSummary struct {
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// DeletedAgentConfig represents an agent config in the trash
+type DeletedAgentConfig struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	AgentID       uuid.UUID `json:"agent_id" db:"agent_id"`
+	ProjectID     uuid.UUID `json:"project_id" db:"project_id"`
+	Name          string    `json:"name" db:"name"`
+	LatestVersion int       `json:"latest_version" db:"latest_version"`
+	DeletedAt     time.Time `json:"deleted_at" db:"deleted_at"`
+	PurgeAt       time.Time `json:"purge_at" db:"-"` // When the config is permanently deleted
+}
+
 // AgentConfigAlias represents a named mapping to one or two agent versions
 type AgentConfigAlias struct {
 	ID        uuid.UUID `json:"id" db:"id"`
@@ -199,6 +324,19 @@ type AgentConfigAlias struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// PickVersion returns the version the alias points to, splitting traffic by weight when it has two versions
+func (a *AgentConfigAlias) PickVersion() int {
+	if a.Version2 == nil {
+		return a.Version1
+	}
+
+	idx := utils.WeightedRandomIndex([]int{100 - *a.Weight, *a.Weight})
+	if idx == 0 {
+		return a.Version1
+	}
+	return *a.Version2
+}
+
 // CreateAliasRequest represents the request to create a new alias
 type CreateAliasRequest struct {
 	Name     string `json:"name" validate:"required,min=1,max=255"`
@@ -213,4 +351,80 @@ type UpdateAliasRequest struct {
 	Version1 *int   `json:"version1,omitempty"`
 	Version2 *int   `json:"version2,omitempty"`
 	Weight   *int   `json:"weight,omitempty" validate:"omitempty,min=0,max=100"`
+
+	// Force points the alias at a new version without running the eval suite of the agent
+	Force bool `json:"force,omitempty"`
+}
+
+// MCPToolSchema is the part of an MCP tool definition that agents depend on
+type MCPToolSchema struct {
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+// MCPToolSchemas maps tool names to their schema
+type MCPToolSchemas map[string]MCPToolSchema
+
+// Scan implements the sql.Scanner interface for database/sql
+func (t *MCPToolSchemas) Scan(value interface{}) error {
+	return scanJSON(value, t)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (t MCPToolSchemas) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+// MCPToolDrift is a change of a tool compared with its accepted schema
+type MCPToolDrift struct {
+	Tool     string   `json:"tool"`
+	Change   string   `json:"change"` // "removed", "added", "schema_changed" or "description_changed"
+	Breaking bool     `json:"breaking"`
+	Details  []string `json:"details,omitempty"`
+}
+
+// MCPToolDrifts is the list of tool changes of an MCP server
+type MCPToolDrifts []MCPToolDrift
+
+// Scan implements the sql.Scanner interface for database/sql
+func (d *MCPToolDrifts) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (d MCPToolDrifts) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// MCPServerDrift compares the tools an MCP server of an agent currently exposes with the accepted ones.
+// The accepted tools are recorded on the first check, and replaced when the drift is accepted.
+type MCPServerDrift struct {
+	AgentID    uuid.UUID      `json:"agent_id" db:"agent_id"`
+	ServerName string         `json:"server_name" db:"server_name"`
+	Endpoint   string         `json:"endpoint" db:"endpoint"`
+	Expected   MCPToolSchemas `json:"expected" db:"expected"`
+	Observed   MCPToolSchemas `json:"observed" db:"observed"`
+	Drifts     MCPToolDrifts  `json:"drifts" db:"drifts"`
+	Breaking   bool           `json:"breaking" db:"breaking"`
+	Error      *string        `json:"error,omitempty" db:"error"` // Why the last check failed, if it did
+	CheckedAt  time.Time      `json:"checked_at" db:"checked_at"`
+	AcceptedAt time.Time      `json:"accepted_at" db:"accepted_at"`
+}
+
+func scanJSON(value interface{}, target any) error {
+	if value == nil {
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, target)
+	}
+
+	return json.Unmarshal(bytes, target)
 }
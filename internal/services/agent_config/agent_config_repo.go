@@ -3,12 +3,17 @@ package agent_config
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrVersion0Modified is returned when version 0 was updated after the revision an update was based on
+var ErrVersion0Modified = errors.New("agent config version 0 was modified")
+
 // AgentConfigRepo handles database operations for agent configs
 type AgentConfigRepo struct {
 	db *sqlx.DB
@@ -38,19 +43,30 @@ func (r *AgentConfigRepo) Create(ctx context.Context, projectID uuid.UUID, req *
 	return &config, nil
 }
 
-// UpdateVersion0 updates version 0 in place (mutable)
-func (r *AgentConfigRepo) UpdateVersion0(ctx context.Context, agentID uuid.UUID, req *UpdateAgentConfigRequest) (*AgentConfig, error) {
+// UpdateVersion0 updates version 0 in place (mutable).
+// If expectedUpdatedAt is set, the update only applies if version 0 wasn't updated since, otherwise
+// ErrVersion0Modified is returned.
+func (r *AgentConfigRepo) UpdateVersion0(ctx context.Context, agentID uuid.UUID, req *UpdateAgentConfigRequest, expectedUpdatedAt *time.Time) (*AgentConfig, error) {
 	query := `
 		UPDATE agent_configs 
 		SET config = $1, updated_at = NOW()
-		WHERE agent_id = $2 AND version = 0
+		WHERE agent_id = $2 AND version = 0 AND deleted_at IS NULL
+		AND ($3::timestamptz IS NULL OR updated_at = $3)
 		RETURNING id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 	`
 
 	var config AgentConfig
-	err := r.db.GetContext(ctx, &config, query, req.Config, agentID)
+	err := r.db.GetContext(ctx, &config, query, req.Config, agentID, expectedUpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
+			if expectedUpdatedAt != nil {
+				var exists bool
+				if err := r.db.GetContext(ctx, &exists, `
+					SELECT EXISTS(SELECT 1 FROM agent_configs WHERE agent_id = $1 AND version = 0 AND deleted_at IS NULL)
+				`, agentID); err == nil && exists {
+					return nil, ErrVersion0Modified
+				}
+			}
 			return nil, fmt.Errorf("agent config version 0 not found")
 		}
 		return nil, fmt.Errorf("failed to update agent config: %w", err)
@@ -66,7 +82,7 @@ func (r *AgentConfigRepo) CreateVersion(ctx context.Context, agentID uuid.UUID)
 	err := r.db.GetContext(ctx, &version0, `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE agent_id = $1 AND version = 0
+		WHERE agent_id = $1 AND version = 0 AND deleted_at IS NULL
 	`, agentID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -107,7 +123,7 @@ func (r *AgentConfigRepo) GetByID(ctx context.Context, projectID, id uuid.UUID)
 	query := `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE id = $1 AND project_id = $2
+		WHERE id = $1 AND project_id = $2 AND deleted_at IS NULL
 	`
 
 	var config AgentConfig
@@ -127,7 +143,7 @@ func (r *AgentConfigRepo) GetByAgentIDAndVersion(ctx context.Context, projectID
 	query := `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE project_id = $1 AND agent_id = $2 AND version = $3
+		WHERE project_id = $1 AND agent_id = $2 AND version = $3 AND deleted_at IS NULL
 	`
 
 	var config AgentConfig
@@ -147,7 +163,7 @@ func (r *AgentConfigRepo) GetByNameAndVersion(ctx context.Context, projectID uui
 	query := `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE project_id = $1 AND name = $2 AND version = $3
+		WHERE project_id = $1 AND name = $2 AND version = $3 AND deleted_at IS NULL
 	`
 
 	var config AgentConfig
@@ -162,12 +178,32 @@ func (r *AgentConfigRepo) GetByNameAndVersion(ctx context.Context, projectID uui
 	return &config, nil
 }
 
+// GetLatestByAgentID retrieves version 0 (the mutable version) of an agent config by agent_id
+func (r *AgentConfigRepo) GetLatestByAgentID(ctx context.Context, agentID uuid.UUID) (*AgentConfig, error) {
+	query := `
+		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
+		FROM agent_configs
+		WHERE agent_id = $1 AND version = 0 AND deleted_at IS NULL
+	`
+
+	var config AgentConfig
+	err := r.db.GetContext(ctx, &config, query, agentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("agent config not found")
+		}
+		return nil, fmt.Errorf("failed to get agent config: %w", err)
+	}
+
+	return &config, nil
+}
+
 // GetLatestByName retrieves version 0 (the mutable version) of an agent config by name
 func (r *AgentConfigRepo) GetLatestByName(ctx context.Context, projectID uuid.UUID, name string) (*AgentConfig, error) {
 	query := `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE project_id = $1 AND name = $2 AND version = 0
+		WHERE project_id = $1 AND name = $2 AND version = 0 AND deleted_at IS NULL
 	`
 
 	var config AgentConfig
@@ -187,7 +223,7 @@ func (r *AgentConfigRepo) GetAgentIDByName(ctx context.Context, projectID uuid.U
 	var agentID uuid.UUID
 	err := r.db.GetContext(ctx, &agentID, `
 		SELECT agent_id FROM agent_configs 
-		WHERE project_id = $1 AND name = $2 AND version = 0
+		WHERE project_id = $1 AND name = $2 AND version = 0 AND deleted_at IS NULL
 		LIMIT 1
 	`, projectID, name)
 	if err != nil {
@@ -206,7 +242,7 @@ func (r *AgentConfigRepo) List(ctx context.Context, projectID uuid.UUID) ([]*Age
 		       COALESCE(MAX(version) FILTER (WHERE version > 0), 0) as latest_version,
 		       created_at, updated_at
 		FROM agent_configs
-		WHERE project_id = $1 AND version = 0
+		WHERE project_id = $1 AND version = 0 AND deleted_at IS NULL
 		GROUP BY id, agent_id, project_id, name, created_at, updated_at
 		ORDER BY name
 	`
@@ -225,7 +261,7 @@ func (r *AgentConfigRepo) ListVersions(ctx context.Context, projectID uuid.UUID,
 	query := `
 		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
 		FROM agent_configs
-		WHERE agent_id = $1 and project_id = $2
+		WHERE agent_id = $1 and project_id = $2 AND deleted_at IS NULL
 		ORDER BY version DESC
 	`
 
@@ -248,9 +284,9 @@ func (r *AgentConfigRepo) ListVersionsByName(ctx context.Context, projectID uuid
 	return r.ListVersions(ctx, projectID, agentID)
 }
 
-// Delete deletes all versions of an agent config by agent_id
+// Delete moves all versions of an agent config to the trash by agent_id
 func (r *AgentConfigRepo) Delete(ctx context.Context, agentID uuid.UUID) error {
-	query := `DELETE FROM agent_configs WHERE agent_id = $1`
+	query := `UPDATE agent_configs SET deleted_at = NOW() WHERE agent_id = $1 AND deleted_at IS NULL`
 	result, err := r.db.ExecContext(ctx, query, agentID)
 	if err != nil {
 		return fmt.Errorf("failed to delete agent config: %w", err)
@@ -268,7 +304,7 @@ func (r *AgentConfigRepo) Delete(ctx context.Context, agentID uuid.UUID) error {
 	return nil
 }
 
-// DeleteByName deletes all versions of an agent config by name
+// DeleteByName moves all versions of an agent config to the trash by name
 func (r *AgentConfigRepo) DeleteByName(ctx context.Context, projectID uuid.UUID, name string) error {
 	agentID, err := r.GetAgentIDByName(ctx, projectID, name)
 	if err != nil {
@@ -284,7 +320,7 @@ func (r *AgentConfigRepo) DeleteVersion(ctx context.Context, agentID uuid.UUID,
 		return fmt.Errorf("cannot delete version 0")
 	}
 
-	query := `DELETE FROM agent_configs WHERE agent_id = $1 AND version = $2`
+	query := `DELETE FROM agent_configs WHERE agent_id = $1 AND version = $2 AND deleted_at IS NULL`
 	result, err := r.db.ExecContext(ctx, query, agentID, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete agent config version: %w", err)
@@ -302,11 +338,103 @@ func (r *AgentConfigRepo) DeleteVersion(ctx context.Context, agentID uuid.UUID,
 	return nil
 }
 
+// ListDeleted retrieves the agent configs in the trash (version 0 summary) for a project
+func (r *AgentConfigRepo) ListDeleted(ctx context.Context, projectID uuid.UUID) ([]*DeletedAgentConfig, error) {
+	query := `
+		SELECT c.id, c.agent_id, c.project_id, c.name, c.deleted_at,
+		       (SELECT COALESCE(MAX(v.version), 0) FROM agent_configs v WHERE v.agent_id = c.agent_id) as latest_version
+		FROM agent_configs c
+		WHERE c.project_id = $1 AND c.version = 0 AND c.deleted_at IS NOT NULL
+		ORDER BY c.deleted_at DESC
+	`
+
+	configs := []*DeletedAgentConfig{}
+	err := r.db.SelectContext(ctx, &configs, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted agent configs: %w", err)
+	}
+
+	return configs, nil
+}
+
+// Restore moves all versions of an agent config out of the trash, if it was deleted after the given time
+func (r *AgentConfigRepo) Restore(ctx context.Context, projectID, agentID uuid.UUID, deletedAfter time.Time) (*AgentConfig, error) {
+	var config AgentConfig
+	err := r.db.GetContext(ctx, &config, `
+		SELECT id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
+		FROM agent_configs
+		WHERE project_id = $1 AND agent_id = $2 AND version = 0 AND deleted_at IS NOT NULL
+	`, projectID, agentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted agent config not found")
+		}
+		return nil, fmt.Errorf("failed to get deleted agent config: %w", err)
+	}
+
+	// A new agent may have been created with the same name in the meantime
+	exists, err := r.Exists(ctx, projectID, config.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("an agent config with name '%s' already exists", config.Name)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE agent_configs SET deleted_at = NULL
+		WHERE project_id = $1 AND agent_id = $2 AND deleted_at > $3
+	`, projectID, agentID, deletedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore agent config: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("agent config was deleted before the retention window and can't be restored")
+	}
+
+	return &config, nil
+}
+
+// Purge permanently deletes the agent configs that were moved to the trash before the given time
+func (r *AgentConfigRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM agent_config_aliases
+		WHERE agent_id IN (SELECT agent_id FROM agent_configs WHERE deleted_at < $1)
+	`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge agent config aliases: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM agent_configs WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge agent configs: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, tx.Commit()
+}
+
 // Exists checks if an agent config with the given name exists
 func (r *AgentConfigRepo) Exists(ctx context.Context, projectID uuid.UUID, name string) (bool, error) {
 	var count int
 	err := r.db.GetContext(ctx, &count, `
-		SELECT COUNT(*) FROM agent_configs WHERE project_id = $1 AND name = $2 AND version = 0
+		SELECT COUNT(*) FROM agent_configs WHERE project_id = $1 AND name = $2 AND version = 0 AND deleted_at IS NULL
 	`, projectID, name)
 	if err != nil {
 		return false, fmt.Errorf("failed to check agent config existence: %w", err)
@@ -512,3 +640,174 @@ func (r *AgentConfigRepo) DeleteAlias(ctx context.Context, projectID, id uuid.UU
 
 	return nil
 }
+
+// ListWithMCPServers retrieves the latest version of every agent that has MCP servers configured
+func (r *AgentConfigRepo) ListWithMCPServers(ctx context.Context) ([]*AgentConfig, error) {
+	query := `
+		SELECT DISTINCT ON (agent_id) id, agent_id, project_id, name, version, immutable, config, created_at, updated_at
+		FROM agent_configs
+		WHERE deleted_at IS NULL AND jsonb_array_length(COALESCE(config->'mcp_servers', '[]'::jsonb)) > 0
+		ORDER BY agent_id, version DESC
+	`
+
+	configs := []*AgentConfig{}
+	if err := r.db.SelectContext(ctx, &configs, query); err != nil {
+		return nil, fmt.Errorf("failed to list agent configs with MCP servers: %w", err)
+	}
+
+	return configs, nil
+}
+
+// ListMCPDrift retrieves the MCP tool drift of the servers of an agent
+func (r *AgentConfigRepo) ListMCPDrift(ctx context.Context, agentID uuid.UUID) ([]*MCPServerDrift, error) {
+	query := `
+		SELECT agent_id, server_name, endpoint, expected, observed, drifts, breaking, error, checked_at, accepted_at
+		FROM agent_mcp_drift
+		WHERE agent_id = $1
+		ORDER BY server_name
+	`
+
+	drifts := []*MCPServerDrift{}
+	if err := r.db.SelectContext(ctx, &drifts, query, agentID); err != nil {
+		return nil, fmt.Errorf("failed to list MCP drift: %w", err)
+	}
+
+	return drifts, nil
+}
+
+// SaveMCPDrift stores the result of a check of an MCP server, keeping when its tools were last accepted
+func (r *AgentConfigRepo) SaveMCPDrift(ctx context.Context, drift *MCPServerDrift) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO agent_mcp_drift (agent_id, server_name, endpoint, expected, observed, drifts, breaking, error, checked_at, accepted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (agent_id, server_name) DO UPDATE SET
+			endpoint = EXCLUDED.endpoint,
+			expected = EXCLUDED.expected,
+			observed = EXCLUDED.observed,
+			drifts = EXCLUDED.drifts,
+			breaking = EXCLUDED.breaking,
+			error = EXCLUDED.error,
+			checked_at = EXCLUDED.checked_at,
+			accepted_at = EXCLUDED.accepted_at
+	`, drift.AgentID, drift.ServerName, drift.Endpoint, drift.Expected, drift.Observed, drift.Drifts, drift.Breaking, drift.Error, drift.CheckedAt, drift.AcceptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save MCP drift: %w", err)
+	}
+
+	return nil
+}
+
+// AcceptMCPDrift accepts the tools last observed on an MCP server of an agent as the expected ones
+func (r *AgentConfigRepo) AcceptMCPDrift(ctx context.Context, agentID uuid.UUID, serverName string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE agent_mcp_drift
+		SET expected = observed, drifts = '[]', breaking = FALSE, accepted_at = NOW()
+		WHERE agent_id = $1 AND server_name = $2
+	`, agentID, serverName)
+	if err != nil {
+		return fmt.Errorf("failed to accept MCP drift: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("MCP server '%s' has not been checked yet", serverName)
+	}
+
+	return nil
+}
+
+// DeleteMCPDriftExcept drops the drift of MCP servers that are no longer configured for an agent
+func (r *AgentConfigRepo) DeleteMCPDriftExcept(ctx context.Context, agentID uuid.UUID, serverNames []string) error {
+	query, args, err := sqlx.In(`DELETE FROM agent_mcp_drift WHERE agent_id = ? AND server_name NOT IN (?)`, agentID, serverNames)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to delete MCP drift: %w", err)
+	}
+
+	return nil
+}
+
+// SaveNamespaceOverride creates or replaces the overrides of an agent for a namespace
+func (r *AgentConfigRepo) SaveNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string, overrides *ConfigOverrides) (*NamespaceOverride, error) {
+	query := `
+		INSERT INTO agent_namespace_overrides (project_id, agent_id, namespace, overrides)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (project_id, agent_id, namespace) DO UPDATE SET
+			overrides = EXCLUDED.overrides,
+			updated_at = NOW()
+		RETURNING project_id, agent_id, namespace, overrides, created_at, updated_at
+	`
+
+	var override NamespaceOverride
+	if err := r.db.GetContext(ctx, &override, query, projectID, agentID, namespace, *overrides); err != nil {
+		return nil, fmt.Errorf("failed to save namespace override: %w", err)
+	}
+
+	return &override, nil
+}
+
+// GetNamespaceOverride retrieves the overrides of an agent for a namespace
+func (r *AgentConfigRepo) GetNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string) (*NamespaceOverride, error) {
+	query := `
+		SELECT project_id, agent_id, namespace, overrides, created_at, updated_at
+		FROM agent_namespace_overrides
+		WHERE project_id = $1 AND agent_id = $2 AND namespace = $3
+	`
+
+	var override NamespaceOverride
+	err := r.db.GetContext(ctx, &override, query, projectID, agentID, namespace)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNamespaceOverrideNotFound
+		}
+		return nil, fmt.Errorf("failed to get namespace override: %w", err)
+	}
+
+	return &override, nil
+}
+
+// ListNamespaceOverrides retrieves the overrides of an agent for all namespaces
+func (r *AgentConfigRepo) ListNamespaceOverrides(ctx context.Context, projectID, agentID uuid.UUID) ([]*NamespaceOverride, error) {
+	query := `
+		SELECT project_id, agent_id, namespace, overrides, created_at, updated_at
+		FROM agent_namespace_overrides
+		WHERE project_id = $1 AND agent_id = $2
+		ORDER BY namespace
+	`
+
+	overrides := []*NamespaceOverride{}
+	if err := r.db.SelectContext(ctx, &overrides, query, projectID, agentID); err != nil {
+		return nil, fmt.Errorf("failed to list namespace overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// DeleteNamespaceOverride deletes the overrides of an agent for a namespace
+func (r *AgentConfigRepo) DeleteNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM agent_namespace_overrides
+		WHERE project_id = $1 AND agent_id = $2 AND namespace = $3
+	`, projectID, agentID, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to delete namespace override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNamespaceOverrideNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,605 @@
+package agent_config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// AgentConfigSchema is the canonical JSON schema of AgentConfigData, served to clients so that they can validate
+// configs before submitting them. ValidateConfig enforces the same rules, plus the ones that span several fields.
+const AgentConfigSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "AgentConfigData",
+  "type": "object",
+  "$defs": {
+    "model": {
+      "type": "object",
+      "required": ["provider_type", "model_id"],
+      "properties": {
+        "provider_type": {"type": "string", "enum": ["OpenAI", "Anthropic", "Gemini", "xAI", "Ollama", "vLLM", "TGI", "HuggingFace", "Replicate", "Bedrock", "Azure", "VertexAI", "Mistral", "Cohere", "TogetherAI", "Fireworks", "Perplexity", "OpenRouter", "OpenAICompatible"]},
+        "model_id": {"type": "string", "minLength": 1},
+        "parameters": {"type": "object"}
+      }
+    },
+    "prompt": {
+      "type": "object",
+      "properties": {
+        "raw_prompt": {"type": "string"},
+        "prompt_id": {"type": "string", "format": "uuid"},
+        "version": {"type": "integer", "minimum": 1}
+      },
+      "oneOf": [
+        {"required": ["raw_prompt"], "not": {"required": ["prompt_id"]}},
+        {"required": ["prompt_id"], "not": {"required": ["raw_prompt"]}}
+      ]
+    },
+    "toggle": {
+      "type": "object",
+      "properties": {"enabled": {"type": "boolean"}}
+    }
+  },
+  "properties": {
+    "max_iteration": {"type": "integer", "minimum": 1},
+    "runtime": {"type": "string", "enum": ["Local", "Restate", "Temporal"]},
+    "disable_argument_repair": {"type": "boolean"},
+    "provenance": {"type": "boolean"},
+    "provider_pinning": {"type": "string", "enum": ["", "provider", "model"]},
+    "model": {"$ref": "#/$defs/model"},
+    "prompt": {"$ref": "#/$defs/prompt"},
+    "schema": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": {"type": "string", "minLength": 1},
+        "description": {"type": "string"},
+        "schema": {"type": "object"},
+        "source_type": {"type": "string", "enum": ["manual", "go_struct", "typescript"]},
+        "source_content": {"type": "string"}
+      }
+    },
+    "mcp_servers": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["name", "endpoint"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "endpoint": {"type": "string", "format": "uri"},
+          "headers": {"type": "object", "additionalProperties": {"type": "string"}},
+          "tool_filters": {"type": "array", "items": {"type": "string"}},
+          "tools_requiring_human_approval": {"type": "array", "items": {"type": "string"}},
+          "block_on_breaking_drift": {"type": "boolean"},
+          "tool_prefix": {"type": "string", "pattern": "^[a-zA-Z0-9_-]*$"},
+          "tool_renames": {"type": "object", "additionalProperties": {"type": "string", "pattern": "^[a-zA-Z0-9_-]{1,64}$"}},
+          "timeout_seconds": {"type": "integer", "minimum": 0},
+          "max_retries": {"type": "integer", "minimum": 0, "maximum": 10},
+          "max_result_bytes": {"type": "integer", "minimum": 0}
+        }
+      }
+    },
+    "history": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "summarizer": {
+          "type": "object",
+          "required": ["type"],
+          "properties": {
+            "type": {"type": "string", "enum": ["llm", "sliding_window", "none"]},
+            "llm_token_threshold": {"type": "integer", "minimum": 1},
+            "llm_keep_recent_count": {"type": "integer", "minimum": 0},
+            "llm_summarizer_prompt": {"$ref": "#/$defs/prompt"},
+            "llm_summarizer_model": {"$ref": "#/$defs/model"},
+            "llm_shadow_mode": {"type": "boolean"},
+            "llm_max_input_tokens": {"type": "integer", "minimum": 1},
+            "sliding_window_keep_count": {"type": "integer", "minimum": 1}
+          }
+        },
+        "deduplicate_tool_outputs": {"type": "boolean"}
+      }
+    },
+    "translation": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "language": {"type": "string", "pattern": "^[a-z]{2}$"},
+        "model": {"$ref": "#/$defs/model"}
+      }
+    },
+    "response_policy": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "language": {"type": "string", "pattern": "^[a-z]{2}$"},
+        "tone": {"type": "string"},
+        "max_length": {"type": "integer", "minimum": 0},
+        "max_revisions": {"type": "integer", "minimum": 0, "maximum": 5},
+        "model": {"$ref": "#/$defs/model"}
+      }
+    },
+    "tools": {
+      "type": "object",
+      "properties": {
+        "image_generation": {"$ref": "#/$defs/toggle"},
+        "web_search": {"$ref": "#/$defs/toggle"},
+        "code_execution": {"$ref": "#/$defs/toggle"},
+        "sandbox": {
+          "type": "object",
+          "properties": {
+            "enabled": {"type": "boolean"},
+            "docker_image": {"type": "string", "minLength": 1}
+          }
+        }
+      }
+    },
+    "skills": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["name", "file_location"],
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "description": {"type": "string"},
+          "file_location": {"type": "string", "minLength": 1}
+        }
+      }
+    },
+    "sub_agents": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["agent_name", "description"],
+        "properties": {
+          "agent_name": {"type": "string", "minLength": 1},
+          "description": {"type": "string", "minLength": 1},
+          "tool_name": {"type": "string", "pattern": "^[a-zA-Z0-9_-]{1,64}$"},
+          "version": {"type": "integer", "minimum": 0}
+        }
+      }
+    },
+    "tool_quotas": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["tool", "scope", "limit"],
+        "properties": {
+          "tool": {"type": "string", "minLength": 1},
+          "scope": {"type": "string", "enum": ["conversation", "user"]},
+          "limit": {"type": "integer", "minimum": 1},
+          "period": {"type": "string", "enum": ["", "hour", "day", "month"]}
+        }
+      }
+    }
+  }
+}`
+
+var (
+	validRuntimes        = []string{"Local", "Restate", "Temporal"}
+	validSummarizerTypes = []string{"llm", "sliding_window", "none"}
+	validSchemaSources   = []string{"manual", "go_struct", "typescript"}
+	validQuotaScopes     = []string{"conversation", "user"}
+	validQuotaPeriods    = []string{"hour", "day", "month"}
+	validPinnings        = []string{"provider", "model"}
+)
+
+// FieldError is a validation error of a single field of the config, addressed by its JSON path
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a config
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "invalid agent config: " + strings.Join(msgs, "; ")
+}
+
+type configValidator struct {
+	errs []FieldError
+}
+
+func (v *configValidator) add(field string, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateConfig validates the config against the canonical schema and returns a *ValidationError listing
+// all invalid fields, or nil if the config is valid.
+func ValidateConfig(config *AgentConfigData) error {
+	v := &configValidator{}
+
+	if config.MaxIteration != nil && *config.MaxIteration < 1 {
+		v.add("max_iteration", "must be >= 1")
+	}
+
+	if config.Runtime != nil && !containsFold(validRuntimes, *config.Runtime) {
+		v.add("runtime", "must be one of %s", strings.Join(validRuntimes, ", "))
+	}
+
+	if config.Model != nil {
+		v.validateModel("model", config.Model)
+	}
+
+	if config.Prompt != nil {
+		v.validatePrompt("prompt", config.Prompt)
+	}
+
+	if config.Schema != nil {
+		if strings.TrimSpace(config.Schema.Name) == "" {
+			v.add("schema.name", "is required")
+		}
+		if config.Schema.Schema != nil && len(*config.Schema.Schema) > 0 {
+			var schema map[string]any
+			if err := json.Unmarshal(*config.Schema.Schema, &schema); err != nil {
+				v.add("schema.schema", "must be a JSON object")
+			}
+		}
+		if config.Schema.SourceType != nil && !containsFold(validSchemaSources, *config.Schema.SourceType) {
+			v.add("schema.source_type", "must be one of %s", strings.Join(validSchemaSources, ", "))
+		}
+	}
+
+	names := map[string]bool{}
+	for i, mcpServer := range config.MCPServers {
+		field := fmt.Sprintf("mcp_servers[%d]", i)
+		if mcpServer.Name == "" {
+			v.add(field+".name", "is required")
+		} else if names[mcpServer.Name] {
+			v.add(field+".name", "duplicate MCP server name %q", mcpServer.Name)
+		}
+		names[mcpServer.Name] = true
+
+		if mcpServer.Endpoint == "" {
+			v.add(field+".endpoint", "is required")
+		} else if u, err := url.Parse(mcpServer.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			v.add(field+".endpoint", "must be an absolute URL")
+		}
+	}
+
+	// Without summarizer, the history falls back to the default summarizer of the project, if any
+	if config.History != nil && config.History.Enabled && config.History.Summarizer != nil {
+		v.validateSummarizer("history.summarizer", config.History.Summarizer)
+	}
+
+	if config.Translation != nil && config.Translation.Enabled {
+		if config.Translation.Language != "" && !isLanguageCode(config.Translation.Language) {
+			v.add("translation.language", "must be an ISO 639-1 code, e.g. \"en\"")
+		}
+		if config.Translation.Model == nil {
+			v.add("translation.model", "is required when translation is enabled")
+		} else {
+			v.validateModel("translation.model", config.Translation.Model)
+		}
+	}
+
+	if policy := config.ResponsePolicy; policy != nil && policy.Enabled {
+		if policy.Language != "" && !isLanguageCode(policy.Language) {
+			v.add("response_policy.language", "must be an ISO 639-1 code, e.g. \"en\"")
+		}
+		if policy.MaxLength < 0 {
+			v.add("response_policy.max_length", "must not be negative")
+		}
+		if policy.MaxRevisions != nil && (*policy.MaxRevisions < 0 || *policy.MaxRevisions > 5) {
+			v.add("response_policy.max_revisions", "must be between 0 and 5")
+		}
+		if policy.Model != nil {
+			v.validateModel("response_policy.model", policy.Model)
+		} else if policy.Language != "" || policy.Tone != "" {
+			v.add("response_policy.model", "is required to check the language or the tone")
+		}
+	}
+
+	if config.Tools != nil && config.Tools.Sandbox != nil && config.Tools.Sandbox.DockerImage != nil && *config.Tools.Sandbox.DockerImage == "" {
+		v.add("tools.sandbox.docker_image", "must not be empty")
+	}
+
+	for i, skill := range config.Skills {
+		field := fmt.Sprintf("skills[%d]", i)
+		if skill.Name == "" {
+			v.add(field+".name", "is required")
+		}
+		if skill.FileLocation == "" {
+			v.add(field+".file_location", "is required")
+		}
+	}
+
+	toolNames := map[string]bool{}
+	for i, subAgent := range config.SubAgents {
+		field := fmt.Sprintf("sub_agents[%d]", i)
+		if subAgent.AgentName == "" {
+			v.add(field+".agent_name", "is required")
+		}
+		if subAgent.Description == "" {
+			v.add(field+".description", "is required")
+		}
+		if subAgent.ToolName != "" && !isToolName(subAgent.ToolName) {
+			v.add(field+".tool_name", "must be 1 to 64 letters, digits, underscores or dashes")
+		}
+		if subAgent.Version != nil && *subAgent.Version < 0 {
+			v.add(field+".version", "must be >= 0")
+		}
+
+		toolName := SubAgentToolName(subAgent)
+		if toolNames[toolName] {
+			v.add(field+".tool_name", "duplicate sub-agent tool name %q", toolName)
+		}
+		toolNames[toolName] = true
+	}
+
+	if config.ProviderPinning != "" && !containsFold(validPinnings, config.ProviderPinning) {
+		v.add("provider_pinning", "must be one of %s", strings.Join(validPinnings, ", "))
+	}
+
+	v.validateToolQuotas("tool_quotas", config.ToolQuotas)
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+
+	return nil
+}
+
+func (v *configValidator) validateModel(field string, model *ModelConfig) {
+	if model.ProviderType == "" {
+		v.add(field+".provider_type", "is required")
+	} else if _, ok := canonicalProvider(model.ProviderType); !ok {
+		v.add(field+".provider_type", "unknown provider %q", model.ProviderType)
+	}
+
+	if model.ModelID == "" {
+		v.add(field+".model_id", "is required")
+	}
+}
+
+func (v *configValidator) validatePrompt(field string, prompt *PromptConfig) {
+	hasRawPrompt := prompt.RawPrompt != nil && *prompt.RawPrompt != ""
+	hasPromptID := prompt.PromptID != nil
+
+	if hasRawPrompt && hasPromptID {
+		v.add(field, "cannot have both raw_prompt and prompt_id")
+	}
+	if !hasRawPrompt && !hasPromptID {
+		v.add(field, "must have either raw_prompt or prompt_id")
+	}
+	if prompt.Version != nil && *prompt.Version < 1 {
+		v.add(field+".version", "must be >= 1")
+	}
+}
+
+func (v *configValidator) validateToolQuotas(field string, quotas []ToolQuotaConfig) {
+	for i, quota := range quotas {
+		field := fmt.Sprintf("%s[%d]", field, i)
+		if quota.Tool == "" {
+			v.add(field+".tool", "is required")
+		}
+		if !containsFold(validQuotaScopes, quota.Scope) {
+			v.add(field+".scope", "must be one of %s", strings.Join(validQuotaScopes, ", "))
+		}
+		if quota.Limit < 1 {
+			v.add(field+".limit", "must be >= 1")
+		}
+		if quota.Period != "" && !containsFold(validQuotaPeriods, quota.Period) {
+			v.add(field+".period", "must be one of %s", strings.Join(validQuotaPeriods, ", "))
+		}
+	}
+}
+
+func (v *configValidator) validateSummarizer(field string, summarizer *SummarizerConfig) {
+	if summarizer == nil {
+		v.add(field, "is required when history is enabled")
+		return
+	}
+
+	switch summarizer.Type {
+	case "":
+		v.add(field+".type", "is required")
+	case "llm":
+		if summarizer.LLMTokenThreshold == nil || *summarizer.LLMTokenThreshold <= 0 {
+			v.add(field+".llm_token_threshold", "is required and must be > 0 for llm type")
+		}
+		if summarizer.LLMKeepRecentCount == nil || *summarizer.LLMKeepRecentCount < 0 {
+			v.add(field+".llm_keep_recent_count", "is required and must be >= 0 for llm type")
+		}
+		if summarizer.LLMMaxInputTokens != nil && *summarizer.LLMMaxInputTokens <= 0 {
+			v.add(field+".llm_max_input_tokens", "must be > 0")
+		}
+		if summarizer.LLMSummarizerPrompt == nil {
+			v.add(field+".llm_summarizer_prompt", "is required for llm type")
+		} else {
+			v.validatePrompt(field+".llm_summarizer_prompt", summarizer.LLMSummarizerPrompt)
+		}
+		if summarizer.LLMSummarizerModel == nil {
+			v.add(field+".llm_summarizer_model", "is required for llm type")
+		} else {
+			v.validateModel(field+".llm_summarizer_model", summarizer.LLMSummarizerModel)
+		}
+	case "sliding_window":
+		if summarizer.SlidingWindowKeepCount == nil || *summarizer.SlidingWindowKeepCount <= 0 {
+			v.add(field+".sliding_window_keep_count", "is required and must be > 0 for sliding_window type")
+		}
+	case "none":
+		// No additional validation needed
+	default:
+		v.add(field+".type", "must be one of %s", strings.Join(validSummarizerTypes, ", "))
+	}
+}
+
+// NormalizeConfig rewrites the config into its canonical form: enum values in their canonical casing,
+// surrounding whitespace trimmed, and empty optional values dropped.
+func NormalizeConfig(config *AgentConfigData) {
+	if config.Runtime != nil {
+		runtime := strings.TrimSpace(*config.Runtime)
+		if runtime == "" {
+			config.Runtime = nil
+		} else {
+			config.Runtime = &runtime
+			for _, valid := range validRuntimes {
+				if strings.EqualFold(runtime, valid) {
+					*config.Runtime = valid
+				}
+			}
+		}
+	}
+
+	normalizeModel(config.Model)
+	normalizePrompt(config.Prompt)
+
+	if config.Schema != nil {
+		config.Schema.Name = strings.TrimSpace(config.Schema.Name)
+		if config.Schema.SourceType != nil {
+			sourceType := strings.ToLower(strings.TrimSpace(*config.Schema.SourceType))
+			config.Schema.SourceType = &sourceType
+		}
+	}
+
+	for i := range config.MCPServers {
+		config.MCPServers[i].Name = strings.TrimSpace(config.MCPServers[i].Name)
+		config.MCPServers[i].Endpoint = strings.TrimSpace(config.MCPServers[i].Endpoint)
+	}
+
+	if config.History != nil && config.History.Summarizer != nil {
+		summarizer := config.History.Summarizer
+		summarizer.Type = strings.ToLower(strings.TrimSpace(summarizer.Type))
+		normalizeModel(summarizer.LLMSummarizerModel)
+		normalizePrompt(summarizer.LLMSummarizerPrompt)
+	}
+
+	if config.Translation != nil {
+		config.Translation.Language = strings.ToLower(strings.TrimSpace(config.Translation.Language))
+		normalizeModel(config.Translation.Model)
+	}
+
+	if config.ResponsePolicy != nil {
+		config.ResponsePolicy.Language = strings.ToLower(strings.TrimSpace(config.ResponsePolicy.Language))
+		config.ResponsePolicy.Tone = strings.TrimSpace(config.ResponsePolicy.Tone)
+		normalizeModel(config.ResponsePolicy.Model)
+	}
+
+	for i := range config.SubAgents {
+		config.SubAgents[i].AgentName = strings.TrimSpace(config.SubAgents[i].AgentName)
+		config.SubAgents[i].Description = strings.TrimSpace(config.SubAgents[i].Description)
+		config.SubAgents[i].ToolName = strings.TrimSpace(config.SubAgents[i].ToolName)
+	}
+
+	config.ProviderPinning = strings.ToLower(strings.TrimSpace(config.ProviderPinning))
+
+	normalizeToolQuotas(config.ToolQuotas)
+
+	if config.Tools != nil && config.Tools.Sandbox != nil && config.Tools.Sandbox.DockerImage != nil {
+		image := strings.TrimSpace(*config.Tools.Sandbox.DockerImage)
+		if image == "" {
+			config.Tools.Sandbox.DockerImage = nil
+		} else {
+			config.Tools.Sandbox.DockerImage = &image
+		}
+	}
+}
+
+func normalizeToolQuotas(quotas []ToolQuotaConfig) {
+	for i := range quotas {
+		quotas[i].Tool = strings.TrimSpace(quotas[i].Tool)
+		quotas[i].Scope = strings.ToLower(strings.TrimSpace(quotas[i].Scope))
+		quotas[i].Period = strings.ToLower(strings.TrimSpace(quotas[i].Period))
+	}
+}
+
+func normalizeModel(model *ModelConfig) {
+	if model == nil {
+		return
+	}
+
+	model.ModelID = strings.TrimSpace(model.ModelID)
+	if provider, ok := canonicalProvider(model.ProviderType); ok {
+		model.ProviderType = string(provider)
+	}
+}
+
+func normalizePrompt(prompt *PromptConfig) {
+	if prompt == nil {
+		return
+	}
+
+	// A blank raw prompt next to a prompt reference is a leftover of switching the prompt type
+	if prompt.RawPrompt != nil && strings.TrimSpace(*prompt.RawPrompt) == "" && prompt.PromptID != nil {
+		prompt.RawPrompt = nil
+	}
+}
+
+// isLanguageCode reports whether the language is a two letter ISO 639-1 code
+func isLanguageCode(language string) bool {
+	if len(language) != 2 {
+		return false
+	}
+	for _, r := range language {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// SubAgentToolName returns the name of the tool calling the sub-agent, the agent name with the characters that
+// tool names don't allow replaced with underscores when no tool name is set
+func SubAgentToolName(subAgent SubAgentConfig) string {
+	if subAgent.ToolName != "" {
+		return subAgent.ToolName
+	}
+
+	name := []rune(subAgent.AgentName)
+	for i, r := range name {
+		if !isToolNameRune(r) {
+			name[i] = '_'
+		}
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return string(name)
+}
+
+// isToolName reports whether the name is accepted as a tool name by all providers
+func isToolName(name string) bool {
+	if len(name) == 0 || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if !isToolNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isToolNameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-'
+}
+
+// canonicalProvider matches the provider name case-insensitively
+func canonicalProvider(name string) (llm.ProviderName, bool) {
+	name = strings.TrimSpace(name)
+	for _, provider := range llm.GetAllProviderNames() {
+		if strings.EqualFold(name, string(provider)) {
+			return provider, true
+		}
+	}
+	return "", false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+	return false
+}
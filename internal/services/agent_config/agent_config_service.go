@@ -2,9 +2,10 @@ package agent_config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
-	"github.com/curaious/uno/internal/utils"
 	"github.com/google/uuid"
 )
 
@@ -77,9 +78,26 @@ func (s *AgentConfigService) UpdateVersion0(ctx context.Context, agentID uuid.UU
 		return nil, err
 	}
 
+	// "*" matches any revision
+	var expectedUpdatedAt *time.Time
+	if req.IfMatch != "" && req.IfMatch != "*" {
+		updatedAt, err := ParseETag(req.IfMatch)
+		if err != nil {
+			return nil, err
+		}
+		expectedUpdatedAt = &updatedAt
+	}
+
 	// Update version 0
-	config, err := s.repo.UpdateVersion0(ctx, agentID, req)
+	config, err := s.repo.UpdateVersion0(ctx, agentID, req, expectedUpdatedAt)
 	if err != nil {
+		if errors.Is(err, ErrVersion0Modified) {
+			latest, getErr := s.repo.GetLatestByAgentID(ctx, agentID)
+			if getErr != nil {
+				return nil, fmt.Errorf("failed to get latest agent config: %w", getErr)
+			}
+			return nil, &ConflictError{Latest: latest}
+		}
 		return nil, fmt.Errorf("failed to update agent config: %w", err)
 	}
 
@@ -174,15 +192,12 @@ func (s *AgentConfigService) GetAgentVersionByAlias(ctx context.Context, project
 		return -1, err
 	}
 
-	if alias.Version2 == nil {
-		return alias.Version1, nil
-	}
+	return alias.PickVersion(), nil
+}
 
-	idx := utils.WeightedRandomIndex([]int{100 - *alias.Weight, *alias.Weight})
-	if idx == 0 {
-		return alias.Version1, nil
-	}
-	return *alias.Version2, nil
+// GetAliasByAgentID retrieves an alias by agent ID and alias name
+func (s *AgentConfigService) GetAliasByAgentID(ctx context.Context, projectID, agentID uuid.UUID, aliasName string) (*AgentConfigAlias, error) {
+	return s.repo.GetAliasByName(ctx, projectID, agentID, aliasName)
 }
 
 // List retrieves all agent configs for a project
@@ -215,7 +230,7 @@ func (s *AgentConfigService) ListVersionsByName(ctx context.Context, projectID u
 	return configs, nil
 }
 
-// Delete deletes all versions of an agent config by agent_id
+// Delete moves all versions of an agent config to the trash by agent_id
 func (s *AgentConfigService) Delete(ctx context.Context, agentID uuid.UUID) error {
 	err := s.repo.Delete(ctx, agentID)
 	if err != nil {
@@ -225,7 +240,7 @@ func (s *AgentConfigService) Delete(ctx context.Context, agentID uuid.UUID) erro
 	return nil
 }
 
-// DeleteByName deletes all versions of an agent config by name
+// DeleteByName moves all versions of an agent config to the trash by name
 func (s *AgentConfigService) DeleteByName(ctx context.Context, projectID uuid.UUID, name string) error {
 	err := s.repo.DeleteByName(ctx, projectID, name)
 	if err != nil {
@@ -235,6 +250,30 @@ func (s *AgentConfigService) DeleteByName(ctx context.Context, projectID uuid.UU
 	return nil
 }
 
+// ListDeleted lists the agent configs in the trash, with the time they will be purged at
+func (s *AgentConfigService) ListDeleted(ctx context.Context, projectID uuid.UUID, retention time.Duration) ([]*DeletedAgentConfig, error) {
+	configs, err := s.repo.ListDeleted(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		config.PurgeAt = config.DeletedAt.Add(retention)
+	}
+
+	return configs, nil
+}
+
+// Restore moves an agent config out of the trash, if it was deleted within the retention window
+func (s *AgentConfigService) Restore(ctx context.Context, projectID, agentID uuid.UUID, retention time.Duration) (*AgentConfig, error) {
+	return s.repo.Restore(ctx, projectID, agentID, time.Now().Add(-retention))
+}
+
+// PurgeDeleted permanently deletes the agent configs that have been in the trash for longer than the retention
+func (s *AgentConfigService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.Purge(ctx, time.Now().Add(-retention))
+}
+
 // DeleteVersion deletes a specific version of an agent config
 func (s *AgentConfigService) DeleteVersion(ctx context.Context, agentID uuid.UUID, version int) error {
 	// Prevent deletion of version 0
@@ -250,78 +289,10 @@ func (s *AgentConfigService) DeleteVersion(ctx context.Context, agentID uuid.UUI
 	return nil
 }
 
-// validateConfig validates the agent configuration
+// validateConfig normalizes the agent configuration and validates it against the canonical schema
 func (s *AgentConfigService) validateConfig(config *AgentConfigData) error {
-	// Validate model config if provided
-	if config.Model != nil {
-		if config.Model.ProviderType == "" {
-			return fmt.Errorf("model provider_type is required")
-		}
-		if config.Model.ModelID == "" {
-			return fmt.Errorf("model model_id is required")
-		}
-	}
-
-	// Validate prompt config if provided
-	if config.Prompt != nil {
-		hasRawPrompt := config.Prompt.RawPrompt != nil && *config.Prompt.RawPrompt != ""
-		hasPromptID := config.Prompt.PromptID != nil
-
-		if hasRawPrompt && hasPromptID {
-			return fmt.Errorf("prompt cannot have both raw_prompt and prompt_id")
-		}
-		if !hasRawPrompt && !hasPromptID {
-			return fmt.Errorf("prompt must have either raw_prompt or prompt_id")
-		}
-	}
-
-	// Validate MCP server configs if provided
-	for i, mcpServer := range config.MCPServers {
-		if mcpServer.Name == "" {
-			return fmt.Errorf("mcp_servers[%d].name is required", i)
-		}
-		if mcpServer.Endpoint == "" {
-			return fmt.Errorf("mcp_servers[%d].endpoint is required", i)
-		}
-	}
-
-	// Validate history config if provided
-	if config.History != nil && config.History.Enabled {
-		if config.History.Summarizer == nil {
-			return fmt.Errorf("history.summarizer is required when history is enabled")
-		}
-
-		summarizer := config.History.Summarizer
-		if summarizer.Type == "" {
-			return fmt.Errorf("history.summarizer.type is required")
-		}
-
-		switch summarizer.Type {
-		case "llm":
-			if summarizer.LLMTokenThreshold == nil || *summarizer.LLMTokenThreshold <= 0 {
-				return fmt.Errorf("history.summarizer.llm_token_threshold is required and must be > 0 for llm type")
-			}
-			if summarizer.LLMKeepRecentCount == nil || *summarizer.LLMKeepRecentCount < 0 {
-				return fmt.Errorf("history.summarizer.llm_keep_recent_count is required and must be >= 0 for llm type")
-			}
-			if summarizer.LLMSummarizerPrompt == nil {
-				return fmt.Errorf("history.summarizer.llm_summarizer_prompt is required for llm type")
-			}
-			if summarizer.LLMSummarizerModel == nil {
-				return fmt.Errorf("history.summarizer.llm_summarizer_model is required for llm type")
-			}
-		case "sliding_window":
-			if summarizer.SlidingWindowKeepCount == nil || *summarizer.SlidingWindowKeepCount <= 0 {
-				return fmt.Errorf("history.summarizer.sliding_window_keep_count is required and must be > 0 for sliding_window type")
-			}
-		case "none":
-			// No additional validation needed
-		default:
-			return fmt.Errorf("history.summarizer.type must be 'llm', 'sliding_window', or 'none'")
-		}
-	}
-
-	return nil
+	NormalizeConfig(config)
+	return ValidateConfig(config)
 }
 
 // CreateAlias creates a new alias for an agent config
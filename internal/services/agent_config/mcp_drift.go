@@ -0,0 +1,348 @@
+package agent_config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/mcpclient"
+	"github.com/google/uuid"
+)
+
+// mcpDriftCheckTimeout bounds listing the tools of a single MCP server
+const mcpDriftCheckTimeout = 30 * time.Second
+
+// BreakingMCPDriftError is returned for runs of agents with an MCP server that blocks on breaking drift
+type BreakingMCPDriftError struct {
+	ServerName string
+	Drifts     MCPToolDrifts
+}
+
+func (e *BreakingMCPDriftError) Error() string {
+	tools := []string{}
+	for _, d := range e.Drifts {
+		if d.Breaking {
+			tools = append(tools, d.Tool)
+		}
+	}
+	return fmt.Sprintf("tools of MCP server '%s' have breaking changes (%s), accept the changes to run the agent", e.ServerName, strings.Join(tools, ", "))
+}
+
+// CheckAllMCPDrift checks the MCP servers of the latest version of every agent
+func (s *AgentConfigService) CheckAllMCPDrift(ctx context.Context) error {
+	configs, err := s.repo.ListWithMCPServers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		drifts, err := s.CheckMCPDrift(ctx, config)
+		if err != nil {
+			slog.Error("Failed to check MCP drift", slog.String("agent_id", config.AgentID.String()), slog.Any("error", err))
+			continue
+		}
+
+		for _, drift := range drifts {
+			if len(drift.Drifts) > 0 {
+				slog.Warn("MCP tools drifted", slog.String("agent_id", config.AgentID.String()), slog.String("server", drift.ServerName),
+					slog.Int("changes", len(drift.Drifts)), slog.Bool("breaking", drift.Breaking))
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckMCPDrift lists the tools of the MCP servers of the config and compares them with the accepted tools.
+// The tools seen on the first check of a server, or after its endpoint changed, are accepted as they are.
+func (s *AgentConfigService) CheckMCPDrift(ctx context.Context, config *AgentConfig) ([]*MCPServerDrift, error) {
+	existing, err := s.repo.ListMCPDrift(ctx, config.AgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := map[string]*MCPServerDrift{}
+	for _, d := range existing {
+		previous[d.ServerName] = d
+	}
+
+	out := []*MCPServerDrift{}
+	names := []string{}
+	for _, server := range config.Config.MCPServers {
+		names = append(names, server.Name)
+		now := time.Now()
+
+		drift := &MCPServerDrift{
+			AgentID:    config.AgentID,
+			ServerName: server.Name,
+			Endpoint:   server.Endpoint,
+			Expected:   MCPToolSchemas{},
+			Observed:   MCPToolSchemas{},
+			Drifts:     MCPToolDrifts{},
+			CheckedAt:  now,
+			AcceptedAt: now,
+		}
+
+		prev, ok := previous[server.Name]
+		if ok && prev.Endpoint == server.Endpoint {
+			drift.Expected = prev.Expected
+			drift.AcceptedAt = prev.AcceptedAt
+		}
+
+		observed, err := fetchMCPTools(ctx, &server)
+		if err != nil {
+			// Keep the outcome of the last successful check, the server may only be briefly unavailable
+			drift.Error = utils.Ptr(err.Error())
+			if ok && prev.Endpoint == server.Endpoint {
+				drift.Observed = prev.Observed
+				drift.Drifts = prev.Drifts
+				drift.Breaking = prev.Breaking
+			}
+		} else {
+			drift.Observed = observed
+			if !ok || prev.Endpoint != server.Endpoint {
+				drift.Expected = observed
+			}
+			drift.Drifts = diffMCPTools(drift.Expected, drift.Observed)
+			for _, d := range drift.Drifts {
+				drift.Breaking = drift.Breaking || d.Breaking
+			}
+		}
+
+		if err := s.repo.SaveMCPDrift(ctx, drift); err != nil {
+			return nil, err
+		}
+		out = append(out, drift)
+	}
+
+	if len(names) > 0 {
+		if err := s.repo.DeleteMCPDriftExcept(ctx, config.AgentID, names); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// CheckMCPDriftByAgentID checks the MCP servers of the latest version of an agent now
+func (s *AgentConfigService) CheckMCPDriftByAgentID(ctx context.Context, projectID, agentID uuid.UUID) ([]*MCPServerDrift, error) {
+	versions, err := s.repo.ListVersions(ctx, projectID, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("agent config not found")
+	}
+
+	latest := versions[0]
+	for _, v := range versions {
+		if v.Version > latest.Version {
+			latest = v
+		}
+	}
+
+	return s.CheckMCPDrift(ctx, latest)
+}
+
+// GetMCPDrift retrieves the result of the last check of the MCP servers of an agent
+func (s *AgentConfigService) GetMCPDrift(ctx context.Context, agentID uuid.UUID) ([]*MCPServerDrift, error) {
+	return s.repo.ListMCPDrift(ctx, agentID)
+}
+
+// AcceptMCPDrift accepts the tools last observed on an MCP server of an agent, clearing its drift
+func (s *AgentConfigService) AcceptMCPDrift(ctx context.Context, agentID uuid.UUID, serverName string) error {
+	return s.repo.AcceptMCPDrift(ctx, agentID, serverName)
+}
+
+// CheckRunnable returns a *BreakingMCPDriftError if an MCP server of the config blocks runs on breaking drift
+// and its tools have breaking changes
+func (s *AgentConfigService) CheckRunnable(ctx context.Context, config *AgentConfig) error {
+	blocking := map[string]bool{}
+	for _, server := range config.Config.MCPServers {
+		if server.BlockOnBreakingDrift {
+			blocking[server.Name] = true
+		}
+	}
+
+	if len(blocking) == 0 {
+		return nil
+	}
+
+	drifts, err := s.repo.ListMCPDrift(ctx, config.AgentID)
+	if err != nil {
+		return err
+	}
+
+	for _, drift := range drifts {
+		if blocking[drift.ServerName] && drift.Breaking {
+			return &BreakingMCPDriftError{ServerName: drift.ServerName, Drifts: drift.Drifts}
+		}
+	}
+
+	return nil
+}
+
+// fetchMCPTools lists the tools the server exposes to the agent, honoring its tool filter
+func fetchMCPTools(ctx context.Context, server *MCPServerConfig) (MCPToolSchemas, error) {
+	ctx, cancel := context.WithTimeout(ctx, mcpDriftCheckTimeout)
+	defer cancel()
+
+	srv, err := mcpclient.NewSSEClient(ctx, server.Endpoint, mcpclient.WithHeaders(server.Headers))
+	if err != nil {
+		return nil, err
+	}
+
+	// Headers can only be resolved from the environment here, there is no request
+	cli, err := srv.GetClient(ctx, map[string]any{"Env": utils.EnvironmentVariables()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools of MCP server '%s': %w", server.Name, err)
+	}
+	defer cli.Client.Close()
+
+	tools := MCPToolSchemas{}
+	for _, tool := range cli.Tools {
+		if len(server.ToolFilters) > 0 && !slices.Contains(server.ToolFilters, tool.Name) {
+			continue
+		}
+
+		inputSchema := map[string]any{}
+		if buf, err := json.Marshal(tool.InputSchema); err == nil {
+			_ = json.Unmarshal(buf, &inputSchema)
+		}
+
+		tools[tool.Name] = MCPToolSchema{Description: tool.Description, InputSchema: inputSchema}
+	}
+
+	return tools, nil
+}
+
+// diffMCPTools compares the observed tools with the expected ones. Removed tools and incompatible input
+// schemas are breaking, new tools, new optional parameters and changed descriptions are not.
+func diffMCPTools(expected, observed MCPToolSchemas) MCPToolDrifts {
+	drifts := MCPToolDrifts{}
+
+	for _, name := range sortedKeys(expected) {
+		want := expected[name]
+		got, ok := observed[name]
+		if !ok {
+			drifts = append(drifts, MCPToolDrift{Tool: name, Change: "removed", Breaking: true})
+			continue
+		}
+
+		var details []string
+		breaking := diffSchema("", want.InputSchema, got.InputSchema, &details)
+		if len(details) > 0 {
+			drifts = append(drifts, MCPToolDrift{Tool: name, Change: "schema_changed", Breaking: breaking, Details: details})
+		} else if want.Description != got.Description {
+			drifts = append(drifts, MCPToolDrift{Tool: name, Change: "description_changed"})
+		}
+	}
+
+	for _, name := range sortedKeys(observed) {
+		if _, ok := expected[name]; !ok {
+			drifts = append(drifts, MCPToolDrift{Tool: name, Change: "added"})
+		}
+	}
+
+	return drifts
+}
+
+// diffSchema compares the properties of two object schemas, recursing into nested objects and arrays.
+// It appends a description of every change to details and returns whether any change is breaking.
+func diffSchema(path string, want, got map[string]any, details *[]string) bool {
+	breaking := false
+	wantProps := schemaMap(want["properties"])
+	gotProps := schemaMap(got["properties"])
+	wantRequired := schemaRequired(want)
+	gotRequired := schemaRequired(got)
+
+	for _, name := range sortedKeys(wantProps) {
+		field := path + name
+		wantProp := schemaMap(wantProps[name])
+		gotPropRaw, ok := gotProps[name]
+		if !ok {
+			*details = append(*details, fmt.Sprintf("parameter '%s' was removed", field))
+			breaking = true
+			continue
+		}
+		gotProp := schemaMap(gotPropRaw)
+
+		wantType, gotType := fmt.Sprint(wantProp["type"]), fmt.Sprint(gotProp["type"])
+		if wantType != gotType {
+			*details = append(*details, fmt.Sprintf("parameter '%s' changed type from %s to %s", field, wantType, gotType))
+			breaking = true
+			continue
+		}
+
+		if !wantRequired[name] && gotRequired[name] {
+			*details = append(*details, fmt.Sprintf("parameter '%s' became required", field))
+			breaking = true
+		}
+
+		switch wantType {
+		case "object":
+			breaking = diffSchema(field+".", wantProp, gotProp, details) || breaking
+		case "array":
+			wantItems, gotItems := schemaMap(wantProp["items"]), schemaMap(gotProp["items"])
+			if wi, gi := fmt.Sprint(wantItems["type"]), fmt.Sprint(gotItems["type"]); wi != gi {
+				*details = append(*details, fmt.Sprintf("items of parameter '%s' changed type from %s to %s", field, wi, gi))
+				breaking = true
+			} else if wi == "object" {
+				breaking = diffSchema(field+"[].", wantItems, gotItems, details) || breaking
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(gotProps) {
+		if _, ok := wantProps[name]; ok {
+			continue
+		}
+		if gotRequired[name] {
+			*details = append(*details, fmt.Sprintf("required parameter '%s' was added", path+name))
+			breaking = true
+		} else {
+			*details = append(*details, fmt.Sprintf("optional parameter '%s' was added", path+name))
+		}
+	}
+
+	return breaking
+}
+
+func schemaMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
+
+func schemaRequired(schema map[string]any) map[string]bool {
+	out := map[string]bool{}
+	switch required := schema["required"].(type) {
+	case []any:
+		for _, r := range required {
+			out[fmt.Sprint(r)] = true
+		}
+	case []string:
+		for _, r := range required {
+			out[r] = true
+		}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+package agent_config
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/google/uuid"
+)
+
+var ErrNamespaceOverrideNotFound = errors.New("namespace override not found")
+
+// ConfigOverrides are the parts of the config of an agent that can be overridden for a namespace, e.g. a larger
+// model for the "premium" namespace. Unset fields keep the value of the agent config.
+type ConfigOverrides struct {
+	Model       *ModelConfig      `json:"model,omitempty"`       // Replaces the model, along with its parameters
+	Temperature *float64          `json:"temperature,omitempty"` // Set on top of the parameters of the model
+	Tools       *ToolConfig       `json:"tools,omitempty"`       // Replaces the provider tools
+	MCPServers  []string          `json:"mcp_servers,omitempty"` // Names of the MCP servers kept, the others are dropped
+	Summarizer  *SummarizerConfig `json:"summarizer,omitempty"`  // Replaces the summarizer of the history
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (o *ConfigOverrides) Scan(value interface{}) error {
+	if value == nil {
+		*o = ConfigOverrides{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ConfigOverrides", value)
+	}
+
+	return json.Unmarshal(bytes, o)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (o ConfigOverrides) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// NamespaceOverride overrides the config of an agent for the conversations of a namespace, whatever the version
+// of the agent
+type NamespaceOverride struct {
+	ProjectID uuid.UUID       `json:"project_id" db:"project_id"`
+	AgentID   uuid.UUID       `json:"agent_id" db:"agent_id"`
+	Namespace string          `json:"namespace" db:"namespace"`
+	Overrides ConfigOverrides `json:"overrides" db:"overrides"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Apply returns a copy of the config with the overrides applied, the config itself is left untouched as it may
+// be shared through the config cache
+func (o *ConfigOverrides) Apply(config *AgentConfig) (*AgentConfig, error) {
+	buf, err := json.Marshal(config.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := *config
+	effective.Config = AgentConfigData{}
+	if err := json.Unmarshal(buf, &effective.Config); err != nil {
+		return nil, err
+	}
+
+	if o.Model != nil {
+		model := *o.Model
+		model.Parameters = maps.Clone(o.Model.Parameters)
+		effective.Config.Model = &model
+	}
+
+	if o.Temperature != nil && effective.Config.Model != nil {
+		if effective.Config.Model.Parameters == nil {
+			effective.Config.Model.Parameters = map[string]interface{}{}
+		}
+		effective.Config.Model.Parameters["temperature"] = *o.Temperature
+	}
+
+	if o.Tools != nil {
+		effective.Config.Tools = o.Tools
+	}
+
+	if len(o.MCPServers) > 0 {
+		effective.Config.MCPServers = slices.DeleteFunc(effective.Config.MCPServers, func(server MCPServerConfig) bool {
+			return !slices.Contains(o.MCPServers, server.Name)
+		})
+	}
+
+	if o.Summarizer != nil && effective.Config.History != nil {
+		effective.Config.History.Summarizer = o.Summarizer
+	}
+
+	return &effective, nil
+}
+
+// validateOverrides validates the overrides with the rules of the fields of the agent config they replace
+func validateOverrides(overrides *ConfigOverrides) error {
+	v := &configValidator{}
+
+	if overrides.Model != nil {
+		v.validateModel("model", overrides.Model)
+	}
+
+	if overrides.Temperature != nil && (*overrides.Temperature < 0 || *overrides.Temperature > 2) {
+		v.add("temperature", "must be between 0 and 2")
+	}
+
+	if overrides.Tools != nil && overrides.Tools.Sandbox != nil && overrides.Tools.Sandbox.DockerImage != nil && *overrides.Tools.Sandbox.DockerImage == "" {
+		v.add("tools.sandbox.docker_image", "must not be empty")
+	}
+
+	if overrides.Summarizer != nil {
+		v.validateSummarizer("summarizer", overrides.Summarizer)
+	}
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+
+	return nil
+}
+
+// SetNamespaceOverride creates or replaces the overrides of the config of an agent for a namespace
+func (s *AgentConfigService) SetNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string, overrides *ConfigOverrides) (*NamespaceOverride, error) {
+	namespace = strings.TrimSpace(namespace)
+	if namespace == "" {
+		return nil, errors.New("namespace is required")
+	}
+
+	normalizeModel(overrides.Model)
+	if overrides.Summarizer != nil {
+		overrides.Summarizer.Type = strings.ToLower(strings.TrimSpace(overrides.Summarizer.Type))
+		normalizeModel(overrides.Summarizer.LLMSummarizerModel)
+		normalizePrompt(overrides.Summarizer.LLMSummarizerPrompt)
+	}
+	if err := validateOverrides(overrides); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetByAgentIDAndVersion(ctx, projectID, agentID, 0); err != nil {
+		return nil, fmt.Errorf("agent config not found: %w", err)
+	}
+
+	return s.repo.SaveNamespaceOverride(ctx, projectID, agentID, namespace, overrides)
+}
+
+// GetNamespaceOverride returns the overrides of the config of an agent for a namespace
+func (s *AgentConfigService) GetNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string) (*NamespaceOverride, error) {
+	return s.repo.GetNamespaceOverride(ctx, projectID, agentID, namespace)
+}
+
+// ListNamespaceOverrides returns the overrides of the config of an agent for all namespaces
+func (s *AgentConfigService) ListNamespaceOverrides(ctx context.Context, projectID, agentID uuid.UUID) ([]*NamespaceOverride, error) {
+	return s.repo.ListNamespaceOverrides(ctx, projectID, agentID)
+}
+
+// DeleteNamespaceOverride drops the overrides of the config of an agent for a namespace
+func (s *AgentConfigService) DeleteNamespaceOverride(ctx context.Context, projectID, agentID uuid.UUID, namespace string) error {
+	return s.repo.DeleteNamespaceOverride(ctx, projectID, agentID, namespace)
+}
+
+// ApplyNamespaceOverride resolves the config of an agent for a conversation of the namespace. It returns the
+// config itself along with a nil override when the namespace has none.
+func (s *AgentConfigService) ApplyNamespaceOverride(ctx context.Context, config *AgentConfig, namespace string) (*AgentConfig, *NamespaceOverride, error) {
+	if namespace == "" {
+		return config, nil, nil
+	}
+
+	override, err := s.repo.GetNamespaceOverride(ctx, config.ProjectID, config.AgentID, namespace)
+	if errors.Is(err, ErrNamespaceOverrideNotFound) {
+		return config, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	effective, err := override.Overrides.Apply(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return effective, override, nil
+}
@@ -0,0 +1,153 @@
+package agent_config
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+)
+
+// AgentDefaults are the settings of a project the configs of its agents fall back to, so that the model or the
+// guardrails shared by the agents are set once. Unlike the namespace overrides, the values set in the agent config
+// always win.
+type AgentDefaults struct {
+	Model       *ModelConfig       `json:"model,omitempty"`       // Model of the agents without model
+	Temperature *float64           `json:"temperature,omitempty"` // Temperature of the models without temperature
+	Summarizer  *SummarizerConfig  `json:"summarizer,omitempty"`  // Summarizer of the agents with history and no summarizer
+	Guardrails  *GuardrailDefaults `json:"guardrails,omitempty"`
+}
+
+// GuardrailDefaults bound the runs of the agents of a project
+type GuardrailDefaults struct {
+	MaxIteration *int              `json:"max_iteration,omitempty"` // Iterations of the agents without max iteration
+	ToolQuotas   []ToolQuotaConfig `json:"tool_quotas,omitempty"`   // Added to the quotas of the agents, unless they set one for the tool and scope
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (d *AgentDefaults) Scan(value interface{}) error {
+	if value == nil {
+		*d = AgentDefaults{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into AgentDefaults", value)
+	}
+
+	return json.Unmarshal(bytes, d)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (d AgentDefaults) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Apply returns a copy of the config with the defaults merged under its values, the config itself is left
+// untouched as it may be shared through the config cache
+func (d *AgentDefaults) Apply(config *AgentConfig) (*AgentConfig, error) {
+	buf, err := json.Marshal(config.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := *config
+	effective.Config = AgentConfigData{}
+	if err := json.Unmarshal(buf, &effective.Config); err != nil {
+		return nil, err
+	}
+
+	if effective.Config.Model == nil && d.Model != nil {
+		model := *d.Model
+		model.Parameters = maps.Clone(d.Model.Parameters)
+		effective.Config.Model = &model
+	}
+
+	if d.Temperature != nil && effective.Config.Model != nil {
+		if _, ok := effective.Config.Model.Parameters["temperature"]; !ok {
+			if effective.Config.Model.Parameters == nil {
+				effective.Config.Model.Parameters = map[string]interface{}{}
+			}
+			effective.Config.Model.Parameters["temperature"] = *d.Temperature
+		}
+	}
+
+	if d.Summarizer != nil && effective.Config.History != nil && effective.Config.History.Enabled && effective.Config.History.Summarizer == nil {
+		summarizer := *d.Summarizer
+		effective.Config.History.Summarizer = &summarizer
+	}
+
+	if d.Guardrails != nil {
+		if effective.Config.MaxIteration == nil && d.Guardrails.MaxIteration != nil {
+			maxIteration := *d.Guardrails.MaxIteration
+			effective.Config.MaxIteration = &maxIteration
+		}
+
+		for _, quota := range d.Guardrails.ToolQuotas {
+			overridden := slices.ContainsFunc(effective.Config.ToolQuotas, func(q ToolQuotaConfig) bool {
+				return q.Tool == quota.Tool && q.Scope == quota.Scope
+			})
+			if !overridden {
+				effective.Config.ToolQuotas = append(effective.Config.ToolQuotas, quota)
+			}
+		}
+	}
+
+	return &effective, nil
+}
+
+// IsEmpty reports whether no default is set, the configs are then used as they are
+func (d *AgentDefaults) IsEmpty() bool {
+	return d == nil || (d.Model == nil && d.Temperature == nil && d.Summarizer == nil && d.Guardrails == nil)
+}
+
+// NormalizeAgentDefaults rewrites the defaults into their canonical form, like the fields of the agent configs
+func NormalizeAgentDefaults(defaults *AgentDefaults) {
+	normalizeModel(defaults.Model)
+	if defaults.Summarizer != nil {
+		defaults.Summarizer.Type = strings.ToLower(strings.TrimSpace(defaults.Summarizer.Type))
+		normalizeModel(defaults.Summarizer.LLMSummarizerModel)
+		normalizePrompt(defaults.Summarizer.LLMSummarizerPrompt)
+	}
+	if defaults.Guardrails != nil {
+		normalizeToolQuotas(defaults.Guardrails.ToolQuotas)
+	}
+}
+
+// ValidateAgentDefaults validates the defaults with the rules of the fields of the agent config they fill
+func ValidateAgentDefaults(defaults *AgentDefaults) error {
+	v := &configValidator{}
+
+	if defaults.Model != nil {
+		v.validateModel("model", defaults.Model)
+	}
+
+	if defaults.Temperature != nil && (*defaults.Temperature < 0 || *defaults.Temperature > 2) {
+		v.add("temperature", "must be between 0 and 2")
+	}
+
+	if defaults.Summarizer != nil {
+		v.validateSummarizer("summarizer", defaults.Summarizer)
+	}
+
+	if defaults.Guardrails != nil {
+		if defaults.Guardrails.MaxIteration != nil && *defaults.Guardrails.MaxIteration < 1 {
+			v.add("guardrails.max_iteration", "must be >= 1")
+		}
+		v.validateToolQuotas("guardrails.tool_quotas", defaults.Guardrails.ToolQuotas)
+	}
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+
+	return nil
+}
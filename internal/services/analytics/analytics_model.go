@@ -0,0 +1,144 @@
+package analytics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidQuery is returned when the time range or the interval of a query is invalid
+var ErrInvalidQuery = errors.New("invalid analytics query")
+
+// AnalyticsQuery holds the filters for computing run analytics. The time range is required.
+type AnalyticsQuery struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Namespace string    `json:"namespace,omitempty"`
+	AgentName string    `json:"agent_name,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Interval  string    `json:"interval"` // "hour" or "day", the width of the buckets the runs are grouped by
+}
+
+// runAggregate is a row of the aggregates of the runs of a group. Total is set on the row aggregating all the runs.
+type runAggregate struct {
+	AgentName       string     `db:"agent_name"`
+	Namespace       string     `db:"namespace"`
+	Bucket          *time.Time `db:"bucket"`
+	Total           bool       `db:"total"`
+	RunCount        int        `db:"run_count"`
+	SuccessCount    int        `db:"success_count"`
+	PausedCount     int        `db:"paused_count"`
+	FailureCount    int        `db:"failure_count"`
+	Loops           int        `db:"loops"`
+	InputTokens     int        `db:"input_tokens"`
+	CachedTokens    int        `db:"cached_tokens"`
+	OutputTokens    int        `db:"output_tokens"`
+	ReasoningTokens int        `db:"reasoning_tokens"`
+	TotalTokens     int        `db:"total_tokens"`
+	P50Ms           float64    `db:"p50_ms"`
+	P95Ms           float64    `db:"p95_ms"`
+	AvgMs           float64    `db:"avg_ms"`
+}
+
+// ttftAggregate is a row of the time to first token of the LLM calls of a group
+type ttftAggregate struct {
+	AgentName string     `db:"agent_name"`
+	Namespace string     `db:"namespace"`
+	Bucket    *time.Time `db:"bucket"`
+	Total     bool       `db:"total"`
+	P50Ms     float64    `db:"p50_ms"`
+	P95Ms     float64    `db:"p95_ms"`
+	AvgMs     float64    `db:"avg_ms"`
+}
+
+// stepAggregate is a row of the LLM calls of a model, or of the calls of a tool, of a group
+type stepAggregate struct {
+	AgentName           string    `db:"agent_name"`
+	Namespace           string    `db:"namespace"`
+	Bucket              time.Time `db:"bucket"`
+	Type                string    `db:"type"`
+	Model               string    `db:"model"`
+	Name                string    `db:"name"`
+	Calls               int       `db:"calls"`
+	UncachedInputTokens int       `db:"uncached_input_tokens"`
+	CachedTokens        int       `db:"cached_tokens"`
+	OutputTokens        int       `db:"output_tokens"`
+}
+
+// summaryShadowAggregate is a row of the summary shadow comparisons of a group
+type summaryShadowAggregate struct {
+	AgentName          string    `db:"agent_name"`
+	Namespace          string    `db:"namespace"`
+	Bucket             time.Time `db:"bucket"`
+	Comparisons        int       `db:"comparisons"`
+	Failures           int       `db:"failures"`
+	SummarizedMessages int       `db:"summarized_messages"`
+	SummaryTokens      int       `db:"summary_tokens"`
+	InputTokens        int       `db:"input_tokens"`
+	ShadowInputTokens  int       `db:"shadow_input_tokens"`
+	OutputTokens       int       `db:"output_tokens"`
+	ShadowOutputTokens int       `db:"shadow_output_tokens"`
+	SimilaritySum      float64   `db:"similarity_sum"`
+	ToolCallMatches    int       `db:"tool_call_matches"`
+}
+
+// TokenTotals aggregates token usage across runs
+type TokenTotals struct {
+	InputTokens     int `json:"input_tokens"`
+	CachedTokens    int `json:"cached_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	ReasoningTokens int `json:"reasoning_tokens"`
+	TotalTokens     int `json:"total_tokens"`
+}
+
+// LatencyStats holds latency percentiles in milliseconds
+type LatencyStats struct {
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	AvgMs float64 `json:"avg_ms"`
+}
+
+// AgentAnalytics holds the aggregates for a single agent within a namespace and a time bucket
+type AgentAnalytics struct {
+	AgentName     string         `json:"agent_name"`
+	Namespace     string         `json:"namespace"`
+	Bucket        *time.Time     `json:"bucket,omitempty"` // Start of the hour or of the day, unset on the totals
+	RunCount      int            `json:"run_count"`
+	SuccessCount  int            `json:"success_count"`
+	PausedCount   int            `json:"paused_count"`
+	FailureCount  int            `json:"failure_count"`
+	AvgLoops      float64        `json:"avg_loops"`
+	Tokens        TokenTotals    `json:"tokens"`
+	CostUSD       float64        `json:"cost_usd"`
+	UnpricedCalls int            `json:"unpriced_calls"`
+	ToolUsage     map[string]int `json:"tool_usage"`
+	Latency       LatencyStats   `json:"latency"`
+	TTFT          LatencyStats   `json:"ttft"` // time to first token of the LLM calls
+
+	SummaryShadow SummaryShadowStats `json:"summary_shadow"`
+}
+
+// SummaryShadowStats compares LLM calls made with the full history against the same calls made with the
+// history summarized by a summarizer in shadow mode
+type SummaryShadowStats struct {
+	Comparisons         int     `json:"comparisons"`
+	Failures            int     `json:"failures"`
+	SummarizedMessages  int     `json:"summarized_messages"`
+	SummaryTokens       int     `json:"summary_tokens"`      // tokens spent generating the summaries
+	InputTokens         int     `json:"input_tokens"`        // input tokens of the calls with the full history
+	ShadowInputTokens   int     `json:"shadow_input_tokens"` // input tokens of the calls with the summarized history
+	OutputTokens        int     `json:"output_tokens"`
+	ShadowOutputTokens  int     `json:"shadow_output_tokens"`
+	AvgAnswerSimilarity float64 `json:"avg_answer_similarity"`
+	ToolCallMatchRate   float64 `json:"tool_call_match_rate"`
+}
+
+// AnalyticsReport is the response of the analytics endpoint
+type AnalyticsReport struct {
+	Interval  string           `json:"interval"`
+	StartTime time.Time        `json:"start_time"`
+	EndTime   time.Time        `json:"end_time"`
+	Groups    []AgentAnalytics `json:"groups"`
+	Totals    AgentAnalytics   `json:"totals"`
+}
@@ -0,0 +1,201 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/curaious/uno/internal/db"
+)
+
+// AnalyticsRepo aggregates run data from the conversation store
+type AnalyticsRepo struct {
+	regions *db.RegionRouter
+}
+
+// NewAnalyticsRepo creates a new analytics repository. Runs are read from the database of the region of the project.
+func NewAnalyticsRepo(regions *db.RegionRouter) *AnalyticsRepo {
+	return &AnalyticsRepo{regions: regions}
+}
+
+// runsQuery selects the runs of the query with their bucket, the queries of the aggregates follow it
+const runsQuery = `
+	WITH runs AS (
+		SELECT
+			COALESCE(m.meta->'run_state'->>'agent_name', '') AS agent_name,
+			c.namespace_id AS namespace,
+			date_trunc($6, m.created_at) AS bucket,
+			m.meta->'run_state' AS run_state,
+			CASE WHEN jsonb_typeof(m.meta->'run_state'->'steps') = 'array'
+				THEN m.meta->'run_state'->'steps' ELSE '[]'::jsonb END AS steps,
+			CASE WHEN jsonb_typeof(m.meta->'run_state'->'summary_shadows') = 'array'
+				THEN m.meta->'run_state'->'summary_shadows' ELSE '[]'::jsonb END AS summary_shadows
+		FROM messages m
+		JOIN threads t ON m.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE c.project_id = $1
+		AND m.created_at >= $2 AND m.created_at < $3
+		AND ($4 = '' OR c.namespace_id = $4)
+		AND ($5 = '' OR m.meta->'run_state'->>'agent_name' = $5)
+		AND jsonb_typeof(m.meta->'run_state') = 'object'
+	)
+`
+
+func (r *AnalyticsRepo) selectAggregates(ctx context.Context, q *AnalyticsQuery, dest any, query string) error {
+	conn, _, err := r.regions.Conn(ctx, q.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	return conn.SelectContext(ctx, dest, runsQuery+query, q.ProjectID, q.StartTime, q.EndTime, q.Namespace, q.AgentName, q.Interval)
+}
+
+// AggregateRuns returns the run counts, token usage and latencies by agent, namespace and bucket, followed by
+// the row of all the runs. A run with a failed step is a failure even if it completed.
+func (r *AnalyticsRepo) AggregateRuns(ctx context.Context, q *AnalyticsQuery) ([]runAggregate, error) {
+	rows := []runAggregate{}
+	err := r.selectAggregates(ctx, q, &rows, `
+		, run_stats AS (
+			SELECT
+				r.agent_name,
+				r.namespace,
+				r.bucket,
+				COALESCE(r.run_state->>'status', '') AS status,
+				COALESCE((r.run_state->>'loop_iteration')::int, 0) AS loop_iteration,
+				r.run_state->'usage' AS usage,
+				s.llm_calls,
+				s.failed_step,
+				s.duration_ms
+			FROM runs r
+			CROSS JOIN LATERAL (
+				SELECT
+					COUNT(*) FILTER (WHERE step->>'type' = 'llm_call') AS llm_calls,
+					COALESCE(BOOL_OR(COALESCE(step->>'error', '') <> ''), false) AS failed_step,
+					EXTRACT(EPOCH FROM
+						MAX((step->>'started_at')::timestamptz + COALESCE((step->>'duration_ms')::bigint, 0) * interval '1 millisecond')
+						- MIN((step->>'started_at')::timestamptz)
+					)::float8 * 1000 AS duration_ms
+				FROM jsonb_array_elements(r.steps) AS step
+			) s
+		)
+		SELECT
+			COALESCE(agent_name, '') AS agent_name,
+			COALESCE(namespace, '') AS namespace,
+			bucket,
+			GROUPING(agent_name, namespace, bucket) <> 0 AS total,
+			COUNT(*) AS run_count,
+			COUNT(*) FILTER (WHERE status = 'completed' AND NOT failed_step) AS success_count,
+			COUNT(*) FILTER (WHERE status = 'paused' AND NOT failed_step) AS paused_count,
+			COUNT(*) FILTER (WHERE status = 'error' OR failed_step) AS failure_count,
+			COALESCE(SUM(CASE WHEN llm_calls = 0 THEN loop_iteration + 1 ELSE llm_calls END), 0) AS loops,
+			COALESCE(SUM((usage->>'input_tokens')::bigint), 0) AS input_tokens,
+			COALESCE(SUM((usage->'input_tokens_details'->>'cached_tokens')::bigint), 0) AS cached_tokens,
+			COALESCE(SUM((usage->>'output_tokens')::bigint), 0) AS output_tokens,
+			COALESCE(SUM((usage->'output_tokens_details'->>'reasoning_tokens')::bigint), 0) AS reasoning_tokens,
+			COALESCE(SUM((usage->>'total_tokens')::bigint), 0) AS total_tokens,
+			COALESCE(PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY duration_ms), 0) AS p50_ms,
+			COALESCE(PERCENTILE_DISC(0.95) WITHIN GROUP (ORDER BY duration_ms), 0) AS p95_ms,
+			COALESCE(AVG(duration_ms), 0) AS avg_ms
+		FROM run_stats
+		GROUP BY GROUPING SETS ((agent_name, namespace, bucket), ())
+		ORDER BY total, bucket, run_count DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate runs: %w", err)
+	}
+
+	return rows, nil
+}
+
+// AggregateTTFT returns the time to first token of the LLM calls by agent, namespace and bucket, followed by
+// the row of all the calls
+func (r *AnalyticsRepo) AggregateTTFT(ctx context.Context, q *AnalyticsQuery) ([]ttftAggregate, error) {
+	rows := []ttftAggregate{}
+	err := r.selectAggregates(ctx, q, &rows, `
+		SELECT
+			COALESCE(r.agent_name, '') AS agent_name,
+			COALESCE(r.namespace, '') AS namespace,
+			r.bucket,
+			GROUPING(r.agent_name, r.namespace, r.bucket) <> 0 AS total,
+			COALESCE(PERCENTILE_DISC(0.5) WITHIN GROUP (ORDER BY (step->'timing'->>'ttft_ms')::float8), 0) AS p50_ms,
+			COALESCE(PERCENTILE_DISC(0.95) WITHIN GROUP (ORDER BY (step->'timing'->>'ttft_ms')::float8), 0) AS p95_ms,
+			COALESCE(AVG((step->'timing'->>'ttft_ms')::float8), 0) AS avg_ms
+		FROM runs r, jsonb_array_elements(r.steps) AS step
+		WHERE step->>'type' = 'llm_call' AND jsonb_typeof(step->'timing') = 'object'
+		GROUP BY GROUPING SETS ((r.agent_name, r.namespace, r.bucket), ())
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate time to first token: %w", err)
+	}
+
+	return rows, nil
+}
+
+// AggregateSteps returns the LLM calls by model and the tool calls by tool of every agent, namespace and bucket.
+// The input tokens are split into cached and uncached ones per call, as the pricing does.
+func (r *AnalyticsRepo) AggregateSteps(ctx context.Context, q *AnalyticsQuery) ([]stepAggregate, error) {
+	rows := []stepAggregate{}
+	err := r.selectAggregates(ctx, q, &rows, `
+		SELECT
+			r.agent_name,
+			r.namespace,
+			r.bucket,
+			step->>'type' AS type,
+			COALESCE(step->>'model', '') AS model,
+			COALESCE(step->>'name', '') AS name,
+			COUNT(*) AS calls,
+			COALESCE(SUM(GREATEST(
+				COALESCE((step->'usage'->>'input_tokens')::bigint, 0)
+				- COALESCE((step->'usage'->'input_tokens_details'->>'cached_tokens')::bigint, 0), 0
+			)), 0) AS uncached_input_tokens,
+			COALESCE(SUM((step->'usage'->'input_tokens_details'->>'cached_tokens')::bigint), 0) AS cached_tokens,
+			COALESCE(SUM((step->'usage'->>'output_tokens')::bigint), 0) AS output_tokens
+		FROM runs r, jsonb_array_elements(r.steps) AS step
+		WHERE step->>'type' IN ('llm_call', 'tool_call')
+		GROUP BY r.agent_name, r.namespace, r.bucket, step->>'type', step->>'model', step->>'name'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate steps: %w", err)
+	}
+
+	return rows, nil
+}
+
+// AggregateSummaryShadows returns the summary shadow comparisons by agent, namespace and bucket. A comparison
+// failed when it has an error or misses the usage of either call.
+func (r *AnalyticsRepo) AggregateSummaryShadows(ctx context.Context, q *AnalyticsQuery) ([]summaryShadowAggregate, error) {
+	rows := []summaryShadowAggregate{}
+	err := r.selectAggregates(ctx, q, &rows, `
+		, shadows AS (
+			SELECT
+				r.agent_name,
+				r.namespace,
+				r.bucket,
+				shadow,
+				COALESCE(shadow->>'error', '') <> ''
+					OR jsonb_typeof(shadow->'usage') IS DISTINCT FROM 'object'
+					OR jsonb_typeof(shadow->'shadow_usage') IS DISTINCT FROM 'object' AS failed
+			FROM runs r, jsonb_array_elements(r.summary_shadows) AS shadow
+		)
+		SELECT
+			agent_name,
+			namespace,
+			bucket,
+			COUNT(*) FILTER (WHERE NOT failed) AS comparisons,
+			COUNT(*) FILTER (WHERE failed) AS failures,
+			COALESCE(SUM((shadow->>'summarized_messages')::bigint) FILTER (WHERE NOT failed), 0) AS summarized_messages,
+			COALESCE(SUM((shadow->'summary_usage'->>'total_tokens')::bigint), 0) AS summary_tokens,
+			COALESCE(SUM((shadow->'usage'->>'input_tokens')::bigint) FILTER (WHERE NOT failed), 0) AS input_tokens,
+			COALESCE(SUM((shadow->'shadow_usage'->>'input_tokens')::bigint) FILTER (WHERE NOT failed), 0) AS shadow_input_tokens,
+			COALESCE(SUM((shadow->'usage'->>'output_tokens')::bigint) FILTER (WHERE NOT failed), 0) AS output_tokens,
+			COALESCE(SUM((shadow->'shadow_usage'->>'output_tokens')::bigint) FILTER (WHERE NOT failed), 0) AS shadow_output_tokens,
+			COALESCE(SUM((shadow->>'answer_similarity')::float8) FILTER (WHERE NOT failed), 0) AS similarity_sum,
+			COUNT(*) FILTER (WHERE NOT failed AND COALESCE((shadow->>'tool_calls_match')::boolean, false)) AS tool_call_matches
+		FROM shadows
+		GROUP BY agent_name, namespace, bucket
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate summary shadows: %w", err)
+	}
+
+	return rows, nil
+}
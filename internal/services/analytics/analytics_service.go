@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// AnalyticsService computes run aggregates per agent and namespace
+type AnalyticsService struct {
+	repo *AnalyticsRepo
+}
+
+// NewAnalyticsService constructs a new AnalyticsService
+func NewAnalyticsService(repo *AnalyticsRepo) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// GetAnalytics returns aggregates grouped by agent, namespace and hour or day for the given time range
+func (s *AnalyticsService) GetAnalytics(ctx context.Context, q *AnalyticsQuery) (*AnalyticsReport, error) {
+	if q.StartTime.IsZero() || q.EndTime.IsZero() {
+		return nil, fmt.Errorf("%w: start_time and end_time are required", ErrInvalidQuery)
+	}
+
+	switch q.Interval {
+	case "":
+		q.Interval = "day"
+	case "hour", "day":
+	default:
+		return nil, fmt.Errorf("%w: invalid interval %q, use hour or day", ErrInvalidQuery, q.Interval)
+	}
+
+	runs, err := s.repo.AggregateRuns(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	ttfts, err := s.repo.AggregateTTFT(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.repo.AggregateSteps(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	shadows, err := s.repo.AggregateSummaryShadows(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[groupKey]*aggregate{}
+	var order []groupKey
+	totals := newAggregate("", "", nil)
+	for _, row := range runs {
+		agg := totals
+		if !row.Total {
+			key := newGroupKey(row.AgentName, row.Namespace, row.Bucket)
+			agg = newAggregate(row.AgentName, row.Namespace, row.Bucket)
+			groups[key] = agg
+			order = append(order, key)
+		}
+
+		out := &agg.out
+		out.RunCount = row.RunCount
+		out.SuccessCount = row.SuccessCount
+		out.PausedCount = row.PausedCount
+		out.FailureCount = row.FailureCount
+		if row.RunCount > 0 {
+			out.AvgLoops = float64(row.Loops) / float64(row.RunCount)
+		}
+		out.Tokens = TokenTotals{
+			InputTokens:     row.InputTokens,
+			CachedTokens:    row.CachedTokens,
+			OutputTokens:    row.OutputTokens,
+			ReasoningTokens: row.ReasoningTokens,
+			TotalTokens:     row.TotalTokens,
+		}
+		out.Latency = LatencyStats{P50Ms: row.P50Ms, P95Ms: row.P95Ms, AvgMs: row.AvgMs}
+	}
+
+	for _, row := range ttfts {
+		agg := totals
+		if !row.Total {
+			agg = groups[newGroupKey(row.AgentName, row.Namespace, row.Bucket)]
+		}
+		if agg != nil {
+			agg.out.TTFT = LatencyStats{P50Ms: row.P50Ms, P95Ms: row.P95Ms, AvgMs: row.AvgMs}
+		}
+	}
+
+	// Steps and summary shadows are only aggregated by group, their totals are the sums of the groups
+	for _, row := range steps {
+		if agg := groups[newGroupKey(row.AgentName, row.Namespace, &row.Bucket)]; agg != nil {
+			agg.addSteps(row)
+		}
+		totals.addSteps(row)
+	}
+
+	for _, row := range shadows {
+		if agg := groups[newGroupKey(row.AgentName, row.Namespace, &row.Bucket)]; agg != nil {
+			agg.addSummaryShadows(row)
+		}
+		totals.addSummaryShadows(row)
+	}
+
+	report := &AnalyticsReport{
+		Interval:  q.Interval,
+		StartTime: q.StartTime,
+		EndTime:   q.EndTime,
+		Groups:    make([]AgentAnalytics, 0, len(order)),
+		Totals:    totals.result(),
+	}
+	for _, key := range order {
+		report.Groups = append(report.Groups, groups[key].result())
+	}
+
+	return report, nil
+}
+
+// groupKey identifies the group of an agent within a namespace and a bucket
+type groupKey struct {
+	agent, namespace string
+	bucket           int64
+}
+
+func newGroupKey(agentName, namespace string, bucket *time.Time) groupKey {
+	key := groupKey{agent: agentName, namespace: namespace}
+	if bucket != nil {
+		key.bucket = bucket.UnixMicro()
+	}
+	return key
+}
+
+type aggregate struct {
+	out AgentAnalytics
+
+	shadowSimilarity  float64
+	shadowToolMatches int
+}
+
+func newAggregate(agentName, namespace string, bucket *time.Time) *aggregate {
+	return &aggregate{
+		out: AgentAnalytics{
+			AgentName: agentName,
+			Namespace: namespace,
+			Bucket:    bucket,
+			ToolUsage: map[string]int{},
+		},
+	}
+}
+
+func (a *aggregate) addSteps(row stepAggregate) {
+	switch core.StepRecordType(row.Type) {
+	case core.StepRecordLLMCall:
+		pricing, ok := llm.GetModelPricing(row.Model)
+		if !ok {
+			a.out.UnpricedCalls += row.Calls
+			return
+		}
+
+		usage := &responses.Usage{
+			InputTokens:  row.UncachedInputTokens + row.CachedTokens,
+			OutputTokens: row.OutputTokens,
+		}
+		usage.InputTokensDetails.CachedTokens = row.CachedTokens
+		a.out.CostUSD += pricing.Cost(usage)
+	case core.StepRecordToolCall:
+		a.out.ToolUsage[row.Name] += row.Calls
+	}
+}
+
+func (a *aggregate) addSummaryShadows(row summaryShadowAggregate) {
+	stats := &a.out.SummaryShadow
+	stats.Comparisons += row.Comparisons
+	stats.Failures += row.Failures
+	stats.SummarizedMessages += row.SummarizedMessages
+	stats.SummaryTokens += row.SummaryTokens
+	stats.InputTokens += row.InputTokens
+	stats.ShadowInputTokens += row.ShadowInputTokens
+	stats.OutputTokens += row.OutputTokens
+	stats.ShadowOutputTokens += row.ShadowOutputTokens
+	a.shadowSimilarity += row.SimilaritySum
+	a.shadowToolMatches += row.ToolCallMatches
+}
+
+func (a *aggregate) result() AgentAnalytics {
+	out := a.out
+	if n := out.SummaryShadow.Comparisons; n > 0 {
+		out.SummaryShadow.AvgAnswerSimilarity = math.Round(a.shadowSimilarity/float64(n)*1e4) / 1e4
+		out.SummaryShadow.ToolCallMatchRate = math.Round(float64(a.shadowToolMatches)/float64(n)*1e4) / 1e4
+	}
+
+	out.CostUSD = math.Round(out.CostUSD*1e6) / 1e6
+
+	return out
+}
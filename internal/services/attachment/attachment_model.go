@@ -0,0 +1,12 @@
+package attachment
+
+import "time"
+
+// Attachment is a row of the attachments table, a file sent by a user kept under the SHA-256 of its data
+type Attachment struct {
+	SHA256    string    `db:"sha256"`
+	MimeType  string    `db:"mime_type"`
+	SizeBytes int       `db:"size_bytes"`
+	Data      []byte    `db:"data"`
+	CreatedAt time.Time `db:"created_at"`
+}
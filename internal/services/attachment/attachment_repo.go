@@ -0,0 +1,59 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrAttachmentNotFound is returned when no attachment has the SHA-256
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// AttachmentRepo handles database operations for attachments
+type AttachmentRepo struct {
+	db *sqlx.DB
+}
+
+// NewAttachmentRepo creates a new attachment repository
+func NewAttachmentRepo(db *sqlx.DB) *AttachmentRepo {
+	return &AttachmentRepo{db: db}
+}
+
+// Create stores an attachment, an attachment with the same SHA-256 is the same file and is kept as it is
+func (r *AttachmentRepo) Create(ctx context.Context, attachment *Attachment) error {
+	query := `
+		INSERT INTO attachments (sha256, mime_type, size_bytes, data)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (sha256) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, attachment.SHA256, attachment.MimeType, attachment.SizeBytes, attachment.Data)
+	if err != nil {
+		return fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetBySHA256 retrieves an attachment by the SHA-256 of its data
+func (r *AttachmentRepo) GetBySHA256(ctx context.Context, sha256 string) (*Attachment, error) {
+	query := `
+		SELECT sha256, mime_type, size_bytes, data, created_at
+		FROM attachments
+		WHERE sha256 = $1
+	`
+
+	var attachment Attachment
+	err := r.db.GetContext(ctx, &attachment, query, sha256)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &attachment, nil
+}
@@ -0,0 +1,29 @@
+package attachment
+
+import "context"
+
+// AttachmentService stores the files sent by the users, e.g. the original of the input images the gateway downscaled.
+// It implements gateway.ImageStore.
+type AttachmentService struct {
+	repo *AttachmentRepo
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(repo *AttachmentRepo) *AttachmentService {
+	return &AttachmentService{repo: repo}
+}
+
+// StoreImage stores an image under the SHA-256 of its data
+func (s *AttachmentService) StoreImage(ctx context.Context, sha256 string, mimeType string, data []byte) error {
+	return s.repo.Create(ctx, &Attachment{
+		SHA256:    sha256,
+		MimeType:  mimeType,
+		SizeBytes: len(data),
+		Data:      data,
+	})
+}
+
+// GetAttachment retrieves an attachment by the SHA-256 of its data
+func (s *AttachmentService) GetAttachment(ctx context.Context, sha256 string) (*Attachment, error) {
+	return s.repo.GetBySHA256(ctx, sha256)
+}
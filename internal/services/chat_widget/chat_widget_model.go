@@ -0,0 +1,80 @@
+package chat_widget
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWidgetNotFound     = errors.New("chat widget not found")
+	ErrWidgetDisabled     = errors.New("chat widget is disabled")
+	ErrOriginNotAllowed   = errors.New("origin is not allowed for the chat widget")
+	ErrInvalidSession     = errors.New("invalid chat widget session")
+	ErrMessageTooLong     = errors.New("message is too long")
+	ErrInvalidOriginEntry = errors.New("allowed origins must be like https://example.com or https://*.example.com")
+)
+
+// ChatWidget lets the visitors of websites converse with an agent from the pages of its allowed origins. The visitors
+// are anonymous, each gets a session token signed with the secret of the widget and converses in a namespace of its own.
+type ChatWidget struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Name      string    `json:"name" db:"name"`
+	// AgentID is the agent that answers, as accepted by the agent_id parameter of the converse endpoint
+	AgentID string `json:"agent_id" db:"agent_id"`
+	// AllowedOrigins are the origins of the pages embedding the widget, like https://example.com or
+	// https://*.example.com for its subdomains
+	AllowedOrigins pq.StringArray `json:"allowed_origins" db:"allowed_origins"`
+	// Secret signs the session tokens, rotating it ends the sessions
+	Secret            string `json:"secret" db:"secret"`
+	SessionTTLMinutes int    `json:"session_ttl_minutes" db:"session_ttl_minutes"`
+	// MessagesPerMinute bounds the messages of a visitor
+	MessagesPerMinute int `json:"messages_per_minute" db:"messages_per_minute"`
+	// SessionsPerHour bounds the sessions issued to a client IP
+	SessionsPerHour  int       `json:"sessions_per_hour" db:"sessions_per_hour"`
+	MaxMessageLength int       `json:"max_message_length" db:"max_message_length"`
+	Enabled          bool      `json:"enabled" db:"enabled"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateChatWidgetRequest represents the request to create a chat widget, the limits left out get their defaults
+type CreateChatWidgetRequest struct {
+	Name              string   `json:"name" validate:"required,min=1,max=255"`
+	AgentID           string   `json:"agent_id" validate:"required"`
+	AllowedOrigins    []string `json:"allowed_origins"`
+	SessionTTLMinutes int      `json:"session_ttl_minutes,omitempty"`
+	MessagesPerMinute int      `json:"messages_per_minute,omitempty"`
+	SessionsPerHour   int      `json:"sessions_per_hour,omitempty"`
+	MaxMessageLength  int      `json:"max_message_length,omitempty"`
+}
+
+// UpdateChatWidgetRequest represents the request to update a chat widget, the fields left out are kept
+type UpdateChatWidgetRequest struct {
+	AgentID           *string   `json:"agent_id,omitempty"`
+	AllowedOrigins    *[]string `json:"allowed_origins,omitempty"`
+	SessionTTLMinutes *int      `json:"session_ttl_minutes,omitempty"`
+	MessagesPerMinute *int      `json:"messages_per_minute,omitempty"`
+	SessionsPerHour   *int      `json:"sessions_per_hour,omitempty"`
+	MaxMessageLength  *int      `json:"max_message_length,omitempty"`
+	Enabled           *bool     `json:"enabled,omitempty"`
+	// RotateSecret replaces the secret of the widget, which ends the sessions of its visitors
+	RotateSecret bool `json:"rotate_secret,omitempty"`
+}
+
+// Session is a session of a visitor of a widget
+type Session struct {
+	Token     string    `json:"token"`
+	VisitorID string    `json:"visitor_id"`
+	Namespace string    `json:"namespace"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Visitor is the visitor a session token was issued to
+type Visitor struct {
+	ID     string
+	Origin string
+}
@@ -0,0 +1,108 @@
+package chat_widget
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const widgetColumns = `id, project_id, name, agent_id, allowed_origins, secret, session_ttl_minutes, messages_per_minute, sessions_per_hour, max_message_length, enabled, created_at, updated_at`
+
+// ChatWidgetRepo handles database operations for chat widgets
+type ChatWidgetRepo struct {
+	db *sqlx.DB
+}
+
+// NewChatWidgetRepo creates a new chat widget repository
+func NewChatWidgetRepo(db *sqlx.DB) *ChatWidgetRepo {
+	return &ChatWidgetRepo{db: db}
+}
+
+// Create creates a new chat widget
+func (r *ChatWidgetRepo) Create(ctx context.Context, widget *ChatWidget) (*ChatWidget, error) {
+	query := `
+		INSERT INTO chat_widgets (project_id, name, agent_id, allowed_origins, secret, session_ttl_minutes, messages_per_minute, sessions_per_hour, max_message_length)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + widgetColumns
+
+	var created ChatWidget
+	err := r.db.GetContext(ctx, &created, query, widget.ProjectID, widget.Name, widget.AgentID, widget.AllowedOrigins, widget.Secret,
+		widget.SessionTTLMinutes, widget.MessagesPerMinute, widget.SessionsPerHour, widget.MaxMessageLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat widget: %w", err)
+	}
+
+	return &created, nil
+}
+
+// List retrieves the chat widgets of a project
+func (r *ChatWidgetRepo) List(ctx context.Context, projectID uuid.UUID) ([]*ChatWidget, error) {
+	query := `SELECT ` + widgetColumns + ` FROM chat_widgets WHERE project_id = $1 ORDER BY name`
+
+	widgets := []*ChatWidget{}
+	if err := r.db.SelectContext(ctx, &widgets, query, projectID); err != nil {
+		return nil, fmt.Errorf("failed to list chat widgets: %w", err)
+	}
+
+	return widgets, nil
+}
+
+// GetByID retrieves a chat widget by ID
+func (r *ChatWidgetRepo) GetByID(ctx context.Context, id uuid.UUID) (*ChatWidget, error) {
+	query := `SELECT ` + widgetColumns + ` FROM chat_widgets WHERE id = $1`
+
+	var widget ChatWidget
+	if err := r.db.GetContext(ctx, &widget, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWidgetNotFound
+		}
+		return nil, fmt.Errorf("failed to get chat widget: %w", err)
+	}
+
+	return &widget, nil
+}
+
+// Update replaces the settings of a chat widget
+func (r *ChatWidgetRepo) Update(ctx context.Context, widget *ChatWidget) (*ChatWidget, error) {
+	query := `
+		UPDATE chat_widgets
+		SET agent_id = $3, allowed_origins = $4, secret = $5, session_ttl_minutes = $6, messages_per_minute = $7,
+			sessions_per_hour = $8, max_message_length = $9, enabled = $10, updated_at = NOW()
+		WHERE project_id = $1 AND id = $2
+		RETURNING ` + widgetColumns
+
+	var updated ChatWidget
+	err := r.db.GetContext(ctx, &updated, query, widget.ProjectID, widget.ID, widget.AgentID, widget.AllowedOrigins, widget.Secret,
+		widget.SessionTTLMinutes, widget.MessagesPerMinute, widget.SessionsPerHour, widget.MaxMessageLength, widget.Enabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWidgetNotFound
+		}
+		return nil, fmt.Errorf("failed to update chat widget: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// Delete deletes a chat widget
+func (r *ChatWidgetRepo) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM chat_widgets WHERE project_id = $1 AND id = $2`, projectID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete chat widget: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrWidgetNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package chat_widget
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultSessionTTLMinutes = 24 * 60
+	defaultMessagesPerMinute = 10
+	defaultSessionsPerHour   = 20
+	defaultMaxMessageLength  = 4000
+
+	// visitorRetention is how long an expired session can be renewed for the same visitor, so that returning
+	// visitors find their conversation
+	visitorRetention = 30 * 24 * time.Hour
+	// sessionIssuer is the issuer of the session tokens
+	sessionIssuer = "uno-chat-widget"
+)
+
+// ChatWidgetService handles business logic for chat widgets and the sessions of their visitors
+type ChatWidgetService struct {
+	repo *ChatWidgetRepo
+}
+
+// NewChatWidgetService creates a new chat widget service
+func NewChatWidgetService(repo *ChatWidgetRepo) *ChatWidgetService {
+	return &ChatWidgetService{repo: repo}
+}
+
+// sessionClaims are the claims of a session token, the subject is the visitor and the audience the widget
+type sessionClaims struct {
+	Origin string `json:"origin"`
+	jwt.RegisteredClaims
+}
+
+// Create creates a chat widget with a new secret
+func (s *ChatWidgetService) Create(ctx context.Context, projectID uuid.UUID, req *CreateChatWidgetRequest) (*ChatWidget, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	widget := &ChatWidget{
+		ProjectID:         projectID,
+		Name:              req.Name,
+		AgentID:           req.AgentID,
+		AllowedOrigins:    pq.StringArray(req.AllowedOrigins),
+		SessionTTLMinutes: cmp.Or(req.SessionTTLMinutes, defaultSessionTTLMinutes),
+		MessagesPerMinute: cmp.Or(req.MessagesPerMinute, defaultMessagesPerMinute),
+		SessionsPerHour:   cmp.Or(req.SessionsPerHour, defaultSessionsPerHour),
+		MaxMessageLength:  cmp.Or(req.MaxMessageLength, defaultMaxMessageLength),
+	}
+	if widget.AllowedOrigins == nil {
+		widget.AllowedOrigins = pq.StringArray{}
+	}
+	if err := validateWidget(widget); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if widget.Secret, err = generateSecret(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, widget)
+}
+
+// List retrieves the chat widgets of a project
+func (s *ChatWidgetService) List(ctx context.Context, projectID uuid.UUID) ([]*ChatWidget, error) {
+	return s.repo.List(ctx, projectID)
+}
+
+// Get retrieves a chat widget of a project
+func (s *ChatWidgetService) Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*ChatWidget, error) {
+	widget, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if widget.ProjectID != projectID {
+		return nil, ErrWidgetNotFound
+	}
+
+	return widget, nil
+}
+
+// GetEnabled retrieves a chat widget for the requests of its visitors, which don't name a project
+func (s *ChatWidgetService) GetEnabled(ctx context.Context, id uuid.UUID) (*ChatWidget, error) {
+	widget, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !widget.Enabled {
+		return nil, ErrWidgetDisabled
+	}
+
+	return widget, nil
+}
+
+// Update updates the settings of a chat widget, rotating its secret when requested
+func (s *ChatWidgetService) Update(ctx context.Context, projectID uuid.UUID, id uuid.UUID, req *UpdateChatWidgetRequest) (*ChatWidget, error) {
+	widget, err := s.Get(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AgentID != nil {
+		widget.AgentID = *req.AgentID
+	}
+	if req.AllowedOrigins != nil {
+		widget.AllowedOrigins = pq.StringArray(*req.AllowedOrigins)
+	}
+	if req.SessionTTLMinutes != nil {
+		widget.SessionTTLMinutes = *req.SessionTTLMinutes
+	}
+	if req.MessagesPerMinute != nil {
+		widget.MessagesPerMinute = *req.MessagesPerMinute
+	}
+	if req.SessionsPerHour != nil {
+		widget.SessionsPerHour = *req.SessionsPerHour
+	}
+	if req.MaxMessageLength != nil {
+		widget.MaxMessageLength = *req.MaxMessageLength
+	}
+	if req.Enabled != nil {
+		widget.Enabled = *req.Enabled
+	}
+	if req.RotateSecret {
+		if widget.Secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateWidget(widget); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(ctx, widget)
+}
+
+// Delete deletes a chat widget
+func (s *ChatWidgetService) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(ctx, projectID, id)
+}
+
+// IssueSession issues a session to a visitor of a widget on a page of an allowed origin. A visitor presenting the
+// token of a previous session, even an expired one, keeps its ID and so its conversation.
+func (s *ChatWidgetService) IssueSession(widget *ChatWidget, origin string, previousToken string) (*Session, error) {
+	if !IsOriginAllowed(widget, origin) {
+		return nil, ErrOriginNotAllowed
+	}
+
+	visitorID := ""
+	if previousToken != "" {
+		claims, err := parseSession(widget, previousToken, jwt.WithoutClaimsValidation())
+		if err == nil && claims.Origin == origin && claims.IssuedAt != nil && time.Since(claims.IssuedAt.Time) < visitorRetention {
+			visitorID = claims.Subject
+		}
+	}
+	if visitorID == "" {
+		visitorID = uuid.NewString()
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(widget.SessionTTLMinutes) * time.Minute)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims{
+		Origin: origin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sessionIssuer,
+			Subject:   visitorID,
+			Audience:  jwt.ClaimStrings{widget.ID.String()},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+
+	signed, err := token.SignedString([]byte(widget.Secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign chat widget session: %w", err)
+	}
+
+	return &Session{
+		Token:     signed,
+		VisitorID: visitorID,
+		Namespace: Namespace(widget, visitorID),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifySession authenticates the request of a visitor, whose session must be issued for the origin of the request
+func (s *ChatWidgetService) VerifySession(widget *ChatWidget, token string, origin string) (*Visitor, error) {
+	claims, err := parseSession(widget, token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Origin != origin || !IsOriginAllowed(widget, origin) {
+		return nil, ErrOriginNotAllowed
+	}
+
+	return &Visitor{ID: claims.Subject, Origin: claims.Origin}, nil
+}
+
+// ValidateMessage checks the message of a visitor against the limits of the widget
+func (s *ChatWidgetService) ValidateMessage(widget *ChatWidget, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("message is required")
+	}
+	if len([]rune(text)) > widget.MaxMessageLength {
+		return fmt.Errorf("%w: at most %d characters", ErrMessageTooLong, widget.MaxMessageLength)
+	}
+
+	return nil
+}
+
+// Namespace is the namespace of the conversations of a visitor of a widget, so that visitors never see each
+// other's conversations
+func Namespace(widget *ChatWidget, visitorID string) string {
+	return "widget-" + widget.ID.String() + "-" + visitorID
+}
+
+// IsOriginAllowed reports whether a page of an origin may embed a widget. Origins match exactly, or any subdomain
+// for the origins with a wildcard like https://*.example.com.
+func IsOriginAllowed(widget *ChatWidget, origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	origin = strings.ToLower(origin)
+	for _, allowed := range widget.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == origin {
+			return true
+		}
+
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseSession(widget *ChatWidget, token string, opts ...jwt.ParserOption) (*sessionClaims, error) {
+	opts = append(opts, jwt.WithIssuer(sessionIssuer), jwt.WithAudience(widget.ID.String()), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+
+	claims := &sessionClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(widget.Secret), nil
+	}, opts...)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidSession, err)
+	}
+	if claims.Subject == "" {
+		return nil, ErrInvalidSession
+	}
+
+	return claims, nil
+}
+
+func validateWidget(widget *ChatWidget) error {
+	if strings.TrimSpace(widget.AgentID) == "" {
+		return fmt.Errorf("agent_id is required")
+	}
+
+	for _, origin := range widget.AllowedOrigins {
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return fmt.Errorf("%w, got '%s'", ErrInvalidOriginEntry, origin)
+		}
+	}
+
+	if widget.SessionTTLMinutes <= 0 || widget.MessagesPerMinute <= 0 || widget.SessionsPerHour <= 0 || widget.MaxMessageLength <= 0 {
+		return fmt.Errorf("session_ttl_minutes, messages_per_minute, sessions_per_hour and max_message_length must be positive")
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return "cwsec_" + base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(bytes), nil
+}
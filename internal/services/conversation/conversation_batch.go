@@ -0,0 +1,346 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// MaxBatchMessages is the most messages a batch append accepts
+const MaxBatchMessages = 500
+
+// batchMetaSourceCreatedAt is the meta key keeping the created_at of a batch message that had to be moved to keep
+// the order of the thread
+const batchMetaSourceCreatedAt = "source_created_at"
+
+// MessageOrder is the order the messages of a batch are appended in
+type MessageOrder string
+
+const (
+	MessageOrderGiven     MessageOrder = "given"      // The order of the request, the default
+	MessageOrderCreatedAt MessageOrder = "created_at" // Oldest first, every message must have a created_at
+)
+
+var (
+	ErrInvalidBatch = errors.New("invalid batch")
+	ErrThreadMoved  = errors.New("the previous message is not the last message of the thread")
+)
+
+// BatchMessage is a message of a batch append, e.g. one imported from another channel like email or Slack
+type BatchMessage struct {
+	MessageID string                        `json:"message_id,omitempty"`
+	Messages  []responses.InputMessageUnion `json:"messages"`
+	Meta      map[string]any                `json:"meta"`
+	// ExternalID is the ID of the message in its channel. A message with an external ID appended already is skipped.
+	ExternalID string `json:"external_id,omitempty"`
+	// CreatedAt is when the message was written in its channel. It is kept when it is after the previous message of
+	// the thread, otherwise the message is stored right after it and the time is kept in the "source_created_at" meta.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// AppendMessagesRequest appends a batch of messages to a thread of a conversation in one transaction
+type AppendMessagesRequest struct {
+	ProjectID      uuid.UUID `json:"-"`
+	Namespace      string    `json:"-"`
+	ConversationID string    `json:"-"`
+
+	// ThreadID is the thread to append to. By default it is the latest thread of the conversation, or a new one.
+	ThreadID string `json:"thread_id,omitempty"`
+	// PreviousMessageID, when set, must be the last message of the thread, else nothing is appended
+	PreviousMessageID string         `json:"previous_message_id,omitempty"`
+	Order             MessageOrder   `json:"order,omitempty"`
+	Messages          []BatchMessage `json:"messages"`
+}
+
+// AppendedMessage is the outcome of a message of a batch append
+type AppendedMessage struct {
+	MessageID  string    `json:"message_id"`
+	ExternalID string    `json:"external_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	// Skipped is set when the external ID was appended already, MessageID is that of the earlier message
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// AppendMessagesResult describes the thread a batch was appended to, with the messages in the order they were appended
+type AppendMessagesResult struct {
+	ConversationID string            `json:"conversation_id"`
+	ThreadID       string            `json:"thread_id"`
+	LastMessageID  string            `json:"last_message_id"`
+	Messages       []AppendedMessage `json:"messages"`
+}
+
+// AppendMessages appends pre-existing messages to a thread of a conversation. The messages, their external IDs and
+// the thread are written in one transaction: either the whole batch is appended or nothing is.
+func (s *ConversationService) AppendMessages(ctx context.Context, in *AppendMessagesRequest) (*AppendMessagesResult, error) {
+	if err := validateBatch(in); err != nil {
+		return nil, err
+	}
+
+	repo, err := s.repoFor(ctx, in.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := repo.GetConversationByID(ctx, in.ProjectID, in.Namespace, in.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var thread Thread
+	if in.ThreadID != "" {
+		thread, err = repo.GetThreadByID(ctx, in.ProjectID, in.Namespace, in.ThreadID)
+		if err != nil {
+			return nil, err
+		}
+		if thread.ThreadID == "" || thread.ConversationID != conversation.ConversationID {
+			return nil, ErrThreadNotFound
+		}
+	} else {
+		threads, err := repo.ListThreads(ctx, in.ProjectID, in.Namespace, conversation.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		if len(threads) > 0 {
+			thread = threads[0]
+		}
+	}
+
+	newThread := thread.ThreadID == ""
+	if newThread {
+		thread = Thread{
+			ConversationID: conversation.ConversationID,
+			ThreadID:       s.newID(ctx),
+			CreatedAt:      time.Now(),
+		}
+	}
+	if in.PreviousMessageID != "" && thread.LastMessageID != in.PreviousMessageID {
+		return nil, ErrThreadMoved
+	}
+
+	batch := in.Messages
+	if in.Order == MessageOrderCreatedAt {
+		batch = slices.Clone(batch)
+		slices.SortStableFunc(batch, func(a, b BatchMessage) int {
+			return a.CreatedAt.Compare(*b.CreatedAt)
+		})
+	}
+
+	// Messages are read back ordered by created_at, so every message must be stored after the previous one
+	var after time.Time
+	if thread.LastMessageID != "" {
+		last, err := repo.GetMessageByID(ctx, in.ProjectID, in.Namespace, thread.LastMessageID)
+		if err != nil {
+			return nil, err
+		}
+		after = last.CreatedAt
+	}
+
+	result := &AppendMessagesResult{
+		ConversationID: conversation.ConversationID,
+		ThreadID:       thread.ThreadID,
+		LastMessageID:  thread.LastMessageID,
+		Messages:       make([]AppendedMessage, 0, len(batch)),
+	}
+	messages := make([]ConversationMessage, 0, len(batch))
+	external := map[string]ExternalMessageID{}
+	for _, msg := range batch {
+		if msg.ExternalID != "" {
+			messageID, err := repo.GetMessageIDByExternalID(ctx, in.ProjectID, in.Namespace, msg.ExternalID)
+			if err == nil {
+				result.Messages = append(result.Messages, AppendedMessage{MessageID: messageID, ExternalID: msg.ExternalID, Skipped: true})
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+		}
+
+		message := ConversationMessage{
+			MessageID:      msg.MessageID,
+			ThreadID:       thread.ThreadID,
+			ConversationID: conversation.ConversationID,
+			Messages:       msg.Messages,
+			Meta:           msg.Meta,
+			CreatedAt:      time.Now(),
+		}
+		if message.MessageID == "" {
+			message.MessageID = s.newID(ctx)
+		} else if _, err := repo.GetMessageByID(ctx, in.ProjectID, in.Namespace, message.MessageID); err == nil {
+			// Writes to an existing message extend it, a batch only appends new messages
+			return nil, fmt.Errorf("%w: message '%s' exists already", ErrInvalidBatch, message.MessageID)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if msg.CreatedAt != nil {
+			message.CreatedAt = *msg.CreatedAt
+		}
+		// Timestamps are stored with microsecond precision
+		message.CreatedAt = message.CreatedAt.UTC().Truncate(time.Microsecond)
+		if !message.CreatedAt.After(after) {
+			if msg.CreatedAt != nil {
+				message.Meta = withSourceCreatedAt(msg.Meta, *msg.CreatedAt)
+			}
+			message.CreatedAt = after.Add(time.Microsecond)
+		}
+		after = message.CreatedAt
+
+		if msg.ExternalID != "" {
+			external[message.MessageID] = ExternalMessageID{
+				ProjectID:  in.ProjectID,
+				Namespace:  in.Namespace,
+				ExternalID: msg.ExternalID,
+			}
+		}
+		messages = append(messages, message)
+		result.Messages = append(result.Messages, AppendedMessage{
+			MessageID:  message.MessageID,
+			ExternalID: msg.ExternalID,
+			CreatedAt:  message.CreatedAt,
+		})
+	}
+
+	if len(messages) == 0 {
+		if newThread {
+			result.ThreadID = ""
+		}
+		return result, nil
+	}
+
+	previousMessageID := thread.LastMessageID
+	last := messages[len(messages)-1]
+	thread.LastMessageID = last.MessageID
+	thread.LastUpdated = time.Now()
+	// The meta of the thread is that of its last message, along with its takeover state
+	thread.Meta = withTakeover(last.Meta, thread.Meta)
+	conversation.LastUpdated = time.Now()
+
+	if err := repo.AppendMessages(ctx, conversation, thread, newThread, previousMessageID, messages, external); err != nil {
+		return nil, err
+	}
+
+	result.LastMessageID = thread.LastMessageID
+	return result, nil
+}
+
+func validateBatch(in *AppendMessagesRequest) error {
+	if len(in.Messages) == 0 {
+		return fmt.Errorf("%w: messages are required", ErrInvalidBatch)
+	}
+	if len(in.Messages) > MaxBatchMessages {
+		return fmt.Errorf("%w: at most %d messages can be appended at once", ErrInvalidBatch, MaxBatchMessages)
+	}
+
+	switch in.Order {
+	case "":
+		in.Order = MessageOrderGiven
+	case MessageOrderGiven, MessageOrderCreatedAt:
+	default:
+		return fmt.Errorf("%w: unsupported order '%s'", ErrInvalidBatch, in.Order)
+	}
+
+	messageIDs := map[string]bool{}
+	externalIDs := map[string]bool{}
+	for i, msg := range in.Messages {
+		if len(msg.Messages) == 0 {
+			return fmt.Errorf("%w: message %d has no messages", ErrInvalidBatch, i)
+		}
+		if in.Order == MessageOrderCreatedAt && msg.CreatedAt == nil {
+			return fmt.Errorf("%w: message %d has no created_at, required by the created_at order", ErrInvalidBatch, i)
+		}
+		if msg.MessageID != "" {
+			if messageIDs[msg.MessageID] {
+				return fmt.Errorf("%w: duplicate message ID '%s'", ErrInvalidBatch, msg.MessageID)
+			}
+			messageIDs[msg.MessageID] = true
+		}
+		if msg.ExternalID != "" {
+			if externalIDs[msg.ExternalID] {
+				return fmt.Errorf("%w: duplicate external ID '%s'", ErrInvalidBatch, msg.ExternalID)
+			}
+			externalIDs[msg.ExternalID] = true
+		}
+	}
+
+	return nil
+}
+
+func withSourceCreatedAt(meta map[string]any, createdAt time.Time) map[string]any {
+	merged := make(map[string]any, len(meta)+1)
+	for k, v := range meta {
+		merged[k] = v
+	}
+	merged[batchMetaSourceCreatedAt] = createdAt.Format(time.RFC3339Nano)
+
+	return merged
+}
+
+// AppendMessages writes messages at the end of a thread in one transaction, creating the thread when newThread is
+// set. External IDs are keyed by message ID. ErrThreadMoved is returned when the last message of the thread is no
+// longer previousMessageID, and ErrExternalIDExists when an external ID was mapped concurrently.
+func (r *ConversationRepo) AppendMessages(ctx context.Context, conversation Conversation, thread Thread, newThread bool, previousMessageID string, messages []ConversationMessage, external map[string]ExternalMessageID) error {
+	projectID, err := r.projectOfConversation(ctx, conversation.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	metaJSON, err := json.Marshal(thread.Meta)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if newThread {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO threads (conversation_id, origin_message_id, thread_id, meta, created_at, last_updated)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, thread.ConversationID, thread.OriginMessageID, thread.ThreadID, metaJSON, thread.CreatedAt, thread.LastUpdated)
+		if err != nil {
+			return fmt.Errorf("failed to create thread %s: %w", thread.ThreadID, err)
+		}
+	}
+
+	for _, message := range messages {
+		var ext []ExternalMessageID
+		if e, ok := external[message.MessageID]; ok {
+			ext = append(ext, e)
+		}
+		if err := r.insertMessages(ctx, tx, projectID, message, ext...); err != nil {
+			return err
+		}
+	}
+
+	// Only move the thread forward from the message the batch was appended after
+	result, err := tx.ExecContext(ctx, `
+		UPDATE threads
+		SET last_message_id = $1, meta = $2, last_updated = $3
+		WHERE thread_id = $4 AND COALESCE(last_message_id, '') = $5
+	`, thread.LastMessageID, metaJSON, thread.LastUpdated, thread.ThreadID, previousMessageID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrThreadMoved
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE conversations SET last_updated = $1
+		WHERE conversation_id = $2 AND namespace_id = $3 AND project_id = $4
+	`, conversation.LastUpdated, conversation.ConversationID, conversation.NamespaceID, conversation.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
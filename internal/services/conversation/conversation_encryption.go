@@ -0,0 +1,289 @@
+package conversation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+var encryptedValueMarker = []byte(`"enc:v1:`)
+
+// encodeMessages marshals the messages of a row. With encryption enabled every message is encrypted on its own,
+// so the messages appended to a row later can be encrypted without reading it.
+func (r *ConversationRepo) encodeMessages(ctx context.Context, projectID uuid.UUID, messages []responses.InputMessageUnion) ([]byte, error) {
+	if !r.keyring.Enabled() {
+		return json.Marshal(messages)
+	}
+
+	encrypted := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		buf, err := json.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+
+		token, err := r.keyring.Encrypt(ctx, projectID, buf)
+		if err != nil {
+			return nil, err
+		}
+		encrypted = append(encrypted, token)
+	}
+
+	return json.Marshal(encrypted)
+}
+
+// decodeMessages unmarshals the messages of a row, which may hold encrypted and unencrypted messages
+func (r *ConversationRepo) decodeMessages(ctx context.Context, raw []byte) ([]responses.InputMessageUnion, error) {
+	var messages []responses.InputMessageUnion
+	if !bytes.Contains(raw, encryptedValueMarker) {
+		err := json.Unmarshal(raw, &messages)
+		return messages, err
+	}
+
+	var elements []utils.RawMessage
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return nil, err
+	}
+
+	for _, element := range elements {
+		msg, err := r.decodeMessage(ctx, element)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// decodeMessage unmarshals a message, decrypting it if it is encrypted
+func (r *ConversationRepo) decodeMessage(ctx context.Context, raw []byte) (responses.InputMessageUnion, error) {
+	var msg responses.InputMessageUnion
+	if len(raw) > 0 && raw[0] == '"' {
+		var token string
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return msg, err
+		}
+
+		plaintext, err := r.keyring.Decrypt(ctx, token)
+		if err != nil {
+			return msg, err
+		}
+		raw = plaintext
+	}
+
+	err := json.Unmarshal(raw, &msg)
+	return msg, err
+}
+
+// encodeSummary marshals a summary message, encrypted when encryption is enabled
+func (r *ConversationRepo) encodeSummary(ctx context.Context, projectID uuid.UUID, message responses.InputMessageUnion) ([]byte, error) {
+	buf, err := json.Marshal(message)
+	if err != nil || !r.keyring.Enabled() {
+		return buf, err
+	}
+
+	token, err := r.keyring.Encrypt(ctx, projectID, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(token)
+}
+
+// projectOfConversation returns the project new data of a conversation is encrypted for
+func (r *ConversationRepo) projectOfConversation(ctx context.Context, conversationID string) (uuid.UUID, error) {
+	if !r.keyring.Enabled() {
+		return uuid.Nil, nil
+	}
+
+	var projectID uuid.UUID
+	err := r.db.GetContext(ctx, &projectID, `SELECT project_id FROM conversations WHERE conversation_id = $1`, conversationID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get project of conversation %s: %w", conversationID, err)
+	}
+	return projectID, nil
+}
+
+// projectOfThread returns the project new data of a thread is encrypted for
+func (r *ConversationRepo) projectOfThread(ctx context.Context, threadID string) (uuid.UUID, error) {
+	if !r.keyring.Enabled() {
+		return uuid.Nil, nil
+	}
+
+	var projectID uuid.UUID
+	err := r.db.GetContext(ctx, &projectID, `
+		SELECT c.project_id FROM threads t
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE t.thread_id = $1
+	`, threadID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get project of thread %s: %w", threadID, err)
+	}
+	return projectID, nil
+}
+
+// projectOfSummary returns the project new data of a summary is encrypted for
+func (r *ConversationRepo) projectOfSummary(ctx context.Context, summaryID string) (uuid.UUID, error) {
+	if !r.keyring.Enabled() {
+		return uuid.Nil, nil
+	}
+
+	var projectID uuid.UUID
+	err := r.db.GetContext(ctx, &projectID, `
+		SELECT c.project_id FROM summaries s
+		JOIN threads t ON s.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE s.id = $1
+	`, summaryID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get project of summary %s: %w", summaryID, err)
+	}
+	return projectID, nil
+}
+
+// ReencryptMessages encrypts the messages that are not encrypted with the active data key of their project with
+// it, including those written before encryption was enabled. It returns the number of rows it rewrote.
+func (r *ConversationRepo) ReencryptMessages(ctx context.Context, batchSize int) (int, error) {
+	query := `
+		SELECT m.id, c.project_id, m.messages
+		FROM messages m
+		JOIN conversations c ON m.conversation_id = c.conversation_id
+		WHERE m.id > $1
+		ORDER BY m.id
+		LIMIT $2
+	`
+
+	rewritten := 0
+	after := ""
+	for {
+		var rows []struct {
+			ID        string           `db:"id"`
+			ProjectID uuid.UUID        `db:"project_id"`
+			Messages  utils.RawMessage `db:"messages"`
+		}
+		if err := r.db.SelectContext(ctx, &rows, query, after, batchSize); err != nil {
+			return rewritten, fmt.Errorf("failed to list messages: %w", err)
+		}
+
+		for _, row := range rows {
+			current, err := r.messagesAreCurrent(ctx, row.ProjectID, row.Messages)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to check message %s: %w", row.ID, err)
+			}
+			if current {
+				continue
+			}
+
+			messages, err := r.decodeMessages(ctx, row.Messages)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to decrypt message %s: %w", row.ID, err)
+			}
+
+			encoded, err := r.encodeMessages(ctx, row.ProjectID, messages)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to encrypt message %s: %w", row.ID, err)
+			}
+
+			// A row that was appended to in the meantime is left for the next run
+			result, err := r.db.ExecContext(ctx, `UPDATE messages SET messages = $2 WHERE id = $1 AND messages = $3`, row.ID, encoded, []byte(row.Messages))
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to update message %s: %w", row.ID, err)
+			}
+			if n, err := result.RowsAffected(); err == nil && n > 0 {
+				rewritten++
+			}
+		}
+
+		if len(rows) < batchSize {
+			return rewritten, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+func (r *ConversationRepo) messagesAreCurrent(ctx context.Context, projectID uuid.UUID, raw []byte) (bool, error) {
+	var elements []any
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return false, err
+	}
+
+	for _, element := range elements {
+		token, ok := element.(string)
+		if !ok {
+			return false, nil
+		}
+		if current, err := r.keyring.IsCurrent(ctx, projectID, token); err != nil || !current {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ReencryptSummaries is ReencryptMessages for summaries
+func (r *ConversationRepo) ReencryptSummaries(ctx context.Context, batchSize int) (int, error) {
+	query := `
+		SELECT s.id, c.project_id, s.summary_message
+		FROM summaries s
+		JOIN threads t ON s.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE s.id > $1
+		ORDER BY s.id
+		LIMIT $2
+	`
+
+	rewritten := 0
+	after := ""
+	for {
+		var rows []struct {
+			ID             string           `db:"id"`
+			ProjectID      uuid.UUID        `db:"project_id"`
+			SummaryMessage utils.RawMessage `db:"summary_message"`
+		}
+		if err := r.db.SelectContext(ctx, &rows, query, after, batchSize); err != nil {
+			return rewritten, fmt.Errorf("failed to list summaries: %w", err)
+		}
+
+		for _, row := range rows {
+			var token string
+			if json.Unmarshal(row.SummaryMessage, &token) == nil {
+				current, err := r.keyring.IsCurrent(ctx, row.ProjectID, token)
+				if err != nil {
+					return rewritten, fmt.Errorf("failed to check summary %s: %w", row.ID, err)
+				}
+				if current {
+					continue
+				}
+			}
+
+			message, err := r.decodeMessage(ctx, row.SummaryMessage)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to decrypt summary %s: %w", row.ID, err)
+			}
+
+			encoded, err := r.encodeSummary(ctx, row.ProjectID, message)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to encrypt summary %s: %w", row.ID, err)
+			}
+
+			result, err := r.db.ExecContext(ctx, `UPDATE summaries SET summary_message = $2 WHERE id = $1 AND summary_message = $3`, row.ID, encoded, []byte(row.SummaryMessage))
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to update summary %s: %w", row.ID, err)
+			}
+			if n, err := result.RowsAffected(); err == nil && n > 0 {
+				rewritten++
+			}
+		}
+
+		if len(rows) < batchSize {
+			return rewritten, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
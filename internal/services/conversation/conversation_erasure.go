@@ -0,0 +1,161 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SubjectErasure counts what was erased for a data subject
+type SubjectErasure struct {
+	Namespaces    []string `json:"namespaces"`
+	Messages      int64    `json:"messages"`
+	Attachments   int64    `json:"attachments"`
+	Summaries     int64    `json:"summaries"`
+	Threads       int64    `json:"threads"`
+	Conversations int64    `json:"conversations"`
+	SpooledWrites int64    `json:"spooled_writes"`
+}
+
+// EraseSubject erases the messages of a project whose meta holds the subject under the key, in all namespaces.
+// The summaries of their threads are deleted as well, as they may hold the content of the messages. With
+// anonymize, the messages are kept without their content and the key is removed from their meta, so that the
+// threads stay intact. Otherwise the messages are deleted, along with the threads and conversations left empty.
+func (s *ConversationService) EraseSubject(ctx context.Context, projectID uuid.UUID, key string, subject string, anonymize bool) (*SubjectErasure, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.EraseSubject(ctx, projectID, key, subject, anonymize)
+}
+
+// EraseSubject is ConversationService.EraseSubject for the database of the repo
+func (r *ConversationRepo) EraseSubject(ctx context.Context, projectID uuid.UUID, key string, subject string, anonymize bool) (*SubjectErasure, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var rows []struct {
+		ID             string           `db:"id"`
+		ThreadID       string           `db:"thread_id"`
+		ConversationID string           `db:"conversation_id"`
+		Namespace      string           `db:"namespace_id"`
+		Messages       utils.RawMessage `db:"messages"`
+	}
+	err = tx.SelectContext(ctx, &rows, `
+		SELECT m.id, m.thread_id, m.conversation_id, c.namespace_id, m.messages
+		FROM messages m
+		JOIN conversations c ON m.conversation_id = c.conversation_id
+		WHERE c.project_id = $1 AND m.meta->>$2 = $3
+		FOR UPDATE OF m
+	`, projectID, key, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find messages of subject: %w", err)
+	}
+
+	erasure := &SubjectErasure{Namespaces: []string{}}
+	if len(rows) == 0 {
+		return erasure, nil
+	}
+
+	var messageIDs, threadIDs, conversationIDs []string
+	for _, row := range rows {
+		messages, err := r.decodeMessages(ctx, row.Messages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message %s: %w", row.ID, err)
+		}
+		erasure.Attachments += int64(countAttachments(messages))
+
+		messageIDs = append(messageIDs, row.ID)
+		if !slices.Contains(threadIDs, row.ThreadID) {
+			threadIDs = append(threadIDs, row.ThreadID)
+		}
+		if !slices.Contains(conversationIDs, row.ConversationID) {
+			conversationIDs = append(conversationIDs, row.ConversationID)
+		}
+		if !slices.Contains(erasure.Namespaces, row.Namespace) {
+			erasure.Namespaces = append(erasure.Namespaces, row.Namespace)
+		}
+	}
+	erasure.Messages = int64(len(messageIDs))
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM summaries WHERE thread_id = ANY($1)`, pq.Array(threadIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete summaries: %w", err)
+	}
+	erasure.Summaries, _ = result.RowsAffected()
+
+	if anonymize {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE messages
+			SET messages = '[]'::jsonb, meta = (COALESCE(meta, '{}'::jsonb) - $2) || '{"erased": true}'::jsonb
+			WHERE id = ANY($1)
+		`, pq.Array(messageIDs), key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to anonymize messages: %w", err)
+		}
+
+		return erasure, tx.Commit()
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM message_external_ids WHERE message_id = ANY($1)`, pq.Array(messageIDs)); err != nil {
+		return nil, fmt.Errorf("failed to delete external message IDs: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM messages WHERE id = ANY($1)`, pq.Array(messageIDs)); err != nil {
+		return nil, fmt.Errorf("failed to delete messages: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		DELETE FROM threads t
+		WHERE t.thread_id = ANY($1) AND NOT EXISTS (SELECT 1 FROM messages m WHERE m.thread_id = t.thread_id)
+	`, pq.Array(threadIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete threads: %w", err)
+	}
+	erasure.Threads, _ = result.RowsAffected()
+
+	result, err = tx.ExecContext(ctx, `
+		DELETE FROM conversations c
+		WHERE c.conversation_id = ANY($1) AND NOT EXISTS (SELECT 1 FROM threads t WHERE t.conversation_id = c.conversation_id)
+	`, pq.Array(conversationIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete conversations: %w", err)
+	}
+	erasure.Conversations, _ = result.RowsAffected()
+
+	return erasure, tx.Commit()
+}
+
+// countAttachments counts the images and files the messages carry
+func countAttachments(messages []responses.InputMessageUnion) int {
+	count := 0
+	countContent := func(content responses.InputContent) {
+		for _, part := range content {
+			if part.OfInputImage != nil {
+				count++
+			}
+		}
+	}
+
+	for _, msg := range messages {
+		switch {
+		case msg.OfEasyInput != nil:
+			countContent(msg.OfEasyInput.Content.OfInputMessageList)
+		case msg.OfInputMessage != nil:
+			countContent(msg.OfInputMessage.Content)
+		case msg.OfImageGenerationCall != nil:
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,429 @@
+package conversation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// ImportFormat identifies the format of a conversation export
+type ImportFormat string
+
+const (
+	ImportFormatOpenAI    ImportFormat = "openai"    // conversations.json of a ChatGPT data export
+	ImportFormatAnthropic ImportFormat = "anthropic" // conversations.json of a Claude data export, or a console conversation
+)
+
+// ImportedConversation is a conversation of an export converted into runs of native messages. A run starts
+// with a message of the user and holds the replies to it.
+type ImportedConversation struct {
+	ExternalID string
+	Name       string
+	Runs       []ImportedRun
+}
+
+// ImportedRun is a run of an imported conversation, stored as a single message
+type ImportedRun struct {
+	ExternalID string
+	Messages   []responses.InputMessageUnion
+}
+
+// ImportResult describes a conversation created or extended by an import
+type ImportResult struct {
+	ExternalID     string `json:"external_id"`
+	ConversationID string `json:"conversation_id"`
+	Name           string `json:"name"`
+	Runs           int    `json:"runs"`
+	LastMessageID  string `json:"last_message_id"`
+}
+
+// ParseConversationExport converts an export of the given format into conversations of native messages
+func ParseConversationExport(format ImportFormat, data []byte) ([]ImportedConversation, error) {
+	switch format {
+	case ImportFormatOpenAI:
+		return parseOpenAIExport(data)
+	case ImportFormatAnthropic:
+		return parseAnthropicExport(data)
+	}
+
+	return nil, fmt.Errorf("unsupported import format '%s'", format)
+}
+
+// ImportConversations stores the conversations of an export in the namespace. Every run is written with an
+// external ID derived from the export, so importing the same export again only adds the runs that are new.
+func (s *ConversationService) ImportConversations(ctx context.Context, projectID uuid.UUID, namespace string, conversations []ImportedConversation) ([]ImportResult, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []ImportResult{}
+	for _, conv := range conversations {
+		if len(conv.Runs) == 0 {
+			continue
+		}
+
+		result := ImportResult{ExternalID: conv.ExternalID, Name: conv.Name}
+
+		// Continue the conversation of an earlier import of the same export
+		messageID, err := repo.GetMessageIDByExternalID(ctx, projectID, namespace, conv.Runs[0].ExternalID)
+		if err == nil {
+			message, err := repo.GetMessageByID(ctx, projectID, namespace, messageID)
+			if err != nil {
+				return nil, err
+			}
+			result.ConversationID = message.ConversationID
+		} else if errors.Is(err, sql.ErrNoRows) {
+			created, err := s.CreateConversation(ctx, projectID, namespace, conv.Name)
+			if err != nil {
+				return nil, err
+			}
+			result.ConversationID = created.ConversationID
+		} else {
+			return nil, err
+		}
+
+		previousMessageID := ""
+		for _, run := range conv.Runs {
+			req := &AddMessageRequest{
+				ProjectID:         projectID,
+				Namespace:         namespace,
+				PreviousMessageID: previousMessageID,
+				ConversationID:    result.ConversationID,
+				Messages:          run.Messages,
+				Meta:              map[string]any{"imported": true},
+				ExternalID:        run.ExternalID,
+			}
+			if err := s.AddMessages(ctx, req); err != nil {
+				return nil, fmt.Errorf("failed to import conversation '%s': %w", conv.Name, err)
+			}
+			previousMessageID = req.MessageID
+		}
+
+		result.Runs = len(conv.Runs)
+		result.LastMessageID = previousMessageID
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// importRuns groups messages into runs, starting a run at every message of the user
+type importRuns struct {
+	prefix string
+	runs   []ImportedRun
+}
+
+func (r *importRuns) add(startsRun bool, externalID string, msg responses.InputMessageUnion) {
+	if startsRun || len(r.runs) == 0 {
+		r.runs = append(r.runs, ImportedRun{ExternalID: r.prefix + externalID})
+	}
+	run := &r.runs[len(r.runs)-1]
+	run.Messages = append(run.Messages, msg)
+}
+
+func importUserMessage(id, text string) responses.InputMessageUnion {
+	return responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+		ID:      id,
+		Role:    constants.RoleUser,
+		Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: text}}},
+	}}
+}
+
+func importAssistantMessage(id, text string) responses.InputMessageUnion {
+	return responses.InputMessageUnion{OfOutputMessage: &responses.OutputMessage{
+		Type:    "message",
+		ID:      id,
+		Role:    constants.RoleAssistant,
+		Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{Text: text, Annotations: []responses.Annotation{}}}},
+	}}
+}
+
+type openAIExportConversation struct {
+	ID             string                      `json:"id"`
+	ConversationID string                      `json:"conversation_id"`
+	Title          string                      `json:"title"`
+	CurrentNode    string                      `json:"current_node"`
+	Mapping        map[string]openAIExportNode `json:"mapping"`
+}
+
+type openAIExportNode struct {
+	ID       string               `json:"id"`
+	Parent   string               `json:"parent"`
+	Children []string             `json:"children"`
+	Message  *openAIExportMessage `json:"message"`
+}
+
+type openAIExportMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	Content struct {
+		ContentType string `json:"content_type"`
+		Parts       []any  `json:"parts"`
+		Text        string `json:"text"`
+	} `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+func (m *openAIExportMessage) text() string {
+	switch m.Content.ContentType {
+	case "text", "multimodal_text":
+		// Images and other attachments are not part of the export, only their references
+		parts := []string{}
+		for _, part := range m.Content.Parts {
+			if text, ok := part.(string); ok && strings.TrimSpace(text) != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	case "code":
+		return m.Content.Text
+	}
+	return ""
+}
+
+// parseOpenAIExport converts the conversations of a ChatGPT export. A conversation is a tree of messages
+// with a branch per edit or regeneration, only the branch that was last shown is imported.
+func parseOpenAIExport(data []byte) ([]ImportedConversation, error) {
+	var export []openAIExportConversation
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid ChatGPT export: %w", err)
+	}
+
+	out := []ImportedConversation{}
+	for _, conv := range export {
+		id := conv.ConversationID
+		if id == "" {
+			id = conv.ID
+		}
+
+		// Walk up from the current node, falling back to the first branch of every node
+		var path []string
+		if _, ok := conv.Mapping[conv.CurrentNode]; ok {
+			for node := conv.CurrentNode; node != ""; node = conv.Mapping[node].Parent {
+				if slices.Contains(path, node) {
+					return nil, fmt.Errorf("invalid ChatGPT export: conversation '%s' has a cycle", id)
+				}
+				path = append(path, node)
+			}
+			slices.Reverse(path)
+		} else {
+			for nodeID, node := range conv.Mapping {
+				if node.Parent == "" {
+					for next := nodeID; next != "" && !slices.Contains(path, next); {
+						path = append(path, next)
+						next = ""
+						if children := conv.Mapping[path[len(path)-1]].Children; len(children) > 0 {
+							next = children[0]
+						}
+					}
+					break
+				}
+			}
+		}
+
+		runs := &importRuns{prefix: "openai:" + id + ":"}
+		for _, nodeID := range path {
+			msg := conv.Mapping[nodeID].Message
+			if msg == nil {
+				continue
+			}
+			if hidden, _ := msg.Metadata["is_visually_hidden_from_conversation"].(bool); hidden {
+				continue
+			}
+
+			text := msg.text()
+			if text == "" {
+				continue
+			}
+
+			switch msg.Author.Role {
+			case "user":
+				runs.add(true, msg.ID, importUserMessage(msg.ID, text))
+			case "assistant":
+				runs.add(false, msg.ID, importAssistantMessage(msg.ID, text))
+			}
+		}
+
+		out = append(out, ImportedConversation{
+			ExternalID: "openai:" + id,
+			Name:       conv.Title,
+			Runs:       runs.runs,
+		})
+	}
+
+	return out, nil
+}
+
+type anthropicExportConversation struct {
+	UUID         string                   `json:"uuid"`
+	Name         string                   `json:"name"`
+	ChatMessages []anthropicExportMessage `json:"chat_messages"`
+}
+
+type anthropicExportMessage struct {
+	UUID    string                 `json:"uuid"`
+	Sender  string                 `json:"sender"`
+	Text    string                 `json:"text"`
+	Content []anthropicExportBlock `json:"content"`
+}
+
+// anthropicConsoleConversation is a conversation in the format of the Messages API
+type anthropicConsoleConversation struct {
+	Messages []struct {
+		Role    string `json:"role"`
+		Content any    `json:"content"`
+	} `json:"messages"`
+}
+
+type anthropicExportBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Input     any    `json:"input"`
+	ToolUseID string `json:"tool_use_id"`
+	Content   any    `json:"content"`
+}
+
+// parseAnthropicExport converts the conversations of a Claude data export, or a single conversation in the
+// format of the Messages API as exported from the console
+func parseAnthropicExport(data []byte) ([]ImportedConversation, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		var console anthropicConsoleConversation
+		if err := json.Unmarshal(data, &console); err != nil {
+			return nil, fmt.Errorf("invalid Anthropic console export: %w", err)
+		}
+
+		id := uuid.NewSHA1(uuid.NameSpaceOID, data).String()
+		runs := &importRuns{prefix: "anthropic:" + id + ":"}
+		for i, msg := range console.Messages {
+			var blocks []anthropicExportBlock
+			switch content := msg.Content.(type) {
+			case string:
+				blocks = []anthropicExportBlock{{Type: "text", Text: content}}
+			default:
+				buf, err := json.Marshal(content)
+				if err != nil {
+					return nil, err
+				}
+				if err := json.Unmarshal(buf, &blocks); err != nil {
+					return nil, fmt.Errorf("invalid Anthropic console export: %w", err)
+				}
+			}
+			addAnthropicBlocks(runs, fmt.Sprintf("%d", i), msg.Role == "user", blocks)
+		}
+
+		return []ImportedConversation{{ExternalID: "anthropic:" + id, Name: "Imported Conversation", Runs: runs.runs}}, nil
+	}
+
+	var export []anthropicExportConversation
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid Claude export: %w", err)
+	}
+
+	out := []ImportedConversation{}
+	for _, conv := range export {
+		runs := &importRuns{prefix: "anthropic:" + conv.UUID + ":"}
+		for _, msg := range conv.ChatMessages {
+			blocks := msg.Content
+			if len(blocks) == 0 && msg.Text != "" {
+				blocks = []anthropicExportBlock{{Type: "text", Text: msg.Text}}
+			}
+			addAnthropicBlocks(runs, msg.UUID, msg.Sender == "human", blocks)
+		}
+
+		out = append(out, ImportedConversation{
+			ExternalID: "anthropic:" + conv.UUID,
+			Name:       conv.Name,
+			Runs:       runs.runs,
+		})
+	}
+
+	return out, nil
+}
+
+// addAnthropicBlocks adds the content blocks of a message. Tool calls are only imported along with their
+// IDs, the results of the calls refer to them.
+func addAnthropicBlocks(runs *importRuns, id string, fromUser bool, blocks []anthropicExportBlock) {
+	texts := []string{}
+	var calls []responses.InputMessageUnion
+	for i, block := range blocks {
+		switch block.Type {
+		case "text":
+			if strings.TrimSpace(block.Text) != "" {
+				texts = append(texts, block.Text)
+			}
+		case "tool_use":
+			if block.ID == "" {
+				continue
+			}
+			arguments, err := json.MarshalString(block.Input)
+			if err != nil {
+				arguments = "{}"
+			}
+			calls = append(calls, responses.InputMessageUnion{OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        fmt.Sprintf("%s-%d", id, i),
+				CallID:    block.ID,
+				Name:      block.Name,
+				Arguments: arguments,
+			}})
+		case "tool_result":
+			if block.ToolUseID == "" {
+				continue
+			}
+			calls = append(calls, responses.InputMessageUnion{OfFunctionCallOutput: &responses.FunctionCallOutputMessage{
+				ID:     fmt.Sprintf("%s-%d", id, i),
+				CallID: block.ToolUseID,
+				Output: responses.FunctionCallOutputContentUnion{OfString: utils.Ptr(anthropicToolResultText(block.Content))},
+			}})
+		}
+	}
+
+	text := strings.Join(texts, "\n")
+	if fromUser {
+		// Tool results are sent as user messages, they continue the run
+		for _, call := range calls {
+			runs.add(false, id, call)
+		}
+		if text != "" {
+			runs.add(true, id, importUserMessage(id, text))
+		}
+		return
+	}
+
+	if text != "" {
+		runs.add(false, id, importAssistantMessage(id, text))
+	}
+	for _, call := range calls {
+		runs.add(false, id, call)
+	}
+}
+
+func anthropicToolResultText(content any) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []any:
+		texts := []string{}
+		for _, block := range c {
+			if b, ok := block.(map[string]any); ok && b["type"] == "text" {
+				texts = append(texts, fmt.Sprint(b["text"]))
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
@@ -35,6 +35,17 @@ type ConversationMessage struct {
 	ConversationID string                        `json:"conversation_id" db:"conversation_id"`
 	Messages       []responses.InputMessageUnion `json:"messages" db:"messages"`
 	Meta           map[string]any                `json:"meta" db:"meta"`
+	CreatedAt      time.Time                     `json:"created_at" db:"created_at"`
+}
+
+// MetaPinned is the meta key of the messages pinned in their thread. Their runs are always part of the history the
+// agent is given, even once older runs are summarized or dropped.
+const MetaPinned = "pinned"
+
+// Pinned tells whether the message is pinned in its thread
+func (m ConversationMessage) Pinned() bool {
+	pinned, _ := m.Meta[MetaPinned].(bool)
+	return pinned
 }
 
 // Summary represents a conversation summary stored in the summaries table
@@ -47,6 +58,23 @@ type Summary struct {
 	Meta                    map[string]any              `json:"meta" db:"meta"`
 }
 
+// SummaryCoverage is a summary along with what it condenses: the summary it builds on, if any, and the
+// messages after it up to the last summarized message
+type SummaryCoverage struct {
+	Summary         Summary               `json:"summary"`
+	PreviousSummary *Summary              `json:"previous_summary,omitempty"`
+	Messages        []ConversationMessage `json:"messages"`
+}
+
+// ExternalMessageID maps an ID of an integrator's own chat store to a message
+type ExternalMessageID struct {
+	ProjectID  uuid.UUID `json:"project_id" db:"project_id"`
+	Namespace  string    `json:"namespace" db:"namespace"`
+	ExternalID string    `json:"external_id" db:"external_id"`
+	MessageID  string    `json:"message_id" db:"message_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
 type AddMessageRequest struct {
 	ProjectID         uuid.UUID                     `json:"project_id"`
 	Namespace         string                        `json:"namespace"`
@@ -55,6 +83,7 @@ type AddMessageRequest struct {
 	Messages          []responses.InputMessageUnion `json:"messages"`
 	Meta              map[string]any                `json:"meta"`
 	ConversationID    string                        `json:"conversation_id"`
+	ExternalID        string                        `json:"external_id,omitempty"` // Client ID of the write, retries with the same ID are applied once
 }
 
 type GetMessagesRequest struct {
@@ -64,3 +93,79 @@ type GetMessagesRequest struct {
 	Offset            int       `json:"offset"`
 	Limit             int       `json:"limit"`
 }
+
+// RunTrace is a structured view of a single run assembled from the persisted messages and run state
+type RunTrace struct {
+	MessageID      string                        `json:"message_id"`
+	ThreadID       string                        `json:"thread_id"`
+	ConversationID string                        `json:"conversation_id"`
+	TraceID        string                        `json:"trace_id,omitempty"`
+	Status         string                        `json:"status"`
+	Usage          responses.Usage               `json:"usage"`
+	DurationMs     int64                         `json:"duration_ms"`
+	Input          []responses.InputMessageUnion `json:"input"`
+	Loops          []RunTraceLoop                `json:"loops"`
+	Approvals      []RunTraceApproval            `json:"approvals"`
+	Errors         []RunTraceError               `json:"errors"`
+
+	// GatewayRequests are the requests to the gateway that no LLM call of the run covers, e.g. the summaries
+	GatewayRequests []RunTraceGatewayRequest `json:"gateway_requests,omitempty"`
+}
+
+// RunTraceLoop represents one iteration of the agent loop: an LLM call followed by the tool calls it requested
+type RunTraceLoop struct {
+	Index     int                `json:"index"`
+	LLMCall   RunTraceLLMCall    `json:"llm_call"`
+	ToolCalls []RunTraceToolCall `json:"tool_calls"`
+}
+
+// RunTraceLLMCall represents a single LLM call within a loop
+type RunTraceLLMCall struct {
+	StartedAt  *time.Time                    `json:"started_at,omitempty"`
+	DurationMs int64                         `json:"duration_ms"`
+	Usage      *responses.Usage              `json:"usage,omitempty"`
+	Output     []responses.InputMessageUnion `json:"output"`
+
+	// GatewayRequests are the requests the call made to the gateway, including the retries and fallbacks
+	GatewayRequests []RunTraceGatewayRequest `json:"gateway_requests,omitempty"`
+}
+
+// RunTraceGatewayRequest represents a request to a provider recorded in the request log of the gateway
+type RunTraceGatewayRequest struct {
+	SpanID       string    `json:"span_id"`
+	Name         string    `json:"name"` // e.g. LLM.StreamingResponses
+	Provider     string    `json:"provider,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	DurationMs   float64   `json:"duration_ms"`
+	InputTokens  int       `json:"input_tokens,omitempty"`
+	OutputTokens int       `json:"output_tokens,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// RunTraceToolCall represents a tool call requested by the LLM and its result
+type RunTraceToolCall struct {
+	CallID     string                                    `json:"call_id"`
+	Name       string                                    `json:"name"`
+	Arguments  string                                    `json:"arguments"`
+	Result     *responses.FunctionCallOutputContentUnion `json:"result,omitempty"`
+	StartedAt  *time.Time                                `json:"started_at,omitempty"`
+	DurationMs int64                                     `json:"duration_ms"`
+	Error      string                                    `json:"error,omitempty"`
+}
+
+// RunTraceApproval represents a human approval decision that resumed the run
+type RunTraceApproval struct {
+	Loop            int        `json:"loop"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	ApprovedCallIDs []string   `json:"approved_call_ids"`
+	RejectedCallIDs []string   `json:"rejected_call_ids"`
+}
+
+// RunTraceError represents an error recorded during the run
+type RunTraceError struct {
+	Loop    int    `json:"loop"`
+	Step    string `json:"step"`
+	CallID  string `json:"call_id,omitempty"`
+	Message string `json:"message"`
+}
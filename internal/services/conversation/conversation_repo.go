@@ -2,11 +2,17 @@ package conversation
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/internal/encryption"
+	"github.com/curaious/uno/internal/services/outbox"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/llm/responses"
 	"github.com/google/uuid"
@@ -14,14 +20,48 @@ import (
 )
 
 type ConversationRepo struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	replicas *db.ReplicaPool
+	keyring  *encryption.Keyring
+	region   string
 }
 
-func NewConversationRepo(db *sqlx.DB) *ConversationRepo {
-	return &ConversationRepo{db: db}
+// NewConversationRepo creates the repo. If keyring is non-nil, message bodies and summaries are encrypted at rest.
+// If replicas is non-nil, long history reads are served by a read replica.
+func NewConversationRepo(conn *sqlx.DB, keyring *encryption.Keyring, replicas ...*db.ReplicaPool) *ConversationRepo {
+	repo := &ConversationRepo{db: conn, keyring: keyring}
+	if len(replicas) > 0 {
+		repo.replicas = replicas[0]
+	}
+	return repo
+}
+
+// NewRegionalConversationRepo creates the repo for the database of a region, which holds the conversations of the
+// projects pinned to the region
+func NewRegionalConversationRepo(conn *sqlx.DB, keyring *encryption.Keyring, region string) *ConversationRepo {
+	return &ConversationRepo{db: conn, keyring: keyring, region: region}
+}
+
+// reader returns the connection to serve history reads from, a replica if one is available and caught up
+func (r *ConversationRepo) reader() *sqlx.DB {
+	if replica := r.replicas.Reader(); replica != nil {
+		return replica
+	}
+	return r.db
 }
 
 func (r *ConversationRepo) CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error) {
+	// The projects live in the primary database, the database of a region only references them
+	if r.region != "" {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO projects (id, name) VALUES ($1, $2)
+			ON CONFLICT (id) DO NOTHING
+		`, conversation.ProjectID, conversation.ProjectID.String())
+		if err != nil {
+			return Conversation{}, fmt.Errorf("failed to reference project in region %s: %w", r.region, err)
+		}
+	}
+
 	query := `
 		INSERT INTO conversations (project_id, namespace_id, conversation_id, name, created_at, last_updated)
 		VALUES ($1, $2, $3, $4, $5, $6)
@@ -108,27 +148,52 @@ func (r *ConversationRepo) CreateThread(ctx context.Context, thread Thread) (Thr
 	return thread, err
 }
 
-func (r *ConversationRepo) CreateMessages(ctx context.Context, message ConversationMessage) error {
+// ErrExternalIDExists is returned when the external ID of a write is already mapped to a message
+var ErrExternalIDExists = errors.New("external message ID already exists")
+
+// CreateMessages appends the messages to the message with the ID of the given message, creating it if needed.
+// With an external ID, the ID is mapped to the message in the same transaction, and ErrExternalIDExists
+// is returned without writing anything when it is mapped already.
+func (r *ConversationRepo) CreateMessages(ctx context.Context, message ConversationMessage, external ...ExternalMessageID) error {
 	if len(message.Messages) == 0 {
 		return nil
 	}
 
+	projectID, err := r.projectOfConversation(ctx, message.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	message.CreatedAt = time.Now()
+	if err := r.insertMessages(ctx, tx, projectID, message, external...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertMessages writes a message, its external IDs and its outbox event in the transaction
+func (r *ConversationRepo) insertMessages(ctx context.Context, tx *sqlx.Tx, projectID uuid.UUID, message ConversationMessage, external ...ExternalMessageID) error {
 	query := `
 		INSERT INTO messages (id, thread_id, conversation_id, messages, meta, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6) 
 		ON CONFLICT (id) DO UPDATE
 		SET
     		messages = messages.messages || EXCLUDED.messages,
-    		meta     = EXCLUDED.meta;
+    		meta     = CASE
+    			WHEN messages.meta -> 'pinned' IS NOT NULL AND jsonb_typeof(EXCLUDED.meta) = 'object'
+    			THEN EXCLUDED.meta || jsonb_build_object('pinned', messages.meta -> 'pinned')
+    			ELSE EXCLUDED.meta
+    		END;
 	`
 
-	stmt, err := r.db.PreparexContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
-
-	messagesJSON, err := json.Marshal(message.Messages)
+	messagesJSON, err := r.encodeMessages(ctx, projectID, message.Messages)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message content: %w", err)
 	}
@@ -138,24 +203,58 @@ func (r *ConversationRepo) CreateMessages(ctx context.Context, message Conversat
 		return err
 	}
 
-	_, err = stmt.ExecContext(ctx,
+	_, err = tx.ExecContext(ctx, query,
 		message.MessageID,
 		message.ThreadID,
 		message.ConversationID,
 		messagesJSON,
 		metaJSON,
-		time.Now(),
+		message.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert message %s: %w", message.MessageID, err)
 	}
 
-	return nil
+	for _, ext := range external {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO message_external_ids (project_id, namespace, external_id, message_id, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (project_id, namespace, external_id) DO NOTHING
+		`, ext.ProjectID, ext.Namespace, ext.ExternalID, message.MessageID, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to map external ID %s: %w", ext.ExternalID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return ErrExternalIDExists
+		}
+	}
+
+	// The event is committed together with the messages. Retried writes of the same messages share the dedup key.
+	sum := sha256.Sum256(messagesJSON)
+	dedupKey := fmt.Sprintf("messages:%s:%s", message.MessageID, hex.EncodeToString(sum[:8]))
+	return outbox.Enqueue(ctx, tx, outbox.TopicMessagesCreated, dedupKey, outbox.MessagesCreatedPayload{
+		ConversationID: message.ConversationID,
+		ThreadID:       message.ThreadID,
+		MessageID:      message.MessageID,
+		Count:          len(message.Messages),
+	})
+}
+
+// GetMessageIDByExternalID returns the ID of the message an external ID is mapped to
+func (r *ConversationRepo) GetMessageIDByExternalID(ctx context.Context, projectID uuid.UUID, namespace string, externalID string) (string, error) {
+	query := `
+		SELECT message_id FROM message_external_ids
+		WHERE project_id = $1 AND namespace = $2 AND external_id = $3
+	`
+
+	var messageID string
+	err := r.db.GetContext(ctx, &messageID, query, projectID, namespace, externalID)
+	return messageID, err
 }
 
 func (r *ConversationRepo) GetMessageByID(ctx context.Context, projectID uuid.UUID, namespace string, ID string) (ConversationMessage, error) {
 	query := `
-		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta
+		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta, m.created_at
 		FROM messages m
 		JOIN threads t ON m.thread_id = t.thread_id
 		JOIN conversations c ON t.conversation_id = c.conversation_id
@@ -168,6 +267,7 @@ func (r *ConversationRepo) GetMessageByID(ctx context.Context, projectID uuid.UU
 		ConversationID string           `db:"conversation_id"`
 		Messages       utils.RawMessage `db:"messages"`
 		Meta           utils.RawMessage `db:"meta"`
+		CreatedAt      time.Time        `db:"created_at"`
 	}
 
 	err := r.db.GetContext(ctx, &result, query, ID, namespace, projectID)
@@ -175,8 +275,7 @@ func (r *ConversationRepo) GetMessageByID(ctx context.Context, projectID uuid.UU
 		return ConversationMessage{}, err
 	}
 
-	var messages []responses.InputMessageUnion
-	err = json.Unmarshal(result.Messages, &messages)
+	messages, err := r.decodeMessages(ctx, result.Messages)
 	if err != nil {
 		return ConversationMessage{}, fmt.Errorf("failed to unmarshal message content: %w", err)
 	}
@@ -193,6 +292,7 @@ func (r *ConversationRepo) GetMessageByID(ctx context.Context, projectID uuid.UU
 		ConversationID: result.ConversationID,
 		Messages:       messages,
 		Meta:           meta,
+		CreatedAt:      result.CreatedAt,
 	}
 
 	return message, nil
@@ -343,7 +443,7 @@ func (r *ConversationRepo) ListThreads(ctx context.Context, projectID uuid.UUID,
 
 func (r *ConversationRepo) GetThreadMessages(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, offset, limit int) ([]ConversationMessage, error) {
 	query := `
-		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta
+		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta, m.created_at
 		FROM messages m
 		JOIN threads t ON m.thread_id = t.thread_id
 		JOIN conversations c ON t.conversation_id = c.conversation_id
@@ -353,7 +453,7 @@ func (r *ConversationRepo) GetThreadMessages(ctx context.Context, projectID uuid
 	`
 
 	messages := []ConversationMessage{}
-	results, err := r.db.QueryContext(ctx, query, threadID, namespace, projectID, limit, offset)
+	results, err := r.reader().QueryContext(ctx, query, threadID, namespace, projectID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -364,12 +464,12 @@ func (r *ConversationRepo) GetThreadMessages(ctx context.Context, projectID uuid
 		rawMessages := []byte{}
 		rawMeta := []byte{}
 
-		err = results.Scan(&message.MessageID, &message.ThreadID, &message.ConversationID, &rawMessages, &rawMeta)
+		err = results.Scan(&message.MessageID, &message.ThreadID, &message.ConversationID, &rawMessages, &rawMeta, &message.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 
-		err = json.Unmarshal(rawMessages, &message.Messages)
+		message.Messages, err = r.decodeMessages(ctx, rawMessages)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
 		}
@@ -391,6 +491,17 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 		return []ConversationMessage{}, nil
 	}
 
+	reader := r.reader()
+	messages, err := r.getAllMessagesTillRun(ctx, reader, projectID, namespace, previousMessageID)
+	// The previous message may have been written moments ago and not be replicated yet
+	if errors.Is(err, sql.ErrNoRows) && reader != r.db {
+		return r.getAllMessagesTillRun(ctx, r.db, projectID, namespace, previousMessageID)
+	}
+
+	return messages, err
+}
+
+func (r *ConversationRepo) getAllMessagesTillRun(ctx context.Context, conn *sqlx.DB, projectID uuid.UUID, namespace string, previousMessageID string) ([]ConversationMessage, error) {
 	// First, find the thread ID for the given previous message ID
 	var thread Thread
 	queryThread := `
@@ -401,18 +512,24 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 		WHERE m.id = $1 AND c.namespace_id = $2 AND c.project_id = $3
 	`
 
-	err := r.db.GetContext(ctx, &thread, queryThread, previousMessageID, namespace, projectID)
+	err := conn.GetContext(ctx, &thread, queryThread, previousMessageID, namespace, projectID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Optimization: Try to load from latest summary in summaries table
 	// Check for summary preceding the previous message
-	summary, err := r.GetLatestSummaryBeforeMessage(ctx, projectID, namespace, thread.ThreadID, previousMessageID)
+	summary, err := r.getLatestSummaryBeforeMessage(ctx, conn, projectID, namespace, thread.ThreadID, previousMessageID)
 	if err == nil && summary.ID != "" {
 		// Found summary. Fetch messages between the summarized point and the previous message
-		msgsBetween, err := r.getMessagesBetween(ctx, projectID, namespace, thread.ThreadID, summary.LastSummarizedMessageID, previousMessageID)
+		msgsBetween, err := r.getMessagesBetween(ctx, conn, projectID, namespace, thread.ThreadID, summary.LastSummarizedMessageID, previousMessageID)
 		if err == nil {
+			// The pinned messages covered by the summary are kept as they are, right after it
+			pinned, err := r.getPinnedMessagesThrough(ctx, conn, projectID, namespace, thread.ThreadID, summary.LastSummarizedMessageID)
+			if err != nil {
+				return nil, err
+			}
+
 			// Convert summary to ConversationMessage format and combine with messages between
 			summaryMsg := ConversationMessage{
 				MessageID:      summary.ID,
@@ -421,7 +538,8 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 				Messages:       []responses.InputMessageUnion{summary.SummaryMessage},
 				Meta:           summary.Meta,
 			}
-			return append([]ConversationMessage{summaryMsg}, msgsBetween...), nil
+			messages := append([]ConversationMessage{summaryMsg}, pinned...)
+			return append(messages, msgsBetween...), nil
 		}
 	}
 
@@ -439,7 +557,7 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 	`
 
 	messages := []ConversationMessage{}
-	results, err := r.db.QueryContext(ctx, query, thread.ThreadID, namespace, projectID, limit, offset)
+	results, err := conn.QueryContext(ctx, query, thread.ThreadID, namespace, projectID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -455,7 +573,7 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 			return nil, err
 		}
 
-		err = json.Unmarshal(rawMessages, &message.Messages)
+		message.Messages, err = r.decodeMessages(ctx, rawMessages)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
 		}
@@ -475,7 +593,7 @@ func (r *ConversationRepo) GetAllMessagesTillRun(ctx context.Context, projectID
 	return messages, nil
 }
 
-func (r *ConversationRepo) getMessagesBetween(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, startMessageID string, endMessageID string) ([]ConversationMessage, error) {
+func (r *ConversationRepo) getMessagesBetween(ctx context.Context, conn *sqlx.DB, projectID uuid.UUID, namespace string, threadID string, startMessageID string, endMessageID string) ([]ConversationMessage, error) {
 	query := `
 		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta
 		FROM messages m
@@ -490,7 +608,7 @@ func (r *ConversationRepo) getMessagesBetween(ctx context.Context, projectID uui
 	`
 
 	messages := []ConversationMessage{}
-	results, err := r.db.QueryContext(ctx, query, threadID, namespace, projectID, startMessageID, endMessageID)
+	results, err := conn.QueryContext(ctx, query, threadID, namespace, projectID, startMessageID, endMessageID)
 	if err != nil {
 		return nil, err
 	}
@@ -506,7 +624,7 @@ func (r *ConversationRepo) getMessagesBetween(ctx context.Context, projectID uui
 			return nil, err
 		}
 
-		err = json.Unmarshal(rawMessages, &message.Messages)
+		message.Messages, err = r.decodeMessages(ctx, rawMessages)
 		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
 		}
@@ -522,6 +640,79 @@ func (r *ConversationRepo) getMessagesBetween(ctx context.Context, projectID uui
 	return messages, nil
 }
 
+// getPinnedMessagesThrough returns the pinned messages of a thread up to the given message, included
+func (r *ConversationRepo) getPinnedMessagesThrough(ctx context.Context, conn *sqlx.DB, projectID uuid.UUID, namespace string, threadID string, endMessageID string) ([]ConversationMessage, error) {
+	query := `
+		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta
+		FROM messages m
+		JOIN threads t ON m.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		JOIN messages end_ref ON end_ref.id = $4
+		WHERE m.thread_id = $1 AND c.namespace_id = $2 AND c.project_id = $3
+		AND m.created_at <= end_ref.created_at
+		AND m.meta ->> 'pinned' = 'true'
+		ORDER BY m.created_at ASC
+	`
+
+	messages := []ConversationMessage{}
+	results, err := conn.QueryContext(ctx, query, threadID, namespace, projectID, endMessageID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	for results.Next() {
+		message := ConversationMessage{}
+		rawMessages := []byte{}
+		rawMeta := []byte{}
+
+		err = results.Scan(&message.MessageID, &message.ThreadID, &message.ConversationID, &rawMessages, &rawMeta)
+		if err != nil {
+			return nil, err
+		}
+
+		message.Messages, err = r.decodeMessages(ctx, rawMessages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
+		}
+
+		err = json.Unmarshal(rawMeta, &message.Meta)
+		if err != nil {
+			message.Meta = make(map[string]interface{})
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// SetMessagePinned pins a message in its thread, or unpins it
+func (r *ConversationRepo) SetMessagePinned(ctx context.Context, projectID uuid.UUID, namespace string, messageID string, pinned bool) error {
+	meta := `(CASE WHEN jsonb_typeof(m.meta) = 'object' THEN m.meta ELSE '{}'::jsonb END) - 'pinned'`
+	if pinned {
+		meta = `(CASE WHEN jsonb_typeof(m.meta) = 'object' THEN m.meta ELSE '{}'::jsonb END) || '{"pinned": true}'::jsonb`
+	}
+
+	query := `
+		UPDATE messages m SET meta = ` + meta + `
+		FROM threads t
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE m.id = $1 AND m.thread_id = t.thread_id AND c.namespace_id = $2 AND c.project_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, messageID, namespace, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to pin message %s: %w", messageID, err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 // CreateSummary saves a summary to the summaries table
 func (r *ConversationRepo) CreateSummary(ctx context.Context, summary Summary) error {
 	query := `
@@ -529,7 +720,12 @@ func (r *ConversationRepo) CreateSummary(ctx context.Context, summary Summary) e
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	summaryJSON, err := json.Marshal(summary.SummaryMessage)
+	projectID, err := r.projectOfThread(ctx, summary.ThreadID)
+	if err != nil {
+		return err
+	}
+
+	summaryJSON, err := r.encodeSummary(ctx, projectID, summary.SummaryMessage)
 	if err != nil {
 		return fmt.Errorf("failed to marshal summary message: %w", err)
 	}
@@ -556,6 +752,10 @@ func (r *ConversationRepo) CreateSummary(ctx context.Context, summary Summary) e
 
 // GetLatestSummaryBeforeMessage finds the latest summary for a thread that precedes the given message ID
 func (r *ConversationRepo) GetLatestSummaryBeforeMessage(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, beforeMessageID string) (Summary, error) {
+	return r.getLatestSummaryBeforeMessage(ctx, r.db, projectID, namespace, threadID, beforeMessageID)
+}
+
+func (r *ConversationRepo) getLatestSummaryBeforeMessage(ctx context.Context, conn *sqlx.DB, projectID uuid.UUID, namespace string, threadID string, beforeMessageID string) (Summary, error) {
 	query := `
 		SELECT s.id, s.thread_id, s.summary_message, s.last_summarized_message_id, s.created_at, s.meta
 		FROM summaries s
@@ -568,38 +768,193 @@ func (r *ConversationRepo) GetLatestSummaryBeforeMessage(ctx context.Context, pr
 		LIMIT 1
 	`
 
-	var result struct {
-		ID                      string           `db:"id"`
-		ThreadID                string           `db:"thread_id"`
-		SummaryMessage          utils.RawMessage `db:"summary_message"`
-		LastSummarizedMessageID string           `db:"last_summarized_message_id"`
-		CreatedAt               time.Time        `db:"created_at"`
-		Meta                    utils.RawMessage `db:"meta"`
-	}
-
-	err := r.db.GetContext(ctx, &result, query, threadID, namespace, projectID, beforeMessageID)
+	var result summaryRow
+	err := conn.GetContext(ctx, &result, query, threadID, namespace, projectID, beforeMessageID)
 	if err != nil {
 		return Summary{}, err
 	}
 
-	var summaryMessage responses.InputMessageUnion
-	err = json.Unmarshal(result.SummaryMessage, &summaryMessage)
+	return r.toSummary(ctx, result)
+}
+
+type summaryRow struct {
+	ID                      string           `db:"id"`
+	ThreadID                string           `db:"thread_id"`
+	SummaryMessage          utils.RawMessage `db:"summary_message"`
+	LastSummarizedMessageID string           `db:"last_summarized_message_id"`
+	CreatedAt               time.Time        `db:"created_at"`
+	Meta                    utils.RawMessage `db:"meta"`
+}
+
+func (r *ConversationRepo) toSummary(ctx context.Context, row summaryRow) (Summary, error) {
+	summaryMessage, err := r.decodeMessage(ctx, row.SummaryMessage)
 	if err != nil {
 		return Summary{}, fmt.Errorf("failed to unmarshal summary message: %w", err)
 	}
 
 	var meta map[string]any
-	err = json.Unmarshal(result.Meta, &meta)
+	err = json.Unmarshal(row.Meta, &meta)
 	if err != nil {
 		meta = make(map[string]any)
 	}
 
 	return Summary{
-		ID:                      result.ID,
-		ThreadID:                result.ThreadID,
+		ID:                      row.ID,
+		ThreadID:                row.ThreadID,
 		SummaryMessage:          summaryMessage,
-		LastSummarizedMessageID: result.LastSummarizedMessageID,
-		CreatedAt:               result.CreatedAt,
+		LastSummarizedMessageID: row.LastSummarizedMessageID,
+		CreatedAt:               row.CreatedAt,
 		Meta:                    meta,
 	}, nil
 }
+
+// ListSummaries lists the summaries of a thread, oldest first
+func (r *ConversationRepo) ListSummaries(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) ([]Summary, error) {
+	query := `
+		SELECT s.id, s.thread_id, s.summary_message, s.last_summarized_message_id, s.created_at, s.meta
+		FROM summaries s
+		JOIN threads t ON s.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE s.thread_id = $1 AND c.namespace_id = $2 AND c.project_id = $3
+		ORDER BY s.created_at ASC
+	`
+
+	var rows []summaryRow
+	if err := r.db.SelectContext(ctx, &rows, query, threadID, namespace, projectID); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(rows))
+	for _, row := range rows {
+		summary, err := r.toSummary(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// GetSummaryByID retrieves a summary, scoped to the project and namespace of its thread
+func (r *ConversationRepo) GetSummaryByID(ctx context.Context, projectID uuid.UUID, namespace string, summaryID string) (Summary, error) {
+	query := `
+		SELECT s.id, s.thread_id, s.summary_message, s.last_summarized_message_id, s.created_at, s.meta
+		FROM summaries s
+		JOIN threads t ON s.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		WHERE s.id = $1 AND c.namespace_id = $2 AND c.project_id = $3
+	`
+
+	var result summaryRow
+	if err := r.db.GetContext(ctx, &result, query, summaryID, namespace, projectID); err != nil {
+		return Summary{}, err
+	}
+
+	return r.toSummary(ctx, result)
+}
+
+// GetPreviousSummary finds the summary of the thread created right before the given one. It returns
+// sql.ErrNoRows for the first summary of a thread.
+func (r *ConversationRepo) GetPreviousSummary(ctx context.Context, summary Summary) (Summary, error) {
+	query := `
+		SELECT s.id, s.thread_id, s.summary_message, s.last_summarized_message_id, s.created_at, s.meta
+		FROM summaries s
+		WHERE s.thread_id = $1 AND s.created_at < $2
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	var result summaryRow
+	if err := r.db.GetContext(ctx, &result, query, summary.ThreadID, summary.CreatedAt); err != nil {
+		return Summary{}, err
+	}
+
+	return r.toSummary(ctx, result)
+}
+
+// GetMessagesThrough fetches the messages of a thread up to and including endMessageID, after startMessageID
+// when it is set
+func (r *ConversationRepo) GetMessagesThrough(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, startMessageID string, endMessageID string) ([]ConversationMessage, error) {
+	if startMessageID != "" {
+		return r.getMessagesBetween(ctx, r.db, projectID, namespace, threadID, startMessageID, endMessageID)
+	}
+
+	query := `
+		SELECT m.id as message_id, m.thread_id, t.conversation_id, m.messages, m.meta
+		FROM messages m
+		JOIN threads t ON m.thread_id = t.thread_id
+		JOIN conversations c ON t.conversation_id = c.conversation_id
+		JOIN messages end_ref ON end_ref.id = $4
+		WHERE m.thread_id = $1 AND c.namespace_id = $2 AND c.project_id = $3
+		AND m.created_at <= end_ref.created_at
+		ORDER BY m.created_at ASC
+	`
+
+	messages := []ConversationMessage{}
+	results, err := r.db.QueryContext(ctx, query, threadID, namespace, projectID, endMessageID)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	for results.Next() {
+		message := ConversationMessage{}
+		rawMessages := []byte{}
+		rawMeta := []byte{}
+
+		err = results.Scan(&message.MessageID, &message.ThreadID, &message.ConversationID, &rawMessages, &rawMeta)
+		if err != nil {
+			return nil, err
+		}
+
+		message.Messages, err = r.decodeMessages(ctx, rawMessages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
+		}
+
+		err = json.Unmarshal(rawMeta, &message.Meta)
+		if err != nil {
+			message.Meta = make(map[string]interface{})
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+// UpdateSummaryMessage replaces the message and meta of a summary. The summary keeps its place in the thread,
+// so the history of the runs after it is assembled from the new message.
+func (r *ConversationRepo) UpdateSummaryMessage(ctx context.Context, summaryID string, message responses.InputMessageUnion, meta map[string]any) error {
+	query := `
+		UPDATE summaries SET summary_message = $2, meta = $3
+		WHERE id = $1
+	`
+
+	projectID, err := r.projectOfSummary(ctx, summaryID)
+	if err != nil {
+		return err
+	}
+
+	summaryJSON, err := r.encodeSummary(ctx, projectID, message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary message: %w", err)
+	}
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary meta: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, summaryID, summaryJSON, metaJSON)
+	if err != nil {
+		return fmt.Errorf("failed to update summary %s: %w", summaryID, err)
+	}
+
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -2,49 +2,153 @@ package conversation
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/pkg/llm/responses"
 	"github.com/google/uuid"
 )
 
+// IDGenerator generates the IDs of the conversations, threads and messages created by the service
+type IDGenerator func(ctx context.Context) string
+
 type ConversationService struct {
-	repo *ConversationRepo
+	repo  *ConversationRepo
+	newID IDGenerator
+
+	regions     *db.RegionRouter
+	regionRepos map[string]*ConversationRepo
+}
+
+type ConversationServiceOption func(*ConversationService)
+
+// WithIDGenerator replaces the UUIDs generated for new conversations, threads and messages
+func WithIDGenerator(gen IDGenerator) ConversationServiceOption {
+	return func(s *ConversationService) {
+		s.newID = gen
+	}
+}
+
+// WithRegions stores the conversations of projects pinned to a region in the database of the region
+func WithRegions(regions *db.RegionRouter, repos map[string]*ConversationRepo) ConversationServiceOption {
+	return func(s *ConversationService) {
+		s.regions = regions
+		s.regionRepos = repos
+	}
 }
 
-func NewConversationService(r *ConversationRepo) *ConversationService {
-	return &ConversationService{
+func NewConversationService(r *ConversationRepo, opts ...ConversationServiceOption) *ConversationService {
+	s := &ConversationService{
 		repo: r,
+		newID: func(ctx context.Context) string {
+			return uuid.NewString()
+		},
 	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	return s
+}
+
+// repoFor returns the repo of the database that holds the conversations of the project
+func (s *ConversationService) repoFor(ctx context.Context, projectID uuid.UUID) (*ConversationRepo, error) {
+	region, err := s.regions.Region(ctx, projectID)
+	if err != nil || region == "" {
+		return s.repo, err
+	}
+
+	repo, ok := s.regionRepos[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", db.ErrRegionUnavailable, region)
+	}
+
+	return repo, nil
 }
 
+// AddMessages appends the messages of the request to its message. A request with an external ID that was
+// applied before is not applied again, in.MessageID is set to the ID of the message it was applied to.
 func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageRequest) error {
+	repo, err := s.repoFor(ctx, in.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	var external []ExternalMessageID
+	if in.ExternalID != "" {
+		messageID, err := repo.GetMessageIDByExternalID(ctx, in.ProjectID, in.Namespace, in.ExternalID)
+		if err == nil {
+			in.MessageID = messageID
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		external = append(external, ExternalMessageID{
+			ProjectID:  in.ProjectID,
+			Namespace:  in.Namespace,
+			ExternalID: in.ExternalID,
+		})
+	}
+
+	if in.MessageID == "" {
+		in.MessageID = s.newID(ctx)
+	}
+
+	err = s.addMessages(ctx, repo, in, external)
+	if errors.Is(err, ErrExternalIDExists) {
+		// A concurrent retry of the same write got there first
+		messageID, err := repo.GetMessageIDByExternalID(ctx, in.ProjectID, in.Namespace, in.ExternalID)
+		if err != nil {
+			return err
+		}
+		in.MessageID = messageID
+		return nil
+	}
+
+	return err
+}
+
+func (s *ConversationService) addMessages(ctx context.Context, repo *ConversationRepo, in *AddMessageRequest, external []ExternalMessageID) error {
 	// Case 1:
 	// User is starting a new conversation
 	if in.PreviousMessageID == "" {
 		conversationID := in.ConversationID
 		if conversationID == "" {
-			conversationID = uuid.NewString()
+			conversationID = s.newID(ctx)
 		}
 
-		// Create conversation
-		conversation, err := s.repo.CreateConversation(ctx, Conversation{
-			ProjectID:      in.ProjectID,
-			NamespaceID:    in.Namespace,
-			ConversationID: conversationID,
-			Name:           "New Conversation",
-			CreatedAt:      time.Now(),
-			LastUpdated:    time.Now(),
-		})
+		// Reuse the conversation if it was created upfront, otherwise create it
+		var conversation Conversation
+		var err error
+		if in.ConversationID != "" {
+			conversation, err = repo.GetConversationByID(ctx, in.ProjectID, in.Namespace, conversationID)
+		}
+		if in.ConversationID == "" || errors.Is(err, sql.ErrNoRows) {
+			conversation, err = repo.CreateConversation(ctx, Conversation{
+				ProjectID:      in.ProjectID,
+				NamespaceID:    in.Namespace,
+				ConversationID: conversationID,
+				Name:           "New Conversation",
+				CreatedAt:      time.Now(),
+				LastUpdated:    time.Now(),
+			})
+		}
 		if err != nil {
 			return err
 		}
 
 		// Create a thread in the conversation
-		thread, err := s.repo.CreateThread(ctx, Thread{
+		thread, err := repo.CreateThread(ctx, Thread{
 			ConversationID:  conversation.ConversationID,
 			OriginMessageID: "",
 			LastMessageID:   "",
-			ThreadID:        uuid.NewString(),
+			ThreadID:        s.newID(ctx),
 			Meta:            in.Meta,
 			CreatedAt:       time.Now(),
 			LastUpdated:     time.Now(),
@@ -54,20 +158,20 @@ func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageReq
 		}
 
 		// Add message to the thread
-		err = s.repo.CreateMessages(ctx, ConversationMessage{
+		err = repo.CreateMessages(ctx, ConversationMessage{
 			ConversationID: conversation.ConversationID,
 			ThreadID:       thread.ThreadID,
 			MessageID:      in.MessageID,
 			Messages:       in.Messages,
 			Meta:           in.Meta,
-		})
+		}, external...)
 		if err != nil {
 			return err
 		}
 
 		// Update the conversation's last updated timestamp
 		conversation.LastUpdated = time.Now()
-		err = s.repo.UpdateConversation(ctx, conversation)
+		err = repo.UpdateConversation(ctx, conversation)
 		if err != nil {
 			return err
 		}
@@ -75,7 +179,7 @@ func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageReq
 		// Update the thread's last message ID
 		if len(in.Messages) > 0 {
 			thread.LastMessageID = in.MessageID
-			err = s.repo.UpdateThread(ctx, thread)
+			err = repo.UpdateThread(ctx, thread)
 			if err != nil {
 				return err
 			}
@@ -88,37 +192,37 @@ func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageReq
 	// User is continuing an existing conversation
 	if in.PreviousMessageID != "" {
 		// Fetch the message and its associated thread
-		message, err := s.repo.GetMessageByID(ctx, in.ProjectID, in.Namespace, in.PreviousMessageID)
+		message, err := repo.GetMessageByID(ctx, in.ProjectID, in.Namespace, in.PreviousMessageID)
 		if err != nil {
 			return err
 		}
 
-		conversation, err := s.repo.GetConversationByID(ctx, in.ProjectID, in.Namespace, message.ConversationID)
+		conversation, err := repo.GetConversationByID(ctx, in.ProjectID, in.Namespace, message.ConversationID)
 		if err != nil {
 			return err
 		}
 
-		thread, err := s.repo.GetThreadByID(ctx, in.ProjectID, in.Namespace, message.ThreadID)
+		thread, err := repo.GetThreadByID(ctx, in.ProjectID, in.Namespace, message.ThreadID)
 		if err != nil {
 			return err
 		}
 
 		if thread.LastMessageID == in.PreviousMessageID {
 			// Append the new message to the existing thread
-			err = s.repo.CreateMessages(ctx, ConversationMessage{
+			err = repo.CreateMessages(ctx, ConversationMessage{
 				MessageID:      in.MessageID,
 				ThreadID:       thread.ThreadID,
 				ConversationID: conversation.ConversationID,
 				Messages:       in.Messages,
 				Meta:           in.Meta,
-			})
+			}, external...)
 			if err != nil {
 				return err
 			}
 
 			// Update the conversation's last updated timestamp
 			conversation.LastUpdated = time.Now()
-			err = s.repo.UpdateConversation(ctx, conversation)
+			err = repo.UpdateConversation(ctx, conversation)
 			if err != nil {
 				return err
 			}
@@ -127,8 +231,9 @@ func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageReq
 			if len(in.Messages) > 0 {
 				thread.LastMessageID = in.MessageID
 				thread.LastUpdated = time.Now()
-				thread.Meta = in.Meta
-				err = s.repo.UpdateThread(ctx, thread)
+				// The meta of the thread is that of its last message, along with its takeover state
+				thread.Meta = withTakeover(in.Meta, thread.Meta)
+				err = repo.UpdateThread(ctx, thread)
 				if err != nil {
 					return err
 				}
@@ -143,8 +248,64 @@ func (s *ConversationService) AddMessages(ctx context.Context, in *AddMessageReq
 	return nil
 }
 
+// CreateConversation creates an empty conversation; its thread is created along with the first message
+func (s *ConversationService) CreateConversation(ctx context.Context, projectID uuid.UUID, namespace string, name string) (Conversation, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	return repo.CreateConversation(ctx, Conversation{
+		ProjectID:      projectID,
+		NamespaceID:    namespace,
+		ConversationID: s.newID(ctx),
+		Name:           name,
+		CreatedAt:      time.Now(),
+		LastUpdated:    time.Now(),
+	})
+}
+
+// GetMessageByExternalID retrieves the message an external ID is mapped to
+func (s *ConversationService) GetMessageByExternalID(ctx context.Context, projectID uuid.UUID, namespace string, externalID string) (ConversationMessage, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return ConversationMessage{}, err
+	}
+
+	messageID, err := repo.GetMessageIDByExternalID(ctx, projectID, namespace, externalID)
+	if err != nil {
+		return ConversationMessage{}, err
+	}
+
+	return repo.GetMessageByID(ctx, projectID, namespace, messageID)
+}
+
+// GetLatestThread returns the most recently updated thread of a conversation, or nil if it has none yet
+func (s *ConversationService) GetLatestThread(ctx context.Context, projectID uuid.UUID, namespace string, conversationID string) (*Thread, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads, err := repo.ListThreads(ctx, projectID, namespace, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(threads) == 0 {
+		return nil, nil
+	}
+
+	return &threads[0], nil
+}
+
 func (s *ConversationService) GetAllMessagesTillRun(ctx context.Context, in *GetMessagesRequest) ([]ConversationMessage, error) {
-	convMessages, err := s.repo.GetAllMessagesTillRun(ctx, in.ProjectID, in.Namespace, in.PreviousMessageID)
+	repo, err := s.repoFor(ctx, in.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	convMessages, err := repo.GetAllMessagesTillRun(ctx, in.ProjectID, in.Namespace, in.PreviousMessageID)
 	if err != nil {
 		return nil, err
 	}
@@ -157,29 +318,139 @@ func (s *ConversationService) GetAllMessagesTillRun(ctx context.Context, in *Get
 }
 
 func (s *ConversationService) ListConversations(ctx context.Context, projectID uuid.UUID, namespaceID string) ([]Conversation, error) {
-	return s.repo.ListConversations(ctx, projectID, namespaceID)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ListConversations(ctx, projectID, namespaceID)
 }
 
 func (s *ConversationService) ListThreads(ctx context.Context, projectID uuid.UUID, namespaceID string, conversationID string) ([]Thread, error) {
-	return s.repo.ListThreads(ctx, projectID, namespaceID, conversationID)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ListThreads(ctx, projectID, namespaceID, conversationID)
 }
 
 func (s *ConversationService) ListMessages(ctx context.Context, projectID uuid.UUID, namespaceID string, threadID string) ([]ConversationMessage, error) {
-	return s.repo.GetThreadMessages(ctx, projectID, namespaceID, threadID, 0, 100)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetThreadMessages(ctx, projectID, namespaceID, threadID, 0, 100)
 }
 
 func (s *ConversationService) GetMessage(ctx context.Context, projectID uuid.UUID, namespaceID string, messageID string) (ConversationMessage, error) {
-	return s.repo.GetMessageByID(ctx, projectID, namespaceID, messageID)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return ConversationMessage{}, err
+	}
+
+	return repo.GetMessageByID(ctx, projectID, namespaceID, messageID)
+}
+
+// SetMessagePinned pins a message in its thread so that the agent always has its run in the history, or unpins it
+func (s *ConversationService) SetMessagePinned(ctx context.Context, projectID uuid.UUID, namespaceID string, messageID string, pinned bool) error {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	return repo.SetMessagePinned(ctx, projectID, namespaceID, messageID, pinned)
 }
 
 func (s *ConversationService) GetThread(ctx context.Context, projectID uuid.UUID, namespaceID string, threadID string) (Thread, error) {
-	return s.repo.GetThreadByID(ctx, projectID, namespaceID, threadID)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return Thread{}, err
+	}
+
+	return repo.GetThreadByID(ctx, projectID, namespaceID, threadID)
 }
 
 func (s *ConversationService) GetConversation(ctx context.Context, projectID uuid.UUID, namespaceID string, conversationID string) (Conversation, error) {
-	return s.repo.GetConversationByID(ctx, projectID, namespaceID, conversationID)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	return repo.GetConversationByID(ctx, projectID, namespaceID, conversationID)
 }
 
 func (s *ConversationService) CreateSummary(ctx context.Context, projectID uuid.UUID, namespace string, summary Summary) error {
-	return s.repo.CreateSummary(ctx, summary)
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	return repo.CreateSummary(ctx, summary)
+}
+
+// ListSummaries lists the summaries of a thread, oldest first
+func (s *ConversationService) ListSummaries(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) ([]Summary, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.ListSummaries(ctx, projectID, namespace, threadID)
+}
+
+// GetSummaryCoverage retrieves a summary along with the summary and messages it condenses
+func (s *ConversationService) GetSummaryCoverage(ctx context.Context, projectID uuid.UUID, namespace string, summaryID string) (*SummaryCoverage, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := repo.GetSummaryByID(ctx, projectID, namespace, summaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	coverage := &SummaryCoverage{Summary: summary}
+
+	var startMessageID string
+	previous, err := repo.GetPreviousSummary(ctx, summary)
+	if err == nil {
+		coverage.PreviousSummary = &previous
+		startMessageID = previous.LastSummarizedMessageID
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	coverage.Messages, err = repo.GetMessagesThrough(ctx, projectID, namespace, summary.ThreadID, startMessageID, summary.LastSummarizedMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return coverage, nil
+}
+
+// UpdateSummaryMessage replaces the message of a summary, the runs after it are continued from the new message
+func (s *ConversationService) UpdateSummaryMessage(ctx context.Context, projectID uuid.UUID, summaryID string, message responses.InputMessageUnion, meta map[string]any) error {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	return repo.UpdateSummaryMessage(ctx, summaryID, message, meta)
+}
+
+func (s *ConversationService) GetRunTrace(ctx context.Context, projectID uuid.UUID, namespaceID string, messageID string) (*RunTrace, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := repo.GetMessageByID(ctx, projectID, namespaceID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildRunTrace(message), nil
 }
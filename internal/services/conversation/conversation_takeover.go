@@ -0,0 +1,225 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// threadMetaTakeover is the key of the takeover state in the meta of a thread
+const threadMetaTakeover = "takeover"
+
+const (
+	TakeoverActionStarted = "started"
+	TakeoverActionMessage = "message"
+	TakeoverActionEnded   = "ended"
+)
+
+var (
+	ErrThreadNotFound    = errors.New("thread not found")
+	ErrTakeoverActive    = errors.New("the conversation is already taken over by an operator")
+	ErrTakeoverNotActive = errors.New("the conversation is not taken over")
+)
+
+// TakeoverEvent records an action of an operator on a thread
+type TakeoverEvent struct {
+	Action    string    `json:"action"` // "started", "message" or "ended"
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason,omitempty"`
+	MessageID string    `json:"message_id,omitempty"` // Message written by the operator, for "message"
+	At        time.Time `json:"at"`
+}
+
+// Takeover is the state of a thread a human operator can take over from the agent. While it is active the agent
+// does not answer, and the operator writes in the thread as the assistant. Events keeps all takeovers of the thread.
+type Takeover struct {
+	Active    bool            `json:"active"`
+	Operator  string          `json:"operator,omitempty"`
+	StartedAt *time.Time      `json:"started_at,omitempty"`
+	Events    []TakeoverEvent `json:"events"`
+}
+
+// StartTakeoverRequest is the request of an operator to take over a thread
+type StartTakeoverRequest struct {
+	Operator string `json:"operator"`
+	Reason   string `json:"reason"`
+}
+
+// TakeoverMessageRequest is a message written by the operator in a thread they took over
+type TakeoverMessageRequest struct {
+	Operator string `json:"operator"`
+	Text     string `json:"text"`
+}
+
+// EndTakeoverRequest is the request of an operator to hand a thread back to the agent
+type EndTakeoverRequest struct {
+	Operator string `json:"operator"`
+}
+
+// GetTakeover returns the takeover state of a thread
+func (s *ConversationService) GetTakeover(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) (*Takeover, error) {
+	_, takeover, err := s.getThreadTakeover(ctx, projectID, namespace, threadID)
+	return takeover, err
+}
+
+// GetTakeoverOfMessage returns the takeover state of the thread of a message
+func (s *ConversationService) GetTakeoverOfMessage(ctx context.Context, projectID uuid.UUID, namespace string, messageID string) (*Thread, *Takeover, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message, err := repo.GetMessageByID(ctx, projectID, namespace, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thread, takeover, err := s.getThreadTakeover(ctx, projectID, namespace, message.ThreadID)
+	return &thread, takeover, err
+}
+
+// StartTakeover pauses the agent on the thread and hands it over to the operator. A run in progress finishes,
+// the agent doesn't answer the messages written after.
+func (s *ConversationService) StartTakeover(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, operator string, reason string) (*Takeover, *TakeoverEvent, error) {
+	thread, takeover, err := s.getThreadTakeover(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if takeover.Active {
+		return nil, nil, ErrTakeoverActive
+	}
+
+	event := TakeoverEvent{Action: TakeoverActionStarted, Operator: operator, Reason: reason, At: time.Now().UTC()}
+	takeover.Active = true
+	takeover.Operator = operator
+	takeover.StartedAt = &event.At
+	takeover.Events = append(takeover.Events, event)
+
+	return takeover, &event, s.saveTakeover(ctx, projectID, thread, takeover)
+}
+
+// InjectMessage appends a message written by the operator to the thread, as the assistant
+func (s *ConversationService) InjectMessage(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, operator string, text string) (*TakeoverEvent, *responses.InputMessageUnion, error) {
+	thread, takeover, err := s.getThreadTakeover(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !takeover.Active {
+		return nil, nil, ErrTakeoverNotActive
+	}
+
+	message := importAssistantMessage(s.newID(ctx), text)
+	in := &AddMessageRequest{
+		ProjectID:         projectID,
+		Namespace:         namespace,
+		PreviousMessageID: thread.LastMessageID,
+		ConversationID:    thread.ConversationID,
+		Messages:          []responses.InputMessageUnion{message},
+		Meta:              map[string]any{"takeover_operator": operator},
+	}
+	if err := s.AddMessages(ctx, in); err != nil {
+		return nil, nil, err
+	}
+
+	// AddMessages moved the thread to the new message
+	thread, takeover, err = s.getThreadTakeover(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	event := TakeoverEvent{Action: TakeoverActionMessage, Operator: operator, MessageID: in.MessageID, At: time.Now().UTC()}
+	takeover.Events = append(takeover.Events, event)
+
+	return &event, &message, s.saveTakeover(ctx, projectID, thread, takeover)
+}
+
+// EndTakeover hands the thread back to the agent, which answers the next message
+func (s *ConversationService) EndTakeover(ctx context.Context, projectID uuid.UUID, namespace string, threadID string, operator string) (*Takeover, *TakeoverEvent, error) {
+	thread, takeover, err := s.getThreadTakeover(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !takeover.Active {
+		return nil, nil, ErrTakeoverNotActive
+	}
+
+	event := TakeoverEvent{Action: TakeoverActionEnded, Operator: operator, At: time.Now().UTC()}
+	takeover.Active = false
+	takeover.Operator = ""
+	takeover.StartedAt = nil
+	takeover.Events = append(takeover.Events, event)
+
+	return takeover, &event, s.saveTakeover(ctx, projectID, thread, takeover)
+}
+
+func (s *ConversationService) getThreadTakeover(ctx context.Context, projectID uuid.UUID, namespace string, threadID string) (Thread, *Takeover, error) {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return Thread{}, nil, err
+	}
+
+	thread, err := repo.GetThreadByID(ctx, projectID, namespace, threadID)
+	if err != nil {
+		return thread, nil, err
+	}
+	if thread.ThreadID == "" {
+		return thread, nil, ErrThreadNotFound
+	}
+
+	takeover, err := takeoverFromMeta(thread.Meta)
+	return thread, takeover, err
+}
+
+func (s *ConversationService) saveTakeover(ctx context.Context, projectID uuid.UUID, thread Thread, takeover *Takeover) error {
+	repo, err := s.repoFor(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	if thread.Meta == nil {
+		thread.Meta = map[string]any{}
+	}
+	thread.Meta[threadMetaTakeover] = takeover
+
+	return repo.UpdateThread(ctx, thread)
+}
+
+// takeoverFromMeta reads the takeover state from the meta of a thread
+func takeoverFromMeta(meta map[string]any) (*Takeover, error) {
+	takeover := &Takeover{Events: []TakeoverEvent{}}
+
+	value, ok := meta[threadMetaTakeover]
+	if !ok || value == nil {
+		return takeover, nil
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, takeover); err != nil {
+		return nil, err
+	}
+
+	return takeover, nil
+}
+
+// withTakeover carries the takeover state of a thread over to the meta that replaces its meta
+func withTakeover(meta map[string]any, previous map[string]any) map[string]any {
+	takeover, ok := previous[threadMetaTakeover]
+	if !ok {
+		return meta
+	}
+
+	merged := make(map[string]any, len(meta)+1)
+	for k, v := range meta {
+		merged[k] = v
+	}
+	merged[threadMetaTakeover] = takeover
+
+	return merged
+}
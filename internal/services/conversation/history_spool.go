@@ -0,0 +1,480 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/google/uuid"
+)
+
+const (
+	SpooledWriteMessages = "messages"
+	SpooledWriteSummary  = "summary"
+)
+
+// SpooledWrite is a history write that failed and is queued for retry
+type SpooledWrite struct {
+	ID         string             `json:"id"`
+	Kind       string             `json:"kind"` // "messages" or "summary"
+	Messages   *AddMessageRequest `json:"messages,omitempty"`
+	ProjectID  uuid.UUID          `json:"project_id"`
+	Namespace  string             `json:"namespace"`
+	Summary    *Summary           `json:"summary,omitempty"`
+	Attempts   int                `json:"attempts"`
+	LastError  string             `json:"last_error"`
+	EnqueuedAt time.Time          `json:"enqueued_at"`
+	NextTryAt  time.Time          `json:"next_try_at"`
+	Dead       bool               `json:"dead"` // Gave up after MaxAttempts, kept for inspection
+}
+
+// HistorySpoolStats reports the backlog and outcome of the spool
+type HistorySpoolStats struct {
+	Pending   int        `json:"pending"`
+	Dead      int        `json:"dead"`
+	Enqueued  int64      `json:"enqueued"`
+	Replayed  int64      `json:"replayed"`
+	Failures  int64      `json:"failures"`
+	OldestAge *string    `json:"oldest_age,omitempty"`
+	OldestAt  *time.Time `json:"oldest_at,omitempty"`
+}
+
+type HistorySpoolOptions struct {
+	// Dir is the directory the queued writes are stored in
+	Dir string
+
+	// Interval between retry passes. Defaults to 10 seconds.
+	Interval time.Duration
+
+	// MaxAttempts after which a write is marked as dead and not retried anymore. Defaults to 100.
+	MaxAttempts int
+
+	// ReplayTimeout bounds each replayed write. Defaults to 30 seconds.
+	ReplayTimeout time.Duration
+}
+
+// ErrNamespacePending is the cause of the writes queued behind the writes of their namespace already in the spool
+var ErrNamespacePending = errors.New("older history writes of the namespace are queued for retry")
+
+// HistorySpool queues history writes that failed, e.g. while the database is briefly unavailable, in a local
+// directory and retries them in the background, so that messages of a run are not lost.
+// Each write is stored in its own file, written atomically, and removed once it has been replayed.
+// The writes of a namespace with writes in the spool must be queued behind them, see HasPending.
+type HistorySpool struct {
+	svc  *ConversationService
+	opts HistorySpoolOptions
+
+	mu       sync.Mutex     // Guards the files and the pending writes, never held while writing to the database
+	replayMu sync.Mutex     // Serializes the replays with the erasures and deletions of the writes
+	pending  map[string]int // Number of writes to replay by namespace, dead writes excluded
+	stop     chan struct{}
+
+	enqueued atomic.Int64
+	replayed atomic.Int64
+	failures atomic.Int64
+}
+
+// NewHistorySpool creates the spool and starts retrying the writes queued in its directory
+func NewHistorySpool(svc *ConversationService, opts HistorySpoolOptions) (*HistorySpool, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 100
+	}
+
+	if opts.ReplayTimeout <= 0 {
+		opts.ReplayTimeout = 30 * time.Second
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history spool directory: %w", err)
+	}
+
+	spool := &HistorySpool{
+		svc:     svc,
+		opts:    opts,
+		pending: map[string]int{},
+		stop:    make(chan struct{}),
+	}
+
+	writes, err := spool.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range writes {
+		spool.track(w, 1)
+	}
+
+	go spool.run()
+
+	return spool, nil
+}
+
+// Stop stops the background retries. Queued writes stay on disk and are retried on the next start.
+func (s *HistorySpool) Stop() {
+	close(s.stop)
+}
+
+// EnqueueMessages queues an AddMessages call for retry
+func (s *HistorySpool) EnqueueMessages(in *AddMessageRequest, cause error) error {
+	return s.enqueue(&SpooledWrite{
+		Kind:      SpooledWriteMessages,
+		Messages:  in,
+		ProjectID: in.ProjectID,
+		Namespace: in.Namespace,
+		LastError: cause.Error(),
+	})
+}
+
+// EnqueueSummary queues a CreateSummary call for retry
+func (s *HistorySpool) EnqueueSummary(projectID uuid.UUID, namespace string, summary Summary, cause error) error {
+	return s.enqueue(&SpooledWrite{
+		Kind:      SpooledWriteSummary,
+		ProjectID: projectID,
+		Namespace: namespace,
+		Summary:   &summary,
+		LastError: cause.Error(),
+	})
+}
+
+// HasPending reports whether writes of the namespace are queued for retry, the writes that follow them must be
+// queued too, to keep the history in order
+func (s *HistorySpool) HasPending(projectID uuid.UUID, namespace string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pending[spoolKey(projectID, namespace)] > 0
+}
+
+// List returns the queued writes, oldest first
+func (s *HistorySpool) List() ([]*SpooledWrite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list()
+}
+
+// Delete drops a queued write without replaying it
+func (s *HistorySpool) Delete(id string) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.read(s.path(id))
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(id)); err != nil {
+		return fmt.Errorf("failed to delete spooled write: %w", err)
+	}
+	s.track(w, -1)
+
+	return nil
+}
+
+// EraseSubject drops the queued message writes of a project whose meta holds the subject under the key
+func (s *HistorySpool) EraseSubject(projectID uuid.UUID, key string, subject string) (int64, error) {
+	// A write being replayed is either in the database once the replay is done, or still in the spool
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writes, err := s.list()
+	if err != nil {
+		return 0, err
+	}
+
+	var erased int64
+	for _, w := range writes {
+		if w.Messages == nil || w.ProjectID != projectID {
+			continue
+		}
+		if value, ok := w.Messages.Meta[key]; !ok || fmt.Sprint(value) != subject {
+			continue
+		}
+		if err := os.Remove(s.path(w.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return erased, fmt.Errorf("failed to delete spooled write: %w", err)
+		}
+		s.track(w, -1)
+		erased++
+	}
+
+	return erased, nil
+}
+
+// Retry replays a queued write now, including writes that are dead
+func (s *HistorySpool) Retry(ctx context.Context, id string) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	w, err := s.read(s.path(id))
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return s.replay(ctx, w)
+}
+
+// Stats returns the current backlog and counters of the spool
+func (s *HistorySpool) Stats() (*HistorySpoolStats, error) {
+	writes, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &HistorySpoolStats{
+		Enqueued: s.enqueued.Load(),
+		Replayed: s.replayed.Load(),
+		Failures: s.failures.Load(),
+	}
+
+	for _, w := range writes {
+		if w.Dead {
+			stats.Dead++
+		} else {
+			stats.Pending++
+		}
+	}
+
+	if len(writes) > 0 {
+		oldest := writes[0].EnqueuedAt
+		age := time.Since(oldest).Round(time.Second).String()
+		stats.OldestAt = &oldest
+		stats.OldestAge = &age
+	}
+
+	return stats, nil
+}
+
+func (s *HistorySpool) enqueue(w *SpooledWrite) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.EnqueuedAt = time.Now()
+	w.NextTryAt = w.EnqueuedAt.Add(s.opts.Interval)
+	// IDs sort by enqueue time, so that writes are replayed in order
+	w.ID = fmt.Sprintf("%020d-%s", w.EnqueuedAt.UnixNano(), uuid.NewString())
+
+	if err := s.write(w); err != nil {
+		return err
+	}
+
+	s.track(w, 1)
+	s.enqueued.Add(1)
+	slog.Warn("History write queued for retry", slog.String("id", w.ID), slog.String("kind", w.Kind), slog.String("error", w.LastError))
+
+	return nil
+}
+
+func (s *HistorySpool) run() {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.retryPending()
+		}
+	}
+}
+
+// retryPending replays the writes that are due, in order. A message write depends on the one before it in the
+// same namespace, so a namespace stops being replayed at its first failure in a pass.
+func (s *HistorySpool) retryPending() {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	writes, err := s.list()
+	s.mu.Unlock()
+	if err != nil {
+		slog.Error("Failed to list history spool", slog.Any("error", err))
+		return
+	}
+
+	ctx := context.Background()
+	blocked := map[string]bool{}
+	for _, w := range writes {
+		if w.Dead {
+			continue
+		}
+
+		key := spoolKey(w.ProjectID, w.Namespace)
+		if blocked[key] || time.Now().Before(w.NextTryAt) {
+			blocked[key] = true
+			continue
+		}
+
+		if err := s.replay(ctx, w); err != nil {
+			blocked[key] = true
+		}
+	}
+}
+
+// replay performs the write, removing it from the spool on success and rescheduling it with backoff on failure.
+// The write is retried even if it is dead.
+func (s *HistorySpool) replay(ctx context.Context, w *SpooledWrite) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ReplayTimeout)
+	defer cancel()
+
+	var err error
+	switch w.Kind {
+	case SpooledWriteMessages:
+		err = s.svc.AddMessages(ctx, w.Messages)
+	case SpooledWriteSummary:
+		err = s.svc.CreateSummary(ctx, w.ProjectID, w.Namespace, *w.Summary)
+	default:
+		err = fmt.Errorf("unknown spooled write kind: %s", w.Kind)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.replayed.Add(1)
+		if rmErr := os.Remove(s.path(w.ID)); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+			slog.Error("Failed to remove replayed history write", slog.String("id", w.ID), slog.Any("error", rmErr))
+		}
+		s.track(w, -1)
+		return nil
+	}
+
+	s.failures.Add(1)
+	s.track(w, -1)
+	w.Attempts++
+	w.LastError = err.Error()
+	w.NextTryAt = time.Now().Add(s.backoff(w.Attempts))
+	w.Dead = w.Attempts >= s.opts.MaxAttempts
+	if w.Dead {
+		slog.Error("Giving up on history write", slog.String("id", w.ID), slog.Int("attempts", w.Attempts), slog.Any("error", err))
+	}
+	s.track(w, 1)
+
+	if writeErr := s.write(w); writeErr != nil {
+		slog.Error("Failed to update history write", slog.String("id", w.ID), slog.Any("error", writeErr))
+	}
+
+	return err
+}
+
+// track counts the write in the pending writes of its namespace, unless it is dead. It must be called with s.mu held.
+func (s *HistorySpool) track(w *SpooledWrite, delta int) {
+	if w.Dead {
+		return
+	}
+
+	key := spoolKey(w.ProjectID, w.Namespace)
+	s.pending[key] += delta
+	if s.pending[key] <= 0 {
+		delete(s.pending, key)
+	}
+}
+
+func spoolKey(projectID uuid.UUID, namespace string) string {
+	return projectID.String() + "/" + namespace
+}
+
+// backoff doubles the retry interval with each attempt, up to 5 minutes
+func (s *HistorySpool) backoff(attempts int) time.Duration {
+	d := s.opts.Interval
+	for i := 1; i < attempts && d < 5*time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, 5*time.Minute)
+}
+
+func (s *HistorySpool) list() ([]*SpooledWrite, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history spool: %w", err)
+	}
+
+	writes := []*SpooledWrite{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		w, err := s.read(filepath.Join(s.opts.Dir, entry.Name()))
+		if err != nil {
+			slog.Error("Failed to read history write", slog.String("file", entry.Name()), slog.Any("error", err))
+			continue
+		}
+		writes = append(writes, w)
+	}
+
+	sort.Slice(writes, func(i, j int) bool {
+		return writes[i].ID < writes[j].ID
+	})
+
+	return writes, nil
+}
+
+func (s *HistorySpool) read(file string) (*SpooledWrite, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("spooled write not found")
+		}
+		return nil, err
+	}
+
+	var w SpooledWrite
+	if err := json.Unmarshal(buf, &w); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// write stores the write atomically, so that a crash never leaves a partial file behind
+func (s *HistorySpool) write(w *SpooledWrite) error {
+	buf, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history write: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.opts.Dir, w.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create history write: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history write: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync history write: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close history write: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path(w.ID))
+}
+
+func (s *HistorySpool) path(id string) string {
+	return filepath.Join(s.opts.Dir, filepath.Base(id)+".json")
+}
@@ -0,0 +1,215 @@
+package conversation
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/services/traces"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// BuildRunTrace assembles a RunTrace from a persisted run message.
+// Messages are split into loops at every transition from non-model items (user input, tool results)
+// to model-produced items; timing and usage are attached from the step records in the run state.
+// The requests to the gateway are joined by AttachGatewayRequests.
+func BuildRunTrace(message ConversationMessage) *RunTrace {
+	out := &RunTrace{
+		MessageID:      message.MessageID,
+		ThreadID:       message.ThreadID,
+		ConversationID: message.ConversationID,
+		Input:          []responses.InputMessageUnion{},
+		Loops:          []RunTraceLoop{},
+		Approvals:      []RunTraceApproval{},
+		Errors:         []RunTraceError{},
+	}
+
+	if runStateData, ok := message.Meta["run_state"].(map[string]any); ok {
+		if status, ok := runStateData["status"].(string); ok {
+			out.Status = status
+		}
+		if traceID, ok := runStateData["traceid"].(string); ok {
+			out.TraceID = traceID
+		}
+	}
+
+	// Walk the messages and build the loop skeleton
+	toolCallIndex := map[string][2]int{}
+	prevFromModel := false
+	for _, msg := range message.Messages {
+		fromModel := isModelOutput(msg)
+		if fromModel && !prevFromModel {
+			out.Loops = append(out.Loops, RunTraceLoop{
+				Index:     len(out.Loops),
+				LLMCall:   RunTraceLLMCall{Output: []responses.InputMessageUnion{}},
+				ToolCalls: []RunTraceToolCall{},
+			})
+		}
+		prevFromModel = fromModel
+
+		switch {
+		case fromModel:
+			loop := &out.Loops[len(out.Loops)-1]
+			loop.LLMCall.Output = append(loop.LLMCall.Output, msg)
+			if msg.OfFunctionCall != nil {
+				toolCallIndex[msg.OfFunctionCall.CallID] = [2]int{len(out.Loops) - 1, len(loop.ToolCalls)}
+				loop.ToolCalls = append(loop.ToolCalls, RunTraceToolCall{
+					CallID:    msg.OfFunctionCall.CallID,
+					Name:      msg.OfFunctionCall.Name,
+					Arguments: msg.OfFunctionCall.Arguments,
+				})
+			}
+
+		case msg.OfFunctionCallOutput != nil:
+			if idx, ok := toolCallIndex[msg.OfFunctionCallOutput.CallID]; ok {
+				result := msg.OfFunctionCallOutput.Output
+				out.Loops[idx[0]].ToolCalls[idx[1]].Result = &result
+			}
+
+		case msg.OfFunctionCallApprovalResponse != nil:
+			// Recorded from the step records below, which also carry the decision time
+
+		default:
+			if len(out.Loops) == 0 {
+				out.Input = append(out.Input, msg)
+			}
+		}
+	}
+
+	// Overlay timing, usage, approvals and errors from the step records
+	runState := core.LoadRunStateFromMeta(message.Meta)
+	if runState == nil {
+		return out
+	}
+	out.Usage = runState.Usage
+
+	llmCallIdx := 0
+	var runStart, runEnd time.Time
+	for _, step := range runState.Steps {
+		startedAt := step.StartedAt
+		if runStart.IsZero() || startedAt.Before(runStart) {
+			runStart = startedAt
+		}
+		if end := startedAt.Add(time.Duration(step.DurationMs) * time.Millisecond); end.After(runEnd) {
+			runEnd = end
+		}
+
+		switch step.Type {
+		case core.StepRecordLLMCall:
+			// A failed call has no output, its loop only holds its timing
+			if llmCallIdx == len(out.Loops) && step.Error != "" {
+				out.Loops = append(out.Loops, RunTraceLoop{
+					Index:     len(out.Loops),
+					LLMCall:   RunTraceLLMCall{Output: []responses.InputMessageUnion{}},
+					ToolCalls: []RunTraceToolCall{},
+				})
+			}
+			if llmCallIdx < len(out.Loops) {
+				call := &out.Loops[llmCallIdx].LLMCall
+				call.StartedAt = &startedAt
+				call.DurationMs = step.DurationMs
+				call.Usage = step.Usage
+			}
+			llmCallIdx++
+
+		case core.StepRecordToolCall:
+			if idx, ok := toolCallIndex[step.CallID]; ok {
+				call := &out.Loops[idx[0]].ToolCalls[idx[1]]
+				call.StartedAt = &startedAt
+				call.DurationMs = step.DurationMs
+				call.Error = step.Error
+			}
+
+		case core.StepRecordApproval:
+			out.Approvals = append(out.Approvals, RunTraceApproval{
+				Loop:            step.Loop,
+				DecidedAt:       &startedAt,
+				ApprovedCallIDs: step.Approved,
+				RejectedCallIDs: step.Rejected,
+			})
+		}
+
+		if step.Error != "" {
+			out.Errors = append(out.Errors, RunTraceError{
+				Loop:    step.Loop,
+				Step:    string(step.Type),
+				CallID:  step.CallID,
+				Message: step.Error,
+			})
+		}
+	}
+
+	if !runStart.IsZero() {
+		out.DurationMs = runEnd.Sub(runStart).Milliseconds()
+	}
+
+	return out
+}
+
+// AttachGatewayRequests joins the request log of the gateway, the LLM spans of the trace of the run, to the LLM
+// calls of the trace. A request belongs to the call it started during, the others are kept on the trace.
+func AttachGatewayRequests(out *RunTrace, spans []traces.Span) {
+	for _, span := range spans {
+		if !strings.HasPrefix(span.Name, "LLM.") {
+			continue
+		}
+
+		request := RunTraceGatewayRequest{
+			SpanID:       span.SpanID,
+			Name:         span.Name,
+			Provider:     span.Attributes["llm.provider"],
+			Model:        span.Attributes["llm.model"],
+			StartedAt:    span.StartTime,
+			DurationMs:   span.Duration,
+			InputTokens:  spanInt(span.Attributes, "gen_ai.response.usage.input_tokens", "llm.usage.input_tokens"),
+			OutputTokens: spanInt(span.Attributes, "gen_ai.response.usage.output_tokens", "llm.usage.output_tokens"),
+		}
+		if span.StatusCode == "STATUS_CODE_ERROR" {
+			request.Error = span.StatusMessage
+		}
+
+		if call := llmCallAt(out, span.StartTime); call != nil {
+			call.GatewayRequests = append(call.GatewayRequests, request)
+		} else {
+			out.GatewayRequests = append(out.GatewayRequests, request)
+		}
+	}
+}
+
+// llmCallAt returns the LLM call running at the given time
+func llmCallAt(out *RunTrace, at time.Time) *RunTraceLLMCall {
+	for i := range out.Loops {
+		call := &out.Loops[i].LLMCall
+		if call.StartedAt == nil {
+			continue
+		}
+		end := call.StartedAt.Add(time.Duration(call.DurationMs) * time.Millisecond)
+		if !at.Before(*call.StartedAt) && !at.After(end) {
+			return call
+		}
+	}
+	return nil
+}
+
+// spanInt returns the first of the attributes set on the span as an integer
+func spanInt(attributes map[string]string, names ...string) int {
+	for _, name := range names {
+		if value, ok := attributes[name]; ok {
+			n, _ := strconv.Atoi(value)
+			return n
+		}
+	}
+	return 0
+}
+
+// isModelOutput reports whether the message was produced by the model
+func isModelOutput(msg responses.InputMessageUnion) bool {
+	return msg.OfOutputMessage != nil ||
+		msg.OfFunctionCall != nil ||
+		msg.OfReasoning != nil ||
+		msg.OfImageGenerationCall != nil ||
+		msg.OfWebSearchCall != nil ||
+		msg.OfFileSearchCall != nil ||
+		msg.OfCodeInterpreterCall != nil
+}
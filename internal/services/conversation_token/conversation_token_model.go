@@ -0,0 +1,59 @@
+package conversation_token
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PermissionRead allows reading the conversations, threads and messages of the scope
+	PermissionRead = "read"
+	// PermissionWrite allows conversing with the agent of the scope
+	PermissionWrite = "write"
+
+	// TokenPrefix tells the conversation tokens apart from the access tokens of the users
+	TokenPrefix = "uct_"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid conversation token")
+	ErrOutOfScope   = errors.New("the request is out of the scope of the conversation token")
+)
+
+// Scope is what a conversation token grants access to: a namespace of a project, or a single conversation of it
+type Scope struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Namespace string    `json:"namespace"`
+	// ConversationID restricts the token to a conversation of the namespace, the token can't start conversations
+	ConversationID string `json:"conversation_id,omitempty"`
+	// AgentID is the agent the token converses with, as accepted by the agent_id parameter of the converse endpoint
+	AgentID     string   `json:"agent_id,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Allows reports whether the scope grants a permission
+func (s *Scope) Allows(permission string) bool {
+	return slices.Contains(s.Permissions, permission)
+}
+
+// IssueTokenRequest represents the request to issue a conversation token
+type IssueTokenRequest struct {
+	Namespace      string `json:"namespace" validate:"required"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	// AgentID is required with the write permission
+	AgentID string `json:"agent_id,omitempty"`
+	// Permissions are read and write, read by default
+	Permissions []string `json:"permissions,omitempty"`
+	// TTLSeconds is how long the token is valid, 15 minutes by default and a day at most
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+}
+
+// IssuedToken is a conversation token and what it grants access to
+type IssuedToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Scope     Scope     `json:"scope"`
+}
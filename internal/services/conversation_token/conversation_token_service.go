@@ -0,0 +1,169 @@
+package conversation_token
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTTL = 15 * time.Minute
+	maxTTL     = 24 * time.Hour
+
+	// tokenIssuer is the issuer of the conversation tokens
+	tokenIssuer = "uno-conversation-token"
+)
+
+// ConversationTokenService issues the short-lived tokens that give browser clients access to a namespace or a
+// conversation, so that they never hold the credentials of the project
+type ConversationTokenService struct {
+	conversations *conversation.ConversationService
+	key           []byte
+}
+
+// NewConversationTokenService creates a new conversation token service. The signing key is derived from the secret,
+// so that the tokens are never valid access tokens even when the secret is shared with them.
+func NewConversationTokenService(conversations *conversation.ConversationService, secret string) *ConversationTokenService {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(tokenIssuer))
+
+	return &ConversationTokenService{
+		conversations: conversations,
+		key:           mac.Sum(nil),
+	}
+}
+
+// tokenClaims are the claims of a conversation token
+type tokenClaims struct {
+	Scope Scope `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Issue issues a token for a namespace of a project, or a conversation of it
+func (s *ConversationTokenService) Issue(ctx context.Context, projectID uuid.UUID, req *IssueTokenRequest) (*IssuedToken, error) {
+	if strings.TrimSpace(req.Namespace) == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+
+	permissions := req.Permissions
+	if len(permissions) == 0 {
+		permissions = []string{PermissionRead}
+	}
+	for _, permission := range permissions {
+		if permission != PermissionRead && permission != PermissionWrite {
+			return nil, fmt.Errorf("invalid permission '%s': use read or write", permission)
+		}
+	}
+	if slices.Contains(permissions, PermissionWrite) && req.AgentID == "" {
+		return nil, fmt.Errorf("agent_id is required with the write permission")
+	}
+
+	ttl := defaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > maxTTL {
+		return nil, fmt.Errorf("ttl_seconds is at most %d", int(maxTTL.Seconds()))
+	}
+
+	if req.ConversationID != "" {
+		if _, err := s.conversations.GetConversation(ctx, projectID, req.Namespace, req.ConversationID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("conversation %s not found in namespace %s", req.ConversationID, req.Namespace)
+			}
+			return nil, err
+		}
+	}
+
+	scope := Scope{
+		ProjectID:      projectID,
+		Namespace:      req.Namespace,
+		ConversationID: req.ConversationID,
+		AgentID:        req.AgentID,
+		Permissions:    slices.Compact(slices.Sorted(slices.Values(permissions))),
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		Scope: scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign conversation token: %w", err)
+	}
+
+	return &IssuedToken{
+		Token:     TokenPrefix + signed,
+		ExpiresAt: expiresAt,
+		Scope:     scope,
+	}, nil
+}
+
+// IsToken reports whether a bearer token is a conversation token
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// Verify verifies a conversation token and returns its scope
+func (s *ConversationTokenService) Verify(token string) (*Scope, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(token, TokenPrefix), claims, func(*jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+
+	return &claims.Scope, nil
+}
+
+// ThreadInScope reports whether a thread of the namespace of a scope belongs to its conversation
+func (s *ConversationTokenService) ThreadInScope(ctx context.Context, scope *Scope, threadID string) (bool, error) {
+	if scope.ConversationID == "" {
+		return true, nil
+	}
+
+	thread, err := s.conversations.GetThread(ctx, scope.ProjectID, scope.Namespace, threadID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return thread.ConversationID == scope.ConversationID, nil
+}
+
+// MessageInScope reports whether a message of the namespace of a scope belongs to its conversation
+func (s *ConversationTokenService) MessageInScope(ctx context.Context, scope *Scope, messageID string) (bool, error) {
+	if scope.ConversationID == "" {
+		return true, nil
+	}
+
+	message, err := s.conversations.GetMessage(ctx, scope.ProjectID, scope.Namespace, messageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return message.ConversationID == scope.ConversationID, nil
+}
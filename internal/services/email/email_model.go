@@ -0,0 +1,22 @@
+package email
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EmailMessage is an email the email channel received or sent, with the thread of its conversation in the history
+type EmailMessage struct {
+	MessageID string    `json:"message_id" db:"message_id"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Namespace string    `json:"namespace" db:"namespace"`
+	// ThreadID is empty until the first run of a new conversation started its thread
+	ThreadID string `json:"thread_id" db:"thread_id"`
+	// RunID is the run a reply of the agent answers with, empty for a received email
+	RunID string `json:"run_id" db:"run_id"`
+	// PendingCallIDs are the tool calls the run of a reply waits the approval of
+	PendingCallIDs pq.StringArray `json:"pending_call_ids" db:"pending_call_ids"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
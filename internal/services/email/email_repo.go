@@ -0,0 +1,72 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// EmailRepo handles database operations for the emails of the email channel
+type EmailRepo struct {
+	db *sqlx.DB
+}
+
+// NewEmailRepo creates a new email repository
+func NewEmailRepo(db *sqlx.DB) *EmailRepo {
+	return &EmailRepo{db: db}
+}
+
+// GetMessage retrieves an email by its Message-ID
+func (r *EmailRepo) GetMessage(ctx context.Context, messageID string) (*EmailMessage, error) {
+	query := `
+		SELECT message_id, project_id, namespace, thread_id, run_id, pending_call_ids, created_at
+		FROM email_messages
+		WHERE message_id = $1
+	`
+
+	var message EmailMessage
+	if err := r.db.GetContext(ctx, &message, query, messageID); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// CreateMessage records an email and reports whether it wasn't recorded already
+func (r *EmailRepo) CreateMessage(ctx context.Context, message *EmailMessage) (bool, error) {
+	query := `
+		INSERT INTO email_messages (message_id, project_id, namespace, thread_id, run_id, pending_call_ids)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (message_id) DO NOTHING
+	`
+
+	callIDs := message.PendingCallIDs
+	if callIDs == nil {
+		callIDs = pq.StringArray{}
+	}
+
+	res, err := r.db.ExecContext(ctx, query, message.MessageID, message.ProjectID, message.Namespace, message.ThreadID, message.RunID, callIDs)
+	if err != nil {
+		return false, fmt.Errorf("failed to record email: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// UpdateThread sets the thread of an email whose conversation started with it
+func (r *EmailRepo) UpdateThread(ctx context.Context, messageID string, threadID string) error {
+	query := `UPDATE email_messages SET thread_id = $2 WHERE message_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, messageID, threadID); err != nil {
+		return fmt.Errorf("failed to update thread of email: %w", err)
+	}
+
+	return nil
+}
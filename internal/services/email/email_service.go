@@ -0,0 +1,38 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// EmailService keeps the emails of the email channel
+type EmailService struct {
+	repo *EmailRepo
+}
+
+// NewEmailService creates a new email service
+func NewEmailService(repo *EmailRepo) *EmailService {
+	return &EmailService{repo: repo}
+}
+
+// GetMessage returns an email by its Message-ID, nil when the channel doesn't know it
+func (s *EmailService) GetMessage(ctx context.Context, messageID string) (*EmailMessage, error) {
+	message, err := s.repo.GetMessage(ctx, messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return message, err
+}
+
+// CreateMessage records an email. It returns false for an email recorded already, which was handled by another
+// replica or an earlier poll.
+func (s *EmailService) CreateMessage(ctx context.Context, message *EmailMessage) (bool, error) {
+	return s.repo.CreateMessage(ctx, message)
+}
+
+// UpdateThread sets the thread an email started the conversation of
+func (s *EmailService) UpdateThread(ctx context.Context, messageID string, threadID string) error {
+	return s.repo.UpdateThread(ctx, messageID, threadID)
+}
@@ -0,0 +1,121 @@
+package environment
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindAgent  = "agent"
+	KindPrompt = "prompt"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApplied  = "applied"
+	StatusRejected = "rejected"
+)
+
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+var ErrNotDeployed = errors.New("nothing is deployed to the environment")
+
+// DefaultEnvironments are created for a project the first time its environments are listed
+var DefaultEnvironments = []CreateEnvironmentRequest{
+	{Name: "dev", RequiredApprovals: 0},
+	{Name: "staging", RequiredApprovals: 0},
+	{Name: "prod", RequiredApprovals: 1},
+}
+
+// Environment is a stage that agent and prompt versions are promoted through, e.g. dev -> staging -> prod
+type Environment struct {
+	ID                uuid.UUID `json:"id" db:"id"`
+	ProjectID         uuid.UUID `json:"project_id" db:"project_id"`
+	Name              string    `json:"name" db:"name"`
+	Position          int       `json:"position" db:"position"`
+	RequiredApprovals int       `json:"required_approvals" db:"required_approvals"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Deployment is the version of an agent or prompt that is live in an environment
+type Deployment struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	EnvironmentID uuid.UUID  `json:"environment_id" db:"environment_id"`
+	Environment   string     `json:"environment" db:"environment"`
+	Kind          string     `json:"kind" db:"kind"`
+	RefID         uuid.UUID  `json:"ref_id" db:"ref_id"` // agent_id or prompt id
+	Version       int        `json:"version" db:"version"`
+	PromotionID   *uuid.UUID `json:"promotion_id,omitempty" db:"promotion_id"`
+	DeployedAt    time.Time  `json:"deployed_at" db:"deployed_at"`
+}
+
+// Promotion is a request to deploy a version to an environment. It is applied once it has
+// the number of approvals required by the target environment.
+type Promotion struct {
+	ID                uuid.UUID         `json:"id" db:"id"`
+	ProjectID         uuid.UUID         `json:"project_id" db:"project_id"`
+	Kind              string            `json:"kind" db:"kind"`
+	RefID             uuid.UUID         `json:"ref_id" db:"ref_id"`
+	Version           int               `json:"version" db:"version"`
+	FromEnvironment   *string           `json:"from_environment,omitempty" db:"from_environment"`
+	ToEnvironment     string            `json:"to_environment" db:"to_environment"`
+	Status            string            `json:"status" db:"status"`
+	RequiredApprovals int               `json:"required_approvals" db:"required_approvals"`
+	RequestedBy       string            `json:"requested_by" db:"requested_by"`
+	Reviews           []PromotionReview `json:"reviews" db:"-"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+	AppliedAt         *time.Time        `json:"applied_at,omitempty" db:"applied_at"`
+}
+
+// Approvals returns the number of approving reviews
+func (p *Promotion) Approvals() int {
+	n := 0
+	for _, review := range p.Reviews {
+		if review.Decision == DecisionApprove {
+			n++
+		}
+	}
+	return n
+}
+
+// PromotionReview is the approval or rejection of a promotion by a user
+type PromotionReview struct {
+	PromotionID uuid.UUID `json:"promotion_id" db:"promotion_id"`
+	UserID      string    `json:"user_id" db:"user_id"`
+	Decision    string    `json:"decision" db:"decision"`
+	Comment     *string   `json:"comment,omitempty" db:"comment"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateEnvironmentRequest represents the request to create a new environment, placed after the existing ones
+type CreateEnvironmentRequest struct {
+	Name              string `json:"name" validate:"required,min=1,max=64"`
+	RequiredApprovals int    `json:"required_approvals" validate:"min=0"`
+}
+
+// UpdateEnvironmentRequest represents the request to update an environment
+type UpdateEnvironmentRequest struct {
+	RequiredApprovals int `json:"required_approvals" validate:"min=0"`
+}
+
+// CreatePromotionRequest represents the request to promote a version to an environment.
+// Either Version or FromEnvironment is set; with FromEnvironment, the version deployed there is promoted.
+type CreatePromotionRequest struct {
+	Kind            string    `json:"kind" validate:"required,oneof=agent prompt"`
+	RefID           uuid.UUID `json:"ref_id" validate:"required"`
+	Version         *int      `json:"version,omitempty"`
+	FromEnvironment *string   `json:"from_environment,omitempty"`
+	ToEnvironment   string    `json:"to_environment" validate:"required"`
+}
+
+// ReviewPromotionRequest represents the request to approve or reject a promotion
+type ReviewPromotionRequest struct {
+	Comment *string `json:"comment,omitempty"`
+}
@@ -0,0 +1,324 @@
+package environment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// EnvironmentRepo handles database operations for environments, promotions and deployments
+type EnvironmentRepo struct {
+	db *sqlx.DB
+}
+
+// NewEnvironmentRepo creates a new environment repository
+func NewEnvironmentRepo(db *sqlx.DB) *EnvironmentRepo {
+	return &EnvironmentRepo{db: db}
+}
+
+// Create creates a new environment after the existing environments of the project
+func (r *EnvironmentRepo) Create(ctx context.Context, projectID uuid.UUID, req *CreateEnvironmentRequest) (*Environment, error) {
+	query := `
+		INSERT INTO environments (project_id, name, position, required_approvals)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position) + 1, 0) FROM environments WHERE project_id = $1), $3)
+		RETURNING id, project_id, name, position, required_approvals, created_at, updated_at
+	`
+
+	var env Environment
+	if err := r.db.GetContext(ctx, &env, query, projectID, req.Name, req.RequiredApprovals); err != nil {
+		return nil, fmt.Errorf("failed to create environment: %w", err)
+	}
+
+	return &env, nil
+}
+
+// List retrieves the environments of a project in promotion order
+func (r *EnvironmentRepo) List(ctx context.Context, projectID uuid.UUID) ([]*Environment, error) {
+	query := `
+		SELECT id, project_id, name, position, required_approvals, created_at, updated_at
+		FROM environments
+		WHERE project_id = $1
+		ORDER BY position, name
+	`
+
+	envs := []*Environment{}
+	if err := r.db.SelectContext(ctx, &envs, query, projectID); err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	return envs, nil
+}
+
+// GetByName retrieves an environment by name
+func (r *EnvironmentRepo) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*Environment, error) {
+	query := `
+		SELECT id, project_id, name, position, required_approvals, created_at, updated_at
+		FROM environments
+		WHERE project_id = $1 AND name = $2
+	`
+
+	var env Environment
+	if err := r.db.GetContext(ctx, &env, query, projectID, name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("environment '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get environment: %w", err)
+	}
+
+	return &env, nil
+}
+
+// Update updates the required approvals of an environment
+func (r *EnvironmentRepo) Update(ctx context.Context, projectID uuid.UUID, name string, req *UpdateEnvironmentRequest) (*Environment, error) {
+	query := `
+		UPDATE environments SET required_approvals = $3, updated_at = NOW()
+		WHERE project_id = $1 AND name = $2
+		RETURNING id, project_id, name, position, required_approvals, created_at, updated_at
+	`
+
+	var env Environment
+	if err := r.db.GetContext(ctx, &env, query, projectID, name, req.RequiredApprovals); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("environment '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to update environment: %w", err)
+	}
+
+	return &env, nil
+}
+
+// Delete deletes an environment together with its deployments
+func (r *EnvironmentRepo) Delete(ctx context.Context, projectID uuid.UUID, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM environments WHERE project_id = $1 AND name = $2`, projectID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete environment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("environment '%s' not found", name)
+	}
+
+	return nil
+}
+
+// VersionExists checks that the version of the agent or prompt exists and is not in the trash
+func (r *EnvironmentRepo) VersionExists(ctx context.Context, projectID uuid.UUID, kind string, refID uuid.UUID, version int) (bool, error) {
+	var query string
+	switch kind {
+	case KindAgent:
+		query = `
+			SELECT COUNT(*) FROM agent_configs
+			WHERE project_id = $1 AND agent_id = $2 AND version = $3 AND deleted_at IS NULL
+		`
+	case KindPrompt:
+		query = `
+			SELECT COUNT(*) FROM prompt_versions pv
+			JOIN prompts p ON pv.prompt_id = p.id
+			WHERE p.project_id = $1 AND p.id = $2 AND pv.version = $3 AND p.deleted_at IS NULL
+		`
+	default:
+		return false, fmt.Errorf("unknown kind: %s", kind)
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, query, projectID, refID, version); err != nil {
+		return false, fmt.Errorf("failed to check version existence: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetDeployment retrieves the deployment of an agent or prompt in an environment
+func (r *EnvironmentRepo) GetDeployment(ctx context.Context, projectID uuid.UUID, environment, kind string, refID uuid.UUID) (*Deployment, error) {
+	query := `
+		SELECT d.id, d.environment_id, e.name as environment, d.kind, d.ref_id, d.version, d.promotion_id, d.deployed_at
+		FROM environment_deployments d
+		JOIN environments e ON d.environment_id = e.id
+		WHERE e.project_id = $1 AND e.name = $2 AND d.kind = $3 AND d.ref_id = $4
+	`
+
+	var deployment Deployment
+	if err := r.db.GetContext(ctx, &deployment, query, projectID, environment, kind, refID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotDeployed
+		}
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	return &deployment, nil
+}
+
+// ListDeployments retrieves the deployments of all environments of a project
+func (r *EnvironmentRepo) ListDeployments(ctx context.Context, projectID uuid.UUID) ([]*Deployment, error) {
+	query := `
+		SELECT d.id, d.environment_id, e.name as environment, d.kind, d.ref_id, d.version, d.promotion_id, d.deployed_at
+		FROM environment_deployments d
+		JOIN environments e ON d.environment_id = e.id
+		WHERE e.project_id = $1
+		ORDER BY e.position, d.kind, d.deployed_at DESC
+	`
+
+	deployments := []*Deployment{}
+	if err := r.db.SelectContext(ctx, &deployments, query, projectID); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	return deployments, nil
+}
+
+// CreatePromotion creates a pending promotion
+func (r *EnvironmentRepo) CreatePromotion(ctx context.Context, projectID uuid.UUID, req *CreatePromotionRequest, version int, requiredApprovals int, requestedBy string) (*Promotion, error) {
+	query := `
+		INSERT INTO promotions (project_id, kind, ref_id, version, from_environment, to_environment, required_approvals, requested_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, project_id, kind, ref_id, version, from_environment, to_environment, status, required_approvals, requested_by, created_at, updated_at, applied_at
+	`
+
+	var promotion Promotion
+	err := r.db.GetContext(ctx, &promotion, query, projectID, req.Kind, req.RefID, version, req.FromEnvironment, req.ToEnvironment, requiredApprovals, requestedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create promotion: %w", err)
+	}
+	promotion.Reviews = []PromotionReview{}
+
+	return &promotion, nil
+}
+
+// GetPromotion retrieves a promotion with its reviews
+func (r *EnvironmentRepo) GetPromotion(ctx context.Context, projectID, id uuid.UUID) (*Promotion, error) {
+	query := `
+		SELECT id, project_id, kind, ref_id, version, from_environment, to_environment, status, required_approvals, requested_by, created_at, updated_at, applied_at
+		FROM promotions
+		WHERE project_id = $1 AND id = $2
+	`
+
+	var promotion Promotion
+	if err := r.db.GetContext(ctx, &promotion, query, projectID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promotion not found")
+		}
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	promotion.Reviews = []PromotionReview{}
+	err := r.db.SelectContext(ctx, &promotion.Reviews, `
+		SELECT promotion_id, user_id, decision, comment, created_at
+		FROM promotion_reviews
+		WHERE promotion_id = $1
+		ORDER BY created_at
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion reviews: %w", err)
+	}
+
+	return &promotion, nil
+}
+
+// ListPromotions retrieves the promotions of a project, newest first, optionally filtered by status
+func (r *EnvironmentRepo) ListPromotions(ctx context.Context, projectID uuid.UUID, status string) ([]*Promotion, error) {
+	query := `
+		SELECT id, project_id, kind, ref_id, version, from_environment, to_environment, status, required_approvals, requested_by, created_at, updated_at, applied_at
+		FROM promotions
+		WHERE project_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT 200
+	`
+
+	promotions := []*Promotion{}
+	if err := r.db.SelectContext(ctx, &promotions, query, projectID, status); err != nil {
+		return nil, fmt.Errorf("failed to list promotions: %w", err)
+	}
+
+	return promotions, nil
+}
+
+// AddReview records the review of a user. Each user reviews a promotion at most once.
+func (r *EnvironmentRepo) AddReview(ctx context.Context, promotionID uuid.UUID, userID, decision string, comment *string) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO promotion_reviews (promotion_id, user_id, decision, comment)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (promotion_id, user_id) DO NOTHING
+	`, promotionID, userID, decision, comment)
+	if err != nil {
+		return fmt.Errorf("failed to review promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user '%s' already reviewed the promotion", userID)
+	}
+
+	return nil
+}
+
+// SetPromotionStatus moves a pending promotion to the given status
+func (r *EnvironmentRepo) SetPromotionStatus(ctx context.Context, id uuid.UUID, status string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE promotions SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("promotion is not pending")
+	}
+
+	return nil
+}
+
+// ApplyPromotion deploys the version of a pending promotion to its environment and marks the promotion as applied
+func (r *EnvironmentRepo) ApplyPromotion(ctx context.Context, promotion *Promotion) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE promotions SET status = 'applied', applied_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, promotion.ID)
+	if err != nil {
+		return fmt.Errorf("failed to apply promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("promotion is not pending")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO environment_deployments (environment_id, kind, ref_id, version, promotion_id)
+		SELECT id, $3, $4, $5, $6 FROM environments WHERE project_id = $1 AND name = $2
+		ON CONFLICT (environment_id, kind, ref_id)
+		DO UPDATE SET version = EXCLUDED.version, promotion_id = EXCLUDED.promotion_id, deployed_at = NOW()
+	`, promotion.ProjectID, promotion.ToEnvironment, promotion.Kind, promotion.RefID, promotion.Version, promotion.ID)
+	if err != nil {
+		return fmt.Errorf("failed to deploy promotion: %w", err)
+	}
+
+	return tx.Commit()
+}
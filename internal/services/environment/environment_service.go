@@ -0,0 +1,224 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+var environmentNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// EnvironmentService handles business logic for environments and the promotion of agent and prompt versions
+// between them
+type EnvironmentService struct {
+	repo *EnvironmentRepo
+}
+
+// NewEnvironmentService creates a new environment service
+func NewEnvironmentService(repo *EnvironmentRepo) *EnvironmentService {
+	return &EnvironmentService{repo: repo}
+}
+
+// Create creates a new environment, which becomes the last one in promotion order
+func (s *EnvironmentService) Create(ctx context.Context, projectID uuid.UUID, req *CreateEnvironmentRequest) (*Environment, error) {
+	if !environmentNameRegex.MatchString(req.Name) || len(req.Name) > 64 {
+		return nil, fmt.Errorf("invalid environment name '%s': use lowercase letters, digits, '-' and '_'", req.Name)
+	}
+
+	if req.RequiredApprovals < 0 {
+		return nil, fmt.Errorf("required_approvals must not be negative")
+	}
+
+	return s.repo.Create(ctx, projectID, req)
+}
+
+// List retrieves the environments of a project in promotion order, creating the default environments
+// for projects that don't have any
+func (s *EnvironmentService) List(ctx context.Context, projectID uuid.UUID) ([]*Environment, error) {
+	envs, err := s.repo.List(ctx, projectID)
+	if err != nil || len(envs) > 0 {
+		return envs, err
+	}
+
+	for _, req := range DefaultEnvironments {
+		if _, err := s.repo.Create(ctx, projectID, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.List(ctx, projectID)
+}
+
+// Update updates the required approvals of an environment. Pending promotions keep the approvals
+// required when they were requested.
+func (s *EnvironmentService) Update(ctx context.Context, projectID uuid.UUID, name string, req *UpdateEnvironmentRequest) (*Environment, error) {
+	if req.RequiredApprovals < 0 {
+		return nil, fmt.Errorf("required_approvals must not be negative")
+	}
+
+	return s.repo.Update(ctx, projectID, name, req)
+}
+
+// Delete deletes an environment together with its deployments
+func (s *EnvironmentService) Delete(ctx context.Context, projectID uuid.UUID, name string) error {
+	return s.repo.Delete(ctx, projectID, name)
+}
+
+// ListDeployments retrieves what is deployed to the environments of a project
+func (s *EnvironmentService) ListDeployments(ctx context.Context, projectID uuid.UUID) ([]*Deployment, error) {
+	return s.repo.ListDeployments(ctx, projectID)
+}
+
+// ResolveVersion returns the version of the agent or prompt deployed to the environment, or ErrNotDeployed
+func (s *EnvironmentService) ResolveVersion(ctx context.Context, projectID uuid.UUID, environment, kind string, refID uuid.UUID) (int, error) {
+	deployment, err := s.repo.GetDeployment(ctx, projectID, environment, kind, refID)
+	if err != nil {
+		return 0, err
+	}
+
+	return deployment.Version, nil
+}
+
+// CreatePromotion requests the promotion of a version to an environment. When promoting from another environment,
+// the version deployed there is promoted, and the source must come before the target in promotion order.
+// Promotions to environments that require no approvals are applied right away.
+func (s *EnvironmentService) CreatePromotion(ctx context.Context, projectID uuid.UUID, req *CreatePromotionRequest, requestedBy string) (*Promotion, error) {
+	if req.Kind != KindAgent && req.Kind != KindPrompt {
+		return nil, fmt.Errorf("kind must be '%s' or '%s'", KindAgent, KindPrompt)
+	}
+
+	if req.RefID == uuid.Nil {
+		return nil, fmt.Errorf("ref_id is required")
+	}
+
+	target, err := s.repo.GetByName(ctx, projectID, req.ToEnvironment)
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	switch {
+	case req.FromEnvironment != nil:
+		source, err := s.repo.GetByName(ctx, projectID, *req.FromEnvironment)
+		if err != nil {
+			return nil, err
+		}
+
+		if source.Position >= target.Position {
+			return nil, fmt.Errorf("can't promote from '%s' to '%s': environments must be promoted in order", source.Name, target.Name)
+		}
+
+		version, err = s.ResolveVersion(ctx, projectID, source.Name, req.Kind, req.RefID)
+		if err != nil {
+			return nil, fmt.Errorf("can't promote from '%s': %w", source.Name, err)
+		}
+
+		if req.Version != nil && *req.Version != version {
+			return nil, fmt.Errorf("version %d is not deployed to '%s', version %d is", *req.Version, source.Name, version)
+		}
+	case req.Version != nil:
+		version = *req.Version
+	default:
+		return nil, fmt.Errorf("either version or from_environment is required")
+	}
+
+	// Version 0 is the mutable draft, only published versions can be promoted
+	if version <= 0 {
+		return nil, fmt.Errorf("only published versions can be promoted")
+	}
+
+	exists, err := s.repo.VersionExists(ctx, projectID, req.Kind, req.RefID, version)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s version %d does not exist", req.Kind, version)
+	}
+
+	promotion, err := s.repo.CreatePromotion(ctx, projectID, req, version, target.RequiredApprovals, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion.RequiredApprovals == 0 {
+		if err := s.repo.ApplyPromotion(ctx, promotion); err != nil {
+			return nil, err
+		}
+		return s.repo.GetPromotion(ctx, projectID, promotion.ID)
+	}
+
+	return promotion, nil
+}
+
+// GetPromotion retrieves a promotion with its reviews
+func (s *EnvironmentService) GetPromotion(ctx context.Context, projectID, id uuid.UUID) (*Promotion, error) {
+	return s.repo.GetPromotion(ctx, projectID, id)
+}
+
+// ListPromotions retrieves the promotions of a project, optionally filtered by status
+func (s *EnvironmentService) ListPromotions(ctx context.Context, projectID uuid.UUID, status string) ([]*Promotion, error) {
+	return s.repo.ListPromotions(ctx, projectID, status)
+}
+
+// ApprovePromotion records the approval of a user, and applies the promotion once it has the required approvals.
+// The requester of a promotion can't approve it.
+func (s *EnvironmentService) ApprovePromotion(ctx context.Context, projectID, id uuid.UUID, userID string, req *ReviewPromotionRequest) (*Promotion, error) {
+	promotion, err := s.pendingPromotion(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == promotion.RequestedBy {
+		return nil, fmt.Errorf("a promotion can't be approved by its requester")
+	}
+
+	if err := s.repo.AddReview(ctx, id, userID, DecisionApprove, req.Comment); err != nil {
+		return nil, err
+	}
+
+	promotion, err = s.repo.GetPromotion(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion.Approvals() >= promotion.RequiredApprovals {
+		if err := s.repo.ApplyPromotion(ctx, promotion); err != nil {
+			return nil, err
+		}
+		return s.repo.GetPromotion(ctx, projectID, id)
+	}
+
+	return promotion, nil
+}
+
+// RejectPromotion rejects a pending promotion
+func (s *EnvironmentService) RejectPromotion(ctx context.Context, projectID, id uuid.UUID, userID string, req *ReviewPromotionRequest) (*Promotion, error) {
+	if _, err := s.pendingPromotion(ctx, projectID, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddReview(ctx, id, userID, DecisionReject, req.Comment); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetPromotionStatus(ctx, id, StatusRejected); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPromotion(ctx, projectID, id)
+}
+
+func (s *EnvironmentService) pendingPromotion(ctx context.Context, projectID, id uuid.UUID) (*Promotion, error) {
+	promotion, err := s.repo.GetPromotion(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion.Status != StatusPending {
+		return nil, fmt.Errorf("promotion is already %s", promotion.Status)
+	}
+
+	return promotion, nil
+}
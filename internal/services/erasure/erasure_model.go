@@ -0,0 +1,46 @@
+package erasure
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ModeDelete hard deletes the data of the subject
+	ModeDelete = "delete"
+	// ModeAnonymize keeps the records of the subject without their content and the identifier of the subject
+	ModeAnonymize = "anonymize"
+
+	// DefaultSubjectKey is the key of message meta the subject is identified by
+	DefaultSubjectKey = "user_id"
+)
+
+// EraseSubjectRequest captures payload for erasing the data of a subject
+type EraseSubjectRequest struct {
+	Subject    string `json:"subject" validate:"required"`
+	SubjectKey string `json:"subject_key,omitempty"`
+	Mode       string `json:"mode,omitempty" validate:"omitempty,oneof=delete anonymize"`
+}
+
+// ErasureReport records what was erased for a subject. It holds the SHA-256 of the subject instead of the subject
+// itself, and is signed with HMAC-SHA256 so that it can be kept as evidence of the erasure.
+type ErasureReport struct {
+	ID          uuid.UUID `json:"id"`
+	ProjectID   uuid.UUID `json:"project_id"`
+	SubjectKey  string    `json:"subject_key"`
+	SubjectHash string    `json:"subject_hash"`
+	Mode        string    `json:"mode"`
+	Namespaces  []string  `json:"namespaces"`
+
+	Messages      int64 `json:"messages"`
+	Attachments   int64 `json:"attachments"`
+	Summaries     int64 `json:"summaries"`
+	Threads       int64 `json:"threads"`
+	Conversations int64 `json:"conversations"`
+	SpooledWrites int64 `json:"spooled_writes"`
+	RequestLogs   int64 `json:"request_logs"`
+
+	ErasedAt  time.Time `json:"erased_at"`
+	Signature string    `json:"signature"`
+}
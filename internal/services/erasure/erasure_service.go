@@ -0,0 +1,138 @@
+package erasure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/services/conversation"
+	"github.com/curaious/uno/internal/services/gateway_response"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSignature = errors.New("invalid erasure report signature")
+
+// ErasureService erases the data of a data subject, identified by a key of message meta, on request of the subject
+type ErasureService struct {
+	conversations *conversation.ConversationService
+	spool         *conversation.HistorySpool
+	responses     *gateway_response.GatewayResponseService
+	secret        []byte
+}
+
+// NewErasureService constructs a new ErasureService. The spool may be nil.
+func NewErasureService(conversations *conversation.ConversationService, spool *conversation.HistorySpool, responses *gateway_response.GatewayResponseService, secret string) *ErasureService {
+	return &ErasureService{
+		conversations: conversations,
+		spool:         spool,
+		responses:     responses,
+		secret:        []byte(secret),
+	}
+}
+
+// EraseSubject erases the messages, summaries and attachments of the subject in all namespaces of the project, the
+// history writes of the subject queued for retry, and the stored gateway responses whose request metadata holds
+// the subject under the same key, made with the virtual keys of the project. It returns the signed report of the
+// erasure.
+func (s *ErasureService) EraseSubject(ctx context.Context, projectID uuid.UUID, req *EraseSubjectRequest) (*ErasureReport, error) {
+	if req.Subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	if req.SubjectKey == "" {
+		req.SubjectKey = DefaultSubjectKey
+	}
+	if req.Mode == "" {
+		req.Mode = ModeDelete
+	}
+	if req.Mode != ModeDelete && req.Mode != ModeAnonymize {
+		return nil, fmt.Errorf("invalid mode %s", req.Mode)
+	}
+	anonymize := req.Mode == ModeAnonymize
+
+	// Queued writes go first, so that they can't be replayed once the stored messages are erased
+	var spooled int64
+	if s.spool != nil {
+		var err error
+		spooled, err = s.spool.EraseSubject(projectID, req.SubjectKey, req.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase queued history writes: %w", err)
+		}
+	}
+
+	erased, err := s.conversations.EraseSubject(ctx, projectID, req.SubjectKey, req.Subject, anonymize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase conversations: %w", err)
+	}
+
+	requestLogs, err := s.responses.EraseSubject(ctx, projectID, req.SubjectKey, req.Subject, anonymize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase gateway responses: %w", err)
+	}
+
+	subjectHash := sha256.Sum256([]byte(req.Subject))
+	report := &ErasureReport{
+		ID:            uuid.New(),
+		ProjectID:     projectID,
+		SubjectKey:    req.SubjectKey,
+		SubjectHash:   hex.EncodeToString(subjectHash[:]),
+		Mode:          req.Mode,
+		Namespaces:    erased.Namespaces,
+		Messages:      erased.Messages,
+		Attachments:   erased.Attachments,
+		Summaries:     erased.Summaries,
+		Threads:       erased.Threads,
+		Conversations: erased.Conversations,
+		SpooledWrites: spooled,
+		RequestLogs:   requestLogs,
+		ErasedAt:      time.Now().UTC(),
+	}
+
+	report.Signature, err = s.sign(report)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Erased data subject",
+		slog.String("report_id", report.ID.String()),
+		slog.String("project_id", projectID.String()),
+		slog.String("mode", report.Mode),
+		slog.Int64("messages", report.Messages),
+		slog.Int64("request_logs", report.RequestLogs))
+
+	return report, nil
+}
+
+// VerifyReport checks that the report was signed by this server and not modified since
+func (s *ErasureService) VerifyReport(report *ErasureReport) error {
+	expected, err := s.sign(report)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(report.Signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// sign returns the signature of the report, computed over its JSON without the signature
+func (s *ErasureService) sign(report *ErasureReport) (string, error) {
+	unsigned := *report
+	unsigned.Signature = ""
+
+	buf, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal erasure report: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(buf)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil)), nil
+}
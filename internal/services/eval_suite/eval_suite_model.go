@@ -0,0 +1,171 @@
+package eval_suite
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/agent-framework/eval"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPassed = "passed"
+	StatusFailed = "failed"
+)
+
+// DefaultMaxRegression is how much the mean score of an evaluator may drop from the baseline version, unless the
+// suite or the evaluator sets another one
+const DefaultMaxRegression = 0.05
+
+// ErrSuiteNotFound is returned when the agent has no eval suite
+var ErrSuiteNotFound = errors.New("eval suite not found")
+
+// Suite is the eval suite of an agent. It runs when an alias is pointed at a new version of the agent, and the
+// promotion is refused when the scores of the version regress from the version the alias pointed at.
+type Suite struct {
+	ProjectID     uuid.UUID  `json:"project_id" db:"project_id"`
+	AgentID       uuid.UUID  `json:"agent_id" db:"agent_id"`
+	Cases         Cases      `json:"cases" db:"cases"`
+	Evaluators    Evaluators `json:"evaluators" db:"evaluators"`
+	MaxRegression float64    `json:"max_regression" db:"max_regression"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Cases are the inputs of an eval suite
+type Cases []eval.Case
+
+// Scan implements the sql.Scanner interface for database/sql
+func (c *Cases) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (c Cases) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Evaluators are the evaluators of an eval suite
+type Evaluators []eval.Config
+
+// Scan implements the sql.Scanner interface for database/sql
+func (e *Evaluators) Scan(value interface{}) error {
+	return scanJSON(value, e)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (e Evaluators) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+// SaveSuiteRequest represents the request to create or replace the eval suite of an agent
+type SaveSuiteRequest struct {
+	Cases         []eval.Case   `json:"cases"`
+	Evaluators    []eval.Config `json:"evaluators"`
+	MaxRegression *float64      `json:"max_regression,omitempty"` // Defaults to DefaultMaxRegression
+}
+
+// RunSuiteRequest represents the request to run the eval suite on a version, compared with the baseline version
+type RunSuiteRequest struct {
+	Version         int  `json:"version"`
+	BaselineVersion *int `json:"baseline_version,omitempty"`
+}
+
+// EvalRun is a run of the eval suite of an agent on a version
+type EvalRun struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	ProjectID       uuid.UUID  `json:"project_id" db:"project_id"`
+	AgentID         uuid.UUID  `json:"agent_id" db:"agent_id"`
+	AliasID         *uuid.UUID `json:"alias_id,omitempty" db:"alias_id"` // Alias whose promotion triggered the run
+	Version         int        `json:"version" db:"version"`
+	BaselineVersion *int       `json:"baseline_version,omitempty" db:"baseline_version"`
+	Status          string     `json:"status" db:"status"`
+	Report          Report     `json:"report" db:"report"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Report holds the scores of the evaluators and the results of every case of an eval run
+type Report struct {
+	Scores  []EvaluatorScore `json:"scores"`
+	Results []CaseResult     `json:"results,omitempty"`
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (r *Report) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (r Report) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// EvaluatorScore is the mean score of an evaluator over the cases, and whether it meets its thresholds
+type EvaluatorScore struct {
+	Evaluator     string   `json:"evaluator"`
+	Score         float64  `json:"score"`
+	Baseline      *float64 `json:"baseline,omitempty"` // Mean score of the baseline version
+	MinScore      *float64 `json:"min_score,omitempty"`
+	MaxRegression float64  `json:"max_regression"`
+	Passed        bool     `json:"passed"`
+	Reason        string   `json:"reason,omitempty"` // Why the evaluator failed
+}
+
+// CaseResult holds the outcomes of a case on both versions, with the score of every evaluator
+type CaseResult struct {
+	Index          int                `json:"index"`
+	Name           string             `json:"name,omitempty"`
+	Candidate      *eval.Outcome      `json:"candidate"`
+	Baseline       *eval.Outcome      `json:"baseline,omitempty"`
+	Scores         map[string]float64 `json:"scores"`
+	BaselineScores map[string]float64 `json:"baseline_scores,omitempty"`
+	Errors         map[string]string  `json:"errors,omitempty"` // Errors of the evaluators, which score 0
+}
+
+// ValidationError is returned when an eval suite is invalid
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// RegressionError is returned when a version fails the eval suite of the agent, carrying the run
+type RegressionError struct {
+	Run *EvalRun
+}
+
+func (e *RegressionError) Error() string {
+	for _, score := range e.Run.Report.Scores {
+		if !score.Passed {
+			return fmt.Sprintf("version %d fails the eval suite: %s %s", e.Run.Version, score.Evaluator, score.Reason)
+		}
+	}
+	return fmt.Sprintf("version %d fails the eval suite", e.Run.Version)
+}
+
+func scanJSON(value interface{}, dest any) error {
+	if value == nil {
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", value, dest)
+	}
+
+	return json.Unmarshal(bytes, dest)
+}
@@ -0,0 +1,126 @@
+package eval_suite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// EvalSuiteRepo handles database operations for the eval suites of agents and their runs
+type EvalSuiteRepo struct {
+	db *sqlx.DB
+}
+
+// NewEvalSuiteRepo creates a new eval suite repository
+func NewEvalSuiteRepo(db *sqlx.DB) *EvalSuiteRepo {
+	return &EvalSuiteRepo{db: db}
+}
+
+// Save creates or replaces the eval suite of an agent
+func (r *EvalSuiteRepo) Save(ctx context.Context, suite *Suite) (*Suite, error) {
+	query := `
+		INSERT INTO agent_eval_suites (project_id, agent_id, cases, evaluators, max_regression)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (project_id, agent_id) DO UPDATE
+		SET cases = EXCLUDED.cases, evaluators = EXCLUDED.evaluators, max_regression = EXCLUDED.max_regression, updated_at = NOW()
+		RETURNING project_id, agent_id, cases, evaluators, max_regression, created_at, updated_at
+	`
+
+	var saved Suite
+	if err := r.db.GetContext(ctx, &saved, query, suite.ProjectID, suite.AgentID, suite.Cases, suite.Evaluators, suite.MaxRegression); err != nil {
+		return nil, fmt.Errorf("failed to save eval suite: %w", err)
+	}
+
+	return &saved, nil
+}
+
+// Get retrieves the eval suite of an agent
+func (r *EvalSuiteRepo) Get(ctx context.Context, projectID, agentID uuid.UUID) (*Suite, error) {
+	query := `
+		SELECT project_id, agent_id, cases, evaluators, max_regression, created_at, updated_at
+		FROM agent_eval_suites
+		WHERE project_id = $1 AND agent_id = $2
+	`
+
+	var suite Suite
+	if err := r.db.GetContext(ctx, &suite, query, projectID, agentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSuiteNotFound
+		}
+		return nil, fmt.Errorf("failed to get eval suite: %w", err)
+	}
+
+	return &suite, nil
+}
+
+// Delete deletes the eval suite of an agent
+func (r *EvalSuiteRepo) Delete(ctx context.Context, projectID, agentID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM agent_eval_suites WHERE project_id = $1 AND agent_id = $2`, projectID, agentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete eval suite: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrSuiteNotFound
+	}
+
+	return nil
+}
+
+// CreateRun stores an eval run
+func (r *EvalSuiteRepo) CreateRun(ctx context.Context, run *EvalRun) (*EvalRun, error) {
+	query := `
+		INSERT INTO agent_eval_runs (project_id, agent_id, alias_id, version, baseline_version, status, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, project_id, agent_id, alias_id, version, baseline_version, status, report, created_at
+	`
+
+	var created EvalRun
+	if err := r.db.GetContext(ctx, &created, query, run.ProjectID, run.AgentID, run.AliasID, run.Version, run.BaselineVersion, run.Status, run.Report); err != nil {
+		return nil, fmt.Errorf("failed to create eval run: %w", err)
+	}
+
+	return &created, nil
+}
+
+// GetRun retrieves an eval run with its report
+func (r *EvalSuiteRepo) GetRun(ctx context.Context, projectID, id uuid.UUID) (*EvalRun, error) {
+	query := `
+		SELECT id, project_id, agent_id, alias_id, version, baseline_version, status, report, created_at
+		FROM agent_eval_runs
+		WHERE project_id = $1 AND id = $2
+	`
+
+	var run EvalRun
+	if err := r.db.GetContext(ctx, &run, query, projectID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("eval run not found")
+		}
+		return nil, fmt.Errorf("failed to get eval run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// ListRuns retrieves the eval runs of an agent, newest first. Only the scores of the reports are returned.
+func (r *EvalSuiteRepo) ListRuns(ctx context.Context, projectID, agentID uuid.UUID) ([]*EvalRun, error) {
+	query := `
+		SELECT id, project_id, agent_id, alias_id, version, baseline_version, status,
+		       report - 'results' as report, created_at
+		FROM agent_eval_runs
+		WHERE project_id = $1 AND agent_id = $2
+		ORDER BY created_at DESC
+		LIMIT 100
+	`
+
+	runs := []*EvalRun{}
+	if err := r.db.SelectContext(ctx, &runs, query, projectID, agentID); err != nil {
+		return nil, fmt.Errorf("failed to list eval runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,191 @@
+package eval_suite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/curaious/uno/pkg/agent-framework/eval"
+	"github.com/google/uuid"
+)
+
+// EvalSuiteService handles business logic for the eval suites of agents
+type EvalSuiteService struct {
+	repo *EvalSuiteRepo
+}
+
+// NewEvalSuiteService creates a new eval suite service
+func NewEvalSuiteService(repo *EvalSuiteRepo) *EvalSuiteService {
+	return &EvalSuiteService{repo: repo}
+}
+
+// Save validates and stores the eval suite of an agent, replacing the previous one
+func (s *EvalSuiteService) Save(ctx context.Context, projectID, agentID uuid.UUID, req *SaveSuiteRequest) (*Suite, error) {
+	suite := &Suite{
+		ProjectID:     projectID,
+		AgentID:       agentID,
+		Cases:         req.Cases,
+		Evaluators:    req.Evaluators,
+		MaxRegression: DefaultMaxRegression,
+	}
+	if req.MaxRegression != nil {
+		suite.MaxRegression = *req.MaxRegression
+	}
+
+	if err := validateSuite(suite); err != nil {
+		return nil, &ValidationError{Err: err}
+	}
+
+	return s.repo.Save(ctx, suite)
+}
+
+// Get retrieves the eval suite of an agent, ErrSuiteNotFound when it has none
+func (s *EvalSuiteService) Get(ctx context.Context, projectID, agentID uuid.UUID) (*Suite, error) {
+	return s.repo.Get(ctx, projectID, agentID)
+}
+
+// Delete deletes the eval suite of an agent, aliases are then promoted without evaluation
+func (s *EvalSuiteService) Delete(ctx context.Context, projectID, agentID uuid.UUID) error {
+	return s.repo.Delete(ctx, projectID, agentID)
+}
+
+// Record scores the results of a run of the suite and stores the run. The run fails when an evaluator does not
+// reach its minimum score or regresses from the baseline version by more than allowed.
+func (s *EvalSuiteService) Record(ctx context.Context, suite *Suite, aliasID *uuid.UUID, version int, baselineVersion *int, results []CaseResult) (*EvalRun, error) {
+	report, err := Score(ctx, suite, results)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &EvalRun{
+		ProjectID:       suite.ProjectID,
+		AgentID:         suite.AgentID,
+		AliasID:         aliasID,
+		Version:         version,
+		BaselineVersion: baselineVersion,
+		Status:          StatusPassed,
+		Report:          report,
+	}
+	for _, score := range report.Scores {
+		if !score.Passed {
+			run.Status = StatusFailed
+		}
+	}
+
+	return s.repo.CreateRun(ctx, run)
+}
+
+// GetRun retrieves an eval run with its report
+func (s *EvalSuiteService) GetRun(ctx context.Context, projectID, id uuid.UUID) (*EvalRun, error) {
+	return s.repo.GetRun(ctx, projectID, id)
+}
+
+// ListRuns retrieves the eval runs of an agent
+func (s *EvalSuiteService) ListRuns(ctx context.Context, projectID, agentID uuid.UUID) ([]*EvalRun, error) {
+	return s.repo.ListRuns(ctx, projectID, agentID)
+}
+
+// Score fills in the scores of every case of the results, and checks the mean score of every evaluator against
+// its thresholds. The baseline scores are only compared when every case ran on the baseline version.
+func Score(ctx context.Context, suite *Suite, results []CaseResult) (Report, error) {
+	report := Report{Scores: make([]EvaluatorScore, 0, len(suite.Evaluators)), Results: results}
+	if len(results) == 0 {
+		return report, nil
+	}
+
+	hasBaseline := true
+	for i := range results {
+		results[i].Scores = map[string]float64{}
+		if results[i].Baseline == nil {
+			hasBaseline = false
+		} else {
+			results[i].BaselineScores = map[string]float64{}
+		}
+	}
+
+	for _, config := range suite.Evaluators {
+		fn, err := config.Func()
+		if err != nil {
+			return Report{}, fmt.Errorf("evaluator %s: %w", config.DisplayName(), err)
+		}
+
+		name := config.DisplayName()
+		var total, baselineTotal float64
+		for i := range results {
+			result := &results[i]
+			c := suite.Cases[result.Index]
+
+			score, err := eval.Score(ctx, fn, c, *result.Candidate)
+			if err != nil {
+				if result.Errors == nil {
+					result.Errors = map[string]string{}
+				}
+				result.Errors[name] = err.Error()
+			}
+			result.Scores[name] = score
+			total += score
+
+			if result.Baseline != nil {
+				// Errors of the evaluator on the baseline only lower its score, they are reported for the candidate
+				baselineScore, _ := eval.Score(ctx, fn, c, *result.Baseline)
+				result.BaselineScores[name] = baselineScore
+				baselineTotal += baselineScore
+			}
+		}
+
+		score := EvaluatorScore{
+			Evaluator:     name,
+			Score:         total / float64(len(results)),
+			MinScore:      config.MinScore,
+			MaxRegression: suite.MaxRegression,
+			Passed:        true,
+		}
+		if config.MaxRegression != nil {
+			score.MaxRegression = *config.MaxRegression
+		}
+		if hasBaseline {
+			baseline := baselineTotal / float64(len(results))
+			score.Baseline = &baseline
+		}
+
+		// A small tolerance keeps the sums of floats from failing equal scores
+		const epsilon = 1e-9
+		switch {
+		case score.MinScore != nil && score.Score < *score.MinScore-epsilon:
+			score.Passed = false
+			score.Reason = fmt.Sprintf("scored %.2f, below the minimum of %.2f", score.Score, *score.MinScore)
+		case score.Baseline != nil && score.Score < *score.Baseline-score.MaxRegression-epsilon:
+			score.Passed = false
+			score.Reason = fmt.Sprintf("scored %.2f, down from %.2f on the baseline version", score.Score, *score.Baseline)
+		}
+
+		report.Scores = append(report.Scores, score)
+	}
+
+	return report, nil
+}
+
+func validateSuite(suite *Suite) error {
+	if len(suite.Cases) == 0 {
+		return fmt.Errorf("at least one case is required")
+	}
+	if len(suite.Evaluators) == 0 {
+		return fmt.Errorf("at least one evaluator is required")
+	}
+	if suite.MaxRegression < 0 || suite.MaxRegression > 1 {
+		return fmt.Errorf("max_regression must be between 0 and 1")
+	}
+
+	names := map[string]bool{}
+	for _, config := range suite.Evaluators {
+		name := config.DisplayName()
+		if err := config.Validate(suite.Cases); err != nil {
+			return fmt.Errorf("evaluator %s: %w", name, err)
+		}
+		if names[name] {
+			return fmt.Errorf("evaluator %s: the name is used by another evaluator", name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package gateway_response
+
+import (
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// GatewayResponse is a row of the gateway_responses table.
+// Input, output and usage are stored as the JSON of their native responses types. The owner is the virtual key, or
+// the hash of the provider key, the response was created with.
+type GatewayResponse struct {
+	ID           string           `db:"id"`
+	Owner        string           `db:"owner"`
+	Provider     llm.ProviderName `db:"provider"`
+	Model        string           `db:"model"`
+	Instructions *string          `db:"instructions"`
+	Input        []byte           `db:"input"`
+	Output       []byte           `db:"output"`
+	Usage        []byte           `db:"usage"`
+	Metadata     []byte           `db:"metadata"`
+	CreatedAt    time.Time        `db:"created_at"`
+}
@@ -0,0 +1,109 @@
+package gateway_response
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/curaious/uno/pkg/gateway/middlewares/response_store_middleware"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// GatewayResponseRepo handles database operations for stored gateway responses
+type GatewayResponseRepo struct {
+	db *sqlx.DB
+}
+
+// NewGatewayResponseRepo creates a new gateway response repository
+func NewGatewayResponseRepo(db *sqlx.DB) *GatewayResponseRepo {
+	return &GatewayResponseRepo{db: db}
+}
+
+// Upsert stores a response, replacing the response with the same ID of the same owner
+func (r *GatewayResponseRepo) Upsert(ctx context.Context, resp *GatewayResponse) error {
+	query := `
+		INSERT INTO gateway_responses (id, owner, provider, model, instructions, input, output, usage, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			model = EXCLUDED.model,
+			instructions = EXCLUDED.instructions,
+			input = EXCLUDED.input,
+			output = EXCLUDED.output,
+			usage = EXCLUDED.usage,
+			metadata = EXCLUDED.metadata
+		WHERE gateway_responses.owner = EXCLUDED.owner
+	`
+
+	_, err := r.db.ExecContext(ctx, query, resp.ID, resp.Owner, resp.Provider, resp.Model, resp.Instructions, resp.Input, resp.Output, resp.Usage, resp.Metadata, resp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store response: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a stored response of the owner by ID
+func (r *GatewayResponseRepo) GetByID(ctx context.Context, owner string, id string) (*GatewayResponse, error) {
+	query := `
+		SELECT id, owner, provider, model, instructions, input, output, usage, metadata, created_at
+		FROM gateway_responses
+		WHERE id = $1 AND owner = $2
+	`
+
+	var resp GatewayResponse
+	err := r.db.GetContext(ctx, &resp, query, id, owner)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, response_store_middleware.ErrResponseNotFound
+		}
+		return nil, fmt.Errorf("failed to get response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// Delete deletes a stored response of the owner by ID
+func (r *GatewayResponseRepo) Delete(ctx context.Context, owner string, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM gateway_responses WHERE id = $1 AND owner = $2`, id, owner)
+	if err != nil {
+		return fmt.Errorf("failed to delete response: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete response: %w", err)
+	}
+
+	if rows == 0 {
+		return response_store_middleware.ErrResponseNotFound
+	}
+
+	return nil
+}
+
+// EraseByMetadata deletes or anonymizes the responses of the virtual keys of the project whose metadata holds the
+// value under the key
+func (r *GatewayResponseRepo) EraseByMetadata(ctx context.Context, projectID uuid.UUID, key string, value string, anonymize bool) (int64, error) {
+	query := `
+		DELETE FROM gateway_responses
+		WHERE metadata->>$1 = $2
+			AND owner IN (SELECT 'vk:' || id::text FROM virtual_keys WHERE project_id = $3)
+	`
+	if anonymize {
+		query = `
+			UPDATE gateway_responses
+			SET instructions = NULL, input = '[]'::jsonb, output = '[]'::jsonb, metadata = metadata - $1
+			WHERE metadata->>$1 = $2
+				AND owner IN (SELECT 'vk:' || id::text FROM virtual_keys WHERE project_id = $3)
+		`
+	}
+
+	result, err := r.db.ExecContext(ctx, query, key, value, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to erase responses: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package gateway_response
+
+import (
+	"context"
+	"fmt"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/gateway/middlewares/response_store_middleware"
+	"github.com/google/uuid"
+)
+
+// GatewayResponseService stores the responses created through the gateway with store=true.
+// It implements response_store_middleware.ResponseStore.
+type GatewayResponseService struct {
+	repo *GatewayResponseRepo
+}
+
+// NewGatewayResponseService creates a new gateway response service
+func NewGatewayResponseService(repo *GatewayResponseRepo) *GatewayResponseService {
+	return &GatewayResponseService{repo: repo}
+}
+
+// SaveResponse stores a response
+func (s *GatewayResponseService) SaveResponse(ctx context.Context, resp *response_store_middleware.StoredResponse) error {
+	input, err := json.Marshal(resp.Input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal input: %w", err)
+	}
+
+	output, err := json.Marshal(resp.Output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	usage, err := json.Marshal(resp.Usage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	metadata := resp.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return s.repo.Upsert(ctx, &GatewayResponse{
+		ID:           resp.ID,
+		Owner:        resp.Owner,
+		Provider:     resp.Provider,
+		Model:        resp.Model,
+		Instructions: resp.Instructions,
+		Input:        input,
+		Output:       output,
+		Usage:        usage,
+		Metadata:     metadataJSON,
+		CreatedAt:    resp.CreatedAt,
+	})
+}
+
+// GetResponse retrieves a stored response of the owner by ID
+func (s *GatewayResponseService) GetResponse(ctx context.Context, owner string, id string) (*response_store_middleware.StoredResponse, error) {
+	row, err := s.repo.GetByID(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response_store_middleware.StoredResponse{
+		ID:           row.ID,
+		Owner:        row.Owner,
+		Provider:     row.Provider,
+		Model:        row.Model,
+		Instructions: row.Instructions,
+		CreatedAt:    row.CreatedAt,
+	}
+
+	if err := json.Unmarshal(row.Input, &resp.Input); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal input: %w", err)
+	}
+
+	if err := json.Unmarshal(row.Output, &resp.Output); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal output: %w", err)
+	}
+
+	if err := json.Unmarshal(row.Usage, &resp.Usage); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal usage: %w", err)
+	}
+
+	if len(row.Metadata) > 0 {
+		if err := json.Unmarshal(row.Metadata, &resp.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// DeleteResponse deletes a stored response of the owner by ID
+func (s *GatewayResponseService) DeleteResponse(ctx context.Context, owner string, id string) error {
+	return s.repo.Delete(ctx, owner, id)
+}
+
+// EraseSubject erases the responses of the virtual keys of the project whose request metadata holds the subject
+// under the key. With anonymize, the responses are kept without their input, output and instructions, otherwise they
+// are deleted.
+func (s *GatewayResponseService) EraseSubject(ctx context.Context, projectID uuid.UUID, key string, subject string, anonymize bool) (int64, error) {
+	return s.repo.EraseByMetadata(ctx, projectID, key, subject, anonymize)
+}
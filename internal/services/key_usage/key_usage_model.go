@@ -0,0 +1,78 @@
+package key_usage
+
+import (
+	"time"
+
+	"github.com/curaious/uno/internal/services/virtual_key"
+	"github.com/google/uuid"
+)
+
+// AlertKind is the kind of anomaly of an alert
+type AlertKind string
+
+const (
+	// AlertKindSpike is raised when the requests of an hour exceed the usual hourly requests of the key
+	AlertKindSpike AlertKind = "spike"
+
+	// AlertKindUnusualModel is raised when a model is requested for the first time in 30 days
+	AlertKindUnusualModel AlertKind = "unusual_model"
+
+	// AlertKindOffHours is raised when the key is used outside of the working hours
+	AlertKindOffHours AlertKind = "off_hours"
+)
+
+// UsagePoint is the usage of a virtual key in an hour or a day
+type UsagePoint struct {
+	Time         time.Time `json:"time" db:"bucket"`
+	Requests     int64     `json:"requests" db:"requests"`
+	Errors       int64     `json:"errors" db:"errors"`
+	InputTokens  int64     `json:"input_tokens" db:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens" db:"output_tokens"`
+}
+
+// ModelUsage is the usage of a virtual key with a model over the range of a report
+type ModelUsage struct {
+	Provider     string `json:"provider" db:"provider_type"`
+	Model        string `json:"model" db:"model_name"`
+	Requests     int64  `json:"requests" db:"requests"`
+	Errors       int64  `json:"errors" db:"errors"`
+	InputTokens  int64  `json:"input_tokens" db:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens" db:"output_tokens"`
+}
+
+// UsageQuery selects the usage of a virtual key, by hour or by day
+type UsageQuery struct {
+	VirtualKeyID uuid.UUID
+	StartTime    time.Time
+	EndTime      time.Time
+	Interval     string
+}
+
+// UsageReport is the time series of the usage of a virtual key, with the totals by model
+type UsageReport struct {
+	VirtualKeyID uuid.UUID    `json:"virtual_key_id"`
+	Interval     string       `json:"interval"`
+	StartTime    time.Time    `json:"start_time"`
+	EndTime      time.Time    `json:"end_time"`
+	Points       []UsagePoint `json:"points"`
+	Models       []ModelUsage `json:"models"`
+}
+
+// Alert is an anomaly of the usage of a virtual key. The alerts are raised at most once by kind, detail and hour.
+type Alert struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	VirtualKeyID   uuid.UUID `json:"virtual_key_id" db:"virtual_key_id"`
+	VirtualKeyName string    `json:"virtual_key_name" db:"-"`
+	Kind           AlertKind `json:"kind" db:"kind"`
+	Detail         string    `json:"detail,omitempty" db:"detail"`
+	WindowStart    time.Time `json:"window_start" db:"window_start"`
+	Message        string    `json:"message" db:"message"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// keyInfo is the virtual key of a secret, with its alert settings
+type keyInfo struct {
+	ID          uuid.UUID                `db:"id"`
+	Name        string                   `db:"name"`
+	UsageAlerts *virtual_key.UsageAlerts `db:"usage_alerts"`
+}
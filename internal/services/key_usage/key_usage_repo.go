@@ -0,0 +1,216 @@
+package key_usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/curaious/uno/internal/services/outbox"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// KeyUsageRepo handles database operations for the usage and the alerts of virtual keys
+type KeyUsageRepo struct {
+	db *sqlx.DB
+}
+
+// NewKeyUsageRepo creates a new key usage repository
+func NewKeyUsageRepo(db *sqlx.DB) *KeyUsageRepo {
+	return &KeyUsageRepo{db: db}
+}
+
+// GetKeyBySecret returns the virtual key of a secret key
+func (r *KeyUsageRepo) GetKeyBySecret(ctx context.Context, secretKey string) (*keyInfo, error) {
+	var key keyInfo
+	err := r.db.GetContext(ctx, &key, `
+		SELECT id, name, usage_alerts
+		FROM virtual_keys
+		WHERE secret_key = $1
+	`, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Increment adds a request to the usage of the key with the model in the hour, and reports whether it is the first
+// request of the model in the hour
+func (r *KeyUsageRepo) Increment(ctx context.Context, keyID uuid.UUID, hour time.Time, provider string, model string, inputTokens int64, outputTokens int64, failed bool) (bool, error) {
+	failures := 0
+	if failed {
+		failures = 1
+	}
+
+	var inserted bool
+	err := r.db.GetContext(ctx, &inserted, `
+		INSERT INTO virtual_key_usage (virtual_key_id, hour, provider_type, model_name, requests, errors, input_tokens, output_tokens)
+		VALUES ($1, $2, $3, $4, 1, $5, $6, $7)
+		ON CONFLICT (virtual_key_id, hour, provider_type, model_name) DO UPDATE SET
+			requests = virtual_key_usage.requests + 1,
+			errors = virtual_key_usage.errors + EXCLUDED.errors,
+			input_tokens = virtual_key_usage.input_tokens + EXCLUDED.input_tokens,
+			output_tokens = virtual_key_usage.output_tokens + EXCLUDED.output_tokens
+		RETURNING (xmax = 0)
+	`, keyID, hour, provider, model, failures, inputTokens, outputTokens)
+	if err != nil {
+		return false, fmt.Errorf("failed to record usage: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// HourRequests returns the requests of the key in the hour
+func (r *KeyUsageRepo) HourRequests(ctx context.Context, keyID uuid.UUID, hour time.Time) (int64, error) {
+	var requests int64
+	err := r.db.GetContext(ctx, &requests, `
+		SELECT COALESCE(SUM(requests), 0)
+		FROM virtual_key_usage
+		WHERE virtual_key_id = $1 AND hour = $2
+	`, keyID, hour)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get requests of the hour: %w", err)
+	}
+
+	return requests, nil
+}
+
+// HourlyAverage returns the average requests per hour of the key in [start, end)
+func (r *KeyUsageRepo) HourlyAverage(ctx context.Context, keyID uuid.UUID, start time.Time, end time.Time) (float64, error) {
+	var requests int64
+	err := r.db.GetContext(ctx, &requests, `
+		SELECT COALESCE(SUM(requests), 0)
+		FROM virtual_key_usage
+		WHERE virtual_key_id = $1 AND hour >= $2 AND hour < $3
+	`, keyID, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get hourly average: %w", err)
+	}
+
+	return float64(requests) / end.Sub(start).Hours(), nil
+}
+
+// ModelSeen reports whether the model was requested with the key in [start, end), and whether the key was used at
+// all in that range
+func (r *KeyUsageRepo) ModelSeen(ctx context.Context, keyID uuid.UUID, provider string, model string, start time.Time, end time.Time) (bool, bool, error) {
+	var row struct {
+		Seen    bool `db:"seen"`
+		History bool `db:"history"`
+	}
+	err := r.db.GetContext(ctx, &row, `
+		SELECT
+			COALESCE(BOOL_OR(provider_type = $2 AND model_name = $3), false) AS seen,
+			COUNT(*) > 0 AS history
+		FROM virtual_key_usage
+		WHERE virtual_key_id = $1 AND hour >= $4 AND hour < $5
+	`, keyID, provider, model, start, end)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get models of the key: %w", err)
+	}
+
+	return row.Seen, row.History, nil
+}
+
+// CreateAlert records the alert and publishes it to the outbox, unless it was already raised. It reports whether the
+// alert was created.
+func (r *KeyUsageRepo) CreateAlert(ctx context.Context, alert *Alert) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryxContext(ctx, `
+		INSERT INTO virtual_key_alerts (virtual_key_id, kind, detail, window_start, message)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (virtual_key_id, kind, detail, window_start) DO NOTHING
+		RETURNING id, created_at
+	`, alert.VirtualKeyID, alert.Kind, alert.Detail, alert.WindowStart, alert.Message)
+	if err != nil {
+		return false, fmt.Errorf("failed to create alert: %w", err)
+	}
+	created := rows.Next()
+	if created {
+		err = rows.Scan(&alert.ID, &alert.CreatedAt)
+	}
+	rows.Close()
+	if err != nil {
+		return false, fmt.Errorf("failed to create alert: %w", err)
+	}
+	if !created {
+		return false, nil
+	}
+
+	if err := outbox.Enqueue(ctx, tx, outbox.TopicVirtualKeyUsageAnomaly, "virtual_key_alert:"+alert.ID.String(), alert); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListAlerts returns the latest alerts of the key
+func (r *KeyUsageRepo) ListAlerts(ctx context.Context, keyID uuid.UUID, limit int) ([]*Alert, error) {
+	alerts := []*Alert{}
+	err := r.db.SelectContext(ctx, &alerts, `
+		SELECT id, virtual_key_id, kind, detail, window_start, message, created_at
+		FROM virtual_key_alerts
+		WHERE virtual_key_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, keyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// Series returns the usage of the key by hour or by day, the buckets without requests are omitted
+func (r *KeyUsageRepo) Series(ctx context.Context, q *UsageQuery) ([]UsagePoint, error) {
+	points := []UsagePoint{}
+	err := r.db.SelectContext(ctx, &points, `
+		SELECT
+			date_trunc($2, hour) AS bucket,
+			SUM(requests) AS requests,
+			SUM(errors) AS errors,
+			SUM(input_tokens) AS input_tokens,
+			SUM(output_tokens) AS output_tokens
+		FROM virtual_key_usage
+		WHERE virtual_key_id = $1 AND hour >= date_trunc('hour', $3::timestamptz) AND hour < $4
+		GROUP BY bucket
+		ORDER BY bucket
+	`, q.VirtualKeyID, q.Interval, q.StartTime, q.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage series: %w", err)
+	}
+
+	return points, nil
+}
+
+// ByModel returns the usage of the key by model
+func (r *KeyUsageRepo) ByModel(ctx context.Context, q *UsageQuery) ([]ModelUsage, error) {
+	models := []ModelUsage{}
+	err := r.db.SelectContext(ctx, &models, `
+		SELECT
+			provider_type,
+			model_name,
+			SUM(requests) AS requests,
+			SUM(errors) AS errors,
+			SUM(input_tokens) AS input_tokens,
+			SUM(output_tokens) AS output_tokens
+		FROM virtual_key_usage
+		WHERE virtual_key_id = $1 AND hour >= date_trunc('hour', $2::timestamptz) AND hour < $3
+		GROUP BY provider_type, model_name
+		ORDER BY requests DESC
+	`, q.VirtualKeyID, q.StartTime, q.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage by model: %w", err)
+	}
+
+	return models, nil
+}
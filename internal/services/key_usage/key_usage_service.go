@@ -0,0 +1,325 @@
+package key_usage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"mime"
+	"mime/quotedprintable"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/internal/integrations/email"
+	"github.com/curaious/uno/internal/services/virtual_key"
+	"github.com/curaious/uno/pkg/gateway/middlewares/key_usage_middleware"
+	"github.com/google/uuid"
+)
+
+const (
+	// keyCacheTTL is how long the alert settings of a key are cached before they are read again
+	keyCacheTTL = time.Minute
+
+	// spikeBaseline is the period the hourly average of a key is computed over
+	spikeBaseline = 7 * 24 * time.Hour
+
+	// defaultSpikeMinRequests is the minimum requests of an hour to be a spike, unless configured
+	defaultSpikeMinRequests = 100
+
+	// modelHistory is the period a model must not have been requested over to be unusual
+	modelHistory = 30 * 24 * time.Hour
+)
+
+// KeyUsageOptions configures the delivery of the alerts by email, the alerts are not emailed without sender
+type KeyUsageOptions struct {
+	Sender email.Sender
+	From   string
+}
+
+// KeyUsageService records the usage of the virtual keys and raises alerts on anomalies.
+// It implements key_usage_middleware.UsageRecorder.
+type KeyUsageService struct {
+	repo *KeyUsageRepo
+	opts KeyUsageOptions
+
+	keys sync.Map // secret key -> *cachedKey
+
+	mu        sync.Mutex
+	hour      time.Time
+	baselines map[uuid.UUID]float64
+	raised    map[string]bool
+}
+
+type cachedKey struct {
+	key     *keyInfo
+	expires time.Time
+}
+
+// NewKeyUsageService creates a new key usage service
+func NewKeyUsageService(repo *KeyUsageRepo, opts KeyUsageOptions) *KeyUsageService {
+	return &KeyUsageService{
+		repo:      repo,
+		opts:      opts,
+		baselines: map[uuid.UUID]float64{},
+		raised:    map[string]bool{},
+	}
+}
+
+// RecordUsage adds a request to the hourly usage of its virtual key, then checks the usage for anomalies if the
+// alerts of the key are enabled. Requests with unknown keys are not recorded.
+func (s *KeyUsageService) RecordUsage(ctx context.Context, usage *key_usage_middleware.Usage) error {
+	key, err := s.getKey(ctx, usage.Key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get virtual key: %w", err)
+	}
+
+	hour := usage.Time.UTC().Truncate(time.Hour)
+	firstOfModel, err := s.repo.Increment(ctx, key.ID, hour, string(usage.Provider), usage.Model, usage.InputTokens, usage.OutputTokens, usage.Failed)
+	if err != nil {
+		return err
+	}
+
+	if key.UsageAlerts == nil || !key.UsageAlerts.Enabled {
+		return nil
+	}
+
+	return s.detect(ctx, key, usage, hour, firstOfModel)
+}
+
+// GetUsage returns the usage of a virtual key by hour or by day
+func (s *KeyUsageService) GetUsage(ctx context.Context, q *UsageQuery) (*UsageReport, error) {
+	switch q.Interval {
+	case "":
+		q.Interval = "hour"
+	case "hour", "day":
+	default:
+		return nil, fmt.Errorf("invalid interval %q, use hour or day", q.Interval)
+	}
+
+	points, err := s.repo.Series(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := s.repo.ByModel(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UsageReport{
+		VirtualKeyID: q.VirtualKeyID,
+		Interval:     q.Interval,
+		StartTime:    q.StartTime,
+		EndTime:      q.EndTime,
+		Points:       points,
+		Models:       models,
+	}, nil
+}
+
+// ListAlerts returns the latest alerts of a virtual key
+func (s *KeyUsageService) ListAlerts(ctx context.Context, keyID uuid.UUID, limit int) ([]*Alert, error) {
+	return s.repo.ListAlerts(ctx, keyID, limit)
+}
+
+func (s *KeyUsageService) getKey(ctx context.Context, secretKey string) (*keyInfo, error) {
+	if cached, ok := s.keys.Load(secretKey); ok && time.Now().Before(cached.(*cachedKey).expires) {
+		return cached.(*cachedKey).key, nil
+	}
+
+	key, err := s.repo.GetKeyBySecret(ctx, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys.Store(secretKey, &cachedKey{key: key, expires: time.Now().Add(keyCacheTTL)})
+	return key, nil
+}
+
+func (s *KeyUsageService) detect(ctx context.Context, key *keyInfo, usage *key_usage_middleware.Usage, hour time.Time, firstOfModel bool) error {
+	settings := key.UsageAlerts
+
+	if settings.OffHours != nil && isOffHours(usage.Time, settings.OffHours) && !s.wasRaised(key.ID, AlertKindOffHours, "", hour) {
+		s.raise(ctx, key, &Alert{
+			Kind:        AlertKindOffHours,
+			WindowStart: hour,
+			Message:     fmt.Sprintf("Virtual key %q was used outside of the working hours", key.Name),
+		})
+	}
+
+	if settings.UnusualModels && firstOfModel {
+		detail := string(usage.Provider) + "/" + usage.Model
+		seen, history, err := s.repo.ModelSeen(ctx, key.ID, string(usage.Provider), usage.Model, hour.Add(-modelHistory), hour)
+		if err != nil {
+			return err
+		}
+		// Every model is unusual for a new key
+		if history && !seen && !s.wasRaised(key.ID, AlertKindUnusualModel, detail, hour) {
+			s.raise(ctx, key, &Alert{
+				Kind:        AlertKindUnusualModel,
+				Detail:      detail,
+				WindowStart: hour,
+				Message:     fmt.Sprintf("Virtual key %q requested %s, which it didn't request in the last 30 days", key.Name, detail),
+			})
+		}
+	}
+
+	if settings.SpikeFactor > 0 && !s.wasRaised(key.ID, AlertKindSpike, "", hour) {
+		baseline, err := s.baseline(ctx, key.ID, hour)
+		if err != nil {
+			return err
+		}
+
+		minRequests := settings.SpikeMinRequests
+		if minRequests <= 0 {
+			minRequests = defaultSpikeMinRequests
+		}
+		threshold := math.Max(baseline*settings.SpikeFactor, float64(minRequests))
+
+		requests, err := s.repo.HourRequests(ctx, key.ID, hour)
+		if err != nil {
+			return err
+		}
+		if float64(requests) >= threshold {
+			s.raise(ctx, key, &Alert{
+				Kind:        AlertKindSpike,
+				WindowStart: hour,
+				Message:     fmt.Sprintf("Virtual key %q made %d requests this hour, against %.1f per hour on average over the last week", key.Name, requests, baseline),
+			})
+		}
+	}
+
+	return nil
+}
+
+// baseline returns the hourly average of the requests of the key over the week before the hour, computed once per
+// hour
+func (s *KeyUsageService) baseline(ctx context.Context, keyID uuid.UUID, hour time.Time) (float64, error) {
+	s.mu.Lock()
+	s.resetHour(hour)
+	baseline, ok := s.baselines[keyID]
+	s.mu.Unlock()
+	if ok {
+		return baseline, nil
+	}
+
+	baseline, err := s.repo.HourlyAverage(ctx, keyID, hour.Add(-spikeBaseline), hour)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.resetHour(hour)
+	s.baselines[keyID] = baseline
+	s.mu.Unlock()
+
+	return baseline, nil
+}
+
+// wasRaised reports whether the alert was raised by this instance in the hour, so that the anomalies aren't checked
+// again until the next hour. The other instances are deduplicated by the database.
+func (s *KeyUsageService) wasRaised(keyID uuid.UUID, kind AlertKind, detail string, hour time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetHour(hour)
+	return s.raised[alertKey(keyID, kind, detail)]
+}
+
+// resetHour forgets the baselines and the alerts of the previous hours, s.mu must be held
+func (s *KeyUsageService) resetHour(hour time.Time) {
+	if hour.After(s.hour) {
+		s.hour = hour
+		s.baselines = map[uuid.UUID]float64{}
+		s.raised = map[string]bool{}
+	}
+}
+
+// raise records the alert, which publishes it to the outbox, and emails it to the recipients of the key
+func (s *KeyUsageService) raise(ctx context.Context, key *keyInfo, alert *Alert) {
+	s.mu.Lock()
+	s.resetHour(alert.WindowStart)
+	s.raised[alertKey(key.ID, alert.Kind, alert.Detail)] = true
+	s.mu.Unlock()
+
+	alert.VirtualKeyID = key.ID
+	alert.VirtualKeyName = key.Name
+
+	created, err := s.repo.CreateAlert(ctx, alert)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create usage alert", slog.String("virtual_key_id", key.ID.String()), slog.String("kind", string(alert.Kind)), slog.Any("error", err))
+		return
+	}
+	if !created {
+		return
+	}
+
+	slog.WarnContext(ctx, "Anomalous usage of virtual key", slog.String("virtual_key_id", key.ID.String()), slog.String("kind", string(alert.Kind)), slog.String("message", alert.Message))
+
+	if s.opts.Sender == nil || len(key.UsageAlerts.Emails) == 0 {
+		return
+	}
+
+	go func() {
+		msg := alertMessage(s.opts.From, key.UsageAlerts.Emails, alert, time.Now())
+		if err := s.opts.Sender.Send(context.WithoutCancel(ctx), s.opts.From, key.UsageAlerts.Emails, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to email usage alert", slog.String("alert_id", alert.ID.String()), slog.Any("error", err))
+		}
+	}()
+}
+
+func alertKey(keyID uuid.UUID, kind AlertKind, detail string) string {
+	return keyID.String() + "|" + string(kind) + "|" + detail
+}
+
+// isOffHours reports whether the time is outside of the working hours
+func isOffHours(t time.Time, offHours *virtual_key.OffHours) bool {
+	loc := time.UTC
+	if offHours.Timezone != "" {
+		if l, err := time.LoadLocation(offHours.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	local := t.In(loc)
+	if offHours.Weekends && (local.Weekday() == time.Saturday || local.Weekday() == time.Sunday) {
+		return true
+	}
+
+	h := local.Hour()
+	if offHours.Start <= offHours.End {
+		return h < offHours.Start || h >= offHours.End
+	}
+	return h >= offHours.End && h < offHours.Start
+}
+
+// alertMessage builds the email of an alert
+func alertMessage(from string, to []string, alert *Alert, now time.Time) []byte {
+	subject := fmt.Sprintf("[Uno] Anomalous usage of virtual key %s", alert.VirtualKeyName)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	for _, recipient := range to {
+		fmt.Fprintf(&b, "To: %s\r\n", recipient)
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Auto-Submitted: auto-generated\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	text := fmt.Sprintf("%s.\n\nKind: %s\nHour: %s\nVirtual key: %s\n\nRevoke the key if the usage is unexpected.",
+		alert.Message, alert.Kind, alert.WindowStart.Format(time.RFC3339), alert.VirtualKeyID)
+
+	w := quotedprintable.NewWriter(&b)
+	_, _ = w.Write([]byte(text))
+	_ = w.Close()
+
+	return b.Bytes()
+}
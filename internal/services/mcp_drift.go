@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// checkMCPDrift compares the tools of the MCP servers of all agents with the accepted tools, once per interval.
+// It runs on every replica, checks are idempotent.
+func (s *Services) checkMCPDrift(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.AgentConfig.CheckAllMCPDrift(context.Background()); err != nil {
+			slog.Error("Failed to check MCP drift", slog.Any("error", err))
+		}
+	}
+}
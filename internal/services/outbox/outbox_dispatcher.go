@@ -0,0 +1,178 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type DispatcherOptions struct {
+	// Interval between polls of the outbox. Defaults to 1 second.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of events claimed per poll. Defaults to 100.
+	BatchSize int
+
+	// MaxAttempts after which an event is marked as dead. Defaults to 20.
+	MaxAttempts int
+
+	// Retention of delivered events. Defaults to 7 days.
+	Retention time.Duration
+}
+
+// Dispatcher delivers the events written to the outbox to every sink, with at-least-once semantics.
+// An event is only marked as delivered once all sinks accepted it; if any sink fails, the event is retried with
+// backoff and redelivered to all sinks, so consumers must deduplicate on the dedup key.
+// Multiple dispatchers may run against the same database, events are leased so they are not delivered concurrently.
+type Dispatcher struct {
+	repo  *OutboxRepo
+	sinks []Sink
+	opts  DispatcherOptions
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewDispatcher creates a dispatcher, call Start to begin delivering events
+func NewDispatcher(repo *OutboxRepo, sinks []Sink, opts DispatcherOptions) *Dispatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 20
+	}
+
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+
+	return &Dispatcher{
+		repo:  repo,
+		sinks: sinks,
+		opts:  opts,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+// Stop stops the dispatcher after the batch in flight is done
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	<-d.done
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-cleanup.C:
+			d.cleanup()
+		case <-ticker.C:
+			// Keep draining while there is a backlog
+			for d.dispatch() == d.opts.BatchSize {
+				select {
+				case <-d.stop:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// dispatch delivers one batch of due events and returns the number of events claimed
+func (d *Dispatcher) dispatch() int {
+	ctx := context.Background()
+
+	// Events of a batch that is still in flight when the lease expires may be delivered twice,
+	// which at-least-once delivery allows for
+	events, err := d.repo.Claim(ctx, d.opts.BatchSize, 5*time.Minute)
+	if err != nil {
+		slog.Error("Failed to claim outbox events", slog.Any("error", err))
+		return 0
+	}
+
+	for _, event := range events {
+		envelope := &Envelope{
+			ID:        event.ID,
+			Topic:     event.Topic,
+			DedupKey:  event.DedupKey,
+			Payload:   event.Payload,
+			CreatedAt: event.CreatedAt,
+		}
+
+		if err := d.deliver(ctx, envelope); err != nil {
+			attempts := event.Attempts + 1
+			dead := attempts >= d.opts.MaxAttempts
+			if dead {
+				slog.Error("Giving up on outbox event", slog.Int64("id", event.ID), slog.String("topic", event.Topic), slog.Int("attempts", attempts), slog.Any("error", err))
+			} else {
+				slog.Warn("Failed to deliver outbox event", slog.Int64("id", event.ID), slog.String("topic", event.Topic), slog.Int("attempts", attempts), slog.Any("error", err))
+			}
+
+			if markErr := d.repo.MarkFailed(ctx, event.ID, err.Error(), time.Now().Add(backoff(attempts)), dead); markErr != nil {
+				slog.Error("Failed to update outbox event", slog.Int64("id", event.ID), slog.Any("error", markErr))
+			}
+			continue
+		}
+
+		if err := d.repo.MarkDelivered(ctx, event.ID); err != nil {
+			slog.Error("Failed to mark outbox event as delivered", slog.Int64("id", event.ID), slog.Any("error", err))
+		}
+	}
+
+	return len(events)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, event *Envelope) error {
+	var errs []error
+	for _, sink := range d.sinks {
+		sinkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := sink.Deliver(sinkCtx, event)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) cleanup() {
+	deleted, err := d.repo.DeleteDeliveredBefore(context.Background(), time.Now().Add(-d.opts.Retention))
+	if err != nil {
+		slog.Error("Failed to clean up delivered outbox events", slog.Any("error", err))
+		return
+	}
+
+	if deleted > 0 {
+		slog.Debug("Cleaned up delivered outbox events", slog.Int64("deleted", deleted))
+	}
+}
+
+// backoff doubles the delay with each attempt, starting at 1 second, up to 10 minutes
+func backoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < 10*time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, 10*time.Minute)
+}
@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+)
+
+const (
+	// TopicMessagesCreated is published when messages are added to a conversation thread
+	TopicMessagesCreated = "conversation.messages.created"
+
+	// TopicVirtualKeyUsageAnomaly is published when the usage of a virtual key looks anomalous
+	TopicVirtualKeyUsageAnomaly = "virtual_key.usage_anomaly"
+)
+
+// Event is an event to be published, written to the outbox in the same transaction as the change it describes
+type Event struct {
+	ID            int64            `json:"id" db:"id"`
+	Topic         string           `json:"topic" db:"topic"`
+	DedupKey      string           `json:"dedup_key" db:"dedup_key"`
+	Payload       utils.RawMessage `json:"payload" db:"payload"`
+	Attempts      int              `json:"attempts" db:"attempts"`
+	LastError     *string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt time.Time        `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt   *time.Time       `json:"delivered_at,omitempty" db:"delivered_at"`
+	DeadAt        *time.Time       `json:"dead_at,omitempty" db:"dead_at"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+}
+
+// Envelope is the format events are delivered in. Delivery is at-least-once, consumers should use
+// DedupKey to drop events they have already processed.
+type Envelope struct {
+	ID        int64            `json:"id"`
+	Topic     string           `json:"topic"`
+	DedupKey  string           `json:"dedup_key"`
+	Payload   utils.RawMessage `json:"payload"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// MessagesCreatedPayload is the payload of TopicMessagesCreated
+type MessagesCreatedPayload struct {
+	ConversationID string `json:"conversation_id"`
+	ThreadID       string `json:"thread_id"`
+	MessageID      string `json:"message_id"`
+	Count          int    `json:"count"`
+}
+
+// OutboxStats reports the backlog of the outbox
+type OutboxStats struct {
+	Pending   int64 `json:"pending" db:"pending"`
+	Dead      int64 `json:"dead" db:"dead"`
+	Delivered int64 `json:"delivered" db:"delivered"`
+}
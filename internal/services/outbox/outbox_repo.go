@@ -0,0 +1,157 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/jmoiron/sqlx"
+)
+
+// Enqueue writes an event to the outbox using the given transaction, so that the event is only published if the
+// change it describes is committed. Events with a dedup key that is already in the outbox are dropped.
+func Enqueue(ctx context.Context, tx sqlx.ExecerContext, topic string, dedupKey string, payload any) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox_events (topic, dedup_key, payload)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (dedup_key) DO NOTHING
+	`
+
+	if _, err := tx.ExecContext(ctx, query, topic, dedupKey, payloadJSON); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// OutboxRepo handles database operations for outbox events
+type OutboxRepo struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepo creates a new outbox repository
+func NewOutboxRepo(db *sqlx.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+// Claim leases up to limit due events for delivery. Leased events are not claimed again by other dispatchers
+// until the lease expires, so an event whose dispatcher crashed is eventually delivered by another one.
+func (r *OutboxRepo) Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	query := `
+		UPDATE outbox_events
+		SET locked_until = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE delivered_at IS NULL AND dead_at IS NULL
+			AND next_attempt_at <= NOW()
+			AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, dedup_key, payload, attempts, last_error, next_attempt_at, delivered_at, dead_at, created_at
+	`
+
+	events := []Event{}
+	if err := r.db.SelectContext(ctx, &events, query, limit, lease.Milliseconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkDelivered marks an event as delivered
+func (r *OutboxRepo) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox_events SET delivered_at = NOW(), locked_until = NULL WHERE id = $1`, id)
+	return err
+}
+
+// MarkFailed records a failed delivery and schedules the next attempt, or marks the event as dead
+func (r *OutboxRepo) MarkFailed(ctx context.Context, id int64, cause string, nextAttemptAt time.Time, dead bool) error {
+	query := `
+		UPDATE outbox_events
+		SET attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = $3,
+			locked_until = NULL,
+			dead_at = CASE WHEN $4 THEN NOW() ELSE NULL END
+		WHERE id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, cause, nextAttemptAt, dead)
+	return err
+}
+
+// Requeue makes a dead or pending event due immediately, with all of its attempts again
+func (r *OutboxRepo) Requeue(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET dead_at = NULL, next_attempt_at = NOW(), locked_until = NULL, attempts = 0, last_error = NULL
+		WHERE id = $1 AND delivered_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("outbox event not found")
+	}
+
+	return nil
+}
+
+// ListUndelivered returns the events that are pending or dead, oldest first
+func (r *OutboxRepo) ListUndelivered(ctx context.Context, limit int) ([]Event, error) {
+	query := `
+		SELECT id, topic, dedup_key, payload, attempts, last_error, next_attempt_at, delivered_at, dead_at, created_at
+		FROM outbox_events
+		WHERE delivered_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+
+	events := []Event{}
+	if err := r.db.SelectContext(ctx, &events, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Stats returns the number of pending, dead and delivered events
+func (r *OutboxRepo) Stats(ctx context.Context) (*OutboxStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE delivered_at IS NULL AND dead_at IS NULL) AS pending,
+			COUNT(*) FILTER (WHERE dead_at IS NOT NULL) AS dead,
+			COUNT(*) FILTER (WHERE delivered_at IS NOT NULL) AS delivered
+		FROM outbox_events
+	`
+
+	var stats OutboxStats
+	if err := r.db.GetContext(ctx, &stats, query); err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// DeleteDeliveredBefore removes delivered events older than the given time
+func (r *OutboxRepo) DeleteDeliveredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE delivered_at IS NOT NULL AND delivered_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"context"
+)
+
+// OutboxService exposes the outbox for inspection and manual redelivery
+type OutboxService struct {
+	repo *OutboxRepo
+}
+
+// NewOutboxService creates a new outbox service
+func NewOutboxService(repo *OutboxRepo) *OutboxService {
+	return &OutboxService{repo: repo}
+}
+
+// ListUndelivered returns the events that are pending or dead
+func (s *OutboxService) ListUndelivered(ctx context.Context, limit int) ([]Event, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.ListUndelivered(ctx, limit)
+}
+
+// Stats returns the backlog of the outbox
+func (s *OutboxService) Stats(ctx context.Context) (*OutboxStats, error) {
+	return s.repo.Stats(ctx)
+}
+
+// Requeue schedules a pending or dead event for immediate delivery
+func (s *OutboxService) Requeue(ctx context.Context, id int64) error {
+	return s.repo.Requeue(ctx, id)
+}
+
+// NewDispatcher creates a dispatcher that delivers the events of this outbox to the sinks
+func (s *OutboxService) NewDispatcher(sinks []Sink, opts DispatcherOptions) *Dispatcher {
+	return NewDispatcher(s.repo, sinks, opts)
+}
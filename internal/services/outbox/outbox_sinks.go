@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sink delivers outbox events to a destination
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event *Envelope) error
+}
+
+// RedisSink appends events to a redis stream
+type RedisSink struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisSink creates a sink that appends events to the given stream, trimmed to roughly maxLen entries
+func NewRedisSink(client *redis.Client, stream string, maxLen int64) *RedisSink {
+	if maxLen <= 0 {
+		maxLen = 10000
+	}
+	return &RedisSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+func (s *RedisSink) Name() string {
+	return "redis"
+}
+
+func (s *RedisSink) Deliver(ctx context.Context, event *Envelope) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"topic":     event.Topic,
+			"dedup_key": event.DedupKey,
+			"data":      data,
+		},
+	}).Err()
+}
+
+// WebhookSink posts events to an HTTP endpoint. The dedup key is sent as the Idempotency-Key header and,
+// if a secret is configured, the body is signed with HMAC-SHA256 in the X-Uno-Signature header.
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink that posts events to url
+func NewWebhookSink(url string, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.url
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, event *Envelope) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", event.DedupKey)
+	req.Header.Set("X-Uno-Event", event.Topic)
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set("X-Uno-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return doRequest(s.client, req)
+}
+
+// KafkaSink produces events to a kafka topic through a Kafka REST proxy, keyed by the dedup key
+type KafkaSink struct {
+	url    string
+	topic  string
+	client *http.Client
+}
+
+// NewKafkaSink creates a sink that produces events to topic via the REST proxy at restURL
+func NewKafkaSink(restURL string, topic string) *KafkaSink {
+	return &KafkaSink{
+		url:    restURL,
+		topic:  topic,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *KafkaSink) Name() string {
+	return "kafka:" + s.topic
+}
+
+func (s *KafkaSink) Deliver(ctx context.Context, event *Envelope) error {
+	body, err := json.Marshal(map[string]any{
+		"records": []map[string]any{
+			{"key": event.DedupKey, "value": event},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := url.JoinPath(s.url, "topics", s.topic)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	return doRequest(s.client, req)
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
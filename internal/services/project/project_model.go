@@ -3,6 +3,7 @@ package project
 import (
 	"time"
 
+	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/google/uuid"
 )
 
@@ -11,18 +12,44 @@ type Project struct {
 	ID         uuid.UUID `json:"id" db:"id"`
 	Name       string    `json:"name" db:"name"`
 	DefaultKey *string   `json:"default_key,omitempty" db:"default_key"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	// Region the data of the project is pinned to, nil when it isn't pinned
+	Region *string `json:"region,omitempty" db:"region"`
+	// ClientSafeStream strips the encrypted content, extra params and internal IDs from the chunks streamed to
+	// clients, the history keeps the full data
+	ClientSafeStream bool `json:"client_safe_stream" db:"client_safe_stream"`
+	// AgentDefaults are merged under the configs of the agents of the project, nil when none is set
+	AgentDefaults *agent_config.AgentDefaults `json:"agent_defaults,omitempty" db:"agent_defaults"`
+	CreatedAt     time.Time                   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time                   `json:"updated_at" db:"updated_at"`
+}
+
+// ApplyAgentDefaults returns the config of an agent of the project with the agent defaults merged under its values.
+// The config itself is returned when the project has no defaults.
+func (p *Project) ApplyAgentDefaults(config *agent_config.AgentConfig) (*agent_config.AgentConfig, error) {
+	if p.AgentDefaults.IsEmpty() {
+		return config, nil
+	}
+
+	return p.AgentDefaults.Apply(config)
 }
 
 // CreateProjectRequest captures payload for creating a project
 type CreateProjectRequest struct {
 	Name       string  `json:"name" validate:"required,min=1,max=255"`
 	DefaultKey *string `json:"default_key,omitempty"`
+	Region     *string `json:"region,omitempty" validate:"omitempty,max=64"`
+	// ClientSafeStream defaults to true
+	ClientSafeStream *bool                       `json:"client_safe_stream,omitempty"`
+	AgentDefaults    *agent_config.AgentDefaults `json:"agent_defaults,omitempty"`
 }
 
 // UpdateProjectRequest captures payload for updating a project
 type UpdateProjectRequest struct {
 	Name       *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
 	DefaultKey *string `json:"default_key,omitempty"`
+	// Region pins the project to a region, an empty string unpins it
+	Region           *string `json:"region,omitempty" validate:"omitempty,max=64"`
+	ClientSafeStream *bool   `json:"client_safe_stream,omitempty"`
+	// AgentDefaults replaces the agent defaults of the project, an empty object drops them
+	AgentDefaults *agent_config.AgentDefaults `json:"agent_defaults,omitempty"`
 }
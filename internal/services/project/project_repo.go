@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -26,13 +27,13 @@ func NewProjectRepo(db *sqlx.DB) *ProjectRepo {
 // Create creates a new project
 func (r *ProjectRepo) Create(ctx context.Context, req *CreateProjectRequest) (*Project, error) {
 	query := `
-        INSERT INTO projects (name, default_key)
-        VALUES ($1, $2)
-        RETURNING id, name, default_key, created_at, updated_at
+        INSERT INTO projects (name, default_key, region, client_safe_stream, agent_defaults)
+        VALUES ($1, $2, $3, COALESCE($4, TRUE), $5)
+        RETURNING id, name, default_key, region, client_safe_stream, agent_defaults, created_at, updated_at
     `
 
 	var project Project
-	err := r.db.GetContext(ctx, &project, query, req.Name, req.DefaultKey)
+	err := r.db.GetContext(ctx, &project, query, req.Name, req.DefaultKey, req.Region, req.ClientSafeStream, agentDefaultsValue(req.AgentDefaults))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
@@ -43,7 +44,7 @@ func (r *ProjectRepo) Create(ctx context.Context, req *CreateProjectRequest) (*P
 // GetByID retrieves a project by ID
 func (r *ProjectRepo) GetByID(ctx context.Context, id uuid.UUID) (*Project, error) {
 	query := `
-        SELECT id, name, default_key, created_at, updated_at
+        SELECT id, name, default_key, region, client_safe_stream, agent_defaults, created_at, updated_at
         FROM projects
         WHERE id = $1
     `
@@ -63,7 +64,7 @@ func (r *ProjectRepo) GetByID(ctx context.Context, id uuid.UUID) (*Project, erro
 // GetByName retrieves a project by name
 func (r *ProjectRepo) GetByName(ctx context.Context, name string) (*Project, error) {
 	query := `
-        SELECT id, name, default_key, created_at, updated_at
+        SELECT id, name, default_key, region, client_safe_stream, agent_defaults, created_at, updated_at
         FROM projects
         WHERE name = $1
     `
@@ -83,7 +84,7 @@ func (r *ProjectRepo) GetByName(ctx context.Context, name string) (*Project, err
 // List retrieves all projects ordered by creation date
 func (r *ProjectRepo) List(ctx context.Context) ([]*Project, error) {
 	query := `
-        SELECT id, name, default_key, created_at, updated_at
+        SELECT id, name, default_key, region, client_safe_stream, agent_defaults, created_at, updated_at
         FROM projects
         ORDER BY created_at DESC
     `
@@ -112,6 +113,21 @@ func (r *ProjectRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateProje
 		args = append(args, *req.DefaultKey)
 	}
 
+	if req.Region != nil {
+		setParts = append(setParts, fmt.Sprintf("region = NULLIF($%d, '')", len(args)+1))
+		args = append(args, *req.Region)
+	}
+
+	if req.ClientSafeStream != nil {
+		setParts = append(setParts, fmt.Sprintf("client_safe_stream = $%d", len(args)+1))
+		args = append(args, *req.ClientSafeStream)
+	}
+
+	if req.AgentDefaults != nil {
+		setParts = append(setParts, fmt.Sprintf("agent_defaults = $%d", len(args)+1))
+		args = append(args, agentDefaultsValue(req.AgentDefaults))
+	}
+
 	if len(setParts) == 0 {
 		return r.GetByID(ctx, id)
 	}
@@ -123,7 +139,7 @@ func (r *ProjectRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateProje
         UPDATE projects
         SET %s
         WHERE id = $%d
-        RETURNING id, name, default_key, created_at, updated_at
+        RETURNING id, name, default_key, region, client_safe_stream, agent_defaults, created_at, updated_at
     `, strings.Join(setParts, ", "), len(args))
 
 	var project Project
@@ -138,6 +154,14 @@ func (r *ProjectRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateProje
 	return &project, nil
 }
 
+// agentDefaultsValue stores empty defaults as NULL
+func agentDefaultsValue(defaults *agent_config.AgentDefaults) any {
+	if defaults.IsEmpty() {
+		return nil
+	}
+	return defaults
+}
+
 // Delete removes a project by ID
 func (r *ProjectRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM projects WHERE id = $1`
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/internal/services/agent_config"
 	"github.com/google/uuid"
 )
 
@@ -12,12 +14,32 @@ var ErrProjectAlreadyExists = errors.New("project already exists")
 
 // ProjectService contains business logic for projects
 type ProjectService struct {
-	repo *ProjectRepo
+	repo    *ProjectRepo
+	regions *db.RegionRouter
 }
 
 // NewProjectService constructs a new ProjectService
-func NewProjectService(repo *ProjectRepo) *ProjectService {
-	return &ProjectService{repo: repo}
+func NewProjectService(repo *ProjectRepo, regions *db.RegionRouter) *ProjectService {
+	return &ProjectService{repo: repo, regions: regions}
+}
+
+// validateRegion rejects regions without a database, the data of the project could not be stored anywhere
+func (s *ProjectService) validateRegion(region *string) error {
+	if region == nil || *region == "" || s.regions.HasRegion(*region) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", db.ErrRegionUnavailable, *region)
+}
+
+// validateAgentDefaults normalizes and validates the agent defaults, an *agent_config.ValidationError lists the
+// invalid fields
+func validateAgentDefaults(defaults *agent_config.AgentDefaults) error {
+	if defaults == nil {
+		return nil
+	}
+
+	agent_config.NormalizeAgentDefaults(defaults)
+	return agent_config.ValidateAgentDefaults(defaults)
 }
 
 // Create registers a new project ensuring name uniqueness
@@ -26,6 +48,17 @@ func (s *ProjectService) Create(ctx context.Context, req *CreateProjectRequest)
 		return nil, fmt.Errorf("project name is required")
 	}
 
+	if req.Region != nil && *req.Region == "" {
+		req.Region = nil
+	}
+	if err := s.validateRegion(req.Region); err != nil {
+		return nil, err
+	}
+
+	if err := validateAgentDefaults(req.AgentDefaults); err != nil {
+		return nil, err
+	}
+
 	if _, err := s.repo.GetByName(ctx, req.Name); err == nil {
 		return nil, fmt.Errorf("%w: %s", ErrProjectAlreadyExists, req.Name)
 	} else if !errors.Is(err, ErrProjectNotFound) {
@@ -94,6 +127,15 @@ func (s *ProjectService) Update(ctx context.Context, id uuid.UUID, req *UpdatePr
 		}
 	}
 
+	if err := validateAgentDefaults(req.AgentDefaults); err != nil {
+		return nil, err
+	}
+
+	// Existing conversations are not moved when the region of a project changes
+	if err := s.validateRegion(req.Region); err != nil {
+		return nil, err
+	}
+
 	project, err := s.repo.Update(ctx, id, req)
 	if err != nil {
 		if errors.Is(err, ErrProjectNotFound) {
@@ -101,6 +143,7 @@ func (s *ProjectService) Update(ctx context.Context, id uuid.UUID, req *UpdatePr
 		}
 		return nil, fmt.Errorf("failed to update project: %w", err)
 	}
+	s.regions.Forget(id)
 
 	return project, nil
 }
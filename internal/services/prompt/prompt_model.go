@@ -15,6 +15,13 @@ type Prompt struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// DeletedPrompt represents a prompt in the trash
+type DeletedPrompt struct {
+	Prompt
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" db:"-"` // When the prompt is permanently deleted
+}
+
 // PromptVersion represents a version of a prompt template
 type PromptVersion struct {
 	ID            uuid.UUID `json:"id" db:"id"`
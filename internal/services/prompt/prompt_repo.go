@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -92,7 +93,7 @@ func (r *PromptRepo) GetPromptByID(ctx context.Context, projectID uuid.UUID, id
 	query := `
 		SELECT id, project_id, name, created_at, updated_at
 		FROM prompts
-		WHERE id = $1 AND project_id = $2
+		WHERE id = $1 AND project_id = $2 AND deleted_at IS NULL
 	`
 
 	var prompt Prompt
@@ -112,7 +113,7 @@ func (r *PromptRepo) GetPromptByName(ctx context.Context, projectID uuid.UUID, n
 	query := `
 		SELECT id, project_id, name, created_at, updated_at
 		FROM prompts
-		WHERE name = $1 AND project_id = $2
+		WHERE name = $1 AND project_id = $2 AND deleted_at IS NULL
 	`
 
 	var prompt Prompt
@@ -133,7 +134,7 @@ func (r *PromptRepo) GetPromptVersion(ctx context.Context, projectID uuid.UUID,
 		SELECT pv.id, pv.prompt_id, pv.version, pv.template, pv.commit_message, pv.label, pv.created_at, pv.updated_at, p.name as prompt_name
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE p.name = $1 AND pv.version = $2 AND p.project_id = $3
+		WHERE p.name = $1 AND pv.version = $2 AND p.project_id = $3 AND p.deleted_at IS NULL
 	`
 
 	var versionWithPrompt PromptVersionWithPrompt
@@ -154,7 +155,7 @@ func (r *PromptRepo) GetPromptVersionByID(ctx context.Context, projectID uuid.UU
 		SELECT pv.id, pv.prompt_id, pv.version, pv.template, pv.commit_message, pv.label, pv.created_at, pv.updated_at, p.name as prompt_name
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE pv.id = $1 AND p.project_id = $3
+		WHERE pv.id = $1 AND p.project_id = $3 AND p.deleted_at IS NULL
 	`
 
 	var versionWithPrompt PromptVersionWithPrompt
@@ -175,7 +176,7 @@ func (r *PromptRepo) GetPromptVersionByVersion(ctx context.Context, projectID uu
 		SELECT pv.id, pv.prompt_id, pv.version, pv.template, pv.commit_message, pv.label, pv.created_at, pv.updated_at, p.name as prompt_name
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE pv.prompt_id = $1 AND pv.version = $2 AND p.project_id = $3
+		WHERE pv.prompt_id = $1 AND pv.version = $2 AND p.project_id = $3 AND p.deleted_at IS NULL
 	`
 
 	var versionWithPrompt PromptVersionWithPrompt
@@ -196,7 +197,7 @@ func (r *PromptRepo) GetPromptVersionByLabel(ctx context.Context, projectID uuid
 		SELECT pv.id, pv.prompt_id, pv.version, pv.template, pv.commit_message, pv.label, pv.created_at, pv.updated_at, p.name as prompt_name
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE p.name = $1 AND pv.label = $2 AND p.project_id = $3
+		WHERE p.name = $1 AND pv.label = $2 AND p.project_id = $3 AND p.deleted_at IS NULL
 	`
 
 	var versionWithPrompt PromptVersionWithPrompt
@@ -217,7 +218,7 @@ func (r *PromptRepo) GetLatestPromptVersion(ctx context.Context, projectID uuid.
 		SELECT pv.id, pv.prompt_id, pv.version, pv.template, pv.commit_message, pv.label, pv.created_at, pv.updated_at, p.name as prompt_name
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE p.name = $1 AND p.project_id = $2
+		WHERE p.name = $1 AND p.project_id = $2 AND p.deleted_at IS NULL
 		ORDER BY pv.version DESC
 		LIMIT 1
 	`
@@ -249,7 +250,7 @@ func (r *PromptRepo) ListPrompts(ctx context.Context, projectID uuid.UUID) ([]*P
 			ORDER BY pv.version DESC
 			LIMIT 1
 		) lv ON true
-		WHERE p.project_id = $1
+		WHERE p.project_id = $1 AND p.deleted_at IS NULL
 		ORDER BY p.created_at DESC
 	`
 
@@ -307,7 +308,7 @@ func (r *PromptRepo) ListPromptVersions(ctx context.Context, projectID uuid.UUID
 		SELECT pv.id, pv.prompt_id, version, template, commit_message, label, pv.created_at, pv.updated_at
 		FROM prompt_versions pv
 		JOIN prompts p ON pv.prompt_id = p.id
-		WHERE pv.prompt_id = $1 AND p.project_id = $2
+		WHERE pv.prompt_id = $1 AND p.project_id = $2 AND p.deleted_at IS NULL
 		ORDER BY version DESC
 	`
 
@@ -387,9 +388,9 @@ func (r *PromptRepo) DeletePromptVersion(ctx context.Context, versionID uuid.UUI
 	return nil
 }
 
-// DeletePrompt deletes a prompt and all its versions (cascade delete)
+// DeletePrompt moves a prompt and all its versions to the trash
 func (r *PromptRepo) DeletePrompt(ctx context.Context, projectID uuid.UUID, promptID uuid.UUID) error {
-	query := `DELETE FROM prompts WHERE id = $1 AND project_id = $2`
+	query := `UPDATE prompts SET deleted_at = NOW() WHERE id = $1 AND project_id = $2 AND deleted_at IS NULL`
 
 	result, err := r.db.ExecContext(ctx, query, promptID, projectID)
 	if err != nil {
@@ -407,3 +408,71 @@ func (r *PromptRepo) DeletePrompt(ctx context.Context, projectID uuid.UUID, prom
 
 	return nil
 }
+
+// ListDeletedPrompts retrieves the prompts in the trash for a project
+func (r *PromptRepo) ListDeletedPrompts(ctx context.Context, projectID uuid.UUID) ([]*DeletedPrompt, error) {
+	query := `
+		SELECT id, project_id, name, created_at, updated_at, deleted_at
+		FROM prompts
+		WHERE project_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	prompts := []*DeletedPrompt{}
+	err := r.db.SelectContext(ctx, &prompts, query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted prompts: %w", err)
+	}
+
+	return prompts, nil
+}
+
+// RestorePrompt moves a prompt out of the trash, if it was deleted after the given time
+func (r *PromptRepo) RestorePrompt(ctx context.Context, projectID uuid.UUID, promptID uuid.UUID, deletedAfter time.Time) (*Prompt, error) {
+	var prompt Prompt
+	err := r.db.GetContext(ctx, &prompt, `
+		SELECT id, project_id, name, created_at, updated_at
+		FROM prompts
+		WHERE id = $1 AND project_id = $2 AND deleted_at IS NOT NULL
+	`, promptID, projectID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("deleted prompt not found")
+		}
+		return nil, fmt.Errorf("failed to get deleted prompt: %w", err)
+	}
+
+	// A new prompt may have been created with the same name in the meantime
+	if _, err := r.GetPromptByName(ctx, projectID, prompt.Name); err == nil {
+		return nil, fmt.Errorf("a prompt with name '%s' already exists", prompt.Name)
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE prompts SET deleted_at = NULL
+		WHERE id = $1 AND project_id = $2 AND deleted_at > $3
+	`, promptID, projectID, deletedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore prompt: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("prompt was deleted before the retention window and can't be restored")
+	}
+
+	return &prompt, nil
+}
+
+// PurgePrompts permanently deletes the prompts that were moved to the trash before the given time
+func (r *PromptRepo) PurgePrompts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM prompts WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge prompts: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -260,7 +261,7 @@ func (s *PromptService) DeletePromptVersion(ctx context.Context, projectID uuid.
 	return nil
 }
 
-// DeletePrompt deletes a prompt and all its versions
+// DeletePrompt moves a prompt and all its versions to the trash
 func (s *PromptService) DeletePrompt(ctx context.Context, projectID uuid.UUID, promptName string) error {
 	// Get the prompt to get its ID
 	prompt, err := s.repo.GetPromptByName(ctx, projectID, promptName)
@@ -275,3 +276,27 @@ func (s *PromptService) DeletePrompt(ctx context.Context, projectID uuid.UUID, p
 
 	return nil
 }
+
+// ListDeletedPrompts lists the prompts in the trash, with the time they will be purged at
+func (s *PromptService) ListDeletedPrompts(ctx context.Context, projectID uuid.UUID, retention time.Duration) ([]*DeletedPrompt, error) {
+	prompts, err := s.repo.ListDeletedPrompts(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, prompt := range prompts {
+		prompt.PurgeAt = prompt.DeletedAt.Add(retention)
+	}
+
+	return prompts, nil
+}
+
+// RestorePrompt moves a prompt out of the trash, if it was deleted within the retention window
+func (s *PromptService) RestorePrompt(ctx context.Context, projectID uuid.UUID, promptID uuid.UUID, retention time.Duration) (*Prompt, error) {
+	return s.repo.RestorePrompt(ctx, projectID, promptID, time.Now().Add(-retention))
+}
+
+// PurgeDeletedPrompts permanently deletes the prompts that have been in the trash for longer than the retention
+func (s *PromptService) PurgeDeletedPrompts(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgePrompts(ctx, time.Now().Add(-retention))
+}
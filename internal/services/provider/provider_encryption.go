@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/curaious/uno/internal/encryption"
+	"github.com/google/uuid"
+)
+
+// encryptKey returns the API key as it is stored, encrypted with the instance data key when encryption is enabled
+func (r *ProviderRepo) encryptKey(ctx context.Context, key string) (string, error) {
+	if !r.keyring.Enabled() {
+		return key, nil
+	}
+
+	token, err := r.keyring.Encrypt(ctx, encryption.InstanceScope, []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt API key: %w", err)
+	}
+	return token, nil
+}
+
+// decryptKey decrypts the API key of a row that was read
+func (r *ProviderRepo) decryptKey(ctx context.Context, apiKey *APIKey) error {
+	key, err := r.keyring.Decrypt(ctx, apiKey.APIKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt API key %s: %w", apiKey.ID, err)
+	}
+
+	apiKey.APIKey = string(key)
+	return nil
+}
+
+// ReencryptAPIKeys encrypts the API keys that are not encrypted with the active instance data key with it,
+// including those written before encryption was enabled. It returns the number of keys it rewrote.
+func (r *ProviderRepo) ReencryptAPIKeys(ctx context.Context) (int, error) {
+	var rows []struct {
+		ID     uuid.UUID `db:"id"`
+		APIKey string    `db:"api_key"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT id, api_key FROM api_keys`); err != nil {
+		return 0, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	rewritten := 0
+	for _, row := range rows {
+		current, err := r.keyring.IsCurrent(ctx, encryption.InstanceScope, row.APIKey)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to check API key %s: %w", row.ID, err)
+		}
+		if current {
+			continue
+		}
+
+		key, err := r.keyring.Decrypt(ctx, row.APIKey)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to decrypt API key %s: %w", row.ID, err)
+		}
+
+		storedKey, err := r.encryptKey(ctx, string(key))
+		if err != nil {
+			return rewritten, err
+		}
+
+		result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET api_key = $2 WHERE id = $1 AND api_key = $3`, row.ID, storedKey, row.APIKey)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to update API key %s: %w", row.ID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			rewritten++
+		}
+	}
+
+	return rewritten, nil
+}
@@ -8,6 +8,7 @@ import (
 	json "github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // CustomHeadersMap represents a map of custom headers that can be stored in JSONB
@@ -56,13 +57,171 @@ func (h *CustomHeadersMap) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// ProviderConfig represents provider-level configuration (base URL, custom headers)
+// ModelDeployments maps the models to the names of their Azure OpenAI deployments, stored in JSONB
+type ModelDeployments map[string]string
+
+// Scan implements the sql.Scanner interface for database/sql
+func (d *ModelDeployments) Scan(value interface{}) error {
+	if value == nil {
+		*d = make(map[string]string)
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ModelDeployments", value)
+	}
+
+	return json.Unmarshal(bytes, d)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (d ModelDeployments) Value() (driver.Value, error) {
+	if d == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(d))
+}
+
+// ProviderRegion is a regional endpoint of a provider
+type ProviderRegion struct {
+	Name    string `json:"name" validate:"required,min=1,max=255"`
+	BaseURL string `json:"base_url" validate:"required,url"`
+}
+
+// ProviderRegions represents a list of regional endpoints that can be stored in JSONB
+type ProviderRegions []ProviderRegion
+
+// Scan implements the sql.Scanner interface for database/sql
+func (r *ProviderRegions) Scan(value interface{}) error {
+	if value == nil {
+		*r = []ProviderRegion{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ProviderRegions", value)
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (r ProviderRegions) Value() (driver.Value, error) {
+	if r == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]ProviderRegion(r))
+}
+
+// ProviderCapabilities are the features supported by the models of a provider, stored in JSONB. The requests are
+// stripped of the features they don't support.
+type ProviderCapabilities struct {
+	Tools     bool `json:"tools"`
+	Reasoning bool `json:"reasoning"`
+	Images    bool `json:"images"`
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (c *ProviderCapabilities) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ProviderCapabilities", value)
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (c ProviderCapabilities) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// ProviderConfig represents provider-level configuration (base URL, regions, custom headers)
 type ProviderConfig struct {
-	ProviderType  llm.ProviderName `json:"provider_type" db:"provider_type"`
-	BaseURL       *string          `json:"base_url,omitempty" db:"base_url"`
-	CustomHeaders CustomHeadersMap `json:"custom_headers,omitempty" db:"custom_headers"`
-	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+	ProviderType llm.ProviderName `json:"provider_type" db:"provider_type"`
+	BaseURL      *string          `json:"base_url,omitempty" db:"base_url"`
+	Regions      ProviderRegions  `json:"regions" db:"regions"`
+	PinnedRegion *string          `json:"pinned_region,omitempty" db:"pinned_region"`
+	DataRegions  pq.StringArray   `json:"data_regions" db:"data_regions"`
+	// ToolShimModels are the models without native tool calling, see gateway.ProviderConfig
+	ToolShimModels pq.StringArray `json:"tool_shim_models" db:"tool_shim_models"`
+	// Capabilities are the features supported by an OpenAI-compatible server, see gateway.ProviderConfig
+	Capabilities  *ProviderCapabilities `json:"capabilities,omitempty" db:"capabilities"`
+	CustomHeaders CustomHeadersMap      `json:"custom_headers,omitempty" db:"custom_headers"`
+	// APIVersion and Deployments configure the requests to Azure OpenAI, see gateway.ProviderConfig
+	APIVersion  *string          `json:"api_version,omitempty" db:"api_version"`
+	Deployments ModelDeployments `json:"deployments" db:"deployments"`
+	// GCPProject is the Google Cloud project of the requests to Vertex AI
+	GCPProject *string `json:"gcp_project,omitempty" db:"gcp_project"`
+	// LastTest is the result of the latest test of the API keys of the provider
+	LastTest  *ProviderTestResult `json:"last_test,omitempty" db:"last_test"`
+	CreatedAt time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// ProviderTestResult is the result of a test of the API keys of a provider, each key is sent a minimal request
+type ProviderTestResult struct {
+	Model    string          `json:"model"`
+	TestedAt time.Time       `json:"tested_at"`
+	Keys     []KeyTestResult `json:"keys"`
+}
+
+// KeyTestResult is the result of the test of an API key, Error is the error returned by the provider, e.g. an
+// invalid key or an unknown model
+type KeyTestResult struct {
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (r *ProviderTestResult) Scan(value interface{}) error {
+	if value == nil {
+		*r = ProviderTestResult{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ProviderTestResult", value)
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (r ProviderTestResult) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// TestProviderRequest represents the request to test the API keys of a provider
+type TestProviderRequest struct {
+	// Model of the test requests, defaults to the probe model of the provider, see ProbeModels
+	Model string `json:"model,omitempty"`
 }
 
 // APIKey represents an API key configuration
@@ -79,20 +238,40 @@ type APIKey struct {
 
 // CreateProviderConfigRequest represents the request to create/update provider config
 type CreateProviderConfigRequest struct {
-	ProviderType  llm.ProviderName `json:"provider_type" validate:"required,oneof=OpenAI Anthropic Gemini xAI"`
-	BaseURL       *string          `json:"base_url,omitempty" validate:"omitempty,url"`
-	CustomHeaders CustomHeadersMap `json:"custom_headers,omitempty"`
+	ProviderType   llm.ProviderName `json:"provider_type" validate:"required,oneof=OpenAI Anthropic Gemini xAI Ollama vLLM TGI HuggingFace Replicate Bedrock Azure VertexAI Mistral Cohere TogetherAI Fireworks Perplexity OpenRouter OpenAICompatible"`
+	BaseURL        *string          `json:"base_url,omitempty" validate:"omitempty,url"`
+	Regions        ProviderRegions  `json:"regions,omitempty" validate:"omitempty,dive"`
+	PinnedRegion   *string          `json:"pinned_region,omitempty"`
+	DataRegions    []string         `json:"data_regions,omitempty"`
+	ToolShimModels []string         `json:"tool_shim_models,omitempty"`
+	// Capabilities declares the features supported by an OpenAI-compatible server, it supports all of them when nil
+	Capabilities  *ProviderCapabilities `json:"capabilities,omitempty"`
+	CustomHeaders CustomHeadersMap      `json:"custom_headers,omitempty"`
+	APIVersion    *string               `json:"api_version,omitempty"`
+	Deployments   ModelDeployments      `json:"deployments,omitempty"`
+	GCPProject    *string               `json:"gcp_project,omitempty"`
 }
 
 // UpdateProviderConfigRequest represents the request to update provider config
 type UpdateProviderConfigRequest struct {
-	BaseURL       *string           `json:"base_url,omitempty" validate:"omitempty,url"`
-	CustomHeaders *CustomHeadersMap `json:"custom_headers,omitempty"`
+	BaseURL        *string               `json:"base_url,omitempty" validate:"omitempty,url"`
+	Regions        *ProviderRegions      `json:"regions,omitempty" validate:"omitempty,dive"`
+	PinnedRegion   *string               `json:"pinned_region,omitempty"`
+	DataRegions    *[]string             `json:"data_regions,omitempty"`
+	ToolShimModels *[]string             `json:"tool_shim_models,omitempty"`
+	Capabilities   *ProviderCapabilities `json:"capabilities,omitempty"`
+	CustomHeaders  *CustomHeadersMap     `json:"custom_headers,omitempty"`
+	// APIVersion sets the api-version of Azure OpenAI, an empty string resets it to the default
+	APIVersion  *string           `json:"api_version,omitempty"`
+	Deployments *ModelDeployments `json:"deployments,omitempty"`
+	// GCPProject sets the Google Cloud project of Vertex AI, an empty string resets it to the project of the
+	// credentials
+	GCPProject *string `json:"gcp_project,omitempty"`
 }
 
 // CreateAPIKeyRequest represents the request to create a new API key
 type CreateAPIKeyRequest struct {
-	ProviderType llm.ProviderName `json:"provider_type" validate:"required,oneof=OpenAI Anthropic Gemini xAI"`
+	ProviderType llm.ProviderName `json:"provider_type" validate:"required,oneof=OpenAI Anthropic Gemini xAI Ollama vLLM TGI HuggingFace Replicate Bedrock Azure VertexAI Mistral Cohere TogetherAI Fireworks Perplexity OpenRouter OpenAICompatible"`
 	Name         string           `json:"name" validate:"required,min=1,max=255"`
 	APIKey       string           `json:"api_key" validate:"required,min=1"`
 	Enabled      bool             `json:"enabled,omitempty"`
@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter/openrouter_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/perplexity/perplexity_responses"
 	"github.com/curaious/uno/pkg/llm"
 )
 
@@ -56,6 +59,166 @@ var ProviderModels = map[llm.ProviderName][]string{
 		"llama3.1:latest",
 		"mistral:latest",
 	},
+	llm.ProviderNameVLLM: {
+		"meta-llama/Llama-3.1-8B-Instruct",
+		"meta-llama/Llama-3.3-70B-Instruct",
+		"mistralai/Mistral-7B-Instruct-v0.3",
+		"Qwen/Qwen2.5-7B-Instruct",
+		"Qwen/Qwen2.5-72B-Instruct",
+	},
+	llm.ProviderNameTGI: {
+		"meta-llama/Llama-3.1-8B-Instruct",
+		"mistralai/Mistral-7B-Instruct-v0.3",
+		"HuggingFaceH4/zephyr-7b-beta",
+	},
+	llm.ProviderNameHuggingFace: {
+		"openai/gpt-oss-120b",
+		"openai/gpt-oss-20b",
+		"meta-llama/Llama-3.3-70B-Instruct",
+		"meta-llama/Llama-3.1-8B-Instruct",
+		"Qwen/Qwen3-235B-A22B",
+		"Qwen/Qwen2.5-72B-Instruct",
+		"deepseek-ai/DeepSeek-V3-0324",
+		"deepseek-ai/DeepSeek-R1",
+		"moonshotai/Kimi-K2-Instruct",
+		"google/gemma-3-27b-it",
+	},
+	llm.ProviderNameReplicate: {
+		"black-forest-labs/flux-schnell",
+		"black-forest-labs/flux-dev",
+		"black-forest-labs/flux-1.1-pro",
+		"black-forest-labs/flux-kontext-pro",
+		"stability-ai/stable-diffusion-3.5-large",
+		"ideogram-ai/ideogram-v3-turbo",
+		"google/imagen-4",
+		"recraft-ai/recraft-v3",
+	},
+	llm.ProviderNameBedrock: {
+		"anthropic.claude-sonnet-4-20250514-v1:0",
+		"anthropic.claude-3-7-sonnet-20250219-v1:0",
+		"anthropic.claude-3-5-haiku-20241022-v1:0",
+		"us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"meta.llama3-3-70b-instruct-v1:0",
+		"meta.llama3-1-8b-instruct-v1:0",
+		"us.meta.llama4-maverick-17b-instruct-v1:0",
+		"amazon.nova-pro-v1:0",
+		"amazon.nova-lite-v1:0",
+		"amazon.titan-text-premier-v1:0",
+		"amazon.titan-text-express-v1",
+	},
+	// The models are the names of the deployments, or the models mapped to deployments in the provider config
+	llm.ProviderNameAzure: {
+		"gpt-4.1",
+		"gpt-4.1-mini",
+		"gpt-4o",
+		"gpt-4o-mini",
+		"o4-mini",
+		"o3",
+		"text-embedding-3-small",
+		"text-embedding-3-large",
+	},
+	llm.ProviderNameVertexAI: {
+		"gemini-3-pro-preview",
+		"gemini-2.5-pro",
+		"gemini-2.5-flash",
+		"gemini-2.5-flash-lite",
+		"gemini-2.0-flash",
+	},
+	llm.ProviderNameMistral: {
+		"mistral-large-latest",
+		"mistral-medium-latest",
+		"mistral-small-latest",
+		"magistral-medium-latest",
+		"magistral-small-latest",
+		"codestral-latest",
+		"ministral-8b-latest",
+		"ministral-3b-latest",
+		"mistral-embed",
+	},
+	llm.ProviderNameCohere: {
+		"command-a-03-2025",
+		"command-a-reasoning-08-2025",
+		"command-a-vision-07-2025",
+		"command-r-plus-08-2024",
+		"command-r-08-2024",
+		"command-r7b-12-2024",
+	},
+	llm.ProviderNameTogetherAI: {
+		"meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		"meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+		"meta-llama/Llama-4-Maverick-17B-128E-Instruct-FP8",
+		"deepseek-ai/DeepSeek-R1",
+		"deepseek-ai/DeepSeek-V3.1",
+		"Qwen/Qwen3-235B-A22B-Instruct-2507-tput",
+		"Qwen/Qwen2.5-72B-Instruct-Turbo",
+		"moonshotai/Kimi-K2-Instruct",
+		"openai/gpt-oss-120b",
+		"openai/gpt-oss-20b",
+	},
+	// The names without account are the serverless models of Fireworks, e.g. accounts/fireworks/models/deepseek-r1
+	llm.ProviderNameFireworks: {
+		"llama-v3p3-70b-instruct",
+		"llama-v3p1-8b-instruct",
+		"llama4-maverick-instruct-basic",
+		"deepseek-r1",
+		"deepseek-v3p1",
+		"qwen3-235b-a22b",
+		"qwen2p5-72b-instruct",
+		"kimi-k2-instruct",
+		"gpt-oss-120b",
+		"gpt-oss-20b",
+	},
+	llm.ProviderNamePerplexity: {
+		"sonar",
+		"sonar-pro",
+		"sonar-reasoning",
+		"sonar-reasoning-pro",
+		"sonar-deep-research",
+	},
+	// The popular models of OpenRouter, the models of its catalog replace them once it is loaded
+	llm.ProviderNameOpenRouter: {
+		"openrouter/auto",
+		"openai/gpt-5",
+		"openai/gpt-4.1-mini",
+		"anthropic/claude-sonnet-4.5",
+		"anthropic/claude-haiku-4.5",
+		"google/gemini-2.5-pro",
+		"google/gemini-2.5-flash",
+		"x-ai/grok-4",
+		"deepseek/deepseek-chat-v3.1",
+		"meta-llama/llama-3.3-70b-instruct",
+		"qwen/qwen3-235b-a22b",
+		"mistralai/mistral-small-3.2-24b-instruct",
+	},
+}
+
+// ProbeModels are the models sent the test requests of the API keys of the providers, usually their cheapest model.
+// The self-hosted providers serve models of their own, the model of their tests is required.
+var ProbeModels = map[llm.ProviderName]string{
+	llm.ProviderNameOpenAI:      "gpt-4.1-nano",
+	llm.ProviderNameAnthropic:   "claude-haiku-4-5",
+	llm.ProviderNameGemini:      "gemini-2.5-flash-lite",
+	llm.ProviderNameXAI:         "grok-3-mini",
+	llm.ProviderNameHuggingFace: "meta-llama/Llama-3.1-8B-Instruct",
+	llm.ProviderNameReplicate:   "black-forest-labs/flux-schnell",
+	llm.ProviderNameBedrock:     "amazon.nova-lite-v1:0",
+	llm.ProviderNameAzure:       "gpt-4o-mini",
+	llm.ProviderNameVertexAI:    "gemini-2.5-flash-lite",
+	llm.ProviderNameMistral:     "ministral-3b-latest",
+	llm.ProviderNameCohere:      "command-r7b-12-2024",
+	llm.ProviderNameTogetherAI:  "meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+	llm.ProviderNameFireworks:   "llama-v3p1-8b-instruct",
+	llm.ProviderNamePerplexity:  "sonar",
+	llm.ProviderNameOpenRouter:  "meta-llama/llama-3.1-8b-instruct",
+}
+
+// ProviderExtraParams contains the extra params accepted by the self-hosted providers, see
+// responses.Parameters.ExtraParams
+var ProviderExtraParams = map[llm.ProviderName][]string{
+	llm.ProviderNameVLLM:       openai.VLLMExtraParams,
+	llm.ProviderNameTGI:        openai.TGIExtraParams,
+	llm.ProviderNamePerplexity: perplexity_responses.ExtraParams,
+	llm.ProviderNameOpenRouter: openrouter_responses.ExtraParams,
 }
 
 // ProviderModelsResponse represents the response structure for provider models API
@@ -65,7 +228,8 @@ type ProviderModelsResponse struct {
 
 // ProviderModelsData represents the models data for a provider
 type ProviderModelsData struct {
-	Models []string `json:"models"`
+	Models      []string `json:"models"`
+	ExtraParams []string `json:"extra_params,omitempty"`
 }
 
 // GetProviderModelsResponse returns the provider models in the API response format
@@ -76,7 +240,8 @@ func GetProviderModelsResponse() ProviderModelsResponse {
 
 	for providerType, models := range ProviderModels {
 		response.Providers[string(providerType)] = ProviderModelsData{
-			Models: models,
+			Models:      models,
+			ExtraParams: ProviderExtraParams[providerType],
 		}
 	}
 
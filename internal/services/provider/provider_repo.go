@@ -5,19 +5,22 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/curaious/uno/internal/encryption"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // ProviderRepo handles database operations for API keys
 type ProviderRepo struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	keyring *encryption.Keyring
 }
 
-// NewProviderRepo creates a new provider repository
-func NewProviderRepo(db *sqlx.DB) *ProviderRepo {
-	return &ProviderRepo{db: db}
+// NewProviderRepo creates a new provider repository. If keyring is non-nil, API keys are encrypted at rest.
+func NewProviderRepo(db *sqlx.DB, keyring *encryption.Keyring) *ProviderRepo {
+	return &ProviderRepo{db: db, keyring: keyring}
 }
 
 // Create creates a new API key
@@ -51,9 +54,14 @@ func (r *ProviderRepo) Create(ctx context.Context, req *CreateAPIKeyRequest) (*A
 		RETURNING id, provider_type, name, api_key, enabled, is_default, created_at, updated_at
 	`
 
+	storedKey, err := r.encryptKey(ctx, req.APIKey)
+	if err != nil {
+		return nil, err
+	}
+
 	var apiKey APIKey
 	err = tx.GetContext(ctx, &apiKey, query,
-		req.ProviderType, req.Name, req.APIKey, enabled, req.IsDefault)
+		req.ProviderType, req.Name, storedKey, enabled, req.IsDefault)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API key: %w", err)
 	}
@@ -62,7 +70,7 @@ func (r *ProviderRepo) Create(ctx context.Context, req *CreateAPIKeyRequest) (*A
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &apiKey, nil
+	return &apiKey, r.decryptKey(ctx, &apiKey)
 }
 
 // GetByID retrieves an API key by ID
@@ -82,7 +90,7 @@ func (r *ProviderRepo) GetByID(ctx context.Context, id uuid.UUID) (*APIKey, erro
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	return &apiKey, nil
+	return &apiKey, r.decryptKey(ctx, &apiKey)
 }
 
 // GetByName retrieves an API key by provider type and name
@@ -102,7 +110,7 @@ func (r *ProviderRepo) GetByName(ctx context.Context, providerType llm.ProviderN
 		return nil, fmt.Errorf("failed to get API key: %w", err)
 	}
 
-	return &apiKey, nil
+	return &apiKey, r.decryptKey(ctx, &apiKey)
 }
 
 // List retrieves all API keys with optional filtering
@@ -135,6 +143,12 @@ func (r *ProviderRepo) List(ctx context.Context, providerType *llm.ProviderName,
 		return nil, fmt.Errorf("failed to list API keys: %w", err)
 	}
 
+	for _, apiKey := range apiKeys {
+		if err := r.decryptKey(ctx, apiKey); err != nil {
+			return nil, err
+		}
+	}
+
 	return apiKeys, nil
 }
 
@@ -168,7 +182,7 @@ func (r *ProviderRepo) GetDefaultAPIKey(ctx context.Context, providerType llm.Pr
 		}
 	}
 
-	return &apiKey, nil
+	return &apiKey, r.decryptKey(ctx, &apiKey)
 }
 
 // Update updates an API key
@@ -190,8 +204,12 @@ func (r *ProviderRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateAPIK
 	}
 
 	if req.APIKey != nil {
+		storedKey, err := r.encryptKey(ctx, *req.APIKey)
+		if err != nil {
+			return nil, err
+		}
 		setParts = append(setParts, fmt.Sprintf("api_key = $%d", argIndex))
-		args = append(args, *req.APIKey)
+		args = append(args, storedKey)
 		argIndex++
 	}
 
@@ -261,7 +279,7 @@ func (r *ProviderRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateAPIK
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &apiKey, nil
+	return &apiKey, r.decryptKey(ctx, &apiKey)
 }
 
 // Delete deletes an API key
@@ -288,7 +306,7 @@ func (r *ProviderRepo) Delete(ctx context.Context, id uuid.UUID) error {
 // GetProviderConfig retrieves provider config by provider type
 func (r *ProviderRepo) GetProviderConfig(ctx context.Context, providerType llm.ProviderName) (*ProviderConfig, error) {
 	query := `
-		SELECT provider_type, base_url, custom_headers, created_at, updated_at
+		SELECT provider_type, base_url, regions, pinned_region, data_regions, tool_shim_models, capabilities, custom_headers, api_version, deployments, gcp_project, last_test, created_at, updated_at
 		FROM provider_configs
 		WHERE provider_type = $1
 	`
@@ -299,9 +317,13 @@ func (r *ProviderRepo) GetProviderConfig(ctx context.Context, providerType llm.P
 		if err == sql.ErrNoRows {
 			// Return empty config if not found
 			return &ProviderConfig{
-				ProviderType:  providerType,
-				BaseURL:       nil,
-				CustomHeaders: make(CustomHeadersMap),
+				ProviderType:   providerType,
+				BaseURL:        nil,
+				Regions:        ProviderRegions{},
+				DataRegions:    pq.StringArray{},
+				ToolShimModels: pq.StringArray{},
+				CustomHeaders:  make(CustomHeadersMap),
+				Deployments:    make(ModelDeployments),
 			}, nil
 		}
 		return nil, fmt.Errorf("failed to get provider config: %w", err)
@@ -320,18 +342,26 @@ func (r *ProviderRepo) CreateOrUpdateProviderConfig(ctx context.Context, req *Cr
 	}
 
 	query := `
-		INSERT INTO provider_configs (provider_type, base_url, custom_headers)
-		VALUES ($1, $2, $3)
+		INSERT INTO provider_configs (provider_type, base_url, regions, pinned_region, data_regions, tool_shim_models, capabilities, custom_headers, api_version, deployments, gcp_project)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, NULLIF($11, ''))
 		ON CONFLICT (provider_type) 
 		DO UPDATE SET 
 			base_url = EXCLUDED.base_url,
+			regions = EXCLUDED.regions,
+			pinned_region = EXCLUDED.pinned_region,
+			data_regions = EXCLUDED.data_regions,
+			tool_shim_models = EXCLUDED.tool_shim_models,
+			capabilities = EXCLUDED.capabilities,
 			custom_headers = EXCLUDED.custom_headers,
+			api_version = EXCLUDED.api_version,
+			deployments = EXCLUDED.deployments,
+			gcp_project = EXCLUDED.gcp_project,
 			updated_at = NOW()
-		RETURNING provider_type, base_url, custom_headers, created_at, updated_at
+		RETURNING provider_type, base_url, regions, pinned_region, data_regions, tool_shim_models, capabilities, custom_headers, api_version, deployments, gcp_project, last_test, created_at, updated_at
 	`
 
 	var config ProviderConfig
-	err := r.db.GetContext(ctx, &config, query, req.ProviderType, req.BaseURL, customHeaders)
+	err := r.db.GetContext(ctx, &config, query, req.ProviderType, req.BaseURL, req.Regions, req.PinnedRegion, pq.StringArray(req.DataRegions), pq.StringArray(req.ToolShimModels), req.Capabilities, customHeaders, req.APIVersion, req.Deployments, req.GCPProject)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create or update provider config: %w", err)
 	}
@@ -357,6 +387,42 @@ func (r *ProviderRepo) UpdateProviderConfig(ctx context.Context, providerType ll
 		argIndex++
 	}
 
+	if req.Regions != nil {
+		setParts = append(setParts, fmt.Sprintf("regions = $%d", argIndex))
+		args = append(args, *req.Regions)
+		argIndex++
+	}
+
+	if req.PinnedRegion != nil {
+		var pinnedRegionValue interface{}
+		if *req.PinnedRegion == "" {
+			pinnedRegionValue = nil
+		} else {
+			pinnedRegionValue = *req.PinnedRegion
+		}
+		setParts = append(setParts, fmt.Sprintf("pinned_region = $%d", argIndex))
+		args = append(args, pinnedRegionValue)
+		argIndex++
+	}
+
+	if req.DataRegions != nil {
+		setParts = append(setParts, fmt.Sprintf("data_regions = $%d", argIndex))
+		args = append(args, pq.StringArray(*req.DataRegions))
+		argIndex++
+	}
+
+	if req.ToolShimModels != nil {
+		setParts = append(setParts, fmt.Sprintf("tool_shim_models = $%d", argIndex))
+		args = append(args, pq.StringArray(*req.ToolShimModels))
+		argIndex++
+	}
+
+	if req.Capabilities != nil {
+		setParts = append(setParts, fmt.Sprintf("capabilities = $%d", argIndex))
+		args = append(args, *req.Capabilities)
+		argIndex++
+	}
+
 	if req.CustomHeaders != nil {
 		var headersValue interface{}
 		if len(*req.CustomHeaders) == 0 {
@@ -369,6 +435,24 @@ func (r *ProviderRepo) UpdateProviderConfig(ctx context.Context, providerType ll
 		argIndex++
 	}
 
+	if req.APIVersion != nil {
+		setParts = append(setParts, fmt.Sprintf("api_version = NULLIF($%d, '')", argIndex))
+		args = append(args, *req.APIVersion)
+		argIndex++
+	}
+
+	if req.Deployments != nil {
+		setParts = append(setParts, fmt.Sprintf("deployments = $%d", argIndex))
+		args = append(args, *req.Deployments)
+		argIndex++
+	}
+
+	if req.GCPProject != nil {
+		setParts = append(setParts, fmt.Sprintf("gcp_project = NULLIF($%d, '')", argIndex))
+		args = append(args, *req.GCPProject)
+		argIndex++
+	}
+
 	if len(setParts) == 0 {
 		return r.GetProviderConfig(ctx, providerType)
 	}
@@ -388,7 +472,7 @@ func (r *ProviderRepo) UpdateProviderConfig(ctx context.Context, providerType ll
 		UPDATE provider_configs
 		SET %s
 		WHERE provider_type = $%d
-		RETURNING provider_type, base_url, custom_headers, created_at, updated_at
+		RETURNING provider_type, base_url, regions, pinned_region, data_regions, tool_shim_models, capabilities, custom_headers, api_version, deployments, gcp_project, last_test, created_at, updated_at
 	`, setClause, argIndex)
 
 	var config ProviderConfig
@@ -406,7 +490,7 @@ func (r *ProviderRepo) UpdateProviderConfig(ctx context.Context, providerType ll
 // ListProviderConfigs retrieves all provider configs
 func (r *ProviderRepo) ListProviderConfigs(ctx context.Context) ([]*ProviderConfig, error) {
 	query := `
-		SELECT provider_type, base_url, custom_headers, created_at, updated_at
+		SELECT provider_type, base_url, regions, pinned_region, data_regions, tool_shim_models, capabilities, custom_headers, api_version, deployments, gcp_project, last_test, created_at, updated_at
 		FROM provider_configs
 		ORDER BY provider_type
 	`
@@ -419,3 +503,18 @@ func (r *ProviderRepo) ListProviderConfigs(ctx context.Context) ([]*ProviderConf
 
 	return configs, nil
 }
+
+// RecordProviderTest stores the result of a test of the API keys of a provider on its config
+func (r *ProviderRepo) RecordProviderTest(ctx context.Context, providerType llm.ProviderName, result *ProviderTestResult) error {
+	query := `
+		INSERT INTO provider_configs (provider_type, last_test)
+		VALUES ($1, $2)
+		ON CONFLICT (provider_type) DO UPDATE SET last_test = EXCLUDED.last_test
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, providerType, result); err != nil {
+		return fmt.Errorf("failed to record provider test: %w", err)
+	}
+
+	return nil
+}
@@ -140,6 +140,10 @@ func (s *ProviderService) CreateOrUpdateProviderConfig(ctx context.Context, req
 		return nil, fmt.Errorf("invalid provider type: %s", req.ProviderType)
 	}
 
+	if err := validateRegions(req.Regions, req.PinnedRegion); err != nil {
+		return nil, err
+	}
+
 	config, err := s.repo.CreateOrUpdateProviderConfig(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create or update provider config: %w", err)
@@ -154,6 +158,21 @@ func (s *ProviderService) UpdateProviderConfig(ctx context.Context, providerType
 		return nil, fmt.Errorf("invalid provider type: %s", providerType)
 	}
 
+	if req.Regions != nil || (req.PinnedRegion != nil && *req.PinnedRegion != "") {
+		regions := req.Regions
+		if regions == nil {
+			existing, err := s.repo.GetProviderConfig(ctx, providerType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get provider config: %w", err)
+			}
+			regions = &existing.Regions
+		}
+
+		if err := validateRegions(*regions, req.PinnedRegion); err != nil {
+			return nil, err
+		}
+	}
+
 	config, err := s.repo.UpdateProviderConfig(ctx, providerType, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update provider config: %w", err)
@@ -171,3 +190,32 @@ func (s *ProviderService) ListProviderConfigs(ctx context.Context) ([]*ProviderC
 
 	return configs, nil
 }
+
+// RecordProviderTest stores the result of a test of the API keys of a provider
+func (s *ProviderService) RecordProviderTest(ctx context.Context, providerType llm.ProviderName, result *ProviderTestResult) error {
+	if !providerType.IsValid() {
+		return fmt.Errorf("invalid provider type: %s", providerType)
+	}
+
+	return s.repo.RecordProviderTest(ctx, providerType, result)
+}
+
+// validateRegions checks that region names are unique and that the pinned region is one of them
+func validateRegions(regions ProviderRegions, pinnedRegion *string) error {
+	names := map[string]bool{}
+	for _, region := range regions {
+		if region.Name == "" || region.BaseURL == "" {
+			return fmt.Errorf("region name and base_url are required")
+		}
+		if names[region.Name] {
+			return fmt.Errorf("duplicate region: %s", region.Name)
+		}
+		names[region.Name] = true
+	}
+
+	if pinnedRegion != nil && *pinnedRegion != "" && !names[*pinnedRegion] {
+		return fmt.Errorf("pinned region %s is not one of the provider regions", *pinnedRegion)
+	}
+
+	return nil
+}
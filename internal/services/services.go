@@ -1,19 +1,40 @@
 package services
 
 import (
+	"log"
 	"log/slog"
+	"time"
 
 	"github.com/curaious/uno/internal/config"
 	"github.com/curaious/uno/internal/db"
+	"github.com/curaious/uno/internal/encryption"
+	"github.com/curaious/uno/internal/integrations/email"
 	agent_config2 "github.com/curaious/uno/internal/services/agent_config"
 	"github.com/curaious/uno/internal/services/agent_config/disk_storage"
+	analytics2 "github.com/curaious/uno/internal/services/analytics"
+	attachment2 "github.com/curaious/uno/internal/services/attachment"
+	chat_widget2 "github.com/curaious/uno/internal/services/chat_widget"
 	conversation2 "github.com/curaious/uno/internal/services/conversation"
+	conversation_token2 "github.com/curaious/uno/internal/services/conversation_token"
+	email2 "github.com/curaious/uno/internal/services/email"
+	environment2 "github.com/curaious/uno/internal/services/environment"
+	erasure2 "github.com/curaious/uno/internal/services/erasure"
+	eval_suite2 "github.com/curaious/uno/internal/services/eval_suite"
+	gateway_response2 "github.com/curaious/uno/internal/services/gateway_response"
+	key_usage2 "github.com/curaious/uno/internal/services/key_usage"
+	outbox2 "github.com/curaious/uno/internal/services/outbox"
 	project2 "github.com/curaious/uno/internal/services/project"
 	prompt2 "github.com/curaious/uno/internal/services/prompt"
 	provider2 "github.com/curaious/uno/internal/services/provider"
+	slack2 "github.com/curaious/uno/internal/services/slack"
+	test_run2 "github.com/curaious/uno/internal/services/test_run"
+	tool_dead_letter2 "github.com/curaious/uno/internal/services/tool_dead_letter"
 	traces2 "github.com/curaious/uno/internal/services/traces"
+	twilio2 "github.com/curaious/uno/internal/services/twilio"
+	usage2 "github.com/curaious/uno/internal/services/usage"
 	user2 "github.com/curaious/uno/internal/services/user"
 	virtual_key2 "github.com/curaious/uno/internal/services/virtual_key"
+	webhook_trigger2 "github.com/curaious/uno/internal/services/webhook_trigger"
 )
 
 type Services struct {
@@ -25,11 +46,50 @@ type Services struct {
 	VirtualKey   *virtual_key2.VirtualKeyService
 	Traces       *traces2.TracesService
 	User         *user2.UserService
+	Analytics    *analytics2.AnalyticsService
+	Environment  *environment2.EnvironmentService
+	TestRun      *test_run2.TestRunService
+	EvalSuite    *eval_suite2.EvalSuiteService
+
+	GatewayResponse *gateway_response2.GatewayResponseService
+	HistorySpool    *conversation2.HistorySpool
+	Outbox          *outbox2.OutboxService
+	Erasure         *erasure2.ErasureService
+	Slack           *slack2.SlackService
+	Twilio          *twilio2.TwilioService
+	Email           *email2.EmailService
+	WebhookTrigger  *webhook_trigger2.WebhookTriggerService
+	ChatWidget      *chat_widget2.ChatWidgetService
+	Usage           *usage2.UsageService
+	KeyUsage        *key_usage2.KeyUsageService
+	Attachment      *attachment2.AttachmentService
+	ToolDeadLetter  *tool_dead_letter2.ToolDeadLetterService
+
+	// ConversationToken issues the tokens that scope browser clients to a namespace or a conversation
+	ConversationToken *conversation_token2.ConversationTokenService
+
+	// Regions routes the data of projects pinned to a region to the database of that region
+	Regions *db.RegionRouter
+
+	// TrashRetention is how long deleted agent configs and prompts can be restored
+	TrashRetention time.Duration
 }
 
 func NewServices(conf *config.Config) *Services {
 	dbconn := db.NewConn(conf)
 
+	// Data would be written unencrypted with a master key that can't be used, so the configuration has to be valid
+	keyring, err := encryption.NewKeyringFromConfig(conf, dbconn)
+	if err != nil {
+		log.Fatalln("Invalid encryption configuration", err.Error())
+	}
+
+	regions := db.NewRegionRouter(conf, dbconn)
+	regionalConversations := map[string]*conversation2.ConversationRepo{}
+	for region, conn := range regions.Conns() {
+		regionalConversations[region] = conversation2.NewRegionalConversationRepo(conn, keyring, region)
+	}
+
 	var tracesSvc *traces2.TracesService
 	if conf.CLICKHOUSE_HOST != "" {
 		chConn, err := traces2.NewClickHouseConn(&traces2.ClickHouseConfig{
@@ -48,15 +108,62 @@ func NewServices(conf *config.Config) *Services {
 		}
 	}
 
+	// The alerts of the virtual keys are emailed from the address of the email channel
+	keyUsageOpts := key_usage2.KeyUsageOptions{From: conf.EMAIL_ADDRESS}
+	if conf.EMAIL_SMTP_ADDR != "" && conf.EMAIL_ADDRESS != "" {
+		keyUsageOpts.Sender = &email.SMTPSender{
+			Addr:     conf.EMAIL_SMTP_ADDR,
+			Username: conf.EMAIL_SMTP_USERNAME,
+			Password: conf.EMAIL_SMTP_PASSWORD,
+		}
+	}
+
 	svc := &Services{
-		Provider:     provider2.NewProviderService(provider2.NewProviderRepo(dbconn)),
+		Provider:     provider2.NewProviderService(provider2.NewProviderRepo(dbconn, keyring)),
 		VirtualKey:   virtual_key2.NewVirtualKeyService(virtual_key2.NewVirtualKeyRepo(dbconn)),
-		Project:      project2.NewProjectService(project2.NewProjectRepo(dbconn)),
+		Project:      project2.NewProjectService(project2.NewProjectRepo(dbconn), regions),
 		Prompt:       prompt2.NewPromptService(prompt2.NewPromptRepo(dbconn)),
 		AgentConfig:  agent_config2.NewAgentConfigService(agent_config2.NewAgentConfigRepo(dbconn), disk_storage.NewDiskStorage(conf.GetAgentDataPath())),
-		Conversation: conversation2.NewConversationService(conversation2.NewConversationRepo(dbconn)),
+		Conversation: conversation2.NewConversationService(conversation2.NewConversationRepo(dbconn, keyring, db.NewReplicaPool(conf, dbconn)), conversation2.WithRegions(regions, regionalConversations)),
 		Traces:       tracesSvc,
 		User:         user2.NewUserService(user2.NewUserRepo(dbconn)),
+		Analytics:    analytics2.NewAnalyticsService(analytics2.NewAnalyticsRepo(regions)),
+		Environment:  environment2.NewEnvironmentService(environment2.NewEnvironmentRepo(dbconn)),
+		TestRun:      test_run2.NewTestRunService(test_run2.NewTestRunRepo(dbconn)),
+		EvalSuite:    eval_suite2.NewEvalSuiteService(eval_suite2.NewEvalSuiteRepo(dbconn)),
+
+		GatewayResponse: gateway_response2.NewGatewayResponseService(gateway_response2.NewGatewayResponseRepo(dbconn)),
+		Outbox:          outbox2.NewOutboxService(outbox2.NewOutboxRepo(dbconn)),
+		Slack:           slack2.NewSlackService(slack2.NewSlackRepo(dbconn)),
+		Twilio:          twilio2.NewTwilioService(twilio2.NewTwilioRepo(dbconn)),
+		Email:           email2.NewEmailService(email2.NewEmailRepo(dbconn)),
+		WebhookTrigger:  webhook_trigger2.NewWebhookTriggerService(webhook_trigger2.NewWebhookTriggerRepo(dbconn)),
+		ChatWidget:      chat_widget2.NewChatWidgetService(chat_widget2.NewChatWidgetRepo(dbconn)),
+		Usage:           usage2.NewUsageService(usage2.NewUsageRepo(dbconn)),
+		KeyUsage:        key_usage2.NewKeyUsageService(key_usage2.NewKeyUsageRepo(dbconn), keyUsageOpts),
+		Attachment:      attachment2.NewAttachmentService(attachment2.NewAttachmentRepo(dbconn)),
+		ToolDeadLetter:  tool_dead_letter2.NewToolDeadLetterService(tool_dead_letter2.NewToolDeadLetterRepo(dbconn)),
+
+		Regions:        regions,
+		TrashRetention: conf.GetTrashRetention(),
+	}
+
+	historySpool, err := conversation2.NewHistorySpool(svc.Conversation, conversation2.HistorySpoolOptions{
+		Dir: conf.GetHistorySpoolPath(),
+	})
+	if err != nil {
+		slog.Warn("Failed to create history spool, failed history writes won't be retried", slog.Any("error", err))
+	} else {
+		svc.HistorySpool = historySpool
+	}
+
+	svc.Erasure = erasure2.NewErasureService(svc.Conversation, svc.HistorySpool, svc.GatewayResponse, conf.GetErasureReportSecret())
+	svc.ConversationToken = conversation_token2.NewConversationTokenService(svc.Conversation, conf.GetConversationTokenSecret())
+
+	go svc.purgeTrash(time.Hour)
+
+	if interval := conf.GetMCPDriftCheckInterval(); interval > 0 {
+		go svc.checkMCPDrift(interval)
 	}
 
 	// Initialize sandbox manager if explicitly enabled via environment / helm values.
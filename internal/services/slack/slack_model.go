@@ -0,0 +1,19 @@
+package slack
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SlackThread maps a Slack thread, or a direct message channel, to the thread of its conversation in the history
+type SlackThread struct {
+	TeamID    string `json:"team_id" db:"team_id"`
+	ChannelID string `json:"channel_id" db:"channel_id"`
+	// ThreadTS is the timestamp of the first message of the Slack thread, empty for a direct message channel
+	ThreadTS  string    `json:"thread_ts" db:"thread_ts"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Namespace string    `json:"namespace" db:"namespace"`
+	ThreadID  string    `json:"thread_id" db:"thread_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,50 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SlackRepo handles database operations for the threads of the Slack integration
+type SlackRepo struct {
+	db *sqlx.DB
+}
+
+// NewSlackRepo creates a new Slack repository
+func NewSlackRepo(db *sqlx.DB) *SlackRepo {
+	return &SlackRepo{db: db}
+}
+
+// GetThread retrieves the thread a Slack thread is mapped to
+func (r *SlackRepo) GetThread(ctx context.Context, teamID, channelID, threadTS string) (*SlackThread, error) {
+	query := `
+		SELECT team_id, channel_id, thread_ts, project_id, namespace, thread_id, created_at
+		FROM slack_threads
+		WHERE team_id = $1 AND channel_id = $2 AND thread_ts = $3
+	`
+
+	var thread SlackThread
+	if err := r.db.GetContext(ctx, &thread, query, teamID, channelID, threadTS); err != nil {
+		return nil, err
+	}
+
+	return &thread, nil
+}
+
+// CreateThread maps a Slack thread to a thread. A Slack thread mapped already keeps its thread.
+func (r *SlackRepo) CreateThread(ctx context.Context, thread *SlackThread) error {
+	query := `
+		INSERT INTO slack_threads (team_id, channel_id, thread_ts, project_id, namespace, thread_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (team_id, channel_id, thread_ts) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, thread.TeamID, thread.ChannelID, thread.ThreadTS, thread.ProjectID, thread.Namespace, thread.ThreadID)
+	if err != nil {
+		return fmt.Errorf("failed to map slack thread: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package slack
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SlackService keeps the conversations of the Slack integration
+type SlackService struct {
+	repo *SlackRepo
+}
+
+// NewSlackService creates a new Slack service
+func NewSlackService(repo *SlackRepo) *SlackService {
+	return &SlackService{repo: repo}
+}
+
+// GetThread returns the thread a Slack thread is mapped to, nil when the Slack thread has no conversation yet
+func (s *SlackService) GetThread(ctx context.Context, teamID, channelID, threadTS string) (*SlackThread, error) {
+	thread, err := s.repo.GetThread(ctx, teamID, channelID, threadTS)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return thread, err
+}
+
+// CreateThread maps a Slack thread to the thread its conversation was started in
+func (s *SlackService) CreateThread(ctx context.Context, thread *SlackThread) error {
+	return s.repo.CreateThread(ctx, thread)
+}
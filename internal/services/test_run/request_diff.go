@@ -0,0 +1,250 @@
+package test_run
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/agent-framework/eval"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Sections of a provider payload the changes are grouped by
+const (
+	SectionInstructions = "instructions"
+	SectionTools        = "tools"
+	SectionInput        = "input"
+	SectionParameters   = "parameters"
+)
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// payloadSections maps the top level fields of the payloads of the providers to their section, the other fields
+// are parameters
+var payloadSections = map[string]string{
+	"instructions":        SectionInstructions, // OpenAI
+	"system":              SectionInstructions, // Anthropic
+	"systemInstruction":   SectionInstructions, // Gemini
+	"system_instruction":  SectionInstructions,
+	"tools":               SectionTools,
+	"tool_choice":         SectionTools,
+	"toolConfig":          SectionTools,
+	"parallel_tool_calls": SectionTools,
+	"input":               SectionInput,
+	"messages":            SectionInput,
+	"contents":            SectionInput,
+}
+
+// payloadCodec keeps numbers as they are written, so that the payloads are compared as sent
+var payloadCodec = sonic.Config{UseNumber: true}.Froze()
+
+// DiffRequest represents the request to compare what two versions of an agent send to the provider for a message.
+// Version is compared against BaseVersion.
+type DiffRequest struct {
+	Message     responses.InputMessageUnion `json:"message"`
+	BaseVersion int                         `json:"base_version"`
+	Version     int                         `json:"version"`
+	Environment string                      `json:"environment,omitempty"`
+	RunContext  map[string]any              `json:"context,omitempty"`
+	Execute     bool                        `json:"execute,omitempty"` // Also run the message on both versions
+}
+
+// RequestDiff is the difference between the requests two versions of an agent send to the provider for a message
+type RequestDiff struct {
+	Input     responses.InputMessageUnion `json:"input"`
+	Base      *RenderedRequest            `json:"base"`
+	Candidate *RenderedRequest            `json:"candidate"`
+	Identical bool                        `json:"identical"`
+	Changes   []RequestChange             `json:"changes"`
+	Outputs   *OutputComparison           `json:"outputs,omitempty"` // Set when the versions are executed
+}
+
+// RenderedRequest is the request a version sends to the provider, or why it could not be rendered
+type RenderedRequest struct {
+	Version int               `json:"version"`
+	DryRun  *responses.DryRun `json:"dry_run,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// RequestChange is a field of the payload that differs between the versions. Path is the JSON path of the field,
+// where the items of lists of tools and functions are identified by their name, e.g. "tools[name=search].description".
+type RequestChange struct {
+	Section string `json:"section"`
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Before  any    `json:"before,omitempty"`
+	After   any    `json:"after,omitempty"`
+}
+
+// OutputComparison compares the outcomes of running the message on both versions
+type OutputComparison struct {
+	Base       *Outcome `json:"base"`
+	Candidate  *Outcome `json:"candidate"`
+	Identical  bool     `json:"identical"`
+	Similarity *float64 `json:"similarity,omitempty"` // Word overlap of both outputs, from 0 to 1
+}
+
+// Diff fills in the changes between the rendered requests of both versions
+func (d *RequestDiff) Diff() error {
+	if d.Base == nil || d.Candidate == nil || d.Base.DryRun == nil || d.Candidate.DryRun == nil {
+		return nil
+	}
+
+	changes, err := DiffRequests(d.Base.DryRun, d.Candidate.DryRun)
+	if err != nil {
+		return err
+	}
+
+	d.Changes = changes
+	d.Identical = len(changes) == 0
+	return nil
+}
+
+// DiffRequests lists the fields that differ between two requests to providers
+func DiffRequests(before, after *responses.DryRun) ([]RequestChange, error) {
+	var changes []RequestChange
+
+	if before.Provider != after.Provider {
+		changes = append(changes, RequestChange{Section: SectionParameters, Path: "provider", Kind: ChangeChanged, Before: before.Provider, After: after.Provider})
+	}
+	if before.URL != after.URL {
+		changes = append(changes, RequestChange{Section: SectionParameters, Path: "url", Kind: ChangeChanged, Before: before.URL, After: after.URL})
+	}
+
+	var beforePayload, afterPayload map[string]any
+	if err := payloadCodec.Unmarshal(before.Payload, &beforePayload); err != nil {
+		return nil, fmt.Errorf("invalid payload of the base request: %w", err)
+	}
+	if err := payloadCodec.Unmarshal(after.Payload, &afterPayload); err != nil {
+		return nil, fmt.Errorf("invalid payload of the candidate request: %w", err)
+	}
+
+	for _, key := range unionKeys(beforePayload, afterPayload) {
+		section, ok := payloadSections[key]
+		if !ok {
+			section = SectionParameters
+		}
+
+		b, inBefore := beforePayload[key]
+		a, inAfter := afterPayload[key]
+		changes = diffValue(changes, section, key, b, inBefore, a, inAfter)
+	}
+
+	return changes, nil
+}
+
+func diffValue(changes []RequestChange, section, path string, before any, inBefore bool, after any, inAfter bool) []RequestChange {
+	switch {
+	case !inBefore:
+		return append(changes, RequestChange{Section: section, Path: path, Kind: ChangeAdded, After: after})
+	case !inAfter:
+		return append(changes, RequestChange{Section: section, Path: path, Kind: ChangeRemoved, Before: before})
+	}
+
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, key := range unionKeys(b, a) {
+			bv, inB := b[key]
+			av, inA := a[key]
+			changes = diffValue(changes, section, path+"."+key, bv, inB, av, inA)
+		}
+		return changes
+
+	case []any:
+		a, ok := after.([]any)
+		if !ok {
+			break
+		}
+		bNamed, bOK := namedItems(b)
+		aNamed, aOK := namedItems(a)
+		if bOK && aOK {
+			for _, name := range unionKeys(bNamed, aNamed) {
+				bv, inB := bNamed[name]
+				av, inA := aNamed[name]
+				changes = diffValue(changes, section, fmt.Sprintf("%s[name=%s]", path, name), bv, inB, av, inA)
+			}
+			return changes
+		}
+		for i := 0; i < max(len(b), len(a)); i++ {
+			var bv, av any
+			if i < len(b) {
+				bv = b[i]
+			}
+			if i < len(a) {
+				av = a[i]
+			}
+			changes = diffValue(changes, section, fmt.Sprintf("%s[%d]", path, i), bv, i < len(b), av, i < len(a))
+		}
+		return changes
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes = append(changes, RequestChange{Section: section, Path: path, Kind: ChangeChanged, Before: before, After: after})
+	}
+	return changes
+}
+
+// namedItems indexes a list of tools or functions by their name. It reports false when an item has no name, or
+// when two items share one, for the list to be compared by position instead.
+func namedItems(items []any) (map[string]any, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	named := make(map[string]any, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		name, _ := obj["name"].(string)
+		if fn, ok := obj["function"].(map[string]any); ok && name == "" {
+			name, _ = fn["name"].(string)
+		}
+		if name == "" {
+			return nil, false
+		}
+		if _, dup := named[name]; dup {
+			return nil, false
+		}
+		named[name] = item
+	}
+
+	return named, true
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CompareOutputs fills in how the outputs of both versions compare
+func CompareOutputs(outputs *OutputComparison) {
+	if outputs.Base == nil || outputs.Candidate == nil || outputs.Base.Error != "" || outputs.Candidate.Error != "" {
+		return
+	}
+
+	outputs.Identical = strings.TrimSpace(outputs.Base.Output) == strings.TrimSpace(outputs.Candidate.Output)
+	similarity := eval.WordSimilarity(outputs.Base.Output, outputs.Candidate.Output)
+	outputs.Similarity = &similarity
+}
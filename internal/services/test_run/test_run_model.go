@@ -0,0 +1,106 @@
+package test_run
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// TestRun is a bulk run of an agent version against sample inputs
+type TestRun struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ProjectID   uuid.UUID  `json:"project_id" db:"project_id"`
+	AgentID     uuid.UUID  `json:"agent_id" db:"agent_id"`
+	Version     int        `json:"version" db:"version"`
+	LiveVersion *int       `json:"live_version,omitempty" db:"live_version"` // Version the results are compared with
+	Status      string     `json:"status" db:"status"`
+	Samples     int        `json:"samples" db:"samples"`
+	Report      Report     `json:"report" db:"report"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// Report holds the result of every sample of a test run and their summary
+type Report struct {
+	Results []SampleResult `json:"results"`
+	Summary Summary        `json:"summary"`
+	Error   string         `json:"error,omitempty"` // Why the run failed, if it did
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (r *Report) Scan(value interface{}) error {
+	if value == nil {
+		*r = Report{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Report", value)
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (r Report) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// SampleResult compares the outcome of a sample on the tested version with the live version
+type SampleResult struct {
+	Index      int                         `json:"index"`
+	Input      responses.InputMessageUnion `json:"input"`
+	Candidate  *Outcome                    `json:"candidate"`
+	Live       *Outcome                    `json:"live,omitempty"`
+	Identical  bool                        `json:"identical"`
+	Similarity *float64                    `json:"similarity,omitempty"` // Word overlap of both outputs, from 0 to 1
+}
+
+// Outcome is the result of running a sample on one version
+type Outcome struct {
+	Version    int              `json:"version"`
+	Status     string           `json:"status"` // Run status, or "error"
+	Output     string           `json:"output"`
+	Error      string           `json:"error,omitempty"`
+	DurationMs int64            `json:"duration_ms"`
+	Usage      *responses.Usage `json:"usage,omitempty"`
+}
+
+// Summary aggregates the results of a test run
+type Summary struct {
+	Samples           int      `json:"samples"`
+	CandidateErrors   int      `json:"candidate_errors"`
+	LiveErrors        int      `json:"live_errors"`
+	Identical         int      `json:"identical"`
+	AvgSimilarity     *float64 `json:"avg_similarity,omitempty"`
+	CandidateAvgMs    int64    `json:"candidate_avg_ms"`
+	LiveAvgMs         int64    `json:"live_avg_ms"`
+	CandidateTokens   int      `json:"candidate_tokens"`
+	LiveTokens        int      `json:"live_tokens"`
+	ComparedToVersion *int     `json:"compared_to_version,omitempty"`
+}
+
+// CreateTestRunRequest represents the request to run sample inputs against an agent version.
+// The live version is the one deployed to Environment when set, or else the latest published version.
+type CreateTestRunRequest struct {
+	Version     int                           `json:"version"`
+	Messages    []responses.InputMessageUnion `json:"messages" validate:"required,min=1"`
+	Environment string                        `json:"environment,omitempty"`
+	RunContext  map[string]any                `json:"context,omitempty"`
+}
@@ -0,0 +1,87 @@
+package test_run
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// TestRunRepo handles database operations for agent test runs
+type TestRunRepo struct {
+	db *sqlx.DB
+}
+
+// NewTestRunRepo creates a new test run repository
+func NewTestRunRepo(db *sqlx.DB) *TestRunRepo {
+	return &TestRunRepo{db: db}
+}
+
+// Create creates a running test run
+func (r *TestRunRepo) Create(ctx context.Context, projectID, agentID uuid.UUID, version int, liveVersion *int, samples int) (*TestRun, error) {
+	query := `
+		INSERT INTO agent_test_runs (project_id, agent_id, version, live_version, samples)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, project_id, agent_id, version, live_version, status, samples, report, created_at, completed_at
+	`
+
+	var run TestRun
+	if err := r.db.GetContext(ctx, &run, query, projectID, agentID, version, liveVersion, samples); err != nil {
+		return nil, fmt.Errorf("failed to create test run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// Complete stores the report of a test run and marks it as finished with the given status
+func (r *TestRunRepo) Complete(ctx context.Context, id uuid.UUID, status string, report Report) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE agent_test_runs SET status = $2, report = $3, completed_at = NOW()
+		WHERE id = $1
+	`, id, status, report)
+	if err != nil {
+		return fmt.Errorf("failed to complete test run: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a test run with its report
+func (r *TestRunRepo) GetByID(ctx context.Context, projectID, id uuid.UUID) (*TestRun, error) {
+	query := `
+		SELECT id, project_id, agent_id, version, live_version, status, samples, report, created_at, completed_at
+		FROM agent_test_runs
+		WHERE project_id = $1 AND id = $2
+	`
+
+	var run TestRun
+	if err := r.db.GetContext(ctx, &run, query, projectID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("test run not found")
+		}
+		return nil, fmt.Errorf("failed to get test run: %w", err)
+	}
+
+	return &run, nil
+}
+
+// ListByAgent retrieves the test runs of an agent, newest first. Only the summary of the reports is returned.
+func (r *TestRunRepo) ListByAgent(ctx context.Context, projectID, agentID uuid.UUID) ([]*TestRun, error) {
+	query := `
+		SELECT id, project_id, agent_id, version, live_version, status, samples,
+		       report - 'results' as report, created_at, completed_at
+		FROM agent_test_runs
+		WHERE project_id = $1 AND agent_id = $2
+		ORDER BY created_at DESC
+		LIMIT 100
+	`
+
+	runs := []*TestRun{}
+	if err := r.db.SelectContext(ctx, &runs, query, projectID, agentID); err != nil {
+		return nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,113 @@
+package test_run
+
+import (
+	"context"
+	"strings"
+
+	"github.com/curaious/uno/pkg/agent-framework/eval"
+	"github.com/google/uuid"
+)
+
+// TestRunService handles business logic for agent test runs
+type TestRunService struct {
+	repo *TestRunRepo
+}
+
+// NewTestRunService creates a new test run service
+func NewTestRunService(repo *TestRunRepo) *TestRunService {
+	return &TestRunService{repo: repo}
+}
+
+// Start records a new running test run
+func (s *TestRunService) Start(ctx context.Context, projectID, agentID uuid.UUID, version int, liveVersion *int, samples int) (*TestRun, error) {
+	return s.repo.Create(ctx, projectID, agentID, version, liveVersion, samples)
+}
+
+// Complete summarizes the results and stores the report of a test run
+func (s *TestRunService) Complete(ctx context.Context, run *TestRun, results []SampleResult, runErr error) (*TestRun, error) {
+	run.Report = Report{Results: results, Summary: Summarize(results, run.LiveVersion)}
+	run.Status = StatusCompleted
+	if runErr != nil {
+		run.Status = StatusFailed
+		run.Report.Error = runErr.Error()
+	}
+
+	if err := s.repo.Complete(ctx, run.ID, run.Status, run.Report); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, run.ProjectID, run.ID)
+}
+
+// Get retrieves a test run with its report
+func (s *TestRunService) Get(ctx context.Context, projectID, id uuid.UUID) (*TestRun, error) {
+	return s.repo.GetByID(ctx, projectID, id)
+}
+
+// ListByAgent retrieves the test runs of an agent
+func (s *TestRunService) ListByAgent(ctx context.Context, projectID, agentID uuid.UUID) ([]*TestRun, error) {
+	return s.repo.ListByAgent(ctx, projectID, agentID)
+}
+
+// Compare fills in how the outcome of the live version compares with the candidate
+func Compare(result *SampleResult) {
+	if result.Candidate == nil || result.Live == nil || result.Candidate.Error != "" || result.Live.Error != "" {
+		return
+	}
+
+	result.Identical = strings.TrimSpace(result.Candidate.Output) == strings.TrimSpace(result.Live.Output)
+	similarity := eval.WordSimilarity(result.Candidate.Output, result.Live.Output)
+	result.Similarity = &similarity
+}
+
+// Summarize aggregates the results of a test run
+func Summarize(results []SampleResult, liveVersion *int) Summary {
+	summary := Summary{Samples: len(results), ComparedToVersion: liveVersion}
+
+	var candidateMs, liveMs int64
+	var liveRuns, compared int
+	var similarity float64
+	for _, result := range results {
+		if c := result.Candidate; c != nil {
+			if c.Error != "" {
+				summary.CandidateErrors++
+			}
+			candidateMs += c.DurationMs
+			if c.Usage != nil {
+				summary.CandidateTokens += c.Usage.TotalTokens
+			}
+		}
+
+		if l := result.Live; l != nil {
+			liveRuns++
+			if l.Error != "" {
+				summary.LiveErrors++
+			}
+			liveMs += l.DurationMs
+			if l.Usage != nil {
+				summary.LiveTokens += l.Usage.TotalTokens
+			}
+		}
+
+		if result.Identical {
+			summary.Identical++
+		}
+		if result.Similarity != nil {
+			compared++
+			similarity += *result.Similarity
+		}
+	}
+
+	if len(results) > 0 {
+		summary.CandidateAvgMs = candidateMs / int64(len(results))
+	}
+	if liveRuns > 0 {
+		summary.LiveAvgMs = liveMs / int64(liveRuns)
+	}
+	if compared > 0 {
+		avg := similarity / float64(compared)
+		summary.AvgSimilarity = &avg
+	}
+
+	return summary
+}
@@ -0,0 +1,81 @@
+package tool_dead_letter
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusFailed   = "failed"
+	StatusReplayed = "replayed" // A replay of the call completed
+)
+
+// ToolDeadLetter is a failed call of a tool by an agent, with what is needed to diagnose and replay it
+type ToolDeadLetter struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	ProjectID      uuid.UUID  `json:"project_id" db:"project_id"`
+	AgentID        uuid.UUID  `json:"agent_id" db:"agent_id"`
+	AgentVersion   int        `json:"agent_version" db:"agent_version"`
+	AgentName      string     `json:"agent_name" db:"agent_name"`
+	RunID          string     `json:"run_id" db:"run_id"`
+	ConversationID string     `json:"conversation_id" db:"conversation_id"`
+	Namespace      string     `json:"namespace" db:"namespace"`
+	ToolName       string     `json:"tool_name" db:"tool_name"`
+	CallID         string     `json:"call_id" db:"call_id"`
+	Arguments      string     `json:"arguments" db:"arguments"`
+	Error          string     `json:"error" db:"error"`
+	Stack          string     `json:"stack" db:"stack"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	Status         string     `json:"status" db:"status"`
+	ReplayCount    int        `json:"replay_count" db:"replay_count"`
+	LastReplay     *Replay    `json:"last_replay,omitempty" db:"last_replay"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	LastReplayAt   *time.Time `json:"last_replay_at,omitempty" db:"last_replay_at"`
+}
+
+// Replay is the outcome of a re-execution of a failed call
+type Replay struct {
+	Version    int                                  `json:"version"` // Version of the agent the call was replayed on
+	Status     string                               `json:"status"`  // Status of the tool invocation
+	Output     *responses.FunctionCallOutputMessage `json:"output,omitempty"`
+	Error      string                               `json:"error,omitempty"`
+	DurationMs int64                                `json:"duration_ms"`
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (r *Replay) Scan(value interface{}) error {
+	if value == nil {
+		*r = Replay{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Replay", value)
+	}
+
+	return json.Unmarshal(bytes, r)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (r Replay) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// ListFilter narrows the dead letters listed, the empty fields match all
+type ListFilter struct {
+	AgentID  *uuid.UUID
+	ToolName string
+	Status   string
+	Limit    int
+}
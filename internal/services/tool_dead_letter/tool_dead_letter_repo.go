@@ -0,0 +1,92 @@
+package tool_dead_letter
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const columns = `id, project_id, agent_id, agent_version, agent_name, run_id, conversation_id, namespace, tool_name,
+		call_id, arguments, error, stack, attempts, status, replay_count, last_replay, created_at, last_replay_at`
+
+// ToolDeadLetterRepo handles database operations for the dead letters of tool calls
+type ToolDeadLetterRepo struct {
+	db *sqlx.DB
+}
+
+// NewToolDeadLetterRepo creates a new tool dead letter repository
+func NewToolDeadLetterRepo(db *sqlx.DB) *ToolDeadLetterRepo {
+	return &ToolDeadLetterRepo{db: db}
+}
+
+// Create stores a failed tool call
+func (r *ToolDeadLetterRepo) Create(ctx context.Context, letter *ToolDeadLetter) error {
+	query := `
+		INSERT INTO tool_dead_letters (project_id, agent_id, agent_version, agent_name, run_id, conversation_id,
+			namespace, tool_name, call_id, arguments, error, stack, attempts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, status, created_at
+	`
+
+	row := r.db.QueryRowxContext(ctx, query, letter.ProjectID, letter.AgentID, letter.AgentVersion, letter.AgentName,
+		letter.RunID, letter.ConversationID, letter.Namespace, letter.ToolName, letter.CallID, letter.Arguments,
+		letter.Error, letter.Stack, letter.Attempts)
+	if err := row.Scan(&letter.ID, &letter.Status, &letter.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create tool dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a dead letter
+func (r *ToolDeadLetterRepo) GetByID(ctx context.Context, projectID, id uuid.UUID) (*ToolDeadLetter, error) {
+	query := `SELECT ` + columns + ` FROM tool_dead_letters WHERE project_id = $1 AND id = $2`
+
+	var letter ToolDeadLetter
+	if err := r.db.GetContext(ctx, &letter, query, projectID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tool dead letter not found")
+		}
+		return nil, fmt.Errorf("failed to get tool dead letter: %w", err)
+	}
+
+	return &letter, nil
+}
+
+// List retrieves the dead letters of a project matching the filter, newest first
+func (r *ToolDeadLetterRepo) List(ctx context.Context, projectID uuid.UUID, filter ListFilter) ([]*ToolDeadLetter, error) {
+	query := `
+		SELECT ` + columns + `
+		FROM tool_dead_letters
+		WHERE project_id = $1
+		  AND ($2::uuid IS NULL OR agent_id = $2)
+		  AND ($3::text = '' OR tool_name = $3)
+		  AND ($4::text = '' OR status = $4)
+		ORDER BY created_at DESC
+		LIMIT $5
+	`
+
+	letters := []*ToolDeadLetter{}
+	if err := r.db.SelectContext(ctx, &letters, query, projectID, filter.AgentID, filter.ToolName, filter.Status, filter.Limit); err != nil {
+		return nil, fmt.Errorf("failed to list tool dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// RecordReplay stores the outcome of a replay of a dead letter, and its status
+func (r *ToolDeadLetterRepo) RecordReplay(ctx context.Context, projectID, id uuid.UUID, status string, replay Replay) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE tool_dead_letters
+		SET status = $3, replay_count = replay_count + 1, last_replay = $4, last_replay_at = NOW()
+		WHERE project_id = $1 AND id = $2
+	`, projectID, id, status, replay)
+	if err != nil {
+		return fmt.Errorf("failed to record tool dead letter replay: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package tool_dead_letter
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// ToolDeadLetterService handles business logic for the dead letters of tool calls
+type ToolDeadLetterService struct {
+	repo *ToolDeadLetterRepo
+}
+
+// NewToolDeadLetterService creates a new tool dead letter service
+func NewToolDeadLetterService(repo *ToolDeadLetterRepo) *ToolDeadLetterService {
+	return &ToolDeadLetterService{repo: repo}
+}
+
+// Record stores a failed call of a tool by a version of an agent
+func (s *ToolDeadLetterService) Record(ctx context.Context, projectID, agentID uuid.UUID, version int, letter *core.ToolDeadLetter) (*ToolDeadLetter, error) {
+	record := &ToolDeadLetter{
+		ProjectID:      projectID,
+		AgentID:        agentID,
+		AgentVersion:   version,
+		AgentName:      letter.AgentName,
+		RunID:          letter.RunID,
+		ConversationID: letter.ConversationID,
+		Namespace:      letter.Namespace,
+		ToolName:       letter.ToolName,
+		CallID:         letter.CallID,
+		Arguments:      letter.Arguments,
+		Error:          letter.Error,
+		Stack:          letter.Stack,
+		Attempts:       letter.Attempts,
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// Get retrieves a dead letter
+func (s *ToolDeadLetterService) Get(ctx context.Context, projectID, id uuid.UUID) (*ToolDeadLetter, error) {
+	return s.repo.GetByID(ctx, projectID, id)
+}
+
+// List retrieves the dead letters of a project, the newest 100 unless the filter has a limit
+func (s *ToolDeadLetterService) List(ctx context.Context, projectID uuid.UUID, filter ListFilter) ([]*ToolDeadLetter, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+
+	return s.repo.List(ctx, projectID, filter)
+}
+
+// RecordReplay stores the outcome of a replay, the dead letter is replayed once a replay completes
+func (s *ToolDeadLetterService) RecordReplay(ctx context.Context, letter *ToolDeadLetter, replay Replay, completed bool) (*ToolDeadLetter, error) {
+	status := letter.Status
+	if completed {
+		status = StatusReplayed
+	}
+
+	if err := s.repo.RecordReplay(ctx, letter.ProjectID, letter.ID, status, replay); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, letter.ProjectID, letter.ID)
+}
+
+// Store returns the store of the dead letters of a version of an agent
+func (s *ToolDeadLetterService) Store(projectID, agentID uuid.UUID, version int) core.ToolDeadLetterStore {
+	return &toolDeadLetterStore{svc: s, projectID: projectID, agentID: agentID, version: version}
+}
+
+type toolDeadLetterStore struct {
+	svc       *ToolDeadLetterService
+	projectID uuid.UUID
+	agentID   uuid.UUID
+	version   int
+}
+
+func (st *toolDeadLetterStore) Record(ctx context.Context, letter *core.ToolDeadLetter) error {
+	_, err := st.svc.Record(ctx, st.projectID, st.agentID, st.version, letter)
+	return err
+}
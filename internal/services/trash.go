@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// toolUsageRetention is how long the counters of tool quotas are kept after they were last counted in
+const toolUsageRetention = 32 * 24 * time.Hour
+
+// purgeTrash permanently deletes the agent configs and prompts that have been in the trash for longer than the
+// retention, once per interval, and the expired counters of tool quotas. It runs on every replica, purging is
+// idempotent.
+func (s *Services) purgeTrash(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+
+		agentConfigs, err := s.AgentConfig.PurgeDeleted(ctx, s.TrashRetention)
+		if err != nil {
+			slog.Error("Failed to purge deleted agent configs", slog.Any("error", err))
+		}
+
+		prompts, err := s.Prompt.PurgeDeletedPrompts(ctx, s.TrashRetention)
+		if err != nil {
+			slog.Error("Failed to purge deleted prompts", slog.Any("error", err))
+		}
+
+		if agentConfigs > 0 || prompts > 0 {
+			slog.Info("Purged trash", slog.Int64("agent_configs", agentConfigs), slog.Int64("prompts", prompts))
+		}
+
+		// The counters of past periods of tool quotas are no longer read
+		if _, err := s.Usage.PurgeExpired(ctx, toolUsageRetention); err != nil {
+			slog.Error("Failed to purge expired tool usage", slog.Any("error", err))
+		}
+	}
+}
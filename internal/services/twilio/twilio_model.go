@@ -0,0 +1,20 @@
+package twilio
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwilioConversation maps the messages of a sender to a number of the Twilio account to the thread of their
+// conversation in the history
+type TwilioConversation struct {
+	// Address is the number of the account the messages are sent to, prefixed with "whatsapp:" for WhatsApp
+	Address string `json:"address" db:"address"`
+	// Sender is the number the messages are sent from, prefixed with "whatsapp:" for WhatsApp
+	Sender    string    `json:"sender" db:"sender"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Namespace string    `json:"namespace" db:"namespace"`
+	ThreadID  string    `json:"thread_id" db:"thread_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,50 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TwilioRepo handles database operations for the conversations of the Twilio integration
+type TwilioRepo struct {
+	db *sqlx.DB
+}
+
+// NewTwilioRepo creates a new Twilio repository
+func NewTwilioRepo(db *sqlx.DB) *TwilioRepo {
+	return &TwilioRepo{db: db}
+}
+
+// GetConversation retrieves the conversation of a sender with a number
+func (r *TwilioRepo) GetConversation(ctx context.Context, address, sender string) (*TwilioConversation, error) {
+	query := `
+		SELECT address, sender, project_id, namespace, thread_id, created_at
+		FROM twilio_conversations
+		WHERE address = $1 AND sender = $2
+	`
+
+	var conversation TwilioConversation
+	if err := r.db.GetContext(ctx, &conversation, query, address, sender); err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+// CreateConversation maps a sender to a thread. A sender mapped already keeps its thread.
+func (r *TwilioRepo) CreateConversation(ctx context.Context, conversation *TwilioConversation) error {
+	query := `
+		INSERT INTO twilio_conversations (address, sender, project_id, namespace, thread_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (address, sender) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, conversation.Address, conversation.Sender, conversation.ProjectID, conversation.Namespace, conversation.ThreadID)
+	if err != nil {
+		return fmt.Errorf("failed to map twilio conversation: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package twilio
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// TwilioService keeps the conversations of the Twilio integration
+type TwilioService struct {
+	repo *TwilioRepo
+}
+
+// NewTwilioService creates a new Twilio service
+func NewTwilioService(repo *TwilioRepo) *TwilioService {
+	return &TwilioService{repo: repo}
+}
+
+// GetConversation returns the conversation of a sender with a number, nil when the sender has none yet
+func (s *TwilioService) GetConversation(ctx context.Context, address, sender string) (*TwilioConversation, error) {
+	conversation, err := s.repo.GetConversation(ctx, address, sender)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return conversation, err
+}
+
+// CreateConversation maps a sender to the thread its conversation was started in
+func (s *TwilioService) CreateConversation(ctx context.Context, conversation *TwilioConversation) error {
+	return s.repo.CreateConversation(ctx, conversation)
+}
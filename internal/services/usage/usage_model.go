@@ -0,0 +1,17 @@
+package usage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ToolUsage is the number of calls of a tool counted for a conversation or a user in a period of a quota
+type ToolUsage struct {
+	ProjectID   uuid.UUID `json:"project_id" db:"project_id"`
+	ScopeKey    string    `json:"scope_key" db:"scope_key"`
+	Tool        string    `json:"tool" db:"tool"`
+	WindowStart time.Time `json:"window_start" db:"window_start"`
+	Count       int       `json:"count" db:"count"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
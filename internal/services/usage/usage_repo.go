@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// UsageRepo handles database operations for the tool usage counters
+type UsageRepo struct {
+	db *sqlx.DB
+}
+
+// NewUsageRepo creates a new usage repository
+func NewUsageRepo(db *sqlx.DB) *UsageRepo {
+	return &UsageRepo{db: db}
+}
+
+// Increment adds n to the counter unless the result exceeds the limit. It returns the counter and whether it was
+// incremented, in a single statement so that concurrent calls can't both pass the limit.
+func (r *UsageRepo) Increment(ctx context.Context, projectID uuid.UUID, scopeKey, tool string, windowStart time.Time, n, limit int) (int, bool, error) {
+	query := `
+		INSERT INTO tool_usage_counters (project_id, scope_key, tool, window_start, count)
+		SELECT $1, $2, $3, $4, $5 WHERE $5 <= $6
+		ON CONFLICT (project_id, scope_key, tool, window_start)
+		DO UPDATE SET count = tool_usage_counters.count + EXCLUDED.count, updated_at = NOW()
+		WHERE tool_usage_counters.count + EXCLUDED.count <= $6
+		RETURNING count`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID, scopeKey, tool, windowStart, n, limit)
+	if errors.Is(err, sql.ErrNoRows) {
+		used, err := r.Get(ctx, projectID, scopeKey, tool, windowStart)
+		return used, false, err
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to increment tool usage: %w", err)
+	}
+
+	return count, true, nil
+}
+
+// Get returns the counter, 0 when nothing was counted yet
+func (r *UsageRepo) Get(ctx context.Context, projectID uuid.UUID, scopeKey, tool string, windowStart time.Time) (int, error) {
+	query := `SELECT count FROM tool_usage_counters WHERE project_id = $1 AND scope_key = $2 AND tool = $3 AND window_start = $4`
+
+	var count int
+	err := r.db.GetContext(ctx, &count, query, projectID, scopeKey, tool, windowStart)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tool usage: %w", err)
+	}
+
+	return count, nil
+}
+
+// List returns the counters of a scope, the most recent periods first
+func (r *UsageRepo) List(ctx context.Context, projectID uuid.UUID, scopeKey string) ([]*ToolUsage, error) {
+	query := `
+		SELECT project_id, scope_key, tool, window_start, count, updated_at
+		FROM tool_usage_counters
+		WHERE project_id = $1 AND scope_key = $2
+		ORDER BY window_start DESC, tool`
+
+	usages := []*ToolUsage{}
+	if err := r.db.SelectContext(ctx, &usages, query, projectID, scopeKey); err != nil {
+		return nil, fmt.Errorf("failed to list tool usage: %w", err)
+	}
+
+	return usages, nil
+}
+
+// DeleteBefore deletes the counters of periods last counted in before the time, the counters without a period are
+// kept as long as their conversation or user
+func (r *UsageRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM tool_usage_counters WHERE window_start > 'epoch' AND updated_at < $1`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete tool usage: %w", err)
+	}
+
+	return result.RowsAffected()
+}
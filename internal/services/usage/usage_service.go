@@ -0,0 +1,67 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/google/uuid"
+)
+
+// UsageService counts the calls of tools against the quotas of the agents
+type UsageService struct {
+	repo *UsageRepo
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(repo *UsageRepo) *UsageService {
+	return &UsageService{repo: repo}
+}
+
+// windowStart returns the start of the current period of the quota, the epoch for quotas without a period
+func windowStart(quota core.ToolQuota) time.Time {
+	start := quota.WindowStart(time.Now())
+	if start.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return start
+}
+
+// ConsumeToolQuota counts n calls of the tool of the quota for the scope, unless they exceed its limit
+func (s *UsageService) ConsumeToolQuota(ctx context.Context, projectID uuid.UUID, quota core.ToolQuota, scopeKey string, n int) (int, bool, error) {
+	return s.repo.Increment(ctx, projectID, scopeKey, quota.Tool, windowStart(quota), n, quota.Limit)
+}
+
+// ToolQuotaUsed returns the calls of the tool of the quota counted for the scope in the current period
+func (s *UsageService) ToolQuotaUsed(ctx context.Context, projectID uuid.UUID, quota core.ToolQuota, scopeKey string) (int, error) {
+	return s.repo.Get(ctx, projectID, scopeKey, quota.Tool, windowStart(quota))
+}
+
+// ListToolUsage returns the counters of a conversation, "conversation:<id>", or of a user, "user:<id>" or
+// "namespace:<namespace>"
+func (s *UsageService) ListToolUsage(ctx context.Context, projectID uuid.UUID, scopeKey string) ([]*ToolUsage, error) {
+	return s.repo.List(ctx, projectID, scopeKey)
+}
+
+// PurgeExpired deletes the counters of periods not counted in for longer than the retention
+func (s *UsageService) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-retention))
+}
+
+// ToolQuotaCounter returns the counter of the tool quotas of the agents of a project
+func (s *UsageService) ToolQuotaCounter(projectID uuid.UUID) core.ToolQuotaCounter {
+	return &toolQuotaCounter{svc: s, projectID: projectID}
+}
+
+type toolQuotaCounter struct {
+	svc       *UsageService
+	projectID uuid.UUID
+}
+
+func (c *toolQuotaCounter) Consume(ctx context.Context, quota core.ToolQuota, scopeKey string, n int) (int, bool, error) {
+	return c.svc.ConsumeToolQuota(ctx, c.projectID, quota, scopeKey, n)
+}
+
+func (c *toolQuotaCounter) Used(ctx context.Context, quota core.ToolQuota, scopeKey string) (int, error) {
+	return c.svc.ToolQuotaUsed(ctx, c.projectID, quota, scopeKey)
+}
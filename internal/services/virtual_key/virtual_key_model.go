@@ -62,30 +62,96 @@ func (r *RateLimits) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// VirtualKey represents a virtual key configuration
+// UsageAlerts configures the detection of the anomalies of the usage of a virtual key, e.g. of a leaked key. The
+// alerts are published to the outbox and emailed to the recipients.
+type UsageAlerts struct {
+	Enabled bool `json:"enabled"`
+
+	// SpikeFactor raises an alert when the requests of an hour exceed the hourly average of the previous week by
+	// the factor, 0 disables the alert. Hours with less than SpikeMinRequests requests (default 100) are no spike.
+	SpikeFactor      float64 `json:"spike_factor,omitempty" validate:"omitempty,gt=1"`
+	SpikeMinRequests int64   `json:"spike_min_requests,omitempty" validate:"omitempty,min=1"`
+
+	// UnusualModels raises an alert when a model not requested with the key over the previous 30 days is requested
+	UnusualModels bool `json:"unusual_models,omitempty"`
+
+	// OffHours raises an alert when the key is used outside of the working hours
+	OffHours *OffHours `json:"off_hours,omitempty"`
+
+	Emails []string `json:"emails,omitempty" validate:"omitempty,dive,email"`
+}
+
+// OffHours are the hours outside of [Start, End) in the timezone, a window wrapping midnight when Start > End
+type OffHours struct {
+	Timezone string `json:"timezone,omitempty"` // IANA name, UTC by default
+	Start    int    `json:"start" validate:"min=0,max=23"`
+	End      int    `json:"end" validate:"min=0,max=24"`
+	Weekends bool   `json:"weekends,omitempty"` // Saturdays and Sundays are off hours as a whole
+}
+
+// Scan implements the sql.Scanner interface for database/sql
+func (u *UsageAlerts) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into UsageAlerts", value)
+	}
+
+	return json.Unmarshal(bytes, u)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (u UsageAlerts) Value() (driver.Value, error) {
+	return json.Marshal(u)
+}
+
+// VirtualKey represents a virtual key configuration. The requests made with a key with a signing secret must be
+// signed with it, see gateway.SignRequest. The data stored by the gateway for a key of a project, e.g. its stored
+// responses, is erased with the data subjects of the project.
 type VirtualKey struct {
-	ID         uuid.UUID          `json:"id" db:"id"`
-	Name       string             `json:"name" db:"name"`
-	SecretKey  string             `json:"secret_key" db:"secret_key"`
-	Providers  []llm.ProviderName `json:"providers" db:"-"`
-	ModelNames []string           `json:"model_ids" db:"-"` // Keep json tag as model_ids for API compatibility
-	RateLimits RateLimits         `json:"rate_limits" db:"rate_limits"`
-	CreatedAt  time.Time          `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time          `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID          `json:"id" db:"id"`
+	Name          string             `json:"name" db:"name"`
+	SecretKey     string             `json:"secret_key" db:"secret_key"`
+	Providers     []llm.ProviderName `json:"providers" db:"-"`
+	ModelNames    []string           `json:"model_ids" db:"-"` // Keep json tag as model_ids for API compatibility
+	RateLimits    RateLimits         `json:"rate_limits" db:"rate_limits"`
+	UsageAlerts   *UsageAlerts       `json:"usage_alerts,omitempty" db:"usage_alerts"`
+	SigningSecret *string            `json:"signing_secret,omitempty" db:"signing_secret"`
+	ProjectID     *uuid.UUID         `json:"project_id,omitempty" db:"project_id"`
+	CreatedAt     time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" db:"updated_at"`
 }
 
 // CreateVirtualKeyRequest represents the request to create a new virtual key
 type CreateVirtualKeyRequest struct {
-	Name       string             `json:"name" validate:"required,min=1,max=255"`
-	Providers  []llm.ProviderName `json:"providers" validate:"dive,oneof=OpenAI Anthropic Gemini xAI"`
-	ModelIDs   []string           `json:"model_ids,omitempty"` // Changed to []string for model names
-	RateLimits *RateLimits        `json:"rate_limits,omitempty"`
+	Name        string             `json:"name" validate:"required,min=1,max=255"`
+	Providers   []llm.ProviderName `json:"providers" validate:"dive,oneof=OpenAI Anthropic Gemini xAI Ollama vLLM TGI HuggingFace Replicate Bedrock Azure VertexAI Mistral Cohere TogetherAI Fireworks Perplexity OpenRouter OpenAICompatible"`
+	ModelIDs    []string           `json:"model_ids,omitempty"` // Changed to []string for model names
+	RateLimits  *RateLimits        `json:"rate_limits,omitempty"`
+	UsageAlerts *UsageAlerts       `json:"usage_alerts,omitempty" validate:"omitempty"`
+
+	// RequireSignature generates the signing secret of the key
+	RequireSignature bool `json:"require_signature,omitempty"`
+
+	// ProjectID is the project the key is used by, if any
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
 }
 
 // UpdateVirtualKeyRequest represents the request to update a virtual key
 type UpdateVirtualKeyRequest struct {
-	Name       *string             `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
-	Providers  *[]llm.ProviderName `json:"providers,omitempty" validate:"omitempty,dive,oneof=OpenAI Anthropic Gemini xAI"`
-	ModelIDs   *[]string           `json:"model_ids,omitempty"` // Changed to *[]string for model names
-	RateLimits *RateLimits         `json:"rate_limits,omitempty"`
+	Name        *string             `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Providers   *[]llm.ProviderName `json:"providers,omitempty" validate:"omitempty,dive,oneof=OpenAI Anthropic Gemini xAI Ollama vLLM TGI HuggingFace Replicate Bedrock Azure VertexAI Mistral Cohere TogetherAI Fireworks Perplexity OpenRouter OpenAICompatible"`
+	ModelIDs    *[]string           `json:"model_ids,omitempty"` // Changed to *[]string for model names
+	RateLimits  *RateLimits         `json:"rate_limits,omitempty"`
+	UsageAlerts *UsageAlerts        `json:"usage_alerts,omitempty" validate:"omitempty"`
+
+	// RequireSignature generates the signing secret of the key if it has none, false removes it
+	RequireSignature *bool `json:"require_signature,omitempty"`
+
+	// RotateSigningSecret replaces the signing secret of a key requiring signatures
+	RotateSigningSecret bool `json:"rotate_signing_secret,omitempty"`
 }
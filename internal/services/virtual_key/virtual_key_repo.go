@@ -37,6 +37,16 @@ func generateSecretKey() (string, error) {
 	return "sk-uno-" + encoded, nil
 }
 
+// generateSigningSecret generates the secret signing the requests of a key with the prefix "vksig_"
+func generateSigningSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return "vksig_" + base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(bytes), nil
+}
+
 // Create creates a new virtual key with its providers and models
 func (r *VirtualKeyRepo) Create(ctx context.Context, req *CreateVirtualKeyRequest) (*VirtualKey, error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -58,13 +68,22 @@ func (r *VirtualKeyRepo) Create(ctx context.Context, req *CreateVirtualKeyReques
 	}
 
 	query := `
-		INSERT INTO virtual_keys (name, secret_key, rate_limits)
-		VALUES ($1, $2, $3)
-		RETURNING id, name, secret_key, rate_limits, created_at, updated_at
+		INSERT INTO virtual_keys (name, secret_key, rate_limits, usage_alerts, signing_secret, project_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, name, secret_key, rate_limits, usage_alerts, signing_secret, project_id, created_at, updated_at
 	`
 
+	var signingSecret *string
+	if req.RequireSignature {
+		secret, err := generateSigningSecret()
+		if err != nil {
+			return nil, err
+		}
+		signingSecret = &secret
+	}
+
 	var vk VirtualKey
-	err = tx.GetContext(ctx, &vk, query, req.Name, secretKey, rateLimits)
+	err = tx.GetContext(ctx, &vk, query, req.Name, secretKey, rateLimits, req.UsageAlerts, signingSecret, req.ProjectID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create virtual key: %w", err)
 	}
@@ -109,7 +128,7 @@ func (r *VirtualKeyRepo) Create(ctx context.Context, req *CreateVirtualKeyReques
 func (r *VirtualKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*VirtualKey, error) {
 	// Get the virtual key
 	query := `
-		SELECT id, name, secret_key, rate_limits, created_at, updated_at
+		SELECT id, name, secret_key, rate_limits, usage_alerts, signing_secret, project_id, created_at, updated_at
 		FROM virtual_keys
 		WHERE id = $1
 	`
@@ -171,7 +190,7 @@ func (r *VirtualKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*VirtualKey
 // GetByName retrieves a virtual key by name
 func (r *VirtualKeyRepo) GetByName(ctx context.Context, name string) (*VirtualKey, error) {
 	query := `
-		SELECT id, name, secret_key, rate_limits, created_at, updated_at
+		SELECT id, name, secret_key, rate_limits, usage_alerts, signing_secret, project_id, created_at, updated_at
 		FROM virtual_keys
 		WHERE name = $1
 	`
@@ -192,7 +211,7 @@ func (r *VirtualKeyRepo) GetByName(ctx context.Context, name string) (*VirtualKe
 // GetBySecretKey retrieves a virtual key by its secret key
 func (r *VirtualKeyRepo) GetBySecretKey(ctx context.Context, secretKey string) (*VirtualKey, error) {
 	query := `
-		SELECT id, name, secret_key, rate_limits, created_at, updated_at
+		SELECT id, name, secret_key, rate_limits, usage_alerts, signing_secret, project_id, created_at, updated_at
 		FROM virtual_keys
 		WHERE secret_key = $1
 	`
@@ -213,7 +232,7 @@ func (r *VirtualKeyRepo) GetBySecretKey(ctx context.Context, secretKey string) (
 // List retrieves all virtual keys with their providers and models
 func (r *VirtualKeyRepo) List(ctx context.Context) ([]*VirtualKey, error) {
 	query := `
-		SELECT id, name, secret_key, rate_limits, created_at, updated_at
+		SELECT id, name, secret_key, rate_limits, usage_alerts, signing_secret, project_id, created_at, updated_at
 		FROM virtual_keys
 		ORDER BY created_at DESC
 	`
@@ -268,6 +287,45 @@ func (r *VirtualKeyRepo) Update(ctx context.Context, id uuid.UUID, req *UpdateVi
 		}
 	}
 
+	// Update usage alerts if provided
+	if req.UsageAlerts != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE virtual_keys
+			SET usage_alerts = $1, updated_at = NOW()
+			WHERE id = $2
+		`, *req.UsageAlerts, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update usage alerts: %w", err)
+		}
+	}
+
+	// Generate, rotate or remove the signing secret
+	if req.RequireSignature != nil && !*req.RequireSignature {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE virtual_keys
+			SET signing_secret = NULL, updated_at = NOW()
+			WHERE id = $1
+		`, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove signing secret: %w", err)
+		}
+	} else if (req.RequireSignature != nil && *req.RequireSignature) || req.RotateSigningSecret {
+		signingSecret, err := generateSigningSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		// Without rotation, a key requiring signatures keeps its secret. A rotation only applies to such keys.
+		_, err = tx.ExecContext(ctx, `
+			UPDATE virtual_keys
+			SET signing_secret = CASE WHEN $2 THEN $1 ELSE COALESCE(signing_secret, $1) END, updated_at = NOW()
+			WHERE id = $3 AND ($4 OR signing_secret IS NOT NULL)
+		`, signingSecret, req.RotateSigningSecret, id, req.RequireSignature != nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update signing secret: %w", err)
+		}
+	}
+
 	// Update providers if provided
 	if req.Providers != nil {
 		// Delete existing providers
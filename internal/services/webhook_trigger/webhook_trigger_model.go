@@ -0,0 +1,125 @@
+package webhook_trigger
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusPaused    = "paused"
+	StatusTakenOver = "taken_over"
+	StatusFailed    = "failed"
+)
+
+var (
+	ErrTriggerNotFound    = errors.New("webhook trigger not found")
+	ErrInvocationNotFound = errors.New("webhook trigger invocation not found")
+	ErrTriggerDisabled    = errors.New("webhook trigger is disabled")
+	ErrInvalidSignature   = errors.New("invalid webhook signature")
+)
+
+// ContextTemplate maps the context variables of a run to the templates of their values, stored in JSONB
+type ContextTemplate map[string]string
+
+// Scan implements the sql.Scanner interface for database/sql
+func (c *ContextTemplate) Scan(value interface{}) error {
+	if value == nil {
+		*c = ContextTemplate{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into ContextTemplate", value)
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// Value implements the driver.Valuer interface for database/sql
+func (c ContextTemplate) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]string(c))
+}
+
+// WebhookTrigger runs an agent on the payloads an external system posts to it. The templates map a payload to the
+// message, namespace and context of the run, with {{payload.path}}, {{headers.name}} and {{query.name}} variables.
+type WebhookTrigger struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Name      string    `json:"name" db:"name"`
+	// AgentID is the agent that runs, as accepted by the agent_id parameter of the converse endpoint
+	AgentID           string `json:"agent_id" db:"agent_id"`
+	NamespaceTemplate string `json:"namespace_template" db:"namespace_template"`
+	// MessageTemplate renders the message of the run, the payload as JSON when it is empty
+	MessageTemplate string          `json:"message_template" db:"message_template"`
+	ContextTemplate ContextTemplate `json:"context_template" db:"context_template"`
+	// CallbackURL receives the result of the runs, no result is delivered when it is empty
+	CallbackURL string `json:"callback_url" db:"callback_url"`
+	// Secret authenticates the requests of the external system and signs the callbacks
+	Secret    string    `json:"secret" db:"secret"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Invocation is a payload posted to a trigger, the run it started and the delivery of its result
+type Invocation struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TriggerID uuid.UUID `json:"trigger_id" db:"trigger_id"`
+	ProjectID uuid.UUID `json:"project_id" db:"project_id"`
+	Status    string    `json:"status" db:"status"`
+	RunID     string    `json:"run_id" db:"run_id"`
+	// Result is the response of the run, as the converse endpoint answers when it doesn't stream
+	Result utils.RawMessage `json:"result,omitempty" db:"result"`
+	Error  string           `json:"error,omitempty" db:"error"`
+	// CallbackStatus is the HTTP status the callback URL answered, 0 while it wasn't delivered
+	CallbackStatus   int        `json:"callback_status" db:"callback_status"`
+	CallbackAttempts int        `json:"callback_attempts" db:"callback_attempts"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// CreateWebhookTriggerRequest represents the request to create a webhook trigger
+type CreateWebhookTriggerRequest struct {
+	Name              string          `json:"name" validate:"required,min=1,max=255"`
+	AgentID           string          `json:"agent_id" validate:"required"`
+	NamespaceTemplate string          `json:"namespace_template"`
+	MessageTemplate   string          `json:"message_template"`
+	ContextTemplate   ContextTemplate `json:"context_template"`
+	CallbackURL       string          `json:"callback_url"`
+}
+
+// UpdateWebhookTriggerRequest represents the request to update a webhook trigger, the fields left out are kept
+type UpdateWebhookTriggerRequest struct {
+	AgentID           *string          `json:"agent_id,omitempty"`
+	NamespaceTemplate *string          `json:"namespace_template,omitempty"`
+	MessageTemplate   *string          `json:"message_template,omitempty"`
+	ContextTemplate   *ContextTemplate `json:"context_template,omitempty"`
+	CallbackURL       *string          `json:"callback_url,omitempty"`
+	Enabled           *bool            `json:"enabled,omitempty"`
+	// RotateSecret replaces the secret of the trigger
+	RotateSecret bool `json:"rotate_secret,omitempty"`
+}
+
+// Rendered is the run a payload maps to
+type Rendered struct {
+	Namespace string         `json:"namespace"`
+	Message   string         `json:"message"`
+	Context   map[string]any `json:"context"`
+}
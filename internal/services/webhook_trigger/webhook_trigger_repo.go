@@ -0,0 +1,188 @@
+package webhook_trigger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const triggerColumns = `id, project_id, name, agent_id, namespace_template, message_template, context_template, callback_url, secret, enabled, created_at, updated_at`
+
+const invocationColumns = `id, trigger_id, project_id, status, run_id, result, error, callback_status, callback_attempts, created_at, completed_at`
+
+// WebhookTriggerRepo handles database operations for webhook triggers and their invocations
+type WebhookTriggerRepo struct {
+	db *sqlx.DB
+}
+
+// NewWebhookTriggerRepo creates a new webhook trigger repository
+func NewWebhookTriggerRepo(db *sqlx.DB) *WebhookTriggerRepo {
+	return &WebhookTriggerRepo{db: db}
+}
+
+// Create creates a new webhook trigger
+func (r *WebhookTriggerRepo) Create(ctx context.Context, projectID uuid.UUID, req *CreateWebhookTriggerRequest, secret string) (*WebhookTrigger, error) {
+	query := `
+		INSERT INTO webhook_triggers (project_id, name, agent_id, namespace_template, message_template, context_template, callback_url, secret)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + triggerColumns
+
+	var trigger WebhookTrigger
+	err := r.db.GetContext(ctx, &trigger, query, projectID, req.Name, req.AgentID, req.NamespaceTemplate, req.MessageTemplate, req.ContextTemplate, req.CallbackURL, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook trigger: %w", err)
+	}
+
+	return &trigger, nil
+}
+
+// List retrieves the webhook triggers of a project
+func (r *WebhookTriggerRepo) List(ctx context.Context, projectID uuid.UUID) ([]*WebhookTrigger, error) {
+	query := `SELECT ` + triggerColumns + ` FROM webhook_triggers WHERE project_id = $1 ORDER BY name`
+
+	triggers := []*WebhookTrigger{}
+	if err := r.db.SelectContext(ctx, &triggers, query, projectID); err != nil {
+		return nil, fmt.Errorf("failed to list webhook triggers: %w", err)
+	}
+
+	return triggers, nil
+}
+
+// GetByID retrieves a webhook trigger by ID
+func (r *WebhookTriggerRepo) GetByID(ctx context.Context, id uuid.UUID) (*WebhookTrigger, error) {
+	query := `SELECT ` + triggerColumns + ` FROM webhook_triggers WHERE id = $1`
+
+	var trigger WebhookTrigger
+	if err := r.db.GetContext(ctx, &trigger, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTriggerNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook trigger: %w", err)
+	}
+
+	return &trigger, nil
+}
+
+// Update replaces the settings of a webhook trigger
+func (r *WebhookTriggerRepo) Update(ctx context.Context, trigger *WebhookTrigger) (*WebhookTrigger, error) {
+	query := `
+		UPDATE webhook_triggers
+		SET agent_id = $3, namespace_template = $4, message_template = $5, context_template = $6, callback_url = $7,
+			secret = $8, enabled = $9, updated_at = NOW()
+		WHERE project_id = $1 AND id = $2
+		RETURNING ` + triggerColumns
+
+	var updated WebhookTrigger
+	err := r.db.GetContext(ctx, &updated, query, trigger.ProjectID, trigger.ID, trigger.AgentID, trigger.NamespaceTemplate,
+		trigger.MessageTemplate, trigger.ContextTemplate, trigger.CallbackURL, trigger.Secret, trigger.Enabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTriggerNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook trigger: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// Delete deletes a webhook trigger together with its invocations
+func (r *WebhookTriggerRepo) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_triggers WHERE project_id = $1 AND id = $2`, projectID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook trigger: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrTriggerNotFound
+	}
+
+	return nil
+}
+
+// CreateInvocation records a payload posted to a trigger, whose run is starting
+func (r *WebhookTriggerRepo) CreateInvocation(ctx context.Context, trigger *WebhookTrigger) (*Invocation, error) {
+	query := `
+		INSERT INTO webhook_trigger_invocations (trigger_id, project_id)
+		VALUES ($1, $2)
+		RETURNING ` + invocationColumns
+
+	var invocation Invocation
+	if err := r.db.GetContext(ctx, &invocation, query, trigger.ID, trigger.ProjectID); err != nil {
+		return nil, fmt.Errorf("failed to create webhook trigger invocation: %w", err)
+	}
+
+	return &invocation, nil
+}
+
+// CompleteInvocation records the end of the run of an invocation
+func (r *WebhookTriggerRepo) CompleteInvocation(ctx context.Context, invocation *Invocation) error {
+	query := `
+		UPDATE webhook_trigger_invocations
+		SET status = $2, run_id = $3, result = $4, error = $5, completed_at = NOW()
+		WHERE id = $1
+	`
+
+	var result any
+	if len(invocation.Result) > 0 {
+		result = []byte(invocation.Result)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, invocation.ID, invocation.Status, invocation.RunID, result, invocation.Error); err != nil {
+		return fmt.Errorf("failed to complete webhook trigger invocation: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateCallback records the delivery of the result of an invocation
+func (r *WebhookTriggerRepo) UpdateCallback(ctx context.Context, id uuid.UUID, status int, attempts int) error {
+	query := `UPDATE webhook_trigger_invocations SET callback_status = $2, callback_attempts = $3 WHERE id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, id, status, attempts); err != nil {
+		return fmt.Errorf("failed to update callback of webhook trigger invocation: %w", err)
+	}
+
+	return nil
+}
+
+// GetInvocation retrieves an invocation of a trigger
+func (r *WebhookTriggerRepo) GetInvocation(ctx context.Context, projectID uuid.UUID, triggerID uuid.UUID, id uuid.UUID) (*Invocation, error) {
+	query := `SELECT ` + invocationColumns + ` FROM webhook_trigger_invocations WHERE project_id = $1 AND trigger_id = $2 AND id = $3`
+
+	var invocation Invocation
+	if err := r.db.GetContext(ctx, &invocation, query, projectID, triggerID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvocationNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook trigger invocation: %w", err)
+	}
+
+	return &invocation, nil
+}
+
+// ListInvocations retrieves the latest invocations of a trigger, newest first
+func (r *WebhookTriggerRepo) ListInvocations(ctx context.Context, projectID uuid.UUID, triggerID uuid.UUID, limit int) ([]*Invocation, error) {
+	query := `
+		SELECT ` + invocationColumns + `
+		FROM webhook_trigger_invocations
+		WHERE project_id = $1 AND trigger_id = $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	invocations := []*Invocation{}
+	if err := r.db.SelectContext(ctx, &invocations, query, projectID, triggerID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list webhook trigger invocations: %w", err)
+	}
+
+	return invocations, nil
+}
@@ -0,0 +1,337 @@
+package webhook_trigger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	json "github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxInvocations bounds the invocations listed for a trigger
+	maxInvocations = 100
+	// callbackAttempts is how many times the result of an invocation is posted until the callback URL accepts it
+	callbackAttempts = 5
+	// maxRequestAge is how old the timestamp of a signed request can be, older requests may be replayed
+	maxRequestAge = 5 * time.Minute
+)
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// WebhookTriggerService handles business logic for webhook triggers
+type WebhookTriggerService struct {
+	repo   *WebhookTriggerRepo
+	client *http.Client
+}
+
+// NewWebhookTriggerService creates a new webhook trigger service
+func NewWebhookTriggerService(repo *WebhookTriggerRepo) *WebhookTriggerService {
+	return &WebhookTriggerService{
+		repo:   repo,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Create creates a webhook trigger with a new secret
+func (s *WebhookTriggerService) Create(ctx context.Context, projectID uuid.UUID, req *CreateWebhookTriggerRequest) (*WebhookTrigger, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := validateTrigger(req.AgentID, req.NamespaceTemplate, req.MessageTemplate, req.ContextTemplate, req.CallbackURL); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, projectID, req, secret)
+}
+
+// List retrieves the webhook triggers of a project
+func (s *WebhookTriggerService) List(ctx context.Context, projectID uuid.UUID) ([]*WebhookTrigger, error) {
+	return s.repo.List(ctx, projectID)
+}
+
+// Get retrieves a webhook trigger of a project
+func (s *WebhookTriggerService) Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*WebhookTrigger, error) {
+	trigger, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if trigger.ProjectID != projectID {
+		return nil, ErrTriggerNotFound
+	}
+
+	return trigger, nil
+}
+
+// GetByID retrieves a webhook trigger by ID, for the requests of the external systems which don't name a project
+func (s *WebhookTriggerService) GetByID(ctx context.Context, id uuid.UUID) (*WebhookTrigger, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Update updates the settings of a webhook trigger, rotating its secret when requested
+func (s *WebhookTriggerService) Update(ctx context.Context, projectID uuid.UUID, id uuid.UUID, req *UpdateWebhookTriggerRequest) (*WebhookTrigger, error) {
+	trigger, err := s.Get(ctx, projectID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AgentID != nil {
+		trigger.AgentID = *req.AgentID
+	}
+	if req.NamespaceTemplate != nil {
+		trigger.NamespaceTemplate = *req.NamespaceTemplate
+	}
+	if req.MessageTemplate != nil {
+		trigger.MessageTemplate = *req.MessageTemplate
+	}
+	if req.ContextTemplate != nil {
+		trigger.ContextTemplate = *req.ContextTemplate
+	}
+	if req.CallbackURL != nil {
+		trigger.CallbackURL = *req.CallbackURL
+	}
+	if req.Enabled != nil {
+		trigger.Enabled = *req.Enabled
+	}
+	if req.RotateSecret {
+		if trigger.Secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateTrigger(trigger.AgentID, trigger.NamespaceTemplate, trigger.MessageTemplate, trigger.ContextTemplate, trigger.CallbackURL); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(ctx, trigger)
+}
+
+// Delete deletes a webhook trigger
+func (s *WebhookTriggerService) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(ctx, projectID, id)
+}
+
+// Verify authenticates a request to a trigger, signed with the secret in the X-Uno-Signature header over its
+// X-Uno-Timestamp and body like the callbacks are, or carrying the secret as a bearer token for the systems that
+// can't sign their requests. Signed requests older than maxRequestAge are rejected, so that they can't be replayed.
+func (s *WebhookTriggerService) Verify(trigger *WebhookTrigger, body []byte, timestamp string, signature string, token string) error {
+	if signature != "" {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		if age := time.Since(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+			return ErrInvalidSignature
+		}
+
+		if hmac.Equal([]byte(signature), []byte(sign(trigger.Secret, timestamp, body))) {
+			return nil
+		}
+		return ErrInvalidSignature
+	}
+
+	if token != "" && hmac.Equal([]byte(token), []byte(trigger.Secret)) {
+		return nil
+	}
+
+	return ErrInvalidSignature
+}
+
+// Render maps the data of a request to the run of a trigger. The data holds the payload, the headers with
+// lowercase names and the query parameters of the request.
+func (s *WebhookTriggerService) Render(trigger *WebhookTrigger, data map[string]any) (*Rendered, error) {
+	rendered := &Rendered{
+		Namespace: strings.TrimSpace(renderTemplate(trigger.NamespaceTemplate, data)),
+		Context:   map[string]any{},
+	}
+	// The runs of a trigger without a namespace template share a namespace named after it
+	if rendered.Namespace == "" {
+		rendered.Namespace = "webhook-" + strings.Trim(nonAlphanumericRegex.ReplaceAllString(strings.ToLower(trigger.Name), "-"), "-")
+	}
+
+	if trigger.MessageTemplate == "" {
+		rendered.Message = formatValue(data["payload"])
+	} else {
+		rendered.Message = renderTemplate(trigger.MessageTemplate, data)
+	}
+	if strings.TrimSpace(rendered.Message) == "" {
+		return nil, fmt.Errorf("the message rendered from the payload is empty")
+	}
+
+	for name, template := range trigger.ContextTemplate {
+		rendered.Context[name] = renderValue(template, data)
+	}
+
+	return rendered, nil
+}
+
+// StartInvocation records a payload posted to a trigger, whose run is starting
+func (s *WebhookTriggerService) StartInvocation(ctx context.Context, trigger *WebhookTrigger) (*Invocation, error) {
+	return s.repo.CreateInvocation(ctx, trigger)
+}
+
+// CompleteInvocation records the end of the run of an invocation
+func (s *WebhookTriggerService) CompleteInvocation(ctx context.Context, invocation *Invocation) error {
+	return s.repo.CompleteInvocation(ctx, invocation)
+}
+
+// GetInvocation retrieves an invocation of a trigger
+func (s *WebhookTriggerService) GetInvocation(ctx context.Context, projectID uuid.UUID, triggerID uuid.UUID, id uuid.UUID) (*Invocation, error) {
+	return s.repo.GetInvocation(ctx, projectID, triggerID, id)
+}
+
+// ListInvocations retrieves the latest invocations of a trigger
+func (s *WebhookTriggerService) ListInvocations(ctx context.Context, projectID uuid.UUID, triggerID uuid.UUID) ([]*Invocation, error) {
+	return s.repo.ListInvocations(ctx, projectID, triggerID, maxInvocations)
+}
+
+// callbackPayload is the body of a callback
+type callbackPayload struct {
+	InvocationID uuid.UUID         `json:"invocation_id"`
+	TriggerID    uuid.UUID         `json:"trigger_id"`
+	Status       string            `json:"status"`
+	RunID        string            `json:"run_id,omitempty"`
+	Result       *utils.RawMessage `json:"result,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// DeliverCallback posts the result of an invocation to the callback URL of its trigger, retrying with backoff until
+// it is accepted. The timestamp and the body are signed with the secret of the trigger in the X-Uno-Signature header
+// and the invocation ID is sent as the Idempotency-Key header.
+func (s *WebhookTriggerService) DeliverCallback(ctx context.Context, trigger *WebhookTrigger, invocation *Invocation) error {
+	if trigger.CallbackURL == "" {
+		return nil
+	}
+
+	payload := callbackPayload{
+		InvocationID: invocation.ID,
+		TriggerID:    trigger.ID,
+		Status:       invocation.Status,
+		RunID:        invocation.RunID,
+		Error:        invocation.Error,
+	}
+	if len(invocation.Result) > 0 {
+		payload.Result = &invocation.Result
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	status := 0
+	attempts := 0
+	backoff := time.Second
+	for attempts < callbackAttempts {
+		attempts++
+		status, err = s.postCallback(ctx, trigger, invocation.ID, body)
+		if err == nil && status >= 200 && status < 300 {
+			break
+		}
+		// The other client errors won't be accepted by retrying
+		if err == nil && status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests {
+			break
+		}
+		if attempts < callbackAttempts {
+			select {
+			case <-ctx.Done():
+				attempts = callbackAttempts
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+
+	if updateErr := s.repo.UpdateCallback(ctx, invocation.ID, status, attempts); updateErr != nil {
+		return updateErr
+	}
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("callback answered with status %d", status)
+	}
+
+	return nil
+}
+
+func (s *WebhookTriggerService) postCallback(ctx context.Context, trigger *WebhookTrigger, invocationID uuid.UUID, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, trigger.CallbackURL, strings.NewReader(string(body)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", invocationID.String())
+	req.Header.Set("X-Uno-Event", "webhook_trigger.invocation")
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-Uno-Timestamp", timestamp)
+	req.Header.Set("X-Uno-Signature", sign(trigger.Secret, timestamp, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode, nil
+}
+
+func validateTrigger(agentID string, namespaceTemplate string, messageTemplate string, contextTemplate ContextTemplate, callbackURL string) error {
+	if strings.TrimSpace(agentID) == "" {
+		return fmt.Errorf("agent_id is required")
+	}
+
+	for _, template := range []string{namespaceTemplate, messageTemplate} {
+		if err := validateTemplate(template); err != nil {
+			return err
+		}
+	}
+	for name, template := range contextTemplate {
+		if err := validateTemplate(template); err != nil {
+			return fmt.Errorf("context variable '%s': %w", name, err)
+		}
+	}
+
+	if callbackURL != "" {
+		u, err := url.Parse(callbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid callback_url '%s': use an http or https URL", callbackURL)
+		}
+	}
+
+	return nil
+}
+
+// generateSecret generates the secret of a trigger with the prefix "whsec_"
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	return "whsec_" + base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString(bytes), nil
+}
+
+// sign signs a timestamp and a body, joined with a dot, with HMAC-SHA256
+func sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,84 @@
+package webhook_trigger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	json "github.com/bytedance/sonic"
+)
+
+// variableRegex finds the {{path}} variables of a template, like {{payload.issue.title}} or {{headers.x-event}}
+var variableRegex = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// validateTemplate checks that the braces of a template are balanced and that they hold variables
+func validateTemplate(template string) error {
+	if strings.Count(template, "{{") != strings.Count(template, "}}") {
+		return fmt.Errorf("unbalanced braces in template %q", template)
+	}
+	if strings.Count(template, "{{") != len(variableRegex.FindAllString(template, -1)) {
+		return fmt.Errorf("invalid variable in template %q", template)
+	}
+
+	return nil
+}
+
+// renderTemplate replaces the variables of a template with their values in the data, the missing ones with nothing.
+// Objects and arrays are rendered as JSON.
+func renderTemplate(template string, data map[string]any) string {
+	return variableRegex.ReplaceAllStringFunc(template, func(variable string) string {
+		path := variableRegex.FindStringSubmatch(variable)[1]
+		return formatValue(lookup(data, path))
+	})
+}
+
+// renderValue renders a template of a context variable. A template that is a single variable keeps the type of
+// its value, so that objects and numbers of the payload stay objects and numbers in the context.
+func renderValue(template string, data map[string]any) any {
+	if match := variableRegex.FindStringSubmatch(strings.TrimSpace(template)); match != nil && match[0] == strings.TrimSpace(template) {
+		return lookup(data, match[1])
+	}
+
+	return renderTemplate(template, data)
+}
+
+// lookup returns the value at a dotted path of the data, indexing arrays with numbers
+func lookup(data any, path string) any {
+	value := data
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+
+	return value
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(buf)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
+	"time"
 
+	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/agent-framework/core"
 	"github.com/curaious/uno/pkg/agent-framework/history"
@@ -15,6 +17,8 @@ import (
 	internal_adapters "github.com/curaious/uno/pkg/sdk/adapters"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -43,25 +47,31 @@ func (l *WrappedLLM) NewStreamingResponses(ctx context.Context, in *responses.Re
 		return nil, err
 	}
 
-	return acc.ReadStream(stream, cb)
-}
-
-type AgentRuntime interface {
-	Run(ctx context.Context, agent *Agent, in *AgentInput) (*AgentOutput, error)
+	return acc.ReadStream(ctx, stream, cb)
 }
 
 type Agent struct {
-	Name         string
-	output       map[string]any
-	history      *history.CommonConversationManager
-	instruction  core.SystemPromptProvider
-	tools        []core.Tool
-	mcpServers   []MCPToolset
-	llm          LLM
-	parameters   responses.Parameters
-	runtime      AgentRuntime
-	maxLoops     int
-	streamBroker core.StreamBroker
+	Name                  string
+	output                map[string]any
+	history               *history.CommonConversationManager
+	instruction           core.SystemPromptProvider
+	tools                 []core.Tool
+	mcpServers            []MCPToolset
+	llm                   LLM
+	parameters            responses.Parameters
+	runtime               AgentRuntime
+	maxLoops              int
+	streamBroker          core.StreamBroker
+	chunkPipeline         *responses.ChunkPipeline
+	disableArgumentRepair bool
+	provenance            bool
+	translation           *TranslationOptions
+	responsePolicy        *ResponsePolicyOptions
+	approvalGate          core.ApprovalGate
+	toolQuotas            []core.ToolQuota
+	toolQuotaCounter      core.ToolQuotaCounter
+	providerPinning       core.ProviderPinning
+	toolDeadLetters       core.ToolDeadLetterStore
 }
 
 type AgentOptions struct {
@@ -76,6 +86,48 @@ type AgentOptions struct {
 	McpServers []MCPToolset
 	Runtime    AgentRuntime
 	MaxLoops   *int
+
+	// ChunkPipeline transforms the chunks delivered to the run's callback
+	ChunkPipeline *responses.ChunkPipeline
+
+	// DisableArgumentRepair passes malformed tool call arguments through as they are, so that the model
+	// is asked to retry instead of repairing them
+	DisableArgumentRepair bool
+
+	// Provenance labels every output message of the model with the model, provider and run that generated it.
+	// The labels are streamed as response.provenance chunks and returned in AgentOutput.Provenance.
+	Provenance bool
+
+	// Translation translates the messages of the user to the working language of the agent, and the answers back.
+	// The translated answers are streamed as response.translation chunks and returned in AgentOutput.Output.
+	Translation *TranslationOptions
+
+	// ResponsePolicy enforces the language, the tone and the length of the answers of the model, asking it to revise
+	// the answers violating the policy. The rejected answers are announced by response.policy_violation chunks.
+	// The answers are checked before their translation.
+	ResponsePolicy *ResponsePolicyOptions
+
+	// DurableApprovals asks the durable runtimes to keep the runs paused for the approval of tool calls waiting,
+	// so that they resume where they stopped once approved, instead of ending them. The runtimes honour it by
+	// setting the ApprovalGate.
+	DurableApprovals bool
+
+	// ApprovalGate keeps the runs paused for approval waiting for the decision, see core.ApprovalGate
+	ApprovalGate core.ApprovalGate
+
+	// ToolQuotas limit the calls of tools per conversation or per user, counted by ToolQuotaCounter. A function
+	// tool call over quota gets a quota_exceeded result, a provider tool over quota is dropped from the LLM calls.
+	ToolQuotas       []core.ToolQuota
+	ToolQuotaCounter core.ToolQuotaCounter
+
+	// ProviderPinning keeps the conversations with the provider, or the model, of their runs. Without it the runs
+	// may switch, and the history of the runs of other LLMs is sanitized for the LLM of the agent. It needs an LLM
+	// implementing IdentifiedLLM.
+	ProviderPinning core.ProviderPinning
+
+	// ToolDeadLetters records the failed tool calls, with their arguments, error and attempts. The calls whose failure
+	// carries an output answer the model with it, instead of ending the run.
+	ToolDeadLetters core.ToolDeadLetterStore
 }
 
 func NewAgent(opts *AgentOptions) *Agent {
@@ -101,45 +153,91 @@ func NewAgent(opts *AgentOptions) *Agent {
 	}
 
 	return &Agent{
-		Name:        opts.Name,
-		output:      opts.Output,
-		history:     opts.History,
-		instruction: opts.Instruction,
-		tools:       opts.Tools,
-		mcpServers:  opts.McpServers,
-		llm:         &WrappedLLM{opts.LLM},
-		parameters:  opts.Parameters,
-		runtime:     opts.Runtime,
-		maxLoops:    maxLoops,
+		Name:                  opts.Name,
+		output:                opts.Output,
+		history:               opts.History,
+		instruction:           opts.Instruction,
+		tools:                 opts.Tools,
+		mcpServers:            opts.McpServers,
+		llm:                   &WrappedLLM{opts.LLM},
+		parameters:            opts.Parameters,
+		runtime:               opts.Runtime,
+		maxLoops:              maxLoops,
+		chunkPipeline:         opts.ChunkPipeline,
+		disableArgumentRepair: opts.DisableArgumentRepair,
+		provenance:            opts.Provenance,
+		translation:           opts.Translation,
+		responsePolicy:        opts.ResponsePolicy,
+		approvalGate:          opts.ApprovalGate,
+		toolQuotas:            opts.ToolQuotas,
+		toolQuotaCounter:      opts.ToolQuotaCounter,
+		providerPinning:       opts.ProviderPinning,
+		toolDeadLetters:       opts.ToolDeadLetters,
 	}
 }
 
 func (e *Agent) WithLLM(wrappedLLM LLM) *Agent {
 	return &Agent{
-		Name:         e.Name,
-		output:       e.output,
-		history:      e.history,
-		instruction:  e.instruction,
-		tools:        e.tools,
-		mcpServers:   e.mcpServers,
-		llm:          wrappedLLM,
-		parameters:   e.parameters,
-		runtime:      e.runtime,
-		maxLoops:     e.maxLoops,
-		streamBroker: e.streamBroker,
+		Name:                  e.Name,
+		output:                e.output,
+		history:               e.history,
+		instruction:           e.instruction,
+		tools:                 e.tools,
+		mcpServers:            e.mcpServers,
+		llm:                   wrappedLLM,
+		parameters:            e.parameters,
+		runtime:               e.runtime,
+		maxLoops:              e.maxLoops,
+		streamBroker:          e.streamBroker,
+		chunkPipeline:         e.chunkPipeline,
+		disableArgumentRepair: e.disableArgumentRepair,
+		provenance:            e.provenance,
+		translation:           e.translation,
+		responsePolicy:        e.responsePolicy,
+		approvalGate:          e.approvalGate,
+		toolQuotas:            e.toolQuotas,
+		toolQuotaCounter:      e.toolQuotaCounter,
+		providerPinning:       e.providerPinning,
+		toolDeadLetters:       e.toolDeadLetters,
 	}
 }
 
+// PrepareMCPTools lists the tools of the MCP servers. A tool whose name is already taken by another tool
+// is exposed under the name of its server as prefix, calls to it are dispatched with its original name.
 func (e *Agent) PrepareMCPTools(ctx context.Context, runContext map[string]any) ([]core.Tool, error) {
 	coreTools := []core.Tool{}
 	if e.mcpServers != nil {
+		taken := map[string]bool{}
+		for _, tool := range e.tools {
+			if t := tool.Tool(ctx); t != nil && t.OfFunction != nil {
+				taken[t.OfFunction.Name] = true
+			}
+		}
+
 		for _, mcpServer := range e.mcpServers {
 			mcpTools, err := mcpServer.ListTools(ctx, runContext)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list MCP tools: %w", err)
 			}
 
-			coreTools = append(coreTools, mcpTools...)
+			for _, tool := range mcpTools {
+				t := tool.Tool(ctx)
+				if t == nil || t.OfFunction == nil {
+					coreTools = append(coreTools, tool)
+					continue
+				}
+
+				if taken[t.OfFunction.Name] {
+					name := toolNamePrefix(mcpServer.GetName()) + t.OfFunction.Name
+					if taken[name] {
+						return nil, fmt.Errorf("MCP tool '%s' of server '%s' collides with another tool, set a tool prefix or rename it", t.OfFunction.Name, mcpServer.GetName())
+					}
+					tool = newNamespacedTool(ctx, tool, name)
+				}
+
+				taken[tool.Tool(ctx).OfFunction.Name] = true
+				coreTools = append(coreTools, tool)
+			}
 		}
 	}
 
@@ -155,6 +253,8 @@ type AgentInput struct {
 	PreviousMessageID string                               `json:"previous_message_id"`
 	Messages          []responses.InputMessageUnion        `json:"messages"`
 	RunContext        map[string]any                       `json:"run_context"`
+	RunMeta           map[string]any                       `json:"run_meta,omitempty"` // Recorded in the run state of the run
+	DryRun            bool                                 `json:"dry_run,omitempty"`  // Stops at the request to the provider, see AgentOutput.DryRun
 	Callback          func(chunk *responses.ResponseChunk) `json:"-"`
 	StreamBroker      core.StreamBroker                    `json:"-"`
 }
@@ -165,26 +265,72 @@ type AgentOutput struct {
 	Status           core.RunStatus                  `json:"status"`
 	Output           []responses.InputMessageUnion   `json:"output"`
 	PendingApprovals []responses.FunctionCallMessage `json:"pending_approvals"`
+
+	// Timing of the first LLM call of this invocation, which determines the latency perceived by the user
+	Timing *responses.Timing `json:"timing,omitempty"`
+
+	// Provenance of the output messages of the model, when enabled on the agent
+	Provenance []responses.Provenance `json:"provenance,omitempty"`
+
+	// DryRun is the request the provider would have received for the next LLM call of the run, for dry runs. Nothing
+	// of the run is saved.
+	DryRun *responses.DryRun `json:"dry_run,omitempty"`
+
+	// Loops is the number of LLM calls of the run, including those made before it was paused and resumed
+	Loops int `json:"loops,omitempty"`
 }
 
-func (e *Agent) Execute(ctx context.Context, in *AgentInput) (*AgentOutput, error) {
+// Execute runs the agent on the input. The run stops as soon as the context is done, see WithTimeout: the stream of the
+// provider and the tool calls in progress are aborted, and nothing more of the run is saved.
+func (e *Agent) Execute(ctx context.Context, in *AgentInput, opts ...ExecuteOption) (*AgentOutput, error) {
 	ctx, span := tracer.Start(ctx, "Agent.Execute")
 	defer span.End()
 
+	ctx, cancel := applyExecuteOptions(ctx, opts)
+	defer cancel()
+
 	if in.Callback == nil {
 		in.Callback = NilCallback
 	}
 
 	// Delegate to runtime, or use default LocalRuntime if none is set
 	runtime := e.runtime
-	if runtime != nil {
-		return runtime.Run(ctx, e, in)
+	if runtime == nil {
+		runtime = NewLocalRuntime()
 	}
 
-	return e.ExecuteWithExecutor(ctx, in, in.Callback)
+	return runtime.Run(ctx, e, in)
 }
 
-func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func(chunk *responses.ResponseChunk)) (*AgentOutput, error) {
+func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func(chunk *responses.ResponseChunk)) (out *AgentOutput, err error) {
+	// Route the chunks through the configured pipeline before they reach the consumer
+	if !e.chunkPipeline.IsEmpty() {
+		chunkStream := e.chunkPipeline.NewStream(ctx, cb)
+		defer chunkStream.Flush()
+		cb = chunkStream.Push
+	}
+
+	// A panic fails the run instead of the process. Once created, a run that fails is ended, see runFailed.
+	var created *history.ConversationRunManager
+	var traceid string
+	defer func() {
+		if p := recover(); p != nil {
+			if core.IsRuntimePanic(p) {
+				panic(p)
+			}
+
+			err = core.NewPanicError(p)
+			out = &AgentOutput{Status: core.RunStatusError}
+			if created != nil {
+				out.RunID = created.GetMessageID()
+			}
+		}
+
+		if err != nil && created != nil {
+			e.runFailed(ctx, created, traceid, err, cb)
+		}
+	}()
+
 	// Connect to MCP servers, and list the tools
 	mcpTools, err := e.PrepareMCPTools(ctx, in.RunContext)
 	if err != nil {
@@ -203,32 +349,67 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 		}
 	}
 
+	// Translate the messages of the user to the working language of the agent
+	messages := in.Messages
+	var userLanguage string
+	var inputTranslations []core.TranslationRecord
+	if e.translation != nil {
+		messages, userLanguage, inputTranslations = e.translateInput(ctx, in.Messages)
+	}
+
 	// Generate a run ID
-	run, err := history.NewRun(ctx, e.history, in.Namespace, in.PreviousMessageID, in.Messages)
+	run, err := history.NewRun(ctx, e.history, in.Namespace, in.PreviousMessageID, messages)
 	if err != nil {
 		return &AgentOutput{Status: core.RunStatusError, RunID: ""}, err
 	}
+	if userLanguage != "" {
+		run.RunState.UserLanguage = userLanguage
+	}
+	run.RunState.Translations = append(run.RunState.Translations, inputTranslations...)
+	if err := e.switchOver(ctx, run); err != nil {
+		return &AgentOutput{Status: core.RunStatusError, RunID: ""}, err
+	}
 
 	// Load run state from meta (in-memory, no DB call)
 	runId := run.GetMessageID()
+	run.RunState.AgentName = e.Name
+	if in.RunMeta != nil {
+		run.RunState.Meta = in.RunMeta
+	}
 
 	// TODO: what's the implication of obtaining traceid from context in case of durable execution?
-	var traceid string
 	if sc := trace.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
 		traceid = sc.TraceID().String()
 	}
 
 	// Collect tool rejections
 	var rejectedToolCallIds []string
-	if run.RunState.IsPaused() {
-		if run.RunState.CurrentStep == core.StepAwaitApproval {
-			rejectedToolCallIds = in.Messages[0].OfFunctionCallApprovalResponse.RejectedCallIds
+	// NewRun has already moved a resumed run from await_approval to execute_tools
+	if run.RunState.CurrentStep == core.StepExecuteTools && len(in.Messages) > 0 && in.Messages[0].OfFunctionCallApprovalResponse != nil {
+		// The runtime still waiting on the run would execute the tools too
+		if run.RunState.ApprovalID != "" {
+			return &AgentOutput{Status: core.RunStatusError, RunID: runId}, core.ErrAwaitingDurableApproval
 		}
+
+		approval := in.Messages[0].OfFunctionCallApprovalResponse
+		rejectedToolCallIds = approval.RejectedCallIds
+		run.RunState.RecordStep(core.StepRecord{
+			Type:      core.StepRecordApproval,
+			StartedAt: time.Now(),
+			Approved:  approval.ApprovedCallIds,
+			Rejected:  approval.RejectedCallIds,
+		})
+	}
+
+	// A dry run renders the next LLM call, a run resumed to execute tools would run them first
+	if in.DryRun && run.RunState.NextStep() != core.StepCallLLM {
+		return &AgentOutput{Status: core.RunStatusError, RunID: runId}, fmt.Errorf("dry run: the run is at step %s, not about to call the model", run.RunState.NextStep())
 	}
 
 	// Emit run.created
 	// TODO: make this a durable step to avoid resending on replays
 	e.runCreated(ctx, runId, traceid, cb)
+	created = run
 
 	// Get the prompt
 	instruction := "You are a helpful assistant."
@@ -254,9 +435,20 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 	}
 
 	finalOutput := []responses.InputMessageUnion{}
+	var timing *responses.Timing
+	estimated := false
+
+	// The rejected answer and the request to revise it, following the history in the next LLM call
+	var revision []responses.InputMessageUnion
+	revisions := 0
 
 	// Main loop - driven by state machine
 	for run.RunState.LoopIteration < e.maxLoops {
+		// A cancelled run stops before its next step, without saving it
+		if err := ctx.Err(); err != nil {
+			return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+		}
+
 		switch run.RunState.NextStep() {
 
 		case core.StepCallLLM:
@@ -265,20 +457,85 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 				return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
 			}
 
-			resp, err := e.llm.NewStreamingResponses(ctx, &responses.Request{
+			llmStart := time.Now()
+			llmReq := &responses.Request{
 				Instructions: utils.Ptr(instruction),
 				Input: responses.InputUnion{
-					OfInputMessageList: convMessages,
+					OfInputMessageList: append(convMessages, revision...),
 				},
-				Tools:      toolDefs,
+				Tools:      e.availableToolDefs(ctx, toolDefs, in, run.GetConversationID()),
 				Parameters: parameters,
-			}, cb)
+			}
+			if in.DryRun {
+				llmReq.DryRun = utils.Ptr(true)
+			}
+			// Warn the consumers of the cost of the run before the first LLM call completes
+			if !estimated {
+				e.runEstimated(runId, llmReq, cb)
+				estimated = true
+			}
+			// With an output schema, stream the structured output parsed so far and abort once it goes off-schema
+			llmCtx, cancelLLM := context.WithCancel(ctx)
+			llmCb := cb
+			var structuredOutput *structuredOutputStream
+			if e.output != nil {
+				structuredOutput = newStructuredOutputStream(e.output, cb, cancelLLM)
+				llmCb = structuredOutput.Push
+			}
+			// Tools streaming their arguments start working on them while the model writes them
+			args := newToolArgumentsStream(ctx, tools, core.ToolCall{AgentName: e.Name, Namespace: in.Namespace, ConversationID: run.GetConversationID()}, llmCb, cancelLLM)
+			if args != nil {
+				llmCb = args.Push
+			}
+
+			resp, err := e.llm.NewStreamingResponses(llmCtx, llmReq, llmCb)
+			cancelLLM()
+			if structuredOutput != nil && structuredOutput.violation != nil {
+				err = structuredOutput.violation
+			}
+			if args != nil && args.panic != nil {
+				err = args.panic
+			}
 			if err != nil {
+				// The failed call is the last step of the trace of the failed run
+				step := core.StepRecord{
+					Type:       core.StepRecordLLMCall,
+					Model:      llmReq.Model,
+					StartedAt:  llmStart,
+					DurationMs: time.Since(llmStart).Milliseconds(),
+					Error:      err.Error(),
+				}
+				if resp != nil {
+					step.Usage, step.Timing = resp.Usage, resp.Timing
+				}
+				run.RunState.RecordStep(step)
 				return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
 			}
 
+			// The provider was not called, the run stops here without saving anything
+			if resp.DryRun != nil {
+				return &AgentOutput{Status: core.RunStatusCompleted, RunID: runId, DryRun: resp.DryRun}, nil
+			}
+
 			// Track the LLM's usage
 			run.TrackUsage(resp.Usage)
+			model := resp.Model
+			if model == "" {
+				model = llmReq.Model
+			}
+			run.RunState.RecordStep(core.StepRecord{
+				Type:       core.StepRecordLLMCall,
+				Model:      model,
+				StartedAt:  llmStart,
+				DurationMs: time.Since(llmStart).Milliseconds(),
+				Usage:      resp.Usage,
+				Timing:     resp.Timing,
+			})
+			e.compareSummaryShadow(ctx, run, llmReq, resp)
+			e.consumeProviderToolQuotas(ctx, resp.Output, in, run.GetConversationID())
+			if timing == nil {
+				timing = resp.Timing
+			}
 
 			// Convert output to input messages and add to history
 			inputMsgs := []responses.InputMessageUnion{}
@@ -291,8 +548,30 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 				inputMsgs = append(inputMsgs, inputMsg)
 			}
 
+			// A final answer violating the response policy is not saved, the model revises it instead
+			if e.responsePolicy != nil && revisions < e.responsePolicy.maxRevisions() && !hasFunctionCalls(resp.Output) {
+				violations, usage := e.checkResponsePolicy(ctx, inputMsgs)
+				if usage != nil {
+					run.TrackUsage(usage)
+				}
+				if len(violations) > 0 {
+					revisions++
+					revision = e.rejectAnswer(inputMsgs, violations, revisions, cb)
+					continue
+				}
+			}
+			revision = nil
+
 			run.AddMessages(ctx, inputMsgs, resp.Usage)
-			finalOutput = append(finalOutput, inputMsgs...)
+			if e.translatesOutput(run.RunState) {
+				finalOutput = append(finalOutput, e.translateOutput(ctx, inputMsgs, run.RunState, cb)...)
+			} else {
+				finalOutput = append(finalOutput, inputMsgs...)
+			}
+
+			if e.provenance {
+				e.labelOutput(runId, model, resp, run.RunState, cb)
+			}
 
 			// Extract tool calls
 			toolCalls := []responses.FunctionCallMessage{}
@@ -325,13 +604,26 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 		case core.StepExecuteTools:
 			// Execute pending tool calls
 			for _, toolCall := range run.RunState.PendingToolCalls {
+				if err := ctx.Err(); err != nil {
+					return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+				}
+
 				tool := findTool(ctx, tools, toolCall.Name)
 				if tool == nil {
 					slog.ErrorContext(ctx, "tool not found", slog.String("tool_name", toolCall.Name))
+					run.RunState.RecordStep(core.StepRecord{
+						Type:      core.StepRecordToolCall,
+						CallID:    toolCall.CallID,
+						Name:      toolCall.Name,
+						StartedAt: time.Now(),
+						Error:     "tool not found",
+					})
 					continue
 				}
 
 				var toolResult *responses.FunctionCallOutputMessage
+				var toolErr string
+				toolStart := time.Now()
 
 				if slices.Contains(rejectedToolCallIds, toolCall.CallID) {
 					// Tool was rejected by human
@@ -342,18 +634,45 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 							OfString: utils.Ptr("Request to call this tool has been declined"),
 						},
 					}
+				} else if violations := e.validateToolCall(ctx, tool, &toolCall); len(violations) > 0 {
+					// Let the model correct the arguments instead of passing them to the tool
+					toolErr = "arguments invalid"
+					toolResult = invalidArgumentsOutput(toolCall, violations)
+				} else if quota, used := e.consumeToolQuotas(ctx, toolCall.Name, 1, in, run.GetConversationID()); quota != nil {
+					toolErr = "quota exceeded"
+					toolResult = quotaExceededOutput(toolCall, quota, used)
 				} else {
-					toolResult, err = tool.Execute(ctx, &core.ToolCall{
+					toolResult, err = core.CallTool(ctx, tool, &core.ToolCall{
 						FunctionCallMessage: &toolCall,
 						AgentName:           e.Name,
 						Namespace:           in.Namespace,
 						ConversationID:      run.GetConversationID(),
 					})
+					if err != nil && ctx.Err() != nil {
+						// The call was aborted with the run, it didn't fail
+						return &AgentOutput{Status: core.RunStatusError, RunID: runId}, ctx.Err()
+					}
 					if err != nil {
-						return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+						// Answer the model with the output of the failure, if any, the call is recorded to be replayed
+						failure := core.AsToolFailure(err)
+						e.recordToolDeadLetter(ctx, runId, run.GetConversationID(), in, toolCall, failure)
+						if failure.Output == nil {
+							return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+						}
+						toolErr = failure.Message
+						toolResult = failure.Output
 					}
 				}
 
+				run.RunState.RecordStep(core.StepRecord{
+					Type:       core.StepRecordToolCall,
+					CallID:     toolCall.CallID,
+					Name:       toolCall.Name,
+					StartedAt:  toolStart,
+					DurationMs: time.Since(toolStart).Milliseconds(),
+					Error:      toolErr,
+				})
+
 				// TODO: Make this a durable step to avoid resending
 				cb(&responses.ResponseChunk{
 					OfFunctionCallOutput: toolResult,
@@ -378,6 +697,14 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 			}
 
 		case core.StepAwaitApproval:
+			var wait func() (*responses.FunctionCallApprovalResponseMessage, error)
+			if e.approvalGate != nil {
+				run.RunState.ApprovalID, wait, err = e.approvalGate.RequestApproval(ctx, runId, run.RunState.PendingToolCalls)
+				if err != nil {
+					return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+				}
+			}
+
 			err = run.SaveMessages(ctx, run.RunState.ToMeta(traceid))
 			if err != nil {
 				return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
@@ -386,10 +713,33 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 			// TODO: make this a durable step to avoid resending on replays
 			e.runPaused(ctx, runId, traceid, run.RunState, cb)
 
+			if wait != nil {
+				// The run waits for the decision and executes the tools it stopped at
+				approval, err := wait()
+				if err != nil {
+					return &AgentOutput{Status: core.RunStatusError, RunID: runId}, err
+				}
+
+				rejectedToolCallIds = approval.RejectedCallIds
+				run.RunState.ApprovalID = ""
+				run.RunState.RecordStep(core.StepRecord{
+					Type:      core.StepRecordApproval,
+					StartedAt: time.Now(),
+					Approved:  approval.ApprovedCallIds,
+					Rejected:  approval.RejectedCallIds,
+				})
+				run.RunState.CurrentStep = core.StepExecuteTools
+
+				e.runResumed(ctx, runId, traceid, cb)
+				continue
+			}
+
 			return &AgentOutput{
 				RunID:            runId,
 				Status:           core.RunStatusPaused,
 				PendingApprovals: run.RunState.PendingToolCalls,
+				Timing:           timing,
+				Loops:            run.RunState.LoopIteration,
 			}, nil
 
 		case core.StepComplete:
@@ -402,9 +752,12 @@ func (e *Agent) ExecuteWithExecutor(ctx context.Context, in *AgentInput, cb func
 			e.runCompleted(ctx, runId, traceid, run.RunState, cb)
 
 			return &AgentOutput{
-				RunID:  runId,
-				Status: core.RunStatusCompleted,
-				Output: finalOutput,
+				RunID:      runId,
+				Status:     core.RunStatusCompleted,
+				Output:     finalOutput,
+				Timing:     timing,
+				Provenance: run.RunState.Provenance,
+				Loops:      run.RunState.LoopIteration,
 			}, nil
 		}
 	}
@@ -439,6 +792,28 @@ func (e *Agent) runCreated(ctx context.Context, runId string, traceId string, cb
 	return nil
 }
 
+// runEstimated streams the estimate of the input tokens of an LLM call, and their cost when the pricing of the model
+// of the agent is known
+func (e *Agent) runEstimated(runId string, llmReq *responses.Request, cb func(chunk *responses.ResponseChunk)) {
+	payload, err := sonic.Marshal(llmReq)
+	if err != nil {
+		return
+	}
+
+	estimate := &responses.ChunkRunEstimate[constants.ChunkTypeRunEstimated]{
+		RunID:                runId,
+		EstimatedInputTokens: llm.EstimateTokens(payload),
+	}
+	if id, ok := e.llm.(IdentifiedLLM); ok {
+		estimate.Model = id.ModelName()
+	}
+	if pricing, ok := llm.GetModelPricing(estimate.Model); ok {
+		estimate.EstimatedCost = utils.Ptr(pricing.Cost(&responses.Usage{InputTokens: estimate.EstimatedInputTokens}))
+	}
+
+	cb(&responses.ResponseChunk{OfRunEstimated: estimate})
+}
+
 func (e *Agent) runPaused(ctx context.Context, runId string, traceId string, runState *core.RunState, cb func(chunk *responses.ResponseChunk)) error {
 	cb(&responses.ResponseChunk{
 		OfRunPaused: &responses.ChunkRun[constants.ChunkTypeRunPaused]{
@@ -449,6 +824,23 @@ func (e *Agent) runPaused(ctx context.Context, runId string, traceId string, run
 				PendingToolCalls: runState.PendingToolCalls,
 				Usage:            runState.Usage,
 				TraceID:          traceId,
+				ApprovalID:       runState.ApprovalID,
+			},
+		},
+	})
+
+	return nil
+}
+
+// runResumed tells the consumers that a run kept waiting for approval by a durable runtime continues
+func (e *Agent) runResumed(ctx context.Context, runId string, traceId string, cb func(chunk *responses.ResponseChunk)) error {
+	cb(&responses.ResponseChunk{
+		OfRunInProgress: &responses.ChunkRun[constants.ChunkTypeRunInProgress]{
+			RunState: responses.ChunkRunData{
+				Id:      runId,
+				Object:  "run",
+				Status:  "resumed",
+				TraceID: traceId,
 			},
 		},
 	})
@@ -471,6 +863,78 @@ func (e *Agent) runCompleted(ctx context.Context, runId string, traceId string,
 	return nil
 }
 
+// runFailed ends a run that failed: the failure is reported, recorded in the run state with the messages of the run
+// the conversation can be continued from, and streamed as a run.failed chunk. A cancelled run saves nothing more.
+func (e *Agent) runFailed(ctx context.Context, run *history.ConversationRunManager, traceId string, err error, cb func(chunk *responses.ResponseChunk)) {
+	runErr := core.NewRunError(err)
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err, trace.WithAttributes(attribute.String("error.type", string(runErr.Code))))
+	span.SetStatus(codes.Error, runErr.Message)
+	slog.ErrorContext(ctx, "Agent run failed",
+		slog.String("agent", e.Name),
+		slog.String("run_id", run.GetMessageID()),
+		slog.String("code", string(runErr.Code)),
+		slog.String("error", runErr.Message),
+		slog.String("stack", runErr.Stack),
+	)
+
+	run.RunState.TransitionToFailed(runErr)
+	if ctx.Err() == nil {
+		run.DiscardUnansweredToolCalls()
+		if err := run.SaveMessages(ctx, run.RunState.ToMeta(traceId)); err != nil {
+			slog.WarnContext(ctx, "Failed to save the failed run", slog.String("run_id", run.GetMessageID()), slog.Any("error", err))
+		}
+	}
+
+	// The consumer may be what failed, it must not take the run down with it
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(ctx, "Failed to stream the failure of the run", slog.String("run_id", run.GetMessageID()), slog.Any("panic", p))
+		}
+	}()
+
+	cb(&responses.ResponseChunk{
+		OfRunFailed: &responses.ChunkRun[constants.ChunkTypeRunFailed]{
+			RunState: responses.ChunkRunData{
+				Id:      run.GetMessageID(),
+				Object:  "run",
+				Status:  "failed",
+				Usage:   run.RunState.Usage,
+				TraceID: traceId,
+				Error:   &responses.ChunkRunError{Code: string(runErr.Code), Message: runErr.Message},
+			},
+		},
+	})
+}
+
+// labelOutput records the provenance of the output messages of an LLM call and streams it
+func (e *Agent) labelOutput(runId string, model string, resp *responses.Response, runState *core.RunState, cb func(chunk *responses.ResponseChunk)) {
+	generatedAt := time.Now().UTC()
+	for _, msg := range resp.Output {
+		if msg.OfOutputMessage == nil {
+			continue
+		}
+
+		provenance := responses.Provenance{
+			MessageID:   msg.OfOutputMessage.ID,
+			Model:       model,
+			Provider:    resp.Provider,
+			Agent:       e.Name,
+			RunID:       runId,
+			GeneratedAt: generatedAt,
+		}
+		runState.Provenance = append(runState.Provenance, provenance)
+
+		cb(&responses.ResponseChunk{
+			OfProvenance: &responses.ChunkProvenance[constants.ChunkTypeProvenance]{
+				ItemId:     provenance.MessageID,
+				Provenance: provenance,
+			},
+		})
+	}
+}
+
 // partitionByApproval splits tool calls into those needing approval and those that can execute immediately
 func partitionByApproval(ctx context.Context, tools []core.Tool, toolCalls []responses.FunctionCallMessage) (needsApproval []responses.FunctionCallMessage, immediate []responses.FunctionCallMessage) {
 	for _, toolCall := range toolCalls {
@@ -484,6 +948,41 @@ func partitionByApproval(ctx context.Context, tools []core.Tool, toolCalls []res
 	return needsApproval, immediate
 }
 
+// validateToolCall repairs malformed arguments of a tool call, unless disabled, and validates them against
+// the parameters schema of the tool
+func (e *Agent) validateToolCall(ctx context.Context, tool core.Tool, toolCall *responses.FunctionCallMessage) []string {
+	t := tool.Tool(ctx)
+	if t == nil || t.OfFunction == nil {
+		return nil
+	}
+
+	if !e.disableArgumentRepair {
+		if repaired, ok := core.RepairToolArguments(toolCall.Arguments); ok {
+			slog.WarnContext(ctx, "repaired tool call arguments", slog.String("tool_name", toolCall.Name), slog.String("call_id", toolCall.CallID))
+			toolCall.Arguments = repaired
+		}
+	}
+
+	return core.ValidateToolArguments(t.OfFunction.Parameters, toolCall.Arguments)
+}
+
+// invalidArgumentsOutput is the tool result returned to the model when the arguments of a call are invalid
+func invalidArgumentsOutput(toolCall responses.FunctionCallMessage, violations []string) *responses.FunctionCallOutputMessage {
+	buf, _ := sonic.Marshal(map[string]any{
+		"error":      "arguments_invalid",
+		"message":    fmt.Sprintf("The arguments of the call to '%s' are invalid, fix them and call the tool again.", toolCall.Name),
+		"violations": violations,
+	})
+
+	return &responses.FunctionCallOutputMessage{
+		ID:     toolCall.ID,
+		CallID: toolCall.CallID,
+		Output: responses.FunctionCallOutputContentUnion{
+			OfString: utils.Ptr(string(buf)),
+		},
+	}
+}
+
 // findTool finds a tool by name
 func findTool(ctx context.Context, tools []core.Tool, toolName string) core.Tool {
 	for _, tool := range tools {
@@ -497,80 +996,32 @@ func findTool(ctx context.Context, tools []core.Tool, toolName string) core.Tool
 type Accumulator struct {
 }
 
-func (a *Accumulator) ReadStream(stream chan *responses.ResponseChunk, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
-	// Process stream
-	finalOutput := []responses.OutputMessageUnion{}
-	var usage *responses.Usage
-	for chunk := range stream {
-		cb(chunk)
-		switch chunk.ChunkType() {
-		case "response.output_item.done":
-			if chunk.OfOutputItemDone.Item.Type == "message" {
-				for _, content := range chunk.OfOutputItemDone.Item.Content {
-					if content.OfOutputText != nil {
-						finalOutput = append(finalOutput, responses.OutputMessageUnion{
-							OfOutputMessage: &responses.OutputMessage{
-								ID:   chunk.OfOutputItemDone.Item.Id,
-								Role: constants.RoleAssistant,
-								Content: responses.OutputContent{
-									content,
-								},
-							},
-						})
-					}
+// ReadStream accumulates the chunks of the stream, passing them to cb. It returns the error of the context as soon as
+// the context is done, without waiting for the provider to end the stream: the request to the provider is aborted with
+// the context, and the chunks it still sends are dropped.
+func (a *Accumulator) ReadStream(ctx context.Context, stream chan *responses.ResponseChunk, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	acc := responses.ResponseAccumulator{}
+	for {
+		select {
+		case <-ctx.Done():
+			go func() {
+				for range stream {
 				}
-			}
-
-			if chunk.OfOutputItemDone.Item.Type == "reasoning" {
-				var encryptedContent *string
-				if chunk.OfOutputItemDone.Item.EncryptedContent != nil {
-					encryptedContent = chunk.OfOutputItemDone.Item.EncryptedContent
+			}()
+			return nil, ctx.Err()
+
+		case chunk, ok := <-stream:
+			if !ok {
+				// The stream of an aborted request ends early, possibly with a failed response
+				if err := ctx.Err(); err != nil {
+					return nil, err
 				}
-
-				finalOutput = append(finalOutput, responses.OutputMessageUnion{
-					OfReasoning: &responses.ReasoningMessage{
-						ID:               chunk.OfOutputItemDone.Item.Id,
-						Summary:          chunk.OfOutputItemDone.Item.Summary,
-						EncryptedContent: encryptedContent,
-					},
-				})
-			}
-
-			if chunk.OfOutputItemDone.Item.Type == "function_call" {
-				finalOutput = append(finalOutput, responses.OutputMessageUnion{
-					OfFunctionCall: &responses.FunctionCallMessage{
-						ID:               chunk.OfOutputItemDone.Item.Id,
-						CallID:           *chunk.OfOutputItemDone.Item.CallID,
-						Name:             *chunk.OfOutputItemDone.Item.Name,
-						Arguments:        *chunk.OfOutputItemDone.Item.Arguments,
-						ThoughtSignature: chunk.OfOutputItemDone.Item.ThoughtSignature,
-					},
-				})
+				return acc.Response(), nil
 			}
-
-			if chunk.OfOutputItemDone.Item.Type == "image_generation_call" {
-				finalOutput = append(finalOutput, responses.OutputMessageUnion{
-					OfImageGenerationCall: &responses.ImageGenerationCallMessage{
-						ID:           chunk.OfOutputItemDone.Item.Id,
-						Status:       chunk.OfOutputItemDone.Item.Status,
-						Background:   *chunk.OfOutputItemDone.Item.Background,
-						OutputFormat: *chunk.OfOutputItemDone.Item.OutputFormat,
-						Quality:      *chunk.OfOutputItemDone.Item.Quality,
-						Size:         *chunk.OfOutputItemDone.Item.Size,
-						Result:       *chunk.OfOutputItemDone.Item.Result,
-					},
-				})
-			}
-
-		case "response.completed":
-			usage = &chunk.OfResponseCompleted.Response.Usage
+			cb(chunk)
+			acc.Add(chunk)
 		}
 	}
-
-	return &responses.Response{
-		Output: finalOutput,
-		Usage:  usage,
-	}, nil
 }
 
 func NilCallback(msg *responses.ResponseChunk) {
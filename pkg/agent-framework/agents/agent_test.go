@@ -0,0 +1,250 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/history"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/curaious/uno/pkg/sdk/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccumulator_ReadStream_Cancelled(t *testing.T) {
+	ctx, cancel := applyExecuteOptions(context.Background(), []ExecuteOption{WithTimeout(50 * time.Millisecond)})
+	defer cancel()
+
+	// The provider keeps streaming after the run was cancelled, until it notices the request was aborted
+	stream := make(chan *responses.ResponseChunk)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer close(stream)
+		stream <- &responses.ResponseChunk{}
+		<-ctx.Done()
+		for range 3 {
+			stream <- &responses.ResponseChunk{}
+		}
+	}()
+
+	received := 0
+	start := time.Now()
+	acc := Accumulator{}
+	resp, err := acc.ReadStream(ctx, stream, func(chunk *responses.ResponseChunk) {
+		received++
+	})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, resp)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.LessOrEqual(t, received, 2)
+
+	// The rest of the stream is drained, the provider doesn't block on it
+	select {
+	case <-produced:
+	case <-time.After(time.Second):
+		t.Fatal("the stream was not drained")
+	}
+}
+
+func TestApplyExecuteOptions(t *testing.T) {
+	ctx, cancel := applyExecuteOptions(context.Background(), nil)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel = applyExecuteOptions(context.Background(), []ExecuteOption{WithTimeout(time.Hour), WithDeadline(deadline)})
+	defer cancel()
+	got, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.Equal(t, deadline, got)
+}
+
+type toolCallingLLM struct{}
+
+func (toolCallingLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	return &responses.Response{
+		Output: []responses.OutputMessageUnion{{OfFunctionCall: &responses.FunctionCallMessage{
+			ID:        "fc_1",
+			CallID:    "call_1",
+			Name:      "explode",
+			Arguments: "{}",
+		}}},
+		Usage: &responses.Usage{},
+	}, nil
+}
+
+type panickingTool struct {
+	core.BaseTool
+}
+
+func (t *panickingTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
+	var m map[string]string
+	m["boom"] = "boom"
+	return nil, nil
+}
+
+func TestAgent_Execute_ToolPanic(t *testing.T) {
+	agent := NewAgent(&AgentOptions{
+		Name: "panicking",
+		Tools: []core.Tool{&panickingTool{BaseTool: core.BaseTool{
+			ToolUnion: responses.ToolUnion{OfFunction: &responses.FunctionTool{Name: "explode"}},
+		}}},
+	}).WithLLM(toolCallingLLM{})
+
+	var chunks []*responses.ResponseChunk
+	out, err := agent.Execute(context.Background(), &AgentInput{
+		Namespace: "default",
+		Messages:  []responses.InputMessageUnion{{OfEasyInput: &responses.EasyMessage{Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Go")}}}},
+		Callback: func(chunk *responses.ResponseChunk) {
+			chunks = append(chunks, chunk)
+		},
+	})
+
+	var failure *core.ToolFailure
+	require.ErrorAs(t, err, &failure)
+	assert.True(t, failure.Panicked)
+	assert.Contains(t, failure.Stack, "panickingTool")
+	assert.Equal(t, core.RunStatusError, out.Status)
+
+	// The run ends with its failure instead of stopping mid-stream
+	require.NotEmpty(t, chunks)
+	failed := chunks[len(chunks)-1].OfRunFailed
+	require.NotNil(t, failed)
+	assert.Equal(t, out.RunID, failed.RunState.Id)
+	assert.Equal(t, "failed", failed.RunState.Status)
+	assert.Equal(t, string(core.RunErrorPanic), failed.RunState.Error.Code)
+	assert.Equal(t, "tool 'explode' panicked: assignment to entry in nil map", failed.RunState.Error.Message)
+}
+
+// argumentsStreamingLLM streams the arguments of a call to the tool, until the context of the call is done
+type argumentsStreamingLLM struct{}
+
+func (argumentsStreamingLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	cb(&responses.ResponseChunk{OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+		Item: responses.ChunkOutputItemData{Type: "function_call", Id: "fc_1", CallID: utils.Ptr("call_1"), Name: utils.Ptr("explode")},
+	}})
+	for _, delta := range []string{`{"path":`, `"a.txt"}`} {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cb(&responses.ResponseChunk{OfFunctionCallArgumentsDelta: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDelta]{
+			ItemId: "fc_1",
+			Delta:  delta,
+		}})
+	}
+	return toolCallingLLM{}.NewStreamingResponses(ctx, in, cb)
+}
+
+type panickingArgsTool struct {
+	panickingTool
+	deltas int
+}
+
+func (t *panickingArgsTool) ArgumentsDelta(ctx context.Context, call *core.ToolCall, delta string) error {
+	t.deltas++
+	panic("unexpected delta")
+}
+
+func TestAgent_Execute_ToolArgumentsPanic(t *testing.T) {
+	tool := &panickingArgsTool{panickingTool: panickingTool{BaseTool: core.BaseTool{
+		ToolUnion: responses.ToolUnion{OfFunction: &responses.FunctionTool{Name: "explode"}},
+	}}}
+	agent := NewAgent(&AgentOptions{Name: "panicking", Tools: []core.Tool{tool}}).WithLLM(argumentsStreamingLLM{})
+
+	var chunks []*responses.ResponseChunk
+	out, err := agent.Execute(context.Background(), &AgentInput{
+		Namespace: "default",
+		Messages:  []responses.InputMessageUnion{{OfEasyInput: &responses.EasyMessage{Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Go")}}}},
+		Callback: func(chunk *responses.ResponseChunk) {
+			chunks = append(chunks, chunk)
+		},
+	})
+
+	var failure *core.ToolFailure
+	require.ErrorAs(t, err, &failure)
+	assert.True(t, failure.Panicked)
+	assert.Equal(t, core.RunStatusError, out.Status)
+
+	// The response is aborted on the first panic, the tool isn't called
+	assert.Equal(t, 1, tool.deltas)
+
+	require.NotEmpty(t, chunks)
+	failed := chunks[len(chunks)-1].OfRunFailed
+	require.NotNil(t, failed)
+	assert.Equal(t, string(core.RunErrorPanic), failed.RunState.Error.Code)
+	assert.Equal(t, "tool 'explode' panicked on the arguments delta: unexpected delta", failed.RunState.Error.Message)
+}
+
+func TestAgent_Execute_RuntimePanic(t *testing.T) {
+	type suspension struct{}
+	t.Cleanup(core.RegisterRuntimePanic(func(p any) bool {
+		_, ok := p.(suspension)
+		return ok
+	}))
+
+	agent := NewAgent(&AgentOptions{Name: "suspended"}).WithLLM(suspendingLLM(func() { panic(suspension{}) }))
+
+	// The panics of the durable runtimes go through to them
+	assert.PanicsWithValue(t, suspension{}, func() {
+		_, _ = agent.Execute(context.Background(), &AgentInput{
+			Namespace: "default",
+			Messages:  []responses.InputMessageUnion{{OfEasyInput: &responses.EasyMessage{Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Go")}}}},
+		})
+	})
+}
+
+type suspendingLLM func()
+
+func (l suspendingLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	l()
+	return nil, nil
+}
+
+type failingLLM struct{}
+
+func (failingLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	in.Model = "gpt-4.1"
+	return nil, errors.New("provider unavailable")
+}
+
+// savingPersistence keeps the meta of the last saved run
+type savingPersistence struct {
+	*adapters.InMemoryConversationPersistence
+	meta map[string]any
+}
+
+func (p *savingPersistence) SaveMessages(ctx context.Context, namespace, msgId, previousMsgId, conversationId string, messages []responses.InputMessageUnion, meta map[string]any) error {
+	p.meta = meta
+	return p.InMemoryConversationPersistence.SaveMessages(ctx, namespace, msgId, previousMsgId, conversationId, messages, meta)
+}
+
+func TestAgent_Execute_LLMCallFailed(t *testing.T) {
+	persistence := &savingPersistence{InMemoryConversationPersistence: adapters.NewInMemoryConversationPersistence()}
+	agent := NewAgent(&AgentOptions{
+		Name:    "failing",
+		History: history.NewConversationManager(persistence),
+	}).WithLLM(failingLLM{})
+
+	_, err := agent.Execute(context.Background(), &AgentInput{
+		Namespace: "default",
+		Messages:  []responses.InputMessageUnion{{OfEasyInput: &responses.EasyMessage{Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Go")}}}},
+	})
+	require.EqualError(t, err, "provider unavailable")
+
+	// The failed call is the last step of the saved run
+	state := core.LoadRunStateFromMeta(persistence.meta)
+	require.NotNil(t, state)
+	require.NotEmpty(t, state.Steps)
+	step := state.Steps[len(state.Steps)-1]
+	assert.Equal(t, core.StepRecordLLMCall, step.Type)
+	assert.Equal(t, "gpt-4.1", step.Model)
+	assert.Equal(t, "provider unavailable", step.Error)
+}
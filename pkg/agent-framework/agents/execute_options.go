@@ -0,0 +1,49 @@
+package agents
+
+import (
+	"context"
+	"time"
+)
+
+// ExecuteOption configures a single execution of an agent, see Agent.Execute
+type ExecuteOption func(*executeOptions)
+
+type executeOptions struct {
+	timeout  time.Duration
+	deadline time.Time
+}
+
+// WithTimeout stops the run once it has run for the duration, the run fails with context.DeadlineExceeded
+func WithTimeout(timeout time.Duration) ExecuteOption {
+	return func(o *executeOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithDeadline stops the run at the deadline, the run fails with context.DeadlineExceeded
+func WithDeadline(deadline time.Time) ExecuteOption {
+	return func(o *executeOptions) {
+		o.deadline = deadline
+	}
+}
+
+// applyExecuteOptions bounds the context of the run with the timeout and the deadline of the options, the earliest wins
+func applyExecuteOptions(ctx context.Context, opts []ExecuteOption) (context.Context, context.CancelFunc) {
+	o := &executeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cancelTimeout, cancelDeadline := context.CancelFunc(func() {}), context.CancelFunc(func() {})
+	if o.timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, o.timeout)
+	}
+	if !o.deadline.IsZero() {
+		ctx, cancelDeadline = context.WithDeadline(ctx, o.deadline)
+	}
+
+	return ctx, func() {
+		cancelDeadline()
+		cancelTimeout()
+	}
+}
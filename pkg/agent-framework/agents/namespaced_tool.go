@@ -0,0 +1,61 @@
+package agents
+
+import (
+	"context"
+	"strings"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// namespacedTool exposes a tool under another name and calls it with its original name
+type namespacedTool struct {
+	wrapped  core.Tool
+	original string
+	def      *responses.ToolUnion
+}
+
+func newNamespacedTool(ctx context.Context, tool core.Tool, name string) *namespacedTool {
+	def := *tool.Tool(ctx)
+	fn := *def.OfFunction
+	original := fn.Name
+	fn.Name = name
+	def.OfFunction = &fn
+
+	return &namespacedTool{
+		wrapped:  tool,
+		original: original,
+		def:      &def,
+	}
+}
+
+func (t *namespacedTool) Tool(ctx context.Context) *responses.ToolUnion {
+	return t.def
+}
+
+func (t *namespacedTool) NeedApproval() bool {
+	return t.wrapped.NeedApproval()
+}
+
+func (t *namespacedTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
+	call := *params
+	if params.FunctionCallMessage != nil {
+		msg := *params.FunctionCallMessage
+		msg.Name = t.original
+		call.FunctionCallMessage = &msg
+	}
+
+	return t.wrapped.Execute(ctx, &call)
+}
+
+// toolNamePrefix turns a server name into a tool name prefix, keeping the characters allowed in tool names
+func toolNamePrefix(server string) string {
+	prefix := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, server)
+
+	return prefix + "__"
+}
@@ -0,0 +1,108 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/history"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// IdentifiedLLM is implemented by the LLMs that know the provider and the model they call. The agent records them
+// on the runs, to pin the conversations and to sanitize their history when they switch to another LLM.
+type IdentifiedLLM interface {
+	ProviderName() llm.ProviderName
+	ModelName() string
+}
+
+func (l *WrappedLLM) ProviderName() llm.ProviderName {
+	if id, ok := l.llm.(IdentifiedLLM); ok {
+		return id.ProviderName()
+	}
+	return ""
+}
+
+func (l *WrappedLLM) ModelName() string {
+	if id, ok := l.llm.(IdentifiedLLM); ok {
+		return id.ModelName()
+	}
+	return ""
+}
+
+// switchOver checks that the conversation may call the LLM of the agent, and sanitizes the history of the runs that
+// called another LLM for it. Nothing is done when the LLM of the agent isn't known.
+func (e *Agent) switchOver(ctx context.Context, run *history.ConversationRunManager) error {
+	id, ok := e.llm.(IdentifiedLLM)
+	if !ok || id.ProviderName() == "" {
+		return nil
+	}
+	provider, model := string(id.ProviderName()), id.ModelName()
+
+	if previous := core.LoadRunStateFromMeta(run.GetMeta()); previous != nil && previous.Provider != "" {
+		if !e.providerPinning.Allows(previous.Provider, previous.Model, provider, model) {
+			return fmt.Errorf("%w: %s/%s, not %s/%s", core.ErrProviderPinned, previous.Provider, previous.Model, provider, model)
+		}
+	}
+
+	if sanitized := run.SanitizeHistory(provider, model, sanitizeHistoryForLLM); sanitized > 0 {
+		slog.InfoContext(ctx, "Sanitized the history of the conversation for another LLM", slog.String("provider", provider), slog.String("model", model), slog.Int("runs", sanitized))
+	}
+
+	run.RunState.Provider = provider
+	run.RunState.Model = model
+	return nil
+}
+
+// sanitizeHistoryForLLM removes from the messages of a run the artifacts another LLM rejects: the encrypted
+// reasoning and the thought signatures, bound to the model producing them, and the file IDs of the provider when the
+// provider changes. Files only referenced by their ID are replaced by a note saying so.
+func sanitizeHistoryForLLM(messages []responses.InputMessageUnion, sameProvider bool) []responses.InputMessageUnion {
+	out := make([]responses.InputMessageUnion, 0, len(messages))
+	for _, msg := range messages {
+		switch {
+		case msg.OfReasoning != nil:
+			continue
+
+		case msg.OfFunctionCall != nil && msg.OfFunctionCall.ThoughtSignature != nil:
+			functionCall := *msg.OfFunctionCall
+			functionCall.ThoughtSignature = nil
+			msg.OfFunctionCall = &functionCall
+
+		case msg.OfInputMessage != nil && !sameProvider:
+			msg.OfInputMessage = withoutFileIDs(msg.OfInputMessage)
+		}
+
+		out = append(out, msg)
+	}
+
+	return out
+}
+
+// withoutFileIDs returns the message without the file IDs of its images
+func withoutFileIDs(msg *responses.InputMessage) *responses.InputMessage {
+	out := *msg
+	out.Content = make(responses.InputContent, 0, len(msg.Content))
+	for _, content := range msg.Content {
+		if content.OfInputImage != nil && content.OfInputImage.FileID != nil {
+			if content.OfInputImage.ImageURL == nil {
+				content = responses.InputContentUnion{
+					OfInputText: &responses.InputTextContent{
+						Type: "input_text",
+						Text: fmt.Sprintf("[Image %s, uploaded to another provider, is no longer available]", *content.OfInputImage.FileID),
+					},
+				}
+			} else {
+				image := *content.OfInputImage
+				image.FileID = nil
+				content.OfInputImage = &image
+			}
+		}
+
+		out.Content = append(out.Content, content)
+	}
+
+	return &out
+}
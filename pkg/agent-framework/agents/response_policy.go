@@ -0,0 +1,108 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ResponsePolicyOptions enforce a policy on the final answers of the agent. An answer violating it is discarded and
+// the model is asked to revise it, up to MaxRevisions times, after which the last answer is kept as it is.
+type ResponsePolicyOptions struct {
+	Policy core.ResponsePolicy
+
+	// Judge checks the language and the tone of the answers, required for a policy with either. The length is
+	// checked by the agent.
+	Judge core.ResponseJudge
+
+	// MaxRevisions is the number of times an answer is revised (default 2)
+	MaxRevisions int
+}
+
+func (o *ResponsePolicyOptions) maxRevisions() int {
+	if o.MaxRevisions <= 0 {
+		return 2
+	}
+	return o.MaxRevisions
+}
+
+// checkResponsePolicy returns how the text of the output messages violates the response policy, and the usage of the
+// judge. A judge that fails lets the answer through.
+func (e *Agent) checkResponsePolicy(ctx context.Context, messages []responses.InputMessageUnion) ([]string, *responses.Usage) {
+	var text strings.Builder
+	for _, msg := range messages {
+		if msg.OfOutputMessage == nil {
+			continue
+		}
+		for _, content := range msg.OfOutputMessage.Content {
+			if content.OfOutputText != nil {
+				text.WriteString(content.OfOutputText.Text)
+			}
+		}
+	}
+	if text.Len() == 0 {
+		return nil, nil
+	}
+
+	policy := e.responsePolicy.Policy
+	var violations []string
+	var usage *responses.Usage
+	if length := utf8.RuneCountInString(text.String()); policy.MaxLength > 0 && length > policy.MaxLength {
+		violations = append(violations, fmt.Sprintf("The answer is %d characters long, over the limit of %d characters.", length, policy.MaxLength))
+	}
+
+	if e.responsePolicy.Judge != nil && (policy.Language != "" || policy.Tone != "") {
+		judgement, err := e.responsePolicy.Judge.Judge(ctx, text.String(), policy)
+		if err != nil {
+			slog.WarnContext(ctx, "response policy judgement failed", slog.String("agent", e.Name), slog.Any("error", err))
+		} else {
+			violations = append(violations, judgement.Violations...)
+			usage = judgement.Usage
+		}
+	}
+
+	return violations, usage
+}
+
+// hasFunctionCalls tells whether the output calls tools, the answer being final otherwise
+func hasFunctionCalls(output []responses.OutputMessageUnion) bool {
+	for _, msg := range output {
+		if msg.OfFunctionCall != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectAnswer streams the violations of the rejected output messages, and returns the messages asking the model to
+// revise them, to follow the history in the next LLM call
+func (e *Agent) rejectAnswer(messages []responses.InputMessageUnion, violations []string, revision int, cb func(chunk *responses.ResponseChunk)) []responses.InputMessageUnion {
+	var itemIDs []string
+	for _, msg := range messages {
+		if msg.OfOutputMessage != nil {
+			itemIDs = append(itemIDs, msg.OfOutputMessage.ID)
+		}
+	}
+
+	cb(&responses.ResponseChunk{
+		OfPolicyViolation: &responses.ChunkPolicyViolation[constants.ChunkTypePolicyViolation]{
+			ItemIDs:    itemIDs,
+			Violations: violations,
+			Revision:   revision,
+		},
+	})
+
+	request := "Your answer doesn't meet the requirements of the answers:\n- " + strings.Join(violations, "\n- ") +
+		"\nWrite the answer again, meeting all of them. Only write the revised answer, without mentioning this request."
+
+	return append(messages, responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
+		Role:    constants.RoleDeveloper,
+		Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: request}}},
+	}})
+}
@@ -0,0 +1,174 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// AgentRuntime decides where and how an agent run is executed. The same agent definition can be run inline,
+// on a bounded worker pool, or durably on Restate or Temporal by setting AgentOptions.Runtime.
+type AgentRuntime interface {
+	Run(ctx context.Context, agent *Agent, in *AgentInput) (*AgentOutput, error)
+}
+
+// LocalRuntime executes the run inline, on the calling goroutine. It is used when no runtime is set.
+type LocalRuntime struct{}
+
+func NewLocalRuntime() *LocalRuntime {
+	return &LocalRuntime{}
+}
+
+func (r *LocalRuntime) Run(ctx context.Context, agent *Agent, in *AgentInput) (*AgentOutput, error) {
+	return agent.ExecuteWithExecutor(ctx, in, in.Callback)
+}
+
+var ErrRuntimeStopped = errors.New("agent runtime is stopped")
+
+type PooledRuntimeOptions struct {
+	// Workers is the number of runs executed concurrently. Defaults to 16.
+	Workers int
+
+	// QueueSize is the number of runs that can wait for a worker before Run blocks. Defaults to Workers.
+	QueueSize int
+}
+
+type pooledRun struct {
+	ctx    context.Context
+	agent  *Agent
+	in     *AgentInput
+	result chan pooledResult
+}
+
+type pooledResult struct {
+	out *AgentOutput
+	err error
+}
+
+// PooledRuntime executes runs on a bounded pool of workers, so that a burst of runs can't exhaust the process.
+// A run that panics fails with an error instead of taking the process down.
+// When all workers are busy and the queue is full, Run waits until a worker is free or the context is done.
+type PooledRuntime struct {
+	queue    chan *pooledRun
+	stop     chan struct{} // Closed when Stop is called, to release the runs waiting for room in the queue
+	done     chan struct{} // Closed once the workers exited
+	mu       sync.RWMutex  // Held by the runs being queued, and by Stop to close the queue
+	stopped  bool
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewPooledRuntime(opts PooledRuntimeOptions) *PooledRuntime {
+	if opts.Workers <= 0 {
+		opts.Workers = 16
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = opts.Workers
+	}
+
+	r := &PooledRuntime{
+		queue: make(chan *pooledRun, opts.QueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	for range opts.Workers {
+		r.wg.Add(1)
+		go r.worker()
+	}
+
+	return r
+}
+
+// Run queues the run and waits for its result. It returns early when the context is done, the run then goes on
+// until the agent honours the context.
+func (r *PooledRuntime) Run(ctx context.Context, agent *Agent, in *AgentInput) (*AgentOutput, error) {
+	run := &pooledRun{
+		ctx:    ctx,
+		agent:  agent,
+		in:     in,
+		result: make(chan pooledResult, 1),
+	}
+
+	if err := r.enqueue(ctx, run); err != nil {
+		return nil, err
+	}
+
+	select {
+	case res := <-run.result:
+		return res.out, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.done:
+		// The workers drain the queue before they exit, the result of the run is already there
+		select {
+		case res := <-run.result:
+			return res.out, res.err
+		default:
+			return nil, ErrRuntimeStopped
+		}
+	}
+}
+
+// enqueue queues the run unless the runtime is stopped. The queue is only closed once no run is being queued.
+func (r *PooledRuntime) enqueue(ctx context.Context, run *pooledRun) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.stopped {
+		return ErrRuntimeStopped
+	}
+
+	select {
+	case <-r.stop:
+		return ErrRuntimeStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.queue <- run:
+		return nil
+	}
+}
+
+// Stop stops accepting runs and waits for the runs in progress and in the queue to finish
+func (r *PooledRuntime) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+
+		r.mu.Lock()
+		r.stopped = true
+		close(r.queue)
+		r.mu.Unlock()
+
+		r.wg.Wait()
+		close(r.done)
+	})
+}
+
+func (r *PooledRuntime) worker() {
+	defer r.wg.Done()
+
+	// The queue is closed by Stop, once the runs already accepted are drained
+	for run := range r.queue {
+		run.result <- r.execute(run)
+	}
+}
+
+func (r *PooledRuntime) execute(run *pooledRun) (res pooledResult) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(run.ctx, "Agent run panicked", slog.String("agent", run.agent.Name), slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+			res = pooledResult{err: fmt.Errorf("agent %s panicked: %v", run.agent.Name, p)}
+		}
+	}()
+
+	if err := run.ctx.Err(); err != nil {
+		return pooledResult{err: err}
+	}
+
+	out, err := run.agent.ExecuteWithExecutor(run.ctx, run.in, run.in.Callback)
+	return pooledResult{out: out, err: err}
+}
@@ -0,0 +1,91 @@
+package agents
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingLLM answers once released, or fails when the context is done
+type blockingLLM chan struct{}
+
+func (l blockingLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
+	select {
+	case <-l:
+		return &responses.Response{Usage: &responses.Usage{}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func runInput() *AgentInput {
+	return &AgentInput{
+		Namespace: "default",
+		Messages:  []responses.InputMessageUnion{{OfEasyInput: &responses.EasyMessage{Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Go")}}}},
+	}
+}
+
+func TestPooledRuntime_Stop(t *testing.T) {
+	release := make(blockingLLM)
+	close(release)
+
+	runtime := NewPooledRuntime(PooledRuntimeOptions{Workers: 2})
+	agent := NewAgent(&AgentOptions{Name: "pooled", Runtime: runtime}).WithLLM(release)
+
+	// The runs racing with Stop either run or are rejected, none of them waits forever
+	var wg sync.WaitGroup
+	results := make(chan error, 50)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := agent.Execute(context.Background(), runInput())
+			results <- err
+		}()
+	}
+	runtime.Stop()
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a run waited on the stopped runtime")
+	}
+
+	close(results)
+	for err := range results {
+		if err != nil {
+			assert.ErrorIs(t, err, ErrRuntimeStopped)
+		}
+	}
+
+	_, err := agent.Execute(context.Background(), runInput())
+	assert.ErrorIs(t, err, ErrRuntimeStopped)
+}
+
+func TestPooledRuntime_Run_Cancelled(t *testing.T) {
+	release := make(blockingLLM)
+	defer close(release)
+
+	runtime := NewPooledRuntime(PooledRuntimeOptions{Workers: 1})
+	defer runtime.Stop()
+	agent := NewAgent(&AgentOptions{Name: "pooled", Runtime: runtime}).WithLLM(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := agent.Execute(ctx, runInput())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
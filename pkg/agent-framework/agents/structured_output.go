@@ -0,0 +1,102 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// OutputSchemaError is returned when the streamed structured output can no longer match the output schema
+type OutputSchemaError struct {
+	Violations []string
+}
+
+func (e *OutputSchemaError) Error() string {
+	return fmt.Sprintf("structured output does not match the output schema: %s", strings.Join(e.Violations, "; "))
+}
+
+// structuredOutputStream parses the output text of a response as it streams in and emits the structured
+// output parsed so far after every delta. It cancels the response as soon as the output goes off-schema.
+type structuredOutputStream struct {
+	schema    map[string]any
+	next      func(chunk *responses.ResponseChunk)
+	cancel    context.CancelFunc
+	texts     map[string]*strings.Builder
+	violation *OutputSchemaError
+}
+
+func newStructuredOutputStream(schema map[string]any, next func(chunk *responses.ResponseChunk), cancel context.CancelFunc) *structuredOutputStream {
+	return &structuredOutputStream{
+		schema: schema,
+		next:   next,
+		cancel: cancel,
+		texts:  map[string]*strings.Builder{},
+	}
+}
+
+func (s *structuredOutputStream) Push(chunk *responses.ResponseChunk) {
+	if s.violation != nil {
+		return
+	}
+
+	s.next(chunk)
+
+	delta := chunk.OfOutputTextDelta
+	if delta == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s:%d", delta.ItemId, delta.ContentIndex)
+	text, ok := s.texts[key]
+	if !ok {
+		text = &strings.Builder{}
+		s.texts[key] = text
+	}
+	text.WriteString(delta.Delta)
+
+	output, complete, err := core.ParsePartialJSON(stripCodeFence(text.String()))
+	if err != nil {
+		s.abort([]string{"output is not valid JSON"})
+		return
+	}
+
+	if violations := core.ValidatePartialOutput(s.schema, output); len(violations) > 0 {
+		s.abort(violations)
+		return
+	}
+
+	if output == nil {
+		return
+	}
+
+	s.next(&responses.ResponseChunk{
+		OfStructuredOutputPartial: &responses.ChunkStructuredOutput[constants.ChunkTypeStructuredOutputPartial]{
+			ItemId:       delta.ItemId,
+			OutputIndex:  delta.OutputIndex,
+			ContentIndex: delta.ContentIndex,
+			Output:       output,
+			Complete:     complete,
+		},
+	})
+}
+
+func (s *structuredOutputStream) abort(violations []string) {
+	s.violation = &OutputSchemaError{Violations: violations}
+	s.cancel()
+}
+
+// stripCodeFence drops a markdown code fence some models wrap JSON output in
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimPrefix(text, "json")
+	return strings.TrimSuffix(strings.TrimSpace(text), "```")
+}
@@ -0,0 +1,114 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/agent-framework/history"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// compareSummaryShadow repeats an LLM call with the history a summarizer in shadow mode would have left, and
+// records how the answer and the token usage differ from the call made with the full history. The repeated
+// call is not streamed and its answer is discarded, failing it does not fail the run.
+func (e *Agent) compareSummaryShadow(ctx context.Context, run *history.ConversationRunManager, req *responses.Request, resp *responses.Response) {
+	summary, messages := run.TakeShadowSummary()
+	if summary == nil {
+		return
+	}
+
+	summarized := len(req.Input.OfInputMessageList) - len(messages)
+	if summary.Summary != nil {
+		summarized++
+	}
+
+	record := core.SummaryShadowRecord{
+		SummarizedMessages: summarized,
+		SummaryUsage:       summary.Usage,
+		Usage:              resp.Usage,
+	}
+
+	shadowReq := *req
+	shadowReq.Input = responses.InputUnion{OfInputMessageList: messages}
+	shadowResp, err := e.llm.NewStreamingResponses(ctx, &shadowReq, func(chunk *responses.ResponseChunk) {})
+	if err != nil {
+		record.Error = err.Error()
+		slog.WarnContext(ctx, "summary shadow call failed", slog.String("agent", e.Name), slog.Any("error", err))
+		run.RunState.RecordSummaryShadow(record)
+		return
+	}
+
+	record.ShadowUsage = shadowResp.Usage
+	record.AnswerSimilarity = answerSimilarity(outputText(resp.Output), outputText(shadowResp.Output))
+	record.ToolCallsMatch = slices.Equal(toolCallNames(resp.Output), toolCallNames(shadowResp.Output))
+	run.RunState.RecordSummaryShadow(record)
+
+	attrs := []any{
+		slog.String("agent", e.Name),
+		slog.Int("summarized_messages", record.SummarizedMessages),
+		slog.Float64("answer_similarity", record.AnswerSimilarity),
+		slog.Bool("tool_calls_match", record.ToolCallsMatch),
+	}
+	if resp.Usage != nil && shadowResp.Usage != nil {
+		attrs = append(attrs, slog.Int("input_tokens", resp.Usage.InputTokens), slog.Int("shadow_input_tokens", shadowResp.Usage.InputTokens))
+	}
+	slog.InfoContext(ctx, "summary shadow compared", attrs...)
+}
+
+// answerSimilarity returns the Jaccard similarity of the sets of words of two answers
+func answerSimilarity(a, b string) float64 {
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+func outputText(output []responses.OutputMessageUnion) string {
+	var sb strings.Builder
+	for _, out := range output {
+		if out.OfOutputMessage == nil {
+			continue
+		}
+		for _, content := range out.OfOutputMessage.Content {
+			if content.OfOutputText != nil {
+				sb.WriteString(content.OfOutputText.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// toolCallNames returns the sorted names of the tools called in the output
+func toolCallNames(output []responses.OutputMessageUnion) []string {
+	names := []string{}
+	for _, out := range output {
+		if out.OfFunctionCall != nil {
+			names = append(names, out.OfFunctionCall.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,116 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// toolArgumentsStream passes the argument deltas of the function calls of a response to the tools streaming their
+// arguments, as the response streams in. A tool panicking on a delta aborts the response and fails the run.
+type toolArgumentsStream struct {
+	ctx   context.Context
+	tools []core.Tool
+	call  core.ToolCall // Agent and conversation of the calls
+	next  func(chunk *responses.ResponseChunk)
+	abort context.CancelFunc
+	calls map[string]*streamedToolCall // By item ID
+	panic *core.ToolFailure            // Of the tool that panicked on a delta
+}
+
+type streamedToolCall struct {
+	tool   core.StreamingArgsTool
+	call   *core.ToolCall
+	failed bool
+}
+
+// newToolArgumentsStream returns nil when none of the tools streams its arguments
+func newToolArgumentsStream(ctx context.Context, tools []core.Tool, call core.ToolCall, next func(chunk *responses.ResponseChunk), abort context.CancelFunc) *toolArgumentsStream {
+	streams := false
+	for _, tool := range tools {
+		if _, ok := tool.(core.StreamingArgsTool); ok {
+			streams = true
+			break
+		}
+	}
+	if !streams {
+		return nil
+	}
+
+	return &toolArgumentsStream{
+		ctx:   ctx,
+		tools: tools,
+		call:  call,
+		next:  next,
+		abort: abort,
+		calls: map[string]*streamedToolCall{},
+	}
+}
+
+func (s *toolArgumentsStream) Push(chunk *responses.ResponseChunk) {
+	s.next(chunk)
+	if s.panic != nil {
+		return
+	}
+
+	switch {
+	case chunk.OfOutputItemAdded != nil && chunk.OfOutputItemAdded.Item.Type == "function_call":
+		item := chunk.OfOutputItemAdded.Item
+		if item.Name == nil {
+			return
+		}
+
+		tool, ok := findTool(s.ctx, s.tools, *item.Name).(core.StreamingArgsTool)
+		if !ok {
+			return
+		}
+
+		msg := &responses.FunctionCallMessage{ID: item.Id, Name: *item.Name}
+		if item.CallID != nil {
+			msg.CallID = *item.CallID
+		}
+
+		call := s.call
+		call.FunctionCallMessage = msg
+		s.calls[item.Id] = &streamedToolCall{tool: tool, call: &call}
+
+	case chunk.OfFunctionCallArgumentsDelta != nil:
+		delta := chunk.OfFunctionCallArgumentsDelta
+		c, ok := s.calls[delta.ItemId]
+		if !ok || c.failed {
+			return
+		}
+
+		c.call.Arguments += delta.Delta
+		if err := s.argumentsDelta(c, delta.Delta); err != nil {
+			slog.WarnContext(s.ctx, "tool failed on the arguments delta", slog.String("tool_name", c.call.Name), slog.String("call_id", c.call.CallID), slog.Any("error", err))
+			c.failed = true
+		}
+	}
+}
+
+// argumentsDelta passes the delta to the tool, turning a panic of the tool into the failure of the response
+func (s *toolArgumentsStream) argumentsDelta(c *streamedToolCall, delta string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if core.IsRuntimePanic(p) {
+				panic(p)
+			}
+
+			c.failed = true
+			s.panic = &core.ToolFailure{
+				Message:  fmt.Sprintf("tool '%s' panicked on the arguments delta: %v", c.call.Name, p),
+				Attempts: 1,
+				Stack:    string(debug.Stack()),
+				Panicked: true,
+			}
+			s.abort()
+		}
+	}()
+
+	return c.tool.ArgumentsDelta(s.ctx, c.call, delta)
+}
@@ -0,0 +1,38 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// recordToolDeadLetter records the failed call of a tool. The failure is only logged when the store fails, the dead
+// letters don't stop the runs.
+func (e *Agent) recordToolDeadLetter(ctx context.Context, runID string, conversationID string, in *AgentInput, toolCall responses.FunctionCallMessage, failure *core.ToolFailure) {
+	slog.WarnContext(ctx, "tool call failed",
+		slog.String("tool_name", toolCall.Name),
+		slog.Int("attempts", failure.Attempts),
+		slog.String("error", failure.Message))
+
+	if e.toolDeadLetters == nil {
+		return
+	}
+
+	err := e.toolDeadLetters.Record(ctx, &core.ToolDeadLetter{
+		AgentName:      e.Name,
+		RunID:          runID,
+		ConversationID: conversationID,
+		Namespace:      in.Namespace,
+		CallID:         toolCall.CallID,
+		ToolName:       toolCall.Name,
+		Arguments:      toolCall.Arguments,
+		Error:          failure.Message,
+		Stack:          failure.Stack,
+		Attempts:       failure.Attempts,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record the dead letter of a tool call", slog.String("tool_name", toolCall.Name), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,121 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+// Statuses of a tool invocation
+const (
+	ToolInvocationCompleted        = "completed"
+	ToolInvocationArgumentsInvalid = "arguments_invalid"
+	ToolInvocationApprovalRequired = "approval_required"
+	ToolInvocationQuotaExceeded    = "quota_exceeded"
+	ToolInvocationFailed           = "failed"
+)
+
+// ErrToolNotFound is returned when the agent has no function tool of the name invoked
+var ErrToolNotFound = errors.New("tool not found")
+
+// ToolInvocation is a call of a tool of the agent made outside of a run, e.g. to debug the tool
+type ToolInvocation struct {
+	Name      string
+	Arguments string // JSON object of the arguments
+
+	// Approved must be set to call a tool requiring approval, the caller is the human approving the call
+	Approved bool
+
+	Namespace string
+
+	// ConversationID is the conversation the call is counted for by the quotas per conversation. The calls without
+	// conversation are counted each as their own conversation.
+	ConversationID string
+
+	RunContext map[string]any
+}
+
+// ToolInvocationResult is the outcome of a tool invocation. Output is what the model would have received: the output
+// of the tool, or the error of the arguments, of the quota or of the failure of the tool.
+type ToolInvocationResult struct {
+	Status     string                               `json:"status"`
+	CallID     string                               `json:"call_id"`
+	Arguments  string                               `json:"arguments"` // The arguments passed to the tool, after their repair
+	Output     *responses.FunctionCallOutputMessage `json:"output,omitempty"`
+	Violations []string                             `json:"violations,omitempty"`
+	Error      string                               `json:"error,omitempty"`
+	DurationMs int64                                `json:"duration_ms"`
+}
+
+// InvokeTool calls a function tool of the agent, native or of its MCP servers, as the agent would during a run: the
+// arguments are repaired and validated, the approval policy and the quotas of the tool apply.
+func (e *Agent) InvokeTool(ctx context.Context, in *ToolInvocation) (*ToolInvocationResult, error) {
+	ctx, span := tracer.Start(ctx, "Agent.InvokeTool")
+	defer span.End()
+
+	mcpTools, err := e.PrepareMCPTools(ctx, in.RunContext)
+	if err != nil {
+		return nil, err
+	}
+
+	tool := findTool(ctx, append(e.tools, mcpTools...), in.Name)
+	if tool == nil {
+		return nil, ErrToolNotFound
+	}
+
+	toolCall := responses.FunctionCallMessage{
+		CallID:    "call_" + uuid.NewString(),
+		Name:      in.Name,
+		Arguments: in.Arguments,
+	}
+	result := &ToolInvocationResult{CallID: toolCall.CallID, Arguments: toolCall.Arguments}
+
+	if tool.NeedApproval() && !in.Approved {
+		result.Status = ToolInvocationApprovalRequired
+		return result, nil
+	}
+
+	violations := e.validateToolCall(ctx, tool, &toolCall)
+	result.Arguments = toolCall.Arguments
+	if len(violations) > 0 {
+		result.Status = ToolInvocationArgumentsInvalid
+		result.Violations = violations
+		result.Output = invalidArgumentsOutput(toolCall, violations)
+		return result, nil
+	}
+
+	conversationID := in.ConversationID
+	if conversationID == "" {
+		conversationID = uuid.NewString()
+	}
+
+	quotaInput := &AgentInput{Namespace: in.Namespace, RunContext: in.RunContext}
+	if quota, used := e.consumeToolQuotas(ctx, toolCall.Name, 1, quotaInput, conversationID); quota != nil {
+		result.Status = ToolInvocationQuotaExceeded
+		result.Output = quotaExceededOutput(toolCall, quota, used)
+		return result, nil
+	}
+
+	start := time.Now()
+	result.Output, err = core.CallTool(ctx, tool, &core.ToolCall{
+		FunctionCallMessage: &toolCall,
+		AgentName:           e.Name,
+		Namespace:           in.Namespace,
+		ConversationID:      in.ConversationID,
+	})
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		span.RecordError(err)
+		result.Status = ToolInvocationFailed
+		result.Error = err.Error()
+		result.Output = core.AsToolFailure(err).Output
+		return result, nil
+	}
+
+	result.Status = ToolInvocationCompleted
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// toolQuotaScopeKey returns the conversation or the user the calls of a tool are counted for. Users are identified
+// by the user_id of the run context, or else by the namespace of the conversation.
+func toolQuotaScopeKey(quota core.ToolQuota, in *AgentInput, conversationID string) string {
+	if quota.Scope != core.ToolQuotaScopeUser {
+		return "conversation:" + conversationID
+	}
+
+	if userID, ok := in.RunContext["user_id"].(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "namespace:" + in.Namespace
+}
+
+// consumeToolQuotas counts n calls of the tool against its quotas, and returns the quota they exceed if any. The
+// calls are allowed when the counter fails, quotas don't stop the runs.
+func (e *Agent) consumeToolQuotas(ctx context.Context, tool string, n int, in *AgentInput, conversationID string) (*core.ToolQuota, int) {
+	if e.toolQuotaCounter == nil {
+		return nil, 0
+	}
+
+	for _, quota := range e.toolQuotas {
+		if quota.Tool != tool {
+			continue
+		}
+
+		used, ok, err := e.toolQuotaCounter.Consume(ctx, quota, toolQuotaScopeKey(quota, in, conversationID), n)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to count tool call against its quota", slog.String("tool", tool), slog.Any("error", err))
+			continue
+		}
+		if !ok {
+			return &quota, used
+		}
+	}
+
+	return nil, 0
+}
+
+// availableToolDefs drops the provider tools whose quotas are used up from the tools of the LLM call, the provider
+// runs them without going through the agent
+func (e *Agent) availableToolDefs(ctx context.Context, toolDefs []responses.ToolUnion, in *AgentInput, conversationID string) []responses.ToolUnion {
+	if e.toolQuotaCounter == nil || len(e.toolQuotas) == 0 {
+		return toolDefs
+	}
+
+	available := make([]responses.ToolUnion, 0, len(toolDefs))
+	for _, toolDef := range toolDefs {
+		if toolDef.OfFunction == nil && e.toolQuotaExhausted(ctx, core.ToolQuotaName(&toolDef), in, conversationID) {
+			continue
+		}
+		available = append(available, toolDef)
+	}
+
+	return available
+}
+
+// toolQuotaExhausted reports whether a quota of the tool has no calls left
+func (e *Agent) toolQuotaExhausted(ctx context.Context, tool string, in *AgentInput, conversationID string) bool {
+	for _, quota := range e.toolQuotas {
+		if quota.Tool != tool {
+			continue
+		}
+
+		used, err := e.toolQuotaCounter.Used(ctx, quota, toolQuotaScopeKey(quota, in, conversationID))
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read tool quota", slog.String("tool", tool), slog.Any("error", err))
+			continue
+		}
+		if used >= quota.Limit {
+			return true
+		}
+	}
+
+	return false
+}
+
+// consumeProviderToolQuotas counts the calls of provider tools made by the provider during an LLM call
+func (e *Agent) consumeProviderToolQuotas(ctx context.Context, output []responses.OutputMessageUnion, in *AgentInput, conversationID string) {
+	if e.toolQuotaCounter == nil || len(e.toolQuotas) == 0 {
+		return
+	}
+
+	calls := map[string]int{}
+	for _, msg := range output {
+		switch {
+		case msg.OfImageGenerationCall != nil:
+			calls[core.ToolQuotaImageGeneration]++
+		case msg.OfWebSearchCall != nil:
+			calls[core.ToolQuotaWebSearch]++
+		case msg.OfCodeInterpreterCall != nil:
+			calls[core.ToolQuotaCodeExecution]++
+		}
+	}
+
+	// The calls are counted one by one, so that the counters reach the limits when they exceed them, and in a fixed
+	// order for the durable runtimes replaying them
+	for _, tool := range []string{core.ToolQuotaImageGeneration, core.ToolQuotaWebSearch, core.ToolQuotaCodeExecution} {
+		for range calls[tool] {
+			if quota, used := e.consumeToolQuotas(ctx, tool, 1, in, conversationID); quota != nil {
+				// The provider already made the call, the tool is dropped from the next LLM calls
+				slog.InfoContext(ctx, "Provider tool calls exceed their quota", slog.String("tool", tool), slog.Int("used", used), slog.Int("limit", quota.Limit))
+				break
+			}
+		}
+	}
+}
+
+// quotaExceededOutput is the result of a tool call that exceeds a quota of the tool
+func quotaExceededOutput(toolCall responses.FunctionCallMessage, quota *core.ToolQuota, used int) *responses.FunctionCallOutputMessage {
+	per := string(quota.Scope)
+	period := "total"
+	if quota.Period != "" {
+		period = string(quota.Period)
+		per += " and per " + period
+	}
+
+	buf, _ := sonic.Marshal(map[string]any{
+		"error":   "quota_exceeded",
+		"message": fmt.Sprintf("The tool '%s' can't be called anymore, its quota of %d calls per %s is used up. Don't call it again, answer without it.", toolCall.Name, quota.Limit, per),
+		"tool":    quota.Tool,
+		"scope":   quota.Scope,
+		"limit":   quota.Limit,
+		"period":  period,
+		"used":    used,
+	})
+
+	return &responses.FunctionCallOutputMessage{
+		ID:     toolCall.ID,
+		CallID: toolCall.CallID,
+		Output: responses.FunctionCallOutputContentUnion{
+			OfString: utils.Ptr(string(buf)),
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Directions of the translation records
+const (
+	TranslationInput  = "input"
+	TranslationOutput = "output"
+)
+
+// TranslationOptions makes the agent work in a single language whatever the language of the user. The messages
+// of the user are translated to Language before they reach the model, and the answers of the model back to the
+// language of the user. The history keeps the texts the model worked with, the run state keeps both texts.
+type TranslationOptions struct {
+	Translator core.Translator
+
+	// Language is the working language of the agent, as an ISO 639-1 code (default "en")
+	Language string
+}
+
+func (o *TranslationOptions) language() string {
+	if o.Language == "" {
+		return "en"
+	}
+	return o.Language
+}
+
+// translateInput translates the texts of the messages of the user to the working language of the agent. It
+// returns the messages to run the agent with, the detected language of the user, and the records of the
+// translated texts. A text that fails to translate is passed through as it is.
+func (e *Agent) translateInput(ctx context.Context, messages []responses.InputMessageUnion) ([]responses.InputMessageUnion, string, []core.TranslationRecord) {
+	to := e.translation.language()
+	language := ""
+	var records []core.TranslationRecord
+
+	translate := func(itemID string, text string) string {
+		if strings.TrimSpace(text) == "" {
+			return text
+		}
+
+		translation, err := e.translation.Translator.Translate(ctx, text, "", to)
+		if err != nil {
+			slog.WarnContext(ctx, "input translation failed", slog.String("agent", e.Name), slog.Any("error", err))
+			return text
+		}
+
+		if language == "" {
+			language = translation.From
+		}
+		if translation.From == translation.To {
+			return text
+		}
+
+		records = append(records, core.TranslationRecord{
+			Direction:  TranslationInput,
+			ItemID:     itemID,
+			From:       translation.From,
+			To:         translation.To,
+			Original:   text,
+			Translated: translation.Text,
+			Usage:      translation.Usage,
+		})
+		return translation.Text
+	}
+
+	translated := make([]responses.InputMessageUnion, len(messages))
+	for i, msg := range messages {
+		translated[i] = msg
+
+		switch {
+		case msg.OfEasyInput != nil && msg.OfEasyInput.Role == constants.RoleUser:
+			easy := *msg.OfEasyInput
+			if easy.Content.OfString != nil {
+				text := translate(easy.ID, *easy.Content.OfString)
+				easy.Content.OfString = &text
+			} else {
+				easy.Content.OfInputMessageList = translateInputContent(easy.ID, easy.Content.OfInputMessageList, translate)
+			}
+			translated[i] = responses.InputMessageUnion{OfEasyInput: &easy}
+
+		case msg.OfInputMessage != nil && msg.OfInputMessage.Role == constants.RoleUser:
+			input := *msg.OfInputMessage
+			input.Content = translateInputContent(input.ID, input.Content, translate)
+			translated[i] = responses.InputMessageUnion{OfInputMessage: &input}
+		}
+	}
+
+	return translated, language, records
+}
+
+func translateInputContent(itemID string, content responses.InputContent, translate func(itemID string, text string) string) responses.InputContent {
+	translated := make(responses.InputContent, len(content))
+	for i, part := range content {
+		translated[i] = part
+		if part.OfInputText != nil {
+			text := *part.OfInputText
+			text.Text = translate(itemID, text.Text)
+			translated[i].OfInputText = &text
+		}
+	}
+	return translated
+}
+
+// translateOutput translates the output messages of an LLM call to the language of the user and streams the
+// translations. It returns the messages with the translated texts, the messages in the history are left in the
+// working language. A message that fails to translate is returned as it is.
+func (e *Agent) translateOutput(ctx context.Context, messages []responses.InputMessageUnion, runState *core.RunState, cb func(chunk *responses.ResponseChunk)) []responses.InputMessageUnion {
+	from := e.translation.language()
+	to := runState.UserLanguage
+
+	translated := make([]responses.InputMessageUnion, len(messages))
+	for i, msg := range messages {
+		translated[i] = msg
+		if msg.OfOutputMessage == nil {
+			continue
+		}
+
+		output := *msg.OfOutputMessage
+		output.Content = make(responses.OutputContent, len(msg.OfOutputMessage.Content))
+		var texts []string
+		failed := false
+		for j, part := range msg.OfOutputMessage.Content {
+			output.Content[j] = part
+			if part.OfOutputText == nil || strings.TrimSpace(part.OfOutputText.Text) == "" {
+				continue
+			}
+
+			translation, err := e.translation.Translator.Translate(ctx, part.OfOutputText.Text, from, to)
+			if err != nil {
+				slog.WarnContext(ctx, "output translation failed", slog.String("agent", e.Name), slog.Any("error", err))
+				failed = true
+				break
+			}
+
+			runState.Translations = append(runState.Translations, core.TranslationRecord{
+				Direction:  TranslationOutput,
+				ItemID:     output.ID,
+				From:       from,
+				To:         to,
+				Original:   part.OfOutputText.Text,
+				Translated: translation.Text,
+				Usage:      translation.Usage,
+			})
+
+			text := *part.OfOutputText
+			text.Text = translation.Text
+			output.Content[j].OfOutputText = &text
+			texts = append(texts, translation.Text)
+		}
+		if failed || len(texts) == 0 {
+			continue
+		}
+
+		translated[i] = responses.InputMessageUnion{OfOutputMessage: &output}
+		cb(&responses.ResponseChunk{
+			OfTranslation: &responses.ChunkTranslation[constants.ChunkTypeTranslation]{
+				ItemId:   output.ID,
+				Language: to,
+				Text:     strings.Join(texts, ""),
+			},
+		})
+	}
+
+	return translated
+}
+
+// translatesOutput reports whether the answers of the model must be translated for the user of the run
+func (e *Agent) translatesOutput(runState *core.RunState) bool {
+	return e.translation != nil && e.output == nil && runState.UserLanguage != "" && runState.UserLanguage != e.translation.language()
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ErrAwaitingDurableApproval is returned when resuming with an approval message a run that a durable runtime is
+// still waiting on, its approval is given to the runtime instead
+var ErrAwaitingDurableApproval = errors.New("the run is waiting for its approval in a durable runtime")
+
+// ApprovalGate lets a durable runtime keep a run waiting for the approval of its tool calls, for as long as it takes,
+// instead of ending the run when it pauses. The run then resumes exactly where it stopped.
+type ApprovalGate interface {
+	// RequestApproval opens a wait for the decision on the tool calls of a run. The ID identifies the wait to
+	// whoever decides, and wait suspends the run until the decision is given.
+	RequestApproval(ctx context.Context, runID string, toolCalls []responses.FunctionCallMessage) (id string, wait func() (*responses.FunctionCallApprovalResponseMessage, error), err error)
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+)
+
+var errPartialJSONSyntax = errors.New("invalid JSON")
+
+// ParsePartialJSON parses JSON that may be cut off at any point, as it is while being streamed. Open strings,
+// arrays and objects hold what has been received so far, while keys, numbers and literals that are cut off
+// are left out. It returns the value, whether the text is a complete JSON value, and an error when the
+// text can never become valid JSON. Text that holds no value yet parses as nil.
+func ParsePartialJSON(text string) (any, bool, error) {
+	p := &partialJSONParser{text: strings.TrimSpace(text)}
+	if p.text == "" {
+		return nil, false, nil
+	}
+
+	value, complete, _, err := p.value()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if complete {
+		p.skipSpace()
+		if p.pos < len(p.text) {
+			return nil, false, errPartialJSONSyntax
+		}
+	}
+
+	return value, complete, nil
+}
+
+// ValidatePartialOutput validates the structured output parsed so far against the output schema. Only the
+// rules that the rest of the output cannot fix are checked, see validateValue.
+func ValidatePartialOutput(schema map[string]any, value any) []string {
+	if len(schema) == 0 || value == nil {
+		return nil
+	}
+
+	var normalized map[string]any
+	if buf, err := sonic.Marshal(schema); err != nil || sonic.Unmarshal(buf, &normalized) != nil {
+		return nil
+	}
+
+	var violations []string
+	validateValue("output", normalized, value, true, &violations)
+	return violations
+}
+
+type partialJSONParser struct {
+	text string
+	pos  int
+}
+
+func (p *partialJSONParser) skipSpace() {
+	for p.pos < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// value parses the value at the current position. present is false when nothing of the value can be used yet.
+func (p *partialJSONParser) value() (value any, complete bool, present bool, err error) {
+	p.skipSpace()
+	if p.pos >= len(p.text) {
+		return nil, false, false, nil
+	}
+
+	switch c := p.text[p.pos]; {
+	case c == '{':
+		v, complete, err := p.object()
+		return v, complete, true, err
+	case c == '[':
+		v, complete, err := p.array()
+		return v, complete, true, err
+	case c == '"':
+		v, complete := p.string()
+		return v, complete, true, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return p.number()
+	default:
+		return p.literal()
+	}
+}
+
+func (p *partialJSONParser) object() (map[string]any, bool, error) {
+	obj := map[string]any{}
+	p.pos++ // {
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return obj, false, nil
+		}
+		if p.text[p.pos] == '}' {
+			p.pos++
+			return obj, true, nil
+		}
+		if p.text[p.pos] != '"' {
+			return nil, false, errPartialJSONSyntax
+		}
+
+		key, complete := p.string()
+		if !complete {
+			return obj, false, nil
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return obj, false, nil
+		}
+		if p.text[p.pos] != ':' {
+			return nil, false, errPartialJSONSyntax
+		}
+		p.pos++
+
+		value, complete, present, err := p.value()
+		if err != nil {
+			return nil, false, err
+		}
+		if present {
+			obj[key] = value
+		}
+		if !complete {
+			return obj, false, nil
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return obj, false, nil
+		}
+		switch p.text[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return obj, true, nil
+		default:
+			return nil, false, errPartialJSONSyntax
+		}
+	}
+}
+
+func (p *partialJSONParser) array() ([]any, bool, error) {
+	arr := []any{}
+	p.pos++ // [
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return arr, false, nil
+		}
+		if p.text[p.pos] == ']' {
+			p.pos++
+			return arr, true, nil
+		}
+
+		value, complete, present, err := p.value()
+		if err != nil {
+			return nil, false, err
+		}
+		if present {
+			arr = append(arr, value)
+		}
+		if !complete {
+			return arr, false, nil
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return arr, false, nil
+		}
+		switch p.text[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return arr, true, nil
+		default:
+			return nil, false, errPartialJSONSyntax
+		}
+	}
+}
+
+// string parses a string, an unterminated one holds the characters received so far
+func (p *partialJSONParser) string() (string, bool) {
+	start := p.pos
+	p.pos++ // "
+
+	cut := -1 // Start of an escape sequence that is cut off
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case '\\':
+			if p.pos+1 >= len(p.text) || p.text[p.pos+1] == 'u' && p.pos+6 > len(p.text) {
+				cut = p.pos
+			}
+			p.pos += 2
+		case '"':
+			p.pos++
+			var s string
+			if err := sonic.UnmarshalString(p.text[start:p.pos], &s); err != nil {
+				return p.text[start+1 : p.pos-1], true
+			}
+			return s, true
+		default:
+			p.pos++
+		}
+	}
+
+	p.pos = len(p.text)
+	raw := p.text[start:]
+	if cut >= 0 {
+		raw = p.text[start:cut]
+	}
+
+	// Drop a multi-byte character that is cut off
+	for i := len(raw) - 1; i > 0 && i >= len(raw)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(raw[i]) {
+			if !utf8.FullRuneInString(raw[i:]) {
+				raw = raw[:i]
+			}
+			break
+		}
+	}
+
+	var s string
+	if err := sonic.UnmarshalString(raw+`"`, &s); err != nil {
+		return raw[1:], false
+	}
+	return s, false
+}
+
+func (p *partialJSONParser) number() (any, bool, bool, error) {
+	start := p.pos
+	for p.pos < len(p.text) && strings.IndexByte("+-0123456789.eE", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+
+	raw := p.text[start:p.pos]
+	complete := p.pos < len(p.text)
+	if !complete {
+		// The number may continue, use the part that already is a number
+		raw = strings.TrimRight(raw, "+-.eE")
+	}
+
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		if complete {
+			return nil, false, false, errPartialJSONSyntax
+		}
+		return nil, false, false, nil
+	}
+
+	return n, complete, true, nil
+}
+
+func (p *partialJSONParser) literal() (any, bool, bool, error) {
+	rest := p.text[p.pos:]
+	for literal, value := range map[string]any{"true": true, "false": false, "null": nil} {
+		if strings.HasPrefix(rest, literal) {
+			p.pos += len(literal)
+			return value, true, true, nil
+		}
+		if strings.HasPrefix(literal, rest) {
+			p.pos = len(p.text)
+			return nil, false, false, nil
+		}
+	}
+
+	return nil, false, false, errPartialJSONSyntax
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+)
+
+// ErrProviderPinned is returned when a run of a conversation calls another LLM than the one the conversation is
+// pinned to
+var ErrProviderPinned = errors.New("the conversation is pinned to another provider or model")
+
+// ProviderPinning is what a conversation keeps of the LLM of its runs. The history of a conversation carries
+// artifacts only its provider accepts, e.g. encrypted reasoning or file IDs, the history is sanitized for the new LLM
+// when a run calls another one than the previous run.
+type ProviderPinning string
+
+const (
+	// ProviderPinningNone lets the runs of a conversation switch provider and model
+	ProviderPinningNone ProviderPinning = ""
+
+	// ProviderPinningProvider keeps the conversation with the provider of its runs, the model may change
+	ProviderPinningProvider ProviderPinning = "provider"
+
+	// ProviderPinningModel keeps the conversation with the provider and the model of its runs
+	ProviderPinningModel ProviderPinning = "model"
+)
+
+// Allows reports whether a conversation whose runs called the provider and the model from may switch to to
+func (p ProviderPinning) Allows(fromProvider, fromModel, toProvider, toModel string) bool {
+	switch p {
+	case ProviderPinningProvider:
+		return fromProvider == toProvider
+	case ProviderPinningModel:
+		return fromProvider == toProvider && fromModel == toModel
+	default:
+		return true
+	}
+}
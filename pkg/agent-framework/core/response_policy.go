@@ -0,0 +1,26 @@
+package core
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ResponsePolicy constrains the final answers of an agent. The answers are checked once generated, and the ones
+// violating the policy are sent back to the model to be revised, rather than relying on the instruction alone.
+type ResponsePolicy struct {
+	Language  string `json:"language,omitempty"`   // Language of the answers, as an ISO 639-1 code
+	Tone      string `json:"tone,omitempty"`       // Tone of the answers, e.g. "formal" or "friendly"
+	MaxLength int    `json:"max_length,omitempty"` // Maximum number of characters of the answers
+}
+
+// ResponseJudgement lists how an answer violates a policy, it is empty when the answer complies
+type ResponseJudgement struct {
+	Violations []string         `json:"violations"`
+	Usage      *responses.Usage `json:"usage,omitempty"` // Usage of the judgement
+}
+
+type ResponseJudge interface {
+	// Judge checks the text against the language and the tone of the policy, the constraints that need a model
+	Judge(ctx context.Context, text string, policy ResponsePolicy) (*ResponseJudgement, error)
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// RunErrorCode classifies the failures of runs
+type RunErrorCode string
+
+const (
+	RunErrorPanic      RunErrorCode = "panic"       // The agent or one of its tools panicked
+	RunErrorCancelled  RunErrorCode = "cancelled"   // The context of the run was cancelled
+	RunErrorTimeout    RunErrorCode = "timeout"     // The deadline of the run was exceeded
+	RunErrorToolFailed RunErrorCode = "tool_failed" // A tool call failed without an answer for the model
+	RunErrorFailed     RunErrorCode = "failed"      // Any other error, e.g. of the provider or of the history
+)
+
+// RunError is why a run failed, recorded in its run state. The stack is the stack of the panics.
+type RunError struct {
+	Code    RunErrorCode `json:"code"`
+	Message string       `json:"message"`
+	Stack   string       `json:"stack,omitempty"`
+}
+
+// NewRunError classifies the error a run failed with
+func NewRunError(err error) *RunError {
+	runErr := &RunError{Code: RunErrorFailed, Message: err.Error()}
+
+	var panicErr *PanicError
+	var failure *ToolFailure
+	switch {
+	case errors.As(err, &panicErr):
+		runErr.Code, runErr.Stack = RunErrorPanic, panicErr.Stack
+	case errors.As(err, &failure) && failure.Panicked:
+		runErr.Code, runErr.Stack = RunErrorPanic, failure.Stack
+	case errors.As(err, &failure):
+		runErr.Code = RunErrorToolFailed
+	case errors.Is(err, context.Canceled):
+		runErr.Code = RunErrorCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		runErr.Code = RunErrorTimeout
+	}
+
+	return runErr
+}
+
+// PanicError is the error of a run that panicked
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+// NewPanicError returns the error of the recovered value, with the stack of the panic. It must be called by the
+// deferred function that recovered it.
+func NewPanicError(value any) *PanicError {
+	return &PanicError{Value: value, Stack: string(debug.Stack())}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+var (
+	runtimePanicsMu sync.RWMutex
+	runtimePanics   = map[int]func(value any) bool{}
+	runtimePanicID  int
+)
+
+// RegisterRuntimePanic declares the panics a durable runtime unwinds the runs with, e.g. to suspend them, which the
+// agents must not recover. It is meant to be called from the init function of the runtime, the returned function
+// removes the declaration, e.g. at the end of a test.
+func RegisterRuntimePanic(match func(value any) bool) (unregister func()) {
+	runtimePanicsMu.Lock()
+	defer runtimePanicsMu.Unlock()
+
+	runtimePanicID++
+	id := runtimePanicID
+	runtimePanics[id] = match
+
+	return func() {
+		runtimePanicsMu.Lock()
+		defer runtimePanicsMu.Unlock()
+		delete(runtimePanics, id)
+	}
+}
+
+// IsRuntimePanic reports whether the recovered value is a panic of a durable runtime, to panic again with
+func IsRuntimePanic(value any) bool {
+	runtimePanicsMu.RLock()
+	defer runtimePanicsMu.RUnlock()
+
+	for _, match := range runtimePanics {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// RunEventEnvelope wraps a chunk of a run in the streams of the agent server and of the SDK, so that clients parse
+// the streams of the converse endpoint, of the stream replays and of durable agents alike
+type RunEventEnvelope struct {
+	// RunID is the run the chunk belongs to, empty for the chunks that aren't part of a run
+	RunID string `json:"run_id,omitempty"`
+	// Seq numbers the chunks of the stream from 1. Chunks merged or dropped by a pipeline leave gaps.
+	Seq int64 `json:"seq"`
+	// ID is the offset to resume the stream from, for the recorded streams
+	ID      string                   `json:"id,omitempty"`
+	Type    string                   `json:"type"`
+	Payload *responses.ResponseChunk `json:"payload"`
+}
+
+// NewRunEventEnvelope wraps a chunk with the run, sequence number and offset of the event it was read from
+func NewRunEventEnvelope(event *RunEvent, chunk *responses.ResponseChunk) *RunEventEnvelope {
+	return &RunEventEnvelope{
+		RunID:   event.RunID,
+		Seq:     event.Seq,
+		ID:      event.Offset,
+		Type:    chunk.ChunkType(),
+		Payload: chunk,
+	}
+}
+
+// RunIDOf returns the ID of the run of the chunks carrying the run state, and "" for the other chunks
+func RunIDOf(chunk *responses.ResponseChunk) string {
+	switch {
+	case chunk.OfRunCreated != nil:
+		return chunk.OfRunCreated.RunState.Id
+	case chunk.OfRunInProgress != nil:
+		return chunk.OfRunInProgress.RunState.Id
+	case chunk.OfRunPaused != nil:
+		return chunk.OfRunPaused.RunState.Id
+	case chunk.OfRunCompleted != nil:
+		return chunk.OfRunCompleted.RunState.Id
+	case chunk.OfRunFailed != nil:
+		return chunk.OfRunFailed.RunState.Id
+	case chunk.OfRunEstimated != nil:
+		return chunk.OfRunEstimated.RunID
+	}
+	return ""
+}
+
+// RunEventSequencer numbers the chunks of a run that isn't recorded, and tags them with the ID of their run once
+// it is known
+type RunEventSequencer struct {
+	seq   int64
+	runID string
+}
+
+// Next returns the event of the next chunk
+func (s *RunEventSequencer) Next(chunk *responses.ResponseChunk) *RunEvent {
+	if id := RunIDOf(chunk); id != "" {
+		s.runID = id
+	}
+	s.seq++
+
+	return &RunEvent{Seq: s.seq, RunID: s.runID, Chunk: chunk}
+}
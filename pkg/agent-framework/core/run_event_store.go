@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+var (
+	// ErrRunStreamNotFound is returned when reading a stream that was never opened, or that expired
+	ErrRunStreamNotFound = errors.New("run stream not found")
+
+	// ErrInvalidRunStreamOffset is returned when reading a stream from an offset the store didn't issue
+	ErrInvalidRunStreamOffset = errors.New("invalid run stream offset")
+)
+
+// RunEvent is a chunk of a run stream along with its offset in the stream. Seq numbers the chunks of the stream
+// from 1, and RunID is the run the chunk belongs to, empty for the chunks that aren't part of a run.
+type RunEvent struct {
+	Offset string                   `json:"offset"`
+	Seq    int64                    `json:"seq"`
+	RunID  string                   `json:"run_id,omitempty"`
+	Chunk  *responses.ResponseChunk `json:"chunk"`
+}
+
+// RunEventStore records the chunks of runs, so that any number of subscribers can attach to the stream of a run
+// while it is in progress. Unlike a StreamBroker, a subscriber that attaches late does not miss the chunks
+// published before: each subscriber reads from its own offset and the chunks recorded since are backfilled
+// before the live ones.
+type RunEventStore interface {
+	// Open creates the stream, it must be called before the first chunk is appended
+	Open(ctx context.Context, stream string) error
+
+	// Append records a chunk of a run at the end of the stream and returns its offset. The run ID is empty for
+	// the chunks that aren't part of a run.
+	Append(ctx context.Context, stream string, runID string, chunk *responses.ResponseChunk) (string, error)
+
+	// Read returns the events recorded after the offset, followed by the live ones. An empty offset reads from
+	// the start of the stream. The returned channel is closed once the stream is closed or the context is done.
+	Read(ctx context.Context, stream string, after string) (<-chan *RunEvent, error)
+
+	// Close signals that no more chunks will be appended. The stream is kept for a while, so that subscribers
+	// can still read it.
+	Close(ctx context.Context, stream string) error
+}
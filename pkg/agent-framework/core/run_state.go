@@ -1,6 +1,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm/responses"
 )
@@ -13,6 +15,7 @@ const (
 	StepExecuteTools  Step = "execute_tools"
 	StepAwaitApproval Step = "await_approval"
 	StepComplete      Step = "complete"
+	StepFailed        Step = "failed"
 )
 
 // RunStatus represents the overall status of a run
@@ -25,13 +28,75 @@ const (
 	RunStatusError      RunStatus = "error"
 )
 
+// StepRecordType identifies the kind of work captured by a StepRecord
+type StepRecordType string
+
+const (
+	StepRecordLLMCall  StepRecordType = "llm_call"
+	StepRecordToolCall StepRecordType = "tool_call"
+	StepRecordApproval StepRecordType = "approval"
+)
+
+// StepRecord captures the timing and outcome of a single step within a run.
+// Records are persisted alongside the run state so that the run can be inspected later.
+type StepRecord struct {
+	Type       StepRecordType    `json:"type"`
+	Loop       int               `json:"loop"`
+	CallID     string            `json:"call_id,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Model      string            `json:"model,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	DurationMs int64             `json:"duration_ms"`
+	Usage      *responses.Usage  `json:"usage,omitempty"`
+	Timing     *responses.Timing `json:"timing,omitempty"`
+	Approved   []string          `json:"approved,omitempty"`
+	Rejected   []string          `json:"rejected,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// SummaryShadowRecord compares an LLM call made with the full history against the same call made with the
+// history a summarizer in shadow mode would have left
+type SummaryShadowRecord struct {
+	Loop               int              `json:"loop"`
+	SummarizedMessages int              `json:"summarized_messages"`
+	SummaryUsage       *responses.Usage `json:"summary_usage,omitempty"` // Usage of generating the summary
+	Usage              *responses.Usage `json:"usage,omitempty"`         // Usage of the call with the full history
+	ShadowUsage        *responses.Usage `json:"shadow_usage,omitempty"`  // Usage of the call with the summarized history
+	AnswerSimilarity   float64          `json:"answer_similarity"`       // Overlap of the words of both answers, from 0 to 1
+	ToolCallsMatch     bool             `json:"tool_calls_match"`        // Both answers call the same tools
+	Error              string           `json:"error,omitempty"`
+}
+
+// TranslationRecord keeps both texts of a message translated between the language of the user and the working
+// language of the agent
+type TranslationRecord struct {
+	Direction  string           `json:"direction"` // "input" for the messages of the user, "output" for the answers
+	ItemID     string           `json:"item_id,omitempty"`
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Original   string           `json:"original"`
+	Translated string           `json:"translated"`
+	Usage      *responses.Usage `json:"usage,omitempty"`
+}
+
 // RunState encapsulates the execution state of an agent run
 type RunState struct {
+	AgentName             string                          `json:"agent_name,omitempty"`
+	Provider              string                          `json:"provider,omitempty"` // Provider and model of the LLM the run calls
+	Model                 string                          `json:"model,omitempty"`
 	CurrentStep           Step                            `json:"current_step"`
 	LoopIteration         int                             `json:"loop_iteration"`
 	Usage                 responses.Usage                 `json:"usage"`
 	PendingToolCalls      []responses.FunctionCallMessage `json:"pending_tool_calls,omitempty"`
 	ToolsAwaitingApproval []responses.FunctionCallMessage `json:"tools_awaiting_approval,omitempty"`
+	ApprovalID            string                          `json:"approval_id,omitempty"` // Set while a durable runtime waits for the approval of the pending tool calls
+	Steps                 []StepRecord                    `json:"steps,omitempty"`
+	SummaryShadows        []SummaryShadowRecord           `json:"summary_shadows,omitempty"`
+	Provenance            []responses.Provenance          `json:"provenance,omitempty"`
+	UserLanguage          string                          `json:"user_language,omitempty"`
+	Translations          []TranslationRecord             `json:"translations,omitempty"`
+	Meta                  map[string]any                  `json:"meta,omitempty"`  // Set by the caller of the run, e.g. the effective config of the agent
+	Error                 *RunError                       `json:"error,omitempty"` // Why the run failed
 }
 
 // NextStep returns what the agent should do next
@@ -63,6 +128,15 @@ func (s *RunState) TransitionToComplete() {
 	s.PendingToolCalls = nil
 }
 
+// TransitionToFailed ends the run with its error and clears pending tools
+func (s *RunState) TransitionToFailed(err *RunError) {
+	s.CurrentStep = StepFailed
+	s.Error = err
+	s.PendingToolCalls = nil
+	s.ToolsAwaitingApproval = nil
+	s.ApprovalID = ""
+}
+
 // ClearPendingTools clears the pending tool calls
 func (s *RunState) ClearPendingTools() {
 	s.PendingToolCalls = nil
@@ -80,6 +154,18 @@ func (s *RunState) PromoteAwaitingToApproval() {
 	s.ToolsAwaitingApproval = nil
 }
 
+// RecordStep appends a step record, stamping it with the current loop iteration
+func (s *RunState) RecordStep(record StepRecord) {
+	record.Loop = s.LoopIteration
+	s.Steps = append(s.Steps, record)
+}
+
+// RecordSummaryShadow appends a summary shadow record, stamping it with the current loop iteration
+func (s *RunState) RecordSummaryShadow(record SummaryShadowRecord) {
+	record.Loop = s.LoopIteration
+	s.SummaryShadows = append(s.SummaryShadows, record)
+}
+
 // IsPaused returns true if the state is awaiting approval
 func (s *RunState) IsPaused() bool {
 	return s.CurrentStep == StepAwaitApproval
@@ -90,6 +176,11 @@ func (s *RunState) IsComplete() bool {
 	return s.CurrentStep == StepComplete
 }
 
+// IsFailed returns true if the run failed, it can't be continued
+func (s *RunState) IsFailed() bool {
+	return s.CurrentStep == StepFailed
+}
+
 // NewRunState creates initial state for a fresh run
 func NewRunState() *RunState {
 	return &RunState{
@@ -109,6 +200,15 @@ func (s *RunState) ToMeta(traceid string) map[string]any {
 		"traceid":        traceid,
 	}
 
+	if s.AgentName != "" {
+		runStateMap["agent_name"] = s.AgentName
+	}
+
+	if s.Provider != "" {
+		runStateMap["provider"] = s.Provider
+		runStateMap["model"] = s.Model
+	}
+
 	if len(s.PendingToolCalls) > 0 {
 		runStateMap["pending_tool_calls"] = s.PendingToolCalls
 	}
@@ -117,6 +217,38 @@ func (s *RunState) ToMeta(traceid string) map[string]any {
 		runStateMap["tools_awaiting_approval"] = s.ToolsAwaitingApproval
 	}
 
+	if s.ApprovalID != "" {
+		runStateMap["approval_id"] = s.ApprovalID
+	}
+
+	if len(s.Steps) > 0 {
+		runStateMap["steps"] = s.Steps
+	}
+
+	if len(s.SummaryShadows) > 0 {
+		runStateMap["summary_shadows"] = s.SummaryShadows
+	}
+
+	if len(s.Provenance) > 0 {
+		runStateMap["provenance"] = s.Provenance
+	}
+
+	if s.UserLanguage != "" {
+		runStateMap["user_language"] = s.UserLanguage
+	}
+
+	if len(s.Translations) > 0 {
+		runStateMap["translations"] = s.Translations
+	}
+
+	if len(s.Meta) > 0 {
+		runStateMap["meta"] = s.Meta
+	}
+
+	if s.Error != nil {
+		runStateMap["error"] = s.Error
+	}
+
 	return map[string]any{
 		"run_state": runStateMap,
 	}
@@ -130,6 +262,8 @@ func (s *RunState) getStatus() RunStatus {
 		return RunStatusPaused
 	case StepComplete:
 		return RunStatusCompleted
+	case StepFailed:
+		return RunStatusError
 	default:
 		return RunStatusError
 	}
@@ -150,6 +284,18 @@ func LoadRunStateFromMeta(meta map[string]any) *RunState {
 		Usage: responses.Usage{},
 	}
 
+	if agentName, ok := runStateData["agent_name"].(string); ok {
+		state.AgentName = agentName
+	}
+
+	if provider, ok := runStateData["provider"].(string); ok {
+		state.Provider = provider
+	}
+
+	if model, ok := runStateData["model"].(string); ok {
+		state.Model = model
+	}
+
 	if currentStep, ok := runStateData["current_step"].(string); ok {
 		state.CurrentStep = Step(currentStep)
 	}
@@ -182,5 +328,57 @@ func LoadRunStateFromMeta(meta map[string]any) *RunState {
 		}
 	}
 
+	if approvalID, ok := runStateData["approval_id"].(string); ok {
+		state.ApprovalID = approvalID
+	}
+
+	if steps, ok := runStateData["steps"]; ok {
+		// Parse step records using JSON marshaling
+		stepsBytes, err := sonic.Marshal(steps)
+		if err == nil {
+			sonic.Unmarshal(stepsBytes, &state.Steps)
+		}
+	}
+
+	if shadows, ok := runStateData["summary_shadows"]; ok {
+		// Parse summary shadow records using JSON marshaling
+		shadowsBytes, err := sonic.Marshal(shadows)
+		if err == nil {
+			sonic.Unmarshal(shadowsBytes, &state.SummaryShadows)
+		}
+	}
+
+	if provenance, ok := runStateData["provenance"]; ok {
+		// Parse provenance labels using JSON marshaling
+		provenanceBytes, err := sonic.Marshal(provenance)
+		if err == nil {
+			sonic.Unmarshal(provenanceBytes, &state.Provenance)
+		}
+	}
+
+	if userLanguage, ok := runStateData["user_language"].(string); ok {
+		state.UserLanguage = userLanguage
+	}
+
+	if runErr, ok := runStateData["error"]; ok {
+		// Parse the error of the run using JSON marshaling
+		runErrBytes, err := sonic.Marshal(runErr)
+		if err == nil {
+			sonic.Unmarshal(runErrBytes, &state.Error)
+		}
+	}
+
+	if translations, ok := runStateData["translations"]; ok {
+		// Parse translation records using JSON marshaling
+		translationsBytes, err := sonic.Marshal(translations)
+		if err == nil {
+			sonic.Unmarshal(translationsBytes, &state.Translations)
+		}
+	}
+
+	if meta, ok := runStateData["meta"].(map[string]any); ok {
+		state.Meta = meta
+	}
+
 	return state
 }
@@ -22,3 +22,13 @@ type StreamBroker interface {
 	// This should close all subscriber channels for the given channel.
 	Close(ctx context.Context, channel string) error
 }
+
+// ResumableStreamBroker is a StreamBroker that keeps the chunks of a channel, so that subscribers can resume
+// from the ID of the last chunk they received.
+type ResumableStreamBroker interface {
+	StreamBroker
+
+	// SubscribeFrom returns the chunks of the channel after the given ID along with their IDs, followed by the
+	// live ones. An empty ID reads the channel from its first chunk.
+	SubscribeFrom(ctx context.Context, channel string, after string) (<-chan *RunEvent, error)
+}
@@ -10,8 +10,10 @@ import (
 type SummaryResult struct {
 	Summary                 *responses.InputMessageUnion // The summary message
 	MessagesToKeep          []responses.InputMessageUnion
-	LastSummarizedMessageID string // ID of the last message that was summarized
-	SummaryID               string // Unique ID for the summary (generated if empty)
+	LastSummarizedMessageID string           // ID of the last message that was summarized
+	SummaryID               string           // Unique ID for the summary (generated if empty)
+	Usage                   *responses.Usage // Usage of generating the summary
+	Shadow                  bool             // The summary is only compared against the full history, not applied
 }
 
 type HistorySummarizer interface {
@@ -19,3 +21,19 @@ type HistorySummarizer interface {
 	// If summarization is not needed, returns a result with KeepFromIndex = -1.
 	Summarize(ctx context.Context, msgIdToRunId map[string]string, messages []responses.InputMessageUnion, usage *responses.Usage) (*SummaryResult, error)
 }
+
+type pinnedRunsKey struct{}
+
+// ContextWithPinnedRuns tells the summarizers the runs pinned in the history, whose content they shouldn't drop
+func ContextWithPinnedRuns(ctx context.Context, runIDs []string) context.Context {
+	if len(runIDs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, pinnedRunsKey{}, runIDs)
+}
+
+// PinnedRunsFromContext returns the runs pinned in the history being summarized
+func PinnedRunsFromContext(ctx context.Context) []string {
+	runIDs, _ := ctx.Value(pinnedRunsKey{}).([]string)
+	return runIDs
+}
@@ -20,6 +20,19 @@ type Tool interface {
 	NeedApproval() bool
 }
 
+// StreamingArgsTool is implemented by the tools receiving the arguments of their calls as the model streams them, to
+// start working before the arguments are complete, e.g. validating or writing a large file. Execute is still called
+// with the complete arguments, unless the call is rejected, has invalid arguments or exceeds a quota: the work started
+// on the deltas of a call that is never executed must be discarded.
+type StreamingArgsTool interface {
+	Tool
+
+	// ArgumentsDelta receives the next delta of the arguments of a call, the call carries the arguments streamed so far
+	// including the delta. It holds the stream of the model, and must return quickly. The tool receives no more deltas
+	// of the call once it returns an error.
+	ArgumentsDelta(ctx context.Context, call *ToolCall, delta string) error
+}
+
 type BaseTool struct {
 	ToolUnion        responses.ToolUnion
 	RequiresApproval bool
@@ -0,0 +1,331 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// ValidateToolArguments validates the arguments of a function call against the parameters schema of the tool.
+// It supports the subset of JSON schema used by tool definitions: type, properties, required,
+// additionalProperties, items, enum, const, and the length and range keywords. It returns one message per
+// violation, or nil when the arguments are valid JSON and either match the schema or the tool declares none.
+func ValidateToolArguments(parameters map[string]any, arguments string) []string {
+	var args any = map[string]any{}
+	if strings.TrimSpace(arguments) != "" {
+		if err := sonic.Unmarshal([]byte(arguments), &args); err != nil {
+			return []string{fmt.Sprintf("arguments are not valid JSON: %s", err.Error())}
+		}
+	}
+
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	// Normalize the schema, tools may declare it with typed values such as []string
+	var schema map[string]any
+	if buf, err := sonic.Marshal(parameters); err != nil || sonic.Unmarshal(buf, &schema) != nil {
+		return nil
+	}
+
+	var violations []string
+	validateValue("arguments", schema, args, false, &violations)
+	return violations
+}
+
+// validateValue appends the violations of value against schema. A partial value is a prefix of the final
+// one, only the rules that a longer value cannot satisfy are checked: types and disallowed properties.
+func validateValue(path string, schema map[string]any, value any, partial bool, violations *[]string) {
+	add := func(format string, a ...any) {
+		*violations = append(*violations, path+" "+fmt.Sprintf(format, a...))
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			add("must be of type %s, got %s", strings.Join(types, " or "), jsonType(value))
+			return
+		}
+	}
+
+	if partial {
+		switch v := value.(type) {
+		case map[string]any:
+			properties, _ := schema["properties"].(map[string]any)
+			for _, k := range sortedKeys(v) {
+				if propSchema, ok := properties[k].(map[string]any); ok {
+					validateValue(path+"."+k, propSchema, v[k], true, violations)
+				} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					*violations = append(*violations, fmt.Sprintf("%s.%s is not an allowed property", path, k))
+				} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+					validateValue(path+"."+k, additional, v[k], true, violations)
+				}
+			}
+		case []any:
+			if items, ok := schema["items"].(map[string]any); ok {
+				for i, item := range v {
+					validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, true, violations)
+				}
+			}
+		}
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !containsValue(enum, value) {
+		options := make([]string, len(enum))
+		for i, e := range enum {
+			buf, _ := sonic.Marshal(e)
+			options[i] = string(buf)
+		}
+		add("must be one of %s", strings.Join(options, ", "))
+	}
+
+	if c, ok := schema["const"]; ok && !containsValue([]any{c}, value) {
+		buf, _ := sonic.Marshal(c)
+		add("must be %s", string(buf))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name := fmt.Sprint(r)
+				if _, ok := v[name]; !ok {
+					*violations = append(*violations, fmt.Sprintf("%s.%s is required", path, name))
+				}
+			}
+		}
+
+		for _, k := range sortedKeys(v) {
+			if propSchema, ok := properties[k].(map[string]any); ok {
+				validateValue(path+"."+k, propSchema, v[k], false, violations)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*violations = append(*violations, fmt.Sprintf("%s.%s is not an allowed property", path, k))
+				}
+			case map[string]any:
+				validateValue(path+"."+k, additional, v[k], false, violations)
+			}
+		}
+
+	case []any:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			add("must have at least %v items", n)
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			add("must have at most %v items", n)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, false, violations)
+			}
+		}
+
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			add("must be at least %v characters long", n)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			add("must be at most %v characters long", n)
+		}
+
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			add("must be >= %v", n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			add("must be <= %v", n)
+		}
+		if n, ok := schemaNumber(schema["exclusiveMinimum"]); ok && v <= n {
+			add("must be > %v", n)
+		}
+		if n, ok := schemaNumber(schema["exclusiveMaximum"]); ok && v >= n {
+			add("must be < %v", n)
+		}
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, s := range t {
+			types = append(types, fmt.Sprint(s))
+		}
+		return types
+	}
+	return nil
+}
+
+func schemaNumber(v any) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func matchesType(t string, value any) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func containsValue(values []any, value any) bool {
+	buf, _ := sonic.Marshal(value)
+	for _, v := range values {
+		if b, _ := sonic.Marshal(v); string(b) == string(buf) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepairToolArguments makes a best-effort attempt at turning malformed or truncated function call arguments
+// into valid JSON: it strips code fences, drops trailing commas, terminates an unterminated string and
+// closes the objects and arrays left open. It returns the repaired arguments and whether they changed;
+// arguments that cannot be repaired are returned as they are.
+func RepairToolArguments(arguments string) (string, bool) {
+	trimmed := strings.TrimSpace(arguments)
+	if trimmed == "" || sonic.ValidString(trimmed) {
+		return arguments, false
+	}
+
+	trimmed = strings.TrimPrefix(trimmed, "```json")
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, "```"))
+
+	var out strings.Builder
+	var stack []byte
+	inString, escaped := false, false
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				// Unbalanced closing bracket, drop it
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			trimTrailingComma(&out)
+		}
+		out.WriteByte(c)
+	}
+
+	repaired := out.String()
+	if inString {
+		if escaped {
+			repaired = repaired[:len(repaired)-1]
+		}
+		repaired += `"`
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		repaired = strings.TrimRightFunc(repaired, unicodeSpace)
+		switch {
+		case strings.HasSuffix(repaired, ","):
+			repaired = repaired[:len(repaired)-1]
+		case strings.HasSuffix(repaired, ":"):
+			repaired += "null"
+		}
+		repaired += string(stack[i])
+	}
+
+	if !sonic.ValidString(repaired) {
+		return arguments, false
+	}
+
+	return repaired, true
+}
+
+// trimTrailingComma drops a comma, and the whitespace after it, at the end of the builder
+func trimTrailingComma(b *strings.Builder) {
+	s := strings.TrimRightFunc(b.String(), unicodeSpace)
+	if strings.HasSuffix(s, ",") {
+		b.Reset()
+		b.WriteString(s[:len(s)-1])
+	}
+}
+
+func unicodeSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
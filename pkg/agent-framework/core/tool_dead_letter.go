@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ToolFailure is the error of a tool call that failed, after its retries. Output is what the model receives instead of
+// the result of the tool, e.g. the error message, the call is aborted when it is nil.
+type ToolFailure struct {
+	Output   *responses.FunctionCallOutputMessage `json:"output,omitempty"`
+	Message  string                               `json:"message"`
+	Attempts int                                  `json:"attempts"`
+	Stack    string                               `json:"stack,omitempty"`
+	Panicked bool                                 `json:"panicked,omitempty"` // The stack is the stack of the panic
+}
+
+// NewToolFailure returns the failure of a tool call with the stack of its caller
+func NewToolFailure(output *responses.FunctionCallOutputMessage, err error, attempts int) *ToolFailure {
+	return &ToolFailure{
+		Output:   output,
+		Message:  err.Error(),
+		Attempts: attempts,
+		Stack:    callerStack(3),
+	}
+}
+
+func (f *ToolFailure) Error() string {
+	return f.Message
+}
+
+// AsToolFailure returns the failure of a tool call of err, capturing the stack of its caller when err is not one
+func AsToolFailure(err error) *ToolFailure {
+	var failure *ToolFailure
+	if errors.As(err, &failure) {
+		return failure
+	}
+
+	return &ToolFailure{Message: err.Error(), Attempts: 1, Stack: callerStack(3)}
+}
+
+// ToolResult is the outcome of a tool call as journaled by the durable runtimes. The failures are results, so that
+// the runtimes do not retry the calls already retried by the tools.
+type ToolResult struct {
+	Output  *responses.FunctionCallOutputMessage `json:"output,omitempty"`
+	Failure *ToolFailure                         `json:"failure,omitempty"`
+}
+
+// CallTool calls the tool, turning a panic of the tool into a ToolFailure aborting the call, with the stack of the panic
+func CallTool(ctx context.Context, tool Tool, params *ToolCall) (out *responses.FunctionCallOutputMessage, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			if IsRuntimePanic(p) {
+				panic(p)
+			}
+
+			out, err = nil, &ToolFailure{
+				Message:  fmt.Sprintf("tool '%s' panicked: %v", params.Name, p),
+				Attempts: 1,
+				Stack:    string(debug.Stack()),
+				Panicked: true,
+			}
+		}
+	}()
+
+	return tool.Execute(ctx, params)
+}
+
+// ExecuteTool calls the tool, returning its failures in the result and the other errors as errors
+func ExecuteTool(ctx context.Context, tool Tool, params *ToolCall) (*ToolResult, error) {
+	out, err := CallTool(ctx, tool, params)
+	if err != nil {
+		var failure *ToolFailure
+		if errors.As(err, &failure) {
+			return &ToolResult{Failure: failure}, nil
+		}
+		return nil, err
+	}
+
+	return &ToolResult{Output: out}, nil
+}
+
+// Unwrap returns the output of the call, or its failure as the error
+func (r *ToolResult) Unwrap() (*responses.FunctionCallOutputMessage, error) {
+	if r.Failure != nil {
+		return nil, r.Failure
+	}
+
+	return r.Output, nil
+}
+
+// ToolDeadLetter is the record of a failed tool call, kept to diagnose and replay it
+type ToolDeadLetter struct {
+	AgentName      string `json:"agent_name"`
+	RunID          string `json:"run_id"`
+	ConversationID string `json:"conversation_id"`
+	Namespace      string `json:"namespace"`
+	CallID         string `json:"call_id"`
+	ToolName       string `json:"tool_name"`
+	Arguments      string `json:"arguments"`
+	Error          string `json:"error"`
+	Stack          string `json:"stack"`
+	Attempts       int    `json:"attempts"`
+}
+
+// ToolDeadLetterStore keeps the records of the failed tool calls
+type ToolDeadLetterStore interface {
+	Record(ctx context.Context, letter *ToolDeadLetter) error
+}
+
+// callerStack formats the stack of the goroutine, skipping the given number of frames
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ToolQuotaScope is what the calls of a tool are counted per
+type ToolQuotaScope string
+
+const (
+	ToolQuotaScopeConversation ToolQuotaScope = "conversation"
+	ToolQuotaScopeUser         ToolQuotaScope = "user"
+)
+
+// ToolQuotaPeriod is how often the counters of a quota start over, the empty period never does
+type ToolQuotaPeriod string
+
+const (
+	ToolQuotaPeriodHour  ToolQuotaPeriod = "hour"
+	ToolQuotaPeriodDay   ToolQuotaPeriod = "day"
+	ToolQuotaPeriodMonth ToolQuotaPeriod = "month"
+)
+
+// Names of the provider tools in tool quotas, the function tools are named by their function name
+const (
+	ToolQuotaImageGeneration = "image_generation"
+	ToolQuotaWebSearch       = "web_search"
+	ToolQuotaCodeExecution   = "code_execution"
+)
+
+// ToolQuota limits the calls of a tool, e.g. 3 image generations per conversation or 100 web searches per user and
+// per day
+type ToolQuota struct {
+	Tool   string          `json:"tool"`
+	Scope  ToolQuotaScope  `json:"scope"`
+	Limit  int             `json:"limit"`
+	Period ToolQuotaPeriod `json:"period,omitempty"`
+}
+
+// WindowStart returns the start of the period of the quota that t is in, the zero time when it has no period
+func (q ToolQuota) WindowStart(t time.Time) time.Time {
+	t = t.UTC()
+	switch q.Period {
+	case ToolQuotaPeriodHour:
+		return t.Truncate(time.Hour)
+	case ToolQuotaPeriodDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case ToolQuotaPeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}
+	}
+}
+
+// ToolQuotaCounter counts the calls of tools against their quotas. scopeKey identifies the conversation or the user
+// the call is counted for.
+type ToolQuotaCounter interface {
+	// Consume counts n calls unless they exceed the quota, ok is false when they do and nothing is counted. used is
+	// the number of calls counted in the current period.
+	Consume(ctx context.Context, quota ToolQuota, scopeKey string, n int) (used int, ok bool, err error)
+
+	// Used returns the number of calls counted in the current period
+	Used(ctx context.Context, quota ToolQuota, scopeKey string) (int, error)
+}
+
+// ToolQuotaName returns the name of the tool in tool quotas
+func ToolQuotaName(tool *responses.ToolUnion) string {
+	switch {
+	case tool == nil:
+		return ""
+	case tool.OfFunction != nil:
+		return tool.OfFunction.Name
+	case tool.OfImageGeneration != nil:
+		return ToolQuotaImageGeneration
+	case tool.OfWebSearch != nil:
+		return ToolQuotaWebSearch
+	case tool.OfCodeExecution != nil:
+		return ToolQuotaCodeExecution
+	default:
+		return ""
+	}
+}
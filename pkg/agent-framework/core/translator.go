@@ -0,0 +1,21 @@
+package core
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Translation is the result of translating a text
+type Translation struct {
+	From  string           `json:"from"` // Language of the original text, detected when not given
+	To    string           `json:"to"`
+	Text  string           `json:"text"`            // The translated text, the original one when it is already in the target language
+	Usage *responses.Usage `json:"usage,omitempty"` // Usage of the translation
+}
+
+type Translator interface {
+	// Translate translates the text to the target language. Languages are ISO 639-1 codes, e.g. "en". An empty
+	// source language is detected from the text.
+	Translate(ctx context.Context, text string, from string, to string) (*Translation, error)
+}
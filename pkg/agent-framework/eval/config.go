@@ -0,0 +1,146 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Types of the config-defined evaluators
+const (
+	TypeExactMatch  = "exact_match"  // The output equals the expected output, ignoring surrounding whitespace
+	TypeContains    = "contains"     // The output contains Value, ignoring case
+	TypeNotContains = "not_contains" // The output does not contain Value, ignoring case
+	TypeRegex       = "regex"        // The output matches the regular expression in Value
+	TypeSimilarity  = "similarity"   // Word overlap of the output with the expected output
+	TypeMaxLatency  = "max_latency"  // The run took at most MaxLatencyMs
+	TypeNoError     = "no_error"     // The run did not fail
+	TypeCode        = "code"         // A code-defined evaluator, see Register
+)
+
+// Config defines an evaluator of an eval suite
+type Config struct {
+	// Name identifies the evaluator in the reports, it defaults to the type, or to the code-defined evaluator
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+
+	Value        string `json:"value,omitempty"`          // For contains, not_contains and regex
+	MaxLatencyMs int64  `json:"max_latency_ms,omitempty"` // For max_latency
+	Evaluator    string `json:"evaluator,omitempty"`      // Name the code-defined evaluator is registered under, for code
+
+	// MinScore is the mean score a version must reach
+	MinScore *float64 `json:"min_score,omitempty"`
+
+	// MaxRegression is how much the mean score may drop from the baseline version, it defaults to the one of the suite
+	MaxRegression *float64 `json:"max_regression,omitempty"`
+}
+
+// DisplayName returns the name of the evaluator in the reports
+func (c Config) DisplayName() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.Type == TypeCode:
+		return c.Evaluator
+	default:
+		return c.Type
+	}
+}
+
+// Validate checks the evaluator can score the cases
+func (c Config) Validate(cases []Case) error {
+	if c.MinScore != nil && (*c.MinScore < 0 || *c.MinScore > 1) {
+		return fmt.Errorf("min_score must be between 0 and 1")
+	}
+	if c.MaxRegression != nil && (*c.MaxRegression < 0 || *c.MaxRegression > 1) {
+		return fmt.Errorf("max_regression must be between 0 and 1")
+	}
+
+	switch c.Type {
+	case TypeExactMatch, TypeSimilarity:
+		for idx, cs := range cases {
+			if strings.TrimSpace(cs.Expected) == "" {
+				return fmt.Errorf("%s: %w", caseName(cs, idx), ErrNoExpected)
+			}
+		}
+	case TypeContains, TypeNotContains:
+		if c.Value == "" {
+			return fmt.Errorf("value is required")
+		}
+	case TypeRegex:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+	case TypeMaxLatency:
+		if c.MaxLatencyMs <= 0 {
+			return fmt.Errorf("max_latency_ms must be positive")
+		}
+	case TypeNoError:
+	case TypeCode:
+		if _, ok := Lookup(c.Evaluator); !ok {
+			return fmt.Errorf("no evaluator is registered under '%s'", c.Evaluator)
+		}
+	default:
+		return fmt.Errorf("unknown evaluator type '%s'", c.Type)
+	}
+
+	return nil
+}
+
+// Func returns the function scoring the outcomes
+func (c Config) Func() (Func, error) {
+	switch c.Type {
+	case TypeExactMatch:
+		return func(_ context.Context, cs Case, o Outcome) (float64, error) {
+			return boolScore(strings.TrimSpace(o.Output) == strings.TrimSpace(cs.Expected)), nil
+		}, nil
+
+	case TypeContains, TypeNotContains:
+		value := strings.ToLower(c.Value)
+		want := c.Type == TypeContains
+		return func(_ context.Context, _ Case, o Outcome) (float64, error) {
+			return boolScore(strings.Contains(strings.ToLower(o.Output), value) == want), nil
+		}, nil
+
+	case TypeRegex:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return func(_ context.Context, _ Case, o Outcome) (float64, error) {
+			return boolScore(re.MatchString(o.Output)), nil
+		}, nil
+
+	case TypeSimilarity:
+		return func(_ context.Context, cs Case, o Outcome) (float64, error) {
+			return WordSimilarity(o.Output, cs.Expected), nil
+		}, nil
+
+	case TypeMaxLatency:
+		return func(_ context.Context, _ Case, o Outcome) (float64, error) {
+			return boolScore(o.DurationMs <= c.MaxLatencyMs), nil
+		}, nil
+
+	case TypeNoError:
+		return func(_ context.Context, _ Case, o Outcome) (float64, error) {
+			return boolScore(o.Error == ""), nil
+		}, nil
+
+	case TypeCode:
+		fn, ok := Lookup(c.Evaluator)
+		if !ok {
+			return nil, fmt.Errorf("no evaluator is registered under '%s'", c.Evaluator)
+		}
+		return fn, nil
+	}
+
+	return nil, fmt.Errorf("unknown evaluator type '%s'", c.Type)
+}
+
+func boolScore(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
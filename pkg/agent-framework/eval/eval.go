@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Case is an input an agent is evaluated on
+type Case struct {
+	Name     string                      `json:"name,omitempty"`
+	Input    responses.InputMessageUnion `json:"input"`
+	Expected string                      `json:"expected,omitempty"` // Reference output, for the evaluators comparing with one
+	Context  map[string]any              `json:"context,omitempty"`  // Context the system prompt is rendered with
+}
+
+// Outcome is the result of running a case on a version of an agent
+type Outcome struct {
+	Version    int              `json:"version"`
+	Status     string           `json:"status"` // Run status, or "error"
+	Output     string           `json:"output"`
+	Error      string           `json:"error,omitempty"`
+	DurationMs int64            `json:"duration_ms"`
+	Usage      *responses.Usage `json:"usage,omitempty"`
+}
+
+// Func scores the outcome of a case, from 0 (worst) to 1 (best)
+type Func func(ctx context.Context, c Case, outcome Outcome) (float64, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Func{}
+)
+
+// Register makes a code-defined evaluator available to the eval suites under the name, as an evaluator of type
+// "code". It is meant to be called at startup, before the server is started:
+//
+//	eval.Register("mentions_order_id", func(ctx context.Context, c eval.Case, o eval.Outcome) (float64, error) {
+//		if orderIDPattern.MatchString(o.Output) {
+//			return 1, nil
+//		}
+//		return 0, nil
+//	})
+func Register(name string, fn Func) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = fn
+}
+
+// Lookup returns the code-defined evaluator registered under the name
+func Lookup(name string) (Func, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	fn, ok := registry[name]
+	return fn, ok
+}
+
+// Score runs the evaluator on the outcome. Failed runs score 0 and scores are clamped to [0, 1].
+func Score(ctx context.Context, fn Func, c Case, outcome Outcome) (float64, error) {
+	if outcome.Error != "" {
+		return 0, nil
+	}
+
+	score, err := fn(ctx, c, outcome)
+	if err != nil {
+		return 0, err
+	}
+
+	return min(max(score, 0), 1), nil
+}
+
+// ErrNoExpected is returned by the evaluators comparing with the reference output of a case without one
+var ErrNoExpected = errors.New("the case has no expected output")
+
+// WordSimilarity is the Jaccard index of the lowercased words of both texts, from 0 to 1
+func WordSimilarity(a, b string) float64 {
+	wordsA := words(a)
+	wordsB := words(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func words(text string) map[string]bool {
+	out := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		out[w] = true
+	}
+	return out
+}
+
+// caseName identifies a case in errors
+func caseName(c Case, idx int) string {
+	if c.Name != "" {
+		return fmt.Sprintf("case '%s'", c.Name)
+	}
+	return fmt.Sprintf("case %d", idx)
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/curaious/uno/internal/services/conversation"
 	"github.com/curaious/uno/pkg/agent-framework/core"
 	"github.com/curaious/uno/pkg/llm/responses"
-	"github.com/google/uuid"
 )
 
 type ConversationPersistenceAdapter interface {
@@ -19,9 +18,17 @@ type ConversationPersistenceAdapter interface {
 	SaveSummary(ctx context.Context, namespace string, summary conversation.Summary) error
 }
 
+// IDGenerator generates the IDs of new conversations and runs, in place of the persistence adapter
+type IDGenerator interface {
+	NewConversationID(ctx context.Context) string
+	NewRunID(ctx context.Context) string
+}
+
 type CommonConversationManager struct {
 	ConversationPersistenceAdapter ConversationPersistenceAdapter
 	Summarizer                     core.HistorySummarizer
+	IDGenerator                    IDGenerator
+	DeduplicateToolOutputs         bool
 
 	Options []ConversationManagerOptions
 }
@@ -46,6 +53,22 @@ func WithSummarizer(summarizer core.HistorySummarizer) ConversationManagerOption
 	}
 }
 
+// WithIDGenerator generates the IDs of new conversations and runs with gen, e.g. to use the IDs of an
+// existing chat store
+func WithIDGenerator(gen IDGenerator) ConversationManagerOptions {
+	return func(cm *CommonConversationManager) {
+		cm.IDGenerator = gen
+	}
+}
+
+// WithToolOutputDeduplication collapses the outputs of the tool calls repeating an earlier call of the context, with
+// the same arguments and the same output, into a reference to that call. The full outputs are still saved.
+func WithToolOutputDeduplication() ConversationManagerOptions {
+	return func(cm *CommonConversationManager) {
+		cm.DeduplicateToolOutputs = true
+	}
+}
+
 type ConversationRunManager struct {
 	ConversationPersistenceAdapter
 
@@ -58,21 +81,33 @@ type ConversationRunManager struct {
 
 	convMessages    []conversation.ConversationMessage
 	oldMessages     []responses.InputMessageUnion
+	pinned          []bool // pinned flags the messages of oldMessages belonging to pinned runs
 	newMessages     []responses.InputMessageUnion
 	usage           *responses.Usage
 	lastMessageMeta map[string]any
 	RunState        *core.RunState
 
-	summarizer core.HistorySummarizer
-	summaries  *core.SummaryResult
+	summarizer             core.HistorySummarizer
+	summaries              *core.SummaryResult
+	idGenerator            IDGenerator
+	deduplicateToolOutputs bool
+
+	// Summary of a summarizer in shadow mode, compared once per run
+	shadow      *core.SummaryResult
+	shadowTaken bool
 }
 
 func NewRun(ctx context.Context, cm *CommonConversationManager, namespace string, previousRunID string, messages []responses.InputMessageUnion, options ...RunOption) (*ConversationRunManager, error) {
 	cr := &ConversationRunManager{
 		ConversationPersistenceAdapter: cm.ConversationPersistenceAdapter,
 		summarizer:                     cm.Summarizer,
+		idGenerator:                    cm.IDGenerator,
+		deduplicateToolOutputs:         cm.DeduplicateToolOutputs,
 		msgIdToRunId:                   make(map[string]string),
 	}
+	if cr.idGenerator == nil {
+		cr.idGenerator = cm.ConversationPersistenceAdapter
+	}
 
 	// Load messages
 	_, err := cr.LoadMessages(ctx, namespace, previousRunID)
@@ -82,9 +117,9 @@ func NewRun(ctx context.Context, cm *CommonConversationManager, namespace string
 
 	// Load the run state
 	var runID string
-	if cr.RunState == nil || cr.RunState.IsComplete() {
+	if cr.RunState == nil || cr.RunState.IsComplete() || cr.RunState.IsFailed() {
 		// Create a new run id
-		runID = cr.ConversationPersistenceAdapter.NewRunID(ctx)
+		runID = cr.idGenerator.NewRunID(ctx)
 		cr.RunState = core.NewRunState()
 		cr.AddMessages(ctx, messages, nil)
 	} else {
@@ -111,7 +146,7 @@ func NewRun(ctx context.Context, cm *CommonConversationManager, namespace string
 	}
 
 	if cr.conversationId == "" {
-		cr.conversationId = cr.ConversationPersistenceAdapter.NewConversationID(ctx)
+		cr.conversationId = cr.idGenerator.NewConversationID(ctx)
 	}
 
 	return cr, nil
@@ -135,24 +170,84 @@ func (cm *ConversationRunManager) AddMessages(ctx context.Context, messages []re
 
 func (cm *ConversationRunManager) GetMessages(ctx context.Context) ([]responses.InputMessageUnion, error) {
 	// Process messages with summarizer if available
-	if cm.summarizer != nil {
-		summaryResult, err := cm.summarizer.Summarize(ctx, cm.msgIdToRunId, cm.oldMessages, cm.usage)
+	if cm.summarizer != nil && cm.shadow == nil && !cm.shadowTaken {
+		summaryResult, err := cm.summarizer.Summarize(core.ContextWithPinnedRuns(ctx, cm.pinnedRuns()), cm.msgIdToRunId, cm.oldMessages, cm.usage)
 		if err != nil {
 			return nil, err
 		}
 
-		// If a summary was created, track it for saving later and apply it to messages
-		if summaryResult != nil {
+		if summaryResult != nil && summaryResult.Shadow {
+			// Keep the history as it is, the summary is only compared against it
+			cm.shadow = summaryResult
+		} else if summaryResult != nil {
+			// If a summary was created, track it for saving later and apply it to messages
 			cm.summaries = summaryResult
-			if summaryResult.Summary == nil {
-				cm.oldMessages = summaryResult.MessagesToKeep
-			} else {
-				cm.oldMessages = append([]responses.InputMessageUnion{*summaryResult.Summary}, summaryResult.MessagesToKeep...)
-			}
+			cm.oldMessages, cm.pinned = cm.applySummary(summaryResult)
 		}
 	}
 
-	return append(cm.oldMessages, cm.newMessages...), nil
+	messages := append(cm.oldMessages, cm.newMessages...)
+	if cm.deduplicateToolOutputs {
+		messages = deduplicateToolOutputs(messages)
+	}
+
+	return messages, nil
+}
+
+// TakeShadowSummary returns the summary of a summarizer in shadow mode along with the messages GetMessages
+// would have returned had it been applied. It returns nil once taken, a run is compared at most once.
+func (cm *ConversationRunManager) TakeShadowSummary() (*core.SummaryResult, []responses.InputMessageUnion) {
+	if cm.shadow == nil {
+		return nil, nil
+	}
+
+	summary := cm.shadow
+	cm.shadow = nil
+	cm.shadowTaken = true
+
+	messages, _ := cm.applySummary(summary)
+	messages = append(messages, cm.newMessages...)
+
+	return summary, messages
+}
+
+// applySummary returns the history with the summary in place of the messages it condenses, along with the flags of
+// its pinned messages. The messages of the pinned runs the summarizer left out are kept after the summary. The
+// summarizers keep the most recent messages, so the messages left out are the ones before those kept.
+func (cm *ConversationRunManager) applySummary(summary *core.SummaryResult) ([]responses.InputMessageUnion, []bool) {
+	messages := []responses.InputMessageUnion{}
+	pinned := []bool{}
+	if summary.Summary != nil {
+		messages = append(messages, *summary.Summary)
+		pinned = append(pinned, false)
+	}
+
+	keepFrom := len(cm.oldMessages) - len(summary.MessagesToKeep)
+	if keepFrom < 0 || len(cm.pinned) != len(cm.oldMessages) {
+		return append(messages, summary.MessagesToKeep...), append(pinned, make([]bool, len(summary.MessagesToKeep))...)
+	}
+
+	for i := 0; i < keepFrom; i++ {
+		if cm.pinned[i] {
+			messages = append(messages, cm.oldMessages[i])
+			pinned = append(pinned, true)
+		}
+	}
+	messages = append(messages, summary.MessagesToKeep...)
+	pinned = append(pinned, cm.pinned[keepFrom:]...)
+
+	return messages, pinned
+}
+
+// pinnedRuns returns the IDs of the pinned runs of the history
+func (cm *ConversationRunManager) pinnedRuns() []string {
+	var runIDs []string
+	for _, msg := range cm.convMessages {
+		if msg.Pinned() {
+			runIDs = append(runIDs, msg.MessageID)
+		}
+	}
+	return runIDs
 }
 
 func (cm *ConversationRunManager) LoadMessages(ctx context.Context, namespace string, previousMessageID string) ([]responses.InputMessageUnion, error) {
@@ -171,9 +266,11 @@ func (cm *ConversationRunManager) LoadMessages(ctx context.Context, namespace st
 	}
 
 	messages := []responses.InputMessageUnion{}
+	pinned := []bool{}
 	for _, msg := range convMessages {
 		for _, m := range msg.Messages {
 			cm.msgIdToRunId[m.ID()] = msg.MessageID
+			pinned = append(pinned, msg.Pinned())
 		}
 		cm.threadId = msg.ThreadID
 		cm.conversationId = msg.ConversationID
@@ -196,6 +293,7 @@ func (cm *ConversationRunManager) LoadMessages(ctx context.Context, namespace st
 	cm.previousMsgId = previousMessageID
 	cm.convMessages = convMessages
 	cm.oldMessages = messages
+	cm.pinned = pinned
 	cm.RunState = core.LoadRunStateFromMeta(cm.lastMessageMeta)
 	if cm.RunState != nil {
 		cm.usage = &cm.RunState.Usage
@@ -204,6 +302,35 @@ func (cm *ConversationRunManager) LoadMessages(ctx context.Context, namespace st
 	return messages, nil
 }
 
+// SanitizeHistory passes the messages of the runs that called another provider or model than the given ones through
+// sanitize, so that the history is accepted by the LLM of the run. The runs whose LLM isn't recorded are kept as
+// they are. It returns the number of runs sanitized.
+func (cm *ConversationRunManager) SanitizeHistory(provider string, model string, sanitize func(messages []responses.InputMessageUnion, sameProvider bool) []responses.InputMessageUnion) int {
+	if len(cm.convMessages) == 0 {
+		return 0
+	}
+
+	sanitized := 0
+	messages := make([]responses.InputMessageUnion, 0, len(cm.oldMessages))
+	pinned := make([]bool, 0, len(cm.oldMessages))
+	for i, msg := range cm.convMessages {
+		runState := core.LoadRunStateFromMeta(msg.Meta)
+		if runState != nil && runState.Provider != "" && (runState.Provider != provider || runState.Model != model) {
+			msg.Messages = sanitize(msg.Messages, runState.Provider == provider)
+			cm.convMessages[i] = msg
+			sanitized++
+		}
+		messages = append(messages, msg.Messages...)
+		for range msg.Messages {
+			pinned = append(pinned, msg.Pinned())
+		}
+	}
+
+	cm.oldMessages = messages
+	cm.pinned = pinned
+	return sanitized
+}
+
 // GetMeta returns the meta from the most recent message
 func (cm *ConversationRunManager) GetMeta() map[string]any {
 	return cm.lastMessageMeta
@@ -255,16 +382,37 @@ func (cm *ConversationRunManager) SaveMessages(ctx context.Context, meta map[str
 	runState := core.LoadRunStateFromMeta(meta)
 	if runState.IsComplete() {
 		cm.previousMsgId = cm.msgId
-		cm.msgId = uuid.NewString()
+		cm.msgId = cm.idGenerator.NewRunID(ctx)
 	}
 
 	cm.lastMessageMeta = meta
 	cm.oldMessages = append(cm.oldMessages, cm.newMessages...)
+	cm.pinned = append(cm.pinned, make([]bool, len(cm.newMessages))...)
 	cm.newMessages = []responses.InputMessageUnion{}
 
 	return nil
 }
 
+// DiscardUnansweredToolCalls drops the function calls of the run without output, e.g. of a run that failed while
+// executing them, so that the conversation can be continued from the messages of the run
+func (cm *ConversationRunManager) DiscardUnansweredToolCalls() {
+	answered := map[string]bool{}
+	for _, msg := range cm.newMessages {
+		if msg.OfFunctionCallOutput != nil {
+			answered[msg.OfFunctionCallOutput.CallID] = true
+		}
+	}
+
+	messages := make([]responses.InputMessageUnion, 0, len(cm.newMessages))
+	for _, msg := range cm.newMessages {
+		if msg.OfFunctionCall != nil && !answered[msg.OfFunctionCall.CallID] {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	cm.newMessages = messages
+}
+
 func (cm *ConversationRunManager) TrackUsage(usage *responses.Usage) {
 	cm.RunState.Usage.InputTokens += usage.InputTokens
 	cm.RunState.Usage.OutputTokens += usage.OutputTokens
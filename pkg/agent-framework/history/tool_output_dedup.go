@@ -0,0 +1,58 @@
+package history
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// deduplicateToolOutputs returns the messages with the outputs of the tool calls repeating an earlier call replaced
+// by a reference to it. A call repeats another when the tool, the arguments and the output are the same. The
+// messages are copied, the history they come from is left untouched.
+func deduplicateToolOutputs(messages []responses.InputMessageUnion) []responses.InputMessageUnion {
+	calls := make(map[string]*responses.FunctionCallMessage)
+	for _, msg := range messages {
+		if msg.OfFunctionCall != nil {
+			calls[msg.OfFunctionCall.CallID] = msg.OfFunctionCall
+		}
+	}
+
+	// The first call of each tool, arguments and output
+	firstCalls := make(map[string]string)
+
+	out := make([]responses.InputMessageUnion, len(messages))
+	for idx, msg := range messages {
+		out[idx] = msg
+		if msg.OfFunctionCallOutput == nil {
+			continue
+		}
+
+		call, ok := calls[msg.OfFunctionCallOutput.CallID]
+		if !ok {
+			continue
+		}
+
+		output, err := sonic.Marshal(&msg.OfFunctionCallOutput.Output)
+		if err != nil {
+			continue
+		}
+
+		key := call.Name + "\x00" + call.Arguments + "\x00" + string(output)
+		firstCall, ok := firstCalls[key]
+		if !ok {
+			firstCalls[key] = call.CallID
+			continue
+		}
+
+		// Short outputs are cheaper than their reference
+		reference := "Same as the output of call " + firstCall
+		if len(reference) >= len(output) {
+			continue
+		}
+
+		collapsed := *msg.OfFunctionCallOutput
+		collapsed.Output = responses.FunctionCallOutputContentUnion{OfString: &reference}
+		out[idx].OfFunctionCallOutput = &collapsed
+	}
+
+	return out
+}
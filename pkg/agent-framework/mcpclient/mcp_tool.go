@@ -3,6 +3,9 @@ package mcpclient
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/internal/utils"
@@ -18,10 +21,23 @@ var (
 	tracer = otel.Tracer("MCPTool")
 )
 
+const (
+	// DefaultToolTimeout bounds an attempt of a tool call when the server has no timeout configured
+	DefaultToolTimeout = 60 * time.Second
+
+	// retryBackoff is the wait before the first retry of a tool call, doubled on every further retry
+	retryBackoff = 500 * time.Millisecond
+)
+
 type McpTool struct {
 	*core.BaseTool
-	Client *client.Client `json:"-"`
-	Meta   *mcp.Meta      `json:"-"`
+	Client     *client.Client `json:"-"`
+	Meta       *mcp.Meta      `json:"-"`
+	RemoteName string         `json:"-"` // Name of the tool on the server, the exposed name may be prefixed or renamed
+
+	Timeout        time.Duration `json:"-"` // Bound of a single attempt, DefaultToolTimeout when zero
+	MaxRetries     int           `json:"-"` // Retries of calls that failed to reach the server or timed out
+	MaxResultBytes int           `json:"-"` // Results are truncated beyond this size, zero means no limit
 }
 
 func NewMcpTool(t mcp.Tool, cli *client.Client, Meta *mcp.Meta, requiresApproval bool) *McpTool {
@@ -52,8 +68,9 @@ func NewMcpTool(t mcp.Tool, cli *client.Client, Meta *mcp.Meta, requiresApproval
 				},
 			},
 		},
-		Client: cli,
-		Meta:   Meta,
+		Client:     cli,
+		Meta:       Meta,
+		RemoteName: t.Name,
 	}
 }
 
@@ -79,25 +96,18 @@ func (c *McpTool) Execute(ctx context.Context, params *core.ToolCall) (*response
 		}
 	}
 
-	// Call the MCP tool
-	res, err := c.Client.CallTool(ctx, mcp.CallToolRequest{
-		Request: mcp.Request{},
-		Params: mcp.CallToolParams{
-			Name:      params.Name,
-			Arguments: args,
-			Meta:      c.Meta,
-		},
-	})
+	// Call the MCP tool. The model is told about the failure, which is returned to be recorded as a dead letter.
+	res, attempts, err := c.callTool(ctx, args)
 	if err != nil {
 		span.RecordError(err)
 		span.SetAttributes(attribute.String("output", err.Error()))
-		return &responses.FunctionCallOutputMessage{
+		return nil, core.NewToolFailure(&responses.FunctionCallOutputMessage{
 			ID:     params.ID,
 			CallID: params.CallID,
 			Output: responses.FunctionCallOutputContentUnion{
 				OfString: utils.Ptr(err.Error()),
 			},
-		}, nil
+		}, err, attempts)
 	}
 
 	// Return the tool result
@@ -108,7 +118,7 @@ func (c *McpTool) Execute(ctx context.Context, params *core.ToolCall) (*response
 				ID:     params.ID,
 				CallID: params.CallID,
 				Output: responses.FunctionCallOutputContentUnion{
-					OfString: utils.Ptr(r.(mcp.TextContent).Text),
+					OfString: utils.Ptr(truncateResult(r.(mcp.TextContent).Text, c.MaxResultBytes)),
 				},
 			}
 			outStr, _ := sonic.Marshal(out)
@@ -121,3 +131,66 @@ func (c *McpTool) Execute(ctx context.Context, params *core.ToolCall) (*response
 	span.RecordError(err)
 	return nil, err
 }
+
+// callTool calls the tool on the server, bounding every attempt with the timeout of the tool. Calls that
+// fail to reach the server or time out are retried with a backoff, errors reported by the tool are not. It returns
+// the number of attempts made.
+func (c *McpTool) callTool(ctx context.Context, args map[string]any) (*mcp.CallToolResult, int, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultToolTimeout
+	}
+
+	var err error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, attempt, ctx.Err()
+			case <-time.After(retryBackoff << (attempt - 1)):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		var res *mcp.CallToolResult
+		res, err = c.Client.CallTool(attemptCtx, mcp.CallToolRequest{
+			Request: mcp.Request{},
+			Params: mcp.CallToolParams{
+				Name:      c.RemoteName,
+				Arguments: args,
+				Meta:      c.Meta,
+			},
+		})
+		cancel()
+		if err == nil {
+			return res, attempt + 1, nil
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("MCP tool '%s' timed out after %s", c.RemoteName, timeout)
+		}
+
+		if ctx.Err() != nil {
+			return nil, attempt + 1, err
+		}
+	}
+
+	if c.MaxRetries > 0 {
+		return nil, c.MaxRetries + 1, fmt.Errorf("%w (after %d attempts)", err, c.MaxRetries+1)
+	}
+	return nil, 1, err
+}
+
+// truncateResult cuts text down to maxBytes, on a character boundary, and marks how much was dropped
+func truncateResult(text string, maxBytes int) string {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s\n[truncated %d of %d bytes]", text[:cut], len(text)-cut, len(text))
+}
@@ -3,6 +3,7 @@ package mcpclient
 import (
 	"context"
 	"slices"
+	"time"
 
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/agent-framework/core"
@@ -11,6 +12,7 @@ import (
 )
 
 type MCPClient struct {
+	Name     string            `json:"-"`
 	Endpoint string            `json:"-"`
 	Headers  map[string]string `json:"-"`
 
@@ -19,6 +21,16 @@ type MCPClient struct {
 	Meta                  *mcp.Meta      `json:"-"`
 	ToolFilter            []string       `json:"-"`
 	ApprovalRequiredTools []string       `json:"-"`
+
+	// ToolPrefix is prepended to the name of every tool and ToolRenames maps tool names to the names
+	// exposed to the model. Filters and approval lists keep using the names of the server.
+	ToolPrefix  string            `json:"-"`
+	ToolRenames map[string]string `json:"-"`
+
+	// Limits of a single tool call, see McpTool
+	Timeout        time.Duration `json:"-"`
+	MaxRetries     int           `json:"-"`
+	MaxResultBytes int           `json:"-"`
 }
 
 func NewInProcessMCPServer(ctx context.Context, client *client.Client, headers map[string]any) (*MCPClient, error) {
@@ -83,10 +95,63 @@ func WithApprovalRequiredTools(tools ...string) McpServerOption {
 	}
 }
 
+// WithName names the server, the name is used to namespace its tools when they collide with other tools
+func WithName(name string) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.Name = name
+	}
+}
+
+// WithToolPrefix prepends prefix to the name of every tool of the server
+func WithToolPrefix(prefix string) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.ToolPrefix = prefix
+	}
+}
+
+// WithToolRenames exposes the tools of the server under other names, it takes precedence over the prefix
+func WithToolRenames(renames map[string]string) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.ToolRenames = renames
+	}
+}
+
+// WithTimeout bounds every attempt of a tool call, DefaultToolTimeout is used when not set
+func WithTimeout(timeout time.Duration) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.Timeout = timeout
+	}
+}
+
+// WithMaxRetries retries tool calls that failed to reach the server or timed out
+func WithMaxRetries(retries int) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.MaxRetries = retries
+	}
+}
+
+// WithMaxResultBytes truncates tool results larger than maxBytes
+func WithMaxResultBytes(maxBytes int) McpServerOption {
+	return func(srv *MCPClient) {
+		srv.MaxResultBytes = maxBytes
+	}
+}
+
 func (srv *MCPClient) GetName() string {
+	if srv.Name != "" {
+		return srv.Name
+	}
 	return "MCPClient"
 }
 
+// ToolName returns the name a tool of the server is exposed under
+func (srv *MCPClient) ToolName(name string) string {
+	if renamed, ok := srv.ToolRenames[name]; ok && renamed != "" {
+		return renamed
+	}
+	return srv.ToolPrefix + name
+}
+
 func (srv *MCPClient) GetClient(ctx context.Context, runContext map[string]any) (*MCPClient, error) {
 	// resolve the headers with run context
 	headers := map[string]string{}
@@ -102,8 +167,10 @@ func (srv *MCPClient) GetClient(ctx context.Context, runContext map[string]any)
 		return nil, err
 	}
 
+	// The connection lives as long as ctx, the requests are aborted once it is done
 	err = client.Start(ctx)
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
@@ -112,6 +179,7 @@ func (srv *MCPClient) GetClient(ctx context.Context, runContext map[string]any)
 		Params:  mcp.InitializeParams{},
 	})
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
@@ -119,10 +187,12 @@ func (srv *MCPClient) GetClient(ctx context.Context, runContext map[string]any)
 		PaginatedRequest: mcp.PaginatedRequest{},
 	})
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
 	return &MCPClient{
+		Name:                  srv.Name,
 		Endpoint:              srv.Endpoint,
 		Headers:               headers,
 		Client:                client,
@@ -130,6 +200,11 @@ func (srv *MCPClient) GetClient(ctx context.Context, runContext map[string]any)
 		Meta:                  srv.Meta,
 		ToolFilter:            srv.ToolFilter,
 		ApprovalRequiredTools: srv.ApprovalRequiredTools,
+		ToolPrefix:            srv.ToolPrefix,
+		ToolRenames:           srv.ToolRenames,
+		Timeout:               srv.Timeout,
+		MaxRetries:            srv.MaxRetries,
+		MaxResultBytes:        srv.MaxResultBytes,
 	}, nil
 }
 
@@ -149,7 +224,12 @@ func (srv *MCPClient) GetTools(opts ...McpServerOption) []core.Tool {
 			requiresApproval = true
 		}
 
-		mcpTools = append(mcpTools, NewMcpTool(tool, srv.Client, srv.Meta, requiresApproval))
+		mcpTool := NewMcpTool(tool, srv.Client, srv.Meta, requiresApproval)
+		mcpTool.ToolUnion.OfFunction.Name = srv.ToolName(tool.Name)
+		mcpTool.Timeout = srv.Timeout
+		mcpTool.MaxRetries = srv.MaxRetries
+		mcpTool.MaxResultBytes = srv.MaxResultBytes
+		mcpTools = append(mcpTools, mcpTool)
 	}
 
 	return mcpTools
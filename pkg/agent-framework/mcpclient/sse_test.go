@@ -0,0 +1,67 @@
+package mcpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMcpTool_Execute_Cancelled(t *testing.T) {
+	// The tool keeps working until the test ends
+	started, release := make(chan struct{}), make(chan struct{})
+	mcpServer := server.NewMCPServer("slow", "1.0.0")
+	mcpServer.AddTool(mcp.NewTool("wait"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-release
+		return mcp.NewToolResultText("done"), nil
+	})
+	testServer := server.NewTestServer(mcpServer)
+	defer testServer.Close()
+	defer close(release)
+
+	cli, err := (&MCPClient{Endpoint: testServer.URL + "/sse"}).GetClient(context.Background(), nil)
+	require.NoError(t, err)
+	defer cli.Client.Close()
+
+	tools := cli.GetTools()
+	require.Len(t, tools, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err = tools[0].Execute(ctx, &core.ToolCall{FunctionCallMessage: &responses.FunctionCallMessage{CallID: "call_1", Name: "wait"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestMCPClient_GetClient_Cancelled(t *testing.T) {
+	// The server accepts the connection but never sends the endpoint of its messages
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := (&MCPClient{Endpoint: testServer.URL}).GetClient(ctx, nil)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
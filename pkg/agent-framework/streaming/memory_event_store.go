@@ -0,0 +1,158 @@
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// memoryRunStream holds the events of a run. notify is closed and replaced whenever the stream changes, which
+// wakes up all readers waiting for new chunks.
+type memoryRunStream struct {
+	events   []*core.RunEvent
+	closed   bool
+	closedAt time.Time
+	notify   chan struct{}
+}
+
+// MemoryRunEventStore is an in-memory implementation of RunEventStore, for when all subscribers are served by
+// the process that runs the agent. Offsets are the positions of the chunks in the stream, starting at 1, like their
+// sequence numbers.
+type MemoryRunEventStore struct {
+	mu        sync.Mutex
+	streams   map[string]*memoryRunStream
+	retention time.Duration
+}
+
+// NewMemoryRunEventStore creates a store that keeps closed streams for the retention, 1 hour by default
+func NewMemoryRunEventStore(retention time.Duration) *MemoryRunEventStore {
+	if retention <= 0 {
+		retention = time.Hour
+	}
+
+	return &MemoryRunEventStore{
+		streams:   map[string]*memoryRunStream{},
+		retention: retention,
+	}
+}
+
+// Open creates the stream, and drops the closed streams whose retention has passed
+func (s *MemoryRunEventStore) Open(ctx context.Context, stream string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, st := range s.streams {
+		if st.closed && time.Since(st.closedAt) > s.retention {
+			delete(s.streams, name)
+		}
+	}
+
+	if _, ok := s.streams[stream]; !ok {
+		s.streams[stream] = &memoryRunStream{notify: make(chan struct{})}
+	}
+
+	return nil
+}
+
+// Append records a chunk at the end of the stream
+func (s *MemoryRunEventStore) Append(ctx context.Context, stream string, runID string, chunk *responses.ResponseChunk) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[stream]
+	if !ok {
+		return "", core.ErrRunStreamNotFound
+	}
+	if st.closed {
+		return "", fmt.Errorf("run stream %s is closed", stream)
+	}
+
+	seq := len(st.events) + 1
+	st.events = append(st.events, &core.RunEvent{
+		Offset: strconv.Itoa(seq),
+		Seq:    int64(seq),
+		RunID:  runID,
+		Chunk:  chunk,
+	})
+	close(st.notify)
+	st.notify = make(chan struct{})
+
+	return strconv.Itoa(seq), nil
+}
+
+// Read returns the chunks after the offset, followed by the live ones
+func (s *MemoryRunEventStore) Read(ctx context.Context, stream string, after string) (<-chan *core.RunEvent, error) {
+	next := 0
+	if after != "" {
+		offset, err := strconv.Atoi(after)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%w: %s", core.ErrInvalidRunStreamOffset, after)
+		}
+		next = offset
+	}
+
+	s.mu.Lock()
+	st, ok := s.streams[stream]
+	s.mu.Unlock()
+	if !ok {
+		return nil, core.ErrRunStreamNotFound
+	}
+
+	ch := make(chan *core.RunEvent, 100)
+	go func() {
+		defer close(ch)
+
+		for {
+			s.mu.Lock()
+			pending := st.events[min(next, len(st.events)):]
+			closed, notify := st.closed, st.notify
+			s.mu.Unlock()
+
+			for _, event := range pending {
+				next++
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if closed {
+				return
+			}
+
+			select {
+			case <-notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Close signals that no more chunks will be appended, readers return once they have read all chunks
+func (s *MemoryRunEventStore) Close(ctx context.Context, stream string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[stream]
+	if !ok || st.closed {
+		return nil
+	}
+
+	st.closed = true
+	st.closedAt = time.Now()
+	close(st.notify)
+
+	return nil
+}
+
+// Ensure MemoryRunEventStore implements RunEventStore
+var _ core.RunEventStore = (*MemoryRunEventStore)(nil)
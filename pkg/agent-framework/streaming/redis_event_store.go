@@ -0,0 +1,219 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/redis/go-redis/v9"
+)
+
+// Fields of the entries of a run stream. The entries written by Open and Close carry no chunk.
+const (
+	runStreamFieldChunk  = "chunk"
+	runStreamFieldOpened = "opened"
+	runStreamFieldClosed = "closed"
+	runStreamFieldRunID  = "run_id"
+	runStreamFieldSeq    = "seq"
+)
+
+// runStreamAddScript appends an entry to a run stream and extends its retention. Every entry carries the sequence
+// number of the last chunk, which chunk entries increment, so that the numbers survive the trimming of the stream.
+//
+// KEYS[1]: stream, ARGV[1]: max length, ARGV[2]: retention in seconds, ARGV[3]: field, ARGV[4]: value,
+// ARGV[5]: run ID
+var runStreamAddScript = redis.NewScript(`
+local seq = 0
+local last = redis.call('XREVRANGE', KEYS[1], '+', '-', 'COUNT', 1)
+if last[1] then
+	local fields = last[1][2]
+	for i = 1, #fields, 2 do
+		if fields[i] == 'seq' then
+			seq = tonumber(fields[i + 1])
+		end
+	end
+end
+if ARGV[3] == 'chunk' then
+	seq = seq + 1
+end
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', ARGV[3], ARGV[4], 'run_id', ARGV[5], 'seq', seq)
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return id
+`)
+
+// RedisRunEventStore implements RunEventStore with Redis Streams, so that subscribers connected to any replica
+// can attach to a run. Offsets are the IDs of the stream entries, which also carry the run ID and sequence number
+// of their chunk.
+type RedisRunEventStore struct {
+	client    *redis.Client
+	prefix    string
+	retention time.Duration
+	maxLen    int64
+}
+
+// RedisRunEventStoreOptions configures the Redis run event store.
+type RedisRunEventStoreOptions struct {
+	// Prefix is prepended to all stream names (default "uno:run-events:").
+	Prefix string
+
+	// Retention is how long a stream is kept after its last chunk (default 1 hour).
+	Retention time.Duration
+
+	// MaxLen caps the number of chunks kept per stream, older chunks are trimmed (default 10000).
+	MaxLen int64
+}
+
+// NewRedisRunEventStore creates a run event store on an existing Redis client.
+func NewRedisRunEventStore(client *redis.Client, opts RedisRunEventStoreOptions) *RedisRunEventStore {
+	if opts.Prefix == "" {
+		opts.Prefix = "uno:run-events:"
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = time.Hour
+	}
+	if opts.MaxLen <= 0 {
+		opts.MaxLen = 10000
+	}
+
+	return &RedisRunEventStore{
+		client:    client,
+		prefix:    opts.Prefix,
+		retention: opts.Retention,
+		maxLen:    opts.MaxLen,
+	}
+}
+
+// streamKey returns the Redis key of the given stream.
+func (s *RedisRunEventStore) streamKey(stream string) string {
+	return s.prefix + stream
+}
+
+// Open creates the stream with a marker entry, so that readers can attach before the first chunk
+func (s *RedisRunEventStore) Open(ctx context.Context, stream string) error {
+	_, err := s.add(ctx, stream, runStreamFieldOpened, "1", "")
+	return err
+}
+
+// Append records a chunk at the end of the stream
+func (s *RedisRunEventStore) Append(ctx context.Context, stream string, runID string, chunk *responses.ResponseChunk) (string, error) {
+	data, err := sonic.Marshal(chunk)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize chunk: %w", err)
+	}
+
+	return s.add(ctx, stream, runStreamFieldChunk, data, runID)
+}
+
+// Close appends a marker entry that ends the reads of the stream
+func (s *RedisRunEventStore) Close(ctx context.Context, stream string) error {
+	_, err := s.add(ctx, stream, runStreamFieldClosed, "1", "")
+	return err
+}
+
+// add appends an entry and extends the retention of the stream
+func (s *RedisRunEventStore) add(ctx context.Context, stream string, field string, value any, runID string) (string, error) {
+	id, err := runStreamAddScript.Run(ctx, s.client, []string{s.streamKey(stream)},
+		s.maxLen, int64(s.retention.Seconds()), field, value, runID).Text()
+	if err != nil {
+		return "", fmt.Errorf("failed to append to run stream: %w", err)
+	}
+
+	return id, nil
+}
+
+// Read returns the chunks after the offset, followed by the live ones
+func (s *RedisRunEventStore) Read(ctx context.Context, stream string, after string) (<-chan *core.RunEvent, error) {
+	if after != "" && !isStreamID(after) {
+		return nil, fmt.Errorf("%w: %s", core.ErrInvalidRunStreamOffset, after)
+	}
+
+	key := s.streamKey(stream)
+	exists, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check run stream: %w", err)
+	}
+	if exists == 0 {
+		return nil, core.ErrRunStreamNotFound
+	}
+
+	if after == "" {
+		after = "0"
+	}
+
+	ch := make(chan *core.RunEvent, 100)
+	go func() {
+		defer close(ch)
+
+		for {
+			result, err := s.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{key, after},
+				Count:   100,
+				Block:   5 * time.Second,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.ErrorContext(ctx, "Failed to read run stream", slog.String("stream", stream), slog.Any("error", err))
+				}
+				return
+			}
+
+			for _, xstream := range result {
+				for _, msg := range xstream.Messages {
+					after = msg.ID
+
+					if _, ok := msg.Values[runStreamFieldClosed]; ok {
+						return
+					}
+
+					data, ok := msg.Values[runStreamFieldChunk].(string)
+					if !ok {
+						continue
+					}
+
+					var chunk responses.ResponseChunk
+					if err := sonic.Unmarshal([]byte(data), &chunk); err != nil {
+						continue
+					}
+
+					runID, _ := msg.Values[runStreamFieldRunID].(string)
+					seqValue, _ := msg.Values[runStreamFieldSeq].(string)
+					seq, _ := strconv.ParseInt(seqValue, 10, 64)
+
+					select {
+					case ch <- &core.RunEvent{Offset: msg.ID, Seq: seq, RunID: runID, Chunk: &chunk}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// isStreamID reports whether the offset is a Redis stream entry ID, of the form <milliseconds>-<sequence>
+func isStreamID(offset string) bool {
+	ms, seq, _ := strings.Cut(offset, "-")
+	if _, err := strconv.ParseUint(ms, 10, 64); err != nil {
+		return false
+	}
+	if seq == "" {
+		return true
+	}
+	_, err := strconv.ParseUint(seq, 10, 64)
+	return err == nil
+}
+
+// Ensure RedisRunEventStore implements RunEventStore
+var _ core.RunEventStore = (*RedisRunEventStore)(nil)
@@ -0,0 +1,293 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStreamsBroker implements StreamBroker using Redis Streams. Unlike the Pub/Sub broker, the chunks are kept
+// in a stream per channel, so that nothing is lost when they are published before the subscriber connects or
+// while its connection to Redis is down.
+//
+// Subscribe reads the channel through a consumer group: chunks are acknowledged once delivered, and the pending
+// ones are delivered again when the subscriber reconnects. The subscribers of a channel in the same group share
+// its chunks, each chunk is delivered to one of them. SubscribeFrom reads the whole stream from an ID instead,
+// for clients resuming from the last chunk they received.
+type RedisStreamsBroker struct {
+	client    *redis.Client
+	prefix    string
+	group     string
+	consumer  string
+	retention time.Duration
+	maxLen    int64
+}
+
+// RedisStreamsBrokerOptions configures the Redis Streams broker.
+type RedisStreamsBrokerOptions struct {
+	// Prefix is prepended to all stream names (default "uno:broker:").
+	Prefix string
+
+	// Group is the consumer group of the subscribers (default "uno").
+	Group string
+
+	// Consumer is the name of the subscribers of this process in the group (default the hostname).
+	Consumer string
+
+	// Retention is how long a stream is kept after its last chunk (default 1 hour).
+	Retention time.Duration
+
+	// MaxLen caps the number of chunks kept per stream, older chunks are trimmed (default 10000).
+	MaxLen int64
+}
+
+// NewRedisStreamsBroker creates a Redis Streams broker on an existing Redis client.
+func NewRedisStreamsBroker(client *redis.Client, opts RedisStreamsBrokerOptions) *RedisStreamsBroker {
+	if opts.Prefix == "" {
+		opts.Prefix = "uno:broker:"
+	}
+	if opts.Group == "" {
+		opts.Group = "uno"
+	}
+	if opts.Consumer == "" {
+		opts.Consumer, _ = os.Hostname()
+		if opts.Consumer == "" {
+			opts.Consumer = "uno"
+		}
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = time.Hour
+	}
+	if opts.MaxLen <= 0 {
+		opts.MaxLen = 10000
+	}
+
+	return &RedisStreamsBroker{
+		client:    client,
+		prefix:    opts.Prefix,
+		group:     opts.Group,
+		consumer:  opts.Consumer,
+		retention: opts.Retention,
+		maxLen:    opts.MaxLen,
+	}
+}
+
+// streamKey returns the Redis key of the stream of the given channel.
+func (b *RedisStreamsBroker) streamKey(channel string) string {
+	return b.prefix + channel
+}
+
+// Publish appends a response chunk to the stream of the given channel.
+func (b *RedisStreamsBroker) Publish(ctx context.Context, channel string, chunk *responses.ResponseChunk) error {
+	data, err := sonic.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to serialize chunk: %w", err)
+	}
+
+	return b.add(ctx, channel, runStreamFieldChunk, data)
+}
+
+// Close appends a marker entry that ends the subscriptions of the channel.
+func (b *RedisStreamsBroker) Close(ctx context.Context, channel string) error {
+	return b.add(ctx, channel, runStreamFieldClosed, "1")
+}
+
+// add appends an entry and extends the retention of the stream
+func (b *RedisStreamsBroker) add(ctx context.Context, channel string, field string, value any) error {
+	key := b.streamKey(channel)
+
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: b.maxLen,
+			Approx: true,
+			Values: map[string]any{field: value},
+		})
+		pipe.Expire(ctx, key, b.retention)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish chunk: %w", err)
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel that receives the chunks of the given channel, from the first one, through the
+// consumer group of the broker. Reads are retried when Redis is unreachable, starting with the chunks that were
+// read but not acknowledged.
+func (b *RedisStreamsBroker) Subscribe(ctx context.Context, channel string) (<-chan *responses.ResponseChunk, error) {
+	key := b.streamKey(channel)
+
+	// The group is created with the stream, so that the chunks published before the first read are kept
+	err := b.client.XGroupCreateMkStream(ctx, key, b.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	if err := b.client.Expire(ctx, key, b.retention).Err(); err != nil {
+		return nil, fmt.Errorf("failed to set stream retention: %w", err)
+	}
+
+	ch := make(chan *responses.ResponseChunk, 100)
+	go func() {
+		defer close(ch)
+
+		// The pending chunks of the consumer are read first, "0", then the new ones, ">"
+		pending := true
+		for {
+			start := ">"
+			if pending {
+				start = "0"
+			}
+
+			result, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    b.group,
+				Consumer: b.consumer,
+				Streams:  []string{key, start},
+				Count:    100,
+				Block:    5 * time.Second,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if strings.HasPrefix(err.Error(), "NOGROUP") {
+					// The stream expired or was deleted
+					slog.WarnContext(ctx, "Broker stream is gone", slog.String("channel", channel))
+					return
+				}
+
+				slog.WarnContext(ctx, "Failed to read broker stream, retrying", slog.String("channel", channel), slog.Any("error", err))
+				pending = true
+				select {
+				case <-time.After(time.Second):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			read := 0
+			for _, xstream := range result {
+				for _, msg := range xstream.Messages {
+					read++
+
+					if _, ok := msg.Values[runStreamFieldClosed]; ok {
+						b.ack(ctx, key, msg.ID)
+						return
+					}
+
+					if data, ok := msg.Values[runStreamFieldChunk].(string); ok {
+						var chunk responses.ResponseChunk
+						if err := sonic.Unmarshal([]byte(data), &chunk); err == nil {
+							select {
+							case ch <- &chunk:
+							case <-ctx.Done():
+								return
+							}
+						}
+					}
+
+					b.ack(ctx, key, msg.ID)
+				}
+			}
+
+			if pending && read == 0 {
+				pending = false
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// ack acknowledges a chunk delivered to a subscriber, a failure only means it may be delivered again
+func (b *RedisStreamsBroker) ack(ctx context.Context, key string, id string) {
+	if err := b.client.XAck(ctx, key, b.group, id).Err(); err != nil && ctx.Err() == nil {
+		slog.WarnContext(ctx, "Failed to acknowledge broker chunk", slog.String("stream", key), slog.String("id", id), slog.Any("error", err))
+	}
+}
+
+// SubscribeFrom returns the chunks of the given channel after the ID of a chunk, followed by the live ones, along
+// with their IDs. An empty ID reads the channel from its first chunk.
+func (b *RedisStreamsBroker) SubscribeFrom(ctx context.Context, channel string, after string) (<-chan *core.RunEvent, error) {
+	if after != "" && !isStreamID(after) {
+		return nil, fmt.Errorf("%w: %s", core.ErrInvalidRunStreamOffset, after)
+	}
+	if after == "" {
+		after = "0"
+	}
+
+	key := b.streamKey(channel)
+	ch := make(chan *core.RunEvent, 100)
+	go func() {
+		defer close(ch)
+
+		for {
+			result, err := b.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{key, after},
+				Count:   100,
+				Block:   5 * time.Second,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				slog.WarnContext(ctx, "Failed to read broker stream, retrying", slog.String("channel", channel), slog.Any("error", err))
+				select {
+				case <-time.After(time.Second):
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			for _, xstream := range result {
+				for _, msg := range xstream.Messages {
+					after = msg.ID
+
+					if _, ok := msg.Values[runStreamFieldClosed]; ok {
+						return
+					}
+
+					data, ok := msg.Values[runStreamFieldChunk].(string)
+					if !ok {
+						continue
+					}
+
+					var chunk responses.ResponseChunk
+					if err := sonic.Unmarshal([]byte(data), &chunk); err != nil {
+						continue
+					}
+
+					select {
+					case ch <- &core.RunEvent{Offset: msg.ID, Chunk: &chunk}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Ensure RedisStreamsBroker implements ResumableStreamBroker
+var _ core.ResumableStreamBroker = (*RedisStreamsBroker)(nil)
@@ -12,6 +12,7 @@ import (
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/curaious/uno/pkg/llm/constants"
 	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/curaious/uno/pkg/llm/textsplitter"
 	"github.com/google/uuid"
 )
 
@@ -21,6 +22,9 @@ type LLMHistorySummarizer struct {
 	tokenThreshold  int
 	keepRecentCount int // Number of recent messages to keep unsummarized
 	parameters      responses.Parameters
+	shadowMode      bool
+	tokenizer       textsplitter.Tokenizer
+	maxInputTokens  int
 }
 
 type LLMHistorySummarizerOptions struct {
@@ -29,6 +33,13 @@ type LLMHistorySummarizerOptions struct {
 	TokenThreshold  int
 	KeepRecentCount int // Optional: defaults to 5
 	Parameters      responses.Parameters
+	ShadowMode      bool // Optional: generate summaries only to compare the answers with and without them
+
+	// MaxInputTokens is the budget of the conversation sent to the LLM, counted by Tokenizer (default the
+	// tokenizer of the OpenAI models). A longer conversation is split into parts summarized one by one, whose
+	// summaries are then merged. Unlimited when 0.
+	MaxInputTokens int
+	Tokenizer      textsplitter.Tokenizer
 }
 
 func NewLLMHistorySummarizer(opts *LLMHistorySummarizerOptions) *LLMHistorySummarizer {
@@ -37,12 +48,20 @@ func NewLLMHistorySummarizer(opts *LLMHistorySummarizerOptions) *LLMHistorySumma
 		keepRecentCount = opts.KeepRecentCount
 	}
 
+	tokenizer := opts.Tokenizer
+	if tokenizer == nil {
+		tokenizer = textsplitter.TokenizerFor(llm.ProviderNameOpenAI)
+	}
+
 	return &LLMHistorySummarizer{
 		llm:             opts.LLM,
 		instruction:     opts.Instruction,
 		tokenThreshold:  opts.TokenThreshold,
 		keepRecentCount: keepRecentCount,
 		parameters:      opts.Parameters,
+		shadowMode:      opts.ShadowMode,
+		tokenizer:       tokenizer,
+		maxInputTokens:  opts.MaxInputTokens,
 	}
 }
 
@@ -129,9 +148,14 @@ func (s *LLMHistorySummarizer) Summarize(ctx context.Context, msgIdToRunId map[s
 		}
 	}
 
+	pinnedRuns := core.PinnedRunsFromContext(ctx)
 	messagesToSummarize := []responses.InputMessageUnion{}
+	pinned := []bool{}
 	for _, run := range runsToSummarize {
 		messagesToSummarize = append(messagesToSummarize, run.Messages...)
+		for range run.Messages {
+			pinned = append(pinned, slices.Contains(pinnedRuns, run.RunID))
+		}
 	}
 
 	messagesToKeep := []responses.InputMessageUnion{}
@@ -139,22 +163,57 @@ func (s *LLMHistorySummarizer) Summarize(ctx context.Context, msgIdToRunId map[s
 		messagesToKeep = append(messagesToKeep, run.Messages...)
 	}
 
-	// Get instruction for summarization
-	var instruction string
-	var err error
-	if s.instruction != nil {
-		instruction, err = s.instruction.GetPrompt(ctx, nil)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	if s.instruction == nil {
 		slog.WarnContext(ctx, "summarizer is missing system instructions, skipping summarization")
 		return nil, nil
 	}
 
+	summaryMessage, summaryUsage, err := s.summarizeMessages(ctx, messagesToSummarize, pinned)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate summary ID
+	summaryID := uuid.NewString()
+
+	return &core.SummaryResult{
+		Summary:                 summaryMessage,
+		LastSummarizedMessageID: lastSummarizedMessageID,
+		SummaryID:               summaryID,
+		MessagesToKeep:          messagesToKeep,
+		Usage:                   summaryUsage,
+		Shadow:                  s.shadowMode,
+	}, nil
+}
+
+// SummarizeMessages summarizes the messages into a system message with the LLM, regardless of the token
+// threshold. It returns the summary along with the usage of generating it.
+func (s *LLMHistorySummarizer) SummarizeMessages(ctx context.Context, messagesToSummarize []responses.InputMessageUnion) (*responses.InputMessageUnion, *responses.Usage, error) {
+	return s.summarizeMessages(ctx, messagesToSummarize, nil)
+}
+
+// summarizeMessages summarizes the messages, asking the LLM to keep the content of the pinned ones in full
+func (s *LLMHistorySummarizer) summarizeMessages(ctx context.Context, messagesToSummarize []responses.InputMessageUnion, pinned []bool) (*responses.InputMessageUnion, *responses.Usage, error) {
+	if s.instruction == nil {
+		return nil, nil, fmt.Errorf("summarizer is missing system instructions")
+	}
+
+	// Get instruction for summarization
+	instruction, err := s.instruction.GetPrompt(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Format history for summarization
 	var historyBuilder strings.Builder
-	for _, msg := range messagesToSummarize {
+	hasPinned := false
+	for i, msg := range messagesToSummarize {
+		isPinned := i < len(pinned) && pinned[i]
+		if isPinned {
+			hasPinned = true
+			historyBuilder.WriteString("[Pinned]\n")
+		}
+
 		switch {
 		case msg.OfEasyInput != nil:
 			if msg.OfEasyInput.Content.OfString != nil {
@@ -191,21 +250,89 @@ func (s *LLMHistorySummarizer) Summarize(ctx context.Context, msgIdToRunId map[s
 			historyBuilder.WriteString(fmt.Sprintf("[Tool Call: %s]\n", msg.OfFunctionCall.Name))
 
 		case msg.OfFunctionCallOutput != nil:
-			historyBuilder.WriteString(fmt.Sprintf("[Tool Result: %s]\n", msg.OfFunctionCallOutput.Output.OfString))
+			if msg.OfFunctionCallOutput.Output.OfString != nil {
+				historyBuilder.WriteString(fmt.Sprintf("[Tool Result: %s]\n", *msg.OfFunctionCallOutput.Output.OfString))
+			}
+		}
+
+		if isPinned {
+			historyBuilder.WriteString("[/Pinned]\n")
 		}
+	}
+
+	request := "Please summarize the following conversation history, preserving important context, decisions, and information that would be needed for future interactions"
+	if hasPinned {
+		request += ". The messages between [Pinned] and [/Pinned] were pinned by the user: keep their content in full, don't drop nor shorten it"
+	}
 
+	// A conversation over the budget of the LLM is summarized in parts, whose summaries are merged
+	parts := []string{historyBuilder.String()}
+	if s.maxInputTokens > 0 && s.tokenizer.CountTokens(parts[0]) > s.maxInputTokens {
+		parts = textsplitter.NewSentenceSplitter(&textsplitter.Options{
+			Tokenizer: s.tokenizer,
+			ChunkSize: s.maxInputTokens,
+		}).Split(parts[0])
 	}
 
+	usage := &responses.Usage{}
+	var summaries []string
+	var summaryId string
+	for i, part := range parts {
+		partRequest := request
+		if len(parts) > 1 {
+			partRequest = fmt.Sprintf("%s. This is part %d of %d of the history", request, i+1, len(parts))
+		}
+
+		text, id, partUsage, err := s.generate(ctx, instruction, fmt.Sprintf("%s:\n\n%s", partRequest, part))
+		if err != nil {
+			return nil, nil, err
+		}
+		addUsage(usage, partUsage)
+		summaries = append(summaries, text)
+		summaryId = id
+	}
+
+	summaryText := summaries[0]
+	if len(summaries) > 1 {
+		mergeRequest := "Please merge the following summaries of consecutive parts of a conversation history into one summary, preserving important context, decisions, and information that would be needed for future interactions"
+		if hasPinned {
+			mergeRequest += ". Keep the content of the pinned messages in full"
+		}
+		text, id, mergeUsage, err := s.generate(ctx, instruction, fmt.Sprintf("%s:\n\n%s", mergeRequest, strings.Join(summaries, "\n\n")))
+		if err != nil {
+			return nil, nil, err
+		}
+		addUsage(usage, mergeUsage)
+		summaryText, summaryId = text, id
+	}
+
+	if summaryText == "" {
+		return nil, nil, fmt.Errorf("empty summary generated")
+	}
+
+	// Return summary as SystemMessage
+	summaryMessage := responses.InputMessageUnion{
+		OfInputMessage: &responses.InputMessage{
+			ID:      summaryId,
+			Role:    constants.RoleSystem,
+			Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: fmt.Sprintf("Previous conversation summary: %s", summaryText)}}},
+		},
+	}
+
+	return &summaryMessage, usage, nil
+}
+
+// generate asks the LLM for the summary, returning its text and ID
+func (s *LLMHistorySummarizer) generate(ctx context.Context, instruction string, prompt string) (string, string, *responses.Usage, error) {
 	userMsg := responses.InputMessageUnion{OfInputMessage: &responses.InputMessage{
 		Role: constants.RoleUser,
 		Content: responses.InputContent{
 			{
-				OfInputText: &responses.InputTextContent{Text: fmt.Sprintf("Please summarize the following conversation history, preserving important context, decisions, and information that would be needed for future interactions:\n\n%s", historyBuilder.String())},
+				OfInputText: &responses.InputTextContent{Text: prompt},
 			},
 		},
 	}}
 
-	// Invoke LLM for summarization
 	resp, err := s.llm.NewResponses(ctx, &responses.Request{
 		Instructions: utils.Ptr(instruction),
 		Input: responses.InputUnion{
@@ -214,46 +341,36 @@ func (s *LLMHistorySummarizer) Summarize(ctx context.Context, msgIdToRunId map[s
 		Parameters: s.parameters,
 	})
 	if err != nil {
-		return nil, err
+		return "", "", nil, err
 	}
 
-	summaryMsg := resp.Output
-
-	// Extract text from summary
 	var summaryText string
-	for _, msg := range summaryMsg {
+	for _, msg := range resp.Output {
+		if msg.OfOutputMessage == nil {
+			continue
+		}
 		for _, content := range msg.OfOutputMessage.Content {
-			summaryText += content.OfOutputText.Text
+			if content.OfOutputText != nil {
+				summaryText += content.OfOutputText.Text
+			}
 		}
 	}
 
 	var summaryId string
-	if len(summaryMsg) > 0 {
-		if summaryMsg[0].OfOutputMessage != nil {
-			summaryId = summaryMsg[0].OfOutputMessage.ID
-		}
+	if len(resp.Output) > 0 && resp.Output[0].OfOutputMessage != nil {
+		summaryId = resp.Output[0].OfOutputMessage.ID
 	}
 
-	if summaryText == "" {
-		return nil, fmt.Errorf("empty summary generated")
-	}
+	return summaryText, summaryId, resp.Usage, nil
+}
 
-	// Return summary as SystemMessage
-	summaryMessage := responses.InputMessageUnion{
-		OfInputMessage: &responses.InputMessage{
-			ID:      summaryId,
-			Role:    constants.RoleSystem,
-			Content: responses.InputContent{{OfInputText: &responses.InputTextContent{Text: fmt.Sprintf("Previous conversation summary: %s", summaryText)}}},
-		},
+func addUsage(total *responses.Usage, usage *responses.Usage) {
+	if usage == nil {
+		return
 	}
-
-	// Generate summary ID
-	summaryID := uuid.NewString()
-
-	return &core.SummaryResult{
-		Summary:                 &summaryMessage,
-		LastSummarizedMessageID: lastSummarizedMessageID,
-		SummaryID:               summaryID,
-		MessagesToKeep:          messagesToKeep,
-	}, nil
+	total.InputTokens += usage.InputTokens
+	total.InputTokensDetails.CachedTokens += usage.InputTokensDetails.CachedTokens
+	total.OutputTokens += usage.OutputTokens
+	total.OutputTokensDetails.ReasoningTokens += usage.OutputTokensDetails.ReasoningTokens
+	total.TotalTokens += usage.TotalTokens
 }
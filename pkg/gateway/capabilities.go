@@ -0,0 +1,117 @@
+package gateway
+
+import (
+	"slices"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// omittedImageText replaces the images sent to the models without image input
+const omittedImageText = "[image omitted, the model doesn't support images]"
+
+// capabilities returns the capabilities declared for the provider, nil when it supports everything
+func (g *LLMGateway) capabilities(providerName llm.ProviderName) *ProviderCapabilities {
+	config, err := g.ConfigStore.GetProviderConfig(providerName)
+	if err != nil || config == nil {
+		return nil
+	}
+
+	return config.Capabilities
+}
+
+// degradeRequest returns the request without the features the provider doesn't support, so that it is not rejected
+// by the server. Without tools, the tools other than functions and their calls are dropped, the function tools are
+// described in the prompt, see shimsTools. Without reasoning, the reasoning parameters and items are dropped. Without
+// images, the images are replaced by a text telling the model they were omitted.
+func degradeRequest(capabilities *ProviderCapabilities, in *responses.Request) *responses.Request {
+	if capabilities == nil || (capabilities.Tools && capabilities.Reasoning && capabilities.Images) {
+		return in
+	}
+
+	req := *in
+
+	if !capabilities.Tools {
+		req.Tools = nil
+		for _, tool := range in.Tools {
+			if tool.OfFunction != nil {
+				req.Tools = append(req.Tools, tool)
+			}
+		}
+		if len(req.Tools) == 0 {
+			req.ToolChoice = nil
+		}
+		req.MaxToolCalls = nil
+		req.ParallelToolCalls = nil
+	}
+
+	if !capabilities.Reasoning {
+		req.Reasoning = nil
+		req.Include = slices.DeleteFunc(slices.Clone(in.Include), func(include responses.Includable) bool {
+			return include == responses.IncludableReasoningEncryptedContent
+		})
+	}
+
+	if in.Input.OfInputMessageList != nil {
+		req.Input = responses.InputUnion{OfInputMessageList: degradeInput(capabilities, in.Input.OfInputMessageList)}
+	}
+
+	return &req
+}
+
+func degradeInput(capabilities *ProviderCapabilities, in responses.InputMessageList) responses.InputMessageList {
+	out := make(responses.InputMessageList, 0, len(in))
+
+	for _, msg := range in {
+		switch {
+		case msg.OfReasoning != nil && !capabilities.Reasoning:
+			continue
+
+		case (msg.OfWebSearchCall != nil || msg.OfCodeInterpreterCall != nil || msg.OfFileSearchCall != nil || msg.OfImageGenerationCall != nil) && !capabilities.Tools:
+			continue
+
+		case msg.OfEasyInput != nil && msg.OfEasyInput.Content.OfInputMessageList != nil && !capabilities.Images:
+			easy := *msg.OfEasyInput
+			easy.Content = responses.EasyInputContentUnion{OfInputMessageList: omitImages(easy.Content.OfInputMessageList)}
+			msg = responses.InputMessageUnion{OfEasyInput: &easy}
+
+		case msg.OfInputMessage != nil && !capabilities.Images:
+			input := *msg.OfInputMessage
+			input.Content = omitImages(input.Content)
+			msg = responses.InputMessageUnion{OfInputMessage: &input}
+
+		case msg.OfFunctionCallOutput != nil && msg.OfFunctionCallOutput.Output.OfList != nil && !capabilities.Images:
+			output := *msg.OfFunctionCallOutput
+			output.Output = responses.FunctionCallOutputContentUnion{OfList: omitImages(output.Output.OfList)}
+			msg = responses.InputMessageUnion{OfFunctionCallOutput: &output}
+		}
+
+		out = append(out, msg)
+	}
+
+	return out
+}
+
+// omitImages replaces the images of the content by a text
+func omitImages(in responses.InputContent) responses.InputContent {
+	out := make(responses.InputContent, 0, len(in))
+	for _, content := range in {
+		if content.OfInputImage != nil {
+			content = responses.InputContentUnion{OfInputText: &responses.InputTextContent{Text: omittedImageText}}
+		}
+		out = append(out, content)
+	}
+
+	return out
+}
+
+// hasFunctionCalls reports whether the input has function calls or their outputs
+func hasFunctionCalls(in responses.InputUnion) bool {
+	for _, msg := range in.OfInputMessageList {
+		if msg.OfFunctionCall != nil || msg.OfFunctionCallOutput != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func capabilitiesRequest(t *testing.T) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "qwen2.5-7b-instruct",
+		"input": [
+			{"type": "message", "role": "user", "content": [
+				{"type": "input_text", "text": "What is in this picture?"},
+				{"type": "input_image", "image_url": "https://example.com/cat.png"}
+			]},
+			{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "A cat."}]},
+			{"type": "message", "role": "user", "content": "Search for cats"}
+		],
+		"tools": [
+			{"type": "web_search"},
+			{"type": "function", "name": "get_weather", "parameters": {"type": "object", "properties": {}}}
+		],
+		"parallel_tool_calls": true,
+		"reasoning": {"effort": "low"},
+		"include": ["reasoning.encrypted_content"]
+	}`), &req))
+	return &req
+}
+
+func TestDegradeRequest(t *testing.T) {
+	in := capabilitiesRequest(t)
+
+	out := degradeRequest(&ProviderCapabilities{}, in)
+
+	require.Len(t, out.Tools, 1)
+	assert.NotNil(t, out.Tools[0].OfFunction)
+	assert.Nil(t, out.ParallelToolCalls)
+	assert.Nil(t, out.Reasoning)
+	assert.Empty(t, out.Include)
+
+	input := out.Input.OfInputMessageList
+	require.Len(t, input, 2)
+	content := input[0].OfEasyInput.Content.OfInputMessageList
+	require.Len(t, content, 2)
+	assert.Nil(t, content[1].OfInputImage)
+	assert.Equal(t, omittedImageText, content[1].OfInputText.Text)
+
+	// The request of the caller is left as it is
+	assert.NotNil(t, in.Reasoning)
+	assert.Len(t, in.Tools, 2)
+	assert.NotNil(t, in.Input.OfInputMessageList[0].OfEasyInput.Content.OfInputMessageList[1].OfInputImage)
+}
+
+func TestDegradeRequest_AllCapabilities(t *testing.T) {
+	in := capabilitiesRequest(t)
+
+	assert.Same(t, in, degradeRequest(nil, in))
+	assert.Same(t, in, degradeRequest(&ProviderCapabilities{Tools: true, Reasoning: true, Images: true}, in))
+
+	out := degradeRequest(&ProviderCapabilities{Tools: true, Reasoning: true}, in)
+	assert.Len(t, out.Tools, 2)
+	assert.NotNil(t, out.Reasoning)
+	assert.Len(t, out.Input.OfInputMessageList, 3)
+}
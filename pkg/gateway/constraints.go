@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// defaultConstraintAttempts bounds the attempts of an emulated constraint
+const defaultConstraintAttempts = 3
+
+// ConstraintError is returned when no attempt of an emulated constraint produced matching output
+type ConstraintError struct {
+	Attempts int
+	Output   string // Output of the last attempt
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("output does not match the constraint after %d attempts", e.Attempts)
+}
+
+// emulatesConstraint reports whether the constraint of the request has to be emulated for the provider
+func emulatesConstraint(p llm.Provider, in *responses.Request) bool {
+	if in.Constraint == nil {
+		return false
+	}
+
+	cp, ok := p.(llm.ConstrainedProvider)
+	return !ok || !cp.SupportsConstraint(in.Constraint)
+}
+
+// constraintMatcher compiles the regex of a constraint that is emulated. Grammars can only be enforced by
+// providers that support them.
+func constraintMatcher(c *responses.Constraint) (*regexp.Regexp, error) {
+	switch c.Type {
+	case responses.ConstraintTypeRegex:
+		re, err := regexp.Compile("^(?:" + c.Definition + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex constraint: %w", err)
+		}
+		return re, nil
+	case responses.ConstraintTypeGrammar:
+		return nil, errors.New("grammar constraints are not supported by this provider")
+	}
+
+	return nil, fmt.Errorf("unknown constraint type '%s'", c.Type)
+}
+
+// constraintAttempt returns the request of an attempt of an emulated constraint. The constraint is described
+// in the instructions, and the outputs of the previous attempts are added to the input with a correction.
+func constraintAttempt(in *responses.Request, previous [][]responses.OutputMessageUnion) (*responses.Request, error) {
+	req := *in
+	req.Constraint = nil
+
+	instructions := fmt.Sprintf("Respond only with text that fully matches the regular expression %s, without any other text.", in.Constraint.Definition)
+	if in.Instructions != nil && *in.Instructions != "" {
+		instructions = *in.Instructions + "\n\n" + instructions
+	}
+	req.Instructions = utils.Ptr(instructions)
+
+	if len(previous) == 0 {
+		return &req, nil
+	}
+
+	input := []responses.InputMessageUnion{}
+	if in.Input.OfString != nil {
+		input = append(input, responses.UserMessage(*in.Input.OfString))
+	} else {
+		input = append(input, in.Input.OfInputMessageList...)
+	}
+
+	for _, output := range previous {
+		for _, out := range output {
+			msg, err := out.AsInput()
+			if err != nil {
+				return nil, err
+			}
+			input = append(input, msg)
+		}
+		input = append(input, responses.UserMessage(fmt.Sprintf("That response does not match the regular expression %s. Respond again with only text that matches it.", in.Constraint.Definition)))
+	}
+
+	req.Input = responses.InputUnion{OfInputMessageList: input}
+	return &req, nil
+}
+
+func constraintAttempts(c *responses.Constraint) int {
+	if c.MaxAttempts != nil && *c.MaxAttempts > 0 {
+		return *c.MaxAttempts
+	}
+	return defaultConstraintAttempts
+}
+
+// emulateConstraint validates the output against the constraint, asking the model to try again until it matches
+func (g *LLMGateway) emulateConstraint(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (*responses.Response, error) {
+	re, err := constraintMatcher(in.Constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous [][]responses.OutputMessageUnion
+	usage := &responses.Usage{}
+	attempts := constraintAttempts(in.Constraint)
+	for attempt := 1; ; attempt++ {
+		req, err := constraintAttempt(in, previous)
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := g.handleResponsesRequest(ctx, providerName, p, req)
+		if err != nil {
+			return nil, err
+		}
+		addUsage(usage, out.Usage)
+		out.Usage = usage
+
+		text := outputText(out.Output)
+		if re.MatchString(text) {
+			return out, nil
+		}
+		if attempt >= attempts {
+			return nil, &ConstraintError{Attempts: attempt, Output: text}
+		}
+		previous = append(previous, out.Output)
+	}
+}
+
+// emulateStreamingConstraint is emulateConstraint for streaming requests. The chunks of an attempt are held
+// back until the output is known to match, so the stream is only delivered once an attempt succeeded.
+func (g *LLMGateway) emulateStreamingConstraint(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (chan *responses.ResponseChunk, error) {
+	re, err := constraintMatcher(in.Constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous [][]responses.OutputMessageUnion
+	usage := responses.Usage{}
+	attempts := constraintAttempts(in.Constraint)
+	for attempt := 1; ; attempt++ {
+		req, err := constraintAttempt(in, previous)
+		if err != nil {
+			return nil, err
+		}
+
+		stream, err := g.handleStreamingResponsesRequest(ctx, providerName, p, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var chunks []*responses.ResponseChunk
+		var completed *responses.ChunkResponseData
+		for chunk := range stream {
+			chunks = append(chunks, chunk)
+			if chunk.OfResponseCompleted != nil {
+				completed = &chunk.OfResponseCompleted.Response
+			}
+		}
+
+		if completed == nil {
+			return nil, errors.New("stream ended without a completed response")
+		}
+		addUsage(&usage, &completed.Usage)
+		completed.Usage = usage
+
+		text := outputText(completed.Output)
+		if re.MatchString(text) {
+			out := make(chan *responses.ResponseChunk, len(chunks))
+			for _, chunk := range chunks {
+				out <- chunk
+			}
+			close(out)
+			return out, nil
+		}
+		if attempt >= attempts {
+			return nil, &ConstraintError{Attempts: attempt, Output: text}
+		}
+		previous = append(previous, completed.Output)
+	}
+}
+
+func addUsage(total *responses.Usage, usage *responses.Usage) {
+	if usage == nil {
+		return
+	}
+	total.InputTokens += usage.InputTokens
+	total.InputTokensDetails.CachedTokens += usage.InputTokensDetails.CachedTokens
+	total.OutputTokens += usage.OutputTokens
+	total.OutputTokensDetails.ReasoningTokens += usage.OutputTokensDetails.ReasoningTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// outputText joins the output text of the messages of a response
+func outputText(output []responses.OutputMessageUnion) string {
+	var sb strings.Builder
+	for _, out := range output {
+		if out.OfOutputMessage == nil {
+			continue
+		}
+		for _, content := range out.OfOutputMessage.Content {
+			if content.OfOutputText != nil {
+				sb.WriteString(content.OfOutputText.Text)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
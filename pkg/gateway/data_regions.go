@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// ErrProviderNotApprovedForRegion is returned for requests made for a data region the provider is not approved for
+var ErrProviderNotApprovedForRegion = errors.New("provider is not approved for the data region")
+
+type dataRegionKey struct{}
+
+// ContextWithDataRegion restricts the requests made with the context to the providers approved for the data
+// region. Unlike ContextWithRegion, it is a compliance boundary: the request fails instead of being sent to a
+// provider that is not approved.
+func ContextWithDataRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, dataRegionKey{}, region)
+}
+
+// DataRegionFromContext returns the data region set by ContextWithDataRegion
+func DataRegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(dataRegionKey{}).(string)
+	return region, ok && region != ""
+}
+
+// checkDataRegion rejects providers that are not approved for the data region of the request. When the provider
+// has an endpoint named after the data region, the request is pinned to it.
+func checkDataRegion(ctx context.Context, providerName llm.ProviderName, config *ProviderConfig) (context.Context, error) {
+	dataRegion, ok := DataRegionFromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	if config == nil || !slices.Contains(config.DataRegions, dataRegion) {
+		return ctx, fmt.Errorf("%w: %s is not approved for %s", ErrProviderNotApprovedForRegion, providerName, dataRegion)
+	}
+
+	if _, pinned := RegionFromContext(ctx); !pinned {
+		for _, region := range config.Regions {
+			if region.Name == dataRegion {
+				return ContextWithRegion(ctx, dataRegion), nil
+			}
+		}
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// redactedHeaders carry the credentials of the providers, they are not returned by dry runs
+var redactedHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "X-Amz-Security-Token"}
+
+// dryRunCapture is the error a dry run transport stops the request with, carrying the request
+type dryRunCapture struct {
+	dryRun *responses.DryRun
+}
+
+func (c *dryRunCapture) Error() string {
+	return "dry run: the request was not sent"
+}
+
+// dryRunTransport captures the requests instead of sending them
+type dryRunTransport struct {
+	provider llm.ProviderName
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		payload, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	headers := make(map[string]string, len(req.Header))
+	for name := range req.Header {
+		headers[name] = req.Header.Get(name)
+	}
+	for _, name := range redactedHeaders {
+		if _, ok := headers[name]; ok {
+			headers[name] = "[REDACTED]"
+		}
+	}
+
+	return nil, &dryRunCapture{dryRun: &responses.DryRun{
+		Provider:             string(t.provider),
+		URL:                  req.URL.String(),
+		Headers:              headers,
+		Payload:              payload,
+		EstimatedInputTokens: llm.EstimateTokens(payload),
+	}}
+}
+
+// dryRunClient returns an HTTP client capturing the requests instead of sending them
+func dryRunClient(provider llm.ProviderName) *http.Client {
+	return &http.Client{Transport: &dryRunTransport{provider: provider}}
+}
+
+// dryRunOf returns the request captured by a dry run from the error of the provider
+func dryRunOf(err error) (*responses.DryRun, bool) {
+	var capture *dryRunCapture
+	if errors.As(err, &capture) {
+		return capture.dryRun, true
+	}
+	return nil, false
+}
+
+// dryRunResponses runs the request against a provider built with a dry run client, and returns the captured request
+func dryRunResponses(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request, streaming bool) (*responses.Response, error) {
+	var err error
+	if streaming {
+		_, err = p.NewStreamingResponses(ctx, in)
+	} else {
+		_, err = p.NewResponses(ctx, in)
+	}
+
+	dryRun, ok := dryRunOf(err)
+	if !ok {
+		if err == nil {
+			err = errors.New("dry run: the provider did not send a request")
+		}
+		return nil, err
+	}
+
+	return &responses.Response{
+		Model:    in.Model,
+		Provider: string(providerName),
+		DryRun:   dryRun,
+	}, nil
+}
+
+// dryRunChunks streams the captured request of a dry run as a single chunk
+func dryRunChunks(resp *responses.Response) chan *responses.ResponseChunk {
+	out := make(chan *responses.ResponseChunk, 1)
+	out <- &responses.ResponseChunk{
+		OfDryRun: &responses.ChunkDryRun[constants.ChunkTypeDryRun]{DryRun: *resp.DryRun},
+	}
+	close(out)
+	return out
+}
@@ -0,0 +1,296 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// Faults injected in the requests to the providers
+const (
+	FaultRateLimit      = "rate_limit"       // The provider responds 429 Too Many Requests
+	FaultServerError    = "server_error"     // The provider responds 500 Internal Server Error
+	FaultSlowFirstChunk = "slow_first_chunk" // The first bytes of the response are delayed
+	FaultDisconnect     = "disconnect"       // The stream is cut after its first event
+	FaultMalformedChunk = "malformed_chunk"  // An event that is not valid JSON is sent after the first event
+)
+
+// defaultSlowFirstChunkDelay is the delay of the slow_first_chunk fault when the config has none
+const defaultSlowFirstChunkDelay = 5 * time.Second
+
+// malformedChunk is the event of the malformed_chunk fault, cut in the middle of its JSON
+var malformedChunk = []byte("data: {\"type\":\"response.output_text.delta\",\"delta\":\"inj\n\n")
+
+// FaultConfig configures the faults injected in the requests to the providers, so that retries, fallbacks and the
+// converters of the responses can be verified in staging. Rates are the probabilities, from 0 to 1, that a request
+// gets the fault. A request gets at most one of the rate limit and server errors, which are returned without
+// sending the request, and any of the other faults.
+type FaultConfig struct {
+	RateLimitRate      float64
+	ServerErrorRate    float64
+	SlowFirstChunkRate float64
+	DisconnectRate     float64 // Only applies to streamed responses
+	MalformedChunkRate float64 // Only applies to streamed responses
+
+	// SlowFirstChunkDelay is how long the first bytes of the response are delayed, 5 seconds by default
+	SlowFirstChunkDelay time.Duration
+
+	// Providers limits the faults to the requests to these providers, all providers get them when it is empty
+	Providers []llm.ProviderName
+}
+
+// IsZero reports whether the config injects no fault
+func (c FaultConfig) IsZero() bool {
+	return c.RateLimitRate <= 0 && c.ServerErrorRate <= 0 && c.SlowFirstChunkRate <= 0 && c.DisconnectRate <= 0 && c.MalformedChunkRate <= 0
+}
+
+// appliesTo reports whether the requests to the provider get the faults, matching the names of the providers
+// case-insensitively
+func (c FaultConfig) appliesTo(provider llm.ProviderName) bool {
+	if len(c.Providers) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.Providers, func(p llm.ProviderName) bool {
+		return strings.EqualFold(string(p), string(provider))
+	})
+}
+
+// ParseFaultConfig parses a fault config from comma separated <fault>=<rate> pairs, e.g.
+// "rate_limit=0.05,server_error=0.02,slow_first_chunk=0.1,slow_first_chunk_delay=3s,providers=openai|anthropic"
+func ParseFaultConfig(spec string) (FaultConfig, error) {
+	var config FaultConfig
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return FaultConfig{}, fmt.Errorf("invalid fault '%s', expected <fault>=<rate>", entry)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		switch name {
+		case "slow_first_chunk_delay":
+			delay, err := time.ParseDuration(value)
+			if err != nil {
+				return FaultConfig{}, fmt.Errorf("invalid slow_first_chunk_delay: %w", err)
+			}
+			config.SlowFirstChunkDelay = delay
+			continue
+		case "providers":
+			for _, provider := range strings.Split(value, "|") {
+				if provider = strings.TrimSpace(provider); provider != "" {
+					config.Providers = append(config.Providers, llm.ProviderName(provider))
+				}
+			}
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return FaultConfig{}, fmt.Errorf("invalid rate of %s: must be between 0 and 1", name)
+		}
+
+		switch name {
+		case FaultRateLimit:
+			config.RateLimitRate = rate
+		case FaultServerError:
+			config.ServerErrorRate = rate
+		case FaultSlowFirstChunk:
+			config.SlowFirstChunkRate = rate
+		case FaultDisconnect:
+			config.DisconnectRate = rate
+		case FaultMalformedChunk:
+			config.MalformedChunkRate = rate
+		default:
+			return FaultConfig{}, fmt.Errorf("unknown fault '%s'", name)
+		}
+	}
+
+	return config, nil
+}
+
+// NewFaultInjectingClient returns an HTTP client injecting the faults of the config in the requests to the provider.
+// Without faults for the provider the client is returned as it is.
+func NewFaultInjectingClient(client *http.Client, provider llm.ProviderName, config FaultConfig) *http.Client {
+	if config.IsZero() || !config.appliesTo(provider) {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	injecting := *client
+	injecting.Transport = &faultTransport{provider: provider, config: config, base: base}
+	return &injecting
+}
+
+type faultTransport struct {
+	provider llm.ProviderName
+	config   FaultConfig
+	base     http.RoundTripper
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	switch {
+	case hit(t.config.RateLimitRate):
+		t.log(ctx, req, FaultRateLimit)
+		return faultResponse(req, http.StatusTooManyRequests, "rate_limit_error"), nil
+	case hit(t.config.ServerErrorRate):
+		t.log(ctx, req, FaultServerError)
+		return faultResponse(req, http.StatusInternalServerError, "api_error"), nil
+	}
+
+	var delay time.Duration
+	if hit(t.config.SlowFirstChunkRate) {
+		t.log(ctx, req, FaultSlowFirstChunk)
+		delay = t.config.SlowFirstChunkDelay
+		if delay <= 0 {
+			delay = defaultSlowFirstChunkDelay
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body := &faultBody{ctx: ctx, body: resp.Body, delay: delay}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		if hit(t.config.DisconnectRate) {
+			t.log(ctx, req, FaultDisconnect)
+			body.disconnect = true
+		} else if hit(t.config.MalformedChunkRate) {
+			t.log(ctx, req, FaultMalformedChunk)
+			body.malformed = true
+		}
+	}
+
+	if body.delay > 0 || body.disconnect || body.malformed {
+		resp.Body = body
+	}
+	return resp, nil
+}
+
+func (t *faultTransport) log(ctx context.Context, req *http.Request, fault string) {
+	slog.WarnContext(ctx, "Injected fault in provider request", slog.String("provider", string(t.provider)), slog.String("fault", fault), slog.String("url", req.URL.String()))
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// faultResponse is an error response of a provider, with the error in the format shared by OpenAI and Anthropic
+func faultResponse(req *http.Request, status int, errorType string) *http.Response {
+	payload := fmt.Sprintf(`{"type":"error","error":{"type":%q,"code":%d,"message":"injected fault: %s"}}`, errorType, status, http.StatusText(status))
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if status == http.StatusTooManyRequests {
+		header.Set("Retry-After", "1")
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       req,
+	}
+}
+
+// faultBody delays the first bytes of a response, and cuts the stream or injects a malformed event after its first
+// event
+type faultBody struct {
+	ctx   context.Context
+	body  io.ReadCloser
+	delay time.Duration
+
+	disconnect bool
+	malformed  bool
+
+	// last is the last byte read, to find the end of the first event across reads
+	last       byte
+	firstEvent bool
+	cut        bool
+	pending    []byte
+}
+
+func (b *faultBody) Read(p []byte) (int, error) {
+	if b.delay > 0 {
+		timer := time.NewTimer(b.delay)
+		b.delay = 0
+		select {
+		case <-timer.C:
+		case <-b.ctx.Done():
+			timer.Stop()
+			return 0, b.ctx.Err()
+		}
+	}
+
+	if len(b.pending) > 0 {
+		n := copy(p, b.pending)
+		b.pending = b.pending[n:]
+		return n, nil
+	}
+	if b.cut {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	n, err := b.body.Read(p)
+	if b.firstEvent || (!b.disconnect && !b.malformed) || n == 0 {
+		return n, err
+	}
+
+	// The end of the first event is the first blank line
+	end := -1
+	if b.last == '\n' && p[0] == '\n' {
+		end = 1
+	} else if idx := bytes.Index(p[:n], []byte("\n\n")); idx >= 0 {
+		end = idx + 2
+	}
+	b.last = p[n-1]
+	if end < 0 {
+		return n, err
+	}
+
+	b.firstEvent = true
+	if b.disconnect {
+		b.cut = true
+		return end, nil
+	}
+
+	b.pending = append(append([]byte{}, malformedChunk...), p[end:n]...)
+	return end, nil
+}
+
+func (b *faultBody) Close() error {
+	return b.body.Close()
+}
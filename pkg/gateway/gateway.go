@@ -3,13 +3,19 @@ package gateway
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter"
+	"github.com/curaious/uno/pkg/gateway/providers/replicate"
 	"github.com/curaious/uno/pkg/llm"
 	"go.opentelemetry.io/otel"
 )
 
 var tracer = otel.Tracer("LLMGateway")
 
+// ErrVirtualKeyNotFound is returned by the config stores for the keys that are not virtual keys they know of
+var ErrVirtualKeyNotFound = errors.New("secret key not exist")
+
 // ConfigStore is the interface required by LLMGateway to get provider and virtual key configurations.
 // Implementations:
 // - InMemoryConfigStore: for SDK consumers with direct API keys
@@ -33,13 +39,28 @@ type Middleware interface {
 
 type LLMGateway struct {
 	ConfigStore ConfigStore
+	Regions     *RegionTracker
 	middlewares []Middleware
+
+	interceptors []RequestInterceptor
+	httpConfig   HTTPConfig
+	httpClients  httpClients
+	faults       FaultConfig
+
+	replicateWebhooks *replicate.Webhooks
+	openRouterCatalog *openrouter.Catalog
+
+	imageStore   ImageStore
+	images       imageCache
+	imageFetcher *imageFetcher
 }
 
 func NewLLMGateway(ConfigStore ConfigStore) *LLMGateway {
 	return &LLMGateway{
-		ConfigStore: ConfigStore,
-		middlewares: []Middleware{},
+		ConfigStore:  ConfigStore,
+		Regions:      NewRegionTracker(),
+		middlewares:  []Middleware{},
+		imageFetcher: newImageFetcher(),
 	}
 }
 
@@ -47,6 +68,33 @@ func (g *LLMGateway) UseMiddleware(middleware ...Middleware) {
 	g.middlewares = append(g.middlewares, middleware...)
 }
 
+// UseHTTPConfig sets the proxy and TLS settings of the requests to the providers, the settings of a provider
+// config take precedence
+func (g *LLMGateway) UseHTTPConfig(config HTTPConfig) {
+	g.httpConfig = config
+}
+
+// UseFaultInjection injects faults in the requests to the providers, for resilience testing. It is not meant for
+// production traffic.
+func (g *LLMGateway) UseFaultInjection(config FaultConfig) {
+	g.faults = config
+}
+
+// UseReplicateWebhooks notifies the completion of the Replicate predictions to the webhooks instead of polling them
+func (g *LLMGateway) UseReplicateWebhooks(webhooks *replicate.Webhooks) {
+	g.replicateWebhooks = webhooks
+}
+
+// ReplicateWebhooks returns the webhooks of the Replicate predictions, nil when they are polled
+func (g *LLMGateway) ReplicateWebhooks() *replicate.Webhooks {
+	return g.replicateWebhooks
+}
+
+// UseRequestInterceptor adds interceptors applied to the HTTP requests sent to all providers
+func (g *LLMGateway) UseRequestInterceptor(interceptors ...RequestInterceptor) {
+	g.interceptors = append(g.interceptors, interceptors...)
+}
+
 func (g *LLMGateway) HandleRequest(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.Response, error) {
 	// Build the middleware chain
 	handler := g.baseRequestHandler
@@ -61,11 +109,23 @@ func (g *LLMGateway) HandleRequest(ctx context.Context, providerName llm.Provide
 // baseRequestHandler contains the core request handling logic
 func (g *LLMGateway) baseRequestHandler(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.Response, error) {
 	// Construct the provider
-	p, err := g.getProvider(ctx, providerName, r, key)
+	p, region, err := g.getProvider(ctx, providerName, r, key)
 	if err != nil {
 		return nil, err
 	}
 
+	if region == "" {
+		return g.handleRequest(ctx, providerName, p, r)
+	}
+
+	start := time.Now()
+	resp, err := g.handleRequest(ctx, providerName, p, r)
+	g.Regions.Observe(providerName, region, time.Since(start), err)
+
+	return resp, err
+}
+
+func (g *LLMGateway) handleRequest(ctx context.Context, providerName llm.ProviderName, p llm.Provider, r *llm.Request) (*llm.Response, error) {
 	// Create the response
 	resp := &llm.Response{}
 
@@ -77,11 +137,12 @@ func (g *LLMGateway) baseRequestHandler(ctx context.Context, providerName llm.Pr
 		}
 		resp.OfEmbeddingsOutput = respOut
 	case r.OfResponsesInput != nil:
-		respOut, err := g.handleResponsesRequest(ctx, providerName, p, r.OfResponsesInput)
+		respOut, err := g.handleResponsesRequest(ctx, providerName, p, reasoningInput(providerName, r.OfResponsesInput))
 		if err != nil {
 			return nil, err
 		}
 
+		tagReasoningProvenance(providerName, r.OfResponsesInput.Model, respOut)
 		resp.OfResponsesOutput = respOut
 	case r.OfChatCompletionInput != nil:
 		respOut, err := g.handleChatCompletionRequest(ctx, providerName, p, r.OfChatCompletionInput)
@@ -116,22 +177,35 @@ func (g *LLMGateway) HandleStreamingRequest(ctx context.Context, providerName ll
 // baseStreamingRequestHandler contains the core streaming request handling logic
 func (g *LLMGateway) baseStreamingRequestHandler(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.StreamingResponse, error) {
 	// Construct the provider
-	p, err := g.getProvider(ctx, providerName, r, key)
+	p, region, err := g.getProvider(ctx, providerName, r, key)
 	if err != nil {
 		return nil, err
 	}
 
+	if region == "" {
+		return g.handleStreamingRequest(ctx, providerName, p, r)
+	}
+
+	// For streams, the latency is the time until the provider accepted the request
+	start := time.Now()
+	resp, err := g.handleStreamingRequest(ctx, providerName, p, r)
+	g.Regions.Observe(providerName, region, time.Since(start), err)
+
+	return resp, err
+}
+
+func (g *LLMGateway) handleStreamingRequest(ctx context.Context, providerName llm.ProviderName, p llm.Provider, r *llm.Request) (*llm.StreamingResponse, error) {
 	// Create the response
 	resp := &llm.StreamingResponse{}
 
 	switch {
 	case r.OfResponsesInput != nil:
-		respOut, err := g.handleStreamingResponsesRequest(ctx, providerName, p, r.OfResponsesInput)
+		respOut, err := g.handleStreamingResponsesRequest(ctx, providerName, p, reasoningInput(providerName, r.OfResponsesInput))
 		if err != nil {
 			return nil, err
 		}
 
-		resp.ResponsesStreamData = respOut
+		resp.ResponsesStreamData = tagStreamingReasoningProvenance(providerName, r.OfResponsesInput.Model, respOut)
 		return resp, nil
 
 	case r.OfChatCompletionInput != nil:
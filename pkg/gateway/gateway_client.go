@@ -6,6 +6,7 @@ import (
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/curaious/uno/pkg/llm/chat_completion"
+	"github.com/curaious/uno/pkg/llm/constants"
 	"github.com/curaious/uno/pkg/llm/embeddings"
 	"github.com/curaious/uno/pkg/llm/responses"
 	"github.com/curaious/uno/pkg/llm/speech"
@@ -42,8 +43,9 @@ type LLMGatewayAdapter interface {
 type LLMClient struct {
 	LLMGatewayAdapter
 
-	provider llm.ProviderName
-	model    string
+	provider      llm.ProviderName
+	model         string
+	chunkPipeline *responses.ChunkPipeline
 }
 
 // NewLLMClient creates a new LLM client with the given provider.
@@ -55,6 +57,16 @@ func NewLLMClient(p LLMGatewayAdapter, providerName llm.ProviderName, model stri
 	}
 }
 
+// ProviderName returns the provider the client calls
+func (c *LLMClient) ProviderName() llm.ProviderName {
+	return c.provider
+}
+
+// ModelName returns the model the client calls
+func (c *LLMClient) ModelName() string {
+	return c.model
+}
+
 func (c *LLMClient) NewResponses(ctx context.Context, in *responses.Request) (*responses.Response, error) {
 	in.Model = c.model
 	in.Stream = utils.Ptr(false)
@@ -62,12 +74,70 @@ func (c *LLMClient) NewResponses(ctx context.Context, in *responses.Request) (*r
 	return c.LLMGatewayAdapter.NewResponses(ctx, c.provider, in)
 }
 
+// WithChunkPipeline sets the pipeline applied to every streaming response of this client
+func (c *LLMClient) WithChunkPipeline(pipeline *responses.ChunkPipeline) *LLMClient {
+	c.chunkPipeline = pipeline
+	return c
+}
+
 // NewStreamingResponses invokes the LLM and streams responses via callback
 func (c *LLMClient) NewStreamingResponses(ctx context.Context, in *responses.Request) (chan *responses.ResponseChunk, error) {
 	in.Model = c.model
 	in.Stream = utils.Ptr(true)
 	in.Store = utils.Ptr(false)
-	return c.LLMGatewayAdapter.NewStreamingResponses(ctx, c.provider, in)
+
+	recorder := responses.NewTimingRecorder(ctx)
+	stream, err := c.LLMGatewayAdapter.NewStreamingResponses(ctx, c.provider, in)
+	if err != nil {
+		return nil, err
+	}
+	recorder.Connected()
+
+	return c.chunkPipeline.Pipe(ctx, withTiming(ctx, stream, recorder, in.Model, c.provider)), nil
+}
+
+// withTiming forwards the stream while measuring it, and appends a response.metrics chunk once the stream ends. When
+// the context is done the forwarding stops, the rest of the stream is drained so that the provider isn't blocked.
+func withTiming(ctx context.Context, in chan *responses.ResponseChunk, recorder *responses.TimingRecorder, model string, provider llm.ProviderName) chan *responses.ResponseChunk {
+	out := make(chan *responses.ResponseChunk, cap(in))
+	go func() {
+		defer close(out)
+
+		send := func(chunk *responses.ResponseChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				go func() {
+					for range in {
+					}
+				}()
+				return false
+			}
+		}
+
+		sequenceNumber := 0
+		for chunk := range in {
+			recorder.Observe(chunk)
+			if chunk.OfResponseCompleted != nil {
+				sequenceNumber = chunk.OfResponseCompleted.SequenceNumber + 1
+			}
+			if !send(chunk) {
+				return
+			}
+		}
+
+		send(&responses.ResponseChunk{
+			OfResponseMetrics: &responses.ChunkResponseMetrics[constants.ChunkTypeResponseMetrics]{
+				SequenceNumber: sequenceNumber,
+				Model:          model,
+				Provider:       string(provider),
+				Timing:         recorder.Timing(),
+			},
+		})
+	}()
+
+	return out
 }
 
 func (c *LLMClient) NewEmbedding(ctx context.Context, in *embeddings.Request) (*embeddings.Response, error) {
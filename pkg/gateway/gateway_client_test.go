@@ -0,0 +1,63 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTiming(t *testing.T) {
+	in := make(chan *responses.ResponseChunk, 1)
+	in <- &responses.ResponseChunk{OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{SequenceNumber: 4}}
+	close(in)
+
+	var chunks []*responses.ResponseChunk
+	for chunk := range withTiming(context.Background(), in, responses.NewTimingRecorder(context.Background()), "gpt-4.1", llm.ProviderNameOpenAI) {
+		chunks = append(chunks, chunk)
+	}
+
+	require.Len(t, chunks, 2)
+	metrics := chunks[1].OfResponseMetrics
+	require.NotNil(t, metrics)
+	assert.Equal(t, 5, metrics.SequenceNumber)
+	assert.Equal(t, "gpt-4.1", metrics.Model)
+}
+
+func TestWithTiming_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *responses.ResponseChunk)
+
+	// The provider sends the chunks, the consumer never reads them
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		for i := 0; i < 3; i++ {
+			in <- &responses.ResponseChunk{OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{SequenceNumber: i}}
+		}
+		close(in)
+	}()
+
+	out := withTiming(ctx, in, responses.NewTimingRecorder(ctx), "gpt-4.1", llm.ProviderNameOpenAI)
+	cancel()
+
+	select {
+	case <-produced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the provider is blocked on the timing of the stream")
+	}
+
+	select {
+	case _, ok := <-out:
+		for ok {
+			_, ok = <-out
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the timed stream was not closed")
+	}
+}
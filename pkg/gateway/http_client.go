@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/curaious/uno/pkg/llm"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// HTTPConfig configures the HTTP connections to a provider, for networks where the traffic goes through an egress
+// proxy or where the servers are signed by a custom CA
+type HTTPConfig struct {
+	// HTTPProxy is the URL of the proxy the requests go through, e.g. "http://proxy.internal:3128". Without it,
+	// the proxy is taken from the environment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY).
+	HTTPProxy string
+
+	// NoProxy lists the hosts reached without the proxy, in the format of the NO_PROXY environment variable
+	NoProxy string
+
+	TLS *TLSConfig
+}
+
+// TLSConfig configures the TLS connections to a provider
+type TLSConfig struct {
+	// RootCAs are PEM encoded certificates trusted on top of the system ones, e.g. the CA of an egress proxy
+	RootCAs string
+
+	// ClientCert and ClientKey are the PEM encoded certificate and key presented to the server, for mutual TLS
+	ClientCert string
+	ClientKey  string
+
+	// InsecureSkipVerify disables the verification of the certificates of the server, it is only meant for tests
+	InsecureSkipVerify bool
+}
+
+// IsZero reports whether the config keeps the default HTTP client
+func (c HTTPConfig) IsZero() bool {
+	return c.HTTPProxy == "" && c.NoProxy == "" && c.TLS == nil
+}
+
+// merge returns the config with the fields set on the override replacing the ones of c
+func (c HTTPConfig) merge(override HTTPConfig) HTTPConfig {
+	if override.HTTPProxy != "" {
+		c.HTTPProxy = override.HTTPProxy
+	}
+	if override.NoProxy != "" {
+		c.NoProxy = override.NoProxy
+	}
+	if override.TLS != nil {
+		c.TLS = override.TLS
+	}
+	return c
+}
+
+// key identifies the config, to share the clients of identical configs
+func (c HTTPConfig) key() string {
+	key := c.HTTPProxy + "\x00" + c.NoProxy
+	if c.TLS != nil {
+		key += fmt.Sprintf("\x00%s\x00%s\x00%s\x00%t", c.TLS.RootCAs, c.TLS.ClientCert, c.TLS.ClientKey, c.TLS.InsecureSkipVerify)
+	}
+	return key
+}
+
+// NewHTTPClient creates an HTTP client honoring the proxy and TLS settings of the config
+func NewHTTPClient(config HTTPConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.HTTPProxy != "" || config.NoProxy != "" {
+		proxyConfig := httpproxy.FromEnvironment()
+		if config.HTTPProxy != "" {
+			if _, err := url.Parse(config.HTTPProxy); err != nil {
+				return nil, fmt.Errorf("invalid HTTP proxy: %w", err)
+			}
+			proxyConfig.HTTPProxy = config.HTTPProxy
+			proxyConfig.HTTPSProxy = config.HTTPProxy
+		}
+		if config.NoProxy != "" {
+			proxyConfig.NoProxy = config.NoProxy
+		}
+
+		proxyFunc := proxyConfig.ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+func (c *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if strings.TrimSpace(c.RootCAs) != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(c.RootCAs)) {
+			return nil, errors.New("invalid root CAs: no PEM encoded certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// httpClients shares the HTTP clients of the providers between requests, so that connections are reused
+type httpClients struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+}
+
+// get returns the HTTP client of the config, nil for the default client
+func (h *httpClients) get(config HTTPConfig) (*http.Client, error) {
+	if config.IsZero() {
+		return nil, nil
+	}
+
+	key := config.key()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if client, ok := h.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := NewHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if h.clients == nil {
+		h.clients = map[string]*http.Client{}
+	}
+	h.clients[key] = client
+
+	return client, nil
+}
+
+// httpConfigOf returns the HTTP settings of the provider
+func httpConfigOf(providerConfig *ProviderConfig) HTTPConfig {
+	if providerConfig == nil {
+		return HTTPConfig{}
+	}
+
+	return HTTPConfig{
+		HTTPProxy: providerConfig.HTTPProxy,
+		NoProxy:   providerConfig.NoProxy,
+		TLS:       providerConfig.TLS,
+	}
+}
+
+// providerHTTPClient returns the HTTP client of the requests to the provider, nil for the default client. The
+// client of a dry run captures the requests, once the interceptors are applied, instead of sending them. The
+// faults configured for the gateway are injected after the interceptors.
+func (g *LLMGateway) providerHTTPClient(providerName llm.ProviderName, providerConfig *ProviderConfig, dryRun bool) (*http.Client, error) {
+	if dryRun {
+		return NewInterceptingClient(dryRunClient(providerName), providerName, g.interceptors), nil
+	}
+
+	client, err := g.httpClients.get(g.httpConfig.merge(httpConfigOf(providerConfig)))
+	if err != nil {
+		return nil, err
+	}
+	client = NewFaultInjectingClient(client, providerName, g.faults)
+
+	return NewInterceptingClient(client, providerName, g.interceptors), nil
+}
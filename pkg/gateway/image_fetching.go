@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// inlineImageProviders are the providers that don't download the images by URL, the images have to be sent inline
+var inlineImageProviders = []llm.ProviderName{
+	llm.ProviderNameAnthropic,
+	llm.ProviderNameGemini,
+	llm.ProviderNameVertexAI,
+	llm.ProviderNameBedrock,
+}
+
+const (
+	// fetchedImageMaxBytes bounds the size of the downloaded images, the largest limit of the providers. The images
+	// are downscaled to the limits of the provider after they are downloaded.
+	fetchedImageMaxBytes = 20 << 20
+
+	fetchedImageTimeout = 10 * time.Second
+
+	// fetchedImageTTL is how long a downloaded image is reused, the images of a conversation are sent again with each
+	// of its requests
+	fetchedImageTTL = time.Hour
+
+	// fetchedImageCacheBytes bounds the downloaded images kept in memory
+	fetchedImageCacheBytes = 128 << 20
+)
+
+var (
+	errImageAddressNotAllowed = errors.New("the address of the image is not public")
+	errFetchedImageTooLarge   = fmt.Errorf("the image is larger than %d bytes", fetchedImageMaxBytes)
+)
+
+// imageFetcher downloads the images by URL to inline them in the requests to the providers requiring inline data
+type imageFetcher struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	images map[string]*fetchedImage
+	bytes  int
+}
+
+type fetchedImage struct {
+	dataURL   string
+	fetchedAt time.Time
+}
+
+// newImageFetcher returns a fetcher that only connects to public addresses, the URLs are sent by the users and must
+// not reach the network of the gateway
+func newImageFetcher() *imageFetcher {
+	dialer := &net.Dialer{Timeout: fetchedImageTimeout, Control: publicAddressesOnly}
+
+	return &imageFetcher{
+		client: &http.Client{
+			Timeout: fetchedImageTimeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: fetchedImageTimeout,
+				MaxIdleConns:        16,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+// publicAddressesOnly refuses the connections to the loopback, private and link-local addresses. It checks the
+// address being dialed, after the DNS resolution and on every redirect.
+func publicAddressesOnly(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return errImageAddressNotAllowed
+	}
+
+	return nil
+}
+
+// inlineImages returns the request with its images by URL replaced by data URLs when the provider requires inline
+// images. An image that can't be downloaded fails the request, instead of the model answering without it.
+func (g *LLMGateway) inlineImages(ctx context.Context, providerName llm.ProviderName, in *responses.Request) (*responses.Request, error) {
+	if !slices.Contains(inlineImageProviders, providerName) {
+		return in, nil
+	}
+
+	return mapImageURLs(in, func(url string) (string, bool, error) {
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return "", false, nil
+		}
+
+		dataURL, err := g.imageFetcher.fetch(ctx, url)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to fetch the image %s: %w", url, err)
+		}
+		return dataURL, true, nil
+	})
+}
+
+// fetch returns the data URL of the image, downloading it unless it was downloaded recently
+func (f *imageFetcher) fetch(ctx context.Context, url string) (string, error) {
+	if dataURL, ok := f.get(url); ok {
+		return dataURL, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join(commonImageFormats, ", "))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the server responded with %s", resp.Status)
+	}
+
+	if resp.ContentLength > fetchedImageMaxBytes {
+		return "", errFetchedImageTooLarge
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, fetchedImageMaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > fetchedImageMaxBytes {
+		return "", errFetchedImageTooLarge
+	}
+
+	mimeType, err := imageMimeType(resp.Header.Get("Content-Type"), data)
+	if err != nil {
+		return "", err
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("image.fetched", trace.WithAttributes(
+		attribute.String("image.url", url),
+		attribute.Int("image.bytes", len(data)),
+		attribute.String("image.mime_type", mimeType),
+	))
+
+	dataURL := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	f.put(url, dataURL)
+
+	return dataURL, nil
+}
+
+// imageMimeType returns the MIME type of the downloaded image. The servers often send the images as
+// application/octet-stream, the type of these is sniffed from their data.
+func imageMimeType(contentType string, data []byte) (string, error) {
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mimeType == "application/octet-stream" || mimeType == "binary/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	if !slices.Contains(commonImageFormats, mimeType) {
+		return "", fmt.Errorf("the content type %q is not a supported image format", mimeType)
+	}
+
+	return mimeType, nil
+}
+
+func (f *imageFetcher) get(url string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	image, ok := f.images[url]
+	if !ok || time.Since(image.fetchedAt) > fetchedImageTTL {
+		return "", false
+	}
+
+	return image.dataURL, true
+}
+
+func (f *imageFetcher) put(url string, dataURL string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.images == nil || f.bytes+len(dataURL) > fetchedImageCacheBytes {
+		f.images = map[string]*fetchedImage{}
+		f.bytes = 0
+	}
+
+	if previous, ok := f.images[url]; ok {
+		f.bytes -= len(previous.dataURL)
+	}
+	f.images[url] = &fetchedImage{dataURL: dataURL, fetchedAt: time.Now()}
+	f.bytes += len(dataURL)
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func imageRequest(urls ...string) *responses.Request {
+	content := responses.InputContent{{OfInputText: &responses.InputTextContent{Text: "Describe them"}}}
+	for _, url := range urls {
+		content = append(content, responses.InputContentUnion{OfInputImage: &responses.InputImageContent{ImageURL: &url}})
+	}
+
+	return &responses.Request{Input: responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+		{OfInputMessage: &responses.InputMessage{Role: "user", Content: content}},
+	}}}
+}
+
+func TestInlineImages(t *testing.T) {
+	data := encodePNG(t, testImage(10, 10, 255))
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(data)
+		case "/cat":
+			// The type of the images sent as binary is sniffed
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(data)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	g := NewLLMGateway(nil)
+	g.imageFetcher.client = server.Client()
+
+	in := imageRequest(server.URL+"/cat.png", server.URL+"/cat", "s3://bucket/cat.png")
+	out, err := g.inlineImages(context.Background(), llm.ProviderNameGemini, in)
+	require.NoError(t, err)
+
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)
+	content := out.Input.OfInputMessageList[0].OfInputMessage.Content
+	assert.Equal(t, dataURL, *content[1].OfInputImage.ImageURL)
+	assert.Equal(t, dataURL, *content[2].OfInputImage.ImageURL)
+	assert.Equal(t, "s3://bucket/cat.png", *content[3].OfInputImage.ImageURL)
+	assert.Equal(t, server.URL+"/cat.png", *in.Input.OfInputMessageList[0].OfInputMessage.Content[1].OfInputImage.ImageURL)
+
+	// The images are downloaded once
+	_, err = g.inlineImages(context.Background(), llm.ProviderNameAnthropic, in)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, fetches.Load())
+
+	// The providers downloading the images get their URLs
+	out, err = g.inlineImages(context.Background(), llm.ProviderNameOpenAI, in)
+	require.NoError(t, err)
+	assert.Same(t, in, out)
+
+	_, err = g.inlineImages(context.Background(), llm.ProviderNameGemini, imageRequest(server.URL+"/page"))
+	assert.ErrorContains(t, err, "not a supported image format")
+
+	_, err = g.inlineImages(context.Background(), llm.ProviderNameGemini, imageRequest(server.URL+"/missing.png"))
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestInlineImages_PrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the image was fetched from a private address")
+	}))
+	defer server.Close()
+
+	g := NewLLMGateway(nil)
+
+	_, err := g.inlineImages(context.Background(), llm.ProviderNameAnthropic, imageRequest(server.URL+"/cat.png"))
+	assert.ErrorIs(t, err, errImageAddressNotAllowed)
+}
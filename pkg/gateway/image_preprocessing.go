@@ -0,0 +1,256 @@
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ImageLimits are the limits of the input images of a provider. The images exceeding them are downscaled, recompressed
+// or converted before they are sent, instead of being rejected by the provider.
+type ImageLimits struct {
+	// MaxDimension is the maximum width and height of an image in pixels, the larger images are downscaled
+	MaxDimension int
+
+	// MaxBytes is the maximum size of an image, the larger images are recompressed and downscaled until they fit
+	MaxBytes int
+
+	// Formats are the MIME types of the images accepted by the provider, the other images are converted to JPEG, or to
+	// PNG for the images with transparency
+	Formats []string
+}
+
+func (l ImageLimits) accepts(mimeType string) bool {
+	return len(l.Formats) == 0 || slices.Contains(l.Formats, mimeType)
+}
+
+var commonImageFormats = []string{mimeTypeJPEG, mimeTypePNG, mimeTypeGIF, mimeTypeWebP}
+
+// defaultImageLimits are the limits of the providers without documented limits
+var defaultImageLimits = ImageLimits{MaxDimension: 2048, MaxBytes: 10 << 20, Formats: commonImageFormats}
+
+// providerImageLimits are the documented limits of the providers. The dimensions are the ones the providers scale
+// the images down to before the model sees them, the larger images only cost bandwidth.
+var providerImageLimits = map[llm.ProviderName]ImageLimits{
+	llm.ProviderNameOpenAI:    {MaxDimension: 2048, MaxBytes: 20 << 20, Formats: commonImageFormats},
+	llm.ProviderNameAzure:     {MaxDimension: 2048, MaxBytes: 20 << 20, Formats: commonImageFormats},
+	llm.ProviderNameAnthropic: {MaxDimension: 1568, MaxBytes: 5 << 20, Formats: commonImageFormats},
+	llm.ProviderNameBedrock:   {MaxDimension: 1568, MaxBytes: 3750 << 10, Formats: commonImageFormats},
+	llm.ProviderNameGemini:    {MaxDimension: 3072, MaxBytes: 7 << 20, Formats: []string{mimeTypeJPEG, mimeTypePNG, mimeTypeWebP}},
+	llm.ProviderNameVertexAI:  {MaxDimension: 3072, MaxBytes: 7 << 20, Formats: []string{mimeTypeJPEG, mimeTypePNG, mimeTypeWebP}},
+	llm.ProviderNameXAI:       {MaxDimension: 2048, MaxBytes: 20 << 20, Formats: []string{mimeTypeJPEG, mimeTypePNG}},
+	llm.ProviderNameMistral:   {MaxDimension: 2048, MaxBytes: 10 << 20, Formats: commonImageFormats},
+}
+
+// imageLimits returns the image limits of the provider
+func imageLimits(providerName llm.ProviderName) ImageLimits {
+	if limits, ok := providerImageLimits[providerName]; ok {
+		return limits
+	}
+
+	return defaultImageLimits
+}
+
+// ImageStore keeps the original of the input images the gateway changed before sending them, so that the images sent
+// by the users are not lost
+type ImageStore interface {
+	// StoreImage stores the image under the SHA-256 of its data, storing an image twice is a no-op
+	StoreImage(ctx context.Context, sha256 string, mimeType string, data []byte) error
+}
+
+// UseImageStore stores the original of the input images changed by the preprocessing in the store
+func (g *LLMGateway) UseImageStore(store ImageStore) {
+	g.imageStore = store
+}
+
+// imageCacheSize bounds the preprocessed images kept in memory, the images of a conversation are sent again with each
+// of its requests
+const imageCacheSize = 256
+
+// imageCache keeps the preprocessed images by the SHA-256 of their original and their limits
+type imageCache struct {
+	mu     sync.Mutex
+	images map[string]*processedImage
+}
+
+func (c *imageCache) get(key string) (*processedImage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	image, ok := c.images[key]
+	return image, ok
+}
+
+func (c *imageCache) put(key string, image *processedImage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.images == nil || len(c.images) >= imageCacheSize {
+		c.images = map[string]*processedImage{}
+	}
+	c.images[key] = image
+}
+
+// preprocessImages returns the request with its images made to fit the image limits of the provider: the images are
+// downscaled, recompressed and converted when they exceed the limits, and their metadata, e.g. the location of a
+// photo, is stripped. Only the images in data URLs are preprocessed, the images by URL are fetched by the provider.
+func (g *LLMGateway) preprocessImages(ctx context.Context, providerName llm.ProviderName, in *responses.Request) *responses.Request {
+	limits := imageLimits(providerName)
+
+	out, _ := mapImageURLs(in, func(url string) (string, bool, error) {
+		url, ok := g.preprocessImage(ctx, url, limits)
+		return url, ok, nil
+	})
+	return out
+}
+
+// mapImageURLs returns the request with the URLs of its input images replaced by fn, the images for which fn returns
+// false are left as they are. The request is copied on write, it is returned as it is when no image is replaced.
+func mapImageURLs(in *responses.Request, fn func(url string) (string, bool, error)) (*responses.Request, error) {
+	if in.Input.OfInputMessageList == nil {
+		return in, nil
+	}
+
+	changed := false
+
+	content := func(contents responses.InputContent) (responses.InputContent, error) {
+		var out responses.InputContent
+		for i, c := range contents {
+			if c.OfInputImage == nil || c.OfInputImage.ImageURL == nil {
+				continue
+			}
+
+			url, ok, err := fn(*c.OfInputImage.ImageURL)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			if out == nil {
+				out = slices.Clone(contents)
+			}
+			image := *c.OfInputImage
+			image.ImageURL = &url
+			out[i] = responses.InputContentUnion{OfInputImage: &image}
+		}
+
+		if out == nil {
+			return contents, nil
+		}
+		changed = true
+		return out, nil
+	}
+
+	input := make(responses.InputMessageList, 0, len(in.Input.OfInputMessageList))
+	for _, msg := range in.Input.OfInputMessageList {
+		switch {
+		case msg.OfEasyInput != nil && msg.OfEasyInput.Content.OfInputMessageList != nil:
+			c, err := content(msg.OfEasyInput.Content.OfInputMessageList)
+			if err != nil {
+				return nil, err
+			}
+			easy := *msg.OfEasyInput
+			easy.Content = responses.EasyInputContentUnion{OfInputMessageList: c}
+			msg = responses.InputMessageUnion{OfEasyInput: &easy}
+
+		case msg.OfInputMessage != nil:
+			c, err := content(msg.OfInputMessage.Content)
+			if err != nil {
+				return nil, err
+			}
+			message := *msg.OfInputMessage
+			message.Content = c
+			msg = responses.InputMessageUnion{OfInputMessage: &message}
+
+		case msg.OfFunctionCallOutput != nil && msg.OfFunctionCallOutput.Output.OfList != nil:
+			c, err := content(msg.OfFunctionCallOutput.Output.OfList)
+			if err != nil {
+				return nil, err
+			}
+			output := *msg.OfFunctionCallOutput
+			output.Output = responses.FunctionCallOutputContentUnion{OfList: c}
+			msg = responses.InputMessageUnion{OfFunctionCallOutput: &output}
+		}
+
+		input = append(input, msg)
+	}
+
+	if !changed {
+		return in, nil
+	}
+
+	req := *in
+	req.Input = responses.InputUnion{OfInputMessageList: input}
+	return &req, nil
+}
+
+// preprocessImage returns the data URL of the image made to fit the limits, ok is false when the image is sent as it is
+func (g *LLMGateway) preprocessImage(ctx context.Context, url string, limits ImageLimits) (string, bool) {
+	mimeType, data, ok := decodeDataURL(url)
+	if !ok {
+		return "", false
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := fmt.Sprintf("%s:%d:%d:%s", hash, limits.MaxDimension, limits.MaxBytes, strings.Join(limits.Formats, ","))
+
+	image, cached := g.images.get(key)
+	if !cached {
+		var err error
+		image, err = processImage(data, limits)
+		if err != nil {
+			// The image is sent as it is, the provider tells what is wrong with it
+			slog.WarnContext(ctx, "unable to preprocess the input image", slog.String("mime_type", mimeType), slog.Int("bytes", len(data)), slog.Any("error", err))
+			return "", false
+		}
+		g.images.put(key, image)
+
+		if image != nil && g.imageStore != nil {
+			if err := g.imageStore.StoreImage(ctx, hash, mimeType, data); err != nil {
+				slog.WarnContext(ctx, "unable to store the original of the input image", slog.String("sha256", hash), slog.Any("error", err))
+			}
+		}
+	}
+
+	if image == nil {
+		return "", false
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("image.preprocessed", trace.WithAttributes(
+		attribute.String("image.original_sha256", hash),
+		attribute.Int("image.original_bytes", len(data)),
+		attribute.Int("image.bytes", len(image.data)),
+		attribute.String("image.mime_type", image.mimeType),
+	))
+
+	return "data:" + image.mimeType + ";base64," + base64.StdEncoding.EncodeToString(image.data), true
+}
+
+// decodeDataURL returns the MIME type and the data of a base64 data URL
+func decodeDataURL(url string) (string, []byte, bool) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasPrefix(url, "data:") || !strings.HasSuffix(header, ";base64") {
+		return "", nil, false
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, false
+	}
+
+	return strings.TrimSuffix(header, ";base64"), data, true
+}
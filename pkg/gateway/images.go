@@ -0,0 +1,301 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Decodes the GIF images
+	"image/jpeg"
+	"image/png"
+	"math"
+)
+
+const (
+	mimeTypeJPEG = "image/jpeg"
+	mimeTypePNG  = "image/png"
+	mimeTypeGIF  = "image/gif"
+	mimeTypeWebP = "image/webp"
+)
+
+// jpegQualities are the qualities the images are encoded with, lower and lower until they fit the bytes of the
+// provider
+var jpegQualities = []int{85, 75, 60, 45}
+
+// errImageTooLarge is returned when an image can't be made to fit the bytes of the provider
+var errImageTooLarge = errors.New("the image can't be reduced to the size accepted by the provider")
+
+// processedImage is an image made to fit the limits of a provider
+type processedImage struct {
+	data     []byte
+	mimeType string
+}
+
+// processImage downscales, recompresses and converts the image to fit the limits, and strips its metadata. It returns
+// nil when the image already fits and has no metadata, or when its format can't be decoded, e.g. WebP, in which case
+// it is sent as it is.
+func processImage(data []byte, limits ImageLimits) (*processedImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	mimeType := "image/" + format
+
+	fits := (limits.MaxDimension <= 0 || max(config.Width, config.Height) <= limits.MaxDimension) &&
+		(limits.MaxBytes <= 0 || len(data) <= limits.MaxBytes) &&
+		limits.accepts(mimeType)
+	if fits && !hasMetadata(data, mimeType) {
+		return nil, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := toRGBA(img)
+	if mimeType == mimeTypeJPEG {
+		rgba = orient(rgba, exifOrientation(data))
+	}
+
+	// The images with transparency stay PNG when the provider accepts it, the others are encoded as JPEG
+	usePNG := mimeType != mimeTypeJPEG && limits.accepts(mimeTypePNG) && !rgba.Opaque()
+	if !usePNG && !limits.accepts(mimeTypeJPEG) {
+		usePNG = true
+	}
+
+	scale := 1.0
+	if limits.MaxDimension > 0 && max(config.Width, config.Height) > limits.MaxDimension {
+		scale = float64(limits.MaxDimension) / float64(max(rgba.Bounds().Dx(), rgba.Bounds().Dy()))
+	}
+
+	// Each round downscales the image further, until its encoding fits the bytes
+	for range 8 {
+		resized := rgba
+		if scale < 1 {
+			resized = downscale(rgba, max(1, int(math.Round(float64(rgba.Bounds().Dx())*scale))), max(1, int(math.Round(float64(rgba.Bounds().Dy())*scale))))
+		}
+
+		if usePNG {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, resized); err != nil {
+				return nil, err
+			}
+			if limits.MaxBytes <= 0 || buf.Len() <= limits.MaxBytes {
+				return &processedImage{data: buf.Bytes(), mimeType: mimeTypePNG}, nil
+			}
+		} else {
+			flattened := flatten(resized)
+			for _, quality := range jpegQualities {
+				var buf bytes.Buffer
+				if err := jpeg.Encode(&buf, flattened, &jpeg.Options{Quality: quality}); err != nil {
+					return nil, err
+				}
+				if limits.MaxBytes <= 0 || buf.Len() <= limits.MaxBytes {
+					return &processedImage{data: buf.Bytes(), mimeType: mimeTypeJPEG}, nil
+				}
+			}
+		}
+
+		scale *= 0.75
+	}
+
+	return nil, errImageTooLarge
+}
+
+// hasMetadata reports whether the image carries metadata, the EXIF and XMP segments of a JPEG, which hold the
+// location and the camera of a photo, or the EXIF and text chunks of a PNG
+func hasMetadata(data []byte, mimeType string) bool {
+	switch mimeType {
+	case mimeTypeJPEG:
+		for _, segment := range jpegSegments(data) {
+			if segment.marker == 0xE1 {
+				return true
+			}
+		}
+	case mimeTypePNG:
+		for i := 8; i+8 <= len(data); {
+			length := int(binary.BigEndian.Uint32(data[i:]))
+			switch string(data[i+4 : i+8]) {
+			case "eXIf", "tEXt", "iTXt", "zTXt":
+				return true
+			}
+			i += 12 + length
+		}
+	}
+
+	return false
+}
+
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// jpegSegments returns the segments of a JPEG before its image data
+func jpegSegments(data []byte) []jpegSegment {
+	var segments []jpegSegment
+
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA { // Start of scan, the image data follows
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segments = append(segments, jpegSegment{marker: marker, data: data[i+4 : i+2+length]})
+		i += 2 + length
+	}
+
+	return segments
+}
+
+// exifOrientation returns the orientation of a JPEG in its EXIF, 1 when it has none. The cameras store the photos as
+// they were taken, and the orientation tells how to rotate them, so it is applied before the EXIF is stripped.
+func exifOrientation(data []byte) int {
+	for _, segment := range jpegSegments(data) {
+		if segment.marker != 0xE1 || !bytes.HasPrefix(segment.data, []byte("Exif\x00\x00")) {
+			continue
+		}
+
+		tiff := segment.data[6:]
+		if len(tiff) < 8 {
+			return 1
+		}
+
+		var order binary.ByteOrder
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return 1
+		}
+
+		ifd := int(order.Uint32(tiff[4:]))
+		if ifd+2 > len(tiff) {
+			return 1
+		}
+
+		entries := int(order.Uint16(tiff[ifd:]))
+		for i := 0; i < entries; i++ {
+			entry := ifd + 2 + i*12
+			if entry+12 > len(tiff) {
+				return 1
+			}
+			if order.Uint16(tiff[entry:]) == 0x0112 {
+				if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+					return orientation
+				}
+				return 1
+			}
+		}
+	}
+
+	return 1
+}
+
+// orient rotates and flips the image as the EXIF orientation tells
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	ow, oh := w, h
+	if orientation >= 5 {
+		ow, oh = h, w
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, ow, oh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Flipped horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Flipped vertically
+				dx, dy = x, h-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // Transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90° counterclockwise
+				dx, dy = y, w-1-x
+			}
+
+			si := img.PixOffset(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)
+			di := out.PixOffset(dx, dy)
+			copy(out.Pix[di:di+4], img.Pix[si:si+4])
+		}
+	}
+
+	return out
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out
+}
+
+// flatten draws the image over a white background, JPEG has no transparency
+func flatten(img *image.RGBA) *image.RGBA {
+	if img.Opaque() {
+		return img
+	}
+
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
+
+// downscale resizes the image to w x h by averaging the area of the source each pixel covers, which keeps the
+// details of the image without the aliasing of the nearest pixel
+func downscale(img *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := img.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(img.Pix[i])
+					g += uint64(img.Pix[i+1])
+					b += uint64(img.Pix[i+2])
+					a += uint64(img.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			di := out.PixOffset(x, y)
+			out.Pix[di] = uint8(r / n)
+			out.Pix[di+1] = uint8(g / n)
+			out.Pix[di+2] = uint8(b / n)
+			out.Pix[di+3] = uint8(a / n)
+		}
+	}
+
+	return out
+}
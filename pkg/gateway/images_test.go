@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(w, h int, alpha uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: alpha})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// encodeJPEGWithOrientation encodes a JPEG with an EXIF segment holding the orientation
+func encodeJPEGWithOrientation(t *testing.T, img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)                   // Entries
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)              // Orientation
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)                   // Short
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)                   // Count
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(orientation)) // Value
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)                   // Next IFD
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(app1)+2))
+	segment = append(segment, app1...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestProcessImage_Downscale(t *testing.T) {
+	data := encodePNG(t, testImage(3000, 1500, 255))
+
+	out, err := processImage(data, providerImageLimits[llm.ProviderNameAnthropic])
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	// The opaque images are recompressed as JPEG
+	assert.Equal(t, mimeTypeJPEG, out.mimeType)
+	config, format, err := image.DecodeConfig(bytes.NewReader(out.data))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 1568, config.Width)
+	assert.Equal(t, 784, config.Height)
+}
+
+func TestProcessImage_Transparency(t *testing.T) {
+	data := encodePNG(t, testImage(3000, 1000, 100))
+
+	out, err := processImage(data, ImageLimits{MaxDimension: 1500, Formats: commonImageFormats})
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	assert.Equal(t, mimeTypePNG, out.mimeType)
+	config, err := png.DecodeConfig(bytes.NewReader(out.data))
+	require.NoError(t, err)
+	assert.Equal(t, 1500, config.Width)
+	assert.Equal(t, 500, config.Height)
+}
+
+func TestProcessImage_MaxBytes(t *testing.T) {
+	// The noise doesn't compress
+	img := testImage(1000, 1000, 255)
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	data := encodePNG(t, img)
+
+	out, err := processImage(data, ImageLimits{MaxBytes: 20 << 10, Formats: commonImageFormats})
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.LessOrEqual(t, len(out.data), 20<<10)
+}
+
+func TestProcessImage_EXIF(t *testing.T) {
+	// Rotated 90° clockwise, the landscape image is a portrait
+	data := encodeJPEGWithOrientation(t, testImage(40, 20, 255), 6)
+	assert.True(t, hasMetadata(data, mimeTypeJPEG))
+	assert.Equal(t, 6, exifOrientation(data))
+
+	out, err := processImage(data, defaultImageLimits)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+
+	assert.False(t, hasMetadata(out.data, mimeTypeJPEG))
+	config, err := jpeg.DecodeConfig(bytes.NewReader(out.data))
+	require.NoError(t, err)
+	assert.Equal(t, 20, config.Width)
+	assert.Equal(t, 40, config.Height)
+}
+
+func TestProcessImage_Format(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gif.Encode(&buf, testImage(50, 50, 255), nil))
+
+	out, err := processImage(buf.Bytes(), providerImageLimits[llm.ProviderNameXAI])
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	assert.Equal(t, mimeTypeJPEG, out.mimeType)
+
+	// The GIF is accepted by OpenAI
+	out, err = processImage(buf.Bytes(), providerImageLimits[llm.ProviderNameOpenAI])
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestProcessImage_Fits(t *testing.T) {
+	out, err := processImage(encodePNG(t, testImage(100, 100, 255)), defaultImageLimits)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+
+	// The images that can't be decoded are sent as they are
+	out, err = processImage([]byte("RIFF....WEBPVP8 "), defaultImageLimits)
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+type testImageStore struct {
+	images map[string]string
+}
+
+func (s *testImageStore) StoreImage(_ context.Context, sha256 string, mimeType string, _ []byte) error {
+	s.images[sha256] = mimeType
+	return nil
+}
+
+func TestPreprocessImages(t *testing.T) {
+	large := "data:image/png;base64," + base64.StdEncoding.EncodeToString(encodePNG(t, testImage(2000, 1000, 255)))
+	small := "data:image/png;base64," + base64.StdEncoding.EncodeToString(encodePNG(t, testImage(10, 10, 255)))
+	remote := "https://example.com/cat.png"
+
+	in := &responses.Request{Input: responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+		{OfInputMessage: &responses.InputMessage{Role: "user", Content: responses.InputContent{
+			{OfInputText: &responses.InputTextContent{Text: "Compare them"}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: &large}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: &small}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: &remote}},
+		}}},
+		{OfInputMessage: &responses.InputMessage{Role: "user", Content: responses.InputContent{
+			{OfInputImage: &responses.InputImageContent{ImageURL: &large}},
+		}}},
+	}}}
+
+	store := &testImageStore{images: map[string]string{}}
+	g := NewLLMGateway(nil)
+	g.UseImageStore(store)
+
+	out := g.preprocessImages(context.Background(), llm.ProviderNameAnthropic, in)
+
+	content := out.Input.OfInputMessageList[0].OfInputMessage.Content
+	assert.Contains(t, *content[1].OfInputImage.ImageURL, "data:image/jpeg;base64,")
+	assert.Equal(t, small, *content[2].OfInputImage.ImageURL)
+	assert.Equal(t, remote, *content[3].OfInputImage.ImageURL)
+	assert.Equal(t, *content[1].OfInputImage.ImageURL, *out.Input.OfInputMessageList[1].OfInputMessage.Content[0].OfInputImage.ImageURL)
+
+	// The original is stored once, and the request of the caller is left as it is
+	assert.Len(t, store.images, 1)
+	assert.Equal(t, large, *in.Input.OfInputMessageList[0].OfInputMessage.Content[1].OfInputImage.ImageURL)
+
+	// A request whose images fit is sent as it is
+	fits := &responses.Request{Input: responses.InputUnion{OfInputMessageList: responses.InputMessageList{in.Input.OfInputMessageList[0]}}}
+	fits.Input.OfInputMessageList[0].OfInputMessage = &responses.InputMessage{Role: "user", Content: content[2:]}
+	assert.Same(t, fits, g.preprocessImages(context.Background(), llm.ProviderNameAnthropic, fits))
+}
@@ -0,0 +1,150 @@
+package key_usage_middleware
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/chat_completion"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Usage is the usage of a request made with a virtual key
+type Usage struct {
+	Key          string
+	Provider     llm.ProviderName
+	Model        string
+	InputTokens  int64
+	OutputTokens int64
+	Failed       bool
+	Time         time.Time
+}
+
+// UsageRecorder records the usage of the virtual keys
+type UsageRecorder interface {
+	RecordUsage(ctx context.Context, usage *Usage) error
+}
+
+// KeyUsageMiddleware records the requests and the tokens of the requests made with virtual keys. It must run before
+// the VirtualKeyMiddleware, which replaces the virtual key with the key of the provider. Streams are recorded once
+// they are drained, a stream ending without completed response is recorded as failed.
+type KeyUsageMiddleware struct {
+	recorder UsageRecorder
+}
+
+// NewKeyUsageMiddleware creates a new KeyUsageMiddleware
+func NewKeyUsageMiddleware(recorder UsageRecorder) *KeyUsageMiddleware {
+	return &KeyUsageMiddleware{recorder: recorder}
+}
+
+func (middleware *KeyUsageMiddleware) HandleRequest(next gateway.RequestHandler) gateway.RequestHandler {
+	return func(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.Response, error) {
+		if !strings.HasPrefix(key, "sk-uno") {
+			return next(ctx, providerName, key, r)
+		}
+
+		res, err := next(ctx, providerName, key, r)
+		if res != nil && res.OfResponsesOutput != nil && res.OfResponsesOutput.DryRun != nil {
+			return res, err
+		}
+
+		usage := &Usage{
+			Key:      key,
+			Provider: providerName,
+			Model:    r.GetRequestedModel(),
+			Failed:   err != nil || res == nil || res.Error != nil,
+			Time:     time.Now(),
+		}
+		if res != nil {
+			usage.InputTokens, usage.OutputTokens = tokensOf(res)
+		}
+		middleware.record(ctx, usage)
+
+		return res, err
+	}
+}
+
+func (middleware *KeyUsageMiddleware) HandleStreamingRequest(next gateway.StreamingRequestHandler) gateway.StreamingRequestHandler {
+	return func(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.StreamingResponse, error) {
+		if !strings.HasPrefix(key, "sk-uno") {
+			return next(ctx, providerName, key, r)
+		}
+
+		usage := &Usage{
+			Key:      key,
+			Provider: providerName,
+			Model:    r.GetRequestedModel(),
+			Time:     time.Now(),
+		}
+
+		res, err := next(ctx, providerName, key, r)
+		if err != nil || res == nil {
+			usage.Failed = true
+			middleware.record(ctx, usage)
+			return res, err
+		}
+
+		switch {
+		case res.ResponsesStreamData != nil:
+			in := res.ResponsesStreamData
+			out := make(chan *responses.ResponseChunk)
+			go func() {
+				defer close(out)
+				usage.Failed = true
+				for chunk := range in {
+					if chunk.OfResponseCompleted != nil {
+						usage.InputTokens = int64(chunk.OfResponseCompleted.Response.Usage.InputTokens)
+						usage.OutputTokens = int64(chunk.OfResponseCompleted.Response.Usage.OutputTokens)
+						usage.Failed = false
+					}
+					out <- chunk
+				}
+				middleware.record(context.WithoutCancel(ctx), usage)
+			}()
+			res.ResponsesStreamData = out
+
+		case res.ChatCompletionStreamData != nil:
+			in := res.ChatCompletionStreamData
+			out := make(chan *chat_completion.ResponseChunk)
+			go func() {
+				defer close(out)
+				for chunk := range in {
+					if chunk.OfChatCompletionChunk != nil && chunk.OfChatCompletionChunk.Usage != nil {
+						usage.InputTokens = chunk.OfChatCompletionChunk.Usage.PromptTokens
+						usage.OutputTokens = chunk.OfChatCompletionChunk.Usage.CompletionTokens
+					}
+					out <- chunk
+				}
+				middleware.record(context.WithoutCancel(ctx), usage)
+			}()
+			res.ChatCompletionStreamData = out
+
+		default:
+			middleware.record(ctx, usage)
+		}
+
+		return res, nil
+	}
+}
+
+func (middleware *KeyUsageMiddleware) record(ctx context.Context, usage *Usage) {
+	if err := middleware.recorder.RecordUsage(ctx, usage); err != nil {
+		slog.ErrorContext(ctx, "Failed to record usage of virtual key", slog.String("provider", string(usage.Provider)), slog.Any("error", err))
+	}
+}
+
+// tokensOf returns the input and output tokens of a response
+func tokensOf(res *llm.Response) (int64, int64) {
+	switch {
+	case res.OfResponsesOutput != nil && res.OfResponsesOutput.Usage != nil:
+		return int64(res.OfResponsesOutput.Usage.InputTokens), int64(res.OfResponsesOutput.Usage.OutputTokens)
+	case res.OfChatCompletionOutput != nil:
+		return res.OfChatCompletionOutput.Usage.PromptTokens, res.OfChatCompletionOutput.Usage.CompletionTokens
+	case res.OfEmbeddingsOutput != nil && res.OfEmbeddingsOutput.Usage != nil:
+		return res.OfEmbeddingsOutput.Usage.PromptTokens, 0
+	}
+	return 0, 0
+}
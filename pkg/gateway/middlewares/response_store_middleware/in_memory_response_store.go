@@ -0,0 +1,57 @@
+package response_store_middleware
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryResponseStore implements ResponseStore in memory.
+// Stored responses are lost on restart, use a persistent store in production.
+type InMemoryResponseStore struct {
+	mu        sync.RWMutex
+	responses map[string]*StoredResponse
+}
+
+// NewInMemoryResponseStore creates a new in-memory response store.
+func NewInMemoryResponseStore() *InMemoryResponseStore {
+	return &InMemoryResponseStore{
+		responses: make(map[string]*StoredResponse),
+	}
+}
+
+func (s *InMemoryResponseStore) SaveResponse(ctx context.Context, resp *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A response of another owner is not replaced
+	if existing, ok := s.responses[resp.ID]; ok && existing.Owner != resp.Owner {
+		return nil
+	}
+
+	s.responses[resp.ID] = resp
+	return nil
+}
+
+func (s *InMemoryResponseStore) GetResponse(ctx context.Context, owner string, id string) (*StoredResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp, ok := s.responses[id]
+	if !ok || resp.Owner != owner {
+		return nil, ErrResponseNotFound
+	}
+
+	return resp, nil
+}
+
+func (s *InMemoryResponseStore) DeleteResponse(ctx context.Context, owner string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.responses[id]; !ok || resp.Owner != owner {
+		return ErrResponseNotFound
+	}
+
+	delete(s.responses, id)
+	return nil
+}
@@ -0,0 +1,260 @@
+package response_store_middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+var ErrResponseNotFound = errors.New("response not found")
+
+// StoredResponse is a response created with store=true, together with the input it was generated from.
+// Only the owner of the response, see ResponseOwner, can read, delete or continue from it.
+type StoredResponse struct {
+	ID           string                         `json:"id"`
+	Owner        string                         `json:"-"`
+	Provider     llm.ProviderName               `json:"provider"`
+	Model        string                         `json:"model"`
+	Instructions *string                        `json:"instructions,omitempty"`
+	Input        responses.InputMessageList     `json:"input"`
+	Output       []responses.OutputMessageUnion `json:"output"`
+	Usage        *responses.Usage               `json:"usage,omitempty"`
+	Metadata     map[string]string              `json:"metadata,omitempty"`
+	CreatedAt    time.Time                      `json:"created_at"`
+}
+
+// ToResponse returns the stored response in the native responses format
+func (s *StoredResponse) ToResponse() *responses.Response {
+	metadata := map[string]interface{}{}
+	for k, v := range s.Metadata {
+		metadata[k] = v
+	}
+
+	return &responses.Response{
+		ID:       s.ID,
+		Model:    s.Model,
+		Output:   s.Output,
+		Usage:    s.Usage,
+		Metadata: metadata,
+	}
+}
+
+// ResponseStore persists stored responses so that they can be retrieved and continued from later.
+type ResponseStore interface {
+	// SaveResponse stores the response, replacing any response with the same ID.
+	SaveResponse(ctx context.Context, resp *StoredResponse) error
+
+	// GetResponse returns the response of the owner with the given ID, or ErrResponseNotFound.
+	GetResponse(ctx context.Context, owner string, id string) (*StoredResponse, error)
+
+	// DeleteResponse deletes the response of the owner with the given ID, or returns ErrResponseNotFound.
+	DeleteResponse(ctx context.Context, owner string, id string) error
+}
+
+// ResponseOwner returns the owner of the responses stored with the key of a request: the ID of a virtual key, or
+// the hash of a provider key, so that the keys are not stored with the responses.
+func ResponseOwner(configStore gateway.ConfigStore, key string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(key, "sk-uno") {
+		if configStore == nil {
+			return "", errors.New("config store is required when virtual key is provided")
+		}
+
+		vk, err := configStore.GetVirtualKey(key)
+		if err != nil {
+			return "", err
+		}
+		return "vk:" + vk.ID, nil
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(hash[:]), nil
+}
+
+// ResponseStoreMiddleware implements the store and previous_response_id parameters of the responses API.
+// Requests with previous_response_id get the input and output of the referenced response prepended to their
+// input, and responses to requests with store=true are saved in the ResponseStore. It must come before the
+// VirtualKeyMiddleware, which replaces the virtual keys the responses are owned by with the provider keys.
+type ResponseStoreMiddleware struct {
+	configStore gateway.ConfigStore
+	store       ResponseStore
+}
+
+// NewResponseStoreMiddleware creates a new ResponseStoreMiddleware.
+// If no store is provided, responses are kept in memory.
+func NewResponseStoreMiddleware(configStore gateway.ConfigStore, store ...ResponseStore) *ResponseStoreMiddleware {
+	var s ResponseStore
+	if len(store) > 0 && store[0] != nil {
+		s = store[0]
+	} else {
+		s = NewInMemoryResponseStore()
+	}
+
+	return &ResponseStoreMiddleware{configStore: configStore, store: s}
+}
+
+func (middleware *ResponseStoreMiddleware) HandleRequest(next gateway.RequestHandler) gateway.RequestHandler {
+	return func(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.Response, error) {
+		if r.OfResponsesInput == nil || (!shouldStore(r.OfResponsesInput) && !continuesResponse(r.OfResponsesInput)) {
+			return next(ctx, providerName, key, r)
+		}
+
+		owner, err := ResponseOwner(middleware.configStore, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := middleware.expandPreviousResponse(ctx, owner, r.OfResponsesInput); err != nil {
+			return nil, err
+		}
+
+		res, err := next(ctx, providerName, key, r)
+		if err != nil || res == nil || res.OfResponsesOutput == nil || res.OfResponsesOutput.DryRun != nil || !shouldStore(r.OfResponsesInput) {
+			return res, err
+		}
+
+		out := res.OfResponsesOutput
+		if out.ID == "" {
+			out.ID = newResponseID()
+		}
+
+		middleware.save(ctx, owner, providerName, r.OfResponsesInput, out.ID, out.Output, out.Usage)
+
+		return res, nil
+	}
+}
+
+func (middleware *ResponseStoreMiddleware) HandleStreamingRequest(next gateway.StreamingRequestHandler) gateway.StreamingRequestHandler {
+	return func(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.StreamingResponse, error) {
+		if r.OfResponsesInput == nil || (!shouldStore(r.OfResponsesInput) && !continuesResponse(r.OfResponsesInput)) {
+			return next(ctx, providerName, key, r)
+		}
+
+		owner, err := ResponseOwner(middleware.configStore, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := middleware.expandPreviousResponse(ctx, owner, r.OfResponsesInput); err != nil {
+			return nil, err
+		}
+
+		res, err := next(ctx, providerName, key, r)
+		if err != nil || res == nil || res.ResponsesStreamData == nil || !shouldStore(r.OfResponsesInput) {
+			return res, err
+		}
+
+		in := res.ResponsesStreamData
+		out := make(chan *responses.ResponseChunk)
+		go func() {
+			defer close(out)
+			for chunk := range in {
+				if chunk.OfResponseCompleted != nil {
+					completed := &chunk.OfResponseCompleted.Response
+					if completed.Id == "" {
+						completed.Id = newResponseID()
+					}
+					usage := completed.Usage
+					middleware.save(context.WithoutCancel(ctx), owner, providerName, r.OfResponsesInput, completed.Id, completed.Output, &usage)
+				}
+				out <- chunk
+			}
+		}()
+
+		res.ResponsesStreamData = out
+		return res, nil
+	}
+}
+
+// expandPreviousResponse replaces previous_response_id with the input and output of the referenced response
+func (middleware *ResponseStoreMiddleware) expandPreviousResponse(ctx context.Context, owner string, req *responses.Request) error {
+	if !continuesResponse(req) {
+		return nil
+	}
+
+	prev, err := middleware.store.GetResponse(ctx, owner, *req.PreviousResponseID)
+	if err != nil {
+		return fmt.Errorf("failed to load previous response %s: %w", *req.PreviousResponseID, err)
+	}
+
+	input := make(responses.InputMessageList, 0, len(prev.Input)+len(prev.Output))
+	input = append(input, prev.Input...)
+	for _, o := range prev.Output {
+		msg, err := o.AsInput()
+		if err != nil {
+			continue
+		}
+		input = append(input, msg)
+	}
+	input = append(input, inputMessages(req.Input)...)
+
+	req.Input = responses.InputUnion{OfInputMessageList: input}
+	req.PreviousResponseID = nil
+
+	// Instructions are not carried over from the previous response, unless the request doesn't set its own
+	if req.Instructions == nil {
+		req.Instructions = prev.Instructions
+	}
+
+	return nil
+}
+
+func (middleware *ResponseStoreMiddleware) save(ctx context.Context, owner string, providerName llm.ProviderName, req *responses.Request, id string, output []responses.OutputMessageUnion, usage *responses.Usage) {
+	err := middleware.store.SaveResponse(ctx, &StoredResponse{
+		ID:           id,
+		Owner:        owner,
+		Provider:     providerName,
+		Model:        req.Model,
+		Instructions: req.Instructions,
+		Input:        inputMessages(req.Input),
+		Output:       output,
+		Usage:        usage,
+		Metadata:     req.Metadata,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to store response", slog.String("response_id", id), slog.Any("error", err))
+	}
+}
+
+func shouldStore(req *responses.Request) bool {
+	return req.Store != nil && *req.Store
+}
+
+func continuesResponse(req *responses.Request) bool {
+	return req.PreviousResponseID != nil && *req.PreviousResponseID != ""
+}
+
+// inputMessages returns the input as a list of messages, converting a plain string input into a user message
+func inputMessages(in responses.InputUnion) responses.InputMessageList {
+	if in.OfString != nil {
+		return responses.InputMessageList{
+			{
+				OfEasyInput: &responses.EasyMessage{
+					Role:    constants.RoleUser,
+					Content: responses.EasyInputContentUnion{OfString: in.OfString},
+				},
+			},
+		}
+	}
+
+	return in.OfInputMessageList
+}
+
+func newResponseID() string {
+	return "resp_" + uuid.NewString()
+}
@@ -0,0 +1,82 @@
+package response_store_middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type virtualKeys map[string]string
+
+func (v virtualKeys) GetProviderConfig(providerName llm.ProviderName) (*gateway.ProviderConfig, error) {
+	return nil, nil
+}
+
+func (v virtualKeys) GetVirtualKey(secretKey string) (*gateway.VirtualKeyConfig, error) {
+	id, ok := v[secretKey]
+	if !ok {
+		return nil, gateway.ErrVirtualKeyNotFound
+	}
+	return &gateway.VirtualKeyConfig{ID: id, SecretKey: secretKey}, nil
+}
+
+func TestResponseStoreMiddleware_Owner(t *testing.T) {
+	store := NewInMemoryResponseStore()
+	configStore := virtualKeys{"sk-uno-a": "a", "sk-uno-b": "b"}
+	middleware := NewResponseStoreMiddleware(configStore, store)
+
+	var received *responses.Request
+	handler := middleware.HandleRequest(func(ctx context.Context, providerName llm.ProviderName, key string, r *llm.Request) (*llm.Response, error) {
+		received = r.OfResponsesInput
+		return &llm.Response{OfResponsesOutput: &responses.Response{ID: "resp_1"}}, nil
+	})
+
+	_, err := handler(context.Background(), llm.ProviderNameOpenAI, "sk-uno-a", &llm.Request{OfResponsesInput: &responses.Request{
+		Input:      responses.InputUnion{OfString: utils.Ptr("Hello")},
+		Parameters: responses.Parameters{Store: utils.Ptr(true)},
+	}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "owner", key: "sk-uno-a"},
+		{name: "other virtual key", key: "sk-uno-b", wantErr: ErrResponseNotFound},
+		{name: "unknown virtual key", key: "sk-uno-c", wantErr: gateway.ErrVirtualKeyNotFound},
+		{name: "provider key", key: "sk-proj-a", wantErr: ErrResponseNotFound},
+		{name: "no key", key: "", wantErr: ErrResponseNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handler(context.Background(), llm.ProviderNameOpenAI, tt.key, &llm.Request{OfResponsesInput: &responses.Request{
+				Input:      responses.InputUnion{OfString: utils.Ptr("Again")},
+				Parameters: responses.Parameters{PreviousResponseID: utils.Ptr("resp_1")},
+			}})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, received.Input.OfInputMessageList, 2)
+
+			owner, err := ResponseOwner(configStore, tt.key)
+			require.NoError(t, err)
+			_, err = store.GetResponse(context.Background(), owner, "resp_1")
+			assert.NoError(t, err)
+		})
+	}
+
+	// The other keys can't delete the response either
+	owner, err := ResponseOwner(configStore, "sk-uno-b")
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.DeleteResponse(context.Background(), owner, "resp_1"), ErrResponseNotFound)
+}
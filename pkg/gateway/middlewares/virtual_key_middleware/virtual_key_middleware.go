@@ -146,6 +146,11 @@ func (middleware *VirtualKeyMiddleware) getDirectKey(ctx context.Context, provid
 		return key, err
 	}
 
+	// Self-hosted servers are often run without api keys, and Vertex AI falls back to the default credentials
+	if providerConfig != nil && len(providerConfig.ApiKeys) == 0 && providerName.KeysOptional() {
+		return "", nil
+	}
+
 	if providerConfig == nil || len(providerConfig.ApiKeys) == 0 {
 		err := errors.New("provider configs or api keys are not found")
 		return key, err
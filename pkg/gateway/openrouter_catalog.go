@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter"
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// UseOpenRouterCatalog rejects the requests to OpenRouter for models missing from the catalog, before sending them.
+// The catalog is loaded by LoadOpenRouterCatalog, the models aren't checked until it is.
+func (g *LLMGateway) UseOpenRouterCatalog(catalog *openrouter.Catalog) {
+	g.openRouterCatalog = catalog
+}
+
+// OpenRouterCatalog returns the catalog of the models of OpenRouter, nil when the models aren't checked
+func (g *LLMGateway) OpenRouterCatalog() *openrouter.Catalog {
+	return g.openRouterCatalog
+}
+
+// LoadOpenRouterCatalog fetches the catalog of OpenRouter, with the base URL and the HTTP settings of its provider
+// config if it is configured. The pricing of the models is registered for their costs, see llm.RegisterModelPricing.
+func (g *LLMGateway) LoadOpenRouterCatalog(ctx context.Context) error {
+	if g.openRouterCatalog == nil {
+		return nil
+	}
+
+	// The catalog is public, it is loaded before the provider is configured too
+	providerConfig, err := g.ConfigStore.GetProviderConfig(llm.ProviderNameOpenRouter)
+	if err != nil {
+		providerConfig = nil
+	}
+
+	var baseURL string
+	if providerConfig != nil {
+		baseURL = providerConfig.BaseURL
+	}
+
+	httpClient, err := g.providerHTTPClient(llm.ProviderNameOpenRouter, providerConfig, false)
+	if err != nil {
+		return err
+	}
+
+	return g.openRouterCatalog.Load(ctx, httpClient, baseURL)
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// probeTimeout bounds the probe of a key, so that an unreachable provider doesn't hold the other probes
+const probeTimeout = 30 * time.Second
+
+// KeyProbe is the outcome of the probe of an API key of a provider
+type KeyProbe struct {
+	Name    string
+	Enabled bool
+	Latency time.Duration
+	Err     error
+}
+
+// ProbeKeys sends a minimal request to the model with each API key of the provider, so that the keys are validated
+// before they serve traffic. The requests bypass the middlewares, they are neither logged nor rate limited, and the
+// providers without keys are probed once without key if their keys are optional.
+func (g *LLMGateway) ProbeKeys(ctx context.Context, providerName llm.ProviderName, model string) ([]KeyProbe, error) {
+	providerConfig, err := g.ConfigStore.GetProviderConfig(providerName)
+	if err != nil {
+		return nil, errors.New("failed to get provider config")
+	}
+
+	keys := providerConfig.ApiKeys
+	if len(keys) == 0 {
+		if !providerName.KeysOptional() {
+			return nil, errors.New("provider has no api keys")
+		}
+		keys = []*APIKeyConfig{{ProviderName: providerName, Enabled: true}}
+	}
+
+	probes := make([]KeyProbe, 0, len(keys))
+	for _, key := range keys {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		start := time.Now()
+		_, err := g.baseRequestHandler(probeCtx, providerName, key.APIKey, &llm.Request{
+			OfResponsesInput: &responses.Request{
+				Model: model,
+				Input: responses.InputUnion{OfString: utils.Ptr("ping")},
+				Parameters: responses.Parameters{
+					MaxOutputTokens: utils.Ptr(16),
+				},
+			},
+		})
+		cancel()
+
+		probes = append(probes, KeyProbe{
+			Name:    key.Name,
+			Enabled: key.Enabled,
+			Latency: time.Since(start),
+			Err:     err,
+		})
+	}
+
+	return probes, nil
+}
@@ -4,73 +4,261 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/curaious/uno/pkg/gateway/providers/anthropic"
+	"github.com/curaious/uno/pkg/gateway/providers/azure"
+	"github.com/curaious/uno/pkg/gateway/providers/bedrock"
+	"github.com/curaious/uno/pkg/gateway/providers/cohere"
+	"github.com/curaious/uno/pkg/gateway/providers/fireworks"
 	"github.com/curaious/uno/pkg/gateway/providers/gemini"
+	"github.com/curaious/uno/pkg/gateway/providers/huggingface"
+	"github.com/curaious/uno/pkg/gateway/providers/mistral"
 	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter"
+	"github.com/curaious/uno/pkg/gateway/providers/perplexity"
+	"github.com/curaious/uno/pkg/gateway/providers/replicate"
+	"github.com/curaious/uno/pkg/gateway/providers/together"
+	"github.com/curaious/uno/pkg/gateway/providers/vertex"
 	"github.com/curaious/uno/pkg/gateway/providers/xai"
 	"github.com/curaious/uno/pkg/llm"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 )
 
-func (g *LLMGateway) getProvider(ctx context.Context, providerName llm.ProviderName, req *llm.Request, key string) (llm.Provider, error) {
+// getProvider constructs the provider client, along with the region it was routed to if the provider has regions
+func (g *LLMGateway) getProvider(ctx context.Context, providerName llm.ProviderName, req *llm.Request, key string) (llm.Provider, string, error) {
 	_, span := tracer.Start(ctx, "Gateway.GetProvider")
 	defer span.End()
 
 	var baseUrl string
 	var customHeaders map[string]string
+	var apiVersion string
+	var deployments map[string]string
+	var gcpProject string
 
 	providerConfig, err := g.ConfigStore.GetProviderConfig(providerName)
 	if err != nil {
 		err = errors.New("failed to get provider config")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
+	ctx, err = checkDataRegion(ctx, providerName, providerConfig)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, "", err
+	}
+
+	var regionName string
 	if providerConfig != nil {
 		baseUrl = providerConfig.BaseURL
 		customHeaders = providerConfig.CustomHeaders
+		apiVersion = providerConfig.APIVersion
+		deployments = providerConfig.Deployments
+		gcpProject = providerConfig.GCPProject
+
+		if region, ok := g.Regions.Select(ctx, providerName, providerConfig); ok {
+			baseUrl = region.BaseURL
+			regionName = region.Name
+		}
 	}
 
-	span.SetAttributes(attribute.String("base_url", baseUrl))
+	span.SetAttributes(attribute.String("base_url", baseUrl), attribute.String("region", regionName))
+
+	if baseUrl == "" && providerName.RequiresBaseURL() {
+		err = fmt.Errorf("base url of %s is not configured", providerName)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, "", err
+	}
+
+	// nil without HTTP settings nor interceptors, the clients then use the default HTTP client
+	dryRun := req.OfResponsesInput != nil && req.OfResponsesInput.IsDryRun()
+	httpClient, err := g.providerHTTPClient(providerName, providerConfig, dryRun)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, "", err
+	}
 
 	switch providerName {
 	case llm.ProviderNameOpenAI:
 		return openai.NewClient(&openai.ClientOptions{
-			BaseURL: baseUrl,
-			ApiKey:  key,
-			Headers: customHeaders,
-		}), nil
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+			// A custom base URL points to an OpenAI-compatible server
+			GuidedDecoding: baseUrl != "" && !strings.HasPrefix(baseUrl, "https://api.openai.com"),
+		}), regionName, nil
 
 	case llm.ProviderNameAnthropic:
 		return anthropic.NewClient(&anthropic.ClientOptions{
-			BaseURL: baseUrl,
-			ApiKey:  key,
-			Headers: customHeaders,
-		}), nil
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
 
 	case llm.ProviderNameGemini:
 		return gemini.NewClient(&gemini.ClientOptions{
-			BaseURL: baseUrl,
-			ApiKey:  key,
-			Headers: customHeaders,
-		}), nil
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
 
 	case llm.ProviderNameXAI:
 		return xai.NewClient(&xai.ClientOptions{
-			BaseURL: baseUrl,
-			ApiKey:  key,
-			Headers: customHeaders,
-		}), nil
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
 	case llm.ProviderNameOllama:
 		return openai.NewClient(&openai.ClientOptions{
-			BaseURL: baseUrl,
-			ApiKey:  key,
-			Headers: customHeaders,
-		}), nil
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameVLLM:
+		return openai.NewClient(&openai.ClientOptions{
+			BaseURL:        baseUrl,
+			ApiKey:         key,
+			Headers:        customHeaders,
+			HTTPClient:     httpClient,
+			GuidedDecoding: true,
+			ExtraParams:    openai.VLLMExtraParams,
+		}), regionName, nil
+
+	case llm.ProviderNameHuggingFace:
+		return huggingface.NewClient(&huggingface.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameReplicate:
+		return replicate.NewClient(&replicate.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+			Webhooks:   g.replicateWebhooks,
+		}), regionName, nil
+
+	// The requests are signed for the region of the endpoint, so the regions of the provider are AWS regions
+	case llm.ProviderNameBedrock:
+		return bedrock.NewClient(&bedrock.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	// The base URL is the endpoint of the resource, the regions are the resources deployed to other Azure regions
+	case llm.ProviderNameAzure:
+		return azure.NewClient(&azure.ClientOptions{
+			BaseURL:     baseUrl,
+			APIVersion:  apiVersion,
+			Deployments: deployments,
+			ApiKey:      key,
+			Headers:     customHeaders,
+			HTTPClient:  httpClient,
+		}), regionName, nil
+
+	// The requests are sent to the location of the endpoint, so the regions of the provider are Google Cloud regions.
+	// The access tokens are requested even for the dry runs, which only capture the requests to the models.
+	case llm.ProviderNameVertexAI:
+		authClient, err := g.providerHTTPClient(providerName, providerConfig, false)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, "", err
+		}
+
+		return vertex.NewClient(&vertex.ClientOptions{
+			BaseURL:        baseUrl,
+			Project:        gcpProject,
+			ApiKey:         key,
+			Headers:        customHeaders,
+			HTTPClient:     httpClient,
+			AuthHTTPClient: authClient,
+		}), regionName, nil
+
+	case llm.ProviderNameMistral:
+		return mistral.NewClient(&mistral.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameCohere:
+		return cohere.NewClient(&cohere.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameTogetherAI:
+		return together.NewClient(&together.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameFireworks:
+		return fireworks.NewClient(&fireworks.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNamePerplexity:
+		return perplexity.NewClient(&perplexity.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameOpenRouter:
+		return openrouter.NewClient(&openrouter.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+			Catalog:    g.openRouterCatalog,
+		}), regionName, nil
+
+	// The features the server doesn't support are dropped from the requests, see ProviderConfig.Capabilities
+	case llm.ProviderNameOpenAICompatible:
+		return openai.NewClient(&openai.ClientOptions{
+			BaseURL:    baseUrl,
+			ApiKey:     key,
+			Headers:    customHeaders,
+			HTTPClient: httpClient,
+		}), regionName, nil
+
+	case llm.ProviderNameTGI:
+		return openai.NewClient(&openai.ClientOptions{
+			BaseURL:     baseUrl,
+			ApiKey:      key,
+			Headers:     customHeaders,
+			HTTPClient:  httpClient,
+			ExtraParams: openai.TGIExtraParams,
+		}), regionName, nil
 	}
 
-	return nil, fmt.Errorf("unknown provider: %s", providerName)
+	return nil, "", fmt.Errorf("unknown provider: %s", providerName)
 }
@@ -1,6 +1,8 @@
 package anthropic_responses
 
 import (
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -39,6 +41,9 @@ func (in *Request) ToNativeRequest() *responses.Request {
 				Summary:      utils.Ptr("auto"),
 				BudgetTokens: in.Thinking.BudgetTokens,
 			}
+			if in.Thinking.BudgetTokens != nil {
+				out.Reasoning.Effort = utils.Ptr(string(responses.ReasoningEffortForBudget(*in.Thinking.BudgetTokens)))
+			}
 			out.Include = append(out.Include, responses.IncludableReasoningEncryptedContent)
 		}
 	}
@@ -146,9 +151,9 @@ func MessagesToNativeMessages(msgs []MessageUnion) responses.InputUnion {
 func (msg *MessageUnion) ToNativeMessage() []responses.InputMessageUnion {
 	out := []responses.InputMessageUnion{}
 
-	var previousServerToolUse *ServerToolUseContent
+	serverToolCalls := pairServerToolCalls(msg.Content)
 
-	for _, content := range msg.Content {
+	for i, content := range msg.Content {
 		if content.OfText != nil {
 			if content.OfText.Citations != nil {
 				out = append(out, responses.InputMessageUnion{
@@ -244,71 +249,11 @@ func (msg *MessageUnion) ToNativeMessage() []responses.InputMessageUnion {
 			})
 		}
 
-		if content.OfServerToolUse != nil {
-			previousServerToolUse = content.OfServerToolUse
-		}
-
-		if content.OfWebSearchResult != nil {
-			if previousServerToolUse != nil && previousServerToolUse.Name == "web_search" {
-				id := previousServerToolUse.Id
-				query := previousServerToolUse.Input.Query
-				var sources []responses.WebSearchCallActionOfSearchSource
-				for _, searchResultContent := range content.OfWebSearchResult.Content {
-					sources = append(sources, responses.WebSearchCallActionOfSearchSource{
-						Type: "url",
-						URL:  searchResultContent.Url,
-						ExtraParams: map[string]any{
-							"Anthropic": searchResultContent,
-						},
-					})
-				}
-
-				out = append(out, responses.InputMessageUnion{
-					OfWebSearchCall: &responses.WebSearchCallMessage{
-						ID: id,
-						Action: responses.WebSearchCallActionUnion{
-							OfSearch: &responses.WebSearchCallActionOfSearch{
-								Queries: []string{
-									query,
-								},
-								Query:   query,
-								Sources: sources,
-							},
-						},
-						Status: "completed",
-					},
-				})
-
-				previousServerToolUse = nil
-			}
-		}
-
-		if content.OfBashCodeExecutionToolResult != nil {
-			if previousServerToolUse != nil && previousServerToolUse.Name == "bash_code_execution" {
-				id := previousServerToolUse.Id
-				cmd := previousServerToolUse.Input.Command
-				cmdOut := content.OfBashCodeExecutionToolResult.Content.Stdout
-				if content.OfBashCodeExecutionToolResult.Content.ReturnCode != 0 {
-					cmd = content.OfBashCodeExecutionToolResult.Content.Stderr
-				}
-
-				out = append(out, responses.InputMessageUnion{
-					OfCodeInterpreterCall: &responses.CodeInterpreterCallMessage{
-						ID:          id,
-						Status:      "completed",
-						Code:        cmd,
-						ContainerID: "",
-						Outputs: []responses.CodeInterpreterCallOutputParam{
-							{
-								Type: "logs",
-								Logs: cmdOut,
-							},
-						},
-					},
-				})
-
-				previousServerToolUse = nil
-			}
+		if call, ok := serverToolCalls[i]; ok {
+			out = append(out, responses.InputMessageUnion{
+				OfWebSearchCall:       call.webSearch,
+				OfCodeInterpreterCall: call.codeInterpreter,
+			})
 		}
 	}
 
@@ -318,9 +263,9 @@ func (msg *MessageUnion) ToNativeMessage() []responses.InputMessageUnion {
 func (in *Response) ToNativeResponse() *responses.Response {
 	output := []responses.OutputMessageUnion{}
 
-	var previousWebSearchCall *ServerToolUseContent
+	serverToolCalls := pairServerToolCalls(in.Content)
 
-	for _, content := range in.Content {
+	for i, content := range in.Content {
 		if content.OfText != nil {
 			output = append(output, responses.OutputMessageUnion{
 				OfOutputMessage: &responses.OutputMessage{
@@ -369,73 +314,16 @@ func (in *Response) ToNativeResponse() *responses.Response {
 		if content.OfRedactedThinking != nil {
 			output = append(output, responses.OutputMessageUnion{
 				OfReasoning: &responses.ReasoningMessage{
-					EncryptedContent: utils.Ptr(content.OfThinking.Signature),
+					EncryptedContent: utils.Ptr(content.OfRedactedThinking.Data),
 				},
 			})
 		}
 
-		// We simply store the service_tool_use message for later reference
-		if content.OfServerToolUse != nil {
-			previousWebSearchCall = content.OfServerToolUse
-		}
-
-		if content.OfWebSearchResult != nil && previousWebSearchCall != nil && previousWebSearchCall.Name == "web_search" {
-			sources := []responses.WebSearchCallActionOfSearchSource{}
-			for _, searchResultContent := range content.OfWebSearchResult.Content {
-				sources = append(sources, responses.WebSearchCallActionOfSearchSource{
-					Type: "url",
-					URL:  searchResultContent.Url,
-					ExtraParams: map[string]any{
-						"Anthropic": searchResultContent,
-					},
-				})
-			}
-
+		if call, ok := serverToolCalls[i]; ok {
 			output = append(output, responses.OutputMessageUnion{
-				OfWebSearchCall: &responses.WebSearchCallMessage{
-					ID: previousWebSearchCall.Id,
-					Action: responses.WebSearchCallActionUnion{
-						OfSearch: &responses.WebSearchCallActionOfSearch{
-							Queries: []string{
-								previousWebSearchCall.Input.Query,
-							},
-							Query:   previousWebSearchCall.Input.Query,
-							Sources: sources,
-						},
-					},
-					Status: "completed",
-				},
+				OfWebSearchCall:       call.webSearch,
+				OfCodeInterpreterCall: call.codeInterpreter,
 			})
-
-			previousWebSearchCall = nil
-		}
-
-		if content.OfBashCodeExecutionToolResult != nil {
-			if previousWebSearchCall != nil && previousWebSearchCall.Name == "bash_code_execution" {
-				id := previousWebSearchCall.Id
-				cmd := previousWebSearchCall.Input.Command
-				cmdOut := content.OfBashCodeExecutionToolResult.Content.Stdout
-				if content.OfBashCodeExecutionToolResult.Content.ReturnCode != 0 {
-					cmd = content.OfBashCodeExecutionToolResult.Content.Stderr
-				}
-
-				output = append(output, responses.OutputMessageUnion{
-					OfCodeInterpreterCall: &responses.CodeInterpreterCallMessage{
-						ID:          id,
-						Status:      "completed",
-						Code:        cmd,
-						ContainerID: "",
-						Outputs: []responses.CodeInterpreterCallOutputParam{
-							{
-								Type: "logs",
-								Logs: cmdOut,
-							},
-						},
-					},
-				})
-
-				previousWebSearchCall = nil
-			}
 		}
 	}
 
@@ -470,6 +358,92 @@ func (in *Response) ToNativeResponse() *responses.Response {
 	}
 }
 
+// serverToolCall is the native item of a server tool use and its result
+type serverToolCall struct {
+	webSearch       *responses.WebSearchCallMessage
+	codeInterpreter *responses.CodeInterpreterCallMessage
+}
+
+// pairServerToolCalls pairs the server tool uses of the contents with their results by ID, and returns their native
+// items by the position of the use. The results may come after other blocks, e.g. after the other uses of parallel
+// searches, the native item takes the position of its use. The uses without a result aren't returned.
+func pairServerToolCalls(contents Contents) map[int]serverToolCall {
+	uses := map[string]int{}
+	for i, content := range contents {
+		if content.OfServerToolUse != nil {
+			uses[content.OfServerToolUse.Id] = i
+		}
+	}
+
+	calls := map[int]serverToolCall{}
+	for _, content := range contents {
+		switch {
+		case content.OfWebSearchResult != nil:
+			i, ok := uses[content.OfWebSearchResult.ToolUseId]
+			if !ok || contents[i].OfServerToolUse.Name != "web_search" {
+				continue
+			}
+			use := contents[i].OfServerToolUse
+			calls[i] = serverToolCall{webSearch: webSearchCallMessage(use.Id, use.Input.Query, content.OfWebSearchResult)}
+
+		case content.OfBashCodeExecutionToolResult != nil:
+			i, ok := uses[content.OfBashCodeExecutionToolResult.ToolUseId]
+			if !ok || contents[i].OfServerToolUse.Name != "bash_code_execution" {
+				continue
+			}
+			use := contents[i].OfServerToolUse
+			calls[i] = serverToolCall{codeInterpreter: codeInterpreterCallMessage(use.Id, use.Input.Command, content.OfBashCodeExecutionToolResult)}
+		}
+	}
+
+	return calls
+}
+
+func webSearchCallMessage(id, query string, result *WebSearchResultContent) *responses.WebSearchCallMessage {
+	sources := []responses.WebSearchCallActionOfSearchSource{}
+	for _, searchResultContent := range result.Content {
+		sources = append(sources, responses.WebSearchCallActionOfSearchSource{
+			Type: "url",
+			URL:  searchResultContent.Url,
+			ExtraParams: map[string]any{
+				"Anthropic": searchResultContent,
+			},
+		})
+	}
+
+	return &responses.WebSearchCallMessage{
+		ID: id,
+		Action: responses.WebSearchCallActionUnion{
+			OfSearch: &responses.WebSearchCallActionOfSearch{
+				Queries: []string{query},
+				Query:   query,
+				Sources: sources,
+			},
+		},
+		Status: "completed",
+	}
+}
+
+func codeInterpreterCallMessage(id, code string, result *BashCodeExecutionResultContent) *responses.CodeInterpreterCallMessage {
+	output := result.Content.Stdout
+	if result.Content.ReturnCode != 0 {
+		output = result.Content.Stderr
+	}
+
+	return &responses.CodeInterpreterCallMessage{
+		ID:          id,
+		Status:      "completed",
+		Code:        code,
+		ContainerID: "",
+		Outputs: []responses.CodeInterpreterCallOutputParam{
+			{
+				Type: "logs",
+				Logs: output,
+			},
+		},
+	}
+}
+
 // =============================================================================
 // ResponseChunk to Native Conversion
 // =============================================================================
@@ -490,8 +464,17 @@ type ResponseChunkToNativeResponseChunkConverter struct {
 	contentIndex     int // Always 0, as each Anthropic content becomes a separate native message
 	currentOutputID  string
 	accumulatedDelta string
-	accumulatedSig   string // Accumulated reasoning signature
-	completedOutputs []responses.OutputMessageUnion
+	accumulatedSig   string                               // Accumulated reasoning signature
+	completedOutputs map[int]responses.OutputMessageUnion // By output index
+	serverToolUses   map[string]*pendingServerToolUse     // Server tool uses waiting for their result, by ID
+}
+
+// pendingServerToolUse is a server tool use waiting for its result, the results may come after other blocks, e.g.
+// after the other uses of parallel searches
+type pendingServerToolUse struct {
+	id          string
+	outputIndex int
+	input       string // Accumulated input JSON
 }
 
 // nextSeqNum returns the next sequence number and increments the counter.
@@ -695,6 +678,10 @@ func (c *ResponseChunkToNativeResponseChunkConverter) handleContentBlockStop() [
 	var result []*responses.ResponseChunk
 	content := c.currentBlock.ContentBlock
 
+	// The results of the server tool uses complete the items of their uses, and the blocks of unknown types aren't
+	// converted, neither takes an output index
+	takesOutputIndex := true
+
 	switch {
 	case content.OfText != nil:
 		result = c.completeTextBlock()
@@ -703,15 +690,22 @@ func (c *ResponseChunkToNativeResponseChunkConverter) handleContentBlockStop() [
 	case content.OfThinking != nil:
 		result = c.completeThinkingBlock()
 	case content.OfServerToolUse != nil:
-		return c.completeServerToolUseBlock(content.OfServerToolUse)
+		result = c.completeServerToolUseBlock(content.OfServerToolUse)
+		takesOutputIndex = c.serverToolUses[content.OfServerToolUse.Id] != nil
 	case content.OfWebSearchResult != nil:
 		result = c.completeWebSearchCallBlock(content.OfWebSearchResult)
+		takesOutputIndex = false
 	case content.OfBashCodeExecutionToolResult != nil:
 		result = c.completeBashCodeExecutionToolResult(content.OfBashCodeExecutionToolResult)
+		takesOutputIndex = false
+	default:
+		takesOutputIndex = false
 	}
 
 	// Reset for next block
-	c.outputIndex++
+	if takesOutputIndex {
+		c.outputIndex++
+	}
 	c.accumulatedDelta = ""
 	c.accumulatedSig = ""
 
@@ -723,7 +717,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) completeTextBlock() []*res
 	role := c.currentRole()
 
 	// Store for final response
-	c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+	c.complete(c.outputIndex, responses.OutputMessageUnion{
 		OfOutputMessage: &responses.OutputMessage{
 			ID:   c.currentOutputID,
 			Role: role,
@@ -747,7 +741,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) completeToolUseBlock(toolU
 	}
 
 	// Store for final response
-	c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+	c.complete(c.outputIndex, responses.OutputMessageUnion{
 		OfFunctionCall: &responses.FunctionCallMessage{
 			ID:        c.currentOutputID,
 			CallID:    toolUse.ID,
@@ -767,7 +761,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) completeThinkingBlock() []
 	sig := c.accumulatedSig
 
 	// Store for final response
-	c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+	c.complete(c.outputIndex, responses.OutputMessageUnion{
 		OfReasoning: &responses.ReasoningMessage{
 			ID:               c.currentOutputID,
 			Summary:          []responses.SummaryTextContent{{Text: text}},
@@ -782,12 +776,24 @@ func (c *ResponseChunkToNativeResponseChunkConverter) completeThinkingBlock() []
 	}
 }
 
+// completeServerToolUseBlock keeps the server tool use waiting for its result, the item of the use is done with its
+// result, which may come after other blocks
 func (c *ResponseChunkToNativeResponseChunkConverter) completeServerToolUseBlock(serverToolUse *ServerToolUseContent) []*responses.ResponseChunk {
 	text := c.accumulatedDelta
 
-	if serverToolUse.Name == "web_search" {
-		return nil // we don't do anything for server_tool_use content stop, we will wait for content_block_stop of "web_search_tool_result"
+	if serverToolUse.Name != "web_search" && serverToolUse.Name != "bash_code_execution" {
+		return nil
+	}
+
+	use := &pendingServerToolUse{
+		id:          serverToolUse.Id,
+		outputIndex: c.outputIndex,
+		input:       text,
+	}
+	if c.serverToolUses == nil {
+		c.serverToolUses = map[string]*pendingServerToolUse{}
 	}
+	c.serverToolUses[use.id] = use
 
 	if serverToolUse.Name == "bash_code_execution" {
 		return []*responses.ResponseChunk{
@@ -800,25 +806,50 @@ func (c *ResponseChunkToNativeResponseChunkConverter) completeServerToolUseBlock
 }
 
 func (c *ResponseChunkToNativeResponseChunkConverter) completeWebSearchCallBlock(webSearchResult *WebSearchResultContent) []*responses.ResponseChunk {
+	use := c.serverToolUses[webSearchResult.ToolUseId]
+	if use == nil {
+		return nil
+	}
+	delete(c.serverToolUses, use.id)
+
+	input := struct {
+		Query string `json:"query"`
+	}{}
+	_ = sonic.UnmarshalString(use.input, &input)
+
+	webSearchCall := webSearchCallMessage(use.id, input.Query, webSearchResult)
+	c.complete(use.outputIndex, responses.OutputMessageUnion{OfWebSearchCall: webSearchCall})
+
 	return []*responses.ResponseChunk{
-		c.buildOutputItemDoneWebSearchCall(webSearchResult),
+		c.buildOutputItemDoneWebSearchCall(use, webSearchCall),
 	}
 }
 
 func (c *ResponseChunkToNativeResponseChunkConverter) completeBashCodeExecutionToolResult(bashCodeExecutionResult *BashCodeExecutionResultContent) []*responses.ResponseChunk {
-	text := c.accumulatedDelta
-
-	output := c.currentBlock.ContentBlock.OfBashCodeExecutionToolResult.Content.Stdout
-	if c.currentBlock.ContentBlock.OfBashCodeExecutionToolResult.Content.ReturnCode > 0 {
-		output = c.currentBlock.ContentBlock.OfBashCodeExecutionToolResult.Content.Stderr
+	use := c.serverToolUses[bashCodeExecutionResult.ToolUseId]
+	if use == nil {
+		return nil
 	}
+	delete(c.serverToolUses, use.id)
+
+	codeInterpreterCall := codeInterpreterCallMessage(use.id, use.input, bashCodeExecutionResult)
+	c.complete(use.outputIndex, responses.OutputMessageUnion{OfCodeInterpreterCall: codeInterpreterCall})
 
 	return []*responses.ResponseChunk{
-		c.buildCodeInterpreterCallCompleted(text),
-		c.buildOutputItemDoneCodeInterpreterCall(text, output),
+		c.buildCodeInterpreterCallCompleted(use),
+		c.buildOutputItemDoneCodeInterpreterCall(use, codeInterpreterCall.Outputs[0].Logs),
 	}
 }
 
+// complete stores the completed output for the final response at its output index. The item of a server tool use
+// is completed by its result, after the items following the use.
+func (c *ResponseChunkToNativeResponseChunkConverter) complete(outputIndex int, output responses.OutputMessageUnion) {
+	if c.completedOutputs == nil {
+		c.completedOutputs = map[int]responses.OutputMessageUnion{}
+	}
+	c.completedOutputs[outputIndex] = output
+}
+
 // handleMessageDelta stores usage info for the final response
 func (c *ResponseChunkToNativeResponseChunkConverter) handleMessageDelta(delta *ChunkMessage[ChunkTypeMessageDelta]) []*responses.ResponseChunk {
 	c.messageDelta = delta
@@ -1032,29 +1063,29 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildCodeInterpreterCallIn
 	}
 }
 
-func (c *ResponseChunkToNativeResponseChunkConverter) buildCodeInterpreterCallCompleted(code string) *responses.ResponseChunk {
+func (c *ResponseChunkToNativeResponseChunkConverter) buildCodeInterpreterCallCompleted(use *pendingServerToolUse) *responses.ResponseChunk {
 	return &responses.ResponseChunk{
 		OfCodeInterpreterCallCompleted: &responses.ChunkCodeInterpreterCall[constants.ChunkTypeCodeInterpreterCallCompleted]{
 			Type:           constants.ChunkTypeCodeInterpreterCallCompleted(""),
 			SequenceNumber: c.nextSeqNum(),
-			ItemId:         c.currentOutputID,
-			OutputIndex:    c.outputIndex,
-			Code:           &code,
+			ItemId:         use.id,
+			OutputIndex:    use.outputIndex,
+			Code:           &use.input,
 		},
 	}
 }
 
-func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneCodeInterpreterCall(code string, output string) *responses.ResponseChunk {
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneCodeInterpreterCall(use *pendingServerToolUse, output string) *responses.ResponseChunk {
 	return &responses.ResponseChunk{
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    c.outputIndex,
+			OutputIndex:    use.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:   "code_interpreter_call",
-				Id:     c.currentOutputID,
+				Id:     use.id,
 				Status: "completed",
-				Code:   &code,
+				Code:   &use.input,
 				Outputs: []responses.CodeInterpreterCallOutputParam{
 					{
 						Type: "logs",
@@ -1281,42 +1312,17 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneReasoni
 	}
 }
 
-func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneWebSearchCall(webSearchResults *WebSearchResultContent) *responses.ResponseChunk {
-	sources := []responses.WebSearchCallActionOfSearchSource{}
-	for _, webSearchResult := range webSearchResults.Content {
-		sources = append(sources, responses.WebSearchCallActionOfSearchSource{
-			Type: "url",
-			URL:  webSearchResult.Url,
-			ExtraParams: map[string]any{
-				"Anthropic": webSearchResult,
-			},
-		})
-	}
-
-	query := ""
-	accumulatedPayload := struct {
-		Query string `json:"query"`
-	}{}
-	if err := sonic.Unmarshal([]byte(c.accumulatedDelta), &accumulatedPayload); err == nil {
-		query = accumulatedPayload.Query
-	}
-
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneWebSearchCall(use *pendingServerToolUse, webSearchCall *responses.WebSearchCallMessage) *responses.ResponseChunk {
 	return &responses.ResponseChunk{
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    use.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:   "web_search_call",
-				Id:     c.currentOutputID,
+				Id:     use.id,
 				Status: "completed",
-				Action: &responses.WebSearchCallActionUnion{
-					OfSearch: &responses.WebSearchCallActionOfSearch{
-						Queries: []string{query},
-						Query:   query,
-						Sources: sources,
-					},
-				},
+				Action: &webSearchCall.Action,
 			},
 		},
 	}
@@ -1326,6 +1332,11 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCompleted() *
 	msg := c.messageStart.Message
 	usage := c.messageDelta.Usage
 
+	output := make([]responses.OutputMessageUnion, 0, len(c.completedOutputs))
+	for _, outputIndex := range slices.Sorted(maps.Keys(c.completedOutputs)) {
+		output = append(output, c.completedOutputs[outputIndex])
+	}
+
 	return &responses.ResponseChunk{
 		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
 			Type:           constants.ChunkTypeResponseCompleted(""),
@@ -1335,7 +1346,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCompleted() *
 				Object:    "response",
 				CreatedAt: int(time.Now().Unix()),
 				Status:    "completed",
-				Output:    c.completedOutputs,
+				Output:    output,
 				Usage: responses.Usage{
 					InputTokens: usage.InputTokens,
 					InputTokensDetails: struct {
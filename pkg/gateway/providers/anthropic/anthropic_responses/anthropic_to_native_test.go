@@ -3,6 +3,7 @@ package anthropic_responses
 import (
 	"testing"
 
+	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm/constants"
 	"github.com/curaious/uno/pkg/llm/responses"
 	"github.com/stretchr/testify/assert"
@@ -94,6 +95,40 @@ func createThinkingBlockStartChunk(index int, signature string) *ResponseChunk {
 	}
 }
 
+// Helper to create a content_block_start for server_tool_use
+func createServerToolUseBlockStartChunk(index int, id, name string) *ResponseChunk {
+	return &ResponseChunk{
+		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
+			Type:  ChunkTypeContentBlockStart("content_block_start"),
+			Index: index,
+			ContentBlock: &ContentUnion{
+				OfServerToolUse: &ServerToolUseContent{
+					Type: "server_tool_use",
+					Id:   id,
+					Name: name,
+				},
+			},
+		},
+	}
+}
+
+// Helper to create a content_block_start for web_search_tool_result
+func createWebSearchResultBlockStartChunk(index int, toolUseId, url string) *ResponseChunk {
+	return &ResponseChunk{
+		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
+			Type:  ChunkTypeContentBlockStart("content_block_start"),
+			Index: index,
+			ContentBlock: &ContentUnion{
+				OfWebSearchResult: &WebSearchResultContent{
+					Type:      "web_search_tool_result",
+					ToolUseId: toolUseId,
+					Content:   []WebSearchResultContentParam{{Type: "web_search_result", Url: url, Title: url}},
+				},
+			},
+		},
+	}
+}
+
 // Helper to create a text_delta chunk
 func createTextDeltaChunk(index int, text string) *ResponseChunk {
 	return &ResponseChunk{
@@ -632,5 +667,211 @@ func TestResponseChunkToNative_OutputIndexTracking(t *testing.T) {
 	converter.ResponseChunkToNativeResponseChunk(createTextBlockStartChunk(1))
 	converter.ResponseChunkToNativeResponseChunk(createTextDeltaChunk(1, "Second"))
 	result = converter.ResponseChunkToNativeResponseChunk(createBlockStopChunk(1))
-	assert.Equal(t, 0, result[2].OfOutputItemDone.OutputIndex) // Note: OutputIndex in native is always 0 based on implementation
+	assert.Equal(t, 1, result[2].OfOutputItemDone.OutputIndex)
+}
+
+// =============================================================================
+// Test: Thinking Budget → Reasoning Param
+// =============================================================================
+
+func TestAnthropicToNative_ThinkingBudgetReasoningEffort(t *testing.T) {
+	tests := []struct {
+		budget int
+		effort string
+	}{
+		{budget: 1024, effort: "minimal"},
+		{budget: 4096, effort: "low"},
+		{budget: 6000, effort: "medium"},
+		{budget: 16384, effort: "high"},
+		{budget: 64000, effort: "xhigh"},
+	}
+
+	for _, tt := range tests {
+		enabled := "enabled"
+		budget := tt.budget
+		out := (&Request{
+			Model:     "claude-sonnet-4-5",
+			MaxTokens: 128000,
+			Thinking:  &ThinkingParam{Type: &enabled, BudgetTokens: &budget},
+		}).ToNativeRequest()
+
+		require.NotNil(t, out.Reasoning)
+		require.NotNil(t, out.Reasoning.Effort)
+		assert.Equal(t, tt.effort, *out.Reasoning.Effort)
+		assert.Equal(t, tt.budget, *out.Reasoning.BudgetTokens)
+	}
+}
+
+// =============================================================================
+// Test: Output and Content Index Contract
+// =============================================================================
+
+// assertIndexContract checks the indices of a native stream: the items take consecutive output indices in the
+// order they are added, every chunk of an item carries the output index of the item, and the content parts of an
+// item take consecutive content indices. It returns the number of items.
+func assertIndexContract(t *testing.T, chunks []*responses.ResponseChunk) int {
+	t.Helper()
+
+	outputIndex, contentParts := -1, 0
+	for _, chunk := range chunks {
+		buf, err := sonic.Marshal(chunk)
+		require.NoError(t, err)
+
+		var indices struct {
+			OutputIndex  *int `json:"output_index"`
+			ContentIndex *int `json:"content_index"`
+		}
+		require.NoError(t, sonic.Unmarshal(buf, &indices))
+
+		switch chunk.ChunkType() {
+		case "response.output_item.added":
+			outputIndex++
+			contentParts = 0
+		case "response.content_part.added":
+			contentParts++
+		}
+
+		if indices.OutputIndex != nil {
+			assert.Equal(t, outputIndex, *indices.OutputIndex, chunk.ChunkType())
+		}
+		if indices.ContentIndex != nil {
+			assert.Equal(t, contentParts-1, *indices.ContentIndex, chunk.ChunkType())
+		}
+	}
+
+	return outputIndex + 1
+}
+
+func TestResponseChunkToNative_IndexContract(t *testing.T) {
+	converter := newConverter()
+
+	var chunks []*responses.ResponseChunk
+	convert := func(in ...*ResponseChunk) {
+		for _, chunk := range in {
+			chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(chunk)...)
+		}
+	}
+
+	convert(createMessageStartChunk("msg_contract", "claude-sonnet-4-5"))
+
+	convert(createThinkingBlockStartChunk(0, ""), createThinkingDeltaChunk(0, "Let me search"), createSignatureDeltaChunk(0, "sig"), createBlockStopChunk(0))
+
+	// Redacted thinking isn't streamed, it doesn't take an output index
+	convert(&ResponseChunk{
+		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
+			Type:         ChunkTypeContentBlockStart("content_block_start"),
+			Index:        1,
+			ContentBlock: &ContentUnion{OfRedactedThinking: &RedactedThinkingContent{Type: "redacted_thinking", Data: "redacted"}},
+		},
+	}, createBlockStopChunk(1))
+
+	convert(createTextBlockStartChunk(2), createTextDeltaChunk(2, "Searching the web"), createBlockStopChunk(2))
+
+	// The web search and its results are a single item
+	convert(createServerToolUseBlockStartChunk(3, "srvtoolu_1", "web_search"), createInputJSONDeltaChunk(3, `{"query":"weather in NYC"}`), createBlockStopChunk(3))
+	convert(createWebSearchResultBlockStartChunk(4, "srvtoolu_1", "https://weather.example"), createBlockStopChunk(4))
+
+	convert(createToolUseBlockStartChunk(5, "toolu_1", "get_weather"), createInputJSONDeltaChunk(5, `{"city":"NYC"}`), createBlockStopChunk(5))
+
+	convert(createMessageDeltaChunk(100, 50, "tool_use"), createMessageStopChunk())
+
+	assert.Equal(t, 4, assertIndexContract(t, chunks))
+}
+
+// =============================================================================
+// Test: Server Tool Uses Interleaved With Other Blocks
+// =============================================================================
+
+func TestResponseChunkToNative_InterleavedServerToolUses(t *testing.T) {
+	converter := newConverter()
+
+	var chunks []*responses.ResponseChunk
+	convert := func(in ...*ResponseChunk) {
+		for _, chunk := range in {
+			chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(chunk)...)
+		}
+	}
+
+	// Two parallel searches, then text and a client tool use, then the results in another order
+	convert(createMessageStartChunk("msg_interleaved", "claude-sonnet-4-5"))
+	convert(createServerToolUseBlockStartChunk(0, "srvtoolu_nyc", "web_search"), createInputJSONDeltaChunk(0, `{"query":"weather in NYC"}`), createBlockStopChunk(0))
+	convert(createServerToolUseBlockStartChunk(1, "srvtoolu_sf", "web_search"), createInputJSONDeltaChunk(1, `{"query":"weather in SF"}`), createBlockStopChunk(1))
+	convert(createTextBlockStartChunk(2), createTextDeltaChunk(2, "Checking both cities"), createBlockStopChunk(2))
+	convert(createToolUseBlockStartChunk(3, "toolu_1", "get_time"), createInputJSONDeltaChunk(3, `{"city":"NYC"}`), createBlockStopChunk(3))
+	convert(createWebSearchResultBlockStartChunk(4, "srvtoolu_sf", "https://sf.example"), createBlockStopChunk(4))
+	convert(createWebSearchResultBlockStartChunk(5, "srvtoolu_nyc", "https://nyc.example"), createBlockStopChunk(5))
+	convert(createMessageDeltaChunk(100, 50, "tool_use"), createMessageStopChunk())
+
+	added := map[string]int{}
+	done := map[string]*responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{}
+	var completed *responses.ChunkResponse[constants.ChunkTypeResponseCompleted]
+	for _, chunk := range chunks {
+		switch {
+		case chunk.OfOutputItemAdded != nil:
+			added[chunk.OfOutputItemAdded.Item.Id] = chunk.OfOutputItemAdded.OutputIndex
+		case chunk.OfOutputItemDone != nil:
+			done[chunk.OfOutputItemDone.Item.Id] = chunk.OfOutputItemDone
+		case chunk.OfResponseCompleted != nil:
+			completed = chunk.OfResponseCompleted
+		}
+	}
+
+	// Each search is done with its own result, at the output index it was added at
+	assert.Equal(t, 0, added["srvtoolu_nyc"])
+	assert.Equal(t, 1, added["srvtoolu_sf"])
+	require.Contains(t, done, "srvtoolu_nyc")
+	require.Contains(t, done, "srvtoolu_sf")
+	assert.Equal(t, 0, done["srvtoolu_nyc"].OutputIndex)
+	assert.Equal(t, "weather in NYC", done["srvtoolu_nyc"].Item.Action.OfSearch.Query)
+	assert.Equal(t, "https://nyc.example", done["srvtoolu_nyc"].Item.Action.OfSearch.Sources[0].URL)
+	assert.Equal(t, 1, done["srvtoolu_sf"].OutputIndex)
+	assert.Equal(t, "weather in SF", done["srvtoolu_sf"].Item.Action.OfSearch.Query)
+	assert.Equal(t, "https://sf.example", done["srvtoolu_sf"].Item.Action.OfSearch.Sources[0].URL)
+
+	// The completed response keeps the order of the blocks
+	require.NotNil(t, completed)
+	require.Len(t, completed.Response.Output, 4)
+	assert.Equal(t, "srvtoolu_nyc", completed.Response.Output[0].OfWebSearchCall.ID)
+	assert.Equal(t, "srvtoolu_sf", completed.Response.Output[1].OfWebSearchCall.ID)
+	assert.Equal(t, "Checking both cities", completed.Response.Output[2].OfOutputMessage.Content[0].OfOutputText.Text)
+	assert.Equal(t, "toolu_1", completed.Response.Output[3].OfFunctionCall.CallID)
+}
+
+func TestAnthropicToNative_InterleavedServerToolUses(t *testing.T) {
+	use := func(id, query string) ContentUnion {
+		content := ContentUnion{OfServerToolUse: &ServerToolUseContent{Type: "server_tool_use", Id: id, Name: "web_search"}}
+		content.OfServerToolUse.Input.Query = query
+		return content
+	}
+	result := func(toolUseId, url string) ContentUnion {
+		return ContentUnion{OfWebSearchResult: &WebSearchResultContent{
+			Type:      "web_search_tool_result",
+			ToolUseId: toolUseId,
+			Content:   []WebSearchResultContentParam{{Type: "web_search_result", Url: url}},
+		}}
+	}
+
+	out := (&Response{
+		Id:    "msg_interleaved",
+		Model: "claude-sonnet-4-5",
+		Content: Contents{
+			use("srvtoolu_nyc", "weather in NYC"),
+			use("srvtoolu_sf", "weather in SF"),
+			{OfToolUse: &ToolUseContent{Type: "tool_use", ID: "toolu_1", Name: "get_time", Input: map[string]any{"city": "NYC"}}},
+			result("srvtoolu_sf", "https://sf.example"),
+			result("srvtoolu_nyc", "https://nyc.example"),
+			// A search without result isn't converted
+			use("srvtoolu_la", "weather in LA"),
+		},
+		Usage: &ChunkMessageUsage{},
+	}).ToNativeResponse()
+
+	require.Len(t, out.Output, 3)
+	assert.Equal(t, "srvtoolu_nyc", out.Output[0].OfWebSearchCall.ID)
+	assert.Equal(t, "weather in NYC", out.Output[0].OfWebSearchCall.Action.OfSearch.Query)
+	assert.Equal(t, "https://nyc.example", out.Output[0].OfWebSearchCall.Action.OfSearch.Sources[0].URL)
+	assert.Equal(t, "srvtoolu_sf", out.Output[1].OfWebSearchCall.ID)
+	assert.Equal(t, "weather in SF", out.Output[1].OfWebSearchCall.Action.OfSearch.Query)
+	assert.Equal(t, "https://sf.example", out.Output[1].OfWebSearchCall.Action.OfSearch.Sources[0].URL)
+	assert.Equal(t, "toolu_1", out.Output[2].OfFunctionCall.CallID)
 }
@@ -94,6 +94,12 @@ func (m ContentTypeBashCodeExecutionToolResultContent) UnmarshalJSON(buf []byte)
 	return unmarshalConstantString(m, buf)
 }
 
+type ContentTypeImage string
+
+func (m ContentTypeImage) Value() string                  { return "image" }
+func (m ContentTypeImage) MarshalJSON() ([]byte, error)   { return sonic.Marshal(m.Value()) }
+func (m ContentTypeImage) UnmarshalJSON(buf []byte) error { return unmarshalConstantString(m, buf) }
+
 type ContentTypeDeltaText string
 
 func (m ContentTypeDeltaText) Value() string                  { return "text_delta" }
@@ -43,13 +43,11 @@ func NativeRequestToRequest(in *responses.Request) *Request {
 		}
 	}
 
-	if in.Reasoning != nil && in.Reasoning.BudgetTokens != nil {
-		if *in.Reasoning.BudgetTokens >= 1024 && *in.Reasoning.BudgetTokens < *in.MaxOutputTokens {
-			out.Thinking = &ThinkingParam{
-				Type:         utils.Ptr("enabled"),
-				BudgetTokens: in.Reasoning.BudgetTokens,
-			}
-		}
+	out.Thinking = NativeReasoningParamToThinkingParam(in.Reasoning, *in.MaxOutputTokens)
+	if out.Thinking != nil && (out.Temperature != nil || out.TopK != nil) {
+		slog.Warn("temperature and top k are not supported with thinking for anthropic models")
+		out.Temperature = nil
+		out.TopK = nil
 	}
 
 	if in.Text != nil {
@@ -63,6 +61,29 @@ func NativeRequestToRequest(in *responses.Request) *Request {
 	return out
 }
 
+// minThinkingBudget is the smallest thinking budget Anthropic accepts
+const minThinkingBudget = 1024
+
+// NativeReasoningParamToThinkingParam maps the reasoning param to a thinking budget: the budget when it is set, the
+// budget of the effort otherwise. The budget is capped below the max tokens, and thinking stays off when the effort
+// is none or when the max tokens leave no room for the smallest budget.
+func NativeReasoningParamToThinkingParam(in *responses.ReasoningParam, maxTokens int) *ThinkingParam {
+	if in == nil || in.NormalizedEffort() == responses.ReasoningEffortNone {
+		return nil
+	}
+
+	budget := max(in.NormalizedBudget(), minThinkingBudget)
+	budget = min(budget, maxTokens-1)
+	if budget < minThinkingBudget {
+		return nil
+	}
+
+	return &ThinkingParam{
+		Type:         utils.Ptr("enabled"),
+		BudgetTokens: utils.Ptr(budget),
+	}
+}
+
 func NativeRoleToRole(in constants.Role) Role {
 	switch in {
 	case constants.RoleUser:
@@ -201,6 +222,12 @@ func NativeMessagesToMessage(in responses.InputUnion) []MessageUnion {
 								},
 							})
 						}
+
+						if nativeContent.OfInputImage != nil {
+							if image := NativeImageToImageContent(nativeContent.OfInputImage); image != nil {
+								contents = append(contents, ContentUnion{OfImage: image})
+							}
+						}
 					}
 				}
 
@@ -230,6 +257,12 @@ func NativeMessagesToMessage(in responses.InputUnion) []MessageUnion {
 							},
 						})
 					}
+
+					if nativeContent.OfInputImage != nil {
+						if image := NativeImageToImageContent(nativeContent.OfInputImage); image != nil {
+							contents = append(contents, ContentUnion{OfImage: image})
+						}
+					}
 				}
 
 				out = append(out, MessageUnion{
@@ -446,6 +479,25 @@ func NativeMessagesToMessage(in responses.InputUnion) []MessageUnion {
 	return out
 }
 
+// NativeImageToImageContent converts the images of data URLs, the images by URL are inlined by the gateway before
+// they get here
+func NativeImageToImageContent(in *responses.InputImageContent) *ImageContent {
+	if in.ImageURL == nil || !strings.HasPrefix(*in.ImageURL, "data:") {
+		slog.Warn("only data URLs of images are supported for anthropic models")
+		return nil
+	}
+
+	mediaType, data, ok := strings.Cut(strings.TrimPrefix(*in.ImageURL, "data:"), ";base64,")
+	if !ok {
+		slog.Warn("unable to decode image data URL for anthropic models")
+		return nil
+	}
+
+	return &ImageContent{
+		Source: ImageSource{Type: "base64", MediaType: mediaType, Data: data},
+	}
+}
+
 func NativeResponseToResponse(in *responses.Response) *Response {
 	contents := Contents{}
 
@@ -621,6 +673,7 @@ func NativeResponseToResponse(in *responses.Response) *Response {
 type NativeResponseChunkToResponseChunkConverter struct {
 	OfResponseCreated *responses.ChunkResponse[constants.ChunkTypeResponseCreated]
 	outputIndex       int
+	blockOpen         bool // A content block was started and not stopped yet
 }
 
 // NativeResponseChunkToResponseChunk converts a single native chunk to zero or more Anthropic chunks.
@@ -704,12 +757,6 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleOutputItemAdded(item
 		}
 	}
 
-	if item.Item.Type == "web_search_call" {
-		return []ResponseChunk{
-			c.buildContentBlockStartServerToolUse("web_search", item.OutputIndex, item.Item.Id),
-		}
-	}
-
 	if item.Item.Type == "code_interpreter_call" {
 		return []ResponseChunk{
 			c.buildContentBlockStartServerToolUse("bash_code_execution", item.OutputIndex, item.Item.Id),
@@ -724,9 +771,10 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleContentPartAdded(par
 	if part.Part.OfOutputText == nil {
 		return nil
 	}
-	return []ResponseChunk{
+	// Each part of a multi-part message is a block of its own
+	return append(c.stopOpenBlock(),
 		c.buildContentBlockStartText(part.OutputIndex, part.Part.OfOutputText.Text),
-	}
+	)
 }
 
 // handleOutputTextDelta emits content_block_delta with text_delta
@@ -751,9 +799,9 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleFunctionCallArgument
 
 // handleReasoningSummaryPartAdded emits content_block_start for thinking
 func (c *NativeResponseChunkToResponseChunkConverter) handleReasoningSummaryPartAdded(part *responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartAdded]) []ResponseChunk {
-	return []ResponseChunk{
+	return append(c.stopOpenBlock(),
 		c.buildContentBlockStartThinking(part.OutputIndex),
-	}
+	)
 }
 
 // handleReasoningSummaryTextDelta emits content_block_delta with thinking or signature
@@ -782,8 +830,10 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleCodeInterpreterCallC
 
 // handleOutputItemDone emits content_block_stop
 func (c *NativeResponseChunkToResponseChunkConverter) handleOutputItemDone(item *responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]) []ResponseChunk {
+	// The web search is streamed whole once done: its block holds the query, known once done, and the searches of a
+	// response may be in progress together, when the content blocks are streamed one at a time
 	if item.Item.Type == "web_search_call" {
-		chunks := []ResponseChunk{}
+		chunks := append(c.stopOpenBlock(), c.buildContentBlockStartServerToolUse("web_search", item.OutputIndex, item.Item.Id))
 		if item.Item.Action.OfSearch != nil {
 			inputQuery := struct {
 				Query string `json:"query"`
@@ -797,7 +847,6 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleOutputItemDone(item
 		}
 
 		chunks = append(chunks, c.buildContentBlockStop(item.OutputIndex))
-		item.OutputIndex++
 		var resultContents []WebSearchResultContentParam
 		for _, source := range item.Item.Action.OfSearch.Sources {
 			if raw, exists := source.ExtraParams["Anthropic"]; exists {
@@ -854,9 +903,15 @@ func (c *NativeResponseChunkToResponseChunkConverter) handleOutputItemDone(item
 		return chunks
 	}
 
-	return []ResponseChunk{
-		c.buildContentBlockStop(item.OutputIndex),
+	return c.stopOpenBlock()
+}
+
+// stopOpenBlock stops the content block still open, if any
+func (c *NativeResponseChunkToResponseChunkConverter) stopOpenBlock() []ResponseChunk {
+	if !c.blockOpen {
+		return nil
 	}
+	return []ResponseChunk{c.buildContentBlockStop(c.outputIndex)}
 }
 
 // handleResponseCompleted emits message_delta and message_stop
@@ -893,6 +948,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildMessageStart(id, mode
 }
 
 func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartText(index int, text string) ResponseChunk {
+	c.blockOpen = true
 	return ResponseChunk{
 		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
 			Type:         ChunkTypeContentBlockStart("content_block_start"),
@@ -903,6 +959,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartText
 }
 
 func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartToolUse(index int, callID, name string, args any) ResponseChunk {
+	c.blockOpen = true
 	return ResponseChunk{
 		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
 			Type:  ChunkTypeContentBlockStart("content_block_start"),
@@ -915,6 +972,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartTool
 }
 
 func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartThinking(index int) ResponseChunk {
+	c.blockOpen = true
 	return ResponseChunk{
 		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
 			Type:  ChunkTypeContentBlockStart("content_block_start"),
@@ -927,6 +985,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartThin
 }
 
 func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartServerToolUse(name string, index int, id string) ResponseChunk {
+	c.blockOpen = true
 	return ResponseChunk{
 		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
 			Type:  ChunkTypeContentBlockStart("content_block_start"),
@@ -946,6 +1005,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartServ
 }
 
 func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStartBashCodeExecutionToolResult(index int, id string, output string) ResponseChunk {
+	c.blockOpen = true
 	return ResponseChunk{
 		OfContentBlockStart: &ChunkContentBlock[ChunkTypeContentBlockStart]{
 			Type:  ChunkTypeContentBlockStart("content_block_start"),
@@ -1040,6 +1100,7 @@ func (c *NativeResponseChunkToResponseChunkConverter) buildContentBlockStop(inde
 	}
 
 	c.outputIndex++
+	c.blockOpen = false
 
 	return out
 }
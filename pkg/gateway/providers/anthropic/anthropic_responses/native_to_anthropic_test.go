@@ -3,6 +3,7 @@ package anthropic_responses
 import (
 	"testing"
 
+	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/llm/constants"
 	"github.com/curaious/uno/pkg/llm/responses"
@@ -217,6 +218,38 @@ func createNativeFunctionCallArgumentsDone(itemId string, outputIndex int, args
 	}
 }
 
+// Helper to create a native output_item.added for reasoning
+func createNativeOutputItemAddedReasoning(itemId string, outputIndex int) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded("response.output_item.added"),
+			SequenceNumber: 2,
+			OutputIndex:    outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:   "reasoning",
+				Id:     itemId,
+				Status: "in_progress",
+			},
+		},
+	}
+}
+
+// Helper to create a native output_item.done for reasoning
+func createNativeOutputItemDoneReasoning(itemId string, outputIndex int) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone("response.output_item.done"),
+			SequenceNumber: 6,
+			OutputIndex:    outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:   "reasoning",
+				Id:     itemId,
+				Status: "completed",
+			},
+		},
+	}
+}
+
 // Helper to create a native reasoning_summary_part.added
 func createNativeReasoningSummaryPartAdded(itemId string, outputIndex, summaryIndex int) *responses.ResponseChunk {
 	return &responses.ResponseChunk{
@@ -421,6 +454,8 @@ func TestNativeToAnthropic_OutputItemDone(t *testing.T) {
 
 	// Setup
 	converter.NativeResponseChunkToResponseChunk(createNativeResponseCreated("resp_done", "claude-3"))
+	converter.NativeResponseChunkToResponseChunk(createNativeOutputItemAddedMessage("item_1", 0))
+	converter.NativeResponseChunkToResponseChunk(createNativeContentPartAddedText("item_1", 0, 0))
 
 	// output_item.done should emit content_block_stop
 	outputDone := createNativeOutputItemDoneMessage("item_1", 0, "Hello World")
@@ -656,3 +691,146 @@ func TestNativeToAnthropic_MultipleFunctionCalls(t *testing.T) {
 	require.Len(t, result, 1)
 	assert.NotNil(t, result[0].OfContentBlockStop)
 }
+
+// =============================================================================
+// Test: Reasoning Param → Thinking Budget
+// =============================================================================
+
+func TestNativeToAnthropic_ReasoningThinkingBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		reasoning *responses.ReasoningParam
+		maxTokens int
+		budget    *int
+	}{
+		{name: "no reasoning", maxTokens: 32000},
+		{name: "none effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("none")}, maxTokens: 32000},
+		{name: "minimal effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("minimal")}, maxTokens: 32000, budget: utils.Ptr(1024)},
+		{name: "low effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("low")}, maxTokens: 32000, budget: utils.Ptr(4096)},
+		{name: "medium effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("medium")}, maxTokens: 32000, budget: utils.Ptr(8192)},
+		{name: "high effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high")}, maxTokens: 32000, budget: utils.Ptr(16384)},
+		{name: "no effort defaults to medium", reasoning: &responses.ReasoningParam{}, maxTokens: 32000, budget: utils.Ptr(8192)},
+		{name: "budget wins over effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high"), BudgetTokens: utils.Ptr(3000)}, maxTokens: 32000, budget: utils.Ptr(3000)},
+		{name: "budget raised to the minimum", reasoning: &responses.ReasoningParam{BudgetTokens: utils.Ptr(100)}, maxTokens: 32000, budget: utils.Ptr(1024)},
+		{name: "budget capped below max tokens", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high")}, maxTokens: 4000, budget: utils.Ptr(3999)},
+		{name: "max tokens too low", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high")}, maxTokens: 512},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thinking := NativeReasoningParamToThinkingParam(tt.reasoning, tt.maxTokens)
+			if tt.budget == nil {
+				assert.Nil(t, thinking)
+				return
+			}
+
+			require.NotNil(t, thinking)
+			assert.Equal(t, utils.Ptr("enabled"), thinking.Type)
+			assert.Equal(t, tt.budget, thinking.BudgetTokens)
+		})
+	}
+}
+
+func TestNativeToAnthropic_ThinkingDropsTemperature(t *testing.T) {
+	out := NativeRequestToRequest(&responses.Request{
+		Model: "claude-sonnet-4-5",
+		Parameters: responses.Parameters{
+			MaxOutputTokens: utils.Ptr(32000),
+			Temperature:     utils.Ptr(0.2),
+			Reasoning:       &responses.ReasoningParam{Effort: utils.Ptr("low")},
+		},
+	})
+
+	require.NotNil(t, out.Thinking)
+	assert.Nil(t, out.Temperature)
+}
+
+// =============================================================================
+// Test: Multi-Part Items Become Consecutive Content Blocks
+// =============================================================================
+
+func TestNativeToAnthropic_MultiPartBlockIndices(t *testing.T) {
+	converter := newNativeToAnthropicConverter()
+
+	var chunks []ResponseChunk
+	convert := func(in ...*responses.ResponseChunk) {
+		for _, chunk := range in {
+			chunks = append(chunks, converter.NativeResponseChunkToResponseChunk(chunk)...)
+		}
+	}
+
+	convert(createNativeResponseCreated("resp_parts", "claude-3"))
+
+	// Reasoning with two summary parts
+	convert(
+		createNativeOutputItemAddedReasoning("rs_1", 0),
+		createNativeReasoningSummaryPartAdded("rs_1", 0, 0),
+		createNativeReasoningSummaryTextDelta("rs_1", 0, 0, "First", 4),
+		createNativeReasoningSummaryPartAdded("rs_1", 0, 1),
+		createNativeReasoningSummaryTextDelta("rs_1", 0, 1, "Second", 6),
+		createNativeOutputItemDoneReasoning("rs_1", 0),
+	)
+
+	// Reasoning without summary has no block
+	convert(createNativeOutputItemAddedReasoning("rs_2", 1), createNativeOutputItemDoneReasoning("rs_2", 1))
+
+	// Message with two text parts
+	convert(
+		createNativeOutputItemAddedMessage("msg_1", 2),
+		createNativeContentPartAddedText("msg_1", 2, 0),
+		createNativeOutputTextDelta("msg_1", 2, 0, "Hello", 10),
+		createNativeContentPartDone("msg_1", 2, 0, "Hello"),
+		createNativeContentPartAddedText("msg_1", 2, 1),
+		createNativeOutputTextDelta("msg_1", 2, 1, "World", 13),
+		createNativeContentPartDone("msg_1", 2, 1, "World"),
+		createNativeOutputItemDoneMessage("msg_1", 2, "HelloWorld"),
+	)
+
+	convert(
+		createNativeOutputItemAddedFunctionCall("fn_1", 3, "call_1", "get_weather"),
+		createNativeFunctionCallArgumentsDelta("fn_1", 3, `{"city":"NYC"}`, 17),
+		createNativeOutputItemDoneFunctionCall("fn_1", 3, "call_1", "get_weather", `{"city":"NYC"}`),
+	)
+
+	// Every block is started at the next index, after the previous one is stopped, and its deltas carry its index
+	open, next := -1, 0
+	for _, chunk := range chunks {
+		switch {
+		case chunk.OfContentBlockStart != nil:
+			assert.Equal(t, -1, open, "block %d started before block %d stopped", chunk.OfContentBlockStart.Index, open)
+			assert.Equal(t, next, chunk.OfContentBlockStart.Index)
+			open = chunk.OfContentBlockStart.Index
+			next++
+		case chunk.OfContentBlockDelta != nil:
+			assert.Equal(t, open, chunk.OfContentBlockDelta.Index)
+		case chunk.OfContentBlockStop != nil:
+			assert.Equal(t, open, chunk.OfContentBlockStop.Index)
+			open = -1
+		}
+	}
+	assert.Equal(t, -1, open)
+	assert.Equal(t, 5, next)
+}
+
+// =============================================================================
+// Test: Input Images Become Base64 Image Blocks
+// =============================================================================
+
+func TestNativeToAnthropic_InputImage(t *testing.T) {
+	out := NativeMessagesToMessage(responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+		{OfInputMessage: &responses.InputMessage{Role: constants.RoleUser, Content: responses.InputContent{
+			{OfInputText: &responses.InputTextContent{Text: "What is this?"}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: utils.Ptr("data:image/png;base64,iVBORw0KGgo=")}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: utils.Ptr("https://example.com/cat.png")}},
+		}}},
+	}})
+
+	require.Len(t, out, 1)
+	require.Len(t, out[0].Content, 2)
+	require.NotNil(t, out[0].Content[1].OfImage)
+	assert.Equal(t, ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}, out[0].Content[1].OfImage.Source)
+
+	data, err := sonic.Marshal(&out[0].Content[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}}`, string(data))
+}
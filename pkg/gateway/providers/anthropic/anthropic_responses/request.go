@@ -43,6 +43,7 @@ type ContentUnion struct {
 	OfServerToolUse               *ServerToolUseContent           `json:",omitempty"`
 	OfWebSearchResult             *WebSearchResultContent         `json:",omitempty"`
 	OfBashCodeExecutionToolResult *BashCodeExecutionResultContent `json:",omitempty"`
+	OfImage                       *ImageContent                   `json:",omitempty"`
 }
 
 func (u *ContentUnion) UnmarshalJSON(data []byte) error {
@@ -94,6 +95,12 @@ func (u *ContentUnion) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var imageContent ImageContent
+	if err := sonic.Unmarshal(data, &imageContent); err == nil {
+		u.OfImage = &imageContent
+		return nil
+	}
+
 	return errors.New("invalid input content union")
 }
 
@@ -130,6 +137,10 @@ func (u *ContentUnion) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(*u.OfBashCodeExecutionToolResult)
 	}
 
+	if u.OfImage != nil {
+		return sonic.Marshal(*u.OfImage)
+	}
+
 	return nil, nil
 }
 
@@ -139,6 +150,18 @@ type TextContent struct {
 	Citations []Citation      `json:"citations,omitempty"`
 }
 
+// ImageContent is an image of a user message, sent inline as base64
+type ImageContent struct {
+	Type   ContentTypeImage `json:"type"` // "image"
+	Source ImageSource      `json:"source"`
+}
+
+type ImageSource struct {
+	Type      string `json:"type"` // "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
 type Citation struct {
 	Type           string `json:"type"` // web_search_result_location
 	Url            string `json:"url"`
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -22,6 +23,9 @@ type ClientOptions struct {
 	ApiKey  string
 	Headers map[string]string
 
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
 	transport *http.Client
 }
 
@@ -31,6 +35,9 @@ type Client struct {
 }
 
 func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
 	if opts.transport == nil {
 		opts.transport = http.DefaultClient
 	}
@@ -52,7 +59,7 @@ func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*res
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/messages", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/messages", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +111,7 @@ func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Reque
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/messages", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/messages", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +128,10 @@ func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Reque
 			betaHeaders = append(betaHeaders, "code-execution-2025-08-25")
 		}
 	}
+	// Stream the tool inputs as they're generated, without buffering them until they're valid JSON
+	if slices.ContainsFunc(anthropicRequest.Tools, func(t anthropic_responses.ToolUnion) bool { return t.OfCustomTool != nil }) {
+		betaHeaders = append(betaHeaders, "fine-grained-tool-streaming-2025-05-14")
+	}
 	if betaHeaders != nil && len(betaHeaders) > 0 {
 		req.Header.Set("anthropic-beta", strings.Join(betaHeaders, ","))
 	}
@@ -0,0 +1,345 @@
+package azure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base"
+	"github.com/curaious/uno/pkg/gateway/providers/openai/openai_chat_completion"
+	"github.com/curaious/uno/pkg/gateway/providers/openai/openai_embeddings"
+	"github.com/curaious/uno/pkg/gateway/providers/openai/openai_responses"
+	"github.com/curaious/uno/pkg/llm/chat_completion"
+	"github.com/curaious/uno/pkg/llm/embeddings"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// DefaultAPIVersion is the api-version of the requests without one, the Responses API is only served by the
+// preview versions
+const DefaultAPIVersion = "2025-04-01-preview"
+
+type ClientOptions struct {
+	// Endpoint of the resource, e.g. https://my-resource.openai.azure.com
+	BaseURL string
+
+	// APIVersion is the api-version query parameter of the requests (default DefaultAPIVersion)
+	APIVersion string
+
+	// Deployments are the names of the deployments of the models, by model. The models without deployment are
+	// sent to the deployment of the same name.
+	Deployments map[string]string
+
+	// ApiKey is the key of the resource, sent in the api-key header, or "Bearer <token>" for a Microsoft Entra ID
+	// token, sent in the Authorization header
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client calls the OpenAI models deployed to an Azure OpenAI resource. The requests and the chunks are the ones of
+// the OpenAI API, Azure routes them by deployment.
+type Client struct {
+	*base.BaseProvider
+	opts *ClientOptions
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.APIVersion == "" {
+		opts.APIVersion = DefaultAPIVersion
+	}
+
+	// The endpoint is shown with a trailing slash in the Azure portal
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+
+	return &Client{
+		opts: opts,
+	}
+}
+
+// Deployment returns the name of the deployment serving the model
+func (c *Client) Deployment(model string) string {
+	if deployment, ok := c.opts.Deployments[model]; ok && deployment != "" {
+		return deployment
+	}
+
+	return model
+}
+
+// deploymentURL returns the URL of an operation of the deployment of the model, e.g. chat/completions
+func (c *Client) deploymentURL(model string, operation string) string {
+	return c.opts.BaseURL + "/openai/deployments/" + url.PathEscape(c.Deployment(model)) + "/" + operation + "?api-version=" + url.QueryEscape(c.opts.APIVersion)
+}
+
+// responsesURL returns the URL of the Responses API, the deployment is the model of the request
+func (c *Client) responsesURL() string {
+	return c.opts.BaseURL + "/openai/responses?api-version=" + url.QueryEscape(c.opts.APIVersion)
+}
+
+func (c *Client) newRequest(ctx context.Context, endpoint string, payload []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if strings.HasPrefix(c.opts.ApiKey, "Bearer ") {
+		req.Header.Set("Authorization", c.opts.ApiKey)
+	} else {
+		req.Header.Set("api-key", c.opts.ApiKey)
+	}
+
+	return req, nil
+}
+
+// responseError returns the error of a failed request, Azure returns the errors of the OpenAI API
+func responseError(res *http.Response) error {
+	var errResp map[string]any
+	if err := utils.DecodeJSON(res.Body, &errResp); err != nil {
+		return errors.New(res.Status)
+	}
+
+	if e, ok := errResp["error"].(map[string]any); ok {
+		if message, ok := e["message"].(string); ok {
+			return errors.New(message)
+		}
+	}
+
+	return errors.New("unknown error occurred")
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	openAiRequest := openai_responses.NativeRequestToRequest(inp)
+	openAiRequest.Model = c.Deployment(inp.Model)
+
+	payload, err := sonic.Marshal(openAiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, c.responsesURL(), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	var openAiResponse *openai_responses.Response
+	err = utils.DecodeJSON(res.Body, &openAiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if openAiResponse.Error != nil {
+		return nil, errors.New(openAiResponse.Error.Message)
+	}
+
+	return openAiResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	openAiRequest := openai_responses.NativeRequestToRequest(inp)
+	openAiRequest.Model = c.Deployment(inp.Model)
+
+	payload, err := sonic.Marshal(openAiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, c.responsesURL(), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+		reader := bufio.NewReader(res.Body)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if strings.HasPrefix(line, "data:") {
+				openAiResponseChunk := &openai_responses.ResponseChunk{}
+				err = sonic.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), openAiResponseChunk)
+				if err != nil {
+					slog.WarnContext(ctx, "unable to unmarshal azure openai response chunk", slog.String("data", line), slog.Any("error", err))
+					continue
+				}
+				out <- openAiResponseChunk.ToNativeResponseChunk()
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (c *Client) NewEmbedding(ctx context.Context, inp *embeddings.Request) (*embeddings.Response, error) {
+	openAiRequest := openai_embeddings.NativeRequestToRequest(inp)
+
+	payload, err := sonic.Marshal(openAiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, c.deploymentURL(inp.Model, "embeddings"), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	var openAiResponse *openai_embeddings.Response
+	err = utils.DecodeJSON(res.Body, &openAiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return openAiResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewChatCompletion(ctx context.Context, inp *chat_completion.Request) (*chat_completion.Response, error) {
+	openAiRequest := openai_chat_completion.NativeRequestToRequest(inp)
+
+	payload, err := sonic.Marshal(openAiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, c.deploymentURL(inp.Model, "chat/completions"), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	var openAiResponse *openai_chat_completion.Response
+	err = utils.DecodeJSON(res.Body, &openAiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return openAiResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingChatCompletion(ctx context.Context, inp *chat_completion.Request) (chan *chat_completion.ResponseChunk, error) {
+	openAiRequest := openai_chat_completion.NativeRequestToRequest(inp)
+
+	payload, err := sonic.Marshal(openAiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, c.deploymentURL(inp.Model, "chat/completions"), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	out := make(chan *chat_completion.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+		reader := bufio.NewReader(res.Body)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if line == "data: [DONE]" {
+				return
+			}
+
+			if strings.HasPrefix(line, "data:") {
+				chunk := &openai_chat_completion.ResponseChunk{}
+				err = sonic.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), chunk)
+				if err != nil {
+					slog.WarnContext(ctx, "unable to unmarshal azure openai chat completion chunk", slog.String("data", line), slog.Any("error", err))
+					continue
+				}
+
+				// Azure sends the results of its content filters first, in a chunk without choices nor model
+				if data := chunk.OfChatCompletionChunk; data == nil || (len(data.Choices) == 0 && data.Model == "") {
+					continue
+				}
+				out <- chunk.ToNativeResponseChunk()
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package azure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/chat_completion"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatRequest(t *testing.T) *chat_completion.Request {
+	var req chat_completion.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hello"}]}`), &req))
+	return &req
+}
+
+func TestClient_NewResponses_Deployment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/responses", r.URL.Path)
+		assert.Equal(t, DefaultAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, "resource-key", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+		assert.Equal(t, "prod-gpt-4o", payload["model"])
+
+		_, _ = w.Write([]byte(`{
+			"id": "resp_1",
+			"model": "gpt-4o",
+			"output": [{"type": "message", "id": "msg_1", "role": "assistant", "content": [{"type": "output_text", "text": "Hi"}]}],
+			"usage": {"input_tokens": 5, "output_tokens": 1, "total_tokens": 6}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{
+		BaseURL:     server.URL + "/",
+		ApiKey:      "resource-key",
+		Deployments: map[string]string{"gpt-4o": "prod-gpt-4o"},
+	})
+	out, err := client.NewResponses(context.Background(), &responses.Request{
+		Model: "gpt-4o",
+		Input: responses.InputUnion{OfString: utils.Ptr("Hello")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "resp_1", out.ID)
+	assert.Equal(t, 6, out.Usage.TotalTokens)
+}
+
+func TestClient_NewChatCompletion_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/gpt-4o/chat/completions", r.URL.Path)
+		assert.Equal(t, "2024-10-21", r.URL.Query().Get("api-version"))
+		assert.Equal(t, "Bearer entra-token", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("api-key"))
+
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": {"code": "DeploymentNotFound", "message": "The API deployment for this resource does not exist."}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, APIVersion: "2024-10-21", ApiKey: "Bearer entra-token"})
+	_, err := client.NewChatCompletion(context.Background(), chatRequest(t))
+	assert.EqualError(t, err, "The API deployment for this resource does not exist.")
+}
+
+func TestClient_NewStreamingChatCompletion_SkipsContentFilterResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/chat/chat/completions", r.URL.Path)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"choices": [], "created": 0, "id": "", "model": "", "object": "", "prompt_filter_results": [{"prompt_index": 0, "content_filter_results": {}}]}
+
+data: {"choices": [{"index": 0, "delta": {"role": "assistant", "content": "Hi"}}], "created": 1, "id": "chatcmpl-1", "model": "gpt-4o-2024-11-20", "object": "chat.completion.chunk"}
+
+data: {"choices": [{"index": 0, "delta": {}, "finish_reason": "stop"}], "created": 1, "id": "chatcmpl-1", "model": "gpt-4o-2024-11-20", "object": "chat.completion.chunk"}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "resource-key", Deployments: map[string]string{"gpt-4o": "chat"}})
+	stream, err := client.NewStreamingChatCompletion(context.Background(), chatRequest(t))
+	require.NoError(t, err)
+
+	var chunks []*chat_completion.ResponseChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "Hi", chunks[0].OfChatCompletionChunk.Choices[0].Delta.Content)
+	assert.Equal(t, "stop", chunks[1].OfChatCompletionChunk.Choices[0].FinishReason)
+}
+
+func TestClient_Deployment(t *testing.T) {
+	client := NewClient(&ClientOptions{Deployments: map[string]string{"gpt-4o": "prod-gpt-4o", "o3": ""}})
+
+	assert.Equal(t, "prod-gpt-4o", client.Deployment("gpt-4o"))
+	assert.Equal(t, "o3", client.Deployment("o3"))
+	assert.Equal(t, "gpt-4.1", client.Deployment("gpt-4.1"))
+}
@@ -0,0 +1,697 @@
+package chat_responses
+
+import (
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+func (in *Response) ToNativeResponse() *responses.Response {
+	output := []responses.OutputMessageUnion{}
+	metadata := map[string]any{}
+
+	if len(in.Choices) > 0 {
+		choice := in.Choices[0]
+		output = MessageToNativeOutput(choice.Message)
+		metadata["finish_reason"] = choice.FinishReason
+	}
+
+	usage := Usage{}
+	if in.Usage != nil {
+		usage = *in.Usage
+	}
+
+	return &responses.Response{
+		ID:       in.ID,
+		Model:    in.Model,
+		Output:   output,
+		Usage:    utils.Ptr(usage.ToNative()),
+		Metadata: metadata,
+	}
+}
+
+// MessageToNativeOutput converts the message of a choice, the reasoning to reasoning, the text to a message and the
+// tool calls to function calls. The thinking parts, and the thinking between think tags leading the content, are
+// reasoning too.
+func MessageToNativeOutput(in Message) []responses.OutputMessageUnion {
+	var output []responses.OutputMessageUnion
+
+	reasoning, text := reasoningText(in.Reasoning, in.ReasoningContent), ""
+	for _, part := range in.Content.OfParts {
+		if part.Type == PartTypeThinking {
+			reasoning += part.ThinkingText()
+		}
+	}
+
+	parser := thinkTagParser{}
+	for _, seg := range append(parser.Feed(in.Content.Text()), parser.Flush()...) {
+		if seg.thinking {
+			reasoning += seg.text
+		} else {
+			text += seg.text
+		}
+	}
+
+	if reasoning != "" {
+		output = append(output, responses.OutputMessageUnion{
+			OfReasoning: &responses.ReasoningMessage{
+				ID:      responses.NewOutputItemReasoningID(),
+				Summary: []responses.SummaryTextContent{{Text: reasoning}},
+			},
+		})
+	}
+
+	if text != "" {
+		output = append(output, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:   responses.NewOutputItemMessageID(),
+				Role: constants.RoleAssistant,
+				Content: responses.OutputContent{
+					{OfOutputText: &responses.OutputTextContent{Text: text}},
+				},
+			},
+		})
+	}
+
+	for _, toolCall := range in.ToolCalls {
+		args := toolCall.Function.Arguments
+		if args == "" {
+			args = "{}"
+		}
+
+		output = append(output, responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        responses.NewOutputItemFunctionCallID(),
+				CallID:    toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+
+	return output
+}
+
+// reasoningText returns the reasoning of a message or a delta, in the field of the provider
+func reasoningText(reasoning, reasoningContent *string) string {
+	if reasoning != nil && *reasoning != "" {
+		return *reasoning
+	}
+	if reasoningContent != nil {
+		return *reasoningContent
+	}
+	return ""
+}
+
+func (in Usage) ToNative() responses.Usage {
+	usage := responses.Usage{
+		InputTokens:  in.PromptTokens,
+		OutputTokens: in.CompletionTokens,
+		TotalTokens:  in.TotalTokens,
+	}
+	if in.PromptTokensDetails != nil {
+		usage.InputTokensDetails.CachedTokens = in.PromptTokensDetails.CachedTokens
+	}
+	if in.CompletionTokensDetails != nil {
+		usage.OutputTokensDetails.ReasoningTokens = in.CompletionTokensDetails.ReasoningTokens
+	}
+
+	return usage
+}
+
+// =============================================================================
+// ResponseChunk to Native Conversion
+// =============================================================================
+
+type blockKind int
+
+const (
+	blockKindText blockKind = iota
+	blockKindToolCall
+	blockKindReasoning
+)
+
+// ResponseChunkToNativeResponseChunkConverter converts the chunks of a chat completion stream to native chunks. The
+// text, the reasoning and each tool call are output items, started by their first delta. The reasoning is either the
+// reasoning of the deltas, their thinking parts, or the thinking between think tags leading the content.
+type ResponseChunkToNativeResponseChunkConverter struct {
+	// Annotate returns the annotations of the text of a message once it is complete, e.g. its citations, optional
+	Annotate func(text string) []responses.Annotation
+
+	sequenceNumber int
+	outputIndex    int
+	started        bool
+	completed      bool
+
+	id    string
+	model string
+	usage Usage
+
+	thinkTags thinkTagParser
+
+	// Current output item
+	block            *streamBlock
+	completedOutputs []responses.OutputMessageUnion
+}
+
+type streamBlock struct {
+	kind      blockKind
+	outputID  string
+	toolIndex int
+	callID    string
+	name      string
+	text      string // Accumulated text, thinking or arguments
+}
+
+// nextSeqNum returns the next sequence number and increments the counter.
+func (c *ResponseChunkToNativeResponseChunkConverter) nextSeqNum() int {
+	n := c.sequenceNumber
+	c.sequenceNumber++
+	return n
+}
+
+// ResponseChunkToNativeResponseChunk converts a single chunk to zero or more native chunks.
+func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeResponseChunk(in *ResponseChunk) []*responses.ResponseChunk {
+	if in == nil || c.completed {
+		return nil
+	}
+
+	if c.id == "" {
+		c.id = in.ID
+	}
+	if c.model == "" {
+		c.model = in.Model
+	}
+
+	result := c.start()
+
+	for _, choice := range in.Choices {
+		delta := choice.Delta
+
+		if reasoning := reasoningText(delta.Reasoning, delta.ReasoningContent); reasoning != "" {
+			result = append(result, c.handleThinking(reasoning)...)
+		}
+
+		if delta.Content.OfString != nil && *delta.Content.OfString != "" {
+			result = append(result, c.handleSegments(c.thinkTags.Feed(*delta.Content.OfString))...)
+		}
+
+		for _, part := range delta.Content.OfParts {
+			switch part.Type {
+			case PartTypeText:
+				if part.Text != "" {
+					result = append(result, c.handleSegments(c.thinkTags.Feed(part.Text))...)
+				}
+			case PartTypeThinking:
+				if text := part.ThinkingText(); text != "" {
+					result = append(result, c.handleThinking(text)...)
+				}
+			}
+		}
+
+		for i, toolCall := range delta.ToolCalls {
+			index := i
+			if toolCall.Index != nil {
+				index = *toolCall.Index
+			}
+			result = append(result, c.handleToolCall(index, toolCall)...)
+		}
+	}
+
+	if in.Usage != nil {
+		c.usage = *in.Usage
+	}
+
+	return result
+}
+
+// Close completes the stream, it returns nothing once the stream has completed
+func (c *ResponseChunkToNativeResponseChunkConverter) Close() []*responses.ResponseChunk {
+	return c.complete(nil)
+}
+
+// Fail completes the stream with an error
+func (c *ResponseChunkToNativeResponseChunkConverter) Fail(message string) []*responses.ResponseChunk {
+	return c.complete(map[string]any{"message": message})
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) start() []*responses.ResponseChunk {
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	return []*responses.ResponseChunk{
+		c.buildResponseCreated(),
+		c.buildResponseInProgress(),
+	}
+}
+
+// handleSegments converts the thinking and the text of the content
+func (c *ResponseChunkToNativeResponseChunkConverter) handleSegments(segments []segment) []*responses.ResponseChunk {
+	var result []*responses.ResponseChunk
+	for _, seg := range segments {
+		if seg.thinking {
+			result = append(result, c.handleThinking(seg.text)...)
+		} else {
+			result = append(result, c.handleText(seg.text)...)
+		}
+	}
+	return result
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) handleText(delta string) []*responses.ResponseChunk {
+	var result []*responses.ResponseChunk
+
+	if c.block == nil || c.block.kind != blockKindText {
+		result = append(result, c.completeBlock()...)
+		c.block = &streamBlock{kind: blockKindText, outputID: responses.NewOutputItemMessageID()}
+		result = append(result, c.buildOutputItemAddedMessage(), c.buildContentPartAddedText())
+	}
+
+	c.block.text += delta
+	return append(result, c.buildOutputTextDelta(delta))
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) handleThinking(delta string) []*responses.ResponseChunk {
+	var result []*responses.ResponseChunk
+
+	if c.block == nil || c.block.kind != blockKindReasoning {
+		result = append(result, c.completeBlock()...)
+		c.block = &streamBlock{kind: blockKindReasoning, outputID: responses.NewOutputItemReasoningID()}
+		result = append(result, c.buildOutputItemAddedReasoning(), c.buildReasoningSummaryPartAdded())
+	}
+
+	c.block.text += delta
+	return append(result, c.buildReasoningSummaryTextDelta(delta))
+}
+
+// handleToolCall starts a function call for a new tool index, the next deltas of the index are its arguments
+func (c *ResponseChunkToNativeResponseChunkConverter) handleToolCall(index int, toolCall ToolCall) []*responses.ResponseChunk {
+	var result []*responses.ResponseChunk
+
+	if c.block == nil || c.block.kind != blockKindToolCall || c.block.toolIndex != index {
+		result = append(result, c.completeBlock()...)
+		c.block = &streamBlock{
+			kind:      blockKindToolCall,
+			outputID:  responses.NewOutputItemFunctionCallID(),
+			toolIndex: index,
+			callID:    toolCall.ID,
+			name:      toolCall.Function.Name,
+		}
+		result = append(result, c.buildOutputItemAddedFunctionCall())
+	}
+
+	if toolCall.Function.Arguments == "" {
+		return result
+	}
+
+	c.block.text += toolCall.Function.Arguments
+	return append(result, c.buildFunctionCallArgumentsDelta(toolCall.Function.Arguments))
+}
+
+// completeBlock emits the done chunks of the current output item and stores it
+func (c *ResponseChunkToNativeResponseChunkConverter) completeBlock() []*responses.ResponseChunk {
+	block := c.block
+	if block == nil {
+		return nil
+	}
+
+	var result []*responses.ResponseChunk
+
+	switch block.kind {
+	case blockKindText:
+		text := &responses.OutputTextContent{Text: block.text}
+		if c.Annotate != nil {
+			text.Annotations = c.Annotate(block.text)
+		}
+		for i, annotation := range text.Annotations {
+			result = append(result, c.buildOutputTextAnnotationAdded(annotation, i))
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:      block.outputID,
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: text}},
+			},
+		})
+
+		result = append(result,
+			c.buildOutputTextDone(block.text),
+			c.buildContentPartDoneText(text),
+			c.buildOutputItemDoneMessage(text),
+		)
+
+	case blockKindToolCall:
+		if block.text == "" {
+			block.text = "{}"
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        block.outputID,
+				CallID:    block.callID,
+				Name:      block.name,
+				Arguments: block.text,
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildFunctionCallArgumentsDone(block.text),
+			c.buildOutputItemDoneFunctionCall(),
+		}
+
+	case blockKindReasoning:
+		reasoning := &responses.ReasoningMessage{
+			ID:      block.outputID,
+			Summary: []responses.SummaryTextContent{{Text: block.text}},
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{OfReasoning: reasoning})
+
+		result = []*responses.ResponseChunk{
+			c.buildReasoningSummaryTextDone(block.text),
+			c.buildReasoningSummaryPartDone(block.text),
+			c.buildOutputItemDoneReasoning(reasoning),
+		}
+	}
+
+	c.block = nil
+	c.outputIndex++
+
+	return result
+}
+
+// complete emits response.completed, or a failed response with an error
+func (c *ResponseChunkToNativeResponseChunkConverter) complete(err map[string]any) []*responses.ResponseChunk {
+	if c.completed {
+		return nil
+	}
+
+	result := c.start()
+	result = append(result, c.handleSegments(c.thinkTags.Flush())...)
+	result = append(result, c.completeBlock()...)
+	c.completed = true
+
+	return append(result, c.buildResponseCompleted(err))
+}
+
+// =============================================================================
+// Chunk Builders
+// =============================================================================
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCreated() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseCreated: &responses.ChunkResponse[constants.ChunkTypeResponseCreated]{
+			Type:           constants.ChunkTypeResponseCreated(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.id,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+				Request:   responses.Request{Model: c.model},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseInProgress() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseInProgress: &responses.ChunkResponse[constants.ChunkTypeResponseInProgress]{
+			Type:           constants.ChunkTypeResponseInProgress(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.id,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedMessage() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "in_progress",
+				CallID:    utils.Ptr(c.block.callID),
+				Name:      utils.Ptr(c.block.name),
+				Arguments: utils.Ptr(""),
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedReasoning() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "reasoning",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Summary: []responses.SummaryTextContent{},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildContentPartAddedText() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartAdded: &responses.ChunkContentPart[constants.ChunkTypeContentPartAdded]{
+			Type:           constants.ChunkTypeContentPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Text: ""}},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryPartAdded() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartAdded: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartAdded]{
+			Type:           constants.ChunkTypeReasoningSummaryPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: ""},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDelta: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDelta]{
+			Type:           constants.ChunkTypeOutputTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildFunctionCallArgumentsDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDelta: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDelta]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDelta: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDelta]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDone: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDone]{
+			Type:           constants.ChunkTypeOutputTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(text),
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextAnnotationAdded(annotation responses.Annotation, index int) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextAnnotationAdded: &responses.ChunkOutputText[constants.ChunkTypeOutputTextAnnotationAdded]{
+			Type:            constants.ChunkTypeOutputTextAnnotationAdded(""),
+			SequenceNumber:  c.nextSeqNum(),
+			ItemId:          c.block.outputID,
+			OutputIndex:     c.outputIndex,
+			Annotation:      annotation,
+			AnnotationIndex: index,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildContentPartDoneText(text *responses.OutputTextContent) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartDone: &responses.ChunkContentPart[constants.ChunkTypeContentPartDone]{
+			Type:           constants.ChunkTypeContentPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: text},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneMessage(text *responses.OutputTextContent) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "completed",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: text}},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildFunctionCallArgumentsDone(args string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDone: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDone]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Arguments:      args,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "completed",
+				CallID:    utils.Ptr(c.block.callID),
+				Name:      utils.Ptr(c.block.name),
+				Arguments: utils.Ptr(c.block.text),
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryTextDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDone: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDone]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(text),
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryPartDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartDone: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartDone]{
+			Type:           constants.ChunkTypeReasoningSummaryPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: text},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneReasoning(reasoning *responses.ReasoningMessage) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "reasoning",
+				Id:      c.block.outputID,
+				Status:  "completed",
+				Summary: reasoning.Summary,
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCompleted(err map[string]any) *responses.ResponseChunk {
+	data := responses.ChunkResponseData{
+		Id:        c.id,
+		Object:    "response",
+		CreatedAt: int(time.Now().Unix()),
+		Status:    "completed",
+		Output:    c.completedOutputs,
+		Usage:     c.usage.ToNative(),
+		Request:   responses.Request{Model: c.model},
+	}
+	if data.Output == nil {
+		data.Output = []responses.OutputMessageUnion{}
+	}
+	if err != nil {
+		data.Status = "failed"
+		data.Error = err
+	}
+
+	return &responses.ResponseChunk{
+		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
+			Type:           constants.ChunkTypeResponseCompleted(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response:       data,
+		},
+	}
+}
@@ -0,0 +1,262 @@
+package chat_responses
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// output is the output of a response or a stream, without the IDs
+type output struct {
+	reasoning string
+	text      string
+	calls     []string // Call ID, name and arguments of the function calls
+}
+
+func outputOf(in []responses.OutputMessageUnion) output {
+	var out output
+	for _, item := range in {
+		switch {
+		case item.OfReasoning != nil:
+			out.reasoning += item.OfReasoning.Summary[0].Text
+		case item.OfOutputMessage != nil:
+			out.text += item.OfOutputMessage.Content[0].OfOutputText.Text
+		case item.OfFunctionCall != nil:
+			out.calls = append(out.calls, item.OfFunctionCall.CallID+" "+item.OfFunctionCall.Name+" "+item.OfFunctionCall.Arguments)
+		}
+	}
+	return out
+}
+
+func TestResponse_ToNativeResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    output
+	}{
+		{
+			name:    "text",
+			message: `{"role": "assistant", "content": "Sunny in Paris."}`,
+			want:    output{text: "Sunny in Paris."},
+		},
+		{
+			name:    "think tags",
+			message: `{"role": "assistant", "content": "<think>It is sunny.</think>\n\nSunny in Paris."}`,
+			want:    output{reasoning: "It is sunny.", text: "Sunny in Paris."},
+		},
+		{
+			name:    "reasoning",
+			message: `{"role": "assistant", "content": "Sunny.", "reasoning": "Looking at the result."}`,
+			want:    output{reasoning: "Looking at the result.", text: "Sunny."},
+		},
+		{
+			name:    "reasoning content",
+			message: `{"role": "assistant", "content": "{\"city\": \"Paris\"}", "reasoning_content": "The user lives in Paris."}`,
+			want:    output{reasoning: "The user lives in Paris.", text: `{"city": "Paris"}`},
+		},
+		{
+			name:    "thinking parts",
+			message: `{"role": "assistant", "content": [{"type": "thinking", "thinking": [{"type": "text", "text": "Paris."}]}, {"type": "text", "text": "Sunny."}]}`,
+			want:    output{reasoning: "Paris.", text: "Sunny."},
+		},
+		{
+			name:    "tool calls",
+			message: `{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Lyon\"}"}}, {"id": "call_2", "type": "function", "function": {"name": "get_time", "arguments": ""}}]}`,
+			want:    output{calls: []string{`call_1 get_weather {"city":"Lyon"}`, "call_2 get_time {}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in Response
+			require.NoError(t, sonic.Unmarshal([]byte(`{
+				"id": "cmpl_1",
+				"model": "qwen3",
+				"choices": [{"index": 0, "message": `+tt.message+`, "finish_reason": "stop"}],
+				"usage": {
+					"prompt_tokens": 40, "completion_tokens": 25, "total_tokens": 65,
+					"prompt_tokens_details": {"cached_tokens": 32},
+					"completion_tokens_details": {"reasoning_tokens": 10}
+				}
+			}`), &in))
+
+			out := in.ToNativeResponse()
+			assert.Equal(t, "cmpl_1", out.ID)
+			assert.Equal(t, "stop", out.Metadata["finish_reason"])
+			assert.Equal(t, 65, out.Usage.TotalTokens)
+			assert.Equal(t, 32, out.Usage.InputTokensDetails.CachedTokens)
+			assert.Equal(t, 10, out.Usage.OutputTokensDetails.ReasoningTokens)
+			assert.Equal(t, tt.want, outputOf(out.Output))
+		})
+	}
+}
+
+func TestResponseChunkToNativeResponseChunkConverter(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []string
+		want   output
+	}{
+		{
+			name:   "text",
+			deltas: []string{`{"role": "assistant", "content": "Sunny "}`, `{"content": "in Paris."}`},
+			want:   output{text: "Sunny in Paris."},
+		},
+		{
+			name: "think tags split across the deltas",
+			deltas: []string{
+				`{"role": "assistant", "content": "<thi"}`,
+				`{"content": "nk>The user wants "}`,
+				`{"content": "the weather.</th"}`,
+				`{"content": "ink>\n\nChecking"}`,
+			},
+			want: output{reasoning: "The user wants the weather.", text: "Checking"},
+		},
+		{
+			name:   "reasoning",
+			deltas: []string{`{"role": "assistant", "reasoning": "Think."}`, `{"content": "It is sunny."}`},
+			want:   output{reasoning: "Think.", text: "It is sunny."},
+		},
+		{
+			name:   "reasoning content",
+			deltas: []string{`{"role": "assistant", "reasoning_content": "Paris is a city."}`, `{"content": "{\"city\": "}`, `{"content": "\"Paris\"}"}`},
+			want:   output{reasoning: "Paris is a city.", text: `{"city": "Paris"}`},
+		},
+		{
+			name: "thinking parts",
+			deltas: []string{
+				`{"role": "assistant", "content": [{"type": "thinking", "thinking": [{"type": "text", "text": "The user wants "}]}]}`,
+				`{"content": [{"type": "thinking", "thinking": [{"type": "text", "text": "the weather."}]}]}`,
+				`{"content": "Checking"}`,
+			},
+			want: output{reasoning: "The user wants the weather.", text: "Checking"},
+		},
+		{
+			name: "tool calls",
+			deltas: []string{
+				`{"role": "assistant", "content": "Checking"}`,
+				`{"tool_calls": [{"index": 0, "id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": ""}}]}`,
+				`{"tool_calls": [{"index": 0, "function": {"arguments": "{\"city\":\"Paris\"}"}}]}`,
+				`{"tool_calls": [{"index": 1, "id": "call_2", "type": "function", "function": {"name": "get_time", "arguments": ""}}]}`,
+			},
+			want: output{text: "Checking", calls: []string{`call_1 get_weather {"city":"Paris"}`, "call_2 get_time {}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter := &ResponseChunkToNativeResponseChunkConverter{}
+
+			var chunks []*responses.ResponseChunk
+			for _, delta := range tt.deltas {
+				var in ResponseChunk
+				require.NoError(t, sonic.Unmarshal([]byte(`{"id": "cmpl_2", "model": "qwen3", "choices": [{"index": 0, "delta": `+delta+`}]}`), &in))
+				chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(&in)...)
+			}
+
+			var usage ResponseChunk
+			require.NoError(t, sonic.Unmarshal([]byte(`{"id": "cmpl_2", "choices": [], "usage": {"prompt_tokens": 20, "completion_tokens": 12, "total_tokens": 32}}`), &usage))
+			chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(&usage)...)
+			chunks = append(chunks, converter.Close()...)
+			assert.Empty(t, converter.Close())
+
+			require.NotEmpty(t, chunks)
+			assert.NotNil(t, chunks[0].OfResponseCreated)
+			assert.NotNil(t, chunks[1].OfResponseInProgress)
+
+			// The deltas add up to the output of the completed response
+			var deltas output
+			args := map[string]string{}
+			for _, chunk := range chunks {
+				switch {
+				case chunk.OfReasoningSummaryTextDelta != nil:
+					deltas.reasoning += chunk.OfReasoningSummaryTextDelta.Delta
+				case chunk.OfOutputTextDelta != nil:
+					deltas.text += chunk.OfOutputTextDelta.Delta
+				case chunk.OfFunctionCallArgumentsDelta != nil:
+					args[chunk.OfFunctionCallArgumentsDelta.ItemId] += chunk.OfFunctionCallArgumentsDelta.Delta
+				}
+			}
+			assert.Equal(t, tt.want.reasoning, deltas.reasoning)
+			assert.Equal(t, tt.want.text, deltas.text)
+			for _, call := range tt.want.calls {
+				if !strings.HasSuffix(call, " {}") {
+					assert.Contains(t, args, chunkItemID(chunks, call), call)
+				}
+			}
+
+			completed := chunks[len(chunks)-1].OfResponseCompleted
+			require.NotNil(t, completed)
+			assert.Equal(t, "completed", completed.Response.Status)
+			assert.Equal(t, "qwen3", completed.Response.Request.Model)
+			assert.Equal(t, 32, completed.Response.Usage.TotalTokens)
+			assert.Equal(t, tt.want, outputOf(completed.Response.Output))
+		})
+	}
+}
+
+// chunkItemID returns the ID of the output item of a function call, by its call ID
+func chunkItemID(chunks []*responses.ResponseChunk, call string) string {
+	for _, chunk := range chunks {
+		if chunk.OfOutputItemAdded != nil && chunk.OfOutputItemAdded.Item.CallID != nil && strings.HasPrefix(call, *chunk.OfOutputItemAdded.Item.CallID+" ") {
+			return chunk.OfOutputItemAdded.Item.Id
+		}
+	}
+	return ""
+}
+
+func TestResponseChunkToNativeResponseChunkConverter_Annotate(t *testing.T) {
+	converter := &ResponseChunkToNativeResponseChunkConverter{
+		Annotate: func(text string) []responses.Annotation {
+			return []responses.Annotation{{Type: "url_citation", URL: "https://example.com", StartIndex: strings.Index(text, "[1]")}}
+		},
+	}
+
+	var chunks []*responses.ResponseChunk
+	for _, delta := range []string{"It is sunny[", "1]."} {
+		chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(&ResponseChunk{
+			ID:      "cmpl_3",
+			Choices: []ChunkChoice{{Delta: Delta{Content: ContentUnion{OfString: &delta}}}},
+		})...)
+	}
+	chunks = append(chunks, converter.Close()...)
+
+	// The annotations of the complete text are added before the text is done
+	var annotations []responses.Annotation
+	for i, chunk := range chunks {
+		if chunk.OfOutputTextAnnotationAdded != nil {
+			annotations = append(annotations, chunk.OfOutputTextAnnotationAdded.Annotation)
+			assert.NotNil(t, chunks[i+1].OfOutputTextDone)
+		}
+	}
+	require.Len(t, annotations, 1)
+	assert.Equal(t, 11, annotations[0].StartIndex)
+
+	completed := chunks[len(chunks)-1].OfResponseCompleted
+	require.NotNil(t, completed)
+	assert.Equal(t, annotations, completed.Response.Output[0].OfOutputMessage.Content[0].OfOutputText.Annotations)
+}
+
+func TestResponseChunkToNativeResponseChunkConverter_Fail(t *testing.T) {
+	converter := &ResponseChunkToNativeResponseChunkConverter{}
+
+	text := "It is"
+	chunks := converter.ResponseChunkToNativeResponseChunk(&ResponseChunk{
+		ID:      "cmpl_4",
+		Choices: []ChunkChoice{{Delta: Delta{Content: ContentUnion{OfString: &text}}}},
+	})
+	chunks = append(chunks, converter.Fail("Provider disconnected")...)
+
+	// The stream is over once failed
+	assert.Empty(t, converter.Close())
+
+	completed := chunks[len(chunks)-1].OfResponseCompleted
+	require.NotNil(t, completed)
+	assert.Equal(t, "failed", completed.Response.Status)
+	assert.Equal(t, map[string]any{"message": "Provider disconnected"}, completed.Response.Error)
+	assert.Equal(t, output{text: "It is"}, outputOf(completed.Response.Output))
+}
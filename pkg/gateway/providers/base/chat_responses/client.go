@@ -0,0 +1,143 @@
+package chat_responses
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Client sends the chat completion requests of a provider
+type Client struct {
+	BaseURL    string
+	ApiKey     string
+	Headers    map[string]string
+	HTTPClient *http.Client
+}
+
+// Post sends the chat completion request, the failed requests are errors, see ResponseError. The caller closes the
+// body of the response.
+func (c *Client) Post(ctx context.Context, payload []byte, stream bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.ApiKey)
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, ResponseError(res)
+	}
+
+	return res, nil
+}
+
+// StreamConverter converts the chunks C of a chat completion stream to native chunks, see
+// ResponseChunkToNativeResponseChunkConverter
+type StreamConverter[C any] interface {
+	ResponseChunkToNativeResponseChunk(in *C) []*responses.ResponseChunk
+	Close() []*responses.ResponseChunk
+	Fail(message string) []*responses.ResponseChunk
+}
+
+// Stream converts the server-sent events of the response of a streamed chat completion to native chunks. When the
+// context is done the request is aborted, and the stream fails with the error of the context.
+func Stream[C any](ctx context.Context, res *http.Response, converter StreamConverter[C]) chan *responses.ResponseChunk {
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+
+		reader := bufio.NewReader(res.Body)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+
+			chunk := new(C)
+			if err = sonic.Unmarshal([]byte(data), chunk); err != nil {
+				slog.WarnContext(ctx, "unable to unmarshal chat completion chunk", slog.String("data", data), slog.Any("error", err))
+				continue
+			}
+
+			for _, nativeChunk := range converter.ResponseChunkToNativeResponseChunk(chunk) {
+				out <- nativeChunk
+			}
+		}
+
+		if ctx.Err() != nil {
+			for _, nativeChunk := range converter.Fail(ctx.Err().Error()) {
+				out <- nativeChunk
+			}
+			return
+		}
+
+		for _, nativeChunk := range converter.Close() {
+			out <- nativeChunk
+		}
+	}()
+
+	return out
+}
+
+// ResponseError returns the error of a failed request, see ErrorResponse
+func ResponseError(res *http.Response) error {
+	var errResp ErrorResponse
+	if err := utils.DecodeJSON(res.Body, &errResp); err != nil {
+		return errors.New(res.Status)
+	}
+
+	if errResp.Error != nil && errResp.Error.Message != "" {
+		return errors.New(errResp.Error.Message)
+	}
+
+	switch message := errResp.Message.(type) {
+	case string:
+		if message != "" {
+			return errors.New(message)
+		}
+	case nil:
+	default:
+		return fmt.Errorf("%v", message)
+	}
+
+	if errResp.Detail != nil {
+		return fmt.Errorf("%v", errResp.Detail)
+	}
+
+	return errors.New(res.Status)
+}
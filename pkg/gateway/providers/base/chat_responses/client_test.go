@@ -0,0 +1,165 @@
+package chat_responses
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Post(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer provider-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		assert.Equal(t, "uno", r.Header.Get("X-Title"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"model": "qwen3"}`, string(body))
+
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, ApiKey: "provider-key", Headers: map[string]string{"X-Title": "uno"}, HTTPClient: http.DefaultClient}
+	res, err := client.Post(context.Background(), []byte(`{"model": "qwen3"}`), true)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`: keep-alive
+
+data: {"id":"cmpl_1","model":"qwen3","choices":[{"index":0,"delta":{"role":"assistant","content":"Sunny"},"finish_reason":null}]}
+
+data: not json
+
+data: {"id":"cmpl_1","model":"qwen3","choices":[{"index":0,"delta":{"content":" in Paris."},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, HTTPClient: http.DefaultClient}
+	res, err := client.Post(context.Background(), []byte(`{}`), true)
+	require.NoError(t, err)
+
+	var text string
+	var last *responses.ResponseChunk
+	for chunk := range Stream[ResponseChunk](context.Background(), res, &ResponseChunkToNativeResponseChunkConverter{}) {
+		if chunk.OfOutputTextDelta != nil {
+			text += chunk.OfOutputTextDelta.Delta
+		}
+		last = chunk
+	}
+
+	// The comments and the invalid events are skipped
+	assert.Equal(t, "Sunny in Paris.", text)
+	require.NotNil(t, last.OfResponseCompleted)
+	assert.Equal(t, "completed", last.OfResponseCompleted.Response.Status)
+}
+
+func TestStream_Cancelled(t *testing.T) {
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"cmpl_2\",\"model\":\"qwen3\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Par\"},\"finish_reason\":null}]}\n\n"))
+		w.(http.Flusher).Flush()
+
+		// The model keeps generating until the client goes away
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &Client{BaseURL: server.URL, HTTPClient: http.DefaultClient}
+	res, err := client.Post(ctx, []byte(`{}`), true)
+	require.NoError(t, err)
+
+	var last *responses.ResponseChunk
+	for chunk := range Stream[ResponseChunk](ctx, res, &ResponseChunkToNativeResponseChunkConverter{}) {
+		if chunk.OfOutputTextDelta != nil {
+			cancel()
+		}
+		last = chunk
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request to the provider was not aborted")
+	}
+
+	require.NotNil(t, last.OfResponseCompleted)
+	assert.Equal(t, "failed", last.OfResponseCompleted.Response.Status)
+	assert.Equal(t, map[string]any{"message": context.Canceled.Error()}, last.OfResponseCompleted.Response.Error)
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "error object",
+			body: `{"error": {"message": "Invalid API key provided", "type": "invalid_request_error", "code": "invalid_api_key"}}`,
+			want: "Invalid API key provided",
+		},
+		{
+			name: "message",
+			body: `{"object": "error", "message": "Unauthorized", "type": "invalid_request_error", "code": null}`,
+			want: "Unauthorized",
+		},
+		{
+			name: "message object",
+			body: `{"object": "error", "message": {"detail": "Invalid model"}}`,
+			want: "map[detail:Invalid model]",
+		},
+		{
+			name: "detail",
+			body: `{"detail": "Model not found"}`,
+			want: "Model not found",
+		},
+		{
+			name: "no message",
+			body: `{"error": {}}`,
+			want: "401 Unauthorized",
+		},
+		{
+			name: "not json",
+			body: `Unauthorized`,
+			want: "401 Unauthorized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := &Client{BaseURL: server.URL, HTTPClient: http.DefaultClient}
+			_, err := client.Post(context.Background(), []byte(`{}`), false)
+			require.Error(t, err)
+			assert.Equal(t, tt.want, err.Error())
+		})
+	}
+}
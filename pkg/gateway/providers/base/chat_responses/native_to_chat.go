@@ -0,0 +1,354 @@
+package chat_responses
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Options are the differences of the chat completions API of a provider
+type Options struct {
+	// Provider names the provider in the warnings about the unsupported features, e.g. "mistral"
+	Provider string
+
+	// RequiredToolChoice is the tool choice mode requiring a tool call, "required" by default, e.g. "any" for Mistral
+	RequiredToolChoice string
+
+	// ToolCallID maps the IDs of the tool calls to IDs the provider accepts, e.g. the IDs of a conversation started
+	// with another provider. The IDs are kept by default.
+	ToolCallID func(id string) string
+
+	// Thinking sends the reasoning of the previous turns as thinking parts leading the content of the assistant
+	// messages. By default the reasoning is left out, the open models aren't given their previous thinking.
+	Thinking bool
+
+	// NoTools drops the tools, and the function calls and their outputs of other providers, for the providers without
+	// tools
+	NoTools bool
+
+	// AlternateRoles merges the consecutive messages of a role, for the providers requiring the user and the
+	// assistant messages to alternate
+	AlternateRoles bool
+}
+
+func (o Options) toolCallID(id string) string {
+	if o.ToolCallID == nil {
+		return id
+	}
+	return o.ToolCallID(id)
+}
+
+func NativeRequestToRequest(in *responses.Request, opts Options) *Request {
+	out := &Request{
+		Model:          in.Model,
+		Temperature:    in.Temperature,
+		TopP:           in.TopP,
+		MaxTokens:      in.MaxOutputTokens,
+		ResponseFormat: NativeTextFormatToResponseFormat(in.Text),
+	}
+
+	out.Messages = NativeMessagesToMessages(in.Input, opts)
+	if in.Instructions != nil && *in.Instructions != "" {
+		out.Messages = append([]Message{{Role: RoleSystem, Content: ContentUnion{OfString: in.Instructions}}}, out.Messages...)
+	}
+
+	if opts.NoTools {
+		if len(in.Tools) > 0 {
+			slog.Warn("tools are not supported for " + opts.Provider + " models")
+		}
+		return out
+	}
+
+	if in.MaxToolCalls != nil {
+		slog.Warn("max tool call is not supported for " + opts.Provider + " models")
+	}
+
+	out.ParallelToolCalls = in.ParallelToolCalls
+	out.Tools, out.ToolChoice = NativeToolsToTools(in.Tools, in.ToolChoice, opts)
+
+	return out
+}
+
+// NativeTextFormatToResponseFormat converts the structured output of the text format, a json_schema format or the
+// json_object mode. The output is the text of the message.
+func NativeTextFormatToResponseFormat(in *responses.TextFormat) *ResponseFormat {
+	if in == nil || in.Format == nil {
+		return nil
+	}
+
+	formatType, _ := in.Format["type"].(string)
+	switch formatType {
+	case "json_object":
+		return &ResponseFormat{Type: "json_object"}
+
+	case "json_schema":
+		schema, _ := in.Format["schema"].(map[string]any)
+		name, _ := in.Format["name"].(string)
+		if name == "" {
+			name = "structured_output"
+		}
+
+		out := &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchema{Name: name, Schema: schema},
+		}
+		if description, ok := in.Format["description"].(string); ok {
+			out.JSONSchema.Description = &description
+		}
+		if strict, ok := in.Format["strict"].(bool); ok {
+			out.JSONSchema.Strict = &strict
+		}
+		return out
+	}
+
+	return nil
+}
+
+// NativeToolsToTools converts the function tools, the other tools aren't supported by the chat completions API. The
+// tools allowed by the tool choice are the only tools sent.
+func NativeToolsToTools(nativeTools []responses.ToolUnion, choice *responses.ToolChoiceUnion, opts Options) ([]Tool, *ToolChoice) {
+	var allowed []string
+	if choice != nil && choice.OfAllowedTools != nil {
+		allowed = choice.OfAllowedTools.FunctionNames()
+	}
+
+	var tools []Tool
+	for _, nativeTool := range nativeTools {
+		if nativeTool.OfFunction == nil {
+			slog.Warn("only function tools are supported for " + opts.Provider + " models")
+			continue
+		}
+
+		if allowed != nil && !slices.Contains(allowed, nativeTool.OfFunction.Name) {
+			continue
+		}
+
+		parameters := nativeTool.OfFunction.Parameters
+		if parameters == nil {
+			parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+
+		tools = append(tools, Tool{
+			Type: "function",
+			Function: Function{
+				Name:        nativeTool.OfFunction.Name,
+				Description: nativeTool.OfFunction.Description,
+				Parameters:  parameters,
+				Strict:      nativeTool.OfFunction.Strict,
+			},
+		})
+	}
+
+	if len(tools) == 0 || choice == nil {
+		return tools, nil
+	}
+
+	mode := func(mode responses.ToolChoiceMode) *ToolChoice {
+		if mode == responses.ToolChoiceModeRequired && opts.RequiredToolChoice != "" {
+			return &ToolChoice{OfMode: utils.Ptr(opts.RequiredToolChoice)}
+		}
+		return &ToolChoice{OfMode: utils.Ptr(string(mode))}
+	}
+
+	switch {
+	case choice.OfFunction != nil:
+		function := &ToolChoiceFunction{Type: "function"}
+		function.Function.Name = choice.OfFunction.Name
+		return tools, &ToolChoice{OfFunction: function}
+	case choice.OfAllowedTools != nil:
+		return tools, mode(choice.OfAllowedTools.Mode)
+	case choice.OfMode != nil:
+		return tools, mode(*choice.OfMode)
+	}
+
+	return tools, nil
+}
+
+func NativeRoleToRole(in constants.Role) Role {
+	switch in {
+	case constants.RoleAssistant:
+		return RoleAssistant
+	case constants.RoleSystem, constants.RoleDeveloper:
+		return RoleSystem
+	}
+
+	return RoleUser
+}
+
+// NativeMessagesToMessages converts the input to messages. The function calls following an assistant message are
+// the tool calls of that message.
+func NativeMessagesToMessages(in responses.InputUnion, opts Options) []Message {
+	if in.OfString != nil {
+		return []Message{{Role: RoleUser, Content: ContentUnion{OfString: in.OfString}}}
+	}
+
+	var messages []Message
+	toolNames := map[string]string{}
+
+	separator := ""
+	if opts.AlternateRoles {
+		separator = "\n\n"
+	}
+
+	// add appends the message, or its content to the last message for the consecutive assistant messages, and for
+	// the consecutive messages of any role when the roles alternate
+	add := func(role Role, content ContentUnion) {
+		if n := len(messages); n > 0 && messages[n-1].Role == role && (role == RoleAssistant || opts.AlternateRoles) {
+			appendContent(&messages[n-1], content, separator)
+			return
+		}
+		messages = append(messages, Message{Role: role, Content: content})
+	}
+
+	// assistant returns the last message if it is an assistant message without tool results after it, or a new
+	// assistant message
+	assistant := func() *Message {
+		if n := len(messages); n > 0 && messages[n-1].Role == RoleAssistant {
+			return &messages[n-1]
+		}
+		messages = append(messages, Message{Role: RoleAssistant})
+		return &messages[len(messages)-1]
+	}
+
+	for _, nativeMessage := range in.OfInputMessageList {
+		switch {
+		case nativeMessage.OfEasyInput != nil:
+			var content ContentUnion
+			if nativeMessage.OfEasyInput.Content.OfString != nil {
+				content.OfString = nativeMessage.OfEasyInput.Content.OfString
+			} else {
+				content = NativeContentToContent(nativeMessage.OfEasyInput.Content.OfInputMessageList, opts)
+			}
+			add(NativeRoleToRole(nativeMessage.OfEasyInput.Role), content)
+
+		case nativeMessage.OfInputMessage != nil:
+			add(NativeRoleToRole(nativeMessage.OfInputMessage.Role), NativeContentToContent(nativeMessage.OfInputMessage.Content, opts))
+
+		case nativeMessage.OfOutputMessage != nil:
+			text := ""
+			for _, content := range nativeMessage.OfOutputMessage.Content {
+				if content.OfOutputText != nil {
+					text += content.OfOutputText.Text
+				}
+			}
+			add(RoleAssistant, ContentUnion{OfString: &text})
+
+		case nativeMessage.OfFunctionCall != nil, nativeMessage.OfFunctionCallOutput != nil:
+			if opts.NoTools {
+				slog.Warn("function calls are not supported for " + opts.Provider + " models")
+				continue
+			}
+
+			if call := nativeMessage.OfFunctionCall; call != nil {
+				callID := opts.toolCallID(call.CallID)
+				toolNames[callID] = call.Name
+
+				msg := assistant()
+				msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+					ID:       callID,
+					Type:     "function",
+					Function: FunctionCall{Name: call.Name, Arguments: call.Arguments},
+				})
+				continue
+			}
+
+			callID := opts.toolCallID(nativeMessage.OfFunctionCallOutput.CallID)
+			messages = append(messages, Message{
+				Role:       RoleTool,
+				Content:    NativeFunctionCallOutputToContent(nativeMessage.OfFunctionCallOutput),
+				ToolCallID: callID,
+				Name:       toolNames[callID],
+			})
+
+		case nativeMessage.OfReasoning != nil:
+			if !opts.Thinking {
+				continue
+			}
+
+			text := ""
+			for _, summary := range nativeMessage.OfReasoning.Summary {
+				text += summary.Text
+			}
+			if text == "" {
+				continue
+			}
+
+			appendContent(assistant(), ContentUnion{OfParts: []ContentPart{{
+				Type:     PartTypeThinking,
+				Thinking: []ContentPart{{Type: PartTypeText, Text: text}},
+			}}}, "")
+		}
+	}
+
+	return messages
+}
+
+// appendContent appends the content to the content of the message, as parts unless both are strings, which are
+// joined by the separator
+func appendContent(msg *Message, content ContentUnion, separator string) {
+	if msg.Content.OfString == nil && msg.Content.OfParts == nil {
+		msg.Content = content
+		return
+	}
+
+	if msg.Content.OfString != nil && content.OfString != nil {
+		msg.Content.OfString = utils.Ptr(*msg.Content.OfString + separator + *content.OfString)
+		return
+	}
+
+	msg.Content = ContentUnion{OfParts: append(contentParts(msg.Content), contentParts(content)...)}
+}
+
+func contentParts(content ContentUnion) []ContentPart {
+	if content.OfString != nil {
+		if *content.OfString == "" {
+			return nil
+		}
+		return []ContentPart{{Type: PartTypeText, Text: *content.OfString}}
+	}
+	return content.OfParts
+}
+
+func NativeContentToContent(in responses.InputContent, opts Options) ContentUnion {
+	var parts []ContentPart
+
+	for _, nativeContent := range in {
+		switch {
+		case nativeContent.OfInputText != nil:
+			parts = append(parts, ContentPart{Type: PartTypeText, Text: nativeContent.OfInputText.Text})
+		case nativeContent.OfOutputText != nil:
+			parts = append(parts, ContentPart{Type: PartTypeText, Text: nativeContent.OfOutputText.Text})
+		case nativeContent.OfInputImage != nil:
+			if nativeContent.OfInputImage.ImageURL == nil {
+				slog.Warn("images by file ID are not supported for " + opts.Provider + " models")
+				continue
+			}
+			parts = append(parts, ContentPart{Type: PartTypeImageURL, ImageURL: &ImageURL{URL: *nativeContent.OfInputImage.ImageURL}})
+		}
+	}
+
+	// A single text is sent as a string, which every model accepts
+	if len(parts) == 1 && parts[0].Type == PartTypeText {
+		return ContentUnion{OfString: &parts[0].Text}
+	}
+
+	return ContentUnion{OfParts: parts}
+}
+
+func NativeFunctionCallOutputToContent(in *responses.FunctionCallOutputMessage) ContentUnion {
+	if in.Output.OfString != nil {
+		return ContentUnion{OfString: in.Output.OfString}
+	}
+
+	text := ""
+	for _, nativeOutput := range in.Output.OfList {
+		if nativeOutput.OfInputText != nil {
+			text += nativeOutput.OfInputText.Text
+		}
+	}
+
+	return ContentUnion{OfString: &text}
+}
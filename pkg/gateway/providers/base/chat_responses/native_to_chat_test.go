@@ -0,0 +1,208 @@
+package chat_responses
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conversation is the input of a turn after a tool call, with an image and the reasoning of the previous answer
+const conversation = `[
+	{"type": "message", "role": "user", "content": [
+		{"type": "input_text", "text": "Weather in this city?"},
+		{"type": "input_image", "image_url": "https://example.com/paris.png"}
+	]},
+	{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "The image is Paris."}]},
+	{"type": "function_call", "call_id": "call_0123456789abcdef", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+	{"type": "function_call_output", "call_id": "call_0123456789abcdef", "output": "Sunny"},
+	{"type": "message", "role": "assistant", "id": "msg_1", "status": "completed", "content": [{"type": "output_text", "text": "It is sunny.", "annotations": []}]},
+	{"type": "message", "role": "user", "content": "And tomorrow?"},
+	{"type": "message", "role": "user", "content": "In Lyon too."}
+]`
+
+func TestNativeRequestToRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		opts    Options
+		want    string
+	}{
+		{
+			name: "openai compatible",
+			request: `{
+				"model": "qwen3",
+				"instructions": "Be brief",
+				"input": ` + conversation + `,
+				"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object"}}],
+				"tool_choice": "required",
+				"parallel_tool_calls": false,
+				"max_output_tokens": 100
+			}`,
+			want: `{
+				"model": "qwen3",
+				"max_tokens": 100,
+				"messages": [
+					{"role": "system", "content": "Be brief"},
+					{"role": "user", "content": [
+						{"type": "text", "text": "Weather in this city?"},
+						{"type": "image_url", "image_url": {"url": "https://example.com/paris.png"}}
+					]},
+					{"role": "assistant", "content": "", "tool_calls": [
+						{"id": "call_0123456789abcdef", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+					]},
+					{"role": "tool", "content": "Sunny", "tool_call_id": "call_0123456789abcdef", "name": "get_weather"},
+					{"role": "assistant", "content": "It is sunny."},
+					{"role": "user", "content": "And tomorrow?"},
+					{"role": "user", "content": "In Lyon too."}
+				],
+				"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}],
+				"tool_choice": "required",
+				"parallel_tool_calls": false
+			}`,
+		},
+		{
+			name: "tool call ids, required tool choice and thinking",
+			request: `{
+				"model": "magistral",
+				"input": ` + conversation + `,
+				"tools": [{"type": "function", "name": "get_weather"}],
+				"tool_choice": "required"
+			}`,
+			opts: Options{
+				RequiredToolChoice: "any",
+				ToolCallID:         func(id string) string { return "D681PevKs" },
+				Thinking:           true,
+			},
+			want: `{
+				"model": "magistral",
+				"messages": [
+					{"role": "user", "content": [
+						{"type": "text", "text": "Weather in this city?"},
+						{"type": "image_url", "image_url": {"url": "https://example.com/paris.png"}}
+					]},
+					{"role": "assistant", "content": [
+						{"type": "thinking", "thinking": [{"type": "text", "text": "The image is Paris."}]}
+					], "tool_calls": [
+						{"id": "D681PevKs", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}
+					]},
+					{"role": "tool", "content": "Sunny", "tool_call_id": "D681PevKs", "name": "get_weather"},
+					{"role": "assistant", "content": "It is sunny."},
+					{"role": "user", "content": "And tomorrow?"},
+					{"role": "user", "content": "In Lyon too."}
+				],
+				"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object", "properties": {}}}}],
+				"tool_choice": "any"
+			}`,
+		},
+		{
+			name: "no tools and alternating roles",
+			request: `{
+				"model": "sonar",
+				"input": ` + conversation + `,
+				"tools": [{"type": "function", "name": "get_weather"}],
+				"tool_choice": "required",
+				"parallel_tool_calls": false
+			}`,
+			opts: Options{NoTools: true, AlternateRoles: true},
+			want: `{
+				"model": "sonar",
+				"messages": [
+					{"role": "user", "content": [
+						{"type": "text", "text": "Weather in this city?"},
+						{"type": "image_url", "image_url": {"url": "https://example.com/paris.png"}}
+					]},
+					{"role": "assistant", "content": "It is sunny."},
+					{"role": "user", "content": "And tomorrow?\n\nIn Lyon too."}
+				]
+			}`,
+		},
+		{
+			name: "allowed tools",
+			request: `{
+				"model": "qwen3",
+				"input": "Weather in Paris?",
+				"tools": [{"type": "function", "name": "get_weather"}, {"type": "function", "name": "get_time"}],
+				"tool_choice": {"type": "allowed_tools", "mode": "required", "tools": [{"type": "function", "name": "get_time"}]}
+			}`,
+			opts: Options{RequiredToolChoice: "any"},
+			want: `{
+				"model": "qwen3",
+				"messages": [{"role": "user", "content": "Weather in Paris?"}],
+				"tools": [{"type": "function", "function": {"name": "get_time", "parameters": {"type": "object", "properties": {}}}}],
+				"tool_choice": "any"
+			}`,
+		},
+		{
+			name: "function tool choice",
+			request: `{
+				"model": "qwen3",
+				"input": "Weather in Paris?",
+				"tools": [{"type": "function", "name": "get_weather"}],
+				"tool_choice": {"type": "function", "name": "get_weather"}
+			}`,
+			want: `{
+				"model": "qwen3",
+				"messages": [{"role": "user", "content": "Weather in Paris?"}],
+				"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object", "properties": {}}}}],
+				"tool_choice": {"type": "function", "function": {"name": "get_weather"}}
+			}`,
+		},
+		{
+			name: "json schema",
+			request: `{
+				"model": "qwen3",
+				"input": "I live in Paris",
+				"text": {"format": {"type": "json_schema", "name": "city", "strict": true, "schema": {"type": "object"}}}
+			}`,
+			want: `{
+				"model": "qwen3",
+				"messages": [{"role": "user", "content": "I live in Paris"}],
+				"response_format": {"type": "json_schema", "json_schema": {"name": "city", "strict": true, "schema": {"type": "object"}}}
+			}`,
+		},
+		{
+			name: "json object",
+			request: `{
+				"model": "qwen3",
+				"input": "I live in Paris",
+				"text": {"format": {"type": "json_object"}}
+			}`,
+			want: `{
+				"model": "qwen3",
+				"messages": [{"role": "user", "content": "I live in Paris"}],
+				"response_format": {"type": "json_object"}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in responses.Request
+			require.NoError(t, sonic.Unmarshal([]byte(tt.request), &in))
+
+			out, err := sonic.Marshal(NativeRequestToRequest(&in, tt.opts))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(out))
+		})
+	}
+}
+
+func TestMarshalWithExtraParams(t *testing.T) {
+	type providerRequest struct {
+		*Request
+		Usage map[string]any `json:"usage"`
+	}
+
+	request := &providerRequest{
+		Request: &Request{Model: "qwen3", Messages: []Message{}},
+		Usage:   map[string]any{"include": true},
+	}
+
+	// The params of the request aren't overridden
+	out, err := MarshalWithExtraParams(request, map[string]any{"top_k": 40, "model": "other"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model": "qwen3", "messages": [], "usage": {"include": true}, "top_k": 40}`, string(out))
+}
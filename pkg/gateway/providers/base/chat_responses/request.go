@@ -0,0 +1,216 @@
+package chat_responses
+
+import (
+	"errors"
+
+	"github.com/bytedance/sonic"
+)
+
+// Request is the body of the chat completions API the open model providers share, e.g. Mistral, Together AI,
+// Fireworks AI, Perplexity or OpenRouter. The params a provider doesn't take are left empty.
+type Request struct {
+	Model             string          `json:"model"`
+	Messages          []Message       `json:"messages"`
+	Temperature       *float64        `json:"temperature,omitempty"`
+	TopP              *float64        `json:"top_p,omitempty"`
+	MaxTokens         *int            `json:"max_tokens,omitempty"`
+	Stream            bool            `json:"stream,omitempty"`
+	ResponseFormat    *ResponseFormat `json:"response_format,omitempty"`
+	Tools             []Tool          `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice     `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
+	Reasoning         *Reasoning      `json:"reasoning,omitempty"`
+	ReasoningEffort   *string         `json:"reasoning_effort,omitempty"`
+}
+
+// Reasoning configures the thinking of the providers taking a reasoning object, e.g. Together AI switching the
+// thinking of the hybrid models on or off, or OpenRouter taking an effort
+type Reasoning struct {
+	Effort  *string `json:"effort,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+// MarshalWithExtraParams marshals the request, or a request of a provider embedding it, with the extra params of a
+// provider. The params of the request aren't overridden.
+func MarshalWithExtraParams(request any, params map[string]any) ([]byte, error) {
+	payload, err := sonic.Marshal(request)
+	if err != nil || len(params) == 0 {
+		return payload, err
+	}
+
+	var fields map[string]any
+	if err := sonic.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, value := range params {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+
+	return sonic.Marshal(fields)
+}
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a message of the conversation. The reasoning of the answers is either in the reasoning or in the
+// reasoning_content field, depending on the provider, or in the thinking parts of the content for Mistral.
+type Message struct {
+	Role             Role         `json:"role"`
+	Content          ContentUnion `json:"content"`
+	Reasoning        *string      `json:"reasoning,omitempty"`
+	ReasoningContent *string      `json:"reasoning_content,omitempty"`
+	ToolCalls        []ToolCall   `json:"tool_calls,omitempty"`
+	ToolCallID       string       `json:"tool_call_id,omitempty"`
+	Name             string       `json:"name,omitempty"`
+}
+
+// ContentUnion is either a string or a list of parts. The parts carry the images of the vision models, and the
+// thinking of the reasoning models of Mistral.
+type ContentUnion struct {
+	OfString *string
+	OfParts  []ContentPart
+}
+
+func (u *ContentUnion) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := sonic.Unmarshal(data, &s); err == nil {
+		u.OfString = &s
+		return nil
+	}
+
+	var parts []ContentPart
+	if err := sonic.Unmarshal(data, &parts); err == nil {
+		u.OfParts = parts
+		return nil
+	}
+
+	return errors.New("invalid content union")
+}
+
+func (u ContentUnion) MarshalJSON() ([]byte, error) {
+	if u.OfString != nil {
+		return sonic.Marshal(u.OfString)
+	}
+
+	if u.OfParts != nil {
+		return sonic.Marshal(u.OfParts)
+	}
+
+	return []byte(`""`), nil
+}
+
+// Text returns the text of the content, without the thinking
+func (u ContentUnion) Text() string {
+	if u.OfString != nil {
+		return *u.OfString
+	}
+
+	text := ""
+	for _, part := range u.OfParts {
+		if part.Type == PartTypeText {
+			text += part.Text
+		}
+	}
+	return text
+}
+
+type PartType string
+
+const (
+	PartTypeText     PartType = "text"
+	PartTypeImageURL PartType = "image_url"
+	PartTypeThinking PartType = "thinking"
+)
+
+type ContentPart struct {
+	Type     PartType      `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *ImageURL     `json:"image_url,omitempty"`
+	Thinking []ContentPart `json:"thinking,omitempty"`
+}
+
+// ThinkingText returns the text of a thinking part
+func (p ContentPart) ThinkingText() string {
+	text := ""
+	for _, thinking := range p.Thinking {
+		text += thinking.Text
+	}
+	return text
+}
+
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+
+	// Index of the tool call in the streams
+	Index *int `json:"index,omitempty"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type Tool struct {
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+type Function struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+	Strict      *bool          `json:"strict,omitempty"`
+}
+
+// ToolChoice is either a mode, e.g. "auto", "none" or "required", or a function
+type ToolChoice struct {
+	OfMode     *string
+	OfFunction *ToolChoiceFunction
+}
+
+type ToolChoiceFunction struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+func (u ToolChoice) MarshalJSON() ([]byte, error) {
+	if u.OfFunction != nil {
+		return sonic.Marshal(u.OfFunction)
+	}
+
+	return sonic.Marshal(u.OfMode)
+}
+
+// ResponseFormat constrains the output of the model to JSON, to any JSON object or to the JSON schema
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object" or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema"`
+	Strict      *bool          `json:"strict,omitempty"`
+}
@@ -0,0 +1,101 @@
+package chat_responses
+
+import "strings"
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// segment is a piece of the content of a message, either thinking or text
+type segment struct {
+	thinking bool
+	text     string
+}
+
+// thinkTagParser separates the thinking of the models answering it in their content between think tags, e.g.
+// DeepSeek R1, from their text. The content is fed delta by delta, the tags split across deltas are held back until
+// they are complete. Only a thinking leading the content is separated, the tags in the text are text.
+type thinkTagParser struct {
+	thinking bool
+	answered bool   // Text was emitted, the thinking is over
+	trim     bool   // The whitespace after the closing tag is dropped
+	pending  string // Start of a tag split across deltas
+}
+
+func (p *thinkTagParser) Feed(delta string) []segment {
+	data := p.pending + delta
+	p.pending = ""
+
+	var out []segment
+	for data != "" {
+		if p.answered {
+			return append(out, segment{text: data})
+		}
+
+		if !p.thinking {
+			trimmed := strings.TrimLeft(data, " \t\r\n")
+			switch {
+			case strings.HasPrefix(trimmed, thinkOpenTag):
+				p.thinking = true
+				data = trimmed[len(thinkOpenTag):]
+				continue
+			case trimmed == "" || strings.HasPrefix(thinkOpenTag, trimmed):
+				// Whitespace or the start of the tag, more content is needed
+				p.pending = data
+				return out
+			}
+
+			p.answered = true
+			if p.trim {
+				data = trimmed
+			}
+			continue
+		}
+
+		idx := strings.Index(data, thinkCloseTag)
+		if idx >= 0 {
+			if idx > 0 {
+				out = append(out, segment{thinking: true, text: data[:idx]})
+			}
+			data = data[idx+len(thinkCloseTag):]
+			p.thinking = false
+			p.trim = true
+			continue
+		}
+
+		// Hold back the end of the delta which may start the closing tag
+		keep := 0
+		for n := min(len(thinkCloseTag)-1, len(data)); n > 0; n-- {
+			if strings.HasSuffix(data, thinkCloseTag[:n]) {
+				keep = n
+				break
+			}
+		}
+		if len(data) > keep {
+			out = append(out, segment{thinking: true, text: data[:len(data)-keep]})
+		}
+		p.pending = data[len(data)-keep:]
+		return out
+	}
+
+	return out
+}
+
+// Flush returns the content held back at the end of the stream
+func (p *thinkTagParser) Flush() []segment {
+	pending := p.pending
+	p.pending = ""
+
+	if pending == "" {
+		return nil
+	}
+	if !p.thinking && p.trim {
+		pending = strings.TrimLeft(pending, " \t\r\n")
+		if pending == "" {
+			return nil
+		}
+	}
+
+	return []segment{{thinking: p.thinking, text: pending}}
+}
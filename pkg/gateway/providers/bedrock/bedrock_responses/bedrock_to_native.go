@@ -0,0 +1,642 @@
+package bedrock_responses
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ToNativeResponse converts the response, Bedrock doesn't identify the responses so the ID is the one of the request
+func (in *Response) ToNativeResponse(id, model string) *responses.Response {
+	output := []responses.OutputMessageUnion{}
+
+	if in.Output.Message != nil {
+		for _, content := range in.Output.Message.Content {
+			if item := ContentBlockToNativeOutput(content); item != nil {
+				output = append(output, *item)
+			}
+		}
+	}
+
+	return &responses.Response{
+		ID:     id,
+		Model:  model,
+		Output: output,
+		Usage:  utils.Ptr(in.Usage.ToNative()),
+		Error: &responses.Error{
+			Type:    "",
+			Message: "",
+			Param:   "",
+			Code:    "",
+		},
+		Metadata: map[string]any{
+			"stop_reason": in.StopReason,
+		},
+	}
+}
+
+// ContentBlockToNativeOutput converts an output content block, the blocks of other types are dropped
+func ContentBlockToNativeOutput(content ContentBlock) *responses.OutputMessageUnion {
+	switch {
+	case content.Text != nil:
+		return &responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:   responses.NewOutputItemMessageID(),
+				Role: constants.RoleAssistant,
+				Content: responses.OutputContent{
+					{OfOutputText: &responses.OutputTextContent{Text: *content.Text}},
+				},
+			},
+		}
+
+	case content.ToolUse != nil:
+		args, err := sonic.Marshal(content.ToolUse.Input)
+		if err != nil || content.ToolUse.Input == nil {
+			args = []byte("{}")
+		}
+
+		return &responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        responses.NewOutputItemFunctionCallID(),
+				CallID:    content.ToolUse.ToolUseID,
+				Name:      content.ToolUse.Name,
+				Arguments: string(args),
+			},
+		}
+
+	case content.ReasoningContent != nil && content.ReasoningContent.ReasoningText != nil:
+		reasoning := content.ReasoningContent.ReasoningText
+		return &responses.OutputMessageUnion{
+			OfReasoning: &responses.ReasoningMessage{
+				ID:               responses.NewOutputItemReasoningID(),
+				Summary:          []responses.SummaryTextContent{{Text: reasoning.Text}},
+				EncryptedContent: reasoning.Signature,
+			},
+		}
+
+	// Redacted reasoning is converted into a reasoning message without summary
+	case content.ReasoningContent != nil && content.ReasoningContent.RedactedContent != nil:
+		return &responses.OutputMessageUnion{
+			OfReasoning: &responses.ReasoningMessage{
+				ID:               responses.NewOutputItemReasoningID(),
+				EncryptedContent: utils.Ptr(base64.StdEncoding.EncodeToString(content.ReasoningContent.RedactedContent)),
+			},
+		}
+	}
+
+	return nil
+}
+
+func (in Usage) ToNative() responses.Usage {
+	return responses.Usage{
+		InputTokens: in.InputTokens,
+		InputTokensDetails: struct {
+			CachedTokens int `json:"cached_tokens"`
+		}{
+			CachedTokens: in.CacheReadInputTokens,
+		},
+		OutputTokens: in.OutputTokens,
+		OutputTokensDetails: struct {
+			ReasoningTokens int `json:"reasoning_tokens"`
+		}{},
+		TotalTokens: in.InputTokens + in.OutputTokens,
+	}
+}
+
+// =============================================================================
+// StreamEvent to Native Conversion
+// =============================================================================
+
+type blockKind int
+
+const (
+	blockKindText blockKind = iota
+	blockKindToolUse
+	blockKindReasoning
+)
+
+// StreamEventToNativeResponseChunkConverter converts the ConverseStream events to native chunks. The text and
+// reasoning blocks have no start event, they start with their first delta.
+type StreamEventToNativeResponseChunkConverter struct {
+	// ID and Model of the response, Bedrock doesn't return them in the stream
+	ID    string
+	Model string
+
+	sequenceNumber int
+	outputIndex    int
+	started        bool
+	completed      bool
+
+	// Current content block
+	block            *streamBlock
+	completedOutputs []responses.OutputMessageUnion
+}
+
+type streamBlock struct {
+	index     int
+	kind      blockKind
+	outputID  string
+	toolUse   *ToolUseBlockStart
+	text      string // Accumulated text, reasoning or tool use input
+	signature string
+	redacted  []byte
+}
+
+// nextSeqNum returns the next sequence number and increments the counter.
+func (c *StreamEventToNativeResponseChunkConverter) nextSeqNum() int {
+	n := c.sequenceNumber
+	c.sequenceNumber++
+	return n
+}
+
+// StreamEventToNativeResponseChunk converts a single event to zero or more native chunks.
+func (c *StreamEventToNativeResponseChunkConverter) StreamEventToNativeResponseChunk(in *StreamEvent) []*responses.ResponseChunk {
+	if in == nil {
+		return nil
+	}
+
+	switch {
+	case in.MessageStart != nil:
+		return c.start()
+	case in.ContentBlockStart != nil:
+		return c.handleContentBlockStart(in.ContentBlockStart)
+	case in.ContentBlockDelta != nil:
+		return c.handleContentBlockDelta(in.ContentBlockDelta)
+	case in.ContentBlockStop != nil:
+		return c.completeBlock()
+	case in.MessageStop != nil:
+		return nil
+	case in.Metadata != nil:
+		return c.complete(in.Metadata.Usage, nil)
+	}
+
+	return nil
+}
+
+// Close completes a stream that ended without its metadata, it returns nothing once the stream has completed
+func (c *StreamEventToNativeResponseChunkConverter) Close() []*responses.ResponseChunk {
+	return c.complete(Usage{}, nil)
+}
+
+// Fail completes the stream with the error of an exception event
+func (c *StreamEventToNativeResponseChunkConverter) Fail(message string) []*responses.ResponseChunk {
+	return c.complete(Usage{}, map[string]any{"message": message})
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) start() []*responses.ResponseChunk {
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	return []*responses.ResponseChunk{
+		c.buildResponseCreated(),
+		c.buildResponseInProgress(),
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) handleContentBlockStart(event *ContentBlockStartEvent) []*responses.ResponseChunk {
+	result := c.completeBlock()
+
+	if event.Start.ToolUse == nil {
+		return result
+	}
+
+	c.block = &streamBlock{
+		index:    event.ContentBlockIndex,
+		kind:     blockKindToolUse,
+		outputID: responses.NewOutputItemFunctionCallID(),
+		toolUse:  event.Start.ToolUse,
+	}
+
+	return append(result, c.buildOutputItemAddedFunctionCall())
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) handleContentBlockDelta(event *ContentBlockDeltaEvent) []*responses.ResponseChunk {
+	var result []*responses.ResponseChunk
+
+	// The text and reasoning blocks start with their first delta
+	if c.block == nil || c.block.index != event.ContentBlockIndex {
+		result = append(result, c.completeBlock()...)
+
+		switch {
+		case event.Delta.Text != nil:
+			c.block = &streamBlock{index: event.ContentBlockIndex, kind: blockKindText, outputID: responses.NewOutputItemMessageID()}
+			result = append(result, c.buildOutputItemAddedMessage(), c.buildContentPartAddedText())
+		case event.Delta.ReasoningContent != nil:
+			c.block = &streamBlock{index: event.ContentBlockIndex, kind: blockKindReasoning, outputID: responses.NewOutputItemReasoningID()}
+			result = append(result, c.buildOutputItemAddedReasoning(), c.buildReasoningSummaryPartAdded())
+		default:
+			return result
+		}
+	}
+
+	delta := event.Delta
+	switch {
+	case c.block.kind == blockKindText && delta.Text != nil:
+		c.block.text += *delta.Text
+		result = append(result, c.buildOutputTextDelta(*delta.Text))
+
+	case c.block.kind == blockKindToolUse && delta.ToolUse != nil:
+		c.block.text += delta.ToolUse.Input
+		result = append(result, c.buildFunctionCallArgumentsDelta(delta.ToolUse.Input))
+
+	case c.block.kind == blockKindReasoning && delta.ReasoningContent != nil:
+		reasoning := delta.ReasoningContent
+		switch {
+		case reasoning.Text != nil:
+			c.block.text += *reasoning.Text
+			result = append(result, c.buildReasoningSummaryTextDelta(*reasoning.Text))
+		case reasoning.Signature != nil:
+			c.block.signature += *reasoning.Signature
+			result = append(result, c.buildReasoningSignatureDelta(*reasoning.Signature))
+		case reasoning.RedactedContent != nil:
+			c.block.redacted = append(c.block.redacted, reasoning.RedactedContent...)
+		}
+	}
+
+	return result
+}
+
+// completeBlock emits the done chunks of the current block and stores its output
+func (c *StreamEventToNativeResponseChunkConverter) completeBlock() []*responses.ResponseChunk {
+	block := c.block
+	if block == nil {
+		return nil
+	}
+
+	var result []*responses.ResponseChunk
+
+	switch block.kind {
+	case blockKindText:
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:      block.outputID,
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{Text: block.text}}},
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildOutputTextDone(block.text),
+			c.buildContentPartDoneText(block.text),
+			c.buildOutputItemDoneMessage(block.text),
+		}
+
+	case blockKindToolUse:
+		if block.text == "" {
+			block.text = "{}"
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        block.outputID,
+				CallID:    block.toolUse.ToolUseID,
+				Name:      block.toolUse.Name,
+				Arguments: block.text,
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildFunctionCallArgumentsDone(block.text),
+			c.buildOutputItemDoneFunctionCall(),
+		}
+
+	case blockKindReasoning:
+		reasoning := &responses.ReasoningMessage{
+			ID:               block.outputID,
+			Summary:          []responses.SummaryTextContent{{Text: block.text}},
+			EncryptedContent: utils.Ptr(block.signature),
+		}
+		if block.redacted != nil {
+			reasoning.Summary = nil
+			reasoning.EncryptedContent = utils.Ptr(base64.StdEncoding.EncodeToString(block.redacted))
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{OfReasoning: reasoning})
+
+		result = []*responses.ResponseChunk{
+			c.buildReasoningSummaryTextDone(block.text),
+			c.buildReasoningSummaryPartDone(block.text),
+			c.buildOutputItemDoneReasoning(reasoning),
+		}
+	}
+
+	c.block = nil
+	c.outputIndex++
+
+	return result
+}
+
+// complete emits response.completed, or a failed response with an error
+func (c *StreamEventToNativeResponseChunkConverter) complete(usage Usage, err map[string]any) []*responses.ResponseChunk {
+	if c.completed {
+		return nil
+	}
+	c.completed = true
+
+	result := c.start()
+	result = append(result, c.completeBlock()...)
+	return append(result, c.buildResponseCompleted(usage, err))
+}
+
+// =============================================================================
+// Chunk Builders
+// =============================================================================
+
+func (c *StreamEventToNativeResponseChunkConverter) buildResponseCreated() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseCreated: &responses.ChunkResponse[constants.ChunkTypeResponseCreated]{
+			Type:           constants.ChunkTypeResponseCreated(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.ID,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+				Request:   responses.Request{Model: c.Model},
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildResponseInProgress() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseInProgress: &responses.ChunkResponse[constants.ChunkTypeResponseInProgress]{
+			Type:           constants.ChunkTypeResponseInProgress(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.ID,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemAddedMessage() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{},
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemAddedFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "in_progress",
+				CallID:    utils.Ptr(c.block.toolUse.ToolUseID),
+				Name:      utils.Ptr(c.block.toolUse.Name),
+				Arguments: utils.Ptr(""),
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemAddedReasoning() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "reasoning",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Summary: []responses.SummaryTextContent{},
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildContentPartAddedText() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartAdded: &responses.ChunkContentPart[constants.ChunkTypeContentPartAdded]{
+			Type:           constants.ChunkTypeContentPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Text: ""}},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildReasoningSummaryPartAdded() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartAdded: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartAdded]{
+			Type:           constants.ChunkTypeReasoningSummaryPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: ""},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDelta: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDelta]{
+			Type:           constants.ChunkTypeOutputTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildFunctionCallArgumentsDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDelta: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDelta]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildReasoningSummaryTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDelta: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDelta]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildReasoningSignatureDelta(sig string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDelta: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDelta]{
+			Type:             constants.ChunkTypeReasoningSummaryTextDelta(""),
+			SequenceNumber:   c.nextSeqNum(),
+			ItemId:           c.block.outputID,
+			OutputIndex:      c.outputIndex,
+			EncryptedContent: utils.Ptr(sig),
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputTextDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDone: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDone]{
+			Type:           constants.ChunkTypeOutputTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(text),
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildContentPartDoneText(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartDone: &responses.ChunkContentPart[constants.ChunkTypeContentPartDone]{
+			Type:           constants.ChunkTypeContentPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Text: text}},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemDoneMessage(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "completed",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{Text: text}}},
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildFunctionCallArgumentsDone(args string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDone: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDone]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Arguments:      args,
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemDoneFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "completed",
+				CallID:    utils.Ptr(c.block.toolUse.ToolUseID),
+				Name:      utils.Ptr(c.block.toolUse.Name),
+				Arguments: utils.Ptr(c.block.text),
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildReasoningSummaryTextDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDone: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDone]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(text),
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildReasoningSummaryPartDone(text string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartDone: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartDone]{
+			Type:           constants.ChunkTypeReasoningSummaryPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: text},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildOutputItemDoneReasoning(reasoning *responses.ReasoningMessage) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:             "reasoning",
+				Id:               c.block.outputID,
+				Status:           "completed",
+				EncryptedContent: reasoning.EncryptedContent,
+				Summary:          reasoning.Summary,
+			},
+		},
+	}
+}
+
+func (c *StreamEventToNativeResponseChunkConverter) buildResponseCompleted(usage Usage, err map[string]any) *responses.ResponseChunk {
+	data := responses.ChunkResponseData{
+		Id:        c.ID,
+		Object:    "response",
+		CreatedAt: int(time.Now().Unix()),
+		Status:    "completed",
+		Output:    c.completedOutputs,
+		Usage:     usage.ToNative(),
+		Request:   responses.Request{Model: c.Model},
+	}
+	if data.Output == nil {
+		data.Output = []responses.OutputMessageUnion{}
+	}
+	if err != nil {
+		data.Status = "failed"
+		data.Error = err
+	}
+
+	return &responses.ResponseChunk{
+		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
+			Type:           constants.ChunkTypeResponseCompleted(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response:       data,
+		},
+	}
+}
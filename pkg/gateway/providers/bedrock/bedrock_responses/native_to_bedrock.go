@@ -0,0 +1,399 @@
+package bedrock_responses
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/anthropic/anthropic_responses"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// defaultThinkingMaxTokens is the max tokens of the requests enabling the thinking of Claude models without max
+// output tokens, the thinking budget must stay below them
+const defaultThinkingMaxTokens = 4096
+
+func NativeRequestToRequest(in *responses.Request) *Request {
+	if in.MaxToolCalls != nil {
+		slog.Warn("max tool call is not supported for bedrock models")
+	}
+
+	if in.ParallelToolCalls != nil {
+		slog.Warn("parallel tool call is not supported for bedrock models")
+	}
+
+	if in.Text != nil && in.Text.Format != nil {
+		slog.Warn("structured output is not supported for bedrock models")
+	}
+
+	out := &Request{
+		RequestMetadata: in.Metadata,
+	}
+
+	var system []SystemContent
+	out.Messages, system = NativeMessagesToMessages(in.Input)
+
+	if in.Instructions != nil && *in.Instructions != "" {
+		system = append([]SystemContent{{Text: *in.Instructions}}, system...)
+	}
+
+	// Titan text models reject system prompts, the instructions lead the first user message instead
+	if len(system) > 0 && !SupportsSystemPrompt(in.Model) {
+		out.Messages = prependSystem(out.Messages, system)
+		system = nil
+	}
+	out.System = system
+
+	if in.MaxOutputTokens != nil || in.Temperature != nil || in.TopP != nil {
+		out.InferenceConfig = &InferenceConfig{
+			MaxTokens:   in.MaxOutputTokens,
+			Temperature: in.Temperature,
+			TopP:        in.TopP,
+		}
+	}
+
+	out.ToolConfig = NativeToolsToToolConfig(in.Tools, in.ToolChoice)
+
+	// The extra params are the fields of the model not covered by the Converse API
+	if len(in.ExtraParams) > 0 {
+		out.AdditionalModelRequestFields = maps.Clone(in.ExtraParams)
+	}
+
+	if IsClaudeModel(in.Model) {
+		maxTokens := defaultThinkingMaxTokens
+		if in.MaxOutputTokens != nil {
+			maxTokens = *in.MaxOutputTokens
+		}
+
+		if thinking := anthropic_responses.NativeReasoningParamToThinkingParam(in.Reasoning, maxTokens); thinking != nil {
+			if out.InferenceConfig == nil {
+				out.InferenceConfig = &InferenceConfig{}
+			}
+			out.InferenceConfig.MaxTokens = utils.Ptr(maxTokens)
+
+			if out.InferenceConfig.Temperature != nil || out.InferenceConfig.TopP != nil {
+				slog.Warn("temperature and top p are not supported with thinking for claude models")
+				out.InferenceConfig.Temperature = nil
+				out.InferenceConfig.TopP = nil
+			}
+
+			if out.AdditionalModelRequestFields == nil {
+				out.AdditionalModelRequestFields = map[string]any{}
+			}
+			out.AdditionalModelRequestFields["thinking"] = thinking
+		}
+	}
+
+	return out
+}
+
+// IsClaudeModel reports whether the model, or the inference profile, is an Anthropic model
+func IsClaudeModel(model string) bool {
+	return strings.Contains(model, "anthropic.claude")
+}
+
+// SupportsSystemPrompt reports whether the model accepts system prompts, the Titan text models don't
+func SupportsSystemPrompt(model string) bool {
+	return !strings.Contains(model, "amazon.titan-text")
+}
+
+func prependSystem(messages []Message, system []SystemContent) []Message {
+	var texts []string
+	for _, s := range system {
+		texts = append(texts, s.Text)
+	}
+	block := ContentBlock{Text: utils.Ptr(strings.Join(texts, "\n\n"))}
+
+	if len(messages) > 0 && messages[0].Role == RoleUser {
+		messages[0].Content = append([]ContentBlock{block}, messages[0].Content...)
+		return messages
+	}
+
+	return append([]Message{{Role: RoleUser, Content: []ContentBlock{block}}}, messages...)
+}
+
+// NativeToolsToToolConfig converts the function tools, the other tools aren't supported by the Converse API. The
+// "none" tool choice isn't supported either, the model then chooses.
+func NativeToolsToToolConfig(nativeTools []responses.ToolUnion, choice *responses.ToolChoiceUnion) *ToolConfig {
+	var allowed []string
+	if choice != nil && choice.OfAllowedTools != nil {
+		allowed = choice.OfAllowedTools.FunctionNames()
+	}
+
+	var tools []Tool
+	for _, nativeTool := range nativeTools {
+		if nativeTool.OfFunction == nil {
+			slog.Warn("only function tools are supported for bedrock models")
+			continue
+		}
+
+		if allowed != nil && !slices.Contains(allowed, nativeTool.OfFunction.Name) {
+			continue
+		}
+
+		schema := nativeTool.OfFunction.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+
+		tools = append(tools, Tool{
+			ToolSpec: &ToolSpec{
+				Name:        nativeTool.OfFunction.Name,
+				Description: nativeTool.OfFunction.Description,
+				InputSchema: ToolInputSchema{JSON: schema},
+			},
+		})
+	}
+
+	if len(tools) == 0 {
+		return nil
+	}
+
+	out := &ToolConfig{Tools: tools}
+
+	switch {
+	case choice == nil:
+	case choice.OfFunction != nil:
+		out.ToolChoice = &ToolChoice{Tool: &SpecificToolName{Name: choice.OfFunction.Name}}
+	case choice.OfAllowedTools != nil && choice.OfAllowedTools.Mode == responses.ToolChoiceModeRequired:
+		out.ToolChoice = &ToolChoice{Any: &struct{}{}}
+	case choice.OfMode != nil && *choice.OfMode == responses.ToolChoiceModeRequired:
+		out.ToolChoice = &ToolChoice{Any: &struct{}{}}
+	case choice.OfMode != nil && *choice.OfMode == responses.ToolChoiceModeNone:
+		slog.Warn("tool choice none is not supported for bedrock models")
+	}
+
+	return out
+}
+
+func NativeRoleToRole(in constants.Role) Role {
+	if in == constants.RoleAssistant {
+		return RoleAssistant
+	}
+
+	return RoleUser
+}
+
+// NativeMessagesToMessages converts the input to the conversation, and the system and developer messages to system
+// prompts. The consecutive messages of a role are merged, the Converse API requires the roles to alternate.
+func NativeMessagesToMessages(in responses.InputUnion) ([]Message, []SystemContent) {
+	if in.OfString != nil {
+		return []Message{{Role: RoleUser, Content: []ContentBlock{{Text: in.OfString}}}}, nil
+	}
+
+	var messages []Message
+	var system []SystemContent
+
+	add := func(role Role, blocks ...ContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, Message{Role: role, Content: blocks})
+	}
+
+	for _, nativeMessage := range in.OfInputMessageList {
+		switch {
+		case nativeMessage.OfEasyInput != nil:
+			var blocks []ContentBlock
+			if nativeMessage.OfEasyInput.Content.OfString != nil {
+				blocks = append(blocks, ContentBlock{Text: nativeMessage.OfEasyInput.Content.OfString})
+			}
+			blocks = append(blocks, NativeContentToContentBlocks(nativeMessage.OfEasyInput.Content.OfInputMessageList)...)
+
+			if isSystemRole(nativeMessage.OfEasyInput.Role) {
+				system = append(system, contentBlocksToSystem(blocks)...)
+				continue
+			}
+			add(NativeRoleToRole(nativeMessage.OfEasyInput.Role), blocks...)
+
+		case nativeMessage.OfInputMessage != nil:
+			blocks := NativeContentToContentBlocks(nativeMessage.OfInputMessage.Content)
+
+			if isSystemRole(nativeMessage.OfInputMessage.Role) {
+				system = append(system, contentBlocksToSystem(blocks)...)
+				continue
+			}
+			add(NativeRoleToRole(nativeMessage.OfInputMessage.Role), blocks...)
+
+		case nativeMessage.OfOutputMessage != nil:
+			var blocks []ContentBlock
+			for _, content := range nativeMessage.OfOutputMessage.Content {
+				if content.OfOutputText != nil {
+					blocks = append(blocks, ContentBlock{Text: utils.Ptr(content.OfOutputText.Text)})
+				}
+			}
+			add(RoleAssistant, blocks...)
+
+		case nativeMessage.OfFunctionCall != nil:
+			args := map[string]any{}
+			if err := sonic.Unmarshal([]byte(nativeMessage.OfFunctionCall.Arguments), &args); err != nil {
+				slog.Warn("unable to unmarshal tool_use args - string into map[string]any")
+			}
+
+			add(RoleAssistant, ContentBlock{
+				ToolUse: &ToolUseBlock{
+					ToolUseID: nativeMessage.OfFunctionCall.CallID,
+					Name:      nativeMessage.OfFunctionCall.Name,
+					Input:     args,
+				},
+			})
+
+		case nativeMessage.OfFunctionCallOutput != nil:
+			add(RoleUser, ContentBlock{
+				ToolResult: NativeFunctionCallOutputToToolResult(nativeMessage.OfFunctionCallOutput),
+			})
+
+		case nativeMessage.OfReasoning != nil:
+			if block := NativeReasoningToContentBlock(nativeMessage.OfReasoning); block != nil {
+				add(RoleAssistant, *block)
+			}
+		}
+	}
+
+	return messages, system
+}
+
+func isSystemRole(role constants.Role) bool {
+	return role == constants.RoleSystem || role == constants.RoleDeveloper
+}
+
+func contentBlocksToSystem(blocks []ContentBlock) []SystemContent {
+	var system []SystemContent
+	for _, block := range blocks {
+		if block.Text != nil {
+			system = append(system, SystemContent{Text: *block.Text})
+		}
+	}
+	return system
+}
+
+func NativeContentToContentBlocks(in responses.InputContent) []ContentBlock {
+	var blocks []ContentBlock
+
+	for _, nativeContent := range in {
+		switch {
+		case nativeContent.OfInputText != nil:
+			blocks = append(blocks, ContentBlock{Text: utils.Ptr(nativeContent.OfInputText.Text)})
+		case nativeContent.OfOutputText != nil:
+			blocks = append(blocks, ContentBlock{Text: utils.Ptr(nativeContent.OfOutputText.Text)})
+		case nativeContent.OfInputImage != nil:
+			if image := NativeImageToImageBlock(nativeContent.OfInputImage); image != nil {
+				blocks = append(blocks, ContentBlock{Image: image})
+			}
+		}
+	}
+
+	return blocks
+}
+
+// NativeImageToImageBlock converts the images of data URLs and S3 URIs, the Converse API doesn't download images
+func NativeImageToImageBlock(in *responses.InputImageContent) *ImageBlock {
+	if in.ImageURL == nil {
+		slog.Warn("images by file ID are not supported for bedrock models")
+		return nil
+	}
+	url := *in.ImageURL
+
+	if strings.HasPrefix(url, "s3://") {
+		return &ImageBlock{
+			Format: imageFormat(strings.ToLower(strings.TrimPrefix(path.Ext(url), "."))),
+			Source: ImageSource{S3Location: &S3Location{URI: url}},
+		}
+	}
+
+	if strings.HasPrefix(url, "data:") {
+		mediaType, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+		if !ok {
+			slog.Warn("unable to decode image data URL for bedrock models")
+			return nil
+		}
+
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			slog.Warn("unable to decode image data URL for bedrock models", slog.Any("error", err))
+			return nil
+		}
+
+		return &ImageBlock{
+			Format: imageFormat(strings.TrimPrefix(mediaType, "image/")),
+			Source: ImageSource{Bytes: b},
+		}
+	}
+
+	slog.Warn("only data URLs and S3 URIs of images are supported for bedrock models")
+	return nil
+}
+
+func imageFormat(format string) string {
+	if format == "jpg" {
+		return "jpeg"
+	}
+	return format
+}
+
+func NativeFunctionCallOutputToToolResult(in *responses.FunctionCallOutputMessage) *ToolResultBlock {
+	out := &ToolResultBlock{
+		ToolUseID: in.CallID,
+		Content:   []ToolResultContent{},
+	}
+
+	if in.Output.OfString != nil {
+		out.Content = append(out.Content, ToolResultContent{Text: in.Output.OfString})
+	}
+
+	for _, nativeOutput := range in.Output.OfList {
+		switch {
+		case nativeOutput.OfInputText != nil:
+			out.Content = append(out.Content, ToolResultContent{Text: utils.Ptr(nativeOutput.OfInputText.Text)})
+		case nativeOutput.OfInputImage != nil:
+			if image := NativeImageToImageBlock(nativeOutput.OfInputImage); image != nil {
+				out.Content = append(out.Content, ToolResultContent{Image: image})
+			}
+		}
+	}
+
+	return out
+}
+
+// NativeReasoningToContentBlock converts the reasoning with a signature, the reasoning without summary is redacted
+// reasoning with its base64 encoded content as encrypted content
+func NativeReasoningToContentBlock(in *responses.ReasoningMessage) *ContentBlock {
+	if in.EncryptedContent == nil || *in.EncryptedContent == "" {
+		return nil
+	}
+
+	if in.Summary == nil {
+		redacted, err := base64.StdEncoding.DecodeString(*in.EncryptedContent)
+		if err != nil {
+			slog.Warn("unable to decode redacted reasoning for bedrock models", slog.Any("error", err))
+			return nil
+		}
+
+		return &ContentBlock{ReasoningContent: &ReasoningContentBlock{RedactedContent: redacted}}
+	}
+
+	text := ""
+	for _, summary := range in.Summary {
+		text += summary.Text
+	}
+
+	return &ContentBlock{
+		ReasoningContent: &ReasoningContentBlock{
+			ReasoningText: &ReasoningText{
+				Text:      text,
+				Signature: in.EncryptedContent,
+			},
+		},
+	}
+}
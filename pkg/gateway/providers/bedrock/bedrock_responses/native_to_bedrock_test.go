@@ -0,0 +1,107 @@
+package bedrock_responses
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nativeRequest(t *testing.T, data string) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(data), &req))
+	return &req
+}
+
+func bedrockJSON(t *testing.T, req *Request) string {
+	data, err := sonic.Marshal(req)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestNativeRequestToRequest_Messages(t *testing.T) {
+	req := nativeRequest(t, `{
+		"model": "anthropic.claude-3-5-haiku-20241022-v1:0",
+		"instructions": "Be brief",
+		"input": [
+			{"role": "developer", "content": "Answer in French"},
+			{"role": "user", "content": "Weather in Paris?"},
+			{"type": "function_call", "call_id": "tooluse_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+			{"type": "function_call_output", "call_id": "tooluse_1", "output": "Sunny"},
+			{"role": "user", "content": "And tomorrow?"}
+		],
+		"max_output_tokens": 512
+	}`)
+
+	assert.JSONEq(t, `{
+		"messages": [
+			{"role": "user", "content": [{"text": "Weather in Paris?"}]},
+			{"role": "assistant", "content": [{"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather", "input": {"city": "Paris"}}}]},
+			{"role": "user", "content": [
+				{"toolResult": {"toolUseId": "tooluse_1", "content": [{"text": "Sunny"}]}},
+				{"text": "And tomorrow?"}
+			]}
+		],
+		"system": [{"text": "Be brief"}, {"text": "Answer in French"}],
+		"inferenceConfig": {"maxTokens": 512}
+	}`, bedrockJSON(t, NativeRequestToRequest(req)))
+}
+
+func TestNativeRequestToRequest_TitanSystemPrompt(t *testing.T) {
+	req := nativeRequest(t, `{
+		"model": "amazon.titan-text-premier-v1:0",
+		"instructions": "Be brief",
+		"input": "Hello"
+	}`)
+
+	out := NativeRequestToRequest(req)
+
+	assert.Empty(t, out.System)
+	require.Len(t, out.Messages, 1)
+	require.Len(t, out.Messages[0].Content, 2)
+	assert.Equal(t, "Be brief", *out.Messages[0].Content[0].Text)
+	assert.Equal(t, "Hello", *out.Messages[0].Content[1].Text)
+}
+
+func TestNativeRequestToRequest_Tools(t *testing.T) {
+	req := nativeRequest(t, `{
+		"model": "meta.llama3-1-70b-instruct-v1:0",
+		"input": "Weather in Paris?",
+		"tools": [
+			{"type": "function", "name": "get_weather", "description": "Weather of a city", "parameters": {"type": "object"}},
+			{"type": "function", "name": "get_time", "parameters": {"type": "object"}}
+		],
+		"tool_choice": {"type": "function", "name": "get_weather"}
+	}`)
+
+	toolConfig, err := sonic.Marshal(NativeRequestToRequest(req).ToolConfig)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"tools": [
+			{"toolSpec": {"name": "get_weather", "description": "Weather of a city", "inputSchema": {"json": {"type": "object"}}}},
+			{"toolSpec": {"name": "get_time", "inputSchema": {"json": {"type": "object"}}}}
+		],
+		"toolChoice": {"tool": {"name": "get_weather"}}
+	}`, string(toolConfig))
+}
+
+func TestNativeRequestToRequest_ClaudeThinking(t *testing.T) {
+	req := nativeRequest(t, `{
+		"model": "us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"input": "Think about it",
+		"temperature": 0.5,
+		"reasoning": {"effort": "low"},
+		"extra_params": {"top_k": 20}
+	}`)
+
+	out := NativeRequestToRequest(req)
+
+	require.NotNil(t, out.InferenceConfig)
+	assert.Nil(t, out.InferenceConfig.Temperature)
+	assert.Equal(t, 4096, *out.InferenceConfig.MaxTokens)
+	assert.Equal(t, 20, int(out.AdditionalModelRequestFields["top_k"].(float64)))
+	assert.Contains(t, out.AdditionalModelRequestFields, "thinking")
+}
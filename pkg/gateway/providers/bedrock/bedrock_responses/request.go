@@ -0,0 +1,122 @@
+package bedrock_responses
+
+// Request is the body of the Converse and ConverseStream APIs, the model is in the path of the request, see
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_Converse.html
+type Request struct {
+	Messages        []Message         `json:"messages"`
+	System          []SystemContent   `json:"system,omitempty"`
+	InferenceConfig *InferenceConfig  `json:"inferenceConfig,omitempty"`
+	ToolConfig      *ToolConfig       `json:"toolConfig,omitempty"`
+	RequestMetadata map[string]string `json:"requestMetadata,omitempty"`
+
+	// AdditionalModelRequestFields are the parameters of the model not covered by the Converse API, e.g. the
+	// thinking of the Claude models
+	AdditionalModelRequestFields map[string]any `json:"additionalModelRequestFields,omitempty"`
+}
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+type Message struct {
+	Role    Role           `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+type SystemContent struct {
+	Text string `json:"text"`
+}
+
+// ContentBlock is a union, a single field is set
+type ContentBlock struct {
+	Text             *string                `json:"text,omitempty"`
+	Image            *ImageBlock            `json:"image,omitempty"`
+	ToolUse          *ToolUseBlock          `json:"toolUse,omitempty"`
+	ToolResult       *ToolResultBlock       `json:"toolResult,omitempty"`
+	ReasoningContent *ReasoningContentBlock `json:"reasoningContent,omitempty"`
+}
+
+type ImageBlock struct {
+	Format string      `json:"format"` // "png", "jpeg", "gif", "webp"
+	Source ImageSource `json:"source"`
+}
+
+// ImageSource is a union, a single field is set
+type ImageSource struct {
+	Bytes      []byte      `json:"bytes,omitempty"`
+	S3Location *S3Location `json:"s3Location,omitempty"`
+}
+
+type S3Location struct {
+	URI string `json:"uri"`
+}
+
+type ToolUseBlock struct {
+	ToolUseID string `json:"toolUseId"`
+	Name      string `json:"name"`
+	Input     any    `json:"input"`
+}
+
+type ToolResultBlock struct {
+	ToolUseID string              `json:"toolUseId"`
+	Content   []ToolResultContent `json:"content"`
+	Status    string              `json:"status,omitempty"` // "success", "error"
+}
+
+// ToolResultContent is a union, a single field is set
+type ToolResultContent struct {
+	Text  *string     `json:"text,omitempty"`
+	Image *ImageBlock `json:"image,omitempty"`
+}
+
+// ReasoningContentBlock is a union, a single field is set
+type ReasoningContentBlock struct {
+	ReasoningText   *ReasoningText `json:"reasoningText,omitempty"`
+	RedactedContent []byte         `json:"redactedContent,omitempty"`
+}
+
+type ReasoningText struct {
+	Text      string  `json:"text"`
+	Signature *string `json:"signature,omitempty"`
+}
+
+type InferenceConfig struct {
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type ToolConfig struct {
+	Tools      []Tool      `json:"tools"`
+	ToolChoice *ToolChoice `json:"toolChoice,omitempty"`
+}
+
+// Tool is a union, only the tool specs are supported
+type Tool struct {
+	ToolSpec *ToolSpec `json:"toolSpec,omitempty"`
+}
+
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description *string         `json:"description,omitempty"`
+	InputSchema ToolInputSchema `json:"inputSchema"`
+}
+
+type ToolInputSchema struct {
+	JSON map[string]any `json:"json"`
+}
+
+// ToolChoice is a union, a single field is set
+type ToolChoice struct {
+	Auto *struct{}         `json:"auto,omitempty"`
+	Any  *struct{}         `json:"any,omitempty"`
+	Tool *SpecificToolName `json:"tool,omitempty"`
+}
+
+type SpecificToolName struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,30 @@
+package bedrock_responses
+
+// Response is the response of the Converse API
+type Response struct {
+	Output     Output  `json:"output"`
+	StopReason string  `json:"stopReason"` // "end_turn", "tool_use", "max_tokens", "stop_sequence", "guardrail_intervened", "content_filtered"
+	Usage      Usage   `json:"usage"`
+	Metrics    Metrics `json:"metrics"`
+}
+
+type Output struct {
+	Message *Message `json:"message,omitempty"`
+}
+
+type Usage struct {
+	InputTokens           int `json:"inputTokens"`
+	OutputTokens          int `json:"outputTokens"`
+	TotalTokens           int `json:"totalTokens"`
+	CacheReadInputTokens  int `json:"cacheReadInputTokens"`
+	CacheWriteInputTokens int `json:"cacheWriteInputTokens"`
+}
+
+type Metrics struct {
+	LatencyMs int `json:"latencyMs"`
+}
+
+// ErrorResponse is the body of the failed requests, the type of the error is in the X-Amzn-ErrorType header
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
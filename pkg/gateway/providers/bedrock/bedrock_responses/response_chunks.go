@@ -0,0 +1,115 @@
+package bedrock_responses
+
+import "github.com/bytedance/sonic"
+
+// StreamEvent is an event of the ConverseStream API, a single field is set. The events are the messages of an
+// application/vnd.amazon.eventstream stream, named by their :event-type header.
+type StreamEvent struct {
+	MessageStart      *MessageStartEvent
+	ContentBlockStart *ContentBlockStartEvent
+	ContentBlockDelta *ContentBlockDeltaEvent
+	ContentBlockStop  *ContentBlockStopEvent
+	MessageStop       *MessageStopEvent
+	Metadata          *MetadataEvent
+}
+
+// Event types, the values of the :event-type header
+const (
+	EventTypeMessageStart      = "messageStart"
+	EventTypeContentBlockStart = "contentBlockStart"
+	EventTypeContentBlockDelta = "contentBlockDelta"
+	EventTypeContentBlockStop  = "contentBlockStop"
+	EventTypeMessageStop       = "messageStop"
+	EventTypeMetadata          = "metadata"
+)
+
+type MessageStartEvent struct {
+	Role Role `json:"role"`
+}
+
+// ContentBlockStartEvent starts the tool use blocks, the other blocks start with their first delta
+type ContentBlockStartEvent struct {
+	ContentBlockIndex int               `json:"contentBlockIndex"`
+	Start             ContentBlockStart `json:"start"`
+}
+
+type ContentBlockStart struct {
+	ToolUse *ToolUseBlockStart `json:"toolUse,omitempty"`
+}
+
+type ToolUseBlockStart struct {
+	ToolUseID string `json:"toolUseId"`
+	Name      string `json:"name"`
+}
+
+type ContentBlockDeltaEvent struct {
+	ContentBlockIndex int               `json:"contentBlockIndex"`
+	Delta             ContentBlockDelta `json:"delta"`
+}
+
+// ContentBlockDelta is a union, a single field is set
+type ContentBlockDelta struct {
+	Text             *string                `json:"text,omitempty"`
+	ToolUse          *ToolUseBlockDelta     `json:"toolUse,omitempty"`
+	ReasoningContent *ReasoningContentDelta `json:"reasoningContent,omitempty"`
+}
+
+type ToolUseBlockDelta struct {
+	Input string `json:"input"` // Partial JSON of the input
+}
+
+// ReasoningContentDelta is a union, a single field is set
+type ReasoningContentDelta struct {
+	Text            *string `json:"text,omitempty"`
+	Signature       *string `json:"signature,omitempty"`
+	RedactedContent []byte  `json:"redactedContent,omitempty"`
+}
+
+type ContentBlockStopEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+}
+
+type MessageStopEvent struct {
+	StopReason string `json:"stopReason"`
+}
+
+// MetadataEvent is the last event of the stream
+type MetadataEvent struct {
+	Usage   Usage   `json:"usage"`
+	Metrics Metrics `json:"metrics"`
+}
+
+// UnmarshalStreamEvent decodes the payload of an event by its type, nil for the events of unknown types
+func UnmarshalStreamEvent(eventType string, payload []byte) (*StreamEvent, error) {
+	event := &StreamEvent{}
+
+	var target any
+	switch eventType {
+	case EventTypeMessageStart:
+		event.MessageStart = &MessageStartEvent{}
+		target = event.MessageStart
+	case EventTypeContentBlockStart:
+		event.ContentBlockStart = &ContentBlockStartEvent{}
+		target = event.ContentBlockStart
+	case EventTypeContentBlockDelta:
+		event.ContentBlockDelta = &ContentBlockDeltaEvent{}
+		target = event.ContentBlockDelta
+	case EventTypeContentBlockStop:
+		event.ContentBlockStop = &ContentBlockStopEvent{}
+		target = event.ContentBlockStop
+	case EventTypeMessageStop:
+		event.MessageStop = &MessageStopEvent{}
+		target = event.MessageStop
+	case EventTypeMetadata:
+		event.Metadata = &MetadataEvent{}
+		target = event.Metadata
+	default:
+		return nil, nil
+	}
+
+	if err := sonic.Unmarshal(payload, target); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,241 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base"
+	"github.com/curaious/uno/pkg/gateway/providers/bedrock/bedrock_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// defaultRegion is the region of the requests without region nor base URL
+const defaultRegion = "us-east-1"
+
+type ClientOptions struct {
+	// https://bedrock-runtime.us-east-1.amazonaws.com
+	BaseURL string
+
+	// Region the requests are signed for, the region of the base URL by default
+	Region string
+
+	// ApiKey is either "<access key id>:<secret access key>[:<session token>]", signing the requests with SigV4, or
+	// a Bedrock API key
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client runs the models of Amazon Bedrock, e.g. Claude, Llama and Titan, through the Converse API
+type Client struct {
+	*base.BaseProvider
+	opts *ClientOptions
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.Region == "" {
+		opts.Region = RegionOfEndpoint(opts.BaseURL)
+	}
+	if opts.Region == "" {
+		opts.Region = defaultRegion
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://bedrock-runtime." + opts.Region + ".amazonaws.com"
+	}
+
+	return &Client{
+		opts: opts,
+	}
+}
+
+// RegionOfEndpoint returns the region of a Bedrock Runtime endpoint, e.g. us-west-2 for
+// https://bedrock-runtime.us-west-2.amazonaws.com, or an empty string for the other URLs
+func RegionOfEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+
+	// The VPC endpoints are named vpce-<id>.bedrock-runtime.<region>.vpce.amazonaws.com
+	labels := strings.Split(u.Hostname(), ".")
+	for i, label := range labels[:max(len(labels)-1, 0)] {
+		if strings.HasPrefix(label, "bedrock-runtime") {
+			return labels[i+1]
+		}
+	}
+
+	return ""
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	req, err := c.newRequest(ctx, inp, "converse")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	var bedrockResponse *bedrock_responses.Response
+	err = utils.DecodeJSON(res.Body, &bedrockResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return bedrockResponse.ToNativeResponse(res.Header.Get("X-Amzn-Requestid"), inp.Model), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	req, err := c.newRequest(ctx, inp, "converse-stream")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.amazon.eventstream")
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+
+		converter := bedrock_responses.StreamEventToNativeResponseChunkConverter{
+			ID:    res.Header.Get("X-Amzn-Requestid"),
+			Model: inp.Model,
+		}
+
+		for {
+			msg, err := readEventStreamMessage(res.Body)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					slog.WarnContext(ctx, "unable to read bedrock event stream", slog.Any("error", err))
+					for _, nativeChunk := range converter.Fail(err.Error()) {
+						out <- nativeChunk
+					}
+				}
+				break
+			}
+
+			switch msg.headers[":message-type"] {
+			case "event":
+				event, err := bedrock_responses.UnmarshalStreamEvent(msg.headers[":event-type"], msg.payload)
+				if err != nil {
+					slog.WarnContext(ctx, "unable to unmarshal bedrock stream event", slog.String("data", string(msg.payload)), slog.Any("error", err))
+					continue
+				}
+
+				for _, nativeChunk := range converter.StreamEventToNativeResponseChunk(event) {
+					out <- nativeChunk
+				}
+
+			case "exception", "error":
+				message := exceptionMessage(msg)
+				slog.WarnContext(ctx, "bedrock stream failed", slog.String("error", message))
+				for _, nativeChunk := range converter.Fail(message) {
+					out <- nativeChunk
+				}
+				return
+			}
+		}
+
+		for _, nativeChunk := range converter.Close() {
+			out <- nativeChunk
+		}
+	}()
+
+	return out, nil
+}
+
+// newRequest creates the request of the operation of the model, signed with the credentials of the API key
+func (c *Client) newRequest(ctx context.Context, inp *responses.Request, operation string) (*http.Request, error) {
+	payload, err := sonic.Marshal(bedrock_responses.NativeRequestToRequest(inp))
+	if err != nil {
+		return nil, err
+	}
+
+	// The model may be an ARN, with colons and slashes
+	endpoint := c.opts.BaseURL + "/model/" + uriEncode(inp.Model) + "/" + operation
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if creds, ok := ParseCredentials(c.opts.ApiKey); ok {
+		signRequest(req, payload, creds, c.opts.Region, signingService, time.Now())
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.opts.ApiKey)
+	}
+
+	return req, nil
+}
+
+// responseError returns the error of a failed request, prefixed by its type, e.g. ValidationException
+func responseError(res *http.Response) error {
+	var errResp bedrock_responses.ErrorResponse
+	if err := utils.DecodeJSON(res.Body, &errResp); err != nil || errResp.Message == "" {
+		errResp.Message = res.Status
+	}
+
+	errorType, _, _ := strings.Cut(res.Header.Get("X-Amzn-Errortype"), ":")
+	if errorType == "" {
+		return errors.New(errResp.Message)
+	}
+
+	return fmt.Errorf("%s: %s", errorType, errResp.Message)
+}
+
+// exceptionMessage returns the error of an exception or error message of a stream
+func exceptionMessage(msg *eventStreamMessage) string {
+	if msg.headers[":message-type"] == "error" {
+		return msg.headers[":error-code"] + ": " + msg.headers[":error-message"]
+	}
+
+	var errResp bedrock_responses.ErrorResponse
+	_ = sonic.Unmarshal(msg.payload, &errResp)
+
+	return msg.headers[":exception-type"] + ": " + errResp.Message
+}
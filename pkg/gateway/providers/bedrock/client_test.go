@@ -0,0 +1,246 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = "anthropic.claude-sonnet-4-20250514-v1:0"
+
+// encodeEventStreamMessage encodes an event of the stream with string headers
+func encodeEventStreamMessage(headers map[string]string, payload string) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(eventStreamHeaderString)
+		_ = binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+
+	total := 12 + h.Len() + len(payload) + 4
+	msg := make([]byte, 0, total)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(total))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(h.Len()))
+	msg = binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+	msg = append(msg, h.Bytes()...)
+	msg = append(msg, payload...)
+	return binary.BigEndian.AppendUint32(msg, crc32.ChecksumIEEE(msg))
+}
+
+func event(eventType, payload string) []byte {
+	return encodeEventStreamMessage(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, payload)
+}
+
+func textRequest() *responses.Request {
+	return &responses.Request{
+		Model:        testModel,
+		Instructions: utils.Ptr("Be brief"),
+		Input:        responses.InputUnion{OfString: utils.Ptr("What's the weather in Paris?")},
+		Tools: []responses.ToolUnion{{OfFunction: &responses.FunctionTool{
+			Name:       "get_weather",
+			Parameters: map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		}}},
+	}
+}
+
+func TestClient_NewResponses_SignsRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/anthropic.claude-sonnet-4-20250514-v1%3A0/converse", r.URL.EscapedPath())
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/bedrock/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature=")
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{
+			"messages": [{"role": "user", "content": [{"text": "What's the weather in Paris?"}]}],
+			"system": [{"text": "Be brief"}],
+			"toolConfig": {"tools": [{"toolSpec": {"name": "get_weather", "inputSchema": {"json": {"type": "object", "properties": {"city": {"type": "string"}}}}}}]}
+		}`, string(body))
+
+		w.Header().Set("X-Amzn-RequestId", "req-1")
+		_, _ = w.Write([]byte(`{
+			"output": {"message": {"role": "assistant", "content": [
+				{"text": "Let me check."},
+				{"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather", "input": {"city": "Paris"}}}
+			]}},
+			"stopReason": "tool_use",
+			"usage": {"inputTokens": 20, "outputTokens": 10, "totalTokens": 30}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, Region: "eu-west-1", ApiKey: "AKIDEXAMPLE:secret:session"})
+	out, err := client.NewResponses(context.Background(), textRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, "req-1", out.ID)
+	require.Len(t, out.Output, 2)
+	assert.Equal(t, "Let me check.", out.Output[0].OfOutputMessage.Content[0].OfOutputText.Text)
+	assert.Equal(t, "tooluse_1", out.Output[1].OfFunctionCall.CallID)
+	assert.JSONEq(t, `{"city": "Paris"}`, out.Output[1].OfFunctionCall.Arguments)
+	assert.Equal(t, 30, out.Usage.TotalTokens)
+}
+
+func TestClient_NewResponses_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer bedrock-api-key", r.Header.Get("Authorization"))
+
+		w.Header().Set("X-Amzn-ErrorType", "ValidationException:http://internal.amazon.com/coral/com.amazon.bedrock/")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"message": "The model ID is invalid"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "bedrock-api-key"})
+	_, err := client.NewResponses(context.Background(), textRequest())
+	assert.EqualError(t, err, "ValidationException: The model ID is invalid")
+}
+
+func TestClient_NewStreamingResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/anthropic.claude-sonnet-4-20250514-v1%3A0/converse-stream", r.URL.EscapedPath())
+
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Header().Set("X-Amzn-RequestId", "req-2")
+		for _, msg := range [][]byte{
+			event("messageStart", `{"role": "assistant"}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 0, "delta": {"reasoningContent": {"text": "The user wants the weather."}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 0, "delta": {"reasoningContent": {"signature": "sig"}}}`),
+			event("contentBlockStop", `{"contentBlockIndex": 0}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 1, "delta": {"text": "Let me "}}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 1, "delta": {"text": "check."}}`),
+			event("contentBlockStop", `{"contentBlockIndex": 1}`),
+			event("contentBlockStart", `{"contentBlockIndex": 2, "start": {"toolUse": {"toolUseId": "tooluse_1", "name": "get_weather"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 2, "delta": {"toolUse": {"input": "{\"city\": "}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex": 2, "delta": {"toolUse": {"input": "\"Paris\"}"}}}`),
+			event("contentBlockStop", `{"contentBlockIndex": 2}`),
+			event("messageStop", `{"stopReason": "tool_use"}`),
+			event("metadata", `{"usage": {"inputTokens": 20, "outputTokens": 15, "totalTokens": 35}, "metrics": {"latencyMs": 300}}`),
+		} {
+			_, _ = w.Write(msg)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "AKIDEXAMPLE:secret"})
+	stream, err := client.NewStreamingResponses(context.Background(), textRequest())
+	require.NoError(t, err)
+
+	var chunkTypes []string
+	var completed *responses.ResponseChunk
+	for chunk := range stream {
+		chunkTypes = append(chunkTypes, chunk.ChunkType())
+		if chunk.OfResponseCompleted != nil {
+			completed = chunk
+		}
+	}
+
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.reasoning_summary_part.added",
+		"response.reasoning_summary_text.delta",
+		"response.reasoning_summary_text.delta",
+		"response.reasoning_summary_text.done",
+		"response.reasoning_summary_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, chunkTypes)
+
+	require.NotNil(t, completed)
+	response := completed.OfResponseCompleted.Response
+	assert.Equal(t, "req-2", response.Id)
+	assert.Equal(t, 35, response.Usage.TotalTokens)
+
+	output := response.Output
+	require.Len(t, output, 3)
+	assert.Equal(t, "The user wants the weather.", output[0].OfReasoning.Summary[0].Text)
+	assert.Equal(t, "sig", *output[0].OfReasoning.EncryptedContent)
+	assert.Equal(t, "Let me check.", output[1].OfOutputMessage.Content[0].OfOutputText.Text)
+	assert.Equal(t, "get_weather", output[2].OfFunctionCall.Name)
+	assert.Equal(t, `{"city": "Paris"}`, output[2].OfFunctionCall.Arguments)
+}
+
+func TestClient_NewStreamingResponses_Exception(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(event("messageStart", `{"role": "assistant"}`))
+		_, _ = w.Write(encodeEventStreamMessage(map[string]string{
+			":message-type":   "exception",
+			":exception-type": "throttlingException",
+		}, `{"message": "Too many requests"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "AKIDEXAMPLE:secret"})
+	stream, err := client.NewStreamingResponses(context.Background(), textRequest())
+	require.NoError(t, err)
+
+	var last *responses.ResponseChunk
+	for chunk := range stream {
+		last = chunk
+	}
+
+	require.NotNil(t, last.OfResponseCompleted)
+	assert.Equal(t, "failed", last.OfResponseCompleted.Response.Status)
+	assert.Equal(t, map[string]any{"message": "throttlingException: Too many requests"}, last.OfResponseCompleted.Response.Error)
+}
+
+func TestSignRequest(t *testing.T) {
+	// get-vanilla of the AWS SigV4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	creds := &Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signRequest(req, nil, creds, "us-east-1", "service", now)
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestRegionOfEndpoint(t *testing.T) {
+	assert.Equal(t, "us-west-2", RegionOfEndpoint("https://bedrock-runtime.us-west-2.amazonaws.com"))
+	assert.Equal(t, "us-gov-west-1", RegionOfEndpoint("https://bedrock-runtime-fips.us-gov-west-1.amazonaws.com"))
+	assert.Equal(t, "eu-central-1", RegionOfEndpoint("https://vpce-0abc.bedrock-runtime.eu-central-1.vpce.amazonaws.com"))
+	assert.Equal(t, "", RegionOfEndpoint("https://proxy.example.com"))
+}
+
+func TestReadEventStreamMessage_Checksum(t *testing.T) {
+	msg := event("messageStop", `{"stopReason": "end_turn"}`)
+	msg[len(msg)-5] ^= 0xff
+
+	_, err := readEventStreamMessage(bytes.NewReader(msg))
+	assert.EqualError(t, err, "event stream message checksum mismatch")
+}
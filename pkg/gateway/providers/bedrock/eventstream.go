@@ -0,0 +1,121 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventStreamMessageSize bounds the messages read from a stream, the messages of ConverseStream are small
+const maxEventStreamMessageSize = 16 << 20
+
+// eventStreamMessage is a message of the application/vnd.amazon.eventstream encoding of the streams of AWS.
+// The message is framed by its total and headers lengths, each part being checked by a CRC32:
+//
+//	[total length][headers length][prelude crc][headers][payload][message crc]
+type eventStreamMessage struct {
+	headers map[string]string // The string headers, e.g. :message-type and :event-type
+	payload []byte
+}
+
+// readEventStreamMessage reads the next message of the stream, io.EOF at the end of the stream
+func readEventStreamMessage(r io.Reader) (*eventStreamMessage, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event stream prelude: %w", err)
+		}
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, errors.New("event stream prelude checksum mismatch")
+	}
+	if totalLength > maxEventStreamMessageSize || totalLength < 16 || headersLength > totalLength-16 {
+		return nil, fmt.Errorf("invalid event stream message length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude)
+	if _, err := io.ReadFull(r, message[12:]); err != nil {
+		return nil, fmt.Errorf("truncated event stream message: %w", err)
+	}
+
+	crc := binary.BigEndian.Uint32(message[totalLength-4:])
+	if crc32.ChecksumIEEE(message[:totalLength-4]) != crc {
+		return nil, errors.New("event stream message checksum mismatch")
+	}
+
+	headers, err := decodeEventStreamHeaders(message[12 : 12+headersLength])
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventStreamMessage{
+		headers: headers,
+		payload: message[12+headersLength : totalLength-4],
+	}, nil
+}
+
+// Sizes of the fixed size header values, by type
+var eventStreamHeaderSizes = map[byte]int{
+	0: 0,  // true
+	1: 0,  // false
+	2: 1,  // byte
+	3: 2,  // short
+	4: 4,  // integer
+	5: 8,  // long
+	8: 8,  // timestamp
+	9: 16, // uuid
+}
+
+const (
+	eventStreamHeaderBytes  byte = 6
+	eventStreamHeaderString byte = 7
+)
+
+// decodeEventStreamHeaders decodes the headers, the values of the headers other than strings are skipped
+func decodeEventStreamHeaders(b []byte) (map[string]string, error) {
+	headers := map[string]string{}
+
+	for len(b) > 0 {
+		nameLength := int(b[0])
+		if len(b) < 1+nameLength+1 {
+			return nil, errors.New("truncated event stream header")
+		}
+		name := string(b[1 : 1+nameLength])
+		valueType := b[1+nameLength]
+		b = b[1+nameLength+1:]
+
+		switch valueType {
+		case eventStreamHeaderBytes, eventStreamHeaderString:
+			if len(b) < 2 {
+				return nil, errors.New("truncated event stream header")
+			}
+			valueLength := int(binary.BigEndian.Uint16(b[0:2]))
+			if len(b) < 2+valueLength {
+				return nil, errors.New("truncated event stream header")
+			}
+			if valueType == eventStreamHeaderString {
+				headers[name] = string(b[2 : 2+valueLength])
+			}
+			b = b[2+valueLength:]
+
+		default:
+			size, ok := eventStreamHeaderSizes[valueType]
+			if !ok {
+				return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+			}
+			if len(b) < size {
+				return nil, errors.New("truncated event stream header")
+			}
+			b = b[size:]
+		}
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,174 @@
+package cohere
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base"
+	"github.com/curaious/uno/pkg/gateway/providers/cohere/cohere_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const DefaultBaseURL = "https://api.cohere.com/v2"
+
+type ClientOptions struct {
+	// https://api.cohere.com/v2
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client calls the v2 chat API of Cohere, serving the Command models with tool use and citations
+type Client struct {
+	*base.BaseProvider
+	opts *ClientOptions
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		opts: opts,
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	req, err := c.newRequest(ctx, inp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, responseError(res)
+	}
+
+	var cohereResponse *cohere_responses.Response
+	err = utils.DecodeJSON(res.Body, &cohereResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return cohereResponse.ToNativeResponse(inp.Model), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	req, err := c.newRequest(ctx, inp, true)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+
+		reader := bufio.NewReader(res.Body)
+		converter := cohere_responses.ResponseChunkToNativeResponseChunkConverter{Model: inp.Model}
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			cohereResponseChunk := &cohere_responses.ResponseChunk{}
+			if err = sonic.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), cohereResponseChunk); err != nil {
+				slog.WarnContext(ctx, "unable to unmarshal cohere response chunk", slog.String("data", line), slog.Any("error", err))
+				continue
+			}
+
+			for _, nativeChunk := range converter.ResponseChunkToNativeResponseChunk(cohereResponseChunk) {
+				out <- nativeChunk
+			}
+		}
+
+		// A stream cut before message-end has failed
+		message := "the stream ended before the end of the message"
+		if ctx.Err() != nil {
+			message = ctx.Err().Error()
+		}
+		for _, nativeChunk := range converter.Fail(message) {
+			out <- nativeChunk
+		}
+	}()
+
+	return out, nil
+}
+
+// newRequest creates the chat request of the responses request
+func (c *Client) newRequest(ctx context.Context, inp *responses.Request, stream bool) (*http.Request, error) {
+	cohereRequest := cohere_responses.NativeRequestToRequest(inp)
+	cohereRequest.Stream = stream
+
+	payload, err := sonic.Marshal(cohereRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/chat", bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.opts.ApiKey)
+	for k, v := range c.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	return req, nil
+}
+
+// responseError returns the error of a failed request
+func responseError(res *http.Response) error {
+	var errResp cohere_responses.ErrorResponse
+	if err := utils.DecodeJSON(res.Body, &errResp); err != nil || errResp.Message == "" {
+		return errors.New(res.Status)
+	}
+
+	return errors.New(errResp.Message)
+}
@@ -0,0 +1,217 @@
+package cohere
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responsesRequest(t *testing.T) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "command-a-03-2025",
+		"instructions": "Be brief",
+		"input": [
+			{"type": "message", "role": "user", "content": "Weather in Paris?"},
+			{"type": "function_call", "call_id": "get_weather_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+			{"type": "function_call_output", "call_id": "get_weather_1", "output": "{\"forecast\":\"Sunny\"}"}
+		],
+		"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}],
+		"tool_choice": "required"
+	}`), &req))
+	return &req
+}
+
+func TestClient_NewResponses_Citations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat", r.URL.Path)
+		assert.Equal(t, "Bearer cohere-key", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+		assert.Equal(t, "command-a-03-2025", payload["model"])
+		assert.Equal(t, "REQUIRED", payload["tool_choice"])
+
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 4)
+		assert.Equal(t, "system", messages[0].(map[string]any)["role"])
+		assistant := messages[2].(map[string]any)
+		assert.Equal(t, "get_weather_1", assistant["tool_calls"].([]any)[0].(map[string]any)["id"])
+		tool := messages[3].(map[string]any)
+		assert.Equal(t, "tool", tool["role"])
+		assert.Equal(t, "get_weather_1", tool["tool_call_id"])
+
+		_, _ = w.Write([]byte(`{
+			"id": "chat_1",
+			"finish_reason": "COMPLETE",
+			"message": {
+				"role": "assistant",
+				"content": [{"type": "text", "text": "It is sunny in Paris."}],
+				"citations": [{
+					"start": 9,
+					"end": 14,
+					"text": "sunny",
+					"type": "TEXT_CONTENT",
+					"sources": [{"type": "tool", "id": "get_weather_1:0", "tool_output": {"forecast": "Sunny", "title": "Paris forecast", "url": "https://weather.example.com/paris"}}]
+				}]
+			},
+			"usage": {"billed_units": {"input_tokens": 20, "output_tokens": 6}, "tokens": {"input_tokens": 500, "output_tokens": 10}}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "cohere-key"})
+	out, err := client.NewResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, "chat_1", out.ID)
+	assert.Equal(t, 26, out.Usage.TotalTokens)
+	require.Len(t, out.Output, 1)
+	require.NotNil(t, out.Output[0].OfOutputMessage)
+
+	text := out.Output[0].OfOutputMessage.Content[0].OfOutputText
+	assert.Equal(t, "It is sunny in Paris.", text.Text)
+	require.Len(t, text.Annotations, 1)
+	assert.Equal(t, "url_citation", text.Annotations[0].Type)
+	assert.Equal(t, "Paris forecast", text.Annotations[0].Title)
+	assert.Equal(t, "https://weather.example.com/paris", text.Annotations[0].URL)
+	assert.Equal(t, 9, text.Annotations[0].StartIndex)
+	assert.Equal(t, 14, text.Annotations[0].EndIndex)
+}
+
+func TestClient_NewStreamingResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+		assert.Equal(t, true, payload["stream"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`event: message-start
+data: {"id":"chat_2","type":"message-start","delta":{"message":{"role":"assistant"}}}
+
+event: tool-plan-delta
+data: {"type":"tool-plan-delta","delta":{"message":{"tool_plan":"I will check the weather."}}}
+
+event: tool-call-start
+data: {"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"get_weather_2","type":"function","function":{"name":"get_weather","arguments":""}}}}}
+
+event: tool-call-delta
+data: {"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"{\"city\":"}}}}}
+
+event: tool-call-delta
+data: {"type":"tool-call-delta","index":0,"delta":{"message":{"tool_calls":{"function":{"arguments":"\"Lyon\"}"}}}}}
+
+event: tool-call-end
+data: {"type":"tool-call-end","index":0}
+
+event: content-start
+data: {"type":"content-start","index":0,"delta":{"message":{"content":{"type":"text","text":""}}}}
+
+event: content-delta
+data: {"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"It is sunny."}}}}
+
+event: content-end
+data: {"type":"content-end","index":0}
+
+event: citation-start
+data: {"type":"citation-start","index":0,"delta":{"message":{"citations":{"start":6,"end":11,"text":"sunny","type":"TEXT_CONTENT","sources":[{"type":"tool","id":"get_weather_1:0","tool_output":{"forecast":"Sunny"}}]}}}}
+
+event: citation-end
+data: {"type":"citation-end","index":0}
+
+event: message-end
+data: {"type":"message-end","delta":{"finish_reason":"TOOL_CALL","usage":{"billed_units":{"input_tokens":20,"output_tokens":12}}}}
+
+`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "cohere-key"})
+	stream, err := client.NewStreamingResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	var chunks []*responses.ResponseChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+
+	require.NotEmpty(t, chunks)
+	assert.NotNil(t, chunks[0].OfResponseCreated)
+
+	reasoning, text, args := "", "", ""
+	var annotations []responses.Annotation
+	for _, chunk := range chunks {
+		switch {
+		case chunk.OfReasoningSummaryTextDelta != nil:
+			reasoning += chunk.OfReasoningSummaryTextDelta.Delta
+		case chunk.OfOutputTextDelta != nil:
+			text += chunk.OfOutputTextDelta.Delta
+		case chunk.OfFunctionCallArgumentsDelta != nil:
+			args += chunk.OfFunctionCallArgumentsDelta.Delta
+		case chunk.OfOutputTextAnnotationAdded != nil:
+			annotations = append(annotations, chunk.OfOutputTextAnnotationAdded.Annotation)
+		}
+	}
+	assert.Equal(t, "I will check the weather.", reasoning)
+	assert.Equal(t, "It is sunny.", text)
+	assert.Equal(t, `{"city":"Lyon"}`, args)
+	require.Len(t, annotations, 1)
+	assert.Equal(t, "sunny", annotations[0].Title)
+	assert.Equal(t, 6, annotations[0].StartIndex)
+
+	completed := chunks[len(chunks)-1].OfResponseCompleted
+	require.NotNil(t, completed)
+	assert.Equal(t, "completed", completed.Response.Status)
+	assert.Equal(t, 32, completed.Response.Usage.TotalTokens)
+	require.Len(t, completed.Response.Output, 3)
+	assert.NotNil(t, completed.Response.Output[0].OfReasoning)
+	require.NotNil(t, completed.Response.Output[1].OfFunctionCall)
+	assert.Equal(t, "get_weather_2", completed.Response.Output[1].OfFunctionCall.CallID)
+	require.NotNil(t, completed.Response.Output[2].OfOutputMessage)
+	assert.Len(t, completed.Response.Output[2].OfOutputMessage.Content[0].OfOutputText.Annotations, 1)
+}
+
+func TestClient_NewStreamingResponses_Cut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`data: {"id":"chat_3","type":"message-start","delta":{"message":{"role":"assistant"}}}
+
+data: {"type":"content-start","index":0,"delta":{"message":{"content":{"type":"text","text":"It is"}}}}
+
+`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "cohere-key"})
+	stream, err := client.NewStreamingResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	var last *responses.ResponseChunk
+	for chunk := range stream {
+		last = chunk
+	}
+
+	require.NotNil(t, last.OfResponseCompleted)
+	assert.Equal(t, "failed", last.OfResponseCompleted.Response.Status)
+}
+
+func TestClient_NewResponses_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"id": "err_1", "message": "invalid api token"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "wrong-key"})
+	_, err := client.NewResponses(context.Background(), responsesRequest(t))
+	require.Error(t, err)
+	assert.Equal(t, "invalid api token", err.Error())
+}
@@ -0,0 +1,727 @@
+package cohere_responses
+
+import (
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+func (in *Response) ToNativeResponse(model string) *responses.Response {
+	var output []responses.OutputMessageUnion
+
+	for _, content := range in.Message.Content {
+		if content.Type == ContentTypeThinking && content.Thinking != "" {
+			output = append(output, reasoningOutput(content.Thinking))
+		}
+	}
+
+	if in.Message.ToolPlan != "" {
+		output = append(output, reasoningOutput(in.Message.ToolPlan))
+	}
+
+	// Each text is a content of the message, with the citations of its span
+	message := responses.OutputContent{}
+	for i, content := range in.Message.Content {
+		if content.Type != ContentTypeText {
+			continue
+		}
+
+		text := &responses.OutputTextContent{Text: content.Text, Annotations: []responses.Annotation{}}
+		for _, citation := range in.Message.Citations {
+			if citation.Type != CitationTypePlan && citation.ContentIndex == i {
+				text.Annotations = append(text.Annotations, CitationToNativeAnnotation(citation))
+			}
+		}
+		message = append(message, responses.OutputContentUnion{OfOutputText: text})
+	}
+	if len(message) > 0 {
+		output = append(output, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:      responses.NewOutputItemMessageID(),
+				Role:    constants.RoleAssistant,
+				Content: message,
+			},
+		})
+	}
+
+	for _, toolCall := range in.Message.ToolCalls {
+		args := toolCall.Function.Arguments
+		if args == "" {
+			args = "{}"
+		}
+
+		output = append(output, responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        responses.NewOutputItemFunctionCallID(),
+				CallID:    toolCall.ID,
+				Name:      toolCall.Function.Name,
+				Arguments: args,
+			},
+		})
+	}
+
+	if output == nil {
+		output = []responses.OutputMessageUnion{}
+	}
+
+	return &responses.Response{
+		ID:     in.ID,
+		Model:  model,
+		Output: output,
+		Usage:  utils.Ptr(in.Usage.ToNative()),
+		Metadata: map[string]any{
+			"finish_reason": in.FinishReason,
+		},
+	}
+}
+
+func reasoningOutput(text string) responses.OutputMessageUnion {
+	return responses.OutputMessageUnion{
+		OfReasoning: &responses.ReasoningMessage{
+			ID:      responses.NewOutputItemReasoningID(),
+			Summary: []responses.SummaryTextContent{{Text: text}},
+		},
+	}
+}
+
+// CitationToNativeAnnotation converts a citation to a url_citation annotation of its span, titled after the first
+// source with a title. The citation is kept in the extra params, so that it is sent back to Cohere.
+func CitationToNativeAnnotation(citation Citation) responses.Annotation {
+	annotation := responses.Annotation{
+		Type:       "url_citation",
+		Title:      citation.Text,
+		StartIndex: citation.Start,
+		EndIndex:   citation.End,
+		ExtraParams: map[string]any{
+			"Cohere": citation,
+		},
+	}
+
+	for _, source := range citation.Sources {
+		fields := source.Document
+		if source.Type == "tool" {
+			fields = source.ToolOutput
+		}
+
+		if title, ok := fields["title"].(string); ok && title != "" {
+			annotation.Title = title
+			annotation.URL, _ = fields["url"].(string)
+			break
+		}
+	}
+
+	return annotation
+}
+
+// ToNative returns the billed tokens, or the tokens of the request if they aren't billed
+func (in *Usage) ToNative() responses.Usage {
+	if in == nil {
+		return responses.Usage{}
+	}
+
+	tokens := in.BilledUnits
+	if tokens == nil {
+		tokens = in.Tokens
+	}
+	if tokens == nil {
+		return responses.Usage{}
+	}
+
+	return responses.Usage{
+		InputTokens:  int(tokens.InputTokens),
+		OutputTokens: int(tokens.OutputTokens),
+		TotalTokens:  int(tokens.InputTokens + tokens.OutputTokens),
+	}
+}
+
+// =============================================================================
+// ResponseChunk to Native Conversion
+// =============================================================================
+
+type blockKind int
+
+const (
+	blockKindText blockKind = iota
+	blockKindToolCall
+	blockKindReasoning
+)
+
+// ResponseChunkToNativeResponseChunkConverter converts the events of a chat stream to native chunks. The texts, the
+// thinking, the tool plan and the tool calls are output items. The citations follow the text they cite, so a text
+// stays open until another item starts.
+type ResponseChunkToNativeResponseChunkConverter struct {
+	Model string
+
+	sequenceNumber int
+	outputIndex    int
+	started        bool
+	completed      bool
+
+	id    string
+	usage *Usage
+
+	// Current output item
+	block            *streamBlock
+	completedOutputs []responses.OutputMessageUnion
+}
+
+type streamBlock struct {
+	kind        blockKind
+	outputID    string
+	callID      string
+	name        string
+	text        string // Accumulated text, thinking or arguments
+	annotations []responses.Annotation
+}
+
+// nextSeqNum returns the next sequence number and increments the counter.
+func (c *ResponseChunkToNativeResponseChunkConverter) nextSeqNum() int {
+	n := c.sequenceNumber
+	c.sequenceNumber++
+	return n
+}
+
+// ResponseChunkToNativeResponseChunk converts a single event to zero or more native chunks.
+func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeResponseChunk(in *ResponseChunk) []*responses.ResponseChunk {
+	if in == nil || c.completed {
+		return nil
+	}
+
+	if in.Type == ChunkTypeMessageStart {
+		c.id = in.ID
+	}
+
+	result := c.start()
+
+	var message *ChunkMessage
+	if in.Delta != nil {
+		message = in.Delta.Message
+	}
+
+	switch in.Type {
+	case ChunkTypeContentStart:
+		if message == nil || message.Content == nil {
+			break
+		}
+		result = append(result, c.completeBlock()...)
+		if message.Content.Type == ContentTypeThinking {
+			result = append(result, c.startReasoning()...)
+			result = append(result, c.reasoningDelta(message.Content.Thinking)...)
+		} else {
+			result = append(result, c.startText()...)
+			result = append(result, c.textDelta(message.Content.Text)...)
+		}
+
+	case ChunkTypeContentDelta:
+		if message == nil || message.Content == nil || c.block == nil {
+			break
+		}
+		if c.block.kind == blockKindReasoning {
+			result = append(result, c.reasoningDelta(message.Content.Thinking)...)
+		} else if c.block.kind == blockKindText {
+			result = append(result, c.textDelta(message.Content.Text)...)
+		}
+
+	case ChunkTypeToolPlanDelta:
+		if message == nil {
+			break
+		}
+		if c.block == nil || c.block.kind != blockKindReasoning {
+			result = append(result, c.completeBlock()...)
+			result = append(result, c.startReasoning()...)
+		}
+		result = append(result, c.reasoningDelta(message.ToolPlan)...)
+
+	case ChunkTypeToolCallStart:
+		if message == nil || message.ToolCalls == nil {
+			break
+		}
+		result = append(result, c.completeBlock()...)
+		c.block = &streamBlock{
+			kind:     blockKindToolCall,
+			outputID: responses.NewOutputItemFunctionCallID(),
+			callID:   message.ToolCalls.ID,
+			name:     message.ToolCalls.Function.Name,
+		}
+		result = append(result, c.buildOutputItemAddedFunctionCall())
+		result = append(result, c.argumentsDelta(message.ToolCalls.Function.Arguments)...)
+
+	case ChunkTypeToolCallDelta:
+		if message == nil || message.ToolCalls == nil || c.block == nil || c.block.kind != blockKindToolCall {
+			break
+		}
+		result = append(result, c.argumentsDelta(message.ToolCalls.Function.Arguments)...)
+
+	case ChunkTypeToolCallEnd:
+		if c.block != nil && c.block.kind == blockKindToolCall {
+			result = append(result, c.completeBlock()...)
+		}
+
+	case ChunkTypeCitationStart:
+		if message == nil || message.Citations == nil || message.Citations.Type == CitationTypePlan {
+			break
+		}
+		if c.block == nil || c.block.kind != blockKindText {
+			break
+		}
+		annotation := CitationToNativeAnnotation(*message.Citations)
+		c.block.annotations = append(c.block.annotations, annotation)
+		result = append(result, c.buildOutputTextAnnotationAdded(annotation, len(c.block.annotations)-1))
+
+	case ChunkTypeMessageEnd:
+		if in.Delta != nil {
+			c.usage = in.Delta.Usage
+			if in.Delta.FinishReason == "ERROR" {
+				message := in.Delta.Error
+				if message == "" {
+					message = "the generation failed"
+				}
+				return append(result, c.Fail(message)...)
+			}
+		}
+		result = append(result, c.Close()...)
+	}
+
+	return result
+}
+
+// Close completes the stream, it returns nothing once the stream has completed
+func (c *ResponseChunkToNativeResponseChunkConverter) Close() []*responses.ResponseChunk {
+	return c.complete(nil)
+}
+
+// Fail completes the stream with an error
+func (c *ResponseChunkToNativeResponseChunkConverter) Fail(message string) []*responses.ResponseChunk {
+	return c.complete(map[string]any{"message": message})
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) start() []*responses.ResponseChunk {
+	if c.started {
+		return nil
+	}
+	c.started = true
+
+	return []*responses.ResponseChunk{
+		c.buildResponseCreated(),
+		c.buildResponseInProgress(),
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) startText() []*responses.ResponseChunk {
+	c.block = &streamBlock{kind: blockKindText, outputID: responses.NewOutputItemMessageID()}
+	return []*responses.ResponseChunk{c.buildOutputItemAddedMessage(), c.buildContentPartAddedText()}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) startReasoning() []*responses.ResponseChunk {
+	c.block = &streamBlock{kind: blockKindReasoning, outputID: responses.NewOutputItemReasoningID()}
+	return []*responses.ResponseChunk{c.buildOutputItemAddedReasoning(), c.buildReasoningSummaryPartAdded()}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) textDelta(delta string) []*responses.ResponseChunk {
+	if delta == "" {
+		return nil
+	}
+	c.block.text += delta
+	return []*responses.ResponseChunk{c.buildOutputTextDelta(delta)}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) reasoningDelta(delta string) []*responses.ResponseChunk {
+	if delta == "" {
+		return nil
+	}
+	c.block.text += delta
+	return []*responses.ResponseChunk{c.buildReasoningSummaryTextDelta(delta)}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) argumentsDelta(delta string) []*responses.ResponseChunk {
+	if delta == "" {
+		return nil
+	}
+	c.block.text += delta
+	return []*responses.ResponseChunk{c.buildFunctionCallArgumentsDelta(delta)}
+}
+
+// completeBlock emits the done chunks of the current output item and stores it
+func (c *ResponseChunkToNativeResponseChunkConverter) completeBlock() []*responses.ResponseChunk {
+	block := c.block
+	if block == nil {
+		return nil
+	}
+
+	var result []*responses.ResponseChunk
+
+	switch block.kind {
+	case blockKindText:
+		if block.annotations == nil {
+			block.annotations = []responses.Annotation{}
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:      block.outputID,
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: c.outputText()}},
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildOutputTextDone(),
+			c.buildContentPartDoneText(),
+			c.buildOutputItemDoneMessage(),
+		}
+
+	case blockKindToolCall:
+		if block.text == "" {
+			block.text = "{}"
+		}
+
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfFunctionCall: &responses.FunctionCallMessage{
+				ID:        block.outputID,
+				CallID:    block.callID,
+				Name:      block.name,
+				Arguments: block.text,
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildFunctionCallArgumentsDone(),
+			c.buildOutputItemDoneFunctionCall(),
+		}
+
+	case blockKindReasoning:
+		c.completedOutputs = append(c.completedOutputs, responses.OutputMessageUnion{
+			OfReasoning: &responses.ReasoningMessage{
+				ID:      block.outputID,
+				Summary: []responses.SummaryTextContent{{Text: block.text}},
+			},
+		})
+
+		result = []*responses.ResponseChunk{
+			c.buildReasoningSummaryTextDone(),
+			c.buildReasoningSummaryPartDone(),
+			c.buildOutputItemDoneReasoning(),
+		}
+	}
+
+	c.block = nil
+	c.outputIndex++
+
+	return result
+}
+
+// complete emits response.completed, or a failed response with an error
+func (c *ResponseChunkToNativeResponseChunkConverter) complete(err map[string]any) []*responses.ResponseChunk {
+	if c.completed {
+		return nil
+	}
+
+	result := c.start()
+	result = append(result, c.completeBlock()...)
+	c.completed = true
+
+	return append(result, c.buildResponseCompleted(err))
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) outputText() *responses.OutputTextContent {
+	return &responses.OutputTextContent{Text: c.block.text, Annotations: c.block.annotations}
+}
+
+// =============================================================================
+// Chunk Builders
+// =============================================================================
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCreated() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseCreated: &responses.ChunkResponse[constants.ChunkTypeResponseCreated]{
+			Type:           constants.ChunkTypeResponseCreated(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.id,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+				Request:   responses.Request{Model: c.Model},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseInProgress() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseInProgress: &responses.ChunkResponse[constants.ChunkTypeResponseInProgress]{
+			Type:           constants.ChunkTypeResponseInProgress(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response: responses.ChunkResponseData{
+				Id:        c.id,
+				Object:    "response",
+				CreatedAt: int(time.Now().Unix()),
+				Status:    "in_progress",
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedMessage() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "in_progress",
+				CallID:    utils.Ptr(c.block.callID),
+				Name:      utils.Ptr(c.block.name),
+				Arguments: utils.Ptr(""),
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedReasoning() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			Type:           constants.ChunkTypeOutputItemAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "reasoning",
+				Id:      c.block.outputID,
+				Status:  "in_progress",
+				Summary: []responses.SummaryTextContent{},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildContentPartAddedText() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartAdded: &responses.ChunkContentPart[constants.ChunkTypeContentPartAdded]{
+			Type:           constants.ChunkTypeContentPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Text: "", Annotations: []responses.Annotation{}}},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryPartAdded() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartAdded: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartAdded]{
+			Type:           constants.ChunkTypeReasoningSummaryPartAdded(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: ""},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDelta: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDelta]{
+			Type:           constants.ChunkTypeOutputTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextAnnotationAdded(annotation responses.Annotation, index int) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextAnnotationAdded: &responses.ChunkOutputText[constants.ChunkTypeOutputTextAnnotationAdded]{
+			Type:            constants.ChunkTypeOutputTextAnnotationAdded(""),
+			SequenceNumber:  c.nextSeqNum(),
+			ItemId:          c.block.outputID,
+			OutputIndex:     c.outputIndex,
+			Annotation:      annotation,
+			AnnotationIndex: index,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildFunctionCallArgumentsDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDelta: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDelta]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryTextDelta(delta string) *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDelta: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDelta]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDelta(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Delta:          delta,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputTextDone() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputTextDone: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDone]{
+			Type:           constants.ChunkTypeOutputTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(c.block.text),
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildContentPartDoneText() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfContentPartDone: &responses.ChunkContentPart[constants.ChunkTypeContentPartDone]{
+			Type:           constants.ChunkTypeContentPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: c.outputText()},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneMessage() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      c.block.outputID,
+				Status:  "completed",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{{OfOutputText: c.outputText()}},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildFunctionCallArgumentsDone() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfFunctionCallArgumentsDone: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDone]{
+			Type:           constants.ChunkTypeFunctionCallArgumentsDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Arguments:      c.block.text,
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneFunctionCall() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:      "function_call",
+				Id:        c.block.outputID,
+				Status:    "completed",
+				CallID:    utils.Ptr(c.block.callID),
+				Name:      utils.Ptr(c.block.name),
+				Arguments: utils.Ptr(c.block.text),
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryTextDone() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryTextDone: &responses.ChunkReasoningSummaryText[constants.ChunkTypeReasoningSummaryTextDone]{
+			Type:           constants.ChunkTypeReasoningSummaryTextDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Text:           utils.Ptr(c.block.text),
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildReasoningSummaryPartDone() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfReasoningSummaryPartDone: &responses.ChunkReasoningSummaryPart[constants.ChunkTypeReasoningSummaryPartDone]{
+			Type:           constants.ChunkTypeReasoningSummaryPartDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			ItemId:         c.block.outputID,
+			OutputIndex:    c.outputIndex,
+			Part:           responses.SummaryTextContent{Text: c.block.text},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneReasoning() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			Type:           constants.ChunkTypeOutputItemDone(""),
+			SequenceNumber: c.nextSeqNum(),
+			OutputIndex:    c.outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "reasoning",
+				Id:      c.block.outputID,
+				Status:  "completed",
+				Summary: []responses.SummaryTextContent{{Text: c.block.text}},
+			},
+		},
+	}
+}
+
+func (c *ResponseChunkToNativeResponseChunkConverter) buildResponseCompleted(err map[string]any) *responses.ResponseChunk {
+	data := responses.ChunkResponseData{
+		Id:        c.id,
+		Object:    "response",
+		CreatedAt: int(time.Now().Unix()),
+		Status:    "completed",
+		Output:    c.completedOutputs,
+		Usage:     c.usage.ToNative(),
+		Request:   responses.Request{Model: c.Model},
+	}
+	if data.Output == nil {
+		data.Output = []responses.OutputMessageUnion{}
+	}
+	if err != nil {
+		data.Status = "failed"
+		data.Error = err
+	}
+
+	return &responses.ResponseChunk{
+		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
+			Type:           constants.ChunkTypeResponseCompleted(""),
+			SequenceNumber: c.nextSeqNum(),
+			Response:       data,
+		},
+	}
+}
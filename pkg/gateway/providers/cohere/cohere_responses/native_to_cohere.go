@@ -0,0 +1,301 @@
+package cohere_responses
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+func NativeRequestToRequest(in *responses.Request) *Request {
+	if in.MaxToolCalls != nil {
+		slog.Warn("max tool call is not supported for cohere models")
+	}
+
+	out := &Request{
+		Model:          in.Model,
+		Temperature:    in.Temperature,
+		P:              in.TopP,
+		MaxTokens:      in.MaxOutputTokens,
+		ResponseFormat: NativeTextFormatToResponseFormat(in.Text),
+		Thinking:       NativeReasoningToThinking(in.Reasoning),
+	}
+
+	out.Messages = NativeMessagesToMessages(in.Input)
+	if in.Instructions != nil && *in.Instructions != "" {
+		out.Messages = append([]Message{{Role: RoleSystem, Content: []Content{{Type: ContentTypeText, Text: *in.Instructions}}}}, out.Messages...)
+	}
+
+	out.Tools, out.ToolChoice = NativeToolsToTools(in.Tools, in.ToolChoice)
+	for _, tool := range in.Tools {
+		if tool.OfFunction != nil && tool.OfFunction.Strict != nil && *tool.OfFunction.Strict {
+			out.StrictTools = utils.Ptr(true)
+		}
+	}
+
+	return out
+}
+
+// NativeTextFormatToResponseFormat converts the structured output to the JSON mode, constrained by the schema of a
+// json_schema format
+func NativeTextFormatToResponseFormat(in *responses.TextFormat) *ResponseFormat {
+	if in == nil || in.Format == nil {
+		return nil
+	}
+
+	formatType, _ := in.Format["type"].(string)
+	switch formatType {
+	case "json_object":
+		return &ResponseFormat{Type: "json_object"}
+	case "json_schema":
+		schema, _ := in.Format["schema"].(map[string]any)
+		return &ResponseFormat{Type: "json_object", JSONSchema: schema}
+	}
+
+	return nil
+}
+
+// NativeReasoningToThinking enables the thinking with the budget of the reasoning, an effort of "none" disables it
+func NativeReasoningToThinking(in *responses.ReasoningParam) *Thinking {
+	if in == nil {
+		return nil
+	}
+
+	if in.Effort != nil && *in.Effort == "none" {
+		return &Thinking{Type: "disabled"}
+	}
+
+	if in.Effort == nil && in.BudgetTokens == nil {
+		return nil
+	}
+
+	return &Thinking{Type: "enabled", TokenBudget: in.BudgetTokens}
+}
+
+// NativeToolsToTools converts the function tools, the other tools aren't supported by Cohere. The tools allowed by
+// the tool choice are the only tools sent, and a function choice only sends the function, whose call is required.
+func NativeToolsToTools(nativeTools []responses.ToolUnion, choice *responses.ToolChoiceUnion) ([]Tool, *string) {
+	var allowed []string
+	if choice != nil && choice.OfAllowedTools != nil {
+		allowed = choice.OfAllowedTools.FunctionNames()
+	}
+	if choice != nil && choice.OfFunction != nil {
+		allowed = []string{choice.OfFunction.Name}
+	}
+
+	var tools []Tool
+	for _, nativeTool := range nativeTools {
+		if nativeTool.OfFunction == nil {
+			slog.Warn("only function tools are supported for cohere models")
+			continue
+		}
+
+		if allowed != nil && !slices.Contains(allowed, nativeTool.OfFunction.Name) {
+			continue
+		}
+
+		parameters := nativeTool.OfFunction.Parameters
+		if parameters == nil {
+			parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+
+		tools = append(tools, Tool{
+			Type: "function",
+			Function: Function{
+				Name:        nativeTool.OfFunction.Name,
+				Description: nativeTool.OfFunction.Description,
+				Parameters:  parameters,
+			},
+		})
+	}
+
+	if len(tools) == 0 || choice == nil {
+		return tools, nil
+	}
+
+	switch {
+	case choice.OfFunction != nil:
+		return tools, utils.Ptr("REQUIRED")
+	case choice.OfAllowedTools != nil && choice.OfAllowedTools.Mode == responses.ToolChoiceModeRequired:
+		return tools, utils.Ptr("REQUIRED")
+	case choice.OfMode != nil && *choice.OfMode == responses.ToolChoiceModeRequired:
+		return tools, utils.Ptr("REQUIRED")
+	case choice.OfMode != nil && *choice.OfMode == responses.ToolChoiceModeNone:
+		return tools, utils.Ptr("NONE")
+	}
+
+	return tools, nil
+}
+
+func NativeRoleToRole(in constants.Role) Role {
+	switch in {
+	case constants.RoleAssistant:
+		return RoleAssistant
+	case constants.RoleSystem, constants.RoleDeveloper:
+		return RoleSystem
+	}
+
+	return RoleUser
+}
+
+// NativeMessagesToMessages converts the input to messages. The function calls following an assistant message are
+// the tool calls of that message, and the reasoning is the thinking of the assistant message.
+func NativeMessagesToMessages(in responses.InputUnion) []Message {
+	if in.OfString != nil {
+		return []Message{{Role: RoleUser, Content: []Content{{Type: ContentTypeText, Text: *in.OfString}}}}
+	}
+
+	var messages []Message
+
+	// assistant returns the last message if it is an assistant message without tool results after it, or a new
+	// assistant message
+	assistant := func() *Message {
+		if n := len(messages); n > 0 && messages[n-1].Role == RoleAssistant {
+			return &messages[n-1]
+		}
+		messages = append(messages, Message{Role: RoleAssistant})
+		return &messages[len(messages)-1]
+	}
+
+	for _, nativeMessage := range in.OfInputMessageList {
+		switch {
+		case nativeMessage.OfEasyInput != nil:
+			var content []Content
+			if nativeMessage.OfEasyInput.Content.OfString != nil {
+				content = []Content{{Type: ContentTypeText, Text: *nativeMessage.OfEasyInput.Content.OfString}}
+			} else {
+				content = NativeContentToContent(nativeMessage.OfEasyInput.Content.OfInputMessageList)
+			}
+
+			role := NativeRoleToRole(nativeMessage.OfEasyInput.Role)
+			if role == RoleAssistant {
+				msg := assistant()
+				msg.Content = append(msg.Content, content...)
+				continue
+			}
+			messages = append(messages, Message{Role: role, Content: content})
+
+		case nativeMessage.OfInputMessage != nil:
+			content := NativeContentToContent(nativeMessage.OfInputMessage.Content)
+
+			role := NativeRoleToRole(nativeMessage.OfInputMessage.Role)
+			if role == RoleAssistant {
+				msg := assistant()
+				msg.Content = append(msg.Content, content...)
+				continue
+			}
+			messages = append(messages, Message{Role: role, Content: content})
+
+		case nativeMessage.OfOutputMessage != nil:
+			msg := assistant()
+			for _, content := range nativeMessage.OfOutputMessage.Content {
+				if content.OfOutputText == nil {
+					continue
+				}
+
+				// The citations are offsets in the text of the message
+				offset := len(msg.Content)
+				msg.Content = append(msg.Content, Content{Type: ContentTypeText, Text: content.OfOutputText.Text})
+				msg.Citations = append(msg.Citations, NativeAnnotationsToCitations(content.OfOutputText.Annotations, offset)...)
+			}
+
+		case nativeMessage.OfFunctionCall != nil:
+			msg := assistant()
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:   nativeMessage.OfFunctionCall.CallID,
+				Type: "function",
+				Function: FunctionCall{
+					Name:      nativeMessage.OfFunctionCall.Name,
+					Arguments: nativeMessage.OfFunctionCall.Arguments,
+				},
+			})
+
+		case nativeMessage.OfFunctionCallOutput != nil:
+			messages = append(messages, Message{
+				Role:       RoleTool,
+				Content:    NativeFunctionCallOutputToContent(nativeMessage.OfFunctionCallOutput),
+				ToolCallID: nativeMessage.OfFunctionCallOutput.CallID,
+			})
+
+		case nativeMessage.OfReasoning != nil:
+			text := ""
+			for _, summary := range nativeMessage.OfReasoning.Summary {
+				text += summary.Text
+			}
+			if text == "" {
+				continue
+			}
+
+			msg := assistant()
+			msg.Content = append(msg.Content, Content{Type: ContentTypeThinking, Thinking: text})
+		}
+	}
+
+	return messages
+}
+
+// NativeAnnotationsToCitations returns the citations of the annotations created from the citations of Cohere, the
+// other annotations have no sources to cite
+func NativeAnnotationsToCitations(annotations []responses.Annotation, contentIndex int) []Citation {
+	var citations []Citation
+
+	for _, annotation := range annotations {
+		raw, ok := annotation.ExtraParams["Cohere"]
+		if !ok {
+			continue
+		}
+
+		citation, ok := raw.(Citation)
+		if !ok {
+			// The annotations of a stored response were read from JSON
+			buf, err := sonic.Marshal(raw)
+			if err != nil || sonic.Unmarshal(buf, &citation) != nil {
+				continue
+			}
+		}
+
+		citation.ContentIndex = contentIndex
+		citations = append(citations, citation)
+	}
+
+	return citations
+}
+
+func NativeContentToContent(in responses.InputContent) []Content {
+	var out []Content
+
+	for _, nativeContent := range in {
+		switch {
+		case nativeContent.OfInputText != nil:
+			out = append(out, Content{Type: ContentTypeText, Text: nativeContent.OfInputText.Text})
+		case nativeContent.OfOutputText != nil:
+			out = append(out, Content{Type: ContentTypeText, Text: nativeContent.OfOutputText.Text})
+		case nativeContent.OfInputImage != nil:
+			if nativeContent.OfInputImage.ImageURL == nil {
+				slog.Warn("images by file ID are not supported for cohere models")
+				continue
+			}
+			out = append(out, Content{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: *nativeContent.OfInputImage.ImageURL}})
+		}
+	}
+
+	return out
+}
+
+func NativeFunctionCallOutputToContent(in *responses.FunctionCallOutputMessage) []Content {
+	if in.Output.OfString != nil {
+		return []Content{{Type: ContentTypeText, Text: *in.Output.OfString}}
+	}
+
+	var out []Content
+	for _, nativeOutput := range in.Output.OfList {
+		if nativeOutput.OfInputText != nil {
+			out = append(out, Content{Type: ContentTypeText, Text: nativeOutput.OfInputText.Text})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,45 @@
+package cohere_responses
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeMessagesToMessages_Citations(t *testing.T) {
+	citation := Citation{
+		Start:   6,
+		End:     11,
+		Text:    "sunny",
+		Type:    "TEXT_CONTENT",
+		Sources: []Source{{Type: "tool", ID: "get_weather_1:0", ToolOutput: map[string]any{"forecast": "Sunny"}}},
+	}
+
+	// The annotations of a stored conversation are read from JSON
+	output := responses.OutputMessage{
+		Role: "assistant",
+		Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{
+			Text:        "It is sunny.",
+			Annotations: []responses.Annotation{CitationToNativeAnnotation(citation)},
+		}}},
+	}
+	buf, err := sonic.Marshal(output)
+	require.NoError(t, err)
+
+	var stored responses.OutputMessage
+	require.NoError(t, sonic.Unmarshal(buf, &stored))
+
+	messages := NativeMessagesToMessages(responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+		{OfOutputMessage: &stored},
+	}})
+
+	require.Len(t, messages, 1)
+	assert.Equal(t, RoleAssistant, messages[0].Role)
+	require.Len(t, messages[0].Citations, 1)
+	assert.Equal(t, citation.Start, messages[0].Citations[0].Start)
+	assert.Equal(t, citation.End, messages[0].Citations[0].End)
+	assert.Equal(t, "get_weather_1:0", messages[0].Citations[0].Sources[0].ID)
+}
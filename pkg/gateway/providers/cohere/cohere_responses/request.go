@@ -0,0 +1,116 @@
+package cohere_responses
+
+// Request is the body of the v2 chat API of Cohere, see https://docs.cohere.com/reference/chat
+type Request struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	ToolChoice     *string         `json:"tool_choice,omitempty"` // "REQUIRED" or "NONE"
+	StrictTools    *bool           `json:"strict_tools,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	P              *float64        `json:"p,omitempty"`
+	MaxTokens      *int            `json:"max_tokens,omitempty"`
+	Thinking       *Thinking       `json:"thinking,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+}
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Message is a message of the conversation. The assistant messages calling tools may have a tool plan, the text the
+// model wrote before calling them, and the tool messages are the results of a call.
+type Message struct {
+	Role       Role       `json:"role"`
+	Content    []Content  `json:"content,omitempty"`
+	ToolPlan   string     `json:"tool_plan,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Citations  []Citation `json:"citations,omitempty"`
+}
+
+type ContentType string
+
+const (
+	ContentTypeText     ContentType = "text"
+	ContentTypeImageURL ContentType = "image_url"
+	ContentTypeThinking ContentType = "thinking"
+	ContentTypeDocument ContentType = "document"
+)
+
+type Content struct {
+	Type     ContentType `json:"type"`
+	Text     string      `json:"text,omitempty"`
+	Thinking string      `json:"thinking,omitempty"`
+	ImageURL *ImageURL   `json:"image_url,omitempty"`
+	Document *Document   `json:"document,omitempty"`
+}
+
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+type Document struct {
+	ID   string         `json:"id,omitempty"`
+	Data map[string]any `json:"data"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type Tool struct {
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+type Function struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// ResponseFormat is the JSON mode, the JSON schema constrains the output to the schema
+type ResponseFormat struct {
+	Type       string         `json:"type"` // "text" or "json_object"
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+}
+
+// Thinking enables the reasoning of the reasoning models, e.g. command-a-reasoning
+type Thinking struct {
+	Type        string `json:"type"` // "enabled" or "disabled"
+	TokenBudget *int   `json:"token_budget,omitempty"`
+}
+
+// Citation is a span of the text of a message, or of its tool plan, citing the results of the tools or the documents
+type Citation struct {
+	Start        int      `json:"start"`
+	End          int      `json:"end"`
+	Text         string   `json:"text"`
+	Sources      []Source `json:"sources,omitempty"`
+	Type         string   `json:"type,omitempty"` // "TEXT_CONTENT" or "PLAN"
+	ContentIndex int      `json:"content_index,omitempty"`
+}
+
+const CitationTypePlan = "PLAN"
+
+// Source is the result of a tool call, with the ID of the call and the index of the result, or a document
+type Source struct {
+	Type       string         `json:"type"` // "tool" or "document"
+	ID         string         `json:"id,omitempty"`
+	ToolOutput map[string]any `json:"tool_output,omitempty"`
+	Document   map[string]any `json:"document,omitempty"`
+}
@@ -0,0 +1,73 @@
+package cohere_responses
+
+type Response struct {
+	ID           string          `json:"id"`
+	FinishReason string          `json:"finish_reason"` // "COMPLETE", "STOP_SEQUENCE", "MAX_TOKENS", "TOOL_CALL" or "ERROR"
+	Message      ResponseMessage `json:"message"`
+	Usage        *Usage          `json:"usage,omitempty"`
+}
+
+type ResponseMessage struct {
+	Role      Role       `json:"role"`
+	Content   []Content  `json:"content,omitempty"`
+	ToolPlan  string     `json:"tool_plan,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Usage has the billed tokens and the tokens of the request, the latter include the tokens of the prompt template
+type Usage struct {
+	BilledUnits *Tokens `json:"billed_units,omitempty"`
+	Tokens      *Tokens `json:"tokens,omitempty"`
+}
+
+type Tokens struct {
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+type ChunkType string
+
+const (
+	ChunkTypeMessageStart  ChunkType = "message-start"
+	ChunkTypeContentStart  ChunkType = "content-start"
+	ChunkTypeContentDelta  ChunkType = "content-delta"
+	ChunkTypeContentEnd    ChunkType = "content-end"
+	ChunkTypeToolPlanDelta ChunkType = "tool-plan-delta"
+	ChunkTypeToolCallStart ChunkType = "tool-call-start"
+	ChunkTypeToolCallDelta ChunkType = "tool-call-delta"
+	ChunkTypeToolCallEnd   ChunkType = "tool-call-end"
+	ChunkTypeCitationStart ChunkType = "citation-start"
+	ChunkTypeCitationEnd   ChunkType = "citation-end"
+	ChunkTypeMessageEnd    ChunkType = "message-end"
+)
+
+// ResponseChunk is an event of a chat stream. The deltas of the content, the tool calls and the citations have the
+// index of their item.
+type ResponseChunk struct {
+	Type  ChunkType   `json:"type"`
+	ID    string      `json:"id,omitempty"`
+	Index int         `json:"index"`
+	Delta *ChunkDelta `json:"delta,omitempty"`
+}
+
+type ChunkDelta struct {
+	Message      *ChunkMessage `json:"message,omitempty"`
+	FinishReason string        `json:"finish_reason,omitempty"`
+	Usage        *Usage        `json:"usage,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// ChunkMessage is the delta of the message, the content, the tool call and the citation are single items
+type ChunkMessage struct {
+	Role      Role      `json:"role,omitempty"`
+	Content   *Content  `json:"content,omitempty"`
+	ToolPlan  string    `json:"tool_plan,omitempty"`
+	ToolCalls *ToolCall `json:"tool_calls,omitempty"`
+	Citations *Citation `json:"citations,omitempty"`
+}
+
+type ErrorResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
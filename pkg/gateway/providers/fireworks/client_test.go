@@ -0,0 +1,100 @@
+package fireworks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/gateway/providers/fireworks/fireworks_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responsesRequest(t *testing.T, model string) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "`+model+`",
+		"instructions": "Extract the city",
+		"input": "I live in Paris",
+		"text": {"format": {
+			"type": "json_schema",
+			"name": "city",
+			"strict": true,
+			"schema": {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}
+		}},
+		"reasoning": {"effort": "minimal"}
+	}`), &req))
+	return &req
+}
+
+func TestClient_NewResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+
+		// The name of a serverless model is the path of the model in the account of Fireworks
+		assert.Equal(t, "accounts/fireworks/models/qwen3-235b-a22b", payload["model"])
+		assert.Equal(t, "low", payload["reasoning_effort"])
+		assert.Equal(t, map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "city",
+				"strict": true,
+				"schema": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"city": map[string]any{"type": "string"}},
+					"required":   []any{"city"},
+				},
+			},
+		}, payload["response_format"])
+
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 2)
+		assert.Equal(t, map[string]any{"role": "system", "content": "Extract the city"}, messages[0])
+		assert.Equal(t, map[string]any{"role": "user", "content": "I live in Paris"}, messages[1])
+
+		_, _ = w.Write([]byte(`{
+			"id": "f1",
+			"object": "chat.completion",
+			"model": "accounts/fireworks/models/qwen3-235b-a22b",
+			"choices": [{
+				"index": 0,
+				"message": {"role": "assistant", "content": "{\"city\": \"Paris\"}", "reasoning_content": "The user lives in Paris."},
+				"finish_reason": "stop"
+			}],
+			"usage": {"prompt_tokens": 30, "completion_tokens": 10, "total_tokens": 40}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "fireworks-key"})
+	out, err := client.NewResponses(context.Background(), responsesRequest(t, "qwen3-235b-a22b"))
+	require.NoError(t, err)
+
+	require.Len(t, out.Output, 2)
+	assert.Equal(t, "The user lives in Paris.", out.Output[0].OfReasoning.Summary[0].Text)
+	assert.Equal(t, `{"city": "Paris"}`, out.Output[1].OfOutputMessage.Content[0].OfOutputText.Text)
+}
+
+func TestModelPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "serverless model", model: "llama-v3p3-70b-instruct", want: "accounts/fireworks/models/llama-v3p3-70b-instruct"},
+		{name: "serverless model path", model: "accounts/fireworks/models/qwen3-235b-a22b", want: "accounts/fireworks/models/qwen3-235b-a22b"},
+		{name: "account model", model: "accounts/acme/models/ft-llama", want: "accounts/acme/models/ft-llama"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fireworks_responses.ModelPath(tt.model))
+		})
+	}
+}
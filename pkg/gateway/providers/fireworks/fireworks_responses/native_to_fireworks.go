@@ -0,0 +1,52 @@
+package fireworks_responses
+
+import (
+	"strings"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Options are the differences of the chat completions API of Fireworks AI, which follows the OpenAI API. Fireworks
+// enforces the schemas of the structured outputs while generating, the reasoning is the reasoning_content.
+var Options = chat_responses.Options{Provider: "fireworks"}
+
+// NativeRequestToRequest converts the request to the chat completions API of Fireworks AI, see
+// https://docs.fireworks.ai/api-reference/post-chatcompletions
+func NativeRequestToRequest(in *responses.Request) *chat_responses.Request {
+	out := chat_responses.NativeRequestToRequest(in, Options)
+	out.Model = ModelPath(in.Model)
+	out.ReasoningEffort = NativeReasoningToReasoningEffort(in.Reasoning)
+
+	return out
+}
+
+// ServerlessModelPrefix is the account of the serverless models of Fireworks
+const ServerlessModelPrefix = "accounts/fireworks/models/"
+
+// ModelPath returns the path of the model. The models of Fireworks are resources of an account, e.g.
+// accounts/fireworks/models/llama-v3p3-70b-instruct, the names without account are the serverless models of Fireworks.
+func ModelPath(model string) string {
+	if strings.Contains(model, "/") {
+		return model
+	}
+	return ServerlessModelPrefix + model
+}
+
+// NativeReasoningToReasoningEffort converts the reasoning param to the effort of the reasoning models, "none" turns
+// the thinking of the hybrid models, e.g. Qwen3, off
+func NativeReasoningToReasoningEffort(in *responses.ReasoningParam) *string {
+	if in == nil {
+		return nil
+	}
+
+	switch effort := in.NormalizedEffort(); effort {
+	case responses.ReasoningEffortMinimal:
+		return utils.Ptr(string(responses.ReasoningEffortLow))
+	case responses.ReasoningEffortXHigh:
+		return utils.Ptr(string(responses.ReasoningEffortHigh))
+	default:
+		return utils.Ptr(string(effort))
+	}
+}
@@ -26,6 +26,9 @@ type ClientOptions struct {
 	ApiKey  string
 	Headers map[string]string
 
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
 	transport *http.Client
 }
 
@@ -35,6 +38,9 @@ type Client struct {
 }
 
 func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
 	if opts.transport == nil {
 		opts.transport = http.DefaultClient
 	}
@@ -64,7 +70,7 @@ func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*res
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +120,7 @@ func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Reque
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -230,7 +236,7 @@ func (c *Client) NewEmbedding(ctx context.Context, inp *embeddings.Request) (*em
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -279,7 +285,7 @@ func (c *Client) NewSpeech(ctx context.Context, inp *speech.Request) (*speech.Re
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -328,7 +334,7 @@ func (c *Client) NewStreamingSpeech(ctx context.Context, inp *speech.Request) (c
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
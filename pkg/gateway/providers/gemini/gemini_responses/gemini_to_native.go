@@ -29,21 +29,13 @@ func (in *Request) ToNativeRequest() *responses.Request {
 			Include:           nil,
 			Metadata:          nil,
 			Stream:            in.Stream,
+			ToolChoice:        ToolConfigToNativeToolChoice(in.ToolConfig),
 		},
 	}
 
 	includables := []responses.Includable{}
 	if in.GenerationConfig.ThinkingConfig != nil {
-		effort := "high"
-		if in.GenerationConfig.ThinkingConfig.ThinkingLevel != nil && *in.GenerationConfig.ThinkingConfig.ThinkingLevel != "HIGH" {
-			effort = "low"
-		}
-
-		out.Reasoning = &responses.ReasoningParam{
-			Effort:       &effort,
-			Summary:      utils.Ptr("auto"),
-			BudgetTokens: in.GenerationConfig.ThinkingConfig.ThinkingBudget,
-		}
+		out.Reasoning = GeminiThinkingConfigToNativeReasoningParam(in.GenerationConfig.ThinkingConfig)
 
 		includables = append(includables, responses.IncludableReasoningEncryptedContent)
 	}
@@ -66,6 +58,37 @@ func (in *Request) ToNativeRequest() *responses.Request {
 	return out
 }
 
+// geminiThinkingLevelEfforts maps the thinking levels of Gemini to the reasoning efforts
+var geminiThinkingLevelEfforts = map[string]responses.ReasoningEffort{
+	"MINIMAL": responses.ReasoningEffortMinimal,
+	"LOW":     responses.ReasoningEffortLow,
+	"MEDIUM":  responses.ReasoningEffortMedium,
+	"HIGH":    responses.ReasoningEffortHigh,
+}
+
+// GeminiThinkingConfigToNativeReasoningParam maps the thinking level, or else the thinking budget, to the reasoning
+// effort. Gemini thinks dynamically at the high level without any of them, or with the budget -1.
+func GeminiThinkingConfigToNativeReasoningParam(in *ThinkingConfig) *responses.ReasoningParam {
+	effort := responses.ReasoningEffortHigh
+	var budget *int
+
+	switch {
+	case in.ThinkingLevel != nil:
+		if e, ok := geminiThinkingLevelEfforts[strings.ToUpper(*in.ThinkingLevel)]; ok {
+			effort = e
+		}
+	case in.ThinkingBudget != nil && *in.ThinkingBudget >= 0:
+		effort = responses.ReasoningEffortForBudget(*in.ThinkingBudget)
+		budget = utils.Ptr(*in.ThinkingBudget)
+	}
+
+	return &responses.ReasoningParam{
+		Effort:       utils.Ptr(string(effort)),
+		Summary:      utils.Ptr("auto"),
+		BudgetTokens: budget,
+	}
+}
+
 func (in Role) ToNativeRole() constants.Role {
 	switch in {
 	case RoleUser:
@@ -89,6 +112,51 @@ func ToolsToNativeTools(in []Tool) []responses.ToolUnion {
 	return out
 }
 
+// ToolConfigToNativeToolChoice maps the function calling mode to the tool choice. The mode ANY restricted to one
+// function forces it, to several functions it requires one of them. The mode VALIDATED allows the functions without
+// requiring them.
+func ToolConfigToNativeToolChoice(in *ToolConfig) *responses.ToolChoiceUnion {
+	if in == nil || in.FunctionCallingConfig == nil {
+		return nil
+	}
+
+	config := in.FunctionCallingConfig
+	switch config.Mode {
+	case FunctionCallingModeNone:
+		return &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeNone)}
+
+	case FunctionCallingModeAny:
+		switch len(config.AllowedFunctionNames) {
+		case 0:
+			return &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeRequired)}
+		case 1:
+			return &responses.ToolChoiceUnion{OfFunction: &responses.ToolChoiceFunction{Name: config.AllowedFunctionNames[0]}}
+		default:
+			return &responses.ToolChoiceUnion{OfAllowedTools: nativeAllowedFunctions(responses.ToolChoiceModeRequired, config.AllowedFunctionNames)}
+		}
+
+	case FunctionCallingModeValidated:
+		if len(config.AllowedFunctionNames) > 0 {
+			return &responses.ToolChoiceUnion{OfAllowedTools: nativeAllowedFunctions(responses.ToolChoiceModeAuto, config.AllowedFunctionNames)}
+		}
+	}
+
+	return &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeAuto)}
+}
+
+func nativeAllowedFunctions(mode responses.ToolChoiceMode, names []string) *responses.ToolChoiceAllowedTools {
+	out := &responses.ToolChoiceAllowedTools{
+		Mode:  mode,
+		Tools: []responses.ToolChoiceAllowedTool{},
+	}
+
+	for _, name := range names {
+		out.Tools = append(out.Tools, responses.ToolChoiceAllowedTool{Type: "function", Name: name})
+	}
+
+	return out
+}
+
 func (in *Tool) ToNative() []responses.ToolUnion {
 	out := []responses.ToolUnion{}
 
@@ -204,8 +272,14 @@ func (content *Content) ToNativeMessage() []responses.InputMessageUnion {
 func (in *Response) ToNativeResponse() *responses.Response {
 	output := []responses.OutputMessageUnion{}
 
+	// A blocked prompt has no candidates
+	candidate := Candidate{}
+	if len(in.Candidates) > 0 {
+		candidate = in.Candidates[0]
+	}
+
 	var previousExecutableCodePart *ExecutableCodePart
-	for _, part := range in.Candidates[0].Content.Parts {
+	for _, part := range candidate.Content.Parts {
 		if part.Text != nil {
 			output = append(output, responses.OutputMessageUnion{
 				OfOutputMessage: &responses.OutputMessage{
@@ -278,11 +352,45 @@ func (in *Response) ToNativeResponse() *responses.Response {
 		Error:       nil,
 		ServiceTier: "",
 		Metadata: map[string]any{
-			"stop_reason": in.Candidates[0].FinishReason,
+			"stop_reason":               candidate.FinishReason,
+			responses.MetadataKeyFinish: in.FinishMetadata(candidate),
 		},
 	}
 }
 
+// FinishMetadata gathers why the candidate finished and the safety ratings of the candidate and of the prompt
+func (in *Response) FinishMetadata(candidate Candidate) *responses.FinishMetadata {
+	out := &responses.FinishMetadata{
+		FinishReason:  candidate.FinishReason,
+		FinishMessage: candidate.FinishMessage,
+		SafetyRatings: SafetyRatingsToNativeSafetyRatings(candidate.SafetyRatings),
+	}
+
+	if in.PromptFeedback != nil {
+		out.PromptBlockReason = in.PromptFeedback.BlockReason
+		out.PromptSafetyRatings = SafetyRatingsToNativeSafetyRatings(in.PromptFeedback.SafetyRatings)
+	}
+
+	return out
+}
+
+func SafetyRatingsToNativeSafetyRatings(in []SafetyRating) []responses.SafetyRating {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make([]responses.SafetyRating, 0, len(in))
+	for _, rating := range in {
+		out = append(out, responses.SafetyRating{
+			Category:    rating.Category,
+			Probability: rating.Probability,
+			Blocked:     rating.Blocked,
+		})
+	}
+
+	return out
+}
+
 // =============================================================================
 // Gemini ResponseChunk to Native Conversion
 // =============================================================================
@@ -356,8 +464,11 @@ func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeRespo
 		return c.handleStreamEnd()
 	}
 
-	// Update usage and model from each chunk (Gemini sends these with every chunk)
-	c.usage = *in.UsageMetadata
+	// Update usage and model from each chunk (Gemini sends these with every chunk, Vertex AI may only send the usage
+	// with the last one)
+	if in.UsageMetadata != nil {
+		c.usage = *in.UsageMetadata
+	}
 	c.model = in.ResponseID
 
 	var out []*responses.ResponseChunk
@@ -367,6 +478,11 @@ func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeRespo
 		out = append(out, c.emitStreamStart(in)...)
 	}
 
+	// A blocked prompt has no candidates
+	if len(in.Candidates) == 0 {
+		return out
+	}
+
 	// Process all parts in this chunk
 	for i := range in.Candidates[0].Content.Parts {
 		part := &in.Candidates[0].Content.Parts[i]
@@ -422,6 +538,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) handlePart(part *Part) []*
 		out = append(out, c.completeCurrentPart()...)
 		c.outputItemActive = false
 		c.accumulatedData = ""
+		c.outputIndex++
 	}
 
 	// Store current block for later reference (used in completion)
@@ -463,6 +580,10 @@ func (c *ResponseChunkToNativeResponseChunkConverter) shouldEndPreviousPart(part
 	if c.previousPart == nil {
 		return false
 	}
+	// Gemini streams the function calls whole, each part is a call of its own
+	if part.FunctionCall != nil {
+		return true
+	}
 	return c.getPartType(c.previousPart) != c.getPartType(part)
 }
 
@@ -774,7 +895,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedMessag
 		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
 			Type:           constants.ChunkTypeOutputItemAdded(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:    "message",
 				Id:      c.outputItemID,
@@ -793,7 +914,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemAddedFuncti
 		OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
 			Type:           constants.ChunkTypeOutputItemAdded(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:             "function_call",
 				Id:               c.outputItemID,
@@ -895,7 +1016,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneMessage
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:    "message",
 				Id:      c.outputItemID,
@@ -924,7 +1045,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneFunctio
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:             "function_call",
 				Id:               c.outputItemID,
@@ -997,7 +1118,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneReasoni
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:    "reasoning",
 				Id:      c.outputItemID,
@@ -1081,7 +1202,7 @@ func (c *ResponseChunkToNativeResponseChunkConverter) buildOutputItemDoneImageGe
 		OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
 			Type:           constants.ChunkTypeOutputItemDone(""),
 			SequenceNumber: c.nextSeqNum(),
-			OutputIndex:    0,
+			OutputIndex:    c.outputIndex,
 			Item: responses.ChunkOutputItemData{
 				Type:   "image_generation_call",
 				Id:     c.outputItemID,
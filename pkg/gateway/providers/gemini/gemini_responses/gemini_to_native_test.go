@@ -3,6 +3,7 @@ package gemini_responses
 import (
 	"testing"
 
+	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/llm/constants"
 	"github.com/curaious/uno/pkg/llm/responses"
@@ -451,6 +452,73 @@ func TestGeminiToNative_FunctionCallInCompleted(t *testing.T) {
 	assert.Equal(t, "get_weather", completed.Response.Output[0].OfFunctionCall.Name)
 }
 
+// =============================================================================
+// Test: Output and Content Index Contract
+// =============================================================================
+
+func TestGeminiToNative_IndexContract(t *testing.T) {
+	converter := newGeminiToNativeConverter()
+
+	thoughtChunk := createGeminiTextChunk("resp_idx", "gemini-2.5-pro", "Thinking about it", 100, 5, 105)
+	thoughtChunk.Candidates[0].Content.Parts[0].Thought = utils.Ptr(true)
+
+	// Gemini streams parallel function calls as parts of a single chunk
+	fnChunk := createGeminiFunctionCallChunk("resp_idx", "gemini-2.5-pro", "get_weather", map[string]any{"city": "NYC"}, 100, 30, 130)
+	fnChunk.Candidates[0].Content.Parts = append(fnChunk.Candidates[0].Content.Parts, Part{
+		FunctionCall: &FunctionCall{Name: "get_time", Args: map[string]any{"city": "NYC"}},
+	})
+
+	var chunks []*responses.ResponseChunk
+	for _, in := range []*Response{
+		thoughtChunk,
+		createGeminiTextChunk("resp_idx", "gemini-2.5-pro", "Let me ", 100, 10, 110),
+		createGeminiTextChunk("resp_idx", "gemini-2.5-pro", "check", 100, 15, 115),
+		fnChunk,
+		nil,
+	} {
+		chunks = append(chunks, converter.ResponseChunkToNativeResponseChunk(in)...)
+	}
+
+	// The items take consecutive output indices in the order they are added, every chunk of an item carries the
+	// output index of the item, and the content parts of an item take consecutive content indices
+	outputIndex, contentParts := -1, 0
+	var completed *responses.ChunkResponse[constants.ChunkTypeResponseCompleted]
+	for _, chunk := range chunks {
+		buf, err := sonic.Marshal(chunk)
+		require.NoError(t, err)
+
+		var indices struct {
+			OutputIndex  *int `json:"output_index"`
+			ContentIndex *int `json:"content_index"`
+		}
+		require.NoError(t, sonic.Unmarshal(buf, &indices))
+
+		switch chunk.ChunkType() {
+		case "response.output_item.added":
+			outputIndex++
+			contentParts = 0
+		case "response.content_part.added":
+			contentParts++
+		case "response.completed":
+			completed = chunk.OfResponseCompleted
+		}
+
+		if indices.OutputIndex != nil {
+			assert.Equal(t, outputIndex, *indices.OutputIndex, chunk.ChunkType())
+		}
+		if indices.ContentIndex != nil {
+			assert.Equal(t, contentParts-1, *indices.ContentIndex, chunk.ChunkType())
+		}
+	}
+
+	assert.Equal(t, 3, outputIndex)
+	require.NotNil(t, completed)
+	require.Len(t, completed.Response.Output, 4)
+	assert.Equal(t, "Let me check", completed.Response.Output[1].OfOutputMessage.Content[0].OfOutputText.Text)
+	assert.Equal(t, "get_weather", completed.Response.Output[2].OfFunctionCall.Name)
+	assert.Equal(t, "get_time", completed.Response.Output[3].OfFunctionCall.Name)
+}
+
 // =============================================================================
 // Test: Model Version Propagation
 // =============================================================================
@@ -470,3 +538,141 @@ func TestGeminiToNative_ModelVersionPropagation(t *testing.T) {
 	}
 	t.Fatal("Should have found response.created")
 }
+
+// =============================================================================
+// Test: Thinking Config → Reasoning Param
+// =============================================================================
+
+func TestGeminiToNative_ThinkingConfigReasoningEffort(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ThinkingConfig
+		effort string
+		budget *int
+	}{
+		{name: "dynamic thinking", config: &ThinkingConfig{}, effort: "high"},
+		{name: "low level", config: &ThinkingConfig{ThinkingLevel: utils.Ptr("LOW")}, effort: "low"},
+		{name: "medium level", config: &ThinkingConfig{ThinkingLevel: utils.Ptr("MEDIUM")}, effort: "medium"},
+		{name: "high level", config: &ThinkingConfig{ThinkingLevel: utils.Ptr("high")}, effort: "high"},
+		{name: "dynamic budget", config: &ThinkingConfig{ThinkingBudget: utils.Ptr(-1)}, effort: "high"},
+		{name: "thinking off", config: &ThinkingConfig{ThinkingBudget: utils.Ptr(0)}, effort: "none", budget: utils.Ptr(0)},
+		{name: "small budget", config: &ThinkingConfig{ThinkingBudget: utils.Ptr(3000)}, effort: "low", budget: utils.Ptr(3000)},
+		{name: "large budget", config: &ThinkingConfig{ThinkingBudget: utils.Ptr(24576)}, effort: "xhigh", budget: utils.Ptr(24576)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasoning := GeminiThinkingConfigToNativeReasoningParam(tt.config)
+
+			require.NotNil(t, reasoning)
+			assert.Equal(t, utils.Ptr(tt.effort), reasoning.Effort)
+			assert.Equal(t, tt.budget, reasoning.BudgetTokens)
+		})
+	}
+}
+
+// =============================================================================
+// Test: Tool Config → Tool Choice
+// =============================================================================
+
+func TestGeminiToNative_ToolConfigToolChoice(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ToolConfig
+		choice string
+	}{
+		{name: "no tool config", choice: "null"},
+		{name: "none", config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeNone}}, choice: `"none"`},
+		{name: "auto", config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeAuto}}, choice: `"auto"`},
+		{name: "any", config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeAny}}, choice: `"required"`},
+		{
+			name:   "any with one function",
+			config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeAny, AllowedFunctionNames: []string{"get_time"}}},
+			choice: `{"type":"function","name":"get_time"}`,
+		},
+		{
+			name:   "any with several functions",
+			config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeAny, AllowedFunctionNames: []string{"get_time", "get_weather"}}},
+			choice: `{"type":"allowed_tools","mode":"required","tools":[{"type":"function","name":"get_time"},{"type":"function","name":"get_weather"}]}`,
+		},
+		{
+			name:   "validated with functions",
+			config: &ToolConfig{FunctionCallingConfig: &FunctionCallingConfig{Mode: FunctionCallingModeValidated, AllowedFunctionNames: []string{"get_time"}}},
+			choice: `{"type":"allowed_tools","mode":"auto","tools":[{"type":"function","name":"get_time"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice := ToolConfigToNativeToolChoice(tt.config)
+
+			buf, err := sonic.Marshal(choice)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.choice, string(buf))
+		})
+	}
+}
+
+// =============================================================================
+// Test: Candidate Safety → Finish Metadata
+// =============================================================================
+
+func TestGeminiToNative_FinishMetadata(t *testing.T) {
+	var in Response
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"candidates": [{
+			"content": {"role": "model", "parts": [{"text": "I can't help"}]},
+			"finishReason": "SAFETY",
+			"finishMessage": "The response was blocked",
+			"safetyRatings": [
+				{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "probability": "HIGH", "blocked": true},
+				{"category": "HARM_CATEGORY_HARASSMENT", "probability": "NEGLIGIBLE"}
+			]
+		}],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 3, "totalTokenCount": 13},
+		"modelVersion": "gemini-2.5-flash",
+		"responseId": "resp_1"
+	}`), &in))
+
+	out := in.ToNativeResponse()
+	assert.Equal(t, "SAFETY", out.Metadata["stop_reason"])
+
+	finish := out.FinishMetadata()
+	require.NotNil(t, finish)
+	assert.Equal(t, "SAFETY", finish.FinishReason)
+	assert.Equal(t, "The response was blocked", finish.FinishMessage)
+	assert.Equal(t, []responses.SafetyRating{
+		{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Probability: "HIGH", Blocked: true},
+		{Category: "HARM_CATEGORY_HARASSMENT", Probability: "NEGLIGIBLE"},
+	}, finish.SafetyRatings)
+
+	// The metadata is typed again once the response went through JSON
+	buf, err := sonic.Marshal(out)
+	require.NoError(t, err)
+	var decoded responses.Response
+	require.NoError(t, sonic.Unmarshal(buf, &decoded))
+	assert.Equal(t, finish, decoded.FinishMetadata())
+}
+
+func TestGeminiToNative_BlockedPrompt(t *testing.T) {
+	in := &Response{
+		PromptFeedback: &PromptFeedback{
+			BlockReason:   "SAFETY",
+			SafetyRatings: []SafetyRating{{Category: "HARM_CATEGORY_HARASSMENT", Probability: "HIGH", Blocked: true}},
+		},
+		UsageMetadata: &UsageMetadata{PromptTokenCount: 10, TotalTokenCount: 10},
+		ModelVersion:  "gemini-2.5-flash",
+		ResponseID:    "resp_1",
+	}
+
+	out := in.ToNativeResponse()
+	assert.Empty(t, out.Output)
+	require.NotNil(t, out.FinishMetadata())
+	assert.Equal(t, "SAFETY", out.FinishMetadata().PromptBlockReason)
+
+	converter := newGeminiToNativeConverter()
+	chunks := converter.ResponseChunkToNativeResponseChunk(in)
+	require.Len(t, chunks, 2)
+	assert.NotNil(t, chunks[0].OfResponseCreated)
+	assert.NotNil(t, chunks[1].OfResponseInProgress)
+}
@@ -2,6 +2,7 @@ package gemini_responses
 
 import (
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -28,8 +29,14 @@ func ResponsesInputToGeminiResponsesInput(in *responses.Request) *Request {
 			TopP:            in.TopP,
 			TopK:            in.TopLogprobs,
 		},
-		Tools:  NativeToolsToTools(in.Tools),
-		Stream: in.Stream,
+		Tools:      NativeToolsToTools(in.Tools),
+		ToolConfig: NativeToolChoiceToToolConfig(in.ToolChoice),
+		Stream:     in.Stream,
+	}
+
+	// Gemini restricts the functions only in the mode ANY, the tools allowed in the mode AUTO are the only ones declared
+	if in.ToolChoice != nil && in.ToolChoice.OfAllowedTools != nil && in.ToolChoice.OfAllowedTools.Mode != responses.ToolChoiceModeRequired {
+		out.Tools = AllowedTools(out.Tools, in.ToolChoice.OfAllowedTools)
 	}
 
 	out.GenerationConfig.ThinkingConfig = NativeReasoningParamToGeminiThinkingConfig(in)
@@ -60,24 +67,37 @@ func ResponsesInputToGeminiResponsesInput(in *responses.Request) *Request {
 	return out
 }
 
+// geminiThinkingLevels maps the reasoning efforts to the thinking levels of Gemini, which only has a low and a high
+// level on every thinking model
+var geminiThinkingLevels = map[responses.ReasoningEffort]string{
+	responses.ReasoningEffortNone:    "LOW",
+	responses.ReasoningEffortMinimal: "LOW",
+	responses.ReasoningEffortLow:     "LOW",
+	responses.ReasoningEffortMedium:  "LOW",
+	responses.ReasoningEffortHigh:    "HIGH",
+	responses.ReasoningEffortXHigh:   "HIGH",
+}
+
+// NativeReasoningParamToGeminiThinkingConfig maps the reasoning param to a thinking budget when it has one, and to a
+// thinking level when it has an effort. Gemini rejects the requests setting both, and thinks dynamically without
+// any of them.
 func NativeReasoningParamToGeminiThinkingConfig(in *responses.Request) *ThinkingConfig {
 	if in.Reasoning == nil {
 		return nil
 	}
 
-	var effort string // "LOW" or "HIGH"
-	switch *in.Reasoning.Effort {
-	case "none", "minimal", "low", "medium":
-		effort = "LOW"
-	case "high", "xhigh":
-		effort = "HIGH"
+	out := &ThinkingConfig{
+		IncludeThoughts: utils.Ptr(true),
 	}
 
-	return &ThinkingConfig{
-		IncludeThoughts: utils.Ptr(true),
-		ThinkingBudget:  in.Reasoning.BudgetTokens,
-		ThinkingLevel:   utils.Ptr(effort),
+	switch {
+	case in.Reasoning.BudgetTokens != nil:
+		out.ThinkingBudget = utils.Ptr(*in.Reasoning.BudgetTokens)
+	case in.Reasoning.Effort != nil:
+		out.ThinkingLevel = utils.Ptr(geminiThinkingLevels[in.Reasoning.NormalizedEffort()])
 	}
+
+	return out
 }
 
 func NativeRoleToRole(role constants.Role) Role {
@@ -118,6 +138,91 @@ func NativeToolsToTools(nativeTools []responses.ToolUnion) []Tool {
 	return []Tool{out}
 }
 
+// NativeToolChoiceToToolConfig maps the tool choice to the function calling mode. Forcing a function, or requiring
+// one of the allowed tools, is the mode ANY restricted to their names.
+func NativeToolChoiceToToolConfig(in *responses.ToolChoiceUnion) *ToolConfig {
+	if in == nil {
+		return nil
+	}
+
+	config := &FunctionCallingConfig{}
+	switch {
+	case in.OfMode != nil:
+		switch *in.OfMode {
+		case responses.ToolChoiceModeNone:
+			config.Mode = FunctionCallingModeNone
+		case responses.ToolChoiceModeRequired:
+			config.Mode = FunctionCallingModeAny
+		default:
+			config.Mode = FunctionCallingModeAuto
+		}
+
+	case in.OfFunction != nil:
+		config.Mode = FunctionCallingModeAny
+		config.AllowedFunctionNames = []string{in.OfFunction.Name}
+
+	case in.OfAllowedTools != nil:
+		if in.OfAllowedTools.Mode != responses.ToolChoiceModeRequired {
+			config.Mode = FunctionCallingModeAuto
+			break
+		}
+
+		config.Mode = FunctionCallingModeAny
+		config.AllowedFunctionNames = in.OfAllowedTools.FunctionNames()
+
+	default:
+		return nil
+	}
+
+	return &ToolConfig{
+		FunctionCallingConfig: config,
+	}
+}
+
+// AllowedTools keeps the functions and the code execution of the tools when the tool choice allows them
+func AllowedTools(tools []Tool, allowed *responses.ToolChoiceAllowedTools) []Tool {
+	names := allowed.FunctionNames()
+	codeExecution := slices.ContainsFunc(allowed.Tools, func(tool responses.ToolChoiceAllowedTool) bool {
+		return tool.Type == "code_interpreter"
+	})
+
+	out := make([]Tool, 0, len(tools))
+	for _, tool := range tools {
+		functions := []FunctionTool{}
+		for _, fn := range tool.FunctionDeclarations {
+			if slices.Contains(names, fn.Name) {
+				functions = append(functions, fn)
+			}
+		}
+		tool.FunctionDeclarations = functions
+
+		if !codeExecution {
+			tool.CodeExecution = nil
+		}
+
+		out = append(out, tool)
+	}
+
+	return out
+}
+
+// NativeImageToInlineData converts the images of data URLs, the images by URL are inlined by the gateway before they
+// get here
+func NativeImageToInlineData(in *responses.InputImageContent) *InlinePartData {
+	if in.ImageURL == nil || !strings.HasPrefix(*in.ImageURL, "data:") {
+		slog.Warn("only data URLs of images are supported for gemini models")
+		return nil
+	}
+
+	mimeType, data, ok := strings.Cut(strings.TrimPrefix(*in.ImageURL, "data:"), ";base64,")
+	if !ok {
+		slog.Warn("unable to decode image data URL for gemini models")
+		return nil
+	}
+
+	return &InlinePartData{MimeType: mimeType, Data: data}
+}
+
 func NativeMessagesToMessages(in responses.InputUnion) []Content {
 	out := []Content{}
 
@@ -158,6 +263,12 @@ func NativeMessagesToMessages(in responses.InputUnion) []Content {
 								Text: utils.Ptr(nativeContent.OfOutputText.Text),
 							})
 						}
+
+						if nativeContent.OfInputImage != nil {
+							if data := NativeImageToInlineData(nativeContent.OfInputImage); data != nil {
+								parts = append(parts, Part{InlineData: data})
+							}
+						}
 					}
 				}
 
@@ -183,6 +294,12 @@ func NativeMessagesToMessages(in responses.InputUnion) []Content {
 							Text: utils.Ptr(nativeContent.OfOutputText.Text),
 						})
 					}
+
+					if nativeContent.OfInputImage != nil {
+						if data := NativeImageToInlineData(nativeContent.OfInputImage); data != nil {
+							parts = append(parts, Part{InlineData: data})
+						}
+					}
 				}
 
 				out = append(out, Content{
@@ -351,6 +468,32 @@ func NativeResponseToResponse(in *responses.Response) *Response {
 		}
 	}
 
+	candidate := Candidate{
+		Content: Content{
+			Role:  RoleModel,
+			Parts: parts,
+		},
+		FinishReason: stopReason,
+	}
+
+	var promptFeedback *PromptFeedback
+	if finish := in.FinishMetadata(); finish != nil {
+		candidate.FinishMessage = finish.FinishMessage
+		candidate.SafetyRatings = NativeSafetyRatingsToSafetyRatings(finish.SafetyRatings)
+
+		if finish.PromptBlockReason != "" {
+			promptFeedback = &PromptFeedback{
+				BlockReason:   finish.PromptBlockReason,
+				SafetyRatings: NativeSafetyRatingsToSafetyRatings(finish.PromptSafetyRatings),
+			}
+		}
+	}
+
+	candidates := []Candidate{candidate}
+	if promptFeedback != nil && len(parts) == 0 {
+		candidates = []Candidate{}
+	}
+
 	return &Response{
 		ModelVersion: in.Model,
 		ResponseID:   in.ID,
@@ -361,19 +504,29 @@ func NativeResponseToResponse(in *responses.Response) *Response {
 			PromptTokensDetails:  nil,
 			ThoughtsTokenCount:   in.Usage.OutputTokensDetails.ReasoningTokens,
 		},
-		Candidates: []Candidate{
-			{
-				Content: Content{
-					Role:  RoleModel,
-					Parts: parts,
-				},
-				FinishReason: stopReason,
-			},
-		},
-		Error: nil,
+		Candidates:     candidates,
+		PromptFeedback: promptFeedback,
+		Error:          nil,
 	}
 }
 
+func NativeSafetyRatingsToSafetyRatings(in []responses.SafetyRating) []SafetyRating {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make([]SafetyRating, 0, len(in))
+	for _, rating := range in {
+		out = append(out, SafetyRating{
+			Category:    rating.Category,
+			Probability: rating.Probability,
+			Blocked:     rating.Blocked,
+		})
+	}
+
+	return out
+}
+
 // =============================================================================
 // Native to Gemini ResponseChunk Conversion
 // =============================================================================
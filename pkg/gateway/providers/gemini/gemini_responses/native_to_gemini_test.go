@@ -301,3 +301,177 @@ func TestNativeToGemini_PartsOnlyWithContent(t *testing.T) {
 	// (Gemini sends complete function calls in one chunk)
 	assert.Len(t, result, 0)
 }
+
+// =============================================================================
+// Test: Reasoning Param → Thinking Config
+// =============================================================================
+
+func TestNativeToGemini_ReasoningThinkingConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		reasoning *responses.ReasoningParam
+		level     *string
+		budget    *int
+	}{
+		{name: "no reasoning"},
+		{name: "minimal effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("minimal")}, level: utils.Ptr("LOW")},
+		{name: "low effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("low")}, level: utils.Ptr("LOW")},
+		{name: "medium effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("medium")}, level: utils.Ptr("LOW")},
+		{name: "high effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high")}, level: utils.Ptr("HIGH")},
+		{name: "effort is case insensitive", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("High")}, level: utils.Ptr("HIGH")},
+		{name: "no effort thinks dynamically", reasoning: &responses.ReasoningParam{Summary: utils.Ptr("auto")}},
+		{name: "budget wins over effort", reasoning: &responses.ReasoningParam{Effort: utils.Ptr("high"), BudgetTokens: utils.Ptr(2048)}, budget: utils.Ptr(2048)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NativeReasoningParamToGeminiThinkingConfig(&responses.Request{
+				Parameters: responses.Parameters{Reasoning: tt.reasoning},
+			})
+			if tt.reasoning == nil {
+				assert.Nil(t, config)
+				return
+			}
+
+			require.NotNil(t, config)
+			assert.Equal(t, tt.level, config.ThinkingLevel)
+			assert.Equal(t, tt.budget, config.ThinkingBudget)
+			assert.Equal(t, utils.Ptr(true), config.IncludeThoughts)
+		})
+	}
+}
+
+// =============================================================================
+// Test: Tool Choice → Tool Config
+// =============================================================================
+
+func TestNativeToGemini_ToolChoiceToolConfig(t *testing.T) {
+	allowed := func(mode responses.ToolChoiceMode, types ...string) *responses.ToolChoiceUnion {
+		choice := &responses.ToolChoiceAllowedTools{Mode: mode}
+		for _, typ := range types {
+			if typ == "code_interpreter" {
+				choice.Tools = append(choice.Tools, responses.ToolChoiceAllowedTool{Type: typ})
+				continue
+			}
+			choice.Tools = append(choice.Tools, responses.ToolChoiceAllowedTool{Type: "function", Name: typ})
+		}
+		return &responses.ToolChoiceUnion{OfAllowedTools: choice}
+	}
+
+	tests := []struct {
+		name          string
+		choice        *responses.ToolChoiceUnion
+		config        *FunctionCallingConfig
+		functions     []string
+		codeExecution bool
+	}{
+		{name: "no tool choice", functions: []string{"get_weather", "get_time"}, codeExecution: true},
+		{name: "none", choice: &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeNone)}, config: &FunctionCallingConfig{Mode: FunctionCallingModeNone}, functions: []string{"get_weather", "get_time"}, codeExecution: true},
+		{name: "auto", choice: &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeAuto)}, config: &FunctionCallingConfig{Mode: FunctionCallingModeAuto}, functions: []string{"get_weather", "get_time"}, codeExecution: true},
+		{name: "required", choice: &responses.ToolChoiceUnion{OfMode: utils.Ptr(responses.ToolChoiceModeRequired)}, config: &FunctionCallingConfig{Mode: FunctionCallingModeAny}, functions: []string{"get_weather", "get_time"}, codeExecution: true},
+		{
+			name:          "forced function",
+			choice:        &responses.ToolChoiceUnion{OfFunction: &responses.ToolChoiceFunction{Name: "get_time"}},
+			config:        &FunctionCallingConfig{Mode: FunctionCallingModeAny, AllowedFunctionNames: []string{"get_time"}},
+			functions:     []string{"get_weather", "get_time"},
+			codeExecution: true,
+		},
+		{
+			name:          "allowed tools required",
+			choice:        allowed(responses.ToolChoiceModeRequired, "get_weather"),
+			config:        &FunctionCallingConfig{Mode: FunctionCallingModeAny, AllowedFunctionNames: []string{"get_weather"}},
+			functions:     []string{"get_weather", "get_time"},
+			codeExecution: true,
+		},
+		{
+			name:      "allowed tools auto declares only them",
+			choice:    allowed(responses.ToolChoiceModeAuto, "get_weather"),
+			config:    &FunctionCallingConfig{Mode: FunctionCallingModeAuto},
+			functions: []string{"get_weather"},
+		},
+		{
+			name:          "allowed tools auto keeps code execution",
+			choice:        allowed(responses.ToolChoiceModeAuto, "get_time", "code_interpreter"),
+			config:        &FunctionCallingConfig{Mode: FunctionCallingModeAuto},
+			functions:     []string{"get_time"},
+			codeExecution: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := ResponsesInputToGeminiResponsesInput(&responses.Request{
+				Model: "gemini-2.5-flash",
+				Input: responses.InputUnion{OfString: utils.Ptr("What time is it?")},
+				Tools: []responses.ToolUnion{
+					{OfFunction: &responses.FunctionTool{Name: "get_weather", Description: utils.Ptr("Weather of a city")}},
+					{OfFunction: &responses.FunctionTool{Name: "get_time", Description: utils.Ptr("Time of a city")}},
+					{OfCodeExecution: &responses.CodeExecutionTool{}},
+				},
+				Parameters: responses.Parameters{ToolChoice: tt.choice},
+			})
+
+			if tt.config == nil {
+				assert.Nil(t, out.ToolConfig)
+			} else {
+				require.NotNil(t, out.ToolConfig)
+				assert.Equal(t, tt.config, out.ToolConfig.FunctionCallingConfig)
+			}
+
+			require.Len(t, out.Tools, 1)
+			functions := []string{}
+			for _, fn := range out.Tools[0].FunctionDeclarations {
+				functions = append(functions, fn.Name)
+			}
+			assert.Equal(t, tt.functions, functions)
+			assert.Equal(t, tt.codeExecution, out.Tools[0].CodeExecution != nil)
+		})
+	}
+}
+
+// =============================================================================
+// Test: Finish Metadata → Candidate
+// =============================================================================
+
+func TestNativeToGemini_FinishMetadata(t *testing.T) {
+	ratings := []responses.SafetyRating{{Category: "HARM_CATEGORY_HARASSMENT", Probability: "HIGH", Blocked: true}}
+
+	out := NativeResponseToResponse(&responses.Response{
+		ID:     "resp_1",
+		Model:  "gemini-2.5-flash",
+		Output: []responses.OutputMessageUnion{},
+		Usage:  &responses.Usage{},
+		Metadata: map[string]any{
+			"stop_reason": "SAFETY",
+			responses.MetadataKeyFinish: map[string]any{
+				"finish_reason":         "SAFETY",
+				"safety_ratings":        ratings,
+				"prompt_block_reason":   "SAFETY",
+				"prompt_safety_ratings": ratings,
+			},
+		},
+	})
+
+	assert.Empty(t, out.Candidates)
+	require.NotNil(t, out.PromptFeedback)
+	assert.Equal(t, "SAFETY", out.PromptFeedback.BlockReason)
+	assert.Equal(t, []SafetyRating{{Category: "HARM_CATEGORY_HARASSMENT", Probability: "HIGH", Blocked: true}}, out.PromptFeedback.SafetyRatings)
+}
+
+// =============================================================================
+// Test: Input Images Become Inline Data
+// =============================================================================
+
+func TestNativeToGemini_InputImage(t *testing.T) {
+	out := NativeMessagesToMessages(responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+		{OfInputMessage: &responses.InputMessage{Role: constants.RoleUser, Content: responses.InputContent{
+			{OfInputText: &responses.InputTextContent{Text: "What is this?"}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: utils.Ptr("data:image/jpeg;base64,/9j/4AAQ")}},
+			{OfInputImage: &responses.InputImageContent{ImageURL: utils.Ptr("https://example.com/cat.jpg")}},
+		}}},
+	}})
+
+	require.Len(t, out, 1)
+	require.Len(t, out[0].Parts, 2)
+	assert.Equal(t, &InlinePartData{MimeType: "image/jpeg", Data: "/9j/4AAQ"}, out[0].Parts[1].InlineData)
+}
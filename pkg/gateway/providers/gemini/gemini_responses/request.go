@@ -6,6 +6,7 @@ type Request struct {
 	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
 	Contents          []Content         `json:"contents"`
 	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
 	Stream            *bool             `json:"-"`
 }
 
@@ -111,3 +112,21 @@ type FunctionTool struct {
 
 type CodeExecutionTool struct {
 }
+
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+type FunctionCallingMode string
+
+const (
+	FunctionCallingModeAuto      FunctionCallingMode = "AUTO"
+	FunctionCallingModeAny       FunctionCallingMode = "ANY"
+	FunctionCallingModeNone      FunctionCallingMode = "NONE"
+	FunctionCallingModeValidated FunctionCallingMode = "VALIDATED"
+)
+
+type FunctionCallingConfig struct {
+	Mode                 FunctionCallingMode `json:"mode,omitempty"`
+	AllowedFunctionNames []string            `json:"allowedFunctionNames,omitempty"` // Only with the modes ANY and VALIDATED
+}
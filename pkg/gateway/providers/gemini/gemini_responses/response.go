@@ -1,16 +1,31 @@
 package gemini_responses
 
 type Response struct {
-	Candidates    []Candidate    `json:"candidates"`
-	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
-	ModelVersion  string         `json:"modelVersion"`
-	ResponseID    string         `json:"responseId"`
-	Error         *Error         `json:"error,omitempty"`
+	Candidates     []Candidate     `json:"candidates"`
+	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion"`
+	ResponseID     string          `json:"responseId"`
+	Error          *Error          `json:"error,omitempty"`
 }
 
 type Candidate struct {
-	Content      Content `json:"content"`
-	FinishReason string  `json:"finishReason,omitempty"`
+	Content       Content        `json:"content"`
+	FinishReason  string         `json:"finishReason,omitempty"`
+	FinishMessage string         `json:"finishMessage,omitempty"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+}
+
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// PromptFeedback is set when the prompt was blocked, the response has no candidates then
+type PromptFeedback struct {
+	BlockReason   string         `json:"blockReason,omitempty"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
 }
 
 type UsageMetadata struct {
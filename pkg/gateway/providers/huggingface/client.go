@@ -0,0 +1,40 @@
+package huggingface
+
+import (
+	"net/http"
+
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+)
+
+// DefaultBaseURL is the OpenAI-compatible router of the serverless Inference Providers
+const DefaultBaseURL = "https://router.huggingface.co/v1"
+
+type ClientOptions struct {
+	// https://router.huggingface.co/v1, or the /v1 URL of an Inference Endpoint
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+}
+
+// Client calls the Hugging Face Inference API, which serves the OpenAI API for the hosted open models
+type Client struct {
+	*openai.Client
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		Client: openai.NewClient(&openai.ClientOptions{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.HTTPClient,
+		}),
+	}
+}
@@ -0,0 +1,100 @@
+package mistral
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/mistral/mistral_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const DefaultBaseURL = "https://api.mistral.ai/v1"
+
+type ClientOptions struct {
+	// https://api.mistral.ai/v1
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client calls the Mistral API. The chat completions and the embeddings are the ones of the OpenAI API, the
+// responses are translated to chat completions, which is the only API of Mistral serving the models.
+type Client struct {
+	*openai.Client
+	chat *chat_responses.Client
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		Client: openai.NewClient(&openai.ClientOptions{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		}),
+		chat: &chat_responses.Client{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		},
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	res, err := c.post(ctx, inp, false)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var mistralResponse *chat_responses.Response
+	err = utils.DecodeJSON(res.Body, &mistralResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return mistralResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	res, err := c.post(ctx, inp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return chat_responses.Stream[chat_responses.ResponseChunk](ctx, res, &chat_responses.ResponseChunkToNativeResponseChunkConverter{}), nil
+}
+
+// post sends the chat completion request of the responses request
+func (c *Client) post(ctx context.Context, inp *responses.Request, stream bool) (*http.Response, error) {
+	mistralRequest := mistral_responses.NativeRequestToRequest(inp)
+	mistralRequest.Stream = stream
+
+	payload, err := sonic.Marshal(mistralRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.chat.Post(ctx, payload, stream)
+}
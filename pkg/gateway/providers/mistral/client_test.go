@@ -0,0 +1,98 @@
+package mistral
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/gateway/providers/mistral/mistral_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responsesRequest(t *testing.T) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "mistral-large-latest",
+		"instructions": "Be brief",
+		"input": [
+			{"type": "message", "role": "user", "content": "Weather in Paris?"},
+			{"type": "function_call", "call_id": "call_0123456789abcdef", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+			{"type": "function_call_output", "call_id": "call_0123456789abcdef", "output": "Sunny"}
+		],
+		"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}],
+		"tool_choice": "required"
+	}`), &req))
+	return &req
+}
+
+func TestClient_NewResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+		assert.Equal(t, "any", payload["tool_choice"])
+
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 4)
+
+		// The call ID is mapped to an ID accepted by Mistral, the same for the call and its output
+		assistant := messages[2].(map[string]any)
+		callID := assistant["tool_calls"].([]any)[0].(map[string]any)["id"].(string)
+		assert.Regexp(t, `^[a-zA-Z0-9]{9}$`, callID)
+		tool := messages[3].(map[string]any)
+		assert.Equal(t, "tool", tool["role"])
+		assert.Equal(t, callID, tool["tool_call_id"])
+		assert.Equal(t, "get_weather", tool["name"])
+
+		_, _ = w.Write([]byte(`{
+			"id": "cmpl_1",
+			"object": "chat.completion",
+			"model": "mistral-large-latest",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": "",
+					"tool_calls": [{"id": "D681PevKs", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Lyon\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 8, "total_tokens": 28}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "mistral-key"})
+	out, err := client.NewResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	require.Len(t, out.Output, 1)
+	assert.Equal(t, "D681PevKs", out.Output[0].OfFunctionCall.CallID)
+}
+
+func TestToolCallID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{name: "mistral", id: "D681PevKs"},
+		{name: "openai", id: "call_0123456789abcdef"},
+		{name: "anthropic", id: "toolu_01A09q90qw90lq917835lq9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := mistral_responses.ToolCallID(tt.id)
+			assert.Regexp(t, `^[a-zA-Z0-9]{9}$`, id)
+			assert.Equal(t, id, mistral_responses.ToolCallID(tt.id))
+			assert.Equal(t, id, mistral_responses.ToolCallID(id))
+		})
+	}
+
+	assert.Equal(t, "D681PevKs", mistral_responses.ToolCallID("D681PevKs"))
+}
@@ -0,0 +1,44 @@
+package mistral_responses
+
+import (
+	"crypto/sha256"
+	"regexp"
+
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Options are the differences of the chat completions API of Mistral: "required" is its "any" tool choice mode, the
+// IDs of the tool calls are 9 alphanumeric characters, and the reasoning leads the assistant messages as thinking
+var Options = chat_responses.Options{
+	Provider:           "mistral",
+	RequiredToolChoice: "any",
+	ToolCallID:         ToolCallID,
+	Thinking:           true,
+}
+
+// NativeRequestToRequest converts the request to the chat completions API of Mistral, see
+// https://docs.mistral.ai/api/#tag/chat/operation/chat_completion_v1_chat_completions_post
+func NativeRequestToRequest(in *responses.Request) *chat_responses.Request {
+	return chat_responses.NativeRequestToRequest(in, Options)
+}
+
+var toolCallIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{9}$`)
+
+const toolCallIDAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// ToolCallID returns the ID of a tool call for Mistral, which only accepts IDs of 9 alphanumeric characters. The IDs
+// of the other providers, e.g. of a conversation started with another model, are mapped to an ID derived from their
+// hash, so that the calls and their results still match.
+func ToolCallID(id string) string {
+	if toolCallIDPattern.MatchString(id) {
+		return id
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	out := make([]byte, 9)
+	for i := range out {
+		out[i] = toolCallIDAlphabet[int(sum[i])%len(toolCallIDAlphabet)]
+	}
+	return string(out)
+}
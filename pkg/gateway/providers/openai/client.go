@@ -10,6 +10,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/bytedance/sonic"
@@ -31,6 +32,16 @@ type ClientOptions struct {
 	ApiKey  string
 	Headers map[string]string
 
+	// GuidedDecoding is set for OpenAI-compatible servers that accept guided_regex and guided_grammar (e.g. vLLM)
+	GuidedDecoding bool
+
+	// ExtraParams are the names of the extra params accepted by the server, see VLLMExtraParams. The other extra
+	// params of a request are dropped.
+	ExtraParams []string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
 	transport *http.Client
 }
 
@@ -40,6 +51,9 @@ type Client struct {
 }
 
 func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
 	if opts.transport == nil {
 		opts.transport = http.DefaultClient
 	}
@@ -53,15 +67,56 @@ func NewClient(opts *ClientOptions) *Client {
 	}
 }
 
+// SupportsConstraint reports whether the server enforces the constraint while generating. The OpenAI API only
+// constrains JSON output, regex and grammar constraints are enforced by OpenAI-compatible servers such as vLLM.
+func (c *Client) SupportsConstraint(constraint *responses.Constraint) bool {
+	if !c.opts.GuidedDecoding {
+		return false
+	}
+
+	return constraint.Type == responses.ConstraintTypeRegex || constraint.Type == responses.ConstraintTypeGrammar
+}
+
+// errorMessage reads the message of an error response. Some OpenAI-compatible servers, such as the Hugging Face
+// router, send the error as a string.
+func errorMessage(errResp map[string]any) string {
+	switch e := errResp["error"].(type) {
+	case map[string]any:
+		if message, ok := e["message"].(string); ok {
+			return message
+		}
+	case string:
+		return e
+	}
+
+	return "unknown error occurred"
+}
+
+// extraParams keeps the extra params accepted by the server
+func (c *Client) extraParams(params map[string]any) map[string]any {
+	if len(params) == 0 || len(c.opts.ExtraParams) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(params))
+	for name, value := range params {
+		if slices.Contains(c.opts.ExtraParams, name) {
+			out[name] = value
+		}
+	}
+
+	return out
+}
+
 func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
 	openAiRequest := openai_responses.NativeRequestToRequest(inp)
 
-	payload, err := sonic.Marshal(openAiRequest)
+	payload, err := openAiRequest.MarshalWithExtraParams(c.extraParams(inp.ExtraParams))
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +129,14 @@ func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*res
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode != http.StatusOK {
+		var errResp map[string]any
+		if err = utils.DecodeJSON(res.Body, &errResp); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(errorMessage(errResp))
+	}
+
 	var openAiResponse *openai_responses.Response
 	err = utils.DecodeJSON(res.Body, &openAiResponse)
 	if err != nil {
@@ -90,12 +153,12 @@ func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*res
 func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
 	openAiRequest := openai_responses.NativeRequestToRequest(inp)
 
-	payload, err := sonic.Marshal(openAiRequest)
+	payload, err := openAiRequest.MarshalWithExtraParams(c.extraParams(inp.ExtraParams))
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -110,8 +173,10 @@ func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Reque
 
 	if res.StatusCode != http.StatusOK {
 		var errResp map[string]any
-		err = utils.DecodeJSON(res.Body, &errResp)
-		return nil, errors.New(errResp["error"].(map[string]any)["message"].(string))
+		if err = utils.DecodeJSON(res.Body, &errResp); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(errorMessage(errResp))
 	}
 
 	out := make(chan *responses.ResponseChunk)
@@ -152,7 +217,7 @@ func (c *Client) NewEmbedding(ctx context.Context, inp *embeddings.Request) (*em
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/embeddings", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/embeddings", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -168,8 +233,10 @@ func (c *Client) NewEmbedding(ctx context.Context, inp *embeddings.Request) (*em
 
 	if res.StatusCode != http.StatusOK {
 		var errResp map[string]any
-		err = utils.DecodeJSON(res.Body, &errResp)
-		return nil, errors.New(errResp["error"].(map[string]any)["message"].(string))
+		if err = utils.DecodeJSON(res.Body, &errResp); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(errorMessage(errResp))
 	}
 
 	var openAiResponse *openai_embeddings.Response
@@ -189,7 +256,7 @@ func (c *Client) NewChatCompletion(ctx context.Context, inp *chat_completion.Req
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/chat/completions", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/chat/completions", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -234,7 +301,7 @@ func (c *Client) NewStreamingChatCompletion(ctx context.Context, inp *chat_compl
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/chat/completions", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/chat/completions", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +371,7 @@ func (c *Client) NewSpeech(ctx context.Context, in *speech.Request) (*speech.Res
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/audio/speech", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/audio/speech", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -368,7 +435,7 @@ func (c *Client) NewStreamingSpeech(ctx context.Context, in *speech.Request) (ch
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/audio/speech", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/audio/speech", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
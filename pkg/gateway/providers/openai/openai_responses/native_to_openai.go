@@ -1,13 +1,109 @@
 package openai_responses
 
 import (
+	"github.com/curaious/uno/internal/utils"
 	"github.com/curaious/uno/pkg/llm/responses"
 )
 
 func NativeRequestToRequest(in *responses.Request) *Request {
-	return &Request{
-		*in,
+	out := &Request{
+		Request: *in,
+	}
+
+	out.Input = NativeInputToInput(in.Input)
+	out.Reasoning = NativeReasoningParamToReasoningParam(in.Reasoning)
+
+	if c := in.Constraint; c != nil {
+		switch c.Type {
+		case responses.ConstraintTypeRegex:
+			out.GuidedRegex = &c.Definition
+		case responses.ConstraintTypeGrammar:
+			out.GuidedGrammar = &c.Definition
+		}
+	}
+
+	return out
+}
+
+// NativeReasoningParamToReasoningParam maps a reasoning budget to the effort covering it, OpenAI has no budgets. The
+// model picks its default effort when neither is set.
+func NativeReasoningParamToReasoningParam(in *responses.ReasoningParam) *responses.ReasoningParam {
+	if in == nil {
+		return nil
+	}
+
+	out := &responses.ReasoningParam{
+		Summary: in.Summary,
+	}
+	if in.Effort != nil || in.BudgetTokens != nil {
+		out.Effort = utils.Ptr(string(in.NormalizedEffort()))
 	}
+
+	return out
+}
+
+// NativeInputToInput drops the extra params of the annotations and of the web search sources. They carry the data
+// of the other providers, and OpenAI rejects the parameters it doesn't know. The messages of the caller aren't modified.
+func NativeInputToInput(in responses.InputUnion) responses.InputUnion {
+	if len(in.OfInputMessageList) == 0 {
+		return in
+	}
+
+	msgs := make(responses.InputMessageList, 0, len(in.OfInputMessageList))
+	for _, msg := range in.OfInputMessageList {
+		switch {
+		case msg.OfOutputMessage != nil:
+			outputMessage := *msg.OfOutputMessage
+			outputMessage.Content = make(responses.OutputContent, len(msg.OfOutputMessage.Content))
+			for i, content := range msg.OfOutputMessage.Content {
+				if content.OfOutputText != nil {
+					content.OfOutputText = nativeOutputTextToOutputText(content.OfOutputText)
+				}
+				outputMessage.Content[i] = content
+			}
+			msg.OfOutputMessage = &outputMessage
+
+		case msg.OfInputMessage != nil:
+			inputMessage := *msg.OfInputMessage
+			inputMessage.Content = make(responses.InputContent, len(msg.OfInputMessage.Content))
+			for i, content := range msg.OfInputMessage.Content {
+				if content.OfOutputText != nil {
+					content.OfOutputText = nativeOutputTextToOutputText(content.OfOutputText)
+				}
+				inputMessage.Content[i] = content
+			}
+			msg.OfInputMessage = &inputMessage
+
+		case msg.OfWebSearchCall != nil && msg.OfWebSearchCall.Action.OfSearch != nil:
+			search := *msg.OfWebSearchCall.Action.OfSearch
+			search.Sources = make([]responses.WebSearchCallActionOfSearchSource, len(msg.OfWebSearchCall.Action.OfSearch.Sources))
+			for i, source := range msg.OfWebSearchCall.Action.OfSearch.Sources {
+				source.ExtraParams = nil
+				search.Sources[i] = source
+			}
+
+			webSearchCall := *msg.OfWebSearchCall
+			webSearchCall.Action = responses.WebSearchCallActionUnion{OfSearch: &search}
+			msg.OfWebSearchCall = &webSearchCall
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	return responses.InputUnion{
+		OfInputMessageList: msgs,
+	}
+}
+
+func nativeOutputTextToOutputText(in *responses.OutputTextContent) *responses.OutputTextContent {
+	out := *in
+	out.Annotations = make([]responses.Annotation, len(in.Annotations))
+	for i, annotation := range in.Annotations {
+		annotation.ExtraParams = nil
+		out.Annotations[i] = annotation
+	}
+
+	return &out
 }
 
 func NativeResponseToResponse(in *responses.Response) *Response {
@@ -0,0 +1,313 @@
+package openai_responses
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// =============================================================================
+// Fixtures captured from the OpenAI Responses API
+// =============================================================================
+
+const requestWithSearchToolsFixture = `{
+	"model": "gpt-4.1",
+	"input": "What does the handbook say about travel, and what is the news today?",
+	"tools": [
+		{
+			"type": "web_search",
+			"filters": {"allowed_domains": ["openai.com"]},
+			"user_location": {"type": "approximate", "country": "US", "city": "San Francisco", "region": "California", "timezone": "America/Los_Angeles"},
+			"search_context_size": "low"
+		},
+		{
+			"type": "file_search",
+			"vector_store_ids": ["vs_1234567890"],
+			"max_num_results": 5,
+			"filters": {"type": "eq", "key": "department", "value": "hr"},
+			"ranking_options": {"ranker": "auto", "score_threshold": 0.5}
+		}
+	],
+	"include": ["file_search_call.results", "web_search_call.action.sources"]
+}`
+
+const webSearchCallFixture = `{
+	"type": "web_search_call",
+	"id": "ws_67c9fa0502748190b7dd390736892e100be649c1a5ff9609",
+	"status": "completed",
+	"action": {
+		"type": "search",
+		"query": "positive news today",
+		"sources": [{"type": "url", "url": "https://www.example.com/news"}]
+	}
+}`
+
+const fileSearchCallFixture = `{
+	"type": "file_search_call",
+	"id": "fs_67c09ccea8c48191ade9367e3ba71515",
+	"status": "completed",
+	"queries": ["travel policy"],
+	"results": [
+		{
+			"file_id": "file-2dtbBZdjtDKS8eqWxqbgDi",
+			"filename": "handbook.pdf",
+			"score": 0.92,
+			"text": "Employees may book economy class flights.",
+			"attributes": {"department": "hr"}
+		}
+	]
+}`
+
+const urlCitationFixture = `{
+	"type": "url_citation",
+	"title": "Good news",
+	"url": "https://www.example.com/news",
+	"start_index": 12,
+	"end_index": 64
+}`
+
+const fileCitationFixture = `{
+	"type": "file_citation",
+	"file_id": "file-2dtbBZdjtDKS8eqWxqbgDi",
+	"filename": "handbook.pdf",
+	"index": 40
+}`
+
+const containerFileCitationFixture = `{
+	"type": "container_file_citation",
+	"container_id": "cntr_682d0e7318108198aa783fd921ff305e",
+	"file_id": "cfile_682d0e8a43c88198a7ab1d4e1d4d4e8f",
+	"filename": "chart.png",
+	"start_index": 0,
+	"end_index": 31
+}`
+
+const responseWithSearchOutputFixture = `{
+	"id": "resp_67ccd3a9da748190baa7f1570fe91ac604becb25c45c1d41",
+	"object": "response",
+	"model": "gpt-4.1-2025-04-14",
+	"status": "completed",
+	"output": [
+		` + webSearchCallFixture + `,
+		` + fileSearchCallFixture + `,
+		{
+			"type": "message",
+			"id": "msg_67ccd3acc8d48190a77525dc6de64b4104becb25c45c1d41",
+			"status": "completed",
+			"role": "assistant",
+			"content": [
+				{
+					"type": "output_text",
+					"text": "Economy flights only, and the news is good.",
+					"annotations": [` + fileCitationFixture + `, ` + urlCitationFixture + `, ` + containerFileCitationFixture + `]
+				}
+			]
+		}
+	],
+	"usage": {"input_tokens": 328, "output_tokens": 52, "total_tokens": 380}
+}`
+
+func marshalJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	buf, err := sonic.Marshal(v)
+	require.NoError(t, err)
+
+	return string(buf)
+}
+
+// =============================================================================
+// Round trip tests
+// =============================================================================
+
+func TestNativeRequestToRequest_SearchToolsRoundTrip(t *testing.T) {
+	var in Request
+	require.NoError(t, sonic.Unmarshal([]byte(requestWithSearchToolsFixture), &in))
+
+	native := in.ToNativeRequest()
+	require.Len(t, native.Tools, 2)
+	require.NotNil(t, native.Tools[0].OfWebSearch)
+	require.NotNil(t, native.Tools[1].OfFileSearch)
+	assert.Equal(t, []string{"vs_1234567890"}, native.Tools[1].OfFileSearch.VectorStoreIDs)
+	assert.Equal(t, []responses.Includable{responses.IncludableFileSearchCallResults, responses.IncludableWebSearchCallActionSources}, native.Include)
+
+	var expected, actual map[string]any
+	require.NoError(t, sonic.Unmarshal([]byte(requestWithSearchToolsFixture), &expected))
+	require.NoError(t, sonic.Unmarshal([]byte(marshalJSON(t, NativeRequestToRequest(native))), &actual))
+
+	assert.JSONEq(t, marshalJSON(t, expected["tools"]), marshalJSON(t, actual["tools"]))
+	assert.JSONEq(t, marshalJSON(t, expected["include"]), marshalJSON(t, actual["include"]))
+}
+
+func TestResponseToNative_SearchOutputRoundTrip(t *testing.T) {
+	var in Response
+	require.NoError(t, sonic.Unmarshal([]byte(responseWithSearchOutputFixture), &in))
+
+	native := in.ToNativeResponse()
+	require.Len(t, native.Output, 3)
+	require.NotNil(t, native.Output[0].OfWebSearchCall)
+	require.NotNil(t, native.Output[1].OfFileSearchCall)
+	require.NotNil(t, native.Output[2].OfOutputMessage)
+
+	fileSearchCall := native.Output[1].OfFileSearchCall
+	assert.Equal(t, []string{"travel policy"}, fileSearchCall.Queries)
+	require.Len(t, fileSearchCall.Results, 1)
+	assert.Equal(t, "handbook.pdf", fileSearchCall.Results[0].Filename)
+
+	annotations := native.Output[2].OfOutputMessage.Content[0].OfOutputText.Annotations
+	require.Len(t, annotations, 3)
+	assert.Equal(t, "file-2dtbBZdjtDKS8eqWxqbgDi", annotations[0].FileID)
+	assert.Equal(t, 40, annotations[0].Index)
+	assert.Equal(t, "https://www.example.com/news", annotations[1].URL)
+	assert.Equal(t, "cntr_682d0e7318108198aa783fd921ff305e", annotations[2].ContainerID)
+
+	// The output is sent back as the input of the next turn
+	input := responses.InputMessageList{}
+	for _, output := range native.Output {
+		msg, err := output.AsInput()
+		require.NoError(t, err)
+		input = append(input, msg)
+	}
+
+	out := NativeRequestToRequest(&responses.Request{
+		Model: "gpt-4.1",
+		Input: responses.InputUnion{OfInputMessageList: input},
+	})
+
+	var payload struct {
+		Input []map[string]any `json:"input"`
+	}
+	require.NoError(t, sonic.Unmarshal([]byte(marshalJSON(t, out)), &payload))
+	require.Len(t, payload.Input, 3)
+
+	assert.JSONEq(t, webSearchCallFixture, marshalJSON(t, payload.Input[0]))
+	assert.JSONEq(t, fileSearchCallFixture, marshalJSON(t, payload.Input[1]))
+
+	content := payload.Input[2]["content"].([]any)[0].(map[string]any)
+	assert.JSONEq(t, `[`+fileCitationFixture+`, `+urlCitationFixture+`, `+containerFileCitationFixture+`]`, marshalJSON(t, content["annotations"]))
+}
+
+func TestNativeRequestToRequest_DropsExtraParams(t *testing.T) {
+	annotation := responses.Annotation{
+		Type:        "url_citation",
+		Title:       "Good news",
+		URL:         "https://www.example.com/news",
+		ExtraParams: map[string]any{"Anthropic": map[string]any{"cited_text": "good news"}},
+	}
+	source := responses.WebSearchCallActionOfSearchSource{
+		Type:        "url",
+		URL:         "https://www.example.com/news",
+		ExtraParams: map[string]any{"Anthropic": map[string]any{"page_age": "1 day"}},
+	}
+
+	in := &responses.Request{
+		Model: "gpt-4.1",
+		Input: responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+			{OfWebSearchCall: &responses.WebSearchCallMessage{
+				ID:     "ws_1",
+				Status: "completed",
+				Action: responses.WebSearchCallActionUnion{OfSearch: &responses.WebSearchCallActionOfSearch{
+					Query:   "news",
+					Sources: []responses.WebSearchCallActionOfSearchSource{source},
+				}},
+			}},
+			{OfOutputMessage: &responses.OutputMessage{
+				ID:   "msg_1",
+				Role: "assistant",
+				Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{
+					Text:        "The news is good.",
+					Annotations: []responses.Annotation{annotation},
+				}}},
+			}},
+		}},
+	}
+
+	payload := marshalJSON(t, NativeRequestToRequest(in))
+	assert.NotContains(t, payload, "extra_params")
+	assert.Contains(t, payload, `"url":"https://www.example.com/news"`)
+
+	// The messages of the caller keep their extra params
+	assert.NotNil(t, in.Input.OfInputMessageList[0].OfWebSearchCall.Action.OfSearch.Sources[0].ExtraParams)
+	assert.NotNil(t, in.Input.OfInputMessageList[1].OfOutputMessage.Content[0].OfOutputText.Annotations[0].ExtraParams)
+}
+
+func TestResponseChunkToNative_FileSearchCallChunks(t *testing.T) {
+	fixtures := []struct {
+		chunkType string
+		payload   string
+	}{
+		{"response.file_search_call.in_progress", `{"type":"response.file_search_call.in_progress","sequence_number":2,"item_id":"fs_1","output_index":0}`},
+		{"response.file_search_call.searching", `{"type":"response.file_search_call.searching","sequence_number":3,"item_id":"fs_1","output_index":0}`},
+		{"response.file_search_call.completed", `{"type":"response.file_search_call.completed","sequence_number":4,"item_id":"fs_1","output_index":0}`},
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.chunkType, func(t *testing.T) {
+			var chunk ResponseChunk
+			require.NoError(t, sonic.Unmarshal([]byte(fixture.payload), &chunk))
+
+			native := chunk.ToNativeResponseChunk()
+			assert.Equal(t, fixture.chunkType, native.ChunkType())
+			assert.JSONEq(t, fixture.payload, marshalJSON(t, native))
+		})
+	}
+}
+
+func TestResponseChunkToNative_AccumulatesSearchCalls(t *testing.T) {
+	payloads := []string{
+		`{"type":"response.output_item.done","sequence_number":5,"output_index":0,"item":` + webSearchCallFixture + `}`,
+		`{"type":"response.output_item.done","sequence_number":9,"output_index":1,"item":` + fileSearchCallFixture + `}`,
+		`{"type":"response.output_text.annotation.added","sequence_number":12,"item_id":"msg_1","output_index":2,"content_index":0,"annotation_index":0,"annotation":` + fileCitationFixture + `}`,
+	}
+
+	acc := responses.ResponseAccumulator{}
+	var annotation responses.Annotation
+	for _, payload := range payloads {
+		var chunk ResponseChunk
+		require.NoError(t, sonic.Unmarshal([]byte(payload), &chunk))
+
+		native := chunk.ToNativeResponseChunk()
+		acc.Add(native)
+		if native.OfOutputTextAnnotationAdded != nil {
+			annotation = native.OfOutputTextAnnotationAdded.Annotation
+		}
+	}
+
+	output := acc.Response().Output
+	require.Len(t, output, 2)
+	require.NotNil(t, output[0].OfWebSearchCall)
+	require.NotNil(t, output[1].OfFileSearchCall)
+
+	assert.JSONEq(t, webSearchCallFixture, marshalJSON(t, &output[0]))
+	assert.JSONEq(t, fileSearchCallFixture, marshalJSON(t, &output[1]))
+	assert.JSONEq(t, fileCitationFixture, marshalJSON(t, annotation))
+}
+
+func TestNativeRequestToRequest_ExtraParams(t *testing.T) {
+	in := &responses.Request{
+		Model: "meta-llama/Llama-3.1-8B-Instruct",
+		Input: responses.InputUnion{OfString: utils.Ptr("Is Paris in France?")},
+		Parameters: responses.Parameters{
+			Constraint: &responses.Constraint{Type: responses.ConstraintTypeRegex, Definition: "yes|no"},
+			ExtraParams: map[string]any{
+				"best_of":      2,
+				"guided_regex": "maybe",
+			},
+		},
+	}
+
+	payload, err := NativeRequestToRequest(in).MarshalWithExtraParams(in.ExtraParams)
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, sonic.Unmarshal(payload, &fields))
+
+	assert.NotContains(t, fields, "extra_params")
+	assert.EqualValues(t, 2, fields["best_of"])
+	// The constraint takes precedence over the extra param
+	assert.Equal(t, "yes|no", fields["guided_regex"])
+}
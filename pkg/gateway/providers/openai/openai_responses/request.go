@@ -1,9 +1,45 @@
 package openai_responses
 
 import (
+	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm/responses"
 )
 
 type Request struct {
 	responses.Request
+
+	// Guided decoding parameters of OpenAI-compatible servers such as vLLM
+	GuidedRegex   *string `json:"guided_regex,omitempty"`
+	GuidedGrammar *string `json:"guided_grammar,omitempty"`
+
+	// Constraint shadows the native constraint, which is sent as guided decoding parameters
+	Constraint *struct{} `json:"constraint,omitempty"`
+
+	// DryRun shadows the native dry run flag, which never reaches the provider
+	DryRun *struct{} `json:"dry_run,omitempty"`
+
+	// ExtraParams shadows the native extra params, which are sent as top level parameters by MarshalWithExtraParams
+	ExtraParams *struct{} `json:"extra_params,omitempty"`
+}
+
+// MarshalWithExtraParams marshals the request with the extra params as top level parameters. The parameters of the
+// request take precedence over the extra params of the same name.
+func (r *Request) MarshalWithExtraParams(params map[string]any) ([]byte, error) {
+	payload, err := sonic.Marshal(r)
+	if err != nil || len(params) == 0 {
+		return payload, err
+	}
+
+	var fields map[string]any
+	if err := sonic.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, value := range params {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+
+	return sonic.Marshal(fields)
 }
@@ -0,0 +1,35 @@
+package openai
+
+// VLLMExtraParams are the sampling and guided decoding parameters vLLM accepts on top of the OpenAI API
+var VLLMExtraParams = []string{
+	"best_of",
+	"top_k",
+	"min_p",
+	"min_tokens",
+	"repetition_penalty",
+	"length_penalty",
+	"use_beam_search",
+	"stop_token_ids",
+	"ignore_eos",
+	"skip_special_tokens",
+	"guided_json",
+	"guided_regex",
+	"guided_choice",
+	"guided_grammar",
+	"guided_whitespace_pattern",
+	"guided_decoding_backend",
+}
+
+// TGIExtraParams are the generation parameters Text Generation Inference accepts on top of the OpenAI API
+var TGIExtraParams = []string{
+	"best_of",
+	"top_k",
+	"typical_p",
+	"repetition_penalty",
+	"do_sample",
+	"truncate",
+	"watermark",
+	"grammar",
+	"top_n_tokens",
+	"decoder_input_details",
+}
@@ -0,0 +1,168 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// Model is a model of the catalog of OpenRouter, see https://openrouter.ai/docs/api-reference/list-available-models
+type Model struct {
+	ID            string       `json:"id"` // e.g. anthropic/claude-sonnet-4
+	Name          string       `json:"name"`
+	ContextLength int          `json:"context_length"`
+	Pricing       ModelPricing `json:"pricing"`
+}
+
+// ModelPricing is the USD price per token of a model, as decimal strings. The price of the models whose price depends
+// on the model routed to, e.g. openrouter/auto, is "-1".
+type ModelPricing struct {
+	Prompt         string `json:"prompt"`
+	Completion     string `json:"completion"`
+	InputCacheRead string `json:"input_cache_read,omitempty"`
+}
+
+// ToPricing returns the price per million tokens of the model, false when the price isn't known in advance
+func (p ModelPricing) ToPricing() (llm.ModelPricing, bool) {
+	input, err := strconv.ParseFloat(p.Prompt, 64)
+	if err != nil || input < 0 {
+		return llm.ModelPricing{}, false
+	}
+
+	output, err := strconv.ParseFloat(p.Completion, 64)
+	if err != nil || output < 0 {
+		return llm.ModelPricing{}, false
+	}
+
+	cachedInput := input
+	if cached, err := strconv.ParseFloat(p.InputCacheRead, 64); err == nil && cached >= 0 {
+		cachedInput = cached
+	}
+
+	return llm.ModelPricing{
+		InputPerMTok:       input * 1_000_000,
+		CachedInputPerMTok: cachedInput * 1_000_000,
+		OutputPerMTok:      output * 1_000_000,
+	}, true
+}
+
+// Catalog holds the models of OpenRouter, fetched from its API as they change every week. Until it is loaded every
+// model is accepted.
+type Catalog struct {
+	mu     sync.RWMutex
+	models map[string]Model
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{}
+}
+
+// Load fetches the models of OpenRouter and registers their pricing, see llm.RegisterModelPricing. The models are
+// listed without API key.
+func (c *Catalog) Load(ctx context.Context, httpClient *http.Client, baseURL string) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list the models of openrouter: %s", res.Status)
+	}
+
+	var body struct {
+		Data []Model `json:"data"`
+	}
+	if err := utils.DecodeJSON(res.Body, &body); err != nil {
+		return err
+	}
+	if len(body.Data) == 0 {
+		return errors.New("the catalog of openrouter is empty")
+	}
+
+	models := make(map[string]Model, len(body.Data))
+	for _, model := range body.Data {
+		models[model.ID] = model
+		if pricing, ok := model.Pricing.ToPricing(); ok {
+			llm.RegisterModelPricing(model.ID, pricing)
+		}
+	}
+
+	c.mu.Lock()
+	c.models = models
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Loaded reports whether the catalog was loaded
+func (c *Catalog) Loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.models != nil
+}
+
+// Model returns the model of the catalog. The variants routing the model differently, e.g.
+// meta-llama/llama-3.3-70b-instruct:nitro, are the model.
+func (c *Catalog) Model(id string) (Model, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if model, ok := c.models[id]; ok {
+		return model, true
+	}
+
+	if i := strings.LastIndex(id, ":"); i > 0 {
+		model, ok := c.models[id[:i]]
+		return model, ok
+	}
+
+	return Model{}, false
+}
+
+// Validate returns an error for the models missing from the loaded catalog
+func (c *Catalog) Validate(id string) error {
+	if !c.Loaded() {
+		return nil
+	}
+
+	if _, ok := c.Model(id); !ok {
+		return fmt.Errorf("model '%s' is not in the catalog of openrouter", id)
+	}
+
+	return nil
+}
+
+// Models returns the IDs of the models of the catalog, sorted
+func (c *Catalog) Models() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	ids := make([]string, 0, len(c.models))
+	for id := range c.models {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
@@ -0,0 +1,59 @@
+package openrouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog_Load(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"data": [
+			{"id": "anthropic/claude-test-4", "name": "Claude Test 4", "context_length": 200000,
+			 "pricing": {"prompt": "0.000003", "completion": "0.000015", "input_cache_read": "0.0000003"}},
+			{"id": "meta-llama/llama-test-8b:free", "name": "Llama Test 8B (free)", "context_length": 131072,
+			 "pricing": {"prompt": "0", "completion": "0"}},
+			{"id": "openrouter/auto-test", "name": "Auto Router", "context_length": 2000000,
+			 "pricing": {"prompt": "-1", "completion": "-1"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	catalog := NewCatalog()
+	assert.NoError(t, catalog.Validate("any/model"), "every model is accepted before the catalog is loaded")
+
+	require.NoError(t, catalog.Load(context.Background(), nil, server.URL))
+	assert.Equal(t, []string{"anthropic/claude-test-4", "meta-llama/llama-test-8b:free", "openrouter/auto-test"}, catalog.Models())
+
+	// The variants routing a model differently are the model
+	assert.NoError(t, catalog.Validate("anthropic/claude-test-4:nitro"))
+	assert.NoError(t, catalog.Validate("meta-llama/llama-test-8b:free"))
+	assert.Error(t, catalog.Validate("meta-llama/llama-test-8b"))
+
+	pricing, ok := llm.GetModelPricing("anthropic/claude-test-4")
+	require.True(t, ok)
+	assert.InDelta(t, 3, pricing.InputPerMTok, 1e-9)
+	assert.InDelta(t, 0.3, pricing.CachedInputPerMTok, 1e-9)
+	assert.InDelta(t, 15, pricing.OutputPerMTok, 1e-9)
+
+	// The models priced by the model routed to have no pricing
+	_, ok = llm.GetModelPricing("openrouter/auto-test")
+	assert.False(t, ok)
+}
+
+func TestCatalog_Load_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	catalog := NewCatalog()
+	require.Error(t, catalog.Load(context.Background(), nil, server.URL))
+	assert.False(t, catalog.Loaded())
+}
@@ -0,0 +1,128 @@
+package openrouter
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/gateway/providers/openrouter/openrouter_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+type ClientOptions struct {
+	// https://openrouter.ai/api/v1
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string // e.g. HTTP-Referer and X-Title, attributing the requests to the app
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	// Catalog rejects the models OpenRouter doesn't serve before sending the requests, optional
+	Catalog *Catalog
+
+	transport *http.Client
+}
+
+// Client calls the OpenRouter API, which routes the requests to the providers of the models. The models are named
+// after their organization, e.g. anthropic/claude-sonnet-4. The chat completions and the embeddings are the ones of
+// the OpenAI API, the responses are translated to chat completions.
+type Client struct {
+	*openai.Client
+	chat    *chat_responses.Client
+	catalog *Catalog
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		Client: openai.NewClient(&openai.ClientOptions{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		}),
+		chat: &chat_responses.Client{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		},
+		catalog: opts.Catalog,
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	res, err := c.post(ctx, inp, false)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var openrouterResponse *openrouter_responses.Response
+	err = utils.DecodeJSON(res.Body, &openrouterResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return openrouterResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	res, err := c.post(ctx, inp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return chat_responses.Stream[openrouter_responses.ResponseChunk](ctx, res, &openrouter_responses.ResponseChunkToNativeResponseChunkConverter{}), nil
+}
+
+// post sends the chat completion request of the responses request
+func (c *Client) post(ctx context.Context, inp *responses.Request, stream bool) (*http.Response, error) {
+	if c.catalog != nil {
+		if err := c.catalog.Validate(inp.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	openrouterRequest := openrouter_responses.NativeRequestToRequest(inp)
+	openrouterRequest.Stream = stream
+
+	payload, err := chat_responses.MarshalWithExtraParams(openrouterRequest, extraParams(inp.ExtraParams))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.chat.Post(ctx, payload, stream)
+}
+
+// extraParams keeps the params of OpenRouter of the extra params, see openrouter_responses.ExtraParams
+func extraParams(params map[string]any) map[string]any {
+	if len(params) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(params))
+	for name, value := range params {
+		if slices.Contains(openrouter_responses.ExtraParams, name) {
+			out[name] = value
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,132 @@
+package openrouter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responsesRequest(t *testing.T) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "deepseek/deepseek-r1",
+		"instructions": "Answer briefly",
+		"input": "What is the weather in Paris?",
+		"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object"}}],
+		"reasoning": {"effort": "xhigh"},
+		"extra_params": {"provider": {"order": ["DeepInfra"], "allow_fallbacks": false}, "best_of": 2}
+	}`), &req))
+	return &req
+}
+
+func TestClient_NewResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+
+		// The routing params are sent, the other extra params are dropped
+		assert.Equal(t, map[string]any{"order": []any{"DeepInfra"}, "allow_fallbacks": false}, payload["provider"])
+		assert.NotContains(t, payload, "best_of")
+		assert.Equal(t, map[string]any{"effort": "high"}, payload["reasoning"])
+		assert.Equal(t, map[string]any{"include": true}, payload["usage"])
+		assert.Len(t, payload["tools"], 1)
+
+		// Whitespace is sent to keep the connection alive while the model is queued
+		_, _ = w.Write([]byte(`
+
+			{
+			"id": "gen-1",
+			"provider": "DeepInfra",
+			"model": "deepseek/deepseek-r1",
+			"choices": [{
+				"index": 0,
+				"message": {
+					"role": "assistant",
+					"content": null,
+					"reasoning": "The user asks for the weather.",
+					"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]
+				},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {
+				"prompt_tokens": 40, "completion_tokens": 25, "total_tokens": 65,
+				"prompt_tokens_details": {"cached_tokens": 32},
+				"completion_tokens_details": {"reasoning_tokens": 10},
+				"cost": 0.00012
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "or-key"})
+	out, err := client.NewResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	require.Len(t, out.Output, 2)
+	assert.Equal(t, "The user asks for the weather.", out.Output[0].OfReasoning.Summary[0].Text)
+	assert.Equal(t, "get_weather", out.Output[1].OfFunctionCall.Name)
+	assert.Equal(t, `{"city":"Paris"}`, out.Output[1].OfFunctionCall.Arguments)
+
+	assert.Equal(t, 32, out.Usage.InputTokensDetails.CachedTokens)
+	assert.Equal(t, 10, out.Usage.OutputTokensDetails.ReasoningTokens)
+	assert.Equal(t, "DeepInfra", out.Metadata["provider"])
+	assert.Equal(t, 0.00012, out.Metadata["cost"])
+}
+
+func TestClient_NewStreamingResponses_ProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"gen-3","model":"deepseek/deepseek-r1","choices":[{"index":0,"delta":{"content":"It is"},"finish_reason":null}]}
+
+data: {"id":"gen-3","model":"deepseek/deepseek-r1","error":{"code":502,"message":"Provider disconnected"},"choices":[{"index":0,"delta":{"content":""},"finish_reason":"error"}]}
+
+`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "or-key"})
+	stream, err := client.NewStreamingResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	var last *responses.ResponseChunk
+	for chunk := range stream {
+		last = chunk
+	}
+
+	require.NotNil(t, last.OfResponseCompleted)
+	assert.Equal(t, "failed", last.OfResponseCompleted.Response.Status)
+	assert.Equal(t, map[string]any{"message": "Provider disconnected"}, last.OfResponseCompleted.Response.Error)
+}
+
+func TestClient_NewResponses_UnknownModel(t *testing.T) {
+	catalogServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data": [{"id": "deepseek/deepseek-r1", "name": "DeepSeek R1", "context_length": 163840, "pricing": {"prompt": "0.0000004", "completion": "0.000002"}}]}`))
+	}))
+	defer catalogServer.Close()
+
+	catalog := NewCatalog()
+	require.NoError(t, catalog.Load(context.Background(), nil, catalogServer.URL))
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "or-key", Catalog: catalog})
+	req := responsesRequest(t)
+	req.Model = "deepseek/deepseek-r2"
+	_, err := client.NewResponses(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, "model 'deepseek/deepseek-r2' is not in the catalog of openrouter", err.Error())
+	assert.False(t, called)
+}
@@ -0,0 +1,67 @@
+package openrouter_responses
+
+import (
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ExtraParams are the params of OpenRouter accepted in the extra params of the requests: the routing of the request
+// between the providers of the model, the fallback models, and the sampling params of the open models. See
+// https://openrouter.ai/docs/features/provider-routing and https://openrouter.ai/docs/features/model-routing
+var ExtraParams = []string{
+	"provider",
+	"models",
+	"route",
+	"transforms",
+	"plugins",
+	"top_k",
+	"min_p",
+	"top_a",
+	"repetition_penalty",
+	"frequency_penalty",
+	"presence_penalty",
+	"seed",
+}
+
+// Options are the differences of the chat completions API of OpenRouter, which follows the OpenAI API
+var Options = chat_responses.Options{Provider: "openrouter"}
+
+// Request is the body of the chat completions API of OpenRouter, see
+// https://openrouter.ai/docs/api-reference/chat-completion
+type Request struct {
+	*chat_responses.Request
+	Usage *UsageOptions `json:"usage,omitempty"`
+}
+
+// UsageOptions asks for the cost of the request in the usage
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+func NativeRequestToRequest(in *responses.Request) *Request {
+	out := &Request{Request: chat_responses.NativeRequestToRequest(in, Options)}
+	out.Reasoning = NativeReasoningToReasoning(in.Reasoning)
+
+	// The usage holds the cost of the request charged by OpenRouter
+	out.Usage = &UsageOptions{Include: true}
+
+	return out
+}
+
+// NativeReasoningToReasoning converts the reasoning param to the reasoning of OpenRouter, which translates it for the
+// provider of the model. "none" turns the thinking of the hybrid models off.
+func NativeReasoningToReasoning(in *responses.ReasoningParam) *chat_responses.Reasoning {
+	if in == nil {
+		return nil
+	}
+
+	switch effort := in.NormalizedEffort(); effort {
+	case responses.ReasoningEffortNone:
+		return &chat_responses.Reasoning{Enabled: utils.Ptr(false)}
+	case responses.ReasoningEffortXHigh:
+		return &chat_responses.Reasoning{Effort: utils.Ptr(string(responses.ReasoningEffortHigh))}
+	default:
+		return &chat_responses.Reasoning{Effort: utils.Ptr(string(effort))}
+	}
+}
@@ -0,0 +1,43 @@
+package openrouter_responses
+
+import (
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ToNativeResponse converts the chat completion, the provider routed to and the cost of the request are metadata
+func (in *Response) ToNativeResponse() *responses.Response {
+	if in.Usage != nil {
+		in.Response.Usage = &in.Usage.Usage
+	}
+
+	out := in.Response.ToNativeResponse()
+	if in.Provider != "" {
+		out.Metadata["provider"] = in.Provider
+	}
+	if in.Usage != nil && in.Usage.Cost != nil {
+		out.Metadata["cost"] = *in.Usage.Cost
+	}
+
+	return out
+}
+
+// ResponseChunkToNativeResponseChunkConverter converts the chunks of a chat completion stream to native chunks, the
+// errors of the provider of the model fail the stream
+type ResponseChunkToNativeResponseChunkConverter struct {
+	chat_responses.ResponseChunkToNativeResponseChunkConverter
+}
+
+// ResponseChunkToNativeResponseChunk converts a single chunk to zero or more native chunks.
+func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeResponseChunk(in *ResponseChunk) []*responses.ResponseChunk {
+	if in == nil {
+		return nil
+	}
+
+	if in.Error != nil {
+		result := c.ResponseChunkToNativeResponseChunkConverter.ResponseChunkToNativeResponseChunk(&chat_responses.ResponseChunk{ID: in.ID, Model: in.Model})
+		return append(result, c.Fail(in.Error.Message)...)
+	}
+
+	return c.ResponseChunkToNativeResponseChunkConverter.ResponseChunkToNativeResponseChunk(&in.ResponseChunk)
+}
@@ -0,0 +1,30 @@
+package openrouter_responses
+
+import "github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+
+type Response struct {
+	chat_responses.Response
+	Provider string `json:"provider,omitempty"` // Provider OpenRouter routed the request to
+	Usage    *Usage `json:"usage,omitempty"`
+}
+
+type Usage struct {
+	chat_responses.Usage
+
+	// Cost is the USD price of the request charged by OpenRouter
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+// ResponseChunk is an event of the stream of a chat completion, the usage is sent with the last choice
+type ResponseChunk struct {
+	chat_responses.ResponseChunk
+	Provider string `json:"provider,omitempty"`
+	Error    *Error `json:"error,omitempty"` // Error of the provider of the model, ending the stream
+}
+
+// Error is an error of the provider of the model, sent in the chunks of the streams after the status was sent
+type Error struct {
+	Message  string         `json:"message"`
+	Code     any            `json:"code"`
+	Metadata map[string]any `json:"metadata,omitempty"` // e.g. the raw error of the provider, or the flagged input
+}
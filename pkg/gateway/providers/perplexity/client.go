@@ -0,0 +1,117 @@
+package perplexity
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/gateway/providers/perplexity/perplexity_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const DefaultBaseURL = "https://api.perplexity.ai"
+
+type ClientOptions struct {
+	// https://api.perplexity.ai
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client calls the Perplexity API, whose Sonar models answer grounded in a web search. The chat completions are the
+// ones of the OpenAI API, the responses are translated to chat completions and the sources of the answers to
+// url_citation annotations.
+type Client struct {
+	*openai.Client
+	chat *chat_responses.Client
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		Client: openai.NewClient(&openai.ClientOptions{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		}),
+		chat: &chat_responses.Client{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		},
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	res, err := c.post(ctx, inp, false)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var perplexityResponse *perplexity_responses.Response
+	err = utils.DecodeJSON(res.Body, &perplexityResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return perplexityResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	res, err := c.post(ctx, inp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return chat_responses.Stream[perplexity_responses.ResponseChunk](ctx, res, &perplexity_responses.ResponseChunkToNativeResponseChunkConverter{}), nil
+}
+
+// post sends the chat completion request of the responses request
+func (c *Client) post(ctx context.Context, inp *responses.Request, stream bool) (*http.Response, error) {
+	perplexityRequest := perplexity_responses.NativeRequestToRequest(inp)
+	perplexityRequest.Stream = stream
+
+	payload, err := chat_responses.MarshalWithExtraParams(perplexityRequest, extraParams(inp.ExtraParams))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.chat.Post(ctx, payload, stream)
+}
+
+// extraParams keeps the search parameters of the extra params, see perplexity_responses.ExtraParams
+func extraParams(params map[string]any) map[string]any {
+	if len(params) == 0 {
+		return nil
+	}
+
+	out := make(map[string]any, len(params))
+	for name, value := range params {
+		if slices.Contains(perplexity_responses.ExtraParams, name) {
+			out[name] = value
+		}
+	}
+
+	return out
+}
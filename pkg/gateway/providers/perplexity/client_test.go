@@ -0,0 +1,133 @@
+package perplexity
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func responsesRequest(t *testing.T) *responses.Request {
+	var req responses.Request
+	require.NoError(t, sonic.Unmarshal([]byte(`{
+		"model": "sonar-pro",
+		"instructions": "Answer briefly",
+		"input": [
+			{"role": "user", "content": "Where is the Eiffel tower?"},
+			{"role": "user", "content": "And how tall is it?"}
+		],
+		"tools": [{"type": "function", "name": "get_weather", "parameters": {"type": "object"}}],
+		"extra_params": {"search_recency_filter": "month", "best_of": 2}
+	}`), &req))
+	return &req
+}
+
+// searchResults are the sources of the answers, on a single line for the events of the streams
+const searchResults = `"citations": ["https://en.wikipedia.org/wiki/Eiffel_Tower", "https://www.toureiffel.paris/en"], ` +
+	`"search_results": [{"title": "Eiffel Tower - Wikipedia", "url": "https://en.wikipedia.org/wiki/Eiffel_Tower", "date": "2025-01-10"}, ` +
+	`{"title": "Official website", "url": "https://www.toureiffel.paris/en"}]`
+
+func TestClient_NewResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		require.NoError(t, sonic.Unmarshal(body, &payload))
+
+		// The search params are sent, the other extra params and the tools are dropped
+		assert.Equal(t, "month", payload["search_recency_filter"])
+		assert.NotContains(t, payload, "best_of")
+		assert.NotContains(t, payload, "tools")
+
+		// The user messages alternate with the assistant messages
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 2)
+		assert.Equal(t, map[string]any{"role": "system", "content": "Answer briefly"}, messages[0])
+		assert.Equal(t, map[string]any{"role": "user", "content": "Where is the Eiffel tower?\n\nAnd how tall is it?"}, messages[1])
+
+		_, _ = w.Write([]byte(`{
+			"id": "p1",
+			"object": "chat.completion",
+			"model": "sonar-pro",
+			"choices": [{
+				"index": 0,
+				"message": {"role": "assistant", "content": "It is in Paris[1] and 330 m tall[2][3]. Café[1]."},
+				"finish_reason": "stop"
+			}],
+			` + searchResults + `,
+			"usage": {"prompt_tokens": 12, "completion_tokens": 20, "total_tokens": 32, "citation_tokens": 500, "num_search_queries": 1}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "pplx-key"})
+	out, err := client.NewResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	require.Len(t, out.Output, 1)
+
+	text := out.Output[0].OfOutputMessage.Content[0].OfOutputText
+	assert.Equal(t, "It is in Paris[1] and 330 m tall[2][3]. Café[1].", text.Text)
+
+	// [3] refers to no source, the spans are in characters
+	require.Len(t, text.Annotations, 3)
+	assert.Equal(t, "url_citation", text.Annotations[0].Type)
+	assert.Equal(t, "Eiffel Tower - Wikipedia", text.Annotations[0].Title)
+	assert.Equal(t, "https://en.wikipedia.org/wiki/Eiffel_Tower", text.Annotations[0].URL)
+	assert.Equal(t, 14, text.Annotations[0].StartIndex)
+	assert.Equal(t, 17, text.Annotations[0].EndIndex)
+	assert.Equal(t, "https://www.toureiffel.paris/en", text.Annotations[1].URL)
+	assert.Equal(t, 32, text.Annotations[1].StartIndex)
+	assert.Equal(t, 44, text.Annotations[2].StartIndex)
+	assert.Equal(t, "[1]", string([]rune(text.Text)[44:47]))
+
+	assert.Len(t, out.Metadata["search_results"], 2)
+}
+
+func TestClient_NewStreamingResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"id":"p2","model":"sonar-reasoning","choices":[{"index":0,"delta":{"role":"assistant","content":"<think>Search the height.</think>"},"finish_reason":null}]}
+
+data: {"id":"p2","model":"sonar-reasoning","choices":[{"index":0,"delta":{"content":"It is 330 m tall[2"},"finish_reason":null}]}
+
+data: {"id":"p2","model":"sonar-reasoning","choices":[{"index":0,"delta":{"content":"]."},"finish_reason":"stop"}],` + searchResults + `,"usage":{"prompt_tokens":12,"completion_tokens":15,"total_tokens":27}}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "pplx-key"})
+	stream, err := client.NewStreamingResponses(context.Background(), responsesRequest(t))
+	require.NoError(t, err)
+
+	var chunks []*responses.ResponseChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+
+	var annotations []responses.Annotation
+	for _, chunk := range chunks {
+		if chunk.OfOutputTextAnnotationAdded != nil {
+			annotations = append(annotations, chunk.OfOutputTextAnnotationAdded.Annotation)
+		}
+	}
+
+	// The reference split across the deltas is annotated with the sources sent after it
+	require.Len(t, annotations, 1)
+	assert.Equal(t, "https://www.toureiffel.paris/en", annotations[0].URL)
+	assert.Equal(t, 16, annotations[0].StartIndex)
+	assert.Equal(t, 19, annotations[0].EndIndex)
+
+	completed := chunks[len(chunks)-1].OfResponseCompleted
+	require.NotNil(t, completed)
+	require.Len(t, completed.Response.Output, 2)
+	assert.Equal(t, annotations, completed.Response.Output[1].OfOutputMessage.Content[0].OfOutputText.Annotations)
+}
@@ -0,0 +1,64 @@
+package perplexity_responses
+
+import (
+	"log/slog"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ExtraParams are the search parameters Perplexity accepts on top of the chat completions API, see
+// responses.Request.ExtraParams
+var ExtraParams = []string{
+	"search_mode",
+	"search_domain_filter",
+	"search_recency_filter",
+	"search_after_date_filter",
+	"search_before_date_filter",
+	"last_updated_after_filter",
+	"last_updated_before_filter",
+	"web_search_options",
+	"return_images",
+	"return_related_questions",
+	"disable_search",
+}
+
+// Options are the differences of the chat completions API of Perplexity: the models search the web themselves and
+// have no tools, and the user and the assistant messages must alternate
+var Options = chat_responses.Options{
+	Provider:       "perplexity",
+	NoTools:        true,
+	AlternateRoles: true,
+}
+
+// NativeRequestToRequest converts the request to the chat completions API of Perplexity, see
+// https://docs.perplexity.ai/api-reference/chat-completions-post
+func NativeRequestToRequest(in *responses.Request) *chat_responses.Request {
+	out := chat_responses.NativeRequestToRequest(in, Options)
+	out.ReasoningEffort = NativeReasoningToReasoningEffort(in.Reasoning)
+
+	// Perplexity has no JSON mode without schema
+	if out.ResponseFormat != nil && out.ResponseFormat.Type == "json_object" {
+		slog.Warn("json_object text format is not supported for perplexity models, use a json_schema format")
+		out.ResponseFormat = nil
+	}
+
+	return out
+}
+
+// NativeReasoningToReasoningEffort converts the reasoning param to the effort of the deep research models
+func NativeReasoningToReasoningEffort(in *responses.ReasoningParam) *string {
+	if in == nil {
+		return nil
+	}
+
+	switch effort := in.NormalizedEffort(); effort {
+	case responses.ReasoningEffortNone, responses.ReasoningEffortMinimal:
+		return utils.Ptr(string(responses.ReasoningEffortLow))
+	case responses.ReasoningEffortXHigh:
+		return utils.Ptr(string(responses.ReasoningEffortHigh))
+	default:
+		return utils.Ptr(string(effort))
+	}
+}
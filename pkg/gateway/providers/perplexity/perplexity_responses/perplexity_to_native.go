@@ -0,0 +1,133 @@
+package perplexity_responses
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ToNativeResponse converts the chat completion, with the references of the text to the sources as annotations
+func (in *Response) ToNativeResponse() *responses.Response {
+	if in.Usage != nil {
+		in.Response.Usage = in.Usage.chatUsage()
+	}
+
+	out := in.Response.ToNativeResponse()
+
+	sources := Sources(in.Citations, in.SearchResults)
+	for _, output := range out.Output {
+		if output.OfOutputMessage == nil {
+			continue
+		}
+		for _, content := range output.OfOutputMessage.Content {
+			if content.OfOutputText != nil {
+				content.OfOutputText.Annotations = CitationsToNativeAnnotations(content.OfOutputText.Text, sources)
+			}
+		}
+	}
+	if len(sources) > 0 {
+		out.Metadata["search_results"] = sources
+	}
+
+	return out
+}
+
+// Sources returns the sources of an answer, the search results or, for the responses without them, the URLs of the
+// citations
+func Sources(citations []string, searchResults []SearchResult) []SearchResult {
+	if len(searchResults) > 0 {
+		return searchResults
+	}
+
+	var sources []SearchResult
+	for _, url := range citations {
+		sources = append(sources, SearchResult{URL: url})
+	}
+	return sources
+}
+
+// citationMarker is a reference of the text to a source, by its number starting at 1
+var citationMarker = regexp.MustCompile(`\[(\d+)\]`)
+
+// CitationsToNativeAnnotations returns a url_citation annotation for each reference of the text to a source, e.g.
+// [1]. The span of an annotation is its reference, in characters. The source is kept in the extra params.
+func CitationsToNativeAnnotations(text string, sources []SearchResult) []responses.Annotation {
+	annotations := []responses.Annotation{}
+	if len(sources) == 0 {
+		return annotations
+	}
+
+	offset, runes := 0, 0
+	for _, match := range citationMarker.FindAllStringSubmatchIndex(text, -1) {
+		n := 0
+		for _, digit := range text[match[2]:match[3]] {
+			n = n*10 + int(digit-'0')
+		}
+		if n < 1 || n > len(sources) {
+			continue
+		}
+
+		runes += utf8.RuneCountInString(text[offset:match[0]])
+		offset = match[0]
+		start := runes
+
+		source := sources[n-1]
+		title := source.Title
+		if title == "" {
+			title = source.URL
+		}
+
+		annotations = append(annotations, responses.Annotation{
+			Type:       "url_citation",
+			Title:      title,
+			URL:        source.URL,
+			StartIndex: start,
+			EndIndex:   start + utf8.RuneCountInString(text[match[0]:match[1]]),
+			ExtraParams: map[string]any{
+				"Perplexity": source,
+			},
+		})
+	}
+
+	return annotations
+}
+
+// chatUsage returns the usage of the chat completion, the reasoning tokens are the ones of the completion
+func (in *Usage) chatUsage() *chat_responses.Usage {
+	usage := in.Usage
+	if in.ReasoningTokens > 0 {
+		usage.CompletionTokensDetails = &chat_responses.CompletionTokensDetails{ReasoningTokens: in.ReasoningTokens}
+	}
+	return &usage
+}
+
+// ResponseChunkToNativeResponseChunkConverter converts the chunks of a chat completion stream to native chunks. The
+// references of the text to the sources are annotated when the text is complete, the sources are sent along the
+// chunks.
+type ResponseChunkToNativeResponseChunkConverter struct {
+	chat_responses.ResponseChunkToNativeResponseChunkConverter
+	sources []SearchResult
+}
+
+// ResponseChunkToNativeResponseChunk converts a single chunk to zero or more native chunks.
+func (c *ResponseChunkToNativeResponseChunkConverter) ResponseChunkToNativeResponseChunk(in *ResponseChunk) []*responses.ResponseChunk {
+	if in == nil {
+		return nil
+	}
+
+	if sources := Sources(in.Citations, in.SearchResults); len(sources) > 0 {
+		c.sources = sources
+	}
+	c.Annotate = func(text string) []responses.Annotation {
+		return CitationsToNativeAnnotations(text, c.sources)
+	}
+
+	chunk := in.ResponseChunk
+	if in.Usage != nil {
+		chunk.Usage = in.Usage.chatUsage()
+	}
+
+	return c.ResponseChunkToNativeResponseChunkConverter.ResponseChunkToNativeResponseChunk(&chunk)
+}
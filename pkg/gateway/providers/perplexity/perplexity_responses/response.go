@@ -0,0 +1,40 @@
+package perplexity_responses
+
+import "github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+
+// Response is a chat completion. The sources the answer was grounded on are the citations, the answer refers to them
+// by their number in brackets, e.g. [1].
+type Response struct {
+	chat_responses.Response
+	Citations     []string       `json:"citations,omitempty"`
+	SearchResults []SearchResult `json:"search_results,omitempty"`
+	Usage         *Usage         `json:"usage,omitempty"`
+}
+
+// SearchResult is a source of the answer, in the order of the citations
+type SearchResult struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Date        string `json:"date,omitempty"`
+	LastUpdated string `json:"last_updated,omitempty"`
+	Snippet     string `json:"snippet,omitempty"`
+}
+
+// Usage is the usage of a chat completion, with the reasoning tokens of the deep research models and the usage of
+// the search
+type Usage struct {
+	chat_responses.Usage
+	ReasoningTokens   int    `json:"reasoning_tokens,omitempty"`
+	CitationTokens    int    `json:"citation_tokens,omitempty"`
+	NumSearchQueries  int    `json:"num_search_queries,omitempty"`
+	SearchContextSize string `json:"search_context_size,omitempty"`
+}
+
+// ResponseChunk is an event of the stream of a chat completion. The citations and the search results are sent with
+// the chunks, the usage with the last choice.
+type ResponseChunk struct {
+	chat_responses.ResponseChunk
+	Citations     []string       `json:"citations,omitempty"`
+	SearchResults []SearchResult `json:"search_results,omitempty"`
+	Usage         *Usage         `json:"usage,omitempty"`
+}
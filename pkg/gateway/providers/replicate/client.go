@@ -0,0 +1,318 @@
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base"
+	"github.com/curaious/uno/pkg/gateway/providers/replicate/replicate_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const (
+	// defaultPollInterval is how often a prediction is polled, while no webhook notifies its completion
+	defaultPollInterval = time.Second
+
+	// webhookPollInterval is how often a prediction is polled when a webhook notifies its completion, in case the
+	// webhook reached another replica
+	webhookPollInterval = 10 * time.Second
+)
+
+type ClientOptions struct {
+	// https://api.replicate.com/v1
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// Webhooks notify the completion of the predictions, they are polled otherwise
+	Webhooks *Webhooks
+
+	// PollInterval is how often the predictions are polled without webhooks (default 1s)
+	PollInterval time.Duration
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client runs the models of Replicate, mostly image models, through its predictions API
+type Client struct {
+	*base.BaseProvider
+	opts *ClientOptions
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.replicate.com/v1"
+	}
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+
+	return &Client{
+		opts: opts,
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	p, err := c.createPrediction(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+
+	if p, err = c.waitPrediction(ctx, p); err != nil {
+		return nil, err
+	}
+
+	if p.Status != replicate_responses.PredictionStatusSucceeded {
+		return nil, errors.New(p.ErrorMessage())
+	}
+
+	return replicate_responses.PredictionToNativeResponse(p, c.downloadImages(ctx, p)), nil
+}
+
+// NewStreamingResponses streams the prediction once it completes, Replicate doesn't stream the images
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	p, err := c.createPrediction(ctx, inp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer close(out)
+
+		s := &stream{id: p.ID, model: inp.Model}
+		out <- s.responseCreated()
+		out <- s.responseInProgress()
+
+		p, err := c.waitPrediction(ctx, p)
+		if err != nil {
+			slog.WarnContext(ctx, "unable to wait for replicate prediction", slog.String("prediction_id", s.id), slog.Any("error", err))
+			return
+		}
+
+		if p.Status != replicate_responses.PredictionStatusSucceeded {
+			out <- s.responseFailed(p.ErrorMessage())
+			return
+		}
+
+		resp := replicate_responses.PredictionToNativeResponse(p, c.downloadImages(ctx, p))
+		for _, chunk := range s.outputChunks(resp.Output) {
+			out <- chunk
+		}
+		out <- s.responseCompleted(resp.Output)
+	}()
+
+	return out, nil
+}
+
+// createPrediction starts the prediction, waiting for its completion up to a minute
+func (c *Client) createPrediction(ctx context.Context, inp *responses.Request) (*replicate_responses.Prediction, error) {
+	in := replicate_responses.NativeRequestToRequest(inp)
+
+	url := c.opts.BaseURL + "/predictions"
+	if in.Version == "" {
+		name, _ := replicate_responses.ModelVersion(inp.Model)
+		url = c.opts.BaseURL + "/models/" + name + "/predictions"
+	}
+
+	if c.opts.Webhooks != nil {
+		in.Webhook = c.opts.Webhooks.URL
+		in.WebhookEventsFilter = []string{"completed"}
+	}
+
+	payload, err := sonic.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Prefer", "wait")
+
+	return c.doPrediction(req)
+}
+
+// waitPrediction waits until the prediction stops, and cancels it if the context is done first
+func (c *Client) waitPrediction(ctx context.Context, p *replicate_responses.Prediction) (*replicate_responses.Prediction, error) {
+	interval := c.opts.PollInterval
+	var delivered <-chan *replicate_responses.Prediction
+	if c.opts.Webhooks != nil && !p.Status.Done() {
+		var stop func()
+		delivered, stop = c.opts.Webhooks.wait(p.ID)
+		defer stop()
+		interval = webhookPollInterval
+	}
+
+	for !p.Status.Done() {
+		select {
+		case <-ctx.Done():
+			c.cancelPrediction(p)
+			return nil, ctx.Err()
+
+		case d := <-delivered:
+			p = d
+
+		case <-time.After(interval):
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URLs.Get, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			if p, err = c.doPrediction(req); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (c *Client) cancelPrediction(p *replicate_responses.Prediction) {
+	if p.URLs.Cancel == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URLs.Cancel, nil)
+	if err != nil {
+		return
+	}
+
+	if _, err = c.doPrediction(req); err != nil {
+		slog.Warn("unable to cancel replicate prediction", slog.String("prediction_id", p.ID), slog.Any("error", err))
+	}
+}
+
+func (c *Client) doPrediction(req *http.Request) (*replicate_responses.Prediction, error) {
+	req.Header.Set("Authorization", "Bearer "+c.opts.ApiKey)
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		var errResp struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		if err = utils.DecodeJSON(res.Body, &errResp); err != nil || errResp.Detail == "" {
+			return nil, fmt.Errorf("replicate request failed with status %d", res.StatusCode)
+		}
+		return nil, errors.New(errResp.Detail)
+	}
+
+	var p *replicate_responses.Prediction
+	if err = utils.DecodeJSON(res.Body, &p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// downloadImages downloads the images of the output of the prediction, the images that can't be downloaded are left
+// as URLs in the text of the response
+func (c *Client) downloadImages(ctx context.Context, p *replicate_responses.Prediction) map[string]replicate_responses.Image {
+	images := map[string]replicate_responses.Image{}
+	for _, url := range p.OutputStrings() {
+		if !isImageURL(url) {
+			continue
+		}
+
+		image, err := c.downloadImage(ctx, url)
+		if err != nil {
+			slog.WarnContext(ctx, "unable to download replicate output", slog.String("url", url), slog.Any("error", err))
+			continue
+		}
+		images[url] = image
+	}
+
+	return images
+}
+
+func (c *Client) downloadImage(ctx context.Context, url string) (replicate_responses.Image, error) {
+	if strings.HasPrefix(url, "data:") {
+		mediaType, data, _ := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+		return replicate_responses.Image{Format: strings.TrimPrefix(mediaType, "image/"), Data: data}, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return replicate_responses.Image{}, err
+	}
+
+	// The files served by the API need the key, unlike the files delivered by the CDN
+	if strings.HasPrefix(url, c.opts.BaseURL) {
+		req.Header.Set("Authorization", "Bearer "+c.opts.ApiKey)
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return replicate_responses.Image{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return replicate_responses.Image{}, fmt.Errorf("download failed with status %d", res.StatusCode)
+	}
+
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return replicate_responses.Image{}, err
+	}
+
+	format := strings.TrimPrefix(path.Ext(req.URL.Path), ".")
+	if mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil && strings.HasPrefix(mediaType, "image/") {
+		format = strings.TrimPrefix(mediaType, "image/")
+	}
+
+	return replicate_responses.Image{Format: format, Data: base64.StdEncoding.EncodeToString(buf)}, nil
+}
+
+func isImageURL(url string) bool {
+	if strings.HasPrefix(url, "data:") {
+		return strings.HasPrefix(url, "data:image/")
+	}
+	if !replicate_responses.IsFileURL(url) {
+		return false
+	}
+
+	ext, _, _ := strings.Cut(path.Ext(url), "?")
+	switch strings.ToLower(ext) {
+	case ".png", ".jpg", ".jpeg", ".webp", ".gif":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package replicate
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngImage = []byte("\x89PNG\r\n\x1a\n")
+
+// newPredictionServer serves a prediction of flux-schnell completing on the second poll
+func newPredictionServer(t *testing.T) *httptest.Server {
+	var polls atomic.Int32
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	prediction := func(status string, output string) string {
+		return `{
+			"id": "p1",
+			"model": "black-forest-labs/flux-schnell",
+			"status": "` + status + `",
+			"output": ` + output + `,
+			"urls": {"get": "` + server.URL + `/predictions/p1", "cancel": "` + server.URL + `/predictions/p1/cancel"}
+		}`
+	}
+
+	mux.HandleFunc("POST /models/black-forest-labs/flux-schnell/predictions", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer r8_test", r.Header.Get("Authorization"))
+		assert.Equal(t, "wait", r.Header.Get("Prefer"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(prediction("starting", "null")))
+	})
+	mux.HandleFunc("GET /predictions/p1", func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 2 {
+			_, _ = w.Write([]byte(prediction("processing", "null")))
+			return
+		}
+		_, _ = w.Write([]byte(prediction("succeeded", `["`+server.URL+`/files/out-0.png"]`)))
+	})
+	mux.HandleFunc("GET /files/out-0.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngImage)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newTestClient(server *httptest.Server) *Client {
+	return NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "r8_test", PollInterval: 10 * time.Millisecond})
+}
+
+func imageRequest() *responses.Request {
+	return &responses.Request{
+		Model: "black-forest-labs/flux-schnell",
+		Input: responses.InputUnion{OfString: utils.Ptr("A sunset over the sea")},
+		Tools: []responses.ToolUnion{{OfImageGeneration: &responses.ImageGenerationTool{}}},
+	}
+}
+
+func TestClient_NewResponses_PollsPrediction(t *testing.T) {
+	server := newPredictionServer(t)
+
+	out, err := newTestClient(server).NewResponses(context.Background(), imageRequest())
+	require.NoError(t, err)
+
+	require.Len(t, out.Output, 1)
+	require.NotNil(t, out.Output[0].OfImageGenerationCall)
+	assert.Equal(t, "png", out.Output[0].OfImageGenerationCall.OutputFormat)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pngImage), out.Output[0].OfImageGenerationCall.Result)
+}
+
+func TestClient_NewStreamingResponses_ImageGenerationCall(t *testing.T) {
+	server := newPredictionServer(t)
+
+	stream, err := newTestClient(server).NewStreamingResponses(context.Background(), imageRequest())
+	require.NoError(t, err)
+
+	var chunkTypes []string
+	acc := responses.ResponseAccumulator{}
+	for chunk := range stream {
+		chunkTypes = append(chunkTypes, chunk.ChunkType())
+		acc.Add(chunk)
+	}
+
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.image_generation_call.in_progress",
+		"response.image_generation_call.generating",
+		"response.image_generation_call.partial_image",
+		"response.output_item.done",
+		"response.completed",
+	}, chunkTypes)
+
+	output := acc.Response().Output
+	require.Len(t, output, 1)
+	require.NotNil(t, output[0].OfImageGenerationCall)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(pngImage), output[0].OfImageGenerationCall.Result)
+}
+
+func TestWebhooks_VerifyAndDeliver(t *testing.T) {
+	secret := []byte("replicate-webhook-secret")
+	webhooks, err := NewWebhooks("https://uno.example.com/api/gateway/replicate/webhook", "whsec_"+base64.StdEncoding.EncodeToString(secret))
+	require.NoError(t, err)
+
+	body := []byte(`{"id":"p1","status":"succeeded","output":["https://replicate.delivery/out-0.png"]}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("msg_1." + timestamp + "." + string(body)))
+	signature := "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.NoError(t, webhooks.Verify("msg_1", timestamp, "v1,bm90IGl0 "+signature, body))
+	assert.ErrorIs(t, webhooks.Verify("msg_1", timestamp, signature, []byte(`{"id":"p2"}`)), ErrInvalidWebhookSignature)
+
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	assert.ErrorIs(t, webhooks.Verify("msg_1", old, signature, body), ErrInvalidWebhookSignature)
+
+	delivered, stop := webhooks.wait("p1")
+	defer stop()
+
+	require.NoError(t, webhooks.Deliver(body))
+	select {
+	case p := <-delivered:
+		assert.Equal(t, []string{"https://replicate.delivery/out-0.png"}, p.OutputStrings())
+	default:
+		t.Fatal("prediction was not delivered")
+	}
+}
@@ -0,0 +1,77 @@
+package replicate_responses
+
+import (
+	"strings"
+
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// NativeRequestToRequest builds the input of the prediction from the last user message, its text is the prompt and
+// its first image the image. The extra params of the request are passed as inputs of the model, and take precedence.
+func NativeRequestToRequest(in *responses.Request) *Request {
+	_, version := ModelVersion(in.Model)
+
+	input := map[string]any{}
+	prompt, images := NativeInputToPrompt(in.Input)
+	if prompt != "" {
+		input["prompt"] = prompt
+	}
+	if len(images) > 0 {
+		input["image"] = images[0]
+	}
+
+	for name, value := range in.ExtraParams {
+		input[name] = value
+	}
+
+	return &Request{
+		Version: version,
+		Input:   input,
+	}
+}
+
+// ModelVersion splits a model into its name, "owner/name", and its version. Without version the latest version of
+// the model runs.
+func ModelVersion(model string) (string, string) {
+	name, version, _ := strings.Cut(model, ":")
+	return name, version
+}
+
+// NativeInputToPrompt returns the text and the images of the last user message of the input
+func NativeInputToPrompt(in responses.InputUnion) (string, []string) {
+	if in.OfString != nil {
+		return *in.OfString, nil
+	}
+
+	for i := len(in.OfInputMessageList) - 1; i >= 0; i-- {
+		msg := in.OfInputMessageList[i]
+
+		switch {
+		case msg.OfEasyInput != nil && (msg.OfEasyInput.Role == constants.RoleUser || msg.OfEasyInput.Role == ""):
+			if msg.OfEasyInput.Content.OfString != nil {
+				return *msg.OfEasyInput.Content.OfString, nil
+			}
+			return nativeContentToPrompt(msg.OfEasyInput.Content.OfInputMessageList)
+
+		case msg.OfInputMessage != nil && msg.OfInputMessage.Role == constants.RoleUser:
+			return nativeContentToPrompt(msg.OfInputMessage.Content)
+		}
+	}
+
+	return "", nil
+}
+
+func nativeContentToPrompt(content responses.InputContent) (string, []string) {
+	var texts, images []string
+	for _, part := range content {
+		switch {
+		case part.OfInputText != nil:
+			texts = append(texts, part.OfInputText.Text)
+		case part.OfInputImage != nil && part.OfInputImage.ImageURL != nil:
+			images = append(images, *part.OfInputImage.ImageURL)
+		}
+	}
+
+	return strings.Join(texts, "\n"), images
+}
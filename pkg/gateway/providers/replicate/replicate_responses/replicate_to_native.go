@@ -0,0 +1,69 @@
+package replicate_responses
+
+import (
+	"strings"
+
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Image is an image generated by a prediction, downloaded from its output URL
+type Image struct {
+	Format string // "png", "webp", ...
+	Data   string // Base64
+}
+
+// PredictionToNativeResponse converts a succeeded prediction. Its images become image generation calls, given by
+// output URL, and its text, including the URLs of the other files, an assistant message.
+func PredictionToNativeResponse(p *Prediction, images map[string]Image) *responses.Response {
+	out := &responses.Response{
+		ID:     p.ID,
+		Model:  p.Model,
+		Output: []responses.OutputMessageUnion{},
+		Usage:  &responses.Usage{},
+		Metadata: map[string]any{
+			"prediction_id": p.ID,
+		},
+	}
+	if p.Metrics != nil && p.Metrics.PredictTime != nil {
+		out.Metadata["predict_time"] = *p.Metrics.PredictTime
+	}
+
+	var text strings.Builder
+	for _, s := range p.OutputStrings() {
+		if image, ok := images[s]; ok {
+			out.Output = append(out.Output, responses.OutputMessageUnion{
+				OfImageGenerationCall: &responses.ImageGenerationCallMessage{
+					ID:           responses.NewOutputItemImageGenerationCallID(),
+					Status:       "completed",
+					OutputFormat: image.Format,
+					Result:       image.Data,
+				},
+			})
+			continue
+		}
+
+		// Language models output their tokens, the other files are listed one per line
+		if IsFileURL(s) && text.Len() > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(s)
+		if IsFileURL(s) {
+			text.WriteString("\n")
+		}
+	}
+
+	if t := strings.TrimSpace(text.String()); t != "" {
+		out.Output = append(out.Output, responses.OutputMessageUnion{
+			OfOutputMessage: &responses.OutputMessage{
+				ID:   responses.NewOutputItemMessageID(),
+				Role: constants.RoleAssistant,
+				Content: responses.OutputContent{
+					{OfOutputText: &responses.OutputTextContent{Text: t, Annotations: []responses.Annotation{}}},
+				},
+			},
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,98 @@
+package replicate_responses
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imagePredictionFixture = `{
+	"id": "gm3qorzdhgbfurvjtvhg6dckhu",
+	"model": "black-forest-labs/flux-schnell",
+	"version": "",
+	"status": "succeeded",
+	"input": {"prompt": "A sunset over the sea"},
+	"output": ["https://replicate.delivery/xezq/out-0.webp", "https://replicate.delivery/xezq/out-1.webp"],
+	"error": null,
+	"logs": "",
+	"metrics": {"predict_time": 0.92},
+	"urls": {
+		"get": "https://api.replicate.com/v1/predictions/gm3qorzdhgbfurvjtvhg6dckhu",
+		"cancel": "https://api.replicate.com/v1/predictions/gm3qorzdhgbfurvjtvhg6dckhu/cancel"
+	},
+	"created_at": "2025-08-12T10:04:13.231Z"
+}`
+
+const textPredictionFixture = `{
+	"id": "q0ke1h8tqnrme0cs4mc8sv8mh8",
+	"model": "yorickvp/llava-13b",
+	"status": "succeeded",
+	"output": ["The ", "image ", "shows ", "a ", "sunset."],
+	"error": null,
+	"urls": {"get": "https://api.replicate.com/v1/predictions/q0ke1h8tqnrme0cs4mc8sv8mh8"}
+}`
+
+func TestNativeRequestToRequest_PromptAndImage(t *testing.T) {
+	in := &responses.Request{
+		Model: "yorickvp/llava-13b:80537f9eead1a5bfa72d5ac6ea6414379be41d4d4f6679fd776e9535d1eb58bb",
+		Input: responses.InputUnion{OfInputMessageList: responses.InputMessageList{
+			{OfEasyInput: &responses.EasyMessage{Role: constants.RoleUser, Content: responses.EasyInputContentUnion{OfString: utils.Ptr("Hello")}}},
+			{OfInputMessage: &responses.InputMessage{
+				Role: constants.RoleUser,
+				Content: responses.InputContent{
+					{OfInputText: &responses.InputTextContent{Text: "What is in this image?"}},
+					{OfInputImage: &responses.InputImageContent{ImageURL: utils.Ptr("https://example.com/sunset.png")}},
+				},
+			}},
+		}},
+		Parameters: responses.Parameters{
+			ExtraParams: map[string]any{"temperature": 0.2, "prompt": "Describe the image"},
+		},
+	}
+
+	out := NativeRequestToRequest(in)
+
+	assert.Equal(t, "80537f9eead1a5bfa72d5ac6ea6414379be41d4d4f6679fd776e9535d1eb58bb", out.Version)
+	assert.Equal(t, map[string]any{
+		"prompt":      "Describe the image", // The extra params take precedence
+		"image":       "https://example.com/sunset.png",
+		"temperature": 0.2,
+	}, out.Input)
+}
+
+func TestPredictionToNativeResponse_Images(t *testing.T) {
+	var p Prediction
+	require.NoError(t, sonic.Unmarshal([]byte(imagePredictionFixture), &p))
+
+	out := PredictionToNativeResponse(&p, map[string]Image{
+		"https://replicate.delivery/xezq/out-0.webp": {Format: "webp", Data: "UklGRg=="},
+	})
+
+	require.Len(t, out.Output, 2)
+	require.NotNil(t, out.Output[0].OfImageGenerationCall)
+	assert.Equal(t, "webp", out.Output[0].OfImageGenerationCall.OutputFormat)
+	assert.Equal(t, "UklGRg==", out.Output[0].OfImageGenerationCall.Result)
+
+	// The image that wasn't downloaded is left as a URL
+	require.NotNil(t, out.Output[1].OfOutputMessage)
+	assert.Equal(t, "https://replicate.delivery/xezq/out-1.webp", out.Output[1].OfOutputMessage.Content[0].OfOutputText.Text)
+
+	assert.Equal(t, "gm3qorzdhgbfurvjtvhg6dckhu", out.Metadata["prediction_id"])
+	assert.Equal(t, 0.92, out.Metadata["predict_time"])
+}
+
+func TestPredictionToNativeResponse_Text(t *testing.T) {
+	var p Prediction
+	require.NoError(t, sonic.Unmarshal([]byte(textPredictionFixture), &p))
+
+	out := PredictionToNativeResponse(&p, nil)
+
+	require.Len(t, out.Output, 1)
+	require.NotNil(t, out.Output[0].OfOutputMessage)
+	assert.Equal(t, "The image shows a sunset.", out.Output[0].OfOutputMessage.Content[0].OfOutputText.Text)
+}
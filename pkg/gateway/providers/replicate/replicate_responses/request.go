@@ -0,0 +1,11 @@
+package replicate_responses
+
+// Request creates a prediction, see https://replicate.com/docs/reference/http#predictions.create
+type Request struct {
+	Version string         `json:"version,omitempty"` // Only for the predictions of a model version
+	Input   map[string]any `json:"input"`
+
+	// Webhook is called when the prediction completes, the client polls the prediction otherwise
+	Webhook             string   `json:"webhook,omitempty"`
+	WebhookEventsFilter []string `json:"webhook_events_filter,omitempty"` // "start", "output", "logs", "completed"
+}
@@ -0,0 +1,83 @@
+package replicate_responses
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PredictionStatus string
+
+const (
+	PredictionStatusStarting   PredictionStatus = "starting"
+	PredictionStatusProcessing PredictionStatus = "processing"
+	PredictionStatusSucceeded  PredictionStatus = "succeeded"
+	PredictionStatusFailed     PredictionStatus = "failed"
+	PredictionStatusCanceled   PredictionStatus = "canceled"
+)
+
+// Done reports whether the prediction has stopped, successfully or not
+func (s PredictionStatus) Done() bool {
+	return s == PredictionStatusSucceeded || s == PredictionStatusFailed || s == PredictionStatusCanceled
+}
+
+type Prediction struct {
+	ID        string           `json:"id"`
+	Model     string           `json:"model"`
+	Version   string           `json:"version"`
+	Status    PredictionStatus `json:"status"`
+	Input     map[string]any   `json:"input"`
+	Output    any              `json:"output"` // A string, or a list of strings, depending on the model
+	Error     any              `json:"error"`
+	Logs      string           `json:"logs"`
+	Metrics   *Metrics         `json:"metrics,omitempty"`
+	URLs      URLs             `json:"urls"`
+	CreatedAt string           `json:"created_at"`
+}
+
+type Metrics struct {
+	PredictTime *float64 `json:"predict_time,omitempty"` // Seconds
+}
+
+type URLs struct {
+	Get    string `json:"get"`
+	Cancel string `json:"cancel"`
+	Stream string `json:"stream,omitempty"`
+}
+
+// ErrorMessage returns the error of a failed or canceled prediction
+func (p *Prediction) ErrorMessage() string {
+	switch {
+	case p.Error != nil:
+		return fmt.Sprint(p.Error)
+	case p.Status == PredictionStatusCanceled:
+		return "prediction was canceled"
+	case p.Status == PredictionStatusFailed:
+		return "prediction failed"
+	}
+
+	return ""
+}
+
+// OutputStrings flattens the output of the prediction, which is a string or a list of strings for the image and
+// language models
+func (p *Prediction) OutputStrings() []string {
+	switch out := p.Output.(type) {
+	case string:
+		return []string{out}
+	case []any:
+		values := make([]string, 0, len(out))
+		for _, v := range out {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+// IsFileURL reports whether an output string is the URL of a file rather than text
+func IsFileURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "data:")
+}
@@ -0,0 +1,192 @@
+package replicate
+
+import (
+	"time"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// stream builds the chunks of a prediction, its output is only known once the prediction completes
+type stream struct {
+	id    string
+	model string
+	seq   int
+}
+
+func (s *stream) nextSeqNum() int {
+	n := s.seq
+	s.seq++
+	return n
+}
+
+func (s *stream) responseData(status string) responses.ChunkResponseData {
+	return responses.ChunkResponseData{
+		Id:        s.id,
+		Object:    "response",
+		CreatedAt: int(time.Now().Unix()),
+		Status:    status,
+		Request:   responses.Request{Model: s.model},
+	}
+}
+
+func (s *stream) responseCreated() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseCreated: &responses.ChunkResponse[constants.ChunkTypeResponseCreated]{
+			SequenceNumber: s.nextSeqNum(),
+			Response:       s.responseData("in_progress"),
+		},
+	}
+}
+
+func (s *stream) responseInProgress() *responses.ResponseChunk {
+	return &responses.ResponseChunk{
+		OfResponseInProgress: &responses.ChunkResponse[constants.ChunkTypeResponseInProgress]{
+			SequenceNumber: s.nextSeqNum(),
+			Response:       s.responseData("in_progress"),
+		},
+	}
+}
+
+func (s *stream) responseCompleted(output []responses.OutputMessageUnion) *responses.ResponseChunk {
+	data := s.responseData("completed")
+	data.Output = output
+
+	return &responses.ResponseChunk{
+		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
+			SequenceNumber: s.nextSeqNum(),
+			Response:       data,
+		},
+	}
+}
+
+func (s *stream) responseFailed(message string) *responses.ResponseChunk {
+	data := s.responseData("failed")
+	data.Output = []responses.OutputMessageUnion{}
+	data.Error = map[string]any{"message": message}
+
+	return &responses.ResponseChunk{
+		OfResponseCompleted: &responses.ChunkResponse[constants.ChunkTypeResponseCompleted]{
+			SequenceNumber: s.nextSeqNum(),
+			Response:       data,
+		},
+	}
+}
+
+// outputChunks streams the output items of the completed prediction
+func (s *stream) outputChunks(output []responses.OutputMessageUnion) []*responses.ResponseChunk {
+	var chunks []*responses.ResponseChunk
+	for i, item := range output {
+		switch {
+		case item.OfImageGenerationCall != nil:
+			chunks = append(chunks, s.imageGenerationCallChunks(i, item.OfImageGenerationCall)...)
+		case item.OfOutputMessage != nil:
+			chunks = append(chunks, s.messageChunks(i, item.OfOutputMessage)...)
+		}
+	}
+
+	return chunks
+}
+
+func (s *stream) imageGenerationCallChunks(outputIndex int, call *responses.ImageGenerationCallMessage) []*responses.ResponseChunk {
+	item := func(status string, result string) responses.ChunkOutputItemData {
+		return responses.ChunkOutputItemData{
+			Type:         "image_generation_call",
+			Id:           call.ID,
+			Status:       status,
+			Background:   utils.Ptr(call.Background),
+			OutputFormat: utils.Ptr(call.OutputFormat),
+			Quality:      utils.Ptr(call.Quality),
+			Size:         utils.Ptr(call.Size),
+			Result:       utils.Ptr(result),
+		}
+	}
+
+	return []*responses.ResponseChunk{
+		{OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			SequenceNumber: s.nextSeqNum(),
+			OutputIndex:    outputIndex,
+			Item:           item("in_progress", ""),
+		}},
+		{OfImageGenerationCallInProgress: &responses.ChunkImageGenerationCall[constants.ChunkTypeImageGenerationCallInProgress]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         call.ID,
+			OutputIndex:    outputIndex,
+		}},
+		{OfImageGenerationCallGenerating: &responses.ChunkImageGenerationCall[constants.ChunkTypeImageGenerationCallGenerating]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         call.ID,
+			OutputIndex:    outputIndex,
+		}},
+		{OfImageGenerationCallPartialImage: &responses.ChunkImageGenerationCall[constants.ChunkTypeImageGenerationCallPartialImage]{
+			SequenceNumber:     s.nextSeqNum(),
+			ItemId:             call.ID,
+			OutputIndex:        outputIndex,
+			PartialImageBase64: call.Result,
+			OutputFormat:       utils.Ptr(call.OutputFormat),
+			Background:         utils.Ptr(call.Background),
+			Quality:            utils.Ptr(call.Quality),
+			Size:               utils.Ptr(call.Size),
+		}},
+		{OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			SequenceNumber: s.nextSeqNum(),
+			OutputIndex:    outputIndex,
+			Item:           item("completed", call.Result),
+		}},
+	}
+}
+
+func (s *stream) messageChunks(outputIndex int, msg *responses.OutputMessage) []*responses.ResponseChunk {
+	text := msg.Content[0].OfOutputText.Text
+	part := responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Text: text, Annotations: []responses.Annotation{}}}
+
+	return []*responses.ResponseChunk{
+		{OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			SequenceNumber: s.nextSeqNum(),
+			OutputIndex:    outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      msg.ID,
+				Status:  "in_progress",
+				Role:    constants.RoleAssistant,
+				Content: responses.OutputContent{},
+			},
+		}},
+		{OfContentPartAdded: &responses.ChunkContentPart[constants.ChunkTypeContentPartAdded]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         msg.ID,
+			OutputIndex:    outputIndex,
+			Part:           responses.OutputContentUnion{OfOutputText: &responses.OutputTextContent{Annotations: []responses.Annotation{}}},
+		}},
+		{OfOutputTextDelta: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDelta]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         msg.ID,
+			OutputIndex:    outputIndex,
+			Delta:          text,
+		}},
+		{OfOutputTextDone: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDone]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         msg.ID,
+			OutputIndex:    outputIndex,
+			Text:           utils.Ptr(text),
+		}},
+		{OfContentPartDone: &responses.ChunkContentPart[constants.ChunkTypeContentPartDone]{
+			SequenceNumber: s.nextSeqNum(),
+			ItemId:         msg.ID,
+			OutputIndex:    outputIndex,
+			Part:           part,
+		}},
+		{OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			SequenceNumber: s.nextSeqNum(),
+			OutputIndex:    outputIndex,
+			Item: responses.ChunkOutputItemData{
+				Type:    "message",
+				Id:      msg.ID,
+				Status:  "completed",
+				Role:    constants.RoleAssistant,
+				Content: msg.Content,
+			},
+		}},
+	}
+}
@@ -0,0 +1,117 @@
+package replicate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/gateway/providers/replicate/replicate_responses"
+)
+
+// webhookTolerance is how old a webhook can be, to prevent replays
+const webhookTolerance = 5 * time.Minute
+
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// Webhooks delivers the completed predictions notified by Replicate to the clients waiting for them, who otherwise
+// poll the predictions. The waiting clients are in memory, the clients of other replicas keep polling.
+type Webhooks struct {
+	// URL receives the webhooks of Replicate, e.g. https://uno.example.com/api/gateway/replicate/webhook
+	URL string
+
+	secret []byte
+
+	mu      sync.Mutex
+	waiters map[string]chan *replicate_responses.Prediction
+}
+
+// NewWebhooks creates the webhooks of the URL, signed with the secret of the account ("whsec_" prefixed), see
+// https://replicate.com/docs/topics/webhooks/verify-webhook
+func NewWebhooks(url string, secret string) (*Webhooks, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return nil, errors.New("invalid webhook secret")
+	}
+
+	return &Webhooks{
+		URL:     url,
+		secret:  key,
+		waiters: map[string]chan *replicate_responses.Prediction{},
+	}, nil
+}
+
+// Verify checks the signature of a webhook, given its webhook-id, webhook-timestamp and webhook-signature headers
+func (w *Webhooks) Verify(id string, timestamp string, signature string, body []byte) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidWebhookSignature
+	}
+
+	mac := hmac.New(sha256.New, w.secret)
+	mac.Write([]byte(id + "." + timestamp + "." + string(body)))
+	expected := mac.Sum(nil)
+
+	// The header lists space separated "v1,<signature>"
+	for _, s := range strings.Fields(signature) {
+		_, sig, ok := strings.Cut(s, ",")
+		if !ok {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(sig)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return ErrInvalidWebhookSignature
+}
+
+// Deliver hands the prediction of a verified webhook to the client waiting for it
+func (w *Webhooks) Deliver(body []byte) error {
+	var p replicate_responses.Prediction
+	if err := sonic.Unmarshal(body, &p); err != nil {
+		return err
+	}
+
+	if !p.Status.Done() {
+		return nil
+	}
+
+	w.mu.Lock()
+	ch, ok := w.waiters[p.ID]
+	delete(w.waiters, p.ID)
+	w.mu.Unlock()
+
+	if ok {
+		ch <- &p
+	}
+
+	return nil
+}
+
+// wait registers a client waiting for the prediction, stop unregisters it
+func (w *Webhooks) wait(id string) (<-chan *replicate_responses.Prediction, func()) {
+	ch := make(chan *replicate_responses.Prediction, 1)
+
+	w.mu.Lock()
+	w.waiters[id] = ch
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		if w.waiters[id] == ch {
+			delete(w.waiters, id)
+		}
+		w.mu.Unlock()
+	}
+}
@@ -0,0 +1,101 @@
+package together
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/gateway/providers/openai"
+	"github.com/curaious/uno/pkg/gateway/providers/together/together_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+const DefaultBaseURL = "https://api.together.xyz/v1"
+
+type ClientOptions struct {
+	// https://api.together.xyz/v1
+	BaseURL string
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client calls the Together AI API. The models are named after their organization, e.g.
+// meta-llama/Llama-3.3-70B-Instruct-Turbo. The chat completions and the embeddings are the ones of the OpenAI API, the
+// responses are translated to chat completions, which Together serves for every model.
+type Client struct {
+	*openai.Client
+	chat *chat_responses.Client
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		Client: openai.NewClient(&openai.ClientOptions{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		}),
+		chat: &chat_responses.Client{
+			BaseURL:    opts.BaseURL,
+			ApiKey:     opts.ApiKey,
+			Headers:    opts.Headers,
+			HTTPClient: opts.transport,
+		},
+	}
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	res, err := c.post(ctx, inp, false)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var togetherResponse *chat_responses.Response
+	err = utils.DecodeJSON(res.Body, &togetherResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return togetherResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	res, err := c.post(ctx, inp, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return chat_responses.Stream[chat_responses.ResponseChunk](ctx, res, &chat_responses.ResponseChunkToNativeResponseChunkConverter{}), nil
+}
+
+// post sends the chat completion request of the responses request
+func (c *Client) post(ctx context.Context, inp *responses.Request, stream bool) (*http.Response, error) {
+	togetherRequest := together_responses.NativeRequestToRequest(inp)
+	togetherRequest.Stream = stream
+
+	payload, err := sonic.Marshal(togetherRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.chat.Post(ctx, payload, stream)
+}
@@ -0,0 +1,81 @@
+package together
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_NewResponses_Reasoning(t *testing.T) {
+	tests := []struct {
+		name                string
+		model               string
+		effort              string
+		wantReasoning       any
+		wantReasoningEffort any
+	}{
+		{
+			name:          "hybrid model",
+			model:         "Qwen/Qwen3-235B-A22B-Instruct-2507-tput",
+			effort:        "high",
+			wantReasoning: map[string]any{"enabled": true},
+		},
+		{
+			name:          "hybrid model without thinking",
+			model:         "deepseek-ai/DeepSeek-V3.1",
+			effort:        "none",
+			wantReasoning: map[string]any{"enabled": false},
+		},
+		{
+			name:                "gpt-oss",
+			model:               "openai/gpt-oss-120b",
+			effort:              "high",
+			wantReasoningEffort: "high",
+		},
+		{
+			name:                "gpt-oss without the effort",
+			model:               "openai/gpt-oss-20b",
+			effort:              "minimal",
+			wantReasoningEffort: "low",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var payload map[string]any
+				require.NoError(t, sonic.Unmarshal(body, &payload))
+				assert.Equal(t, tt.model, payload["model"])
+				assert.Equal(t, tt.wantReasoning, payload["reasoning"])
+				assert.Equal(t, tt.wantReasoningEffort, payload["reasoning_effort"])
+
+				_, _ = w.Write([]byte(`{
+					"id": "o1",
+					"model": "` + tt.model + `",
+					"choices": [{"index": 0, "message": {"role": "assistant", "content": "Sunny."}, "finish_reason": "stop"}]
+				}`))
+			}))
+			defer server.Close()
+
+			var req responses.Request
+			require.NoError(t, sonic.Unmarshal([]byte(`{
+				"model": "`+tt.model+`",
+				"input": "Weather in Paris?",
+				"reasoning": {"effort": "`+tt.effort+`"}
+			}`), &req))
+
+			client := NewClient(&ClientOptions{BaseURL: server.URL, ApiKey: "together-key"})
+			out, err := client.NewResponses(context.Background(), &req)
+			require.NoError(t, err)
+			assert.Equal(t, "Sunny.", out.Output[0].OfOutputMessage.Content[0].OfOutputText.Text)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package together_responses
+
+import (
+	"strings"
+
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base/chat_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// Options are the differences of the chat completions API of Together AI, which follows the OpenAI API
+var Options = chat_responses.Options{Provider: "together"}
+
+// NativeRequestToRequest converts the request to the chat completions API of Together AI, see
+// https://docs.together.ai/reference/chat-completions-1
+func NativeRequestToRequest(in *responses.Request) *chat_responses.Request {
+	out := chat_responses.NativeRequestToRequest(in, Options)
+	out.Reasoning, out.ReasoningEffort = NativeReasoningToReasoning(in.Model, in.Reasoning)
+
+	return out
+}
+
+// NativeReasoningToReasoning converts the reasoning param. The gpt-oss models take an effort, the hybrid models, e.g.
+// DeepSeek V3.1 or Qwen3, switch their thinking on or off. The reasoning models always think.
+func NativeReasoningToReasoning(model string, in *responses.ReasoningParam) (*chat_responses.Reasoning, *string) {
+	if in == nil {
+		return nil, nil
+	}
+
+	effort := in.NormalizedEffort()
+
+	if strings.HasPrefix(model, "openai/gpt-oss") {
+		switch effort {
+		case responses.ReasoningEffortNone, responses.ReasoningEffortMinimal:
+			return nil, utils.Ptr(string(responses.ReasoningEffortLow))
+		case responses.ReasoningEffortXHigh:
+			return nil, utils.Ptr(string(responses.ReasoningEffortHigh))
+		}
+		return nil, utils.Ptr(string(effort))
+	}
+
+	return &chat_responses.Reasoning{Enabled: utils.Ptr(effort != responses.ReasoningEffortNone)}, nil
+}
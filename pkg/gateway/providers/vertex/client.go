@@ -0,0 +1,254 @@
+package vertex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway/providers/base"
+	"github.com/curaious/uno/pkg/gateway/providers/gemini/gemini_responses"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// defaultLocation is the location of the requests without location nor base URL
+const defaultLocation = "us-central1"
+
+type ClientOptions struct {
+	// https://us-central1-aiplatform.googleapis.com
+	BaseURL string
+
+	// Location of the models, the location of the base URL by default
+	Location string
+
+	// Project the requests are billed to, the project of the credentials by default
+	Project string
+
+	// ApiKey is the JSON key of a service account, "Bearer <access token>", or empty for the application default
+	// credentials
+	ApiKey  string
+	Headers map[string]string
+
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
+	// AuthHTTPClient sends the requests of the access tokens (default http.DefaultClient)
+	AuthHTTPClient *http.Client
+
+	transport *http.Client
+}
+
+// Client runs the Gemini models of Vertex AI, authenticated with the credentials of Google Cloud instead of the API
+// keys of the Gemini API. The requests and the responses are the ones of the Gemini API.
+type Client struct {
+	*base.BaseProvider
+	opts *ClientOptions
+}
+
+func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
+	if opts.transport == nil {
+		opts.transport = http.DefaultClient
+	}
+	if opts.AuthHTTPClient == nil {
+		opts.AuthHTTPClient = http.DefaultClient
+	}
+
+	if opts.Location == "" {
+		opts.Location = LocationOfEndpoint(opts.BaseURL)
+	}
+	if opts.Location == "" {
+		opts.Location = defaultLocation
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://" + opts.Location + "-aiplatform.googleapis.com"
+		if opts.Location == "global" {
+			opts.BaseURL = "https://aiplatform.googleapis.com"
+		}
+	}
+	opts.BaseURL = strings.TrimSuffix(opts.BaseURL, "/")
+
+	return &Client{
+		opts: opts,
+	}
+}
+
+// LocationOfEndpoint returns the location of a Vertex AI endpoint, e.g. europe-west4 for
+// https://europe-west4-aiplatform.googleapis.com, global for https://aiplatform.googleapis.com, or an empty string
+// for the other URLs
+func LocationOfEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+
+	host := u.Hostname()
+	if host == "aiplatform.googleapis.com" {
+		return "global"
+	}
+
+	location, ok := strings.CutSuffix(host, "-aiplatform.googleapis.com")
+	if !ok {
+		return ""
+	}
+
+	return location
+}
+
+func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*responses.Response, error) {
+	req, err := c.newRequest(ctx, inp, "generateContent")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var geminiResponse *gemini_responses.Response
+	err = utils.DecodeJSON(res.Body, &geminiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	if geminiResponse.Error != nil {
+		return nil, fmt.Errorf("vertex AI error: %s (code: %d, status: %s)", geminiResponse.Error.Message, geminiResponse.Error.Code, geminiResponse.Error.Status)
+	}
+
+	return geminiResponse.ToNativeResponse(), nil
+}
+
+func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Request) (chan *responses.ResponseChunk, error) {
+	// The chunks are sent as server-sent events rather than as a JSON array
+	req, err := c.newRequest(ctx, inp, "streamGenerateContent?alt=sse")
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.opts.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+
+		var errResp gemini_responses.Response
+		if err := utils.DecodeJSON(res.Body, &errResp); err != nil || errResp.Error == nil {
+			return nil, errors.New(res.Status)
+		}
+		return nil, fmt.Errorf("vertex AI error: %s (code: %d, status: %s)", errResp.Error.Message, errResp.Error.Code, errResp.Error.Status)
+	}
+
+	out := make(chan *responses.ResponseChunk)
+
+	go func() {
+		defer res.Body.Close()
+		defer close(out)
+
+		reader := bufio.NewReader(res.Body)
+		converter := gemini_responses.ResponseChunkToNativeResponseChunkConverter{}
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				for _, nativeChunk := range converter.ResponseChunkToNativeResponseChunk(nil) {
+					out <- nativeChunk
+				}
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			geminiChunk := &gemini_responses.Response{}
+			if err := sonic.UnmarshalString(strings.TrimPrefix(line, "data:"), geminiChunk); err != nil {
+				slog.WarnContext(ctx, "unable to unmarshal vertex AI chunk", slog.String("data", line), slog.Any("error", err))
+				continue
+			}
+
+			for _, nativeChunk := range converter.ResponseChunkToNativeResponseChunk(geminiChunk) {
+				out <- nativeChunk
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// newRequest creates the request of the method of the model, authenticated with the credentials of the API key
+func (c *Client) newRequest(ctx context.Context, inp *responses.Request, method string) (*http.Request, error) {
+	payload, err := sonic.Marshal(gemini_responses.ResponsesInputToGeminiResponsesInput(inp))
+	if err != nil {
+		return nil, err
+	}
+
+	var creds *credentials
+	token, isToken := strings.CutPrefix(c.opts.ApiKey, "Bearer ")
+	if !isToken {
+		creds, err = credentialsOf(c.opts.ApiKey, c.opts.AuthHTTPClient)
+		if err != nil {
+			return nil, err
+		}
+
+		accessToken, err := creds.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token of Vertex AI: %w", err)
+		}
+		token = accessToken.AccessToken
+	}
+
+	project := c.opts.Project
+	if project == "" && creds != nil {
+		project = creds.project
+	}
+	if project == "" {
+		project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if project == "" {
+		return nil, errors.New("project of Vertex AI is not configured")
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/locations/%s/%s:%s", c.opts.BaseURL, project, c.opts.Location, modelPath(inp.Model), method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req, nil
+}
+
+// modelPath returns the resource of the model relative to the location. The Gemini models are published by Google,
+// the other models are given by their resource, e.g. endpoints/<id> for a tuned model.
+func modelPath(model string) string {
+	if model == "" {
+		model = "gemini-2.5-flash"
+	}
+
+	if strings.Contains(model, "/") {
+		return model
+	}
+
+	return "publishers/google/models/" + model
+}
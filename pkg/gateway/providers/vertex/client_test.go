@@ -0,0 +1,159 @@
+package vertex
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceAccountKey returns the JSON key of a service account whose tokens are issued by the token URL
+func serviceAccountKey(t *testing.T, tokenURL string) string {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	key, err := sonic.MarshalString(map[string]string{
+		"type":           "service_account",
+		"project_id":     "sa-project",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "gateway@sa-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+
+	return key
+}
+
+func textRequest() *responses.Request {
+	return &responses.Request{
+		Model: "gemini-2.5-flash",
+		Input: responses.InputUnion{OfString: utils.Ptr("Hello")},
+	}
+}
+
+func TestClient_NewResponses_ServiceAccount(t *testing.T) {
+	var tokenRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			assert.NotEmpty(t, r.PostForm.Get("assertion"))
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "ya29.token", "token_type": "Bearer", "expires_in": 3600}`))
+			return
+		}
+
+		assert.Equal(t, "/v1/projects/sa-project/locations/europe-west4/publishers/google/models/gemini-2.5-flash:generateContent", r.URL.Path)
+		assert.Equal(t, "Bearer ya29.token", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"text":"Hello"`)
+
+		_, _ = w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "Hi"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 2, "candidatesTokenCount": 1, "totalTokenCount": 3},
+			"modelVersion": "gemini-2.5-flash",
+			"responseId": "resp-1"
+		}`))
+	}))
+	defer server.Close()
+
+	apiKey := serviceAccountKey(t, server.URL+"/token")
+	for range 2 {
+		client := NewClient(&ClientOptions{BaseURL: server.URL, Location: "europe-west4", ApiKey: apiKey})
+		out, err := client.NewResponses(context.Background(), textRequest())
+		require.NoError(t, err)
+
+		require.Len(t, out.Output, 1)
+		assert.Equal(t, "Hi", out.Output[0].OfOutputMessage.Content[0].OfOutputText.Text)
+	}
+
+	// The access token is reused by the clients of the same key
+	assert.Equal(t, int32(1), tokenRequests.Load())
+}
+
+func TestClient_NewStreamingResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/projects/my-project/locations/us-central1/endpoints/123:streamGenerateContent", r.URL.Path)
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		assert.Equal(t, "Bearer ya29.user-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"candidates": [{"content": {"role": "model", "parts": [{"text": "Hel"}]}}], "modelVersion": "gemini-2.5-flash", "responseId": "resp-2"}
+
+data: {"candidates": [{"content": {"role": "model", "parts": [{"text": "lo"}]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 2, "candidatesTokenCount": 2, "totalTokenCount": 4}, "modelVersion": "gemini-2.5-flash", "responseId": "resp-2"}
+
+`))
+	}))
+	defer server.Close()
+
+	req := textRequest()
+	req.Model = "endpoints/123"
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, Project: "my-project", ApiKey: "Bearer ya29.user-token"})
+	stream, err := client.NewStreamingResponses(context.Background(), req)
+	require.NoError(t, err)
+
+	var text strings.Builder
+	var completed *responses.ResponseChunk
+	for chunk := range stream {
+		if chunk.OfOutputTextDelta != nil {
+			text.WriteString(chunk.OfOutputTextDelta.Delta)
+		}
+		if chunk.OfResponseCompleted != nil {
+			completed = chunk
+		}
+	}
+
+	assert.Equal(t, "Hello", text.String())
+	require.NotNil(t, completed)
+	assert.Equal(t, 4, completed.OfResponseCompleted.Response.Usage.TotalTokens)
+}
+
+func TestClient_NewStreamingResponses_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error": {"code": 403, "message": "Permission denied on resource project my-project.", "status": "PERMISSION_DENIED"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&ClientOptions{BaseURL: server.URL, Project: "my-project", ApiKey: "Bearer ya29.user-token"})
+	_, err := client.NewStreamingResponses(context.Background(), textRequest())
+	assert.EqualError(t, err, "vertex AI error: Permission denied on resource project my-project. (code: 403, status: PERMISSION_DENIED)")
+}
+
+func TestClient_ProjectRequired(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	client := NewClient(&ClientOptions{ApiKey: "Bearer ya29.user-token"})
+	_, err := client.NewResponses(context.Background(), textRequest())
+	assert.EqualError(t, err, "project of Vertex AI is not configured")
+}
+
+func TestLocationOfEndpoint(t *testing.T) {
+	assert.Equal(t, "europe-west4", LocationOfEndpoint("https://europe-west4-aiplatform.googleapis.com"))
+	assert.Equal(t, "global", LocationOfEndpoint("https://aiplatform.googleapis.com/"))
+	assert.Equal(t, "", LocationOfEndpoint("https://vertex.internal.example.com"))
+
+	client := NewClient(&ClientOptions{Location: "global"})
+	assert.Equal(t, "https://aiplatform.googleapis.com", client.opts.BaseURL)
+}
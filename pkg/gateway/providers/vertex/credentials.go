@@ -0,0 +1,222 @@
+package vertex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// cloudPlatformScope is the OAuth scope of the requests to Vertex AI
+	cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+	defaultMetadataHost = "metadata.google.internal"
+)
+
+// credentials authenticate the requests to Vertex AI, project is the project of the credentials if known
+type credentials struct {
+	tokenSource oauth2.TokenSource
+	project     string
+}
+
+// credentialsFile is a credentials file of Google Cloud, either the key of a service account or the application
+// default credentials of a user written by gcloud
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// Service account
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+
+	// Authorized user
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// credentialsCache holds the credentials by hash of the API key, so that the access tokens are reused across the
+// clients, which are created for each request
+var credentialsCache sync.Map
+
+// credentialsOf returns the credentials of an API key, the key of a service account in JSON or an empty key for the
+// application default credentials. The token requests are sent with the HTTP client.
+func credentialsOf(apiKey string, client *http.Client) (*credentials, error) {
+	sum := sha256.Sum256([]byte(apiKey))
+	cacheKey := hex.EncodeToString(sum[:])
+
+	if cached, ok := credentialsCache.Load(cacheKey); ok {
+		return cached.(*credentials), nil
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	var creds *credentials
+	var err error
+	if strings.TrimSpace(apiKey) == "" {
+		creds, err = defaultCredentials(ctx, client)
+	} else {
+		creds, err = credentialsFromJSON(ctx, []byte(apiKey))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := credentialsCache.LoadOrStore(cacheKey, creds)
+	return cached.(*credentials), nil
+}
+
+func credentialsFromJSON(ctx context.Context, data []byte) (*credentials, error) {
+	var file credentialsFile
+	if err := sonic.Unmarshal(data, &file); err != nil {
+		return nil, errors.New("api key of Vertex AI must be the JSON key of a service account")
+	}
+
+	switch file.Type {
+	case "service_account":
+		tokenURL := file.TokenURI
+		if tokenURL == "" {
+			tokenURL = defaultTokenURL
+		}
+
+		config := &jwt.Config{
+			Email:        file.ClientEmail,
+			PrivateKey:   []byte(file.PrivateKey),
+			PrivateKeyID: file.PrivateKeyID,
+			Scopes:       []string{cloudPlatformScope},
+			TokenURL:     tokenURL,
+		}
+
+		return &credentials{tokenSource: config.TokenSource(ctx), project: file.ProjectID}, nil
+
+	case "authorized_user":
+		config := &oauth2.Config{
+			ClientID:     file.ClientID,
+			ClientSecret: file.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: defaultTokenURL, AuthStyle: oauth2.AuthStyleInParams},
+			Scopes:       []string{cloudPlatformScope},
+		}
+
+		return &credentials{tokenSource: config.TokenSource(ctx, &oauth2.Token{RefreshToken: file.RefreshToken}), project: file.QuotaProjectID}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported credentials type %q", file.Type)
+}
+
+// defaultCredentials finds the application default credentials, in order the file named by
+// GOOGLE_APPLICATION_CREDENTIALS, the credentials written by `gcloud auth application-default login` and the service
+// account of the metadata server of Compute Engine, GKE or Cloud Run
+func defaultCredentials(ctx context.Context, client *http.Client) (*credentials, error) {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		return credentialsFromJSON(ctx, data)
+	}
+
+	if path := wellKnownCredentialsFile(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			return credentialsFromJSON(ctx, data)
+		}
+	}
+
+	metadata := &metadataServer{host: os.Getenv("GCE_METADATA_HOST"), client: client}
+	if metadata.host == "" {
+		metadata.host = defaultMetadataHost
+	}
+
+	project, err := metadata.get(ctx, "project/project-id")
+	if err != nil {
+		return nil, errors.New("no api key nor application default credentials found for Vertex AI")
+	}
+
+	return &credentials{tokenSource: oauth2.ReuseTokenSource(nil, metadata), project: project}, nil
+}
+
+// wellKnownCredentialsFile returns the path of the application default credentials written by gcloud
+func wellKnownCredentialsFile() string {
+	if dir := os.Getenv("APPDATA"); dir != "" {
+		return filepath.Join(dir, "gcloud", "application_default_credentials.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+// metadataServer issues the access tokens of the service account attached to the instance
+type metadataServer struct {
+	host   string
+	client *http.Client
+}
+
+func (m *metadataServer) get(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+m.host+"/computeMetadata/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server returned %s", res.Status)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (m *metadataServer) Token() (*oauth2.Token, error) {
+	body, err := m.get(context.Background(), "instance/service-accounts/default/token")
+	if err != nil {
+		return nil, err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := sonic.UnmarshalString(body, &token); err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
@@ -22,6 +22,9 @@ type ClientOptions struct {
 	ApiKey  string
 	Headers map[string]string
 
+	// HTTPClient sends the requests to the provider (default http.DefaultClient)
+	HTTPClient *http.Client
+
 	transport *http.Client
 }
 
@@ -31,6 +34,9 @@ type Client struct {
 }
 
 func NewClient(opts *ClientOptions) *Client {
+	if opts.transport == nil {
+		opts.transport = opts.HTTPClient
+	}
 	if opts.transport == nil {
 		opts.transport = http.DefaultClient
 	}
@@ -52,7 +58,7 @@ func (c *Client) NewResponses(ctx context.Context, inp *responses.Request) (*res
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +92,7 @@ func (c *Client) NewStreamingResponses(ctx context.Context, inp *responses.Reque
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.BaseURL+"/responses", bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -8,13 +8,15 @@ import (
 func NativeRequestToRequest(in *responses.Request) *Request {
 	r := &Request{
 		Request: &openai_responses.Request{
-			*in,
+			Request: *in,
 		},
 	}
 
 	// Grok doesn't support reasoning effort except for older models like grok-3
 	if in.Reasoning != nil {
-		r.Reasoning.Effort = nil
+		r.Reasoning = &responses.ReasoningParam{
+			Summary: in.Reasoning.Summary,
+		}
 	}
 
 	return r
@@ -11,19 +11,20 @@ func (in *Request) ToNativeRequest() *responses.Request {
 		Instructions: in.Instructions,
 		Tools:        in.Tools,
 		Parameters: responses.Parameters{
-			Background:        in.Background,
-			MaxOutputTokens:   in.MaxOutputTokens,
-			MaxToolCalls:      in.MaxToolCalls,
-			ParallelToolCalls: in.ParallelToolCalls,
-			Store:             in.Store,
-			Temperature:       in.Temperature,
-			TopLogprobs:       in.TopLogprobs,
-			TopP:              in.TopP,
-			Include:           in.Include,
-			Metadata:          in.Metadata,
-			Stream:            in.Stream,
-			Reasoning:         in.Reasoning,
-			Text:              in.Text,
+			Background:         in.Background,
+			MaxOutputTokens:    in.MaxOutputTokens,
+			MaxToolCalls:       in.MaxToolCalls,
+			ParallelToolCalls:  in.ParallelToolCalls,
+			Store:              in.Store,
+			PreviousResponseID: in.PreviousResponseID,
+			Temperature:        in.Temperature,
+			TopLogprobs:        in.TopLogprobs,
+			TopP:               in.TopP,
+			Include:            in.Include,
+			Metadata:           in.Metadata,
+			Stream:             in.Stream,
+			Reasoning:          in.Reasoning,
+			Text:               in.Text,
 		},
 	}
 }
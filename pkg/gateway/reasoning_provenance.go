@@ -0,0 +1,96 @@
+package gateway
+
+import (
+	"log/slog"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// reasoningInput prepares the reasoning of the conversation for the provider. The encrypted content of reasoning
+// is signed by the provider producing it, the others reject the request with it, so the reasoning of other
+// providers is dropped, as are the thought signatures of Gemini function calls when the provider isn't Gemini or
+// Vertex AI.
+// The reasoning without provenance is sent as is. The request is copied, the input of the caller isn't modified.
+func reasoningInput(providerName llm.ProviderName, in *responses.Request) *responses.Request {
+	if len(in.Input.OfInputMessageList) == 0 {
+		return in
+	}
+
+	msgs := make(responses.InputMessageList, 0, len(in.Input.OfInputMessageList))
+	dropped := 0
+	for _, msg := range in.Input.OfInputMessageList {
+		switch {
+		case msg.OfReasoning != nil:
+			if msg.OfReasoning.Provider != "" && msg.OfReasoning.Provider != string(providerName) {
+				dropped++
+				continue
+			}
+
+			reasoning := *msg.OfReasoning
+			reasoning.Provider = ""
+			reasoning.Model = ""
+			msg.OfReasoning = &reasoning
+
+		case msg.OfFunctionCall != nil && msg.OfFunctionCall.ThoughtSignature != nil && providerName != llm.ProviderNameGemini && providerName != llm.ProviderNameVertexAI:
+			functionCall := *msg.OfFunctionCall
+			functionCall.ThoughtSignature = nil
+			msg.OfFunctionCall = &functionCall
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	if dropped > 0 {
+		slog.Debug("dropped the reasoning of other providers", slog.String("provider", string(providerName)), slog.Int("count", dropped))
+	}
+
+	out := *in
+	out.Input = responses.InputUnion{
+		OfInputMessageList: msgs,
+	}
+
+	return &out
+}
+
+// tagReasoningProvenance sets the provider and the model producing the reasoning of the response
+func tagReasoningProvenance(providerName llm.ProviderName, model string, out *responses.Response) {
+	if out == nil {
+		return
+	}
+
+	for _, msg := range out.Output {
+		if msg.OfReasoning != nil {
+			msg.OfReasoning.Provider = string(providerName)
+			msg.OfReasoning.Model = model
+		}
+	}
+}
+
+// tagStreamingReasoningProvenance sets the provider and the model producing the reasoning of the streamed response,
+// on the reasoning items and on the completed response
+func tagStreamingReasoningProvenance(providerName llm.ProviderName, model string, in chan *responses.ResponseChunk) chan *responses.ResponseChunk {
+	out := make(chan *responses.ResponseChunk)
+	go func() {
+		defer close(out)
+
+		for chunk := range in {
+			switch {
+			case chunk.OfOutputItemDone != nil && chunk.OfOutputItemDone.Item.Type == "reasoning":
+				chunk.OfOutputItemDone.Item.Provider = string(providerName)
+				chunk.OfOutputItemDone.Item.Model = model
+			case chunk.OfResponseCompleted != nil:
+				for _, msg := range chunk.OfResponseCompleted.Response.Output {
+					if msg.OfReasoning != nil {
+						msg.OfReasoning.Provider = string(providerName)
+						msg.OfReasoning.Model = model
+					}
+				}
+			}
+
+			out <- chunk
+		}
+	}()
+
+	return out
+}
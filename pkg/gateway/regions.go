@@ -0,0 +1,186 @@
+package gateway
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+)
+
+const (
+	// regionEWMAAlpha is the weight of the latest observation in the moving averages
+	regionEWMAAlpha = 0.2
+
+	// regionErrorPenalty scales the latency of a region by its error rate when comparing regions
+	regionErrorPenalty = 10.0
+
+	// regionExploreRate is the share of requests sent to a random region, so that the stats
+	// of regions that are not selected anymore keep getting refreshed
+	regionExploreRate = 0.05
+)
+
+type regionKey struct{}
+
+// ContextWithRegion pins the requests made with the context to the named region of the provider,
+// overriding both the pinned region of the provider config and the latency-based selection.
+func ContextWithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// RegionFromContext returns the region set by ContextWithRegion
+func RegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(regionKey{}).(string)
+	return region, ok && region != ""
+}
+
+// RegionStats holds the observed latency and error rate of a provider region
+type RegionStats struct {
+	Provider  llm.ProviderName `json:"provider"`
+	Region    string           `json:"region"`
+	LatencyMs float64          `json:"latency_ms"`
+	ErrorRate float64          `json:"error_rate"`
+	Requests  int64            `json:"requests"`
+	Errors    int64            `json:"errors"`
+}
+
+// RegionTracker tracks the latency and error rate of provider regions and selects the best region for a request
+type RegionTracker struct {
+	mu    sync.RWMutex
+	stats map[llm.ProviderName]map[string]*RegionStats
+}
+
+func NewRegionTracker() *RegionTracker {
+	return &RegionTracker{
+		stats: make(map[llm.ProviderName]map[string]*RegionStats),
+	}
+}
+
+// Select returns the region to send the request to. A region pinned via the context, or else via the provider
+// config, is always used when it exists. Otherwise, regions without observations are tried first, then the region
+// with the lowest latency weighted by its error rate is chosen. The regions without successful requests are given
+// the worst latency observed, so that a region failing fast is not taken for the fastest one.
+func (t *RegionTracker) Select(ctx context.Context, providerName llm.ProviderName, config *ProviderConfig) (RegionConfig, bool) {
+	if config == nil || len(config.Regions) == 0 {
+		return RegionConfig{}, false
+	}
+
+	pinned, ok := RegionFromContext(ctx)
+	if !ok {
+		pinned = config.PinnedRegion
+	}
+
+	if pinned != "" {
+		for _, region := range config.Regions {
+			if region.Name == pinned {
+				return region, true
+			}
+		}
+	}
+
+	if len(config.Regions) == 1 {
+		return config.Regions[0], true
+	}
+
+	if rand.Float64() < regionExploreRate {
+		return config.Regions[rand.IntN(len(config.Regions))], true
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	worstLatencyMs := 1.0
+	for _, region := range config.Regions {
+		stats := t.stats[providerName][region.Name]
+		if stats == nil {
+			return region, true
+		}
+		if stats.Requests > stats.Errors {
+			worstLatencyMs = max(worstLatencyMs, stats.LatencyMs)
+		}
+	}
+
+	best := config.Regions[0]
+	bestScore := -1.0
+	for _, region := range config.Regions {
+		stats := t.stats[providerName][region.Name]
+		latencyMs := max(stats.LatencyMs, 1)
+		if stats.Requests == stats.Errors {
+			latencyMs = worstLatencyMs
+		}
+
+		score := latencyMs * (1 + regionErrorPenalty*stats.ErrorRate)
+		if bestScore < 0 || score < bestScore {
+			best = region
+			bestScore = score
+		}
+	}
+
+	return best, true
+}
+
+// Observe records the outcome of a request sent to a region
+func (t *RegionTracker) Observe(providerName llm.ProviderName, region string, latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	regions, ok := t.stats[providerName]
+	if !ok {
+		regions = make(map[string]*RegionStats)
+		t.stats[providerName] = regions
+	}
+
+	stats, ok := regions[region]
+	if !ok {
+		stats = &RegionStats{Provider: providerName, Region: region}
+		regions[region] = stats
+	}
+
+	failed := 0.0
+	if err != nil {
+		failed = 1.0
+		stats.Errors++
+	}
+
+	if stats.Requests == 0 {
+		stats.ErrorRate = failed
+	} else {
+		stats.ErrorRate = regionEWMAAlpha*failed + (1-regionEWMAAlpha)*stats.ErrorRate
+	}
+
+	// Failed requests often return early, so only successful requests count towards the latency
+	if err == nil {
+		latencyMs := float64(latency.Milliseconds())
+		// No successful request so far
+		if stats.Requests == stats.Errors {
+			stats.LatencyMs = latencyMs
+		} else {
+			stats.LatencyMs = regionEWMAAlpha*latencyMs + (1-regionEWMAAlpha)*stats.LatencyMs
+		}
+	}
+	stats.Requests++
+}
+
+// Stats returns the stats of all observed regions
+func (t *RegionTracker) Stats() []RegionStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := []RegionStats{}
+	for _, regions := range t.stats {
+		for _, stats := range regions {
+			out = append(out, *stats)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Region < out[j].Region
+	})
+
+	return out
+}
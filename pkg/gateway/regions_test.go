@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionTracker_Observe(t *testing.T) {
+	tracker := NewRegionTracker()
+	tracker.Observe(llm.ProviderNameOpenAI, "eu", 0, errors.New("unavailable"))
+	tracker.Observe(llm.ProviderNameOpenAI, "eu", 100*time.Millisecond, nil)
+	tracker.Observe(llm.ProviderNameOpenAI, "eu", 200*time.Millisecond, nil)
+
+	stats := tracker.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(3), stats[0].Requests)
+	assert.Equal(t, int64(1), stats[0].Errors)
+	// The first successful request sets the latency, the failed ones don't count towards it
+	assert.InDelta(t, 120, stats[0].LatencyMs, 0.001)
+	assert.InDelta(t, 0.64, stats[0].ErrorRate, 0.001)
+}
+
+func TestRegionTracker_Select(t *testing.T) {
+	fail := errors.New("unavailable")
+	config := &ProviderConfig{Regions: []RegionConfig{{Name: "us"}, {Name: "eu"}}}
+
+	tests := []struct {
+		name     string
+		observe  func(tracker *RegionTracker)
+		ctx      context.Context
+		expected string
+	}{
+		{
+			name: "unobserved region first",
+			observe: func(tracker *RegionTracker) {
+				tracker.Observe(llm.ProviderNameOpenAI, "us", 100*time.Millisecond, nil)
+			},
+			expected: "eu",
+		},
+		{
+			name: "lowest latency",
+			observe: func(tracker *RegionTracker) {
+				tracker.Observe(llm.ProviderNameOpenAI, "us", 300*time.Millisecond, nil)
+				tracker.Observe(llm.ProviderNameOpenAI, "eu", 100*time.Millisecond, nil)
+			},
+			expected: "eu",
+		},
+		{
+			name: "region that only fails",
+			observe: func(tracker *RegionTracker) {
+				tracker.Observe(llm.ProviderNameOpenAI, "us", 5*time.Second, nil)
+				for range 3 {
+					tracker.Observe(llm.ProviderNameOpenAI, "eu", time.Millisecond, fail)
+				}
+			},
+			expected: "us",
+		},
+		{
+			name: "least failing region",
+			observe: func(tracker *RegionTracker) {
+				tracker.Observe(llm.ProviderNameOpenAI, "us", 0, fail)
+				tracker.Observe(llm.ProviderNameOpenAI, "eu", 0, fail)
+				tracker.Observe(llm.ProviderNameOpenAI, "eu", 0, nil)
+			},
+			expected: "eu",
+		},
+		{
+			name: "pinned region",
+			observe: func(tracker *RegionTracker) {
+				tracker.Observe(llm.ProviderNameOpenAI, "us", 100*time.Millisecond, nil)
+				tracker.Observe(llm.ProviderNameOpenAI, "eu", 0, fail)
+			},
+			ctx:      ContextWithRegion(context.Background(), "eu"),
+			expected: "eu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewRegionTracker()
+			tt.observe(tracker)
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			// A few requests explore a random region
+			selected := 0
+			for range 200 {
+				region, ok := tracker.Select(ctx, llm.ProviderNameOpenAI, config)
+				require.True(t, ok)
+				if region.Name == tt.expected {
+					selected++
+				}
+			}
+			assert.Greater(t, selected, 160)
+		})
+	}
+}
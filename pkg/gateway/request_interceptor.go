@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// ProviderRequest is an outgoing request to a provider, as seen by the request interceptors. The interceptors
+// may change the headers and the body of the request.
+type ProviderRequest struct {
+	Provider llm.ProviderName
+	URL      string
+	Header   http.Header
+
+	// Body is the JSON payload of the request in the format of the provider, e.g. "metadata" for OpenAI and
+	// Anthropic. It is nil for requests without a JSON body.
+	Body map[string]any
+}
+
+// RequestInterceptor mutates the outgoing requests to the providers, e.g. to add the headers of a compliance
+// proxy or to tag requests with the tenant read from the context. An error aborts the request.
+type RequestInterceptor func(ctx context.Context, req *ProviderRequest) error
+
+// bodyCodec keeps numbers as they are written, so that a payload goes through the interceptors unchanged
+var bodyCodec = sonic.Config{UseNumber: true}.Froze()
+
+// NewInterceptingClient returns an HTTP client sending the requests to the provider through the interceptors,
+// in order. Without interceptors the client is returned as it is.
+func NewInterceptingClient(client *http.Client, provider llm.ProviderName, interceptors []RequestInterceptor) *http.Client {
+	if len(interceptors) == 0 {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	intercepting := *client
+	intercepting.Transport = &interceptingTransport{
+		provider:     provider,
+		interceptors: interceptors,
+		base:         base,
+	}
+	return &intercepting
+}
+
+type interceptingTransport struct {
+	provider     llm.ProviderName
+	interceptors []RequestInterceptor
+	base         http.RoundTripper
+}
+
+func (t *interceptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// A round tripper must not modify the request it is given
+	req = req.Clone(ctx)
+
+	in := &ProviderRequest{
+		Provider: t.provider,
+		URL:      req.URL.String(),
+		Header:   req.Header,
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		payload, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) > 0 {
+			if err := bodyCodec.Unmarshal(payload, &in.Body); err != nil {
+				return nil, fmt.Errorf("request interceptor: the request body is not a JSON object: %w", err)
+			}
+		}
+	}
+
+	for _, interceptor := range t.interceptors {
+		if err := interceptor(ctx, in); err != nil {
+			return nil, fmt.Errorf("request interceptor: %w", err)
+		}
+	}
+
+	req.Header = in.Header
+	if in.Body != nil {
+		payload, err := bodyCodec.Marshal(in.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(payload))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(payload)), nil
+		}
+		req.ContentLength = int64(len(payload))
+	}
+
+	return t.base.RoundTrip(req)
+}
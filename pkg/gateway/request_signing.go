@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of the signed requests to the LLM Gateway server
+const (
+	HeaderSignatureTimestamp = "X-Uno-Timestamp"
+	HeaderSignatureNonce     = "X-Uno-Nonce"
+	HeaderSignature          = "X-Uno-Signature"
+)
+
+// SignatureTolerance is the maximum skew between the timestamp of a signed request and the clock of the server. The
+// nonces are remembered for as long, so that a request is only accepted once.
+const SignatureTolerance = 5 * time.Minute
+
+var (
+	ErrMissingSignature = errors.New("the request is not signed")
+	ErrInvalidSignature = errors.New("the signature of the request is invalid")
+	ErrExpiredSignature = errors.New("the timestamp of the signed request is outside of the tolerance")
+	ErrReplayedRequest  = errors.New("the nonce of the signed request was already used")
+)
+
+// NonceStore remembers the nonces of the signed requests
+type NonceStore interface {
+	// UseNonce records the nonce for the ttl, it returns false if the nonce is already recorded
+	UseNonce(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// SignRequest returns the hex encoded HMAC-SHA256 of a request with the secret. The signed payload is the method,
+// the request URI (the path and the query), the unix timestamp, the nonce and the SHA-256 of the body, joined by
+// newlines.
+func SignRequest(secret, method, requestURI string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d\n%s\n%s", method, requestURI, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature verifies the signature headers of a request signed with the secret, and records its nonce
+// in the store so that it can't be replayed
+func VerifyRequestSignature(ctx context.Context, secret, method, requestURI string, header func(string) string, body []byte, nonces NonceStore, now time.Time) error {
+	timestampHeader := header(HeaderSignatureTimestamp)
+	nonce := header(HeaderSignatureNonce)
+	signature := header(HeaderSignature)
+	if timestampHeader == "" || nonce == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew > SignatureTolerance || skew < -SignatureTolerance {
+		return ErrExpiredSignature
+	}
+
+	expected := SignRequest(secret, method, requestURI, timestamp, nonce, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	// The nonce is only recorded for valid signatures, so that nobody can burn the nonces of others. It is kept
+	// until the timestamp can no longer be accepted.
+	ok, err := nonces.UseNonce(ctx, nonce, 2*SignatureTolerance)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReplayedRequest
+	}
+
+	return nil
+}
+
+// NewSigningClient returns an HTTP client signing the requests with the secret, with a new timestamp and nonce for
+// each request. Without secret the client is returned as it is.
+func NewSigningClient(client *http.Client, secret string) *http.Client {
+	if secret == "" {
+		return client
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	signing := *client
+	signing.Transport = &signingTransport{secret: secret, base: base}
+	return &signing
+}
+
+type signingTransport struct {
+	secret string
+	base   http.RoundTripper
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A round tripper must not modify the request it is given
+	req = req.Clone(req.Context())
+
+	var payload []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		payload, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(payload))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(payload)), nil
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set(HeaderSignatureTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignatureNonce, hex.EncodeToString(nonce))
+	req.Header.Set(HeaderSignature, SignRequest(t.secret, req.Method, req.URL.RequestURI(), timestamp, hex.EncodeToString(nonce), payload))
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]bool
+}
+
+func (s *memoryNonceStore) UseNonce(_ context.Context, nonce string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.nonces[nonce] {
+		return false, nil
+	}
+	s.nonces[nonce] = true
+	return true, nil
+}
+
+func TestSigningClient_Verify(t *testing.T) {
+	nonces := &memoryNonceStore{nonces: map[string]bool{}}
+
+	var captured []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		captured = append(captured, r)
+		bodies = append(bodies, body)
+
+		err := VerifyRequestSignature(r.Context(), "vksig_secret", r.Method, r.RequestURI, r.Header.Get, body, nonces, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSigningClient(server.Client(), "vksig_secret")
+	res, err := client.Post(server.URL+"/api/gateway/responses?stream=true", "application/json", strings.NewReader(`{"model":"OpenAI:gpt-4.1"}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	signed := captured[0]
+	verify := func(body []byte, now time.Time) error {
+		return VerifyRequestSignature(context.Background(), "vksig_secret", signed.Method, signed.RequestURI, signed.Header.Get, body, nonces, now)
+	}
+
+	// The same request is not accepted twice
+	assert.ErrorIs(t, verify(bodies[0], time.Now()), ErrReplayedRequest)
+
+	// A changed body or an expired timestamp fail before the nonce is checked
+	assert.ErrorIs(t, verify([]byte(`{"model":"OpenAI:o3"}`), time.Now()), ErrInvalidSignature)
+	assert.ErrorIs(t, verify(bodies[0], time.Now().Add(SignatureTolerance+time.Minute)), ErrExpiredSignature)
+
+	// Unsigned requests and requests signed with another secret are rejected
+	res, err = server.Client().Post(server.URL+"/api/gateway/responses", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, err = NewSigningClient(server.Client(), "vksig_other").Post(server.URL+"/api/gateway/responses", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
@@ -11,6 +11,25 @@ import (
 )
 
 func (g *LLMGateway) handleResponsesRequest(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (*responses.Response, error) {
+	in = degradeRequest(g.capabilities(providerName), in)
+	in, err := g.inlineImages(ctx, providerName, in)
+	if err != nil {
+		return nil, err
+	}
+	in = g.preprocessImages(ctx, providerName, in)
+
+	if g.shimsTools(providerName, in) {
+		return g.shimTools(ctx, providerName, p, in)
+	}
+
+	if in.IsDryRun() {
+		return dryRunResponses(ctx, providerName, p, in, false)
+	}
+
+	if emulatesConstraint(p, in) {
+		return g.emulateConstraint(ctx, providerName, p, in)
+	}
+
 	ctx, span := tracer.Start(ctx, "LLM.Responses")
 	defer span.End()
 
@@ -42,6 +61,29 @@ func (g *LLMGateway) handleResponsesRequest(ctx context.Context, providerName ll
 }
 
 func (g *LLMGateway) handleStreamingResponsesRequest(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (chan *responses.ResponseChunk, error) {
+	in = degradeRequest(g.capabilities(providerName), in)
+	in, err := g.inlineImages(ctx, providerName, in)
+	if err != nil {
+		return nil, err
+	}
+	in = g.preprocessImages(ctx, providerName, in)
+
+	if g.shimsTools(providerName, in) {
+		return g.shimStreamingTools(ctx, providerName, p, in)
+	}
+
+	if in.IsDryRun() {
+		resp, err := dryRunResponses(ctx, providerName, p, in, true)
+		if err != nil {
+			return nil, err
+		}
+		return dryRunChunks(resp), nil
+	}
+
+	if emulatesConstraint(p, in) {
+		return g.emulateStreamingConstraint(ctx, providerName, p, in)
+	}
+
 	_, span := tracer.Start(ctx, "LLM.StreamingResponses")
 
 	span.SetAttributes(
@@ -70,31 +112,26 @@ func (g *LLMGateway) handleStreamingResponsesRequest(ctx context.Context, provid
 		return nil, err
 	}
 
-	// Wrap the channel to track completion and end span
-	wrappedChan := make(chan *responses.ResponseChunk)
-	go func() {
+	// The span records the accumulated response, on a branch of its own so that it doesn't hold back the stream
+	tee := responses.NewTee(streamChan)
+	out, _ := tee.Branch(0, responses.TeeBlock)
+	tee.Sink(16, responses.TeeDrop, nil, func(resp *responses.Response) {
 		defer span.End()
-		defer close(wrappedChan)
-
-		chunkCount := 0
-		for chunk := range streamChan {
-			chunkCount++
-			wrappedChan <- chunk
-
-			if chunk.OfResponseCompleted != nil {
-				span.SetAttributes(attribute.Int("gen_ai.response.usage.input_tokens", chunk.OfResponseCompleted.Response.Usage.InputTokens))
-				span.SetAttributes(attribute.Int("gen_ai.response.usage.cached_input_tokens", chunk.OfResponseCompleted.Response.Usage.InputTokensDetails.CachedTokens))
-				span.SetAttributes(attribute.Int("gen_ai.response.usage.output_tokens", chunk.OfResponseCompleted.Response.Usage.OutputTokens))
-				span.SetAttributes(attribute.Int("gen_ai.response.usage.total_tokens", chunk.OfResponseCompleted.Response.Usage.TotalTokens))
-
-				msgsString, err = sonic.Marshal(chunk.OfResponseCompleted.Response.Output)
-				if err != nil {
-					span.RecordError(err)
-				}
-				span.SetAttributes(attribute.String("gen_ai.output.messages", string(msgsString)))
-			}
+
+		if resp.Usage != nil {
+			span.SetAttributes(attribute.Int("gen_ai.response.usage.input_tokens", resp.Usage.InputTokens))
+			span.SetAttributes(attribute.Int("gen_ai.response.usage.cached_input_tokens", resp.Usage.InputTokensDetails.CachedTokens))
+			span.SetAttributes(attribute.Int("gen_ai.response.usage.output_tokens", resp.Usage.OutputTokens))
+			span.SetAttributes(attribute.Int("gen_ai.response.usage.total_tokens", resp.Usage.TotalTokens))
 		}
-	}()
 
-	return wrappedChan, nil
+		msgsString, err := sonic.Marshal(resp.Output)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.SetAttributes(attribute.String("gen_ai.output.messages", string(msgsString)))
+	})
+	tee.Start()
+
+	return out, nil
 }
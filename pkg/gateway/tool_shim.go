@@ -0,0 +1,586 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/curaious/uno/pkg/llm/responses"
+	"github.com/google/uuid"
+)
+
+const (
+	toolCallOpenTag  = "<tool_call>"
+	toolCallCloseTag = "</tool_call>"
+)
+
+// shimsTools reports whether the function tools of the request are described in the prompt instead of being sent to
+// the provider, because the model has no native tool calling, see ProviderConfig.ToolShimModels. The requests to a
+// provider declared without tools are shimmed as soon as they have function tools or calls.
+func (g *LLMGateway) shimsTools(providerName llm.ProviderName, in *responses.Request) bool {
+	config, err := g.ConfigStore.GetProviderConfig(providerName)
+	if err != nil || config == nil {
+		return false
+	}
+
+	if config.Capabilities != nil && !config.Capabilities.Tools {
+		return hasFunctionTools(in.Tools) || hasFunctionCalls(in.Input)
+	}
+
+	if !hasFunctionTools(in.Tools) {
+		return false
+	}
+
+	for _, pattern := range config.ToolShimModels {
+		if ok, _ := path.Match(pattern, in.Model); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasFunctionTools(tools []responses.ToolUnion) bool {
+	for _, tool := range tools {
+		if tool.OfFunction != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toolShimRequest returns the request with the function tools described in the instructions. The function calls
+// and their outputs of the history are turned into the texts the model is asked to write, so that the provider
+// doesn't receive messages it can't handle.
+func toolShimRequest(in *responses.Request) (*responses.Request, error) {
+	req := *in
+	req.ToolChoice = nil
+	req.ParallelToolCalls = nil
+
+	var functions []*responses.FunctionTool
+	req.Tools = nil
+	for _, tool := range in.Tools {
+		if tool.OfFunction == nil {
+			req.Tools = append(req.Tools, tool)
+			continue
+		}
+		if toolShimAllows(in.ToolChoice, tool.OfFunction.Name) {
+			functions = append(functions, tool.OfFunction)
+		}
+	}
+
+	if len(functions) > 0 {
+		prompt, err := toolShimPrompt(functions, in.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+
+		if in.Instructions != nil && *in.Instructions != "" {
+			prompt = *in.Instructions + "\n\n" + prompt
+		}
+		req.Instructions = utils.Ptr(prompt)
+	}
+
+	if in.Input.OfInputMessageList != nil {
+		input, err := toolShimInput(in.Input.OfInputMessageList)
+		if err != nil {
+			return nil, err
+		}
+		req.Input = responses.InputUnion{OfInputMessageList: input}
+	}
+
+	return &req, nil
+}
+
+// toolShimAllows reports whether the tool choice lets the model call the function
+func toolShimAllows(choice *responses.ToolChoiceUnion, name string) bool {
+	switch {
+	case choice == nil:
+		return true
+	case choice.OfMode != nil:
+		return *choice.OfMode != responses.ToolChoiceModeNone
+	case choice.OfFunction != nil:
+		return choice.OfFunction.Name == name
+	case choice.OfAllowedTools != nil:
+		for _, allowed := range choice.OfAllowedTools.FunctionNames() {
+			if allowed == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func toolShimPrompt(functions []*responses.FunctionTool, choice *responses.ToolChoiceUnion) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("You can call the following tools. To call a tool, write only a JSON object with the name of the tool and its arguments in a " + toolCallOpenTag + " tag, one tag per call:\n")
+	sb.WriteString(toolCallOpenTag + `{"name": "<tool name>", "arguments": {<arguments>}}` + toolCallCloseTag + "\n")
+	sb.WriteString("The results of the calls are given in <tool_result> tags. When you don't need a tool, answer normally.\n\nTools:\n")
+
+	for _, fn := range functions {
+		sb.WriteString("- " + fn.Name)
+		if fn.Description != nil && *fn.Description != "" {
+			sb.WriteString(": " + *fn.Description)
+		}
+		sb.WriteString("\n")
+
+		if fn.Parameters != nil {
+			params, err := sonic.Marshal(fn.Parameters)
+			if err != nil {
+				return "", fmt.Errorf("invalid parameters of tool '%s': %w", fn.Name, err)
+			}
+			sb.WriteString("  Parameters (JSON schema): " + string(params) + "\n")
+		}
+	}
+
+	switch {
+	case choice != nil && choice.OfFunction != nil:
+		sb.WriteString("\nYou must call the tool " + choice.OfFunction.Name + ".")
+	case choice != nil && choice.OfMode != nil && *choice.OfMode == responses.ToolChoiceModeRequired,
+		choice != nil && choice.OfAllowedTools != nil && choice.OfAllowedTools.Mode == responses.ToolChoiceModeRequired:
+		sb.WriteString("\nYou must call one of the tools.")
+	}
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+type toolShimCall struct {
+	Name      string `json:"name"`
+	Arguments any    `json:"arguments"`
+}
+
+func toolShimInput(msgs responses.InputMessageList) (responses.InputMessageList, error) {
+	names := map[string]string{}
+	out := make(responses.InputMessageList, 0, len(msgs))
+	for _, msg := range msgs {
+		switch {
+		case msg.OfFunctionCall != nil:
+			names[msg.OfFunctionCall.CallID] = msg.OfFunctionCall.Name
+
+			var args any = map[string]any{}
+			if msg.OfFunctionCall.Arguments != "" {
+				if err := sonic.Unmarshal([]byte(msg.OfFunctionCall.Arguments), &args); err != nil {
+					args = msg.OfFunctionCall.Arguments
+				}
+			}
+
+			call, err := sonic.Marshal(toolShimCall{Name: msg.OfFunctionCall.Name, Arguments: args})
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, responses.InputMessageUnion{
+				OfOutputMessage: &responses.OutputMessage{
+					ID:   msg.OfFunctionCall.ID,
+					Role: constants.RoleAssistant,
+					Content: responses.OutputContent{{OfOutputText: &responses.OutputTextContent{
+						Text:        toolCallOpenTag + string(call) + toolCallCloseTag,
+						Annotations: []responses.Annotation{},
+					}}},
+				},
+			})
+
+		case msg.OfFunctionCallOutput != nil:
+			output := ""
+			if msg.OfFunctionCallOutput.Output.OfString != nil {
+				output = *msg.OfFunctionCallOutput.Output.OfString
+			}
+			for _, content := range msg.OfFunctionCallOutput.Output.OfList {
+				if content.OfInputText != nil {
+					output += content.OfInputText.Text
+				}
+			}
+
+			name := names[msg.OfFunctionCallOutput.CallID]
+			out = append(out, responses.UserMessage(fmt.Sprintf("<tool_result name=%q>%s</tool_result>", name, output)))
+
+		default:
+			out = append(out, msg)
+		}
+	}
+
+	return out, nil
+}
+
+// toolCallParser splits the text written by the model into the visible text and the tool calls. The text is fed
+// as it is streamed, the text that may start a tool call is held back until it is known.
+type toolCallParser struct {
+	buf    string
+	inCall bool
+}
+
+func (p *toolCallParser) feed(text string) (string, []responses.FunctionCallMessage) {
+	p.buf += text
+
+	var visible strings.Builder
+	var calls []responses.FunctionCallMessage
+	for {
+		if !p.inCall {
+			if i := strings.Index(p.buf, toolCallOpenTag); i >= 0 {
+				visible.WriteString(p.buf[:i])
+				p.buf = p.buf[i+len(toolCallOpenTag):]
+				p.inCall = true
+				continue
+			}
+
+			held := partialTagSuffix(p.buf, toolCallOpenTag)
+			visible.WriteString(p.buf[:len(p.buf)-held])
+			p.buf = p.buf[len(p.buf)-held:]
+			break
+		}
+
+		j := strings.Index(p.buf, toolCallCloseTag)
+		if j < 0 {
+			break
+		}
+
+		if call, ok := parseToolShimCall(p.buf[:j]); ok {
+			calls = append(calls, call)
+		} else {
+			visible.WriteString(toolCallOpenTag + p.buf[:j] + toolCallCloseTag)
+		}
+		p.buf = p.buf[j+len(toolCallCloseTag):]
+		p.inCall = false
+	}
+
+	return visible.String(), calls
+}
+
+// flush ends the text. A tool call the model didn't close is still a call when it is complete.
+func (p *toolCallParser) flush() (string, []responses.FunctionCallMessage) {
+	defer func() {
+		p.buf = ""
+		p.inCall = false
+	}()
+
+	if !p.inCall {
+		return p.buf, nil
+	}
+
+	if call, ok := parseToolShimCall(p.buf); ok {
+		return "", []responses.FunctionCallMessage{call}
+	}
+
+	return toolCallOpenTag + p.buf, nil
+}
+
+// partialTagSuffix returns the length of the longest suffix of s that is a prefix of the tag
+func partialTagSuffix(s, tag string) int {
+	for n := min(len(s), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(s, tag[:n]) {
+			return n
+		}
+	}
+
+	return 0
+}
+
+func parseToolShimCall(text string) (responses.FunctionCallMessage, bool) {
+	var call toolShimCall
+	if err := sonic.Unmarshal([]byte(strings.TrimSpace(text)), &call); err != nil || call.Name == "" {
+		return responses.FunctionCallMessage{}, false
+	}
+
+	args := "{}"
+	switch v := call.Arguments.(type) {
+	case nil:
+	case string:
+		args = v
+	default:
+		buf, err := sonic.Marshal(v)
+		if err != nil {
+			return responses.FunctionCallMessage{}, false
+		}
+		args = string(buf)
+	}
+
+	return responses.FunctionCallMessage{
+		ID:        responses.NewOutputItemFunctionCallID(),
+		CallID:    "call_" + uuid.NewString(),
+		Name:      call.Name,
+		Arguments: args,
+	}, true
+}
+
+// parseToolShimText splits a whole text into its visible text and its tool calls
+func parseToolShimText(text string) (string, []responses.FunctionCallMessage) {
+	p := &toolCallParser{}
+	visible, calls := p.feed(text)
+	rest, last := p.flush()
+
+	return visible + rest, append(calls, last...)
+}
+
+// toolShimOutput removes the tool calls written in the messages of the output, and returns them. The messages left
+// without text are dropped.
+func toolShimOutput(output []responses.OutputMessageUnion) ([]responses.OutputMessageUnion, []responses.FunctionCallMessage) {
+	out := make([]responses.OutputMessageUnion, 0, len(output))
+	var calls []responses.FunctionCallMessage
+	for _, item := range output {
+		if item.OfOutputMessage == nil {
+			out = append(out, item)
+			continue
+		}
+
+		msg := *item.OfOutputMessage
+		msg.Content = make(responses.OutputContent, 0, len(item.OfOutputMessage.Content))
+		visible := false
+		for _, content := range item.OfOutputMessage.Content {
+			if content.OfOutputText != nil {
+				text := *content.OfOutputText
+				var textCalls []responses.FunctionCallMessage
+				text.Text, textCalls = parseToolShimText(text.Text)
+				calls = append(calls, textCalls...)
+				content.OfOutputText = &text
+
+				visible = visible || strings.TrimSpace(text.Text) != ""
+			}
+			msg.Content = append(msg.Content, content)
+		}
+
+		if visible {
+			out = append(out, responses.OutputMessageUnion{OfOutputMessage: &msg})
+		}
+	}
+
+	return out, calls
+}
+
+// appendFunctionCalls adds the function calls at the end of the output
+func appendFunctionCalls(output []responses.OutputMessageUnion, calls []responses.FunctionCallMessage) []responses.OutputMessageUnion {
+	for i := range calls {
+		output = append(output, responses.OutputMessageUnion{OfFunctionCall: &calls[i]})
+	}
+
+	return output
+}
+
+// shimTools runs the request with the function tools described in the prompt, and turns the tool calls written
+// by the model into function calls
+func (g *LLMGateway) shimTools(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (*responses.Response, error) {
+	req, err := toolShimRequest(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := g.handleResponsesRequest(ctx, providerName, p, req)
+	if err != nil || out.DryRun != nil {
+		return out, err
+	}
+
+	output, calls := toolShimOutput(out.Output)
+	out.Output = appendFunctionCalls(output, calls)
+	return out, nil
+}
+
+// shimStreamingTools is shimTools for streaming requests. The text is streamed as it is written, except for the
+// tool calls, which are streamed as function calls before the response completes.
+func (g *LLMGateway) shimStreamingTools(ctx context.Context, providerName llm.ProviderName, p llm.Provider, in *responses.Request) (chan *responses.ResponseChunk, error) {
+	req, err := toolShimRequest(in)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := g.handleStreamingResponsesRequest(ctx, providerName, p, req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *responses.ResponseChunk)
+	go func() {
+		defer close(out)
+
+		s := &toolShimStream{}
+		for chunk := range stream {
+			s.transform(chunk, func(chunk *responses.ResponseChunk) {
+				out <- chunk
+			})
+		}
+	}()
+
+	return out, nil
+}
+
+// toolShimStream holds back the chunks of a message until it shows some text, so that the messages only made of
+// tool calls aren't streamed
+type toolShimStream struct {
+	message   *toolShimMessage
+	calls     []responses.FunctionCallMessage
+	nextIndex int
+}
+
+type toolShimMessage struct {
+	held    []*responses.ResponseChunk
+	started bool
+	parser  toolCallParser
+	pending string   // Visible text held back until the message starts
+	text    string   // Visible text of the current content part
+	texts   []string // Visible texts of the done content parts
+}
+
+func (s *toolShimStream) transform(chunk *responses.ResponseChunk, emit func(*responses.ResponseChunk)) {
+	m := s.message
+	switch {
+	case chunk.OfOutputItemAdded != nil && chunk.OfOutputItemAdded.Item.Type == "message":
+		s.message = &toolShimMessage{held: []*responses.ResponseChunk{chunk}}
+		return
+
+	case m == nil:
+		// Not a chunk of a message
+
+	case chunk.OfContentPartAdded != nil:
+		m.text = ""
+		if !m.started {
+			m.held = append(m.held, chunk)
+			return
+		}
+
+	case chunk.OfOutputTextDelta != nil:
+		visible, calls := m.parser.feed(chunk.OfOutputTextDelta.Delta)
+		s.calls = append(s.calls, calls...)
+		s.emitText(visible, chunk, emit)
+		return
+
+	case chunk.OfOutputTextDone != nil:
+		visible, calls := m.parser.flush()
+		s.calls = append(s.calls, calls...)
+		if visible != "" {
+			done := chunk.OfOutputTextDone
+			s.emitText(visible, &responses.ResponseChunk{OfOutputTextDelta: &responses.ChunkOutputText[constants.ChunkTypeOutputTextDelta]{
+				SequenceNumber: done.SequenceNumber,
+				ItemId:         done.ItemId,
+				OutputIndex:    done.OutputIndex,
+				ContentIndex:   done.ContentIndex,
+			}}, emit)
+		}
+
+		m.texts = append(m.texts, m.text)
+		if !m.started {
+			return
+		}
+		chunk.OfOutputTextDone.Text = utils.Ptr(m.text)
+
+	case chunk.OfContentPartDone != nil:
+		if !m.started {
+			return
+		}
+		if chunk.OfContentPartDone.Part.OfOutputText != nil && len(m.texts) > 0 {
+			chunk.OfContentPartDone.Part.OfOutputText.Text = m.texts[len(m.texts)-1]
+		}
+
+	case chunk.OfOutputItemDone != nil && chunk.OfOutputItemDone.Item.Type == "message":
+		s.message = nil
+		if !m.started {
+			return
+		}
+		for i, content := range chunk.OfOutputItemDone.Item.Content {
+			if content.OfOutputText != nil && i < len(m.texts) {
+				content.OfOutputText.Text = m.texts[i]
+			}
+		}
+
+	case chunk.OfResponseCompleted != nil:
+		// Handled below
+	}
+
+	if chunk.OfOutputItemAdded != nil {
+		s.nextIndex = max(s.nextIndex, chunk.OfOutputItemAdded.OutputIndex+1)
+	}
+
+	if chunk.OfResponseCompleted != nil {
+		completed := chunk.OfResponseCompleted
+		seq := completed.SequenceNumber
+		for i := range s.calls {
+			for _, c := range toolShimCallChunks(&s.calls[i], s.nextIndex, &seq) {
+				emit(c)
+			}
+			s.nextIndex++
+		}
+		completed.SequenceNumber = seq
+		output, _ := toolShimOutput(completed.Response.Output)
+		completed.Response.Output = appendFunctionCalls(output, s.calls)
+	}
+
+	emit(chunk)
+}
+
+// emitText streams the visible text of a delta, starting the message with its held back chunks once it shows text
+func (s *toolShimStream) emitText(visible string, chunk *responses.ResponseChunk, emit func(*responses.ResponseChunk)) {
+	m := s.message
+	if !m.started {
+		m.pending += visible
+		if strings.TrimSpace(m.pending) == "" {
+			return
+		}
+
+		m.started = true
+		for _, held := range m.held {
+			if held.OfOutputItemAdded != nil {
+				s.nextIndex = max(s.nextIndex, held.OfOutputItemAdded.OutputIndex+1)
+			}
+			emit(held)
+		}
+		m.held = nil
+		visible, m.pending = m.pending, ""
+	}
+
+	if visible == "" {
+		return
+	}
+
+	m.text += visible
+	chunk.OfOutputTextDelta.Delta = visible
+	emit(chunk)
+}
+
+func toolShimCallChunks(call *responses.FunctionCallMessage, outputIndex int, seq *int) []*responses.ResponseChunk {
+	next := func() int {
+		n := *seq
+		*seq++
+		return n
+	}
+
+	item := func(status string) responses.ChunkOutputItemData {
+		return responses.ChunkOutputItemData{
+			Type:      "function_call",
+			Id:        call.ID,
+			Status:    status,
+			CallID:    utils.Ptr(call.CallID),
+			Name:      utils.Ptr(call.Name),
+			Arguments: utils.Ptr(call.Arguments),
+		}
+	}
+
+	return []*responses.ResponseChunk{
+		{OfOutputItemAdded: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemAdded]{
+			SequenceNumber: next(),
+			OutputIndex:    outputIndex,
+			Item:           item("in_progress"),
+		}},
+		{OfFunctionCallArgumentsDelta: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDelta]{
+			SequenceNumber: next(),
+			ItemId:         call.ID,
+			OutputIndex:    outputIndex,
+			Delta:          call.Arguments,
+		}},
+		{OfFunctionCallArgumentsDone: &responses.ChunkFunctionCall[constants.ChunkTypeFunctionCallArgumentsDone]{
+			SequenceNumber: next(),
+			ItemId:         call.ID,
+			OutputIndex:    outputIndex,
+			Arguments:      call.Arguments,
+		}},
+		{OfOutputItemDone: &responses.ChunkOutputItem[constants.ChunkTypeOutputItemDone]{
+			SequenceNumber: next(),
+			OutputIndex:    outputIndex,
+			Item:           item("completed"),
+		}},
+	}
+}
@@ -11,6 +11,54 @@ type ProviderConfig struct {
 	BaseURL       string
 	CustomHeaders map[string]string
 	ApiKeys       []*APIKeyConfig
+
+	// Regions are alternative endpoints of the provider. When set, BaseURL is ignored and
+	// requests are routed to the region with the best observed latency and error rate.
+	Regions []RegionConfig
+
+	// PinnedRegion routes all requests to the named region instead
+	PinnedRegion string
+
+	// DataRegions are the data regions the provider is approved for. Requests made for a data region, see
+	// ContextWithDataRegion, are only sent to the providers approved for it.
+	DataRegions []string
+
+	// ToolShimModels are the models without native tool calling, as path.Match patterns (e.g. "llama-3*"). The
+	// function tools of their requests are described in the prompt, and the tool calls they write are returned as
+	// function calls.
+	ToolShimModels []string
+
+	// Capabilities are the features supported by the models of the provider, declared for the OpenAI-compatible
+	// servers. The features they don't support are dropped from the requests, nil when they support all of them.
+	Capabilities *ProviderCapabilities
+
+	// APIVersion is the api-version of the requests to Azure OpenAI
+	APIVersion string
+
+	// Deployments are the names of the Azure OpenAI deployments of the models, by model. The models without
+	// deployment are sent to the deployment of the same name.
+	Deployments map[string]string
+
+	// GCPProject is the Google Cloud project of the requests to Vertex AI, the project of the credentials by default
+	GCPProject string
+
+	// HTTPProxy, NoProxy and TLS configure the connections to the provider, see HTTPConfig
+	HTTPProxy string
+	NoProxy   string
+	TLS       *TLSConfig
+}
+
+// ProviderCapabilities are the features supported by the models of a provider, see degradeRequest
+type ProviderCapabilities struct {
+	Tools     bool `json:"tools"`     // Tools and tool calls, the function tools are shimmed without them
+	Reasoning bool `json:"reasoning"` // Reasoning parameters and items
+	Images    bool `json:"images"`    // Image inputs
+}
+
+// RegionConfig is a regional endpoint of a provider
+type RegionConfig struct {
+	Name    string `json:"name"`
+	BaseURL string `json:"base_url"`
 }
 
 // APIKeyConfig contains API key information for a provider.
@@ -28,10 +76,15 @@ type APIKeyConfig struct {
 // VirtualKeyConfig contains virtual key access configuration.
 // This is the gateway's own type, independent of services layer.
 type VirtualKeyConfig struct {
+	ID               string
 	SecretKey        string
 	AllowedProviders []llm.ProviderName
 	AllowedModels    []string
 	RateLimits       []RateLimit
+
+	// SigningSecret is the secret signing the requests made with the key, see SignRequest. The requests of keys
+	// without signing secret need no signature.
+	SigningSecret string
 }
 
 type RateLimit struct {
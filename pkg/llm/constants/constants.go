@@ -95,6 +95,16 @@ func (m *MessageTypeWebSearchCall) UnmarshalJSON(buf []byte) error {
 	return unmarshalConstantString(m, buf)
 }
 
+type MessageTypeFileSearchCall string
+
+func (m *MessageTypeFileSearchCall) Value() string { return "file_search_call" }
+func (m *MessageTypeFileSearchCall) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *MessageTypeFileSearchCall) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
 type MessageTypeCodeInterpreterCall string
 
 func (m *MessageTypeCodeInterpreterCall) Value() string { return "code_interpreter_call" }
@@ -206,6 +216,106 @@ func (m *ChunkTypeRunCompleted) UnmarshalJSON(buf []byte) error {
 	return unmarshalConstantString(m, buf)
 }
 
+type ChunkTypeRunFailed string
+
+func (m *ChunkTypeRunFailed) Value() string                { return "run.failed" }
+func (m *ChunkTypeRunFailed) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeRunFailed) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeRunEstimated string
+
+func (m *ChunkTypeRunEstimated) Value() string                { return "run.estimated" }
+func (m *ChunkTypeRunEstimated) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeRunEstimated) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeResponseMetrics string
+
+func (m *ChunkTypeResponseMetrics) Value() string                { return "response.metrics" }
+func (m *ChunkTypeResponseMetrics) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeResponseMetrics) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeStructuredOutputPartial string
+
+func (m *ChunkTypeStructuredOutputPartial) Value() string {
+	return "response.structured_output.partial"
+}
+func (m *ChunkTypeStructuredOutputPartial) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ChunkTypeStructuredOutputPartial) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeProvenance string
+
+func (m *ChunkTypeProvenance) Value() string                { return "response.provenance" }
+func (m *ChunkTypeProvenance) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeProvenance) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeTranslation string
+
+func (m *ChunkTypeTranslation) Value() string                { return "response.translation" }
+func (m *ChunkTypeTranslation) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeTranslation) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypePolicyViolation string
+
+func (m *ChunkTypePolicyViolation) Value() string                { return "response.policy_violation" }
+func (m *ChunkTypePolicyViolation) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypePolicyViolation) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeDryRun string
+
+func (m *ChunkTypeDryRun) Value() string                { return "response.dry_run" }
+func (m *ChunkTypeDryRun) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeDryRun) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeTakeoverStarted string
+
+func (m *ChunkTypeTakeoverStarted) Value() string                { return "takeover.started" }
+func (m *ChunkTypeTakeoverStarted) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeTakeoverStarted) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeTakeoverActive string
+
+func (m *ChunkTypeTakeoverActive) Value() string                { return "takeover.active" }
+func (m *ChunkTypeTakeoverActive) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeTakeoverActive) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeTakeoverMessage string
+
+func (m *ChunkTypeTakeoverMessage) Value() string                { return "takeover.message" }
+func (m *ChunkTypeTakeoverMessage) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeTakeoverMessage) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeTakeoverEnded string
+
+func (m *ChunkTypeTakeoverEnded) Value() string                { return "takeover.ended" }
+func (m *ChunkTypeTakeoverEnded) MarshalJSON() ([]byte, error) { return sonic.Marshal(m.Value()) }
+func (m *ChunkTypeTakeoverEnded) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
 type ChunkTypeResponseCreated string
 
 func (m *ChunkTypeResponseCreated) Value() string                { return "response.created" }
@@ -442,6 +552,42 @@ func (m *ChunkTypeWebSearchCallCompleted) UnmarshalJSON(buf []byte) error {
 	return unmarshalConstantString(m, buf)
 }
 
+type ChunkTypeFileSearchCallInProgress string
+
+func (m *ChunkTypeFileSearchCallInProgress) Value() string {
+	return "response.file_search_call.in_progress"
+}
+func (m *ChunkTypeFileSearchCallInProgress) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ChunkTypeFileSearchCallInProgress) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeFileSearchCallSearching string
+
+func (m *ChunkTypeFileSearchCallSearching) Value() string {
+	return "response.file_search_call.searching"
+}
+func (m *ChunkTypeFileSearchCallSearching) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ChunkTypeFileSearchCallSearching) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+type ChunkTypeFileSearchCallCompleted string
+
+func (m *ChunkTypeFileSearchCallCompleted) Value() string {
+	return "response.file_search_call.completed"
+}
+func (m *ChunkTypeFileSearchCallCompleted) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ChunkTypeFileSearchCallCompleted) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
 type ChunkTypeCodeInterpreterCallInProgress string
 
 func (m *ChunkTypeCodeInterpreterCallInProgress) Value() string {
@@ -546,6 +692,18 @@ func (m *ToolTypeWebSearch) UnmarshalJSON(buf []byte) error {
 	return unmarshalConstantString(m, buf)
 }
 
+type ToolTypeFileSearch string
+
+func (m *ToolTypeFileSearch) Value() string {
+	return "file_search"
+}
+func (m *ToolTypeFileSearch) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ToolTypeFileSearch) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
 type ToolTypeCodeExecution string
 
 func (m *ToolTypeCodeExecution) Value() string {
@@ -605,3 +763,23 @@ func (m *WebSearchActionTypeFind) UnmarshalJSON(buf []byte) error {
 // ------------------------- //
 // End Of Web search action //
 // ----------------------- //
+
+// -------------------- //
+// Tool Choice Types //
+// ------------------ //
+
+type ToolChoiceTypeAllowedTools string
+
+func (m *ToolChoiceTypeAllowedTools) Value() string {
+	return "allowed_tools"
+}
+func (m *ToolChoiceTypeAllowedTools) MarshalJSON() ([]byte, error) {
+	return sonic.Marshal(m.Value())
+}
+func (m *ToolChoiceTypeAllowedTools) UnmarshalJSON(buf []byte) error {
+	return unmarshalConstantString(m, buf)
+}
+
+// ------------------------- //
+// End Of Tool Choice Types //
+// ----------------------- //
@@ -20,14 +20,37 @@ type Provider interface {
 	NewStreamingSpeech(ctx context.Context, in *speech.Request) (chan *speech.ResponseChunk, error)
 }
 
+// ConstrainedProvider is implemented by providers that can enforce some constraints while generating.
+// Constraints of other providers are emulated by the gateway.
+type ConstrainedProvider interface {
+	SupportsConstraint(c *responses.Constraint) bool
+}
+
 type ProviderName string
 
 var (
-	ProviderNameOpenAI    ProviderName = "OpenAI"
-	ProviderNameAnthropic ProviderName = "Anthropic"
-	ProviderNameGemini    ProviderName = "Gemini"
-	ProviderNameXAI       ProviderName = "xAI"
-	ProviderNameOllama    ProviderName = "Ollama"
+	ProviderNameOpenAI      ProviderName = "OpenAI"
+	ProviderNameAnthropic   ProviderName = "Anthropic"
+	ProviderNameGemini      ProviderName = "Gemini"
+	ProviderNameXAI         ProviderName = "xAI"
+	ProviderNameOllama      ProviderName = "Ollama"
+	ProviderNameVLLM        ProviderName = "vLLM"
+	ProviderNameTGI         ProviderName = "TGI"
+	ProviderNameHuggingFace ProviderName = "HuggingFace"
+	ProviderNameReplicate   ProviderName = "Replicate"
+	ProviderNameBedrock     ProviderName = "Bedrock"
+	ProviderNameAzure       ProviderName = "Azure"
+	ProviderNameVertexAI    ProviderName = "VertexAI"
+	ProviderNameMistral     ProviderName = "Mistral"
+	ProviderNameCohere      ProviderName = "Cohere"
+	ProviderNameTogetherAI  ProviderName = "TogetherAI"
+	ProviderNameFireworks   ProviderName = "Fireworks"
+	ProviderNamePerplexity  ProviderName = "Perplexity"
+	ProviderNameOpenRouter  ProviderName = "OpenRouter"
+
+	// ProviderNameOpenAICompatible is any server implementing the OpenAI API, e.g. LM Studio or LiteLLM, whose
+	// capabilities are declared in the config of the provider
+	ProviderNameOpenAICompatible ProviderName = "OpenAICompatible"
 )
 
 func GetAllProviderNames() []ProviderName {
@@ -37,9 +60,41 @@ func GetAllProviderNames() []ProviderName {
 		ProviderNameGemini,
 		ProviderNameXAI,
 		ProviderNameOllama,
+		ProviderNameVLLM,
+		ProviderNameTGI,
+		ProviderNameHuggingFace,
+		ProviderNameReplicate,
+		ProviderNameBedrock,
+		ProviderNameAzure,
+		ProviderNameVertexAI,
+		ProviderNameMistral,
+		ProviderNameCohere,
+		ProviderNameTogetherAI,
+		ProviderNameFireworks,
+		ProviderNamePerplexity,
+		ProviderNameOpenRouter,
+		ProviderNameOpenAICompatible,
 	}
 }
 
+// IsSelfHosted reports whether the provider is a server run by the user, it has no default endpoint and its API keys
+// are optional
+func (p ProviderName) IsSelfHosted() bool {
+	return p == ProviderNameOllama || p == ProviderNameVLLM || p == ProviderNameTGI || p == ProviderNameOpenAICompatible
+}
+
+// RequiresBaseURL reports whether the provider has no default endpoint: the self-hosted servers, and Azure OpenAI
+// whose endpoint is the one of the resource
+func (p ProviderName) RequiresBaseURL() bool {
+	return p.IsSelfHosted() || p == ProviderNameAzure
+}
+
+// KeysOptional reports whether the provider is called without API key when it has none: the self-hosted servers, and
+// Vertex AI which then authenticates with the application default credentials
+func (p ProviderName) KeysOptional() bool {
+	return p.IsSelfHosted() || p == ProviderNameVertexAI
+}
+
 func (p *ProviderName) IsValid() bool {
 	return slices.Contains(GetAllProviderNames(), *p)
 }
@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/llm/responses"
+)
+
+// ModelPricing holds the USD price per million tokens for a model
+type ModelPricing struct {
+	InputPerMTok       float64 `json:"input_per_mtok"`
+	CachedInputPerMTok float64 `json:"cached_input_per_mtok"`
+	OutputPerMTok      float64 `json:"output_per_mtok"`
+}
+
+// Cost returns the USD cost of the given usage. Cached input tokens are billed at the cached rate.
+func (p ModelPricing) Cost(usage *responses.Usage) float64 {
+	if usage == nil {
+		return 0
+	}
+
+	cached := usage.InputTokensDetails.CachedTokens
+	uncached := usage.InputTokens - cached
+	if uncached < 0 {
+		uncached = 0
+	}
+
+	return (float64(uncached)*p.InputPerMTok +
+		float64(cached)*p.CachedInputPerMTok +
+		float64(usage.OutputTokens)*p.OutputPerMTok) / 1_000_000
+}
+
+var (
+	pricingMu sync.RWMutex
+
+	// modelPricing is keyed by model ID prefix; the longest matching prefix wins
+	modelPricing = map[string]ModelPricing{
+		// OpenAI
+		"gpt-5":        {InputPerMTok: 1.25, CachedInputPerMTok: 0.125, OutputPerMTok: 10},
+		"gpt-5-mini":   {InputPerMTok: 0.25, CachedInputPerMTok: 0.025, OutputPerMTok: 2},
+		"gpt-5-nano":   {InputPerMTok: 0.05, CachedInputPerMTok: 0.005, OutputPerMTok: 0.4},
+		"gpt-4.1":      {InputPerMTok: 2, CachedInputPerMTok: 0.5, OutputPerMTok: 8},
+		"gpt-4.1-mini": {InputPerMTok: 0.4, CachedInputPerMTok: 0.1, OutputPerMTok: 1.6},
+		"gpt-4.1-nano": {InputPerMTok: 0.1, CachedInputPerMTok: 0.025, OutputPerMTok: 0.4},
+		"gpt-4o":       {InputPerMTok: 2.5, CachedInputPerMTok: 1.25, OutputPerMTok: 10},
+		"gpt-4o-mini":  {InputPerMTok: 0.15, CachedInputPerMTok: 0.075, OutputPerMTok: 0.6},
+		"o3":           {InputPerMTok: 2, CachedInputPerMTok: 0.5, OutputPerMTok: 8},
+		"o4-mini":      {InputPerMTok: 1.1, CachedInputPerMTok: 0.275, OutputPerMTok: 4.4},
+
+		// Anthropic
+		"claude-opus-4":     {InputPerMTok: 15, CachedInputPerMTok: 1.5, OutputPerMTok: 75},
+		"claude-sonnet-4":   {InputPerMTok: 3, CachedInputPerMTok: 0.3, OutputPerMTok: 15},
+		"claude-haiku-4":    {InputPerMTok: 1, CachedInputPerMTok: 0.1, OutputPerMTok: 5},
+		"claude-3-5-haiku":  {InputPerMTok: 0.8, CachedInputPerMTok: 0.08, OutputPerMTok: 4},
+		"claude-3-7-sonnet": {InputPerMTok: 3, CachedInputPerMTok: 0.3, OutputPerMTok: 15},
+
+		// Gemini
+		"gemini-2.5-pro":        {InputPerMTok: 1.25, CachedInputPerMTok: 0.31, OutputPerMTok: 10},
+		"gemini-2.5-flash":      {InputPerMTok: 0.3, CachedInputPerMTok: 0.075, OutputPerMTok: 2.5},
+		"gemini-2.5-flash-lite": {InputPerMTok: 0.1, CachedInputPerMTok: 0.025, OutputPerMTok: 0.4},
+
+		// xAI
+		"grok-4":      {InputPerMTok: 3, CachedInputPerMTok: 0.75, OutputPerMTok: 15},
+		"grok-3":      {InputPerMTok: 3, CachedInputPerMTok: 0.75, OutputPerMTok: 15},
+		"grok-3-mini": {InputPerMTok: 0.3, CachedInputPerMTok: 0.075, OutputPerMTok: 0.5},
+
+		// Together AI, the models are named after their organization
+		"meta-llama/Llama-3.3-70B-Instruct-Turbo":     {InputPerMTok: 0.88, CachedInputPerMTok: 0.88, OutputPerMTok: 0.88},
+		"meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo": {InputPerMTok: 0.18, CachedInputPerMTok: 0.18, OutputPerMTok: 0.18},
+		"deepseek-ai/DeepSeek-R1":                     {InputPerMTok: 3, CachedInputPerMTok: 3, OutputPerMTok: 7},
+		"deepseek-ai/DeepSeek-V3":                     {InputPerMTok: 1.25, CachedInputPerMTok: 1.25, OutputPerMTok: 1.25},
+		"Qwen/Qwen2.5-72B-Instruct-Turbo":             {InputPerMTok: 1.2, CachedInputPerMTok: 1.2, OutputPerMTok: 1.2},
+
+		// Fireworks, the serverless models without their account
+		"llama-v3p3-70b-instruct": {InputPerMTok: 0.9, CachedInputPerMTok: 0.45, OutputPerMTok: 0.9},
+		"llama-v3p1-8b-instruct":  {InputPerMTok: 0.2, CachedInputPerMTok: 0.1, OutputPerMTok: 0.2},
+		"deepseek-r1":             {InputPerMTok: 3, CachedInputPerMTok: 1.5, OutputPerMTok: 8},
+		"qwen3-235b-a22b":         {InputPerMTok: 0.22, CachedInputPerMTok: 0.11, OutputPerMTok: 0.88},
+
+		// Perplexity, without the fees of the searches
+		"sonar":               {InputPerMTok: 1, CachedInputPerMTok: 1, OutputPerMTok: 1},
+		"sonar-pro":           {InputPerMTok: 3, CachedInputPerMTok: 3, OutputPerMTok: 15},
+		"sonar-reasoning":     {InputPerMTok: 1, CachedInputPerMTok: 1, OutputPerMTok: 5},
+		"sonar-reasoning-pro": {InputPerMTok: 2, CachedInputPerMTok: 2, OutputPerMTok: 8},
+		"sonar-deep-research": {InputPerMTok: 2, CachedInputPerMTok: 2, OutputPerMTok: 8},
+	}
+)
+
+// RegisterModelPricing adds or overrides the pricing for a model ID prefix
+func RegisterModelPricing(modelPrefix string, pricing ModelPricing) {
+	pricingMu.Lock()
+	defer pricingMu.Unlock()
+	modelPricing[modelPrefix] = pricing
+}
+
+// GetModelPricing returns the pricing for the given model, matching the longest registered prefix
+func GetModelPricing(model string) (ModelPricing, bool) {
+	pricingMu.RLock()
+	defer pricingMu.RUnlock()
+
+	// Strip provider prefixes like "models/" (Gemini) or "accounts/fireworks/models/" (Fireworks) before matching
+	model = strings.TrimPrefix(model, "models/")
+	model = strings.TrimPrefix(model, "accounts/fireworks/models/")
+
+	var best string
+	for prefix := range modelPricing {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+
+	if best == "" {
+		return ModelPricing{}, false
+	}
+
+	return modelPricing[best], true
+}
+
+// EstimateTokens approximates the tokens of a JSON payload at 4 characters per token of its string values, leaving
+// out the data URLs of files and images which are billed differently
+func EstimateTokens(payload []byte) int {
+	var body any
+	if err := sonic.Unmarshal(payload, &body); err != nil {
+		return (utf8.RuneCount(payload) + 3) / 4
+	}
+
+	chars := 0
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if !strings.HasPrefix(v, "data:") {
+				chars += utf8.RuneCountInString(v)
+			}
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(body)
+
+	return (chars + 3) / 4
+}
@@ -0,0 +1,121 @@
+package responses
+
+import (
+	"github.com/curaious/uno/pkg/llm/constants"
+)
+
+// ResponseAccumulator builds the response of a stream from its chunks
+type ResponseAccumulator struct {
+	output   []OutputMessageUnion
+	usage    *Usage
+	model    string
+	timing   *Timing
+	provider string
+	dryRun   *DryRun
+}
+
+// Add accumulates a chunk of the stream
+func (a *ResponseAccumulator) Add(chunk *ResponseChunk) {
+	switch chunk.ChunkType() {
+	case "response.output_item.done":
+		item := chunk.OfOutputItemDone.Item
+		switch item.Type {
+		case "message":
+			for _, content := range item.Content {
+				if content.OfOutputText != nil {
+					a.output = append(a.output, OutputMessageUnion{
+						OfOutputMessage: &OutputMessage{
+							ID:      item.Id,
+							Role:    constants.RoleAssistant,
+							Content: OutputContent{content},
+						},
+					})
+				}
+			}
+
+		case "reasoning":
+			a.output = append(a.output, OutputMessageUnion{
+				OfReasoning: &ReasoningMessage{
+					ID:               item.Id,
+					Summary:          item.Summary,
+					EncryptedContent: item.EncryptedContent,
+					Provider:         item.Provider,
+					Model:            item.Model,
+				},
+			})
+
+		case "function_call":
+			a.output = append(a.output, OutputMessageUnion{
+				OfFunctionCall: &FunctionCallMessage{
+					ID:               item.Id,
+					CallID:           *item.CallID,
+					Name:             *item.Name,
+					Arguments:        *item.Arguments,
+					ThoughtSignature: item.ThoughtSignature,
+				},
+			})
+
+		case "image_generation_call":
+			a.output = append(a.output, OutputMessageUnion{
+				OfImageGenerationCall: &ImageGenerationCallMessage{
+					ID:           item.Id,
+					Status:       item.Status,
+					Background:   *item.Background,
+					OutputFormat: *item.OutputFormat,
+					Quality:      *item.Quality,
+					Size:         *item.Size,
+					Result:       *item.Result,
+				},
+			})
+
+		case "web_search_call":
+			if item.Action != nil {
+				a.output = append(a.output, OutputMessageUnion{
+					OfWebSearchCall: &WebSearchCallMessage{
+						ID:     item.Id,
+						Action: *item.Action,
+						Status: item.Status,
+					},
+				})
+			}
+
+		case "file_search_call":
+			a.output = append(a.output, OutputMessageUnion{
+				OfFileSearchCall: &FileSearchCallMessage{
+					ID:      item.Id,
+					Queries: item.Queries,
+					Status:  item.Status,
+					Results: item.Results,
+				},
+			})
+		}
+
+	case "response.completed":
+		a.usage = &chunk.OfResponseCompleted.Response.Usage
+		a.model = chunk.OfResponseCompleted.Response.Model
+
+	case "response.metrics":
+		a.timing = &chunk.OfResponseMetrics.Timing
+		a.provider = chunk.OfResponseMetrics.Provider
+
+	case "response.dry_run":
+		a.dryRun = &chunk.OfDryRun.DryRun
+	}
+}
+
+// Response returns the response accumulated so far
+func (a *ResponseAccumulator) Response() *Response {
+	output := a.output
+	if output == nil {
+		output = []OutputMessageUnion{}
+	}
+
+	return &Response{
+		Model:    a.model,
+		Output:   output,
+		Usage:    a.usage,
+		Timing:   a.timing,
+		Provider: a.provider,
+		DryRun:   a.dryRun,
+	}
+}
@@ -0,0 +1,203 @@
+package responses
+
+import (
+	"context"
+	"time"
+)
+
+// ChunkTransformer is a single stage of a ChunkPipeline.
+// Transform receives every chunk of the stream and may emit it (optionally modified), drop it by
+// not emitting, or emit additional chunks. Flush is called once when the stream ends so buffering
+// transformers can emit whatever they are holding back.
+type ChunkTransformer interface {
+	Transform(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk))
+	Flush(ctx context.Context, emit func(*ResponseChunk))
+}
+
+// ChunkExpirer is implemented by the transformers holding chunks back for at most a time, e.g. CoalesceTextDeltas.
+// Once the deadline passes the stream calls Expire, from the goroutine pushing the chunks, so that the held chunks
+// are released while the input stalls. See ChunkStream.Expiry.
+type ChunkExpirer interface {
+	// Deadline returns when the held chunks expire, false when none are held
+	Deadline() (time.Time, bool)
+	Expire(ctx context.Context, emit func(*ResponseChunk))
+}
+
+// ChunkTransformerFactory creates a fresh transformer for every stream, so that stateful
+// transformers don't share state across concurrent requests.
+type ChunkTransformerFactory func() ChunkTransformer
+
+// ChunkTransformerFunc adapts a stateless function to the ChunkTransformer interface
+type ChunkTransformerFunc func(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk))
+
+func (f ChunkTransformerFunc) Transform(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk)) {
+	f(ctx, chunk, emit)
+}
+
+func (f ChunkTransformerFunc) Flush(ctx context.Context, emit func(*ResponseChunk)) {}
+
+// ChunkPipeline holds an ordered list of transformers applied between a provider and its consumer
+type ChunkPipeline struct {
+	factories []ChunkTransformerFactory
+}
+
+// NewChunkPipeline creates a pipeline with the given transformers, applied in order
+func NewChunkPipeline(factories ...ChunkTransformerFactory) *ChunkPipeline {
+	return &ChunkPipeline{factories: factories}
+}
+
+// Use appends transformers to the end of the pipeline
+func (p *ChunkPipeline) Use(factories ...ChunkTransformerFactory) *ChunkPipeline {
+	p.factories = append(p.factories, factories...)
+	return p
+}
+
+// IsEmpty returns true if the pipeline has no transformers
+func (p *ChunkPipeline) IsEmpty() bool {
+	return p == nil || len(p.factories) == 0
+}
+
+// NewStream instantiates the transformers for a single stream, delivering the output to sink
+func (p *ChunkPipeline) NewStream(ctx context.Context, sink func(*ResponseChunk)) *ChunkStream {
+	s := &ChunkStream{ctx: ctx}
+	if p.IsEmpty() {
+		s.head = sink
+		return s
+	}
+
+	s.transformers = make([]ChunkTransformer, len(p.factories))
+	s.emitters = make([]func(*ResponseChunk), len(p.factories))
+	for i, factory := range p.factories {
+		s.transformers[i] = factory()
+	}
+
+	// Wire the stages back to front so every stage emits into the next one
+	next := sink
+	for i := len(s.transformers) - 1; i >= 0; i-- {
+		s.emitters[i] = next
+		transformer, emit := s.transformers[i], next
+		next = func(chunk *ResponseChunk) {
+			transformer.Transform(ctx, chunk, emit)
+		}
+	}
+	s.head = next
+
+	return s
+}
+
+// Pipe applies the pipeline to a chunk channel and returns the transformed channel.
+// The returned channel is closed after the input channel is closed and the pipeline is flushed. When the context is
+// done before, e.g. the consumer went away, the returned channel is closed and the rest of the input is drained so
+// that the producer isn't blocked.
+func (p *ChunkPipeline) Pipe(ctx context.Context, in chan *ResponseChunk) chan *ResponseChunk {
+	if p.IsEmpty() {
+		return in
+	}
+
+	out := make(chan *ResponseChunk, cap(in))
+	go func() {
+		defer close(out)
+
+		stream := p.NewStream(ctx, func(chunk *ResponseChunk) {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+			}
+		})
+
+		for {
+			select {
+			case chunk, ok := <-in:
+				if !ok {
+					stream.Flush()
+					return
+				}
+				stream.Push(chunk)
+			case <-stream.Expiry():
+				stream.Expire()
+			case <-ctx.Done():
+				go drain(in)
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// drain reads the channel until it is closed
+func drain(in chan *ResponseChunk) {
+	for range in {
+	}
+}
+
+// ChunkStream is a pipeline instantiated for a single stream
+type ChunkStream struct {
+	ctx          context.Context
+	head         func(*ResponseChunk)
+	transformers []ChunkTransformer
+	emitters     []func(*ResponseChunk)
+	timer        *time.Timer
+}
+
+// Push feeds a chunk into the first stage of the pipeline
+func (s *ChunkStream) Push(chunk *ResponseChunk) {
+	if chunk == nil {
+		return
+	}
+	s.head(chunk)
+}
+
+// Flush flushes every stage in order, so that chunks released by a stage still pass through the later stages
+func (s *ChunkStream) Flush() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	for i, transformer := range s.transformers {
+		transformer.Flush(s.ctx, s.emitters[i])
+	}
+}
+
+// Expiry returns a channel receiving once the chunks held back by a stage expire, nil when no chunks are held. The
+// owner of the stream selects on it next to its input and then calls Expire, so that the chunks are emitted from its
+// goroutine. Call it again after every Push or Expire, the deadline moves with the chunks.
+func (s *ChunkStream) Expiry() <-chan time.Time {
+	var deadline time.Time
+	held := false
+	for _, transformer := range s.transformers {
+		expirer, ok := transformer.(ChunkExpirer)
+		if !ok {
+			continue
+		}
+		if d, ok := expirer.Deadline(); ok && (!held || d.Before(deadline)) {
+			deadline, held = d, true
+		}
+	}
+	if !held {
+		return nil
+	}
+
+	if s.timer == nil {
+		s.timer = time.NewTimer(time.Until(deadline))
+	} else {
+		s.timer.Reset(time.Until(deadline))
+	}
+	return s.timer.C
+}
+
+// Expire releases the chunks whose deadline passed, the released chunks still pass through the later stages
+func (s *ChunkStream) Expire() {
+	now := time.Now()
+	for i, transformer := range s.transformers {
+		expirer, ok := transformer.(ChunkExpirer)
+		if !ok {
+			continue
+		}
+		if d, ok := expirer.Deadline(); ok && !now.Before(d) {
+			expirer.Expire(s.ctx, s.emitters[i])
+		}
+	}
+}
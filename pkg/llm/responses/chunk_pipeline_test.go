@@ -0,0 +1,90 @@
+package responses
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/curaious/uno/pkg/llm/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func textDelta(delta string) *ResponseChunk {
+	return &ResponseChunk{OfOutputTextDelta: &ChunkOutputText[constants.ChunkTypeOutputTextDelta]{ItemId: "msg_1", Delta: delta}}
+}
+
+func TestChunkPipeline_Pipe(t *testing.T) {
+	in := make(chan *ResponseChunk, 3)
+	in <- textDelta("hello")
+	in <- textDelta("world")
+	close(in)
+
+	pipeline := NewChunkPipeline(TransformText(func(text string) string { return text + "!" }))
+
+	var deltas []string
+	for chunk := range pipeline.Pipe(context.Background(), in) {
+		deltas = append(deltas, chunk.OfOutputTextDelta.Delta)
+	}
+
+	assert.Equal(t, []string{"hello!", "world!"}, deltas)
+}
+
+func TestChunkPipeline_Pipe_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *ResponseChunk)
+
+	// The producer sends the chunks, the consumer never reads them
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		for _, delta := range []string{"a", "b", "c", "d"} {
+			in <- textDelta(delta)
+		}
+		close(in)
+	}()
+
+	out := NewChunkPipeline(TransformText(func(text string) string { return text })).Pipe(ctx, in)
+	cancel()
+
+	select {
+	case <-produced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the producer is blocked on the pipeline")
+	}
+
+	select {
+	case _, ok := <-out:
+		for ok {
+			_, ok = <-out
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the output of the pipeline was not closed")
+	}
+}
+
+func TestChunkPipeline_Pipe_CoalesceStalled(t *testing.T) {
+	in := make(chan *ResponseChunk)
+	defer close(in)
+
+	out := NewChunkPipeline(CoalesceTextDeltas(CoalesceOptions{Window: 100 * time.Millisecond})).Pipe(context.Background(), in)
+
+	// The model stalls after two deltas, the merged delta is released once the window expires
+	in <- textDelta("Hel")
+	in <- textDelta("lo")
+
+	select {
+	case chunk := <-out:
+		assert.Equal(t, "Hello", chunk.OfOutputTextDelta.Delta)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the coalesced delta was held back while the stream stalled")
+	}
+
+	in <- textDelta(" world")
+
+	select {
+	case chunk := <-out:
+		assert.Equal(t, " world", chunk.OfOutputTextDelta.Delta)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the coalesced delta was held back while the stream stalled")
+	}
+}
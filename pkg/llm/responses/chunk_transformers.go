@@ -0,0 +1,272 @@
+package responses
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TransformText applies fn to every piece of output text in the stream: text deltas, the final
+// text of output_text.done, content parts, output items and the completed response. Keeping the
+// final texts in sync with the deltas means the accumulated output matches what was streamed.
+//
+// fn is applied to each delta independently, so patterns spanning two deltas are not matched.
+func TransformText(fn func(string) string) ChunkTransformerFactory {
+	return func() ChunkTransformer {
+		return ChunkTransformerFunc(func(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk)) {
+			switch {
+			case chunk.OfOutputTextDelta != nil:
+				chunk.OfOutputTextDelta.Delta = fn(chunk.OfOutputTextDelta.Delta)
+
+			case chunk.OfOutputTextDone != nil:
+				if chunk.OfOutputTextDone.Text != nil {
+					text := fn(*chunk.OfOutputTextDone.Text)
+					chunk.OfOutputTextDone.Text = &text
+				}
+
+			case chunk.OfContentPartDone != nil:
+				transformOutputContent(fn, &chunk.OfContentPartDone.Part)
+
+			case chunk.OfOutputItemDone != nil:
+				for i := range chunk.OfOutputItemDone.Item.Content {
+					transformOutputContent(fn, &chunk.OfOutputItemDone.Item.Content[i])
+				}
+
+			case chunk.OfResponseCompleted != nil:
+				for _, out := range chunk.OfResponseCompleted.Response.Output {
+					if out.OfOutputMessage == nil {
+						continue
+					}
+					for i := range out.OfOutputMessage.Content {
+						transformOutputContent(fn, &out.OfOutputMessage.Content[i])
+					}
+				}
+			}
+
+			emit(chunk)
+		})
+	}
+}
+
+func transformOutputContent(fn func(string) string, content *OutputContentUnion) {
+	if content.OfOutputText != nil {
+		content.OfOutputText.Text = fn(content.OfOutputText.Text)
+	}
+}
+
+// MaskWords replaces whole-word, case-insensitive occurrences of the given words with mask
+func MaskWords(words []string, mask string) ChunkTransformerFactory {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return TransformText(func(s string) string { return s })
+	}
+
+	re := regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	return TransformText(func(s string) string {
+		return re.ReplaceAllString(s, mask)
+	})
+}
+
+var markdownImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+var htmlTagPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+
+// SanitizeMarkdown strips raw HTML tags and replaces markdown images with their alt text,
+// which prevents model output from loading remote content when rendered.
+func SanitizeMarkdown() ChunkTransformerFactory {
+	return TransformText(func(s string) string {
+		s = markdownImagePattern.ReplaceAllString(s, "$1")
+		return htmlTagPattern.ReplaceAllString(s, "")
+	})
+}
+
+// DropChunks removes chunks of the given types (e.g. "response.reasoning_summary_text.delta") from the stream
+func DropChunks(chunkTypes ...string) ChunkTransformerFactory {
+	return func() ChunkTransformer {
+		return ChunkTransformerFunc(func(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk)) {
+			if slices.Contains(chunkTypes, chunk.ChunkType()) {
+				return
+			}
+			emit(chunk)
+		})
+	}
+}
+
+// DefaultCoalesceWindow is the window of CoalesceTextDeltas when none is configured
+const DefaultCoalesceWindow = 30 * time.Millisecond
+
+// CoalesceOptions configures CoalesceTextDeltas. A zero MaxBytes disables the size limit, a zero Window is
+// DefaultCoalesceWindow, so that the deltas are never held back for long.
+type CoalesceOptions struct {
+	// Window is the maximum time a delta is held back before being released
+	Window time.Duration
+
+	// MaxBytes releases the buffered text once it reaches this size
+	MaxBytes int
+}
+
+// CoalesceTextDeltas merges consecutive output_text.delta chunks of the same content part into a
+// single chunk, reducing the number of events emitted for high token-rate models. All other chunks,
+// including output_text.done and response.completed, pass through unchanged; any buffered text is
+// released before them so ordering is preserved.
+//
+// A buffered delta is released once the window elapses, even when the stream stalls: the coalescer is a
+// ChunkExpirer, released by the timer of the stream, see ChunkStream.Expiry. Streams not watching their expiry
+// release it with the next chunk after the window elapses, or when the stream is flushed.
+func CoalesceTextDeltas(opts CoalesceOptions) ChunkTransformerFactory {
+	if opts.Window == 0 {
+		opts.Window = DefaultCoalesceWindow
+	}
+
+	return func() ChunkTransformer {
+		return &deltaCoalescer{opts: opts}
+	}
+}
+
+type deltaCoalescer struct {
+	opts      CoalesceOptions
+	pending   *ResponseChunk
+	text      strings.Builder
+	startedAt time.Time
+}
+
+func (c *deltaCoalescer) Transform(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk)) {
+	delta := chunk.OfOutputTextDelta
+	if delta == nil {
+		c.Flush(ctx, emit)
+		emit(chunk)
+		return
+	}
+
+	if c.pending != nil {
+		p := c.pending.OfOutputTextDelta
+		if p.ItemId != delta.ItemId || p.OutputIndex != delta.OutputIndex || p.ContentIndex != delta.ContentIndex {
+			c.Flush(ctx, emit)
+		}
+	}
+
+	if c.pending == nil {
+		c.pending = chunk
+		c.startedAt = time.Now()
+		c.text.Reset()
+		c.text.WriteString(delta.Delta)
+	} else {
+		p := c.pending.OfOutputTextDelta
+		p.SequenceNumber = delta.SequenceNumber
+		p.Logprobs = append(p.Logprobs, delta.Logprobs...)
+		c.text.WriteString(delta.Delta)
+	}
+
+	if (c.opts.MaxBytes > 0 && c.text.Len() >= c.opts.MaxBytes) || time.Since(c.startedAt) >= c.opts.Window {
+		c.Flush(ctx, emit)
+	}
+}
+
+func (c *deltaCoalescer) Deadline() (time.Time, bool) {
+	if c.pending == nil {
+		return time.Time{}, false
+	}
+	return c.startedAt.Add(c.opts.Window), true
+}
+
+func (c *deltaCoalescer) Expire(ctx context.Context, emit func(*ResponseChunk)) {
+	c.Flush(ctx, emit)
+}
+
+func (c *deltaCoalescer) Flush(ctx context.Context, emit func(*ResponseChunk)) {
+	if c.pending == nil {
+		return
+	}
+
+	c.pending.OfOutputTextDelta.Delta = c.text.String()
+	emit(c.pending)
+	c.pending = nil
+	c.text.Reset()
+}
+
+// ClientSafe removes the data a browser has no use for, and shouldn't see, from the stream: the encrypted reasoning
+// and thought signatures of the model, the extra params of the request, and the IDs internal to the provider, such
+// as the previous response and the code interpreter container. The chunks are copied before being redacted, the
+// chunks received by the other consumers of the stream, such as the conversation history, keep the full data.
+func ClientSafe() ChunkTransformerFactory {
+	return func() ChunkTransformer {
+		return ChunkTransformerFunc(func(ctx context.Context, chunk *ResponseChunk, emit func(*ResponseChunk)) {
+			emit(ClientSafeChunk(chunk))
+		})
+	}
+}
+
+// ClientSafeChunk returns a redacted copy of the chunk, see ClientSafe. The chunks without client-unsafe data are
+// returned as they are.
+func ClientSafeChunk(chunk *ResponseChunk) *ResponseChunk {
+	switch {
+	case chunk.OfResponseCreated != nil:
+		return &ResponseChunk{OfResponseCreated: clientSafeResponse(chunk.OfResponseCreated)}
+	case chunk.OfResponseInProgress != nil:
+		return &ResponseChunk{OfResponseInProgress: clientSafeResponse(chunk.OfResponseInProgress)}
+	case chunk.OfResponseCompleted != nil:
+		return &ResponseChunk{OfResponseCompleted: clientSafeResponse(chunk.OfResponseCompleted)}
+
+	case chunk.OfOutputItemAdded != nil:
+		return &ResponseChunk{OfOutputItemAdded: clientSafeOutputItem(chunk.OfOutputItemAdded)}
+	case chunk.OfOutputItemDone != nil:
+		return &ResponseChunk{OfOutputItemDone: clientSafeOutputItem(chunk.OfOutputItemDone)}
+
+	case chunk.OfReasoningSummaryTextDelta != nil && chunk.OfReasoningSummaryTextDelta.EncryptedContent != nil:
+		text := *chunk.OfReasoningSummaryTextDelta
+		text.EncryptedContent = nil
+		return &ResponseChunk{OfReasoningSummaryTextDelta: &text}
+	case chunk.OfReasoningSummaryTextDone != nil && chunk.OfReasoningSummaryTextDone.EncryptedContent != nil:
+		text := *chunk.OfReasoningSummaryTextDone
+		text.EncryptedContent = nil
+		return &ResponseChunk{OfReasoningSummaryTextDone: &text}
+
+	case chunk.OfCodeInterpreterCallCodeDone != nil && chunk.OfCodeInterpreterCallCodeDone.ThoughtSignature != nil:
+		code := *chunk.OfCodeInterpreterCallCodeDone
+		code.ThoughtSignature = nil
+		return &ResponseChunk{OfCodeInterpreterCallCodeDone: &code}
+	}
+
+	return chunk
+}
+
+func clientSafeResponse[T any](chunk *ChunkResponse[T]) *ChunkResponse[T] {
+	out := *chunk
+	out.Response.ExtraParams = nil
+	out.Response.PreviousResponseID = nil
+
+	out.Response.Output = make([]OutputMessageUnion, len(chunk.Response.Output))
+	for i, item := range chunk.Response.Output {
+		switch {
+		case item.OfReasoning != nil:
+			reasoning := *item.OfReasoning
+			reasoning.EncryptedContent = nil
+			item.OfReasoning = &reasoning
+		case item.OfFunctionCall != nil:
+			call := *item.OfFunctionCall
+			call.ThoughtSignature = nil
+			item.OfFunctionCall = &call
+		case item.OfCodeInterpreterCall != nil:
+			call := *item.OfCodeInterpreterCall
+			call.ContainerID = ""
+			item.OfCodeInterpreterCall = &call
+		}
+		out.Response.Output[i] = item
+	}
+
+	return &out
+}
+
+func clientSafeOutputItem[T any](chunk *ChunkOutputItem[T]) *ChunkOutputItem[T] {
+	out := *chunk
+	out.Item.EncryptedContent = nil
+	out.Item.ThoughtSignature = nil
+	out.Item.ContainerID = nil
+	return &out
+}
@@ -0,0 +1,88 @@
+package responses
+
+import "strings"
+
+// ReasoningEffort is the provider independent level of reasoning of a request. Each provider maps it to its own
+// parameter: the effort for OpenAI, the thinking level or budget for Gemini, the thinking budget for Anthropic.
+type ReasoningEffort string
+
+const (
+	ReasoningEffortNone    ReasoningEffort = "none"
+	ReasoningEffortMinimal ReasoningEffort = "minimal"
+	ReasoningEffortLow     ReasoningEffort = "low"
+	ReasoningEffortMedium  ReasoningEffort = "medium"
+	ReasoningEffortHigh    ReasoningEffort = "high"
+	ReasoningEffortXHigh   ReasoningEffort = "xhigh"
+)
+
+// DefaultReasoningEffort is the effort of the requests enabling reasoning without an effort or a budget
+const DefaultReasoningEffort = ReasoningEffortMedium
+
+// reasoningEffortBudgets is the budget of reasoning tokens each effort stands for, for the providers taking budgets
+var reasoningEffortBudgets = map[ReasoningEffort]int{
+	ReasoningEffortNone:    0,
+	ReasoningEffortMinimal: 1024,
+	ReasoningEffortLow:     4096,
+	ReasoningEffortMedium:  8192,
+	ReasoningEffortHigh:    16384,
+	ReasoningEffortXHigh:   32768,
+}
+
+// ParseReasoningEffort returns the effort named by s, ok is false when s isn't an effort
+func ParseReasoningEffort(s string) (ReasoningEffort, bool) {
+	effort := ReasoningEffort(strings.ToLower(strings.TrimSpace(s)))
+	_, ok := reasoningEffortBudgets[effort]
+	return effort, ok
+}
+
+// Budget returns the budget of reasoning tokens of the effort
+func (e ReasoningEffort) Budget() int {
+	if budget, ok := reasoningEffortBudgets[e]; ok {
+		return budget
+	}
+	return reasoningEffortBudgets[DefaultReasoningEffort]
+}
+
+// ReasoningEffortForBudget returns the lowest effort whose budget covers the given budget of reasoning tokens
+func ReasoningEffortForBudget(budget int) ReasoningEffort {
+	for _, effort := range []ReasoningEffort{ReasoningEffortNone, ReasoningEffortMinimal, ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh} {
+		if budget <= reasoningEffortBudgets[effort] {
+			return effort
+		}
+	}
+	return ReasoningEffortXHigh
+}
+
+// NormalizedEffort returns the effort of the reasoning param. An unknown or missing effort is derived from the
+// budget when there is one, and is the default effort otherwise.
+func (r *ReasoningParam) NormalizedEffort() ReasoningEffort {
+	if r == nil {
+		return ReasoningEffortNone
+	}
+
+	if r.Effort != nil {
+		if effort, ok := ParseReasoningEffort(*r.Effort); ok {
+			return effort
+		}
+	}
+
+	if r.BudgetTokens != nil {
+		return ReasoningEffortForBudget(*r.BudgetTokens)
+	}
+
+	return DefaultReasoningEffort
+}
+
+// NormalizedBudget returns the budget of reasoning tokens of the reasoning param, the budget when it is set and the
+// budget of its effort otherwise
+func (r *ReasoningParam) NormalizedBudget() int {
+	if r == nil {
+		return 0
+	}
+
+	if r.BudgetTokens != nil {
+		return *r.BudgetTokens
+	}
+
+	return r.NormalizedEffort().Budget()
+}
@@ -29,8 +29,123 @@ type Parameters struct {
 	Metadata        map[string]string `json:"metadata,omitempty"`
 	Stream          *bool             `json:"stream,omitempty"`
 
-	MaxToolCalls      *int  `json:"max_tool_calls,omitempty"`
-	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+	MaxToolCalls      *int             `json:"max_tool_calls,omitempty"`
+	ParallelToolCalls *bool            `json:"parallel_tool_calls,omitempty"`
+	ToolChoice        *ToolChoiceUnion `json:"tool_choice,omitempty"`
+
+	// PreviousResponseID continues from a response created with store=true
+	PreviousResponseID *string `json:"previous_response_id,omitempty"`
+
+	// Constraint restricts the generated text to a regular expression or a grammar
+	Constraint *Constraint `json:"constraint,omitempty"`
+
+	// DryRun stops the request before it is sent to the provider, the response carries the request instead
+	DryRun *bool `json:"dry_run,omitempty"`
+
+	// ExtraParams are the request parameters of self-hosted servers extending the OpenAI API, such as guided_json or
+	// best_of for vLLM. They are sent to the providers accepting them, and dropped elsewhere.
+	ExtraParams map[string]any `json:"extra_params,omitempty"`
+}
+
+type ConstraintType string
+
+const (
+	ConstraintTypeRegex   ConstraintType = "regex"
+	ConstraintTypeGrammar ConstraintType = "grammar"
+)
+
+// Constraint restricts the text generated by the model. Providers that support constrained decoding
+// enforce it while generating; elsewhere regex constraints are emulated by validating the output and
+// asking the model to try again.
+type Constraint struct {
+	Type       ConstraintType `json:"type"`
+	Definition string         `json:"definition"`       // The regular expression, or the grammar
+	Syntax     string         `json:"syntax,omitempty"` // Syntax of the grammar, e.g. "ebnf" or "lark"
+
+	// MaxAttempts bounds the attempts when the constraint is emulated (default 3)
+	MaxAttempts *int `json:"max_attempts,omitempty"`
+}
+
+type ToolChoiceMode string
+
+const (
+	ToolChoiceModeNone     ToolChoiceMode = "none"
+	ToolChoiceModeAuto     ToolChoiceMode = "auto"
+	ToolChoiceModeRequired ToolChoiceMode = "required"
+)
+
+// ToolChoiceUnion controls whether the model calls tools, and which ones. The mode applies to all the tools, the
+// function forces a call of the function, and the allowed tools restrict the tools the model may call in the mode.
+type ToolChoiceUnion struct {
+	OfMode         *ToolChoiceMode         `json:",omitempty"`
+	OfFunction     *ToolChoiceFunction     `json:",omitempty"`
+	OfAllowedTools *ToolChoiceAllowedTools `json:",omitempty"`
+}
+
+func (u *ToolChoiceUnion) UnmarshalJSON(data []byte) error {
+	var mode ToolChoiceMode
+	if err := sonic.Unmarshal(data, &mode); err == nil {
+		u.OfMode = &mode
+		return nil
+	}
+
+	var function ToolChoiceFunction
+	if err := sonic.Unmarshal(data, &function); err == nil {
+		u.OfFunction = &function
+		return nil
+	}
+
+	var allowedTools ToolChoiceAllowedTools
+	if err := sonic.Unmarshal(data, &allowedTools); err == nil {
+		u.OfAllowedTools = &allowedTools
+		return nil
+	}
+
+	return errors.New("invalid tool choice union")
+}
+
+func (u *ToolChoiceUnion) MarshalJSON() ([]byte, error) {
+	if u.OfMode != nil {
+		return sonic.Marshal(u.OfMode)
+	}
+
+	if u.OfFunction != nil {
+		return sonic.Marshal(u.OfFunction)
+	}
+
+	if u.OfAllowedTools != nil {
+		return sonic.Marshal(u.OfAllowedTools)
+	}
+
+	return nil, nil
+}
+
+type ToolChoiceFunction struct {
+	Type constants.ToolTypeFunction `json:"type"` // "function"
+	Name string                     `json:"name"`
+}
+
+type ToolChoiceAllowedTools struct {
+	Type  constants.ToolChoiceTypeAllowedTools `json:"type"` // "allowed_tools"
+	Mode  ToolChoiceMode                       `json:"mode"` // "auto" or "required"
+	Tools []ToolChoiceAllowedTool              `json:"tools"`
+}
+
+type ToolChoiceAllowedTool struct {
+	Type string `json:"type"`           // "function", or the type of a provider tool, e.g. "web_search"
+	Name string `json:"name,omitempty"` // Only for "function"
+}
+
+// FunctionNames returns the names of the functions allowed
+func (t *ToolChoiceAllowedTools) FunctionNames() []string {
+	names := []string{}
+	for _, tool := range t.Tools {
+		if tool.Type == "function" {
+			names = append(names, tool.Name)
+		}
+	}
+
+	return names
 }
 
 type TextFormat struct {
@@ -45,6 +160,10 @@ func (s *Request) IsStreamingRequest() bool {
 	return *s.Stream
 }
 
+func (s *Request) IsDryRun() bool {
+	return s.DryRun != nil && *s.DryRun
+}
+
 type Includable string
 
 const (
@@ -54,12 +173,13 @@ const (
 	IncludableMessageInputImageImageURL        Includable = "message.input_image.image_url"
 	IncludableMessageOutputTextLogprobs        Includable = "message.output_text.logprobs"
 	IncludableReasoningEncryptedContent        Includable = "reasoning.encrypted_content"
+	IncludableWebSearchCallActionSources       Includable = "web_search_call.action.sources"
 )
 
 type ReasoningParam struct {
 	Summary      *string `json:"summary"` // "auto", "concise", "detailed"
-	Effort       *string `json:"effort"`  // "none", "minimal", "low", "medium", "high", "xhigh"
-	BudgetTokens *int    `json:",omitempty"`
+	Effort       *string `json:"effort"`  // see ReasoningEffort
+	BudgetTokens *int    `json:"budget_tokens,omitempty"`
 }
 
 type InputUnion struct {
@@ -108,7 +228,7 @@ type InputMessageUnion struct {
 	OfImageGenerationCall          *ImageGenerationCallMessage          `json:",omitempty,inline"`
 	OfWebSearchCall                *WebSearchCallMessage                `json:",omitempty,inline"`
 	OfCodeInterpreterCall          *CodeInterpreterCallMessage          `json:",omitempty,inline"`
-	//OfFileSearchCall       *ResponseFileSearchToolCallParam            `json:",omitempty,inline"`
+	OfFileSearchCall               *FileSearchCallMessage               `json:",omitempty,inline"`
 	//OfComputerCall         *ResponseComputerToolCallParam              `json:",omitempty,inline"`
 	//OfComputerCallOutput   *ResponseInputItemComputerCallOutputParam   `json:",omitempty,inline"`
 	//OfLocalShellCall       *ResponseInputItemLocalShellCallParam       `json:",omitempty,inline"`
@@ -163,6 +283,10 @@ func (u *InputMessageUnion) ID() string {
 		return u.OfCodeInterpreterCall.ID
 	}
 
+	if u.OfFileSearchCall != nil {
+		return u.OfFileSearchCall.ID
+	}
+
 	return ""
 }
 
@@ -227,6 +351,12 @@ func (u *InputMessageUnion) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var fileSearchMsg FileSearchCallMessage
+	if err := sonic.Unmarshal(data, &fileSearchMsg); err == nil {
+		u.OfFileSearchCall = &fileSearchMsg
+		return nil
+	}
+
 	return errors.New("invalid input message union")
 }
 
@@ -271,6 +401,10 @@ func (u *InputMessageUnion) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(u.OfCodeInterpreterCall)
 	}
 
+	if u.OfFileSearchCall != nil {
+		return sonic.Marshal(u.OfFileSearchCall)
+	}
+
 	return nil, nil
 }
 
@@ -316,6 +450,11 @@ type ReasoningMessage struct {
 	ID               string                         `json:"id"`
 	Summary          []SummaryTextContent           `json:"summary"`
 	EncryptedContent *string                        `json:"encrypted_content,omitempty"`
+
+	// Provider and Model produced the encrypted content, only the provider producing it accepts it back. The gateway
+	// sets them on the responses, and doesn't send them to the providers.
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
 }
 
 type ImageGenerationCallMessage struct {
@@ -350,6 +489,22 @@ type CodeInterpreterCallOutputParam struct {
 	Logs string `json:"logs"`
 }
 
+type FileSearchCallMessage struct {
+	Type    constants.MessageTypeFileSearchCall `json:"type"`
+	ID      string                              `json:"id"`
+	Queries []string                            `json:"queries"`
+	Status  string                              `json:"status"`            // "in_progress", "searching", "completed", "incomplete", "failed"
+	Results []FileSearchCallResult              `json:"results,omitempty"` // Only with the "file_search_call.results" includable
+}
+
+type FileSearchCallResult struct {
+	FileID     string         `json:"file_id,omitempty"`
+	Filename   string         `json:"filename,omitempty"`
+	Score      *float64       `json:"score,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
 type EasyInputContentUnion struct {
 	OfString           *string      `json:",omitempty"`
 	OfInputMessageList InputContent `json:",omitempty"`
@@ -466,9 +621,52 @@ type Annotation struct {
 	StartIndex int    `json:"start_index"`
 	EndIndex   int    `json:"end_index"`
 
+	// For the citations of files
+	FileID      string `json:"file_id,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ContainerID string `json:"container_id,omitempty"` // Only for "container_file_citation"
+	Index       int    `json:"index,omitempty"`        // Only for "file_citation" and "file_path"
+
 	ExtraParams map[string]any `json:"extra_params"`
 }
 
+// annotationJSON holds the fields of an annotation that exist for its type
+type annotationJSON struct {
+	Type        string         `json:"type"`
+	Title       *string        `json:"title,omitempty"`
+	URL         *string        `json:"url,omitempty"`
+	ContainerID *string        `json:"container_id,omitempty"`
+	FileID      *string        `json:"file_id,omitempty"`
+	Filename    *string        `json:"filename,omitempty"`
+	Index       *int           `json:"index,omitempty"`
+	StartIndex  *int           `json:"start_index,omitempty"`
+	EndIndex    *int           `json:"end_index,omitempty"`
+	ExtraParams map[string]any `json:"extra_params,omitempty"`
+}
+
+// MarshalJSON writes only the fields of the type of the annotation, so that the annotations of a response are sent
+// back in the shape the provider produced them.
+func (a Annotation) MarshalJSON() ([]byte, error) {
+	out := annotationJSON{
+		Type:        a.Type,
+		ExtraParams: a.ExtraParams,
+	}
+
+	switch a.Type {
+	case "file_citation":
+		out.FileID, out.Filename, out.Index = &a.FileID, &a.Filename, &a.Index
+	case "container_file_citation":
+		out.ContainerID, out.FileID, out.Filename = &a.ContainerID, &a.FileID, &a.Filename
+		out.StartIndex, out.EndIndex = &a.StartIndex, &a.EndIndex
+	case "file_path":
+		out.FileID, out.Index = &a.FileID, &a.Index
+	default:
+		out.Title, out.URL, out.StartIndex, out.EndIndex = &a.Title, &a.URL, &a.StartIndex, &a.EndIndex
+	}
+
+	return sonic.Marshal(out)
+}
+
 type FunctionCallOutputContentUnion struct {
 	OfString *string      `json:",omitempty"`
 	OfList   InputContent `json:",omitempty"`
@@ -507,6 +705,7 @@ type ToolUnion struct {
 	OfImageGeneration *ImageGenerationTool `json:",omitempty"`
 	OfWebSearch       *WebSearchTool       `json:",omitempty"`
 	OfCodeExecution   *CodeExecutionTool   `json:",omitempty"`
+	OfFileSearch      *FileSearchTool      `json:",omitempty"`
 }
 
 func (u *ToolUnion) UnmarshalJSON(data []byte) error {
@@ -534,6 +733,12 @@ func (u *ToolUnion) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var fileSearchTool FileSearchTool
+	if err := sonic.Unmarshal(data, &fileSearchTool); err == nil {
+		u.OfFileSearch = &fileSearchTool
+		return nil
+	}
+
 	return errors.New("invalid tool union")
 }
 
@@ -554,6 +759,10 @@ func (u *ToolUnion) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(u.OfCodeExecution)
 	}
 
+	if u.OfFileSearch != nil {
+		return sonic.Marshal(u.OfFileSearch)
+	}
+
 	return nil, nil
 }
 
@@ -635,9 +844,9 @@ func (u *WebSearchCallActionUnion) MarshalJSON() ([]byte, error) {
 
 type WebSearchCallActionOfSearch struct {
 	Type    constants.WebSearchActionTypeSearch `json:"type"`
-	Queries []string                            `json:"queries"`
+	Queries []string                            `json:"queries,omitempty"`
 	Query   string                              `json:"query"`
-	Sources []WebSearchCallActionOfSearchSource `json:"sources"`
+	Sources []WebSearchCallActionOfSearchSource `json:"sources,omitempty"` // Only with the "web_search_call.action.sources" includable
 }
 
 type WebSearchCallActionOfOpenPage struct {
@@ -654,7 +863,20 @@ type WebSearchCallActionOfFind struct {
 type WebSearchCallActionOfSearchSource struct {
 	Type        string         `json:"type"` // always "url"
 	URL         string         `json:"url"`
-	ExtraParams map[string]any `json:"extra_params"`
+	ExtraParams map[string]any `json:"extra_params,omitempty"`
+}
+
+type FileSearchTool struct {
+	Type           constants.ToolTypeFileSearch  `json:"type"` // file_search
+	VectorStoreIDs []string                      `json:"vector_store_ids"`
+	MaxNumResults  *int                          `json:"max_num_results,omitempty"`
+	Filters        map[string]any                `json:"filters,omitempty"` // Comparison or compound filter on the attributes of the files
+	RankingOptions *FileSearchToolRankingOptions `json:"ranking_options,omitempty"`
+}
+
+type FileSearchToolRankingOptions struct {
+	Ranker         *string  `json:"ranker,omitempty"` // "auto", "default-2024-11-15"
+	ScoreThreshold *float64 `json:"score_threshold,omitempty"`
 }
 
 type CodeExecutionTool struct {
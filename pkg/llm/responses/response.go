@@ -1,7 +1,9 @@
 package responses
 
 import (
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm/constants"
@@ -16,6 +18,78 @@ type Response struct {
 	Error       *Error                 `json:"error"`
 	ServiceTier string                 `json:"service_tier"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	Timing      *Timing                `json:"timing,omitempty"`
+	Provider    string                 `json:"provider,omitempty"` // Provider that served the request, set by the gateway client
+	DryRun      *DryRun                `json:"dry_run,omitempty"`  // Request the provider would have received, for dry run requests
+}
+
+// DryRun is the request a provider would have received for a dry run request, once the prompt, the history and
+// the tools are resolved and converted to the format of the provider
+type DryRun struct {
+	Provider string            `json:"provider"`
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"` // Credentials are redacted
+	Payload  json.RawMessage   `json:"payload"` // Body of the request, in the format of the provider
+
+	// EstimatedInputTokens approximates the input tokens of the request from the length of its texts
+	EstimatedInputTokens int `json:"estimated_input_tokens"`
+}
+
+// Provenance labels an output message as generated by a model, so that downstream systems can tell AI generated
+// content apart
+type Provenance struct {
+	MessageID   string    `json:"message_id"`
+	Model       string    `json:"model"`
+	Provider    string    `json:"provider,omitempty"`
+	Agent       string    `json:"agent,omitempty"`
+	RunID       string    `json:"run_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// MetadataKeyFinish is the key of the FinishMetadata in the metadata of a response
+const MetadataKeyFinish = "finish"
+
+// FinishMetadata tells why the provider stopped generating, and how it rated the safety of the prompt and of the
+// output, for the providers rating it
+type FinishMetadata struct {
+	FinishReason  string         `json:"finish_reason,omitempty"`
+	FinishMessage string         `json:"finish_message,omitempty"`
+	SafetyRatings []SafetyRating `json:"safety_ratings,omitempty"`
+
+	// The prompt was blocked, the response has no output
+	PromptBlockReason   string         `json:"prompt_block_reason,omitempty"`
+	PromptSafetyRatings []SafetyRating `json:"prompt_safety_ratings,omitempty"`
+}
+
+type SafetyRating struct {
+	Category    string `json:"category"`    // e.g. "HARM_CATEGORY_DANGEROUS_CONTENT"
+	Probability string `json:"probability"` // e.g. "NEGLIGIBLE", "LOW", "MEDIUM", "HIGH"
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// FinishMetadata returns the finish metadata of the response, or nil when the provider didn't set it. It is read
+// back from its JSON form when the response was decoded.
+func (r *Response) FinishMetadata() *FinishMetadata {
+	switch val := r.Metadata[MetadataKeyFinish].(type) {
+	case *FinishMetadata:
+		return val
+	case FinishMetadata:
+		return &val
+	case nil:
+		return nil
+	default:
+		buf, err := sonic.Marshal(val)
+		if err != nil {
+			return nil
+		}
+
+		var out FinishMetadata
+		if err := sonic.Unmarshal(buf, &out); err != nil {
+			return nil
+		}
+
+		return &out
+	}
 }
 
 type Error struct {
@@ -34,6 +108,7 @@ type OutputMessageUnion struct {
 	OfImageGenerationCall *ImageGenerationCallMessage `json:",omitempty"`
 	OfWebSearchCall       *WebSearchCallMessage       `json:",omitempty"`
 	OfCodeInterpreterCall *CodeInterpreterCallMessage `json:",omitempty"`
+	OfFileSearchCall      *FileSearchCallMessage      `json:",omitempty"`
 }
 
 func (u *OutputMessageUnion) UnmarshalJSON(data []byte) error {
@@ -73,6 +148,12 @@ func (u *OutputMessageUnion) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var fileSearchCallMessage *FileSearchCallMessage
+	if err := sonic.Unmarshal(data, &fileSearchCallMessage); err == nil {
+		u.OfFileSearchCall = fileSearchCallMessage
+		return nil
+	}
+
 	return errors.New("invalid output message union type")
 }
 
@@ -101,6 +182,10 @@ func (u *OutputMessageUnion) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(u.OfCodeInterpreterCall)
 	}
 
+	if u.OfFileSearchCall != nil {
+		return sonic.Marshal(u.OfFileSearchCall)
+	}
+
 	return nil, nil
 }
 
@@ -129,6 +214,10 @@ func (u *OutputMessageUnion) AsInput() (InputMessageUnion, error) {
 		return InputMessageUnion{OfCodeInterpreterCall: u.OfCodeInterpreterCall}, nil
 	}
 
+	if u.OfFileSearchCall != nil {
+		return InputMessageUnion{OfFileSearchCall: u.OfFileSearchCall}, nil
+	}
+
 	return InputMessageUnion{}, errors.New("invalid output message union type")
 }
 
@@ -190,3 +279,7 @@ func NewOutputItemReasoningID() string {
 func NewOutputItemWebSearchCallID() string {
 	return "ws_" + uuid.NewString()
 }
+
+func NewOutputItemImageGenerationCallID() string {
+	return "ig_" + uuid.NewString()
+}
@@ -2,6 +2,7 @@ package responses
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/pkg/llm/constants"
@@ -46,6 +47,11 @@ type ResponseChunk struct {
 	OfWebSearchCallSearching  *ChunkWebSearchCall[constants.ChunkTypeWebSearchCallSearching]  `json:",omitempty"`
 	OfWebSearchCallCompleted  *ChunkWebSearchCall[constants.ChunkTypeWebSearchCallCompleted]  `json:",omitempty"`
 
+	// For output item of type "file_search_call"
+	OfFileSearchCallInProgress *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallInProgress] `json:",omitempty"`
+	OfFileSearchCallSearching  *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallSearching]  `json:",omitempty"`
+	OfFileSearchCallCompleted  *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallCompleted]  `json:",omitempty"`
+
 	// For output item of type "code_interpreter"
 	OfCodeInterpreterCallInProgress   *ChunkCodeInterpreterCall[constants.ChunkTypeCodeInterpreterCallInProgress]   `json:",omitempty"`
 	OfCodeInterpreterCallCodeDelta    *ChunkCodeInterpreterCall[constants.ChunkTypeCodeInterpreterCallCodeDelta]    `json:",omitempty"`
@@ -58,7 +64,28 @@ type ResponseChunk struct {
 	OfRunInProgress      *ChunkRun[constants.ChunkTypeRunInProgress] `json:",omitempty"`
 	OfRunPaused          *ChunkRun[constants.ChunkTypeRunPaused]     `json:",omitempty"`
 	OfRunCompleted       *ChunkRun[constants.ChunkTypeRunCompleted]  `json:",omitempty"`
+	OfRunFailed          *ChunkRun[constants.ChunkTypeRunFailed]     `json:",omitempty"`
 	OfFunctionCallOutput *FunctionCallOutputMessage                  `json:",omitempty"`
+
+	OfRunEstimated *ChunkRunEstimate[constants.ChunkTypeRunEstimated] `json:",omitempty"`
+
+	OfResponseMetrics *ChunkResponseMetrics[constants.ChunkTypeResponseMetrics] `json:",omitempty"`
+
+	OfStructuredOutputPartial *ChunkStructuredOutput[constants.ChunkTypeStructuredOutputPartial] `json:",omitempty"`
+
+	OfProvenance *ChunkProvenance[constants.ChunkTypeProvenance] `json:",omitempty"`
+
+	OfTranslation *ChunkTranslation[constants.ChunkTypeTranslation] `json:",omitempty"`
+
+	OfPolicyViolation *ChunkPolicyViolation[constants.ChunkTypePolicyViolation] `json:",omitempty"`
+
+	OfDryRun *ChunkDryRun[constants.ChunkTypeDryRun] `json:",omitempty"`
+
+	// Human operator taking over a conversation
+	OfTakeoverStarted *ChunkTakeover[constants.ChunkTypeTakeoverStarted] `json:",omitempty"`
+	OfTakeoverActive  *ChunkTakeover[constants.ChunkTypeTakeoverActive]  `json:",omitempty"`
+	OfTakeoverMessage *ChunkTakeover[constants.ChunkTypeTakeoverMessage] `json:",omitempty"`
+	OfTakeoverEnded   *ChunkTakeover[constants.ChunkTypeTakeoverEnded]   `json:",omitempty"`
 }
 
 func (u *ResponseChunk) UnmarshalJSON(data []byte) error {
@@ -86,6 +113,78 @@ func (u *ResponseChunk) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var runFailed *ChunkRun[constants.ChunkTypeRunFailed]
+	if err := sonic.Unmarshal(data, &runFailed); err == nil {
+		u.OfRunFailed = runFailed
+		return nil
+	}
+
+	var runEstimated *ChunkRunEstimate[constants.ChunkTypeRunEstimated]
+	if err := sonic.Unmarshal(data, &runEstimated); err == nil {
+		u.OfRunEstimated = runEstimated
+		return nil
+	}
+
+	var responseMetrics *ChunkResponseMetrics[constants.ChunkTypeResponseMetrics]
+	if err := sonic.Unmarshal(data, &responseMetrics); err == nil {
+		u.OfResponseMetrics = responseMetrics
+		return nil
+	}
+
+	var structuredOutputPartial *ChunkStructuredOutput[constants.ChunkTypeStructuredOutputPartial]
+	if err := sonic.Unmarshal(data, &structuredOutputPartial); err == nil {
+		u.OfStructuredOutputPartial = structuredOutputPartial
+		return nil
+	}
+
+	var provenance *ChunkProvenance[constants.ChunkTypeProvenance]
+	if err := sonic.Unmarshal(data, &provenance); err == nil {
+		u.OfProvenance = provenance
+		return nil
+	}
+
+	var translation *ChunkTranslation[constants.ChunkTypeTranslation]
+	if err := sonic.Unmarshal(data, &translation); err == nil {
+		u.OfTranslation = translation
+		return nil
+	}
+
+	var policyViolation *ChunkPolicyViolation[constants.ChunkTypePolicyViolation]
+	if err := sonic.Unmarshal(data, &policyViolation); err == nil {
+		u.OfPolicyViolation = policyViolation
+		return nil
+	}
+
+	var dryRun *ChunkDryRun[constants.ChunkTypeDryRun]
+	if err := sonic.Unmarshal(data, &dryRun); err == nil {
+		u.OfDryRun = dryRun
+		return nil
+	}
+
+	var takeoverStarted *ChunkTakeover[constants.ChunkTypeTakeoverStarted]
+	if err := sonic.Unmarshal(data, &takeoverStarted); err == nil {
+		u.OfTakeoverStarted = takeoverStarted
+		return nil
+	}
+
+	var takeoverActive *ChunkTakeover[constants.ChunkTypeTakeoverActive]
+	if err := sonic.Unmarshal(data, &takeoverActive); err == nil {
+		u.OfTakeoverActive = takeoverActive
+		return nil
+	}
+
+	var takeoverMessage *ChunkTakeover[constants.ChunkTypeTakeoverMessage]
+	if err := sonic.Unmarshal(data, &takeoverMessage); err == nil {
+		u.OfTakeoverMessage = takeoverMessage
+		return nil
+	}
+
+	var takeoverEnded *ChunkTakeover[constants.ChunkTypeTakeoverEnded]
+	if err := sonic.Unmarshal(data, &takeoverEnded); err == nil {
+		u.OfTakeoverEnded = takeoverEnded
+		return nil
+	}
+
 	var responseCreated *ChunkResponse[constants.ChunkTypeResponseCreated]
 	if err := sonic.Unmarshal(data, &responseCreated); err == nil {
 		u.OfResponseCreated = responseCreated
@@ -224,6 +323,24 @@ func (u *ResponseChunk) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	var fileSearchCallInProgress *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallInProgress]
+	if err := sonic.Unmarshal(data, &fileSearchCallInProgress); err == nil {
+		u.OfFileSearchCallInProgress = fileSearchCallInProgress
+		return nil
+	}
+
+	var fileSearchCallSearching *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallSearching]
+	if err := sonic.Unmarshal(data, &fileSearchCallSearching); err == nil {
+		u.OfFileSearchCallSearching = fileSearchCallSearching
+		return nil
+	}
+
+	var fileSearchCallCompleted *ChunkFileSearchCall[constants.ChunkTypeFileSearchCallCompleted]
+	if err := sonic.Unmarshal(data, &fileSearchCallCompleted); err == nil {
+		u.OfFileSearchCallCompleted = fileSearchCallCompleted
+		return nil
+	}
+
 	var codeInterpreterCallInProgress *ChunkCodeInterpreterCall[constants.ChunkTypeCodeInterpreterCallInProgress]
 	if err := sonic.Unmarshal(data, &codeInterpreterCallInProgress); err == nil {
 		u.OfCodeInterpreterCallInProgress = codeInterpreterCallInProgress
@@ -346,6 +463,18 @@ func (u *ResponseChunk) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(u.OfWebSearchCallCompleted)
 	}
 
+	if u.OfFileSearchCallInProgress != nil {
+		return sonic.Marshal(u.OfFileSearchCallInProgress)
+	}
+
+	if u.OfFileSearchCallSearching != nil {
+		return sonic.Marshal(u.OfFileSearchCallSearching)
+	}
+
+	if u.OfFileSearchCallCompleted != nil {
+		return sonic.Marshal(u.OfFileSearchCallCompleted)
+	}
+
 	// Custom Chunks
 	if u.OfRunCreated != nil {
 		return sonic.Marshal(u.OfRunCreated)
@@ -363,10 +492,58 @@ func (u *ResponseChunk) MarshalJSON() ([]byte, error) {
 		return sonic.Marshal(u.OfRunCompleted)
 	}
 
+	if u.OfRunFailed != nil {
+		return sonic.Marshal(u.OfRunFailed)
+	}
+
 	if u.OfFunctionCallOutput != nil {
 		return sonic.Marshal(u.OfFunctionCallOutput)
 	}
 
+	if u.OfRunEstimated != nil {
+		return sonic.Marshal(u.OfRunEstimated)
+	}
+
+	if u.OfResponseMetrics != nil {
+		return sonic.Marshal(u.OfResponseMetrics)
+	}
+
+	if u.OfStructuredOutputPartial != nil {
+		return sonic.Marshal(u.OfStructuredOutputPartial)
+	}
+
+	if u.OfProvenance != nil {
+		return sonic.Marshal(u.OfProvenance)
+	}
+
+	if u.OfTranslation != nil {
+		return sonic.Marshal(u.OfTranslation)
+	}
+
+	if u.OfPolicyViolation != nil {
+		return sonic.Marshal(u.OfPolicyViolation)
+	}
+
+	if u.OfDryRun != nil {
+		return sonic.Marshal(u.OfDryRun)
+	}
+
+	if u.OfTakeoverStarted != nil {
+		return sonic.Marshal(u.OfTakeoverStarted)
+	}
+
+	if u.OfTakeoverActive != nil {
+		return sonic.Marshal(u.OfTakeoverActive)
+	}
+
+	if u.OfTakeoverMessage != nil {
+		return sonic.Marshal(u.OfTakeoverMessage)
+	}
+
+	if u.OfTakeoverEnded != nil {
+		return sonic.Marshal(u.OfTakeoverEnded)
+	}
+
 	if u.OfCodeInterpreterCallInProgress != nil {
 		return sonic.Marshal(u.OfCodeInterpreterCallInProgress)
 	}
@@ -479,6 +656,18 @@ func (u *ResponseChunk) ChunkType() string {
 		return u.OfWebSearchCallCompleted.Type.Value()
 	}
 
+	if u.OfFileSearchCallInProgress != nil {
+		return u.OfFileSearchCallInProgress.Type.Value()
+	}
+
+	if u.OfFileSearchCallSearching != nil {
+		return u.OfFileSearchCallSearching.Type.Value()
+	}
+
+	if u.OfFileSearchCallCompleted != nil {
+		return u.OfFileSearchCallCompleted.Type.Value()
+	}
+
 	if u.OfCodeInterpreterCallInProgress != nil {
 		return u.OfCodeInterpreterCallInProgress.Type.Value()
 	}
@@ -516,10 +705,58 @@ func (u *ResponseChunk) ChunkType() string {
 		return u.OfRunCompleted.Type.Value()
 	}
 
+	if u.OfRunFailed != nil {
+		return u.OfRunFailed.Type.Value()
+	}
+
 	if u.OfFunctionCallOutput != nil {
 		return u.OfFunctionCallOutput.Type.Value()
 	}
 
+	if u.OfRunEstimated != nil {
+		return u.OfRunEstimated.Type.Value()
+	}
+
+	if u.OfResponseMetrics != nil {
+		return u.OfResponseMetrics.Type.Value()
+	}
+
+	if u.OfStructuredOutputPartial != nil {
+		return u.OfStructuredOutputPartial.Type.Value()
+	}
+
+	if u.OfProvenance != nil {
+		return u.OfProvenance.Type.Value()
+	}
+
+	if u.OfTranslation != nil {
+		return u.OfTranslation.Type.Value()
+	}
+
+	if u.OfPolicyViolation != nil {
+		return u.OfPolicyViolation.Type.Value()
+	}
+
+	if u.OfDryRun != nil {
+		return u.OfDryRun.Type.Value()
+	}
+
+	if u.OfTakeoverStarted != nil {
+		return u.OfTakeoverStarted.Type.Value()
+	}
+
+	if u.OfTakeoverActive != nil {
+		return u.OfTakeoverActive.Type.Value()
+	}
+
+	if u.OfTakeoverMessage != nil {
+		return u.OfTakeoverMessage.Type.Value()
+	}
+
+	if u.OfTakeoverEnded != nil {
+		return u.OfTakeoverEnded.Type.Value()
+	}
+
 	return ""
 }
 
@@ -532,10 +769,99 @@ type ChunkRun[T any] struct {
 type ChunkRunData struct {
 	Id               string                `json:"id"`
 	Object           string                `json:"object"` // "run"
-	Status           string                `json:"status"` // "created", "in_progress", "paused", "resumed", "completed", "aborted"
+	Status           string                `json:"status"` // "created", "in_progress", "paused", "resumed", "completed", "failed", "aborted"
 	PendingToolCalls []FunctionCallMessage `json:"pending_tool_calls"`
 	Usage            Usage                 `json:"usage"`
 	TraceID          string                `json:"traceid"`
+
+	// ApprovalID is set on the paused runs that a durable runtime keeps waiting for the approval of their pending
+	// tool calls, instead of ending them
+	ApprovalID string `json:"approval_id,omitempty"`
+
+	// Error is why a failed run failed
+	Error *ChunkRunError `json:"error,omitempty"`
+}
+
+// ChunkRunError is the failure of a run, classified by its code, e.g. "panic", "cancelled", "timeout", "tool_failed"
+// or "failed"
+type ChunkRunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ChunkRunEstimate is emitted before the first LLM call of a run with an estimate of its input tokens, from the
+// assembled instruction, history and tools. EstimatedCost is the USD price of those tokens, unset when the pricing of
+// the model isn't known.
+type ChunkRunEstimate[T any] struct {
+	Type                 T        `json:"type"`
+	RunID                string   `json:"run_id"`
+	Model                string   `json:"model,omitempty"`
+	EstimatedInputTokens int      `json:"estimated_input_tokens"`
+	EstimatedCost        *float64 `json:"estimated_cost,omitempty"`
+}
+
+// ChunkResponseMetrics is emitted by the client after the provider stream ends and carries the request timing
+type ChunkResponseMetrics[T any] struct {
+	Type           T      `json:"type"`
+	SequenceNumber int    `json:"sequence_number"`
+	Model          string `json:"model"`
+	Provider       string `json:"provider,omitempty"`
+	Timing         Timing `json:"timing"`
+}
+
+// ChunkStructuredOutput is emitted by agents with an output schema as the structured output streams in.
+// Output holds the JSON parsed so far: open strings, arrays and objects hold what has been received.
+type ChunkStructuredOutput[T any] struct {
+	Type         T      `json:"type"`
+	ItemId       string `json:"item_id"`
+	OutputIndex  int    `json:"output_index"`
+	ContentIndex int    `json:"content_index"`
+	Output       any    `json:"output"`
+	Complete     bool   `json:"complete"` // Whether Output is a complete JSON value
+}
+
+// ChunkProvenance is emitted by agents with provenance enabled for every output message of the model, once the
+// message is complete
+type ChunkProvenance[T any] struct {
+	Type       T          `json:"type"`
+	ItemId     string     `json:"item_id"`
+	Provenance Provenance `json:"provenance"`
+}
+
+// ChunkTranslation is emitted by agents with translation enabled for every output message of the model, once the
+// message is complete. Text is the message translated to the language of the user, which replaces the streamed text.
+type ChunkTranslation[T any] struct {
+	Type     T      `json:"type"`
+	ItemId   string `json:"item_id"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// ChunkPolicyViolation is emitted by agents with a response policy when an answer of the model violates it. The
+// output messages of ItemIDs are discarded, and the model streams a revised answer after this chunk.
+type ChunkPolicyViolation[T any] struct {
+	Type       T        `json:"type"`
+	ItemIDs    []string `json:"item_ids"`
+	Violations []string `json:"violations"`
+	Revision   int      `json:"revision"`
+}
+
+// ChunkDryRun is the only chunk of the stream of a dry run request, with the request the provider would have received
+type ChunkDryRun[T any] struct {
+	Type   T      `json:"type"`
+	DryRun DryRun `json:"dry_run"`
+}
+
+// ChunkTakeover reports that a human operator took over a conversation, wrote in it as the assistant, or handed it
+// back to the agent. takeover.active is sent instead of a run to a user writing while the operator is in control.
+type ChunkTakeover[T any] struct {
+	Type      T                  `json:"type"`
+	ThreadID  string             `json:"thread_id"`
+	Operator  string             `json:"operator"`
+	Reason    string             `json:"reason,omitempty"`
+	MessageID string             `json:"message_id,omitempty"`
+	Message   *InputMessageUnion `json:"message,omitempty"`
+	At        time.Time          `json:"at"`
 }
 
 type ChunkResponse[T any] struct {
@@ -565,7 +891,7 @@ type ChunkOutputItem[T any] struct {
 }
 
 type ChunkOutputItemData struct {
-	Type string `json:"type"` // "function_call" , "message", "reasoning", "image_generation_call", "web_search_call", "code_interpreter_call"
+	Type string `json:"type"` // "function_call" , "message", "reasoning", "image_generation_call", "web_search_call", "file_search_call", "code_interpreter_call"
 
 	// Common fields
 	Id     string `json:"id"`
@@ -584,6 +910,8 @@ type ChunkOutputItemData struct {
 	// For "reasoning"
 	EncryptedContent *string              `json:"encrypted_content,omitempty"`
 	Summary          []SummaryTextContent `json:"summary,omitempty"`
+	Provider         string               `json:"provider,omitempty"` // Provenance of the encrypted content, see ReasoningMessage
+	Model            string               `json:"model,omitempty"`
 
 	// For "image_generation_call"
 	Background   *string `json:"background,omitempty"`    // "opaque"
@@ -595,6 +923,10 @@ type ChunkOutputItemData struct {
 	// For "web_search_call"
 	Action *WebSearchCallActionUnion `json:"action,omitempty"`
 
+	// For "file_search_call"
+	Queries []string               `json:"queries,omitempty"`
+	Results []FileSearchCallResult `json:"results,omitempty"`
+
 	// For "code_interpreter_call"
 	Code        *string                          `json:"code,omitempty"`
 	ContainerID *string                          `json:"container_id,omitempty"`
@@ -692,6 +1024,14 @@ type ChunkWebSearchCall[T any] struct {
 	OutputIndex    int    `json:"output_index"`
 }
 
+type ChunkFileSearchCall[T any] struct {
+	Type T `json:"type"`
+
+	SequenceNumber int    `json:"sequence_number"`
+	ItemId         string `json:"item_id"`
+	OutputIndex    int    `json:"output_index"`
+}
+
 type ChunkCodeInterpreterCall[T any] struct {
 	Type T `json:"type"`
 
@@ -0,0 +1,127 @@
+package responses
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// TeeOverflow is what a branch of a Tee does with the chunks its consumer has no room for
+type TeeOverflow int
+
+const (
+	// TeeBlock holds the stream back until the consumer makes room, the consumer gets every chunk
+	TeeBlock TeeOverflow = iota
+
+	// TeeDrop drops the chunks the consumer has no room for, so that a slow consumer doesn't hold back the stream.
+	// The response accumulated by the Tee is complete all the same.
+	TeeDrop
+)
+
+// Tee feeds the chunks of a stream to several consumers, e.g. the SSE writer, the history and an analytics sink.
+// Each consumer reads its own branch, buffered independently, and the Tee accumulates the response once for all
+// of them. The chunks are shared by the branches, the consumers must not modify them.
+type Tee struct {
+	in       chan *ResponseChunk
+	branches []*teeBranch
+	acc      ResponseAccumulator
+	started  bool
+	done     chan struct{}
+}
+
+type teeBranch struct {
+	out      chan *ResponseChunk
+	overflow TeeOverflow
+	detached chan struct{}
+	dropped  int
+}
+
+// NewTee creates a Tee of the stream. Add the branches, then Start it.
+func NewTee(in chan *ResponseChunk) *Tee {
+	return &Tee{
+		in:   in,
+		done: make(chan struct{}),
+	}
+}
+
+// Branch adds a consumer reading the stream from the returned channel, which is closed at the end of the stream.
+// buffer is how many chunks the consumer may lag behind before overflow applies. A consumer that stops reading
+// before the end, e.g. when its client disconnects, detaches so that it doesn't hold back the stream.
+func (t *Tee) Branch(buffer int, overflow TeeOverflow) (out chan *ResponseChunk, detach func()) {
+	if t.started {
+		panic("responses: branch added to a started tee")
+	}
+
+	branch := &teeBranch{
+		out:      make(chan *ResponseChunk, buffer),
+		overflow: overflow,
+		detached: make(chan struct{}),
+	}
+	t.branches = append(t.branches, branch)
+
+	var once sync.Once
+	return branch.out, func() {
+		once.Do(func() { close(branch.detached) })
+	}
+}
+
+// Sink adds a consumer called with every chunk of its branch, on a goroutine of its own, then with the accumulated
+// response once the stream ended. Either function may be nil.
+func (t *Tee) Sink(buffer int, overflow TeeOverflow, onChunk func(*ResponseChunk), onEnd func(*Response)) {
+	branch, _ := t.Branch(buffer, overflow)
+	go func() {
+		for chunk := range branch {
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+		}
+
+		if onEnd != nil {
+			onEnd(t.Wait())
+		}
+	}()
+}
+
+// Start feeds the branches until the end of the stream
+func (t *Tee) Start() {
+	t.started = true
+	go func() {
+		defer close(t.done)
+
+		for chunk := range t.in {
+			t.acc.Add(chunk)
+			for _, branch := range t.branches {
+				branch.send(chunk)
+			}
+		}
+
+		for _, branch := range t.branches {
+			close(branch.out)
+			if branch.dropped > 0 {
+				slog.Debug("tee branch dropped chunks", slog.Int("dropped", branch.dropped))
+			}
+		}
+	}()
+}
+
+// Wait waits for the end of the stream and returns the accumulated response
+func (t *Tee) Wait() *Response {
+	<-t.done
+	return t.acc.Response()
+}
+
+func (b *teeBranch) send(chunk *ResponseChunk) {
+	if b.overflow == TeeBlock {
+		select {
+		case b.out <- chunk:
+		case <-b.detached:
+		}
+		return
+	}
+
+	select {
+	case b.out <- chunk:
+	case <-b.detached:
+	default:
+		b.dropped++
+	}
+}
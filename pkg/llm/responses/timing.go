@@ -0,0 +1,90 @@
+package responses
+
+import (
+	"context"
+	"time"
+)
+
+// Timing holds the latency breakdown of a single streaming request, in milliseconds
+type Timing struct {
+	// QueueMs is the time between the request being enqueued (see ContextWithEnqueuedAt) and it being sent
+	QueueMs int64 `json:"queue_ms"`
+
+	// ConnectMs is the time until the provider accepted the request and the stream was opened
+	ConnectMs int64 `json:"connect_ms"`
+
+	// TTFTMs is the time until the first output chunk (text, reasoning, tool call, ...) was received
+	TTFTMs int64 `json:"ttft_ms"`
+
+	// TotalMs is the time until the stream ended
+	TotalMs int64 `json:"total_ms"`
+}
+
+type enqueuedAtKey struct{}
+
+// ContextWithEnqueuedAt marks the time a request was accepted, so that the time spent before the
+// LLM call is made is reported as queue time
+func ContextWithEnqueuedAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, enqueuedAtKey{}, t)
+}
+
+// EnqueuedAtFromContext returns the time set by ContextWithEnqueuedAt
+func EnqueuedAtFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(enqueuedAtKey{}).(time.Time)
+	return t, ok
+}
+
+// TimingRecorder measures the timing of a streaming request
+type TimingRecorder struct {
+	queuedAt   time.Time
+	startedAt  time.Time
+	connectMs  int64
+	ttftMs     int64
+	firstChunk bool
+}
+
+// NewTimingRecorder starts measuring a request that is about to be sent
+func NewTimingRecorder(ctx context.Context) *TimingRecorder {
+	now := time.Now()
+	queuedAt, ok := EnqueuedAtFromContext(ctx)
+	if !ok || queuedAt.After(now) {
+		queuedAt = now
+	}
+
+	return &TimingRecorder{queuedAt: queuedAt, startedAt: now}
+}
+
+// Connected records that the provider stream has been opened
+func (r *TimingRecorder) Connected() {
+	r.connectMs = time.Since(r.startedAt).Milliseconds()
+}
+
+// Observe records the time to first token on the first output chunk
+func (r *TimingRecorder) Observe(chunk *ResponseChunk) {
+	if r.firstChunk || !isOutputChunk(chunk) {
+		return
+	}
+	r.firstChunk = true
+	r.ttftMs = time.Since(r.startedAt).Milliseconds()
+}
+
+// Timing returns the timing so far, with the total measured up to now
+func (r *TimingRecorder) Timing() Timing {
+	return Timing{
+		QueueMs:   r.startedAt.Sub(r.queuedAt).Milliseconds(),
+		ConnectMs: r.connectMs,
+		TTFTMs:    r.ttftMs,
+		TotalMs:   time.Since(r.startedAt).Milliseconds(),
+	}
+}
+
+// isOutputChunk reports whether the chunk carries model output, as opposed to response lifecycle events
+func isOutputChunk(chunk *ResponseChunk) bool {
+	return chunk.OfOutputTextDelta != nil ||
+		chunk.OfFunctionCallArgumentsDelta != nil ||
+		chunk.OfReasoningSummaryTextDelta != nil ||
+		chunk.OfImageGenerationCallInProgress != nil ||
+		chunk.OfWebSearchCallInProgress != nil ||
+		chunk.OfFileSearchCallInProgress != nil ||
+		chunk.OfCodeInterpreterCallInProgress != nil
+}
@@ -0,0 +1,101 @@
+package textsplitter
+
+import (
+	"regexp"
+	"strings"
+)
+
+var headingRe = regexp.MustCompile(`^#{1,6}(\s|$)`)
+
+// MarkdownSplitter splits markdown documents into chunks of their sections, so that a chunk never spans two
+// sections. The sections too long for a chunk are split between their blocks: the paragraphs, lists and tables, split
+// further as prose, and the fenced code blocks, split further as code.
+type MarkdownSplitter struct {
+	*splitter
+}
+
+func NewMarkdownSplitter(opts *Options) *MarkdownSplitter {
+	return &MarkdownSplitter{splitter: newSplitter(opts)}
+}
+
+func (s *MarkdownSplitter) Split(text string) []string {
+	var chunks []string
+
+	for _, section := range markdownSections(text) {
+		var pieces []piece
+		for _, b := range section {
+			if b.code {
+				pieces = append(pieces, s.pieces(b.text, codeSeparators)...)
+			} else {
+				pieces = append(pieces, s.pieces(b.text, proseSeparators)...)
+			}
+		}
+		chunks = append(chunks, s.merge(pieces)...)
+	}
+
+	return chunks
+}
+
+type markdownBlock struct {
+	text string
+	code bool
+}
+
+// markdownSections splits the document at its headings, and the sections at the blank lines and around the fenced
+// code blocks. The headings and blank lines in the code blocks are part of the code.
+func markdownSections(text string) [][]markdownBlock {
+	var sections [][]markdownBlock
+	var blocks []markdownBlock
+	var current strings.Builder
+	code := false
+	fence := ""
+
+	flush := func() {
+		if current.Len() > 0 {
+			blocks = append(blocks, markdownBlock{text: current.String(), code: code})
+			current.Reset()
+		}
+		code = false
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			current.WriteString(line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				flush()
+			}
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			code = true
+			current.WriteString(line)
+
+		case headingRe.MatchString(line):
+			flush()
+			if len(blocks) > 0 {
+				sections = append(sections, blocks)
+				blocks = nil
+			}
+			current.WriteString(line)
+
+		case trimmed == "":
+			current.WriteString(line)
+			flush()
+
+		default:
+			current.WriteString(line)
+		}
+	}
+
+	flush()
+	if len(blocks) > 0 {
+		sections = append(sections, blocks)
+	}
+
+	return sections
+}
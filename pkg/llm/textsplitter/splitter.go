@@ -0,0 +1,218 @@
+// Package textsplitter splits texts into chunks fitting a budget of tokens, e.g. the documents of a knowledge base
+// before they are embedded, or a conversation too long for the model summarizing it. The chunks are measured with
+// the tokenizer of the model they are sent to.
+package textsplitter
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Splitter splits a text into chunks
+type Splitter interface {
+	Split(text string) []string
+}
+
+type Options struct {
+	// Tokenizer measures the chunks (default TokenizerFor the OpenAI models)
+	Tokenizer Tokenizer
+
+	// ChunkSize is the maximum number of tokens of a chunk (default 512)
+	ChunkSize int
+
+	// ChunkOverlap is the number of tokens at the end of a chunk repeated at the start of the next one, so that the
+	// context at the boundaries of the chunks is not lost. The overlap is made of whole pieces of the text, e.g. whole
+	// sentences. It must be lower than ChunkSize (default 0).
+	ChunkOverlap int
+}
+
+// separator splits a text into pieces without dropping any character, the pieces joined are the text
+type separator func(text string) []string
+
+func separateAfter(re *regexp.Regexp) separator {
+	return func(text string) []string {
+		var parts []string
+		start := 0
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[1] > start {
+				parts = append(parts, text[start:loc[1]])
+				start = loc[1]
+			}
+		}
+		if start < len(text) {
+			parts = append(parts, text[start:])
+		}
+		return parts
+	}
+}
+
+var (
+	separateParagraphs = separateAfter(regexp.MustCompile(`\n[ \t]*\n\s*`))
+	separateLines      = separateAfter(regexp.MustCompile(`\n`))
+	separateSentences  = separateAfter(regexp.MustCompile(`[.!?]+["')\]]*\s+|[。！？]+`))
+	separateWords      = separateAfter(regexp.MustCompile(`\s+`))
+)
+
+type piece struct {
+	text   string
+	tokens int
+}
+
+// splitter splits the texts into pieces, finer and finer until they fit a chunk, and merges the pieces into chunks
+type splitter struct {
+	tokenizer Tokenizer
+	size      int
+	overlap   int
+}
+
+func newSplitter(opts *Options) *splitter {
+	s := &splitter{tokenizer: opts.Tokenizer, size: opts.ChunkSize, overlap: opts.ChunkOverlap}
+	if s.tokenizer == nil {
+		s.tokenizer = TokenizerFor("")
+	}
+	if s.size <= 0 {
+		s.size = 512
+	}
+	if s.overlap < 0 || s.overlap >= s.size {
+		s.overlap = 0
+	}
+
+	return s
+}
+
+// pieces splits the text with the first separator, and the pieces still too large for a chunk with the next ones.
+// The pieces left too large, e.g. a long URL, are cut.
+func (s *splitter) pieces(text string, separators []separator) []piece {
+	tokens := s.tokenizer.CountTokens(text)
+	if tokens <= s.size {
+		return []piece{{text: text, tokens: tokens}}
+	}
+
+	if len(separators) == 0 {
+		return s.cut(text, tokens)
+	}
+
+	var out []piece
+	for _, part := range separators[0](text) {
+		out = append(out, s.pieces(part, separators[1:])...)
+	}
+
+	return out
+}
+
+// cut cuts the text into pieces of the chunk size
+func (s *splitter) cut(text string, tokens int) []piece {
+	var out []piece
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		n := max(1, min(len(runes), len(runes)*s.size/max(1, tokens)))
+		count := s.tokenizer.CountTokens(string(runes[:n]))
+		for n > 1 && count > s.size {
+			n = max(1, n*9/10)
+			count = s.tokenizer.CountTokens(string(runes[:n]))
+		}
+
+		out = append(out, piece{text: string(runes[:n]), tokens: count})
+		runes = runes[n:]
+		tokens = s.tokenizer.CountTokens(string(runes))
+	}
+
+	return out
+}
+
+// merge packs the pieces into chunks of the chunk size, each chunk starting with the overlap of the previous one
+func (s *splitter) merge(pieces []piece) []string {
+	var chunks []string
+	var current []piece
+	tokens := 0
+
+	for _, p := range pieces {
+		if len(current) > 0 && tokens+p.tokens > s.size {
+			chunks = appendChunk(chunks, current)
+			current, tokens = s.overlapOf(current, p.tokens)
+		}
+
+		current = append(current, p)
+		tokens += p.tokens
+	}
+
+	return appendChunk(chunks, current)
+}
+
+// overlapOf returns the pieces at the end of the chunk repeated in the next one, leaving room for its first piece
+func (s *splitter) overlapOf(chunk []piece, next int) ([]piece, int) {
+	tokens := 0
+	i := len(chunk)
+	for i > 0 && tokens+chunk[i-1].tokens <= s.overlap && tokens+chunk[i-1].tokens+next <= s.size {
+		i--
+		tokens += chunk[i].tokens
+	}
+
+	return slices.Clone(chunk[i:]), tokens
+}
+
+// appendChunk appends the text of the pieces, without the blank lines and the spaces around it. The indentation of
+// the first line is kept for the code.
+func appendChunk(chunks []string, pieces []piece) []string {
+	var b strings.Builder
+	for _, p := range pieces {
+		b.WriteString(p.text)
+	}
+
+	text := strings.TrimRight(b.String(), " \t\r\n")
+	if i := strings.LastIndex(text[:len(text)-len(strings.TrimLeft(text, " \t\r\n"))], "\n"); i >= 0 {
+		text = text[i+1:]
+	}
+	if strings.TrimSpace(text) == "" {
+		return chunks
+	}
+
+	return append(chunks, text)
+}
+
+// TokenSplitter splits texts into chunks of words, for texts without structure
+type TokenSplitter struct {
+	*splitter
+}
+
+func NewTokenSplitter(opts *Options) *TokenSplitter {
+	return &TokenSplitter{splitter: newSplitter(opts)}
+}
+
+func (s *TokenSplitter) Split(text string) []string {
+	return s.merge(s.pieces(text, []separator{separateWords}))
+}
+
+// SentenceSplitter splits texts into chunks of paragraphs, of lines, or of sentences for the paragraphs too long for
+// a chunk, so that the chunks don't end in the middle of a sentence
+type SentenceSplitter struct {
+	*splitter
+}
+
+func NewSentenceSplitter(opts *Options) *SentenceSplitter {
+	return &SentenceSplitter{splitter: newSplitter(opts)}
+}
+
+func (s *SentenceSplitter) Split(text string) []string {
+	return s.merge(s.pieces(text, proseSeparators))
+}
+
+var proseSeparators = []separator{separateParagraphs, separateLines, separateSentences, separateWords}
+
+// CodeSplitter splits source code into chunks of the blocks separated by blank lines, e.g. functions, or of lines
+// for the blocks too long for a chunk
+type CodeSplitter struct {
+	*splitter
+}
+
+func NewCodeSplitter(opts *Options) *CodeSplitter {
+	return &CodeSplitter{splitter: newSplitter(opts)}
+}
+
+func (s *CodeSplitter) Split(text string) []string {
+	return s.merge(s.pieces(text, codeSeparators))
+}
+
+var codeSeparators = []separator{separateParagraphs, separateLines, separateWords}
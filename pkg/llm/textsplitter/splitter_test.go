@@ -0,0 +1,79 @@
+package textsplitter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/curaious/uno/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// words counts a token per word, so that the sizes of the chunks are easy to read
+var words = TokenizerFunc(func(text string) int {
+	return len(strings.Fields(text))
+})
+
+func TestTokenSplitter(t *testing.T) {
+	s := NewTokenSplitter(&Options{Tokenizer: words, ChunkSize: 4, ChunkOverlap: 1})
+
+	chunks := s.Split("one two three four five six seven")
+
+	assert.Equal(t, []string{"one two three four", "four five six seven"}, chunks)
+}
+
+func TestTokenSplitter_Cut(t *testing.T) {
+	s := NewTokenSplitter(&Options{Tokenizer: EstimatingTokenizer{CharsPerToken: 1}, ChunkSize: 4})
+
+	chunks := s.Split("abcdefghij")
+
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, chunks)
+}
+
+func TestSentenceSplitter(t *testing.T) {
+	s := NewSentenceSplitter(&Options{Tokenizer: words, ChunkSize: 8})
+
+	text := "The cat sat. It was on the mat. Then it left.\n\nA short paragraph."
+	chunks := s.Split(text)
+
+	assert.Equal(t, []string{"The cat sat. It was on the mat.", "Then it left.\n\nA short paragraph."}, chunks)
+
+	// A text fitting a chunk is kept whole, without the blank lines around it
+	assert.Equal(t, []string{"A short paragraph."}, s.Split("\n\nA short paragraph.\n"))
+}
+
+func TestMarkdownSplitter(t *testing.T) {
+	s := NewMarkdownSplitter(&Options{Tokenizer: words, ChunkSize: 16})
+
+	text := "# Install\n\nRun the installer.\n\n```sh\n# not a heading\nmake install\n```\n\n# Usage\n\nRun it.\n"
+	chunks := s.Split(text)
+
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "# Install\n\nRun the installer.\n\n```sh\n# not a heading\nmake install\n```", chunks[0])
+	assert.Equal(t, "# Usage\n\nRun it.", chunks[1])
+
+	// A section too long is split between its blocks, the code block is kept whole
+	s = NewMarkdownSplitter(&Options{Tokenizer: words, ChunkSize: 8})
+	chunks = s.Split(text)
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "# Install\n\nRun the installer.", chunks[0])
+	assert.Equal(t, "```sh\n# not a heading\nmake install\n```", chunks[1])
+}
+
+func TestCodeSplitter(t *testing.T) {
+	s := NewCodeSplitter(&Options{Tokenizer: words, ChunkSize: 6})
+
+	text := "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n"
+	chunks := s.Split(text)
+
+	assert.Equal(t, []string{"func a() {\n\treturn 1\n}", "func b() {\n\treturn 2\n}"}, chunks)
+}
+
+func TestEstimatingTokenizer(t *testing.T) {
+	tokenizer := TokenizerFor(llm.ProviderNameOpenAI)
+
+	assert.Equal(t, 3, tokenizer.CountTokens("hello world!"))
+	assert.Equal(t, 4, tokenizer.CountTokens("日本語は"))
+	assert.Equal(t, 0, tokenizer.CountTokens(""))
+}
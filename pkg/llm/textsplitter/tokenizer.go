@@ -0,0 +1,54 @@
+package textsplitter
+
+import (
+	"math"
+	"unicode"
+
+	"github.com/curaious/uno/pkg/llm"
+)
+
+// Tokenizer counts the tokens of a text for a model. The splitters measure the chunks with it, so that they match the
+// budget of the model they are sent to.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc is a function counting tokens, e.g. the encoder of a tokenizer library
+type TokenizerFunc func(text string) int
+
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// EstimatingTokenizer estimates the tokens of a text from its characters, without the vocabulary of the model. The
+// ideographs and kana are a token each, the tokenizers rarely merge them.
+type EstimatingTokenizer struct {
+	// CharsPerToken is the average number of characters of a token in alphabetic text
+	CharsPerToken float64
+}
+
+func (t EstimatingTokenizer) CountTokens(text string) int {
+	chars, ideographs := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			ideographs++
+		} else {
+			chars++
+		}
+	}
+
+	return ideographs + int(math.Ceil(float64(chars)/t.CharsPerToken))
+}
+
+// TokenizerFor returns the tokenizer of the models of the provider. The counts are estimates calibrated on the
+// vocabularies of the models, plug an exact tokenizer with TokenizerFunc when the counts must match the bills.
+func TokenizerFor(provider llm.ProviderName) Tokenizer {
+	switch provider {
+	// The vocabularies of Claude and of the open models (Llama, Mistral, Qwen) are smaller than the ones of GPT
+	case llm.ProviderNameAnthropic, llm.ProviderNameBedrock, llm.ProviderNameMistral, llm.ProviderNameOllama,
+		llm.ProviderNameVLLM, llm.ProviderNameTGI, llm.ProviderNameHuggingFace:
+		return EstimatingTokenizer{CharsPerToken: 3.5}
+	}
+
+	return EstimatingTokenizer{CharsPerToken: 4}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/bytedance/sonic"
 	"github.com/curaious/uno/internal/utils"
+	"github.com/curaious/uno/pkg/gateway"
 	"github.com/curaious/uno/pkg/llm"
 	"github.com/curaious/uno/pkg/llm/chat_completion"
 	"github.com/curaious/uno/pkg/llm/embeddings"
@@ -22,20 +23,44 @@ import (
 // ExternalLLMGateway calls the agent-server's gateway API via HTTP.
 // Use this when you're an SDK consumer calling the agent-server remotely.
 type ExternalLLMGateway struct {
-	endpoint   string
-	virtualKey string
-	httpClient *http.Client
+	endpoint      string
+	virtualKey    string
+	httpClient    *http.Client
+	interceptors  []gateway.RequestInterceptor
+	signingSecret string
 }
 
-// NewExternalLLMGateway creates a provider that calls agent-server via HTTP.
-func NewExternalLLMGateway(endpoint, virtualKey string) *ExternalLLMGateway {
+// NewExternalLLMGateway creates a provider that calls agent-server via HTTP. The interceptors are applied to the
+// requests to the agent-server, whose body is in the format of the gateway API.
+func NewExternalLLMGateway(endpoint, virtualKey string, interceptors ...gateway.RequestInterceptor) *ExternalLLMGateway {
 	return &ExternalLLMGateway{
-		endpoint:   strings.TrimSuffix(endpoint, "/"),
-		virtualKey: virtualKey,
-		httpClient: &http.Client{},
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		virtualKey:   virtualKey,
+		httpClient:   &http.Client{},
+		interceptors: interceptors,
 	}
 }
 
+// WithHTTPClient sets the HTTP client of the requests to the agent-server, e.g. one going through an egress proxy
+func (p *ExternalLLMGateway) WithHTTPClient(client *http.Client) *ExternalLLMGateway {
+	if client != nil {
+		p.httpClient = client
+	}
+	return p
+}
+
+// WithSigningSecret signs the requests to the agent-server with the signing secret of the virtual key
+func (p *ExternalLLMGateway) WithSigningSecret(secret string) *ExternalLLMGateway {
+	p.signingSecret = secret
+	return p
+}
+
+// client returns the HTTP client for the requests of the provider. The requests are signed after the interceptors,
+// so that the signature covers their changes.
+func (p *ExternalLLMGateway) client(providerName llm.ProviderName) *http.Client {
+	return gateway.NewInterceptingClient(gateway.NewSigningClient(p.httpClient, p.signingSecret), providerName, p.interceptors)
+}
+
 func (p *ExternalLLMGateway) NewResponses(ctx context.Context, providerName llm.ProviderName, req *responses.Request) (*responses.Response, error) {
 	// Prepend provider to model for gateway routing
 	originalModel := req.Model
@@ -61,7 +86,7 @@ func (p *ExternalLLMGateway) NewResponses(ctx context.Context, providerName llm.
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -110,7 +135,7 @@ func (p *ExternalLLMGateway) NewStreamingResponses(ctx context.Context, provider
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -150,7 +175,12 @@ func (p *ExternalLLMGateway) NewStreamingResponses(ctx context.Context, provider
 				continue
 			}
 
-			out <- &chunk
+			// The caller stopped reading, the request is aborted with the context
+			select {
+			case out <- &chunk:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
@@ -182,7 +212,7 @@ func (p *ExternalLLMGateway) NewEmbedding(ctx context.Context, providerName llm.
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -227,7 +257,7 @@ func (p *ExternalLLMGateway) NewChatCompletion(ctx context.Context, providerName
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -276,7 +306,7 @@ func (p *ExternalLLMGateway) NewStreamingChatCompletion(ctx context.Context, pro
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -316,7 +346,12 @@ func (p *ExternalLLMGateway) NewStreamingChatCompletion(ctx context.Context, pro
 				continue
 			}
 
-			out <- &chunk
+			// The caller stopped reading, the request is aborted with the context
+			select {
+			case out <- &chunk:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
@@ -348,7 +383,7 @@ func (p *ExternalLLMGateway) NewSpeech(ctx context.Context, providerName llm.Pro
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -394,7 +429,7 @@ func (p *ExternalLLMGateway) NewStreamingSpeech(ctx context.Context, providerNam
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("x-virtual-key", p.virtualKey)
 
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := p.client(providerName).Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -434,7 +469,12 @@ func (p *ExternalLLMGateway) NewStreamingSpeech(ctx context.Context, providerNam
 				continue
 			}
 
-			out <- &chunk
+			// The caller stopped reading, the request is aborted with the context
+			select {
+			case out <- &chunk:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 
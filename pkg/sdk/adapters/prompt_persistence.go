@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/curaious/uno/internal/integrations"
 	"github.com/curaious/uno/internal/services/prompt"
@@ -18,6 +19,11 @@ type ExternalPromptPersistence struct {
 	projectID uuid.UUID
 	name      string
 	label     string
+
+	// The last template loaded and its etag, the prompt is only downloaded again when it changed
+	mu       sync.Mutex
+	etag     string
+	template string
 }
 
 func NewExternalPromptPersistence(endpoint string, projectID uuid.UUID, name string, label string) *ExternalPromptPersistence {
@@ -33,17 +39,39 @@ func (p *ExternalPromptPersistence) LoadPrompt(ctx context.Context) (string, err
 	// Read the prompt from file
 	url := fmt.Sprintf("%s/api/agent-server/prompts/%s/label/%s?project_id=%s", p.Endpoint, p.name, p.label, p.projectID)
 
-	resp, err := http.DefaultClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	etag, template := p.etag, p.template
+	p.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return template, nil
+	}
+
 	data := Response[prompt.PromptVersionWithPrompt]{}
 	if err := utils.DecodeJSON(resp.Body, &data); err != nil {
 		return "", err
 	}
 
+	if etag := resp.Header.Get("ETag"); etag != "" && resp.StatusCode == http.StatusOK {
+		p.mu.Lock()
+		p.etag, p.template = etag, data.Data.Template
+		p.mu.Unlock()
+	}
+
 	return data.Data.Template, nil
 }
 
@@ -33,6 +33,30 @@ type AgentOptions struct {
 	Instruction core.SystemPromptProvider
 	McpServers  []agents.MCPToolset
 	MaxLoops    *int
+
+	// ChunkPipeline transforms the chunks streamed to the caller
+	ChunkPipeline *responses.ChunkPipeline
+
+	// DisableArgumentRepair asks the model to retry malformed tool call arguments instead of repairing them
+	DisableArgumentRepair bool
+
+	// Provenance labels every output message of the model with the model, provider and run that generated it
+	Provenance bool
+
+	// Translation translates the messages of the user to the working language of the agent, and the answers back
+	Translation *agents.TranslationOptions
+
+	// ProviderPinning keeps the conversations with the provider, or the model, of their runs, see
+	// agents.AgentOptions.ProviderPinning
+	ProviderPinning core.ProviderPinning
+
+	// DurableApprovals keeps the runs of agents created with NewRestateAgent waiting for the approval of tool calls,
+	// for as long as it takes, instead of ending them when they pause. The approval is given with ResolveApproval.
+	DurableApprovals bool
+
+	// Runtime executes the runs of agents created with NewAgent, e.g. agents.NewPooledRuntime.
+	// Defaults to running inline. NewRestateAgent and NewTemporalAgent set their own runtime.
+	Runtime agents.AgentRuntime
 }
 
 func (c *SDK) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -63,8 +87,17 @@ func (c *SDK) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 
+	// With ?envelope=true the chunks are wrapped like on the streams of the agent server
+	envelope := r.URL.Query().Get("envelope") == "true"
+	sequencer := &core.RunEventSequencer{}
+
 	payload.Callback = func(chunk *responses.ResponseChunk) {
-		buf, err := sonic.Marshal(chunk)
+		var data any = chunk
+		if envelope {
+			data = core.NewRunEventEnvelope(sequencer.Next(chunk), chunk)
+		}
+
+		buf, err := sonic.Marshal(data)
 		if err != nil {
 			return
 		}
@@ -83,14 +116,20 @@ func (c *SDK) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (c *SDK) NewAgent(options *AgentOptions) *agents.Agent {
 	agent := agents.NewAgent(&agents.AgentOptions{
-		Name:        options.Name,
-		LLM:         options.LLM,
-		History:     options.History,
-		Parameters:  options.Parameters,
-		Output:      options.Output,
-		Tools:       options.Tools,
-		Instruction: options.Instruction,
-		McpServers:  options.McpServers,
+		Name:                  options.Name,
+		LLM:                   options.LLM,
+		History:               options.History,
+		Parameters:            options.Parameters,
+		Output:                options.Output,
+		Tools:                 options.Tools,
+		Instruction:           options.Instruction,
+		McpServers:            options.McpServers,
+		ChunkPipeline:         options.ChunkPipeline,
+		DisableArgumentRepair: options.DisableArgumentRepair,
+		Provenance:            options.Provenance,
+		ProviderPinning:       options.ProviderPinning,
+		Translation:           options.Translation,
+		Runtime:               options.Runtime,
 	})
 
 	c.agents[options.Name] = agent
@@ -100,34 +139,51 @@ func (c *SDK) NewAgent(options *AgentOptions) *agents.Agent {
 
 func (c *SDK) NewRestateAgent(options *AgentOptions) *agents.Agent {
 	agent := agents.NewAgent(&agents.AgentOptions{
-		Name:        options.Name,
-		LLM:         options.LLM,
-		History:     options.History,
-		Parameters:  options.Parameters,
-		Output:      options.Output,
-		Tools:       options.Tools,
-		Instruction: options.Instruction,
-		McpServers:  options.McpServers,
-		Runtime:     restate_runtime.NewRestateRuntime(c.restateConfig.Endpoint, c.redisBroker),
-		MaxLoops:    options.MaxLoops,
+		Name:                  options.Name,
+		LLM:                   options.LLM,
+		History:               options.History,
+		Parameters:            options.Parameters,
+		Output:                options.Output,
+		Tools:                 options.Tools,
+		Instruction:           options.Instruction,
+		McpServers:            options.McpServers,
+		ChunkPipeline:         options.ChunkPipeline,
+		DisableArgumentRepair: options.DisableArgumentRepair,
+		Provenance:            options.Provenance,
+		ProviderPinning:       options.ProviderPinning,
+		Translation:           options.Translation,
+		Runtime:               restate_runtime.NewRestateRuntime(c.restateConfig.Endpoint, c.redisBroker),
+		MaxLoops:              options.MaxLoops,
 	})
 
 	c.agents[options.Name] = agent
 	c.restateAgentConfigs[options.Name] = &agents.AgentOptions{
-		Name:        options.Name,
-		LLM:         options.LLM,
-		History:     options.History,
-		Parameters:  options.Parameters,
-		Output:      options.Output,
-		Tools:       options.Tools,
-		Instruction: options.Instruction,
-		McpServers:  options.McpServers,
-		MaxLoops:    options.MaxLoops,
+		Name:                  options.Name,
+		LLM:                   options.LLM,
+		History:               options.History,
+		Parameters:            options.Parameters,
+		Output:                options.Output,
+		Tools:                 options.Tools,
+		Instruction:           options.Instruction,
+		McpServers:            options.McpServers,
+		ChunkPipeline:         options.ChunkPipeline,
+		DisableArgumentRepair: options.DisableArgumentRepair,
+		Provenance:            options.Provenance,
+		ProviderPinning:       options.ProviderPinning,
+		Translation:           options.Translation,
+		MaxLoops:              options.MaxLoops,
+		DurableApprovals:      options.DurableApprovals,
 	}
 
 	return agent
 }
 
+// ResolveApproval gives the decision on the tool calls of a run of a Restate agent with durable approvals, with the
+// approval ID of its run.paused chunk. The run resumes where it stopped.
+func (c *SDK) ResolveApproval(ctx context.Context, approvalID string, approval *responses.FunctionCallApprovalResponseMessage) error {
+	return restate_runtime.ResolveApproval(ctx, c.restateConfig.Endpoint, approvalID, approval)
+}
+
 func (c *SDK) StartRestateService(host, port string) {
 	wf := restate_runtime.NewRestateWorkflow(c.restateAgentConfigs, c.redisBroker)
 
@@ -142,28 +198,38 @@ func (c *SDK) StartRestateService(host, port string) {
 
 func (c *SDK) NewTemporalAgent(options *AgentOptions) *agents.Agent {
 	agent := agents.NewAgent(&agents.AgentOptions{
-		Name:        options.Name,
-		LLM:         options.LLM,
-		History:     options.History,
-		Parameters:  options.Parameters,
-		Output:      options.Output,
-		Tools:       options.Tools,
-		Instruction: options.Instruction,
-		McpServers:  options.McpServers,
-		Runtime:     temporal_runtime.NewTemporalRuntime(c.temporalConfig.Endpoint, c.redisBroker),
-		MaxLoops:    options.MaxLoops,
+		Name:                  options.Name,
+		LLM:                   options.LLM,
+		History:               options.History,
+		Parameters:            options.Parameters,
+		Output:                options.Output,
+		Tools:                 options.Tools,
+		Instruction:           options.Instruction,
+		McpServers:            options.McpServers,
+		ChunkPipeline:         options.ChunkPipeline,
+		DisableArgumentRepair: options.DisableArgumentRepair,
+		Provenance:            options.Provenance,
+		ProviderPinning:       options.ProviderPinning,
+		Translation:           options.Translation,
+		Runtime:               temporal_runtime.NewTemporalRuntime(c.temporalConfig.Endpoint, c.redisBroker),
+		MaxLoops:              options.MaxLoops,
 	})
 
 	c.agents[options.Name] = agent
 	c.temporalAgentConfigs[options.Name] = &agents.AgentOptions{
-		Name:        options.Name,
-		LLM:         options.LLM,
-		History:     options.History,
-		Parameters:  options.Parameters,
-		Output:      options.Output,
-		Tools:       options.Tools,
-		Instruction: options.Instruction,
-		McpServers:  options.McpServers,
+		Name:                  options.Name,
+		LLM:                   options.LLM,
+		History:               options.History,
+		Parameters:            options.Parameters,
+		Output:                options.Output,
+		Tools:                 options.Tools,
+		Instruction:           options.Instruction,
+		McpServers:            options.McpServers,
+		ChunkPipeline:         options.ChunkPipeline,
+		DisableArgumentRepair: options.DisableArgumentRepair,
+		Provenance:            options.Provenance,
+		ProviderPinning:       options.ProviderPinning,
+		Translation:           options.Translation,
 	}
 
 	return agent
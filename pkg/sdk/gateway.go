@@ -24,10 +24,16 @@ func (c *SDK) NewLLM(opts LLMOptions) llm.Provider {
 
 func (c *SDK) getGatewayAdapter(providerName llm.ProviderName) gateway.LLMGatewayAdapter {
 	if c.directMode {
-		return internal_adapters.NewInternalLLMGateway(gateway.NewLLMGateway(c.llmConfigs), getKey(c.llmConfigs, providerName))
+		llmGateway := gateway.NewLLMGateway(c.llmConfigs)
+		llmGateway.UseHTTPConfig(c.httpConfig)
+		llmGateway.UseRequestInterceptor(c.requestInterceptors...)
+		llmGateway.UseFaultInjection(c.faults)
+		return internal_adapters.NewInternalLLMGateway(llmGateway, getKey(c.llmConfigs, providerName))
 	}
 
-	return adapters.NewExternalLLMGateway(c.endpoint, c.virtualKey)
+	return adapters.NewExternalLLMGateway(c.endpoint, c.virtualKey, c.requestInterceptors...).
+		WithHTTPClient(c.httpClient).
+		WithSigningSecret(c.signingSecret)
 }
 
 func getKey(cfgStore gateway.ConfigStore, providerName llm.ProviderName) string {
@@ -49,6 +49,25 @@ func (w *AgentWorkflow) Run(restateCtx restate.WorkflowContext, input *WorkflowI
 	}
 	conversationHistory := history.NewConversationManager(conversationPersistenceProxy, options...)
 
+	var translation *agents.TranslationOptions
+	if agentOptions.Translation != nil {
+		translation = &agents.TranslationOptions{
+			Translator: NewRestateTranslator(restateCtx, agentOptions.Translation.Translator),
+			Language:   agentOptions.Translation.Language,
+		}
+	}
+
+	var responsePolicy *agents.ResponsePolicyOptions
+	if agentOptions.ResponsePolicy != nil {
+		responsePolicy = &agents.ResponsePolicyOptions{
+			Policy:       agentOptions.ResponsePolicy.Policy,
+			MaxRevisions: agentOptions.ResponsePolicy.MaxRevisions,
+		}
+		if agentOptions.ResponsePolicy.Judge != nil {
+			responsePolicy.Judge = NewRestateResponseJudge(restateCtx, agentOptions.ResponsePolicy.Judge)
+		}
+	}
+
 	var restateTools []core.Tool
 	for _, tool := range agentOptions.Tools {
 		restateTools = append(restateTools, NewRestateTool(restateCtx, tool))
@@ -59,12 +78,24 @@ func (w *AgentWorkflow) Run(restateCtx restate.WorkflowContext, input *WorkflowI
 		mcpClients = append(mcpClients, NewRestateMCPServer(restateCtx, mcpClient))
 	}
 
+	var approvalGate core.ApprovalGate
+	if agentOptions.DurableApprovals {
+		approvalGate = NewRestateApprovalGate(restateCtx)
+	}
+
 	agent := agents.NewAgent(&agents.AgentOptions{
 		Name:       agentOptions.Name,
 		Output:     agentOptions.Output,
 		Parameters: agentOptions.Parameters,
 		MaxLoops:   agentOptions.MaxLoops,
 
+		ChunkPipeline:         agentOptions.ChunkPipeline,
+		DisableArgumentRepair: agentOptions.DisableArgumentRepair,
+		Provenance:            agentOptions.Provenance,
+		Translation:           translation,
+		ResponsePolicy:        responsePolicy,
+		ApprovalGate:          approvalGate,
+
 		Instruction: promptProxy,
 		History:     conversationHistory,
 		Tools:       restateTools,
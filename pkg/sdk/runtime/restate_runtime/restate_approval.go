@@ -0,0 +1,69 @@
+package restate_runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"github.com/curaious/uno/pkg/llm/responses"
+	restate "github.com/restatedev/sdk-go"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// RestateApprovalGate keeps the runs paused for approval waiting on a Restate awakeable, the workflow is suspended
+// until the awakeable is resolved with the decision, for hours or days
+type RestateApprovalGate struct {
+	restateCtx restate.Context
+}
+
+func NewRestateApprovalGate(restateCtx restate.Context) *RestateApprovalGate {
+	return &RestateApprovalGate{
+		restateCtx: restateCtx,
+	}
+}
+
+// RequestApproval creates the awakeable of the decision, its ID is the ID of the approval
+func (g *RestateApprovalGate) RequestApproval(ctx context.Context, runID string, toolCalls []responses.FunctionCallMessage) (string, func() (*responses.FunctionCallApprovalResponseMessage, error), error) {
+	awakeable := restate.Awakeable[*responses.FunctionCallApprovalResponseMessage](g.restateCtx)
+
+	return awakeable.Id(), awakeable.Result, nil
+}
+
+// ResolveApproval gives the decision on the tool calls of a run waiting on a Restate approval gate, through the
+// ingress of the Restate server at the endpoint
+func ResolveApproval(ctx context.Context, endpoint string, approvalID string, approval *responses.FunctionCallApprovalResponseMessage) error {
+	body, err := sonic.Marshal(approval)
+	if err != nil {
+		return fmt.Errorf("failed to serialize approval: %w", err)
+	}
+
+	u := strings.TrimSuffix(endpoint, "/") + "/restate/awakeables/" + url.PathEscape(approvalID) + "/resolve"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to resolve approval: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to resolve approval: restate answered %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// Ensure RestateApprovalGate implements ApprovalGate
+var _ core.ApprovalGate = (*RestateApprovalGate)(nil)
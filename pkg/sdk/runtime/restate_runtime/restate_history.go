@@ -10,11 +10,11 @@ import (
 )
 
 type RestateHistory struct {
-	restateCtx         restate.WorkflowContext
+	restateCtx         restate.Context
 	wrappedPersistence history.ConversationPersistenceAdapter
 }
 
-func NewRestateConversationPersistence(restateCtx restate.WorkflowContext, wrappedPersistence history.ConversationPersistenceAdapter) *RestateHistory {
+func NewRestateConversationPersistence(restateCtx restate.Context, wrappedPersistence history.ConversationPersistenceAdapter) *RestateHistory {
 	return &RestateHistory{
 		restateCtx:         restateCtx,
 		wrappedPersistence: wrappedPersistence,
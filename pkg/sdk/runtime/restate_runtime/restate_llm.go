@@ -10,17 +10,31 @@ import (
 )
 
 type RestateLLM struct {
-	restateCtx restate.WorkflowContext
+	restateCtx restate.Context
 	wrappedLLM llm.Provider
 }
 
-func NewRestateLLM(restateCtx restate.WorkflowContext, wrappedLLM llm.Provider) agents.LLM {
+func NewRestateLLM(restateCtx restate.Context, wrappedLLM llm.Provider) agents.LLM {
 	return &RestateLLM{
 		restateCtx: restateCtx,
 		wrappedLLM: wrappedLLM,
 	}
 }
 
+func (l *RestateLLM) ProviderName() llm.ProviderName {
+	if id, ok := l.wrappedLLM.(agents.IdentifiedLLM); ok {
+		return id.ProviderName()
+	}
+	return ""
+}
+
+func (l *RestateLLM) ModelName() string {
+	if id, ok := l.wrappedLLM.(agents.IdentifiedLLM); ok {
+		return id.ModelName()
+	}
+	return ""
+}
+
 func (l *RestateLLM) NewStreamingResponses(ctx context.Context, in *responses.Request, cb func(chunk *responses.ResponseChunk)) (*responses.Response, error) {
 	return restate.Run(l.restateCtx, func(ctx restate.RunContext) (*responses.Response, error) {
 		stream, err := l.wrappedLLM.NewStreamingResponses(ctx, in)
@@ -29,7 +43,7 @@ func (l *RestateLLM) NewStreamingResponses(ctx context.Context, in *responses.Re
 		}
 
 		acc := agents.Accumulator{}
-		resp, err := acc.ReadStream(stream, func(chunk *responses.ResponseChunk) {
+		resp, err := acc.ReadStream(ctx, stream, func(chunk *responses.ResponseChunk) {
 			cb(chunk)
 		})
 		if err != nil {
@@ -11,11 +11,11 @@ import (
 )
 
 type RestateMCPServer struct {
-	restateCtx       restate.WorkflowContext
+	restateCtx       restate.Context
 	wrappedMcpServer agents.MCPToolset
 }
 
-func NewRestateMCPServer(restateCtx restate.WorkflowContext, wrappedMcpServer agents.MCPToolset) *RestateMCPServer {
+func NewRestateMCPServer(restateCtx restate.Context, wrappedMcpServer agents.MCPToolset) *RestateMCPServer {
 	return &RestateMCPServer{
 		restateCtx:       restateCtx,
 		wrappedMcpServer: wrappedMcpServer,
@@ -23,7 +23,7 @@ func NewRestateMCPServer(restateCtx restate.WorkflowContext, wrappedMcpServer ag
 }
 
 func (t *RestateMCPServer) GetName() string {
-	return ""
+	return t.wrappedMcpServer.GetName()
 }
 
 func (t *RestateMCPServer) ListTools(ctx context.Context, runContext map[string]any) ([]core.Tool, error) {
@@ -57,13 +57,13 @@ func (t *RestateMCPServer) ListTools(ctx context.Context, runContext map[string]
 }
 
 type RestateMCPTool struct {
-	restateCtx       restate.WorkflowContext
+	restateCtx       restate.Context
 	runContext       map[string]any
 	wrappedMcpServer agents.MCPToolset
 	*core.BaseTool
 }
 
-func NewRestateMCPTool(restateCtx restate.WorkflowContext, wrappedMcpServer agents.MCPToolset, runContext map[string]any, baseTool core.BaseTool) *RestateMCPTool {
+func NewRestateMCPTool(restateCtx restate.Context, wrappedMcpServer agents.MCPToolset, runContext map[string]any, baseTool core.BaseTool) *RestateMCPTool {
 	return &RestateMCPTool{
 		restateCtx:       restateCtx,
 		runContext:       runContext,
@@ -73,7 +73,7 @@ func NewRestateMCPTool(restateCtx restate.WorkflowContext, wrappedMcpServer agen
 }
 
 func (t *RestateMCPTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	return restate.Run(t.restateCtx, func(ctx restate.RunContext) (*responses.FunctionCallOutputMessage, error) {
+	result, err := restate.Run(t.restateCtx, func(ctx restate.RunContext) (*core.ToolResult, error) {
 		mcpTools, err := t.wrappedMcpServer.ListTools(ctx, t.runContext)
 		if err != nil {
 			return nil, err
@@ -81,10 +81,15 @@ func (t *RestateMCPTool) Execute(ctx context.Context, params *core.ToolCall) (*r
 
 		for _, tool := range mcpTools {
 			if t := tool.Tool(ctx); t != nil && t.OfFunction != nil && params.Name == t.OfFunction.Name {
-				return tool.Execute(ctx, params)
+				return core.ExecuteTool(ctx, tool, params)
 			}
 		}
 
 		return nil, fmt.Errorf("no restate tool found with name %s", params.Name)
 	}, restate.WithName("MCPToolCall"))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Unwrap()
 }
@@ -0,0 +1,23 @@
+package restate_runtime
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+)
+
+// Restate suspends the invocations waiting on the journal, and aborts them on protocol errors, by panicking with its
+// own values, the agent runs must let those panics through to the SDK
+func init() {
+	core.RegisterRuntimePanic(isRestatePanic)
+}
+
+func isRestatePanic(p any) bool {
+	t := reflect.TypeOf(p)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t != nil && strings.HasPrefix(t.PkgPath(), "github.com/restatedev/sdk-go")
+}
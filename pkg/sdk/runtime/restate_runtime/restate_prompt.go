@@ -8,11 +8,11 @@ import (
 )
 
 type RestatePrompt struct {
-	restateCtx    restate.WorkflowContext
+	restateCtx    restate.Context
 	wrappedPrompt core.SystemPromptProvider
 }
 
-func NewRestatePrompt(restateCtx restate.WorkflowContext, instruction core.SystemPromptProvider) core.SystemPromptProvider {
+func NewRestatePrompt(restateCtx restate.Context, instruction core.SystemPromptProvider) core.SystemPromptProvider {
 	return &RestatePrompt{
 		restateCtx:    restateCtx,
 		wrappedPrompt: instruction,
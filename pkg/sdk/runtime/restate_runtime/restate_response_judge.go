@@ -0,0 +1,26 @@
+package restate_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	restate "github.com/restatedev/sdk-go"
+)
+
+type RestateResponseJudge struct {
+	restateCtx   restate.Context
+	wrappedJudge core.ResponseJudge
+}
+
+func NewRestateResponseJudge(restateCtx restate.Context, wrappedJudge core.ResponseJudge) *RestateResponseJudge {
+	return &RestateResponseJudge{
+		restateCtx:   restateCtx,
+		wrappedJudge: wrappedJudge,
+	}
+}
+
+func (j *RestateResponseJudge) Judge(ctx context.Context, text string, policy core.ResponsePolicy) (*core.ResponseJudgement, error) {
+	return restate.Run(j.restateCtx, func(ctx restate.RunContext) (*core.ResponseJudgement, error) {
+		return j.wrappedJudge.Judge(ctx, text, policy)
+	})
+}
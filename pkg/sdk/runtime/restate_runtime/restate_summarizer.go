@@ -9,11 +9,11 @@ import (
 )
 
 type RestateConversationSummarizer struct {
-	restateCtx        restate.WorkflowContext
+	restateCtx        restate.Context
 	wrappedSummarizer core.HistorySummarizer
 }
 
-func NewRestateConversationSummarizer(restateCtx restate.WorkflowContext, wrappedSummarizer core.HistorySummarizer) *RestateConversationSummarizer {
+func NewRestateConversationSummarizer(restateCtx restate.Context, wrappedSummarizer core.HistorySummarizer) *RestateConversationSummarizer {
 	return &RestateConversationSummarizer{
 		restateCtx:        restateCtx,
 		wrappedSummarizer: wrappedSummarizer,
@@ -0,0 +1,27 @@
+package restate_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	restate "github.com/restatedev/sdk-go"
+)
+
+type RestateToolDeadLetterStore struct {
+	restateCtx   restate.Context
+	wrappedStore core.ToolDeadLetterStore
+}
+
+func NewRestateToolDeadLetterStore(restateCtx restate.Context, wrappedStore core.ToolDeadLetterStore) *RestateToolDeadLetterStore {
+	return &RestateToolDeadLetterStore{
+		restateCtx:   restateCtx,
+		wrappedStore: wrappedStore,
+	}
+}
+
+func (s *RestateToolDeadLetterStore) Record(ctx context.Context, letter *core.ToolDeadLetter) error {
+	_, err := restate.Run(s.restateCtx, func(ctx restate.RunContext) (any, error) {
+		return nil, s.wrappedStore.Record(ctx, letter)
+	}, restate.WithName("RecordToolDeadLetter"))
+	return err
+}
@@ -0,0 +1,40 @@
+package restate_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	restate "github.com/restatedev/sdk-go"
+)
+
+type RestateToolQuotaCounter struct {
+	restateCtx     restate.Context
+	wrappedCounter core.ToolQuotaCounter
+}
+
+func NewRestateToolQuotaCounter(restateCtx restate.Context, wrappedCounter core.ToolQuotaCounter) *RestateToolQuotaCounter {
+	return &RestateToolQuotaCounter{
+		restateCtx:     restateCtx,
+		wrappedCounter: wrappedCounter,
+	}
+}
+
+// toolQuotaConsumption is the journaled result of Consume
+type toolQuotaConsumption struct {
+	Used int  `json:"used"`
+	OK   bool `json:"ok"`
+}
+
+func (c *RestateToolQuotaCounter) Consume(ctx context.Context, quota core.ToolQuota, scopeKey string, n int) (int, bool, error) {
+	out, err := restate.Run(c.restateCtx, func(ctx restate.RunContext) (toolQuotaConsumption, error) {
+		used, ok, err := c.wrappedCounter.Consume(ctx, quota, scopeKey, n)
+		return toolQuotaConsumption{Used: used, OK: ok}, err
+	}, restate.WithName("ConsumeToolQuota"))
+	return out.Used, out.OK, err
+}
+
+func (c *RestateToolQuotaCounter) Used(ctx context.Context, quota core.ToolQuota, scopeKey string) (int, error) {
+	return restate.Run(c.restateCtx, func(ctx restate.RunContext) (int, error) {
+		return c.wrappedCounter.Used(ctx, quota, scopeKey)
+	}, restate.WithName("ToolQuotaUsed"))
+}
@@ -9,11 +9,11 @@ import (
 )
 
 type RestateTool struct {
-	restateCtx  restate.WorkflowContext
+	restateCtx  restate.Context
 	wrappedTool core.Tool
 }
 
-func NewRestateTool(restateCtx restate.WorkflowContext, wrappedTool core.Tool) *RestateTool {
+func NewRestateTool(restateCtx restate.Context, wrappedTool core.Tool) *RestateTool {
 	return &RestateTool{
 		restateCtx:  restateCtx,
 		wrappedTool: wrappedTool,
@@ -21,9 +21,14 @@ func NewRestateTool(restateCtx restate.WorkflowContext, wrappedTool core.Tool) *
 }
 
 func (t *RestateTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	return restate.Run(t.restateCtx, func(ctx restate.RunContext) (*responses.FunctionCallOutputMessage, error) {
-		return t.wrappedTool.Execute(ctx, params)
+	result, err := restate.Run(t.restateCtx, func(ctx restate.RunContext) (*core.ToolResult, error) {
+		return core.ExecuteTool(ctx, t.wrappedTool, params)
 	}, restate.WithName(params.Name+"_ToolCall"))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Unwrap()
 }
 
 func (t *RestateTool) Tool(ctx context.Context) *responses.ToolUnion {
@@ -0,0 +1,26 @@
+package restate_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	restate "github.com/restatedev/sdk-go"
+)
+
+type RestateTranslator struct {
+	restateCtx        restate.Context
+	wrappedTranslator core.Translator
+}
+
+func NewRestateTranslator(restateCtx restate.Context, wrappedTranslator core.Translator) *RestateTranslator {
+	return &RestateTranslator{
+		restateCtx:        restateCtx,
+		wrappedTranslator: wrappedTranslator,
+	}
+}
+
+func (t *RestateTranslator) Translate(ctx context.Context, text string, from string, to string) (*core.Translation, error) {
+	return restate.Run(t.restateCtx, func(ctx restate.RunContext) (*core.Translation, error) {
+		return t.wrappedTranslator.Translate(ctx, text, from, to)
+	})
+}
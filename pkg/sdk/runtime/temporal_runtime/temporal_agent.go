@@ -42,6 +42,16 @@ func (a *TemporalAgentV2) GetActivities() map[string]interface{} {
 		activities[a.options.Name+"_SummarizerActivity"] = temporalSummarizer
 	}
 
+	if a.options.Translation != nil {
+		temporalTranslator := NewTemporalTranslator(a.options.Translation.Translator)
+		activities[a.options.Name+"_TranslateActivity"] = temporalTranslator.Translate
+	}
+
+	if a.options.ResponsePolicy != nil && a.options.ResponsePolicy.Judge != nil {
+		temporalJudge := NewTemporalResponseJudge(a.options.ResponsePolicy.Judge)
+		activities[a.options.Name+"_JudgeResponseActivity"] = temporalJudge.Judge
+	}
+
 	for _, tool := range a.options.Tools {
 		temporalTool := NewTemporalTool(tool)
 		activities[getToolName(a.options.Name, tool)+"_ExecuteToolActivity"] = temporalTool.Execute
@@ -79,6 +89,25 @@ func (a *TemporalAgentV2) Execute(ctx workflow.Context, in *agents.AgentInput) (
 	}
 	conversationHistory := history.NewConversationManager(conversationPersistenceProxy, options...)
 
+	var translation *agents.TranslationOptions
+	if a.options.Translation != nil {
+		translation = &agents.TranslationOptions{
+			Translator: NewTemporalTranslatorProxy(ctx, a.options.Name),
+			Language:   a.options.Translation.Language,
+		}
+	}
+
+	var responsePolicy *agents.ResponsePolicyOptions
+	if a.options.ResponsePolicy != nil {
+		responsePolicy = &agents.ResponsePolicyOptions{
+			Policy:       a.options.ResponsePolicy.Policy,
+			MaxRevisions: a.options.ResponsePolicy.MaxRevisions,
+		}
+		if a.options.ResponsePolicy.Judge != nil {
+			responsePolicy.Judge = NewTemporalResponseJudgeProxy(ctx, a.options.Name)
+		}
+	}
+
 	var toolProxies []core.Tool
 	for _, tool := range a.options.Tools {
 		toolProxy := NewTemporalToolProxy(ctx, getToolName(a.options.Name, tool), tool)
@@ -97,6 +126,12 @@ func (a *TemporalAgentV2) Execute(ctx workflow.Context, in *agents.AgentInput) (
 		Parameters: a.options.Parameters,
 		MaxLoops:   a.options.MaxLoops,
 
+		ChunkPipeline:         a.options.ChunkPipeline,
+		DisableArgumentRepair: a.options.DisableArgumentRepair,
+		Provenance:            a.options.Provenance,
+		Translation:           translation,
+		ResponsePolicy:        responsePolicy,
+
 		History:     conversationHistory,
 		Instruction: promptProxy,
 		Tools:       toolProxies,
@@ -31,7 +31,7 @@ func (l *TemporalLLM) NewStreamingResponsesActivity(ctx context.Context, in *res
 	}
 
 	acc := agents.Accumulator{}
-	resp, err := acc.ReadStream(stream, func(chunk *responses.ResponseChunk) {
+	resp, err := acc.ReadStream(ctx, stream, func(chunk *responses.ResponseChunk) {
 		if err := l.broker.Publish(ctx, activity.GetInfo(ctx).WorkflowExecution.ID, chunk); err != nil {
 			slog.ErrorContext(ctx, "Failed to publish chunk to stream broker", "error", err)
 		}
@@ -37,7 +37,7 @@ func (t *TemporalMCPServer) ListTools(ctx context.Context, runContext map[string
 	return tools, nil
 }
 
-func (t *TemporalMCPServer) ExecuteTool(ctx context.Context, params *core.ToolCall, runContext map[string]any) (*responses.FunctionCallOutputMessage, error) {
+func (t *TemporalMCPServer) ExecuteTool(ctx context.Context, params *core.ToolCall, runContext map[string]any) (*core.ToolResult, error) {
 	// TODO: directly call the tool without listing
 	mcpTools, err := t.wrappedMcpServer.ListTools(ctx, runContext)
 	if err != nil {
@@ -46,7 +46,7 @@ func (t *TemporalMCPServer) ExecuteTool(ctx context.Context, params *core.ToolCa
 
 	for _, tool := range mcpTools {
 		if t := tool.Tool(ctx); t != nil && t.OfFunction != nil && params.Name == t.OfFunction.Name {
-			return tool.Execute(ctx, params)
+			return core.ExecuteTool(ctx, tool, params)
 		}
 	}
 
@@ -101,11 +101,11 @@ func NewTemporalMCPToolProxy(workflowCtx workflow.Context, prefix string, runCon
 }
 
 func (t *TemporalMCPToolProxy) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	var output *responses.FunctionCallOutputMessage
-	err := workflow.ExecuteActivity(t.workflowCtx, t.prefix+"_ExecuteMCPToolActivity", params, t.runContext).Get(t.workflowCtx, &output)
+	var result core.ToolResult
+	err := workflow.ExecuteActivity(t.workflowCtx, t.prefix+"_ExecuteMCPToolActivity", params, t.runContext).Get(t.workflowCtx, &result)
 	if err != nil {
 		return nil, err
 	}
 
-	return output, nil
+	return result.Unwrap()
 }
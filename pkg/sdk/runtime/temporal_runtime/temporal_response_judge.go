@@ -0,0 +1,42 @@
+package temporal_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"go.temporal.io/sdk/workflow"
+)
+
+type TemporalResponseJudge struct {
+	wrappedJudge core.ResponseJudge
+}
+
+func NewTemporalResponseJudge(wrappedJudge core.ResponseJudge) *TemporalResponseJudge {
+	return &TemporalResponseJudge{wrappedJudge: wrappedJudge}
+}
+
+func (j *TemporalResponseJudge) Judge(ctx context.Context, text string, policy core.ResponsePolicy) (*core.ResponseJudgement, error) {
+	return j.wrappedJudge.Judge(ctx, text, policy)
+}
+
+type TemporalResponseJudgeProxy struct {
+	workflowCtx workflow.Context
+	prefix      string
+}
+
+func NewTemporalResponseJudgeProxy(workflowCtx workflow.Context, prefix string) core.ResponseJudge {
+	return &TemporalResponseJudgeProxy{
+		workflowCtx: workflowCtx,
+		prefix:      prefix,
+	}
+}
+
+func (j *TemporalResponseJudgeProxy) Judge(ctx context.Context, text string, policy core.ResponsePolicy) (*core.ResponseJudgement, error) {
+	var judgement *core.ResponseJudgement
+	err := workflow.ExecuteActivity(j.workflowCtx, j.prefix+"_JudgeResponseActivity", text, policy).Get(j.workflowCtx, &judgement)
+	if err != nil {
+		return nil, err
+	}
+
+	return judgement, nil
+}
@@ -18,8 +18,8 @@ func NewTemporalTool(wrappedTool core.Tool) *TemporalTool {
 	}
 }
 
-func (t *TemporalTool) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	return t.wrappedTool.Execute(ctx, params)
+func (t *TemporalTool) Execute(ctx context.Context, params *core.ToolCall) (*core.ToolResult, error) {
+	return core.ExecuteTool(ctx, t.wrappedTool, params)
 }
 
 type TemporalToolProxy struct {
@@ -37,13 +37,13 @@ func NewTemporalToolProxy(workflowCtx workflow.Context, prefix string, wrappedTo
 }
 
 func (t *TemporalToolProxy) Execute(ctx context.Context, params *core.ToolCall) (*responses.FunctionCallOutputMessage, error) {
-	var output *responses.FunctionCallOutputMessage
-	err := workflow.ExecuteActivity(t.workflowCtx, t.prefix+"_ExecuteToolActivity", params).Get(t.workflowCtx, &output)
+	var result core.ToolResult
+	err := workflow.ExecuteActivity(t.workflowCtx, t.prefix+"_ExecuteToolActivity", params).Get(t.workflowCtx, &result)
 	if err != nil {
 		return nil, err
 	}
 
-	return output, nil
+	return result.Unwrap()
 }
 
 func (t *TemporalToolProxy) Tool(ctx context.Context) *responses.ToolUnion {
@@ -0,0 +1,42 @@
+package temporal_runtime
+
+import (
+	"context"
+
+	"github.com/curaious/uno/pkg/agent-framework/core"
+	"go.temporal.io/sdk/workflow"
+)
+
+type TemporalTranslator struct {
+	wrappedTranslator core.Translator
+}
+
+func NewTemporalTranslator(wrappedTranslator core.Translator) *TemporalTranslator {
+	return &TemporalTranslator{wrappedTranslator: wrappedTranslator}
+}
+
+func (t *TemporalTranslator) Translate(ctx context.Context, text string, from string, to string) (*core.Translation, error) {
+	return t.wrappedTranslator.Translate(ctx, text, from, to)
+}
+
+type TemporalTranslatorProxy struct {
+	workflowCtx workflow.Context
+	prefix      string
+}
+
+func NewTemporalTranslatorProxy(workflowCtx workflow.Context, prefix string) core.Translator {
+	return &TemporalTranslatorProxy{
+		workflowCtx: workflowCtx,
+		prefix:      prefix,
+	}
+}
+
+func (t *TemporalTranslatorProxy) Translate(ctx context.Context, text string, from string, to string) (*core.Translation, error) {
+	var translation *core.Translation
+	err := workflow.ExecuteActivity(t.workflowCtx, t.prefix+"_TranslateActivity", text, from, to).Get(t.workflowCtx, &translation)
+	if err != nil {
+		return nil, err
+	}
+
+	return translation, nil
+}
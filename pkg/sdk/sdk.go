@@ -18,6 +18,7 @@ type SDK struct {
 	endpoint       string
 	projectId      uuid.UUID
 	virtualKey     string
+	signingSecret  string
 	directMode     bool
 	llmConfigs     gateway.ConfigStore
 	restateConfig  RestateConfig
@@ -28,6 +29,10 @@ type SDK struct {
 	restateAgentConfigs  map[string]*agents.AgentOptions
 	temporalAgentConfigs map[string]*agents.AgentOptions
 	redisBroker          core.StreamBroker
+	requestInterceptors  []gateway.RequestInterceptor
+	httpConfig           gateway.HTTPConfig
+	httpClient           *http.Client
+	faults               gateway.FaultConfig
 }
 
 type ServerConfig struct {
@@ -37,6 +42,10 @@ type ServerConfig struct {
 	// For LLM calls
 	VirtualKey string
 
+	// SigningSecret is the signing secret of the virtual key, set when the key requires signed requests. The LLM
+	// calls are signed with a timestamp and a nonce, so that they can't be tampered with or replayed.
+	SigningSecret string
+
 	// For conversations
 	ProjectName string
 }
@@ -63,13 +72,59 @@ type ClientOptions struct {
 	RestateConfig  RestateConfig
 	TemporalConfig TemporalConfig
 	RedisConfig    RedisConfig
+
+	// HTTPProxy is the URL of the egress proxy of the LLM calls, e.g. "http://proxy.internal:3128". Without it,
+	// the proxy is taken from the environment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY).
+	HTTPProxy string
+
+	// NoProxy lists the hosts reached without the proxy, in the format of the NO_PROXY environment variable
+	NoProxy string
+
+	// TLS configures the custom root CAs, the client certificate and the verification of the servers of the LLM
+	// calls. With LLMConfigs, the settings of a provider config take precedence over these.
+	TLS *gateway.TLSConfig
+}
+
+// Option configures the SDK beyond its client options
+type Option func(*SDK)
+
+// WithRequestInterceptor adds an interceptor to the outgoing requests of the LLM calls, e.g. to add headers for a
+// compliance proxy or tag requests with the tenant read from the context. The interceptors apply to all providers,
+// in the order they are added. With LLMConfigs they see the requests to the providers, in the format of each
+// provider, otherwise the requests to the LLM Gateway server.
+func WithRequestInterceptor(interceptor gateway.RequestInterceptor) Option {
+	return func(s *SDK) {
+		s.requestInterceptors = append(s.requestInterceptors, interceptor)
+	}
+}
+
+// WithFaultInjection injects faults in the requests of the LLM calls to the providers, to test how the agents
+// handle rate limits, server errors, slow and broken streams. It only applies with LLMConfigs.
+func WithFaultInjection(config gateway.FaultConfig) Option {
+	return func(s *SDK) {
+		s.faults = config
+	}
 }
 
-func New(opts *ClientOptions) (*SDK, error) {
+func New(opts *ClientOptions, options ...Option) (*SDK, error) {
 	if opts.LLMConfigs == nil && opts.ServerConfig.Endpoint == "" {
 		return nil, fmt.Errorf("must provide either ServerConfig.Endpoint or LLMConfigs")
 	}
 
+	httpConfig := gateway.HTTPConfig{
+		HTTPProxy: opts.HTTPProxy,
+		NoProxy:   opts.NoProxy,
+		TLS:       opts.TLS,
+	}
+	httpClient := http.DefaultClient
+	if !httpConfig.IsZero() {
+		client, err := gateway.NewHTTPClient(httpConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error creating http client: %w", err)
+		}
+		httpClient = client
+	}
+
 	var broker core.StreamBroker
 	var err error
 	if opts.RedisConfig.Endpoint != "" {
@@ -86,6 +141,7 @@ func New(opts *ClientOptions) (*SDK, error) {
 		directMode:     opts.LLMConfigs != nil,
 		endpoint:       opts.ServerConfig.Endpoint,
 		virtualKey:     opts.ServerConfig.VirtualKey,
+		signingSecret:  opts.ServerConfig.SigningSecret,
 		restateConfig:  opts.RestateConfig,
 		temporalConfig: opts.TemporalConfig,
 		redisConfig:    opts.RedisConfig,
@@ -94,6 +150,12 @@ func New(opts *ClientOptions) (*SDK, error) {
 		restateAgentConfigs:  map[string]*agents.AgentOptions{},
 		temporalAgentConfigs: map[string]*agents.AgentOptions{},
 		redisBroker:          broker,
+		httpConfig:           httpConfig,
+		httpClient:           httpClient,
+	}
+
+	for _, option := range options {
+		option(sdk)
 	}
 
 	if opts.ServerConfig.ProjectName == "" {
@@ -101,7 +163,7 @@ func New(opts *ClientOptions) (*SDK, error) {
 	}
 
 	// Convert project name to ID
-	resp, err := http.DefaultClient.Get(fmt.Sprintf("%s/api/agent-server/projects", opts.ServerConfig.Endpoint))
+	resp, err := httpClient.Get(fmt.Sprintf("%s/api/agent-server/projects", opts.ServerConfig.Endpoint))
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +269,7 @@ export interface ProviderModelsResponse {
 }
 
 export interface ReasoningConfig {
-  effort?: 'low' | 'medium' | 'high';
+  effort?: 'minimal' | 'low' | 'medium' | 'high';
   budget_tokens?: number;
 }
 
@@ -1344,7 +1344,7 @@ export const AgentBuilderDetail: React.FC = () => {
                       value={reasoningConfig.effort || ''}
                       onChange={(e) => setReasoningConfig({
                         ...reasoningConfig,
-                        effort: e.target.value as 'low' | 'medium' | 'high' | undefined || undefined
+                        effort: e.target.value as 'minimal' | 'low' | 'medium' | 'high' | undefined || undefined
                       })}
                       fullWidth
                       displayEmpty
@@ -1354,12 +1354,13 @@ export const AgentBuilderDetail: React.FC = () => {
                       }}
                     >
                       <MenuItem value="">Default</MenuItem>
+                      <MenuItem value="minimal">Minimal</MenuItem>
                       <MenuItem value="low">Low</MenuItem>
                       <MenuItem value="medium">Medium</MenuItem>
                       <MenuItem value="high">High</MenuItem>
                     </Select>
                     <p style={{ fontSize: '0.75rem', color: '#666', marginTop: '4px' }}>
-                      Level of reasoning effort (low, medium, high)
+                      Level of reasoning effort (minimal, low, medium, high)
                     </p>
                   </Box>
                   <Box sx={{ flex: 1 }}>
@@ -2112,7 +2113,7 @@ export const AgentBuilderDetail: React.FC = () => {
                                 value={summarizerReasoningConfig.effort || ''}
                                 onChange={(e) => setSummarizerReasoningConfig({
                                   ...summarizerReasoningConfig,
-                                  effort: e.target.value as 'low' | 'medium' | 'high' | undefined || undefined
+                                  effort: e.target.value as 'minimal' | 'low' | 'medium' | 'high' | undefined || undefined
                                 })}
                                 fullWidth
                                 displayEmpty
@@ -2122,6 +2123,7 @@ export const AgentBuilderDetail: React.FC = () => {
                                 }}
                               >
                                 <MenuItem value="">Default</MenuItem>
+                                <MenuItem value="minimal">Minimal</MenuItem>
                                 <MenuItem value="low">Low</MenuItem>
                                 <MenuItem value="medium">Medium</MenuItem>
                                 <MenuItem value="high">High</MenuItem>
@@ -37,6 +37,7 @@ export enum ChunkType {
   ChunkTypeRunInProgress = "run.in_progress",
   ChunkTypeRunPaused = "run.paused",
   ChunkTypeRunCompleted = "run.completed",
+  ChunkTypeRunEstimated = "run.estimated",
   ChunkTypeResponseCreated = "response.created",
   ChunkTypeResponseInProgress = "response.in_progress",
   ChunkTypeResponseCompleted = "response.completed",